@@ -0,0 +1,274 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{AlarmActionDisabled: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	objectTypes := cfg.AlarmActionObjectTypes()
+	ignoredObjects := cfg.IgnoredAlarmActionObjects
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = config.ThresholdNotUsed
+
+	plugin.WarningThreshold = "One or more evaluated inventory objects with alarm actions disabled"
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("object_types", strings.Join(objectTypes, ", ")).
+		Str("ignored_objects", strings.Join(ignoredObjects, ", ")).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	var dcs []mo.Datacenter
+	if textutils.InList(config.AlarmActionObjectTypeDatacenter, objectTypes, true) {
+		log.Debug().Msg("Retrieving datacenters")
+		result, dcsFetchErr := vsphere.GetDatacenters(ctx, c.Client, nil, true)
+		if dcsFetchErr != nil {
+			log.Error().Err(dcsFetchErr).Msg(
+				"error retrieving datacenters",
+			)
+
+			plugin.AddError(dcsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving datacenters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved datacenters")
+
+		dcs = result
+	}
+
+	var clusters []mo.ClusterComputeResource
+	if textutils.InList(config.AlarmActionObjectTypeCluster, objectTypes, true) {
+		log.Debug().Msg("Retrieving clusters")
+		result, clustersFetchErr := vsphere.GetClusters(ctx, c.Client, true)
+		if clustersFetchErr != nil {
+			log.Error().Err(clustersFetchErr).Msg(
+				"error retrieving clusters",
+			)
+
+			plugin.AddError(clustersFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved clusters")
+
+		clusters = result
+	}
+
+	var hostSystems []mo.HostSystem
+	if textutils.InList(config.AlarmActionObjectTypeHost, objectTypes, true) {
+		log.Debug().Msg("Retrieving hosts")
+		result, hsFetchErr := vsphere.GetHostSystems(ctx, c.Client, true)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved hosts")
+
+		hostSystems = result
+	}
+
+	objects := vsphere.NewAlarmActionObjects(dcs, clusters, hostSystems, ignoredObjects)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.AlarmActionsPerfData(objects)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("objects", len(objects)).
+		Int("objects_evaluated", objects.NumEvaluated()).
+		Int("objects_ignored", objects.NumIgnored()).
+		Int("alarm_actions_disabled", objects.NumDisabled()).
+		Logger()
+
+	log.Debug().Msg("Evaluating alarm actions")
+	switch {
+	case objects.HasWarningState():
+
+		for _, obj := range objects.Disabled() {
+			log.Warn().
+				Str("object_type", obj.Type).
+				Str("object_name", obj.Name).
+				Str("object_id", obj.MOID).
+				Msg("alarm actions disabled")
+		}
+
+		plugin.AddError(vsphere.ErrAlarmActionsDisabled)
+
+		plugin.ServiceOutput = vsphere.AlarmActionsOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			objects,
+		)
+
+		plugin.LongServiceOutput = vsphere.AlarmActionsReport(
+			c.Client,
+			objects,
+			objectTypes,
+			ignoredObjects,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No objects with alarm actions disabled")
+
+		plugin.ServiceOutput = vsphere.AlarmActionsOneLineCheckSummary(
+			nagios.StateOKLabel,
+			objects,
+		)
+
+		plugin.LongServiceOutput = vsphere.AlarmActionsReport(
+			c.Client,
+			objects,
+			objectTypes,
+			ignoredObjects,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{AlarmDefinitionsHash: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "Not used."
+
+	plugin.WarningThreshold = "One or more alarm definitions added, removed or modified since the last plugin run."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("state_file", cfg.AlarmDefinitionsStateFile).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Loading alarm definitions baseline")
+	baseline, loadErr := vsphere.LoadAlarmDefinitionsBaseline(cfg.AlarmDefinitionsStateFile)
+	if loadErr != nil {
+		log.Error().Err(loadErr).Msg("error loading alarm definitions baseline")
+
+		plugin.AddError(loadErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error loading alarm definitions baseline from %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.AlarmDefinitionsStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving alarm definitions")
+	alarms, alarmsErr := vsphere.GetAlarmDefinitions(ctx, c.Client)
+	if alarmsErr != nil {
+		log.Error().Err(alarmsErr).Msg("error retrieving alarm definitions")
+
+		plugin.AddError(alarmsErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving alarm definitions",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Generating alarm definition checksums")
+	current, hashErr := vsphere.HashAlarmDefinitions(alarms)
+	if hashErr != nil {
+		log.Error().Err(hashErr).Msg("error generating alarm definition checksums")
+
+		plugin.AddError(hashErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error generating alarm definition checksums",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	changeSet := vsphere.NewAlarmDefinitionChangeSet(current, baseline)
+
+	// Record current checksums as the baseline for the next plugin run.
+	log.Debug().Msg("Saving alarm definitions baseline")
+	if err := vsphere.SaveAlarmDefinitionsBaseline(cfg.AlarmDefinitionsStateFile, current); err != nil {
+		log.Error().Err(err).Msg("error saving alarm definitions baseline")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error saving alarm definitions baseline to %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.AlarmDefinitionsStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.AlarmDefinitionsHashPerfData(changeSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Bool("baseline_found", changeSet.HasBaseline()).
+		Int("alarm_definitions", len(current.Alarms)).
+		Int("alarm_definitions_changed", len(changeSet.Changes)).
+		Logger()
+
+	log.Debug().Msg("Evaluating alarm definition changes")
+	switch {
+	case changeSet.HasChanges():
+
+		log.Error().Msg("alarm definitions changed since last run")
+
+		plugin.AddError(vsphere.ErrAlarmDefinitionsChanged)
+
+		plugin.ServiceOutput = vsphere.AlarmDefinitionsHashOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			changeSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.AlarmDefinitionsHashReport(
+			c.Client,
+			changeSet,
+			cfg.AlarmDefinitionsStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No alarm definition changes detected")
+
+		plugin.ServiceOutput = vsphere.AlarmDefinitionsHashOneLineCheckSummary(
+			nagios.StateOKLabel,
+			changeSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.AlarmDefinitionsHashReport(
+			c.Client,
+			changeSet,
+			cfg.AlarmDefinitionsStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
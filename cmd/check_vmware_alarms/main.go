@@ -31,10 +31,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -63,6 +59,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -81,15 +84,37 @@ func main() {
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("datacenter_names", strings.Join(cfg.DatacenterNames, ", ")).
 		Bool("eval_acknowledged_alarms", cfg.EvaluateAcknowledgedAlarms).
+		Str("acknowledged_alarms_max_state", cfg.AcknowledgedAlarmsMaxState).
+		Bool("show_datastore_vms", cfg.AlarmDatastoreVMs).
+		Str("alarm_filter_file", cfg.AlarmFilterFile).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("maintenance_mode_action", cfg.AlarmMaintenanceModeAction).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -107,13 +132,23 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	// At this point we're logged in, ready to process alarms.
 
 	log.Debug().
@@ -221,11 +256,54 @@ func main() {
 		ExcludedAlarmDescriptions:        cfg.ExcludedAlarmDescriptions,
 		IncludedAlarmStatuses:            cfg.IncludedAlarmStatuses,
 		ExcludedAlarmStatuses:            cfg.ExcludedAlarmStatuses,
+		IncludedAlarmEntityTags:          cfg.IncludedTags,
+		ExcludedAlarmEntityTags:          cfg.ExcludedTags,
 		EvaluateAcknowledgedAlarms:       cfg.EvaluateAcknowledgedAlarms,
+		AcknowledgedAlarmsMaxState:       cfg.AcknowledgedAlarmsMaxState,
+		TriggeredSince:                   cfg.AlarmTriggeredSinceTime(),
+		TriggeredBefore:                  cfg.AlarmTriggeredBeforeTime(),
+		ExcludeMaintenanceMode:           cfg.AlarmsExcludeMaintenanceMode(),
+		MaintenanceModeMaxState:          cfg.AlarmsMaintenanceModeMaxState(),
 	}
 
 	var numTriggeredAlarmsToReport int
 	if len(triggeredAlarms) > 0 {
+		// Retrieve the maintenance mode state of triggered alarm entities
+		// if special handling of those entities was requested.
+		if cfg.AlarmsEvalMaintenanceMode() {
+			log.Debug().Msg("Retrieving maintenance mode state for triggered alarm entities")
+			if err := triggeredAlarms.SetEntityMaintenanceMode(ctx, c.Client); err != nil {
+				log.Error().Err(err).Msg("error retrieving maintenance mode state for triggered alarm entities")
+
+				plugin.AddError(err)
+				plugin.ServiceOutput = fmt.Sprintf(
+					"%s: Error retrieving maintenance mode state for triggered alarm entities",
+					nagios.StateCRITICALLabel,
+				)
+				plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+				return
+			}
+		}
+
+		// Retrieve the tags attached to triggered alarm entities if tag
+		// filtering was requested.
+		if rc != nil {
+			log.Debug().Msg("Retrieving tags for triggered alarm entities")
+			if err := triggeredAlarms.SetEntityTags(ctx, rc); err != nil {
+				log.Error().Err(err).Msg("error retrieving tags for triggered alarm entities")
+
+				plugin.AddError(err)
+				plugin.ServiceOutput = fmt.Sprintf(
+					"%s: Error retrieving tags for triggered alarm entities",
+					nagios.StateCRITICALLabel,
+				)
+				plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+				return
+			}
+		}
+
 		// Filter Triggered Alarms using requested settings, marking alarms as
 		// explicitly included or excluded, but retaining them in the
 		// collection for further potential evaluation.
@@ -239,45 +317,111 @@ func main() {
 		log.Debug().
 			Int("remaining_triggered_alarms", numTriggeredAlarmsToReport).
 			Msg("triggered alarms remaining after filtering")
+
+		// List the VMs residing on affected datastores if requested. Failure
+		// to retrieve these details is noted, but does not prevent
+		// evaluation of the triggered alarms.
+		if cfg.AlarmDatastoreVMs {
+			log.Debug().Msg("Retrieving VMs for datastore triggered alarms")
+			if err := triggeredAlarms.SetDatastoreEntityVMs(ctx, c.Client); err != nil {
+				log.Error().Err(err).Msg("error retrieving VMs for datastore triggered alarms")
+
+				plugin.AddError(err)
+			}
+		}
 	}
 
 	log.Debug().Msg("Compiling Performance Data details")
 
+	alarmsSummary := triggeredAlarms.Summary()
+
 	pd := []nagios.PerformanceData{
 		// The `time` (runtime) metric is appended at plugin exit, so do not
 		// duplicate it here.
 		{
 			Label: "datacenters",
 			Value: fmt.Sprintf("%d", len(dcs)),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms",
 			Value: fmt.Sprintf("%d", len(triggeredAlarms)),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_included",
 			Value: fmt.Sprintf("%d", numTriggeredAlarmsToReport),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_excluded",
 			Value: fmt.Sprintf("%d", triggeredAlarms.NumExcluded()),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_critical",
 			Value: fmt.Sprintf("%d", triggeredAlarms.NumCriticalState(false)),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_warning",
 			Value: fmt.Sprintf("%d", triggeredAlarms.NumWarningState(false)),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_unknown",
 			Value: fmt.Sprintf("%d", triggeredAlarms.NumUnknownState(false)),
+			Min:   "0",
 		},
 		{
 			Label: "triggered_alarms_ok",
 			Value: fmt.Sprintf("%d", triggeredAlarms.NumOKState(false)),
+			Min:   "0",
+		},
+		{
+			Label: "triggered_alarms_red",
+			Value: fmt.Sprintf("%d", alarmsSummary.Red),
+			Min:   "0",
+		},
+		{
+			Label: "triggered_alarms_yellow",
+			Value: fmt.Sprintf("%d", alarmsSummary.Yellow),
+			Min:   "0",
+		},
+		{
+			Label: "triggered_alarms_gray",
+			Value: fmt.Sprintf("%d", alarmsSummary.Gray),
+			Min:   "0",
+		},
+		{
+			Label: "triggered_alarms_acknowledged",
+			Value: fmt.Sprintf("%d", alarmsSummary.Acknowledged),
+			Min:   "0",
 		},
+		{
+			Label: "triggered_alarms_unacknowledged",
+			Value: fmt.Sprintf("%d", alarmsSummary.Unacknowledged),
+			Min:   "0",
+		},
+	}
+
+	pd = append(pd, triggeredAlarms.PerfData()...)
+
+	// Record HA capacity details for clusters in the evaluated datacenters.
+	// Failure to retrieve these details is noted, but does not prevent
+	// evaluation of the triggered alarms.
+	log.Debug().Msg("Retrieving clusters for evaluated datacenters")
+	clusters, clustersFetchErr := vsphere.GetDatacenterClusters(ctx, c.Client, dcs, true)
+	switch {
+	case clustersFetchErr != nil:
+		log.Error().Err(clustersFetchErr).Msg("error retrieving clusters for evaluated datacenters")
+
+		plugin.AddError(clustersFetchErr)
+
+	default:
+		log.Debug().Int("clusters", len(clusters)).Msg("Successfully retrieved clusters")
+
+		pd = append(pd, vsphere.ClustersFailoverLevelsPerfData(clusters)...)
 	}
 
 	if err := plugin.AddPerfData(false, pd...); err != nil {
@@ -0,0 +1,323 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{HostAcceleration: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	requirements := vsphere.HostAccelerationRequirements{
+		RequireCBRC:          cfg.RequireCBRC,
+		RequireMemoryTiering: cfg.RequireMemoryTiering,
+	}
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "Not used."
+	plugin.WarningThreshold = "One or more required host acceleration features (CBRC, memory tiering) disabled."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	hostName := cfg.HostSystemName
+	if hostName == "" {
+		hostName = "all"
+	}
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("host_system_name", hostName).
+		Str("datacenter_name", dcName).
+		Bool("require_cbrc", cfg.RequireCBRC).
+		Bool("require_memory_tiering", cfg.RequireMemoryTiering).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing hosts instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing hosts")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeHostSystem,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	var hostSystems []mo.HostSystem
+	switch {
+	case cfg.HostSystemName != "":
+		log.Debug().Msg("Retrieving host by name")
+		hostSystem, hsFetchErr := vsphere.GetHostSystemByName(
+			ctx,
+			c.Client,
+			cfg.HostSystemName,
+			cfg.DatacenterName,
+			true,
+		)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving requested host",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving host %q",
+				nagios.StateCRITICALLabel,
+				cfg.HostSystemName,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved host by name")
+
+		hostSystems = []mo.HostSystem{hostSystem}
+
+	default:
+		log.Debug().Msg("Retrieving hosts")
+		hss, hsFetchErr := vsphere.GetHostSystems(ctx, c.Client, true)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved hosts")
+
+		hostSystems = hss
+	}
+
+	log.Debug().Msg("Retrieving CBRC and memory tiering status")
+	statusSet, getStatusErr := vsphere.GetHostAccelerationStatusSet(
+		ctx,
+		c.Client,
+		hostSystems,
+		requirements,
+	)
+	if getStatusErr != nil {
+		log.Error().Err(getStatusErr).Msg(
+			"error retrieving CBRC and memory tiering status",
+		)
+
+		plugin.AddError(getStatusErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving CBRC and memory tiering status",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.HostAccelerationPerfData(statusSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("hosts_evaluated", statusSet.NumHostsEvaluated()).
+		Int("hosts_unavailable", statusSet.NumHostsUnavailable()).
+		Int("hosts_with_disabled_features", statusSet.NumHostsWithDisabledFeatures()).
+		Int("cbrc_disabled", statusSet.NumCBRCDisabled()).
+		Int("memory_tiering_disabled", statusSet.NumMemoryTieringDisabled()).
+		Logger()
+
+	log.Debug().Msg("Evaluating CBRC and memory tiering status")
+	switch {
+	case statusSet.HasWarningState():
+
+		log.Error().Msg("Required host acceleration features disabled")
+
+		plugin.AddError(vsphere.ErrHostAccelerationFeaturesDisabled)
+
+		plugin.ServiceOutput = vsphere.HostAccelerationOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			statusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostAccelerationReport(
+			c.Client,
+			statusSet,
+			requirements,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No required host acceleration features disabled")
+
+		plugin.ServiceOutput = vsphere.HostAccelerationOneLineCheckSummary(
+			nagios.StateOKLabel,
+			statusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostAccelerationReport(
+			c.Client,
+			statusSet,
+			requirements,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
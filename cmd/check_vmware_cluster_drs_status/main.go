@@ -0,0 +1,299 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{ClusterDRSStatus: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"DRS disabled or more than %d pending DRS recommendations",
+		cfg.DRSRecommendationsCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"DRS automation level less than %s, cluster imbalanced or more than %d pending DRS recommendations",
+		cfg.RequiredDRSBehavior,
+		cfg.DRSRecommendationsWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	clusterNames := strings.Join(cfg.ClusterNames, ", ")
+	if clusterNames == "" {
+		clusterNames = "all"
+	}
+
+	log := cfg.Log.With().
+		Str("cluster_names", clusterNames).
+		Str("datacenter_name", cfg.DatacenterName).
+		Str("required_drs_behavior", cfg.RequiredDRSBehavior).
+		Int("drs_recommendations_critical", cfg.DRSRecommendationsCritical).
+		Int("drs_recommendations_warning", cfg.DRSRecommendationsWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing clusters instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing clusters")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving clusters")
+	clusters, getClustersErr := vsphere.GetClustersByNames(
+		ctx,
+		c.Client,
+		cfg.ClusterNames,
+		cfg.DatacenterName,
+		true,
+	)
+	if getClustersErr != nil {
+		log.Error().Err(getClustersErr).Msg(
+			"error retrieving clusters",
+		)
+
+		plugin.AddError(getClustersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving clusters",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved clusters")
+
+	log.Debug().Msg("Evaluating cluster DRS status")
+	drsStatusSet := vsphere.NewClusterDRSStatusSet(
+		clusters,
+		vsphere.ClusterDRSStatusThresholds{
+			RequiredBehavior:        cfg.RequiredDRSBehavior,
+			RecommendationsWarning:  cfg.DRSRecommendationsWarning,
+			RecommendationsCritical: cfg.DRSRecommendationsCritical,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.ClusterDRSStatusPerfData(drsStatusSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("clusters_evaluated", len(drsStatusSet)).
+		Int("clusters_critical", drsStatusSet.NumCritical()).
+		Int("clusters_warning", drsStatusSet.NumWarning()).
+		Int("clusters_drs_disabled", drsStatusSet.NumDRSDisabled()).
+		Int("drs_recommendations", drsStatusSet.NumRecommendations()).
+		Logger()
+
+	switch {
+	case drsStatusSet.HasCriticalState():
+
+		log.Error().Msg("cluster DRS issues mapping to CRITICAL state detected")
+
+		plugin.AddError(vsphere.ErrClusterDRSStatusCheckFailed)
+
+		plugin.ServiceOutput = vsphere.ClusterDRSStatusOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			drsStatusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDRSStatusReport(
+			c.Client,
+			drsStatusSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case drsStatusSet.HasWarningState():
+
+		log.Error().Msg("cluster DRS issues mapping to WARNING state detected")
+
+		plugin.AddError(vsphere.ErrClusterDRSStatusCheckFailed)
+
+		plugin.ServiceOutput = vsphere.ClusterDRSStatusOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			drsStatusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDRSStatusReport(
+			c.Client,
+			drsStatusSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No cluster DRS issues detected")
+
+		plugin.ServiceOutput = vsphere.ClusterDRSStatusOneLineCheckSummary(
+			nagios.StateOKLabel,
+			drsStatusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDRSStatusReport(
+			c.Client,
+			drsStatusSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,26 @@
+/*
+Nagios plugin used to monitor recent vSphere HA host failure and failover events for one or more clusters.
+
+# PURPOSE
+
+In addition to reporting the host isolation response and isolation addresses
+configured for each cluster, this plugin alerts on clusters still using the
+default isolation address (the management network default gateway) which is
+not suitable for stretched clusters.
+
+The output for this plugin is designed to provide the one-line summary needed
+by Nagios for quick identification of a problem while providing longer, more
+detailed information for use in email and Teams notifications
+(https://github.com/atc0005/send2teams).
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
@@ -0,0 +1,345 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{ClusterResourceUsage: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% CPU or %d%% memory usage of effective cluster capacity",
+		cfg.ClusterCPUUseCritical,
+		cfg.ClusterMemoryUseCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% CPU or %d%% memory usage of effective cluster capacity",
+		cfg.ClusterCPUUseWarning,
+		cfg.ClusterMemoryUseWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	clusterName := cfg.ClusterName
+	if clusterName == "" {
+		clusterName = "all"
+	}
+
+	log := cfg.Log.With().
+		Str("cluster_name", clusterName).
+		Str("datacenter_name", cfg.DatacenterName).
+		Int("cpu_usage_critical", cfg.ClusterCPUUseCritical).
+		Int("cpu_usage_warning", cfg.ClusterCPUUseWarning).
+		Int("memory_usage_critical", cfg.ClusterMemoryUseCritical).
+		Int("memory_usage_warning", cfg.ClusterMemoryUseWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing clusters instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing clusters")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	var clusters []mo.ClusterComputeResource
+	switch {
+	case cfg.ClusterName != "":
+		log.Debug().Msg("Retrieving cluster by name")
+		cluster, getClusterErr := vsphere.GetClusterByName(
+			ctx,
+			c.Client,
+			cfg.ClusterName,
+			cfg.DatacenterName,
+			true,
+		)
+		if getClusterErr != nil {
+			log.Error().Err(getClusterErr).Msg(
+				"error retrieving requested cluster",
+			)
+
+			plugin.AddError(getClusterErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving cluster %q",
+				nagios.StateCRITICALLabel,
+				cfg.ClusterName,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved cluster by name")
+
+		clusters = []mo.ClusterComputeResource{cluster}
+
+	default:
+		log.Debug().Msg("Retrieving clusters")
+		allClusters, getClustersErr := vsphere.GetClusters(ctx, c.Client, true)
+		if getClustersErr != nil {
+			log.Error().Err(getClustersErr).Msg(
+				"error retrieving clusters",
+			)
+
+			plugin.AddError(getClustersErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved clusters")
+
+		clusters = allClusters
+	}
+
+	log.Debug().Msg("Evaluating cluster resource usage")
+	usageSet, usageErr := vsphere.GetClusterResourceUsageSet(
+		ctx,
+		c.Client,
+		clusters,
+		vsphere.ClusterResourceUsageThresholds{
+			CPUWarning:     cfg.ClusterCPUUseWarning,
+			CPUCritical:    cfg.ClusterCPUUseCritical,
+			MemoryWarning:  cfg.ClusterMemoryUseWarning,
+			MemoryCritical: cfg.ClusterMemoryUseCritical,
+		},
+	)
+	if usageErr != nil {
+		log.Error().Err(usageErr).Msg(
+			"error evaluating cluster resource usage",
+		)
+
+		plugin.AddError(usageErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating cluster resource usage",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.ClusterResourceUsagePerfData(usageSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("clusters_evaluated", len(usageSet)).
+		Int("clusters_critical", usageSet.NumCritical()).
+		Int("clusters_warning", usageSet.NumWarning()).
+		Int("hosts", usageSet.NumHosts()).
+		Logger()
+
+	switch {
+	case usageSet.HasCriticalState():
+
+		log.Error().Msg("cluster CPU or memory usage exceeds CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrClusterResourceUsageThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.ClusterResourceUsageOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			usageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterResourceUsageReport(
+			c.Client,
+			usageSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case usageSet.HasWarningState():
+
+		log.Error().Msg("cluster CPU or memory usage exceeds WARNING threshold")
+
+		plugin.AddError(vsphere.ErrClusterResourceUsageThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.ClusterResourceUsageOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			usageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterResourceUsageReport(
+			c.Client,
+			usageSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("Cluster CPU and memory usage within thresholds")
+
+		plugin.ServiceOutput = vsphere.ClusterResourceUsageOneLineCheckSummary(
+			nagios.StateOKLabel,
+			usageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterResourceUsageReport(
+			c.Client,
+			usageSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,305 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{DatacenterInventoryDrift: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"More than %d objects of any kind added or removed within a datacenter since the last plugin run",
+		cfg.InventoryDriftCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"More than %d objects of any kind added or removed within a datacenter since the last plugin run",
+		cfg.InventoryDriftWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Strs("datacenters", cfg.DatacenterNames).
+		Str("state_file", cfg.InventoryStateFile).
+		Int("drift_critical", cfg.InventoryDriftCritical).
+		Int("drift_warning", cfg.InventoryDriftWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().
+		Int("datacenters_specified", len(cfg.DatacenterNames)).
+		Msg("Validating datacenter names")
+	validateDCsErr := vsphere.ValidateDCs(ctx, c.Client, cfg.DatacenterNames)
+	if validateDCsErr != nil {
+		log.Error().Err(validateDCsErr).Msg("error validating datacenter names")
+
+		plugin.AddError(validateDCsErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error validating requested datacenter names",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving Datacenters")
+	dcs, dcsFetchErr := vsphere.GetDatacenters(ctx, c.Client, cfg.DatacenterNames, true)
+	if dcsFetchErr != nil {
+		log.Error().Err(dcsFetchErr).Msg("error retrieving datacenters")
+
+		plugin.AddError(dcsFetchErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving datacenters",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Loading inventory baseline")
+	baseline, loadErr := vsphere.LoadInventoryBaseline(cfg.InventoryStateFile)
+	if loadErr != nil {
+		log.Error().Err(loadErr).Msg("error loading inventory baseline")
+
+		plugin.AddError(loadErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error loading inventory baseline from %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.InventoryStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving inventory object counts")
+	current, countsErr := vsphere.GetInventoryCounts(ctx, c.Client, dcs)
+	if countsErr != nil {
+		log.Error().Err(countsErr).Msg("error retrieving inventory object counts")
+
+		plugin.AddError(countsErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving inventory object counts",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	driftSet := vsphere.NewInventoryDriftSet(
+		current,
+		baseline,
+		vsphere.InventoryDriftThresholds{
+			Warning:  cfg.InventoryDriftWarning,
+			Critical: cfg.InventoryDriftCritical,
+		},
+	)
+
+	// Record current counts as the baseline for the next plugin run.
+	log.Debug().Msg("Saving inventory baseline")
+	if err := vsphere.SaveInventoryBaseline(cfg.InventoryStateFile, current); err != nil {
+		log.Error().Err(err).Msg("error saving inventory baseline")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error saving inventory baseline to %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.InventoryStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.InventoryDriftPerfData(driftSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Bool("baseline_found", driftSet.HasBaseline()).
+		Int("drift_critical_count", driftSet.NumCritical()).
+		Int("drift_warning_count", driftSet.NumWarning()).
+		Logger()
+
+	log.Debug().Msg("Evaluating inventory drift")
+	switch {
+	case driftSet.HasCriticalState():
+
+		log.Error().Msg("inventory drift exceeds CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrInventoryDriftThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.InventoryDriftOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			driftSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.InventoryDriftReport(
+			c.Client,
+			driftSet,
+			cfg.InventoryStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case driftSet.HasWarningState():
+
+		log.Error().Msg("inventory drift exceeds WARNING threshold")
+
+		plugin.AddError(vsphere.ErrInventoryDriftThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.InventoryDriftOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			driftSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.InventoryDriftReport(
+			c.Client,
+			driftSet,
+			cfg.InventoryStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No unexpected inventory drift detected")
+
+		plugin.ServiceOutput = vsphere.InventoryDriftOneLineCheckSummary(
+			nagios.StateOKLabel,
+			driftSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.InventoryDriftReport(
+			c.Client,
+			driftSet,
+			cfg.InventoryStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
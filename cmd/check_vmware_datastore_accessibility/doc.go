@@ -0,0 +1,27 @@
+/*
+Nagios plugin used to monitor datastore accessibility and maintenance status.
+
+# PURPOSE
+
+This plugin evaluates each datastore across all hosts which mount it and
+alerts when a datastore is inaccessible from any host, unexpectedly in
+maintenance mode or mounted read-only. The affected hosts are listed for
+each datastore with issues. All datastores within a datacenter or available
+to a cluster are evaluated, optionally limited by name or pattern.
+
+The output for this plugin is designed to provide the one-line summary needed
+by Nagios for quick identification of a problem while providing longer, more
+detailed information for use in email and Teams notifications
+(https://github.com/atc0005/send2teams).
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
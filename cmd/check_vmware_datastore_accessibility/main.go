@@ -0,0 +1,326 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{DatastoresAccessibility: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "datastore inaccessible from one or more hosts"
+
+	plugin.WarningThreshold = "datastore unexpectedly in maintenance mode or mounted read-only on one or more hosts"
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("datacenter_name", dcName).
+		Str("cluster_name", cfg.ClusterName).
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_datastores", cfg.IncludedDatastores.String()).
+		Str("excluded_datastores", cfg.IgnoredDatastores.String()).
+		Str("allowed_maintenance_datastores", cfg.AllowedMaintenanceDatastores.String()).
+		Str("allowed_read_only_datastores", cfg.AllowedReadOnlyDatastores.String()).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing datastores instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing datastores")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeDatastore,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving datastores in scope")
+	dss, getDSErr := vsphere.GetDatastoresInScope(
+		ctx,
+		c.Client,
+		cfg.ClusterName,
+		cfg.DatacenterName,
+		true,
+	)
+	if getDSErr != nil {
+		log.Error().Err(getDSErr).Msg(
+			"error retrieving datastores",
+		)
+
+		plugin.AddError(getDSErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving datastores",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Evaluating datastore accessibility")
+	dsAccessibilitySet, dsAccessibilityErr := vsphere.NewDatastoreAccessibilitySet(
+		ctx,
+		c.Client,
+		dss,
+		cfg.IncludedDatastores,
+		cfg.IgnoredDatastores,
+		cfg.AllowedMaintenanceDatastores,
+		cfg.AllowedReadOnlyDatastores,
+	)
+	if dsAccessibilityErr != nil {
+		log.Error().Err(dsAccessibilityErr).Msg(
+			"error evaluating datastore accessibility",
+		)
+
+		plugin.AddError(dsAccessibilityErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating datastore accessibility",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.DatastoreAccessibilityPerfData(dsAccessibilitySet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("datastores_in_scope", len(dss)).
+		Int("datastores_evaluated", dsAccessibilitySet.NumEvaluated()).
+		Int("datastores_excluded", dsAccessibilitySet.NumExcluded).
+		Int("datastores_critical", dsAccessibilitySet.NumCritical()).
+		Int("datastores_warning", dsAccessibilitySet.NumWarning()).
+		Int("datastores_in_maintenance", dsAccessibilitySet.NumInMaintenance()).
+		Int("datastores_read_only", dsAccessibilitySet.NumReadOnly()).
+		Int("inaccessible_mounts", dsAccessibilitySet.NumInaccessibleMounts()).
+		Logger()
+
+	report := vsphere.DatastoreAccessibilityReport(
+		c.Client,
+		dsAccessibilitySet,
+		cfg.IncludedDatastores,
+		cfg.IgnoredDatastores,
+		cfg.ClusterName,
+		cfg.DatacenterName,
+	)
+
+	log.Debug().Msg("Evaluating datastore accessibility state")
+	switch {
+	case dsAccessibilitySet.HasCriticalState():
+
+		log.Error().Msg("Datastore accessibility CRITICAL")
+
+		plugin.AddError(vsphere.ErrDatastoreInaccessible)
+
+		plugin.ServiceOutput = vsphere.DatastoreAccessibilityOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			dsAccessibilitySet,
+		)
+
+		plugin.LongServiceOutput = report
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case dsAccessibilitySet.HasWarningState():
+
+		log.Error().Msg("Datastore accessibility WARNING")
+
+		plugin.AddError(vsphere.ErrDatastoreAccessibilityCheckFailed)
+
+		plugin.ServiceOutput = vsphere.DatastoreAccessibilityOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			dsAccessibilitySet,
+		)
+
+		plugin.LongServiceOutput = report
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No datastore accessibility issues detected")
+
+		plugin.ServiceOutput = vsphere.DatastoreAccessibilityOneLineCheckSummary(
+			nagios.StateOKLabel,
+			dsAccessibilitySet,
+		)
+
+		plugin.LongServiceOutput = report
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
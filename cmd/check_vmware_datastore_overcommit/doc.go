@@ -0,0 +1,28 @@
+/*
+Nagios plugin used to monitor datastore overcommitment.
+
+# PURPOSE
+
+This plugin compares the space provisioned on each datastore (space used by
+VM files, including snapshots, plus space not yet used by thin provisioned
+disks) against the datastore capacity. Unlike current space usage, this
+indicates how much space VMs may eventually consume. All datastores within a
+datacenter or available to a cluster are evaluated, optionally limited by
+name or pattern.
+
+The output for this plugin is designed to provide the one-line summary needed
+by Nagios for quick identification of a problem while providing longer, more
+detailed information for use in email and Teams notifications
+(https://github.com/atc0005/send2teams).
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
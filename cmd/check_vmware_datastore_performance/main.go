@@ -32,10 +32,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -64,6 +60,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -94,7 +97,7 @@ func main() {
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -112,13 +115,55 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing datastores instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing datastores")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeDatastore,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
 	// At this point we're logged in, ready to retrieve the requested
 	// datastore.
 
@@ -346,14 +391,17 @@ func main() {
 		{
 			Label: "vms",
 			Value: fmt.Sprintf("%d", len(dsPerfSummarySet.VMs)),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_off",
 			Value: fmt.Sprintf("%d", dsPerfSummarySet.VMs.NumVMsPoweredOff()),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_on",
 			Value: fmt.Sprintf("%d", dsPerfSummarySet.VMs.NumVMsPoweredOn()),
+			Min:   "0",
 		},
 	}
 
@@ -387,26 +435,50 @@ func main() {
 				Int("percentile", percentile).
 				Msg("Summary metrics for percentile are available, including in perf data")
 
+			// Thresholds are only available for user-specified percentiles.
+			thresholds, hasThresholds := perfThresholdsIndex[percentile]
+			threshold := func(value float64) string {
+				if !hasThresholds {
+					return ""
+				}
+
+				return fmt.Sprintf("%f", value)
+			}
+
 			metricsPerfData := []nagios.PerformanceData{
 				{
-					Label: fmt.Sprintf("p%d_read_latency", percentile),
-					Value: fmt.Sprintf("%f", summary.ReadLatency),
+					Label:             fmt.Sprintf("p%d_read_latency", percentile),
+					Value:             fmt.Sprintf("%f", summary.ReadLatency),
+					UnitOfMeasurement: "ms",
+					Warn:              threshold(thresholds.ReadLatencyWarning),
+					Crit:              threshold(thresholds.ReadLatencyCritical),
+					Min:               "0",
 				},
 				{
-					Label: fmt.Sprintf("p%d_write_latency", percentile),
-					Value: fmt.Sprintf("%f", summary.WriteLatency),
+					Label:             fmt.Sprintf("p%d_write_latency", percentile),
+					Value:             fmt.Sprintf("%f", summary.WriteLatency),
+					UnitOfMeasurement: "ms",
+					Warn:              threshold(thresholds.WriteLatencyWarning),
+					Crit:              threshold(thresholds.WriteLatencyCritical),
+					Min:               "0",
 				},
 				{
-					Label: fmt.Sprintf("p%d_vm_latency", percentile),
-					Value: fmt.Sprintf("%f", summary.VMLatency),
+					Label:             fmt.Sprintf("p%d_vm_latency", percentile),
+					Value:             fmt.Sprintf("%f", summary.VMLatency),
+					UnitOfMeasurement: "ms",
+					Warn:              threshold(thresholds.VMLatencyWarning),
+					Crit:              threshold(thresholds.VMLatencyCritical),
+					Min:               "0",
 				},
 				{
 					Label: fmt.Sprintf("p%d_read_iops", percentile),
 					Value: fmt.Sprintf("%d", int64(summary.ReadIops)),
+					Min:   "0",
 				},
 				{
 					Label: fmt.Sprintf("p%d_write_iops", percentile),
 					Value: fmt.Sprintf("%d", int64(summary.WriteIops)),
+					Min:   "0",
 				},
 			}
 
@@ -5,7 +5,8 @@ Nagios plugin used to monitor datastore usage.
 
 In addition to reporting current datastore usage, this plugin also reports
 which VMs reside on the datastore along with their percentage of the total
-datastore space used.
+datastore space used. Multiple datastores (by name, pattern, cluster or
+datacenter) may optionally be evaluated in a single plugin execution.
 
 The output for this plugin is designed to provide the one-line summary needed
 by Nagios for quick identification of a problem while providing longer, more
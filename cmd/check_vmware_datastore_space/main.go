@@ -31,10 +31,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -63,6 +59,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -81,6 +84,21 @@ func main() {
 		cfg.DatastoreSpaceUsageWarning,
 	)
 
+	// Optional threshold above the CRITICAL threshold used to flag a
+	// CRITICAL state as an emergency.
+	emergencyThresholdValue, emergencyThresholdSet := cfg.EmergencyThreshold()
+	emergencyThreshold := vsphere.EmergencyThreshold{
+		Value:   emergencyThresholdValue,
+		Enabled: emergencyThresholdSet,
+	}
+
+	if emergencyThreshold.Enabled {
+		plugin.CriticalThreshold += fmt.Sprintf(
+			" (%d%% EMERGENCY)",
+			emergencyThreshold.Value,
+		)
+	}
+
 	if cfg.EmitBranding {
 		// If enabled, show application details at end of notification
 		plugin.BrandingCallback = config.Branding("Notification generated by ")
@@ -94,15 +112,35 @@ func main() {
 	log := cfg.Log.With().
 		Str("datastore_name", cfg.DatastoreName).
 		Str("datacenter_name", dcName).
+		Str("cluster_name", cfg.ClusterName).
+		Bool("all_datastores", cfg.AllDatastores).
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_datastores", cfg.IncludedDatastores.String()).
+		Str("excluded_datastores", cfg.IgnoredDatastores.String()).
 		Int("datastore_critical_usage", cfg.DatastoreSpaceUsageCritical).
 		Int("datastore_warning_usage", cfg.DatastoreSpaceUsageWarning).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -120,13 +158,204 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing datastores instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing datastores")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeDatastore,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	if cfg.MultipleDatastores() {
+		log.Debug().Msg("Retrieving datastores in scope")
+		dss, getDSErr := vsphere.GetDatastoresInScope(
+			ctx,
+			c.Client,
+			cfg.ClusterName,
+			cfg.DatacenterName,
+			true,
+		)
+		if getDSErr != nil {
+			log.Error().Err(getDSErr).Msg(
+				"error retrieving datastores",
+			)
+
+			plugin.AddError(getDSErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		log.Debug().Msg("Generating datastore usage summaries")
+		dsSpaceUsageSet, dsSpaceUsageSetErr := vsphere.NewDatastoreSpaceUsageSet(
+			ctx,
+			c.Client,
+			dss,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			cfg.DatastoreSpaceUsageCritical,
+			cfg.DatastoreSpaceUsageWarning,
+		)
+		if dsSpaceUsageSetErr != nil {
+			log.Error().Err(dsSpaceUsageSetErr).Msg(
+				"error generating datastore usage summaries",
+			)
+
+			plugin.AddError(dsSpaceUsageSetErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error generating datastore usage summaries",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		log.Debug().Msg("Compiling Performance Data details")
+
+		pd := vsphere.DatastoreSpaceUsageSetPerfData(dsSpaceUsageSet)
+		pd = append(pd, emergencyThreshold.PerfData(dsSpaceUsageSet.MaxUsedPercent())...)
+
+		if err := plugin.AddPerfData(false, pd...); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to add performance data")
+
+			// Surface the error in plugin output.
+			plugin.AddError(err)
+
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Failed to process performance data metrics",
+				nagios.StateUNKNOWNLabel,
+			)
+
+			return
+		}
+
+		// Update logger with new performance data related fields
+		log = log.With().
+			Int("datastores_in_scope", len(dss)).
+			Int("datastores_evaluated", dsSpaceUsageSet.NumEvaluated()).
+			Int("datastores_excluded", dsSpaceUsageSet.NumExcluded).
+			Int("datastores_inaccessible", len(dsSpaceUsageSet.Inaccessible)).
+			Int("datastores_critical", dsSpaceUsageSet.NumCritical()).
+			Int("datastores_warning", dsSpaceUsageSet.NumWarning()).
+			Float64("datastore_usage_max_percentage", dsSpaceUsageSet.MaxUsedPercent()).
+			Logger()
+
+		report := vsphere.DatastoreSpaceUsageSetReport(
+			c.Client,
+			dsSpaceUsageSet,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			cfg.ClusterName,
+			cfg.DatacenterName,
+		)
+
+		log.Debug().Msg("Evaluating datastore usage state")
+		switch {
+		case dsSpaceUsageSet.IsCriticalState():
+
+			log.Error().Msg("Datastore usage CRITICAL")
+
+			if len(dsSpaceUsageSet.Inaccessible) > 0 {
+				plugin.AddError(vsphere.ErrDatastoreInaccessible)
+			}
+
+			if dsSpaceUsageSet.NumCritical() > 0 {
+				plugin.AddError(vsphere.ErrDatastoreSpaceUsageThresholdCrossed)
+			}
+
+			plugin.ServiceOutput = vsphere.DatastoreSpaceUsageSetOneLineCheckSummary(
+				nagios.StateCRITICALLabel,
+				dsSpaceUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			emergencyThreshold.Annotate(plugin, dsSpaceUsageSet.MaxUsedPercent())
+
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		case dsSpaceUsageSet.IsWarningState():
+
+			log.Error().Msg("Datastore usage WARNING")
+
+			plugin.AddError(vsphere.ErrDatastoreSpaceUsageThresholdCrossed)
+
+			plugin.ServiceOutput = vsphere.DatastoreSpaceUsageSetOneLineCheckSummary(
+				nagios.StateWARNINGLabel,
+				dsSpaceUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		default:
+
+			log.Debug().Msg("Datastore usage within specified thresholds")
+
+			plugin.ServiceOutput = vsphere.DatastoreSpaceUsageSetOneLineCheckSummary(
+				nagios.StateOKLabel,
+				dsSpaceUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		}
+
+		return
+	}
+
 	// At this point we're logged in, ready to retrieve the requested
 	// datastore.
 
@@ -228,31 +457,42 @@ func main() {
 			UnitOfMeasurement: "%",
 			Warn:              fmt.Sprintf("%d", dsSpaceUsage.WarningThreshold),
 			Crit:              fmt.Sprintf("%d", dsSpaceUsage.CriticalThreshold),
+			Min:               "0",
+			Max:               "100",
 		},
 		{
 			Label:             "datastore_space_used",
 			Value:             fmt.Sprintf("%d", dsSpaceUsage.StorageUsed),
 			UnitOfMeasurement: "B",
+			Min:               "0",
+			Max:               fmt.Sprintf("%d", dsSpaceUsage.StorageTotal),
 		},
 		{
 			Label:             "datastore_space_remaining",
 			Value:             fmt.Sprintf("%d", dsSpaceUsage.StorageRemaining),
 			UnitOfMeasurement: "B",
+			Min:               "0",
+			Max:               fmt.Sprintf("%d", dsSpaceUsage.StorageTotal),
 		},
 		{
 			Label: "vms",
 			Value: fmt.Sprintf("%d", len(dsSpaceUsage.VMs)),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_off",
 			Value: fmt.Sprintf("%d", dsSpaceUsage.VMs.NumVMsPoweredOff()),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_on",
 			Value: fmt.Sprintf("%d", dsSpaceUsage.VMs.NumVMsPoweredOn()),
+			Min:   "0",
 		},
 	}
 
+	pd = append(pd, emergencyThreshold.PerfData(dsSpaceUsage.StorageUsedPercent)...)
+
 	if err := plugin.AddPerfData(false, pd...); err != nil {
 		log.Error().
 			Err(err).
@@ -303,6 +543,8 @@ func main() {
 			dsSpaceUsage,
 		)
 
+		emergencyThreshold.Annotate(plugin, dsSpaceUsage.StorageUsedPercent)
+
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
 
 		return
@@ -0,0 +1,28 @@
+/*
+Nagios plugin used to monitor the number of VMs and VMDKs per datastore.
+
+# PURPOSE
+
+This plugin counts the VMs registered on each datastore and the virtual disks
+(VMDKs) attached to VMs which are backed by files on each datastore. Dense
+datastores concentrate I/O on a single device queue and increase contention
+for on-disk locks (e.g., during snapshot, power and backup operations). All
+datastores within a datacenter or available to a cluster are evaluated,
+optionally limited by name or pattern.
+
+The output for this plugin is designed to provide the one-line summary needed
+by Nagios for quick identification of a problem while providing longer, more
+detailed information for use in email and Teams notifications
+(https://github.com/atc0005/send2teams).
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
@@ -31,10 +31,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -63,6 +59,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -81,16 +84,42 @@ func main() {
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Bool("consolidate", cfg.ConsolidateDisks).
+		Int("max_remediations", cfg.MaxRemediations).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -108,17 +137,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Performing initial filtering of vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
 		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
 		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
 		FoldersIncluded:             cfg.IncludedFolders,
 		FoldersExcluded:             cfg.ExcludedFolders,
 		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
@@ -204,6 +254,27 @@ func main() {
 		Int("vms_excluded_by_consolidation_state", numVMsExcludedByConsolidationState).
 		Msg("VMs after disk consolidation needed filtering")
 
+	// Disk consolidation is only triggered if explicitly requested. A nil
+	// collection of results indicates that remediation was not requested.
+	var consolidationResults vsphere.VMDiskConsolidationResults
+	if cfg.ConsolidateDisks {
+		log.Debug().Msg("Triggering disk consolidation for VMs requiring it")
+		consolidationResults = vsphere.ConsolidateVMDisks(
+			ctx,
+			c.Client,
+			vmsNeedingConsolidation,
+			cfg.MaxRemediations,
+			cfg.RemediationTimeout(),
+		)
+
+		log.Debug().
+			Int("vms_consolidated", consolidationResults.NumSucceeded()).
+			Int("vms_consolidation_in_progress", consolidationResults.NumInProgress()).
+			Int("vms_consolidation_failed", consolidationResults.NumFailed()).
+			Int("vms_consolidation_skipped", consolidationResults.NumSkipped()).
+			Msg("Finished disk consolidation")
+	}
+
 	log.Debug().Msg("Compiling Performance Data details")
 
 	pd := append(
@@ -214,10 +285,32 @@ func main() {
 			{
 				Label: "vms_with_consolidation_need",
 				Value: fmt.Sprintf("%d", numVMsRequiringDiskConsolidation),
+				Min:   "0",
 			},
 			{
 				Label: "vms_without_consolidation_need",
 				Value: fmt.Sprintf("%d", numVMsExcludedByConsolidationState),
+				Min:   "0",
+			},
+			{
+				Label: "vms_consolidated",
+				Value: fmt.Sprintf("%d", consolidationResults.NumSucceeded()),
+				Min:   "0",
+			},
+			{
+				Label: "vms_consolidation_in_progress",
+				Value: fmt.Sprintf("%d", consolidationResults.NumInProgress()),
+				Min:   "0",
+			},
+			{
+				Label: "vms_consolidation_failed",
+				Value: fmt.Sprintf("%d", consolidationResults.NumFailed()),
+				Min:   "0",
+			},
+			{
+				Label: "vms_consolidation_skipped",
+				Value: fmt.Sprintf("%d", consolidationResults.NumSkipped()),
+				Min:   "0",
 			},
 		}...,
 	)
@@ -248,9 +341,74 @@ func main() {
 		Int("vms_excluded_by_power_state", vmsFilterResults.NumVMsExcludedByPowerState()).
 		Int("vms_with_consolidation_need", numVMsRequiringDiskConsolidation).
 		Int("vms_without_consolidation_need", numVMsExcludedByConsolidationState).
+		Int("vms_consolidated", consolidationResults.NumSucceeded()).
+		Int("vms_consolidation_in_progress", consolidationResults.NumInProgress()).
+		Int("vms_consolidation_failed", consolidationResults.NumFailed()).
+		Int("vms_consolidation_skipped", consolidationResults.NumSkipped()).
 		Logger()
 
 	switch {
+	case numVMsRequiringDiskConsolidation > 0 && consolidationResults.Remediated():
+
+		// Disk consolidation completed successfully for all VMs requiring
+		// it; the need for consolidation has been resolved.
+
+		log.Info().
+			Str("virtual_machines", strings.Join(vsphere.VMNames(vmsNeedingConsolidation), ", ")).
+			Msg("Disk consolidation completed for all Virtual Machines in need of it")
+
+		plugin.ServiceOutput = vsphere.VMDiskConsolidationOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vmsFilterResults,
+			vmsNeedingConsolidation,
+			consolidationResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMDiskConsolidationReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			vmsNeedingConsolidation,
+			consolidationResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	case numVMsRequiringDiskConsolidation > 0 && consolidationResults.Pending():
+
+		// Disk consolidation was triggered for all VMs requiring it without
+		// any failures, but one or more tasks are still running. This is not
+		// treated as a failure; the next plugin execution will report the
+		// outcome.
+
+		log.Warn().
+			Str("virtual_machines", strings.Join(vsphere.VMNames(vmsNeedingConsolidation), ", ")).
+			Int("vms_consolidation_in_progress", consolidationResults.NumInProgress()).
+			Msg("Disk consolidation in progress for Virtual Machines in need of it")
+
+		plugin.AddError(vsphere.ErrVirtualMachineDiskConsolidationInProgress)
+
+		plugin.ServiceOutput = vsphere.VMDiskConsolidationOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			vmsFilterResults,
+			vmsNeedingConsolidation,
+			consolidationResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMDiskConsolidationReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			vmsNeedingConsolidation,
+			consolidationResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
 	case numVMsRequiringDiskConsolidation > 0:
 
 		// *ANY* VMs requiring disk consolidation results in a CRITICAL state.
@@ -263,10 +421,15 @@ func main() {
 
 		plugin.AddError(vsphere.ErrVirtualMachineDiskConsolidationNeeded)
 
+		if consolidationResults.NumFailed() > 0 {
+			plugin.AddError(vsphere.ErrVirtualMachineDiskConsolidationFailed)
+		}
+
 		plugin.ServiceOutput = vsphere.VMDiskConsolidationOneLineCheckSummary(
 			nagios.StateCRITICALLabel,
 			vmsFilterResults,
 			vmsNeedingConsolidation,
+			consolidationResults,
 		)
 
 		plugin.LongServiceOutput = vsphere.VMDiskConsolidationReport(
@@ -274,6 +437,7 @@ func main() {
 			vmsFilterOptions,
 			vmsFilterResults,
 			vmsNeedingConsolidation,
+			consolidationResults,
 		)
 
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
@@ -290,6 +454,7 @@ func main() {
 			nagios.StateOKLabel,
 			vmsFilterResults,
 			vmsNeedingConsolidation,
+			consolidationResults,
 		)
 
 		plugin.LongServiceOutput = vsphere.VMDiskConsolidationReport(
@@ -297,6 +462,7 @@ func main() {
 			vmsFilterOptions,
 			vmsFilterResults,
 			vmsNeedingConsolidation,
+			consolidationResults,
 		)
 
 		plugin.ExitStatusCode = nagios.StateOKExitCode
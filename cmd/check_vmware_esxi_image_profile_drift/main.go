@@ -0,0 +1,345 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{ESXiImageProfileDrift: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "Host ESXi build does not match expected build for cluster."
+
+	plugin.WarningThreshold = "Host image profile does not match expected image profile for cluster."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	clusterNames := strings.Join(cfg.ClusterNames, ", ")
+	if clusterNames == "" {
+		clusterNames = "all"
+	}
+
+	log := cfg.Log.With().
+		Str("cluster_names", clusterNames).
+		Str("datacenter_name", cfg.DatacenterName).
+		Str("expected_image_profile", cfg.ExpectedImageProfile).
+		Bool("vlcm_desired_image", cfg.UseVLCMDesiredImage).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing clusters instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing clusters")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving clusters")
+	clusters, getClustersErr := vsphere.GetClustersByNames(
+		ctx,
+		c.Client,
+		cfg.ClusterNames,
+		cfg.DatacenterName,
+		true,
+	)
+	if getClustersErr != nil {
+		log.Error().Err(getClustersErr).Msg(
+			"error retrieving clusters",
+		)
+
+		plugin.AddError(getClustersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving clusters",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved clusters")
+
+	desiredImages := make(map[string]*vsphere.VLCMDesiredImage, len(clusters))
+	if cfg.UseVLCMDesiredImage {
+		rc, restLogout, restLoginErr := vsphere.SetupREST(ctx, c.Client, cfg, plugin)
+		if restLoginErr != nil {
+			log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+			return
+		}
+		defer restLogout()
+
+		log.Debug().Msg("Retrieving vLCM desired images for clusters")
+		for _, cluster := range clusters {
+			desiredImage, desiredImageErr := vsphere.GetClusterDesiredImage(ctx, rc, cluster)
+			if desiredImageErr != nil {
+				log.Error().Err(desiredImageErr).Msg(
+					"error retrieving vLCM desired image",
+				)
+
+				plugin.AddError(desiredImageErr)
+				plugin.ServiceOutput = fmt.Sprintf(
+					"%s: Error retrieving vLCM desired image for cluster %q",
+					nagios.StateCRITICALLabel,
+					cluster.Name,
+				)
+				plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+				return
+			}
+
+			desiredImages[cluster.Reference().Value] = desiredImage
+		}
+		log.Debug().Msg("Successfully retrieved vLCM desired images for clusters")
+	}
+
+	log.Debug().Msg("Evaluating host image profiles")
+	imageProfileSet, imageProfileErr := vsphere.GetClusterImageProfileSet(
+		ctx,
+		c.Client,
+		clusters,
+		cfg.ExpectedImageProfile,
+		desiredImages,
+	)
+	if imageProfileErr != nil {
+		log.Error().Err(imageProfileErr).Msg(
+			"error evaluating host image profiles",
+		)
+
+		plugin.AddError(imageProfileErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating host image profiles",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.ClusterImageProfilePerfData(imageProfileSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("clusters_evaluated", len(imageProfileSet)).
+		Int("clusters_vlcm_image", imageProfileSet.NumClustersVLCMManaged()).
+		Int("hosts_evaluated", imageProfileSet.NumHostsEvaluated()).
+		Int("hosts_unavailable", imageProfileSet.NumHostsUnavailable()).
+		Int("hosts_build_drift", imageProfileSet.NumHostsBuildDrift()).
+		Int("hosts_image_profile_drift", imageProfileSet.NumHostsProfileDrift()).
+		Logger()
+
+	switch {
+	case imageProfileSet.HasCriticalState():
+
+		log.Error().Msg("host ESXi build drift detected")
+
+		plugin.AddError(vsphere.ErrESXiImageProfileDrift)
+
+		plugin.ServiceOutput = vsphere.ClusterImageProfileOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			imageProfileSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterImageProfileReport(
+			c.Client,
+			imageProfileSet,
+			cfg.ExpectedImageProfile,
+			cfg.UseVLCMDesiredImage,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case imageProfileSet.HasWarningState():
+
+		log.Error().Msg("host image profile drift detected")
+
+		plugin.AddError(vsphere.ErrESXiImageProfileDrift)
+
+		plugin.ServiceOutput = vsphere.ClusterImageProfileOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			imageProfileSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterImageProfileReport(
+			c.Client,
+			imageProfileSet,
+			cfg.ExpectedImageProfile,
+			cfg.UseVLCMDesiredImage,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No ESXi image profile drift detected")
+
+		plugin.ServiceOutput = vsphere.ClusterImageProfileOneLineCheckSummary(
+			nagios.StateOKLabel,
+			imageProfileSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterImageProfileReport(
+			c.Client,
+			imageProfileSet,
+			cfg.ExpectedImageProfile,
+			cfg.UseVLCMDesiredImage,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{Events: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"More than %d matching events within the last %d minutes",
+		cfg.MatchedEventsCritical,
+		cfg.EventsLookback,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"More than %d matching events within the last %d minutes",
+		cfg.MatchedEventsWarning,
+		cfg.EventsLookback,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Int("lookback_minutes", cfg.EventsLookback).
+		Int("matched_events_critical", cfg.MatchedEventsCritical).
+		Int("matched_events_warning", cfg.MatchedEventsWarning).
+		Strs("included_event_types", cfg.IncludedEventTypeIDs).
+		Strs("excluded_event_types", cfg.ExcludedEventTypeIDs).
+		Strs("included_event_messages", cfg.IncludedEventMessages).
+		Strs("excluded_event_messages", cfg.ExcludedEventMessages).
+		Strs("excluded_entity_names", cfg.ExcludedEventEntityNames).
+		Strs("excluded_users", cfg.ExcludedEventUserNames).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	lookback := time.Duration(cfg.EventsLookback) * time.Minute
+
+	filters := vsphere.VCenterEventFilters{
+		IncludedEventTypeIDs:  cfg.IncludedEventTypeIDs,
+		ExcludedEventTypeIDs:  cfg.ExcludedEventTypeIDs,
+		IncludedEventMessages: cfg.IncludedEventMessages,
+		ExcludedEventMessages: cfg.ExcludedEventMessages,
+		ExcludedEntityNames:   cfg.ExcludedEventEntityNames,
+		ExcludedUserNames:     cfg.ExcludedEventUserNames,
+	}
+
+	thresholds := vsphere.VCenterEventThresholds{
+		Warning:  cfg.MatchedEventsWarning,
+		Critical: cfg.MatchedEventsCritical,
+	}
+
+	log.Debug().Msg("Retrieving events")
+	events, getEventsErr := vsphere.GetVCenterEvents(ctx, c.Client, lookback, filters)
+	if getEventsErr != nil {
+		log.Error().Err(getEventsErr).Msg(
+			"error retrieving events",
+		)
+
+		plugin.AddError(getEventsErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving events",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("events", len(events)).
+		Msg("Successfully retrieved events")
+
+	log.Debug().Msg("Filtering events")
+	events.Filter(filters)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.VCenterEventsPerfData(events, thresholds)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("events_evaluated", len(events)).
+		Int("events_matched", events.NumMatched()).
+		Int("events_excluded", events.NumExcludedFinal()).
+		Logger()
+
+	log.Debug().Msg("Evaluating matched events")
+	switch {
+	case events.IsCriticalState(thresholds):
+
+		log.Error().Msg("matched events exceed CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrMatchedEventsThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VCenterEventsOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			events,
+			lookback,
+			thresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterEventsReport(
+			c.Client,
+			events,
+			lookback,
+			filters,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case events.IsWarningState(thresholds):
+
+		log.Error().Msg("matched events exceed WARNING threshold")
+
+		plugin.AddError(vsphere.ErrMatchedEventsThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VCenterEventsOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			events,
+			lookback,
+			thresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterEventsReport(
+			c.Client,
+			events,
+			lookback,
+			filters,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No matched event issues detected")
+
+		plugin.ServiceOutput = vsphere.VCenterEventsOneLineCheckSummary(
+			nagios.StateOKLabel,
+			events,
+			lookback,
+			thresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterEventsReport(
+			c.Client,
+			events,
+			lookback,
+			filters,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{FolderVMCounts: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	thresholds := vsphere.FolderVMCountThresholds(cfg.FolderVMCountThresholds())
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = thresholds.CriticalThresholdDesc()
+	plugin.WarningThreshold = thresholds.WarningThresholdDesc()
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Strs("folder_ids", cfg.FolderIDs).
+		Strs("ignored_vms", cfg.IgnoredVMs).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Validating folders")
+	if err := vsphere.ValidateFolders(ctx, c.Client, cfg.FolderIDs, nil); err != nil {
+		log.Error().Err(err).Msg("error validating folders")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error validating folders",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully validated folders")
+
+	log.Debug().Msg("Retrieving folders")
+	folders, getFoldersErr := vsphere.GetFoldersByIDs(ctx, c.Client, cfg.FolderIDs, true)
+	if getFoldersErr != nil {
+		log.Error().Err(getFoldersErr).Msg("error retrieving folders")
+
+		plugin.AddError(getFoldersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving folders",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving VMs from folders")
+	folderVMCountSet, getSetErr := vsphere.GetFolderVMCountSet(
+		ctx,
+		c.Client,
+		folders,
+		cfg.IgnoredVMs,
+		thresholds,
+	)
+	if getSetErr != nil {
+		log.Error().Err(getSetErr).Msg("error retrieving VMs from folders")
+
+		plugin.AddError(getSetErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving VMs from folders",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.FolderVMCountPerfData(folderVMCountSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("folders_evaluated", len(folderVMCountSet)).
+		Int("folders_critical", folderVMCountSet.NumCritical()).
+		Int("folders_warning", folderVMCountSet.NumWarning()).
+		Int("vms", folderVMCountSet.NumVMs()).
+		Logger()
+
+	log.Debug().Msg("Evaluating folder VM counts")
+	switch {
+	case folderVMCountSet.HasCriticalState():
+
+		log.Error().Msg("folder VM counts outside of CRITICAL thresholds")
+
+		plugin.AddError(vsphere.ErrFolderVMCountThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.FolderVMCountOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			folderVMCountSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.FolderVMCountReport(
+			c.Client,
+			folderVMCountSet,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case folderVMCountSet.HasWarningState():
+
+		log.Error().Msg("folder VM counts outside of WARNING thresholds")
+
+		plugin.AddError(vsphere.ErrFolderVMCountThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.FolderVMCountOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			folderVMCountSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.FolderVMCountReport(
+			c.Client,
+			folderVMCountSet,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No folder VM count issues detected")
+
+		plugin.ServiceOutput = vsphere.FolderVMCountOneLineCheckSummary(
+			nagios.StateOKLabel,
+			folderVMCountSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.FolderVMCountReport(
+			c.Client,
+			folderVMCountSet,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
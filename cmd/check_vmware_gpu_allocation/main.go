@@ -0,0 +1,354 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{HostGPUAllocation: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% or more GPU framebuffer memory on a host allocated to VMs or %d%% or more GPU utilization.",
+		cfg.GPUAllocationCritical,
+		cfg.GPUUtilizationCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% or more GPU framebuffer memory on a host allocated to VMs or %d%% or more GPU utilization.",
+		cfg.GPUAllocationWarning,
+		cfg.GPUUtilizationWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	hostName := cfg.HostSystemName
+	if hostName == "" {
+		hostName = "all"
+	}
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("host_system_name", hostName).
+		Str("datacenter_name", dcName).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing hosts instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing hosts")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeHostSystem,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	var hostSystems []mo.HostSystem
+	switch {
+	case cfg.HostSystemName != "":
+		log.Debug().Msg("Retrieving host by name")
+		hostSystem, hsFetchErr := vsphere.GetHostSystemByName(
+			ctx,
+			c.Client,
+			cfg.HostSystemName,
+			cfg.DatacenterName,
+			true,
+		)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving requested host",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving host %q",
+				nagios.StateCRITICALLabel,
+				cfg.HostSystemName,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved host by name")
+
+		hostSystems = []mo.HostSystem{hostSystem}
+
+	default:
+		log.Debug().Msg("Retrieving hosts")
+		hss, hsFetchErr := vsphere.GetHostSystems(ctx, c.Client, true)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved hosts")
+
+		hostSystems = hss
+	}
+
+	thresholds := vsphere.HostGPUAllocationThresholds{
+		AllocationWarning:   cfg.GPUAllocationWarning,
+		AllocationCritical:  cfg.GPUAllocationCritical,
+		UtilizationWarning:  cfg.GPUUtilizationWarning,
+		UtilizationCritical: cfg.GPUUtilizationCritical,
+	}
+
+	log.Debug().Msg("Retrieving host GPU allocation details")
+	allocationSet, getAllocationErr := vsphere.GetHostGPUAllocationSet(
+		ctx,
+		c.Client,
+		hostSystems,
+		thresholds,
+	)
+	if getAllocationErr != nil {
+		log.Error().Err(getAllocationErr).Msg(
+			"error retrieving host GPU allocation details",
+		)
+
+		plugin.AddError(getAllocationErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving host GPU allocation details",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := vsphere.HostGPUAllocationPerfData(allocationSet, thresholds)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("hosts_evaluated", allocationSet.NumHostsEvaluated()).
+		Int("hosts_unavailable", allocationSet.NumHostsUnavailable()).
+		Int("gpus_total", allocationSet.NumGPUs()).
+		Int("gpus_assigned", allocationSet.NumAssignedGPUs()).
+		Int64("gpu_memory_unallocated", allocationSet.MemoryUnallocated()).
+		Float64("gpu_allocation_max", allocationSet.MaxAllocation()).
+		Float64("gpu_utilization_max", allocationSet.MaxUtilization()).
+		Logger()
+
+	log.Debug().Msg("Evaluating host GPU allocation and utilization")
+	switch {
+	case allocationSet.HasCriticalState():
+
+		log.Error().Msg("GPU allocation or utilization CRITICAL threshold crossed")
+
+		plugin.AddError(vsphere.ErrHostGPUAllocationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.HostGPUAllocationOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			allocationSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostGPUAllocationReport(
+			c.Client,
+			allocationSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case allocationSet.HasWarningState():
+
+		log.Error().Msg("GPU allocation or utilization WARNING threshold crossed")
+
+		plugin.AddError(vsphere.ErrHostGPUAllocationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.HostGPUAllocationOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			allocationSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostGPUAllocationReport(
+			c.Client,
+			allocationSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("GPU allocation and utilization within thresholds")
+
+		plugin.ServiceOutput = vsphere.HostGPUAllocationOneLineCheckSummary(
+			nagios.StateOKLabel,
+			allocationSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostGPUAllocationReport(
+			c.Client,
+			allocationSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,312 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{HostDNSRouting: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "Host default gateway (IPv4 or IPv6) does not match expected value for cluster or no default gateway configured."
+
+	plugin.WarningThreshold = "Host DNS servers or search domains do not match expected values for cluster."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	clusterNames := strings.Join(cfg.ClusterNames, ", ")
+	if clusterNames == "" {
+		clusterNames = "all"
+	}
+
+	log := cfg.Log.With().
+		Str("cluster_names", clusterNames).
+		Str("datacenter_name", cfg.DatacenterName).
+		Strs("expected_dns_servers", cfg.ExpectedDNSServers).
+		Strs("expected_search_domains", cfg.ExpectedSearchDomains).
+		Str("expected_ipv4_gateway", cfg.ExpectedIPv4Gateway).
+		Str("expected_ipv6_gateway", cfg.ExpectedIPv6Gateway).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing hosts instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing hosts")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeHostSystem,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving clusters")
+	clusters, getClustersErr := vsphere.GetClustersByNames(
+		ctx,
+		c.Client,
+		cfg.ClusterNames,
+		cfg.DatacenterName,
+		true,
+	)
+	if getClustersErr != nil {
+		log.Error().Err(getClustersErr).Msg(
+			"error retrieving clusters",
+		)
+
+		plugin.AddError(getClustersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving clusters",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved clusters")
+
+	expected := vsphere.HostDNSRoutingConfig{
+		DNSServers:    cfg.ExpectedDNSServers,
+		SearchDomains: cfg.ExpectedSearchDomains,
+		IPv4Gateway:   cfg.ExpectedIPv4Gateway,
+		IPv6Gateway:   cfg.ExpectedIPv6Gateway,
+	}
+
+	log.Debug().Msg("Evaluating host DNS and routing configuration")
+	dnsRoutingSet, dnsRoutingErr := vsphere.GetClusterDNSRoutingSet(
+		ctx,
+		c.Client,
+		clusters,
+		expected,
+	)
+	if dnsRoutingErr != nil {
+		log.Error().Err(dnsRoutingErr).Msg(
+			"error evaluating host DNS and routing configuration",
+		)
+
+		plugin.AddError(dnsRoutingErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating host DNS and routing configuration",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.ClusterDNSRoutingPerfData(dnsRoutingSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("clusters_evaluated", len(dnsRoutingSet)).
+		Int("hosts_evaluated", dnsRoutingSet.NumHostsEvaluated()).
+		Int("hosts_unavailable", dnsRoutingSet.NumHostsUnavailable()).
+		Int("hosts_gateway_drift", dnsRoutingSet.NumHostsCritical()).
+		Int("hosts_dns_drift", dnsRoutingSet.NumHostsWarning()).
+		Logger()
+
+	switch {
+	case dnsRoutingSet.HasCriticalState():
+
+		log.Error().Msg("host default gateway drift detected")
+
+		plugin.AddError(vsphere.ErrHostDNSRoutingDrift)
+
+		plugin.ServiceOutput = vsphere.ClusterDNSRoutingOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			dnsRoutingSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDNSRoutingReport(
+			c.Client,
+			dnsRoutingSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case dnsRoutingSet.HasWarningState():
+
+		log.Error().Msg("host DNS drift detected")
+
+		plugin.AddError(vsphere.ErrHostDNSRoutingDrift)
+
+		plugin.ServiceOutput = vsphere.ClusterDNSRoutingOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			dnsRoutingSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDNSRoutingReport(
+			c.Client,
+			dnsRoutingSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No host DNS or routing drift detected")
+
+		plugin.ServiceOutput = vsphere.ClusterDNSRoutingOneLineCheckSummary(
+			nagios.StateOKLabel,
+			dnsRoutingSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterDNSRoutingReport(
+			c.Client,
+			dnsRoutingSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -32,10 +32,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -64,6 +60,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -82,6 +85,21 @@ func main() {
 		cfg.HostSystemMemoryUseWarning,
 	)
 
+	// Optional threshold above the CRITICAL threshold used to flag a
+	// CRITICAL state as an emergency.
+	emergencyThresholdValue, emergencyThresholdSet := cfg.EmergencyThreshold()
+	emergencyThreshold := vsphere.EmergencyThreshold{
+		Value:   emergencyThresholdValue,
+		Enabled: emergencyThresholdSet,
+	}
+
+	if emergencyThreshold.Enabled {
+		plugin.CriticalThreshold += fmt.Sprintf(
+			" (%d%% EMERGENCY)",
+			emergencyThreshold.Value,
+		)
+	}
+
 	if cfg.EmitBranding {
 		// If enabled, show application details at end of notification
 		plugin.BrandingCallback = config.Branding("Notification generated by ")
@@ -95,15 +113,34 @@ func main() {
 	log := cfg.Log.With().
 		Str("host_system_name", cfg.HostSystemName).
 		Str("datacenter_name", dcName).
+		Str("cluster_name", cfg.ClusterName).
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
 		Int("host_system_memory_critical_usage", cfg.HostSystemMemoryUseCritical).
 		Int("host_system_memory_warning_usage", cfg.HostSystemMemoryUseWarning).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -121,13 +158,228 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing hosts instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing hosts")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeHostSystem,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	if cfg.MultipleHosts() {
+		log.Debug().Msg("Retrieving hosts in scope")
+		hss, getHostsErr := vsphere.GetHostSystemsInScope(
+			ctx,
+			c.Client,
+			cfg.ClusterName,
+			cfg.DatacenterName,
+			true,
+		)
+		if getHostsErr != nil {
+			log.Error().Err(getHostsErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(getHostsErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		log.Debug().Msg("Generating host memory usage summaries")
+		hsUsageSet, hsUsageSetErr := vsphere.NewHostSystemMemoryUsageSet(
+			hss,
+			cfg.IncludedHosts,
+			cfg.ExcludedHosts,
+			cfg.HostSystemMemoryUseCritical,
+			cfg.HostSystemMemoryUseWarning,
+		)
+		if hsUsageSetErr != nil {
+			log.Error().Err(hsUsageSetErr).Msg(
+				"error generating host memory usage summaries",
+			)
+
+			plugin.AddError(hsUsageSetErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error generating host memory usage summaries",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		log.Debug().Msg("Compiling Performance Data details")
+
+		pd := vsphere.HostSystemMemoryUsageSetPerfData(hsUsageSet)
+
+		// Record HA capacity details for clusters with hosts in scope. The
+		// cluster name prefix is omitted when a specific cluster is
+		// evaluated to match the labels used when evaluating a single host.
+		log.Debug().Msg("Retrieving clusters for hosts in scope")
+		hsClusters, hsClustersFetchErr := vsphere.GetHostSystemsClusters(
+			ctx,
+			c.Client,
+			hss,
+			true,
+		)
+		switch {
+		case hsClustersFetchErr != nil:
+			log.Error().Err(hsClustersFetchErr).Msg(
+				"error retrieving clusters for hosts in scope",
+			)
+
+			plugin.AddError(hsClustersFetchErr)
+
+		case cfg.ClusterName != "" && len(hsClusters) == 1:
+			pd = append(pd, vsphere.ClusterFailoverLevelsPerfData(hsClusters[0])...)
+
+		default:
+			pd = append(pd, vsphere.ClustersFailoverLevelsPerfData(hsClusters)...)
+		}
+
+		pd = append(pd, emergencyThreshold.PerfData(hsUsageSet.MaxUsedPercent())...)
+
+		if err := plugin.AddPerfData(false, pd...); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to add performance data")
+
+			// Surface the error in plugin output.
+			plugin.AddError(err)
+
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Failed to process performance data metrics",
+				nagios.StateUNKNOWNLabel,
+			)
+
+			return
+		}
+
+		// Update logger with new performance data related fields
+		log = log.With().
+			Int("hosts_in_scope", len(hss)).
+			Int("hosts_evaluated", hsUsageSet.NumEvaluated()).
+			Int("hosts_excluded", hsUsageSet.NumExcluded).
+			Int("hosts_unavailable", len(hsUsageSet.Unavailable)).
+			Int("hosts_critical", hsUsageSet.NumCritical()).
+			Int("hosts_warning", hsUsageSet.NumWarning()).
+			Float64("host_memory_usage_max_percentage", hsUsageSet.MaxUsedPercent()).
+			Logger()
+
+		report := vsphere.HostSystemMemoryUsageSetReport(
+			c.Client,
+			hsUsageSet,
+			cfg.IncludedHosts,
+			cfg.ExcludedHosts,
+			cfg.ClusterName,
+			cfg.DatacenterName,
+		)
+
+		log.Debug().Msg("Evaluating host memory usage state")
+		switch {
+		case hsUsageSet.IsCriticalState():
+
+			log.Error().Msg("Host memory usage CRITICAL")
+
+			if len(hsUsageSet.Unavailable) > 0 {
+				plugin.AddError(vsphere.ErrHostSystemUnavailable)
+			}
+
+			if hsUsageSet.NumCritical() > 0 {
+				plugin.AddError(vsphere.ErrHostSystemMemoryUsageThresholdCrossed)
+			}
+
+			plugin.ServiceOutput = vsphere.HostSystemMemoryUsageSetOneLineCheckSummary(
+				nagios.StateCRITICALLabel,
+				hsUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			emergencyThreshold.Annotate(plugin, hsUsageSet.MaxUsedPercent())
+
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		case hsUsageSet.IsWarningState():
+
+			log.Error().Msg("Host memory usage WARNING")
+
+			plugin.AddError(vsphere.ErrHostSystemMemoryUsageThresholdCrossed)
+
+			plugin.ServiceOutput = vsphere.HostSystemMemoryUsageSetOneLineCheckSummary(
+				nagios.StateWARNINGLabel,
+				hsUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		default:
+
+			log.Debug().Msg("Host memory usage within specified thresholds")
+
+			plugin.ServiceOutput = vsphere.HostSystemMemoryUsageSetOneLineCheckSummary(
+				nagios.StateOKLabel,
+				hsUsageSet,
+			)
+
+			plugin.LongServiceOutput = report
+
+			plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		}
+
+		return
+	}
+
 	// At this point we're logged in, ready to retrieve the requested
 	// HostSystem.
 
@@ -156,6 +408,33 @@ func main() {
 	}
 	log.Debug().Msg("Successfully retrieved host by name")
 
+	log.Debug().Msg("Retrieving cluster for host")
+	hsCluster, isClusterMember, hsClusterFetchErr := vsphere.GetHostSystemCluster(
+		ctx,
+		c.Client,
+		hostSystem,
+		true,
+	)
+	if hsClusterFetchErr != nil {
+		log.Error().Err(hsClusterFetchErr).Msg(
+			"error retrieving cluster for host",
+		)
+
+		plugin.AddError(hsClusterFetchErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving cluster for host %q",
+			nagios.StateCRITICALLabel,
+			cfg.HostSystemName,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Bool("cluster_member", isClusterMember).
+		Str("cluster_name", hsCluster.Name).
+		Msg("Finished retrieving cluster for host")
+
 	log.Debug().Msg("Generating host memory usage summary")
 	hsUsage, hsUsageErr := vsphere.NewHostSystemMemoryUsageSummary(
 		hostSystem,
@@ -233,36 +512,53 @@ func main() {
 			UnitOfMeasurement: "%",
 			Warn:              fmt.Sprintf("%d", cfg.HostSystemMemoryUseWarning),
 			Crit:              fmt.Sprintf("%d", cfg.HostSystemMemoryUseCritical),
+			Min:               "0",
+			Max:               "100",
 		},
 		{
 			Label:             "memory_total",
 			Value:             fmt.Sprintf("%d", hsUsage.MemoryTotal),
 			UnitOfMeasurement: "B",
+			Min:               "0",
 		},
 		{
 			Label:             "memory_used",
 			Value:             fmt.Sprintf("%d", hsUsage.MemoryUsed),
 			UnitOfMeasurement: "B",
+			Min:               "0",
+			Max:               fmt.Sprintf("%d", hsUsage.MemoryTotal),
 		},
 		{
 			Label:             "memory_remaining",
 			Value:             fmt.Sprintf("%d", hsUsage.MemoryRemaining),
 			UnitOfMeasurement: "B",
+			Min:               "0",
+			Max:               fmt.Sprintf("%d", hsUsage.MemoryTotal),
 		},
 		{
 			Label: "vms",
 			Value: fmt.Sprintf("%d", len(hsVMs)),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_off",
 			Value: fmt.Sprintf("%d", numVMsPoweredOff),
+			Min:   "0",
 		},
 		{
 			Label: "vms_powered_on",
 			Value: fmt.Sprintf("%d", numVMsPoweredOn),
+			Min:   "0",
 		},
 	}
 
+	// Record HA capacity details for hosts that are members of a cluster.
+	if isClusterMember {
+		pd = append(pd, vsphere.ClusterFailoverLevelsPerfData(hsCluster)...)
+	}
+
+	pd = append(pd, emergencyThreshold.PerfData(hsUsage.MemoryUsedPercent)...)
+
 	if err := plugin.AddPerfData(false, pd...); err != nil {
 		log.Error().
 			Err(err).
@@ -311,6 +607,8 @@ func main() {
 			hsUsage,
 		)
 
+		emergencyThreshold.Annotate(plugin, hsUsage.MemoryUsedPercent)
+
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
 
 		return
@@ -0,0 +1,369 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{HostRebootPending: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	thresholds := vsphere.HostRebootPendingThresholds{
+		Warning:  cfg.RebootPendingAgeWarning,
+		Critical: cfg.RebootPendingAgeCritical,
+	}
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"ESXi host reboot pending for %d hours or more.",
+		cfg.RebootPendingAgeCritical,
+	)
+	plugin.WarningThreshold = fmt.Sprintf(
+		"ESXi host reboot pending for %d hours or more.",
+		cfg.RebootPendingAgeWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	hostName := cfg.HostSystemName
+	if hostName == "" {
+		hostName = "all"
+	}
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("host_system_name", hostName).
+		Str("datacenter_name", dcName).
+		Int("pending_age_warning", cfg.RebootPendingAgeWarning).
+		Int("pending_age_critical", cfg.RebootPendingAgeCritical).
+		Str("state_file", cfg.RebootPendingStateFile).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing hosts instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing hosts")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeHostSystem,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeHostSystem,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Loading host pending reboot baseline")
+	baseline, loadErr := vsphere.LoadHostRebootPendingBaseline(cfg.RebootPendingStateFile)
+	if loadErr != nil {
+		log.Error().Err(loadErr).Msg("error loading host pending reboot baseline")
+
+		plugin.AddError(loadErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error loading host pending reboot baseline from %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.RebootPendingStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	var hostSystems []mo.HostSystem
+	switch {
+	case cfg.HostSystemName != "":
+		log.Debug().Msg("Retrieving host by name")
+		hostSystem, hsFetchErr := vsphere.GetHostSystemByName(
+			ctx,
+			c.Client,
+			cfg.HostSystemName,
+			cfg.DatacenterName,
+			true,
+		)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving requested host",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving host %q",
+				nagios.StateCRITICALLabel,
+				cfg.HostSystemName,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved host by name")
+
+		hostSystems = []mo.HostSystem{hostSystem}
+
+	default:
+		log.Debug().Msg("Retrieving hosts")
+		hss, hsFetchErr := vsphere.GetHostSystems(ctx, c.Client, true)
+		if hsFetchErr != nil {
+			log.Error().Err(hsFetchErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(hsFetchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved hosts")
+
+		hostSystems = hss
+	}
+
+	log.Debug().Msg("Evaluating hosts for pending reboot")
+	rebootSummary := vsphere.NewHostRebootPendingSummary(
+		hostSystems,
+		baseline,
+		thresholds,
+	)
+
+	// Record when pending reboots were first observed as the baseline for
+	// the next plugin run.
+	log.Debug().Msg("Saving host pending reboot baseline")
+	if err := vsphere.SaveHostRebootPendingBaseline(cfg.RebootPendingStateFile, rebootSummary.NextBaseline()); err != nil {
+		log.Error().Err(err).Msg("error saving host pending reboot baseline")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error saving host pending reboot baseline to %q",
+			nagios.StateUNKNOWNLabel,
+			cfg.RebootPendingStateFile,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.HostRebootPendingPerfData(rebootSummary)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("hosts_evaluated", rebootSummary.HostsEvaluated).
+		Int("hosts_unavailable", rebootSummary.NumHostsUnavailable()).
+		Int("hosts_reboot_pending", rebootSummary.NumHostsPending()).
+		Int("hosts_reboot_pending_warning", rebootSummary.NumHostsWarning()).
+		Int("hosts_reboot_pending_critical", rebootSummary.NumHostsCritical()).
+		Logger()
+
+	log.Debug().Msg("Evaluating host pending reboot durations")
+	switch {
+	case rebootSummary.IsCriticalState():
+
+		log.Error().Msg("Host reboot pending longer than specified threshold")
+
+		plugin.AddError(vsphere.ErrHostRebootPendingThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.HostRebootPendingOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			rebootSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostRebootPendingReport(
+			c.Client,
+			rebootSummary,
+			cfg.RebootPendingStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case rebootSummary.IsWarningState():
+
+		log.Error().Msg("Host reboot pending longer than specified threshold")
+
+		plugin.AddError(vsphere.ErrHostRebootPendingThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.HostRebootPendingOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			rebootSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostRebootPendingReport(
+			c.Client,
+			rebootSummary,
+			cfg.RebootPendingStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No host reboot pending longer than specified threshold")
+
+		plugin.ServiceOutput = vsphere.HostRebootPendingOneLineCheckSummary(
+			nagios.StateOKLabel,
+			rebootSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.HostRebootPendingReport(
+			c.Client,
+			rebootSummary,
+			cfg.RebootPendingStateFile,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
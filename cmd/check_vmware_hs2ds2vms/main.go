@@ -31,10 +31,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -63,6 +59,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -80,8 +83,17 @@ func main() {
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Str("ignored_vms", cfg.IgnoredVMs.String()).
 		Bool("eval_powered_off", cfg.PoweredOff).
 		Bool("ignore_missing_ca_on_objects", cfg.IgnoreMissingCustomAttribute).
@@ -91,11 +103,26 @@ func main() {
 		Str("host_ca_prefix_separator", cfg.HostCASep()).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -113,17 +140,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Performing initial filtering of vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
 		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
 		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
 		FoldersIncluded:             cfg.IncludedFolders,
 		FoldersExcluded:             cfg.ExcludedFolders,
 		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
@@ -350,14 +398,17 @@ func main() {
 			{
 				Label: "pairing_issues",
 				Value: fmt.Sprintf("%d", numMismatches),
+				Min:   "0",
 			},
 			{
 				Label: "datastores",
 				Value: fmt.Sprintf("%d", len(allDS)),
+				Min:   "0",
 			},
 			{
 				Label: "hosts",
 				Value: fmt.Sprintf("%d", len(allHosts)),
+				Min:   "0",
 			},
 		}...,
 	)
@@ -0,0 +1,250 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{License: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% license capacity used (or usage exceeding capacity) or license expired or expiring within %d days",
+		cfg.LicenseUsageCritical,
+		cfg.LicenseExpiryCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% license capacity used or license expiring within %d days",
+		cfg.LicenseUsageWarning,
+		cfg.LicenseExpiryWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Int("license_usage_critical", cfg.LicenseUsageCritical).
+		Int("license_usage_warning", cfg.LicenseUsageWarning).
+		Int("license_expiry_critical", cfg.LicenseExpiryCritical).
+		Int("license_expiry_warning", cfg.LicenseExpiryWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Retrieving licenses")
+	licenses, assignments, getLicensesErr := vsphere.GetLicenses(ctx, c.Client)
+	if getLicensesErr != nil {
+		log.Error().Err(getLicensesErr).Msg(
+			"error retrieving licenses",
+		)
+
+		plugin.AddError(getLicensesErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving licenses",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("licenses", len(licenses)).
+		Msg("Successfully retrieved licenses")
+
+	licenseSet := vsphere.NewLicenseStatusSet(
+		licenses,
+		assignments,
+		vsphere.LicenseThresholds{
+			UsageWarning:   cfg.LicenseUsageWarning,
+			UsageCritical:  cfg.LicenseUsageCritical,
+			ExpiryWarning:  cfg.LicenseExpiryWarning,
+			ExpiryCritical: cfg.LicenseExpiryCritical,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.LicenseStatusPerfData(licenseSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("licenses_evaluated", len(licenseSet)).
+		Int("licenses_critical", licenseSet.NumCritical()).
+		Int("licenses_warning", licenseSet.NumWarning()).
+		Int("licenses_expiring", licenseSet.NumExpiring()).
+		Int("licenses_expired", licenseSet.NumExpired()).
+		Int("licenses_over_allocated", licenseSet.NumOverAllocated()).
+		Logger()
+
+	log.Debug().Msg("Evaluating license usage and expiration")
+	switch {
+	case licenseSet.HasCriticalState():
+
+		log.Error().Msg("license usage or expiration exceeds CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrLicenseThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.LicenseStatusOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			licenseSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.LicenseStatusReport(
+			c.Client,
+			licenseSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case licenseSet.HasWarningState():
+
+		log.Error().Msg("license usage or expiration exceeds WARNING threshold")
+
+		plugin.AddError(vsphere.ErrLicenseThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.LicenseStatusOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			licenseSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.LicenseStatusReport(
+			c.Client,
+			licenseSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No license usage or expiration issues detected")
+
+		plugin.ServiceOutput = vsphere.LicenseStatusOneLineCheckSummary(
+			nagios.StateOKLabel,
+			licenseSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.LicenseStatusReport(
+			c.Client,
+			licenseSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,328 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{OrphanedVMDKs: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"orphaned VMDK files of %d GB (combined size) present",
+		cfg.OrphanedVMDKsSizeCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"orphaned VMDK files of %d GB (combined size) present",
+		cfg.OrphanedVMDKsSizeWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_datastores", cfg.IncludedDatastores.String()).
+		Str("excluded_datastores", cfg.IgnoredDatastores.String()).
+		Str("ignored_paths", cfg.IgnoredVMDKPaths.String()).
+		Int("orphaned_vmdks_size_critical", cfg.OrphanedVMDKsSizeCritical).
+		Int("orphaned_vmdks_size_warning", cfg.OrphanedVMDKsSizeWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Retrieving datastores")
+	dss, getDSErr := vsphere.GetDatastores(ctx, c.Client, true)
+	if getDSErr != nil {
+		log.Error().Err(getDSErr).Msg(
+			"error retrieving datastores",
+		)
+
+		plugin.AddError(getDSErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving datastores",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	filteredDSs, numDSsExcluded, numDSsInaccessible := vsphere.FilterDatastoresByNames(
+		dss,
+		cfg.IncludedDatastores,
+		cfg.IgnoredDatastores,
+	)
+
+	log.Debug().
+		Int("datastores_total", len(dss)).
+		Int("datastores_excluded", numDSsExcluded).
+		Int("datastores_inaccessible", numDSsInaccessible).
+		Msg("Datastores after filtering")
+
+	log.Debug().Msg("Retrieving VMs")
+	vms, getVMsErr := vsphere.GetVMs(ctx, c.Client, true)
+	if getVMsErr != nil {
+		log.Error().Err(getVMsErr).Msg(
+			"error retrieving VMs",
+		)
+
+		plugin.AddError(getVMsErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	referencedFiles := vsphere.VMReferencedFiles(vms)
+
+	log.Debug().Msg("Searching datastores for VMDK files")
+	var vmdks vsphere.DatastoreVMDKs
+	for _, ds := range filteredDSs {
+		dsVMDKs, searchErr := vsphere.GetDatastoreVMDKs(ctx, c.Client, ds)
+		if searchErr != nil {
+			log.Error().
+				Err(searchErr).
+				Str("datastore", ds.Name).
+				Msg("error searching datastore for VMDK files")
+
+			plugin.AddError(searchErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error searching datastore %s for VMDK files",
+				nagios.StateCRITICALLabel,
+				ds.Name,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		vmdks = append(vmdks, dsVMDKs...)
+	}
+
+	thresholds := vsphere.OrphanedVMDKThresholds{
+		SizeWarning:  cfg.OrphanedVMDKsSizeWarning,
+		SizeCritical: cfg.OrphanedVMDKsSizeCritical,
+	}
+
+	summary := vsphere.NewOrphanedVMDKsSummary(
+		vmdks,
+		referencedFiles,
+		cfg.IgnoredVMDKPaths,
+		thresholds,
+	)
+	summary.NumDatastores = len(filteredDSs)
+	summary.NumDatastoresSkipped = numDSsInaccessible
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.OrphanedVMDKsPerfData(summary)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("datastores_evaluated", summary.NumDatastores).
+		Int("datastores_skipped", summary.NumDatastoresSkipped).
+		Int("vmdks", summary.NumVMDKs).
+		Int("orphaned_vmdks", len(summary.Orphaned)).
+		Int64("orphaned_vmdks_size", summary.Orphaned.Size()).
+		Int("ignored_vmdks", len(summary.Ignored)).
+		Logger()
+
+	switch {
+	case summary.IsCriticalState():
+
+		log.Error().Msg("Orphaned VMDK files exceed specified CRITICAL size threshold")
+
+		plugin.AddError(vsphere.ErrOrphanedVMDKsSizeThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.OrphanedVMDKsOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			summary,
+		)
+
+		plugin.LongServiceOutput = vsphere.OrphanedVMDKsReport(
+			c.Client,
+			summary,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			cfg.IgnoredVMDKPaths,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case summary.IsWarningState():
+
+		log.Error().Msg("Orphaned VMDK files exceed specified WARNING size threshold")
+
+		plugin.AddError(vsphere.ErrOrphanedVMDKsSizeThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.OrphanedVMDKsOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			summary,
+		)
+
+		plugin.LongServiceOutput = vsphere.OrphanedVMDKsReport(
+			c.Client,
+			summary,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			cfg.IgnoredVMDKPaths,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No orphaned VMDK files exceeding size thresholds detected")
+
+		plugin.ServiceOutput = vsphere.OrphanedVMDKsOneLineCheckSummary(
+			nagios.StateOKLabel,
+			summary,
+		)
+
+		plugin.LongServiceOutput = vsphere.OrphanedVMDKsReport(
+			c.Client,
+			summary,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			cfg.IgnoredVMDKPaths,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -30,10 +30,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -62,6 +58,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -80,16 +83,42 @@ func main() {
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Str("auto_answers", cfg.AutoAnswers.String()).
+		Bool("auto_answer_apply", cfg.AutoAnswerApply).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -107,17 +136,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Performing initial filtering of vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
 		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
 		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
 		FoldersIncluded:             cfg.IncludedFolders,
 		FoldersExcluded:             cfg.ExcludedFolders,
 		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
@@ -165,6 +215,34 @@ func main() {
 		Int("vms_excluded_by_interactive_question_status", numVMsExcludedByQuestionStatus).
 		Msg("VMs after interactive question status filtering")
 
+	// Interactive questions are only evaluated against auto-answer mappings
+	// if requested. A nil collection of results indicates that auto-answers
+	// were not requested. Matching answers are only applied if explicitly
+	// requested (dry-run by default).
+	var answerResults vsphere.VMQuestionAnswerResults
+	if len(cfg.AutoAnswers) > 0 {
+		log.Debug().Msg("Evaluating auto-answers for VMs requiring input")
+
+		autoAnswers := make([]vsphere.VMQuestionAutoAnswer, 0, len(cfg.AutoAnswers))
+		for _, aa := range cfg.AutoAnswers {
+			autoAnswers = append(autoAnswers, vsphere.VMQuestionAutoAnswer(aa))
+		}
+
+		answerResults = vsphere.AnswerVMQuestions(
+			ctx,
+			c.Client,
+			vmsWaitingOnInput,
+			autoAnswers,
+			cfg.AutoAnswerApply,
+		)
+
+		log.Debug().
+			Int("vms_auto_answer_matched", answerResults.NumMatched()).
+			Int("vms_auto_answered", answerResults.NumAnswered()).
+			Int("vms_auto_answer_failed", answerResults.NumFailed()).
+			Msg("Finished evaluating auto-answers")
+	}
+
 	log.Debug().Msg("Compiling Performance Data details")
 
 	pd := append(
@@ -175,10 +253,22 @@ func main() {
 			{
 				Label: "vms_requiring_input",
 				Value: fmt.Sprintf("%d", numVMsWaitingOnInput),
+				Min:   "0",
 			},
 			{
 				Label: "vms_not_requiring_input",
 				Value: fmt.Sprintf("%d", numVMsExcludedByQuestionStatus),
+				Min:   "0",
+			},
+			{
+				Label: "vms_auto_answered",
+				Value: fmt.Sprintf("%d", answerResults.NumAnswered()),
+				Min:   "0",
+			},
+			{
+				Label: "vms_auto_answer_failed",
+				Value: fmt.Sprintf("%d", answerResults.NumFailed()),
+				Min:   "0",
 			},
 		}...,
 	)
@@ -209,9 +299,39 @@ func main() {
 		Int("vms_excluded_by_power_state", vmsFilterResults.NumVMsExcludedByPowerState()).
 		Int("vms_requiring_input", numVMsWaitingOnInput).
 		Int("vms_not_requiring_input", numVMsExcludedByQuestionStatus).
+		Int("vms_auto_answered", answerResults.NumAnswered()).
+		Int("vms_auto_answer_failed", answerResults.NumFailed()).
 		Logger()
 
 	switch {
+	case numVMsWaitingOnInput > 0 && answerResults.Remediated():
+
+		// Interactive questions were answered for all VMs requiring input;
+		// the VMs are no longer blocked.
+
+		log.Info().
+			Str("virtual_machines", strings.Join(vsphere.VMNames(vmsWaitingOnInput), ", ")).
+			Msg("Interactive questions answered for all Virtual Machines requiring input")
+
+		plugin.ServiceOutput = vsphere.VMInteractiveQuestionOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vmsFilterResults,
+			vmsWaitingOnInput,
+			answerResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMInteractiveQuestionReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			vmsWaitingOnInput,
+			answerResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
 	case numVMsWaitingOnInput > 0:
 
 		// *ANY* VMs requiring interactive feedback results in a CRITICAL state.
@@ -225,10 +345,15 @@ func main() {
 
 		plugin.AddError(vsphere.ErrVirtualMachineInteractiveResponseNeeded)
 
+		if answerResults.NumFailed() > 0 {
+			plugin.AddError(vsphere.ErrVirtualMachineQuestionAutoAnswerFailed)
+		}
+
 		plugin.ServiceOutput = vsphere.VMInteractiveQuestionOneLineCheckSummary(
 			nagios.StateCRITICALLabel,
 			vmsFilterResults,
 			vmsWaitingOnInput,
+			answerResults,
 		)
 
 		plugin.LongServiceOutput = vsphere.VMInteractiveQuestionReport(
@@ -236,6 +361,7 @@ func main() {
 			vmsFilterOptions,
 			vmsFilterResults,
 			vmsWaitingOnInput,
+			answerResults,
 		)
 
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
@@ -252,6 +378,7 @@ func main() {
 			nagios.StateOKLabel,
 			vmsFilterResults,
 			vmsWaitingOnInput,
+			answerResults,
 		)
 
 		plugin.LongServiceOutput = vsphere.VMInteractiveQuestionReport(
@@ -259,6 +386,7 @@ func main() {
 			vmsFilterOptions,
 			vmsFilterResults,
 			vmsWaitingOnInput,
+			answerResults,
 		)
 
 		plugin.ExitStatusCode = nagios.StateOKExitCode
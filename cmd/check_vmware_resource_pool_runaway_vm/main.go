@@ -0,0 +1,335 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{ResourcePoolRunawayVM: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% of Resource Pool CPU usage or %d%% of Resource Pool memory usage by a single VM",
+		cfg.RunawayVMCPUShareCritical,
+		cfg.RunawayVMMemoryShareCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% of Resource Pool CPU usage or %d%% of Resource Pool memory usage by a single VM",
+		cfg.RunawayVMCPUShareWarning,
+		cfg.RunawayVMMemoryShareWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
+		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Int("cpu_share_warning", cfg.RunawayVMCPUShareWarning).
+		Int("cpu_share_critical", cfg.RunawayVMCPUShareCritical).
+		Int("memory_share_warning", cfg.RunawayVMMemoryShareWarning).
+		Int("memory_share_critical", cfg.RunawayVMMemoryShareCritical).
+		Int("top_vms", cfg.RunawayVMTopCount).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Performing initial filtering of vms")
+	vmsFilterOptions := vsphere.VMsFilterOptions{
+		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
+		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
+
+		// NOTE: Powered off VMs do not consume CPU or memory resources, so
+		// this plugin is hard-coded to exclude them.
+		IncludePoweredOff: false,
+	}
+	vmsFilterResults, vmsFilterErr := vsphere.FilterVMs(
+		ctx,
+		c.Client,
+		vmsFilterOptions,
+	)
+	if vmsFilterErr != nil {
+		log.Error().Err(vmsFilterErr).Msg(
+			"error filtering VMs",
+		)
+
+		plugin.AddError(vmsFilterErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error filtering VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Finished initial filtering of vms")
+
+	thresholds := vsphere.RunawayVMThresholds{
+		CPUShareWarning:     cfg.RunawayVMCPUShareWarning,
+		CPUShareCritical:    cfg.RunawayVMCPUShareCritical,
+		MemoryShareWarning:  cfg.RunawayVMMemoryShareWarning,
+		MemoryShareCritical: cfg.RunawayVMMemoryShareCritical,
+	}
+
+	// NOTE: Ignored VMs still contribute to the combined Resource Pool usage
+	// figures, so VMs are collected prior to VM name filtering.
+	log.Debug().Msg("Evaluating Resource Pool usage share for VMs")
+	rpUsageSet := vsphere.NewResourcePoolUsageSet(
+		vmsFilterResults.RPsAfterFiltering(),
+		vmsFilterResults.VMsBeforeVMNameFiltering(),
+		cfg.IgnoredVMs,
+		thresholds,
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := append(
+		vsphere.VMFilterResultsPerfData(vmsFilterResults),
+		vsphere.ResourcePoolRunawayVMPerfData(rpUsageSet, thresholds)...,
+	)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("resource_pools_evaluated", vmsFilterResults.NumRPsAfterFiltering()).
+		Int("vms_total", vmsFilterResults.NumVMsAll()).
+		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
+		Int("vms_excluded_by_name", vmsFilterResults.NumVMsExcludedByName()).
+		Int("runaway_vms_critical", rpUsageSet.NumCritical()).
+		Int("runaway_vms_warning", rpUsageSet.NumWarning()).
+		Logger()
+
+	switch {
+	case rpUsageSet.IsCriticalState():
+
+		log.Error().
+			Msg("VMs consuming excessive share of Resource Pool usage")
+
+		plugin.AddError(vsphere.ErrResourcePoolRunawayVMThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.ResourcePoolRunawayVMOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			vmsFilterResults,
+			rpUsageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ResourcePoolRunawayVMReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			rpUsageSet,
+			cfg.RunawayVMTopCount,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case rpUsageSet.IsWarningState():
+
+		log.Error().
+			Msg("VMs consuming excessive share of Resource Pool usage")
+
+		plugin.AddError(vsphere.ErrResourcePoolRunawayVMThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.ResourcePoolRunawayVMOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			vmsFilterResults,
+			rpUsageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ResourcePoolRunawayVMReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			rpUsageSet,
+			cfg.RunawayVMTopCount,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		// success path
+
+		log.Debug().Msg("No VMs consuming excessive share of Resource Pool usage")
+
+		plugin.ServiceOutput = vsphere.ResourcePoolRunawayVMOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vmsFilterResults,
+			rpUsageSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.ResourcePoolRunawayVMReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			rpUsageSet,
+			cfg.RunawayVMTopCount,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+}
@@ -30,10 +30,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -62,6 +58,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -82,6 +85,21 @@ func main() {
 		cfg.ResourcePoolsMemoryMaxAllowed,
 	)
 
+	// Optional threshold above the CRITICAL threshold used to flag a
+	// CRITICAL state as an emergency.
+	emergencyThresholdValue, emergencyThresholdSet := cfg.EmergencyThreshold()
+	emergencyThreshold := vsphere.EmergencyThreshold{
+		Value:   emergencyThresholdValue,
+		Enabled: emergencyThresholdSet,
+	}
+
+	if emergencyThreshold.Enabled {
+		plugin.CriticalThreshold += fmt.Sprintf(
+			" (%d%% EMERGENCY)",
+			emergencyThreshold.Value,
+		)
+	}
+
 	if cfg.EmitBranding {
 		// If enabled, show application details at end of notification
 		plugin.BrandingCallback = config.Branding("Notification generated by ")
@@ -90,8 +108,17 @@ func main() {
 	// Setup logger with details showing actual user-specified CLI flag
 	// values.
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Int("max_memory_usage_allowed", cfg.ResourcePoolsMemoryMaxAllowed).
 		Int("memory_usage_critical", cfg.ResourcePoolsMemoryUseCritical).
 		Int("memory_usage_warning", cfg.ResourcePoolsMemoryUseWarning).
@@ -121,6 +148,14 @@ func main() {
 	log = cfg.Log.With().
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Int("max_memory_usage_allowed", cfg.ResourcePoolsMemoryMaxAllowed).
 		Int("memory_usage_critical", cfg.ResourcePoolsMemoryUseCritical).
 		Int("memory_usage_warning", cfg.ResourcePoolsMemoryUseWarning).
@@ -131,11 +166,26 @@ func main() {
 		vsphere.ParentResourcePool,
 	)
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -153,17 +203,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Filtering vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
-		ResourcePoolsIncluded: cfg.IncludedResourcePools,
-		ResourcePoolsExcluded: cfg.ExcludedResourcePools,
+		ResourcePoolsIncluded:   cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:   cfg.ExcludedResourcePools,
+		DatacentersIncluded:     cfg.IncludedDatacenters,
+		DatacentersExcluded:     cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:    cfg.IncludedClusters,
+		ClusterNamesExcluded:    cfg.ExcludedClusters,
+		HostNamesIncluded:       cfg.IncludedHosts,
+		HostNamesExcluded:       cfg.ExcludedHosts,
+		TagsIncluded:            cfg.IncludedTags,
+		TagsExcluded:            cfg.ExcludedTags,
+		TaggingClient:           rc,
+		MaintenanceCAName:       cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat: cfg.VMMaintenanceCADateFormat,
 
 		// No Exclusions; evaluate all VMs for non-excluded or explicitly
 		// included resource pools.
@@ -282,30 +353,39 @@ func main() {
 				UnitOfMeasurement: "%",
 				Warn:              fmt.Sprintf("%d", cfg.ResourcePoolsMemoryUseWarning),
 				Crit:              fmt.Sprintf("%d", cfg.ResourcePoolsMemoryUseCritical),
+				Min:               "0",
 			},
 			{
 				Label:             "memory_used",
 				Value:             fmt.Sprintf("%d", aggregateRPStats.MemoryUsageInBytes),
 				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", memoryUsageMaxInBytes),
 			},
 			{
 				Label:             "memory_remaining",
 				Value:             fmt.Sprintf("%d", memoryRemainingInBytes),
 				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", memoryUsageMaxInBytes),
 			},
 			{
 				Label:             "memory_ballooned",
 				Value:             fmt.Sprintf("%d", aggregateRPStats.BalloonedMemoryInBytes),
 				UnitOfMeasurement: "B",
+				Min:               "0",
 			},
 			{
 				Label:             "memory_swapped",
 				Value:             fmt.Sprintf("%d", aggregateRPStats.SwappedMemoryInBytes),
 				UnitOfMeasurement: "B",
+				Min:               "0",
 			},
 		}...,
 	)
 
+	pd = append(pd, emergencyThreshold.PerfData(memoryPercentageUsedOfAllowed)...)
+
 	if err := plugin.AddPerfData(false, pd...); err != nil {
 		log.Error().
 			Err(err).
@@ -363,6 +443,8 @@ func main() {
 			clusterMemoryInBytes,
 		)
 
+		emergencyThreshold.Annotate(plugin, memoryPercentageUsedOfAllowed)
+
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
 
 		return
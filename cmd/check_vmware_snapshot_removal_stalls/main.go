@@ -0,0 +1,264 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{SnapshotRemovalStalls: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"Snapshot removal tasks running longer than %d minutes",
+		cfg.SnapshotRemovalStallCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"Snapshot removal tasks running longer than %d minutes",
+		cfg.SnapshotRemovalStallWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Int("stall_critical_minutes", cfg.SnapshotRemovalStallCritical).
+		Int("stall_warning_minutes", cfg.SnapshotRemovalStallWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Retrieving in-progress snapshot removal tasks")
+	removalTasks, getTasksErr := vsphere.GetSnapshotRemovalTasks(ctx, c.Client)
+	if getTasksErr != nil {
+		log.Error().Err(getTasksErr).Msg(
+			"error retrieving snapshot removal tasks",
+		)
+
+		plugin.AddError(getTasksErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving snapshot removal tasks",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("snapshot_removal_tasks", len(removalTasks)).
+		Msg("Successfully retrieved snapshot removal tasks")
+
+	stallsSummary := vsphere.NewSnapshotRemovalStallsSummary(
+		removalTasks,
+		cfg.IgnoredVMs,
+		time.Now(),
+		vsphere.SnapshotRemovalStallThresholds{
+			Warning:  time.Duration(cfg.SnapshotRemovalStallWarning) * time.Minute,
+			Critical: time.Duration(cfg.SnapshotRemovalStallCritical) * time.Minute,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.SnapshotRemovalStallsPerfData(stallsSummary)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("snapshot_removal_tasks", len(stallsSummary.Tasks)).
+		Int("snapshot_removal_tasks_critical", stallsSummary.NumCritical()).
+		Int("snapshot_removal_tasks_warning", stallsSummary.NumWarning()).
+		Int("snapshot_removal_tasks_excluded", stallsSummary.NumExcluded).
+		Logger()
+
+	log.Debug().Msg("Evaluating snapshot removal tasks")
+	switch {
+	case stallsSummary.IsCriticalState():
+
+		log.Error().Msg("snapshot removal tasks exceed CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrSnapshotRemovalStalled)
+
+		plugin.ServiceOutput = vsphere.SnapshotRemovalStallsOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			stallsSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotRemovalStallsReport(
+			c.Client,
+			stallsSummary,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case stallsSummary.IsWarningState():
+
+		log.Error().Msg("snapshot removal tasks exceed WARNING threshold")
+
+		plugin.AddError(vsphere.ErrSnapshotRemovalStalled)
+
+		plugin.ServiceOutput = vsphere.SnapshotRemovalStallsOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			stallsSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotRemovalStallsReport(
+			c.Client,
+			stallsSummary,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No stalled snapshot removal tasks detected")
+
+		plugin.ServiceOutput = vsphere.SnapshotRemovalStallsOneLineCheckSummary(
+			nagios.StateOKLabel,
+			stallsSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotRemovalStallsReport(
+			c.Client,
+			stallsSummary,
+			cfg.IgnoredVMs,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
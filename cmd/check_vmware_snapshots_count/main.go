@@ -30,10 +30,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -62,6 +58,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -80,24 +83,62 @@ func main() {
 		cfg.SnapshotsCountWarning,
 	)
 
+	if cfg.SnapshotsTotalCountWarning > 0 {
+		plugin.CriticalThreshold += fmt.Sprintf(
+			" per VM, %d snapshots present in total",
+			cfg.SnapshotsTotalCountCritical,
+		)
+
+		plugin.WarningThreshold += fmt.Sprintf(
+			" per VM, %d snapshots present in total",
+			cfg.SnapshotsTotalCountWarning,
+		)
+	}
+
 	if cfg.EmitBranding {
 		// If enabled, show application details at end of notification
 		plugin.BrandingCallback = config.Branding("Notification generated by ")
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Str("ignored_vms", cfg.IgnoredVMs.String()).
 		Int("snapshots_count_critical", cfg.SnapshotsCountCritical).
 		Int("snapshots_count_warning", cfg.SnapshotsCountWarning).
+		Int("snapshots_total_count_critical", cfg.SnapshotsTotalCountCritical).
+		Int("snapshots_total_count_warning", cfg.SnapshotsTotalCountWarning).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -115,17 +156,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Performing initial filtering of vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
 		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
 		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
 		FoldersIncluded:             cfg.IncludedFolders,
 		FoldersExcluded:             cfg.ExcludedFolders,
 		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
@@ -175,8 +237,10 @@ func main() {
 	snapshotSets := make(vsphere.SnapshotSummarySets, 0, len(vmsWithSnapshots))
 
 	snapshotThresholds := vsphere.SnapshotThresholds{
-		CountCritical: cfg.SnapshotsCountCritical,
-		CountWarning:  cfg.SnapshotsCountWarning,
+		CountCritical:      cfg.SnapshotsCountCritical,
+		CountWarning:       cfg.SnapshotsCountWarning,
+		TotalCountCritical: cfg.SnapshotsTotalCountCritical,
+		TotalCountWarning:  cfg.SnapshotsTotalCountWarning,
 	}
 
 	for _, vm := range vmsWithSnapshots {
@@ -197,6 +261,15 @@ func main() {
 	numVMsWithCriticalSnapshots, numCriticalSnapshots := snapshotSets.CountCriticalSnapshots()
 	numVMsWithWarningSnapshots, numWarningSnapshots := snapshotSets.CountWarningSnapshots()
 	numSnapshots := snapshotSets.Snapshots()
+	maxSnapshotsPerVM := snapshotSets.MaxSnapshotsPerVM()
+
+	// Aggregate thresholds are only included in performance data if
+	// aggregate evaluation is enabled.
+	var snapshotsWarn, snapshotsCrit string
+	if cfg.SnapshotsTotalCountWarning > 0 {
+		snapshotsWarn = fmt.Sprintf("%d", cfg.SnapshotsTotalCountWarning)
+		snapshotsCrit = fmt.Sprintf("%d", cfg.SnapshotsTotalCountCritical)
+	}
 
 	pd := append(
 		vsphere.VMFilterResultsPerfData(vmsFilterResults),
@@ -206,22 +279,36 @@ func main() {
 			{
 				Label: "vms_with_critical_snapshots",
 				Value: fmt.Sprintf("%d", numVMsWithCriticalSnapshots),
+				Min:   "0",
 			},
 			{
 				Label: "vms_with_warning_snapshots",
 				Value: fmt.Sprintf("%d", numVMsWithWarningSnapshots),
+				Min:   "0",
 			},
 			{
 				Label: "snapshots",
 				Value: fmt.Sprintf("%d", numSnapshots),
+				Warn:  snapshotsWarn,
+				Crit:  snapshotsCrit,
+				Min:   "0",
+			},
+			{
+				Label: "max_snapshots_per_vm",
+				Value: fmt.Sprintf("%d", maxSnapshotsPerVM),
+				Warn:  fmt.Sprintf("%d", cfg.SnapshotsCountWarning),
+				Crit:  fmt.Sprintf("%d", cfg.SnapshotsCountCritical),
+				Min:   "0",
 			},
 			{
 				Label: "critical_snapshots",
 				Value: fmt.Sprintf("%d", numCriticalSnapshots),
+				Min:   "0",
 			},
 			{
 				Label: "warning_snapshots",
 				Value: fmt.Sprintf("%d", numWarningSnapshots),
+				Min:   "0",
 			},
 		}...,
 	)
@@ -250,6 +337,7 @@ func main() {
 		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
 		Int("vms_excluded_by_name", vmsFilterResults.NumVMsExcludedByName()).
 		Int("snapshots_total", numSnapshots).
+		Int("max_snapshots_per_vm", maxSnapshotsPerVM).
 		Int("num_vms_with_critical_snapshots", numVMsWithCriticalSnapshots).
 		Int("num_snapshots_count_critical", numCriticalSnapshots).
 		Int("num_vms_with_warning_snapshots", numVMsWithWarningSnapshots).
@@ -288,6 +376,34 @@ func main() {
 
 		return
 
+	case snapshotSets.IsTotalCountCriticalState(snapshotThresholds):
+
+		log.Error().
+			Int("snapshots_total", numSnapshots).
+			Int("snapshots_total_threshold", cfg.SnapshotsTotalCountCritical).
+			Msg("Total snapshots exceed number of specified (permitted) snapshots across all VMs")
+
+		plugin.AddError(vsphere.ErrSnapshotTotalCountThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.SnapshotsCountOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			snapshotSets,
+			snapshotThresholds,
+			vmsFilterResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotsCountReport(
+			c.Client,
+			snapshotSets,
+			snapshotThresholds,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
 	case snapshotSets.IsCountWarningState():
 
 		_, numExcessSnaps, _ :=
@@ -318,6 +434,34 @@ func main() {
 
 		return
 
+	case snapshotSets.IsTotalCountWarningState(snapshotThresholds):
+
+		log.Error().
+			Int("snapshots_total", numSnapshots).
+			Int("snapshots_total_threshold", cfg.SnapshotsTotalCountWarning).
+			Msg("Total snapshots exceed number of specified (permitted) snapshots across all VMs")
+
+		plugin.AddError(vsphere.ErrSnapshotTotalCountThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.SnapshotsCountOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			snapshotSets,
+			snapshotThresholds,
+			vmsFilterResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotsCountReport(
+			c.Client,
+			snapshotSets,
+			snapshotThresholds,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
 	default:
 
 		log.Debug().Msg("No VMs found with snapshots exceeding specified count")
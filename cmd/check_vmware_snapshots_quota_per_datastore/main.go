@@ -0,0 +1,388 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{SnapshotsDatastoreQuota: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"snapshots of %d GB (combined size) present on a datastore",
+		cfg.SnapshotsDatastoreQuotaCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"snapshots of %d GB (combined size) present on a datastore",
+		cfg.SnapshotsDatastoreQuotaWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
+		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Str("included_datastores", cfg.IncludedDatastores.String()).
+		Str("excluded_datastores", cfg.IgnoredDatastores.String()).
+		Int("snapshots_size_critical", cfg.SnapshotsDatastoreQuotaCritical).
+		Int("snapshots_size_warning", cfg.SnapshotsDatastoreQuotaWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Performing initial filtering of vms")
+	vmsFilterOptions := vsphere.VMsFilterOptions{
+		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
+		FoldersIncluded:             cfg.IncludedFolders,
+		FoldersExcluded:             cfg.ExcludedFolders,
+		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
+
+		// NOTE: This plugin is hard-coded to evaluate powered off and powered
+		// on VMs equally. I'm not sure whether ignoring powered off VMs by
+		// default makes sense for this particular plugin.
+		//
+		// Please share your feedback here if you feel differently:
+		// https://github.com/atc0005/check-vmware/discussions/177
+		//
+		// Please expand on some use cases for ignoring powered off VMs by
+		// default.
+		// IncludePoweredOff:           cfg.PoweredOff,
+		IncludePoweredOff: true,
+	}
+	vmsFilterResults, vmsFilterErr := vsphere.FilterVMs(
+		ctx,
+		c.Client,
+		vmsFilterOptions,
+	)
+	if vmsFilterErr != nil {
+		log.Error().Err(vmsFilterErr).Msg(
+			"error filtering VMs",
+		)
+
+		plugin.AddError(vmsFilterErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error filtering VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Finished initial filtering of vms")
+
+	log.Debug().Msg("Retrieving datastores")
+	dss, getDSErr := vsphere.GetDatastores(ctx, c.Client, true)
+	if getDSErr != nil {
+		log.Error().Err(getDSErr).Msg(
+			"error retrieving datastores",
+		)
+
+		plugin.AddError(getDSErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving datastores",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Filter VMs to those with snapshots")
+	vmsWithSnapshots, numVMsExcludedBySnapshots := vsphere.FilterVMsWithSnapshots(vmsFilterResults.VMsAfterFiltering())
+
+	log.Debug().
+		Str("vms_filtered_by_snapshot_state", strings.Join(vsphere.VMNames(vmsWithSnapshots), ", ")).
+		Int("vms_excluded_by_snapshot_state", numVMsExcludedBySnapshots).
+		Msg("VMs after snapshot filtering")
+
+	log.Debug().Msg("Build snapshot sets for bulk processing")
+	snapshotSets := make(vsphere.SnapshotSummarySets, 0, len(vmsWithSnapshots))
+	for _, vm := range vmsWithSnapshots {
+
+		log.Debug().Str("vm", vm.Name).Msg("Evaluating snapshots for VM")
+
+		// Per-VM thresholds are not used by this plugin; snapshot sizes are
+		// evaluated in aggregate per datastore instead.
+		snapshotSets = append(
+			snapshotSets,
+			vsphere.NewSnapshotSummarySet(
+				vm,
+				vsphere.SnapshotThresholds{},
+			),
+		)
+	}
+
+	quotaThresholds := vsphere.SnapshotsDatastoreQuotaThresholds{
+		SizeCritical: cfg.SnapshotsDatastoreQuotaCritical,
+		SizeWarning:  cfg.SnapshotsDatastoreQuotaWarning,
+	}
+
+	log.Debug().Msg("Aggregating snapshots per datastore")
+	usageSet, numDatastoresExcluded := vsphere.NewDatastoreSnapshotUsageSet(
+		snapshotSets,
+		dss,
+		cfg.IncludedDatastores,
+		cfg.IgnoredDatastores,
+		quotaThresholds,
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := append(
+		vsphere.VMFilterResultsPerfData(vmsFilterResults),
+		vsphere.SnapshotsDatastoreQuotaPerfData(usageSet)...,
+	)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("vms_total", vmsFilterResults.NumVMsAll()).
+		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
+		Int("datastores_evaluated", len(usageSet)).
+		Int("datastores_excluded", numDatastoresExcluded).
+		Int("datastores_with_snapshots", usageSet.NumWithSnapshots()).
+		Int("datastores_critical", usageSet.NumCritical()).
+		Int("datastores_warning", usageSet.NumWarning()).
+		Int("snapshots_total", usageSet.Snapshots()).
+		Logger()
+
+	switch {
+
+	case usageSet.IsCriticalState():
+
+		log.Error().Msg("Cumulative datastore snapshots size exceeds specified CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrSnapshotsDatastoreQuotaThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.SnapshotsDatastoreQuotaOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			usageSet,
+			quotaThresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotsDatastoreQuotaReport(
+			c.Client,
+			usageSet,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			numDatastoresExcluded,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case usageSet.IsWarningState():
+
+		log.Error().Msg("Cumulative datastore snapshots size exceeds specified WARNING threshold")
+
+		plugin.AddError(vsphere.ErrSnapshotsDatastoreQuotaThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.SnapshotsDatastoreQuotaOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			usageSet,
+			quotaThresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotsDatastoreQuotaReport(
+			c.Client,
+			usageSet,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			numDatastoresExcluded,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No datastore snapshot quota issues detected")
+
+		plugin.ServiceOutput = vsphere.SnapshotsDatastoreQuotaOneLineCheckSummary(
+			nagios.StateOKLabel,
+			usageSet,
+			quotaThresholds,
+		)
+
+		plugin.LongServiceOutput = vsphere.SnapshotsDatastoreQuotaReport(
+			c.Client,
+			usageSet,
+			cfg.IncludedDatastores,
+			cfg.IgnoredDatastores,
+			numDatastoresExcluded,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,27 @@
+/*
+Nagios plugin used to monitor VM placement across the sites of stretched clusters.
+
+# PURPOSE
+
+For stretched (metro) clusters this plugin compares the number of powered on
+VMs placed on the hosts of each site (defined by DRS host groups or vSAN fault
+domains) and alerts when placement is imbalanced beyond the specified
+thresholds. VMs running on hosts not permitted by VM/Host affinity rules
+(e.g., site affinity rules) are also reported.
+
+The output for this plugin is designed to provide the one-line summary needed
+by Nagios for quick identification of a problem while providing longer, more
+detailed information for use in email and Teams notifications
+(https://github.com/atc0005/send2teams).
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
@@ -0,0 +1,261 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{Tasks: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"More than %d failed tasks within the last %d minutes",
+		cfg.FailedTasksCritical,
+		cfg.TasksLookback,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"More than %d failed tasks within the last %d minutes",
+		cfg.FailedTasksWarning,
+		cfg.TasksLookback,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Int("lookback_minutes", cfg.TasksLookback).
+		Int("failed_tasks_critical", cfg.FailedTasksCritical).
+		Int("failed_tasks_warning", cfg.FailedTasksWarning).
+		Strs("included_task_ids", cfg.IncludedTaskDescriptionIDs).
+		Strs("excluded_task_ids", cfg.ExcludedTaskDescriptionIDs).
+		Strs("included_users", cfg.IncludedTaskUsers).
+		Strs("excluded_users", cfg.ExcludedTaskUsers).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	lookback := time.Duration(cfg.TasksLookback) * time.Minute
+
+	log.Debug().Msg("Retrieving failed tasks")
+	failedTasks, getTasksErr := vsphere.GetFailedTasks(ctx, c.Client, lookback)
+	if getTasksErr != nil {
+		log.Error().Err(getTasksErr).Msg(
+			"error retrieving failed tasks",
+		)
+
+		plugin.AddError(getTasksErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving failed tasks",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("failed_tasks", len(failedTasks)).
+		Msg("Successfully retrieved failed tasks")
+
+	filterOpts := vsphere.TaskFilterOptions{
+		IncludedDescriptionIDs: cfg.IncludedTaskDescriptionIDs,
+		ExcludedDescriptionIDs: cfg.ExcludedTaskDescriptionIDs,
+		IncludedUsers:          cfg.IncludedTaskUsers,
+		ExcludedUsers:          cfg.ExcludedTaskUsers,
+	}
+
+	tasksSummary := vsphere.NewFailedTasksSummary(
+		failedTasks,
+		filterOpts,
+		lookback,
+		vsphere.TaskThresholds{
+			Warning:  cfg.FailedTasksWarning,
+			Critical: cfg.FailedTasksCritical,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.FailedTasksPerfData(tasksSummary)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("tasks_failed", len(tasksSummary.Tasks)).
+		Int("tasks_failed_excluded", tasksSummary.NumExcluded).
+		Logger()
+
+	log.Debug().Msg("Evaluating failed tasks")
+	switch {
+	case tasksSummary.IsCriticalState():
+
+		log.Error().Msg("failed tasks exceed CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrFailedTasksThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.FailedTasksOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			tasksSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.FailedTasksReport(
+			c.Client,
+			tasksSummary,
+			filterOpts,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case tasksSummary.IsWarningState():
+
+		log.Error().Msg("failed tasks exceed WARNING threshold")
+
+		plugin.AddError(vsphere.ErrFailedTasksThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.FailedTasksOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			tasksSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.FailedTasksReport(
+			c.Client,
+			tasksSummary,
+			filterOpts,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No failed task issues detected")
+
+		plugin.ServiceOutput = vsphere.FailedTasksOneLineCheckSummary(
+			nagios.StateOKLabel,
+			tasksSummary,
+		)
+
+		plugin.LongServiceOutput = vsphere.FailedTasksReport(
+			c.Client,
+			tasksSummary,
+			filterOpts,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VASAProviderStatus: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"VASA provider offline, required provider not registered or certificate expiring within %d days",
+		cfg.VASACertExpiryCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"VASA provider synchronization error or certificate expiring within %d days",
+		cfg.VASACertExpiryWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Strs("required_providers", cfg.RequiredVASAProviders).
+		Int("cert_expiry_critical", cfg.VASACertExpiryCritical).
+		Int("cert_expiry_warning", cfg.VASACertExpiryWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	log.Debug().Msg("Retrieving VASA providers")
+	providers, getProvidersErr := vsphere.GetVASAProviders(ctx, c.Client)
+	if getProvidersErr != nil {
+		log.Error().Err(getProvidersErr).Msg(
+			"error retrieving VASA providers",
+		)
+
+		plugin.AddError(getProvidersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving VASA providers",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("providers", len(providers)).
+		Msg("Successfully retrieved VASA providers")
+
+	providerSet := vsphere.NewVASAProviderStatusSet(
+		providers,
+		cfg.RequiredVASAProviders,
+		vsphere.VASAProviderThresholds{
+			CertExpiryWarning:  cfg.VASACertExpiryWarning,
+			CertExpiryCritical: cfg.VASACertExpiryCritical,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.VASAProviderStatusPerfData(providerSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("vasa_providers", len(providerSet.Providers)).
+		Int("vasa_providers_offline", providerSet.NumOffline()).
+		Int("vasa_providers_missing", len(providerSet.Missing)).
+		Logger()
+
+	switch {
+	case providerSet.HasCriticalState():
+
+		log.Error().Msg("VASA providers offline, missing or with expiring certificates")
+
+		plugin.AddError(vsphere.ErrVASAProviderStatusCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VASAProviderStatusOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			providerSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VASAProviderStatusReport(
+			c.Client,
+			providerSet,
+			cfg.RequiredVASAProviders,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case providerSet.HasWarningState():
+
+		log.Error().Msg("VASA providers with synchronization errors or expiring certificates")
+
+		plugin.AddError(vsphere.ErrVASAProviderStatusCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VASAProviderStatusOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			providerSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VASAProviderStatusReport(
+			c.Client,
+			providerSet,
+			cfg.RequiredVASAProviders,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No VASA provider issues detected")
+
+		plugin.ServiceOutput = vsphere.VASAProviderStatusOneLineCheckSummary(
+			nagios.StateOKLabel,
+			providerSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VASAProviderStatusReport(
+			c.Client,
+			providerSet,
+			cfg.RequiredVASAProviders,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
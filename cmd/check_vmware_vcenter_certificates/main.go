@@ -0,0 +1,339 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VCenterCertificates: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"Certificate expired or expiring within %d days",
+		cfg.CertificateExpiryCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"Certificate expiring within %d days",
+		cfg.CertificateExpiryWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	hostName := cfg.HostSystemName
+	if hostName == "" {
+		hostName = "all"
+	}
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("host_system_name", hostName).
+		Str("datacenter_name", dcName).
+		Bool("exclude_host_certs", cfg.ExcludeHostCertificates).
+		Int("cert_expiry_critical", cfg.CertificateExpiryCritical).
+		Int("cert_expiry_warning", cfg.CertificateExpiryWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	thresholds := vsphere.CertificateThresholds{
+		ExpiryWarning:  cfg.CertificateExpiryWarning,
+		ExpiryCritical: cfg.CertificateExpiryCritical,
+	}
+
+	log.Debug().Msg("Retrieving vSphere endpoint certificate")
+	connCert, getConnCertErr := vsphere.GetConnectionCertificate(ctx, c.Client)
+	if getConnCertErr != nil {
+		log.Error().Err(getConnCertErr).Msg(
+			"error retrieving vSphere endpoint certificate",
+		)
+
+		plugin.AddError(getConnCertErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving certificate for %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved vSphere endpoint certificate")
+
+	certSet := vsphere.CertificateStatusSet{
+		vsphere.NewConnectionCertificateStatus(
+			c.Client.URL().Host,
+			connCert,
+			thresholds,
+		),
+	}
+
+	if !cfg.ExcludeHostCertificates {
+		var hostSystems []mo.HostSystem
+		switch {
+		case cfg.HostSystemName != "":
+			log.Debug().Msg("Retrieving host by name")
+			hostSystem, hsFetchErr := vsphere.GetHostSystemByName(
+				ctx,
+				c.Client,
+				cfg.HostSystemName,
+				cfg.DatacenterName,
+				true,
+			)
+			if hsFetchErr != nil {
+				log.Error().Err(hsFetchErr).Msg(
+					"error retrieving requested host",
+				)
+
+				plugin.AddError(hsFetchErr)
+				plugin.ServiceOutput = fmt.Sprintf(
+					"%s: Error retrieving host %q",
+					nagios.StateCRITICALLabel,
+					cfg.HostSystemName,
+				)
+				plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+				return
+			}
+			log.Debug().Msg("Successfully retrieved host by name")
+
+			hostSystems = []mo.HostSystem{hostSystem}
+
+		default:
+			log.Debug().Msg("Retrieving hosts")
+			hss, hsFetchErr := vsphere.GetHostSystems(ctx, c.Client, true)
+			if hsFetchErr != nil {
+				log.Error().Err(hsFetchErr).Msg(
+					"error retrieving hosts",
+				)
+
+				plugin.AddError(hsFetchErr)
+				plugin.ServiceOutput = fmt.Sprintf(
+					"%s: Error retrieving hosts",
+					nagios.StateCRITICALLabel,
+				)
+				plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+				return
+			}
+			log.Debug().Msg("Successfully retrieved hosts")
+
+			hostSystems = hss
+		}
+
+		log.Debug().Msg("Retrieving host certificates")
+		hostCertSet, getHostCertsErr := vsphere.GetHostCertificateStatusSet(
+			ctx,
+			c.Client,
+			hostSystems,
+			thresholds,
+		)
+		if getHostCertsErr != nil {
+			log.Error().Err(getHostCertsErr).Msg(
+				"error retrieving host certificates",
+			)
+
+			plugin.AddError(getHostCertsErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving host certificates",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved host certificates")
+
+		certSet = append(certSet, hostCertSet...)
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.CertificateStatusPerfData(certSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("certificates_evaluated", certSet.NumEvaluated()).
+		Int("certificates_unavailable", certSet.NumUnavailable()).
+		Int("certificates_expired", certSet.NumExpired()).
+		Int("certificates_critical", certSet.NumCritical()).
+		Int("certificates_warning", certSet.NumWarning()).
+		Logger()
+
+	log.Debug().Msg("Evaluating certificates")
+	switch {
+	case certSet.HasCriticalState():
+
+		log.Error().Msg("expired or expiring certificates detected")
+
+		plugin.AddError(vsphere.ErrCertificateExpirationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.CertificateStatusOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			certSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.CertificateStatusReport(
+			c.Client,
+			certSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case certSet.HasWarningState():
+
+		log.Error().Msg("expiring certificates detected")
+
+		plugin.AddError(vsphere.ErrCertificateExpirationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.CertificateStatusOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			certSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.CertificateStatusReport(
+			c.Client,
+			certSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No expiring certificates detected")
+
+		plugin.ServiceOutput = vsphere.CertificateStatusOneLineCheckSummary(
+			nagios.StateOKLabel,
+			certSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.CertificateStatusReport(
+			c.Client,
+			certSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,245 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VCenterDatabaseHealth: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"Database storage health red, database unhealthy or database partition usage at or above %d%%",
+		cfg.VCenterDatabaseUsageCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"Database storage health yellow/orange, database degraded or database partition usage at or above %d%%",
+		cfg.VCenterDatabaseUsageWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Int("db_usage_critical", cfg.VCenterDatabaseUsageCritical).
+		Int("db_usage_warning", cfg.VCenterDatabaseUsageWarning).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	rc, restLogout, restLoginErr := vsphere.SetupREST(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Retrieving vCenter database health")
+	dbHealth, getDBHealthErr := vsphere.GetVCenterDatabaseHealth(
+		ctx,
+		rc,
+		cfg.VCenterDatabaseUsageWarning,
+		cfg.VCenterDatabaseUsageCritical,
+	)
+	if getDBHealthErr != nil {
+		log.Error().Err(getDBHealthErr).Msg(
+			"error retrieving vCenter database health",
+		)
+
+		plugin.AddError(getDBHealthErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving vCenter database health for %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved vCenter database health")
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.VCenterDatabaseHealthPerfData(dbHealth)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Str("storage_health", dbHealth.StorageHealth).
+		Str("database_status", dbHealth.DatabaseStatus).
+		Int("partitions_critical", dbHealth.NumPartitionsCritical()).
+		Int("partitions_warning", dbHealth.NumPartitionsWarning()).
+		Logger()
+
+	log.Debug().Msg("Evaluating vCenter database health")
+	switch {
+	case dbHealth.IsCriticalState():
+
+		log.Error().Msg("vCenter database health issues detected")
+
+		plugin.AddError(vsphere.ErrVCenterDatabaseHealthThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VCenterDatabaseHealthOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			dbHealth,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterDatabaseHealthReport(
+			c.Client,
+			dbHealth,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case dbHealth.IsWarningState():
+
+		log.Error().Msg("vCenter database health issues detected")
+
+		plugin.AddError(vsphere.ErrVCenterDatabaseHealthThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VCenterDatabaseHealthOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			dbHealth,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterDatabaseHealthReport(
+			c.Client,
+			dbHealth,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No vCenter database health issues detected")
+
+		plugin.ServiceOutput = vsphere.VCenterDatabaseHealthOneLineCheckSummary(
+			nagios.StateOKLabel,
+			dbHealth,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterDatabaseHealthReport(
+			c.Client,
+			dbHealth,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,248 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VCenterServiceStatus: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "One or more required vCenter services not running, degraded or missing"
+
+	plugin.WarningThreshold = "One or more vCenter services configured for automatic startup not running or reporting health issues"
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("required_services", strings.Join(cfg.RequiredVCenterServices(), ", ")).
+		Str("ignored_services", strings.Join(cfg.IgnoredVCenterServices(), ", ")).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	rc, restLogout, restLoginErr := vsphere.SetupREST(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Retrieving vCenter services")
+	services, getServicesErr := vsphere.GetVCenterServices(ctx, rc)
+	if getServicesErr != nil {
+		log.Error().Err(getServicesErr).Msg(
+			"error retrieving vCenter services",
+		)
+
+		plugin.AddError(getServicesErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving vCenter services for %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully retrieved vCenter services")
+
+	vcServices := vsphere.NewVCenterServices(
+		services,
+		cfg.RequiredVCenterServices(),
+		cfg.IgnoredVCenterServices(),
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.VCenterServicesPerfData(vcServices)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("services", len(vcServices.Services)).
+		Int("services_running", vcServices.NumRunning()).
+		Int("services_required_not_running", vcServices.NumRequiredNotRunning()).
+		Int("services_automatic_not_running", len(vcServices.AutomaticNotRunning)).
+		Int("services_degraded", len(vcServices.Degraded)).
+		Logger()
+
+	log.Debug().Msg("Evaluating vCenter services")
+	switch {
+	case vcServices.HasCriticalState():
+
+		log.Error().Msg("required vCenter services not running")
+
+		plugin.AddError(vsphere.ErrVCenterServiceRequiredNotRunning)
+
+		plugin.ServiceOutput = vsphere.VCenterServicesOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			vcServices,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterServicesReport(
+			c.Client,
+			vcServices,
+			cfg.RequiredVCenterServices(),
+			cfg.IgnoredVCenterServices(),
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case vcServices.HasWarningState():
+
+		log.Error().Msg("vCenter services stopped or degraded")
+
+		plugin.AddError(vsphere.ErrVCenterServiceDegraded)
+
+		plugin.ServiceOutput = vsphere.VCenterServicesOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			vcServices,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterServicesReport(
+			c.Client,
+			vcServices,
+			cfg.RequiredVCenterServices(),
+			cfg.IgnoredVCenterServices(),
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No vCenter service issues detected")
+
+		plugin.ServiceOutput = vsphere.VCenterServicesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vcServices,
+		)
+
+		plugin.LongServiceOutput = vsphere.VCenterServicesReport(
+			c.Client,
+			vcServices,
+			cfg.RequiredVCenterServices(),
+			cfg.IgnoredVCenterServices(),
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -29,10 +29,6 @@ func main() {
 	// defer this from the start so it is the last deferred function to run
 	defer plugin.ReturnCheckResults()
 
-	// Annotate all errors (if any) with remediation advice just before ending
-	// plugin execution.
-	defer vsphere.AnnotateError(plugin)
-
 	// Setup configuration by parsing user-provided flags. Note plugin type so
 	// that only applicable CLI flags are exposed and any plugin-specific
 	// settings are applied.
@@ -61,6 +57,13 @@ func main() {
 	// enabled app-wide.
 	handleLibraryLogging()
 
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
 	// Set context deadline equal to user-specified timeout value for plugin
 	// runtime/execution.
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
@@ -81,26 +84,80 @@ func main() {
 		cfg.VCPUsMaxAllowed,
 	)
 
+	if cfg.VCPUsClusterCapacity {
+		plugin.CriticalThreshold = fmt.Sprintf(
+			"%.2f:1 vCPU:pCPU ratio per cluster",
+			cfg.VCPUsClusterRatioCritical,
+		)
+
+		plugin.WarningThreshold = fmt.Sprintf(
+			"%.2f:1 vCPU:pCPU ratio per cluster",
+			cfg.VCPUsClusterRatioWarning,
+		)
+	}
+
+	// Optional threshold above the CRITICAL threshold used to flag a
+	// CRITICAL state as an emergency.
+	emergencyThresholdValue, emergencyThresholdSet := cfg.EmergencyThreshold()
+	emergencyThreshold := vsphere.EmergencyThreshold{
+		Value:   emergencyThresholdValue,
+		Enabled: emergencyThresholdSet,
+	}
+
+	if emergencyThreshold.Enabled {
+		plugin.CriticalThreshold += fmt.Sprintf(
+			" (%d%% EMERGENCY)",
+			emergencyThreshold.Value,
+		)
+	}
+
 	if cfg.EmitBranding {
 		// If enabled, show application details at end of notification
 		plugin.BrandingCallback = config.Branding("Notification generated by ")
 	}
 
 	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
 		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
 		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
 		Str("ignored_vms", cfg.IgnoredVMs.String()).
 		Bool("eval_powered_off", cfg.PoweredOff).
 		Int("max_vcpus_allowed", cfg.VCPUsMaxAllowed).
 		Int("vcpus_critical_allocation", cfg.VCPUsAllocatedCritical).
 		Int("vcpus_warning_allocation", cfg.VCPUsAllocatedWarning).
+		Bool("cluster_capacity", cfg.VCPUsClusterCapacity).
+		Float64("vcpu_ratio_critical", cfg.VCPUsClusterRatioCritical).
+		Float64("vcpu_ratio_warning", cfg.VCPUsClusterRatioWarning).
 		Logger()
 
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
 	log.Debug().Msg("Logging into vSphere environment")
 	c, loginErr := vsphere.Login(
 		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
 		cfg.Username, cfg.Domain, cfg.Password,
-		cfg.UserAgent(),
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
 	)
 	if loginErr != nil {
 		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
@@ -118,17 +175,38 @@ func main() {
 	log.Debug().Msg("Successfully logged into vSphere environment")
 
 	defer func() {
-		if err := c.Logout(ctx); err != nil {
+		if err := vsphere.Logout(ctx, c); err != nil {
 			log.Error().
 				Err(err).
 				Msg("failed to logout")
 		}
 	}()
 
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
 	log.Debug().Msg("Filtering vms")
 	vmsFilterOptions := vsphere.VMsFilterOptions{
 		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
 		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
 		FoldersIncluded:             cfg.IncludedFolders,
 		FoldersExcluded:             cfg.ExcludedFolders,
 		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
@@ -170,6 +248,112 @@ func main() {
 		Int64("vcpus_allocated", vCPUsAllocated).
 		Msg("Finished counting vCPUs")
 
+	// Compare allocated vCPUs against physical CPU cores per cluster instead
+	// of a single static maximum if requested.
+	if cfg.VCPUsClusterCapacity {
+
+		log.Debug().Msg("Retrieving clusters")
+		clusters, getClustersErr := vsphere.GetClusters(ctx, c.Client, true)
+		if getClustersErr != nil {
+			log.Error().Err(getClustersErr).Msg(
+				"error retrieving clusters",
+			)
+
+			plugin.AddError(getClustersErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		ratioThresholds := vsphere.VCPUsClusterRatioThresholds{
+			Critical: cfg.VCPUsClusterRatioCritical,
+			Warning:  cfg.VCPUsClusterRatioWarning,
+		}
+
+		clusterAllocations, numVMsNotInCluster := vsphere.NewClusterVCPUsAllocationSet(
+			clusters,
+			vmsFilterResults.VMsAfterFiltering(),
+			ratioThresholds,
+		)
+
+		log.Debug().
+			Int("clusters_evaluated", len(clusterAllocations)).
+			Int("vms_not_in_cluster", numVMsNotInCluster).
+			Msg("Finished tallying vCPUs per cluster")
+
+		pd := append(
+			vsphere.VMFilterResultsPerfData(vmsFilterResults),
+			nagios.PerformanceData{
+				Label: "vcpus_used",
+				Value: fmt.Sprintf("%d", vCPUsAllocated),
+				Min:   "0",
+			},
+		)
+
+		pd = append(pd, vsphere.ClusterVCPUsAllocationPerfData(clusterAllocations, ratioThresholds)...)
+
+		if err := plugin.AddPerfData(false, pd...); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to add performance data")
+
+			// Surface the error in plugin output.
+			plugin.AddError(err)
+
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Failed to process performance data metrics",
+				nagios.StateUNKNOWNLabel,
+			)
+
+			return
+		}
+
+		var stateLabel string
+		switch {
+		case clusterAllocations.HasCriticalState():
+			log.Error().Msg("cluster vCPU:pCPU ratio CRITICAL")
+
+			plugin.AddError(vsphere.ErrVCPUsClusterRatioThresholdCrossed)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+			stateLabel = nagios.StateCRITICALLabel
+
+		case clusterAllocations.HasWarningState():
+			log.Error().Msg("cluster vCPU:pCPU ratio WARNING")
+
+			plugin.AddError(vsphere.ErrVCPUsClusterRatioThresholdCrossed)
+			plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+			stateLabel = nagios.StateWARNINGLabel
+
+		default:
+			log.Debug().Msg("cluster vCPU:pCPU ratio OK")
+
+			plugin.ExitStatusCode = nagios.StateOKExitCode
+			stateLabel = nagios.StateOKLabel
+		}
+
+		plugin.ServiceOutput = vsphere.ClusterVCPUsAllocationOneLineCheckSummary(
+			stateLabel,
+			clusterAllocations,
+			ratioThresholds,
+			vmsFilterResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.ClusterVCPUsAllocationReport(
+			c.Client,
+			clusterAllocations,
+			numVMsNotInCluster,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		return
+	}
+
 	vCPUsPercentageUsedOfAllowed := float64(vCPUsAllocated) / float64(cfg.VCPUsMaxAllowed) * 100
 	var vCPUsRemaining int64
 
@@ -198,18 +382,25 @@ func main() {
 				UnitOfMeasurement: "%",
 				Warn:              fmt.Sprintf("%d", cfg.VCPUsAllocatedWarning),
 				Crit:              fmt.Sprintf("%d", cfg.VCPUsAllocatedCritical),
+				Min:               "0",
 			},
 			{
 				Label: "vcpus_used",
 				Value: fmt.Sprintf("%d", vCPUsAllocated),
+				Min:   "0",
+				Max:   fmt.Sprintf("%d", cfg.VCPUsMaxAllowed),
 			},
 			{
 				Label: "vcpus_remaining",
 				Value: fmt.Sprintf("%d", vCPUsRemaining),
+				Min:   "0",
+				Max:   fmt.Sprintf("%d", cfg.VCPUsMaxAllowed),
 			},
 		}...,
 	)
 
+	pd = append(pd, emergencyThreshold.PerfData(vCPUsPercentageUsedOfAllowed)...)
+
 	if err := plugin.AddPerfData(false, pd...); err != nil {
 		log.Error().
 			Err(err).
@@ -262,6 +453,8 @@ func main() {
 			cfg.VCPUsMaxAllowed,
 		)
 
+		emergencyThreshold.Annotate(plugin, vCPUsPercentageUsedOfAllowed)
+
 		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
 
 		return
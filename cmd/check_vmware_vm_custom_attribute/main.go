@@ -0,0 +1,342 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VirtualMachineCustomAttribute: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "One or more VMs with a Custom Attribute value not satisfying specified constraints."
+
+	plugin.WarningThreshold = "One or more VMs missing specified Custom Attribute (unless ignored)."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
+		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Bool("include_powered_off", cfg.PoweredOff).
+		Str("custom_attribute", cfg.VMCustomAttributeName).
+		Bool("ignore_missing_ca", cfg.IgnoreMissingCustomAttribute).
+		Str("ca_value_pattern", cfg.VMCustomAttributePattern).
+		Str("ca_value_min", cfg.VMCustomAttributeMin).
+		Str("ca_value_max", cfg.VMCustomAttributeMax).
+		Str("ca_value_date_format", cfg.VMCustomAttributeDateFormat).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	caPolicy, policyErr := vsphere.NewVMCustomAttributePolicy(
+		cfg.VMCustomAttributeName,
+		cfg.IgnoreMissingCustomAttribute,
+		cfg.VMCustomAttributePattern,
+		cfg.VMCustomAttributeMin,
+		cfg.VMCustomAttributeMax,
+		cfg.VMCustomAttributeDateFormat,
+	)
+	if policyErr != nil {
+		log.Error().Err(policyErr).Msg("error processing custom attribute constraints")
+
+		plugin.AddError(policyErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error processing custom attribute constraints",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Performing initial filtering of vms")
+	vmsFilterOptions := vsphere.VMsFilterOptions{
+		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
+		FoldersIncluded:             cfg.IncludedFolders,
+		FoldersExcluded:             cfg.ExcludedFolders,
+		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
+		IncludePoweredOff:           cfg.PoweredOff,
+	}
+	vmsFilterResults, vmsFilterErr := vsphere.FilterVMs(
+		ctx,
+		c.Client,
+		vmsFilterOptions,
+	)
+	if vmsFilterErr != nil {
+		log.Error().Err(vmsFilterErr).Msg(
+			"error filtering VMs",
+		)
+
+		plugin.AddError(vmsFilterErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error filtering VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Finished initial filtering of vms")
+
+	log.Debug().Msg("Evaluating custom attribute for VMs")
+	caStatusSet, caEvalErr := vsphere.NewVMCustomAttributeStatusSet(
+		vmsFilterResults.VMsAfterFiltering(),
+		caPolicy,
+	)
+	if caEvalErr != nil {
+		log.Error().Err(caEvalErr).Msg(
+			"error evaluating custom attribute for VMs",
+		)
+
+		plugin.AddError(caEvalErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating custom attribute for VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := append(
+		vsphere.VMFilterResultsPerfData(vmsFilterResults),
+		vsphere.VMCustomAttributePerfData(caStatusSet)...,
+	)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("resource_pools_evaluated", vmsFilterResults.NumRPsAfterFiltering()).
+		Int("vms_total", vmsFilterResults.NumVMsAll()).
+		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
+		Int("vms_excluded_by_name", vmsFilterResults.NumVMsExcludedByName()).
+		Int("vms_with_ca_violations", caStatusSet.NumViolations()).
+		Int("vms_missing_ca", caStatusSet.NumMissing()).
+		Int("vms_missing_ca_ignored", caStatusSet.NumIgnored()).
+		Logger()
+
+	switch {
+	case caStatusSet.IsCriticalState() || caStatusSet.IsWarningState():
+
+		stateLabel := nagios.StateWARNINGLabel
+		stateExitCode := nagios.StateWARNINGExitCode
+
+		// Constraint violations have precedence over missing custom
+		// attributes.
+		switch {
+		case caStatusSet.IsCriticalState():
+			stateLabel = nagios.StateCRITICALLabel
+			stateExitCode = nagios.StateCRITICALExitCode
+			plugin.AddError(vsphere.ErrVirtualMachineCustomAttributeViolation)
+
+		default:
+			plugin.AddError(vsphere.ErrVirtualMachineCustomAttributeMissing)
+		}
+
+		log.Error().Msg("Virtual Machines with missing or non-compliant custom attribute")
+
+		plugin.ServiceOutput = vsphere.VMCustomAttributeOneLineCheckSummary(
+			stateLabel,
+			caPolicy,
+			vmsFilterResults,
+			caStatusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMCustomAttributeReport(
+			c.Client,
+			caPolicy,
+			vmsFilterOptions,
+			vmsFilterResults,
+			caStatusSet,
+		)
+
+		plugin.ExitStatusCode = stateExitCode
+
+		return
+
+	default:
+
+		// success path
+
+		log.Debug().Msg("No Virtual Machines with missing or non-compliant custom attribute")
+
+		plugin.ServiceOutput = vsphere.VMCustomAttributeOneLineCheckSummary(
+			nagios.StateOKLabel,
+			caPolicy,
+			vmsFilterResults,
+			caStatusSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMCustomAttributeReport(
+			c.Client,
+			caPolicy,
+			vmsFilterOptions,
+			vmsFilterResults,
+			caStatusSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+}
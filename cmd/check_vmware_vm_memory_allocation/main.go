@@ -0,0 +1,518 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VMMemoryAllocation: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Convert the user-specified maximum from GiB to bytes for comparison
+	// against allocated memory.
+	memoryMaxAllowed := int64(cfg.VMMemoryMaxAllowed) * units.GB
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% of %s memory allocated",
+		cfg.VMMemoryAllocatedCritical,
+		units.ByteSize(memoryMaxAllowed),
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% of %s memory allocated",
+		cfg.VMMemoryAllocatedWarning,
+		units.ByteSize(memoryMaxAllowed),
+	)
+
+	if cfg.VMMemoryPhysicalCapacity {
+		plugin.CriticalThreshold = fmt.Sprintf(
+			"%.2f:1 memory overcommit ratio per cluster or standalone host",
+			cfg.VMMemoryRatioCritical,
+		)
+
+		plugin.WarningThreshold = fmt.Sprintf(
+			"%.2f:1 memory overcommit ratio per cluster or standalone host",
+			cfg.VMMemoryRatioWarning,
+		)
+	}
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
+		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Bool("eval_powered_off", cfg.PoweredOff).
+		Int("max_memory_allowed_gib", cfg.VMMemoryMaxAllowed).
+		Int("memory_critical_allocation", cfg.VMMemoryAllocatedCritical).
+		Int("memory_warning_allocation", cfg.VMMemoryAllocatedWarning).
+		Bool("physical_capacity", cfg.VMMemoryPhysicalCapacity).
+		Float64("memory_ratio_critical", cfg.VMMemoryRatioCritical).
+		Float64("memory_ratio_warning", cfg.VMMemoryRatioWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Filtering vms")
+	vmsFilterOptions := vsphere.VMsFilterOptions{
+		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
+		FoldersIncluded:             cfg.IncludedFolders,
+		FoldersExcluded:             cfg.ExcludedFolders,
+		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
+		IncludePoweredOff:           cfg.PoweredOff,
+	}
+	vmsFilterResults, vmsFilterErr := vsphere.FilterVMs(
+		ctx,
+		c.Client,
+		vmsFilterOptions,
+	)
+	if vmsFilterErr != nil {
+		log.Error().Err(vmsFilterErr).Msg(
+			"error filtering VMs",
+		)
+
+		plugin.AddError(vmsFilterErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error filtering VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Finished filtering vms")
+
+	// here we diverge from VMware Tools plugin
+
+	memoryAllocated := vsphere.VMMemoryAllocated(vmsFilterResults.VMsAfterFiltering())
+	for _, vm := range vmsFilterResults.VMsAfterFiltering() {
+		log.Debug().
+			Str("vm_name", vm.Name).
+			Int32("memory_size_mb", vm.Summary.Config.MemorySizeMB).
+			Msg("")
+	}
+
+	log.Debug().
+		Int64("memory_allocated", memoryAllocated).
+		Msg("Finished tallying allocated memory")
+
+	// Compare allocated memory against physical memory per cluster (or
+	// standalone host) instead of a single static maximum if requested.
+	if cfg.VMMemoryPhysicalCapacity {
+
+		log.Debug().Msg("Retrieving clusters")
+		clusters, getClustersErr := vsphere.GetClusters(ctx, c.Client, true)
+		if getClustersErr != nil {
+			log.Error().Err(getClustersErr).Msg(
+				"error retrieving clusters",
+			)
+
+			plugin.AddError(getClustersErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		log.Debug().Msg("Retrieving hosts")
+		hosts, getHostsErr := vsphere.GetHostSystems(ctx, c.Client, true)
+		if getHostsErr != nil {
+			log.Error().Err(getHostsErr).Msg(
+				"error retrieving hosts",
+			)
+
+			plugin.AddError(getHostsErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving hosts",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		ratioThresholds := vsphere.MemoryOvercommitRatioThresholds{
+			Critical: cfg.VMMemoryRatioCritical,
+			Warning:  cfg.VMMemoryRatioWarning,
+		}
+
+		allocations, numVMsUnknownHost := vsphere.NewComputeResourceMemoryAllocationSet(
+			clusters,
+			hosts,
+			vmsFilterResults.VMsAfterFiltering(),
+			ratioThresholds,
+		)
+
+		log.Debug().
+			Int("clusters_hosts_evaluated", len(allocations)).
+			Int("vms_unknown_host", numVMsUnknownHost).
+			Msg("Finished tallying memory per cluster or standalone host")
+
+		pd := append(
+			vsphere.VMFilterResultsPerfData(vmsFilterResults),
+			nagios.PerformanceData{
+				Label:             "memory_allocated",
+				Value:             fmt.Sprintf("%d", memoryAllocated),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+			},
+		)
+
+		pd = append(pd, vsphere.ComputeResourceMemoryAllocationPerfData(allocations, ratioThresholds)...)
+
+		if err := plugin.AddPerfData(false, pd...); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to add performance data")
+
+			// Surface the error in plugin output.
+			plugin.AddError(err)
+
+			plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Failed to process performance data metrics",
+				nagios.StateUNKNOWNLabel,
+			)
+
+			return
+		}
+
+		var stateLabel string
+		switch {
+		case allocations.HasCriticalState():
+			log.Error().Msg("memory overcommit ratio CRITICAL")
+
+			plugin.AddError(vsphere.ErrVMMemoryOvercommitRatioThresholdCrossed)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+			stateLabel = nagios.StateCRITICALLabel
+
+		case allocations.HasWarningState():
+			log.Error().Msg("memory overcommit ratio WARNING")
+
+			plugin.AddError(vsphere.ErrVMMemoryOvercommitRatioThresholdCrossed)
+			plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+			stateLabel = nagios.StateWARNINGLabel
+
+		default:
+			log.Debug().Msg("memory overcommit ratio OK")
+
+			plugin.ExitStatusCode = nagios.StateOKExitCode
+			stateLabel = nagios.StateOKLabel
+		}
+
+		plugin.ServiceOutput = vsphere.ComputeResourceMemoryAllocationOneLineCheckSummary(
+			stateLabel,
+			allocations,
+			ratioThresholds,
+			vmsFilterResults,
+		)
+
+		plugin.LongServiceOutput = vsphere.ComputeResourceMemoryAllocationReport(
+			c.Client,
+			allocations,
+			numVMsUnknownHost,
+			vmsFilterOptions,
+			vmsFilterResults,
+		)
+
+		return
+	}
+
+	memoryPercentageUsedOfAllowed := float64(memoryAllocated) / float64(memoryMaxAllowed) * 100
+	var memoryRemaining int64
+
+	switch {
+	case memoryAllocated > memoryMaxAllowed:
+		memoryRemaining = 0
+	default:
+		memoryRemaining = memoryMaxAllowed - memoryAllocated
+	}
+
+	log.Debug().
+		Float64("memory_usage", memoryPercentageUsedOfAllowed).
+		Int64("memory_remaining", memoryRemaining).
+		Msg("")
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := append(
+		vsphere.VMFilterResultsPerfData(vmsFilterResults),
+		[]nagios.PerformanceData{
+			// The `time` (runtime) metric is appended at plugin exit, so do not
+			// duplicate it here.
+			{
+				Label:             "memory_usage",
+				Value:             fmt.Sprintf("%.2f", memoryPercentageUsedOfAllowed),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", cfg.VMMemoryAllocatedWarning),
+				Crit:              fmt.Sprintf("%d", cfg.VMMemoryAllocatedCritical),
+				Min:               "0",
+			},
+			{
+				Label:             "memory_allocated",
+				Value:             fmt.Sprintf("%d", memoryAllocated),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", memoryMaxAllowed),
+			},
+			{
+				Label:             "memory_remaining",
+				Value:             fmt.Sprintf("%d", memoryRemaining),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", memoryMaxAllowed),
+			},
+		}...,
+	)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("resource_pools_evaluated", vmsFilterResults.NumRPsAfterFiltering()).
+		Int("vms_total", vmsFilterResults.NumVMsAll()).
+		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
+		Int("vms_excluded_by_name", vmsFilterResults.NumVMsExcludedByName()).
+		Int("vms_excluded_by_power_state", vmsFilterResults.NumVMsExcludedByPowerState()).
+		Float64("memory_usage", memoryPercentageUsedOfAllowed).
+		Int64("memory_allocated", memoryAllocated).
+		Int64("memory_remaining", memoryRemaining).
+		Logger()
+
+	log.Debug().Msg("Evaluating VM memory allocation")
+	switch {
+	case memoryPercentageUsedOfAllowed > float64(cfg.VMMemoryAllocatedCritical):
+
+		log.Error().Msg("VM memory allocation CRITICAL")
+
+		plugin.AddError(vsphere.ErrVMMemoryAllocationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VMMemoryAllocationOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryAllocationReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case memoryPercentageUsedOfAllowed > float64(cfg.VMMemoryAllocatedWarning):
+
+		log.Error().Msg("VM memory allocation WARNING")
+
+		plugin.AddError(vsphere.ErrVMMemoryAllocationThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VMMemoryAllocationOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryAllocationReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("VM memory allocation OK")
+
+		plugin.ServiceOutput = vsphere.VMMemoryAllocationOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryAllocationReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryAllocated,
+			memoryMaxAllowed,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
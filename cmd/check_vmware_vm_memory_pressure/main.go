@@ -0,0 +1,342 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VirtualMachineMemoryPressure: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"%d%% ballooned, %d%% swapped or %d%% compressed memory",
+		cfg.VMMemoryBalloonedCritical,
+		cfg.VMMemorySwappedCritical,
+		cfg.VMMemoryCompressedCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"%d%% ballooned, %d%% swapped or %d%% compressed memory",
+		cfg.VMMemoryBalloonedWarning,
+		cfg.VMMemorySwappedWarning,
+		cfg.VMMemoryCompressedWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("included_resource_pools", cfg.IncludedResourcePools.String()).
+		Str("excluded_resource_pools", cfg.ExcludedResourcePools.String()).
+		Str("included_datacenters", cfg.IncludedDatacenters.String()).
+		Str("excluded_datacenters", cfg.ExcludedDatacenters.String()).
+		Str("included_clusters", cfg.IncludedClusters.String()).
+		Str("excluded_clusters", cfg.ExcludedClusters.String()).
+		Str("included_hosts", cfg.IncludedHosts.String()).
+		Str("excluded_hosts", cfg.ExcludedHosts.String()).
+		Str("included_tags", cfg.IncludedTags.String()).
+		Str("excluded_tags", cfg.ExcludedTags.String()).
+		Str("ignored_vms", cfg.IgnoredVMs.String()).
+		Int("ballooned_critical", cfg.VMMemoryBalloonedCritical).
+		Int("ballooned_warning", cfg.VMMemoryBalloonedWarning).
+		Int("swapped_critical", cfg.VMMemorySwappedCritical).
+		Int("swapped_warning", cfg.VMMemorySwappedWarning).
+		Int("compressed_critical", cfg.VMMemoryCompressedCritical).
+		Int("compressed_warning", cfg.VMMemoryCompressedWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	// Filtering by vSphere Tags requires access to the vSphere Automation
+	// API tagging service.
+	rc, restLogout, restLoginErr := vsphere.SetupTagging(ctx, c.Client, cfg, plugin)
+	if restLoginErr != nil {
+		log.Error().Err(restLoginErr).Msgf("error logging into %s", cfg.Server)
+
+		return
+	}
+	defer restLogout()
+
+	log.Debug().Msg("Performing initial filtering of vms")
+	vmsFilterOptions := vsphere.VMsFilterOptions{
+		ResourcePoolsIncluded:       cfg.IncludedResourcePools,
+		ResourcePoolsExcluded:       cfg.ExcludedResourcePools,
+		DatacentersIncluded:         cfg.IncludedDatacenters,
+		DatacentersExcluded:         cfg.ExcludedDatacenters,
+		ClusterNamesIncluded:        cfg.IncludedClusters,
+		ClusterNamesExcluded:        cfg.ExcludedClusters,
+		HostNamesIncluded:           cfg.IncludedHosts,
+		HostNamesExcluded:           cfg.ExcludedHosts,
+		TagsIncluded:                cfg.IncludedTags,
+		TagsExcluded:                cfg.ExcludedTags,
+		TaggingClient:               rc,
+		MaintenanceCAName:           cfg.VMMaintenanceCA,
+		MaintenanceCADateFormat:     cfg.VMMaintenanceCADateFormat,
+		FoldersIncluded:             cfg.IncludedFolders,
+		FoldersExcluded:             cfg.ExcludedFolders,
+		VirtualMachineNamesExcluded: cfg.IgnoredVMs,
+
+		// NOTE: Memory quick stats are not populated for powered off VMs,
+		// so this plugin is hard-coded to exclude them.
+		IncludePoweredOff: false,
+	}
+	vmsFilterResults, vmsFilterErr := vsphere.FilterVMs(
+		ctx,
+		c.Client,
+		vmsFilterOptions,
+	)
+	if vmsFilterErr != nil {
+		log.Error().Err(vmsFilterErr).Msg(
+			"error filtering VMs",
+		)
+
+		plugin.AddError(vmsFilterErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error filtering VMs",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Finished initial filtering of vms")
+
+	log.Debug().Msg("Evaluating memory pressure for VMs")
+	memoryPressureSet := vsphere.NewVMMemoryPressureSet(
+		vmsFilterResults.VMsAfterFiltering(),
+		vsphere.VMMemoryPressureThresholds{
+			BalloonedWarning:   cfg.VMMemoryBalloonedWarning,
+			BalloonedCritical:  cfg.VMMemoryBalloonedCritical,
+			SwappedWarning:     cfg.VMMemorySwappedWarning,
+			SwappedCritical:    cfg.VMMemorySwappedCritical,
+			CompressedWarning:  cfg.VMMemoryCompressedWarning,
+			CompressedCritical: cfg.VMMemoryCompressedCritical,
+		},
+	)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := append(
+		vsphere.VMFilterResultsPerfData(vmsFilterResults),
+		vsphere.VMMemoryPressurePerfData(memoryPressureSet)...,
+	)
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("resource_pools_evaluated", vmsFilterResults.NumRPsAfterFiltering()).
+		Int("vms_total", vmsFilterResults.NumVMsAll()).
+		Int("vms_after_filtering", vmsFilterResults.NumVMsAfterFiltering()).
+		Int("vms_excluded_by_name", vmsFilterResults.NumVMsExcludedByName()).
+		Int("vms_excluded_by_power_state", vmsFilterResults.NumVMsExcludedByPowerState()).
+		Int("vms_memory_pressure_critical", memoryPressureSet.NumCritical()).
+		Int("vms_memory_pressure_warning", memoryPressureSet.NumWarning()).
+		Logger()
+
+	offendingVMs := make([]string, 0, len(memoryPressureSet))
+	for _, m := range memoryPressureSet.Offending() {
+		offendingVMs = append(offendingVMs, m.VM.Name)
+	}
+
+	switch {
+	case memoryPressureSet.HasCriticalState():
+
+		log.Error().
+			Str("virtual_machines", strings.Join(offendingVMs, ", ")).
+			Msg("Virtual Machines with memory ballooning, swapping or compression values exceeding CRITICAL threshold")
+
+		plugin.AddError(vsphere.ErrVirtualMachineMemoryPressureThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VMMemoryPressureOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryPressureReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case memoryPressureSet.HasWarningState():
+
+		log.Error().
+			Str("virtual_machines", strings.Join(offendingVMs, ", ")).
+			Msg("Virtual Machines with memory ballooning, swapping or compression values exceeding WARNING threshold")
+
+		plugin.AddError(vsphere.ErrVirtualMachineMemoryPressureThresholdCrossed)
+
+		plugin.ServiceOutput = vsphere.VMMemoryPressureOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryPressureReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		// success path
+
+		log.Debug().Msg("No Virtual Machines under memory pressure")
+
+		plugin.ServiceOutput = vsphere.VMMemoryPressureOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VMMemoryPressureReport(
+			c.Client,
+			vmsFilterOptions,
+			vmsFilterResults,
+			memoryPressureSet,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+}
@@ -0,0 +1,320 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VSANHealth: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = "One or more vSAN health test groups in a red state."
+	plugin.WarningThreshold = "One or more vSAN health test groups in a yellow state."
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	clusterName := cfg.ClusterName
+	if clusterName == "" {
+		clusterName = "all"
+	}
+
+	fetchFromCache := !cfg.VSANHealthRefresh
+
+	log := cfg.Log.With().
+		Str("cluster_name", clusterName).
+		Str("datacenter_name", cfg.DatacenterName).
+		Bool("fetch_from_cache", fetchFromCache).
+		Strs("ignored_tests", cfg.IgnoredVSANHealthTests).
+		Logger()
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing clusters instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing clusters")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing clusters",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeClusterComputeResource,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Retrieving vSAN enabled clusters")
+	clusters, getClustersErr := vsphere.GetVSANClusters(
+		ctx,
+		c.Client,
+		cfg.ClusterName,
+		cfg.DatacenterName,
+	)
+	if getClustersErr != nil {
+		log.Error().Err(getClustersErr).Msg(
+			"error retrieving vSAN enabled clusters",
+		)
+
+		plugin.AddError(getClustersErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving vSAN enabled clusters",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().
+		Int("clusters", len(clusters)).
+		Msg("Successfully retrieved vSAN enabled clusters")
+
+	log.Debug().Msg("Retrieving vSAN health summaries")
+	healthSet, getHealthErr := vsphere.GetVSANClusterHealthSet(
+		ctx,
+		c.Client,
+		clusters,
+		fetchFromCache,
+	)
+	if getHealthErr != nil {
+		log.Error().Err(getHealthErr).Msg(
+			"error retrieving vSAN health summaries",
+		)
+
+		plugin.AddError(getHealthErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error retrieving vSAN health summaries",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	healthSet = healthSet.FilterVSANHealthTests(cfg.IgnoredVSANHealthTests)
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	pd := vsphere.VSANHealthPerfData(healthSet)
+
+	// The failover level metric labels are not cluster specific, so only
+	// record them when evaluating a single cluster.
+	if len(healthSet) == 1 {
+		pd = append(pd, vsphere.ClusterFailoverLevelsPerfData(healthSet[0].Cluster)...)
+	}
+
+	if err := plugin.AddPerfData(false, pd...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("clusters_evaluated", len(healthSet)).
+		Int("vsan_health_groups", healthSet.NumGroups()).
+		Int("vsan_health_groups_red", healthSet.NumGroupsRed()).
+		Int("vsan_health_groups_yellow", healthSet.NumGroupsYellow()).
+		Logger()
+
+	log.Debug().Msg("Evaluating vSAN health state")
+	switch {
+	case healthSet.HasCriticalState():
+
+		log.Error().Msg("vSAN health test groups in red state detected")
+
+		plugin.AddError(vsphere.ErrVSANHealthCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VSANHealthOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VSANHealthReport(
+			c.Client,
+			healthSet,
+			cfg.IgnoredVSANHealthTests,
+			fetchFromCache,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case healthSet.HasWarningState():
+
+		log.Error().Msg("vSAN health test groups in yellow state detected")
+
+		plugin.AddError(vsphere.ErrVSANHealthCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VSANHealthOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VSANHealthReport(
+			c.Client,
+			healthSet,
+			cfg.IgnoredVSANHealthTests,
+			fetchFromCache,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No vSAN health issues detected")
+
+		plugin.ServiceOutput = vsphere.VSANHealthOneLineCheckSummary(
+			nagios.StateOKLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VSANHealthReport(
+			c.Client,
+			healthSet,
+			cfg.IgnoredVSANHealthTests,
+			fetchFromCache,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
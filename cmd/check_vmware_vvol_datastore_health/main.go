@@ -0,0 +1,366 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	plugin := nagios.NewPlugin()
+
+	// defer this from the start so it is the last deferred function to run
+	defer plugin.ReturnCheckResults()
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VVolDatastoreHealth: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error initializing application",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.AddError(cfgErr)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Apply settings shared by all plugins (e.g., session caching, TLS,
+	// tracing) and adjust or record plugin results (e.g., error advice, state
+	// mappings, name redaction, traces) once the final plugin state has been
+	// determined.
+	cleanup := vsphere.SetupPlugin(cfg, plugin)
+	defer cleanup()
+
+	// Set context deadline equal to user-specified timeout value for plugin
+	// runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	// Record thresholds for use as Nagios "Long Service Output" content. This
+	// content is shown in the detailed web UI and in notifications generated
+	// by Nagios.
+	plugin.CriticalThreshold = fmt.Sprintf(
+		"vVol datastore inaccessible, no protocol endpoints bound to a host with the datastore mounted or %d%% storage container usage",
+		cfg.DatastoreSpaceUsageCritical,
+	)
+
+	plugin.WarningThreshold = fmt.Sprintf(
+		"vVol datastore not mounted on any host or %d%% storage container usage",
+		cfg.DatastoreSpaceUsageWarning,
+	)
+
+	if cfg.EmitBranding {
+		// If enabled, show application details at end of notification
+		plugin.BrandingCallback = config.Branding("Notification generated by ")
+	}
+
+	datastoreName := cfg.DatastoreName
+	if datastoreName == "" {
+		datastoreName = "all"
+	}
+
+	log := cfg.Log.With().
+		Str("pattern_match", cfg.PatternMatch).
+		Str("datastore_name", datastoreName).
+		Str("datacenter_name", cfg.DatacenterName).
+		Int("datastore_usage_critical", cfg.DatastoreSpaceUsageCritical).
+		Int("datastore_usage_warning", cfg.DatastoreSpaceUsageWarning).
+		Logger()
+
+	// Apply requested pattern matching mode for name based include/exclude
+	// lists.
+	if err := vsphere.SetPatternMatchMode(cfg.PatternMatch); err != nil {
+		log.Error().Err(err).Msg("error setting pattern matching mode")
+
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error setting pattern matching mode",
+			nagios.StateUNKNOWNLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		log.Error().Err(loginErr).Msgf("error logging into %s", cfg.Server)
+
+		plugin.AddError(loginErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := vsphere.Logout(ctx, c); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	if cfg.ListObjects {
+		log.Debug().Msg("Listing datastores instead of performing evaluation")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+		)
+		if listErr != nil {
+			log.Error().Err(listErr).Msg("error listing datastores")
+
+			plugin.AddError(listErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error listing datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+
+		plugin.ServiceOutput = vsphere.ListObjectNamesOneLineCheckSummary(
+			nagios.StateOKLabel,
+			vsphere.MgObjRefTypeDatastore,
+			names,
+		)
+
+		plugin.LongServiceOutput = vsphere.ListObjectNamesReport(
+			c.Client,
+			vsphere.MgObjRefTypeDatastore,
+			cfg.DatacenterName,
+			cfg.ListObjectsPattern,
+			names,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+	}
+
+	// Full property retrieval is used as the datastore info property (vVol
+	// storage container and protocol endpoint details) is not included in
+	// the standard properties subset.
+	var datastores []mo.Datastore
+	var numDatastoresExcluded int
+	switch {
+	case cfg.DatastoreName != "":
+		log.Debug().Msg("Retrieving datastore by name")
+		datastore, getDatastoreErr := vsphere.GetDatastoreByName(
+			ctx,
+			c.Client,
+			cfg.DatastoreName,
+			cfg.DatacenterName,
+			false,
+		)
+		if getDatastoreErr != nil {
+			log.Error().Err(getDatastoreErr).Msg(
+				"error retrieving requested datastore",
+			)
+
+			plugin.AddError(getDatastoreErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving datastore %q",
+				nagios.StateCRITICALLabel,
+				cfg.DatastoreName,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved datastore by name")
+
+		datastores = []mo.Datastore{datastore}
+
+	default:
+		log.Debug().Msg("Retrieving datastores")
+		allDatastores, getDatastoresErr := vsphere.GetDatastores(ctx, c.Client, false)
+		if getDatastoresErr != nil {
+			log.Error().Err(getDatastoresErr).Msg(
+				"error retrieving datastores",
+			)
+
+			plugin.AddError(getDatastoresErr)
+			plugin.ServiceOutput = fmt.Sprintf(
+				"%s: Error retrieving datastores",
+				nagios.StateCRITICALLabel,
+			)
+			plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+			return
+		}
+		log.Debug().Msg("Successfully retrieved datastores")
+
+		datastores, numDatastoresExcluded = vsphere.FilterVVolDatastores(
+			allDatastores,
+			cfg.IgnoredDatastores,
+		)
+	}
+
+	log.Debug().Msg("Evaluating vVol datastore health")
+	healthSet, healthErr := vsphere.GetVVolDatastoreHealthSet(
+		ctx,
+		c.Client,
+		datastores,
+		vsphere.VVolDatastoreHealthThresholds{
+			UsageWarning:  cfg.DatastoreSpaceUsageWarning,
+			UsageCritical: cfg.DatastoreSpaceUsageCritical,
+		},
+	)
+	if healthErr != nil {
+		log.Error().Err(healthErr).Msg(
+			"error evaluating vVol datastore health",
+		)
+
+		plugin.AddError(healthErr)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error evaluating vVol datastore health",
+			nagios.StateCRITICALLabel,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+	}
+
+	log.Debug().Msg("Compiling Performance Data details")
+
+	if err := plugin.AddPerfData(false, vsphere.VVolDatastoreHealthPerfData(healthSet)...); err != nil {
+		log.Error().
+			Err(err).
+			Msg("failed to add performance data")
+
+		// Surface the error in plugin output.
+		plugin.AddError(err)
+
+		plugin.ExitStatusCode = nagios.StateUNKNOWNExitCode
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Failed to process performance data metrics",
+			nagios.StateUNKNOWNLabel,
+		)
+
+		return
+	}
+
+	// Update logger with new performance data related fields
+	log = log.With().
+		Int("datastores_evaluated", len(healthSet)).
+		Int("datastores_excluded", numDatastoresExcluded).
+		Int("datastores_critical", healthSet.NumCritical()).
+		Int("datastores_warning", healthSet.NumWarning()).
+		Int("datastores_inaccessible", healthSet.NumInaccessible()).
+		Logger()
+
+	switch {
+	case healthSet.HasCriticalState():
+
+		log.Error().Msg("vVol datastore health issues mapping to CRITICAL state detected")
+
+		plugin.AddError(vsphere.ErrVVolDatastoreHealthCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VVolDatastoreHealthOneLineCheckSummary(
+			nagios.StateCRITICALLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VVolDatastoreHealthReport(
+			c.Client,
+			healthSet,
+			numDatastoresExcluded,
+		)
+
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return
+
+	case healthSet.HasWarningState():
+
+		log.Error().Msg("vVol datastore health issues mapping to WARNING state detected")
+
+		plugin.AddError(vsphere.ErrVVolDatastoreHealthCheckFailed)
+
+		plugin.ServiceOutput = vsphere.VVolDatastoreHealthOneLineCheckSummary(
+			nagios.StateWARNINGLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VVolDatastoreHealthReport(
+			c.Client,
+			healthSet,
+			numDatastoresExcluded,
+		)
+
+		plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+		return
+
+	default:
+
+		log.Debug().Msg("No vVol datastore health issues detected")
+
+		plugin.ServiceOutput = vsphere.VVolDatastoreHealthOneLineCheckSummary(
+			nagios.StateOKLabel,
+			healthSet,
+		)
+
+		plugin.LongServiceOutput = vsphere.VVolDatastoreHealthReport(
+			c.Client,
+			healthSet,
+			numDatastoresExcluded,
+		)
+
+		plugin.ExitStatusCode = nagios.StateOKExitCode
+
+		return
+
+	}
+
+}
@@ -0,0 +1,25 @@
+/*
+Tool used to expose vSphere inventory metrics for Prometheus.
+
+# PURPOSE
+
+This tool connects to a vSphere environment on a fixed interval and collects
+metrics computed by the plugins provided by this project (datastore usage,
+VM snapshot counts and sizes, host CPU and memory usage and VM power states).
+The most recently collected metrics are served via HTTP from the /metrics
+path using the Prometheus text-based exposition format.
+
+Unlike the plugins provided by this project, this tool is long-running and is
+not intended to be run by Nagios.
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
@@ -0,0 +1,155 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/exporter"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+// metricsPath is the HTTP path used to serve collected metrics.
+const metricsPath string = "/metrics"
+
+// shutdownTimeout is the time allowed for in-flight HTTP requests to
+// complete when the exporter is stopped.
+const shutdownTimeout = 5 * time.Second
+
+func main() {
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{VMwareExporter: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+
+		os.Exit(1)
+	}
+
+	// Unlike our plugins this tool is not called by Nagios; deferred
+	// function calls are handled within run so that a non-zero exit code can
+	// be returned on failure.
+	if err := run(cfg); err != nil {
+		cfg.Log.Error().Err(err).Msg("metrics exporter failed")
+
+		os.Exit(1)
+	}
+}
+
+// run collects vSphere metrics on the configured interval and serves them
+// via HTTP until interrupted.
+func run(cfg *config.Config) error {
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	log := cfg.Log.With().
+		Str("listen_address", cfg.ExporterListenAddress).
+		Dur("collection_interval", cfg.ExporterCollectionInterval()).
+		Dur("collection_timeout", cfg.Timeout()).
+		Logger()
+
+	// A new session is used for each collection so that expired sessions
+	// (e.g., due to vCenter restarts) do not require restarting the
+	// exporter.
+	collect := func(ctx context.Context) (exporter.Metrics, error) {
+		// Timings are recorded per collection; discard those recorded by
+		// the previous collection so that they do not accumulate.
+		vsphere.ResetPhaseTimings()
+		vsphere.ResetPropertyRetrievalTimings()
+
+		log.Debug().Msg("Logging into vSphere environment")
+		c, loginErr := vsphere.Login(
+			ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+			cfg.Username, cfg.Domain, cfg.Password,
+			cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+		)
+		if loginErr != nil {
+			return nil, fmt.Errorf("error logging into %q: %w", cfg.Server, loginErr)
+		}
+		log.Debug().Msg("Successfully logged into vSphere environment")
+
+		defer func() {
+			if err := c.Logout(ctx); err != nil {
+				log.Error().
+					Err(err).
+					Msg("failed to logout")
+			}
+		}()
+
+		metrics, collectErr := exporter.Collect(ctx, c.Client)
+		if collectErr != nil {
+			return nil, collectErr
+		}
+
+		log.Debug().Int("metrics", len(metrics)).Msg("Collected vSphere metrics")
+
+		return metrics, nil
+	}
+
+	exp := exporter.New(collect, cfg.ExporterCollectionInterval(), cfg.Timeout())
+	exp.ErrorHandler = func(err error) {
+		log.Error().Err(err).Msg("failed to collect vSphere metrics")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go exp.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, exp)
+
+	server := &http.Server{
+		Addr:              cfg.ExporterListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.Timeout(),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info().Msgf("Serving metrics on %s%s", cfg.ExporterListenAddress, metricsPath)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve metrics: %w", err)
+
+	case <-ctx.Done():
+		log.Info().Msg("Stopping metrics exporter")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	}
+}
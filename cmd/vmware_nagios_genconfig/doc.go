@@ -0,0 +1,26 @@
+/*
+Tool used to generate Nagios object definitions from discovered vSphere
+inventory.
+
+# PURPOSE
+
+This tool connects to a vSphere environment, discovers ESXi hosts,
+datastores and clusters (optionally filtered by name pattern) and renders
+Nagios service (and optionally host) definitions for them using a built-in or
+user-provided Go text/template file. Running this tool on a schedule helps
+keep monitoring coverage in sync with vSphere inventory.
+
+Unlike the plugins provided by this project, this tool is not intended to be
+run by Nagios directly.
+
+# PROJECT HOME
+
+See our GitHub repo (https://github.com/atc0005/check-vmware) for the latest
+code, to file an issue or submit improvements for review and potential
+inclusion into the project.
+
+# USAGE
+
+See our main README for supported settings and examples.
+*/
+package main
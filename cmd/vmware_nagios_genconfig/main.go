@@ -0,0 +1,204 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/config"
+	"github.com/atc0005/check-vmware/internal/genconfig"
+	"github.com/atc0005/check-vmware/internal/vsphere"
+
+	zlog "github.com/rs/zerolog/log"
+)
+
+//go:generate go-winres make --product-version=git-tag --file-version=git-tag
+
+func main() {
+
+	// Setup configuration by parsing user-provided flags. Note plugin type so
+	// that only applicable CLI flags are exposed and any plugin-specific
+	// settings are applied.
+	cfg, cfgErr := config.New(config.PluginType{NagiosGenConfig: true})
+	switch {
+	case errors.Is(cfgErr, config.ErrVersionRequested):
+		fmt.Println(config.Version())
+
+		return
+
+	case cfgErr != nil:
+		// We're using the standalone Err function from rs/zerolog/log as we
+		// do not have a working configuration.
+		zlog.Err(cfgErr).Msg("Error initializing application")
+
+		os.Exit(1)
+	}
+
+	// Unlike our plugins this tool is not called by Nagios; deferred
+	// function calls are handled within run so that a non-zero exit code can
+	// be returned on failure.
+	if err := run(cfg); err != nil {
+		cfg.Log.Error().Err(err).Msg("failed to generate Nagios object definitions")
+
+		os.Exit(1)
+	}
+}
+
+// run performs inventory discovery and renders Nagios object definitions
+// using the specified configuration.
+func run(cfg *config.Config) error {
+
+	// Enable library-level logging if debug or greater logging level is
+	// enabled app-wide.
+	handleLibraryLogging()
+
+	// Set context deadline equal to user-specified timeout value for
+	// application runtime/execution.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout())
+	defer cancel()
+
+	dcName := cfg.DatacenterName
+	if dcName == "" {
+		dcName = "not provided"
+	}
+
+	log := cfg.Log.With().
+		Str("datacenter_name", dcName).
+		Str("host_pattern", cfg.GenConfigHostPattern).
+		Str("datastore_pattern", cfg.GenConfigDatastorePattern).
+		Str("cluster_pattern", cfg.GenConfigClusterPattern).
+		Str("template_file", cfg.GenConfigTemplateFile).
+		Str("output_file", cfg.GenConfigOutputFile).
+		Logger()
+
+	// Load template before connecting to the vSphere environment so that we
+	// fail early if the template cannot be used.
+	tmplName, tmplText, loadErr := genconfig.LoadTemplate(cfg.GenConfigTemplateFile)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	log.Debug().Msg("Logging into vSphere environment")
+	c, loginErr := vsphere.Login(
+		ctx, cfg.Server, cfg.Port, cfg.TrustCert,
+		cfg.Username, cfg.Domain, cfg.Password,
+		cfg.UserAgent(), vsphere.NewConnectionOptions(cfg),
+	)
+	if loginErr != nil {
+		return fmt.Errorf("error logging into %q: %w", cfg.Server, loginErr)
+	}
+	log.Debug().Msg("Successfully logged into vSphere environment")
+
+	defer func() {
+		if err := c.Logout(ctx); err != nil {
+			log.Error().
+				Err(err).
+				Msg("failed to logout")
+		}
+	}()
+
+	nagiosHost := cfg.GenConfigNagiosHost
+	if nagiosHost == "" {
+		nagiosHost = cfg.Server
+	}
+
+	data := genconfig.TemplateData{
+		Generated:      time.Now(),
+		Generator:      config.Version(),
+		Server:         cfg.Server,
+		NagiosHost:     nagiosHost,
+		Domain:         cfg.Domain,
+		Username:       cfg.Username,
+		Datacenter:     cfg.DatacenterName,
+		HostDefinition: cfg.GenConfigHostDefinition,
+	}
+
+	discovery := []struct {
+		objKind string
+		pattern string
+		names   *[]string
+	}{
+		{
+			objKind: vsphere.MgObjRefTypeHostSystem,
+			pattern: cfg.GenConfigHostPattern,
+			names:   &data.Hosts,
+		},
+		{
+			objKind: vsphere.MgObjRefTypeDatastore,
+			pattern: cfg.GenConfigDatastorePattern,
+			names:   &data.Datastores,
+		},
+		{
+			objKind: vsphere.MgObjRefTypeClusterComputeResource,
+			pattern: cfg.GenConfigClusterPattern,
+			names:   &data.Clusters,
+		},
+	}
+
+	for _, d := range discovery {
+		log.Debug().Str("object_type", d.objKind).Msg("Discovering objects")
+
+		names, listErr := vsphere.ListObjectNames(
+			ctx,
+			c.Client,
+			d.objKind,
+			cfg.DatacenterName,
+			d.pattern,
+		)
+		if listErr != nil {
+			return fmt.Errorf("error discovering %s objects: %w", d.objKind, listErr)
+		}
+
+		log.Debug().
+			Str("object_type", d.objKind).
+			Int("objects", len(names)).
+			Msg("Discovered objects")
+
+		*d.names = names
+	}
+
+	var w io.Writer = os.Stdout
+	if cfg.GenConfigOutputFile != "" {
+		f, createErr := os.Create(cfg.GenConfigOutputFile)
+		if createErr != nil {
+			return fmt.Errorf(
+				"failed to create output file %q: %w",
+				cfg.GenConfigOutputFile,
+				createErr,
+			)
+		}
+
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Error().
+					Err(err).
+					Msg("failed to close output file")
+			}
+		}()
+
+		w = f
+	}
+
+	if err := genconfig.Render(w, tmplName, tmplText, data); err != nil {
+		return err
+	}
+
+	log.Info().
+		Int("hosts", len(data.Hosts)).
+		Int("datastores", len(data.Datastores)).
+		Int("clusters", len(data.Clusters)).
+		Msg("Nagios object definitions generated")
+
+	return nil
+
+}
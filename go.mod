@@ -10,10 +10,12 @@ module github.com/atc0005/check-vmware
 go 1.22
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/atc0005/go-nagios v0.19.0
 	github.com/google/go-cmp v0.6.0
 	github.com/rs/zerolog v1.33.0
 	github.com/vmware/govmomi v0.48.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,259 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrAlarmFilterFileInvalid indicates that an alarm filter file is invalid.
+var ErrAlarmFilterFileInvalid = errors.New("invalid alarm filter file")
+
+// ErrInvalidAlarmTimeBoundary indicates that a triggered alarm time boundary
+// value could not be parsed as either a duration or an RFC3339 timestamp or
+// that the resulting time window is invalid.
+var ErrInvalidAlarmTimeBoundary = errors.New("invalid triggered alarm time boundary")
+
+// alarmFilterList is a list of alarm filter values loaded from an alarm
+// filter file. Nested lists are flattened so that reusable lists (defined
+// once via a YAML anchor and referenced elsewhere via an alias) may be
+// combined with other values in the same list.
+type alarmFilterList []string
+
+// AlarmFilterFile is the collection of triggered alarm filters loaded from
+// a user-specified YAML file. Keys in the file match the names of the
+// equivalent command-line flags. Values specified in the file are combined
+// with values specified via command-line flags.
+//
+// The top-level definitions key is ignored and is intended to hold reusable
+// lists (YAML anchors) referenced by other keys.
+type AlarmFilterFile struct {
+	Definitions                      yaml.Node       `yaml:"definitions"`
+	IncludedAlarmEntityTypes         alarmFilterList `yaml:"include-entity-type"`
+	ExcludedAlarmEntityTypes         alarmFilterList `yaml:"exclude-entity-type"`
+	IncludedAlarmEntityNames         alarmFilterList `yaml:"include-entity-name"`
+	ExcludedAlarmEntityNames         alarmFilterList `yaml:"exclude-entity-name"`
+	IncludedAlarmEntityResourcePools alarmFilterList `yaml:"include-entity-rp"`
+	ExcludedAlarmEntityResourcePools alarmFilterList `yaml:"exclude-entity-rp"`
+	IncludedAlarmNames               alarmFilterList `yaml:"include-name"`
+	ExcludedAlarmNames               alarmFilterList `yaml:"exclude-name"`
+	IncludedAlarmDescriptions        alarmFilterList `yaml:"include-desc"`
+	ExcludedAlarmDescriptions        alarmFilterList `yaml:"exclude-desc"`
+	IncludedAlarmStatuses            alarmFilterList `yaml:"include-status"`
+	ExcludedAlarmStatuses            alarmFilterList `yaml:"exclude-status"`
+	IncludedTags                     alarmFilterList `yaml:"include-tag"`
+	ExcludedTags                     alarmFilterList `yaml:"exclude-tag"`
+	EvaluateAcknowledgedAlarms       *bool           `yaml:"eval-acknowledged"`
+	AcknowledgedAlarmsMaxState       *string         `yaml:"acknowledged-max-state"`
+	AlarmTriggeredSince              *string         `yaml:"triggered-since"`
+	AlarmTriggeredBefore             *string         `yaml:"triggered-before"`
+	AlarmMaintenanceModeAction       *string         `yaml:"maintenance-mode-action"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface, flattening nested
+// sequences (e.g., from aliases of reusable lists) into a single list of
+// values.
+func (afl *alarmFilterList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.AliasNode:
+		return afl.UnmarshalYAML(value.Alias)
+
+	case yaml.ScalarNode:
+		if v := strings.TrimSpace(value.Value); v != "" {
+			*afl = append(*afl, v)
+		}
+
+		return nil
+
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if err := afl.UnmarshalYAML(item); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf(
+			"line %d: expected list or string value",
+			value.Line,
+		)
+	}
+}
+
+// LoadAlarmFilterFile reads triggered alarm filters from the specified YAML
+// file.
+func LoadAlarmFilterFile(filename string) (AlarmFilterFile, error) {
+	var filters AlarmFilterFile
+
+	// #nosec G304 -- file path is explicitly provided by the user
+	data, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return filters, fmt.Errorf(
+			"failed to read alarm filter file %q: %w",
+			filename,
+			err,
+		)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(&filters); err != nil {
+		return filters, fmt.Errorf(
+			"%w: failed to parse %q: %v",
+			ErrAlarmFilterFileInvalid,
+			filename,
+			err,
+		)
+	}
+
+	return filters, nil
+}
+
+// applyAlarmFilterFile loads the user-specified alarm filter file (if
+// provided) and combines the filter lists with those specified via
+// command-line flags. Settings specified via command-line flags have
+// precedence over the same settings specified in the file. This is performed
+// prior to validation so that file values are subject to the same validation
+// as command-line values.
+func (c *Config) applyAlarmFilterFile() error {
+	if c.AlarmFilterFile == "" {
+		return nil
+	}
+
+	filters, err := LoadAlarmFilterFile(c.AlarmFilterFile)
+	if err != nil {
+		return err
+	}
+
+	c.IncludedAlarmEntityTypes = append(c.IncludedAlarmEntityTypes, filters.IncludedAlarmEntityTypes...)
+	c.ExcludedAlarmEntityTypes = append(c.ExcludedAlarmEntityTypes, filters.ExcludedAlarmEntityTypes...)
+	c.IncludedAlarmEntityNames = append(c.IncludedAlarmEntityNames, filters.IncludedAlarmEntityNames...)
+	c.ExcludedAlarmEntityNames = append(c.ExcludedAlarmEntityNames, filters.ExcludedAlarmEntityNames...)
+	c.IncludedAlarmEntityResourcePools = append(c.IncludedAlarmEntityResourcePools, filters.IncludedAlarmEntityResourcePools...)
+	c.ExcludedAlarmEntityResourcePools = append(c.ExcludedAlarmEntityResourcePools, filters.ExcludedAlarmEntityResourcePools...)
+	c.IncludedAlarmNames = append(c.IncludedAlarmNames, filters.IncludedAlarmNames...)
+	c.ExcludedAlarmNames = append(c.ExcludedAlarmNames, filters.ExcludedAlarmNames...)
+	c.IncludedAlarmDescriptions = append(c.IncludedAlarmDescriptions, filters.IncludedAlarmDescriptions...)
+	c.ExcludedAlarmDescriptions = append(c.ExcludedAlarmDescriptions, filters.ExcludedAlarmDescriptions...)
+	c.includedAlarmStatuses = append(c.includedAlarmStatuses, filters.IncludedAlarmStatuses...)
+	c.excludedAlarmStatuses = append(c.excludedAlarmStatuses, filters.ExcludedAlarmStatuses...)
+	c.IncludedTags = append(c.IncludedTags, filters.IncludedTags...)
+	c.ExcludedTags = append(c.ExcludedTags, filters.ExcludedTags...)
+
+	// Only apply single value settings from the file if not explicitly set
+	// via the command-line.
+	flagsSet := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		flagsSet[f.Name] = true
+	})
+
+	if filters.EvaluateAcknowledgedAlarms != nil && !flagsSet[AlarmEvalAcknowledgedFlagLong] {
+		c.EvaluateAcknowledgedAlarms = *filters.EvaluateAcknowledgedAlarms
+	}
+
+	if filters.AcknowledgedAlarmsMaxState != nil && !flagsSet[AlarmAcknowledgedMaxStateFlagLong] {
+		c.AcknowledgedAlarmsMaxState = *filters.AcknowledgedAlarmsMaxState
+	}
+
+	if filters.AlarmTriggeredSince != nil && !flagsSet[AlarmTriggeredSinceFlagLong] {
+		c.AlarmTriggeredSince = *filters.AlarmTriggeredSince
+	}
+
+	if filters.AlarmTriggeredBefore != nil && !flagsSet[AlarmTriggeredBeforeFlagLong] {
+		c.AlarmTriggeredBefore = *filters.AlarmTriggeredBefore
+	}
+
+	if filters.AlarmMaintenanceModeAction != nil && !flagsSet[AlarmMaintenanceModeFlagLong] {
+		c.AlarmMaintenanceModeAction = *filters.AlarmMaintenanceModeAction
+	}
+
+	return nil
+}
+
+// parseAlarmTimeBoundary parses a triggered alarm time boundary value. The
+// value is either a (positive) duration relative to the given reference time
+// (e.g., 2h is interpreted as two hours before the reference time) or an
+// absolute RFC3339 timestamp. The zero value is returned for an empty value.
+func parseAlarmTimeBoundary(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf(
+				"%w: %q: duration must be greater than zero",
+				ErrInvalidAlarmTimeBoundary,
+				value,
+			)
+		}
+
+		return now.Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"%w: %q: expected duration (e.g., 2h) or RFC3339 timestamp",
+			ErrInvalidAlarmTimeBoundary,
+			value,
+		)
+	}
+
+	return t, nil
+}
+
+// validateAlarmTimeWindow asserts that the given triggered alarm time boundary
+// values are valid and, if both are specified, that the since value is
+// earlier than the before value.
+func validateAlarmTimeWindow(since string, before string, now time.Time) error {
+	triggeredSince, err := parseAlarmTimeBoundary(since, now)
+	if err != nil {
+		return fmt.Errorf(
+			"invalid %q value: %w",
+			AlarmTriggeredSinceFlagLong,
+			err,
+		)
+	}
+
+	triggeredBefore, err := parseAlarmTimeBoundary(before, now)
+	if err != nil {
+		return fmt.Errorf(
+			"invalid %q value: %w",
+			AlarmTriggeredBeforeFlagLong,
+			err,
+		)
+	}
+
+	if !triggeredSince.IsZero() && !triggeredBefore.IsZero() &&
+		!triggeredSince.Before(triggeredBefore) {
+		return fmt.Errorf(
+			"%w: %q value (%v) must be earlier than %q value (%v)",
+			ErrInvalidAlarmTimeBoundary,
+			AlarmTriggeredSinceFlagLong,
+			triggeredSince.Format(time.RFC3339),
+			AlarmTriggeredBeforeFlagLong,
+			triggeredBefore.Format(time.RFC3339),
+		)
+	}
+
+	return nil
+}
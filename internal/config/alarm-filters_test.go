@@ -0,0 +1,118 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseAlarmTimeBoundary(t *testing.T) {
+
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// value is the user-specified time boundary value
+		value string
+
+		// wantErr indicates whether parsing is expected to fail
+		wantErr bool
+
+		// want is the expected point in time
+		want time.Time
+	}{
+		{testName: "empty value", value: "", want: time.Time{}},
+		{testName: "whitespace only", value: "  ", want: time.Time{}},
+		{testName: "duration minutes", value: "30m", want: now.Add(-30 * time.Minute)},
+		{testName: "duration hours and minutes", value: "1h30m", want: now.Add(-90 * time.Minute)},
+		{testName: "duration surrounding whitespace", value: " 2h ", want: now.Add(-2 * time.Hour)},
+		{testName: "zero duration", value: "0s", wantErr: true},
+		{testName: "negative duration", value: "-2h", wantErr: true},
+		{testName: "absolute UTC timestamp", value: "2021-05-31T15:04:05Z", want: time.Date(2021, time.May, 31, 15, 4, 5, 0, time.UTC)},
+		{testName: "absolute timestamp with offset", value: "2021-05-31T15:04:05-05:00", want: time.Date(2021, time.May, 31, 20, 4, 5, 0, time.UTC)},
+		{testName: "date without time", value: "2021-05-31", wantErr: true},
+		{testName: "unit without number", value: "h", wantErr: true},
+		{testName: "days are not a duration unit", value: "2d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			got, err := parseAlarmTimeBoundary(tt.value, now)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("want error; got nil (value %v)", got)
+			case tt.wantErr && !errors.Is(err, ErrInvalidAlarmTimeBoundary):
+				t.Fatalf("want %v error; got %v", ErrInvalidAlarmTimeBoundary, err)
+			case tt.wantErr:
+				t.Logf("Got expected error: %v", err)
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateAlarmTimeWindow(t *testing.T) {
+
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// since is the user-specified start of the time window
+		since string
+
+		// before is the user-specified end of the time window
+		before string
+
+		// wantErr indicates whether validation is expected to fail
+		wantErr bool
+	}{
+		{testName: "no time window"},
+		{testName: "since only", since: "2h"},
+		{testName: "before only", before: "15m"},
+		{testName: "since earlier than before", since: "2h", before: "15m"},
+		{testName: "absolute since earlier than before", since: "2021-05-31T00:00:00Z", before: "2021-05-31T12:00:00Z"},
+		{testName: "mixed duration and timestamp", since: "2021-05-31T00:00:00Z", before: "15m"},
+		{testName: "since later than before", since: "15m", before: "2h", wantErr: true},
+		{testName: "since equal to before", since: "1h", before: "60m", wantErr: true},
+		{testName: "absolute since later than before", since: "2021-05-31T12:00:00Z", before: "2021-05-31T00:00:00Z", wantErr: true},
+		{testName: "invalid since", since: "yesterday", before: "15m", wantErr: true},
+		{testName: "invalid before", since: "2h", before: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := validateAlarmTimeWindow(tt.since, tt.before, now)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Errorf("want error; got nil")
+			case tt.wantErr && !errors.Is(err, ErrInvalidAlarmTimeBoundary):
+				t.Errorf("want %v error; got %v", ErrInvalidAlarmTimeBoundary, err)
+			case tt.wantErr:
+				t.Logf("Got expected error: %v", err)
+			case err != nil:
+				t.Errorf("want nil error; got %v", err)
+			}
+		})
+	}
+}
@@ -29,6 +29,38 @@ func getTriggeredAlarmStatuses() map[string]string {
 	}
 }
 
+// supportedAcknowledgedAlarmsMaxStates returns the Nagios state keywords
+// which may be used to limit the state of acknowledged triggered alarms.
+func supportedAcknowledgedAlarmsMaxStates() []string {
+	return []string{
+		AlarmStatusOk,
+		AlarmStatusWarning,
+		AlarmStatusCritical,
+	}
+}
+
+// supportedAlarmMaintenanceModeActions returns the keywords which may be
+// used to specify how triggered alarms associated with entities in
+// maintenance mode are handled.
+func supportedAlarmMaintenanceModeActions() []string {
+	return []string{
+		AlarmMaintenanceModeActionEvaluate,
+		AlarmMaintenanceModeActionExclude,
+		AlarmStatusWarning,
+		AlarmStatusOk,
+	}
+}
+
+// supportedAlarmActionObjectTypes returns the inventory object type keywords
+// which may be evaluated for disabled alarm actions.
+func supportedAlarmActionObjectTypes() []string {
+	return []string{
+		AlarmActionObjectTypeDatacenter,
+		AlarmActionObjectTypeCluster,
+		AlarmActionObjectTypeHost,
+	}
+}
+
 // setAlarmStatuses evaluates user-provided triggered alarm status keywords
 // and assigns a list of valid/equivalent (and de-duplicated)
 // ManagedEntityStatus keywords to exported fields for later use. This method
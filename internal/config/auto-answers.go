@@ -0,0 +1,110 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AutoAnswer is a user-specified PATTERN=ANSWER mapping used to
+// automatically answer matching interactive questions.
+type AutoAnswer struct {
+
+	// Pattern is the case-insensitive regular expression as specified by
+	// the user.
+	Pattern string
+
+	// Regexp is the compiled (case-insensitive) form of Pattern.
+	Regexp *regexp.Regexp
+
+	// Answer is the label or key of the possible answer to apply.
+	Answer string
+}
+
+// AutoAnswerFlag is a custom type that satisfies the flag.Value interface.
+// This type is used to accept interactive question auto-answer mappings in
+// PATTERN=ANSWER format (e.g., msg.uuid.altered=I Moved It). Patterns are
+// case-insensitive regular expressions matched against question details and
+// answers are the answers to apply. The flag may be repeated; mappings are
+// kept in the order specified. A later mapping for the same pattern replaces
+// the answer of the earlier one without changing its position.
+type AutoAnswerFlag []AutoAnswer
+
+// String returns a comma separated string consisting of all mappings in
+// PATTERN=ANSWER format in the order specified.
+func (aaf *AutoAnswerFlag) String() string {
+
+	// From the `flag` package docs:
+	// "The flag package may call the String method with a zero-valued
+	// receiver, such as a nil pointer."
+	if aaf == nil {
+		return ""
+	}
+
+	items := make([]string, 0, len(*aaf))
+	for _, aa := range *aaf {
+		items = append(items, fmt.Sprintf("%s=%s", aa.Pattern, aa.Answer))
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present. Unlike other list flags, a single mapping is accepted per
+// flag as question text patterns may contain commas. The last equals sign
+// separates the pattern from the answer.
+func (aaf *AutoAnswerFlag) Set(value string) error {
+
+	// Only strip surrounding quotes; question text may contain apostrophes.
+	item := strings.Trim(strings.TrimSpace(value), `'"`)
+
+	i := strings.LastIndex(item, "=")
+	if i < 0 {
+		return fmt.Errorf(
+			"error processing flag; string %q not in PATTERN=ANSWER format",
+			item,
+		)
+	}
+
+	pattern := strings.TrimSpace(item[:i])
+	answer := strings.TrimSpace(item[i+1:])
+
+	if pattern == "" || answer == "" {
+		return fmt.Errorf(
+			"error processing flag; empty pattern or answer in %q",
+			item,
+		)
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return fmt.Errorf(
+			"error processing flag; invalid pattern %q: %w",
+			pattern,
+			err,
+		)
+	}
+
+	for idx := range *aaf {
+		if (*aaf)[idx].Pattern == pattern {
+			(*aaf)[idx].Answer = answer
+
+			return nil
+		}
+	}
+
+	*aaf = append(*aaf, AutoAnswer{
+		Pattern: pattern,
+		Regexp:  re,
+		Answer:  answer,
+	})
+
+	return nil
+}
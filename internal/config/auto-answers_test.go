@@ -0,0 +1,115 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAutoAnswerFlagSet(t *testing.T) {
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// values is the collection of flag values in command line order
+		values []string
+
+		// wantErr indicates whether setting a flag value is expected to fail
+		wantErr bool
+
+		// want is the expected collection of mappings in PATTERN=ANSWER
+		// format
+		want []string
+	}{
+		{
+			testName: "order specified is kept",
+			values: []string{
+				"msg.uuid.altered=I Moved It",
+				"^copied=I Copied It",
+				"cdrom=Yes",
+			},
+			want: []string{
+				"msg.uuid.altered=I Moved It",
+				"^copied=I Copied It",
+				"cdrom=Yes",
+			},
+		},
+		{
+			testName: "repeated pattern replaces answer in place",
+			values: []string{
+				"msg.uuid.altered=I Moved It",
+				"cdrom=Yes",
+				"msg.uuid.altered=I Copied It",
+			},
+			want: []string{
+				"msg.uuid.altered=I Copied It",
+				"cdrom=Yes",
+			},
+		},
+		{
+			testName: "last equals sign separates answer",
+			values:   []string{"'a=b=Cancel'"},
+			want:     []string{"a=b=Cancel"},
+		},
+		{
+			testName: "missing separator",
+			values:   []string{"msg.uuid.altered"},
+			wantErr:  true,
+		},
+		{
+			testName: "empty answer",
+			values:   []string{"msg.uuid.altered="},
+			wantErr:  true,
+		},
+		{
+			testName: "invalid pattern",
+			values:   []string{"msg.(uuid=I Moved It"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var aaf AutoAnswerFlag
+
+			var err error
+			for _, v := range tt.values {
+				if err = aaf.Set(v); err != nil {
+					break
+				}
+			}
+
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("want error; got nil")
+			case tt.wantErr:
+				t.Logf("Got expected error: %v", err)
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			got := make([]string, 0, len(aaf))
+			for _, aa := range aaf {
+				if aa.Regexp == nil {
+					t.Errorf("want compiled pattern for %q; got nil", aa.Pattern)
+				}
+				got = append(got, aa.Pattern+"="+aa.Answer)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configFileTOMLExtension is the file extension used to identify TOML
+// configuration files.
+const configFileTOMLExtension string = ".toml"
+
+// tomlScalar converts the given decoded TOML string, integer, float or
+// boolean value to the equivalent command-line flag value.
+func tomlScalar(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []any:
+		return "", fmt.Errorf("nested arrays are not supported")
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// tomlValues converts the given decoded TOML value to one or more
+// command-line flag values. Each element of an array is returned as a
+// separate value so that arrays may be used for repeatable flags.
+func tomlValues(value any) ([]string, error) {
+	elements, ok := value.([]any)
+	if !ok {
+		v, err := tomlScalar(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{v}, nil
+	}
+
+	values := make([]string, 0, len(elements))
+	for _, element := range elements {
+		v, err := tomlScalar(element)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// tomlLookup returns the decoded value for the given key.
+func tomlLookup(raw map[string]any, key toml.Key) any {
+	var value any = raw
+	for _, part := range key {
+		table, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = table[part]
+	}
+
+	return value
+}
+
+// parseTOML parses settings from the given TOML configuration file content.
+// Tables are used as sections with the parts of nested table names (e.g.,
+// [profile.nightly-backups]) joined using a period. String, integer, float
+// and boolean values are supported. Array values are recorded as one
+// setting per element. Arrays of tables and date/time values are not
+// supported.
+//
+// The TOML decoder does not report the position of decoded keys, so line
+// numbers are not recorded for TOML settings.
+func (cf *ConfigFile) parseTOML(filename string, data []byte) error {
+	var raw map[string]any
+
+	md, err := toml.Decode(string(data), &raw)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: %q: %v",
+			ErrConfigFileInvalid,
+			filename,
+			err,
+		)
+	}
+
+	// Keys are returned in file order, each table before the keys within
+	// it.
+	for _, key := range md.Keys() {
+		switch md.Type(key...) {
+		case "Hash":
+			cf.addSection(strings.ToLower(strings.Join(key, ".")))
+			continue
+
+		case "ArrayHash":
+			return fmt.Errorf(
+				"%w: %q: key %q: arrays of tables are not supported",
+				ErrConfigFileInvalid,
+				filename,
+				key.String(),
+			)
+		}
+
+		// Keys within an array of tables are reported with the array's
+		// key and are rejected above.
+		section := configFileDefaultSection
+		if len(key) > 1 {
+			section = strings.ToLower(strings.Join(key[:len(key)-1], "."))
+		}
+		name := strings.ToLower(key[len(key)-1])
+
+		values, err := tomlValues(tomlLookup(raw, key))
+		if err != nil {
+			return fmt.Errorf(
+				"%w: %q: key %q: %v",
+				ErrConfigFileInvalid,
+				filename,
+				key.String(),
+				err,
+			)
+		}
+
+		cf.addSection(section)
+		for _, v := range values {
+			cf.addSetting(section, name, v, 0)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ErrConfigFileInvalid indicates that a configuration file is invalid.
+var ErrConfigFileInvalid = errors.New("invalid configuration file")
+
+// configFileDefaultSection is the name of the configuration file section
+// containing settings shared by all plugins. Settings specified before the
+// first section header are also part of this section.
+const configFileDefaultSection string = "default"
+
+// configFileSetting is a single key/value pair loaded from a configuration
+// file. The key is the long name of the equivalent command-line flag.
+type configFileSetting struct {
+	Name    string
+	Value   string
+	Section string
+	Line    int
+}
+
+// position returns a description of where the setting was specified for use
+// in error messages. The line number is used if known, otherwise the
+// section name.
+func (s configFileSetting) position() string {
+	if s.Line > 0 {
+		return fmt.Sprintf("line %d", s.Line)
+	}
+
+	return fmt.Sprintf("section [%s]", s.Section)
+}
+
+// ConfigFile is the collection of settings loaded from a user-specified
+// INI-style or TOML configuration file. Settings in the default section
+// apply to all plugins while settings in a section named after a plugin
+// (e.g., [snapshots-age]) apply only to that plugin and replace default
+// section settings of the same name. Sections named with a profile. prefix (e.g.,
+// [profile.nightly-backups]) define threshold profiles.
+type ConfigFile struct {
+	Sections map[string][]configFileSetting
+
+	// sectionOrder is the list of section names in the order first
+	// encountered in the file.
+	sectionOrder []string
+}
+
+// unquote removes matching leading and trailing single or double quotes from
+// the given value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
+
+// addSection records the given section name, preserving the order in which
+// sections are first encountered.
+func (cf *ConfigFile) addSection(section string) {
+	if _, ok := cf.Sections[section]; !ok {
+		cf.Sections[section] = nil
+		cf.sectionOrder = append(cf.sectionOrder, section)
+	}
+}
+
+// addSetting records the given setting for the specified section.
+func (cf *ConfigFile) addSetting(section string, name string, value string, line int) {
+	cf.Sections[section] = append(cf.Sections[section], configFileSetting{
+		Name:    name,
+		Value:   value,
+		Section: section,
+		Line:    line,
+	})
+}
+
+// LoadConfigFile reads settings from the specified configuration file.
+// Files with a .toml extension are parsed as TOML, all other files are
+// parsed as INI-style files.
+func LoadConfigFile(filename string) (ConfigFile, error) {
+	cf := ConfigFile{
+		Sections: make(map[string][]configFileSetting),
+	}
+
+	// #nosec G304 -- file path is explicitly provided by the user
+	data, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return cf, fmt.Errorf(
+			"failed to read configuration file %q: %w",
+			filename,
+			err,
+		)
+	}
+
+	switch {
+	case strings.EqualFold(filepath.Ext(filename), configFileTOMLExtension):
+		err = cf.parseTOML(filename, data)
+	default:
+		err = cf.parseINI(filename, data)
+	}
+
+	return cf, err
+}
+
+// parseINI parses settings from the given INI-style configuration file
+// content. Blank lines and lines beginning with a # or ; character are
+// ignored. Inline comments are not supported.
+func (cf *ConfigFile) parseINI(filename string, data []byte) error {
+	section := configFileDefaultSection
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	var lineNum int
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "",
+			strings.HasPrefix(line, "#"),
+			strings.HasPrefix(line, ";"):
+			continue
+
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return fmt.Errorf(
+					"%w: %q: line %d: invalid section header %q",
+					ErrConfigFileInvalid,
+					filename,
+					lineNum,
+					line,
+				)
+			}
+
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			cf.addSection(section)
+
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !found || name == "" {
+			return fmt.Errorf(
+				"%w: %q: line %d: expected name = value pair",
+				ErrConfigFileInvalid,
+				filename,
+				lineNum,
+			)
+		}
+
+		cf.addSetting(section, name, unquote(strings.TrimSpace(value)), lineNum)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf(
+			"%w: failed to parse %q: %v",
+			ErrConfigFileInvalid,
+			filename,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Settings returns the settings applicable to the specified plugin. Default
+// section settings are returned first, omitting any setting also specified
+// in the plugin section. Plugin section settings are returned last.
+func (cf ConfigFile) Settings(pluginLabel string) []configFileSetting {
+	pluginSettings := cf.Sections[strings.ToLower(pluginLabel)]
+
+	overridden := make(map[string]bool, len(pluginSettings))
+	for _, setting := range pluginSettings {
+		overridden[setting.Name] = true
+	}
+
+	settings := make([]configFileSetting, 0, len(cf.Sections[configFileDefaultSection])+len(pluginSettings))
+	for _, setting := range cf.Sections[configFileDefaultSection] {
+		if !overridden[setting.Name] {
+			settings = append(settings, setting)
+		}
+	}
+
+	return append(settings, pluginSettings...)
+}
+
+// flagTarget returns the address of the variable bound to the given flag.
+// Short and long variants of a flag share the same variable and therefore
+// the same address. Zero is returned if the address cannot be determined.
+func flagTarget(f *flag.Flag) uintptr {
+	v := reflect.ValueOf(f.Value)
+	if v.Kind() != reflect.Pointer {
+		return 0
+	}
+
+	return v.Pointer()
+}
+
+// applyConfigFile loads the user-specified configuration file (if provided)
+// and applies settings applicable to the given plugin type. Settings
+// specified via command-line flags (long or short variant) have precedence
+// over the same settings specified in the file. Default section settings
+// for flags not supported by the plugin are ignored. This is performed
+// prior to validation so that file values are subject to the same
+// validation as command-line values.
+func (c *Config) applyConfigFile(pluginType PluginType) error {
+	if c.ConfigFile == "" {
+		return nil
+	}
+
+	cf, err := LoadConfigFile(c.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	c.configFile = &cf
+
+	flagsSet := make(map[string]bool)
+	targetsSet := make(map[uintptr]bool)
+	flag.Visit(func(f *flag.Flag) {
+		flagsSet[f.Name] = true
+		if target := flagTarget(f); target != 0 {
+			targetsSet[target] = true
+		}
+	})
+
+	// A credential may be specified directly or via a file, but not both.
+	// Each pair of flags is treated as a single setting so that a
+	// credential specified on the command-line in either form takes
+	// precedence over the same credential specified in the file in either
+	// form.
+	credentialPairs := map[string]string{
+		UsernameFlagLong:     UsernameFileFlagLong,
+		UsernameFileFlagLong: UsernameFlagLong,
+		PasswordFlagLong:     PasswordFileFlagLong,
+		PasswordFileFlagLong: PasswordFlagLong,
+	}
+	for name, partnerName := range credentialPairs {
+		f, partner := flag.Lookup(name), flag.Lookup(partnerName)
+		if f == nil || partner == nil {
+			continue
+		}
+
+		if flagsSet[f.Name] || targetsSet[flagTarget(f)] {
+			flagsSet[partner.Name] = true
+			if target := flagTarget(partner); target != 0 {
+				targetsSet[target] = true
+			}
+		}
+	}
+
+	for _, setting := range cf.Settings(pluginTypeLabel(pluginType)) {
+		f := flag.Lookup(setting.Name)
+
+		switch {
+		case setting.Name == ConfigFileFlagLong:
+			return fmt.Errorf(
+				"%w: %q: %s: flag %q not supported in configuration file",
+				ErrConfigFileInvalid,
+				c.ConfigFile,
+				setting.position(),
+				setting.Name,
+			)
+
+		case f == nil && setting.Section == configFileDefaultSection:
+			continue
+
+		case f == nil:
+			return fmt.Errorf(
+				"%w: %q: %s: flag %q not supported by this plugin",
+				ErrConfigFileInvalid,
+				c.ConfigFile,
+				setting.position(),
+				setting.Name,
+			)
+
+		case flagsSet[f.Name], targetsSet[flagTarget(f)]:
+			continue
+		}
+
+		if err := flag.Set(setting.Name, setting.Value); err != nil {
+			return fmt.Errorf(
+				"%w: %q: %s: failed to set flag %q: %v",
+				ErrConfigFileInvalid,
+				c.ConfigFile,
+				setting.position(),
+				setting.Name,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
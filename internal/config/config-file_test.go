@@ -0,0 +1,318 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// sectionValues flattens the given configuration file settings into values
+// indexed by section and setting name for comparison.
+func sectionValues(cf ConfigFile) map[string]map[string][]string {
+	got := make(map[string]map[string][]string)
+	for section, settings := range cf.Sections {
+		got[section] = make(map[string][]string)
+		for _, setting := range settings {
+			got[section][setting.Name] = append(got[section][setting.Name], setting.Value)
+		}
+	}
+
+	return got
+}
+
+func TestLoadConfigFileTOML(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    map[string]map[string][]string
+		wantErr bool
+	}{
+		"default and plugin sections": {
+			content: `# shared settings
+server = "vc1.example.com"
+port = 443
+trust-cert = true
+
+[snapshots-age]
+ignore-vm = ["build1", 'build2'] # inline comment
+age-warning = 2.5
+`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {
+					"server":     {"vc1.example.com"},
+					"port":       {"443"},
+					"trust-cert": {"true"},
+				},
+				"snapshots-age": {
+					"ignore-vm":   {"build1", "build2"},
+					"age-warning": {"2.5"},
+				},
+			},
+		},
+		"dotted table and multi-line array": {
+			content: `[profile.nightly-backups]
+days = [
+  "Mon", # first
+  "Tue",
+]
+start = "22:00"
+"DS-Read-Latency-Warning" = 40
+`,
+			want: map[string]map[string][]string{
+				"profile.nightly-backups": {
+					"days":                    {"Mon", "Tue"},
+					"start":                   {"22:00"},
+					"ds-read-latency-warning": {"40"},
+				},
+			},
+		},
+		"dotted key": {
+			content: `snapshots-age.age-warning = 3`,
+			want: map[string]map[string][]string{
+				"snapshots-age": {"age-warning": {"3"}},
+			},
+		},
+		"hash character in quoted string": {
+			content: `password = "se#cret\"x"`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {"password": {`se#cret"x`}},
+			},
+		},
+		"unquoted string":      {content: `server = vc1.example.com`, wantErr: true},
+		"array of tables":      {content: "[[servers]]\nname = \"vc1\"", wantErr: true},
+		"nested array":         {content: `ignore-vm = [["a"]]`, wantErr: true},
+		"date value":           {content: `start = 2021-06-01`, wantErr: true},
+		"unterminated array":   {content: `ignore-vm = ["build1",`, wantErr: true},
+		"octal style integer":  {content: `age-critical = 010`, wantErr: true},
+		"duplicate definition": {content: "port = 1\nport = 2", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(filename, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			cf, err := LoadConfigFile(filename)
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfigFileInvalid) {
+					t.Fatalf("want %v error; got %v", ErrConfigFileInvalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if d := cmp.Diff(tt.want, sectionValues(cf)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    map[string]map[string][]string
+		wantErr bool
+	}{
+		"default and plugin sections": {
+			content: `; shared settings
+server = vc1.example.com
+Trust-Cert = true
+
+[ Snapshots-Age ]
+# repeatable flags may be listed more than once
+ignore-vm = build1
+ignore-vm = "build 2"
+age-warning='2'
+`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {
+					"server":     {"vc1.example.com"},
+					"trust-cert": {"true"},
+				},
+				"snapshots-age": {
+					"ignore-vm":   {"build1", "build 2"},
+					"age-warning": {"2"},
+				},
+			},
+		},
+		"equals sign in value": {
+			content: `password = a=b`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {"password": {"a=b"}},
+			},
+		},
+		"inline comments are part of the value": {
+			content: `server = vc1 # primary`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {"server": {"vc1 # primary"}},
+			},
+		},
+		"mismatched quotes are kept": {
+			content: `password = "secret'`,
+			want: map[string]map[string][]string{
+				configFileDefaultSection: {"password": {`"secret'`}},
+			},
+		},
+		"empty section": {
+			content: "[vcpus-allocation]\n",
+			want: map[string]map[string][]string{
+				"vcpus-allocation": {},
+			},
+		},
+		"unterminated section header": {content: "[snapshots-age", wantErr: true},
+		"empty section header":        {content: "[]", wantErr: true},
+		"missing equals sign":         {content: "trust-cert", wantErr: true},
+		"missing name":                {content: "= true", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cf := ConfigFile{Sections: make(map[string][]configFileSetting)}
+
+			err := cf.parseINI("test.ini", []byte(tt.content))
+			if tt.wantErr {
+				if !errors.Is(err, ErrConfigFileInvalid) {
+					t.Fatalf("want %v error; got %v", ErrConfigFileInvalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if d := cmp.Diff(tt.want, sectionValues(cf)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestParseINILineNumbers(t *testing.T) {
+	cf := ConfigFile{Sections: make(map[string][]configFileSetting)}
+
+	content := "# comment\n\nserver = vc1\n[snapshots-age]\nage-warning = 2\n"
+	if err := cf.parseINI("test.ini", []byte(content)); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if got := cf.Sections[configFileDefaultSection][0].Line; got != 3 {
+		t.Errorf("want server on line 3; got %d", got)
+	}
+
+	if got := cf.Sections["snapshots-age"][0].position(); got != "line 5" {
+		t.Errorf("want %q; got %q", "line 5", got)
+	}
+
+	if got := (configFileSetting{Section: "snapshots-age"}).position(); got != "section [snapshots-age]" {
+		t.Errorf("want %q; got %q", "section [snapshots-age]", got)
+	}
+}
+
+func TestApplyConfigFileCredentialPrecedence(t *testing.T) {
+	dir := t.TempDir()
+
+	cliPasswordFile := filepath.Join(dir, "cli-password")
+	if err := os.WriteFile(cliPasswordFile, []byte("cli-secret\n"), 0o600); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	filePasswordFile := filepath.Join(dir, "file-password")
+	if err := os.WriteFile(filePasswordFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	tests := map[string]struct {
+		args         []string
+		content      string
+		wantPassword string
+		wantErr      bool
+	}{
+		"password file flag overrides password setting": {
+			args:         []string{"--" + PasswordFileFlagLong, cliPasswordFile},
+			content:      "password = file-secret\n",
+			wantPassword: "cli-secret",
+		},
+		"password flag overrides password file setting": {
+			args:         []string{"--" + PasswordFlagLong, "cli-secret"},
+			content:      "password-file = " + filePasswordFile + "\n",
+			wantPassword: "cli-secret",
+		},
+		"short password flag overrides password file setting": {
+			args:         []string{"-" + PasswordFlagShort, "cli-secret"},
+			content:      "password-file = " + filePasswordFile + "\n",
+			wantPassword: "cli-secret",
+		},
+		"password file setting used without flags": {
+			content:      "password-file = " + filePasswordFile + "\n",
+			wantPassword: "file-secret",
+		},
+		"both forms in file": {
+			content: "password = file-secret\npassword-file = " + filePasswordFile + "\n",
+			wantErr: true,
+		},
+		"both forms on command-line": {
+			args:    []string{"--" + PasswordFlagLong, "cli-secret", "--" + PasswordFileFlagLong, cliPasswordFile},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(PasswordEnvVar, "")
+			t.Setenv(PasswordFileEnvVar, "")
+
+			origFlags := flag.CommandLine
+			defer func() { flag.CommandLine = origFlags }()
+			flag.CommandLine = flag.NewFlagSet(name, flag.ContinueOnError)
+
+			var c Config
+			flag.StringVar(&c.Password, PasswordFlagLong, "", "")
+			flag.StringVar(&c.Password, PasswordFlagShort, "", "")
+			flag.StringVar(&c.PasswordFile, PasswordFileFlagLong, "", "")
+			flag.StringVar(&c.Username, UsernameFlagLong, "", "")
+			flag.StringVar(&c.UsernameFile, UsernameFileFlagLong, "", "")
+
+			if err := flag.CommandLine.Parse(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			c.ConfigFile = filepath.Join(t.TempDir(), "config.ini")
+			if err := os.WriteFile(c.ConfigFile, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to create test file: %v", err)
+			}
+
+			err := c.applyConfigFile(PluginType{SnapshotsAge: true})
+			if err == nil {
+				err = c.loadCredentials()
+			}
+
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("want error; got nil")
+			case tt.wantErr:
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if c.Password != tt.wantPassword {
+				t.Errorf("want password %q; got %q", tt.wantPassword, c.Password)
+			}
+		})
+	}
+}
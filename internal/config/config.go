@@ -12,7 +12,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -50,6 +52,66 @@ type PluginType struct {
 	Alarms                         bool
 	VirtualMachineLastBackupViaCA  bool
 	VirtualMachineList             bool
+	VSANHealth                     bool
+	HostServices                   bool
+	LicensingFeatureUsage          bool
+	VirtualMachineCPUReady         bool
+	FolderVMCounts                 bool
+	VirtualMachineMemoryPressure   bool
+	ClusterResourceUsage           bool
+	VASAProviderStatus             bool
+	ClusterHAStatus                bool
+	VVolDatastoreHealth            bool
+	ClusterDRSStatus               bool
+	HostHardwareSensors            bool
+	VirtualMachineFTLatency        bool
+	HostStoragePaths               bool
+	VirtualMachineToolsNoIP        bool
+	VCenterCertificates            bool
+	License                        bool
+	DatacenterInventoryDrift       bool
+	Tasks                          bool
+	HostESXiShellSSHEnabled        bool
+	HostDNSRouting                 bool
+	Events                         bool
+	SnapshotsDatastoreQuota        bool
+	VirtualMachineOrphanedDevices  bool
+	OrphanedVMDKs                  bool
+	NagiosGenConfig                bool
+	VirtualMachineConnectedMedia   bool
+	VirtualMachineGuestDiskUsage   bool
+	ResourcePoolRunawayVM          bool
+	VirtualMachineToolsVersion     bool
+	VirtualMachineCPUAffinity      bool
+	HostTimeDrift                  bool
+	ESXiImageProfileDrift          bool
+	VLCMCompliance                 bool
+	AlarmDefinitionsHash           bool
+	HostAcceleration               bool
+	VirtualMachineLastBackupViaTag bool
+	VirtualMachineCustomAttribute  bool
+	VCenterDatabaseHealth          bool
+	VCenterServiceStatus           bool
+	VMwareExporter                 bool
+	AlarmActionDisabled            bool
+	VirtualMachineIndependentDisks bool
+	VirtualMachineRDMUsage         bool
+	HostSRIOVPassthroughCapacity   bool
+	HostGPUAllocation              bool
+	ClusterDASIsolation            bool
+	DatastoresOvercommit           bool
+	StretchedClusterSiteBalance    bool
+	DatastoresAccessibility        bool
+	VirtualMachinePendingHWUpgrade bool
+	VirtualMachineRestoreDetection bool
+	HostRebootPending              bool
+	VirtualMachineTimeSyncPolicy   bool
+	DatastoresVMDensity            bool
+	SnapshotRemovalStalls          bool
+	VMMemoryAllocation             bool
+	VirtualMachineDiskUUIDEnabled  bool
+	HostConnectionState            bool
+	ClusterHAHostFailures          bool
 
 	// TODO:
 	// - vCenter/server time (NTP)
@@ -116,6 +178,63 @@ func (mvs *multiValueStringFlag) Set(value string) error {
 	return nil
 }
 
+// optionalIntFlag is a custom type that satisfies the flag.Value interface
+// in order to accept an integer value while tracking whether the value was
+// explicitly provided by the user.
+type optionalIntFlag struct {
+
+	// value is the user-specified value
+	value int
+
+	// isSet identifies whether a value was provided by the user
+	isSet bool
+}
+
+// String satisfies the flag.Value interface method set requirements.
+func (oif *optionalIntFlag) String() string {
+
+	// The String() method is called by the flag.isZeroValue function in order
+	// to determine whether the output string represents the zero value for a
+	// flag. This occurs even if the flag is not specified by the user.
+
+	if oif == nil || !oif.isSet {
+		return ""
+	}
+
+	return strconv.Itoa(oif.value)
+}
+
+// Set satisfies the flag.Value interface method set requirements.
+func (oif *optionalIntFlag) Set(value string) error {
+
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, "'", "")
+	value = strings.ReplaceAll(value, "\"", "")
+
+	parsedVal, strConvErr := strconv.Atoi(value)
+	if strConvErr != nil {
+		return fmt.Errorf(
+			"error processing flag; failed to convert %q: %v",
+			value,
+			strConvErr,
+		)
+	}
+
+	oif.value = parsedVal
+	oif.isSet = true
+
+	return nil
+}
+
+// FolderVMCountThresholds represents the thresholds used to evaluate the
+// number of VMs in a folder. Thresholds not specified by the user are nil.
+type FolderVMCountThresholds struct {
+	MaxWarning  *int
+	MaxCritical *int
+	MinWarning  *int
+	MinCritical *int
+}
+
 // Config represents the application configuration as specified via
 // command-line flags.
 type Config struct {
@@ -136,6 +255,20 @@ type Config struct {
 	// host or vCenter instance.
 	Domain string
 
+	// UsernameFile is the fully-qualified path to an optional file
+	// containing the username used to login to the ESXi host or vCenter
+	// instance.
+	UsernameFile string
+
+	// PasswordFile is the fully-qualified path to an optional file
+	// containing the password used to login to the ESXi host or vCenter
+	// instance.
+	PasswordFile string
+
+	// ConfigFile is the fully-qualified path to an optional INI-style
+	// configuration file containing settings shared by multiple plugins.
+	ConfigFile string
+
 	// ClusterName is the name of the vSphere cluster where monitored objects
 	// reside.
 	ClusterName string
@@ -188,6 +321,11 @@ type Config struct {
 	// vSphere inventory of the specified ESXi host or vCenter instance.
 	DatastoreName string
 
+	// AllDatastores indicates whether all datastores within the specified
+	// datacenter (or cluster) are evaluated instead of a single named
+	// datastore.
+	AllDatastores bool
+
 	// DatacenterName is the name of a Datacenter in the associated vSphere
 	// inventory. This field is used by plugins which support monitoring only
 	// a single Datacenter. Not applicable to standalone ESXi hosts.
@@ -203,6 +341,58 @@ type Config struct {
 	// vSphere inventory.
 	HostSystemName string
 
+	// ListObjects indicates whether the names of candidate objects (e.g.,
+	// datastores, hosts) should be listed instead of performing an
+	// evaluation. This is intended to help with building monitoring
+	// configuration.
+	ListObjects bool
+
+	// ListObjectsPattern is an optional (case-insensitive) glob pattern used
+	// to filter the object names listed when ListObjects is enabled.
+	ListObjectsPattern string
+
+	// GenConfigHostPattern is an optional (case-insensitive) glob pattern
+	// used to filter the ESXi hosts included in generated Nagios object
+	// definitions.
+	GenConfigHostPattern string
+
+	// GenConfigDatastorePattern is an optional (case-insensitive) glob
+	// pattern used to filter the datastores included in generated Nagios
+	// object definitions.
+	GenConfigDatastorePattern string
+
+	// GenConfigClusterPattern is an optional (case-insensitive) glob pattern
+	// used to filter the clusters included in generated Nagios object
+	// definitions.
+	GenConfigClusterPattern string
+
+	// GenConfigTemplateFile is the optional path to a text/template file
+	// used to render Nagios object definitions. If not specified, a built-in
+	// template is used.
+	GenConfigTemplateFile string
+
+	// GenConfigOutputFile is the optional path to a file where rendered
+	// Nagios object definitions are written. If not specified, output is
+	// written to stdout.
+	GenConfigOutputFile string
+
+	// GenConfigNagiosHost is the Nagios host_name that generated service
+	// definitions are associated with. If not specified, the server value
+	// is used.
+	GenConfigNagiosHost string
+
+	// GenConfigHostDefinition indicates whether a Nagios host definition for
+	// the vSphere environment is rendered along with service definitions.
+	GenConfigHostDefinition bool
+
+	// ExporterListenAddress is the TCP address (host:port) that the metrics
+	// exporter listens on.
+	ExporterListenAddress string
+
+	// exporterCollectionInterval is the number of seconds between
+	// collections of vSphere metrics by the metrics exporter.
+	exporterCollectionInterval int
+
 	// VMBackupDate specifies the Custom Attribute used by Virtual Machine
 	// backup software to record when the last backup occurred.
 	VMBackupDateCustomAttribute string
@@ -222,6 +412,42 @@ type Config struct {
 	// last backup occurred.
 	VMBackupDateTimezone string
 
+	// VMBackupTagCategory specifies the vSphere Tag category used to record
+	// when the last backup occurred. The backup date is read from the name of
+	// the tag attached to a Virtual Machine in this category.
+	VMBackupTagCategory string
+
+	// VMBackupTagPrefix specifies an optional prefix removed from the name of
+	// a backup date tag before the remaining value is parsed as a date.
+	VMBackupTagPrefix string
+
+	// VMCustomAttributeName specifies the name of the Custom Attribute
+	// evaluated for Virtual Machines.
+	VMCustomAttributeName string
+
+	// VMCustomAttributePattern specifies an optional regular expression
+	// which the Custom Attribute value must match.
+	VMCustomAttributePattern string
+
+	// VMCustomAttributeMin and VMCustomAttributeMax specify optional
+	// (inclusive) range bounds for the Custom Attribute value. Bounds are
+	// evaluated numerically unless a date format is specified.
+	VMCustomAttributeMin string
+	VMCustomAttributeMax string
+
+	// VMCustomAttributeDateFormat specifies an optional Go time layout used
+	// to parse the Custom Attribute value (and range bounds) as a date.
+	VMCustomAttributeDateFormat string
+
+	// VMMaintenanceCA is the name of an optional Custom Attribute used to
+	// record the end of a maintenance window for a VM. VMs with an active
+	// maintenance window are suppressed (excluded from evaluation).
+	VMMaintenanceCA string
+
+	// VMMaintenanceCADateFormat specifies the Go time layout used to parse
+	// the maintenance window Custom Attribute value.
+	VMMaintenanceCADateFormat string
+
 	// IncludedFolders lists folders that are explicitly monitored.
 	IncludedFolders multiValueStringFlag
 
@@ -238,6 +464,43 @@ type Config struct {
 	// or excluded from being monitored.
 	ExcludedResourcePools multiValueStringFlag
 
+	// IncludedDatacenters lists datacenters that are explicitly monitored.
+	// Specifying list values automatically excludes VirtualMachine objects
+	// outside of the specified Datacenters.
+	IncludedDatacenters multiValueStringFlag
+
+	// ExcludedDatacenters lists datacenters that are explicitly ignored or
+	// excluded from being monitored.
+	ExcludedDatacenters multiValueStringFlag
+
+	// IncludedClusters lists clusters that are explicitly monitored.
+	// Specifying list values automatically excludes VirtualMachine objects
+	// running on hosts outside of the specified Clusters.
+	IncludedClusters multiValueStringFlag
+
+	// ExcludedClusters lists clusters that are explicitly ignored or
+	// excluded from being monitored.
+	ExcludedClusters multiValueStringFlag
+
+	// IncludedHosts lists ESXi hosts that are explicitly monitored.
+	// Specifying list values automatically excludes VirtualMachine objects
+	// running on hosts other than those specified.
+	IncludedHosts multiValueStringFlag
+
+	// ExcludedHosts lists ESXi hosts that are explicitly ignored or excluded
+	// from being monitored.
+	ExcludedHosts multiValueStringFlag
+
+	// IncludedTags lists vSphere Tags (optionally qualified by category
+	// name) that are explicitly required for VMs (or triggered alarm
+	// entities) to be evaluated.
+	IncludedTags multiValueStringFlag
+
+	// ExcludedTags lists vSphere Tags (optionally qualified by category
+	// name) for VMs (or triggered alarm entities) that are explicitly
+	// ignored or excluded from being evaluated.
+	ExcludedTags multiValueStringFlag
+
 	// IgnoredVM is a list of VMs that are explicitly ignored or excluded
 	// from being monitored.
 	IgnoredVMs multiValueStringFlag
@@ -247,6 +510,95 @@ type Config struct {
 	// with its current host.
 	IgnoredDatastores multiValueStringFlag
 
+	// IncludedDatastores is a list of datastore names for Datastores that
+	// should be explicitly included for evaluation.
+	IncludedDatastores multiValueStringFlag
+
+	// PatternMatch is the pattern matching mode (exact, glob or regex) used
+	// when evaluating name based include/exclude lists (e.g., VM names,
+	// Resource Pools, Folders, alarm names, Datastores).
+	PatternMatch string
+
+	// IgnoredVMDKPaths is a list of datastore path substrings for VMDK files
+	// that are known-good and should be ignored when evaluating orphaned
+	// VMDK files.
+	IgnoredVMDKPaths multiValueStringFlag
+
+	// AllowedMediaVMs is a list of VirtualMachine names which are permitted
+	// to have connected CD-ROM or floppy media.
+	AllowedMediaVMs multiValueStringFlag
+
+	// AllowedMediaPaths is a list of ISO (or floppy image) path substrings
+	// which are permitted to remain connected to VirtualMachines.
+	AllowedMediaPaths multiValueStringFlag
+
+	// AllowedAffinityVMs is a list of VirtualMachine names which are
+	// permitted to have CPU or NUMA node affinity configured.
+	AllowedAffinityVMs multiValueStringFlag
+
+	// VMGuestDiskExcludedMounts is a list of case-insensitive glob patterns
+	// for guest filesystem mount points (or drive letters) that are excluded
+	// from evaluation.
+	VMGuestDiskExcludedMounts multiValueStringFlag
+
+	// VMGuestDiskMountThresholds is a collection of per-filesystem usage
+	// thresholds which override the default guest filesystem usage
+	// thresholds for matching mount points.
+	VMGuestDiskMountThresholds multiValueGuestDiskThresholdFlag
+
+	// IgnoredVSANHealthTests is a list of vSAN health test IDs or names
+	// that are explicitly ignored or excluded from evaluation.
+	IgnoredVSANHealthTests multiValueStringFlag
+
+	// requiredHostServices is a list of ESXi host service keys or labels
+	// that are required to be running.
+	requiredHostServices multiValueStringFlag
+
+	// disallowedHostServices is a list of ESXi host service keys or labels
+	// that are required to be disabled (not running and not configured to
+	// start with the host).
+	disallowedHostServices multiValueStringFlag
+
+	// requiredVCenterServices is a list of vCenter appliance service IDs
+	// that are required to be running and healthy.
+	requiredVCenterServices multiValueStringFlag
+
+	// ignoredVCenterServices is a list of vCenter appliance service IDs that
+	// are explicitly ignored or excluded from evaluation.
+	ignoredVCenterServices multiValueStringFlag
+
+	// alarmActionObjectTypes is a list of inventory object types (e.g.,
+	// cluster or host) evaluated for disabled alarm actions.
+	alarmActionObjectTypes multiValueStringFlag
+
+	// IgnoredAlarmActionObjects is a list of inventory object names that are
+	// explicitly ignored or excluded from evaluation of alarm actions.
+	IgnoredAlarmActionObjects multiValueStringFlag
+
+	// AllowedIndependentDiskVMs is a list of VirtualMachine names which are
+	// permitted to have independent (persistent or nonpersistent) disks.
+	AllowedIndependentDiskVMs multiValueStringFlag
+
+	// AllowedRDMVMs is a list of VirtualMachine names which are permitted to
+	// use Raw Device Mappings (RDMs).
+	AllowedRDMVMs multiValueStringFlag
+
+	// IncludedHostSensors is a list of ESXi host hardware sensor name
+	// substrings used to limit evaluation to matching sensors.
+	IncludedHostSensors multiValueStringFlag
+
+	// ExcludedHostSensors is a list of ESXi host hardware sensor name
+	// substrings used to exclude matching sensors from evaluation.
+	ExcludedHostSensors multiValueStringFlag
+
+	// LicenseFeatures is a list of licensed feature names used to limit
+	// evaluation to licenses providing one of the listed features.
+	LicenseFeatures multiValueStringFlag
+
+	// FolderIDs is a list of Folder Managed Object ID (MOID) values for
+	// folders whose VM counts are evaluated.
+	FolderIDs multiValueStringFlag
+
 	// IncludedAlarmEntityTypes is a list of entity types for Alarms that will
 	// be explicitly included for evaluation. Unless included by later
 	// filtering logic, unmatched Triggered Alarms will be excluded from final
@@ -344,6 +696,11 @@ type Config struct {
 	// explicit inclusions.
 	ExcludedAlarmStatuses multiValueStringFlag
 
+	// AlarmFilterFile is the fully-qualified path to an optional YAML file
+	// containing triggered alarm filters. Filter lists from the file are
+	// combined with those specified via command-line flags.
+	AlarmFilterFile string
+
 	// App represents common details about the plugins provided by this
 	// project.
 	App AppInfo
@@ -382,6 +739,521 @@ type Config struct {
 	// a whole number) when a WARNING threshold is reached.
 	VCPUsAllocatedWarning int
 
+	// LicenseUsageCritical specifies the percentage of license capacity
+	// used (as a whole number) when a CRITICAL threshold is reached.
+	LicenseUsageCritical int
+
+	// LicenseUsageWarning specifies the percentage of license capacity used
+	// (as a whole number) when a WARNING threshold is reached.
+	LicenseUsageWarning int
+
+	// VFUsageCritical specifies the percentage of SR-IOV virtual functions
+	// on a host demanded by VMs (as a whole number) when a CRITICAL
+	// threshold is reached.
+	VFUsageCritical int
+
+	// VFUsageWarning specifies the percentage of SR-IOV virtual functions on
+	// a host demanded by VMs (as a whole number) when a WARNING threshold is
+	// reached.
+	VFUsageWarning int
+
+	// GPUAllocationCritical specifies the percentage of GPU framebuffer
+	// memory on a host allocated to VMs (as a whole number) when a CRITICAL
+	// threshold is reached.
+	GPUAllocationCritical int
+
+	// GPUAllocationWarning specifies the percentage of GPU framebuffer
+	// memory on a host allocated to VMs (as a whole number) when a WARNING
+	// threshold is reached.
+	GPUAllocationWarning int
+
+	// GPUUtilizationCritical specifies the percentage of GPU utilization on
+	// a host (as a whole number) when a CRITICAL threshold is reached.
+	GPUUtilizationCritical int
+
+	// GPUUtilizationWarning specifies the percentage of GPU utilization on a
+	// host (as a whole number) when a WARNING threshold is reached.
+	GPUUtilizationWarning int
+
+	// DatastoreOvercommitCritical specifies the percentage of a datastore's
+	// capacity provisioned to VMs (as a whole number) when a CRITICAL
+	// threshold is reached.
+	DatastoreOvercommitCritical int
+
+	// DatastoreOvercommitWarning specifies the percentage of a datastore's
+	// capacity provisioned to VMs (as a whole number) when a WARNING
+	// threshold is reached.
+	DatastoreOvercommitWarning int
+
+	// LicenseExpiryCritical specifies the number of days remaining before a
+	// license expires when a CRITICAL threshold is reached.
+	LicenseExpiryCritical int
+
+	// LicenseExpiryWarning specifies the number of days remaining before a
+	// license expires when a WARNING threshold is reached.
+	LicenseExpiryWarning int
+
+	// InventoryDriftCritical specifies the number of inventory objects of
+	// any kind added or removed within a Datacenter between plugin runs when
+	// a CRITICAL threshold is reached.
+	InventoryDriftCritical int
+
+	// InventoryDriftWarning specifies the number of inventory objects of any
+	// kind added or removed within a Datacenter between plugin runs when a
+	// WARNING threshold is reached.
+	InventoryDriftWarning int
+
+	// InventoryStateFile is the fully-qualified path to the state file used
+	// to record inventory object counts between plugin runs.
+	InventoryStateFile string
+
+	// TasksLookback is the number of minutes prior to plugin execution
+	// evaluated for failed vCenter tasks.
+	TasksLookback int
+
+	// FailedTasksCritical specifies the number of failed tasks within the
+	// lookback window when a CRITICAL threshold is reached.
+	FailedTasksCritical int
+
+	// FailedTasksWarning specifies the number of failed tasks within the
+	// lookback window when a WARNING threshold is reached.
+	FailedTasksWarning int
+
+	// IncludedTaskDescriptionIDs is a list of task description IDs (e.g.,
+	// VirtualMachine.clone) that should be explicitly included for
+	// evaluation. Failed tasks not matching one of the listed IDs are
+	// ignored.
+	IncludedTaskDescriptionIDs multiValueStringFlag
+
+	// ExcludedTaskDescriptionIDs is a list of task description IDs (e.g.,
+	// VirtualMachine.clone) that should be explicitly excluded from
+	// evaluation.
+	ExcludedTaskDescriptionIDs multiValueStringFlag
+
+	// IncludedTaskUsers is a list of user names that should be explicitly
+	// included for evaluation. Failed tasks not initiated by one of the
+	// listed users are ignored.
+	IncludedTaskUsers multiValueStringFlag
+
+	// ExcludedTaskUsers is a list of user names that should be explicitly
+	// excluded from evaluation.
+	ExcludedTaskUsers multiValueStringFlag
+
+	// ShellSSHRunningCritical specifies the number of minutes that the ESXi
+	// Shell or SSH service may run on a host before a CRITICAL threshold is
+	// reached.
+	ShellSSHRunningCritical int
+
+	// RequireCBRC indicates whether Content-Based Read Cache (CBRC) is
+	// required to be enabled on evaluated hosts.
+	RequireCBRC bool
+
+	// RequireMemoryTiering indicates whether memory tiering (e.g., NVMe
+	// tiering) is required to be enabled on evaluated hosts which support
+	// it.
+	RequireMemoryTiering bool
+
+	// ShellSSHRunningWarning specifies the number of minutes that the ESXi
+	// Shell or SSH service may run on a host before a WARNING threshold is
+	// reached.
+	ShellSSHRunningWarning int
+
+	// AllowedShellSSHHosts is a list of ESXi host names permitted to run the
+	// ESXi Shell or SSH service without time limit.
+	AllowedShellSSHHosts multiValueStringFlag
+
+	// TimeDriftWarning specifies the number of seconds of clock drift
+	// between an ESXi host and the reference clock when a WARNING threshold
+	// is reached.
+	TimeDriftWarning int
+
+	// TimeDriftCritical specifies the number of seconds of clock drift
+	// between an ESXi host and the reference clock when a CRITICAL threshold
+	// is reached.
+	TimeDriftCritical int
+
+	// TimeDriftCompareVCenter indicates whether ESXi host clocks are also
+	// compared against the vCenter server clock.
+	TimeDriftCompareVCenter bool
+
+	// ExpectedImageProfile is the ESXi image profile name that all
+	// evaluated hosts are expected to use.
+	ExpectedImageProfile string
+
+	// UseVLCMDesiredImage indicates whether the vSphere Lifecycle Manager
+	// (vLCM) desired image for a cluster is used (when available) to
+	// determine the expected ESXi build for hosts in the cluster.
+	UseVLCMDesiredImage bool
+
+	// VLCMNonCompliantWarning specifies the number of hosts not compliant
+	// with the vSphere Lifecycle Manager (vLCM) desired image for their
+	// cluster when a WARNING threshold is reached.
+	VLCMNonCompliantWarning int
+
+	// VLCMNonCompliantCritical specifies the number of hosts not compliant
+	// with the vSphere Lifecycle Manager (vLCM) desired image for their
+	// cluster when a CRITICAL threshold is reached.
+	VLCMNonCompliantCritical int
+
+	// AlarmDefinitionsStateFile is the fully-qualified path to the state file
+	// used to record alarm definition checksums between plugin runs.
+	AlarmDefinitionsStateFile string
+
+	// ExpectedDNSServers is a list of DNS server IP addresses that all
+	// evaluated ESXi hosts are expected to use. If not specified, the most
+	// common list of DNS servers within each cluster is expected.
+	ExpectedDNSServers multiValueStringFlag
+
+	// ExpectedSearchDomains is a list of DNS search domains that all
+	// evaluated ESXi hosts are expected to use. If not specified, the most
+	// common list of search domains within each cluster is expected.
+	ExpectedSearchDomains multiValueStringFlag
+
+	// ExpectedIPv4Gateway is the IPv4 default gateway that all evaluated
+	// ESXi hosts are expected to use. If not specified, the most common IPv4
+	// default gateway within each cluster is expected.
+	ExpectedIPv4Gateway string
+
+	// ExpectedIPv6Gateway is the IPv6 default gateway that all evaluated
+	// ESXi hosts are expected to use. If not specified, the most common IPv6
+	// default gateway within each cluster is expected.
+	ExpectedIPv6Gateway string
+
+	// EventsLookback is the number of minutes prior to plugin execution
+	// evaluated for matching vCenter events.
+	EventsLookback int
+
+	// MatchedEventsCritical specifies the number of matching events within
+	// the lookback window when a CRITICAL threshold is reached.
+	MatchedEventsCritical int
+
+	// MatchedEventsWarning specifies the number of matching events within
+	// the lookback window when a WARNING threshold is reached.
+	MatchedEventsWarning int
+
+	// IncludedEventTypeIDs is a list of event type IDs (e.g.,
+	// HostConnectionLostEvent) used to match events for evaluation.
+	IncludedEventTypeIDs multiValueStringFlag
+
+	// ExcludedEventTypeIDs is a list of event type IDs that should be
+	// explicitly excluded from evaluation.
+	ExcludedEventTypeIDs multiValueStringFlag
+
+	// IncludedEventMessages is a list of substrings used to match events for
+	// evaluation by event message.
+	IncludedEventMessages multiValueStringFlag
+
+	// ExcludedEventMessages is a list of event message substrings that should
+	// be explicitly excluded from evaluation.
+	ExcludedEventMessages multiValueStringFlag
+
+	// ExcludedEventEntityNames is a list of entity name substrings that
+	// should be explicitly excluded from evaluation.
+	ExcludedEventEntityNames multiValueStringFlag
+
+	// ExcludedEventUserNames is a list of user name substrings that should be
+	// explicitly excluded from evaluation.
+	ExcludedEventUserNames multiValueStringFlag
+
+	// VMCPUReadyCritical specifies the percentage of CPU ready time per vCPU
+	// (as a whole number) when a CRITICAL threshold is reached.
+	VMCPUReadyCritical int
+
+	// VMCPUReadyWarning specifies the percentage of CPU ready time per vCPU
+	// (as a whole number) when a WARNING threshold is reached.
+	VMCPUReadyWarning int
+
+	// VMCPUCoStopCritical specifies the percentage of CPU co-stop time per
+	// vCPU (as a whole number) when a CRITICAL threshold is reached.
+	VMCPUCoStopCritical int
+
+	// VMCPUCoStopWarning specifies the percentage of CPU co-stop time per
+	// vCPU (as a whole number) when a WARNING threshold is reached.
+	VMCPUCoStopWarning int
+
+	// VMMemoryBalloonedCritical specifies the percentage of configured memory
+	// reclaimed by the balloon driver (as a whole number) when a CRITICAL
+	// threshold is reached.
+	VMMemoryBalloonedCritical int
+
+	// VMMemoryBalloonedWarning specifies the percentage of configured memory
+	// reclaimed by the balloon driver (as a whole number) when a WARNING
+	// threshold is reached.
+	VMMemoryBalloonedWarning int
+
+	// VMMemorySwappedCritical specifies the percentage of configured memory
+	// swapped to disk (as a whole number) when a CRITICAL threshold is
+	// reached.
+	VMMemorySwappedCritical int
+
+	// VMMemorySwappedWarning specifies the percentage of configured memory
+	// swapped to disk (as a whole number) when a WARNING threshold is
+	// reached.
+	VMMemorySwappedWarning int
+
+	// VMMemoryCompressedCritical specifies the percentage of configured
+	// memory compressed (as a whole number) when a CRITICAL threshold is
+	// reached.
+	VMMemoryCompressedCritical int
+
+	// VMMemoryCompressedWarning specifies the percentage of configured memory
+	// compressed (as a whole number) when a WARNING threshold is reached.
+	VMMemoryCompressedWarning int
+
+	// VMFTLatencyCritical specifies the Fault Tolerance secondary latency in
+	// milliseconds when a CRITICAL threshold is reached.
+	VMFTLatencyCritical int
+
+	// VMFTLatencyWarning specifies the Fault Tolerance secondary latency in
+	// milliseconds when a WARNING threshold is reached.
+	VMFTLatencyWarning int
+
+	// VMFTBandwidthCritical specifies the Fault Tolerance logging bandwidth
+	// in Mbps when a CRITICAL threshold is reached.
+	VMFTBandwidthCritical int
+
+	// VMFTBandwidthWarning specifies the Fault Tolerance logging bandwidth in
+	// Mbps when a WARNING threshold is reached.
+	VMFTBandwidthWarning int
+
+	// ToolsNoIPGracePeriod specifies the number of minutes after power on
+	// that a VM with VMware Tools running is allowed to go without reporting
+	// an IP Address.
+	ToolsNoIPGracePeriod int
+
+	// ClusterCPUUseCritical specifies the percentage of effective cluster CPU
+	// capacity used (as a whole number) when a CRITICAL threshold is reached.
+	ClusterCPUUseCritical int
+
+	// ClusterCPUUseWarning specifies the percentage of effective cluster CPU
+	// capacity used (as a whole number) when a WARNING threshold is reached.
+	ClusterCPUUseWarning int
+
+	// ClusterMemoryUseCritical specifies the percentage of effective cluster
+	// memory used (as a whole number) when a CRITICAL threshold is reached.
+	ClusterMemoryUseCritical int
+
+	// ClusterMemoryUseWarning specifies the percentage of effective cluster
+	// memory used (as a whole number) when a WARNING threshold is reached.
+	ClusterMemoryUseWarning int
+
+	// RequiredVASAProviders is a list of VASA storage provider names which
+	// are required to be registered.
+	RequiredVASAProviders multiValueStringFlag
+
+	// VASACertExpiryCritical specifies the number of days remaining before a
+	// VASA provider certificate expires when a CRITICAL threshold is reached.
+	VASACertExpiryCritical int
+
+	// VASACertExpiryWarning specifies the number of days remaining before a
+	// VASA provider certificate expires when a WARNING threshold is reached.
+	VASACertExpiryWarning int
+
+	// CertificateExpiryCritical specifies the number of days remaining
+	// before a vCenter or ESXi host certificate expires when a CRITICAL
+	// threshold is reached.
+	CertificateExpiryCritical int
+
+	// CertificateExpiryWarning specifies the number of days remaining before
+	// a vCenter or ESXi host certificate expires when a WARNING threshold is
+	// reached.
+	CertificateExpiryWarning int
+
+	// VCenterDatabaseUsageCritical specifies the percentage of a vCenter
+	// database partition's space usage when a CRITICAL threshold is reached.
+	VCenterDatabaseUsageCritical int
+
+	// VCenterDatabaseUsageWarning specifies the percentage of a vCenter
+	// database partition's space usage when a WARNING threshold is reached.
+	VCenterDatabaseUsageWarning int
+
+	// emergencyThreshold specifies an optional threshold above the CRITICAL
+	// threshold which flags a CRITICAL state as an emergency.
+	emergencyThreshold optionalIntFlag
+
+	// RequiredDRSBehavior is the minimum DRS automation level required for
+	// evaluated clusters.
+	RequiredDRSBehavior string
+
+	// DRSRecommendationsCritical specifies the number of pending DRS
+	// recommendations above which a CRITICAL threshold is reached.
+	DRSRecommendationsCritical int
+
+	// DRSRecommendationsWarning specifies the number of pending DRS
+	// recommendations above which a WARNING threshold is reached.
+	DRSRecommendationsWarning int
+
+	// ClusterNames is a list of vSphere cluster names to evaluate.
+	ClusterNames multiValueStringFlag
+
+	// ExpectedIsolationAddresses is a list of vSphere HA isolation addresses
+	// which are required to be configured for evaluated clusters.
+	ExpectedIsolationAddresses multiValueStringFlag
+
+	// allowedIsolationResponses is a list of vSphere HA host isolation
+	// responses permitted for evaluated clusters.
+	allowedIsolationResponses multiValueStringFlag
+
+	// MinIsolationAddresses is the minimum number of custom vSphere HA
+	// isolation addresses required to be configured for evaluated clusters.
+	MinIsolationAddresses int
+
+	// AllowDefaultIsolationAddress indicates whether use of the default
+	// vSphere HA isolation address (the default gateway of the management
+	// network) is permitted for evaluated clusters.
+	AllowDefaultIsolationAddress bool
+
+	// SiteHostGroups is a list of DRS host group names used to define the
+	// sites of evaluated stretched clusters.
+	SiteHostGroups multiValueStringFlag
+
+	// SiteImbalanceWarning specifies the difference (in percentage points of
+	// all powered on VMs) between the stretched cluster sites with the most
+	// and the fewest powered on VMs when a WARNING threshold is reached.
+	SiteImbalanceWarning int
+
+	// SiteImbalanceCritical specifies the difference (in percentage points
+	// of all powered on VMs) between the stretched cluster sites with the
+	// most and the fewest powered on VMs when a CRITICAL threshold is
+	// reached.
+	SiteImbalanceCritical int
+
+	// AllowedMaintenanceDatastores is a list of datastore names which are
+	// permitted to be in maintenance mode.
+	AllowedMaintenanceDatastores multiValueStringFlag
+
+	// AllowedReadOnlyDatastores is a list of datastore names which are
+	// permitted to be mounted read-only.
+	AllowedReadOnlyDatastores multiValueStringFlag
+
+	// AllowedMaintenanceHosts is a list of ESXi host names which are
+	// permitted to be in maintenance mode.
+	AllowedMaintenanceHosts multiValueStringFlag
+
+	// AllowedStandbyHosts is a list of ESXi host names which are permitted
+	// to be in standby mode.
+	AllowedStandbyHosts multiValueStringFlag
+
+	// HAHostFailuresLookback is the number of minutes prior to plugin
+	// execution evaluated for vSphere HA host failure events.
+	HAHostFailuresLookback int
+
+	// HAHostFailuresWarning specifies the number of vSphere HA host failure
+	// events for a single cluster above which a WARNING threshold is
+	// reached.
+	HAHostFailuresWarning int
+
+	// HAHostFailuresCritical specifies the number of vSphere HA host failure
+	// events for a single cluster above which a CRITICAL threshold is
+	// reached.
+	HAHostFailuresCritical int
+
+	// PendingHardwareUpgradeAgeWarning specifies the number of days a
+	// scheduled virtual hardware upgrade may remain pending before a WARNING
+	// threshold is reached.
+	PendingHardwareUpgradeAgeWarning int
+
+	// PendingHardwareUpgradeAgeCritical specifies the number of days a
+	// scheduled virtual hardware upgrade may remain pending before a
+	// CRITICAL threshold is reached.
+	PendingHardwareUpgradeAgeCritical int
+
+	// VMRestoreStateFile is the fully-qualified path to the state file used
+	// to record VM identity details (instance UUID, creation date) between
+	// plugin runs.
+	VMRestoreStateFile string
+
+	// RebootPendingAgeWarning specifies the number of hours a required ESXi
+	// host reboot may remain pending before a WARNING threshold is reached.
+	RebootPendingAgeWarning int
+
+	// RebootPendingAgeCritical specifies the number of hours a required ESXi
+	// host reboot may remain pending before a CRITICAL threshold is reached.
+	RebootPendingAgeCritical int
+
+	// RebootPendingStateFile is the fully-qualified path to the state file
+	// used to record when a pending ESXi host reboot was first observed.
+	RebootPendingStateFile string
+
+	// VMTimeSyncPeriodic is the required state (enabled, disabled or any) of
+	// VMware Tools periodic time synchronization for evaluated VMs.
+	VMTimeSyncPeriodic string
+
+	// VMTimeSyncStartup is the required state (enabled, disabled or any) of
+	// VMware Tools one-off (e.g., startup) time synchronization for
+	// evaluated VMs.
+	VMTimeSyncStartup string
+
+	// DatastoreVMsWarning specifies the number of VMs registered on a
+	// datastore when a WARNING threshold is reached.
+	DatastoreVMsWarning int
+
+	// DatastoreVMsCritical specifies the number of VMs registered on a
+	// datastore when a CRITICAL threshold is reached.
+	DatastoreVMsCritical int
+
+	// DatastoreVMDKsWarning specifies the number of virtual disks (VMDKs)
+	// backed by files on a datastore when a WARNING threshold is reached.
+	DatastoreVMDKsWarning int
+
+	// DatastoreVMDKsCritical specifies the number of virtual disks (VMDKs)
+	// backed by files on a datastore when a CRITICAL threshold is reached.
+	DatastoreVMDKsCritical int
+
+	// SnapshotRemovalStallWarning specifies the number of minutes an
+	// in-progress snapshot removal or disk consolidation task may run before
+	// a WARNING threshold is reached.
+	SnapshotRemovalStallWarning int
+
+	// SnapshotRemovalStallCritical specifies the number of minutes an
+	// in-progress snapshot removal or disk consolidation task may run before
+	// a CRITICAL threshold is reached.
+	SnapshotRemovalStallCritical int
+
+	// VMMemoryAllocatedWarning specifies the percentage of VM memory
+	// allocation (as a whole number) when a WARNING threshold is reached.
+	VMMemoryAllocatedWarning int
+
+	// VMMemoryAllocatedCritical specifies the percentage of VM memory
+	// allocation (as a whole number) when a CRITICAL threshold is reached.
+	VMMemoryAllocatedCritical int
+
+	// VMMemoryMaxAllowed specifies the maximum amount of memory in GiB (as a
+	// whole number) that we are allowed to allocate to VMs in the target
+	// VMware environment.
+	VMMemoryMaxAllowed int
+
+	// VMMemoryPhysicalCapacity indicates whether allocated VM memory is
+	// compared against the physical memory of each cluster (or standalone
+	// host) instead of a single static maximum.
+	VMMemoryPhysicalCapacity bool
+
+	// VMMemoryRatioWarning specifies the memory overcommit ratio (allocated
+	// to physical) per cluster or standalone host when a WARNING threshold
+	// is reached.
+	VMMemoryRatioWarning float64
+
+	// VMMemoryRatioCritical specifies the memory overcommit ratio (allocated
+	// to physical) per cluster or standalone host when a CRITICAL threshold
+	// is reached.
+	VMMemoryRatioCritical float64
+
+	// folderVMCountMaxWarning specifies the number of VMs in a folder above
+	// which a WARNING threshold is reached.
+	folderVMCountMaxWarning optionalIntFlag
+
+	// folderVMCountMaxCritical specifies the number of VMs in a folder above
+	// which a CRITICAL threshold is reached.
+	folderVMCountMaxCritical optionalIntFlag
+
+	// folderVMCountMinWarning specifies the number of VMs in a folder below
+	// which a WARNING threshold is reached.
+	folderVMCountMinWarning optionalIntFlag
+
+	// folderVMCountMinCritical specifies the number of VMs in a folder below
+	// which a CRITICAL threshold is reached.
+	folderVMCountMinCritical optionalIntFlag
+
 	// VCPUsAllocatedCritical specifies the percentage of vCPUs allocation (as
 	// a whole number) when a CRITICAL threshold is reached.
 	VCPUsAllocatedCritical int
@@ -391,6 +1263,19 @@ type Config struct {
 	// environment.
 	VCPUsMaxAllowed int
 
+	// VCPUsClusterCapacity indicates whether allocated vCPUs are compared
+	// against physical CPU cores per cluster (vCPU:pCPU overcommit ratio)
+	// instead of a single static maximum.
+	VCPUsClusterCapacity bool
+
+	// VCPUsClusterRatioWarning specifies the vCPU:pCPU overcommit ratio per
+	// cluster when a WARNING threshold is reached.
+	VCPUsClusterRatioWarning float64
+
+	// VCPUsClusterRatioCritical specifies the vCPU:pCPU overcommit ratio per
+	// cluster when a CRITICAL threshold is reached.
+	VCPUsClusterRatioCritical float64
+
 	// ResourcePoolsMemoryUseWarning specifies the percentage of memory use
 	// (as a whole number) across all specified Resource Pools when a WARNING
 	// threshold is reached.
@@ -402,7 +1287,7 @@ type Config struct {
 	ResourcePoolsMemoryUseCritical int
 
 	// ResourcePoolsMemoryMaxAllowed specifies the maximum amount of memory
-	// that we are allowed to consume in GB (as a whole number) in the target
+	// that we are allowed to consume in GiB (as a whole number) in the target
 	// VMware environment across all specified Resource Pools. VMs that are
 	// running outside of resource pools are not considered in these
 	// calculations.
@@ -447,14 +1332,69 @@ type Config struct {
 	// specific percentile.
 	datastorePerformancePercentileSet MultiValueDSPerfPercentileSetFlag
 
-	// SnapshotsSizeCritical specifies the cumulative size in GB of all
+	// SnapshotsSizeCritical specifies the cumulative size in GiB of all
 	// snapshots for a VM when a WARNING threshold is reached.
 	SnapshotsSizeWarning int
 
-	// SnapshotsSizeCritical specifies the cumulative size in GB of all
+	// SnapshotsSizeCritical specifies the cumulative size in GiB of all
 	// snapshots for a VM when a CRITICAL threshold is reached.
 	SnapshotsSizeCritical int
 
+	// SnapshotsDatastoreQuotaWarning specifies the cumulative size in GiB of
+	// all snapshots stored on a datastore before a WARNING state is
+	// triggered.
+	SnapshotsDatastoreQuotaWarning int
+
+	// SnapshotsDatastoreQuotaCritical specifies the cumulative size in GiB of
+	// all snapshots stored on a datastore before a CRITICAL state is
+	// triggered.
+	SnapshotsDatastoreQuotaCritical int
+
+	// OrphanedVMDKsSizeWarning specifies the cumulative size in GiB of all
+	// orphaned VMDK files before a WARNING state is triggered.
+	OrphanedVMDKsSizeWarning int
+
+	// OrphanedVMDKsSizeCritical specifies the cumulative size in GiB of all
+	// orphaned VMDK files before a CRITICAL state is triggered.
+	OrphanedVMDKsSizeCritical int
+
+	// VMGuestDiskUsageWarning specifies the percentage of guest filesystem
+	// usage when a WARNING threshold is reached.
+	VMGuestDiskUsageWarning int
+
+	// VMGuestDiskUsageCritical specifies the percentage of guest filesystem
+	// usage when a CRITICAL threshold is reached.
+	VMGuestDiskUsageCritical int
+
+	// RunawayVMCPUShareWarning specifies the percentage of a Resource Pool's
+	// CPU usage consumed by a single VM when a WARNING threshold is reached.
+	RunawayVMCPUShareWarning int
+
+	// RunawayVMCPUShareCritical specifies the percentage of a Resource
+	// Pool's CPU usage consumed by a single VM when a CRITICAL threshold is
+	// reached.
+	RunawayVMCPUShareCritical int
+
+	// RunawayVMMemoryShareWarning specifies the percentage of a Resource
+	// Pool's memory usage consumed by a single VM when a WARNING threshold is
+	// reached.
+	RunawayVMMemoryShareWarning int
+
+	// RunawayVMMemoryShareCritical specifies the percentage of a Resource
+	// Pool's memory usage consumed by a single VM when a CRITICAL threshold
+	// is reached.
+	RunawayVMMemoryShareCritical int
+
+	// RunawayVMTopCount specifies the number of top CPU and memory consuming
+	// VMs listed for each Resource Pool.
+	RunawayVMTopCount int
+
+	// VMToolsMinVersion specifies the minimum acceptable VMware Tools
+	// version number (as reported by the guest.toolsVersion property). VMs
+	// with an older VMware Tools version are considered to be in a CRITICAL
+	// state. A value of zero disables this check.
+	VMToolsMinVersion int
+
 	// SnapshotsAgeWarning specifies the age of a snapshot in days when a
 	// WARNING threshold is reached.
 	SnapshotsAgeWarning int
@@ -463,6 +1403,15 @@ type Config struct {
 	// CRITICAL threshold is reached.
 	SnapshotsAgeCritical int
 
+	// IncludedSnapshots lists snapshot name or description substrings (or
+	// patterns) for snapshots that are explicitly required to be evaluated.
+	IncludedSnapshots multiValueStringFlag
+
+	// ExcludedSnapshots lists snapshot name or description substrings (or
+	// patterns) for snapshots that are explicitly ignored or excluded from
+	// being evaluated (e.g., temporary snapshots created by backup tools).
+	ExcludedSnapshots multiValueStringFlag
+
 	// SnapshotsCountWarning specifies the number of snapshots per VM when a
 	// WARNING threshold is reached.
 	SnapshotsCountWarning int
@@ -471,6 +1420,16 @@ type Config struct {
 	// CRITICAL threshold is reached.
 	SnapshotsCountCritical int
 
+	// SnapshotsTotalCountWarning specifies the total number of snapshots
+	// across all evaluated VMs when a WARNING threshold is reached. A value of
+	// zero disables aggregate evaluation.
+	SnapshotsTotalCountWarning int
+
+	// SnapshotsTotalCountCritical specifies the total number of snapshots
+	// across all evaluated VMs when a CRITICAL threshold is reached. A value
+	// of zero disables aggregate evaluation.
+	SnapshotsTotalCountCritical int
+
 	// VMPowerCycleUptimeWarning specifies the power cycle (off/on) uptime in
 	// days per VM when a WARNING threshold is reached.
 	VMPowerCycleUptimeWarning int
@@ -479,6 +1438,10 @@ type Config struct {
 	// days per VM when a CRITICAL threshold is reached.
 	VMPowerCycleUptimeCritical int
 
+	// VMPowerCycleUptimeSource specifies the source of VM power cycle uptime
+	// values; QuickStats uptime or the time elapsed since the last boot.
+	VMPowerCycleUptimeSource string
+
 	// VMBackupAgeWarning specifies the number of days since the last backup
 	// for a VM when a WARNING threshold is reached.
 	VMBackupAgeWarning int
@@ -533,14 +1496,93 @@ type Config struct {
 	// alarms are evaluated in addition to unacknowledged ones.
 	EvaluateAcknowledgedAlarms bool
 
+	// AlarmDatastoreVMs indicates whether the VMs residing on the affected
+	// datastore are listed for triggered alarms associated with a Datastore
+	// entity.
+	AlarmDatastoreVMs bool
+
+	// ExcludeHostCertificates indicates whether ESXi host certificates
+	// retrieved from the HostCertificateManager should be excluded from
+	// evaluation. If excluded, only the certificate presented by the vSphere
+	// endpoint used for the plugin connection is evaluated.
+	ExcludeHostCertificates bool
+
+	// AcknowledgedAlarmsMaxState is the most severe Nagios state (ok,
+	// warning, critical) that acknowledged triggered alarms may contribute
+	// to the overall plugin state. If specified, acknowledged triggered
+	// alarms are evaluated.
+	AcknowledgedAlarmsMaxState string
+
+	// AlarmTriggeredSince is the user-specified duration (relative to now)
+	// or RFC3339 timestamp used to limit evaluation to triggered alarms
+	// triggered at or after the specified point in time.
+	AlarmTriggeredSince string
+
+	// AlarmTriggeredBefore is the user-specified duration (relative to now)
+	// or RFC3339 timestamp used to limit evaluation to triggered alarms
+	// triggered at or before the specified point in time.
+	AlarmTriggeredBefore string
+
+	// AlarmMaintenanceModeAction indicates how triggered alarms associated
+	// with ESXi hosts in maintenance mode (or VMs running on those hosts)
+	// are handled.
+	AlarmMaintenanceModeAction string
+
 	// TriggerReloadStateData indicates whether the state data for evaluated
 	// objects (e.g., VirtualMachines) will be reloaded/refreshed prior to
 	// evaluation of specific properties.
 	TriggerReloadStateData bool
 
+	// ConsolidateDisks indicates whether disk consolidation is triggered
+	// for VirtualMachines found to require it.
+	ConsolidateDisks bool
+
+	// MaxRemediations is the maximum number of VirtualMachines for which
+	// disk consolidation is triggered during a single plugin execution.
+	MaxRemediations int
+
+	// remediationTimeout is the number of seconds to wait for triggered
+	// disk consolidation tasks to complete.
+	remediationTimeout int
+
+	// AutoAnswers is the collection of user-specified question text patterns
+	// and the answers used to automatically respond to matching interactive
+	// questions.
+	AutoAnswers AutoAnswerFlag
+
+	// AutoAnswerApply indicates whether matching auto-answers are applied
+	// to VirtualMachines blocked by an interactive question. If false,
+	// matching answers are only reported (dry-run).
+	AutoAnswerApply bool
+
+	// VSANHealthRefresh indicates whether a new vSAN health check run is
+	// triggered instead of using cached health test results.
+	VSANHealthRefresh bool
+
 	// Whether the certificate should be trusted as-is without validation.
 	TrustCert bool
 
+	// CAFile is the fully-qualified path to an optional PEM encoded CA
+	// certificate bundle used to validate the server certificate.
+	CAFile string
+
+	// TLSMinVersion is the minimum TLS version (e.g., 1.2) used when
+	// connecting to the vSphere server.
+	TLSMinVersion string
+
+	// InsecureSkipHostnameVerify indicates whether verification of the
+	// hostname in the server certificate is skipped while still validating
+	// the certificate chain.
+	InsecureSkipHostnameVerify bool
+
+	// Proxy is the URL of an optional HTTP, HTTPS or SOCKS5 proxy used to
+	// connect to the vSphere server.
+	Proxy string
+
+	// NoProxy is a comma-separated list of hosts, domains, IP Addresses or
+	// CIDR ranges which are connected to directly instead of via the proxy.
+	NoProxy string
+
 	// EmitBranding controls whether "generated by" text is included at the
 	// bottom of application output. This output is included in the Nagios
 	// dashboard and notifications. This output may not mix well with branding
@@ -551,6 +1593,58 @@ type Config struct {
 	// ShowVersion is a flag indicating whether the user opted to display only
 	// the version string and then immediately exit the application.
 	ShowVersion bool
+
+	// propertyRetrievalWarning is the number of milliseconds property
+	// retrieval for a managed object type may take before a notice is
+	// included in plugin output.
+	propertyRetrievalWarning int
+
+	// StateMap is the collection of user-specified Nagios state mappings
+	// (e.g., WARNING to OK) applied to the final plugin state. Keys and
+	// values are upper-case Nagios state labels.
+	StateMap StateMapFlag
+
+	// SummaryTemplate is the user-specified Go template string used to
+	// override the one-line summary format, if any.
+	SummaryTemplate string
+
+	// SessionCache indicates whether vSphere sessions are cached and reused
+	// between plugin executions.
+	SessionCache bool
+
+	// sessionCacheDir is the user-specified directory used to store cached
+	// vSphere sessions.
+	sessionCacheDir string
+
+	// EvaluationManifest is the fully-qualified path to an optional JSON
+	// file (or existing directory) used to record what was evaluated and
+	// skipped during plugin execution.
+	EvaluationManifest string
+
+	// RedactNames indicates whether VM, host and datastore names are
+	// redacted in plugin output.
+	RedactNames bool
+
+	// redactNamesLookupFile is the user-specified JSON file used to record
+	// the mapping of redacted name tokens to names.
+	redactNamesLookupFile string
+
+	// OmitReportSections is the collection of user-specified optional
+	// sections (e.g., recently-started-vms) omitted from the long service
+	// output.
+	OmitReportSections multiValueStringFlag
+
+	// OTLPEndpoint is the user-specified OTLP/HTTP base URL used to export
+	// OpenTelemetry traces for vSphere retrieval functions.
+	OTLPEndpoint string
+
+	// ActiveThresholdProfile is the name of the threshold profile applied
+	// during this plugin execution, if any.
+	ActiveThresholdProfile string
+
+	// configFile is the configuration file loaded from the user-specified
+	// path, if any.
+	configFile *ConfigFile
 }
 
 // Usage is a custom override for the default Help text provided by the flag
@@ -643,6 +1737,186 @@ func pluginTypeLabel(pluginType PluginType) string {
 	case pluginType.VirtualMachineList:
 		label = PluginTypeVirtualMachineList
 
+	case pluginType.VSANHealth:
+		label = PluginTypeVSANHealth
+
+	case pluginType.HostServices:
+		label = PluginTypeHostServices
+
+	case pluginType.LicensingFeatureUsage:
+		label = PluginTypeLicensingFeatureUsage
+
+	case pluginType.VirtualMachineCPUReady:
+		label = PluginTypeVirtualMachineCPUReady
+
+	case pluginType.FolderVMCounts:
+		label = PluginTypeFolderVMCounts
+
+	case pluginType.VirtualMachineMemoryPressure:
+		label = PluginTypeVirtualMachineMemoryPressure
+
+	case pluginType.ClusterResourceUsage:
+		label = PluginTypeClusterResourceUsage
+
+	case pluginType.VASAProviderStatus:
+		label = PluginTypeVASAProviderStatus
+
+	case pluginType.ClusterHAStatus:
+		label = PluginTypeClusterHAStatus
+
+	case pluginType.VVolDatastoreHealth:
+		label = PluginTypeVVolDatastoreHealth
+
+	case pluginType.ClusterDRSStatus:
+		label = PluginTypeClusterDRSStatus
+
+	case pluginType.HostHardwareSensors:
+		label = PluginTypeHostHardwareSensors
+
+	case pluginType.VirtualMachineFTLatency:
+		label = PluginTypeVirtualMachineFTLatency
+
+	case pluginType.HostStoragePaths:
+		label = PluginTypeHostStoragePaths
+
+	case pluginType.VirtualMachineToolsNoIP:
+		label = PluginTypeVirtualMachineToolsNoIP
+
+	case pluginType.VCenterCertificates:
+		label = PluginTypeVCenterCertificates
+
+	case pluginType.License:
+		label = PluginTypeLicense
+
+	case pluginType.DatacenterInventoryDrift:
+		label = PluginTypeDatacenterInventoryDrift
+
+	case pluginType.Tasks:
+		label = PluginTypeTasks
+
+	case pluginType.HostESXiShellSSHEnabled:
+		label = PluginTypeHostESXiShellSSHEnabled
+
+	case pluginType.HostDNSRouting:
+		label = PluginTypeHostDNSRouting
+
+	case pluginType.Events:
+		label = PluginTypeEvents
+
+	case pluginType.SnapshotsDatastoreQuota:
+		label = PluginTypeSnapshotsDatastoreQuota
+
+	case pluginType.VirtualMachineOrphanedDevices:
+		label = PluginTypeVirtualMachineOrphanedDevices
+
+	case pluginType.OrphanedVMDKs:
+		label = PluginTypeOrphanedVMDKs
+
+	case pluginType.NagiosGenConfig:
+		label = PluginTypeNagiosGenConfig
+
+	case pluginType.VirtualMachineConnectedMedia:
+		label = PluginTypeVirtualMachineConnectedMedia
+
+	case pluginType.VirtualMachineGuestDiskUsage:
+		label = PluginTypeVirtualMachineGuestDiskUsage
+
+	case pluginType.ResourcePoolRunawayVM:
+		label = PluginTypeResourcePoolRunawayVM
+
+	case pluginType.VirtualMachineToolsVersion:
+		label = PluginTypeVirtualMachineToolsVersion
+
+	case pluginType.VirtualMachineCPUAffinity:
+		label = PluginTypeVirtualMachineCPUAffinity
+
+	case pluginType.HostTimeDrift:
+		label = PluginTypeHostTimeDrift
+
+	case pluginType.ESXiImageProfileDrift:
+		label = PluginTypeESXiImageProfileDrift
+
+	case pluginType.VLCMCompliance:
+		label = PluginTypeVLCMCompliance
+
+	case pluginType.AlarmDefinitionsHash:
+		label = PluginTypeAlarmDefinitionsHash
+
+	case pluginType.HostAcceleration:
+		label = PluginTypeHostAcceleration
+
+	case pluginType.VirtualMachineLastBackupViaTag:
+		label = PluginTypeVirtualMachineLastBackupViaTag
+
+	case pluginType.VirtualMachineCustomAttribute:
+		label = PluginTypeVirtualMachineCustomAttribute
+
+	case pluginType.VCenterDatabaseHealth:
+		label = PluginTypeVCenterDatabaseHealth
+
+	case pluginType.VCenterServiceStatus:
+		label = PluginTypeVCenterServiceStatus
+
+	case pluginType.VMwareExporter:
+		label = PluginTypeVMwareExporter
+
+	case pluginType.AlarmActionDisabled:
+		label = PluginTypeAlarmActionDisabled
+
+	case pluginType.VirtualMachineIndependentDisks:
+		label = PluginTypeVirtualMachineIndependentDisks
+
+	case pluginType.VirtualMachineRDMUsage:
+		label = PluginTypeVirtualMachineRDMUsage
+
+	case pluginType.HostSRIOVPassthroughCapacity:
+		label = PluginTypeHostSRIOVPassthroughCapacity
+
+	case pluginType.HostGPUAllocation:
+		label = PluginTypeHostGPUAllocation
+
+	case pluginType.ClusterDASIsolation:
+		label = PluginTypeClusterDASIsolation
+
+	case pluginType.DatastoresOvercommit:
+		label = PluginTypeDatastoresOvercommit
+
+	case pluginType.StretchedClusterSiteBalance:
+		label = PluginTypeStretchedClusterSiteBalance
+
+	case pluginType.DatastoresAccessibility:
+		label = PluginTypeDatastoresAccessibility
+
+	case pluginType.VirtualMachinePendingHWUpgrade:
+		label = PluginTypeVirtualMachinePendingHWUpgrade
+
+	case pluginType.VirtualMachineRestoreDetection:
+		label = PluginTypeVirtualMachineRestoreDetection
+
+	case pluginType.HostRebootPending:
+		label = PluginTypeHostRebootPending
+
+	case pluginType.VirtualMachineTimeSyncPolicy:
+		label = PluginTypeVirtualMachineTimeSyncPolicy
+
+	case pluginType.DatastoresVMDensity:
+		label = PluginTypeDatastoresVMDensity
+
+	case pluginType.SnapshotRemovalStalls:
+		label = PluginTypeSnapshotRemovalStalls
+
+	case pluginType.VMMemoryAllocation:
+		label = PluginTypeVMMemoryAllocation
+
+	case pluginType.VirtualMachineDiskUUIDEnabled:
+		label = PluginTypeVirtualMachineDiskUUIDEnabled
+
+	case pluginType.HostConnectionState:
+		label = PluginTypeHostConnectionState
+
+	case pluginType.ClusterHAHostFailures:
+		label = PluginTypeClusterHAHostFailures
+
 	default:
 		label = "ERROR: Please report this; I evidently forgot to expand the PluginType collection"
 
@@ -677,6 +1951,30 @@ func New(pluginType PluginType) (*Config, error) {
 		return nil, ErrVersionRequested
 	}
 
+	// apply settings from configuration file (if any) for flags not
+	// specified via the command-line before all other sources
+	if err := config.applyConfigFile(pluginType); err != nil {
+		return nil, fmt.Errorf("failed to apply configuration file: %w", err)
+	}
+
+	// apply credentials from files or environment variables (if any) for
+	// settings not specified via command-line flags
+	if err := config.loadCredentials(); err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+
+	// combine alarm filters from file (if any) with command-line values
+	// before validation so that file values are validated the same way
+	if err := config.applyAlarmFilterFile(); err != nil {
+		return nil, fmt.Errorf("failed to apply alarm filter file: %w", err)
+	}
+
+	// apply active threshold profile (if any) before validation so that
+	// profile values are validated the same as command-line values
+	if err := config.applyThresholdProfile(pluginType, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to apply threshold profile: %w", err)
+	}
+
 	if err := config.validate(pluginType); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
@@ -15,21 +15,42 @@ const (
 	logLevelFlagHelp                                string = "Sets log level to one of disabled, panic, fatal, error, warn, info, debug or trace."
 	serverFlagHelp                                  string = "The fully-qualified domain name or IP Address of the remote ESXi host or vCenter instance."
 	trustCertFlagHelp                               string = "Whether the certificate should be trusted as-is without validation. WARNING: TLS is susceptible to man-in-the-middle attacks if enabling this option."
+	caFileFlagHelp                                  string = "Specifies the fully-qualified path to a PEM encoded CA certificate bundle used to validate the vSphere server certificate in place of the system certificate pool. This allows validating certificates issued by an internal CA without resorting to the trust-cert flag."
+	tlsMinVersionFlagHelp                           string = "Specifies the minimum TLS version used when connecting to the vSphere server. Supported values are 1.0, 1.1, 1.2 and 1.3."
+	proxyFlagHelp                                   string = "Specifies the URL of an HTTP, HTTPS or SOCKS5 proxy (e.g., http://proxy.example.com:3128 or socks5://proxy.example.com:1080) used to connect to the vSphere server. If not specified, the HTTPS_PROXY environment variable is used."
+	noProxyFlagHelp                                 string = "Specifies a comma-separated list of hosts, domains, IP Addresses or CIDR ranges which are connected to directly instead of via the proxy. If not specified, the NO_PROXY environment variable is used."
+	insecureSkipHostnameVerifyFlagHelp              string = "Whether verification of the hostname in the vSphere server certificate is skipped. The certificate chain is still validated. WARNING: This option should only be used if the server certificate does not list the name or IP Address used to connect to the server."
 	portFlagHelp                                    string = "TCP port of the remote ESXi host or vCenter instance. This is usually 443 (HTTPS)."
 	timeoutPluginRuntimeFlagHelp                    string = "Timeout value in seconds allowed before a plugin execution attempt is abandoned and an error returned."
 	brandingFlagHelp                                string = "Toggles emission of branding details with plugin status details. This output is disabled by default."
-	usernameFlagHelp                                string = "Username with permission to access specified ESXi host or vCenter instance."
-	passwordFlagHelp                                string = "Password used to login to ESXi host or vCenter instance."
-	userDomainFlagHelp                              string = "(Optional) domain for user account used to login to ESXi host or vCenter instance. This is needed for user accounts residing in a non-default domain (e.g., SSO specific domain)."
+	usernameFlagHelp                                string = "Username with permission to access specified ESXi host or vCenter instance. If not specified, the username-file flag or the VMWARE_USERNAME (or VMWARE_USERNAME_FILE) environment variable is used."
+	passwordFlagHelp                                string = "Password used to login to ESXi host or vCenter instance. If not specified, the password-file flag or the VMWARE_PASSWORD (or VMWARE_PASSWORD_FILE) environment variable is used. Use of this flag exposes the password in process listings."
+	userDomainFlagHelp                              string = "(Optional) domain for user account used to login to ESXi host or vCenter instance. This is needed for user accounts residing in a non-default domain (e.g., SSO specific domain). If not specified, the VMWARE_DOMAIN environment variable is used."
+	usernameFileFlagHelp                            string = "Specifies the fully-qualified path to a file containing the username used to login to the ESXi host or vCenter instance. Leading and trailing whitespace is ignored. This flag is incompatible with the username flag."
+	passwordFileFlagHelp                            string = "Specifies the fully-qualified path to a file containing the password used to login to the ESXi host or vCenter instance. Trailing newline characters are ignored. This flag is incompatible with the password flag."
+	configFileFlagHelp                              string = "Specifies the fully-qualified path to an optional INI-style or TOML (.toml extension) configuration file containing shared settings (e.g., server, port, credentials, trust-cert, filters, logging level). Keys match the long names of command-line flags. Settings in the [default] section apply to all plugins while settings in a section named after a plugin apply only to that plugin. Sections named profile.NAME define time-of-day and day-of-week based threshold profiles. Flag values from the command-line have precedence over values from the configuration file, except for threshold values from the first active threshold profile applicable to this plugin."
 	vmIncludedFoldersFlagHelp                       string = "Specifies a comma-separated list of Folder Managed Object ID (MOID) values (e.g., group-v34) that should be exclusively used when evaluating VMs. This option is incompatible with specifying a list of Folder IDs to ignore or exclude from evaluation."
 	vmExcludedFoldersFlagHelp                       string = "Specifies a comma-separated list of Folder Managed Object ID (MOID) values (e.g., group-v34) that should be ignored when evaluating VMs. This option is incompatible with specifying a list of Folder Managed Object ID (MOID) values to include for evaluation."
 	vmIncludedResourcePoolsFlagHelp                 string = "Specifies a comma-separated list of Resource Pool names that should be exclusively used when evaluating VMs. Specifying this option will also exclude any VMs from evaluation that are *outside* of a Resource Pool. This option is incompatible with specifying a list of Resource Pool names to ignore or exclude from evaluation."
+	vmIncludedDatacentersFlagHelp                   string = "Specifies a comma-separated list of Datacenter names that should be exclusively used when evaluating VMs. This is useful for limiting evaluation to a specific Datacenter in a vCenter instance managing multiple Datacenters with Resource Pools of the same name. This option is incompatible with specifying a list of Datacenter names to ignore or exclude from evaluation."
+	vmExcludedDatacentersFlagHelp                   string = "Specifies a comma-separated list of Datacenter names that should be ignored when evaluating VMs. This option is incompatible with specifying a list of Datacenter names to include for evaluation."
+	vmIncludedClustersFlagHelp                      string = "Specifies a comma-separated list of Cluster names that should be exclusively used when evaluating VMs. Only VMs currently running on hosts within the specified Clusters are evaluated. This option is incompatible with specifying a list of Cluster names to ignore or exclude from evaluation."
+	vmExcludedClustersFlagHelp                      string = "Specifies a comma-separated list of Cluster names that should be ignored when evaluating VMs. VMs currently running on hosts within the specified Clusters are not evaluated. This option is incompatible with specifying a list of Cluster names to include for evaluation."
+	vmIncludedHostsFlagHelp                         string = "Specifies a comma-separated list of ESXi host names that should be exclusively used when evaluating VMs. Only VMs currently running on the specified hosts are evaluated. This option is incompatible with specifying a list of ESXi host names to ignore or exclude from evaluation."
+	vmIncludedTagsFlagHelp                          string = "Specifies a comma-separated list of vSphere Tags that should be exclusively used when evaluating VMs. Tags may be specified by name (matching a tag of that name in any category) or qualified by category name (e.g., Backup:Nightly). Only VMs with at least one matching tag are evaluated. This option is incompatible with specifying a list of vSphere Tags to exclude from evaluation."
+	vmExcludedTagsFlagHelp                          string = "Specifies a comma-separated list of vSphere Tags that should be ignored when evaluating VMs. Tags may be specified by name (matching a tag of that name in any category) or qualified by category name (e.g., Backup:Nightly). VMs with any matching tag are not evaluated. This option is incompatible with specifying a list of vSphere Tags to include for evaluation."
+	vmMaintenanceCAFlagHelp                         string = "Specifies the name of an optional Custom Attribute used to record the end of a maintenance window for a VM (e.g., nagios_maintenance_until). VMs with a value later than the current time are suppressed (excluded from evaluation) and listed separately. VMs without a value (or with a value which cannot be parsed) are evaluated as usual."
+	vmMaintenanceCADateFormatFlagHelp               string = "Specifies the format of the date recorded in the maintenance window Custom Attribute. Requires the layout string format used by the Go time package. See also https://pkg.go.dev/time#pkg-constants for examples."
+	vmExcludedHostsFlagHelp                         string = "Specifies a comma-separated list of ESXi host names that should be ignored when evaluating VMs. VMs currently running on the specified hosts are not evaluated. This option is incompatible with specifying a list of ESXi host names to include for evaluation."
 	vmExcludedResourcePoolsFlagHelp                 string = "Specifies a comma-separated list of Resource Pool names that should be ignored when evaluating VMs. This option is incompatible with specifying a list of Resource Pool names to include for evaluation."
 	ignoreVMsFlagHelp                               string = "Specifies a comma-separated list of VM names that should be ignored or excluded from evaluation."
 	poweredOffFlagHelp                              string = "Toggles evaluation of powered off VMs in addition to powered on VMs. Evaluation of powered off VMs is disabled by default."
 	vCPUsAllocatedMaxAllowedFlagHelp                string = "Specifies the maximum amount of virtual CPUs (as a whole number) that we are allowed to allocate in the target VMware environment."
 	vCPUsAllocatedCriticalFlagHelp                  string = "Specifies the percentage of vCPUs allocation (as a whole number) when a CRITICAL threshold is reached."
 	vCPUsAllocatedWarningFlagHelp                   string = "Specifies the percentage of vCPUs allocation (as a whole number) when a WARNING threshold is reached."
+	vCPUsClusterCapacityFlagHelp                    string = "Toggles comparison of allocated vCPUs against physical CPU cores per cluster (vCPU:pCPU overcommit ratio) instead of a single static maximum. VMs running on standalone hosts are not evaluated in this mode."
+	vCPUsClusterRatioCriticalFlagHelp               string = "Specifies the vCPU:pCPU overcommit ratio per cluster (e.g., 6 or 6.5 for 6.5:1) when a CRITICAL threshold is reached. Only applies if cluster capacity mode is enabled."
+	vCPUsClusterRatioWarningFlagHelp                string = "Specifies the vCPU:pCPU overcommit ratio per cluster (e.g., 4 or 4.5 for 4.5:1) when a WARNING threshold is reached. Only applies if cluster capacity mode is enabled."
 	hostCustomAttributeNameFlagHelp                 string = "Custom attribute name specific to host ESXi systems. Optional if specifying shared custom attribute flag."
 	hostCustomAttributePrefixSeparatorFlagHelp      string = "Custom attribute prefix separator specific to host ESXi systems. Skip if using custom Attribute values as-is for comparison, otherwise optional if specifying shared custom attribute prefix separator, or using the default separator."
 	datastoreCustomAttributeNameFlagHelp            string = "Custom attribute name specific to datastores. Optional if specifying shared custom attribute flag."
@@ -54,12 +75,16 @@ const (
 	datacenterNamesFlagHelp                         string = "Specifies the name of one or more vSphere Datacenters. If not specified, applicable plugins will attempt to evaluate all visible datacenters found in the vSphere environment. Not applicable to standalone ESXi hosts."
 	clusterNameFlagHelp                             string = "Specifies the name of a vSphere Cluster. If not specified, applicable plugins will attempt to use the default cluster found in the vSphere environment. Not applicable to standalone ESXi hosts."
 	snapshotsAgeCriticalFlagHelp                    string = "Specifies the age of a snapshot in days when a CRITICAL threshold is reached."
+	includeSnapshotFlagHelp                         string = "Specifies a comma-separated list of snapshot name or description substrings (case-insensitive; glob or regex patterns if enabled via the pattern-match flag). If specified, only snapshots with a matching name or description are evaluated. Explicit exclusions have precedence over explicit inclusions."
+	excludeSnapshotFlagHelp                         string = "Specifies a comma-separated list of snapshot name or description substrings (case-insensitive; glob or regex patterns if enabled via the pattern-match flag) for snapshots that should be excluded from evaluation (e.g., temporary snapshots created by backup tools)."
 	snapshotsAgeWarningFlagHelp                     string = "Specifies the age of a snapshot in days when a WARNING threshold is reached."
 	snapshotsCountCriticalFlagHelp                  string = "Specifies the number of snapshots per VM when a CRITICAL threshold is reached."
 	snapshotsCountWarningFlagHelp                   string = "Specifies the number of snapshots per VM when a WARNING threshold is reached."
-	snapshotsSizeCriticalFlagHelp                   string = "Specifies the cumulative size in GB of all snapshots for a Virtual Machine when a CRITICAL threshold is reached."
-	snapshotsSizeWarningFlagHelp                    string = "Specifies the cumulative size in GB of all snapshots for a Virtual Machine when a WARNING threshold is reached."
-	resourcePoolsMemoryMaxAllowedFlagHelp           string = "Specifies the maximum amount of memory that we are allowed to consume in GB (as a whole number) in the target VMware environment across all specified Resource Pools. VMs that are running outside of resource pools are not considered in these calculations."
+	snapshotsTotalCountCriticalFlagHelp             string = "Specifies the total number of snapshots across all evaluated VMs when a CRITICAL threshold is reached. Aggregate evaluation is disabled by default (0) and is performed in addition to per VM evaluation."
+	snapshotsTotalCountWarningFlagHelp              string = "Specifies the total number of snapshots across all evaluated VMs when a WARNING threshold is reached. Aggregate evaluation is disabled by default (0) and is performed in addition to per VM evaluation."
+	snapshotsSizeCriticalFlagHelp                   string = "Specifies the cumulative size of all snapshots for a Virtual Machine when a CRITICAL threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	snapshotsSizeWarningFlagHelp                    string = "Specifies the cumulative size of all snapshots for a Virtual Machine when a WARNING threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	resourcePoolsMemoryMaxAllowedFlagHelp           string = "Specifies the maximum amount of memory that we are allowed to consume in the target VMware environment across all specified Resource Pools. VMs that are running outside of resource pools are not considered in these calculations. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
 	resourcePoolsMemoryUseCriticalFlagHelp          string = "Specifies the percentage of memory use (as a whole number) across all specified Resource Pools when a CRITICAL threshold is reached."
 	resourcePoolsMemoryUseWarningFlagHelp           string = "Specifies the percentage of memory use (as a whole number) across all specified Resource Pools when a WARNING threshold is reached."
 	hostSystemMemoryUseCriticalFlagHelp             string = "Specifies the percentage of memory use (as a whole number) when a CRITICAL threshold is reached."
@@ -75,6 +100,7 @@ const (
 	vmBackupDateTimezoneFlagHelp                    string = "Specifies the time zone for the specified custom attribute used by virtual machine backup software to record when the last backup occurred. Requires tz database format (e.g., Europe/Amsterdam, America/New_York, Europe/Paris). See also https://en.wikipedia.org/wiki/Tz_database for examples."
 	vmPowerCycleUptimeCriticalFlagHelp              string = "Specifies the power cycle (off/on) uptime in days per VM when a CRITICAL threshold is reached."
 	vmPowerCycleUptimeWarningFlagHelp               string = "Specifies the power cycle (off/on) uptime in days per VM when a WARNING threshold is reached."
+	vmPowerCycleUptimeSourceFlagHelp                string = "Specifies the source of power cycle uptime values. Supported values are quickstats (uptime reported by VM QuickStats) and boot-time (time elapsed since the VM boot time). The boot-time source remains available when QuickStats uptime is not (e.g., host disconnected, delayed stats)."
 	virtualHardwareOutdatedByCriticalFlagHelp       string = "If provided, this value is the CRITICAL threshold for outdated virtual hardware versions. If the current virtual hardware version for a VM is found to be more than this many versions older than the latest version a CRITICAL state is triggered. Required if specifying the WARNING threshold for outdated virtual hardware versions."
 	virtualHardwareOutdatedByWarningFlagHelp        string = "If provided, this value is the WARNING threshold for outdated virtual hardware versions. If the current virtual hardware version for a VM is found to be more than this many versions older than the latest version a WARNING state is triggered. Required if specifying the CRITICAL threshold for outdated virtual hardware versions."
 	virtualHardwareMinimumVersionFlagHelp           string = "If provided, this value is the minimum virtual hardware version accepted for each Virtual Machine. Any Virtual Machine not meeting this minimum value is considered to be in a CRITICAL state. Per KB 1003746, version 3 appears to be the oldest version supported."
@@ -92,7 +118,239 @@ const (
 	excludedAlarmStatusesFlagHelp                   string = "If specified, triggered alarms will only be evaluated if the alarm status (e.g., \"yellow\") DOES NOT case-insensitively match one of the specified keywords (e.g., \"yellow\" or \"warning\") and is not explicitly excluded by another filter in the pipeline; while multiple explicit inclusions are allowed, explicit exclusions have precedence over explicit inclusions and will exclude the triggered alarm from further evaluation."
 	includedAlarmEntityResourcePoolsFlagHelp        string = "If specified, triggered alarms will only be evaluated if the associated entity is part of one of the specified Resource Pools (case-insensitive match on the name) and is not explicitly excluded by another filter in the pipeline; while multiple explicit inclusions are allowed, explicit exclusions have precedence over explicit inclusions and will exclude the triggered alarm from further evaluation."
 	excludedAlarmEntityResourcePoolsFlagHelp        string = "If specified, triggered alarms will only be evaluated if the associated entity is NOT part of one of the specified Resource Pools (case-insensitive match on the name) and is not explicitly excluded by another filter in the pipeline; while multiple explicit inclusions are allowed, explicit exclusions have precedence over explicit inclusions and will exclude the triggered alarm from further evaluation."
+	vsanClusterNameFlagHelp                         string = "Specifies the name of a vSAN enabled vSphere Cluster. If not specified, all visible vSAN enabled clusters are evaluated."
+	vsanHealthRefreshFlagHelp                       string = "Toggles triggering a new (potentially slow) vSAN health check run instead of using cached health test results. Using cached results is the default."
+	ignoredVSANHealthTestsFlagHelp                  string = "Specifies a comma-separated list of vSAN health test IDs or names (case-insensitive) to ignore when evaluating vSAN health."
+	hostServicesHostNameFlagHelp                    string = "ESXi host/server name as it is found within the vSphere inventory. If not specified, all visible hosts are evaluated."
+	requiredHostServicesFlagHelp                    string = "Specifies a comma-separated list of ESXi host service keys or labels (case-insensitive, e.g., ntpd or \"NTP Daemon\") that are required to be running. A CRITICAL state is returned if a required service is not running or is not found."
+	disallowedHostServicesFlagHelp                  string = "Specifies a comma-separated list of ESXi host service keys or labels (case-insensitive, e.g., TSM-SSH or \"SSH\") that are required to be disabled. A WARNING state is returned if a disallowed service is running or is configured to start and stop with the host."
+	licenseUsageCriticalFlagHelp                    string = "Specifies the percentage of license capacity used (as a whole number) when a CRITICAL threshold is reached. Usage exceeding license capacity is always considered CRITICAL."
+	licenseUsageWarningFlagHelp                     string = "Specifies the percentage of license capacity used (as a whole number) when a WARNING threshold is reached."
+	licenseFeaturesFlagHelp                         string = "Specifies a comma-separated list of licensed feature names (case-insensitive substring match, e.g., vSAN, DRS or Tanzu). If specified, only licenses providing one of the listed features are evaluated."
+	vmCPUReadyWarningFlagHelp                       string = "Specifies the percentage of CPU ready time per vCPU (as a whole number) when a WARNING threshold is reached."
+	vmCPUReadyCriticalFlagHelp                      string = "Specifies the percentage of CPU ready time per vCPU (as a whole number) when a CRITICAL threshold is reached."
+	vmCPUCoStopWarningFlagHelp                      string = "Specifies the percentage of CPU co-stop time per vCPU (as a whole number) when a WARNING threshold is reached."
+	vmCPUCoStopCriticalFlagHelp                     string = "Specifies the percentage of CPU co-stop time per vCPU (as a whole number) when a CRITICAL threshold is reached."
+	folderVMCountsFolderIDFlagHelp                  string = "Specifies a comma-separated list of Folder Managed Object ID (MOID) values (e.g., group-v34) for folders whose VM counts should be evaluated. VMs within nested folders are included in the count."
+	folderVMCountMaxWarningFlagHelp                 string = "Specifies the number of VMs in a folder above which a WARNING threshold is reached (e.g., 0 to require an empty folder)."
+	folderVMCountMaxCriticalFlagHelp                string = "Specifies the number of VMs in a folder above which a CRITICAL threshold is reached (e.g., 0 to require an empty folder)."
+	folderVMCountMinWarningFlagHelp                 string = "Specifies the number of VMs in a folder below which a WARNING threshold is reached."
+	folderVMCountMinCriticalFlagHelp                string = "Specifies the number of VMs in a folder below which a CRITICAL threshold is reached."
+	vmMemoryBalloonedWarningFlagHelp                string = "Specifies the percentage of configured memory reclaimed by the balloon driver (as a whole number) when a WARNING threshold is reached."
+	vmMemoryBalloonedCriticalFlagHelp               string = "Specifies the percentage of configured memory reclaimed by the balloon driver (as a whole number) when a CRITICAL threshold is reached."
+	vmMemorySwappedWarningFlagHelp                  string = "Specifies the percentage of configured memory swapped to disk (as a whole number) when a WARNING threshold is reached."
+	vmMemorySwappedCriticalFlagHelp                 string = "Specifies the percentage of configured memory swapped to disk (as a whole number) when a CRITICAL threshold is reached."
+	vmMemoryCompressedWarningFlagHelp               string = "Specifies the percentage of configured memory compressed (as a whole number) when a WARNING threshold is reached."
+	vmMemoryCompressedCriticalFlagHelp              string = "Specifies the percentage of configured memory compressed (as a whole number) when a CRITICAL threshold is reached."
+	clusterResourceUsageClusterNameFlagHelp         string = "Specifies the name of a vSphere Cluster. If not specified, all visible clusters are evaluated."
+	clusterCPUUseCriticalFlagHelp                   string = "Specifies the percentage of effective cluster CPU capacity used (as a whole number) when a CRITICAL threshold is reached."
+	clusterCPUUseWarningFlagHelp                    string = "Specifies the percentage of effective cluster CPU capacity used (as a whole number) when a WARNING threshold is reached."
+	clusterMemoryUseCriticalFlagHelp                string = "Specifies the percentage of effective cluster memory used (as a whole number) when a CRITICAL threshold is reached."
+	clusterMemoryUseWarningFlagHelp                 string = "Specifies the percentage of effective cluster memory used (as a whole number) when a WARNING threshold is reached."
+	requiredVASAProvidersFlagHelp                   string = "Specifies a comma-separated list of VASA storage provider names which are required to be registered."
+	vasaCertExpiryCriticalFlagHelp                  string = "Specifies the number of days remaining before a VASA provider certificate expires when a CRITICAL threshold is reached."
+	vasaCertExpiryWarningFlagHelp                   string = "Specifies the number of days remaining before a VASA provider certificate expires when a WARNING threshold is reached."
+	clusterHAStatusClusterNamesFlagHelp             string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated."
+	vvolDatastoreNameFlagHelp                       string = "Specifies the name of a vVol datastore. If not specified, all visible vVol datastores are evaluated."
+	emergencyThresholdFlagHelp                      string = "Specifies an optional emergency threshold (using the same unit as the CRITICAL threshold) which, when crossed, flags the CRITICAL state as an emergency via an [EMERGENCY] output prefix and emergency performance data metric. This is not set by default."
+	clusterDRSStatusClusterNamesFlagHelp            string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated."
+	requiredDRSBehaviorFlagHelp                     string = "Specifies the minimum DRS automation level (manual, partiallyAutomated, fullyAutomated) required for evaluated clusters. A less automated level results in a WARNING state."
+	drsRecommendationsCriticalFlagHelp              string = "Specifies the number of pending DRS recommendations above which a CRITICAL threshold is reached."
+	drsRecommendationsWarningFlagHelp               string = "Specifies the number of pending DRS recommendations above which a WARNING threshold is reached."
+	includedHostSensorsFlagHelp                     string = "Specifies a comma-separated list of ESXi host hardware sensor names or name substrings (case-insensitive) used to limit evaluation to matching sensors. If not specified, all sensors are evaluated."
+	excludedHostSensorsFlagHelp                     string = "Specifies a comma-separated list of ESXi host hardware sensor names or name substrings (case-insensitive, e.g., \"Fan Device 3\") that are excluded from evaluation. Exclusions have precedence over inclusions."
+	vmFTLatencyCriticalFlagHelp                     string = "Specifies the Fault Tolerance secondary VM latency in milliseconds when a CRITICAL threshold is reached. A red latency status reported by vSphere also results in a CRITICAL state."
+	vmFTLatencyWarningFlagHelp                      string = "Specifies the Fault Tolerance secondary VM latency in milliseconds when a WARNING threshold is reached. A yellow latency status reported by vSphere also results in a WARNING state."
+	vmFTBandwidthCriticalFlagHelp                   string = "Specifies the Fault Tolerance logging (checkpoint) bandwidth in Mbps when a CRITICAL threshold is reached."
+	vmFTBandwidthWarningFlagHelp                    string = "Specifies the Fault Tolerance logging (checkpoint) bandwidth in Mbps when a WARNING threshold is reached."
+	toolsNoIPGracePeriodFlagHelp                    string = "Specifies the number of minutes after power on that a VM with VMware Tools running is allowed to go without reporting an IP Address. VMs powered on for less than this grace period are listed, but do not result in a WARNING state."
+	acknowledgedAlarmsMaxStateFlagHelp              string = "If specified, acknowledged triggered alarms are evaluated (and listed), but contribute at most the specified state (ok, warning, critical) to the overall plugin state. This is useful for treating acknowledged alarms as \"known, still degraded\". Acknowledged triggered alarms are excluded from evaluation by default."
+	alarmDatastoreVMsFlagHelp                       string = "Toggles listing of the VMs residing on the affected datastore for non-excluded triggered alarms associated with a Datastore entity. This helps to gauge the impact of an alarm without logging into vSphere."
+	alarmTriggeredSinceFlagHelp                     string = "If specified, only triggered alarms triggered at or after the given point in time are evaluated. Accepts a duration relative to now (e.g., 30m, 2h, 1h30m) or an absolute RFC3339 timestamp (e.g., 2021-06-01T15:04:05Z). Useful for only alerting on recent alarms."
+	alarmTriggeredBeforeFlagHelp                    string = "If specified, only triggered alarms triggered at or before the given point in time are evaluated. Accepts a duration relative to now (e.g., 15m, 1h) or an absolute RFC3339 timestamp (e.g., 2021-06-01T15:04:05Z). Useful for providing a grace period for brand-new alarms."
+	alarmIncludedTagsFlagHelp                       string = "Specifies a comma-separated list of vSphere Tags used to explicitly include triggered alarms for evaluation. Tags may be specified by name (matching a tag of that name in any category) or qualified by category name (e.g., Tier:Production). Triggered alarms are only evaluated if the associated entity has at least one matching tag and the alarm is not explicitly excluded by another filter in the pipeline. This option is incompatible with specifying a list of vSphere Tags to exclude."
+	alarmExcludedTagsFlagHelp                       string = "Specifies a comma-separated list of vSphere Tags used to explicitly exclude triggered alarms from evaluation. Tags may be specified by name (matching a tag of that name in any category) or qualified by category name (e.g., Tier:Development). Triggered alarms are excluded if the associated entity has any matching tag. This option is incompatible with specifying a list of vSphere Tags to include."
+	alarmMaintenanceModeActionFlagHelp              string = "Specifies how triggered alarms are handled for ESXi hosts in maintenance mode and for VMs running on those hosts. Supported values: evaluate (no special handling), exclude (exclude triggered alarms from evaluation), warning or ok (triggered alarms are listed, but contribute at most the specified state to the overall plugin state)."
+	certificateExpiryCriticalFlagHelp               string = "Specifies the number of days remaining before a vCenter or ESXi host certificate expires when a CRITICAL threshold is reached."
+	certificateExpiryWarningFlagHelp                string = "Specifies the number of days remaining before a vCenter or ESXi host certificate expires when a WARNING threshold is reached."
+	excludeHostCertificatesFlagHelp                 string = "Toggles evaluation of ESXi host certificates retrieved from the HostCertificateManager. If specified, only the certificate presented by the vSphere endpoint used for the plugin connection is evaluated."
+	certificatesHostNameFlagHelp                    string = "ESXi host/server name as it is found within the vSphere inventory. If not specified, the certificates for all ESXi hosts are evaluated."
+	licenseExpiryCriticalFlagHelp                   string = "Specifies the number of days remaining before a license expires when a CRITICAL threshold is reached. Expired licenses are always considered CRITICAL."
+	licenseExpiryWarningFlagHelp                    string = "Specifies the number of days remaining before a license expires when a WARNING threshold is reached."
+	inventoryDriftCriticalFlagHelp                  string = "Specifies the number of inventory objects of any kind (hosts, VMs, datastores, networks) added or removed within a datacenter between plugin runs when a CRITICAL threshold is reached."
+	inventoryDriftWarningFlagHelp                   string = "Specifies the number of inventory objects of any kind (hosts, VMs, datastores, networks) added or removed within a datacenter between plugin runs when a WARNING threshold is reached."
+	inventoryStateFileFlagHelp                      string = "Fully-qualified path to the state file used to record inventory object counts between plugin runs. The state file is created if it does not exist and is updated on each plugin run. A unique state file should be used for each monitored vSphere environment."
+	tasksLookbackFlagHelp                           string = "Specifies the number of minutes prior to plugin execution evaluated for failed vCenter tasks."
+	failedTasksCriticalFlagHelp                     string = "Specifies the number of failed tasks within the lookback window when a CRITICAL threshold is reached."
+	failedTasksWarningFlagHelp                      string = "Specifies the number of failed tasks within the lookback window when a WARNING threshold is reached."
+	includeTaskIDFlagHelp                           string = "Specifies a comma-separated list of task description IDs (e.g., VirtualMachine.clone, Drm.ExecuteVMotionLRO) that should be explicitly included for evaluation (case-insensitive). Failed tasks not matching one of the listed IDs are ignored."
+	excludeTaskIDFlagHelp                           string = "Specifies a comma-separated list of task description IDs (e.g., VirtualMachine.clone, Drm.ExecuteVMotionLRO) that should be explicitly excluded from evaluation (case-insensitive)."
+	includeTaskUserFlagHelp                         string = "Specifies a comma-separated list of user names (e.g., VSPHERE.LOCAL\\backup-svc) that should be explicitly included for evaluation (case-insensitive). Failed tasks not initiated by one of the listed users are ignored."
+	excludeTaskUserFlagHelp                         string = "Specifies a comma-separated list of user names (e.g., VSPHERE.LOCAL\\backup-svc) that should be explicitly excluded from evaluation (case-insensitive)."
+	shellSSHRunningCriticalFlagHelp                 string = "Specifies the number of minutes that the ESXi Shell or SSH service may run on a host before a CRITICAL threshold is reached."
+	shellSSHRunningWarningFlagHelp                  string = "Specifies the number of minutes that the ESXi Shell or SSH service may run on a host before a WARNING threshold is reached."
+	allowShellSSHHostFlagHelp                       string = "Specifies a comma-separated list of ESXi host names (case-insensitive) permitted to run the ESXi Shell or SSH service without time limit."
+	hostDNSRoutingClusterNamesFlagHelp              string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated."
+	expectedDNSServerFlagHelp                       string = "Specifies a comma-separated list of DNS server IP addresses that all evaluated hosts are expected to use. If not specified, the most common list of DNS servers within each cluster is expected."
+	expectedSearchDomainFlagHelp                    string = "Specifies a comma-separated list of DNS search domains that all evaluated hosts are expected to use. If not specified, the most common list of search domains within each cluster is expected."
+	expectedIPv4GatewayFlagHelp                     string = "Specifies the IPv4 default gateway that all evaluated hosts are expected to use. If not specified, the most common IPv4 default gateway within each cluster is expected."
+	expectedIPv6GatewayFlagHelp                     string = "Specifies the IPv6 default gateway that all evaluated hosts are expected to use. If not specified, the most common IPv6 default gateway within each cluster is expected."
+	eventsLookbackFlagHelp                          string = "Specifies the number of minutes prior to plugin execution evaluated for matching vCenter events."
+	matchedEventsCriticalFlagHelp                   string = "Specifies the number of matching events within the lookback window when a CRITICAL threshold is reached."
+	matchedEventsWarningFlagHelp                    string = "Specifies the number of matching events within the lookback window when a WARNING threshold is reached."
+	includeEventTypeFlagHelp                        string = "Specifies a comma-separated list of event type IDs (e.g., HostConnectionLostEvent, VmFailedToPowerOnEvent, esx.problem.vmfs.heartbeat.timedout) used to match events for evaluation (case-insensitive)."
+	excludeEventTypeFlagHelp                        string = "Specifies a comma-separated list of event type IDs that should be explicitly excluded from evaluation (case-insensitive)."
+	includeEventMessageFlagHelp                     string = "Specifies a comma-separated list of substrings used to match events for evaluation by event message (case-insensitive)."
+	excludeEventMessageFlagHelp                     string = "Specifies a comma-separated list of event message substrings that should be explicitly excluded from evaluation (case-insensitive)."
+	excludeEventEntityNameFlagHelp                  string = "Specifies a comma-separated list of entity name substrings (e.g., VM, host or datastore names) for events that should be explicitly excluded from evaluation (case-insensitive)."
+	excludeEventUserNameFlagHelp                    string = "Specifies a comma-separated list of user name substrings for events that should be explicitly excluded from evaluation (case-insensitive)."
+	snapshotsDatastoreQuotaCriticalFlagHelp         string = "Specifies the cumulative size of all snapshots stored on a datastore when a CRITICAL threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	snapshotsDatastoreQuotaWarningFlagHelp          string = "Specifies the cumulative size of all snapshots stored on a datastore when a WARNING threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	includeDatastoreFlagHelp                        string = "Specifies a comma-separated list of Datastore names that should be exclusively used when evaluating snapshots. Snapshots stored on all other datastores are ignored."
+	excludeDatastoreSnapshotsFlagHelp               string = "Specifies a comma-separated list of Datastore names that should be ignored when evaluating snapshots."
+	listDatastoresFlagHelp                          string = "Toggles listing the names of datastores (optionally filtered by datacenter and name pattern) instead of performing an evaluation. This is intended to help with building monitoring configuration."
+	listClustersFlagHelp                            string = "Toggles listing the names of clusters (optionally filtered by datacenter and name pattern) instead of performing an evaluation. This is intended to help with building monitoring configuration."
+	listHostsFlagHelp                               string = "Toggles listing the names of ESXi hosts (optionally filtered by datacenter and name pattern) instead of performing an evaluation. This is intended to help with building monitoring configuration."
+	listObjectsPatternFlagHelp                      string = "Specifies an optional case-insensitive glob pattern (e.g., esx*.example.com) used to filter the object names listed when the list flag is specified."
+	orphanedVMDKsSizeCriticalFlagHelp               string = "Specifies the cumulative size of all orphaned VMDK files when a CRITICAL threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	orphanedVMDKsSizeWarningFlagHelp                string = "Specifies the cumulative size of all orphaned VMDK files when a WARNING threshold is reached. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	orphanedVMDKsIncludeDatastoreFlagHelp           string = "Specifies a comma-separated list of Datastore names that should be exclusively searched for orphaned VMDK files. All other datastores are ignored."
+	orphanedVMDKsIgnoreDatastoreFlagHelp            string = "Specifies a comma-separated list of Datastore names that should not be searched for orphaned VMDK files."
+	ignoreVMDKPathFlagHelp                          string = "Specifies a comma-separated list of datastore path substrings (e.g., \"[ds1] templates/\") for known-good VMDK files that should be ignored when evaluating orphaned VMDK files (case-insensitive)."
+	genConfigHostPatternFlagHelp                    string = "Specifies an optional case-insensitive glob pattern (e.g., esx*.example.com) used to filter the ESXi hosts included in generated Nagios object definitions. All hosts are included if not specified."
+	genConfigDatastorePatternFlagHelp               string = "Specifies an optional case-insensitive glob pattern (e.g., HUSVM-DC1-*) used to filter the datastores included in generated Nagios object definitions. All datastores are included if not specified."
+	genConfigClusterPatternFlagHelp                 string = "Specifies an optional case-insensitive glob pattern (e.g., prod-*) used to filter the clusters included in generated Nagios object definitions. All clusters are included if not specified."
+	genConfigTemplateFileFlagHelp                   string = "Specifies the optional path to a Go text/template file used to render Nagios object definitions. A built-in template generating service definitions for the contrib command definitions provided by this project is used if not specified."
+	genConfigOutputFileFlagHelp                     string = "Specifies the optional path to a file where rendered Nagios object definitions are written. Output is written to stdout if not specified."
+	genConfigNagiosHostFlagHelp                     string = "Specifies the Nagios host_name that generated service definitions are associated with. The server value is used if not specified."
+	genConfigHostDefinitionFlagHelp                 string = "Toggles whether a Nagios host definition for the vSphere environment is rendered along with service definitions."
+	allowMediaVMFlagHelp                            string = "Specifies a comma-separated list of VM names which are permitted to have connected CD-ROM or floppy media (case-insensitive)."
+	allowMediaPathFlagHelp                          string = "Specifies a comma-separated list of ISO or floppy image path substrings (e.g., \"[ISOs] vmware-tools/\") which are permitted to remain connected to VMs (case-insensitive)."
+	vmGuestDiskUsageWarningFlagHelp                 string = "Specifies the percentage of guest filesystem usage (as a whole number) when a WARNING threshold is reached."
+	vmGuestDiskUsageCriticalFlagHelp                string = "Specifies the percentage of guest filesystem usage (as a whole number) when a CRITICAL threshold is reached."
+	vmGuestDiskMountThresholdFlagHelp               string = "Specifies usage thresholds for guest filesystems with a matching mount point in MOUNT:WARNING:CRITICAL format (e.g., \"/var:85:95\" or \"C:\\:90:95\"). The mount point may be a case-insensitive glob pattern. This flag may be repeated; the first matching pattern applies. Filesystems not matching any pattern use the default thresholds."
+	vmGuestDiskExcludeMountFlagHelp                 string = "Specifies a comma-separated list of case-insensitive glob patterns (e.g., \"/snap/*\") for guest filesystem mount points that should be excluded from evaluation."
+	runawayVMCPUShareWarningFlagHelp                string = "Specifies the percentage of a Resource Pool's CPU usage consumed by a single VM (as a whole number) when a WARNING threshold is reached."
+	runawayVMCPUShareCriticalFlagHelp               string = "Specifies the percentage of a Resource Pool's CPU usage consumed by a single VM (as a whole number) when a CRITICAL threshold is reached."
+	runawayVMMemoryShareWarningFlagHelp             string = "Specifies the percentage of a Resource Pool's memory usage consumed by a single VM (as a whole number) when a WARNING threshold is reached."
+	runawayVMMemoryShareCriticalFlagHelp            string = "Specifies the percentage of a Resource Pool's memory usage consumed by a single VM (as a whole number) when a CRITICAL threshold is reached."
+	runawayVMTopCountFlagHelp                       string = "Specifies the number of top CPU and memory consuming VMs listed for each Resource Pool."
+	vmToolsMinVersionFlagHelp                       string = "Specifies the minimum acceptable VMware Tools version number as reported by the vSphere API (e.g., 12352 for version 12.2.0). VMs with an older VMware Tools version are considered to be in a CRITICAL state. The default value of zero disables this check."
+	allowAffinityVMFlagHelp                         string = "Specifies a comma-separated list of VM names which are permitted to have CPU or NUMA node affinity configured (case-insensitive)."
+	propertyRetrievalWarningFlagHelp                string = "Specifies the number of milliseconds that property retrieval for a managed object type (e.g., VirtualMachine) may take before a notice is included in plugin output. Slow property retrieval may indicate vCenter inventory service degradation. A value of 0 disables the notice."
+	stateMapFlagHelp                                string = "Specifies a comma-separated list of FROM=TO Nagios state mappings (e.g., warning=ok,critical=warning) applied to the final plugin state. Supported FROM states are warning, critical and unknown. Supported TO states are ok, warning, critical and unknown. This flag may be repeated."
+	sessionCacheFlagHelp                            string = "Toggles caching of vSphere sessions between plugin executions. If enabled, a valid cached session is reused instead of performing a new login and the session is not logged out at the end of plugin execution. Expired sessions are transparently replaced."
+	sessionCacheDirFlagHelp                         string = "Specifies the fully-qualified path to the directory used to store cached vSphere sessions. If not specified, a check-vmware/sessions directory within the user cache directory (e.g., $HOME/.cache) is used."
+	evaluationManifestFlagHelp                      string = "Specifies the fully-qualified path to an optional JSON file used to record an evaluation manifest describing what was evaluated, what was skipped and why (e.g., VMs excluded by filters). If the path is an existing directory, a new file is created in that directory for each plugin execution."
+	redactNamesFlagHelp                             string = "Toggles redaction of VM, host and datastore names in plugin output (including performance data labels). If enabled, each name is replaced with a token derived from a keyed hash of the name (e.g., vm-9f86d081884c) and the tokens used are recorded in a local lookup file. The hash key is stored alongside the lookup file. This is disabled by default."
+	omitReportSectionFlagHelp                       string = "Specifies a comma-separated list of optional report sections to omit from the long service output in order to keep notifications focused. Supported values are recently-started-vms (most recently started VMs), top-consumers (VMs consuming the most of an evaluated resource) and top-ok-vms (VMs not yet exceeding thresholds). All sections are included by default."
+	otlpEndpointFlagHelp                            string = "Specifies the base URL of an OTLP/HTTP endpoint (e.g., http://localhost:4318) used to export OpenTelemetry traces for vSphere login and object retrieval operations. Traces are sent to the /v1/traces path of the given URL within the plugin timeout. Tracing is disabled unless this flag is specified."
+	redactNamesLookupFileFlagHelp                   string = "Specifies the fully-qualified path to the JSON file used to record the mapping of redacted name tokens to names. If not specified, a check-vmware/redacted-names.json file within the user cache directory (e.g., $HOME/.cache) is used."
+	summaryTemplateFlagHelp                         string = "Specifies an optional Go template string used to override the one-line summary format. This is typically specified in the plugin section of the configuration file. The template is validated at startup; an invalid template results in an UNKNOWN state."
+	timeDriftWarningFlagHelp                        string = "Specifies the number of seconds of clock drift between an ESXi host and the reference clock (the system running this plugin and optionally vCenter) when a WARNING threshold is reached."
+	timeDriftCriticalFlagHelp                       string = "Specifies the number of seconds of clock drift between an ESXi host and the reference clock (the system running this plugin and optionally vCenter) when a CRITICAL threshold is reached."
+	timeDriftCompareVCenterFlagHelp                 string = "Toggles comparison of ESXi host clocks against the vCenter server clock in addition to the clock of the system running this plugin. The vCenter server clock is also evaluated against the clock of the system running this plugin. This is disabled by default."
+	esxiImageProfileClusterNamesFlagHelp            string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated."
+	expectedImageProfileFlagHelp                    string = "Specifies the ESXi image profile name (e.g., ESXi-7.0U3i-20842708-standard) that all evaluated hosts are expected to use. If not specified, the most common image profile within each cluster is expected."
+	useVLCMDesiredImageFlagHelp                     string = "Toggles use of the vSphere Lifecycle Manager (vLCM) desired image (when available) to determine the expected ESXi build for hosts in each cluster. If not enabled, or if a cluster is not managed with a single image, the most common ESXi build within each cluster is expected."
+	vlcmComplianceClusterNamesFlagHelp              string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated. Clusters not managed with a single vSphere Lifecycle Manager (vLCM) image are listed but not evaluated."
+	vlcmNonCompliantWarningFlagHelp                 string = "Specifies the number of hosts not compliant with (or incompatible with) the vSphere Lifecycle Manager (vLCM) desired image for their cluster when a WARNING threshold is reached."
+	vlcmNonCompliantCriticalFlagHelp                string = "Specifies the number of hosts not compliant with (or incompatible with) the vSphere Lifecycle Manager (vLCM) desired image for their cluster when a CRITICAL threshold is reached."
+	alarmDefinitionsStateFileFlagHelp               string = "Fully-qualified path to the state file used to record alarm definition checksums between plugin runs. The state file is created if it does not exist and is updated on each plugin run. A unique state file should be used for each monitored vSphere environment."
+	patternMatchFlagHelp                            string = "Specifies the pattern matching mode used when evaluating name based include/exclude lists (e.g., VM names, Resource Pools, Folders, Clusters, ESXi hosts, alarm names, Datastores). Supported values are exact (case-insensitive literal match), glob (case-insensitive shell-style wildcard match) and regex (case-insensitive, unanchored regular expression match)."
+	requireCBRCFlagHelp                             string = "Toggles whether Content-Based Read Cache (CBRC) is required to be enabled on evaluated hosts. Hosts which do not support CBRC are not evaluated for this feature."
+	requireMemoryTieringFlagHelp                    string = "Toggles whether memory tiering (e.g., NVMe tiering) is required to be enabled on evaluated hosts. Hosts which do not support memory tiering are not evaluated for this feature."
+	hostAccelerationHostNameFlagHelp                string = "ESXi host/server name as it is found within the vSphere inventory. If not specified, all visible hosts are evaluated."
+	vmBackupTagCategoryFlagHelp                     string = "Specifies the name of the vSphere Tag category used by virtual machine backup software to record when the last backup occurred. The tag name is parsed as the backup date."
+	vmBackupTagPrefixFlagHelp                       string = "Specifies an optional prefix removed from the name of the backup date tag before the remaining value is parsed using the specified backup date format (e.g., \"Last Backup: \")."
+	alarmFilterFileFlagHelp                         string = "Specifies the fully-qualified path to an optional YAML file containing triggered alarm include/exclude filters. File keys match the names of the equivalent flags; list values are combined with flag values while single value settings specified via flag have precedence. YAML anchors and aliases may be used to define reusable lists."
+	vmCustomAttributeNameFlagHelp                   string = "Specifies the name of the custom attribute evaluated for virtual machines."
+	vmCustomAttributePatternFlagHelp                string = "Specifies an optional regular expression which the custom attribute value must match."
+	vmCustomAttributeMinFlagHelp                    string = "Specifies an optional (inclusive) minimum for the custom attribute value. Evaluated numerically unless a date format is specified, in which case this value is parsed using the date format."
+	vmCustomAttributeMaxFlagHelp                    string = "Specifies an optional (inclusive) maximum for the custom attribute value. Evaluated numerically unless a date format is specified, in which case this value is parsed using the date format."
+	vmCustomAttributeDateFormatFlagHelp             string = "Specifies an optional format used to parse the custom attribute value (and minimum/maximum values) as a date. Requires the layout string format used by the Go time package. See also https://pkg.go.dev/time#pkg-constants for examples."
+	vcenterDatabaseUsageCriticalFlagHelp            string = "Specifies the percentage of a vCenter database partition's space usage (as a whole number) when a CRITICAL threshold is reached."
+	vcenterDatabaseUsageWarningFlagHelp             string = "Specifies the percentage of a vCenter database partition's space usage (as a whole number) when a WARNING threshold is reached."
+	requiredVCenterServicesFlagHelp                 string = "Specifies a comma-separated list of vCenter appliance service IDs (case-insensitive, e.g., vpxd or vsphere-ui) that are required to be running and healthy. A CRITICAL state is returned if a required service is not running, is degraded or is not found."
+	ignoredVCenterServicesFlagHelp                  string = "Specifies a comma-separated list of vCenter appliance service IDs (case-insensitive) that are ignored when evaluating services configured for automatic startup and service health."
+	exporterListenAddressFlagHelp                   string = "Specifies the TCP address (host:port) that the metrics exporter listens on. Metrics are served from the /metrics path."
+	exporterCollectionIntervalFlagHelp              string = "Specifies the number of seconds between collections of vSphere metrics. Scrapes are served from the most recent collection. The timeout value applies to each collection attempt."
+	alarmActionObjectTypeFlagHelp                   string = "Specifies a comma-separated list of inventory object types (datacenter, cluster, host) evaluated for disabled alarm actions. All supported object types are evaluated if not specified."
+	ignoreAlarmActionObjectFlagHelp                 string = "Specifies a comma-separated list of inventory object names (case-insensitive) that are ignored when evaluating alarm actions (e.g., hosts permanently in maintenance)."
+	allowIndependentDiskVMFlagHelp                  string = "Specifies a comma-separated list of VM names which are permitted to have independent (persistent or nonpersistent) disks (case-insensitive)."
+	vfUsageWarningFlagHelp                          string = "Specifies the percentage of SR-IOV virtual functions on a host demanded by VMs registered on the host (as a whole number) when a WARNING threshold is reached."
+	vfUsageCriticalFlagHelp                         string = "Specifies the percentage of SR-IOV virtual functions on a host demanded by VMs registered on the host (as a whole number) when a CRITICAL threshold is reached. Demand exceeding the virtual functions present on a physical function is always considered CRITICAL."
+	gpuAllocationWarningFlagHelp                    string = "Specifies the percentage of GPU framebuffer memory on a host allocated to VMs (as a whole number) when a WARNING threshold is reached. The percentage of GPUs in use is evaluated if framebuffer capacity is not available."
+	gpuAllocationCriticalFlagHelp                   string = "Specifies the percentage of GPU framebuffer memory on a host allocated to VMs (as a whole number) when a CRITICAL threshold is reached. The percentage of GPUs in use is evaluated if framebuffer capacity is not available."
+	gpuUtilizationWarningFlagHelp                   string = "Specifies the percentage of GPU utilization (highest recent average of any GPU on a host, as a whole number) when a WARNING threshold is reached."
+	gpuUtilizationCriticalFlagHelp                  string = "Specifies the percentage of GPU utilization (highest recent average of any GPU on a host, as a whole number) when a CRITICAL threshold is reached."
+	allowRDMVMFlagHelp                              string = "Specifies a comma-separated list of VM names which are permitted to use Raw Device Mappings (RDMs) in physical or virtual compatibility mode (case-insensitive)."
+	datastoreSpaceIncludeDatastoreFlagHelp          string = "Specifies a comma-separated list of Datastore names that should be exclusively evaluated for space usage. All other datastores in scope are ignored. Enables evaluation of multiple datastores; incompatible with specifying a single datastore name."
+	datastoreSpaceIgnoreDatastoreFlagHelp           string = "Specifies a comma-separated list of Datastore names that should not be evaluated for space usage. Enables evaluation of multiple datastores; incompatible with specifying a single datastore name."
+	datastoreSpaceClusterNameFlagHelp               string = "Specifies the name of a vSphere Cluster. If specified, the datastores available to the cluster are evaluated for space usage instead of a single datastore."
+	hostUsageClusterNameFlagHelp                    string = "Specifies the name of a vSphere Cluster. If specified, all ESXi hosts in the cluster are evaluated instead of a single host. The state of the host with the highest usage determines the plugin state."
+	hostUsageIncludeHostFlagHelp                    string = "Specifies a comma-separated list of ESXi host names that should be exclusively evaluated. All other hosts in scope (the specified cluster or datacenter) are ignored. Enables evaluation of multiple hosts; incompatible with specifying a single host name."
+	hostUsageExcludeHostFlagHelp                    string = "Specifies a comma-separated list of ESXi host names that should not be evaluated. Enables evaluation of multiple hosts; incompatible with specifying a single host name."
+	allDatastoresFlagHelp                           string = "Toggles evaluation of all datastores within the specified (or default) datacenter (or cluster if specified) instead of a single datastore. The state of the datastore with the highest space usage determines the plugin state."
+	clusterDASIsolationClusterNamesFlagHelp         string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated. Clusters with vSphere HA disabled are listed but not evaluated."
+	isolationAddressFlagHelp                        string = "Specifies a comma-separated list of vSphere HA isolation addresses (das.isolationaddressX advanced options) which are required to be configured for each evaluated cluster (e.g., one address per site for stretched clusters)."
+	isolationResponseFlagHelp                       string = "Specifies a comma-separated list of vSphere HA host isolation responses (none, powerOff, shutdown) permitted for evaluated clusters. If not specified, powerOff and shutdown are permitted."
+	minIsolationAddressesFlagHelp                   string = "Specifies the minimum number of custom vSphere HA isolation addresses (das.isolationaddressX advanced options) required to be configured for each evaluated cluster."
+	allowDefaultIsolationAddressFlagHelp            string = "Toggles whether use of the default vSphere HA isolation address (the default gateway of the management network) is permitted. Stretched clusters should instead use custom isolation addresses local to each site with das.usedefaultisolationaddress set to false."
+	datastoreOvercommitWarningFlagHelp              string = "Specifies the percentage of a datastore's capacity provisioned to VMs (as a whole number) when a WARNING threshold is reached. Provisioned space includes space used by VM files (including snapshots) and space not yet used by thin provisioned disks. Values over 100 indicate overcommitment."
+	datastoreOvercommitCriticalFlagHelp             string = "Specifies the percentage of a datastore's capacity provisioned to VMs (as a whole number) when a CRITICAL threshold is reached. Provisioned space includes space used by VM files (including snapshots) and space not yet used by thin provisioned disks. Values over 100 indicate overcommitment."
+	datastoreOvercommitIncludeDatastoreFlagHelp     string = "Specifies a comma-separated list of Datastore names that should be exclusively evaluated for overcommitment. All other datastores in scope are ignored."
+	datastoreOvercommitIgnoreDatastoreFlagHelp      string = "Specifies a comma-separated list of Datastore names that should not be evaluated for overcommitment."
+	datastoreOvercommitClusterNameFlagHelp          string = "Specifies the name of a vSphere Cluster. If specified, the datastores available to the cluster are evaluated instead of all datastores within the specified (or default) datacenter."
+	stretchedClusterNamesFlagHelp                   string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated. Clusters for which sites cannot be determined are listed but not evaluated for site balance."
+	siteHostGroupFlagHelp                           string = "Specifies a comma-separated list of DRS host group names used to define the sites of evaluated stretched clusters (e.g., one host group per site). If not specified, vSAN fault domains are used to define sites."
+	siteImbalanceWarningFlagHelp                    string = "Specifies the difference (in percentage points of all powered on VMs, as a whole number) between the sites with the most and the fewest powered on VMs when a WARNING threshold is reached."
+	siteImbalanceCriticalFlagHelp                   string = "Specifies the difference (in percentage points of all powered on VMs, as a whole number) between the sites with the most and the fewest powered on VMs when a CRITICAL threshold is reached."
+	datastoreAccessibilityIncludeDatastoreFlagHelp  string = "Specifies a comma-separated list of Datastore names that should be exclusively evaluated for accessibility. All other datastores in scope are ignored."
+	datastoreAccessibilityIgnoreDatastoreFlagHelp   string = "Specifies a comma-separated list of Datastore names that should not be evaluated for accessibility."
+	datastoreAccessibilityClusterNameFlagHelp       string = "Specifies the name of a vSphere Cluster. If specified, the datastores available to the cluster are evaluated instead of all datastores within the specified (or default) datacenter."
+	allowMaintenanceDatastoreFlagHelp               string = "Specifies a comma-separated list of Datastore names which are permitted to be in maintenance mode."
+	allowReadOnlyDatastoreFlagHelp                  string = "Specifies a comma-separated list of Datastore names which are permitted to be mounted read-only (e.g., ISO or template repositories)."
+	pendingHWUpgradeAgeWarningFlagHelp              string = "Specifies the number of days a scheduled virtual hardware upgrade may remain pending (based on the last VM configuration change) before a WARNING threshold is reached."
+	pendingHWUpgradeAgeCriticalFlagHelp             string = "Specifies the number of days a scheduled virtual hardware upgrade may remain pending (based on the last VM configuration change) before a CRITICAL threshold is reached."
+	pendingHWUpgradeMinimumVersionFlagHelp          string = "If provided, this value is the minimum virtual hardware version (e.g., as used by the check_vmware_vhw plugin) accepted for each Virtual Machine. A scheduled upgrade targeting an older version conflicts with this policy and is considered to be in a WARNING state."
+	vmRestoreStateFileFlagHelp                      string = "Fully-qualified path to the state file used to record VM identity details (instance UUID, creation date) between plugin runs. The state file is created if it does not exist and is updated on each plugin run. A unique state file should be used for each monitored vSphere environment."
+	rebootPendingAgeWarningFlagHelp                 string = "Specifies the number of hours a required ESXi host reboot (e.g., after a VIB install or staged image remediation) may remain pending before a WARNING threshold is reached."
+	rebootPendingAgeCriticalFlagHelp                string = "Specifies the number of hours a required ESXi host reboot (e.g., after a VIB install or staged image remediation) may remain pending before a CRITICAL threshold is reached."
+	vmTimeSyncPeriodicFlagHelp                      string = "Specifies the required state of VMware Tools periodic time synchronization with the host. Supported values are enabled, disabled and any. Periodic synchronization conflicts with in-guest NTP and is commonly disabled by policy."
+	vmTimeSyncStartupFlagHelp                       string = "Specifies the required state of VMware Tools one-off time synchronization with the host (e.g., at startup, resume from suspend or snapshot revert). Supported values are enabled, disabled and any. Disallowing one-off synchronization requires vSphere 7.0 Update 1 or newer."
+	datastoreVMsWarningFlagHelp                     string = "Specifies the number of VMs (including templates) registered on a datastore when a WARNING threshold is reached."
+	datastoreVMsCriticalFlagHelp                    string = "Specifies the number of VMs (including templates) registered on a datastore when a CRITICAL threshold is reached."
+	datastoreVMDKsWarningFlagHelp                   string = "Specifies the number of virtual disks (VMDKs) attached to VMs and backed by files on a datastore when a WARNING threshold is reached."
+	datastoreVMDKsCriticalFlagHelp                  string = "Specifies the number of virtual disks (VMDKs) attached to VMs and backed by files on a datastore when a CRITICAL threshold is reached."
+	datastoreVMDensityIncludeDatastoreFlagHelp      string = "Specifies a comma-separated list of Datastore names that should be exclusively evaluated for VM density. All other datastores in scope are ignored."
+	datastoreVMDensityIgnoreDatastoreFlagHelp       string = "Specifies a comma-separated list of Datastore names that should not be evaluated for VM density."
+	datastoreVMDensityClusterNameFlagHelp           string = "Specifies the name of a vSphere Cluster. If specified, the datastores available to the cluster are evaluated instead of all datastores within the specified (or default) datacenter."
+	snapshotRemovalStallWarningFlagHelp             string = "Specifies the number of minutes an in-progress snapshot removal or disk consolidation task may run before a WARNING threshold is reached."
+	snapshotRemovalStallCriticalFlagHelp            string = "Specifies the number of minutes an in-progress snapshot removal or disk consolidation task may run before a CRITICAL threshold is reached."
+	snapshotRemovalStallIgnoreVMFlagHelp            string = "Specifies a comma-separated list of VM names for which in-progress snapshot removal or disk consolidation tasks should be ignored."
+	vmMemoryMaxAllowedFlagHelp                      string = "Specifies the maximum amount of memory that we are allowed to allocate to VMs in the target VMware environment. Accepts a unit suffix (e.g., 750GiB, 2.5TiB) if the size is a whole number of GiB; values without a unit suffix are interpreted as GiB."
+	vmMemoryAllocatedWarningFlagHelp                string = "Specifies the percentage of VM memory allocation (as a whole number) when a WARNING threshold is reached."
+	vmMemoryAllocatedCriticalFlagHelp               string = "Specifies the percentage of VM memory allocation (as a whole number) when a CRITICAL threshold is reached."
+	vmMemoryPhysicalCapacityFlagHelp                string = "Toggles comparison of allocated VM memory against the physical memory of each cluster (or standalone host) as a memory overcommit ratio instead of a single static maximum."
+	vmMemoryRatioWarningFlagHelp                    string = "Specifies the memory overcommit ratio (allocated to physical) per cluster or standalone host (e.g., 1 or 1.25 for 1.25:1) when a WARNING threshold is reached. Only applies if physical capacity mode is enabled."
+	vmMemoryRatioCriticalFlagHelp                   string = "Specifies the memory overcommit ratio (allocated to physical) per cluster or standalone host (e.g., 1.5 or 2 for 2:1) when a CRITICAL threshold is reached. Only applies if physical capacity mode is enabled."
+	rebootPendingStateFileFlagHelp                  string = "Fully-qualified path to the state file used to record when a pending ESXi host reboot was first observed. vSphere does not record when a reboot became required, so pending durations are measured from the first plugin run which observed the pending reboot. A unique state file should be used for each monitored vSphere environment."
+	hostConnectionClusterNameFlagHelp               string = "Specifies the name of a vSphere Cluster. If specified, only ESXi hosts in the cluster are evaluated instead of all hosts within the specified (or default) datacenter."
+	hostConnectionIncludeHostFlagHelp               string = "Specifies a comma-separated list of ESXi host names that should be exclusively evaluated for connection state. All other hosts in scope are ignored."
+	hostConnectionExcludeHostFlagHelp               string = "Specifies a comma-separated list of ESXi host names that should not be evaluated for connection state."
+	allowMaintenanceHostFlagHelp                    string = "Specifies a comma-separated list of ESXi host names which are permitted to be in maintenance mode (e.g., planned maintenance). Permitted hosts which are not connected while in maintenance mode (e.g., during a reboot) are also considered OK."
+	allowStandbyHostFlagHelp                        string = "Specifies a comma-separated list of ESXi host names which are permitted to be in standby mode (e.g., powered off by DPM). Permitted hosts which are not connected while in standby mode are also considered OK."
+	haHostFailuresClusterNamesFlagHelp              string = "Specifies a comma-separated list of vSphere Cluster names. If not specified, all visible clusters are evaluated."
+	haHostFailuresLookbackFlagHelp                  string = "Specifies the number of minutes prior to plugin execution (the history window) evaluated for vSphere HA host failure and VM restart events."
+	haHostFailuresWarningFlagHelp                   string = "Specifies the number of vSphere HA host failure events for a single cluster within the history window above which a WARNING threshold is reached."
+	haHostFailuresCriticalFlagHelp                  string = "Specifies the number of vSphere HA host failure events for a single cluster within the history window above which a CRITICAL threshold is reached."
 	triggerReloadStateDataFlagHelp                  string = "Toggles (potentially expensive) reload/refresh of state data for evaluated vSphere objects. This is disabled by default."
+	consolidateDisksFlagHelp                        string = "Toggles automatic remediation by triggering disk consolidation for VMs found to require it. The outcome of each disk consolidation task is included in the plugin output. This is disabled by default."
+	maxRemediationsFlagHelp                         string = "Specifies the maximum number of VMs for which disk consolidation is triggered during a single plugin execution. Remaining VMs are skipped and reported."
+	remediationTimeoutFlagHelp                      string = "Specifies the number of seconds to wait for triggered disk consolidation tasks to complete. Tasks still running when this timeout is reached are reported as in progress. If zero, tasks are started but not waited on. Must be less than the plugin timeout value."
+	autoAnswerFlagHelp                              string = "Specifies a PATTERN=ANSWER mapping used to automatically answer interactive questions blocking VMs (e.g., 'msg.uuid.altered=I Moved It'). PATTERN is a case-insensitive regular expression matched against the question text and message IDs; ANSWER is the label (or key) of one of the possible answers. Patterns are evaluated in the order specified; the first match is used. This flag may be repeated."
+	autoAnswerApplyFlagHelp                         string = "Toggles answering interactive questions using matching auto-answer mappings. If not specified, matching answers are reported but not applied (dry-run)."
 )
 
 // shorthandFlagSuffix is appended to short flag help text to emphasize that
@@ -121,13 +379,34 @@ const (
 	PasswordFlagLong  string = "password"
 	PasswordFlagShort string = "pw"
 	DomainFlagLong    string = "domain"
-	TrustCertFlagLong string = "trust-cert"
+
+	UsernameFileFlagLong string = "username-file"
+	PasswordFileFlagLong string = "password-file"
+	ConfigFileFlagLong   string = "config-file"
+
+	PropertyRetrievalWarningFlagLong   string = "property-retrieval-warning"
+	StateMapFlagLong                   string = "state-map"
+	SummaryTemplateFlagLong            string = "summary-template"
+	SessionCacheFlagLong               string = "session-cache"
+	SessionCacheDirFlagLong            string = "session-cache-dir"
+	EvaluationManifestFlagLong         string = "evaluation-manifest"
+	RedactNamesFlagLong                string = "redact-names"
+	RedactNamesLookupFileFlagLong      string = "redact-names-lookup-file"
+	OmitReportSectionFlagLong          string = "omit-report-section"
+	OTLPEndpointFlagLong               string = "otlp-endpoint"
+	TrustCertFlagLong                  string = "trust-cert"
+	ProxyFlagLong                      string = "proxy"
+	NoProxyFlagLong                    string = "no-proxy"
+	CAFileFlagLong                     string = "ca-file"
+	TLSMinVersionFlagLong              string = "tls-min-version"
+	InsecureSkipHostnameVerifyFlagLong string = "insecure-skip-hostname-verify"
 
 	// Alarms, Datastore (Space, Performance), VirtualHardwareVersion, ...
 	DatacenterNameFlagLong string = "dc-name"
 	DatastoreNameFlagLong  string = "ds-name"
 	HostNameFlagLong       string = "host-name"
 	ClusterNameFlagLong    string = "cluster-name"
+	AllDatastoresFlagLong  string = "all-ds"
 
 	// Virtual Hardware Version
 	OutdatedByCriticalFlagLong       string = "outdated-by-critical"
@@ -147,6 +426,11 @@ const (
 	VirtualCPUsWarningFlagLong     string = "vcpus-warning"
 	VirtualCPUsWarningFlagShort    string = "vw"
 
+	// vCPUs (cluster capacity)
+	VirtualCPUsClusterCapacityFlagLong string = "cluster-capacity"
+	VirtualCPUsRatioCriticalFlagLong   string = "vcpu-ratio-critical"
+	VirtualCPUsRatioWarningFlagLong    string = "vcpu-ratio-warning"
+
 	// ResourcePool Memory Usage
 	RPMemoryMaxAllowedFlagLong   string = "memory-max-allowed"
 	RPMemoryMaxAllowedFlagShort  string = "mma"
@@ -216,6 +500,12 @@ const (
 	SnapshotSizeCriticalFlagShort  string = "sc"
 	SnapshotSizeWarningFlagLong    string = "size-warning"
 	SnapshotSizeWarningFlagShort   string = "sw"
+	IncludeSnapshotFlagLong        string = "include-snapshot"
+	ExcludeSnapshotFlagLong        string = "exclude-snapshot"
+
+	// Snapshots count (aggregate)
+	SnapshotTotalCountCriticalFlagLong string = "total-count-critical"
+	SnapshotTotalCountWarningFlagLong  string = "total-count-warning"
 
 	// Common Filter related
 	IgnoreVMFlagLong string = "ignore-vm" // DEPRECATED (GH-896)
@@ -225,12 +515,23 @@ const (
 	IncludePoweredOffVMsFlagLong string = "powered-off"
 	IncludeFolderIDFlagLong      string = "include-folder-id"
 	ExcludeFolderIDFlagLong      string = "exclude-folder-id"
+	IncludeDatacenterFlagLong    string = "include-datacenter-name"
+	ExcludeDatacenterFlagLong    string = "exclude-datacenter-name"
+	IncludeClusterFlagLong       string = "include-cluster-name"
+	ExcludeClusterFlagLong       string = "exclude-cluster-name"
+	IncludeHostFlagLong          string = "include-host-name"
+	ExcludeHostFlagLong          string = "exclude-host-name"
+	IncludeTagFlagLong           string = "include-tag"
+	ExcludeTagFlagLong           string = "exclude-tag"
+	MaintenanceCAFlagLong        string = "maintenance-ca"
+	MaintenanceCAFormatFlagLong  string = "maintenance-ca-date-format"
 
 	// Power uptime
 	PowerUptimeCriticalFlagLong  string = "uptime-critical"
 	PowerUptimeCriticalFlagShort string = "uc"
 	PowerUptimeWarningFlagLong   string = "uptime-warning"
 	PowerUptimeWarningFlagShort  string = "uw"
+	PowerUptimeSourceFlagLong    string = "uptime-source"
 
 	// Backup via CA
 	BackupDateCAFlagLong       string = "backup-date-ca"
@@ -243,22 +544,331 @@ const (
 	BackupAgeWarningFlagShort  string = "baw"
 
 	// Alarm related
-	AlarmEvalAcknowledgedFlagLong   string = "eval-acknowledged"
-	AlarmIncludeEntityTypeFlagLong  string = "include-entity-type"
-	AlarmExcludeEntityTypeFlagLong  string = "exclude-entity-type"
-	AlarmIncludeEntityNameFlagLong  string = "include-entity-name"
-	AlarmExcludeEntityNameFlagLong  string = "exclude-entity-name"
-	AlarmIncludeEntityRPoolFlagLong string = "include-entity-rp"
-	AlarmExcludeEntityRPoolFlagLong string = "exclude-entity-rp"
-	AlarmIncludeNameFlagLong        string = "include-name"
-	AlarmExcludeNameFlagLong        string = "exclude-name"
-	AlarmIncludeDescFlagLong        string = "include-desc"
-	AlarmExcludeDescFlagLong        string = "exclude-desc"
-	AlarmIncludeStatusFlagLong      string = "include-status"
-	AlarmExcludeStatusFlagLong      string = "exclude-status"
+	AlarmEvalAcknowledgedFlagLong     string = "eval-acknowledged"
+	AlarmAcknowledgedMaxStateFlagLong string = "acknowledged-max-state"
+	AlarmIncludeEntityTypeFlagLong    string = "include-entity-type"
+	AlarmExcludeEntityTypeFlagLong    string = "exclude-entity-type"
+	AlarmIncludeEntityNameFlagLong    string = "include-entity-name"
+	AlarmExcludeEntityNameFlagLong    string = "exclude-entity-name"
+	AlarmIncludeEntityRPoolFlagLong   string = "include-entity-rp"
+	AlarmExcludeEntityRPoolFlagLong   string = "exclude-entity-rp"
+	AlarmIncludeNameFlagLong          string = "include-name"
+	AlarmExcludeNameFlagLong          string = "exclude-name"
+	AlarmIncludeDescFlagLong          string = "include-desc"
+	AlarmExcludeDescFlagLong          string = "exclude-desc"
+	AlarmIncludeStatusFlagLong        string = "include-status"
+	AlarmFilterFileFlagLong           string = "alarm-filter-file"
+	AlarmExcludeStatusFlagLong        string = "exclude-status"
+	AlarmDatastoreVMsFlagLong         string = "show-datastore-vms"
+	AlarmTriggeredSinceFlagLong       string = "triggered-since"
+	AlarmTriggeredBeforeFlagLong      string = "triggered-before"
+	AlarmMaintenanceModeFlagLong      string = "maintenance-mode-action"
 
 	// Disk consolidation
-	TriggerReloadFlagLong string = "trigger-reload"
+	TriggerReloadFlagLong      string = "trigger-reload"
+	ConsolidateFlagLong        string = "consolidate"
+	MaxRemediationsFlagLong    string = "max-remediations"
+	RemediationTimeoutFlagLong string = "remediation-timeout"
+
+	// Interactive question
+	AutoAnswerFlagLong      string = "auto-answer"
+	AutoAnswerApplyFlagLong string = "auto-answer-apply"
+
+	// vSAN health
+	VSANHealthRefreshFlagLong    string = "vsan-health-refresh"
+	IgnoreVSANHealthTestFlagLong string = "ignore-vsan-test"
+
+	// Host services
+	RequireHostServiceFlagLong  string = "require-service"
+	DisallowHostServiceFlagLong string = "disallow-service"
+
+	// Host hardware sensors
+	IncludeHostSensorFlagLong string = "include-sensor"
+	ExcludeHostSensorFlagLong string = "exclude-sensor"
+
+	// License usage
+	LicenseUsageCriticalFlagLong  string = "license-usage-critical"
+	LicenseUsageCriticalFlagShort string = "luc"
+	LicenseUsageWarningFlagLong   string = "license-usage-warning"
+	LicenseUsageWarningFlagShort  string = "luw"
+	LicenseFeatureFlagLong        string = "license-feature"
+
+	// License expiration
+	LicenseExpiryCriticalFlagLong  string = "license-expiry-critical"
+	LicenseExpiryCriticalFlagShort string = "lec"
+	LicenseExpiryWarningFlagLong   string = "license-expiry-warning"
+	LicenseExpiryWarningFlagShort  string = "lew"
+
+	// VM CPU ready
+	VMCPUReadyCriticalFlagLong   string = "cpu-ready-critical"
+	VMCPUReadyCriticalFlagShort  string = "crc"
+	VMCPUReadyWarningFlagLong    string = "cpu-ready-warning"
+	VMCPUReadyWarningFlagShort   string = "crw"
+	VMCPUCoStopCriticalFlagLong  string = "cpu-costop-critical"
+	VMCPUCoStopCriticalFlagShort string = "csc"
+	VMCPUCoStopWarningFlagLong   string = "cpu-costop-warning"
+	VMCPUCoStopWarningFlagShort  string = "csw"
+
+	// Folder VM counts
+	FolderIDFlagLong                 string = "folder-id"
+	FolderVMCountMaxWarningFlagLong  string = "vm-count-max-warning"
+	FolderVMCountMaxCriticalFlagLong string = "vm-count-max-critical"
+	FolderVMCountMinWarningFlagLong  string = "vm-count-min-warning"
+	FolderVMCountMinCriticalFlagLong string = "vm-count-min-critical"
+
+	// VM memory pressure
+	VMMemoryBalloonedCriticalFlagLong   string = "ballooned-critical"
+	VMMemoryBalloonedCriticalFlagShort  string = "bc"
+	VMMemoryBalloonedWarningFlagLong    string = "ballooned-warning"
+	VMMemoryBalloonedWarningFlagShort   string = "bw"
+	VMMemorySwappedCriticalFlagLong     string = "swapped-critical"
+	VMMemorySwappedCriticalFlagShort    string = "sc"
+	VMMemorySwappedWarningFlagLong      string = "swapped-warning"
+	VMMemorySwappedWarningFlagShort     string = "sw"
+	VMMemoryCompressedCriticalFlagLong  string = "compressed-critical"
+	VMMemoryCompressedCriticalFlagShort string = "cc"
+	VMMemoryCompressedWarningFlagLong   string = "compressed-warning"
+	VMMemoryCompressedWarningFlagShort  string = "cw"
+
+	// VM Tools running but no IP
+	ToolsNoIPGracePeriodFlagLong string = "grace-period"
+
+	// VM Fault Tolerance latency
+	VMFTLatencyCriticalFlagLong    string = "ft-latency-critical"
+	VMFTLatencyCriticalFlagShort   string = "flc"
+	VMFTLatencyWarningFlagLong     string = "ft-latency-warning"
+	VMFTLatencyWarningFlagShort    string = "flw"
+	VMFTBandwidthCriticalFlagLong  string = "ft-bandwidth-critical"
+	VMFTBandwidthCriticalFlagShort string = "fbc"
+	VMFTBandwidthWarningFlagLong   string = "ft-bandwidth-warning"
+	VMFTBandwidthWarningFlagShort  string = "fbw"
+
+	// VASA provider status
+	RequiredVASAProviderFlagLong    string = "require-provider"
+	VASACertExpiryCriticalFlagLong  string = "cert-expiry-critical"
+	VASACertExpiryCriticalFlagShort string = "cec"
+	VASACertExpiryWarningFlagLong   string = "cert-expiry-warning"
+	VASACertExpiryWarningFlagShort  string = "cew"
+
+	// Emergency threshold tier
+	EmergencyThresholdFlagLong  string = "emergency-threshold"
+	EmergencyThresholdFlagShort string = "et"
+
+	// Cluster DRS status
+	RequiredDRSBehaviorFlagLong         string = "drs-behavior"
+	DRSRecommendationsCriticalFlagLong  string = "drs-recommendations-critical"
+	DRSRecommendationsCriticalFlagShort string = "drc"
+	DRSRecommendationsWarningFlagLong   string = "drs-recommendations-warning"
+	DRSRecommendationsWarningFlagShort  string = "drw"
+
+	// vCenter certificates
+	CertificateExpiryCriticalFlagLong  string = "cert-expiry-critical"
+	CertificateExpiryCriticalFlagShort string = "cec"
+	CertificateExpiryWarningFlagLong   string = "cert-expiry-warning"
+	CertificateExpiryWarningFlagShort  string = "cew"
+	ExcludeHostCertificatesFlagLong    string = "exclude-host-certs"
+
+	// Datacenter inventory drift
+	InventoryDriftCriticalFlagLong  string = "drift-critical"
+	InventoryDriftCriticalFlagShort string = "idc"
+	InventoryDriftWarningFlagLong   string = "drift-warning"
+	InventoryDriftWarningFlagShort  string = "idw"
+	InventoryStateFileFlagLong      string = "state-file"
+
+	// Tasks
+	TasksLookbackFlagLong            string = "lookback"
+	FailedTasksCriticalFlagLong      string = "failed-tasks-critical"
+	FailedTasksCriticalFlagShort     string = "ftc"
+	FailedTasksWarningFlagLong       string = "failed-tasks-warning"
+	FailedTasksWarningFlagShort      string = "ftw"
+	IncludeTaskDescriptionIDFlagLong string = "include-task-id"
+	ExcludeTaskDescriptionIDFlagLong string = "exclude-task-id"
+	IncludeTaskUserFlagLong          string = "include-user"
+	ExcludeTaskUserFlagLong          string = "exclude-user"
+
+	// ESXi Shell / SSH
+	ShellSSHRunningCriticalFlagLong  string = "running-critical"
+	ShellSSHRunningCriticalFlagShort string = "rc"
+	ShellSSHRunningWarningFlagLong   string = "running-warning"
+	ShellSSHRunningWarningFlagShort  string = "rw"
+	AllowShellSSHHostFlagLong        string = "allow-host"
+
+	// Host DNS / routing
+	ExpectedDNSServerFlagLong    string = "expected-dns-server"
+	ExpectedSearchDomainFlagLong string = "expected-search-domain"
+	ExpectedIPv4GatewayFlagLong  string = "expected-gateway"
+	ExpectedIPv6GatewayFlagLong  string = "expected-ipv6-gateway"
+
+	// Events
+	EventsLookbackFlagLong         string = "lookback"
+	MatchedEventsCriticalFlagLong  string = "events-critical"
+	MatchedEventsCriticalFlagShort string = "ec"
+	MatchedEventsWarningFlagLong   string = "events-warning"
+	MatchedEventsWarningFlagShort  string = "ew"
+	IncludeEventTypeFlagLong       string = "event-type"
+	ExcludeEventTypeFlagLong       string = "exclude-event-type"
+	IncludeEventMessageFlagLong    string = "event-message"
+	ExcludeEventMessageFlagLong    string = "exclude-event-message"
+	ExcludeEventEntityNameFlagLong string = "exclude-entity-name"
+	ExcludeEventUserNameFlagLong   string = "exclude-user"
+
+	IncludeDatastoreFlagLong string = "include-ds"
+
+	ListObjectsFlagLong        string = "list"
+	ListObjectsPatternFlagLong string = "list-pattern"
+
+	IgnoreVMDKPathFlagLong string = "ignore-path"
+
+	GenConfigHostPatternFlagLong      string = "host-pattern"
+	GenConfigDatastorePatternFlagLong string = "ds-pattern"
+	GenConfigClusterPatternFlagLong   string = "cluster-pattern"
+	GenConfigTemplateFileFlagLong     string = "template-file"
+	GenConfigOutputFileFlagLong       string = "output-file"
+	GenConfigNagiosHostFlagLong       string = "nagios-host"
+	GenConfigHostDefinitionFlagLong   string = "host-definition"
+
+	AllowMediaVMFlagLong   string = "allow-vm"
+	AllowMediaPathFlagLong string = "allow-path"
+
+	GuestDiskUsageWarningFlagLong   string = "disk-usage-warning"
+	GuestDiskUsageWarningFlagShort  string = "duw"
+	GuestDiskUsageCriticalFlagLong  string = "disk-usage-critical"
+	GuestDiskUsageCriticalFlagShort string = "duc"
+	GuestDiskMountThresholdFlagLong string = "fs-threshold"
+	GuestDiskExcludeMountFlagLong   string = "exclude-mount"
+
+	RunawayVMCPUShareWarningFlagLong     string = "cpu-share-warning"
+	RunawayVMCPUShareCriticalFlagLong    string = "cpu-share-critical"
+	RunawayVMMemoryShareWarningFlagLong  string = "memory-share-warning"
+	RunawayVMMemoryShareCriticalFlagLong string = "memory-share-critical"
+	RunawayVMTopCountFlagLong            string = "top-vms"
+
+	// Flags used by the VMware Tools version plugin.
+	VMToolsMinVersionFlagLong string = "min-tools-version"
+
+	// Flags used by the VM CPU affinity plugin.
+	AllowAffinityVMFlagLong string = "allow-vm"
+
+	// Flags used by the host time drift plugin.
+	TimeDriftWarningFlagLong        string = "drift-warning"
+	TimeDriftCriticalFlagLong       string = "drift-critical"
+	TimeDriftCompareVCenterFlagLong string = "compare-vcenter"
+
+	// Flags used by the ESXi image profile drift plugin.
+	ExpectedImageProfileFlagLong string = "expected-image-profile"
+	UseVLCMDesiredImageFlagLong  string = "vlcm-desired-image"
+
+	// Flags used by the vLCM compliance plugin.
+	VLCMNonCompliantWarningFlagLong  string = "non-compliant-warning"
+	VLCMNonCompliantCriticalFlagLong string = "non-compliant-critical"
+
+	// Flags used by the alarm definitions hash plugin.
+	AlarmDefinitionsStateFileFlagLong string = "state-file"
+
+	PatternMatchFlagLong string = "pattern-match"
+
+	RequireCBRCFlagLong          string = "require-cbrc"
+	RequireMemoryTieringFlagLong string = "require-memory-tiering"
+
+	BackupTagCategoryFlagLong string = "backup-tag-category"
+	BackupTagPrefixFlagLong   string = "backup-tag-prefix"
+
+	VMCustomAttributePatternFlagLong    string = "ca-value-pattern"
+	VMCustomAttributeMinFlagLong        string = "ca-value-min"
+	VMCustomAttributeMaxFlagLong        string = "ca-value-max"
+	VMCustomAttributeDateFormatFlagLong string = "ca-value-date-format"
+
+	VCenterDatabaseUsageCriticalFlagLong  string = "db-usage-critical"
+	VCenterDatabaseUsageCriticalFlagShort string = "dbuc"
+	VCenterDatabaseUsageWarningFlagLong   string = "db-usage-warning"
+	VCenterDatabaseUsageWarningFlagShort  string = "dbuw"
+
+	RequireVCenterServiceFlagLong string = "require-service"
+	IgnoreVCenterServiceFlagLong  string = "ignore-service"
+
+	ExporterListenAddressFlagLong      string = "web-listen-address"
+	ExporterCollectionIntervalFlagLong string = "collection-interval"
+
+	AlarmActionObjectTypeFlagLong   string = "object-type"
+	IgnoreAlarmActionObjectFlagLong string = "ignore-object"
+
+	// Flags used by the VM independent disk mode plugin.
+	AllowIndependentDiskVMFlagLong string = "allow-vm"
+
+	// Flags used by the VM RDM usage plugin.
+	AllowRDMVMFlagLong string = "allow-vm"
+
+	// Flags used by the SR-IOV and DirectPath I/O capacity plugin.
+	VFUsageWarningFlagLong  string = "vf-usage-warning"
+	VFUsageCriticalFlagLong string = "vf-usage-critical"
+
+	// Flags used by the GPU allocation plugin.
+	GPUAllocationWarningFlagLong   string = "gpu-allocation-warning"
+	GPUAllocationCriticalFlagLong  string = "gpu-allocation-critical"
+	GPUUtilizationWarningFlagLong  string = "gpu-utilization-warning"
+	GPUUtilizationCriticalFlagLong string = "gpu-utilization-critical"
+
+	// Flags used by the cluster HA isolation settings plugin.
+	IsolationAddressFlagLong             string = "isolation-address"
+	IsolationResponseFlagLong            string = "isolation-response"
+	MinIsolationAddressesFlagLong        string = "min-isolation-addresses"
+	AllowDefaultIsolationAddressFlagLong string = "allow-default-isolation-address"
+
+	// Flags used by the datastore overcommit plugin.
+	DatastoreOvercommitWarningFlagLong  string = "ds-overcommit-warning"
+	DatastoreOvercommitCriticalFlagLong string = "ds-overcommit-critical"
+
+	// Flags used by the stretched cluster site balance plugin.
+	SiteHostGroupFlagLong         string = "site-host-group"
+	SiteImbalanceWarningFlagLong  string = "site-imbalance-warning"
+	SiteImbalanceCriticalFlagLong string = "site-imbalance-critical"
+
+	// Flags used by the datastore accessibility plugin.
+	AllowMaintenanceDatastoreFlagLong string = "allow-maintenance-ds"
+	AllowReadOnlyDatastoreFlagLong    string = "allow-read-only-ds"
+
+	// Flags used by the host connection state plugin.
+	AllowMaintenanceHostFlagLong string = "allow-maintenance-host"
+	AllowStandbyHostFlagLong     string = "allow-standby-host"
+
+	// Flags used by the cluster HA host failures in history plugin.
+	HAHostFailuresLookbackFlagLong string = "lookback"
+	HAHostFailuresWarningFlagLong  string = "failures-warning"
+	HAHostFailuresCriticalFlagLong string = "failures-critical"
+
+	// Flags used by the VM pending hardware upgrade plugin.
+	PendingHWUpgradeAgeWarningFlagLong  string = "pending-age-warning"
+	PendingHWUpgradeAgeCriticalFlagLong string = "pending-age-critical"
+
+	// Flags used by the VM restore detection plugin.
+	VMRestoreStateFileFlagLong string = "state-file"
+
+	// Flags used by the host scheduled reboot pending plugin.
+	RebootPendingAgeWarningFlagLong  string = "pending-age-warning"
+	RebootPendingAgeCriticalFlagLong string = "pending-age-critical"
+	RebootPendingStateFileFlagLong   string = "state-file"
+
+	// Flags used by the VM time sync policy plugin.
+	VMTimeSyncPeriodicFlagLong string = "periodic-sync"
+	VMTimeSyncStartupFlagLong  string = "startup-sync"
+
+	// Flags used by the datastore VM density plugin.
+	DatastoreVMsWarningFlagLong    string = "ds-vms-warning"
+	DatastoreVMsCriticalFlagLong   string = "ds-vms-critical"
+	DatastoreVMDKsWarningFlagLong  string = "ds-vmdks-warning"
+	DatastoreVMDKsCriticalFlagLong string = "ds-vmdks-critical"
+
+	// Flags used by the snapshot removal stalls plugin.
+	SnapshotRemovalStallWarningFlagLong  string = "stall-warning"
+	SnapshotRemovalStallCriticalFlagLong string = "stall-critical"
+
+	// Flags used by the VM memory allocation plugin.
+	VMMemoryMaxAllowedFlagLong        string = "memory-max-allowed"
+	VMMemoryAllocatedWarningFlagLong  string = "memory-warning"
+	VMMemoryAllocatedCriticalFlagLong string = "memory-critical"
+	VMMemoryPhysicalCapacityFlagLong  string = "physical-capacity"
+	VMMemoryRatioWarningFlagLong      string = "memory-ratio-warning"
+	VMMemoryRatioCriticalFlagLong     string = "memory-ratio-critical"
 )
 
 // Default flag settings if not overridden by user input
@@ -266,20 +876,53 @@ const (
 	defaultLogLevel                              string  = "info"
 	defaultServer                                string  = ""
 	defaultTrustCert                             bool    = false
+	defaultProxy                                 string  = ""
+	defaultNoProxy                               string  = ""
+	defaultCAFile                                string  = ""
+	defaultTLSMinVersion                         string  = "1.2"
+	defaultInsecureSkipHostnameVerify            bool    = false
 	defaultUsername                              string  = ""
 	defaultPassword                              string  = ""
 	defaultUserDomain                            string  = ""
+	defaultUsernameFile                          string  = ""
+	defaultPasswordFile                          string  = ""
+	defaultConfigFile                            string  = ""
 	defaultClusterName                           string  = ""
 	defaultPort                                  int     = 443
 	defaultBranding                              bool    = false
 	defaultDisplayVersionAndExit                 bool    = false
+	defaultPropertyRetrievalWarning              int     = 5000
+	defaultSummaryTemplate                       string  = ""
+	defaultSessionCache                          bool    = false
+	defaultSessionCacheDir                       string  = ""
+	defaultEvaluationManifest                    string  = ""
+	defaultRedactNames                           bool    = false
+	defaultRedactNamesLookupFile                 string  = ""
+	defaultOTLPEndpoint                          string  = ""
 	defaultPoweredOff                            bool    = false
 	defaultEvaluateAcknowledgedAlarms            bool    = false
+	defaultAcknowledgedAlarmsMaxState            string  = ""
+	defaultAlarmDatastoreVMs                     bool    = false
+	defaultAlarmTriggeredSince                   string  = ""
+	defaultAlarmTriggeredBefore                  string  = ""
+	defaultAlarmMaintenanceModeAction            string  = AlarmMaintenanceModeActionEvaluate
+	defaultVMMaintenanceCA                       string  = ""
+	defaultVMMaintenanceCADateFormat             string  = "2006-01-02 15:04"
 	defaultTriggerReloadStateData                bool    = false
+	defaultConsolidateDisks                      bool    = false
+	defaultMaxRemediations                       int     = 5
+	defaultRemediationTimeout                    int     = 0
+	defaultAutoAnswerApply                       bool    = false
+	defaultVSANHealthRefresh                     bool    = false
+	defaultDisallowedHostServices                string  = "TSM,TSM-SSH"
 	defaultVCPUsAllocatedCritical                int     = 100
 	defaultVCPUsAllocatedWarning                 int     = 95
+	defaultVCPUsClusterCapacity                  bool    = false
+	defaultVCPUsClusterRatioCritical             float64 = 6
+	defaultVCPUsClusterRatioWarning              float64 = 4
 	defaultIgnoreMissingCustomAttribute          bool    = false
 	defaultDatastoreName                         string  = ""
+	defaultAllDatastores                         bool    = false
 	defaultDatastoreSpaceUsageCritical           int     = 95
 	defaultDatastoreSpaceUsageWarning            int     = 90
 	defaultIgnoreMissingDatastoreMetrics         bool    = false
@@ -296,11 +939,14 @@ const (
 	defaultSnapshotsAgeWarning                   int     = 1
 	defaultSnapshotsCountCritical                int     = 25 // max is 32
 	defaultSnapshotsCountWarning                 int     = 4  // recommended cap is 3-4
-	defaultSnapshotsSizeCritical                 int     = 40 // size in GB
-	defaultSnapshotsSizeWarning                  int     = 20 // size in GB
+	defaultSnapshotsTotalCountCritical           int     = 0  // disabled
+	defaultSnapshotsTotalCountWarning            int     = 0  // disabled
+	defaultSnapshotsSizeCritical                 int     = 40 // size in GiB
+	defaultSnapshotsSizeWarning                  int     = 20 // size in GiB
 	defaultHostSystemName                        string  = ""
 	defaultVMPowerCycleUptimeCritical            int     = 90
 	defaultVMPowerCycleUptimeWarning             int     = 60
+	defaultVMPowerCycleUptimeSource              string  = PowerUptimeSourceQuickStats
 	defaultVMBackupAgeCritical                   int     = 2
 	defaultVMBackupAgeWarning                    int     = 1
 	defaultVMBackupDateCustomAttribute           string  = "Last Backup"
@@ -330,6 +976,7 @@ const (
 	// the end user.
 	defaultVCPUsMaxAllowed               int = 0
 	defaultResourcePoolsMemoryMaxAllowed int = 0
+	defaultVMMemoryMaxAllowed            int = 0
 
 	// Default timeout (in seconds) used for plugin runtime
 	defaultPluginRuntimeTimeout int = 10
@@ -354,6 +1001,172 @@ const (
 	// forces the user to provide an actual prefix separator to enable prefix
 	// splitting.
 	defaultCustomAttributePrefixSeparator string = ""
+
+	defaultLicenseUsageCritical int = 100
+	defaultLicenseUsageWarning  int = 90
+
+	defaultVFUsageCritical int = 95
+	defaultVFUsageWarning  int = 80
+
+	defaultGPUAllocationCritical  int = 95
+	defaultGPUAllocationWarning   int = 80
+	defaultGPUUtilizationCritical int = 95
+	defaultGPUUtilizationWarning  int = 85
+
+	defaultAllowedIsolationResponses    string = "powerOff,shutdown"
+	defaultMinIsolationAddresses        int    = 1
+	defaultAllowDefaultIsolationAddress bool   = false
+
+	defaultDatastoreOvercommitCritical int = 200
+	defaultDatastoreOvercommitWarning  int = 150
+
+	defaultSiteImbalanceCritical int = 50
+	defaultSiteImbalanceWarning  int = 30
+
+	defaultPendingHWUpgradeAgeCritical int = 30
+	defaultPendingHWUpgradeAgeWarning  int = 7
+
+	defaultLicenseExpiryCritical int = 15
+	defaultLicenseExpiryWarning  int = 30
+
+	defaultVMCPUReadyCritical  int = 10
+	defaultVMCPUReadyWarning   int = 5
+	defaultVMCPUCoStopCritical int = 5
+	defaultVMCPUCoStopWarning  int = 3
+
+	defaultVMMemoryBalloonedCritical  int = 10
+	defaultVMMemoryBalloonedWarning   int = 5
+	defaultVMMemorySwappedCritical    int = 5
+	defaultVMMemorySwappedWarning     int = 1
+	defaultVMMemoryCompressedCritical int = 10
+	defaultVMMemoryCompressedWarning  int = 5
+
+	defaultVMFTLatencyCritical   int = 2000
+	defaultVMFTLatencyWarning    int = 1000
+	defaultVMFTBandwidthCritical int = 9000
+	defaultVMFTBandwidthWarning  int = 8000
+
+	defaultToolsNoIPGracePeriod int = 15
+
+	defaultVASACertExpiryCritical int = 15
+	defaultVASACertExpiryWarning  int = 30
+
+	defaultRequiredDRSBehavior        string = DRSBehaviorFullyAutomated
+	defaultDRSRecommendationsCritical int    = 10
+	defaultDRSRecommendationsWarning  int    = 5
+
+	defaultCertificateExpiryCritical int  = 15
+	defaultCertificateExpiryWarning  int  = 30
+	defaultExcludeHostCertificates   bool = false
+
+	defaultInventoryDriftCritical int    = 20
+	defaultInventoryDriftWarning  int    = 5
+	defaultInventoryStateFile     string = ""
+
+	defaultTasksLookback       int = 60
+	defaultFailedTasksCritical int = 5
+	defaultFailedTasksWarning  int = 0
+
+	defaultShellSSHRunningCritical int = 240
+	defaultShellSSHRunningWarning  int = 60
+
+	defaultExpectedIPv4Gateway string = ""
+	defaultExpectedIPv6Gateway string = ""
+
+	defaultEventsLookback        int = 60
+	defaultMatchedEventsCritical int = 5
+	defaultMatchedEventsWarning  int = 0
+
+	defaultSnapshotsDatastoreQuotaCritical int = 500 // size in GiB
+	defaultSnapshotsDatastoreQuotaWarning  int = 250 // size in GiB
+
+	defaultListObjects        bool   = false
+	defaultListObjectsPattern string = ""
+
+	defaultOrphanedVMDKsSizeCritical int = 50 // size in GiB
+	defaultOrphanedVMDKsSizeWarning  int = 0  // size in GiB
+
+	defaultGenConfigHostPattern      string = ""
+	defaultGenConfigDatastorePattern string = ""
+	defaultGenConfigClusterPattern   string = ""
+	defaultGenConfigTemplateFile     string = ""
+	defaultGenConfigOutputFile       string = ""
+	defaultGenConfigNagiosHost       string = ""
+	defaultGenConfigHostDefinition   bool   = false
+
+	defaultVMGuestDiskUsageCritical int = 90
+	defaultVMGuestDiskUsageWarning  int = 80
+
+	defaultRunawayVMCPUShareCritical    int = 75
+	defaultRunawayVMCPUShareWarning     int = 50
+	defaultRunawayVMMemoryShareCritical int = 75
+	defaultRunawayVMMemoryShareWarning  int = 50
+	defaultRunawayVMTopCount            int = 3
+
+	defaultVMToolsMinVersion int = 0
+
+	defaultTimeDriftWarning        int  = 5
+	defaultTimeDriftCritical       int  = 30
+	defaultTimeDriftCompareVCenter bool = false
+
+	defaultExpectedImageProfile string = ""
+	defaultUseVLCMDesiredImage  bool   = false
+
+	defaultVLCMNonCompliantWarning  int = 0
+	defaultVLCMNonCompliantCritical int = 2
+
+	defaultAlarmDefinitionsStateFile string = ""
+
+	defaultVMRestoreStateFile string = ""
+
+	defaultRebootPendingAgeWarning  int    = 24
+	defaultRebootPendingAgeCritical int    = 72
+	defaultRebootPendingStateFile   string = ""
+
+	defaultVMTimeSyncPeriodic string = TimeSyncPolicyDisabled
+	defaultVMTimeSyncStartup  string = TimeSyncPolicyAny
+
+	defaultDatastoreVMsWarning    int = 25
+	defaultDatastoreVMsCritical   int = 35
+	defaultDatastoreVMDKsWarning  int = 50
+	defaultDatastoreVMDKsCritical int = 75
+
+	defaultSnapshotRemovalStallWarning  int = 60  // minutes
+	defaultSnapshotRemovalStallCritical int = 240 // minutes
+
+	defaultVMMemoryAllocatedWarning  int     = 95
+	defaultVMMemoryAllocatedCritical int     = 100
+	defaultVMMemoryPhysicalCapacity  bool    = false
+	defaultVMMemoryRatioWarning      float64 = 1
+	defaultVMMemoryRatioCritical     float64 = 1.5
+
+	defaultHAHostFailuresLookback int = 1440 // minutes
+	defaultHAHostFailuresWarning  int = 0
+	defaultHAHostFailuresCritical int = 1
+
+	defaultPatternMatch string = "exact"
+
+	defaultRequireCBRC          bool = false
+	defaultRequireMemoryTiering bool = false
+
+	defaultVMBackupTagCategory string = ""
+	defaultVMBackupTagPrefix   string = ""
+
+	defaultAlarmFilterFile string = ""
+
+	defaultVMCustomAttributeName       string = ""
+	defaultVMCustomAttributePattern    string = ""
+	defaultVMCustomAttributeMin        string = ""
+	defaultVMCustomAttributeMax        string = ""
+	defaultVMCustomAttributeDateFormat string = ""
+
+	defaultVCenterDatabaseUsageCritical int = 90
+	defaultVCenterDatabaseUsageWarning  int = 80
+
+	defaultRequiredVCenterServices string = "vpxd,vsphere-ui,sps,content-library"
+
+	defaultExporterListenAddress      string = ":9272"
+	defaultExporterCollectionInterval int    = 60
 )
 
 // Plugin types provided by this project. These values are used as labels in
@@ -378,12 +1191,76 @@ const (
 	PluginTypeAlarms                         string = "alarms"
 	PluginTypeVirtualMachineLastBackupViaCA  string = "vm-last-backup-via-ca"
 	PluginTypeVirtualMachineList             string = "vm-list"
+	PluginTypeVSANHealth                     string = "vsan-health"
+	PluginTypeHostServices                   string = "host-services"
+	PluginTypeLicensingFeatureUsage          string = "licensing-feature-usage"
+	PluginTypeVirtualMachineCPUReady         string = "vm-cpu-ready"
+	PluginTypeFolderVMCounts                 string = "folder-vm-counts"
+	PluginTypeVirtualMachineMemoryPressure   string = "vm-memory-pressure"
+	PluginTypeClusterResourceUsage           string = "cluster-resource-usage"
+	PluginTypeVASAProviderStatus             string = "vasa-provider-status"
+	PluginTypeClusterHAStatus                string = "cluster-ha-status"
+	PluginTypeVVolDatastoreHealth            string = "vvol-datastore-health"
+	PluginTypeClusterDRSStatus               string = "cluster-drs-status"
+	PluginTypeHostHardwareSensors            string = "host-hardware-sensors"
+	PluginTypeVirtualMachineFTLatency        string = "vm-ft-latency"
+	PluginTypeHostStoragePaths               string = "host-storage-paths"
+	PluginTypeVirtualMachineToolsNoIP        string = "vm-tools-running-but-no-ip"
+	PluginTypeVCenterCertificates            string = "vcenter-certificates"
+	PluginTypeLicense                        string = "license"
+	PluginTypeDatacenterInventoryDrift       string = "datacenter-inventory-drift"
+	PluginTypeTasks                          string = "tasks"
+	PluginTypeHostESXiShellSSHEnabled        string = "host-esxi-shell-ssh-enabled"
+	PluginTypeHostDNSRouting                 string = "host-dns-routing"
+	PluginTypeEvents                         string = "events"
+	PluginTypeSnapshotsDatastoreQuota        string = "snapshots-quota-per-datastore"
+	PluginTypeVirtualMachineOrphanedDevices  string = "vm-hotplug-orphan-devices"
+	PluginTypeOrphanedVMDKs                  string = "orphaned-vmdks"
+	PluginTypeNagiosGenConfig                string = "nagios-genconfig"
+	PluginTypeVirtualMachineConnectedMedia   string = "vm-connected-media"
+	PluginTypeVirtualMachineGuestDiskUsage   string = "vm-guest-disk-usage"
+	PluginTypeResourcePoolRunawayVM          string = "resource-pool-runaway-vm"
+	PluginTypeVirtualMachineToolsVersion     string = "vm-tools-version"
+	PluginTypeVirtualMachineCPUAffinity      string = "vm-cpu-affinity-set"
+	PluginTypeHostTimeDrift                  string = "host-time-drift"
+	PluginTypeESXiImageProfileDrift          string = "esxi-image-profile-drift"
+	PluginTypeVLCMCompliance                 string = "vlcm-compliance"
+	PluginTypeAlarmDefinitionsHash           string = "alarm-definitions-hash"
+	PluginTypeHostAcceleration               string = "cbrc-memory-tiering-status"
+	PluginTypeVirtualMachineLastBackupViaTag string = "vm-last-backup-via-tag"
+	PluginTypeVirtualMachineCustomAttribute  string = "vm-custom-attribute"
+	PluginTypeVCenterDatabaseHealth          string = "vcenter-database-health"
+	PluginTypeVCenterServiceStatus           string = "vcenter-service-status"
+	PluginTypeVMwareExporter                 string = "vmware-exporter"
+	PluginTypeAlarmActionDisabled            string = "alarm-action-disabled"
+	PluginTypeVirtualMachineIndependentDisks string = "vm-disk-mode-independent"
+	PluginTypeVirtualMachineRDMUsage         string = "vm-rdm-usage"
+	PluginTypeHostSRIOVPassthroughCapacity   string = "sriov-passthrough-capacity"
+	PluginTypeHostGPUAllocation              string = "gpu-allocation"
+	PluginTypeClusterDASIsolation            string = "cluster-das-isolation"
+	PluginTypeDatastoresOvercommit           string = "datastores-overcommit"
+	PluginTypeStretchedClusterSiteBalance    string = "stretched-cluster-site-balance"
+	PluginTypeDatastoresAccessibility        string = "datastores-accessibility"
+	PluginTypeVirtualMachinePendingHWUpgrade string = "vm-pending-hardware-upgrade"
+	PluginTypeVirtualMachineRestoreDetection string = "vm-restore-detection"
+	PluginTypeHostRebootPending              string = "host-scheduled-reboot-pending"
+	PluginTypeVirtualMachineTimeSyncPolicy   string = "vm-time-sync-policy"
+	PluginTypeDatastoresVMDensity            string = "datastores-vm-density"
+	PluginTypeSnapshotRemovalStalls          string = "snapshot-removal-stalls"
+	PluginTypeVMMemoryAllocation             string = "vm-memory-allocation"
+	PluginTypeVirtualMachineDiskUUIDEnabled  string = "vm-disk-uuid-enabled"
+	PluginTypeHostConnectionState            string = "host-connection-state"
+	PluginTypeClusterHAHostFailures          string = "cluster-ha-host-failures-in-history"
 )
 
 // Known limits
 // https://trainingrevolution.wordpress.com/2018/07/22/vmware-vsphere-6-7-character-limits-for-objects/
 const (
 	MaxClusterNameChars int = 80
+
+	// MaxIsolationAddresses is the maximum number of custom vSphere HA
+	// isolation addresses which may be configured for a cluster.
+	MaxIsolationAddresses int = 10
 )
 
 // ThresholdNotUsed indicates that a plugin is not using a specific threshold.
@@ -434,6 +1311,50 @@ const (
 	AlarmStatusUnknown  string = "unknown"
 )
 
+// Supported keywords for handling triggered alarms associated with entities
+// in maintenance mode. The warning and ok Nagios state keywords are also
+// supported.
+const (
+	AlarmMaintenanceModeActionEvaluate string = "evaluate"
+	AlarmMaintenanceModeActionExclude  string = "exclude"
+)
+
+// Supported keywords for the source of VM power cycle uptime values.
+const (
+	PowerUptimeSourceQuickStats string = "quickstats"
+	PowerUptimeSourceBootTime   string = "boot-time"
+)
+
+// Supported keywords for optional report sections which may be omitted from
+// the long service output. These match the report section identifiers
+// registered by the vsphere package.
+const (
+	ReportSectionRecentlyStartedVMs string = "recently-started-vms"
+	ReportSectionTopConsumers       string = "top-consumers"
+	ReportSectionTopOKVMs           string = "top-ok-vms"
+)
+
+// Supported keywords for VMware Tools time synchronization policy settings.
+const (
+	TimeSyncPolicyEnabled  string = "enabled"
+	TimeSyncPolicyDisabled string = "disabled"
+	TimeSyncPolicyAny      string = "any"
+)
+
+// Valid DRS automation level keywords. Maps to DrsBehavior values.
+const (
+	DRSBehaviorManual             string = "manual"
+	DRSBehaviorPartiallyAutomated string = "partiallyAutomated"
+	DRSBehaviorFullyAutomated     string = "fullyAutomated"
+)
+
+// Inventory object type keywords evaluated for disabled alarm actions.
+const (
+	AlarmActionObjectTypeDatacenter string = "datacenter"
+	AlarmActionObjectTypeCluster    string = "cluster"
+	AlarmActionObjectTypeHost       string = "host"
+)
+
 // Nagios plugin/service check state "labels". Duplicates constants provided
 // by the atc0005/go-nagios package in order to not create a dependency
 // between this package and that one.
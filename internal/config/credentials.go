@@ -0,0 +1,153 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables used to provide login credentials as an alternative
+// to command-line flags. Values provided via command-line flags have
+// precedence over values provided via environment variables.
+const (
+	UsernameEnvVar     string = "VMWARE_USERNAME"
+	UsernameFileEnvVar string = "VMWARE_USERNAME_FILE"
+	PasswordEnvVar     string = "VMWARE_PASSWORD"
+	PasswordFileEnvVar string = "VMWARE_PASSWORD_FILE"
+	DomainEnvVar       string = "VMWARE_DOMAIN"
+)
+
+// ErrCredentialsFileInvalid indicates that a credentials file is invalid.
+var ErrCredentialsFileInvalid = errors.New("invalid credentials file")
+
+// credentialTrimFunc is used to remove unwanted characters (e.g., a trailing
+// newline added by a text editor) from a credential value read from a file.
+type credentialTrimFunc func(string) string
+
+// trimUsername removes leading and trailing whitespace from a username.
+func trimUsername(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// trimPassword removes trailing newline characters from a password. Other
+// whitespace is preserved as it may be a valid part of the password.
+func trimPassword(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
+
+// readCredentialFile reads a single credential value from the specified
+// file. An error is returned if the file cannot be read or if the credential
+// value is empty.
+func readCredentialFile(filename string, trim credentialTrimFunc) (string, error) {
+	// #nosec G304 -- file path is explicitly provided by the user
+	data, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to read credentials file %q: %w",
+			filename,
+			err,
+		)
+	}
+
+	value := trim(string(data))
+	if value == "" {
+		return "", fmt.Errorf(
+			"%w: %q is empty",
+			ErrCredentialsFileInvalid,
+			filename,
+		)
+	}
+
+	return value, nil
+}
+
+// resolveCredential sets the given credential value if not already
+// specified via command-line flag. Sources are evaluated in this order:
+//
+//   - command-line flag
+//   - credentials file specified via command-line flag
+//   - environment variable
+//   - credentials file specified via environment variable
+//
+// An error is returned if both the command-line flag and the credentials
+// file flag are specified.
+func resolveCredential(
+	value *string,
+	filename string,
+	flagName string,
+	fileFlagName string,
+	envVar string,
+	fileEnvVar string,
+	trim credentialTrimFunc,
+) error {
+	var err error
+
+	switch {
+	case *value != "" && filename != "":
+		return fmt.Errorf(
+			"only one of %q or %q flags may be specified",
+			flagName,
+			fileFlagName,
+		)
+
+	case *value != "":
+		return nil
+
+	case filename != "":
+		*value, err = readCredentialFile(filename, trim)
+
+	case os.Getenv(envVar) != "":
+		*value = os.Getenv(envVar)
+
+	case os.Getenv(fileEnvVar) != "":
+		*value, err = readCredentialFile(os.Getenv(fileEnvVar), trim)
+	}
+
+	return err
+}
+
+// loadCredentials applies login credentials from user-specified files or
+// environment variables for any credentials not specified via command-line
+// flags. This allows credentials to be provided without exposing them in
+// process listings or Nagios command definitions. This is performed prior
+// to validation so that credentials from all sources are validated the
+// same way.
+func (c *Config) loadCredentials() error {
+	if err := resolveCredential(
+		&c.Username,
+		c.UsernameFile,
+		UsernameFlagLong,
+		UsernameFileFlagLong,
+		UsernameEnvVar,
+		UsernameFileEnvVar,
+		trimUsername,
+	); err != nil {
+		return err
+	}
+
+	if err := resolveCredential(
+		&c.Password,
+		c.PasswordFile,
+		PasswordFlagLong,
+		PasswordFileFlagLong,
+		PasswordEnvVar,
+		PasswordFileEnvVar,
+		trimPassword,
+	); err != nil {
+		return err
+	}
+
+	if c.Domain == "" {
+		c.Domain = os.Getenv(DomainEnvVar)
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCredentialFile writes the given content to a file in a temporary
+// directory and returns the path to the file.
+func writeCredentialFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "credential")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write credentials file: %v", err)
+	}
+
+	return filename
+}
+
+func TestReadCredentialFile(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		trim    credentialTrimFunc
+		want    string
+		wantErr error
+	}{
+		"username trailing newline": {
+			content: "svc-nagios\n",
+			trim:    trimUsername,
+			want:    "svc-nagios",
+		},
+		"username surrounding whitespace": {
+			content: "  svc-nagios \r\n",
+			trim:    trimUsername,
+			want:    "svc-nagios",
+		},
+		"password CRLF line ending": {
+			content: "s3cret\r\n",
+			trim:    trimPassword,
+			want:    "s3cret",
+		},
+		"password whitespace preserved": {
+			content: " s3cret pass \n",
+			trim:    trimPassword,
+			want:    " s3cret pass ",
+		},
+		"empty username": {
+			content: " \n",
+			trim:    trimUsername,
+			wantErr: ErrCredentialsFileInvalid,
+		},
+		"empty password": {
+			content: "\n",
+			trim:    trimPassword,
+			wantErr: ErrCredentialsFileInvalid,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := readCredentialFile(writeCredentialFile(t, tt.content), tt.trim)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("want %v error; got %v", tt.wantErr, err)
+				}
+			case err != nil:
+				t.Errorf("want nil error; got %v", err)
+			case got != tt.want:
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadCredentialFileMissing(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing")
+
+	_, err := readCredentialFile(filename, trimPassword)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("want %v error; got %v", os.ErrNotExist, err)
+	}
+}
+
+func TestResolveCredential(t *testing.T) {
+	const (
+		envVar     string = "CHECK_VMWARE_TEST_PASSWORD"
+		fileEnvVar string = "CHECK_VMWARE_TEST_PASSWORD_FILE"
+	)
+
+	flagFile := writeCredentialFile(t, "from-flag-file\n")
+	envFile := writeCredentialFile(t, "from-env-file\n")
+
+	tests := map[string]struct {
+		value     string
+		filename  string
+		env       string
+		fileEnv   string
+		want      string
+		wantError bool
+	}{
+		"flag": {
+			value:   "from-flag",
+			env:     "from-env",
+			fileEnv: envFile,
+			want:    "from-flag",
+		},
+		"flag file": {
+			filename: flagFile,
+			env:      "from-env",
+			fileEnv:  envFile,
+			want:     "from-flag-file",
+		},
+		"environment variable": {
+			env:     "from-env",
+			fileEnv: envFile,
+			want:    "from-env",
+		},
+		"environment variable file": {
+			fileEnv: envFile,
+			want:    "from-env-file",
+		},
+		"no source": {
+			want: "",
+		},
+		"flag and flag file": {
+			value:     "from-flag",
+			filename:  flagFile,
+			wantError: true,
+		},
+		"unreadable environment variable file": {
+			fileEnv:   filepath.Join(t.TempDir(), "missing"),
+			wantError: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(envVar, tt.env)
+			t.Setenv(fileEnvVar, tt.fileEnv)
+
+			value := tt.value
+			err := resolveCredential(
+				&value,
+				tt.filename,
+				PasswordFlagLong,
+				PasswordFileFlagLong,
+				envVar,
+				fileEnvVar,
+				trimPassword,
+			)
+
+			switch {
+			case tt.wantError && err == nil:
+				t.Errorf("want error; got nil (value %q)", value)
+			case !tt.wantError && err != nil:
+				t.Errorf("want nil error; got %v", err)
+			case !tt.wantError && value != tt.want:
+				t.Errorf("want %q; got %q", tt.want, value)
+			}
+		})
+	}
+}
+
+func TestLoadCredentialsFromEnvironment(t *testing.T) {
+	t.Setenv(UsernameEnvVar, "svc-nagios")
+	t.Setenv(UsernameFileEnvVar, "")
+	t.Setenv(PasswordEnvVar, "")
+	t.Setenv(PasswordFileEnvVar, writeCredentialFile(t, "s3cret\n"))
+	t.Setenv(DomainEnvVar, "example.com")
+
+	tests := map[string]struct {
+		domain     string
+		wantDomain string
+	}{
+		"domain from environment": {
+			wantDomain: "example.com",
+		},
+		"domain flag has precedence": {
+			domain:     "vsphere.local",
+			wantDomain: "vsphere.local",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := Config{Domain: tt.domain}
+
+			if err := c.loadCredentials(); err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if c.Username != "svc-nagios" {
+				t.Errorf("want username %q; got %q", "svc-nagios", c.Username)
+			}
+
+			if c.Password != "s3cret" {
+				t.Errorf("want password %q; got %q", "s3cret", c.Password)
+			}
+
+			if c.Domain != tt.wantDomain {
+				t.Errorf("want domain %q; got %q", tt.wantDomain, c.Domain)
+			}
+		})
+	}
+}
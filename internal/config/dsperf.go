@@ -297,3 +297,8 @@ func (mvdsperf *MultiValueDSPerfPercentileSetFlag) Set(value string) error {
 	return nil
 
 }
+
+// reset discards all percentile sets recorded by previous calls to Set.
+func (mvdsperf *MultiValueDSPerfPercentileSetFlag) reset() {
+	clear(*mvdsperf)
+}
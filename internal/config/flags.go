@@ -7,7 +7,11 @@
 
 package config
 
-import "flag"
+import (
+	"flag"
+
+	"github.com/vmware/govmomi/units"
+)
 
 // handleFlagsConfig handles toggling the exposure of specific configuration
 // flags to the user. This behavior is controlled via the specified plugin
@@ -25,7 +29,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
 
 	case pluginType.SnapshotsAge:
@@ -35,7 +50,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		// NOTE: This plugin is hard-coded to evaluate powered off and powered
 		// on VMs equally. I'm not sure whether ignoring powered off VMs by
@@ -52,6 +78,9 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.IntVar(&c.SnapshotsAgeCritical, SnapshotAgeCriticalFlagLong, defaultSnapshotsAgeCritical, snapshotsAgeCriticalFlagHelp)
 		flag.IntVar(&c.SnapshotsAgeCritical, SnapshotAgeCriticalFlagShort, defaultSnapshotsAgeCritical, snapshotsAgeCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.Var(&c.IncludedSnapshots, IncludeSnapshotFlagLong, includeSnapshotFlagHelp)
+		flag.Var(&c.ExcludedSnapshots, ExcludeSnapshotFlagLong, excludeSnapshotFlagHelp)
+
 	case pluginType.SnapshotsCount:
 
 		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
@@ -59,7 +88,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		// NOTE: This plugin is hard-coded to evaluate powered off and powered
 		// on VMs equally. I'm not sure whether ignoring powered off VMs by
@@ -76,6 +116,9 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.IntVar(&c.SnapshotsCountCritical, SnapshotCountCriticalFlagLong, defaultSnapshotsCountCritical, snapshotsCountCriticalFlagHelp)
 		flag.IntVar(&c.SnapshotsCountCritical, SnapshotCountCriticalFlagShort, defaultSnapshotsCountCritical, snapshotsCountCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.IntVar(&c.SnapshotsTotalCountWarning, SnapshotTotalCountWarningFlagLong, defaultSnapshotsTotalCountWarning, snapshotsTotalCountWarningFlagHelp)
+		flag.IntVar(&c.SnapshotsTotalCountCritical, SnapshotTotalCountCriticalFlagLong, defaultSnapshotsTotalCountCritical, snapshotsTotalCountCriticalFlagHelp)
+
 	case pluginType.SnapshotsSize:
 
 		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
@@ -83,7 +126,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		// NOTE: This plugin is hard-coded to evaluate powered off and powered
 		// on VMs equally. I'm not sure whether ignoring powered off VMs by
@@ -94,11 +148,13 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		//
 		// flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
 
-		flag.IntVar(&c.SnapshotsSizeWarning, SnapshotSizeWarningFlagLong, defaultSnapshotsSizeWarning, snapshotsSizeWarningFlagHelp)
-		flag.IntVar(&c.SnapshotsSizeWarning, SnapshotSizeWarningFlagShort, defaultSnapshotsSizeWarning, snapshotsSizeWarningFlagHelp+shorthandFlagSuffix)
+		snapshotsSizeWarningFlag := newSizeFlag(&c.SnapshotsSizeWarning, defaultSnapshotsSizeWarning, units.GB, "GiB")
+		flag.Var(snapshotsSizeWarningFlag, SnapshotSizeWarningFlagLong, snapshotsSizeWarningFlagHelp)
+		flag.Var(snapshotsSizeWarningFlag, SnapshotSizeWarningFlagShort, snapshotsSizeWarningFlagHelp+shorthandFlagSuffix)
 
-		flag.IntVar(&c.SnapshotsSizeCritical, SnapshotSizeCriticalFlagLong, defaultSnapshotsSizeCritical, snapshotsSizeCriticalFlagHelp)
-		flag.IntVar(&c.SnapshotsSizeCritical, SnapshotSizeCriticalFlagShort, defaultSnapshotsSizeCritical, snapshotsSizeCriticalFlagHelp+shorthandFlagSuffix)
+		snapshotsSizeCriticalFlag := newSizeFlag(&c.SnapshotsSizeCritical, defaultSnapshotsSizeCritical, units.GB, "GiB")
+		flag.Var(snapshotsSizeCriticalFlag, SnapshotSizeCriticalFlagLong, snapshotsSizeCriticalFlagHelp)
+		flag.Var(snapshotsSizeCriticalFlag, SnapshotSizeCriticalFlagShort, snapshotsSizeCriticalFlagHelp+shorthandFlagSuffix)
 
 	case pluginType.VirtualMachinePowerCycleUptime:
 
@@ -107,7 +163,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		flag.IntVar(&c.VMPowerCycleUptimeWarning, PowerUptimeWarningFlagLong, defaultVMPowerCycleUptimeWarning, vmPowerCycleUptimeWarningFlagHelp)
 		flag.IntVar(&c.VMPowerCycleUptimeWarning, PowerUptimeWarningFlagShort, defaultVMPowerCycleUptimeWarning, vmPowerCycleUptimeWarningFlagHelp+shorthandFlagSuffix)
@@ -115,6 +182,8 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.IntVar(&c.VMPowerCycleUptimeCritical, PowerUptimeCriticalFlagLong, defaultVMPowerCycleUptimeCritical, vmPowerCycleUptimeCriticalFlagHelp)
 		flag.IntVar(&c.VMPowerCycleUptimeCritical, PowerUptimeCriticalFlagShort, defaultVMPowerCycleUptimeCritical, vmPowerCycleUptimeCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.StringVar(&c.VMPowerCycleUptimeSource, PowerUptimeSourceFlagLong, defaultVMPowerCycleUptimeSource, vmPowerCycleUptimeSourceFlagHelp)
+
 	case pluginType.DiskConsolidation:
 
 		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
@@ -122,8 +191,22 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 		flag.BoolVar(&c.TriggerReloadStateData, TriggerReloadFlagLong, defaultTriggerReloadStateData, triggerReloadStateDataFlagHelp)
+		flag.BoolVar(&c.ConsolidateDisks, ConsolidateFlagLong, defaultConsolidateDisks, consolidateDisksFlagHelp)
+		flag.IntVar(&c.MaxRemediations, MaxRemediationsFlagLong, defaultMaxRemediations, maxRemediationsFlagHelp)
+		flag.IntVar(&c.remediationTimeout, RemediationTimeoutFlagLong, defaultRemediationTimeout, remediationTimeoutFlagHelp)
 
 		// NOTE: This plugin is hard-coded to evaluate powered off and powered
 		// on VMs equally. I'm not sure whether ignoring powered off VMs by
@@ -143,7 +226,20 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.Var(&c.AutoAnswers, AutoAnswerFlagLong, autoAnswerFlagHelp)
+		flag.BoolVar(&c.AutoAnswerApply, AutoAnswerApplyFlagLong, defaultAutoAnswerApply, autoAnswerApplyFlagHelp)
 
 	case pluginType.Alarms:
 
@@ -152,9 +248,17 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.Var(&c.ExcludedAlarmEntityTypes, AlarmExcludeEntityTypeFlagLong, excludedAlarmEntityTypesFlagHelp)
 
 		flag.BoolVar(&c.EvaluateAcknowledgedAlarms, AlarmEvalAcknowledgedFlagLong, defaultEvaluateAcknowledgedAlarms, evaluateAcknowledgedTriggeredAlarmFlagHelp)
+		flag.StringVar(&c.AcknowledgedAlarmsMaxState, AlarmAcknowledgedMaxStateFlagLong, defaultAcknowledgedAlarmsMaxState, acknowledgedAlarmsMaxStateFlagHelp)
+		flag.BoolVar(&c.AlarmDatastoreVMs, AlarmDatastoreVMsFlagLong, defaultAlarmDatastoreVMs, alarmDatastoreVMsFlagHelp)
+		flag.StringVar(&c.AlarmTriggeredSince, AlarmTriggeredSinceFlagLong, defaultAlarmTriggeredSince, alarmTriggeredSinceFlagHelp)
+		flag.StringVar(&c.AlarmTriggeredBefore, AlarmTriggeredBeforeFlagLong, defaultAlarmTriggeredBefore, alarmTriggeredBeforeFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, alarmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, alarmExcludedTagsFlagHelp)
+		flag.StringVar(&c.AlarmMaintenanceModeAction, AlarmMaintenanceModeFlagLong, defaultAlarmMaintenanceModeAction, alarmMaintenanceModeActionFlagHelp)
 
 		flag.Var(&c.IncludedAlarmNames, AlarmIncludeNameFlagLong, includedAlarmNamesFlagHelp)
 		flag.Var(&c.ExcludedAlarmNames, AlarmExcludeNameFlagLong, excludedAlarmNamesFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		flag.Var(&c.IncludedAlarmDescriptions, AlarmIncludeDescFlagLong, includedAlarmDescriptionsFlagHelp)
 		flag.Var(&c.ExcludedAlarmDescriptions, AlarmExcludeDescFlagLong, excludedAlarmDescriptionsFlagHelp)
@@ -168,24 +272,41 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.Var(&c.IncludedAlarmEntityResourcePools, AlarmIncludeEntityRPoolFlagLong, includedAlarmEntityResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedAlarmEntityResourcePools, AlarmExcludeEntityRPoolFlagLong, excludedAlarmEntityResourcePoolsFlagHelp)
 
+		flag.StringVar(&c.AlarmFilterFile, AlarmFilterFileFlagLong, defaultAlarmFilterFile, alarmFilterFileFlagHelp)
+
 	case pluginType.DatastoresSpace:
 
 		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
 
 		flag.StringVar(&c.DatastoreName, DatastoreNameFlagLong, defaultDatastoreName, datastoreNameFlagHelp)
 
+		flag.BoolVar(&c.AllDatastores, AllDatastoresFlagLong, defaultAllDatastores, allDatastoresFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, datastoreSpaceClusterNameFlagHelp)
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, datastoreSpaceIncludeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, datastoreSpaceIgnoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
 		flag.IntVar(&c.DatastoreSpaceUsageWarning, DatastoreSpaceUsageWarningFlagLong, defaultDatastoreSpaceUsageWarning, datastoreSpaceUsageWarningFlagHelp)
 		flag.IntVar(&c.DatastoreSpaceUsageWarning, DatastoreSpaceUsageWarningFlagShort, defaultDatastoreSpaceUsageWarning, datastoreSpaceUsageWarningFlagHelp+shorthandFlagSuffix)
 
 		flag.IntVar(&c.DatastoreSpaceUsageCritical, DatastoreSpaceUsageCriticalFlagLong, defaultDatastoreSpaceUsageCritical, datastoreSpaceUsageCriticalFlagHelp)
 		flag.IntVar(&c.DatastoreSpaceUsageCritical, DatastoreSpaceUsageCriticalFlagShort, defaultDatastoreSpaceUsageCritical, datastoreSpaceUsageCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagLong, emergencyThresholdFlagHelp)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagShort, emergencyThresholdFlagHelp+shorthandFlagSuffix)
+
 	case pluginType.DatastoresPerformance:
 
 		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
 
 		flag.StringVar(&c.DatastoreName, DatastoreNameFlagLong, defaultDatastoreName, datastoreNameFlagHelp)
 
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
 		flag.BoolVar(&c.IgnoreMissingDatastorePerfMetrics, DatastorePerformanceIgnoreMissingMetricsFlagLong, defaultIgnoreMissingDatastoreMetrics, ignoreMissingDatastorePerfMetricsFlagHelp)
 		flag.BoolVar(&c.IgnoreMissingDatastorePerfMetrics, DatastorePerformanceIgnoreMissingMetricsFlagShort, defaultIgnoreMissingDatastoreMetrics, ignoreMissingDatastorePerfMetricsFlagHelp+shorthandFlagSuffix)
 
@@ -219,28 +340,61 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostSystemNameFlagHelp)
 
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, hostUsageClusterNameFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, hostUsageIncludeHostFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, hostUsageExcludeHostFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
 		flag.IntVar(&c.HostSystemMemoryUseWarning, HostMemoryUsageWarningFlagLong, defaultMemoryUseWarning, hostSystemMemoryUseWarningFlagHelp)
 		flag.IntVar(&c.HostSystemMemoryUseWarning, HostMemoryUsageWarningFlagShort, defaultMemoryUseWarning, hostSystemMemoryUseWarningFlagHelp+shorthandFlagSuffix)
 
 		flag.IntVar(&c.HostSystemMemoryUseCritical, HostMemoryUsageCriticalFlagLong, defaultMemoryUseCritical, hostSystemMemoryUseCriticalFlagHelp)
 		flag.IntVar(&c.HostSystemMemoryUseCritical, HostMemoryUsageCriticalFlagShort, defaultMemoryUseCritical, hostSystemMemoryUseCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagLong, emergencyThresholdFlagHelp)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagShort, emergencyThresholdFlagHelp+shorthandFlagSuffix)
+
 	case pluginType.HostSystemCPU:
 
 		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
 
 		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostSystemNameFlagHelp)
 
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, hostUsageClusterNameFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, hostUsageIncludeHostFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, hostUsageExcludeHostFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
 		flag.IntVar(&c.HostSystemCPUUseWarning, HostCPUUsageWarningFlagLong, defaultCPUUseWarning, hostSystemCPUUseWarningFlagHelp)
 		flag.IntVar(&c.HostSystemCPUUseWarning, HostCPUUsageWarningFlagShort, defaultCPUUseWarning, hostSystemCPUUseWarningFlagHelp+shorthandFlagSuffix)
 
 		flag.IntVar(&c.HostSystemCPUUseCritical, HostCPUUsageCriticalFlagLong, defaultCPUUseCritical, hostSystemCPUUseCriticalFlagHelp)
 		flag.IntVar(&c.HostSystemCPUUseCritical, HostCPUUsageCriticalFlagShort, defaultCPUUseCritical, hostSystemCPUUseCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagLong, emergencyThresholdFlagHelp)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagShort, emergencyThresholdFlagHelp+shorthandFlagSuffix)
+
 	case pluginType.ResourcePoolsMemory:
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 
 		flag.IntVar(&c.ResourcePoolsMemoryUseWarning, RPMemoryUseWarningFlagLong, defaultMemoryUseWarning, resourcePoolsMemoryUseWarningFlagHelp)
 		flag.IntVar(&c.ResourcePoolsMemoryUseWarning, RPMemoryUseWarningFlagShort, defaultMemoryUseWarning, resourcePoolsMemoryUseWarningFlagHelp+shorthandFlagSuffix)
@@ -248,8 +402,12 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.IntVar(&c.ResourcePoolsMemoryUseCritical, RPMemoryUseCriticalFlagLong, defaultMemoryUseCritical, resourcePoolsMemoryUseCriticalFlagHelp)
 		flag.IntVar(&c.ResourcePoolsMemoryUseCritical, RPMemoryUseCriticalFlagShort, defaultMemoryUseCritical, resourcePoolsMemoryUseCriticalFlagHelp+shorthandFlagSuffix)
 
-		flag.IntVar(&c.ResourcePoolsMemoryMaxAllowed, RPMemoryMaxAllowedFlagLong, defaultResourcePoolsMemoryMaxAllowed, resourcePoolsMemoryMaxAllowedFlagHelp)
-		flag.IntVar(&c.ResourcePoolsMemoryMaxAllowed, RPMemoryMaxAllowedFlagShort, defaultResourcePoolsMemoryMaxAllowed, resourcePoolsMemoryMaxAllowedFlagHelp+shorthandFlagSuffix)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagLong, emergencyThresholdFlagHelp)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagShort, emergencyThresholdFlagHelp+shorthandFlagSuffix)
+
+		resourcePoolsMemoryMaxAllowedFlag := newSizeFlag(&c.ResourcePoolsMemoryMaxAllowed, defaultResourcePoolsMemoryMaxAllowed, units.GB, "GiB")
+		flag.Var(resourcePoolsMemoryMaxAllowedFlag, RPMemoryMaxAllowedFlagLong, resourcePoolsMemoryMaxAllowedFlagHelp)
+		flag.Var(resourcePoolsMemoryMaxAllowedFlag, RPMemoryMaxAllowedFlagShort, resourcePoolsMemoryMaxAllowedFlagHelp+shorthandFlagSuffix)
 
 	case pluginType.VirtualCPUsAllocation:
 
@@ -258,7 +416,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
 
 		flag.IntVar(&c.VCPUsAllocatedWarning, VirtualCPUsWarningFlagLong, defaultVCPUsAllocatedWarning, vCPUsAllocatedWarningFlagHelp)
@@ -267,9 +436,16 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 		flag.IntVar(&c.VCPUsAllocatedCritical, VirtualCPUsCriticalFlagLong, defaultVCPUsAllocatedCritical, vCPUsAllocatedCriticalFlagHelp)
 		flag.IntVar(&c.VCPUsAllocatedCritical, VirtualCPUsCriticalFlagShort, defaultVCPUsAllocatedCritical, vCPUsAllocatedCriticalFlagHelp+shorthandFlagSuffix)
 
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagLong, emergencyThresholdFlagHelp)
+		flag.Var(&c.emergencyThreshold, EmergencyThresholdFlagShort, emergencyThresholdFlagHelp+shorthandFlagSuffix)
+
 		flag.IntVar(&c.VCPUsMaxAllowed, VirtualCPUsMaxAllowedFlagLong, defaultVCPUsMaxAllowed, vCPUsAllocatedMaxAllowedFlagHelp)
 		flag.IntVar(&c.VCPUsMaxAllowed, VirtualCPUsMaxAllowedFlagShort, defaultVCPUsMaxAllowed, vCPUsAllocatedMaxAllowedFlagHelp+shorthandFlagSuffix)
 
+		flag.BoolVar(&c.VCPUsClusterCapacity, VirtualCPUsClusterCapacityFlagLong, defaultVCPUsClusterCapacity, vCPUsClusterCapacityFlagHelp)
+		flag.Float64Var(&c.VCPUsClusterRatioWarning, VirtualCPUsRatioWarningFlagLong, defaultVCPUsClusterRatioWarning, vCPUsClusterRatioWarningFlagHelp)
+		flag.Float64Var(&c.VCPUsClusterRatioCritical, VirtualCPUsRatioCriticalFlagLong, defaultVCPUsClusterRatioCritical, vCPUsClusterRatioCriticalFlagHelp)
+
 	case pluginType.VirtualHardwareVersion:
 
 		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
@@ -277,7 +453,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
 
 		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
@@ -303,10 +490,21 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
 		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
 
 		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, ignoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		flag.StringVar(&c.sharedCustomAttributeName, CustomAttributeNameFlagLong, defaultCustomAttributeName, sharedCustomAttributeNameFlagHelp)
 		flag.StringVar(&c.sharedCustomAttributePrefixSeparator, CustomAttributePrefixSeparatorFlagLong, defaultCustomAttributePrefixSeparator, sharedCustomAttributePrefixSeparatorFlagHelp)
@@ -337,7 +535,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
 
 		flag.StringVar(&c.VMBackupDateCustomAttribute, BackupDateCAFlagLong, defaultVMBackupDateCustomAttribute, vmBackupDateCustomAttributeFlagHelp)
 		flag.StringVar(&c.VMBackupMetadataCustomAttribute, BackupMetadataCAFlagLong, defaultVMBackupMetadataCustomAttribute, vmBackupMetadataCustomAttributeFlagHelp)
@@ -366,7 +575,1067 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
 		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+	case pluginType.VSANHealth:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, vsanClusterNameFlagHelp)
+
+		flag.BoolVar(&c.VSANHealthRefresh, VSANHealthRefreshFlagLong, defaultVSANHealthRefresh, vsanHealthRefreshFlagHelp)
+		flag.Var(&c.IgnoredVSANHealthTests, IgnoreVSANHealthTestFlagLong, ignoredVSANHealthTestsFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.HostServices:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.Var(&c.requiredHostServices, RequireHostServiceFlagLong, requiredHostServicesFlagHelp)
+		flag.Var(&c.disallowedHostServices, DisallowHostServiceFlagLong, disallowedHostServicesFlagHelp)
+
+	case pluginType.LicensingFeatureUsage:
+
+		flag.IntVar(&c.LicenseUsageWarning, LicenseUsageWarningFlagLong, defaultLicenseUsageWarning, licenseUsageWarningFlagHelp)
+		flag.IntVar(&c.LicenseUsageWarning, LicenseUsageWarningFlagShort, defaultLicenseUsageWarning, licenseUsageWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.LicenseUsageCritical, LicenseUsageCriticalFlagLong, defaultLicenseUsageCritical, licenseUsageCriticalFlagHelp)
+		flag.IntVar(&c.LicenseUsageCritical, LicenseUsageCriticalFlagShort, defaultLicenseUsageCritical, licenseUsageCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.Var(&c.LicenseFeatures, LicenseFeatureFlagLong, licenseFeaturesFlagHelp)
+
+	case pluginType.VirtualMachineCPUReady:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Real-time performance statistics are not available for
+		// powered off VMs, so the flag to include them is not exposed.
+
+		flag.IntVar(&c.VMCPUReadyWarning, VMCPUReadyWarningFlagLong, defaultVMCPUReadyWarning, vmCPUReadyWarningFlagHelp)
+		flag.IntVar(&c.VMCPUReadyWarning, VMCPUReadyWarningFlagShort, defaultVMCPUReadyWarning, vmCPUReadyWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMCPUReadyCritical, VMCPUReadyCriticalFlagLong, defaultVMCPUReadyCritical, vmCPUReadyCriticalFlagHelp)
+		flag.IntVar(&c.VMCPUReadyCritical, VMCPUReadyCriticalFlagShort, defaultVMCPUReadyCritical, vmCPUReadyCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMCPUCoStopWarning, VMCPUCoStopWarningFlagLong, defaultVMCPUCoStopWarning, vmCPUCoStopWarningFlagHelp)
+		flag.IntVar(&c.VMCPUCoStopWarning, VMCPUCoStopWarningFlagShort, defaultVMCPUCoStopWarning, vmCPUCoStopWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMCPUCoStopCritical, VMCPUCoStopCriticalFlagLong, defaultVMCPUCoStopCritical, vmCPUCoStopCriticalFlagHelp)
+		flag.IntVar(&c.VMCPUCoStopCritical, VMCPUCoStopCriticalFlagShort, defaultVMCPUCoStopCritical, vmCPUCoStopCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.FolderVMCounts:
+
+		flag.Var(&c.FolderIDs, FolderIDFlagLong, folderVMCountsFolderIDFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.Var(&c.folderVMCountMaxWarning, FolderVMCountMaxWarningFlagLong, folderVMCountMaxWarningFlagHelp)
+		flag.Var(&c.folderVMCountMaxCritical, FolderVMCountMaxCriticalFlagLong, folderVMCountMaxCriticalFlagHelp)
+		flag.Var(&c.folderVMCountMinWarning, FolderVMCountMinWarningFlagLong, folderVMCountMinWarningFlagHelp)
+		flag.Var(&c.folderVMCountMinCritical, FolderVMCountMinCriticalFlagLong, folderVMCountMinCriticalFlagHelp)
+
+	case pluginType.VirtualMachineMemoryPressure:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Memory quick stats are not populated for powered off VMs, so
+		// the flag to include them is not exposed.
+
+		flag.IntVar(&c.VMMemoryBalloonedWarning, VMMemoryBalloonedWarningFlagLong, defaultVMMemoryBalloonedWarning, vmMemoryBalloonedWarningFlagHelp)
+		flag.IntVar(&c.VMMemoryBalloonedWarning, VMMemoryBalloonedWarningFlagShort, defaultVMMemoryBalloonedWarning, vmMemoryBalloonedWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMMemoryBalloonedCritical, VMMemoryBalloonedCriticalFlagLong, defaultVMMemoryBalloonedCritical, vmMemoryBalloonedCriticalFlagHelp)
+		flag.IntVar(&c.VMMemoryBalloonedCritical, VMMemoryBalloonedCriticalFlagShort, defaultVMMemoryBalloonedCritical, vmMemoryBalloonedCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMMemorySwappedWarning, VMMemorySwappedWarningFlagLong, defaultVMMemorySwappedWarning, vmMemorySwappedWarningFlagHelp)
+		flag.IntVar(&c.VMMemorySwappedWarning, VMMemorySwappedWarningFlagShort, defaultVMMemorySwappedWarning, vmMemorySwappedWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMMemorySwappedCritical, VMMemorySwappedCriticalFlagLong, defaultVMMemorySwappedCritical, vmMemorySwappedCriticalFlagHelp)
+		flag.IntVar(&c.VMMemorySwappedCritical, VMMemorySwappedCriticalFlagShort, defaultVMMemorySwappedCritical, vmMemorySwappedCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMMemoryCompressedWarning, VMMemoryCompressedWarningFlagLong, defaultVMMemoryCompressedWarning, vmMemoryCompressedWarningFlagHelp)
+		flag.IntVar(&c.VMMemoryCompressedWarning, VMMemoryCompressedWarningFlagShort, defaultVMMemoryCompressedWarning, vmMemoryCompressedWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMMemoryCompressedCritical, VMMemoryCompressedCriticalFlagLong, defaultVMMemoryCompressedCritical, vmMemoryCompressedCriticalFlagHelp)
+		flag.IntVar(&c.VMMemoryCompressedCritical, VMMemoryCompressedCriticalFlagShort, defaultVMMemoryCompressedCritical, vmMemoryCompressedCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.ClusterResourceUsage:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, clusterResourceUsageClusterNameFlagHelp)
+
+		flag.IntVar(&c.ClusterCPUUseWarning, HostCPUUsageWarningFlagLong, defaultCPUUseWarning, clusterCPUUseWarningFlagHelp)
+		flag.IntVar(&c.ClusterCPUUseWarning, HostCPUUsageWarningFlagShort, defaultCPUUseWarning, clusterCPUUseWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.ClusterCPUUseCritical, HostCPUUsageCriticalFlagLong, defaultCPUUseCritical, clusterCPUUseCriticalFlagHelp)
+		flag.IntVar(&c.ClusterCPUUseCritical, HostCPUUsageCriticalFlagShort, defaultCPUUseCritical, clusterCPUUseCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.ClusterMemoryUseWarning, HostMemoryUsageWarningFlagLong, defaultMemoryUseWarning, clusterMemoryUseWarningFlagHelp)
+		flag.IntVar(&c.ClusterMemoryUseWarning, HostMemoryUsageWarningFlagShort, defaultMemoryUseWarning, clusterMemoryUseWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.ClusterMemoryUseCritical, HostMemoryUsageCriticalFlagLong, defaultMemoryUseCritical, clusterMemoryUseCriticalFlagHelp)
+		flag.IntVar(&c.ClusterMemoryUseCritical, HostMemoryUsageCriticalFlagShort, defaultMemoryUseCritical, clusterMemoryUseCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.VASAProviderStatus:
+
+		flag.Var(&c.RequiredVASAProviders, RequiredVASAProviderFlagLong, requiredVASAProvidersFlagHelp)
+
+		flag.IntVar(&c.VASACertExpiryWarning, VASACertExpiryWarningFlagLong, defaultVASACertExpiryWarning, vasaCertExpiryWarningFlagHelp)
+		flag.IntVar(&c.VASACertExpiryWarning, VASACertExpiryWarningFlagShort, defaultVASACertExpiryWarning, vasaCertExpiryWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VASACertExpiryCritical, VASACertExpiryCriticalFlagLong, defaultVASACertExpiryCritical, vasaCertExpiryCriticalFlagHelp)
+		flag.IntVar(&c.VASACertExpiryCritical, VASACertExpiryCriticalFlagShort, defaultVASACertExpiryCritical, vasaCertExpiryCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.ClusterHAStatus:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, clusterHAStatusClusterNamesFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.VVolDatastoreHealth:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.DatastoreName, DatastoreNameFlagLong, defaultDatastoreName, vvolDatastoreNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, ignoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.IntVar(&c.DatastoreSpaceUsageWarning, DatastoreSpaceUsageWarningFlagLong, defaultDatastoreSpaceUsageWarning, datastoreSpaceUsageWarningFlagHelp)
+		flag.IntVar(&c.DatastoreSpaceUsageWarning, DatastoreSpaceUsageWarningFlagShort, defaultDatastoreSpaceUsageWarning, datastoreSpaceUsageWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.DatastoreSpaceUsageCritical, DatastoreSpaceUsageCriticalFlagLong, defaultDatastoreSpaceUsageCritical, datastoreSpaceUsageCriticalFlagHelp)
+		flag.IntVar(&c.DatastoreSpaceUsageCritical, DatastoreSpaceUsageCriticalFlagShort, defaultDatastoreSpaceUsageCritical, datastoreSpaceUsageCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.ClusterDRSStatus:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, clusterDRSStatusClusterNamesFlagHelp)
+
+		flag.StringVar(&c.RequiredDRSBehavior, RequiredDRSBehaviorFlagLong, defaultRequiredDRSBehavior, requiredDRSBehaviorFlagHelp)
+
+		flag.IntVar(&c.DRSRecommendationsWarning, DRSRecommendationsWarningFlagLong, defaultDRSRecommendationsWarning, drsRecommendationsWarningFlagHelp)
+		flag.IntVar(&c.DRSRecommendationsWarning, DRSRecommendationsWarningFlagShort, defaultDRSRecommendationsWarning, drsRecommendationsWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.DRSRecommendationsCritical, DRSRecommendationsCriticalFlagLong, defaultDRSRecommendationsCritical, drsRecommendationsCriticalFlagHelp)
+		flag.IntVar(&c.DRSRecommendationsCritical, DRSRecommendationsCriticalFlagShort, defaultDRSRecommendationsCritical, drsRecommendationsCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.HostHardwareSensors:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.Var(&c.IncludedHostSensors, IncludeHostSensorFlagLong, includedHostSensorsFlagHelp)
+		flag.Var(&c.ExcludedHostSensors, ExcludeHostSensorFlagLong, excludedHostSensorsFlagHelp)
+
+	case pluginType.VirtualMachineFTLatency:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
 		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Fault Tolerance quick stats are not populated for powered off
+		// VMs, so the flag to include them is not exposed.
+
+		flag.IntVar(&c.VMFTLatencyWarning, VMFTLatencyWarningFlagLong, defaultVMFTLatencyWarning, vmFTLatencyWarningFlagHelp)
+		flag.IntVar(&c.VMFTLatencyWarning, VMFTLatencyWarningFlagShort, defaultVMFTLatencyWarning, vmFTLatencyWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMFTLatencyCritical, VMFTLatencyCriticalFlagLong, defaultVMFTLatencyCritical, vmFTLatencyCriticalFlagHelp)
+		flag.IntVar(&c.VMFTLatencyCritical, VMFTLatencyCriticalFlagShort, defaultVMFTLatencyCritical, vmFTLatencyCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMFTBandwidthWarning, VMFTBandwidthWarningFlagLong, defaultVMFTBandwidthWarning, vmFTBandwidthWarningFlagHelp)
+		flag.IntVar(&c.VMFTBandwidthWarning, VMFTBandwidthWarningFlagShort, defaultVMFTBandwidthWarning, vmFTBandwidthWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMFTBandwidthCritical, VMFTBandwidthCriticalFlagLong, defaultVMFTBandwidthCritical, vmFTBandwidthCriticalFlagHelp)
+		flag.IntVar(&c.VMFTBandwidthCritical, VMFTBandwidthCriticalFlagShort, defaultVMFTBandwidthCritical, vmFTBandwidthCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.HostStoragePaths:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.VirtualMachineToolsNoIP:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Powered off VMs do not report an IP Address, so the flag to
+		// include them is not exposed.
+
+		flag.IntVar(&c.ToolsNoIPGracePeriod, ToolsNoIPGracePeriodFlagLong, defaultToolsNoIPGracePeriod, toolsNoIPGracePeriodFlagHelp)
+
+	case pluginType.VCenterCertificates:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, certificatesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ExcludeHostCertificates, ExcludeHostCertificatesFlagLong, defaultExcludeHostCertificates, excludeHostCertificatesFlagHelp)
+
+		flag.IntVar(&c.CertificateExpiryWarning, CertificateExpiryWarningFlagLong, defaultCertificateExpiryWarning, certificateExpiryWarningFlagHelp)
+		flag.IntVar(&c.CertificateExpiryWarning, CertificateExpiryWarningFlagShort, defaultCertificateExpiryWarning, certificateExpiryWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.CertificateExpiryCritical, CertificateExpiryCriticalFlagLong, defaultCertificateExpiryCritical, certificateExpiryCriticalFlagHelp)
+		flag.IntVar(&c.CertificateExpiryCritical, CertificateExpiryCriticalFlagShort, defaultCertificateExpiryCritical, certificateExpiryCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.License:
+
+		flag.IntVar(&c.LicenseUsageWarning, LicenseUsageWarningFlagLong, defaultLicenseUsageWarning, licenseUsageWarningFlagHelp)
+		flag.IntVar(&c.LicenseUsageWarning, LicenseUsageWarningFlagShort, defaultLicenseUsageWarning, licenseUsageWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.LicenseUsageCritical, LicenseUsageCriticalFlagLong, defaultLicenseUsageCritical, licenseUsageCriticalFlagHelp)
+		flag.IntVar(&c.LicenseUsageCritical, LicenseUsageCriticalFlagShort, defaultLicenseUsageCritical, licenseUsageCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.LicenseExpiryWarning, LicenseExpiryWarningFlagLong, defaultLicenseExpiryWarning, licenseExpiryWarningFlagHelp)
+		flag.IntVar(&c.LicenseExpiryWarning, LicenseExpiryWarningFlagShort, defaultLicenseExpiryWarning, licenseExpiryWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.LicenseExpiryCritical, LicenseExpiryCriticalFlagLong, defaultLicenseExpiryCritical, licenseExpiryCriticalFlagHelp)
+		flag.IntVar(&c.LicenseExpiryCritical, LicenseExpiryCriticalFlagShort, defaultLicenseExpiryCritical, licenseExpiryCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.DatacenterInventoryDrift:
+
+		flag.Var(&c.DatacenterNames, DatacenterNameFlagLong, datacenterNamesFlagHelp)
+
+		flag.StringVar(&c.InventoryStateFile, InventoryStateFileFlagLong, defaultInventoryStateFile, inventoryStateFileFlagHelp)
+
+		flag.IntVar(&c.InventoryDriftWarning, InventoryDriftWarningFlagLong, defaultInventoryDriftWarning, inventoryDriftWarningFlagHelp)
+		flag.IntVar(&c.InventoryDriftWarning, InventoryDriftWarningFlagShort, defaultInventoryDriftWarning, inventoryDriftWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.InventoryDriftCritical, InventoryDriftCriticalFlagLong, defaultInventoryDriftCritical, inventoryDriftCriticalFlagHelp)
+		flag.IntVar(&c.InventoryDriftCritical, InventoryDriftCriticalFlagShort, defaultInventoryDriftCritical, inventoryDriftCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.Tasks:
+
+		flag.IntVar(&c.TasksLookback, TasksLookbackFlagLong, defaultTasksLookback, tasksLookbackFlagHelp)
+
+		flag.IntVar(&c.FailedTasksWarning, FailedTasksWarningFlagLong, defaultFailedTasksWarning, failedTasksWarningFlagHelp)
+		flag.IntVar(&c.FailedTasksWarning, FailedTasksWarningFlagShort, defaultFailedTasksWarning, failedTasksWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.FailedTasksCritical, FailedTasksCriticalFlagLong, defaultFailedTasksCritical, failedTasksCriticalFlagHelp)
+		flag.IntVar(&c.FailedTasksCritical, FailedTasksCriticalFlagShort, defaultFailedTasksCritical, failedTasksCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.Var(&c.IncludedTaskDescriptionIDs, IncludeTaskDescriptionIDFlagLong, includeTaskIDFlagHelp)
+		flag.Var(&c.ExcludedTaskDescriptionIDs, ExcludeTaskDescriptionIDFlagLong, excludeTaskIDFlagHelp)
+
+		flag.Var(&c.IncludedTaskUsers, IncludeTaskUserFlagLong, includeTaskUserFlagHelp)
+		flag.Var(&c.ExcludedTaskUsers, ExcludeTaskUserFlagLong, excludeTaskUserFlagHelp)
+
+	case pluginType.HostESXiShellSSHEnabled:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.IntVar(&c.ShellSSHRunningWarning, ShellSSHRunningWarningFlagLong, defaultShellSSHRunningWarning, shellSSHRunningWarningFlagHelp)
+		flag.IntVar(&c.ShellSSHRunningWarning, ShellSSHRunningWarningFlagShort, defaultShellSSHRunningWarning, shellSSHRunningWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.ShellSSHRunningCritical, ShellSSHRunningCriticalFlagLong, defaultShellSSHRunningCritical, shellSSHRunningCriticalFlagHelp)
+		flag.IntVar(&c.ShellSSHRunningCritical, ShellSSHRunningCriticalFlagShort, defaultShellSSHRunningCritical, shellSSHRunningCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.Var(&c.AllowedShellSSHHosts, AllowShellSSHHostFlagLong, allowShellSSHHostFlagHelp)
+
+	case pluginType.HostDNSRouting:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, hostDNSRoutingClusterNamesFlagHelp)
+
+		flag.Var(&c.ExpectedDNSServers, ExpectedDNSServerFlagLong, expectedDNSServerFlagHelp)
+		flag.Var(&c.ExpectedSearchDomains, ExpectedSearchDomainFlagLong, expectedSearchDomainFlagHelp)
+
+		flag.StringVar(&c.ExpectedIPv4Gateway, ExpectedIPv4GatewayFlagLong, defaultExpectedIPv4Gateway, expectedIPv4GatewayFlagHelp)
+		flag.StringVar(&c.ExpectedIPv6Gateway, ExpectedIPv6GatewayFlagLong, defaultExpectedIPv6Gateway, expectedIPv6GatewayFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.Events:
+
+		flag.IntVar(&c.EventsLookback, EventsLookbackFlagLong, defaultEventsLookback, eventsLookbackFlagHelp)
+
+		flag.IntVar(&c.MatchedEventsWarning, MatchedEventsWarningFlagLong, defaultMatchedEventsWarning, matchedEventsWarningFlagHelp)
+		flag.IntVar(&c.MatchedEventsWarning, MatchedEventsWarningFlagShort, defaultMatchedEventsWarning, matchedEventsWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.MatchedEventsCritical, MatchedEventsCriticalFlagLong, defaultMatchedEventsCritical, matchedEventsCriticalFlagHelp)
+		flag.IntVar(&c.MatchedEventsCritical, MatchedEventsCriticalFlagShort, defaultMatchedEventsCritical, matchedEventsCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.Var(&c.IncludedEventTypeIDs, IncludeEventTypeFlagLong, includeEventTypeFlagHelp)
+		flag.Var(&c.ExcludedEventTypeIDs, ExcludeEventTypeFlagLong, excludeEventTypeFlagHelp)
+
+		flag.Var(&c.IncludedEventMessages, IncludeEventMessageFlagLong, includeEventMessageFlagHelp)
+		flag.Var(&c.ExcludedEventMessages, ExcludeEventMessageFlagLong, excludeEventMessageFlagHelp)
+
+		flag.Var(&c.ExcludedEventEntityNames, ExcludeEventEntityNameFlagLong, excludeEventEntityNameFlagHelp)
+		flag.Var(&c.ExcludedEventUserNames, ExcludeEventUserNameFlagLong, excludeEventUserNameFlagHelp)
+
+	case pluginType.SnapshotsDatastoreQuota:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, includeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, excludeDatastoreSnapshotsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		snapshotsDatastoreQuotaWarningFlag := newSizeFlag(&c.SnapshotsDatastoreQuotaWarning, defaultSnapshotsDatastoreQuotaWarning, units.GB, "GiB")
+		flag.Var(snapshotsDatastoreQuotaWarningFlag, SnapshotSizeWarningFlagLong, snapshotsDatastoreQuotaWarningFlagHelp)
+		flag.Var(snapshotsDatastoreQuotaWarningFlag, SnapshotSizeWarningFlagShort, snapshotsDatastoreQuotaWarningFlagHelp+shorthandFlagSuffix)
+
+		snapshotsDatastoreQuotaCriticalFlag := newSizeFlag(&c.SnapshotsDatastoreQuotaCritical, defaultSnapshotsDatastoreQuotaCritical, units.GB, "GiB")
+		flag.Var(snapshotsDatastoreQuotaCriticalFlag, SnapshotSizeCriticalFlagLong, snapshotsDatastoreQuotaCriticalFlagHelp)
+		flag.Var(snapshotsDatastoreQuotaCriticalFlag, SnapshotSizeCriticalFlagShort, snapshotsDatastoreQuotaCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.VirtualMachineOrphanedDevices:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Orphaned devices are most likely to cause problems when
+		// powering on a VM, so powered off VMs are always evaluated and the
+		// flag to include them is not exposed.
+
+	case pluginType.OrphanedVMDKs:
+
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, orphanedVMDKsIncludeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, orphanedVMDKsIgnoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.Var(&c.IgnoredVMDKPaths, IgnoreVMDKPathFlagLong, ignoreVMDKPathFlagHelp)
+
+		orphanedVMDKsSizeWarningFlag := newSizeFlag(&c.OrphanedVMDKsSizeWarning, defaultOrphanedVMDKsSizeWarning, units.GB, "GiB")
+		flag.Var(orphanedVMDKsSizeWarningFlag, SnapshotSizeWarningFlagLong, orphanedVMDKsSizeWarningFlagHelp)
+		flag.Var(orphanedVMDKsSizeWarningFlag, SnapshotSizeWarningFlagShort, orphanedVMDKsSizeWarningFlagHelp+shorthandFlagSuffix)
+
+		orphanedVMDKsSizeCriticalFlag := newSizeFlag(&c.OrphanedVMDKsSizeCritical, defaultOrphanedVMDKsSizeCritical, units.GB, "GiB")
+		flag.Var(orphanedVMDKsSizeCriticalFlag, SnapshotSizeCriticalFlagLong, orphanedVMDKsSizeCriticalFlagHelp)
+		flag.Var(orphanedVMDKsSizeCriticalFlag, SnapshotSizeCriticalFlagShort, orphanedVMDKsSizeCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.NagiosGenConfig:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.GenConfigHostPattern, GenConfigHostPatternFlagLong, defaultGenConfigHostPattern, genConfigHostPatternFlagHelp)
+		flag.StringVar(&c.GenConfigDatastorePattern, GenConfigDatastorePatternFlagLong, defaultGenConfigDatastorePattern, genConfigDatastorePatternFlagHelp)
+		flag.StringVar(&c.GenConfigClusterPattern, GenConfigClusterPatternFlagLong, defaultGenConfigClusterPattern, genConfigClusterPatternFlagHelp)
+
+		flag.StringVar(&c.GenConfigTemplateFile, GenConfigTemplateFileFlagLong, defaultGenConfigTemplateFile, genConfigTemplateFileFlagHelp)
+		flag.StringVar(&c.GenConfigOutputFile, GenConfigOutputFileFlagLong, defaultGenConfigOutputFile, genConfigOutputFileFlagHelp)
+
+		flag.StringVar(&c.GenConfigNagiosHost, GenConfigNagiosHostFlagLong, defaultGenConfigNagiosHost, genConfigNagiosHostFlagHelp)
+		flag.BoolVar(&c.GenConfigHostDefinition, GenConfigHostDefinitionFlagLong, defaultGenConfigHostDefinition, genConfigHostDefinitionFlagHelp)
+
+	case pluginType.VirtualMachineConnectedMedia:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.Var(&c.AllowedMediaVMs, AllowMediaVMFlagLong, allowMediaVMFlagHelp)
+		flag.Var(&c.AllowedMediaPaths, AllowMediaPathFlagLong, allowMediaPathFlagHelp)
+
+	case pluginType.VirtualMachineGuestDiskUsage:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		// NOTE: Guest filesystem details are only reported by VMware Tools
+		// for running VMs, so the flag to include powered off VMs is not
+		// exposed.
+
+		flag.IntVar(&c.VMGuestDiskUsageWarning, GuestDiskUsageWarningFlagLong, defaultVMGuestDiskUsageWarning, vmGuestDiskUsageWarningFlagHelp)
+		flag.IntVar(&c.VMGuestDiskUsageWarning, GuestDiskUsageWarningFlagShort, defaultVMGuestDiskUsageWarning, vmGuestDiskUsageWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMGuestDiskUsageCritical, GuestDiskUsageCriticalFlagLong, defaultVMGuestDiskUsageCritical, vmGuestDiskUsageCriticalFlagHelp)
+		flag.IntVar(&c.VMGuestDiskUsageCritical, GuestDiskUsageCriticalFlagShort, defaultVMGuestDiskUsageCritical, vmGuestDiskUsageCriticalFlagHelp+shorthandFlagSuffix)
+
+		flag.Var(&c.VMGuestDiskMountThresholds, GuestDiskMountThresholdFlagLong, vmGuestDiskMountThresholdFlagHelp)
+		flag.Var(&c.VMGuestDiskExcludedMounts, GuestDiskExcludeMountFlagLong, vmGuestDiskExcludeMountFlagHelp)
+
+	case pluginType.ResourcePoolRunawayVM:
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.IntVar(&c.RunawayVMCPUShareWarning, RunawayVMCPUShareWarningFlagLong, defaultRunawayVMCPUShareWarning, runawayVMCPUShareWarningFlagHelp)
+		flag.IntVar(&c.RunawayVMCPUShareCritical, RunawayVMCPUShareCriticalFlagLong, defaultRunawayVMCPUShareCritical, runawayVMCPUShareCriticalFlagHelp)
+
+		flag.IntVar(&c.RunawayVMMemoryShareWarning, RunawayVMMemoryShareWarningFlagLong, defaultRunawayVMMemoryShareWarning, runawayVMMemoryShareWarningFlagHelp)
+		flag.IntVar(&c.RunawayVMMemoryShareCritical, RunawayVMMemoryShareCriticalFlagLong, defaultRunawayVMMemoryShareCritical, runawayVMMemoryShareCriticalFlagHelp)
+
+		flag.IntVar(&c.RunawayVMTopCount, RunawayVMTopCountFlagLong, defaultRunawayVMTopCount, runawayVMTopCountFlagHelp)
+
+	case pluginType.VirtualMachineToolsVersion:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.IntVar(&c.VMToolsMinVersion, VMToolsMinVersionFlagLong, defaultVMToolsMinVersion, vmToolsMinVersionFlagHelp)
+
+	case pluginType.VirtualMachineCPUAffinity:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.Var(&c.AllowedAffinityVMs, AllowAffinityVMFlagLong, allowAffinityVMFlagHelp)
+
+	case pluginType.HostTimeDrift:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.IntVar(&c.TimeDriftWarning, TimeDriftWarningFlagLong, defaultTimeDriftWarning, timeDriftWarningFlagHelp)
+		flag.IntVar(&c.TimeDriftCritical, TimeDriftCriticalFlagLong, defaultTimeDriftCritical, timeDriftCriticalFlagHelp)
+
+		flag.BoolVar(&c.TimeDriftCompareVCenter, TimeDriftCompareVCenterFlagLong, defaultTimeDriftCompareVCenter, timeDriftCompareVCenterFlagHelp)
+
+	case pluginType.ESXiImageProfileDrift:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, esxiImageProfileClusterNamesFlagHelp)
+
+		flag.StringVar(&c.ExpectedImageProfile, ExpectedImageProfileFlagLong, defaultExpectedImageProfile, expectedImageProfileFlagHelp)
+		flag.BoolVar(&c.UseVLCMDesiredImage, UseVLCMDesiredImageFlagLong, defaultUseVLCMDesiredImage, useVLCMDesiredImageFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.VLCMCompliance:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, vlcmComplianceClusterNamesFlagHelp)
+
+		flag.IntVar(&c.VLCMNonCompliantWarning, VLCMNonCompliantWarningFlagLong, defaultVLCMNonCompliantWarning, vlcmNonCompliantWarningFlagHelp)
+		flag.IntVar(&c.VLCMNonCompliantCritical, VLCMNonCompliantCriticalFlagLong, defaultVLCMNonCompliantCritical, vlcmNonCompliantCriticalFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.AlarmDefinitionsHash:
+
+		flag.StringVar(&c.AlarmDefinitionsStateFile, AlarmDefinitionsStateFileFlagLong, defaultAlarmDefinitionsStateFile, alarmDefinitionsStateFileFlagHelp)
+
+	case pluginType.HostAcceleration:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostAccelerationHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.BoolVar(&c.RequireCBRC, RequireCBRCFlagLong, defaultRequireCBRC, requireCBRCFlagHelp)
+		flag.BoolVar(&c.RequireMemoryTiering, RequireMemoryTieringFlagLong, defaultRequireMemoryTiering, requireMemoryTieringFlagHelp)
+
+	case pluginType.VirtualMachineLastBackupViaTag:
+
+		// NOTE: As with the Custom Attribute variant, this plugin is
+		// hard-coded to evaluate powered off and powered on VMs equally.
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.StringVar(&c.VMBackupTagCategory, BackupTagCategoryFlagLong, defaultVMBackupTagCategory, vmBackupTagCategoryFlagHelp)
+		flag.StringVar(&c.VMBackupTagPrefix, BackupTagPrefixFlagLong, defaultVMBackupTagPrefix, vmBackupTagPrefixFlagHelp)
+		flag.StringVar(&c.VMBackupDateFormat, BackupDateFormatFlagLong, defaultVMBackupDateFormat, vmBackupDateFormatFlagHelp)
+		flag.StringVar(&c.VMBackupDateTimezone, BackupDateTimezoneFlagLong, defaultVMBackupDateTimezone, vmBackupDateTimezoneFlagHelp)
+
+		flag.IntVar(&c.VMBackupAgeWarning, BackupAgeWarningFlagLong, defaultVMBackupAgeWarning, vmBackupAgeWarningFlagHelp)
+		flag.IntVar(&c.VMBackupAgeWarning, BackupAgeWarningFlagShort, defaultVMBackupAgeWarning, vmBackupAgeWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VMBackupAgeCritical, BackupAgeCriticalFlagLong, defaultVMBackupAgeCritical, vmBackupAgeCriticalFlagHelp)
+		flag.IntVar(&c.VMBackupAgeCritical, BackupAgeCriticalFlagShort, defaultVMBackupAgeCritical, vmBackupAgeCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.VirtualMachineCustomAttribute:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.StringVar(&c.VMCustomAttributeName, CustomAttributeNameFlagLong, defaultVMCustomAttributeName, vmCustomAttributeNameFlagHelp)
+		flag.BoolVar(&c.IgnoreMissingCustomAttribute, CustomAttributeIgnoreMissingCAFlagLong, defaultIgnoreMissingCustomAttribute, ignoreMissingCustomAttributeFlagHelp)
+
+		flag.StringVar(&c.VMCustomAttributePattern, VMCustomAttributePatternFlagLong, defaultVMCustomAttributePattern, vmCustomAttributePatternFlagHelp)
+		flag.StringVar(&c.VMCustomAttributeMin, VMCustomAttributeMinFlagLong, defaultVMCustomAttributeMin, vmCustomAttributeMinFlagHelp)
+		flag.StringVar(&c.VMCustomAttributeMax, VMCustomAttributeMaxFlagLong, defaultVMCustomAttributeMax, vmCustomAttributeMaxFlagHelp)
+		flag.StringVar(&c.VMCustomAttributeDateFormat, VMCustomAttributeDateFormatFlagLong, defaultVMCustomAttributeDateFormat, vmCustomAttributeDateFormatFlagHelp)
+
+	case pluginType.VCenterDatabaseHealth:
+
+		flag.IntVar(&c.VCenterDatabaseUsageWarning, VCenterDatabaseUsageWarningFlagLong, defaultVCenterDatabaseUsageWarning, vcenterDatabaseUsageWarningFlagHelp)
+		flag.IntVar(&c.VCenterDatabaseUsageWarning, VCenterDatabaseUsageWarningFlagShort, defaultVCenterDatabaseUsageWarning, vcenterDatabaseUsageWarningFlagHelp+shorthandFlagSuffix)
+
+		flag.IntVar(&c.VCenterDatabaseUsageCritical, VCenterDatabaseUsageCriticalFlagLong, defaultVCenterDatabaseUsageCritical, vcenterDatabaseUsageCriticalFlagHelp)
+		flag.IntVar(&c.VCenterDatabaseUsageCritical, VCenterDatabaseUsageCriticalFlagShort, defaultVCenterDatabaseUsageCritical, vcenterDatabaseUsageCriticalFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.VCenterServiceStatus:
+
+		flag.Var(&c.requiredVCenterServices, RequireVCenterServiceFlagLong, requiredVCenterServicesFlagHelp)
+		flag.Var(&c.ignoredVCenterServices, IgnoreVCenterServiceFlagLong, ignoredVCenterServicesFlagHelp)
+
+	case pluginType.VMwareExporter:
+
+		flag.StringVar(&c.ExporterListenAddress, ExporterListenAddressFlagLong, defaultExporterListenAddress, exporterListenAddressFlagHelp)
+		flag.IntVar(&c.exporterCollectionInterval, ExporterCollectionIntervalFlagLong, defaultExporterCollectionInterval, exporterCollectionIntervalFlagHelp)
+
+	case pluginType.AlarmActionDisabled:
+
+		flag.Var(&c.alarmActionObjectTypes, AlarmActionObjectTypeFlagLong, alarmActionObjectTypeFlagHelp)
+		flag.Var(&c.IgnoredAlarmActionObjects, IgnoreAlarmActionObjectFlagLong, ignoreAlarmActionObjectFlagHelp)
+
+	case pluginType.VirtualMachineIndependentDisks:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.Var(&c.AllowedIndependentDiskVMs, AllowIndependentDiskVMFlagLong, allowIndependentDiskVMFlagHelp)
+
+	case pluginType.VirtualMachineRDMUsage:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.Var(&c.AllowedRDMVMs, AllowRDMVMFlagLong, allowRDMVMFlagHelp)
+
+	case pluginType.HostSRIOVPassthroughCapacity:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.IntVar(&c.VFUsageWarning, VFUsageWarningFlagLong, defaultVFUsageWarning, vfUsageWarningFlagHelp)
+		flag.IntVar(&c.VFUsageCritical, VFUsageCriticalFlagLong, defaultVFUsageCritical, vfUsageCriticalFlagHelp)
+
+	case pluginType.HostGPUAllocation:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.IntVar(&c.GPUAllocationWarning, GPUAllocationWarningFlagLong, defaultGPUAllocationWarning, gpuAllocationWarningFlagHelp)
+		flag.IntVar(&c.GPUAllocationCritical, GPUAllocationCriticalFlagLong, defaultGPUAllocationCritical, gpuAllocationCriticalFlagHelp)
+
+		flag.IntVar(&c.GPUUtilizationWarning, GPUUtilizationWarningFlagLong, defaultGPUUtilizationWarning, gpuUtilizationWarningFlagHelp)
+		flag.IntVar(&c.GPUUtilizationCritical, GPUUtilizationCriticalFlagLong, defaultGPUUtilizationCritical, gpuUtilizationCriticalFlagHelp)
+
+	case pluginType.ClusterDASIsolation:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, clusterDASIsolationClusterNamesFlagHelp)
+
+		flag.Var(&c.ExpectedIsolationAddresses, IsolationAddressFlagLong, isolationAddressFlagHelp)
+		flag.Var(&c.allowedIsolationResponses, IsolationResponseFlagLong, isolationResponseFlagHelp)
+		flag.IntVar(&c.MinIsolationAddresses, MinIsolationAddressesFlagLong, defaultMinIsolationAddresses, minIsolationAddressesFlagHelp)
+		flag.BoolVar(&c.AllowDefaultIsolationAddress, AllowDefaultIsolationAddressFlagLong, defaultAllowDefaultIsolationAddress, allowDefaultIsolationAddressFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.DatastoresOvercommit:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, datastoreOvercommitClusterNameFlagHelp)
+
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, datastoreOvercommitIncludeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, datastoreOvercommitIgnoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.IntVar(&c.DatastoreOvercommitWarning, DatastoreOvercommitWarningFlagLong, defaultDatastoreOvercommitWarning, datastoreOvercommitWarningFlagHelp)
+		flag.IntVar(&c.DatastoreOvercommitCritical, DatastoreOvercommitCriticalFlagLong, defaultDatastoreOvercommitCritical, datastoreOvercommitCriticalFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.StretchedClusterSiteBalance:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, stretchedClusterNamesFlagHelp)
+
+		flag.Var(&c.SiteHostGroups, SiteHostGroupFlagLong, siteHostGroupFlagHelp)
+
+		flag.IntVar(&c.SiteImbalanceWarning, SiteImbalanceWarningFlagLong, defaultSiteImbalanceWarning, siteImbalanceWarningFlagHelp)
+		flag.IntVar(&c.SiteImbalanceCritical, SiteImbalanceCriticalFlagLong, defaultSiteImbalanceCritical, siteImbalanceCriticalFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.DatastoresAccessibility:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, datastoreAccessibilityClusterNameFlagHelp)
+
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, datastoreAccessibilityIncludeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, datastoreAccessibilityIgnoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.Var(&c.AllowedMaintenanceDatastores, AllowMaintenanceDatastoreFlagLong, allowMaintenanceDatastoreFlagHelp)
+		flag.Var(&c.AllowedReadOnlyDatastores, AllowReadOnlyDatastoreFlagLong, allowReadOnlyDatastoreFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.VirtualMachinePendingHWUpgrade:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.IntVar(&c.PendingHardwareUpgradeAgeWarning, PendingHWUpgradeAgeWarningFlagLong, defaultPendingHWUpgradeAgeWarning, pendingHWUpgradeAgeWarningFlagHelp)
+		flag.IntVar(&c.PendingHardwareUpgradeAgeCritical, PendingHWUpgradeAgeCriticalFlagLong, defaultPendingHWUpgradeAgeCritical, pendingHWUpgradeAgeCriticalFlagHelp)
+
+		flag.IntVar(&c.VirtualHardwareMinimumVersion, MinimumVersionFlagLong, defaultVirtualHardwareMinimumVersion, pendingHWUpgradeMinimumVersionFlagHelp)
+		flag.IntVar(&c.VirtualHardwareMinimumVersion, MinimumVersionFlagShort, defaultVirtualHardwareMinimumVersion, pendingHWUpgradeMinimumVersionFlagHelp+shorthandFlagSuffix)
+
+	case pluginType.VirtualMachineRestoreDetection:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.StringVar(&c.VMRestoreStateFile, VMRestoreStateFileFlagLong, defaultVMRestoreStateFile, vmRestoreStateFileFlagHelp)
+
+	case pluginType.HostRebootPending:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+
+		flag.StringVar(&c.HostSystemName, HostNameFlagLong, defaultHostSystemName, hostServicesHostNameFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+		flag.IntVar(&c.RebootPendingAgeWarning, RebootPendingAgeWarningFlagLong, defaultRebootPendingAgeWarning, rebootPendingAgeWarningFlagHelp)
+		flag.IntVar(&c.RebootPendingAgeCritical, RebootPendingAgeCriticalFlagLong, defaultRebootPendingAgeCritical, rebootPendingAgeCriticalFlagHelp)
+
+		flag.StringVar(&c.RebootPendingStateFile, RebootPendingStateFileFlagLong, defaultRebootPendingStateFile, rebootPendingStateFileFlagHelp)
+
+	case pluginType.VirtualMachineTimeSyncPolicy:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.StringVar(&c.VMTimeSyncPeriodic, VMTimeSyncPeriodicFlagLong, defaultVMTimeSyncPeriodic, vmTimeSyncPeriodicFlagHelp)
+		flag.StringVar(&c.VMTimeSyncStartup, VMTimeSyncStartupFlagLong, defaultVMTimeSyncStartup, vmTimeSyncStartupFlagHelp)
+
+	case pluginType.DatastoresVMDensity:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, datastoreVMDensityClusterNameFlagHelp)
+
+		flag.Var(&c.IncludedDatastores, IncludeDatastoreFlagLong, datastoreVMDensityIncludeDatastoreFlagHelp)
+		flag.Var(&c.IgnoredDatastores, IgnoreDatastoreFlagLong, datastoreVMDensityIgnoreDatastoreFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.IntVar(&c.DatastoreVMsWarning, DatastoreVMsWarningFlagLong, defaultDatastoreVMsWarning, datastoreVMsWarningFlagHelp)
+		flag.IntVar(&c.DatastoreVMsCritical, DatastoreVMsCriticalFlagLong, defaultDatastoreVMsCritical, datastoreVMsCriticalFlagHelp)
+		flag.IntVar(&c.DatastoreVMDKsWarning, DatastoreVMDKsWarningFlagLong, defaultDatastoreVMDKsWarning, datastoreVMDKsWarningFlagHelp)
+		flag.IntVar(&c.DatastoreVMDKsCritical, DatastoreVMDKsCriticalFlagLong, defaultDatastoreVMDKsCritical, datastoreVMDKsCriticalFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listDatastoresFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.SnapshotRemovalStalls:
+
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, snapshotRemovalStallIgnoreVMFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.IntVar(&c.SnapshotRemovalStallWarning, SnapshotRemovalStallWarningFlagLong, defaultSnapshotRemovalStallWarning, snapshotRemovalStallWarningFlagHelp)
+		flag.IntVar(&c.SnapshotRemovalStallCritical, SnapshotRemovalStallCriticalFlagLong, defaultSnapshotRemovalStallCritical, snapshotRemovalStallCriticalFlagHelp)
+
+	case pluginType.VMMemoryAllocation:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+		flag.IntVar(&c.VMMemoryAllocatedWarning, VMMemoryAllocatedWarningFlagLong, defaultVMMemoryAllocatedWarning, vmMemoryAllocatedWarningFlagHelp)
+		flag.IntVar(&c.VMMemoryAllocatedCritical, VMMemoryAllocatedCriticalFlagLong, defaultVMMemoryAllocatedCritical, vmMemoryAllocatedCriticalFlagHelp)
+
+		vmMemoryMaxAllowedFlag := newSizeFlag(&c.VMMemoryMaxAllowed, defaultVMMemoryMaxAllowed, units.GB, "GiB")
+		flag.Var(vmMemoryMaxAllowedFlag, VMMemoryMaxAllowedFlagLong, vmMemoryMaxAllowedFlagHelp)
+
+		flag.BoolVar(&c.VMMemoryPhysicalCapacity, VMMemoryPhysicalCapacityFlagLong, defaultVMMemoryPhysicalCapacity, vmMemoryPhysicalCapacityFlagHelp)
+		flag.Float64Var(&c.VMMemoryRatioWarning, VMMemoryRatioWarningFlagLong, defaultVMMemoryRatioWarning, vmMemoryRatioWarningFlagHelp)
+		flag.Float64Var(&c.VMMemoryRatioCritical, VMMemoryRatioCriticalFlagLong, defaultVMMemoryRatioCritical, vmMemoryRatioCriticalFlagHelp)
+
+	case pluginType.VirtualMachineDiskUUIDEnabled:
+
+		flag.Var(&c.IncludedFolders, IncludeFolderIDFlagLong, vmIncludedFoldersFlagHelp)
+		flag.Var(&c.ExcludedFolders, ExcludeFolderIDFlagLong, vmExcludedFoldersFlagHelp)
+
+		flag.Var(&c.IncludedResourcePools, IncludeResourcePoolFlagLong, vmIncludedResourcePoolsFlagHelp)
+		flag.Var(&c.ExcludedResourcePools, ExcludeResourcePoolFlagLong, vmExcludedResourcePoolsFlagHelp)
+		flag.Var(&c.IncludedDatacenters, IncludeDatacenterFlagLong, vmIncludedDatacentersFlagHelp)
+		flag.Var(&c.ExcludedDatacenters, ExcludeDatacenterFlagLong, vmExcludedDatacentersFlagHelp)
+		flag.Var(&c.IncludedClusters, IncludeClusterFlagLong, vmIncludedClustersFlagHelp)
+		flag.Var(&c.ExcludedClusters, ExcludeClusterFlagLong, vmExcludedClustersFlagHelp)
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, vmIncludedHostsFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, vmExcludedHostsFlagHelp)
+		flag.Var(&c.IncludedTags, IncludeTagFlagLong, vmIncludedTagsFlagHelp)
+		flag.Var(&c.ExcludedTags, ExcludeTagFlagLong, vmExcludedTagsFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCA, MaintenanceCAFlagLong, defaultVMMaintenanceCA, vmMaintenanceCAFlagHelp)
+		flag.StringVar(&c.VMMaintenanceCADateFormat, MaintenanceCAFormatFlagLong, defaultVMMaintenanceCADateFormat, vmMaintenanceCADateFormatFlagHelp)
+		flag.Var(&c.IgnoredVMs, IgnoreVMFlagLong, ignoreVMsFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+		flag.BoolVar(&c.PoweredOff, IncludePoweredOffVMsFlagLong, defaultPoweredOff, poweredOffFlagHelp)
+
+	case pluginType.HostConnectionState:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.StringVar(&c.ClusterName, ClusterNameFlagLong, defaultClusterName, hostConnectionClusterNameFlagHelp)
+
+		flag.Var(&c.IncludedHosts, IncludeHostFlagLong, hostConnectionIncludeHostFlagHelp)
+		flag.Var(&c.ExcludedHosts, ExcludeHostFlagLong, hostConnectionExcludeHostFlagHelp)
+		flag.StringVar(&c.PatternMatch, PatternMatchFlagLong, defaultPatternMatch, patternMatchFlagHelp)
+
+		flag.Var(&c.AllowedMaintenanceHosts, AllowMaintenanceHostFlagLong, allowMaintenanceHostFlagHelp)
+		flag.Var(&c.AllowedStandbyHosts, AllowStandbyHostFlagLong, allowStandbyHostFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listHostsFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
+
+	case pluginType.ClusterHAHostFailures:
+
+		flag.StringVar(&c.DatacenterName, DatacenterNameFlagLong, defaultDatacenterName, datacenterNameFlagHelp)
+		flag.Var(&c.ClusterNames, ClusterNameFlagLong, haHostFailuresClusterNamesFlagHelp)
+
+		flag.IntVar(&c.HAHostFailuresLookback, HAHostFailuresLookbackFlagLong, defaultHAHostFailuresLookback, haHostFailuresLookbackFlagHelp)
+		flag.IntVar(&c.HAHostFailuresWarning, HAHostFailuresWarningFlagLong, defaultHAHostFailuresWarning, haHostFailuresWarningFlagHelp)
+		flag.IntVar(&c.HAHostFailuresCritical, HAHostFailuresCriticalFlagLong, defaultHAHostFailuresCritical, haHostFailuresCriticalFlagHelp)
+
+		flag.BoolVar(&c.ListObjects, ListObjectsFlagLong, defaultListObjects, listClustersFlagHelp)
+		flag.StringVar(&c.ListObjectsPattern, ListObjectsPatternFlagLong, defaultListObjectsPattern, listObjectsPatternFlagHelp)
 
 	}
 
@@ -379,7 +1648,18 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 
 	flag.StringVar(&c.Domain, DomainFlagLong, defaultUserDomain, userDomainFlagHelp)
 
+	flag.StringVar(&c.UsernameFile, UsernameFileFlagLong, defaultUsernameFile, usernameFileFlagHelp)
+	flag.StringVar(&c.PasswordFile, PasswordFileFlagLong, defaultPasswordFile, passwordFileFlagHelp)
+
+	flag.StringVar(&c.ConfigFile, ConfigFileFlagLong, defaultConfigFile, configFileFlagHelp)
+
 	flag.BoolVar(&c.TrustCert, TrustCertFlagLong, defaultTrustCert, trustCertFlagHelp)
+	flag.StringVar(&c.CAFile, CAFileFlagLong, defaultCAFile, caFileFlagHelp)
+	flag.StringVar(&c.TLSMinVersion, TLSMinVersionFlagLong, defaultTLSMinVersion, tlsMinVersionFlagHelp)
+	flag.StringVar(&c.Proxy, ProxyFlagLong, defaultProxy, proxyFlagHelp)
+	flag.StringVar(&c.NoProxy, NoProxyFlagLong, defaultNoProxy, noProxyFlagHelp)
+
+	flag.BoolVar(&c.InsecureSkipHostnameVerify, InsecureSkipHostnameVerifyFlagLong, defaultInsecureSkipHostnameVerify, insecureSkipHostnameVerifyFlagHelp)
 
 	flag.BoolVar(&c.EmitBranding, BrandingFlag, defaultBranding, brandingFlagHelp)
 
@@ -398,6 +1678,28 @@ func (c *Config) handleFlagsConfig(pluginType PluginType) {
 	flag.BoolVar(&c.ShowVersion, VersionFlagLong, defaultDisplayVersionAndExit, versionFlagHelp)
 	flag.BoolVar(&c.ShowVersion, VersionFlagShort, defaultDisplayVersionAndExit, versionFlagHelp+shorthandFlagSuffix)
 
+	flag.IntVar(&c.propertyRetrievalWarning, PropertyRetrievalWarningFlagLong, defaultPropertyRetrievalWarning, propertyRetrievalWarningFlagHelp)
+
+	// State mapping, summary templates, session caching, evaluation
+	// manifests, name redaction and tracing only apply to plugins which
+	// report a Nagios state.
+	if !pluginType.NagiosGenConfig && !pluginType.VMwareExporter {
+		flag.Var(&c.StateMap, StateMapFlagLong, stateMapFlagHelp)
+		flag.StringVar(&c.SummaryTemplate, SummaryTemplateFlagLong, defaultSummaryTemplate, summaryTemplateFlagHelp)
+
+		flag.BoolVar(&c.SessionCache, SessionCacheFlagLong, defaultSessionCache, sessionCacheFlagHelp)
+		flag.StringVar(&c.sessionCacheDir, SessionCacheDirFlagLong, defaultSessionCacheDir, sessionCacheDirFlagHelp)
+
+		flag.StringVar(&c.EvaluationManifest, EvaluationManifestFlagLong, defaultEvaluationManifest, evaluationManifestFlagHelp)
+
+		flag.BoolVar(&c.RedactNames, RedactNamesFlagLong, defaultRedactNames, redactNamesFlagHelp)
+		flag.StringVar(&c.redactNamesLookupFile, RedactNamesLookupFileFlagLong, defaultRedactNamesLookupFile, redactNamesLookupFileFlagHelp)
+
+		flag.Var(&c.OmitReportSections, OmitReportSectionFlagLong, omitReportSectionFlagHelp)
+
+		flag.StringVar(&c.OTLPEndpoint, OTLPEndpointFlagLong, defaultOTLPEndpoint, otlpEndpointFlagHelp)
+	}
+
 	// Allow our function to override the default Help output
 	flag.Usage = Usage
 
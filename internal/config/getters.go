@@ -9,6 +9,9 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -19,6 +22,110 @@ func (c Config) Timeout() time.Duration {
 	return time.Duration(c.timeout) * time.Second
 }
 
+// RemediationTimeout converts the user-specified remediation timeout value in
+// seconds to a time duration value used to limit how long triggered disk
+// consolidation tasks are waited on.
+func (c Config) RemediationTimeout() time.Duration {
+	return time.Duration(c.remediationTimeout) * time.Second
+}
+
+// SessionCacheDir returns the user-specified directory used to store cached
+// vSphere sessions or a check-vmware/sessions directory within the user
+// cache directory if not specified. An empty string is returned if the user
+// cache directory cannot be determined; the default session cache directory
+// of the vSphere client library is used in that case.
+func (c Config) SessionCacheDir() string {
+	if c.sessionCacheDir != "" {
+		return c.sessionCacheDir
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "check-vmware", "sessions")
+}
+
+// RedactNamesLookupFile returns the user-specified JSON file used to record
+// the mapping of redacted name tokens to names. If not specified, a
+// check-vmware/redacted-names.json file within the user cache directory is
+// used. An empty string is returned if the user cache directory cannot be
+// determined.
+func (c Config) RedactNamesLookupFile() string {
+	if c.redactNamesLookupFile != "" {
+		return c.redactNamesLookupFile
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "check-vmware", "redacted-names.json")
+}
+
+// PropertyRetrievalWarning converts the user-specified property retrieval
+// warning threshold in milliseconds to a time duration value. A zero value
+// indicates that the threshold is disabled.
+func (c Config) PropertyRetrievalWarning() time.Duration {
+	return time.Duration(c.propertyRetrievalWarning) * time.Millisecond
+}
+
+// ExporterCollectionInterval converts the user-specified metrics exporter
+// collection interval in seconds to a time duration value.
+func (c Config) ExporterCollectionInterval() time.Duration {
+	return time.Duration(c.exporterCollectionInterval) * time.Second
+}
+
+// AlarmTriggeredSinceTime returns the point in time used to limit evaluation to
+// triggered alarms triggered at or after that time. Duration values are
+// resolved relative to the current time. The zero value is returned if not
+// specified (or invalid; config validation rejects invalid values).
+func (c Config) AlarmTriggeredSinceTime() time.Time {
+	t, _ := parseAlarmTimeBoundary(c.AlarmTriggeredSince, time.Now())
+	return t
+}
+
+// AlarmTriggeredBeforeTime returns the point in time used to limit
+// evaluation to triggered alarms triggered at or before that time. Duration
+// values are resolved relative to the current time. The zero value is
+// returned if not specified (or invalid; config validation rejects invalid
+// values).
+func (c Config) AlarmTriggeredBeforeTime() time.Time {
+	t, _ := parseAlarmTimeBoundary(c.AlarmTriggeredBefore, time.Now())
+	return t
+}
+
+// AlarmsEvalMaintenanceMode indicates whether the maintenance mode state of
+// entities associated with triggered alarms is needed in order to apply the
+// user-specified maintenance mode action.
+func (c Config) AlarmsEvalMaintenanceMode() bool {
+	return c.AlarmMaintenanceModeAction != "" &&
+		!strings.EqualFold(c.AlarmMaintenanceModeAction, AlarmMaintenanceModeActionEvaluate)
+}
+
+// AlarmsExcludeMaintenanceMode indicates whether triggered alarms associated
+// with entities in maintenance mode are excluded from evaluation.
+func (c Config) AlarmsExcludeMaintenanceMode() bool {
+	return strings.EqualFold(c.AlarmMaintenanceModeAction, AlarmMaintenanceModeActionExclude)
+}
+
+// AlarmsMaintenanceModeMaxState returns the most severe Nagios state that
+// triggered alarms associated with entities in maintenance mode may
+// contribute to the overall plugin state. An empty value is returned if the
+// state of these triggered alarms is not limited.
+func (c Config) AlarmsMaintenanceModeMaxState() string {
+	switch {
+	case strings.EqualFold(c.AlarmMaintenanceModeAction, AlarmStatusWarning):
+		return AlarmStatusWarning
+	case strings.EqualFold(c.AlarmMaintenanceModeAction, AlarmStatusOk):
+		return AlarmStatusOk
+	default:
+		return ""
+	}
+}
+
 // add getters to indicate whether user has specified a shared custom
 // attribute or whether separate host and datastore attributes are used.
 
@@ -152,6 +259,190 @@ func (c Config) UserAgent() string {
 
 }
 
+// RequiredHostServices returns the user-specified list of ESXi host services
+// required to be running. No services are required by default.
+func (c Config) RequiredHostServices() []string {
+	return c.requiredHostServices
+}
+
+// DisallowedHostServices returns the user-specified list of ESXi host
+// services required to be disabled. If not specified by the user, the ESXi
+// Shell and SSH services are returned.
+func (c Config) DisallowedHostServices() []string {
+	if len(c.disallowedHostServices) == 0 {
+		return strings.Split(defaultDisallowedHostServices, ",")
+	}
+
+	return c.disallowedHostServices
+}
+
+// RequiredVCenterServices returns the user-specified list of vCenter
+// appliance services required to be running. If not specified by the user,
+// the vCenter Server (vpxd), vSphere Client (vsphere-ui), storage policy
+// (sps) and content library services are returned.
+func (c Config) RequiredVCenterServices() []string {
+	if len(c.requiredVCenterServices) == 0 {
+		return strings.Split(defaultRequiredVCenterServices, ",")
+	}
+
+	return c.requiredVCenterServices
+}
+
+// IgnoredVCenterServices returns the user-specified list of vCenter
+// appliance services ignored when evaluating services configured for
+// automatic startup and service health.
+func (c Config) IgnoredVCenterServices() []string {
+	return c.ignoredVCenterServices
+}
+
+// AlarmActionObjectTypes returns the user-specified list of inventory object
+// types evaluated for disabled alarm actions. If not specified by the user,
+// all supported object types (datacenter, cluster, host) are returned.
+func (c Config) AlarmActionObjectTypes() []string {
+	if len(c.alarmActionObjectTypes) == 0 {
+		return supportedAlarmActionObjectTypes()
+	}
+
+	types := make([]string, 0, len(c.alarmActionObjectTypes))
+	for _, objType := range c.alarmActionObjectTypes {
+		types = append(types, strings.ToLower(objType))
+	}
+
+	return types
+}
+
+// AllowedIsolationResponses returns the user-specified list of vSphere HA
+// host isolation responses permitted for evaluated clusters. If not specified
+// by the user, the powerOff and shutdown isolation responses are returned.
+func (c Config) AllowedIsolationResponses() []string {
+	if len(c.allowedIsolationResponses) == 0 {
+		return strings.Split(defaultAllowedIsolationResponses, ",")
+	}
+
+	return c.allowedIsolationResponses
+}
+
+// VMPowerCycleUptimeUseBootTime indicates whether VM power cycle uptime is
+// calculated from the VM boot time instead of QuickStats uptime.
+func (c Config) VMPowerCycleUptimeUseBootTime() bool {
+	return strings.EqualFold(c.VMPowerCycleUptimeSource, PowerUptimeSourceBootTime)
+}
+
+// supportedPowerUptimeSources returns the keywords which may be used to
+// specify the source of VM power cycle uptime values.
+func supportedPowerUptimeSources() []string {
+	return []string{
+		PowerUptimeSourceQuickStats,
+		PowerUptimeSourceBootTime,
+	}
+}
+
+// supportedReportSections returns the keywords which may be used to specify
+// optional report sections omitted from the long service output.
+func supportedReportSections() []string {
+	return []string{
+		ReportSectionRecentlyStartedVMs,
+		ReportSectionTopConsumers,
+		ReportSectionTopOKVMs,
+	}
+}
+
+// VMTimeSyncPeriodicPolicy returns the required state of VMware Tools
+// periodic time synchronization. A nil value indicates that any state is
+// accepted.
+func (c Config) VMTimeSyncPeriodicPolicy() *bool {
+	return timeSyncPolicySetting(c.VMTimeSyncPeriodic)
+}
+
+// VMTimeSyncStartupPolicy returns the required state of VMware Tools
+// one-off (e.g., startup) time synchronization. A nil value indicates that
+// any state is accepted.
+func (c Config) VMTimeSyncStartupPolicy() *bool {
+	return timeSyncPolicySetting(c.VMTimeSyncStartup)
+}
+
+// timeSyncPolicySetting converts the given time synchronization policy
+// keyword to the required state. A nil value is returned for the "any"
+// keyword.
+func timeSyncPolicySetting(keyword string) *bool {
+	var setting bool
+
+	switch strings.ToLower(keyword) {
+	case TimeSyncPolicyEnabled:
+		setting = true
+	case TimeSyncPolicyDisabled:
+		setting = false
+	default:
+		return nil
+	}
+
+	return &setting
+}
+
+// supportedTimeSyncPolicies returns the keywords which may be used to
+// specify the required state of VMware Tools time synchronization settings.
+func supportedTimeSyncPolicies() []string {
+	return []string{
+		TimeSyncPolicyEnabled,
+		TimeSyncPolicyDisabled,
+		TimeSyncPolicyAny,
+	}
+}
+
+// supportedIsolationResponses returns the vSphere HA host isolation
+// responses which may be specified.
+func supportedIsolationResponses() []string {
+	return []string{"none", "powerOff", "shutdown"}
+}
+
+// FolderVMCountThresholds returns the user-specified folder VM count
+// thresholds. Thresholds not specified by the user are returned as nil.
+func (c Config) FolderVMCountThresholds() FolderVMCountThresholds {
+
+	optionalValue := func(oif optionalIntFlag) *int {
+		if !oif.isSet {
+			return nil
+		}
+		v := oif.value
+
+		return &v
+	}
+
+	return FolderVMCountThresholds{
+		MaxWarning:  optionalValue(c.folderVMCountMaxWarning),
+		MaxCritical: optionalValue(c.folderVMCountMaxCritical),
+		MinWarning:  optionalValue(c.folderVMCountMinWarning),
+		MinCritical: optionalValue(c.folderVMCountMinCritical),
+	}
+
+}
+
+// EmergencyThreshold returns the user-specified emergency threshold and
+// whether the threshold was specified.
+func (c Config) EmergencyThreshold() (int, bool) {
+	return c.emergencyThreshold.value, c.emergencyThreshold.isSet
+}
+
+// MultipleDatastores indicates whether multiple datastores are evaluated
+// instead of a single named datastore. This is the case if all datastores
+// were requested, a cluster name was specified or a list of datastores to
+// include or exclude was specified.
+func (c Config) MultipleDatastores() bool {
+	return c.AllDatastores ||
+		c.ClusterName != "" ||
+		len(c.IncludedDatastores) > 0 ||
+		len(c.IgnoredDatastores) > 0
+}
+
+// MultipleHosts indicates whether multiple hosts are evaluated instead of a
+// single named host. This is the case if a cluster name was specified or a
+// list of hosts to include or exclude was specified.
+func (c Config) MultipleHosts() bool {
+	return c.ClusterName != "" ||
+		len(c.IncludedHosts) > 0 ||
+		len(c.ExcludedHosts) > 0
+}
+
 // DatastorePerfThresholds returns Datastore Performance Summary latency
 // thresholds for the default percentile. If defined by the user, those values
 // are returned. If the user did not specify individual threshold values,
@@ -0,0 +1,64 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import "testing"
+
+func TestAlarmsMaintenanceModeGetters(t *testing.T) {
+	tests := map[string]struct {
+		action       string
+		wantEval     bool
+		wantExclude  bool
+		wantMaxState string
+	}{
+		"not set": {
+			action: "",
+		},
+		"evaluate": {
+			action: AlarmMaintenanceModeActionEvaluate,
+		},
+		"exclude": {
+			action:      AlarmMaintenanceModeActionExclude,
+			wantEval:    true,
+			wantExclude: true,
+		},
+		"exclude mixed case": {
+			action:      "Exclude",
+			wantEval:    true,
+			wantExclude: true,
+		},
+		"warning": {
+			action:       AlarmStatusWarning,
+			wantEval:     true,
+			wantMaxState: AlarmStatusWarning,
+		},
+		"ok upper case": {
+			action:       "OK",
+			wantEval:     true,
+			wantMaxState: AlarmStatusOk,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := Config{AlarmMaintenanceModeAction: tt.action}
+
+			if got := c.AlarmsEvalMaintenanceMode(); got != tt.wantEval {
+				t.Errorf("want AlarmsEvalMaintenanceMode %t; got %t", tt.wantEval, got)
+			}
+
+			if got := c.AlarmsExcludeMaintenanceMode(); got != tt.wantExclude {
+				t.Errorf("want AlarmsExcludeMaintenanceMode %t; got %t", tt.wantExclude, got)
+			}
+
+			if got := c.AlarmsMaintenanceModeMaxState(); got != tt.wantMaxState {
+				t.Errorf("want AlarmsMaintenanceModeMaxState %q; got %q", tt.wantMaxState, got)
+			}
+		})
+	}
+}
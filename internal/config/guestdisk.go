@@ -0,0 +1,127 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// GuestDiskMountThresholds represents the usage thresholds applied to guest
+// filesystems with a mount point (or drive letter) matching a specific
+// pattern.
+type GuestDiskMountThresholds struct {
+	// Pattern is the case-insensitive glob pattern used to match guest
+	// filesystem mount points.
+	Pattern string
+
+	// Warning is the filesystem usage percentage when a WARNING threshold is
+	// reached.
+	Warning int
+
+	// Critical is the filesystem usage percentage when a CRITICAL threshold
+	// is reached.
+	Critical int
+}
+
+// multiValueGuestDiskThresholdFlag is a custom type that satisfies the
+// flag.Value interface. This type is used to accept per-filesystem usage
+// thresholds in MOUNT:WARNING:CRITICAL format. The flag may be repeated to
+// specify thresholds for multiple filesystems; the first matching pattern
+// applies.
+type multiValueGuestDiskThresholdFlag []GuestDiskMountThresholds
+
+// String returns a comma separated string consisting of all slice elements.
+func (mvgd *multiValueGuestDiskThresholdFlag) String() string {
+
+	// From the `flag` package docs:
+	// "The flag package may call the String method with a zero-valued
+	// receiver, such as a nil pointer."
+	if mvgd == nil {
+		return ""
+	}
+
+	items := make([]string, 0, len(*mvgd))
+	for _, t := range *mvgd {
+		items = append(items, fmt.Sprintf("%s:%d:%d", t.Pattern, t.Warning, t.Critical))
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present. The mount point pattern is split from the threshold values
+// using the last two colons so that Windows drive letters (e.g., C:\) may be
+// used.
+func (mvgd *multiValueGuestDiskThresholdFlag) Set(value string) error {
+
+	value = strings.TrimSpace(value)
+	value = strings.ReplaceAll(value, "'", "")
+	value = strings.ReplaceAll(value, "\"", "")
+
+	critIdx := strings.LastIndex(value, ":")
+	if critIdx < 0 {
+		return fmt.Errorf(
+			"error processing flag; string %q not in MOUNT:WARNING:CRITICAL format",
+			value,
+		)
+	}
+
+	warnIdx := strings.LastIndex(value[:critIdx], ":")
+	if warnIdx < 1 {
+		return fmt.Errorf(
+			"error processing flag; string %q not in MOUNT:WARNING:CRITICAL format",
+			value,
+		)
+	}
+
+	// Backslashes (e.g., Windows drive letters) are normalized to forward
+	// slashes when matching mount points.
+	pattern := value[:warnIdx]
+	if _, err := path.Match(strings.ReplaceAll(pattern, `\`, "/"), ""); err != nil {
+		return fmt.Errorf(
+			"error processing flag; invalid mount pattern %q: %v",
+			pattern,
+			err,
+		)
+	}
+
+	warning, warnConvErr := strconv.Atoi(strings.TrimSpace(value[warnIdx+1 : critIdx]))
+	if warnConvErr != nil {
+		return fmt.Errorf(
+			"error processing flag; failed to convert WARNING threshold in %q: %v",
+			value,
+			warnConvErr,
+		)
+	}
+
+	critical, critConvErr := strconv.Atoi(strings.TrimSpace(value[critIdx+1:]))
+	if critConvErr != nil {
+		return fmt.Errorf(
+			"error processing flag; failed to convert CRITICAL threshold in %q: %v",
+			value,
+			critConvErr,
+		)
+	}
+
+	*mvgd = append(*mvgd, GuestDiskMountThresholds{
+		Pattern:  pattern,
+		Warning:  warning,
+		Critical: critical,
+	})
+
+	return nil
+}
+
+// reset discards all filesystem thresholds recorded by previous calls to
+// Set.
+func (mvgd *multiValueGuestDiskThresholdFlag) reset() {
+	*mvgd = nil
+}
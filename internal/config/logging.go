@@ -83,8 +83,14 @@ func (c *Config) setupLogging(pluginType PluginType) error {
 		Str("username", c.Username).
 		Str("user_domain", c.Domain).
 		Bool("trust_cert", c.TrustCert).
+		Str("ca_file", c.CAFile).
+		Str("tls_min_version", c.TLSMinVersion).
+		Bool("insecure_skip_hostname_verify", c.InsecureSkipHostnameVerify).
+		Bool("proxy", c.Proxy != "").
+		Bool("redact_names", c.RedactNames).
 		Str("server", c.Server).
 		Int("port", c.Port).
+		Str("threshold_profile", c.ActiveThresholdProfile).
 		Logger()
 
 	return setLoggingLevel(c.LoggingLevel)
@@ -0,0 +1,422 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrThresholdProfileInvalid indicates that a threshold profile definition
+// is invalid.
+var ErrThresholdProfileInvalid = errors.New("invalid threshold profile")
+
+// thresholdProfileTimeLayout is the layout used for threshold profile
+// window start and end times.
+const thresholdProfileTimeLayout string = "15:04"
+
+// configFileProfileSectionPrefix is the prefix for configuration file
+// section names defining a threshold profile (e.g.,
+// [profile.nightly-backups]).
+const configFileProfileSectionPrefix string = "profile."
+
+// Threshold profile section keys describing the profile time window and
+// scope. All other keys in a profile section are threshold flag names.
+const (
+	thresholdProfileTimezoneKey string = "timezone"
+	thresholdProfileDaysKey     string = "days"
+	thresholdProfileStartKey    string = "start"
+	thresholdProfileEndKey      string = "end"
+	thresholdProfilePluginsKey  string = "plugins"
+)
+
+// ThresholdProfiles is a collection of threshold profiles loaded from the
+// profile sections of a configuration file. Profiles are evaluated in the
+// order listed; the first profile with an active time window applicable to
+// the current plugin is used.
+type ThresholdProfiles []ThresholdProfile
+
+// ThresholdProfile is a named set of threshold flag values applied during a
+// specific time-of-day and day-of-week window (e.g., relaxed datastore
+// latency thresholds during a nightly backup window).
+type ThresholdProfile struct {
+
+	// Name is the name of the profile.
+	Name string
+
+	// Timezone is the IANA time zone name (e.g., America/Chicago) used to
+	// evaluate this profile's time window. If not specified, the local
+	// system time zone is used.
+	Timezone string
+
+	// Days is the list of days of the week (e.g., Mon, Tuesday) when the
+	// profile time window starts. If not specified, all days are used.
+	Days []string
+
+	// Start is the time of day in HH:MM (24 hour) format when the profile
+	// time window starts.
+	Start string
+
+	// End is the time of day in HH:MM (24 hour) format when the profile
+	// time window ends. If End is earlier than Start the window spans
+	// midnight.
+	End string
+
+	// Plugins is the list of plugin types (e.g., datastore-performance) the
+	// profile applies to. If not specified, the profile applies to all
+	// plugins.
+	Plugins []string
+
+	// Thresholds is the collection of threshold flag settings applied, in
+	// file order, while the profile is active.
+	Thresholds []configFileSetting
+}
+
+// repeatableFlag is implemented by flag values which accumulate entries
+// when the flag is specified multiple times. Accumulated entries are
+// discarded before threshold profile values are applied so that profile
+// values replace (instead of extend) command-line values.
+type repeatableFlag interface {
+	reset()
+}
+
+// isThresholdFlag indicates whether the flag with the given long name
+// specifies a threshold and may therefore be set by a threshold profile.
+func isThresholdFlag(name string) bool {
+	switch name {
+	case EmergencyThresholdFlagLong,
+		DatastoreLatencyPercentileSetFlagLong,
+		GuestDiskMountThresholdFlagLong:
+		return true
+
+	case PropertyRetrievalWarningFlagLong:
+		return false
+	}
+
+	return strings.HasSuffix(name, "-warning") ||
+		strings.HasSuffix(name, "-critical") ||
+		strings.HasSuffix(name, "-max-allowed")
+}
+
+// splitList splits the given comma separated value into a list of trimmed,
+// non-empty items.
+func splitList(value string) []string {
+	items := strings.Split(value, ",")
+
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// parseWeekday converts the given day name (full or abbreviated,
+// case-insensitive) to a time.Weekday value.
+func parseWeekday(day string) (time.Weekday, error) {
+	day = strings.ToLower(strings.TrimSpace(day))
+
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if day == name || (len(day) >= 3 && strings.HasPrefix(name, day)) {
+			return wd, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unknown day of week %q", day)
+}
+
+// parseClock converts the given HH:MM time of day to the number of minutes
+// since midnight.
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse(thresholdProfileTimeLayout, strings.TrimSpace(clock))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", clock, err)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// location returns the time zone used to evaluate the profile time window.
+func (tp ThresholdProfile) location() (*time.Location, error) {
+	if tp.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(tp.Timezone)
+}
+
+// appliesTo indicates whether the profile applies to the specified plugin
+// type.
+func (tp ThresholdProfile) appliesTo(pluginLabel string) bool {
+	if len(tp.Plugins) == 0 {
+		return true
+	}
+
+	for _, plugin := range tp.Plugins {
+		if strings.EqualFold(strings.TrimSpace(plugin), pluginLabel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate asserts that the profile definition is usable.
+func (tp ThresholdProfile) validate() error {
+	if strings.TrimSpace(tp.Name) == "" {
+		return fmt.Errorf("%w: missing profile name", ErrThresholdProfileInvalid)
+	}
+
+	if _, err := tp.location(); err != nil {
+		return fmt.Errorf("%w: profile %q: %v", ErrThresholdProfileInvalid, tp.Name, err)
+	}
+
+	for _, day := range tp.Days {
+		if _, err := parseWeekday(day); err != nil {
+			return fmt.Errorf("%w: profile %q: %v", ErrThresholdProfileInvalid, tp.Name, err)
+		}
+	}
+
+	start, err := parseClock(tp.Start)
+	if err != nil {
+		return fmt.Errorf("%w: profile %q: %v", ErrThresholdProfileInvalid, tp.Name, err)
+	}
+
+	end, err := parseClock(tp.End)
+	if err != nil {
+		return fmt.Errorf("%w: profile %q: %v", ErrThresholdProfileInvalid, tp.Name, err)
+	}
+
+	if start == end {
+		return fmt.Errorf(
+			"%w: profile %q: start and end times are equal",
+			ErrThresholdProfileInvalid,
+			tp.Name,
+		)
+	}
+
+	if len(tp.Thresholds) == 0 {
+		return fmt.Errorf(
+			"%w: profile %q: no thresholds specified",
+			ErrThresholdProfileInvalid,
+			tp.Name,
+		)
+	}
+
+	for _, setting := range tp.Thresholds {
+		if !isThresholdFlag(setting.Name) {
+			return fmt.Errorf(
+				"%w: profile %q: %s: flag %q is not a threshold flag",
+				ErrThresholdProfileInvalid,
+				tp.Name,
+				setting.position(),
+				setting.Name,
+			)
+		}
+	}
+
+	return nil
+}
+
+// IsActive indicates whether the profile time window includes the given
+// time. For windows spanning midnight, the day of week is evaluated against
+// the day the window started.
+func (tp ThresholdProfile) IsActive(now time.Time) (bool, error) {
+	loc, err := tp.location()
+	if err != nil {
+		return false, err
+	}
+
+	start, err := parseClock(tp.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := parseClock(tp.End)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(loc)
+	current := now.Hour()*60 + now.Minute()
+	windowDay := now.Weekday()
+
+	switch {
+	case start < end:
+		if current < start || current >= end {
+			return false, nil
+		}
+
+	default:
+		switch {
+		case current >= start:
+		case current < end:
+			// Early morning portion of a window which started the day
+			// before.
+			windowDay = now.AddDate(0, 0, -1).Weekday()
+		default:
+			return false, nil
+		}
+	}
+
+	if len(tp.Days) == 0 {
+		return true, nil
+	}
+
+	for _, day := range tp.Days {
+		wd, err := parseWeekday(day)
+		if err != nil {
+			return false, err
+		}
+
+		if wd == windowDay {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ThresholdProfiles returns the validated threshold profiles defined by the
+// profile sections of the configuration file, in file order.
+func (cf ConfigFile) ThresholdProfiles() (ThresholdProfiles, error) {
+	var profiles ThresholdProfiles
+
+	for _, section := range cf.sectionOrder {
+		name, ok := strings.CutPrefix(section, configFileProfileSectionPrefix)
+		if !ok {
+			continue
+		}
+
+		profile := ThresholdProfile{Name: name}
+		for _, setting := range cf.Sections[section] {
+			switch setting.Name {
+			case thresholdProfileTimezoneKey:
+				profile.Timezone = setting.Value
+			case thresholdProfileDaysKey:
+				profile.Days = append(profile.Days, splitList(setting.Value)...)
+			case thresholdProfileStartKey:
+				profile.Start = setting.Value
+			case thresholdProfileEndKey:
+				profile.End = setting.Value
+			case thresholdProfilePluginsKey:
+				profile.Plugins = append(profile.Plugins, splitList(setting.Value)...)
+			default:
+				profile.Thresholds = append(profile.Thresholds, setting)
+			}
+		}
+
+		if err := profile.validate(); err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// Active returns the first profile applicable to the specified plugin type
+// with a time window including the given time. A nil value is returned if no
+// profile is active.
+func (tps ThresholdProfiles) Active(now time.Time, pluginLabel string) (*ThresholdProfile, error) {
+	for i := range tps {
+		if !tps[i].appliesTo(pluginLabel) {
+			continue
+		}
+
+		active, err := tps[i].IsActive(now)
+		if err != nil {
+			return nil, err
+		}
+
+		if active {
+			return &tps[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// applyThresholdProfile applies the threshold flag values from the active
+// profile (if any) defined in the user-specified configuration file for the
+// given plugin type. Profile values override values specified via the
+// command-line or elsewhere in the configuration file; values for
+// repeatable flags replace all previously specified values. Settings for
+// flags not supported by the plugin are ignored for profiles which do not
+// list specific plugins and rejected otherwise. This is
+// performed prior to validation so that profile values are subject to the
+// same validation as command-line values.
+func (c *Config) applyThresholdProfile(pluginType PluginType, now time.Time) error {
+	if c.configFile == nil {
+		return nil
+	}
+
+	profiles, err := c.configFile.ThresholdProfiles()
+	if err != nil {
+		return err
+	}
+
+	profile, err := profiles.Active(now, pluginTypeLabel(pluginType))
+	if err != nil {
+		return err
+	}
+
+	if profile == nil {
+		return nil
+	}
+
+	reset := make(map[string]bool)
+	for _, setting := range profile.Thresholds {
+		f := flag.Lookup(setting.Name)
+
+		switch {
+		case f == nil && len(profile.Plugins) == 0:
+			// Profiles not scoped to specific plugins commonly set
+			// thresholds only some plugins support. As with default
+			// section settings, these are ignored by other plugins.
+			continue
+
+		case f == nil:
+			return fmt.Errorf(
+				"%w: profile %q: %s: flag %q not supported by this plugin",
+				ErrThresholdProfileInvalid,
+				profile.Name,
+				setting.position(),
+				setting.Name,
+			)
+		}
+
+		if rf, ok := f.Value.(repeatableFlag); ok && !reset[f.Name] {
+			rf.reset()
+			reset[f.Name] = true
+		}
+
+		if err := flag.Set(setting.Name, setting.Value); err != nil {
+			return fmt.Errorf(
+				"%w: profile %q: %s: failed to set flag %q to %q: %v",
+				ErrThresholdProfileInvalid,
+				profile.Name,
+				setting.position(),
+				setting.Name,
+				setting.Value,
+				err,
+			)
+		}
+	}
+
+	c.ActiveThresholdProfile = profile.Name
+
+	return nil
+}
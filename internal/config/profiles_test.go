@@ -0,0 +1,221 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	// Embed the time zone database so that profile time zones resolve
+	// regardless of the test host configuration.
+	_ "time/tzdata"
+)
+
+func TestThresholdProfileIsActive(t *testing.T) {
+	// 2021-06-04 is a Friday.
+	friday := func(hour, min int) time.Time {
+		return time.Date(2021, time.June, 4, hour, min, 0, 0, time.UTC)
+	}
+
+	nightly := ThresholdProfile{Timezone: "UTC", Start: "22:00", End: "04:00"}
+	fridayNights := ThresholdProfile{Timezone: "UTC", Days: []string{"Fri"}, Start: "22:00", End: "04:00"}
+	chicagoHours := ThresholdProfile{Timezone: "America/Chicago", Start: "09:00", End: "17:00"}
+	newYorkMonday := ThresholdProfile{Timezone: "America/New_York", Days: []string{"monday"}, Start: "20:00", End: "23:00"}
+
+	tests := map[string]struct {
+		profile ThresholdProfile
+		now     time.Time
+		want    bool
+	}{
+		"same day window, inside": {
+			profile: ThresholdProfile{Timezone: "UTC", Start: "09:00", End: "17:00"},
+			now:     friday(12, 0),
+			want:    true,
+		},
+		"same day window, end is exclusive": {
+			profile: ThresholdProfile{Timezone: "UTC", Start: "09:00", End: "17:00"},
+			now:     friday(17, 0),
+			want:    false,
+		},
+		"spans midnight, before midnight": {
+			profile: nightly,
+			now:     friday(23, 30),
+			want:    true,
+		},
+		"spans midnight, after midnight": {
+			profile: nightly,
+			now:     friday(3, 59),
+			want:    true,
+		},
+		"spans midnight, outside": {
+			profile: nightly,
+			now:     friday(12, 0),
+			want:    false,
+		},
+		"day matches window start before midnight": {
+			profile: fridayNights,
+			now:     friday(22, 0),
+			want:    true,
+		},
+		"day matches window started the day before": {
+			profile: fridayNights,
+			now:     friday(2, 0).AddDate(0, 0, 1),
+			want:    true,
+		},
+		"day does not match window started the day before": {
+			profile: fridayNights,
+			now:     friday(2, 0),
+			want:    false,
+		},
+		"time zone shifts time of day into window": {
+			profile: chicagoHours,
+			now:     friday(14, 30),
+			want:    true,
+		},
+		"time zone shifts time of day out of window": {
+			profile: chicagoHours,
+			now:     friday(13, 30),
+			want:    false,
+		},
+		"time zone shifts day of week": {
+			// Tuesday 01:00 UTC is Monday 21:00 in New York.
+			profile: newYorkMonday,
+			now:     time.Date(2021, time.June, 8, 1, 0, 0, 0, time.UTC),
+			want:    true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tt.profile.IsActive(tt.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("want active %t; got %t", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("unknown time zone", func(t *testing.T) {
+		profile := ThresholdProfile{Timezone: "Mars/Olympus_Mons", Start: "09:00", End: "17:00"}
+		if _, err := profile.IsActive(friday(12, 0)); err == nil {
+			t.Error("want error for unknown time zone; got nil")
+		}
+	})
+}
+
+func TestThresholdProfilesActive(t *testing.T) {
+	now := time.Date(2021, time.June, 4, 23, 0, 0, 0, time.UTC)
+
+	profiles := ThresholdProfiles{
+		{Name: "daytime", Timezone: "UTC", Start: "09:00", End: "17:00"},
+		{Name: "datastores", Timezone: "UTC", Start: "22:00", End: "04:00", Plugins: []string{"datastore-performance"}},
+		{Name: "nightly", Timezone: "UTC", Start: "22:00", End: "04:00"},
+		{Name: "late-nightly", Timezone: "UTC", Start: "22:30", End: "04:00"},
+	}
+
+	tests := map[string]struct {
+		plugin string
+		now    time.Time
+		want   string
+	}{
+		"first applicable profile wins":      {plugin: "datastore-performance", now: now, want: "datastores"},
+		"profile for other plugin skipped":   {plugin: "snapshots-age", now: now, want: "nightly"},
+		"plugin names are case-insensitive":  {plugin: "Datastore-Performance", now: now, want: "datastores"},
+		"no active profile":                  {plugin: "snapshots-age", now: now.Add(-5 * time.Hour), want: ""},
+		"active profile during another time": {plugin: "snapshots-age", now: now.Add(-10 * time.Hour), want: "daytime"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			profile, err := profiles.Active(tt.now, tt.plugin)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got string
+			if profile != nil {
+				got = profile.Name
+			}
+
+			if got != tt.want {
+				t.Errorf("want active profile %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestApplyThresholdProfileUnsupportedFlags(t *testing.T) {
+	supported := flag.Int("profile-test-warning", 1, "threshold registered for this test")
+
+	// Friday evening, within each profile window.
+	now := time.Date(2021, time.June, 4, 23, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		content     string
+		wantErr     bool
+		wantProfile string
+		wantValue   int
+	}{
+		"unscoped profile ignores unsupported flags": {
+			content: `[profile.nightly]
+timezone = UTC
+start = 22:00
+end = 04:00
+ds-read-latency-warning = 40
+profile-test-warning = 5
+`,
+			wantProfile: "nightly",
+			wantValue:   5,
+		},
+		"scoped profile rejects unsupported flags": {
+			content: `[profile.nightly]
+timezone = UTC
+start = 22:00
+end = 04:00
+plugins = snapshots-age
+ds-read-latency-warning = 40
+`,
+			wantErr:   true,
+			wantValue: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			*supported = 1
+
+			cf := ConfigFile{Sections: make(map[string][]configFileSetting)}
+			if err := cf.parseINI("test.ini", []byte(tt.content)); err != nil {
+				t.Fatalf("failed to parse configuration: %v", err)
+			}
+
+			c := Config{configFile: &cf}
+			err := c.applyThresholdProfile(PluginType{SnapshotsAge: true}, now)
+
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrThresholdProfileInvalid):
+				t.Errorf("want %v; got %v", ErrThresholdProfileInvalid, err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if c.ActiveThresholdProfile != tt.wantProfile {
+				t.Errorf("want active profile %q; got %q", tt.wantProfile, c.ActiveThresholdProfile)
+			}
+
+			if *supported != tt.wantValue {
+				t.Errorf("want threshold %d; got %d", tt.wantValue, *supported)
+			}
+		})
+	}
+}
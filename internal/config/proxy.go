@@ -0,0 +1,58 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// supportedProxySchemes returns the proxy URL schemes which may be specified
+// for connections to the vSphere server.
+func supportedProxySchemes() []string {
+	return []string{
+		"http",
+		"https",
+		"socks5",
+		"socks5h",
+	}
+}
+
+// validateProxy asserts that the user-specified proxy URL is valid. A proxy
+// URL without a scheme is assumed to be an HTTP proxy.
+func validateProxy(proxy string) error {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid %q flag value: %w", ProxyFlagLong, err)
+	}
+
+	if !textutils.InList(u.Scheme, supportedProxySchemes(), true) {
+		return fmt.Errorf(
+			"%q is not a supported scheme for the %q flag; supported values: %v",
+			u.Scheme,
+			ProxyFlagLong,
+			supportedProxySchemes(),
+		)
+	}
+
+	if u.Hostname() == "" {
+		return fmt.Errorf(
+			"invalid %q flag value: proxy host not specified",
+			ProxyFlagLong,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/units"
+)
+
+// ErrInvalidSizeValue indicates that a size value could not be parsed.
+var ErrInvalidSizeValue = errors.New("invalid size value")
+
+// sizeUnits maps the (lowercase) unit suffixes supported by ParseSize to
+// the number of bytes each represents. Decimal (SI) suffixes use powers of
+// 1000 while binary (IEC) suffixes use powers of 1024. Single letter
+// suffixes are treated as binary units to match the convention used by
+// vSphere tooling.
+var sizeUnits = map[string]float64{
+	"b": 1,
+
+	"kb": 1e3,
+	"mb": 1e6,
+	"gb": 1e9,
+	"tb": 1e12,
+	"pb": 1e15,
+
+	"k":   units.KB,
+	"m":   units.MB,
+	"g":   units.GB,
+	"t":   units.TB,
+	"p":   units.PB,
+	"kib": units.KB,
+	"mib": units.MB,
+	"gib": units.GB,
+	"tib": units.TB,
+	"pib": units.PB,
+}
+
+// ParseSize parses a human-friendly size value (e.g., 750GB, 2.5TiB) and
+// returns the equivalent number of bytes. Unit suffixes are case
+// insensitive and may be separated from the number by whitespace. Values
+// without a unit suffix are interpreted using the given default unit (e.g.,
+// units.GB) in order to retain compatibility with plain whole number flag
+// values.
+func ParseSize(value string, defaultUnit int64) (int64, error) {
+	value = strings.TrimSpace(value)
+
+	numEnd := strings.IndexFunc(value, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numEnd < 0 {
+		numEnd = len(value)
+	}
+
+	numStr := value[:numEnd]
+	suffix := strings.ToLower(strings.TrimSpace(value[numEnd:]))
+
+	if numStr == "" {
+		return 0, fmt.Errorf(
+			"%w: %q: missing numeric value",
+			ErrInvalidSizeValue,
+			value,
+		)
+	}
+
+	num, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"%w: %q: %v",
+			ErrInvalidSizeValue,
+			value,
+			err,
+		)
+	}
+
+	multiplier := float64(defaultUnit)
+	if suffix != "" {
+		var ok bool
+		multiplier, ok = sizeUnits[suffix]
+		if !ok {
+			return 0, fmt.Errorf(
+				"%w: %q: unsupported unit %q",
+				ErrInvalidSizeValue,
+				value,
+				suffix,
+			)
+		}
+	}
+
+	bytes := num * multiplier
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf(
+			"%w: %q: value too large",
+			ErrInvalidSizeValue,
+			value,
+		)
+	}
+
+	return int64(bytes), nil
+}
+
+// sizeFlag is a custom type that satisfies the flag.Value interface in
+// order to accept human-friendly size values (e.g., 750GiB, 2.5TiB) for
+// flags historically accepting a whole number in a fixed unit. The parsed
+// value is stored as a whole number of the fixed unit; values which are not
+// a whole multiple of the unit are rejected instead of being silently
+// rounded.
+type sizeFlag struct {
+
+	// value is the bound configuration field
+	value *int
+
+	// unit is the number of bytes in the unit used by the bound field
+	unit int64
+
+	// unitLabel is used when displaying the bound field value
+	unitLabel string
+}
+
+// newSizeFlag returns a sizeFlag bound to the given configuration field
+// using the specified unit. The field is set to the given default value.
+// The same sizeFlag should be registered for both the long and short
+// variant of a flag.
+func newSizeFlag(p *int, defaultVal int, unit int64, unitLabel string) *sizeFlag {
+	*p = defaultVal
+
+	return &sizeFlag{
+		value:     p,
+		unit:      unit,
+		unitLabel: unitLabel,
+	}
+}
+
+// String satisfies the flag.Value interface method set requirements.
+func (sf *sizeFlag) String() string {
+
+	// The String() method is called by the flag.isZeroValue function in order
+	// to determine whether the output string represents the zero value for a
+	// flag. This occurs even if the flag is not specified by the user.
+
+	if sf == nil || sf.value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*sf.value) + sf.unitLabel
+}
+
+// Set satisfies the flag.Value interface method set requirements.
+func (sf *sizeFlag) Set(value string) error {
+
+	value = strings.ReplaceAll(value, "'", "")
+	value = strings.ReplaceAll(value, "\"", "")
+
+	bytes, err := ParseSize(value, sf.unit)
+	if err != nil {
+		return fmt.Errorf("error processing flag; %w", err)
+	}
+
+	converted := bytes / sf.unit
+	if bytes > 0 && converted == 0 {
+		return fmt.Errorf(
+			"error processing flag; %w: %q: less than 1%s",
+			ErrInvalidSizeValue,
+			value,
+			sf.unitLabel,
+		)
+	}
+
+	if bytes%sf.unit != 0 {
+		return fmt.Errorf(
+			"error processing flag; %w: %q: not a whole number of %s (nearest values %d%s and %d%s)",
+			ErrInvalidSizeValue,
+			value,
+			sf.unitLabel,
+			converted,
+			sf.unitLabel,
+			converted+1,
+			sf.unitLabel,
+		)
+	}
+
+	if converted > math.MaxInt32 {
+		return fmt.Errorf(
+			"error processing flag; %w: %q: value too large",
+			ErrInvalidSizeValue,
+			value,
+		)
+	}
+
+	*sf.value = int(converted)
+
+	return nil
+}
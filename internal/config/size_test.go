@@ -0,0 +1,167 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vmware/govmomi/units"
+)
+
+func TestParseSize(t *testing.T) {
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// value is the user-specified size value
+		value string
+
+		// defaultUnit is the unit used for values without a suffix
+		defaultUnit int64
+
+		// wantErr indicates whether parsing is expected to fail
+		wantErr bool
+
+		// want is the expected number of bytes
+		want int64
+	}{
+		{testName: "bytes", value: "512b", defaultUnit: units.GB, want: 512},
+
+		{testName: "decimal kilobytes", value: "2kb", defaultUnit: units.GB, want: 2e3},
+		{testName: "decimal megabytes", value: "2mb", defaultUnit: units.GB, want: 2e6},
+		{testName: "decimal gigabytes", value: "2gb", defaultUnit: units.GB, want: 2e9},
+		{testName: "decimal terabytes", value: "2tb", defaultUnit: units.GB, want: 2e12},
+		{testName: "decimal petabytes", value: "2pb", defaultUnit: units.GB, want: 2e15},
+
+		{testName: "binary kibibytes", value: "2kib", defaultUnit: units.GB, want: 2 * units.KB},
+		{testName: "binary mebibytes", value: "2mib", defaultUnit: units.GB, want: 2 * units.MB},
+		{testName: "binary gibibytes", value: "2gib", defaultUnit: units.GB, want: 2 * units.GB},
+		{testName: "binary tebibytes", value: "2tib", defaultUnit: units.GB, want: 2 * units.TB},
+		{testName: "binary pebibytes", value: "2pib", defaultUnit: units.GB, want: 2 * units.PB},
+
+		{testName: "single letter k", value: "2k", defaultUnit: units.GB, want: 2 * units.KB},
+		{testName: "single letter m", value: "2m", defaultUnit: units.GB, want: 2 * units.MB},
+		{testName: "single letter g", value: "2g", defaultUnit: units.GB, want: 2 * units.GB},
+		{testName: "single letter t", value: "2t", defaultUnit: units.GB, want: 2 * units.TB},
+		{testName: "single letter p", value: "2p", defaultUnit: units.GB, want: 2 * units.PB},
+
+		{testName: "uppercase decimal suffix", value: "750GB", defaultUnit: units.MB, want: 750e9},
+		{testName: "uppercase binary suffix", value: "3TIB", defaultUnit: units.MB, want: 3 * units.TB},
+		{testName: "mixed case binary suffix", value: "2.5TiB", defaultUnit: units.MB, want: 5 * units.TB / 2},
+		{testName: "mixed case decimal suffix", value: "1Gb", defaultUnit: units.MB, want: 1e9},
+		{testName: "uppercase single letter", value: "4G", defaultUnit: units.MB, want: 4 * units.GB},
+
+		{testName: "whitespace before suffix", value: "10 GiB", defaultUnit: units.MB, want: 10 * units.GB},
+		{testName: "surrounding whitespace", value: " 10GiB ", defaultUnit: units.MB, want: 10 * units.GB},
+		{testName: "fractional value", value: "0.5k", defaultUnit: units.GB, want: 512},
+		{testName: "no suffix uses default unit", value: "10", defaultUnit: units.GB, want: 10 * units.GB},
+		{testName: "no suffix fractional uses default unit", value: "1.5", defaultUnit: units.GB, want: 3 * units.GB / 2},
+		{testName: "zero", value: "0", defaultUnit: units.GB, want: 0},
+
+		{testName: "empty value", value: "", defaultUnit: units.GB, wantErr: true},
+		{testName: "suffix only", value: "GB", defaultUnit: units.GB, wantErr: true},
+		{testName: "negative value", value: "-5GB", defaultUnit: units.GB, wantErr: true},
+		{testName: "unsupported suffix", value: "5XB", defaultUnit: units.GB, wantErr: true},
+		{testName: "suffix with trailing text", value: "5GB free", defaultUnit: units.GB, wantErr: true},
+		{testName: "multiple decimal points", value: "1.2.3GB", defaultUnit: units.GB, wantErr: true},
+		{testName: "exponent notation", value: "1e3GB", defaultUnit: units.GB, wantErr: true},
+		{testName: "value too large", value: "9000000pib", defaultUnit: units.GB, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			got, err := ParseSize(tt.value, tt.defaultUnit)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("want error; got nil (value %d)", got)
+			case tt.wantErr && !errors.Is(err, ErrInvalidSizeValue):
+				t.Fatalf("want %v error; got %v", ErrInvalidSizeValue, err)
+			case tt.wantErr:
+				t.Logf("Got expected error: %v", err)
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSizeFlagSet(t *testing.T) {
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// value is the user-specified flag value
+		value string
+
+		// unit is the unit used by the bound field
+		unit int64
+
+		// wantErr indicates whether setting the flag is expected to fail
+		wantErr bool
+
+		// want is the expected bound field value
+		want int
+	}{
+		{testName: "plain whole number", value: "500", unit: units.MB, want: 500},
+		{testName: "larger unit", value: "2GiB", unit: units.MB, want: 2048},
+		{testName: "mixed case larger unit", value: "2gIb", unit: units.MB, want: 2048},
+		{testName: "decimal unit not whole number of field unit", value: "1GB", unit: units.MB, wantErr: true},
+		{testName: "decimal gigabytes as gibibytes", value: "750GB", unit: units.GB, wantErr: true},
+		{testName: "decimal gigabytes whole number of gibibytes", value: "1073.741824GB", unit: units.GB, want: 1000},
+		{testName: "decimal megabytes whole number of mebibytes", value: "2.097152MB", unit: units.MB, want: 2},
+		{testName: "fractional gibibytes", value: "1.5GiB", unit: units.GB, wantErr: true},
+		{testName: "fractional value without suffix", value: "1.5", unit: units.GB, wantErr: true},
+		{testName: "fractional gibibytes as mebibytes", value: "1.5GiB", unit: units.MB, want: 1536},
+		{testName: "fractional tebibytes whole number of gibibytes", value: "0.5TiB", unit: units.GB, want: 512},
+		{testName: "quoted value", value: `"1.5 TiB"`, unit: units.GB, want: 1536},
+		{testName: "single quoted value", value: "'10G'", unit: units.GB, want: 10},
+		{testName: "zero", value: "0GB", unit: units.GB, want: 0},
+		{testName: "less than one unit", value: "512MiB", unit: units.GB, wantErr: true},
+		{testName: "too large for field", value: "4096PiB", unit: units.MB, wantErr: true},
+		{testName: "invalid value", value: "lots", unit: units.GB, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			var field int
+			sf := newSizeFlag(&field, 7, tt.unit, "")
+
+			err := sf.Set(tt.value)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("want error; got nil (value %d)", field)
+			case tt.wantErr && !errors.Is(err, ErrInvalidSizeValue):
+				t.Fatalf("want %v error; got %v", ErrInvalidSizeValue, err)
+			case tt.wantErr:
+				if field != 7 {
+					t.Errorf("want field left at default value 7; got %d", field)
+				}
+				t.Logf("Got expected error: %v", err)
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if field != tt.want {
+				t.Errorf("want %d; got %d", tt.want, field)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// supportedStateMapSources returns the Nagios state labels which may be
+// mapped to another state.
+func supportedStateMapSources() []string {
+	return []string{
+		StateWARNINGLabel,
+		StateCRITICALLabel,
+		StateUNKNOWNLabel,
+	}
+}
+
+// supportedStateMapTargets returns the Nagios state labels which a mapped
+// state may be changed to.
+func supportedStateMapTargets() []string {
+	return []string{
+		StateOKLabel,
+		StateWARNINGLabel,
+		StateCRITICALLabel,
+		StateUNKNOWNLabel,
+	}
+}
+
+// stateExitCodes maps the Nagios state labels supported by state mappings
+// to their exit codes.
+var stateExitCodes = map[string]int{
+	StateOKLabel:       0,
+	StateWARNINGLabel:  1,
+	StateCRITICALLabel: 2,
+	StateUNKNOWNLabel:  3,
+}
+
+// StateMapping is a user-specified Nagios state mapping applicable to a
+// specific plugin state.
+type StateMapping struct {
+	FromLabel    string
+	ToLabel      string
+	FromExitCode int
+	ToExitCode   int
+}
+
+// StateMapFlag is a custom type that satisfies the flag.Value interface.
+// This type is used to accept Nagios state mappings in FROM=TO format (e.g.,
+// warning=ok). Keys and values are stored as upper-case Nagios state labels.
+// The flag may be repeated; later mappings for the same state override
+// earlier ones.
+type StateMapFlag map[string]string
+
+// String returns a comma separated string consisting of all mappings in
+// FROM=TO format.
+func (smf *StateMapFlag) String() string {
+
+	// From the `flag` package docs:
+	// "The flag package may call the String method with a zero-valued
+	// receiver, such as a nil pointer."
+	if smf == nil || *smf == nil {
+		return ""
+	}
+
+	items := make([]string, 0, len(*smf))
+	for from, to := range *smf {
+		items = append(items, fmt.Sprintf("%s=%s", from, to))
+	}
+	sort.Strings(items)
+
+	return strings.Join(items, ", ")
+}
+
+// Set is called once by the flag package, in command line order, for each
+// flag present.
+func (smf *StateMapFlag) Set(value string) error {
+
+	if *smf == nil {
+		*smf = make(StateMapFlag)
+	}
+
+	items := strings.Split(value, ",")
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		item = strings.ReplaceAll(item, "'", "")
+		item = strings.ReplaceAll(item, "\"", "")
+
+		if item == "" {
+			continue
+		}
+
+		from, to, found := strings.Cut(item, "=")
+		if !found {
+			return fmt.Errorf(
+				"error processing flag; string %q not in FROM=TO format",
+				item,
+			)
+		}
+
+		from = strings.ToUpper(strings.TrimSpace(from))
+		to = strings.ToUpper(strings.TrimSpace(to))
+
+		if !textutils.InList(from, supportedStateMapSources(), true) {
+			return fmt.Errorf(
+				"error processing flag; unsupported state %q to map from; supported values: %v",
+				from,
+				supportedStateMapSources(),
+			)
+		}
+
+		if !textutils.InList(to, supportedStateMapTargets(), true) {
+			return fmt.Errorf(
+				"error processing flag; unsupported state %q to map to; supported values: %v",
+				to,
+				supportedStateMapTargets(),
+			)
+		}
+
+		(*smf)[from] = to
+	}
+
+	return nil
+}
+
+// Mapping returns the user-specified state mapping applicable to the given
+// plugin exit code. False is returned if the state is not mapped to a
+// different state.
+func (smf StateMapFlag) Mapping(exitCode int) (StateMapping, bool) {
+	for from, fromExitCode := range stateExitCodes {
+		if fromExitCode != exitCode {
+			continue
+		}
+
+		to, ok := smf[from]
+		if !ok || to == from {
+			return StateMapping{}, false
+		}
+
+		return StateMapping{
+			FromLabel:    from,
+			ToLabel:      to,
+			FromExitCode: fromExitCode,
+			ToExitCode:   stateExitCodes[to],
+		}, true
+	}
+
+	return StateMapping{}, false
+}
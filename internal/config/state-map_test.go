@@ -0,0 +1,134 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStateMapFlagSet(t *testing.T) {
+	tests := map[string]struct {
+		values  []string
+		want    StateMapFlag
+		wantErr bool
+	}{
+		"single mapping": {
+			values: []string{"warning=ok"},
+			want:   StateMapFlag{"WARNING": "OK"},
+		},
+		"comma separated mappings": {
+			values: []string{"warning=ok, critical = warning"},
+			want:   StateMapFlag{"WARNING": "OK", "CRITICAL": "WARNING"},
+		},
+		"quoted mapping": {
+			values: []string{`"unknown=critical"`},
+			want:   StateMapFlag{"UNKNOWN": "CRITICAL"},
+		},
+		"repeated flag overrides earlier mapping": {
+			values: []string{"warning=ok", "warning=critical"},
+			want:   StateMapFlag{"WARNING": "CRITICAL"},
+		},
+		"empty items ignored": {
+			values: []string{"warning=ok,,"},
+			want:   StateMapFlag{"WARNING": "OK"},
+		},
+		"missing separator":       {values: []string{"warning"}, wantErr: true},
+		"OK cannot be remapped":   {values: []string{"ok=critical"}, wantErr: true},
+		"unknown source state":    {values: []string{"pending=ok"}, wantErr: true},
+		"unknown target state":    {values: []string{"warning=dependent"}, wantErr: true},
+		"invalid item in list":    {values: []string{"warning=ok,critical"}, wantErr: true},
+		"empty target state":      {values: []string{"warning="}, wantErr: true},
+		"target state misspelled": {values: []string{"critical=warn"}, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var smf StateMapFlag
+
+			var err error
+			for _, v := range tt.values {
+				if err = smf.Set(v); err != nil {
+					break
+				}
+			}
+
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatalf("want error; got nil (mappings %v)", smf)
+			case tt.wantErr:
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if d := cmp.Diff(tt.want, smf); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestStateMapFlagString(t *testing.T) {
+	var smf StateMapFlag
+	if got := smf.String(); got != "" {
+		t.Errorf("want empty string for unset flag; got %q", got)
+	}
+
+	smf = StateMapFlag{"WARNING": "OK", "CRITICAL": "WARNING"}
+	if got, want := smf.String(), "CRITICAL=WARNING, WARNING=OK"; got != want {
+		t.Errorf("want %q; got %q", want, got)
+	}
+}
+
+func TestStateMapFlagMapping(t *testing.T) {
+	smf := StateMapFlag{
+		"WARNING":  "OK",
+		"CRITICAL": "WARNING",
+		"UNKNOWN":  "UNKNOWN",
+	}
+
+	tests := map[string]struct {
+		exitCode int
+		want     StateMapping
+		wantOK   bool
+	}{
+		"OK not mapped": {exitCode: 0},
+		"WARNING mapped to OK": {
+			exitCode: 1,
+			want:     StateMapping{FromLabel: "WARNING", ToLabel: "OK", FromExitCode: 1, ToExitCode: 0},
+			wantOK:   true,
+		},
+		"CRITICAL mapped to WARNING": {
+			exitCode: 2,
+			want:     StateMapping{FromLabel: "CRITICAL", ToLabel: "WARNING", FromExitCode: 2, ToExitCode: 1},
+			wantOK:   true,
+		},
+		"mapping to same state ignored": {exitCode: 3},
+		"unsupported exit code":         {exitCode: 4},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := smf.Mapping(tt.exitCode)
+			if ok != tt.wantOK {
+				t.Fatalf("want ok %t; got %t", tt.wantOK, ok)
+			}
+
+			if got != tt.want {
+				t.Errorf("want %+v; got %+v", tt.want, got)
+			}
+		})
+	}
+
+	var unset StateMapFlag
+	if _, ok := unset.Mapping(1); ok {
+		t.Error("want no mapping for unset flag")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// ErrSummaryTemplateInvalid indicates that a one-line summary template
+// definition is invalid.
+var ErrSummaryTemplateInvalid = errors.New("invalid summary template")
+
+// validateSummaryTemplate asserts that the user-specified one-line summary
+// template (if any) is a valid Go template. The template is parsed using the
+// same options used when the template is applied so that invalid templates
+// are reported before any vSphere retrieval is performed.
+func (c Config) validateSummaryTemplate() error {
+	if c.SummaryTemplate == "" {
+		return nil
+	}
+
+	if strings.TrimSpace(c.SummaryTemplate) == "" {
+		return fmt.Errorf(
+			"%w: %q flag value is empty",
+			ErrSummaryTemplateInvalid,
+			SummaryTemplateFlagLong,
+		)
+	}
+
+	_, err := template.New("summary").Option("missingkey=error").Parse(c.SummaryTemplate)
+	if err != nil {
+		return fmt.Errorf(
+			"%w: %q flag value: %v",
+			ErrSummaryTemplateInvalid,
+			SummaryTemplateFlagLong,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSummaryTemplate(t *testing.T) {
+	tests := map[string]struct {
+		tmpl    string
+		wantErr bool
+	}{
+		"not set":             {tmpl: ""},
+		"plain text":          {tmpl: "vSphere check complete"},
+		"state and summary":   {tmpl: "{{ .State }}: {{ .Summary }}"},
+		"conditional":         {tmpl: `{{ if eq .ExitCode 0 }}all good{{ else }}{{ .Summary }}{{ end }}`},
+		"whitespace only":     {tmpl: "  \t", wantErr: true},
+		"unterminated action": {tmpl: "{{ .State ", wantErr: true},
+		"unknown function":    {tmpl: "{{ upper .State }}", wantErr: true},
+		"unmatched end":       {tmpl: "{{ .State }}{{ end }}", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := Config{SummaryTemplate: tt.tmpl}
+
+			err := c.validateSummaryTemplate()
+			switch {
+			case tt.wantErr && !errors.Is(err, ErrSummaryTemplateInvalid):
+				t.Errorf("want %v error; got %v", ErrSummaryTemplateInvalid, err)
+			case !tt.wantErr && err != nil:
+				t.Errorf("want nil error; got %v", err)
+			}
+		})
+	}
+}
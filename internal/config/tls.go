@@ -0,0 +1,19 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package config
+
+// supportedTLSMinVersions returns the TLS versions which may be specified as
+// the minimum TLS version used when connecting to the vSphere server.
+func supportedTLSMinVersions() []string {
+	return []string{
+		"1.0",
+		"1.1",
+		"1.2",
+		"1.3",
+	}
+}
@@ -9,8 +9,16 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
 )
 
 // validate verifies all Config struct fields have been provided acceptable
@@ -30,6 +38,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 	case pluginType.DiskConsolidation:
 
 		// only one of these options may be used
@@ -41,6 +85,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -50,6 +130,24 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if c.MaxRemediations < 1 {
+			return fmt.Errorf(
+				"invalid %q value %d specified; must be greater than zero",
+				MaxRemediationsFlagLong,
+				c.MaxRemediations,
+			)
+		}
+
+		if c.remediationTimeout < 0 || c.remediationTimeout >= c.timeout {
+			return fmt.Errorf(
+				"invalid %q value %d specified; must be zero or greater and less than the %q value %d",
+				RemediationTimeoutFlagLong,
+				c.remediationTimeout,
+				TimeoutFlagLong,
+				c.timeout,
+			)
+		}
+
 	case pluginType.InteractiveQuestion:
 
 		// only one of these options may be used
@@ -61,6 +159,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -70,6 +204,14 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if c.AutoAnswerApply && len(c.AutoAnswers) == 0 {
+			return fmt.Errorf(
+				"%q flag requires %q flag",
+				AutoAnswerApplyFlagLong,
+				AutoAnswerFlagLong,
+			)
+		}
+
 	case pluginType.SnapshotsAge:
 
 		// only one of these options may be used
@@ -81,6 +223,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -121,6 +299,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -150,6 +364,30 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// Aggregate evaluation is optional; both thresholds are required if
+		// either is specified.
+		if c.SnapshotsTotalCountWarning != 0 || c.SnapshotsTotalCountCritical != 0 {
+			if c.SnapshotsTotalCountWarning < 1 {
+				return fmt.Errorf(
+					"invalid snapshot total count WARNING threshold number: %d",
+					c.SnapshotsTotalCountWarning,
+				)
+			}
+
+			if c.SnapshotsTotalCountCritical < 1 {
+				return fmt.Errorf(
+					"invalid snapshot total count CRITICAL threshold number: %d",
+					c.SnapshotsTotalCountCritical,
+				)
+			}
+
+			if c.SnapshotsTotalCountCritical <= c.SnapshotsTotalCountWarning {
+				return fmt.Errorf(
+					"total count critical threshold set lower than or equal to total count warning threshold",
+				)
+			}
+		}
+
 	case pluginType.SnapshotsSize:
 
 		// only one of these options may be used
@@ -161,6 +399,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -201,6 +475,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -230,12 +540,53 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		supportedSources := supportedPowerUptimeSources()
+		if !textutils.InList(c.VMPowerCycleUptimeSource, supportedSources, true) {
+			return fmt.Errorf(
+				"invalid %q value %q specified; supported values: %v",
+				PowerUptimeSourceFlagLong,
+				c.VMPowerCycleUptimeSource,
+				supportedSources,
+			)
+		}
+
 	case pluginType.DatastoresSpace:
 
-		if c.DatastoreName == "" {
+		if c.DatastoreName == "" && !c.ListObjects && !c.MultipleDatastores() {
 			return fmt.Errorf("datastore name not provided")
 		}
 
+		if c.DatastoreName != defaultDatastoreName && c.MultipleDatastores() {
+			return fmt.Errorf(
+				"%q flag is incompatible with the %q, %q, %q and %q flags",
+				DatastoreNameFlagLong,
+				AllDatastoresFlagLong,
+				ClusterNameFlagLong,
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
 		if c.DatastoreSpaceUsageCritical < 1 {
 			return fmt.Errorf(
 				"invalid datastore usage (percentage as whole number) CRITICAL threshold number: %d",
@@ -256,9 +607,13 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if err := c.validateEmergencyThreshold(c.DatastoreSpaceUsageCritical); err != nil {
+			return err
+		}
+
 	case pluginType.DatastoresPerformance:
 
-		if c.DatastoreName == "" {
+		if c.DatastoreName == "" && !c.ListObjects {
 			return fmt.Errorf("datastore name not provided")
 		}
 
@@ -364,10 +719,40 @@ func (c Config) validate(pluginType PluginType) error {
 
 	case pluginType.HostSystemMemory:
 
-		if c.HostSystemName == "" {
+		if c.HostSystemName == "" && !c.ListObjects && !c.MultipleHosts() {
 			return fmt.Errorf("host name not provided")
 		}
 
+		if c.HostSystemName != defaultHostSystemName && c.MultipleHosts() {
+			return fmt.Errorf(
+				"%q flag is incompatible with the %q, %q and %q flags",
+				HostNameFlagLong,
+				ClusterNameFlagLong,
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.IncludedHosts) > 0 && len(c.ExcludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
 		if c.HostSystemMemoryUseCritical < 1 {
 			return fmt.Errorf(
 				"invalid host memory usage (percentage as whole number) CRITICAL threshold number: %d",
@@ -388,30 +773,68 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if err := c.validateEmergencyThreshold(c.HostSystemMemoryUseCritical); err != nil {
+			return err
+		}
+
 	case pluginType.HostSystemCPU:
 
-		if c.HostSystemName == "" {
+		if c.HostSystemName == "" && !c.ListObjects && !c.MultipleHosts() {
 			return fmt.Errorf("host name not provided")
 		}
 
-		if c.HostSystemCPUUseCritical < 1 {
+		if c.HostSystemName != defaultHostSystemName && c.MultipleHosts() {
 			return fmt.Errorf(
-				"invalid host CPU usage (percentage as whole number) CRITICAL threshold number: %d",
-				c.HostSystemCPUUseCritical,
+				"%q flag is incompatible with the %q, %q and %q flags",
+				HostNameFlagLong,
+				ClusterNameFlagLong,
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
 			)
 		}
 
-		if c.HostSystemCPUUseWarning < 1 {
+		// only one of these options may be used
+		if len(c.IncludedHosts) > 0 && len(c.ExcludedHosts) > 0 {
 			return fmt.Errorf(
-				"invalid host CPU usage (percentage as whole number) WARNING threshold number: %d",
-				c.HostSystemCPUUseWarning,
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
 			)
 		}
 
-		if c.HostSystemCPUUseCritical <= c.HostSystemCPUUseWarning {
-			return fmt.Errorf(
-				"critical threshold set lower than or equal to warning threshold",
-			)
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+		if c.HostSystemCPUUseCritical < 1 {
+			return fmt.Errorf(
+				"invalid host CPU usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.HostSystemCPUUseCritical,
+			)
+		}
+
+		if c.HostSystemCPUUseWarning < 1 {
+			return fmt.Errorf(
+				"invalid host CPU usage (percentage as whole number) WARNING threshold number: %d",
+				c.HostSystemCPUUseWarning,
+			)
+		}
+
+		if c.HostSystemCPUUseCritical <= c.HostSystemCPUUseWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if err := c.validateEmergencyThreshold(c.HostSystemCPUUseCritical); err != nil {
+			return err
 		}
 
 	case pluginType.ResourcePoolsMemory:
@@ -425,6 +848,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		if c.ResourcePoolsMemoryMaxAllowed < 1 {
 			return fmt.Errorf(
 				"invalid value specified for maximum memory usage allowed: %d",
@@ -452,6 +911,10 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if err := c.validateEmergencyThreshold(c.ResourcePoolsMemoryUseCritical); err != nil {
+			return err
+		}
+
 	case pluginType.VirtualCPUsAllocation:
 
 		// only one of these options may be used
@@ -463,6 +926,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -472,6 +971,40 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// The static maximum is not used when comparing allocated vCPUs
+		// against physical CPU cores per cluster.
+		if c.VCPUsClusterCapacity {
+			if c.VCPUsClusterRatioCritical <= 0 {
+				return fmt.Errorf(
+					"invalid vCPU:pCPU ratio CRITICAL threshold: %v",
+					c.VCPUsClusterRatioCritical,
+				)
+			}
+
+			if c.VCPUsClusterRatioWarning <= 0 {
+				return fmt.Errorf(
+					"invalid vCPU:pCPU ratio WARNING threshold: %v",
+					c.VCPUsClusterRatioWarning,
+				)
+			}
+
+			if c.VCPUsClusterRatioCritical <= c.VCPUsClusterRatioWarning {
+				return fmt.Errorf(
+					"vCPU:pCPU ratio critical threshold set lower than or equal to warning threshold",
+				)
+			}
+
+			if c.emergencyThreshold.isSet {
+				return fmt.Errorf(
+					"%q flag is incompatible with the %q flag",
+					EmergencyThresholdFlagLong,
+					VirtualCPUsClusterCapacityFlagLong,
+				)
+			}
+
+			break
+		}
+
 		if c.VCPUsMaxAllowed < 1 {
 			return fmt.Errorf(
 				"invalid value specified for maximum number of vCPUs allowed: %d",
@@ -499,6 +1032,10 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if err := c.validateEmergencyThreshold(c.VCPUsAllocatedCritical); err != nil {
+			return err
+		}
+
 	case pluginType.Host2Datastores2VMs:
 
 		// only one of these options may be used
@@ -510,6 +1047,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -607,6 +1180,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -742,6 +1351,15 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.IncludedAlarmNames) > 0 && len(c.ExcludedAlarmNames) > 0 {
 			return fmt.Errorf(
@@ -760,6 +1378,35 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		if err := validateAlarmTimeWindow(
+			c.AlarmTriggeredSince,
+			c.AlarmTriggeredBefore,
+			time.Now(),
+		); err != nil {
+			return err
+		}
+
+		supportedMMActions := supportedAlarmMaintenanceModeActions()
+		if !textutils.InList(c.AlarmMaintenanceModeAction, supportedMMActions, true) {
+			return fmt.Errorf(
+				"invalid %q value %q specified; supported values: %v",
+				AlarmMaintenanceModeFlagLong,
+				c.AlarmMaintenanceModeAction,
+				supportedMMActions,
+			)
+		}
+
+		if c.AcknowledgedAlarmsMaxState != "" {
+			supportedStates := supportedAcknowledgedAlarmsMaxStates()
+			if !textutils.InList(c.AcknowledgedAlarmsMaxState, supportedStates, true) {
+				return fmt.Errorf(
+					"invalid acknowledged alarms max state %q specified; supported values: %v",
+					c.AcknowledgedAlarmsMaxState,
+					supportedStates,
+				)
+			}
+		}
+
 		if len(c.IncludedAlarmStatuses) > 0 || len(c.ExcludedAlarmStatuses) > 0 {
 
 			// only one of these options may be used
@@ -822,6 +1469,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -891,6 +1574,42 @@ func (c Config) validate(pluginType PluginType) error {
 			)
 		}
 
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
 		// only one of these options may be used
 		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
 			return fmt.Errorf(
@@ -899,36 +1618,2905 @@ func (c Config) validate(pluginType PluginType) error {
 				ExcludeFolderIDFlagLong,
 			)
 		}
-	}
-
-	// shared validation checks
+	case pluginType.VSANHealth:
 
-	if c.Server == "" {
-		return fmt.Errorf("server FQDN or IP Address not provided")
-	}
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
 
-	if c.Username == "" {
-		return fmt.Errorf("username not provided")
-	}
+	case pluginType.HostServices:
 
-	if c.Password == "" {
-		return fmt.Errorf("password not provided")
-	}
+		for _, svc := range c.RequiredHostServices() {
+			if textutils.InList(svc, c.DisallowedHostServices(), true) {
+				return fmt.Errorf(
+					"service %q specified for both %q and %q flags",
+					svc,
+					RequireHostServiceFlagLong,
+					DisallowHostServiceFlagLong,
+				)
+			}
+		}
 
-	if c.Port < 0 {
-		return fmt.Errorf("invalid TCP port number %d", c.Port)
-	}
+	case pluginType.LicensingFeatureUsage:
 
-	if c.Timeout() < 1 {
-		return fmt.Errorf("invalid timeout value %d provided", c.Timeout())
-	}
+		if c.LicenseUsageCritical < 1 {
+			return fmt.Errorf(
+				"invalid license usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.LicenseUsageCritical,
+			)
+		}
 
-	requestedLoggingLevel := strings.ToLower(c.LoggingLevel)
-	if _, ok := loggingLevels[requestedLoggingLevel]; !ok {
-		return fmt.Errorf("invalid logging level %q", c.LoggingLevel)
-	}
+		if c.LicenseUsageWarning < 1 {
+			return fmt.Errorf(
+				"invalid license usage (percentage as whole number) WARNING threshold number: %d",
+				c.LicenseUsageWarning,
+			)
+		}
 
-	// Optimist
-	return nil
+		if c.LicenseUsageCritical <= c.LicenseUsageWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineCPUReady:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.VMCPUReadyCritical < 1 {
+			return fmt.Errorf(
+				"invalid CPU ready (percentage as whole number) CRITICAL threshold number: %d",
+				c.VMCPUReadyCritical,
+			)
+		}
+
+		if c.VMCPUReadyWarning < 1 {
+			return fmt.Errorf(
+				"invalid CPU ready (percentage as whole number) WARNING threshold number: %d",
+				c.VMCPUReadyWarning,
+			)
+		}
+
+		if c.VMCPUReadyCritical <= c.VMCPUReadyWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.VMCPUCoStopCritical < 1 {
+			return fmt.Errorf(
+				"invalid CPU co-stop (percentage as whole number) CRITICAL threshold number: %d",
+				c.VMCPUCoStopCritical,
+			)
+		}
+
+		if c.VMCPUCoStopWarning < 1 {
+			return fmt.Errorf(
+				"invalid CPU co-stop (percentage as whole number) WARNING threshold number: %d",
+				c.VMCPUCoStopWarning,
+			)
+		}
+
+		if c.VMCPUCoStopCritical <= c.VMCPUCoStopWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.FolderVMCounts:
+
+		if len(c.FolderIDs) == 0 {
+			return fmt.Errorf("folder ID not provided")
+		}
+
+		thresholds := []struct {
+			flagName string
+			flag     optionalIntFlag
+		}{
+			{FolderVMCountMaxWarningFlagLong, c.folderVMCountMaxWarning},
+			{FolderVMCountMaxCriticalFlagLong, c.folderVMCountMaxCritical},
+			{FolderVMCountMinWarningFlagLong, c.folderVMCountMinWarning},
+			{FolderVMCountMinCriticalFlagLong, c.folderVMCountMinCritical},
+		}
+
+		var numThresholdsSet int
+		for _, threshold := range thresholds {
+			if !threshold.flag.isSet {
+				continue
+			}
+			numThresholdsSet++
+
+			if threshold.flag.value < 0 {
+				return fmt.Errorf(
+					"invalid value specified for %q flag: %d",
+					threshold.flagName,
+					threshold.flag.value,
+				)
+			}
+		}
+
+		if numThresholdsSet == 0 {
+			return fmt.Errorf(
+				"at least one of %q, %q, %q or %q flags must be specified",
+				FolderVMCountMaxWarningFlagLong,
+				FolderVMCountMaxCriticalFlagLong,
+				FolderVMCountMinWarningFlagLong,
+				FolderVMCountMinCriticalFlagLong,
+			)
+		}
+
+		if c.folderVMCountMaxWarning.isSet && c.folderVMCountMaxCritical.isSet &&
+			c.folderVMCountMaxCritical.value <= c.folderVMCountMaxWarning.value {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.folderVMCountMinWarning.isSet && c.folderVMCountMinCritical.isSet &&
+			c.folderVMCountMinCritical.value >= c.folderVMCountMinWarning.value {
+			return fmt.Errorf(
+				"minimum critical threshold set higher than or equal to minimum warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineMemoryPressure:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.VMMemoryBalloonedCritical < 1 {
+			return fmt.Errorf(
+				"invalid ballooned memory (percentage as whole number) CRITICAL threshold number: %d",
+				c.VMMemoryBalloonedCritical,
+			)
+		}
+
+		if c.VMMemoryBalloonedWarning < 1 {
+			return fmt.Errorf(
+				"invalid ballooned memory (percentage as whole number) WARNING threshold number: %d",
+				c.VMMemoryBalloonedWarning,
+			)
+		}
+
+		if c.VMMemoryBalloonedCritical <= c.VMMemoryBalloonedWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.VMMemorySwappedCritical < 1 {
+			return fmt.Errorf(
+				"invalid swapped memory (percentage as whole number) CRITICAL threshold number: %d",
+				c.VMMemorySwappedCritical,
+			)
+		}
+
+		if c.VMMemorySwappedWarning < 1 {
+			return fmt.Errorf(
+				"invalid swapped memory (percentage as whole number) WARNING threshold number: %d",
+				c.VMMemorySwappedWarning,
+			)
+		}
+
+		if c.VMMemorySwappedCritical <= c.VMMemorySwappedWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.VMMemoryCompressedCritical < 1 {
+			return fmt.Errorf(
+				"invalid compressed memory (percentage as whole number) CRITICAL threshold number: %d",
+				c.VMMemoryCompressedCritical,
+			)
+		}
+
+		if c.VMMemoryCompressedWarning < 1 {
+			return fmt.Errorf(
+				"invalid compressed memory (percentage as whole number) WARNING threshold number: %d",
+				c.VMMemoryCompressedWarning,
+			)
+		}
+
+		if c.VMMemoryCompressedCritical <= c.VMMemoryCompressedWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.ClusterResourceUsage:
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+		if c.ClusterCPUUseCritical < 1 {
+			return fmt.Errorf(
+				"invalid cluster CPU usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.ClusterCPUUseCritical,
+			)
+		}
+
+		if c.ClusterCPUUseWarning < 1 {
+			return fmt.Errorf(
+				"invalid cluster CPU usage (percentage as whole number) WARNING threshold number: %d",
+				c.ClusterCPUUseWarning,
+			)
+		}
+
+		if c.ClusterCPUUseCritical <= c.ClusterCPUUseWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.ClusterMemoryUseCritical < 1 {
+			return fmt.Errorf(
+				"invalid cluster memory usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.ClusterMemoryUseCritical,
+			)
+		}
+
+		if c.ClusterMemoryUseWarning < 1 {
+			return fmt.Errorf(
+				"invalid cluster memory usage (percentage as whole number) WARNING threshold number: %d",
+				c.ClusterMemoryUseWarning,
+			)
+		}
+
+		if c.ClusterMemoryUseCritical <= c.ClusterMemoryUseWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VASAProviderStatus:
+
+		if c.VASACertExpiryCritical < 0 {
+			return fmt.Errorf(
+				"invalid VASA provider certificate expiration CRITICAL threshold number: %d",
+				c.VASACertExpiryCritical,
+			)
+		}
+
+		if c.VASACertExpiryWarning < 0 {
+			return fmt.Errorf(
+				"invalid VASA provider certificate expiration WARNING threshold number: %d",
+				c.VASACertExpiryWarning,
+			)
+		}
+
+		// Thresholds are expressed as days remaining, so the WARNING
+		// threshold is expected to be larger than the CRITICAL threshold.
+		if c.VASACertExpiryWarning <= c.VASACertExpiryCritical {
+			return fmt.Errorf(
+				"warning threshold set lower than or equal to critical threshold",
+			)
+		}
+
+	case pluginType.ClusterHAStatus:
+
+		for _, clusterName := range c.ClusterNames {
+			if len(clusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(clusterName),
+				)
+			}
+		}
+
+	case pluginType.VVolDatastoreHealth:
+
+		if c.DatastoreName != defaultDatastoreName && len(c.IgnoredDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				DatastoreNameFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		if c.DatastoreSpaceUsageCritical < 1 {
+			return fmt.Errorf(
+				"invalid datastore usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.DatastoreSpaceUsageCritical,
+			)
+		}
+
+		if c.DatastoreSpaceUsageWarning < 1 {
+			return fmt.Errorf(
+				"invalid datastore usage (percentage as whole number) WARNING threshold number: %d",
+				c.DatastoreSpaceUsageWarning,
+			)
+		}
+
+		if c.DatastoreSpaceUsageCritical <= c.DatastoreSpaceUsageWarning {
+			return fmt.Errorf(
+				"datastore critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.ClusterDRSStatus:
+
+		for _, clusterName := range c.ClusterNames {
+			if len(clusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(clusterName),
+				)
+			}
+		}
+
+		supportedDRSBehaviors := []string{
+			DRSBehaviorManual,
+			DRSBehaviorPartiallyAutomated,
+			DRSBehaviorFullyAutomated,
+		}
+
+		if !textutils.InList(c.RequiredDRSBehavior, supportedDRSBehaviors, false) {
+			return fmt.Errorf(
+				"invalid DRS automation level %q specified; supported values: %v",
+				c.RequiredDRSBehavior,
+				supportedDRSBehaviors,
+			)
+		}
+
+		if c.DRSRecommendationsWarning < 0 {
+			return fmt.Errorf(
+				"invalid pending DRS recommendations WARNING threshold number: %d",
+				c.DRSRecommendationsWarning,
+			)
+		}
+
+		if c.DRSRecommendationsCritical <= c.DRSRecommendationsWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineFTLatency:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.VMFTLatencyCritical < 1 {
+			return fmt.Errorf(
+				"invalid FT secondary latency (milliseconds) CRITICAL threshold number: %d",
+				c.VMFTLatencyCritical,
+			)
+		}
+
+		if c.VMFTLatencyWarning < 1 {
+			return fmt.Errorf(
+				"invalid FT secondary latency (milliseconds) WARNING threshold number: %d",
+				c.VMFTLatencyWarning,
+			)
+		}
+
+		if c.VMFTLatencyCritical <= c.VMFTLatencyWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.VMFTBandwidthCritical < 1 {
+			return fmt.Errorf(
+				"invalid FT logging bandwidth (Mbps) CRITICAL threshold number: %d",
+				c.VMFTBandwidthCritical,
+			)
+		}
+
+		if c.VMFTBandwidthWarning < 1 {
+			return fmt.Errorf(
+				"invalid FT logging bandwidth (Mbps) WARNING threshold number: %d",
+				c.VMFTBandwidthWarning,
+			)
+		}
+
+		if c.VMFTBandwidthCritical <= c.VMFTBandwidthWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineToolsNoIP:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.ToolsNoIPGracePeriod < 0 {
+			return fmt.Errorf(
+				"invalid grace period (minutes) specified: %d",
+				c.ToolsNoIPGracePeriod,
+			)
+		}
+
+	case pluginType.VCenterCertificates:
+
+		if c.CertificateExpiryCritical < 0 {
+			return fmt.Errorf(
+				"invalid certificate expiration CRITICAL threshold number: %d",
+				c.CertificateExpiryCritical,
+			)
+		}
+
+		if c.CertificateExpiryWarning < 0 {
+			return fmt.Errorf(
+				"invalid certificate expiration WARNING threshold number: %d",
+				c.CertificateExpiryWarning,
+			)
+		}
+
+		// Thresholds are expressed as days remaining, so the WARNING
+		// threshold is expected to be larger than the CRITICAL threshold.
+		if c.CertificateExpiryWarning <= c.CertificateExpiryCritical {
+			return fmt.Errorf(
+				"warning threshold set lower than or equal to critical threshold",
+			)
+		}
+
+		if c.ExcludeHostCertificates && c.HostSystemName != "" {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				ExcludeHostCertificatesFlagLong,
+				HostNameFlagLong,
+			)
+		}
+
+	case pluginType.License:
+
+		if c.LicenseUsageCritical < 1 {
+			return fmt.Errorf(
+				"invalid license usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.LicenseUsageCritical,
+			)
+		}
+
+		if c.LicenseUsageWarning < 1 {
+			return fmt.Errorf(
+				"invalid license usage (percentage as whole number) WARNING threshold number: %d",
+				c.LicenseUsageWarning,
+			)
+		}
+
+		if c.LicenseUsageCritical <= c.LicenseUsageWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.LicenseExpiryCritical < 0 {
+			return fmt.Errorf(
+				"invalid license expiration CRITICAL threshold number: %d",
+				c.LicenseExpiryCritical,
+			)
+		}
+
+		if c.LicenseExpiryWarning < 0 {
+			return fmt.Errorf(
+				"invalid license expiration WARNING threshold number: %d",
+				c.LicenseExpiryWarning,
+			)
+		}
+
+		// Expiration thresholds are expressed as days remaining, so the
+		// WARNING threshold is expected to be larger than the CRITICAL
+		// threshold.
+		if c.LicenseExpiryWarning <= c.LicenseExpiryCritical {
+			return fmt.Errorf(
+				"warning threshold set lower than or equal to critical threshold",
+			)
+		}
+
+	case pluginType.DatacenterInventoryDrift:
+
+		if c.InventoryStateFile == "" {
+			return fmt.Errorf(
+				"%s flag not specified; a state file is required",
+				InventoryStateFileFlagLong,
+			)
+		}
+
+		if c.InventoryDriftCritical < 0 {
+			return fmt.Errorf(
+				"invalid inventory drift CRITICAL threshold number: %d",
+				c.InventoryDriftCritical,
+			)
+		}
+
+		if c.InventoryDriftWarning < 0 {
+			return fmt.Errorf(
+				"invalid inventory drift WARNING threshold number: %d",
+				c.InventoryDriftWarning,
+			)
+		}
+
+		if c.InventoryDriftCritical <= c.InventoryDriftWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.Tasks:
+
+		if c.TasksLookback < 1 {
+			return fmt.Errorf(
+				"invalid tasks lookback window (minutes) specified: %d",
+				c.TasksLookback,
+			)
+		}
+
+		if c.FailedTasksWarning < 0 {
+			return fmt.Errorf(
+				"invalid failed tasks WARNING threshold number: %d",
+				c.FailedTasksWarning,
+			)
+		}
+
+		if c.FailedTasksCritical < 0 {
+			return fmt.Errorf(
+				"invalid failed tasks CRITICAL threshold number: %d",
+				c.FailedTasksCritical,
+			)
+		}
+
+		if c.FailedTasksCritical <= c.FailedTasksWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if len(c.IncludedTaskDescriptionIDs) > 0 && len(c.ExcludedTaskDescriptionIDs) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTaskDescriptionIDFlagLong,
+				ExcludeTaskDescriptionIDFlagLong,
+			)
+		}
+
+		if len(c.IncludedTaskUsers) > 0 && len(c.ExcludedTaskUsers) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTaskUserFlagLong,
+				ExcludeTaskUserFlagLong,
+			)
+		}
+
+	case pluginType.HostESXiShellSSHEnabled:
+
+		if c.ShellSSHRunningWarning < 0 {
+			return fmt.Errorf(
+				"invalid ESXi Shell or SSH running WARNING threshold number: %d",
+				c.ShellSSHRunningWarning,
+			)
+		}
+
+		if c.ShellSSHRunningCritical < 1 {
+			return fmt.Errorf(
+				"invalid ESXi Shell or SSH running CRITICAL threshold number: %d",
+				c.ShellSSHRunningCritical,
+			)
+		}
+
+		if c.ShellSSHRunningCritical <= c.ShellSSHRunningWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.HostDNSRouting:
+
+		for _, server := range c.ExpectedDNSServers {
+			if net.ParseIP(server) == nil {
+				return fmt.Errorf(
+					"invalid expected DNS server IP address specified: %q",
+					server,
+				)
+			}
+		}
+
+		if c.ExpectedIPv4Gateway != "" {
+			ip := net.ParseIP(c.ExpectedIPv4Gateway)
+			if ip == nil || ip.To4() == nil {
+				return fmt.Errorf(
+					"invalid expected IPv4 default gateway specified: %q",
+					c.ExpectedIPv4Gateway,
+				)
+			}
+		}
+
+		if c.ExpectedIPv6Gateway != "" {
+			ip := net.ParseIP(c.ExpectedIPv6Gateway)
+			if ip == nil || ip.To4() != nil {
+				return fmt.Errorf(
+					"invalid expected IPv6 default gateway specified: %q",
+					c.ExpectedIPv6Gateway,
+				)
+			}
+		}
+
+	case pluginType.Events:
+
+		if c.EventsLookback < 1 {
+			return fmt.Errorf(
+				"invalid events lookback window (minutes) specified: %d",
+				c.EventsLookback,
+			)
+		}
+
+		if c.MatchedEventsWarning < 0 {
+			return fmt.Errorf(
+				"invalid matched events WARNING threshold number: %d",
+				c.MatchedEventsWarning,
+			)
+		}
+
+		if c.MatchedEventsCritical < 0 {
+			return fmt.Errorf(
+				"invalid matched events CRITICAL threshold number: %d",
+				c.MatchedEventsCritical,
+			)
+		}
+
+		if c.MatchedEventsCritical <= c.MatchedEventsWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if len(c.IncludedEventTypeIDs) == 0 && len(c.IncludedEventMessages) == 0 {
+			return fmt.Errorf(
+				"one of %q or %q flags must be specified",
+				IncludeEventTypeFlagLong,
+				IncludeEventMessageFlagLong,
+			)
+		}
+
+	case pluginType.SnapshotsDatastoreQuota:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		if c.SnapshotsDatastoreQuotaWarning < 0 {
+			return fmt.Errorf(
+				"invalid datastore snapshots size WARNING threshold number: %d",
+				c.SnapshotsDatastoreQuotaWarning,
+			)
+		}
+
+		if c.SnapshotsDatastoreQuotaCritical < 0 {
+			return fmt.Errorf(
+				"invalid datastore snapshots size CRITICAL threshold number: %d",
+				c.SnapshotsDatastoreQuotaCritical,
+			)
+		}
+
+		if c.SnapshotsDatastoreQuotaCritical <= c.SnapshotsDatastoreQuotaWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineOrphanedDevices:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.OrphanedVMDKs:
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		if c.OrphanedVMDKsSizeWarning < 0 {
+			return fmt.Errorf(
+				"invalid orphaned VMDKs size WARNING threshold number: %d",
+				c.OrphanedVMDKsSizeWarning,
+			)
+		}
+
+		if c.OrphanedVMDKsSizeCritical < 0 {
+			return fmt.Errorf(
+				"invalid orphaned VMDKs size CRITICAL threshold number: %d",
+				c.OrphanedVMDKsSizeCritical,
+			)
+		}
+
+		if c.OrphanedVMDKsSizeCritical <= c.OrphanedVMDKsSizeWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.NagiosGenConfig:
+
+		patterns := []struct {
+			flagName string
+			pattern  string
+		}{
+			{flagName: GenConfigHostPatternFlagLong, pattern: c.GenConfigHostPattern},
+			{flagName: GenConfigDatastorePatternFlagLong, pattern: c.GenConfigDatastorePattern},
+			{flagName: GenConfigClusterPatternFlagLong, pattern: c.GenConfigClusterPattern},
+		}
+
+		for _, p := range patterns {
+			if _, err := path.Match(p.pattern, ""); err != nil {
+				return fmt.Errorf(
+					"invalid %s value %q: %w",
+					p.flagName,
+					p.pattern,
+					err,
+				)
+			}
+		}
+
+		if c.GenConfigTemplateFile != "" {
+			if _, err := os.Stat(c.GenConfigTemplateFile); err != nil {
+				return fmt.Errorf(
+					"invalid template file %q: %w",
+					c.GenConfigTemplateFile,
+					err,
+				)
+			}
+		}
+
+	case pluginType.VirtualMachineConnectedMedia:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.VirtualMachineGuestDiskUsage:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		thresholds := append(
+			[]GuestDiskMountThresholds{
+				{
+					Pattern:  "default",
+					Warning:  c.VMGuestDiskUsageWarning,
+					Critical: c.VMGuestDiskUsageCritical,
+				},
+			},
+			c.VMGuestDiskMountThresholds...,
+		)
+
+		for _, t := range thresholds {
+			if t.Critical < 1 || t.Critical > 100 {
+				return fmt.Errorf(
+					"invalid guest filesystem usage (percentage as whole number) CRITICAL threshold number for %s: %d",
+					t.Pattern,
+					t.Critical,
+				)
+			}
+
+			if t.Warning < 1 || t.Warning > 100 {
+				return fmt.Errorf(
+					"invalid guest filesystem usage (percentage as whole number) WARNING threshold number for %s: %d",
+					t.Pattern,
+					t.Warning,
+				)
+			}
+
+			if t.Critical <= t.Warning {
+				return fmt.Errorf(
+					"critical threshold set lower than or equal to warning threshold for %s",
+					t.Pattern,
+				)
+			}
+		}
+
+		for _, pattern := range c.VMGuestDiskExcludedMounts {
+			if _, err := path.Match(strings.ReplaceAll(pattern, `\`, "/"), ""); err != nil {
+				return fmt.Errorf(
+					"invalid %s value %q: %w",
+					GuestDiskExcludeMountFlagLong,
+					pattern,
+					err,
+				)
+			}
+		}
+
+	case pluginType.ResourcePoolRunawayVM:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		shareThresholds := []struct {
+			description string
+			warning     int
+			critical    int
+		}{
+			{
+				description: "CPU",
+				warning:     c.RunawayVMCPUShareWarning,
+				critical:    c.RunawayVMCPUShareCritical,
+			},
+			{
+				description: "memory",
+				warning:     c.RunawayVMMemoryShareWarning,
+				critical:    c.RunawayVMMemoryShareCritical,
+			},
+		}
+
+		for _, t := range shareThresholds {
+			if t.critical < 1 || t.critical > 100 {
+				return fmt.Errorf(
+					"invalid %s usage share (percentage as whole number) CRITICAL threshold number: %d",
+					t.description,
+					t.critical,
+				)
+			}
+
+			if t.warning < 1 || t.warning > 100 {
+				return fmt.Errorf(
+					"invalid %s usage share (percentage as whole number) WARNING threshold number: %d",
+					t.description,
+					t.warning,
+				)
+			}
+
+			if t.critical <= t.warning {
+				return fmt.Errorf(
+					"%s critical threshold set lower than or equal to warning threshold",
+					t.description,
+				)
+			}
+		}
+
+		if c.RunawayVMTopCount < 1 {
+			return fmt.Errorf(
+				"invalid number of top VMs specified: %d",
+				c.RunawayVMTopCount,
+			)
+		}
+
+	case pluginType.VirtualMachineToolsVersion:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.VMToolsMinVersion < 0 {
+			return fmt.Errorf(
+				"invalid minimum VMware Tools version specified: %d",
+				c.VMToolsMinVersion,
+			)
+		}
+
+	case pluginType.VirtualMachineCPUAffinity:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.HostTimeDrift:
+
+		if c.TimeDriftWarning < 1 {
+			return fmt.Errorf(
+				"invalid time drift WARNING threshold number: %d",
+				c.TimeDriftWarning,
+			)
+		}
+
+		if c.TimeDriftCritical < 1 {
+			return fmt.Errorf(
+				"invalid time drift CRITICAL threshold number: %d",
+				c.TimeDriftCritical,
+			)
+		}
+
+		if c.TimeDriftCritical <= c.TimeDriftWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VLCMCompliance:
+
+		if c.VLCMNonCompliantWarning < 0 {
+			return fmt.Errorf(
+				"invalid non-compliant hosts WARNING threshold number: %d",
+				c.VLCMNonCompliantWarning,
+			)
+		}
+
+		if c.VLCMNonCompliantCritical < 0 {
+			return fmt.Errorf(
+				"invalid non-compliant hosts CRITICAL threshold number: %d",
+				c.VLCMNonCompliantCritical,
+			)
+		}
+
+		if c.VLCMNonCompliantCritical <= c.VLCMNonCompliantWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.AlarmDefinitionsHash:
+
+		if c.AlarmDefinitionsStateFile == "" {
+			return fmt.Errorf(
+				"%s flag not specified; a state file is required",
+				AlarmDefinitionsStateFileFlagLong,
+			)
+		}
+
+	case pluginType.HostAcceleration:
+
+		if !c.ListObjects && !c.RequireCBRC && !c.RequireMemoryTiering {
+			return fmt.Errorf(
+				"one or both of %q or %q flags must be specified",
+				RequireCBRCFlagLong,
+				RequireMemoryTieringFlagLong,
+			)
+		}
+
+	case pluginType.VirtualMachineLastBackupViaTag:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		// assert that specified time zone is recognized
+		if _, err := time.LoadLocation(c.VMBackupDateTimezone); err != nil {
+			return fmt.Errorf(
+				"unable to load location data for specified time zone %q: %w",
+				c.VMBackupDateTimezone,
+				err,
+			)
+		}
+
+		// The backup date is read from tags in this category, so it is
+		// required.
+		if c.VMBackupTagCategory == "" {
+			return fmt.Errorf("tag category for last backup date not provided")
+		}
+
+		// The default value is valid, but the user could potentially override
+		// with an empty string (unlikely, but possible).
+		if c.VMBackupDateFormat == "" {
+			return fmt.Errorf("last backup date format not provided")
+		}
+
+		// The default value is valid, but the user could potentially override
+		// with an empty string (unlikely, but possible).
+		if c.VMBackupDateTimezone == "" {
+			return fmt.Errorf("last backup date time zone not provided")
+		}
+
+		if c.VMBackupAgeCritical < 1 {
+			return fmt.Errorf(
+				"invalid backup date age CRITICAL threshold number: %d",
+				c.VMBackupAgeCritical,
+			)
+		}
+
+		if c.VMBackupAgeWarning < 1 {
+			return fmt.Errorf(
+				"invalid backup date age WARNING threshold number: %d",
+				c.VMBackupAgeWarning,
+			)
+		}
+
+		if c.VMBackupAgeCritical <= c.VMBackupAgeWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineCustomAttribute:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if strings.TrimSpace(c.VMCustomAttributeName) == "" {
+			return fmt.Errorf("custom attribute name not provided")
+		}
+
+		if c.VMCustomAttributePattern != "" {
+			if _, err := regexp.Compile(c.VMCustomAttributePattern); err != nil {
+				return fmt.Errorf(
+					"invalid value specified for %q flag: %w",
+					VMCustomAttributePatternFlagLong,
+					err,
+				)
+			}
+		}
+
+		bounds := []struct {
+			flagName string
+			value    string
+		}{
+			{VMCustomAttributeMinFlagLong, c.VMCustomAttributeMin},
+			{VMCustomAttributeMaxFlagLong, c.VMCustomAttributeMax},
+		}
+
+		for _, bound := range bounds {
+			if bound.value == "" {
+				continue
+			}
+
+			var err error
+			switch {
+			case c.VMCustomAttributeDateFormat != "":
+				_, err = time.Parse(c.VMCustomAttributeDateFormat, bound.value)
+			default:
+				_, err = strconv.ParseFloat(bound.value, 64)
+			}
+
+			if err != nil {
+				return fmt.Errorf(
+					"invalid value specified for %q flag: %w",
+					bound.flagName,
+					err,
+				)
+			}
+		}
+
+		// Unless missing custom attributes are ignored, the custom attribute
+		// is required to exist. Otherwise at least one other constraint is
+		// needed.
+		if c.IgnoreMissingCustomAttribute &&
+			c.VMCustomAttributePattern == "" &&
+			c.VMCustomAttributeMin == "" &&
+			c.VMCustomAttributeMax == "" &&
+			c.VMCustomAttributeDateFormat == "" {
+			return fmt.Errorf(
+				"at least one of %q, %q, %q or %q flags must be specified if %q flag is specified",
+				VMCustomAttributePatternFlagLong,
+				VMCustomAttributeMinFlagLong,
+				VMCustomAttributeMaxFlagLong,
+				VMCustomAttributeDateFormatFlagLong,
+				CustomAttributeIgnoreMissingCAFlagLong,
+			)
+		}
+
+	case pluginType.VCenterDatabaseHealth:
+
+		if c.VCenterDatabaseUsageCritical < 1 || c.VCenterDatabaseUsageCritical > 100 {
+			return fmt.Errorf(
+				"invalid database usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.VCenterDatabaseUsageCritical,
+			)
+		}
+
+		if c.VCenterDatabaseUsageWarning < 1 || c.VCenterDatabaseUsageWarning > 100 {
+			return fmt.Errorf(
+				"invalid database usage (percentage as whole number) WARNING threshold number: %d",
+				c.VCenterDatabaseUsageWarning,
+			)
+		}
+
+		if c.VCenterDatabaseUsageCritical <= c.VCenterDatabaseUsageWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VCenterServiceStatus:
+
+		for _, svc := range c.RequiredVCenterServices() {
+			if textutils.InList(svc, c.IgnoredVCenterServices(), true) {
+				return fmt.Errorf(
+					"service %q specified for both %q and %q flags",
+					svc,
+					RequireVCenterServiceFlagLong,
+					IgnoreVCenterServiceFlagLong,
+				)
+			}
+		}
+
+	case pluginType.VMwareExporter:
+
+		if c.ExporterListenAddress == "" {
+			return fmt.Errorf("exporter listen address not provided")
+		}
+
+		if c.exporterCollectionInterval < 1 {
+			return fmt.Errorf(
+				"invalid collection interval (seconds) specified: %d",
+				c.exporterCollectionInterval,
+			)
+		}
+
+	case pluginType.AlarmActionDisabled:
+
+		for _, objType := range c.AlarmActionObjectTypes() {
+			if !textutils.InList(objType, supportedAlarmActionObjectTypes(), true) {
+				return fmt.Errorf(
+					"%q is not a supported value for the %q flag; supported values: %v",
+					objType,
+					AlarmActionObjectTypeFlagLong,
+					supportedAlarmActionObjectTypes(),
+				)
+			}
+		}
+
+	case pluginType.VirtualMachineIndependentDisks:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.VirtualMachineRDMUsage:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.HostSRIOVPassthroughCapacity:
+
+		if c.VFUsageCritical < 1 {
+			return fmt.Errorf(
+				"invalid SR-IOV virtual function usage (percentage as whole number) CRITICAL threshold number: %d",
+				c.VFUsageCritical,
+			)
+		}
+
+		if c.VFUsageWarning < 1 {
+			return fmt.Errorf(
+				"invalid SR-IOV virtual function usage (percentage as whole number) WARNING threshold number: %d",
+				c.VFUsageWarning,
+			)
+		}
+
+		if c.VFUsageCritical <= c.VFUsageWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.HostGPUAllocation:
+
+		if c.GPUAllocationCritical < 1 {
+			return fmt.Errorf(
+				"invalid GPU allocation (percentage as whole number) CRITICAL threshold number: %d",
+				c.GPUAllocationCritical,
+			)
+		}
+
+		if c.GPUAllocationWarning < 1 {
+			return fmt.Errorf(
+				"invalid GPU allocation (percentage as whole number) WARNING threshold number: %d",
+				c.GPUAllocationWarning,
+			)
+		}
+
+		if c.GPUAllocationCritical <= c.GPUAllocationWarning {
+			return fmt.Errorf(
+				"GPU allocation critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.GPUUtilizationCritical < 1 {
+			return fmt.Errorf(
+				"invalid GPU utilization (percentage as whole number) CRITICAL threshold number: %d",
+				c.GPUUtilizationCritical,
+			)
+		}
+
+		if c.GPUUtilizationWarning < 1 {
+			return fmt.Errorf(
+				"invalid GPU utilization (percentage as whole number) WARNING threshold number: %d",
+				c.GPUUtilizationWarning,
+			)
+		}
+
+		if c.GPUUtilizationCritical <= c.GPUUtilizationWarning {
+			return fmt.Errorf(
+				"GPU utilization critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.ClusterDASIsolation:
+
+		for _, clusterName := range c.ClusterNames {
+			if len(clusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(clusterName),
+				)
+			}
+		}
+
+		for _, address := range c.ExpectedIsolationAddresses {
+			if net.ParseIP(address) == nil {
+				return fmt.Errorf(
+					"invalid IP Address %q specified for the %q flag",
+					address,
+					IsolationAddressFlagLong,
+				)
+			}
+		}
+
+		for _, response := range c.AllowedIsolationResponses() {
+			if !textutils.InList(response, supportedIsolationResponses(), true) {
+				return fmt.Errorf(
+					"%q is not a supported value for the %q flag; supported values: %v",
+					response,
+					IsolationResponseFlagLong,
+					supportedIsolationResponses(),
+				)
+			}
+		}
+
+		// vSphere HA supports up to 10 custom isolation addresses
+		// (das.isolationaddress0 through das.isolationaddress9).
+		if c.MinIsolationAddresses < 0 || c.MinIsolationAddresses > MaxIsolationAddresses {
+			return fmt.Errorf(
+				"invalid minimum number of isolation addresses specified; must be between 0 and %d, received %d",
+				MaxIsolationAddresses,
+				c.MinIsolationAddresses,
+			)
+		}
+
+	case pluginType.DatastoresOvercommit:
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+		if c.DatastoreOvercommitCritical < 1 {
+			return fmt.Errorf(
+				"invalid datastore overcommit (percentage as whole number) CRITICAL threshold number: %d",
+				c.DatastoreOvercommitCritical,
+			)
+		}
+
+		if c.DatastoreOvercommitWarning < 1 {
+			return fmt.Errorf(
+				"invalid datastore overcommit (percentage as whole number) WARNING threshold number: %d",
+				c.DatastoreOvercommitWarning,
+			)
+		}
+
+		if c.DatastoreOvercommitCritical <= c.DatastoreOvercommitWarning {
+			return fmt.Errorf(
+				"datastore overcommit critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.StretchedClusterSiteBalance:
+
+		for _, clusterName := range c.ClusterNames {
+			if len(clusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(clusterName),
+				)
+			}
+		}
+
+		if len(c.SiteHostGroups) == 1 {
+			return fmt.Errorf(
+				"at least two site host group names required for the %q flag, received %d",
+				SiteHostGroupFlagLong,
+				len(c.SiteHostGroups),
+			)
+		}
+
+		if c.SiteImbalanceCritical < 1 || c.SiteImbalanceCritical > 100 {
+			return fmt.Errorf(
+				"invalid site imbalance (percentage as whole number) CRITICAL threshold number: %d",
+				c.SiteImbalanceCritical,
+			)
+		}
+
+		if c.SiteImbalanceWarning < 1 || c.SiteImbalanceWarning > 100 {
+			return fmt.Errorf(
+				"invalid site imbalance (percentage as whole number) WARNING threshold number: %d",
+				c.SiteImbalanceWarning,
+			)
+		}
+
+		if c.SiteImbalanceCritical <= c.SiteImbalanceWarning {
+			return fmt.Errorf(
+				"site imbalance critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.DatastoresAccessibility:
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+	case pluginType.VirtualMachinePendingHWUpgrade:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.PendingHardwareUpgradeAgeWarning < 1 {
+			return fmt.Errorf(
+				"invalid pending hardware upgrade age (in days) WARNING threshold number: %d",
+				c.PendingHardwareUpgradeAgeWarning,
+			)
+		}
+
+		if c.PendingHardwareUpgradeAgeCritical < 1 {
+			return fmt.Errorf(
+				"invalid pending hardware upgrade age (in days) CRITICAL threshold number: %d",
+				c.PendingHardwareUpgradeAgeCritical,
+			)
+		}
+
+		if c.PendingHardwareUpgradeAgeCritical <= c.PendingHardwareUpgradeAgeWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		// ESX 2.x, GSX Server 3.x, Workstation 4.x & 5.x, ...
+		// https://kb.vmware.com/s/article/1003746
+		if c.VirtualHardwareMinimumVersion != defaultVirtualHardwareMinimumVersion &&
+			c.VirtualHardwareMinimumVersion < 3 {
+			return fmt.Errorf("invalid value specified for minimum virtual hardware version")
+		}
+
+	case pluginType.VirtualMachineRestoreDetection:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		if c.VMRestoreStateFile == "" {
+			return fmt.Errorf(
+				"%s flag not specified; a state file is required",
+				VMRestoreStateFileFlagLong,
+			)
+		}
+
+	case pluginType.HostRebootPending:
+
+		if c.RebootPendingAgeWarning < 0 {
+			return fmt.Errorf(
+				"invalid reboot pending age (in hours) WARNING threshold number: %d",
+				c.RebootPendingAgeWarning,
+			)
+		}
+
+		if c.RebootPendingAgeCritical < 0 {
+			return fmt.Errorf(
+				"invalid reboot pending age (in hours) CRITICAL threshold number: %d",
+				c.RebootPendingAgeCritical,
+			)
+		}
+
+		if c.RebootPendingAgeCritical <= c.RebootPendingAgeWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.RebootPendingStateFile == "" && !c.ListObjects {
+			return fmt.Errorf(
+				"%s flag not specified; a state file is required",
+				RebootPendingStateFileFlagLong,
+			)
+		}
+
+	case pluginType.VirtualMachineTimeSyncPolicy:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		supportedPolicies := supportedTimeSyncPolicies()
+		if !textutils.InList(c.VMTimeSyncPeriodic, supportedPolicies, true) {
+			return fmt.Errorf(
+				"invalid %q value %q specified; supported values: %v",
+				VMTimeSyncPeriodicFlagLong,
+				c.VMTimeSyncPeriodic,
+				supportedPolicies,
+			)
+		}
+
+		if !textutils.InList(c.VMTimeSyncStartup, supportedPolicies, true) {
+			return fmt.Errorf(
+				"invalid %q value %q specified; supported values: %v",
+				VMTimeSyncStartupFlagLong,
+				c.VMTimeSyncStartup,
+				supportedPolicies,
+			)
+		}
+
+		if c.VMTimeSyncPeriodicPolicy() == nil && c.VMTimeSyncStartupPolicy() == nil {
+			return fmt.Errorf(
+				"%q and %q flags both set to %q; no policy to evaluate",
+				VMTimeSyncPeriodicFlagLong,
+				VMTimeSyncStartupFlagLong,
+				TimeSyncPolicyAny,
+			)
+		}
+
+	case pluginType.DatastoresVMDensity:
+
+		// only one of these options may be used
+		if len(c.IgnoredDatastores) > 0 && len(c.IncludedDatastores) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatastoreFlagLong,
+				IgnoreDatastoreFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+		if c.DatastoreVMsCritical < 1 {
+			return fmt.Errorf(
+				"invalid datastore VMs CRITICAL threshold number: %d",
+				c.DatastoreVMsCritical,
+			)
+		}
+
+		if c.DatastoreVMsWarning < 1 {
+			return fmt.Errorf(
+				"invalid datastore VMs WARNING threshold number: %d",
+				c.DatastoreVMsWarning,
+			)
+		}
+
+		if c.DatastoreVMsCritical <= c.DatastoreVMsWarning {
+			return fmt.Errorf(
+				"datastore VMs critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+		if c.DatastoreVMDKsCritical < 1 {
+			return fmt.Errorf(
+				"invalid datastore VMDKs CRITICAL threshold number: %d",
+				c.DatastoreVMDKsCritical,
+			)
+		}
+
+		if c.DatastoreVMDKsWarning < 1 {
+			return fmt.Errorf(
+				"invalid datastore VMDKs WARNING threshold number: %d",
+				c.DatastoreVMDKsWarning,
+			)
+		}
+
+		if c.DatastoreVMDKsCritical <= c.DatastoreVMDKsWarning {
+			return fmt.Errorf(
+				"datastore VMDKs critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.SnapshotRemovalStalls:
+
+		if c.SnapshotRemovalStallCritical < 1 {
+			return fmt.Errorf(
+				"invalid snapshot removal stall CRITICAL threshold number: %d",
+				c.SnapshotRemovalStallCritical,
+			)
+		}
+
+		if c.SnapshotRemovalStallWarning < 1 {
+			return fmt.Errorf(
+				"invalid snapshot removal stall WARNING threshold number: %d",
+				c.SnapshotRemovalStallWarning,
+			)
+		}
+
+		if c.SnapshotRemovalStallCritical <= c.SnapshotRemovalStallWarning {
+			return fmt.Errorf(
+				"snapshot removal stall critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VMMemoryAllocation:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+		// The static maximum is not used when comparing allocated memory
+		// against physical memory per cluster or standalone host.
+		if c.VMMemoryPhysicalCapacity {
+			if c.VMMemoryRatioCritical <= 0 {
+				return fmt.Errorf(
+					"invalid memory overcommit ratio CRITICAL threshold: %v",
+					c.VMMemoryRatioCritical,
+				)
+			}
+
+			if c.VMMemoryRatioWarning <= 0 {
+				return fmt.Errorf(
+					"invalid memory overcommit ratio WARNING threshold: %v",
+					c.VMMemoryRatioWarning,
+				)
+			}
+
+			if c.VMMemoryRatioCritical <= c.VMMemoryRatioWarning {
+				return fmt.Errorf(
+					"memory overcommit ratio critical threshold set lower than or equal to warning threshold",
+				)
+			}
+
+			break
+		}
+
+		if c.VMMemoryMaxAllowed < 1 {
+			return fmt.Errorf(
+				"invalid value specified for maximum amount of memory allowed: %d",
+				c.VMMemoryMaxAllowed,
+			)
+		}
+
+		if c.VMMemoryAllocatedCritical < 1 {
+			return fmt.Errorf(
+				"invalid VM memory allocation CRITICAL threshold number: %d",
+				c.VMMemoryAllocatedCritical,
+			)
+		}
+
+		if c.VMMemoryAllocatedWarning < 1 {
+			return fmt.Errorf(
+				"invalid VM memory allocation WARNING threshold number: %d",
+				c.VMMemoryAllocatedWarning,
+			)
+		}
+
+		if c.VMMemoryAllocatedCritical <= c.VMMemoryAllocatedWarning {
+			return fmt.Errorf(
+				"VM memory allocation critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	case pluginType.VirtualMachineDiskUUIDEnabled:
+
+		// only one of these options may be used
+		if len(c.ExcludedResourcePools) > 0 && len(c.IncludedResourcePools) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeResourcePoolFlagLong,
+				ExcludeResourcePoolFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedDatacenters) > 0 && len(c.IncludedDatacenters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeDatacenterFlagLong,
+				ExcludeDatacenterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedClusters) > 0 && len(c.IncludedClusters) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeClusterFlagLong,
+				ExcludeClusterFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedHosts) > 0 && len(c.IncludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedTags) > 0 && len(c.IncludedTags) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeTagFlagLong,
+				ExcludeTagFlagLong,
+			)
+		}
+
+		// only one of these options may be used
+		if len(c.ExcludedFolders) > 0 && len(c.IncludedFolders) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeFolderIDFlagLong,
+				ExcludeFolderIDFlagLong,
+			)
+		}
+
+	case pluginType.HostConnectionState:
+
+		// only one of these options may be used
+		if len(c.IncludedHosts) > 0 && len(c.ExcludedHosts) > 0 {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				IncludeHostFlagLong,
+				ExcludeHostFlagLong,
+			)
+		}
+
+		// optional flag; if not default value, assert known requirements
+		if c.ClusterName != defaultClusterName {
+			if len(c.ClusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(c.ClusterName),
+				)
+			}
+		}
+
+	case pluginType.ClusterHAHostFailures:
+
+		for _, clusterName := range c.ClusterNames {
+			if len(clusterName) > MaxClusterNameChars {
+				return fmt.Errorf(
+					"invalid cluster name specified; max supported length is %d, received %d",
+					MaxClusterNameChars,
+					len(clusterName),
+				)
+			}
+		}
+
+		if c.HAHostFailuresLookback < 1 {
+			return fmt.Errorf(
+				"invalid HA host failures lookback window (minutes) specified: %d",
+				c.HAHostFailuresLookback,
+			)
+		}
+
+		if c.HAHostFailuresWarning < 0 {
+			return fmt.Errorf(
+				"invalid HA host failures WARNING threshold number: %d",
+				c.HAHostFailuresWarning,
+			)
+		}
+
+		if c.HAHostFailuresCritical < 0 {
+			return fmt.Errorf(
+				"invalid HA host failures CRITICAL threshold number: %d",
+				c.HAHostFailuresCritical,
+			)
+		}
+
+		if c.HAHostFailuresCritical <= c.HAHostFailuresWarning {
+			return fmt.Errorf(
+				"critical threshold set lower than or equal to warning threshold",
+			)
+		}
+
+	}
+
+	// shared validation checks
+
+	if c.Server == "" {
+		return fmt.Errorf("server FQDN or IP Address not provided")
+	}
+
+	if c.Username == "" {
+		return fmt.Errorf(
+			"username not provided via %q or %q flags or %s environment variable",
+			UsernameFlagLong,
+			UsernameFileFlagLong,
+			UsernameEnvVar,
+		)
+	}
+
+	if c.Password == "" {
+		return fmt.Errorf(
+			"password not provided via %q or %q flags or %s environment variable",
+			PasswordFlagLong,
+			PasswordFileFlagLong,
+			PasswordEnvVar,
+		)
+	}
+
+	if c.Port < 0 {
+		return fmt.Errorf("invalid TCP port number %d", c.Port)
+	}
+
+	if !textutils.InList(c.TLSMinVersion, supportedTLSMinVersions(), false) {
+		return fmt.Errorf(
+			"%q is not a supported value for the %q flag; supported values: %v",
+			c.TLSMinVersion,
+			TLSMinVersionFlagLong,
+			supportedTLSMinVersions(),
+		)
+	}
+
+	if c.CAFile != "" {
+		if c.TrustCert {
+			return fmt.Errorf(
+				"only one of %q or %q flags may be specified",
+				CAFileFlagLong,
+				TrustCertFlagLong,
+			)
+		}
+
+		if _, err := os.Stat(c.CAFile); err != nil {
+			return fmt.Errorf(
+				"invalid %q flag value: %w",
+				CAFileFlagLong,
+				err,
+			)
+		}
+	}
 
+	if c.Proxy != "" {
+		if err := validateProxy(c.Proxy); err != nil {
+			return err
+		}
+	}
+
+	if c.InsecureSkipHostnameVerify && c.TrustCert {
+		return fmt.Errorf(
+			"only one of %q or %q flags may be specified",
+			InsecureSkipHostnameVerifyFlagLong,
+			TrustCertFlagLong,
+		)
+	}
+
+	if c.redactNamesLookupFile != "" && !c.RedactNames {
+		return fmt.Errorf(
+			"%q flag requires %q flag",
+			RedactNamesLookupFileFlagLong,
+			RedactNamesFlagLong,
+		)
+	}
+
+	if c.RedactNames && c.RedactNamesLookupFile() == "" {
+		return fmt.Errorf(
+			"unable to determine default redacted name lookup file; specify via %q flag",
+			RedactNamesLookupFileFlagLong,
+		)
+	}
+
+	supportedSections := supportedReportSections()
+	for _, section := range c.OmitReportSections {
+		if !textutils.InList(section, supportedSections, true) {
+			return fmt.Errorf(
+				"invalid %q value %q specified; supported values: %v",
+				OmitReportSectionFlagLong,
+				section,
+				supportedSections,
+			)
+		}
+	}
+
+	if c.OTLPEndpoint != "" {
+		u, err := url.Parse(c.OTLPEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf(
+				"invalid %q flag value %q; an http or https URL is required (e.g., http://localhost:4318)",
+				OTLPEndpointFlagLong,
+				c.OTLPEndpoint,
+			)
+		}
+	}
+
+	if err := c.validateSummaryTemplate(); err != nil {
+		return err
+	}
+
+	if c.VMMaintenanceCA != "" && strings.TrimSpace(c.VMMaintenanceCADateFormat) == "" {
+		return fmt.Errorf(
+			"%q flag requires a non-empty %q flag value",
+			MaintenanceCAFlagLong,
+			MaintenanceCAFormatFlagLong,
+		)
+	}
+
+	if c.Timeout() < 1 {
+		return fmt.Errorf("invalid timeout value %d provided", c.Timeout())
+	}
+
+	if c.propertyRetrievalWarning < 0 {
+		return fmt.Errorf(
+			"invalid property retrieval warning threshold %d provided",
+			c.propertyRetrievalWarning,
+		)
+	}
+
+	// Only validate pattern matching settings if the flag was exposed for the
+	// current plugin type.
+	if c.PatternMatch != "" {
+		if !textutils.InList(c.PatternMatch, textutils.PatternMatchModes(), true) {
+			return fmt.Errorf(
+				"%q is not a supported value for the %q flag; supported values: %v",
+				c.PatternMatch,
+				PatternMatchFlagLong,
+				textutils.PatternMatchModes(),
+			)
+		}
+
+		patternLists := [][]string{
+			c.IgnoredVMs,
+			c.IncludedResourcePools,
+			c.ExcludedResourcePools,
+			c.IncludedFolders,
+			c.ExcludedFolders,
+			c.IncludedAlarmNames,
+			c.ExcludedAlarmNames,
+			c.IgnoredDatastores,
+			c.IncludedDatastores,
+			c.IncludedClusters,
+			c.ExcludedClusters,
+			c.IncludedHosts,
+			c.ExcludedHosts,
+			c.AllowedMaintenanceHosts,
+			c.AllowedStandbyHosts,
+		}
+
+		for _, patterns := range patternLists {
+			if err := textutils.ValidatePatterns(patterns, strings.ToLower(c.PatternMatch)); err != nil {
+				return fmt.Errorf(
+					"invalid %q flag value: %w",
+					PatternMatchFlagLong,
+					err,
+				)
+			}
+		}
+	}
+
+	if c.ListObjectsPattern != "" {
+		if !c.ListObjects {
+			return fmt.Errorf(
+				"%q flag requires %q flag",
+				ListObjectsPatternFlagLong,
+				ListObjectsFlagLong,
+			)
+		}
+
+		if _, err := path.Match(c.ListObjectsPattern, ""); err != nil {
+			return fmt.Errorf(
+				"invalid list pattern %q: %w",
+				c.ListObjectsPattern,
+				err,
+			)
+		}
+	}
+
+	requestedLoggingLevel := strings.ToLower(c.LoggingLevel)
+	if _, ok := loggingLevels[requestedLoggingLevel]; !ok {
+		return fmt.Errorf("invalid logging level %q", c.LoggingLevel)
+	}
+
+	// Optimist
+	return nil
+
+}
+
+// validateEmergencyThreshold asserts that the optional emergency threshold,
+// if specified, is greater than the given CRITICAL threshold.
+func (c Config) validateEmergencyThreshold(criticalThreshold int) error {
+	if c.emergencyThreshold.isSet &&
+		c.emergencyThreshold.value <= criticalThreshold {
+		return fmt.Errorf(
+			"emergency threshold set lower than or equal to critical threshold",
+		)
+	}
+
+	return nil
 }
@@ -0,0 +1,167 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/vsphere"
+)
+
+// vmPowerStates is the collection of VirtualMachine power states exposed as
+// metrics. All states are exposed (with a zero count if applicable) so that
+// series do not disappear between scrapes.
+var vmPowerStates = []types.VirtualMachinePowerState{
+	types.VirtualMachinePowerStatePoweredOn,
+	types.VirtualMachinePowerStatePoweredOff,
+	types.VirtualMachinePowerStateSuspended,
+}
+
+// Collect uses the given vSphere client to retrieve inventory details and
+// returns metrics for datastore usage, host CPU and memory usage, VM power
+// states and VM snapshot counts and sizes. Inventory retrieval is performed
+// using the same property retrieval logic used by the plugins provided by
+// this project.
+func Collect(ctx context.Context, c *vim25.Client) (Metrics, error) {
+
+	dsCapacity := NewGauge("datastore_capacity_bytes", "Datastore capacity in bytes.")
+	dsFree := NewGauge("datastore_free_bytes", "Datastore free space in bytes.")
+	dsUsage := NewGauge("datastore_usage_percent", "Datastore space usage as a percentage of capacity.")
+	dsAccessible := NewGauge("datastore_accessible", "Whether the datastore is accessible (1) or not (0).")
+
+	hostCPUUsed := NewGauge("host_cpu_used_hertz", "Host CPU usage in Hz.")
+	hostCPUTotal := NewGauge("host_cpu_total_hertz", "Host CPU capacity in Hz.")
+	hostCPUUsage := NewGauge("host_cpu_usage_percent", "Host CPU usage as a percentage of capacity.")
+	hostMemUsed := NewGauge("host_memory_used_bytes", "Host memory usage in bytes.")
+	hostMemTotal := NewGauge("host_memory_total_bytes", "Host memory capacity in bytes.")
+	hostMemUsage := NewGauge("host_memory_usage_percent", "Host memory usage as a percentage of capacity.")
+
+	vmCount := NewGauge("vms", "Number of virtual machines by power state.")
+	vmPowerState := NewGauge("vm_power_state", "Virtual machine power state; the sample for the current power state is 1.")
+	vmSnapshots := NewGauge("vm_snapshots", "Number of snapshots for the virtual machine.")
+	vmSnapshotsSize := NewGauge("vm_snapshots_size_bytes", "Size of all snapshots for the virtual machine in bytes.")
+
+	datastores, err := vsphere.GetDatastores(ctx, c, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve datastores: %w", err)
+	}
+
+	for _, ds := range datastores {
+		if !ds.Summary.Accessible {
+			dsAccessible.Add(0, "datastore", ds.Name)
+
+			continue
+		}
+		dsAccessible.Add(1, "datastore", ds.Name)
+
+		dsCapacity.Add(float64(ds.Summary.Capacity), "datastore", ds.Name)
+		dsFree.Add(float64(ds.Summary.FreeSpace), "datastore", ds.Name)
+
+		if ds.Summary.Capacity > 0 {
+			used := ds.Summary.Capacity - ds.Summary.FreeSpace
+			dsUsage.Add(
+				float64(used)/float64(ds.Summary.Capacity)*100,
+				"datastore", ds.Name,
+			)
+		}
+	}
+
+	hosts, err := vsphere.GetHostSystems(ctx, c, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve hosts: %w", err)
+	}
+
+	for _, hs := range hosts {
+		// Thresholds are not evaluated by the exporter.
+		cpuSummary, cpuErr := vsphere.NewHostSystemCPUUsageSummary(hs, 0, 0)
+		if cpuErr == nil {
+			hostCPUUsed.Add(cpuSummary.CPUUsed, "host", hs.Name)
+			hostCPUTotal.Add(cpuSummary.CPUTotal, "host", hs.Name)
+			hostCPUUsage.Add(cpuSummary.CPUUsedPercent, "host", hs.Name)
+		}
+
+		memSummary, memErr := vsphere.NewHostSystemMemoryUsageSummary(hs, 0, 0)
+		if memErr == nil {
+			hostMemUsed.Add(float64(memSummary.MemoryUsed), "host", hs.Name)
+			hostMemTotal.Add(float64(memSummary.MemoryTotal), "host", hs.Name)
+			hostMemUsage.Add(memSummary.MemoryUsedPercent, "host", hs.Name)
+		}
+	}
+
+	vms, err := vsphere.GetVMs(ctx, c, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve virtual machines: %w", err)
+	}
+
+	powerStateCounts := make(map[types.VirtualMachinePowerState]int)
+	for _, vm := range vms {
+		powerState := vm.Runtime.PowerState
+		powerStateCounts[powerState]++
+
+		for _, state := range vmPowerStates {
+			var value float64
+			if state == powerState {
+				value = 1
+			}
+			vmPowerState.Add(value, "vm", vm.Name, "state", string(state))
+		}
+
+		snapshots := vsphere.NewSnapshotSummarySet(vm, vsphere.SnapshotThresholds{})
+		vmSnapshots.Add(float64(len(snapshots.Snapshots)), "vm", vm.Name)
+		vmSnapshotsSize.Add(float64(snapshots.Size()), "vm", vm.Name)
+	}
+
+	for _, state := range vmPowerStates {
+		vmCount.Add(float64(powerStateCounts[state]), "power_state", string(state))
+	}
+
+	return Metrics{
+		dsCapacity,
+		dsFree,
+		dsUsage,
+		dsAccessible,
+		hostCPUUsed,
+		hostCPUTotal,
+		hostCPUUsage,
+		hostMemUsed,
+		hostMemTotal,
+		hostMemUsage,
+		vmCount,
+		vmPowerState,
+		vmSnapshots,
+		vmSnapshotsSize,
+	}, nil
+
+}
+
+// collectionMetrics returns metrics describing the most recent collection
+// attempt.
+func collectionMetrics(success bool, duration time.Duration, last time.Time) Metrics {
+	up := NewGauge("exporter_collection_success", "Whether the most recent collection of vSphere metrics succeeded (1) or not (0).")
+	dur := NewGauge("exporter_collection_duration_seconds", "Time spent on the most recent collection of vSphere metrics.")
+	ts := NewGauge("exporter_last_collection_timestamp_seconds", "Unix time of the most recent successful collection of vSphere metrics.")
+
+	var upValue float64
+	if success {
+		upValue = 1
+	}
+
+	up.Add(upValue)
+	dur.Add(duration.Seconds())
+
+	if !last.IsZero() {
+		ts.Add(float64(last.Unix()))
+	}
+
+	return Metrics{up, dur, ts}
+}
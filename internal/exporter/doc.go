@@ -0,0 +1,11 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package exporter provides types and helper functions used to collect
+// vSphere inventory metrics and expose them in the Prometheus text-based
+// exposition format.
+package exporter
@@ -0,0 +1,146 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Metric types supported by the Prometheus text-based exposition format.
+const (
+	MetricTypeGauge   string = "gauge"
+	MetricTypeCounter string = "counter"
+)
+
+// metricNamePrefix is prepended to the name of all exposed metrics.
+const metricNamePrefix string = "vmware_"
+
+// Label is a metric label name/value pair.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single metric value along with the labels which identify it.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Metric is a named collection of samples sharing the same help text and
+// type.
+type Metric struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Metrics is a collection of Metric values.
+type Metrics []Metric
+
+// labelValueReplacer escapes label values per the Prometheus text-based
+// exposition format.
+var labelValueReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+)
+
+// helpReplacer escapes help text per the Prometheus text-based exposition
+// format.
+var helpReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+)
+
+// NewGauge returns a gauge metric with the given name (without prefix) and
+// help text.
+func NewGauge(name string, help string) Metric {
+	return Metric{
+		Name: metricNamePrefix + name,
+		Help: help,
+		Type: MetricTypeGauge,
+	}
+}
+
+// Add records a sample for the metric using the given value and label
+// name/value pairs. Label names and values are specified in alternating
+// order (e.g., "datastore", "ds1").
+func (m *Metric) Add(value float64, labelPairs ...string) {
+	sample := Sample{Value: value}
+	for i := 0; i+1 < len(labelPairs); i += 2 {
+		sample.Labels = append(sample.Labels, Label{
+			Name:  labelPairs[i],
+			Value: labelPairs[i+1],
+		})
+	}
+
+	m.Samples = append(m.Samples, sample)
+}
+
+// formatValue formats a sample value per the Prometheus text-based
+// exposition format.
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+// WriteText writes the collection of metrics to the given writer using the
+// Prometheus text-based exposition format. Metrics without samples are
+// omitted.
+func (metrics Metrics) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metrics {
+		if len(m.Samples) == 0 {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n", m.Name, helpReplacer.Replace(m.Help))
+		_, _ = fmt.Fprintf(bw, "# TYPE %s %s\n", m.Name, m.Type)
+
+		for _, sample := range m.Samples {
+			_, _ = bw.WriteString(m.Name)
+
+			if len(sample.Labels) > 0 {
+				labels := make([]string, 0, len(sample.Labels))
+				for _, label := range sample.Labels {
+					labels = append(labels, fmt.Sprintf(
+						"%s=\"%s\"",
+						label.Name,
+						labelValueReplacer.Replace(label.Value),
+					))
+				}
+
+				_, _ = fmt.Fprintf(bw, "{%s}", strings.Join(labels, ","))
+			}
+
+			_, _ = fmt.Fprintf(bw, " %s\n", formatValue(sample.Value))
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// textContentType is the HTTP Content-Type of the Prometheus text-based
+// exposition format.
+const textContentType string = "text/plain; version=0.0.4; charset=utf-8"
+
+// CollectFunc retrieves a fresh set of metrics.
+type CollectFunc func(ctx context.Context) (Metrics, error)
+
+// Exporter periodically collects metrics using the given collection function
+// and serves the most recent results via HTTP. Collection is performed on a
+// fixed interval rather than per scrape so that frequent (or concurrent)
+// scrapes do not place additional load on the vSphere environment.
+type Exporter struct {
+	collect  CollectFunc
+	interval time.Duration
+	timeout  time.Duration
+
+	// ErrorHandler is called (if set) when a collection attempt fails.
+	ErrorHandler func(err error)
+
+	mu            sync.RWMutex
+	metrics       Metrics
+	lastSuccess   time.Time
+	lastDuration  time.Duration
+	lastSucceeded bool
+}
+
+// New returns an Exporter which uses the given collection function on the
+// specified interval. Each collection attempt is limited to the specified
+// timeout.
+func New(collect CollectFunc, interval time.Duration, timeout time.Duration) *Exporter {
+	return &Exporter{
+		collect:  collect,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// refresh performs a single collection attempt, retaining the previously
+// collected metrics if the attempt fails.
+func (e *Exporter) refresh(ctx context.Context) {
+	start := time.Now()
+
+	collectCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	metrics, err := e.collect(collectCtx)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lastDuration = time.Since(start)
+	e.lastSucceeded = err == nil
+
+	if err != nil {
+		if e.ErrorHandler != nil {
+			e.ErrorHandler(err)
+		}
+
+		return
+	}
+
+	e.metrics = metrics
+	e.lastSuccess = time.Now()
+}
+
+// Run performs collection immediately and then on the configured interval
+// until the given context is canceled.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	e.refresh(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.refresh(ctx)
+		}
+	}
+}
+
+// ServeHTTP satisfies the http.Handler interface, writing the most recently
+// collected metrics using the Prometheus text-based exposition format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	e.mu.RLock()
+	metrics := make(Metrics, 0, len(e.metrics)+3)
+	metrics = append(metrics, e.metrics...)
+	metrics = append(metrics, collectionMetrics(e.lastSucceeded, e.lastDuration, e.lastSuccess)...)
+	e.mu.RUnlock()
+
+	w.Header().Set("Content-Type", textContentType)
+
+	if err := metrics.WriteText(w); err != nil && e.ErrorHandler != nil {
+		e.ErrorHandler(err)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+// Package genconfig provides types and helper functions used to render
+// Nagios object definitions from discovered vSphere inventory.
+package genconfig
@@ -0,0 +1,215 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package genconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ErrTemplateParseFailed indicates that a provided Nagios object definitions
+// template could not be parsed.
+var ErrTemplateParseFailed = errors.New("failed to parse template")
+
+// ErrTemplateRenderFailed indicates that a Nagios object definitions
+// template could not be rendered using the provided inventory details.
+var ErrTemplateRenderFailed = errors.New("failed to render template")
+
+// DefaultTemplateName is the name used for the built-in Nagios object
+// definitions template.
+const DefaultTemplateName string = "default"
+
+// DefaultTemplate is the built-in template used to render Nagios object
+// definitions when a custom template is not specified. The service
+// definitions generated by this template mirror those provided in the
+// contrib/nagios directory of this project and make use of the command
+// definitions provided there.
+const DefaultTemplate string = `###########################################################################
+# Generated by {{ .Generator }} on {{ .Generated.Format "2006-01-02 15:04:05 MST" }}
+#
+# vSphere environment: {{ .Server }}
+# Datacenter: {{ if .Datacenter }}{{ .Datacenter }}{{ else }}all{{ end }}
+# Hosts: {{ len .Hosts }}, Datastores: {{ len .Datastores }}, Clusters: {{ len .Clusters }}
+#
+# Changes made to this file will be lost when it is regenerated.
+###########################################################################
+{{- if .HostDefinition }}
+
+define host{
+    use                     generic-host
+    host_name               {{ .NagiosHost }}
+    alias                   {{ .NagiosHost }} VMware vCenter
+    address                 {{ .Server }}
+    }
+{{- end }}
+{{- range .Hosts }}
+
+define service{
+    use                     vmware-vsphere-service
+    host_name               {{ $.NagiosHost }}
+    service_description     VMware Host CPU - {{ . }}
+    servicegroups           vmware-checks, vmware-host-checks
+    check_command           check_vmware_host_cpu!{{ escape $.Domain }}!{{ escape $.Username }}!$USER13$!80!95!{{ escape . }}
+    # Argument 1: User Domain
+    # Argument 2: Service Account username
+    # Argument 3: Service Account password (see resource.cfg)
+    # Argument 4: Warning CPU threshold in percentage, given as whole number
+    # Argument 5: Critical CPU threshold in percentage, given as whole number
+    # Argument 6: ESXi hostname as seen within vSphere client
+    }
+
+define service{
+    use                     vmware-vsphere-service
+    host_name               {{ $.NagiosHost }}
+    service_description     VMware Host Memory - {{ . }}
+    servicegroups           vmware-checks, vmware-host-checks
+    check_command           check_vmware_host_memory!{{ escape $.Domain }}!{{ escape $.Username }}!$USER13$!80!95!{{ escape . }}
+    # Argument 1: User Domain
+    # Argument 2: Service Account username
+    # Argument 3: Service Account password (see resource.cfg)
+    # Argument 4: Warning memory threshold in percentage, given as whole number
+    # Argument 5: Critical memory threshold in percentage, given as whole number
+    # Argument 6: ESXi hostname as seen within vSphere client
+    }
+{{- end }}
+{{- range .Datastores }}
+
+define service{
+    use                     vmware-vsphere-service
+    host_name               {{ $.NagiosHost }}
+    service_description     VMware Datastore Space - {{ . }}
+    servicegroups           vmware-checks, vmware-datastore-checks
+    check_command           check_vmware_datastore_space!{{ escape $.Domain }}!{{ escape $.Username }}!$USER13$!90!95!{{ escape . }}
+    # Argument 1: User Domain
+    # Argument 2: Service Account username
+    # Argument 3: Service Account password (see resource.cfg)
+    # Argument 4: Warning space usage threshold in percentage, given as whole number
+    # Argument 5: Critical space usage threshold in percentage, given as whole number
+    # Argument 6: Datastore name as seen within vSphere client
+    }
+{{- end }}
+{{- range .Clusters }}
+
+define service{
+    use                     vmware-vsphere-service
+    host_name               {{ $.NagiosHost }}
+    service_description     VMware Cluster HA Status - {{ . }}
+    servicegroups           vmware-checks, vmware-availability-checks
+    check_command           check_vmware_cluster_ha_status!{{ escape $.Domain }}!{{ escape $.Username }}!$USER13$!{{ escape . }}
+    # Argument 1: User Domain
+    # Argument 2: Service Account username
+    # Argument 3: Service Account password (see resource.cfg)
+    # Argument 4: Cluster name as seen within vSphere client
+    }
+{{- end }}
+`
+
+// TemplateData is the collection of discovered inventory details (and
+// related settings) made available to Nagios object definition templates.
+type TemplateData struct {
+	// Generated is the time that the object definitions were rendered.
+	Generated time.Time
+
+	// Generator is the name and version of the application used to render
+	// the object definitions.
+	Generator string
+
+	// Server is the FQDN or IP Address of the vSphere environment queried
+	// for inventory details.
+	Server string
+
+	// NagiosHost is the Nagios host_name that generated service definitions
+	// are associated with.
+	NagiosHost string
+
+	// Domain is the user domain used when connecting to the vSphere
+	// environment.
+	Domain string
+
+	// Username is the user account used when connecting to the vSphere
+	// environment. The associated password is intentionally not provided.
+	Username string
+
+	// Datacenter is the optional name of the datacenter used to limit
+	// inventory discovery.
+	Datacenter string
+
+	// HostDefinition indicates whether a host definition for the vSphere
+	// environment should be rendered along with service definitions.
+	HostDefinition bool
+
+	// Hosts is the collection of discovered ESXi host names.
+	Hosts []string
+
+	// Datastores is the collection of discovered datastore names.
+	Datastores []string
+
+	// Clusters is the collection of discovered cluster names.
+	Clusters []string
+}
+
+// EscapeArgument escapes characters within the given value which have
+// special meaning within a Nagios check_command directive.
+func EscapeArgument(value string) string {
+	return strings.ReplaceAll(value, "!", `\!`)
+}
+
+// LoadTemplate returns the contents of the specified template file. If the
+// filename is empty the built-in default template is returned instead.
+func LoadTemplate(filename string) (string, string, error) {
+	if filename == "" {
+		return DefaultTemplateName, DefaultTemplate, nil
+	}
+
+	content, err := os.ReadFile(filename) //nolint:gosec
+	if err != nil {
+		return "", "", fmt.Errorf(
+			"failed to read template file %q: %w",
+			filename,
+			err,
+		)
+	}
+
+	return filename, string(content), nil
+}
+
+// Render renders the given template text using the provided inventory
+// details, writing the results to w.
+func Render(w io.Writer, name string, text string, data TemplateData) error {
+	tmpl, parseErr := template.New(name).
+		Funcs(template.FuncMap{
+			"escape": EscapeArgument,
+			"lower":  strings.ToLower,
+			"upper":  strings.ToUpper,
+		}).
+		Parse(text)
+	if parseErr != nil {
+		return fmt.Errorf(
+			"%w %s: %v",
+			ErrTemplateParseFailed,
+			name,
+			parseErr,
+		)
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf(
+			"%w %s: %v",
+			ErrTemplateRenderFailed,
+			name,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -9,10 +9,59 @@ package textutils
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
+// Supported pattern matching modes for include/exclude lists.
+const (
+	// PatternMatchExact requires values to match list entries exactly.
+	PatternMatchExact string = "exact"
+
+	// PatternMatchGlob treats list entries as shell glob patterns (e.g.,
+	// web-*-prod).
+	PatternMatchGlob string = "glob"
+
+	// PatternMatchRegex treats list entries as regular expressions. Patterns
+	// are not implicitly anchored.
+	PatternMatchRegex string = "regex"
+)
+
+// compiledRegexes is the collection of regular expressions compiled for the
+// regex pattern matching mode, indexed by expression. Expressions are
+// compiled once (typically during validation) and reused for each
+// comparison.
+var compiledRegexes sync.Map
+
+// compiledRegex is the result of compiling a regular expression.
+type compiledRegex struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// regexFor returns the compiled form of the given pattern for the regex
+// pattern matching mode. The caller can optionally ignore case of compared
+// items.
+func regexFor(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	expr := pattern
+	if ignoreCase {
+		expr = "(?i)" + pattern
+	}
+
+	if cached, ok := compiledRegexes.Load(expr); ok {
+		result := cached.(compiledRegex)
+		return result.re, result.err
+	}
+
+	re, err := regexp.Compile(expr)
+	compiledRegexes.Store(expr, compiledRegex{re: re, err: err})
+
+	return re, err
+}
+
 // InList is a helper function to emulate Python's `if "x"
 // in list:` functionality. The caller can optionally ignore case of compared
 // items.
@@ -32,6 +81,83 @@ func InList(needle string, haystack []string, ignoreCase bool) bool {
 	return false
 }
 
+// PatternMatchModes returns the supported pattern matching modes.
+func PatternMatchModes() []string {
+	return []string{
+		PatternMatchExact,
+		PatternMatchGlob,
+		PatternMatchRegex,
+	}
+}
+
+// ValidatePatterns asserts that each of the given patterns is valid for the
+// specified pattern matching mode. Regular expressions are compiled once
+// here for use by later comparisons.
+func ValidatePatterns(patterns []string, mode string) error {
+	switch mode {
+	case PatternMatchExact, "":
+		return nil
+
+	case PatternMatchGlob, PatternMatchRegex:
+
+	default:
+		return fmt.Errorf("unsupported pattern matching mode %q", mode)
+	}
+
+	for _, pattern := range patterns {
+		switch mode {
+		case PatternMatchGlob:
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+			}
+
+		case PatternMatchRegex:
+			if _, err := regexFor(pattern, true); err != nil {
+				return fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// MatchPattern indicates whether the given value matches the given pattern
+// using the specified pattern matching mode. The caller can optionally
+// ignore case of compared items. Invalid patterns do not match.
+func MatchPattern(value string, pattern string, mode string, ignoreCase bool) bool {
+	switch mode {
+	case PatternMatchGlob:
+		if ignoreCase {
+			value = strings.ToLower(value)
+			pattern = strings.ToLower(pattern)
+		}
+		matched, err := path.Match(pattern, value)
+
+		return err == nil && matched
+
+	case PatternMatchRegex:
+		re, err := regexFor(pattern, ignoreCase)
+
+		return err == nil && re.MatchString(value)
+
+	default:
+		return InList(value, []string{pattern}, ignoreCase)
+	}
+}
+
+// MatchesAnyPattern indicates whether the given value matches any of the
+// given patterns using the specified pattern matching mode. The caller can
+// optionally ignore case of compared items.
+func MatchesAnyPattern(value string, patterns []string, mode string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(value, pattern, mode, ignoreCase) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // DedupeList returns a copy of a provided string slice with all duplicate
 // entries removed.
 // FIXME: Is there already a standard library version of this functionality?
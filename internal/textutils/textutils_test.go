@@ -0,0 +1,156 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package textutils
+
+import (
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// value is the value compared against the pattern
+		value string
+
+		// pattern is the include/exclude list entry
+		pattern string
+
+		// mode is the pattern matching mode
+		mode string
+
+		// ignoreCase indicates whether case is ignored
+		ignoreCase bool
+
+		// want is the expected match result
+		want bool
+	}{
+		{testName: "exact match", value: "web-01", pattern: "web-01", mode: PatternMatchExact, want: true},
+		{testName: "exact match ignore case", value: "WEB-01", pattern: "web-01", mode: PatternMatchExact, ignoreCase: true, want: true},
+		{testName: "exact mismatch case", value: "WEB-01", pattern: "web-01", mode: PatternMatchExact, want: false},
+		{testName: "exact does not treat wildcard as pattern", value: "web-01", pattern: "web-*", mode: PatternMatchExact, want: false},
+		{testName: "default mode is exact", value: "web-01", pattern: "web-01", mode: "", want: true},
+
+		{testName: "glob star", value: "web-01-prod", pattern: "web-*-prod", mode: PatternMatchGlob, want: true},
+		{testName: "glob star mismatch", value: "web-01-dev", pattern: "web-*-prod", mode: PatternMatchGlob, want: false},
+		{testName: "glob question mark", value: "web-1", pattern: "web-?", mode: PatternMatchGlob, want: true},
+		{testName: "glob character class", value: "web-3", pattern: "web-[1-3]", mode: PatternMatchGlob, want: true},
+		{testName: "glob is anchored", value: "old-web-01", pattern: "web-*", mode: PatternMatchGlob, want: false},
+		{testName: "glob ignore case", value: "WEB-01", pattern: "web-*", mode: PatternMatchGlob, ignoreCase: true, want: true},
+		{testName: "glob case sensitive", value: "WEB-01", pattern: "web-*", mode: PatternMatchGlob, want: false},
+		{testName: "glob invalid pattern", value: "web-1", pattern: "web-[", mode: PatternMatchGlob, want: false},
+
+		{testName: "regex anchored", value: "web-42", pattern: "^web-[0-9]+$", mode: PatternMatchRegex, want: true},
+		{testName: "regex anchored mismatch", value: "web-42a", pattern: "^web-[0-9]+$", mode: PatternMatchRegex, want: false},
+		{testName: "regex unanchored", value: "old-web-01", pattern: "web-", mode: PatternMatchRegex, want: true},
+		{testName: "regex ignore case", value: "WEB-01", pattern: "^web", mode: PatternMatchRegex, ignoreCase: true, want: true},
+		{testName: "regex case sensitive", value: "WEB-01", pattern: "^web", mode: PatternMatchRegex, want: false},
+		{testName: "regex invalid pattern", value: "web-1", pattern: "web-(", mode: PatternMatchRegex, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			got := MatchPattern(tt.value, tt.pattern, tt.mode, tt.ignoreCase)
+			if got != tt.want {
+				t.Errorf(
+					"MatchPattern(%q, %q, %q, %t): want %t; got %t",
+					tt.value,
+					tt.pattern,
+					tt.mode,
+					tt.ignoreCase,
+					tt.want,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestMatchesAnyPattern(t *testing.T) {
+	patterns := []string{"db-*", "web-[0-9]"}
+
+	if !MatchesAnyPattern("WEB-1", patterns, PatternMatchGlob, true) {
+		t.Errorf("want match for %q against %v", "WEB-1", patterns)
+	}
+
+	if MatchesAnyPattern("app-1", patterns, PatternMatchGlob, true) {
+		t.Errorf("want no match for %q against %v", "app-1", patterns)
+	}
+
+	if MatchesAnyPattern("app-1", nil, PatternMatchRegex, true) {
+		t.Errorf("want no match for %q against empty list", "app-1")
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// patterns is the collection of include/exclude list entries
+		patterns []string
+
+		// mode is the pattern matching mode
+		mode string
+
+		// wantErr indicates whether validation is expected to fail
+		wantErr bool
+	}{
+		{testName: "exact accepts any value", patterns: []string{"web-[", "web-("}, mode: PatternMatchExact},
+		{testName: "valid glob", patterns: []string{"web-*", "db-[0-9]"}, mode: PatternMatchGlob},
+		{testName: "invalid glob", patterns: []string{"web-*", "web-["}, mode: PatternMatchGlob, wantErr: true},
+		{testName: "valid regex", patterns: []string{"^web-[0-9]+$"}, mode: PatternMatchRegex},
+		{testName: "invalid regex", patterns: []string{"web-("}, mode: PatternMatchRegex, wantErr: true},
+		{testName: "unsupported mode", patterns: []string{"web"}, mode: "fuzzy", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			err := ValidatePatterns(tt.patterns, tt.mode)
+			switch {
+			case tt.wantErr && err == nil:
+				t.Errorf("want error; got nil")
+			case !tt.wantErr && err != nil:
+				t.Errorf("want nil error; got %v", err)
+			}
+		})
+	}
+}
+
+func TestRegexCompiledOnce(t *testing.T) {
+	pattern := "^compiled-once-[a-z]+$"
+
+	if err := ValidatePatterns([]string{pattern}, PatternMatchRegex); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	first, err := regexFor(pattern, true)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if !MatchPattern("COMPILED-ONCE-abc", pattern, PatternMatchRegex, true) {
+		t.Errorf("want match for %q", pattern)
+	}
+
+	second, err := regexFor(pattern, true)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if first != second {
+		t.Errorf("want regular expression %q to be compiled once", pattern)
+	}
+}
@@ -0,0 +1,371 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrAlarmActionsDisabled indicates that alarm actions are disabled for one
+// or more inventory objects.
+var ErrAlarmActionsDisabled = errors.New("alarm actions disabled")
+
+// AlarmActionObject represents the alarm actions setting for an inventory
+// object (e.g., Datacenter, Cluster or HostSystem).
+type AlarmActionObject struct {
+	// Type is the managed object type (e.g., HostSystem).
+	Type string
+
+	// Name is the name of the inventory object.
+	Name string
+
+	// MOID is the Managed Object ID of the inventory object.
+	MOID string
+
+	// Enabled indicates whether alarm actions are enabled for the inventory
+	// object. Objects which do not report this setting are treated as
+	// enabled.
+	Enabled bool
+
+	// Ignored indicates whether the inventory object was explicitly ignored
+	// by the user.
+	Ignored bool
+}
+
+// AlarmActionObjects is a collection of AlarmActionObject values.
+type AlarmActionObjects []AlarmActionObject
+
+// newAlarmActionObject evaluates the alarm actions setting for the given
+// ManagedEntity.
+func newAlarmActionObject(me mo.ManagedEntity, ignoredObjects []string) AlarmActionObject {
+	enabled := true
+	if me.AlarmActionsEnabled != nil {
+		enabled = *me.AlarmActionsEnabled
+	} else {
+		logger.Printf(
+			"alarm actions setting not reported for %s %s; treating as enabled",
+			me.Self.Type,
+			me.Name,
+		)
+	}
+
+	return AlarmActionObject{
+		Type:    me.Self.Type,
+		Name:    me.Name,
+		MOID:    me.Self.Value,
+		Enabled: enabled,
+		Ignored: textutils.InList(me.Name, ignoredObjects, true),
+	}
+}
+
+// NewAlarmActionObjects evaluates the alarm actions setting for the given
+// Datacenters, Clusters and HostSystems. Objects with names matching an
+// entry in the given list of ignored objects are noted but not evaluated
+// further.
+func NewAlarmActionObjects(
+	dcs []mo.Datacenter,
+	clusters []mo.ClusterComputeResource,
+	hss []mo.HostSystem,
+	ignoredObjects []string,
+) AlarmActionObjects {
+
+	funcTimeStart := time.Now()
+
+	objects := make(AlarmActionObjects, 0, len(dcs)+len(clusters)+len(hss))
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewAlarmActionObjects func (and evaluate %d objects).\n",
+			time.Since(funcTimeStart),
+			len(objects),
+		)
+	}()
+
+	for _, dc := range dcs {
+		objects = append(objects, newAlarmActionObject(dc.ManagedEntity, ignoredObjects))
+	}
+
+	for _, cluster := range clusters {
+		objects = append(objects, newAlarmActionObject(cluster.ManagedEntity, ignoredObjects))
+	}
+
+	for _, hs := range hss {
+		objects = append(objects, newAlarmActionObject(hs.ManagedEntity, ignoredObjects))
+	}
+
+	return objects
+
+}
+
+// Disabled returns the inventory objects not explicitly ignored which have
+// alarm actions disabled.
+func (objects AlarmActionObjects) Disabled() AlarmActionObjects {
+	disabled := make(AlarmActionObjects, 0, len(objects))
+	for _, obj := range objects {
+		if !obj.Ignored && !obj.Enabled {
+			disabled = append(disabled, obj)
+		}
+	}
+
+	return disabled
+}
+
+// NumDisabled returns the number of inventory objects not explicitly ignored
+// which have alarm actions disabled.
+func (objects AlarmActionObjects) NumDisabled() int {
+	return len(objects.Disabled())
+}
+
+// NumDisabledByType returns the number of inventory objects of the given
+// managed object type (e.g., HostSystem) not explicitly ignored which have
+// alarm actions disabled.
+func (objects AlarmActionObjects) NumDisabledByType(objType string) int {
+	var num int
+	for _, obj := range objects.Disabled() {
+		if obj.Type == objType {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumIgnored returns the number of inventory objects explicitly ignored.
+func (objects AlarmActionObjects) NumIgnored() int {
+	var num int
+	for _, obj := range objects {
+		if obj.Ignored {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumEvaluated returns the number of inventory objects not explicitly
+// ignored.
+func (objects AlarmActionObjects) NumEvaluated() int {
+	return len(objects) - objects.NumIgnored()
+}
+
+// HasWarningState indicates whether any inventory object not explicitly
+// ignored has alarm actions disabled.
+func (objects AlarmActionObjects) HasWarningState() bool {
+	return objects.NumDisabled() > 0
+}
+
+// AlarmActionsPerfData generates performance data metrics from the given
+// collection of evaluated inventory objects.
+func AlarmActionsPerfData(objects AlarmActionObjects) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "objects",
+			Value: fmt.Sprintf("%d", len(objects)),
+			Min:   "0",
+		},
+		{
+			Label: "objects_evaluated",
+			Value: fmt.Sprintf("%d", objects.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "objects_ignored",
+			Value: fmt.Sprintf("%d", objects.NumIgnored()),
+			Min:   "0",
+		},
+		{
+			Label: "alarm_actions_disabled",
+			Value: fmt.Sprintf("%d", objects.NumDisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "datacenters_alarm_actions_disabled",
+			Value: fmt.Sprintf("%d", objects.NumDisabledByType(MgObjRefTypeDatacenter)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_alarm_actions_disabled",
+			Value: fmt.Sprintf("%d", objects.NumDisabledByType(MgObjRefTypeClusterComputeResource)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_alarm_actions_disabled",
+			Value: fmt.Sprintf("%d", objects.NumDisabledByType(MgObjRefTypeHostSystem)),
+			Min:   "0",
+		},
+	}
+}
+
+// AlarmActionsOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func AlarmActionsOneLineCheckSummary(stateLabel string, objects AlarmActionObjects) string {
+
+	recordSummaryData(map[string]interface{}{
+		"objects": objects,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute AlarmActionsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case objects.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d objects with alarm actions disabled (evaluated %d objects)",
+			stateLabel,
+			objects.NumDisabled(),
+			objects.NumEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No objects with alarm actions disabled (evaluated %d objects)",
+			stateLabel,
+			objects.NumEvaluated(),
+		)
+	}
+}
+
+// AlarmActionsReport generates a summary of inventory objects with alarm
+// actions disabled along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func AlarmActionsReport(
+	c *vim25.Client,
+	objects AlarmActionObjects,
+	objectTypes []string,
+	ignoredObjects []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute AlarmActionsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Objects with alarm actions disabled:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	disabled := objects.Disabled()
+	switch {
+	case len(disabled) == 0:
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+
+	default:
+		for _, obj := range disabled {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (%s, %s)%s",
+				obj.Name,
+				obj.Type,
+				obj.MOID,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if objects.NumIgnored() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sIgnored objects:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, obj := range objects {
+			if !obj.Ignored {
+				continue
+			}
+
+			state := "enabled"
+			if !obj.Enabled {
+				state = "disabled"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (%s, alarm actions %s)%s",
+				obj.Name,
+				obj.Type,
+				state,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Evaluated object types (%d): [%v]%s",
+		len(objectTypes),
+		strings.Join(objectTypes, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified objects to ignore (%d): [%v]%s",
+		len(ignoredObjects),
+		strings.Join(ignoredObjects, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
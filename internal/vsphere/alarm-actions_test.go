@@ -0,0 +1,135 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestNewAlarmActionObject(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := map[string]struct {
+		setting     *bool
+		ignored     []string
+		wantEnabled bool
+		wantIgnored bool
+	}{
+		"enabled":                  {setting: &enabled, wantEnabled: true},
+		"disabled":                 {setting: &disabled},
+		"not reported":             {setting: nil, wantEnabled: true},
+		"ignored case-insensitive": {setting: &disabled, ignored: []string{"ESX1.example.com"}, wantIgnored: true},
+		"other object ignored":     {setting: &disabled, ignored: []string{"esx2.example.com"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			me := mo.ManagedEntity{
+				ExtensibleManagedObject: mo.ExtensibleManagedObject{
+					Self: types.ManagedObjectReference{Type: MgObjRefTypeHostSystem, Value: "host-1"},
+				},
+				Name:                "esx1.example.com",
+				AlarmActionsEnabled: tt.setting,
+			}
+
+			got := newAlarmActionObject(me, tt.ignored)
+
+			if got.Enabled != tt.wantEnabled || got.Ignored != tt.wantIgnored {
+				t.Errorf("want enabled %t, ignored %t; got %+v", tt.wantEnabled, tt.wantIgnored, got)
+			}
+			if got.Type != MgObjRefTypeHostSystem || got.MOID != "host-1" {
+				t.Errorf("want HostSystem host-1; got %s %s", got.Type, got.MOID)
+			}
+		})
+	}
+}
+
+func TestNewAlarmActionObjects(t *testing.T) {
+	enabled, disabled := true, false
+
+	entity := func(objType string, name string, setting *bool) mo.ManagedEntity {
+		return mo.ManagedEntity{
+			ExtensibleManagedObject: mo.ExtensibleManagedObject{
+				Self: types.ManagedObjectReference{Type: objType, Value: name},
+			},
+			Name:                name,
+			AlarmActionsEnabled: setting,
+		}
+	}
+
+	dcs := []mo.Datacenter{
+		{ManagedEntity: entity(MgObjRefTypeDatacenter, "dc1", &enabled)},
+	}
+	clusters := []mo.ClusterComputeResource{
+		{ComputeResource: mo.ComputeResource{ManagedEntity: entity(MgObjRefTypeClusterComputeResource, "cluster1", &disabled)}},
+		{ComputeResource: mo.ComputeResource{ManagedEntity: entity(MgObjRefTypeClusterComputeResource, "cluster2", nil)}},
+	}
+	hss := []mo.HostSystem{
+		{ManagedEntity: entity(MgObjRefTypeHostSystem, "esx1", &disabled)},
+		{ManagedEntity: entity(MgObjRefTypeHostSystem, "esx2", &disabled)},
+		{ManagedEntity: entity(MgObjRefTypeHostSystem, "esx3", &enabled)},
+	}
+
+	tests := map[string]struct {
+		ignored          []string
+		wantDisabled     int
+		wantHosts        int
+		wantClusters     int
+		wantEvaluated    int
+		wantWarningState bool
+	}{
+		"nothing ignored": {
+			wantDisabled:     3,
+			wantHosts:        2,
+			wantClusters:     1,
+			wantEvaluated:    6,
+			wantWarningState: true,
+		},
+		"one host ignored": {
+			ignored:          []string{"ESX1"},
+			wantDisabled:     2,
+			wantHosts:        1,
+			wantClusters:     1,
+			wantEvaluated:    5,
+			wantWarningState: true,
+		},
+		"all disabled objects ignored": {
+			ignored:       []string{"cluster1", "esx1", "esx2"},
+			wantEvaluated: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			objects := NewAlarmActionObjects(dcs, clusters, hss, tt.ignored)
+
+			if len(objects) != 6 {
+				t.Fatalf("want 6 objects; got %d", len(objects))
+			}
+
+			if got := objects.NumDisabled(); got != tt.wantDisabled {
+				t.Errorf("want %d disabled; got %d", tt.wantDisabled, got)
+			}
+			if got := objects.NumDisabledByType(MgObjRefTypeHostSystem); got != tt.wantHosts {
+				t.Errorf("want %d disabled hosts; got %d", tt.wantHosts, got)
+			}
+			if got := objects.NumDisabledByType(MgObjRefTypeClusterComputeResource); got != tt.wantClusters {
+				t.Errorf("want %d disabled clusters; got %d", tt.wantClusters, got)
+			}
+			if got := objects.NumEvaluated(); got != tt.wantEvaluated {
+				t.Errorf("want %d evaluated; got %d", tt.wantEvaluated, got)
+			}
+			if got := objects.HasWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
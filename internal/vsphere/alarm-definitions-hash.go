@@ -0,0 +1,469 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrAlarmDefinitionsChanged indicates that one or more alarm definitions
+// were added, removed or modified since the last plugin run.
+var ErrAlarmDefinitionsChanged = errors.New("alarm definitions changed since last run")
+
+// Alarm definition change types.
+const (
+	AlarmDefinitionAdded    string = "added"
+	AlarmDefinitionRemoved  string = "removed"
+	AlarmDefinitionModified string = "modified"
+)
+
+// AlarmDefinitionDigest is the checksum of a specific alarm definition.
+type AlarmDefinitionDigest struct {
+	// Name is the name of the alarm definition.
+	Name string `json:"name"`
+
+	// Hash is the SHA-256 checksum of the alarm definition.
+	Hash string `json:"hash"`
+}
+
+// AlarmDefinitionsBaseline is the checksum of all alarm definitions as
+// recorded by a plugin run.
+type AlarmDefinitionsBaseline struct {
+	// Recorded is when the baseline was recorded.
+	Recorded time.Time `json:"recorded"`
+
+	// Hash is the SHA-256 checksum of all alarm definitions.
+	Hash string `json:"hash"`
+
+	// Alarms is the checksum of each alarm definition keyed by alarm
+	// Managed Object ID.
+	Alarms map[string]AlarmDefinitionDigest `json:"alarms"`
+}
+
+// AlarmDefinitionChange is a specific alarm definition added, removed or
+// modified since the baseline.
+type AlarmDefinitionChange struct {
+	ID   string
+	Name string
+	Type string
+}
+
+// AlarmDefinitionChangeSet is the result of comparing current alarm
+// definition checksums against a stored baseline.
+type AlarmDefinitionChangeSet struct {
+	// Current is the alarm definition checksums for the current plugin run.
+	Current AlarmDefinitionsBaseline
+
+	// Baseline is the alarm definition checksums recorded by a previous
+	// plugin run. This is nil if a baseline was not previously recorded.
+	Baseline *AlarmDefinitionsBaseline
+
+	// Changes is the collection of alarm definitions added, removed or
+	// modified since the baseline.
+	Changes []AlarmDefinitionChange
+}
+
+// GetAlarmDefinitions retrieves all alarm definitions visible to the
+// service account executing the plugin.
+func GetAlarmDefinitions(ctx context.Context, c *vim25.Client) ([]mo.Alarm, error) {
+
+	funcTimeStart := time.Now()
+
+	var alarms []mo.Alarm
+
+	defer func(alarms *[]mo.Alarm) {
+		logger.Printf(
+			"It took %v to execute GetAlarmDefinitions func (and retrieve %d alarm definitions).\n",
+			time.Since(funcTimeStart),
+			len(*alarms),
+		)
+	}(&alarms)
+
+	if c.ServiceContent.AlarmManager == nil {
+		return nil, fmt.Errorf("alarm manager not available")
+	}
+
+	// If an entity is not specified, all visible alarm definitions are
+	// returned.
+	req := types.GetAlarm{
+		This: *c.ServiceContent.AlarmManager,
+	}
+
+	res, err := methods.GetAlarm(ctx, c, &req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve alarm definitions: %w", err)
+	}
+
+	if len(res.Returnval) == 0 {
+		return alarms, nil
+	}
+
+	pc := property.DefaultCollector(c)
+	if err := pc.Retrieve(ctx, res.Returnval, getAlarmPropsSubset(), &alarms); err != nil {
+		return nil, fmt.Errorf("failed to retrieve alarm definition properties: %w", err)
+	}
+
+	sort.Slice(alarms, func(i, j int) bool {
+		return alarms[i].Self.Value < alarms[j].Self.Value
+	})
+
+	return alarms, nil
+
+}
+
+// HashAlarmDefinitions generates SHA-256 checksums for each of the given
+// alarm definitions along with a checksum for the entire collection.
+func HashAlarmDefinitions(alarms []mo.Alarm) (AlarmDefinitionsBaseline, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HashAlarmDefinitions func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	current := AlarmDefinitionsBaseline{
+		Recorded: time.Now(),
+		Alarms:   make(map[string]AlarmDefinitionDigest, len(alarms)),
+	}
+
+	ids := make([]string, 0, len(alarms))
+	for _, alarm := range alarms {
+		data, err := json.Marshal(alarm.Info)
+		if err != nil {
+			return AlarmDefinitionsBaseline{}, fmt.Errorf(
+				"failed to encode alarm definition %s: %w",
+				alarm.Info.Name,
+				err,
+			)
+		}
+
+		sum := sha256.Sum256(data)
+
+		current.Alarms[alarm.Self.Value] = AlarmDefinitionDigest{
+			Name: alarm.Info.Name,
+			Hash: hex.EncodeToString(sum[:]),
+		}
+		ids = append(ids, alarm.Self.Value)
+	}
+
+	// Generate the collection checksum from the individual checksums in a
+	// stable order.
+	sort.Strings(ids)
+	h := sha256.New()
+	for _, id := range ids {
+		_, _ = fmt.Fprintf(h, "%s:%s\n", id, current.Alarms[id].Hash)
+	}
+	current.Hash = hex.EncodeToString(h.Sum(nil))
+
+	return current, nil
+
+}
+
+// LoadAlarmDefinitionsBaseline reads the alarm definitions baseline from the
+// specified state file. A nil baseline is returned if the state file does
+// not exist.
+func LoadAlarmDefinitionsBaseline(filename string) (*AlarmDefinitionsBaseline, error) {
+	data, err := readStateFile(filename)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to load alarm definitions baseline: %w", err)
+
+	case data == nil:
+		return nil, nil
+	}
+
+	var baseline AlarmDefinitionsBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse alarm definitions state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return &baseline, nil
+}
+
+// SaveAlarmDefinitionsBaseline writes the given alarm definition checksums
+// to the specified state file for use as the baseline by the next plugin
+// run.
+func SaveAlarmDefinitionsBaseline(filename string, baseline AlarmDefinitionsBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alarm definitions baseline: %w", err)
+	}
+
+	if err := writeStateFile(filename, data); err != nil {
+		return fmt.Errorf("failed to save alarm definitions baseline: %w", err)
+	}
+
+	return nil
+}
+
+// NewAlarmDefinitionChangeSet compares the current alarm definition
+// checksums against the given baseline.
+func NewAlarmDefinitionChangeSet(current AlarmDefinitionsBaseline, baseline *AlarmDefinitionsBaseline) AlarmDefinitionChangeSet {
+
+	set := AlarmDefinitionChangeSet{
+		Current:  current,
+		Baseline: baseline,
+	}
+
+	if baseline == nil || baseline.Hash == current.Hash {
+		return set
+	}
+
+	for id, digest := range current.Alarms {
+		previous, ok := baseline.Alarms[id]
+		switch {
+		case !ok:
+			set.Changes = append(set.Changes, AlarmDefinitionChange{
+				ID:   id,
+				Name: digest.Name,
+				Type: AlarmDefinitionAdded,
+			})
+
+		case previous.Hash != digest.Hash:
+			set.Changes = append(set.Changes, AlarmDefinitionChange{
+				ID:   id,
+				Name: digest.Name,
+				Type: AlarmDefinitionModified,
+			})
+		}
+	}
+
+	for id, digest := range baseline.Alarms {
+		if _, ok := current.Alarms[id]; !ok {
+			set.Changes = append(set.Changes, AlarmDefinitionChange{
+				ID:   id,
+				Name: digest.Name,
+				Type: AlarmDefinitionRemoved,
+			})
+		}
+	}
+
+	sort.Slice(set.Changes, func(i, j int) bool {
+		return strings.ToLower(set.Changes[i].Name) < strings.ToLower(set.Changes[j].Name)
+	})
+
+	return set
+
+}
+
+// HasBaseline indicates whether a baseline was available for comparison.
+func (set AlarmDefinitionChangeSet) HasBaseline() bool {
+	return set.Baseline != nil
+}
+
+// HasChanges indicates whether any alarm definitions were added, removed or
+// modified since the baseline.
+func (set AlarmDefinitionChangeSet) HasChanges() bool {
+	return len(set.Changes) > 0
+}
+
+// NumChanges returns the number of alarm definitions with the specified
+// change type.
+func (set AlarmDefinitionChangeSet) NumChanges(changeType string) int {
+	var num int
+	for _, change := range set.Changes {
+		if change.Type == changeType {
+			num++
+		}
+	}
+
+	return num
+}
+
+// AlarmDefinitionsHashPerfData generates performance data metrics from the
+// given alarm definition change evaluation results.
+func AlarmDefinitionsHashPerfData(set AlarmDefinitionChangeSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "alarm_definitions",
+			Value: fmt.Sprintf("%d", len(set.Current.Alarms)),
+			Min:   "0",
+		},
+		{
+			Label: "alarm_definitions_added",
+			Value: fmt.Sprintf("%d", set.NumChanges(AlarmDefinitionAdded)),
+			Min:   "0",
+		},
+		{
+			Label: "alarm_definitions_removed",
+			Value: fmt.Sprintf("%d", set.NumChanges(AlarmDefinitionRemoved)),
+			Min:   "0",
+		},
+		{
+			Label: "alarm_definitions_modified",
+			Value: fmt.Sprintf("%d", set.NumChanges(AlarmDefinitionModified)),
+			Min:   "0",
+		},
+	}
+}
+
+// AlarmDefinitionsHashOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func AlarmDefinitionsHashOneLineCheckSummary(
+	stateLabel string,
+	set AlarmDefinitionChangeSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute AlarmDefinitionsHashOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case !set.HasBaseline():
+		return fmt.Sprintf(
+			"%s: Alarm definitions baseline recorded (evaluated %d alarm definitions)",
+			stateLabel,
+			len(set.Current.Alarms),
+		)
+
+	case set.HasChanges():
+		return fmt.Sprintf(
+			"%s: %d alarm definitions changed since last run (%d added, %d removed, %d modified)",
+			stateLabel,
+			len(set.Changes),
+			set.NumChanges(AlarmDefinitionAdded),
+			set.NumChanges(AlarmDefinitionRemoved),
+			set.NumChanges(AlarmDefinitionModified),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No alarm definition changes detected (evaluated %d alarm definitions)",
+			stateLabel,
+			len(set.Current.Alarms),
+		)
+	}
+}
+
+// AlarmDefinitionsHashReport generates a summary of alarm definitions
+// changed since the baseline along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body of
+// many notifications.
+func AlarmDefinitionsHashReport(
+	c *vim25.Client,
+	set AlarmDefinitionChangeSet,
+	stateFile string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute AlarmDefinitionsHashReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Alarm definition changes since baseline:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, change := range set.Changes {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s): %s%s",
+			change.Name,
+			change.ID,
+			change.Type,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(set.Changes) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* State file: %s%s",
+		stateFile,
+		nagios.CheckOutputEOL,
+	)
+
+	baselineRecorded := "none (baseline recorded by this run)"
+	if set.HasBaseline() {
+		baselineRecorded = set.Baseline.Recorded.Format(time.RFC3339)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Baseline recorded: %s%s",
+		baselineRecorded,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Alarm definitions checksum: %s%s",
+		set.Current.Hash,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
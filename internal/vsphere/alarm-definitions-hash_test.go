@@ -0,0 +1,156 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func alarmDefinition(id string, name string, enabled bool) mo.Alarm {
+	return mo.Alarm{
+		ExtensibleManagedObject: mo.ExtensibleManagedObject{
+			Self: types.ManagedObjectReference{Type: "Alarm", Value: id},
+		},
+		Info: types.AlarmInfo{
+			AlarmSpec: types.AlarmSpec{Name: name, Enabled: enabled},
+			Key:       id,
+		},
+	}
+}
+
+func hashAlarmDefinitions(t *testing.T, alarms ...mo.Alarm) AlarmDefinitionsBaseline {
+	t.Helper()
+
+	baseline, err := HashAlarmDefinitions(alarms)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	return baseline
+}
+
+func TestHashAlarmDefinitions(t *testing.T) {
+	cpu := alarmDefinition("alarm-1", "Host CPU usage", true)
+	memory := alarmDefinition("alarm-2", "Host memory usage", true)
+
+	first := hashAlarmDefinitions(t, cpu, memory)
+	reordered := hashAlarmDefinitions(t, memory, cpu)
+
+	if first.Hash != reordered.Hash {
+		t.Errorf("want collection checksum independent of order; got %s and %s", first.Hash, reordered.Hash)
+	}
+
+	if len(first.Alarms) != 2 || first.Alarms["alarm-1"].Name != "Host CPU usage" {
+		t.Errorf("want checksums indexed by alarm ID; got %+v", first.Alarms)
+	}
+
+	disabled := hashAlarmDefinitions(t, cpu, alarmDefinition("alarm-2", "Host memory usage", false))
+
+	if disabled.Hash == first.Hash {
+		t.Error("want collection checksum to change when a definition changes")
+	}
+	if disabled.Alarms["alarm-1"].Hash != first.Alarms["alarm-1"].Hash {
+		t.Error("want unchanged definition checksum to be stable")
+	}
+	if disabled.Alarms["alarm-2"].Hash == first.Alarms["alarm-2"].Hash {
+		t.Error("want changed definition checksum to differ")
+	}
+}
+
+func TestNewAlarmDefinitionChangeSet(t *testing.T) {
+	cpu := alarmDefinition("alarm-1", "Host CPU usage", true)
+	memory := alarmDefinition("alarm-2", "Host memory usage", true)
+	datastore := alarmDefinition("alarm-3", "Datastore usage on disk", true)
+
+	previous := hashAlarmDefinitions(t, cpu, memory, datastore)
+
+	tests := map[string]struct {
+		current  []mo.Alarm
+		baseline *AlarmDefinitionsBaseline
+		want     []AlarmDefinitionChange
+	}{
+		"no baseline": {
+			current: []mo.Alarm{cpu, memory, datastore},
+		},
+		"unchanged": {
+			current:  []mo.Alarm{datastore, memory, cpu},
+			baseline: &previous,
+		},
+		"modified": {
+			current:  []mo.Alarm{cpu, alarmDefinition("alarm-2", "Host memory usage", false), datastore},
+			baseline: &previous,
+			want: []AlarmDefinitionChange{
+				{ID: "alarm-2", Name: "Host memory usage", Type: AlarmDefinitionModified},
+			},
+		},
+		"renamed": {
+			current:  []mo.Alarm{alarmDefinition("alarm-1", "host cpu", true), memory, datastore},
+			baseline: &previous,
+			want: []AlarmDefinitionChange{
+				{ID: "alarm-1", Name: "host cpu", Type: AlarmDefinitionModified},
+			},
+		},
+		"added and removed sorted by name": {
+			current:  []mo.Alarm{cpu, memory, alarmDefinition("alarm-4", "cluster HA failover", true)},
+			baseline: &previous,
+			want: []AlarmDefinitionChange{
+				{ID: "alarm-4", Name: "cluster HA failover", Type: AlarmDefinitionAdded},
+				{ID: "alarm-3", Name: "Datastore usage on disk", Type: AlarmDefinitionRemoved},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewAlarmDefinitionChangeSet(hashAlarmDefinitions(t, tt.current...), tt.baseline)
+
+			if got, want := set.HasBaseline(), tt.baseline != nil; got != want {
+				t.Errorf("want baseline %t; got %t", want, got)
+			}
+
+			if d := cmp.Diff(tt.want, set.Changes); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got, want := set.HasChanges(), len(tt.want) > 0; got != want {
+				t.Errorf("want changes %t; got %t", want, got)
+			}
+		})
+	}
+}
+
+func TestAlarmDefinitionsBaselineStateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "alarm-definitions.json")
+
+	missing, err := LoadAlarmDefinitionsBaseline(filename)
+	if err != nil || missing != nil {
+		t.Fatalf("want nil baseline and error for missing state file; got %+v, %v", missing, err)
+	}
+
+	current := hashAlarmDefinitions(t, alarmDefinition("alarm-1", "Host CPU usage", true))
+	if err := SaveAlarmDefinitionsBaseline(filename, current); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	loaded, err := LoadAlarmDefinitionsBaseline(filename)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if loaded.Hash != current.Hash {
+		t.Errorf("want checksum %s; got %s", current.Hash, loaded.Hash)
+	}
+	if d := cmp.Diff(current.Alarms, loaded.Alarms); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
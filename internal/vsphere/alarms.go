@@ -18,6 +18,7 @@ import (
 	"github.com/atc0005/check-vmware/internal/textutils"
 	"github.com/atc0005/go-nagios"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -49,6 +50,19 @@ type AlarmEntity struct {
 	// ResourcePool types. VirtualMachine types have one entry and
 	// ResourcePool types have two (self & parent).
 	ResourcePools []string
+
+	// VMs are the names of the VirtualMachines residing on the entity. This
+	// is only populated for Datastore entities (and only if requested).
+	VMs []string
+
+	// Tags are the vSphere Tags attached to the entity. This is only
+	// populated if filtering by vSphere Tags is requested.
+	Tags Tags
+
+	// InMaintenanceMode indicates whether the entity is an ESXi host in
+	// maintenance mode or a VirtualMachine running on such a host. This is
+	// only populated if requested.
+	InMaintenanceMode bool
 }
 
 // TriggeredAlarm represents the state of an alarm along with the affected
@@ -99,6 +113,11 @@ type TriggeredAlarm struct {
 	// acknowledged by an admin user.
 	Acknowledged bool
 
+	// MaxState is the most severe Nagios state (e.g., "warning") that the
+	// TriggeredAlarm may contribute to the overall plugin state. If not set,
+	// the state is determined solely by the alarm status.
+	MaxState string
+
 	// Exclude indicates whether the TriggeredAlarm has been excluded from
 	// final evaluation. During processing multiple filters are applied. We
 	// track exclusion state through the filtering pipeline so that any
@@ -137,7 +156,27 @@ type TriggeredAlarmFilters struct {
 	ExcludedAlarmDescriptions        []string
 	IncludedAlarmStatuses            []string
 	ExcludedAlarmStatuses            []string
+	IncludedAlarmEntityTags          []string
+	ExcludedAlarmEntityTags          []string
 	EvaluateAcknowledgedAlarms       bool
+	AcknowledgedAlarmsMaxState       string
+
+	// TriggeredSince limits evaluation to TriggeredAlarms triggered at or
+	// after this time. Ignored if the zero value.
+	TriggeredSince time.Time
+
+	// TriggeredBefore limits evaluation to TriggeredAlarms triggered at or
+	// before this time. Ignored if the zero value.
+	TriggeredBefore time.Time
+
+	// ExcludeMaintenanceMode indicates whether TriggeredAlarms associated
+	// with entities in maintenance mode are excluded from evaluation.
+	ExcludeMaintenanceMode bool
+
+	// MaintenanceModeMaxState is the most severe Nagios state (ok, warning)
+	// that TriggeredAlarms associated with entities in maintenance mode may
+	// contribute to the overall plugin state. Ignored if empty.
+	MaintenanceModeMaxState string
 }
 
 // NumExcluded returns the number of TriggeredAlarms that have been implicitly
@@ -205,6 +244,128 @@ func (tas TriggeredAlarms) CountPerDatacenter() map[string]int {
 
 }
 
+// TriggeredAlarmsSummary is a breakdown of triggered alarm counts by
+// severity (overall status) and acknowledgement state, either for all
+// triggered alarms in the collection or for a specific Datacenter.
+type TriggeredAlarmsSummary struct {
+
+	// Datacenter is the name of the Datacenter the counts apply to. This is
+	// empty if the counts apply to all Datacenters.
+	Datacenter string
+
+	// Total is the number of triggered alarms.
+	Total int
+
+	// Red is the number of triggered alarms with a red status.
+	Red int
+
+	// Yellow is the number of triggered alarms with a yellow status.
+	Yellow int
+
+	// Gray is the number of triggered alarms with a gray status.
+	Gray int
+
+	// Acknowledged is the number of acknowledged triggered alarms.
+	Acknowledged int
+
+	// Unacknowledged is the number of unacknowledged triggered alarms.
+	Unacknowledged int
+}
+
+// add records the given TriggeredAlarm in the summary counts.
+func (tasum *TriggeredAlarmsSummary) add(ta TriggeredAlarm) {
+	tasum.Total++
+
+	switch ta.OverallStatus {
+	case types.ManagedEntityStatusRed:
+		tasum.Red++
+	case types.ManagedEntityStatusYellow:
+		tasum.Yellow++
+	case types.ManagedEntityStatusGray:
+		tasum.Gray++
+	}
+
+	if ta.Acknowledged {
+		tasum.Acknowledged++
+	} else {
+		tasum.Unacknowledged++
+	}
+}
+
+// Summary returns a breakdown of triggered alarm counts by severity and
+// acknowledgement state for all triggered alarms in the collection. Filter
+// settings are not applied.
+func (tas TriggeredAlarms) Summary() TriggeredAlarmsSummary {
+	var summary TriggeredAlarmsSummary
+
+	for i := range tas {
+		summary.add(tas[i])
+	}
+
+	return summary
+}
+
+// SummaryPerDatacenter returns a breakdown of triggered alarm counts by
+// severity and acknowledgement state for each Datacenter with triggered
+// alarms. Filter settings are not applied. Results are sorted by Datacenter
+// name.
+func (tas TriggeredAlarms) SummaryPerDatacenter() []TriggeredAlarmsSummary {
+
+	dcIdx := make(map[string]int)
+	summaries := make([]TriggeredAlarmsSummary, 0, len(tas.CountPerDatacenter()))
+
+	for i := range tas {
+		idx, ok := dcIdx[tas[i].Datacenter]
+		if !ok {
+			summaries = append(summaries, TriggeredAlarmsSummary{
+				Datacenter: tas[i].Datacenter,
+			})
+			idx = len(summaries) - 1
+			dcIdx[tas[i].Datacenter] = idx
+		}
+
+		summaries[idx].add(tas[i])
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return strings.ToLower(summaries[i].Datacenter) < strings.ToLower(summaries[j].Datacenter)
+	})
+
+	return summaries
+}
+
+// PerfData returns performance data metrics for the triggered alarm counts
+// of each Datacenter in the collection broken down by severity and
+// acknowledgement state.
+func (tas TriggeredAlarms) PerfData() []nagios.PerformanceData {
+	summaries := tas.SummaryPerDatacenter()
+	pd := make([]nagios.PerformanceData, 0, len(summaries)*6)
+
+	for _, summary := range summaries {
+		metrics := []struct {
+			label string
+			value int
+		}{
+			{label: "triggered_alarms", value: summary.Total},
+			{label: "triggered_alarms_red", value: summary.Red},
+			{label: "triggered_alarms_yellow", value: summary.Yellow},
+			{label: "triggered_alarms_gray", value: summary.Gray},
+			{label: "triggered_alarms_acknowledged", value: summary.Acknowledged},
+			{label: "triggered_alarms_unacknowledged", value: summary.Unacknowledged},
+		}
+
+		for _, metric := range metrics {
+			pd = append(pd, nagios.PerformanceData{
+				Label: PerfDataLabel(summary.Datacenter, metric.label),
+				Value: fmt.Sprintf("%d", metric.value),
+				Min:   "0",
+			})
+		}
+	}
+
+	return pd
+}
+
 // Keys returns a list of TriggeredAlarm keys or unique identifiers associated
 // with each TriggeredAlarm in the collection. If specified, keys are also
 // returned for acknowledged triggered alarms. Keys are returned in ascending
@@ -370,7 +531,7 @@ func (tas TriggeredAlarms) HasCriticalState(evalExcluded bool) bool {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateCRITICALExitCode {
 				hasCriticalState = true
 			}
@@ -409,7 +570,7 @@ func (tas TriggeredAlarms) NumCriticalState(evalExcluded bool) int {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateCRITICALExitCode {
 				numCriticalState++
 			}
@@ -454,7 +615,7 @@ func (tas TriggeredAlarms) HasWarningState(evalExcluded bool) bool {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateWARNINGExitCode {
 				hasWarningState = true
 			}
@@ -493,7 +654,7 @@ func (tas TriggeredAlarms) NumWarningState(evalExcluded bool) int {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateWARNINGExitCode {
 				numWarningState++
 			}
@@ -539,7 +700,7 @@ func (tas TriggeredAlarms) HasUnknownState(evalExcluded bool) bool {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateUNKNOWNExitCode {
 				hasUnknownState = true
 			}
@@ -578,7 +739,7 @@ func (tas TriggeredAlarms) NumUnknownState(evalExcluded bool) int {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateUNKNOWNExitCode {
 				numUnknownState++
 			}
@@ -634,7 +795,7 @@ func (tas TriggeredAlarms) NumOKState(evalExcluded bool) int {
 		case tas[i].Exclude && !evalExcluded:
 			continue
 		default:
-			_, exitCode := EntityStatusToNagiosState(tas[i].OverallStatus)
+			_, exitCode := tas[i].NagiosState()
 			if exitCode == nagios.StateOKExitCode {
 				numOKState++
 			}
@@ -885,6 +1046,60 @@ func EntityStatusToNagiosState(entityStatus types.ManagedEntityStatus) (string,
 
 }
 
+// nagiosStateSeverity returns a rank for the given Nagios state exit code,
+// ordered by how the overall state for a collection of TriggeredAlarms is
+// determined (CRITICAL, WARNING, UNKNOWN then OK).
+func nagiosStateSeverity(exitCode int) int {
+	switch exitCode {
+	case nagios.StateCRITICALExitCode:
+		return 3
+	case nagios.StateWARNINGExitCode:
+		return 2
+	case nagios.StateUNKNOWNExitCode:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// nagiosStateKeywordToState converts a Nagios state keyword (e.g.,
+// "warning") to a Nagios state label and exit code. The boolean return value
+// indicates whether the keyword is recognized.
+func nagiosStateKeywordToState(keyword string) (string, int, bool) {
+	switch {
+	case strings.EqualFold(keyword, nagios.StateOKLabel):
+		return nagios.StateOKLabel, nagios.StateOKExitCode, true
+	case strings.EqualFold(keyword, nagios.StateWARNINGLabel):
+		return nagios.StateWARNINGLabel, nagios.StateWARNINGExitCode, true
+	case strings.EqualFold(keyword, nagios.StateCRITICALLabel):
+		return nagios.StateCRITICALLabel, nagios.StateCRITICALExitCode, true
+	default:
+		return "", 0, false
+	}
+}
+
+// NagiosState returns the Nagios state label and exit code for the
+// TriggeredAlarm. The state is determined by the alarm status and is limited
+// to the most severe state permitted by MaxState (if set).
+func (ta TriggeredAlarm) NagiosState() (string, int) {
+	stateLabel, exitCode := EntityStatusToNagiosState(ta.OverallStatus)
+
+	if ta.MaxState == "" {
+		return stateLabel, exitCode
+	}
+
+	maxLabel, maxExitCode, ok := nagiosStateKeywordToState(ta.MaxState)
+	if !ok {
+		return stateLabel, exitCode
+	}
+
+	if nagiosStateSeverity(exitCode) > nagiosStateSeverity(maxExitCode) {
+		return maxLabel, maxExitCode
+	}
+
+	return stateLabel, exitCode
+}
+
 // getSubstringFilterKeywords is a helper function that returns a map of all
 // valid keywords used by the TriggeredAlarms.filterByString method.
 // func getSubstringFilterKeywords() map[string]struct{} {
@@ -900,7 +1115,18 @@ func EntityStatusToNagiosState(entityStatus types.ManagedEntityStatus) (string,
 func (tas *TriggeredAlarms) Filter(filters TriggeredAlarmFilters) {
 
 	logger.Println("Filtering triggered alarms by acknowledged state")
-	tas.FilterByAcknowledgedState(filters.EvaluateAcknowledgedAlarms)
+	tas.FilterByAcknowledgedState(
+		filters.EvaluateAcknowledgedAlarms || filters.AcknowledgedAlarmsMaxState != "",
+	)
+
+	logger.Println("Limiting state of acknowledged triggered alarms")
+	tas.SetAcknowledgedMaxState(filters.AcknowledgedAlarmsMaxState)
+
+	logger.Println("Filtering triggered alarms by age")
+	tas.filterByAge(filters.TriggeredSince, filters.TriggeredBefore)
+
+	logger.Println("Filtering triggered alarms by entity maintenance mode")
+	tas.filterByMaintenanceMode(filters.ExcludeMaintenanceMode, filters.MaintenanceModeMaxState)
 
 	logger.Println("Filtering triggered alarms by entity type")
 	tas.filterByEntityType(filters.IncludedAlarmEntityTypes, filters.ExcludedAlarmEntityTypes)
@@ -920,6 +1146,9 @@ func (tas *TriggeredAlarms) Filter(filters TriggeredAlarmFilters) {
 	logger.Println("Filtering triggered alarms by entity resource pool")
 	tas.filterByEntityResourcePool(filters.IncludedAlarmEntityResourcePools, filters.ExcludedAlarmEntityResourcePools)
 
+	logger.Println("Filtering triggered alarms by entity tag")
+	tas.filterByEntityTag(filters.IncludedAlarmEntityTags, filters.ExcludedAlarmEntityTags)
+
 }
 
 // FilterByIncludedEntityType accepts a slice of entity type keywords to use
@@ -1183,7 +1412,7 @@ func (tas *TriggeredAlarms) filterByEntityResourcePool(include []string, exclude
 					// TriggeredAlarm matches one of the provided Resource
 					// Pool names to compare against mark the TriggeredAlarm
 					// as explicitly included.
-					case textutils.InList((*tas)[i].Entity.ResourcePools[j], include, true):
+					case inPatternList((*tas)[i].Entity.ResourcePools[j], include):
 
 						// Don't explicitly *include* the TriggeredAlarm if
 						// the TriggeredAlarm has already been explicitly
@@ -1231,7 +1460,7 @@ func (tas *TriggeredAlarms) filterByEntityResourcePool(include []string, exclude
 				// no implicit inclusions are applied for non-matching alarm
 				// types as that could unintentionally flip the results from
 				// earlier filtering stages.
-				if textutils.InList((*tas)[i].Entity.ResourcePools[j], exclude, true) {
+				if inPatternList((*tas)[i].Entity.ResourcePools[j], exclude) {
 					(*tas)[i].Exclude = true
 					(*tas)[i].ExcludeReason = alarmExcludeReasonEntityResourcePool
 					(*tas)[i].ExplicitlyExcluded = true
@@ -1292,6 +1521,155 @@ func (tas *TriggeredAlarms) FilterByAcknowledgedState(includeAcknowledged bool)
 
 }
 
+// SetAcknowledgedMaxState accepts a Nagios state keyword (e.g., "warning")
+// used to limit the state that previously acknowledged TriggeredAlarms may
+// contribute to the overall plugin state. Acknowledged TriggeredAlarms
+// remain listed (unless excluded by a filter), but are treated as "known,
+// still degraded". If an empty value is provided, no changes are made.
+func (tas *TriggeredAlarms) SetAcknowledgedMaxState(maxState string) {
+
+	if maxState == "" {
+		return
+	}
+
+	for i := range *tas {
+		if (*tas)[i].Acknowledged {
+			(*tas)[i].MaxState = maxState
+		}
+	}
+
+}
+
+// FilterByTriggeredSince accepts a point in time used to explicitly exclude
+// TriggeredAlarms triggered before that time. This is useful for only
+// alerting on recent alarms.
+func (tas *TriggeredAlarms) FilterByTriggeredSince(since time.Time) {
+	tas.filterByAge(since, time.Time{})
+}
+
+// FilterByTriggeredBefore accepts a point in time used to explicitly exclude
+// TriggeredAlarms triggered after that time. This is useful for providing a
+// grace period for brand-new alarms.
+func (tas *TriggeredAlarms) FilterByTriggeredBefore(before time.Time) {
+	tas.filterByAge(time.Time{}, before)
+}
+
+// filterByAge uses the given time window to explicitly mark TriggeredAlarm
+// values for exclusion from the final evaluation. TriggeredAlarms triggered
+// before the since value or after the before value are excluded. A zero
+// value for either bound disables that side of the window.
+func (tas *TriggeredAlarms) filterByAge(since time.Time, before time.Time) {
+
+	funcTimeStart := time.Now()
+
+	// Collect number of non-excluded TriggeredAlarms at the start of this
+	// filtering process. We'll collect this number again after filtering has
+	// been applied in order to show the results of this filter.
+	nonExcludedStart := len(*tas) - tas.NumExcluded()
+
+	defer func(start *int) {
+		logger.Printf(
+			"It took %v to execute filterByAge func (for %d non-excluded TriggeredAlarms, yielding %d non-excluded TriggeredAlarms)\n",
+			time.Since(funcTimeStart),
+			*start,
+			len(*tas)-tas.NumExcluded(),
+		)
+	}(&nonExcludedStart)
+
+	switch {
+	// if the collection of TriggeredAlarms is empty, skip filtering attempts.
+	case len(*tas) == 0:
+		logger.Println("Triggered Alarms list is empty, aborting")
+		return
+
+	// if we're not limiting TriggeredAlarms by age, skip filtering attempts.
+	case since.IsZero() && before.IsZero():
+		logger.Println("Triggered Alarms time window not specified, aborting")
+		return
+	}
+
+	for i := range *tas {
+
+		triggered := (*tas)[i].Time
+
+		tooOld := !since.IsZero() && triggered.Before(since)
+		tooNew := !before.IsZero() && triggered.After(before)
+
+		if tooOld || tooNew {
+			(*tas)[i].Exclude = true
+			(*tas)[i].ExcludeReason = alarmExcludeReasonAlarmAge
+			(*tas)[i].ExplicitlyExcluded = true
+			(*tas)[i].logExcluded(true)
+		}
+
+	}
+
+}
+
+// filterByMaintenanceMode either explicitly excludes TriggeredAlarms
+// associated with entities in maintenance mode or limits the state that
+// those TriggeredAlarms may contribute to the overall plugin state. The
+// maintenance mode state of entities is expected to have been previously
+// retrieved.
+func (tas *TriggeredAlarms) filterByMaintenanceMode(exclude bool, maxState string) {
+
+	funcTimeStart := time.Now()
+
+	// Collect number of non-excluded TriggeredAlarms at the start of this
+	// filtering process. We'll collect this number again after filtering has
+	// been applied in order to show the results of this filter.
+	nonExcludedStart := len(*tas) - tas.NumExcluded()
+
+	defer func(start *int) {
+		logger.Printf(
+			"It took %v to execute filterByMaintenanceMode func (for %d non-excluded TriggeredAlarms, yielding %d non-excluded TriggeredAlarms)\n",
+			time.Since(funcTimeStart),
+			*start,
+			len(*tas)-tas.NumExcluded(),
+		)
+	}(&nonExcludedStart)
+
+	switch {
+	// if the collection of TriggeredAlarms is empty, skip filtering attempts.
+	case len(*tas) == 0:
+		logger.Println("Triggered Alarms list is empty, aborting")
+		return
+
+	// if we're not handling maintenance mode entities specially, skip
+	// filtering attempts.
+	case !exclude && maxState == "":
+		logger.Println("Triggered Alarms maintenance mode handling not requested, aborting")
+		return
+	}
+
+	_, maxExitCode, _ := nagiosStateKeywordToState(maxState)
+
+	for i := range *tas {
+
+		if !(*tas)[i].Entity.InMaintenanceMode {
+			continue
+		}
+
+		switch {
+		case exclude:
+			(*tas)[i].Exclude = true
+			(*tas)[i].ExcludeReason = alarmExcludeReasonMaintenanceMode
+			(*tas)[i].ExplicitlyExcluded = true
+			(*tas)[i].logExcluded(true)
+
+		default:
+			// Retain a previously applied max state if it is more
+			// restrictive than the maintenance mode max state.
+			_, currentExitCode, ok := nagiosStateKeywordToState((*tas)[i].MaxState)
+			if !ok || nagiosStateSeverity(maxExitCode) < nagiosStateSeverity(currentExitCode) {
+				(*tas)[i].MaxState = maxState
+			}
+		}
+
+	}
+
+}
+
 // FilterByIncludedNameSubstring accepts a slice of substrings to use in
 // comparisons against TriggeredAlarm names. For any matches, the
 // TriggeredAlarm is marked as explicitly included. This will prevent later
@@ -1475,9 +1853,9 @@ func (tas *TriggeredAlarms) filterBySubstring(fieldKeyword string, include []str
 				switch {
 
 				// Attempt literal, case-insensitive match first then attempt
-				// substring, case-insensitive match.
-				case strings.EqualFold(substrField, substr) ||
-					strings.Contains(substrField, substr):
+				// substring, case-insensitive match (or pattern match if
+				// requested).
+				case matchesSubstringFilter(substrField, substr):
 
 					// Don't explicitly *include* the TriggeredAlarm if the
 					// TriggeredAlarm has already been explicitly *excluded*.
@@ -1508,8 +1886,7 @@ func (tas *TriggeredAlarms) filterBySubstring(fieldKeyword string, include []str
 				// no implicit inclusions are applied for non-matching alarm
 				// types as that could unintentionally flip the results from
 				// earlier filtering stages.
-				if strings.EqualFold(substrField, substr) ||
-					strings.Contains(substrField, substr) {
+				if matchesSubstringFilter(substrField, substr) {
 					(*tas)[i].Exclude = true
 					(*tas)[i].ExcludeReason = excludeReason
 					(*tas)[i].ExplicitlyExcluded = true
@@ -1697,6 +2074,11 @@ func AlarmsOneLineCheckSummary(
 	datacentersEvaluated []string,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"triggeredAlarms":      triggeredAlarms,
+		"datacentersEvaluated": datacentersEvaluated,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1726,6 +2108,340 @@ func AlarmsOneLineCheckSummary(
 	}
 }
 
+// FilterByIncludedEntityTag accepts a slice of vSphere Tag specifications to
+// use in comparison against the tags attached to the entity associated with
+// a TriggeredAlarm. For any matches, the TriggeredAlarm is marked as
+// explicitly included. This will prevent later filtering from implicitly
+// excluding the TriggeredAlarm, but will not stop explicit exclusions from
+// "dropping" the TriggeredAlarm from further evaluation in the filtering
+// pipeline. Entity tags are expected to have been previously retrieved.
+func (tas *TriggeredAlarms) FilterByIncludedEntityTag(include []string) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FilterByIncludedEntityTag func for %d tags\n",
+			time.Since(funcTimeStart),
+			len(include),
+		)
+	}()
+
+	tas.filterByEntityTag(include, []string{})
+
+}
+
+// FilterByExcludedEntityTag accepts a slice of vSphere Tag specifications to
+// use in comparison against the tags attached to the entity associated with
+// a TriggeredAlarm. For any matches, the TriggeredAlarm is marked as
+// explicitly excluded. This will result in "dropping" the TriggeredAlarm
+// from further evaluation in the filtering pipeline. Entity tags are
+// expected to have been previously retrieved.
+func (tas *TriggeredAlarms) FilterByExcludedEntityTag(exclude []string) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FilterByExcludedEntityTag func for %d tags\n",
+			time.Since(funcTimeStart),
+			len(exclude),
+		)
+	}()
+
+	tas.filterByEntityTag([]string{}, exclude)
+
+}
+
+// filterByEntityTag accepts slices of vSphere Tag specifications to use in
+// comparison against the tags attached to the entity associated with a
+// TriggeredAlarm. This is done to explicitly mark TriggeredAlarm values for
+// inclusion or exclusion in the final evaluation. Flag evaluation logic
+// prevents sysadmins from providing both an inclusion and exclusion list.
+func (tas *TriggeredAlarms) filterByEntityTag(include []string, exclude []string) {
+
+	funcTimeStart := time.Now()
+
+	// Collect number of non-excluded TriggeredAlarms at the start of this
+	// filtering process. We'll collect this number again after filtering has
+	// been applied in order to show the results of this filter.
+	nonExcludedStart := len(*tas) - tas.NumExcluded()
+
+	defer func(start *int) {
+		logger.Printf(
+			"It took %v to execute filterByEntityTag func (for %d non-excluded TriggeredAlarms, yielding %d non-excluded TriggeredAlarms)\n",
+			time.Since(funcTimeStart),
+			*start,
+			len(*tas)-tas.NumExcluded(),
+		)
+	}(&nonExcludedStart)
+
+	switch {
+	// if the collection of TriggeredAlarms is empty, skip filtering attempts.
+	case len(*tas) == 0:
+		logger.Println("Triggered Alarms list is empty, aborting")
+		return
+
+	// if we're not limiting TriggeredAlarms by entity tag, skip filtering
+	// attempts.
+	case len(include) == 0 && len(exclude) == 0:
+		logger.Println("Triggered Alarms entity tag inclusion and exclusion lists are empty, aborting")
+		return
+	}
+
+	switch {
+	case len(include) > 0:
+		logger.Printf(
+			"Include list provided; explicitly marking TriggeredAlarms for inclusion for %d specified tags",
+			len(include),
+		)
+
+	case len(exclude) > 0:
+		logger.Printf(
+			"Exclude list provided; explicitly marking TriggeredAlarms for exclusion for %d specified tags",
+			len(exclude),
+		)
+	}
+
+	for i := range *tas {
+
+		switch {
+
+		case len(include) > 0:
+
+			switch {
+
+			// If any tag attached to the entity associated with the
+			// TriggeredAlarm matches one of the provided tag specifications
+			// mark the TriggeredAlarm as explicitly included.
+			case (*tas)[i].Entity.Tags.MatchesAny(include):
+
+				// Don't explicitly *include* the TriggeredAlarm if the
+				// TriggeredAlarm has already been explicitly *excluded*.
+				if !(*tas)[i].ExplicitlyExcluded {
+					(*tas)[i].Exclude = false
+					(*tas)[i].ExplicitlyIncluded = true
+					(*tas)[i].logIncluded(true)
+				}
+
+			// if not explicitly included by another filter in the pipeline,
+			// implicitly mark as excluded
+			default:
+				if !(*tas)[i].ExplicitlyIncluded {
+					(*tas)[i].Exclude = true
+					(*tas)[i].ExcludeReason = alarmExcludeReasonEntityTag
+					(*tas)[i].logExcluded(false)
+				}
+			}
+
+		case len(exclude) > 0:
+
+			// explicitly excluded
+			//
+			// no implicit inclusions are applied for non-matching entities
+			// as that could unintentionally flip the results from earlier
+			// filtering stages.
+			if (*tas)[i].Entity.Tags.MatchesAny(exclude) {
+				(*tas)[i].Exclude = true
+				(*tas)[i].ExcludeReason = alarmExcludeReasonEntityTag
+				(*tas)[i].ExplicitlyExcluded = true
+				(*tas)[i].logExcluded(true)
+			}
+
+		}
+	}
+
+}
+
+// SetEntityTags uses the given vSphere Automation API client to retrieve
+// the vSphere Tags attached to the entity associated with each
+// TriggeredAlarm. This is required prior to filtering TriggeredAlarms by
+// entity tag. Tags for each entity are retrieved once regardless of the
+// number of TriggeredAlarms for that entity.
+func (tas *TriggeredAlarms) SetEntityTags(ctx context.Context, rc *rest.Client) error {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SetEntityTags func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if len(*tas) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	entities := make([]types.ManagedObjectReference, 0, len(*tas))
+	for i := range *tas {
+		moid := (*tas)[i].Entity.MOID
+		if _, ok := seen[moid.Value]; ok {
+			continue
+		}
+		seen[moid.Value] = struct{}{}
+		entities = append(entities, moid)
+	}
+
+	entityTags, err := GetAttachedTags(ctx, rc, entities)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to retrieve tags for triggered alarm entities: %w",
+			err,
+		)
+	}
+
+	for i := range *tas {
+		(*tas)[i].Entity.Tags = entityTags[(*tas)[i].Entity.MOID.Value]
+	}
+
+	return nil
+}
+
+// SetEntityMaintenanceMode records whether the entity associated with each
+// TriggeredAlarm is an ESXi host in maintenance mode or a VirtualMachine
+// running on such a host. This is required prior to filtering
+// TriggeredAlarms by entity maintenance mode.
+func (tas *TriggeredAlarms) SetEntityMaintenanceMode(ctx context.Context, c *vim25.Client) error {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SetEntityMaintenanceMode func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if len(*tas) == 0 {
+		return nil
+	}
+
+	hss, err := GetHostSystems(ctx, c, true)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to retrieve hosts for triggered alarms: %w",
+			err,
+		)
+	}
+
+	hostsInMM := make(map[string]bool, len(hss))
+	for i := range hss {
+		hostsInMM[hss[i].Self.Value] = hss[i].Runtime.InMaintenanceMode
+	}
+
+	var vmEntities bool
+	for i := range *tas {
+		if (*tas)[i].Entity.MOID.Type == MgObjRefTypeVirtualMachine {
+			vmEntities = true
+			break
+		}
+	}
+
+	vmsInMM := make(map[string]bool)
+	if vmEntities {
+		vms, err := GetVMs(ctx, c, true)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to retrieve VMs for triggered alarms: %w",
+				err,
+			)
+		}
+
+		for i := range vms {
+			if vms[i].Runtime.Host == nil {
+				continue
+			}
+			vmsInMM[vms[i].Self.Value] = hostsInMM[vms[i].Runtime.Host.Value]
+		}
+	}
+
+	for i := range *tas {
+		moid := (*tas)[i].Entity.MOID
+
+		switch moid.Type {
+		case MgObjRefTypeHostSystem:
+			(*tas)[i].Entity.InMaintenanceMode = hostsInMM[moid.Value]
+		case MgObjRefTypeVirtualMachine:
+			(*tas)[i].Entity.InMaintenanceMode = vmsInMM[moid.Value]
+		}
+	}
+
+	return nil
+}
+
+// SetDatastoreEntityVMs records the names of the VirtualMachines residing
+// on the affected datastore for each non-excluded TriggeredAlarm associated
+// with a Datastore entity. The VMs for each datastore are retrieved once
+// regardless of the number of TriggeredAlarms for that datastore.
+func (tas *TriggeredAlarms) SetDatastoreEntityVMs(ctx context.Context, c *vim25.Client) error {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SetDatastoreEntityVMs func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	dsVMNames := make(map[string][]string)
+	for i := range *tas {
+		if (*tas)[i].Exclude || (*tas)[i].Entity.MOID.Type != MgObjRefTypeDatastore {
+			continue
+		}
+		dsVMNames[(*tas)[i].Entity.MOID.Value] = nil
+	}
+
+	if len(dsVMNames) == 0 {
+		return nil
+	}
+
+	dss, err := GetDatastores(ctx, c, true)
+	if err != nil {
+		return fmt.Errorf(
+			"failed to retrieve datastores for triggered alarms: %w",
+			err,
+		)
+	}
+
+	for dsID := range dsVMNames {
+		ds, _, err := FilterDatastoresByID(dss, dsID)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to retrieve datastore for triggered alarm: %w",
+				err,
+			)
+		}
+
+		vms, _, err := GetVMsFromDatastore(ctx, c, ds, true)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to retrieve VMs for datastore %s: %w",
+				ds.Name,
+				err,
+			)
+		}
+
+		names := make([]string, 0, len(vms))
+		for _, vm := range vms {
+			names = append(names, vm.Name)
+		}
+		dsVMNames[dsID] = names
+	}
+
+	for i := range *tas {
+		if names, ok := dsVMNames[(*tas)[i].Entity.MOID.Value]; ok &&
+			!(*tas)[i].Exclude &&
+			(*tas)[i].Entity.MOID.Type == MgObjRefTypeDatastore {
+			(*tas)[i].Entity.VMs = names
+		}
+	}
+
+	return nil
+}
+
 // AlarmsReport generates a summary of detected alarms along with various
 // verbose details intended to aid in troubleshooting check results at a
 // glance. This information is provided for use with the Long Service Output
@@ -1773,15 +2489,34 @@ func AlarmsReport(
 			// only look at non-excluded alarms
 			if !triggeredAlarms[i].Exclude {
 				alarmCtr++
+				var acknowledged string
+				if triggeredAlarms[i].Acknowledged {
+					acknowledged = fmt.Sprintf(
+						" [acknowledged by %s]",
+						triggeredAlarms[i].AcknowledgedByUser,
+					)
+				}
+
 				_, _ = fmt.Fprintf(
 					&report,
-					"* (%.2d) %s (type %s): %s%s",
+					"* (%.2d) %s (type %s): %s%s%s",
 					alarmCtr,
 					triggeredAlarms[i].Entity.Name,
 					triggeredAlarms[i].Entity.MOID.Type,
 					triggeredAlarms[i].Name,
+					acknowledged,
 					nagios.CheckOutputEOL,
 				)
+
+				if triggeredAlarms[i].Entity.VMs != nil {
+					_, _ = fmt.Fprintf(
+						&report,
+						"** VMs on datastore (%d): [%v]%s",
+						len(triggeredAlarms[i].Entity.VMs),
+						strings.Join(triggeredAlarms[i].Entity.VMs, ", "),
+						nagios.CheckOutputEOL,
+					)
+				}
 			}
 		}
 
@@ -1866,10 +2601,55 @@ func AlarmsReport(
 	_, _ = fmt.Fprintf(
 		&report,
 		"* Acknowledged Triggered Alarms evaluated: %t%s",
-		triggeredAlarmFilters.EvaluateAcknowledgedAlarms,
+		triggeredAlarmFilters.EvaluateAcknowledgedAlarms ||
+			triggeredAlarmFilters.AcknowledgedAlarmsMaxState != "",
 		nagios.CheckOutputEOL,
 	)
 
+	if triggeredAlarmFilters.AcknowledgedAlarmsMaxState != "" {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Acknowledged Triggered Alarms max state: %s%s",
+			strings.ToUpper(triggeredAlarmFilters.AcknowledgedAlarmsMaxState),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if !triggeredAlarmFilters.TriggeredSince.IsZero() {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Triggered Alarms triggered since: %s%s",
+			triggeredAlarmFilters.TriggeredSince.Format(time.RFC3339),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if !triggeredAlarmFilters.TriggeredBefore.IsZero() {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Triggered Alarms triggered before: %s%s",
+			triggeredAlarmFilters.TriggeredBefore.Format(time.RFC3339),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	switch {
+	case triggeredAlarmFilters.ExcludeMaintenanceMode:
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Triggered Alarms for entities in maintenance mode: excluded%s",
+			nagios.CheckOutputEOL,
+		)
+
+	case triggeredAlarmFilters.MaintenanceModeMaxState != "":
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Triggered Alarms for entities in maintenance mode max state: %s%s",
+			strings.ToUpper(triggeredAlarmFilters.MaintenanceModeMaxState),
+			nagios.CheckOutputEOL,
+		)
+	}
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"* Triggered Alarms to explicitly include%s",
@@ -1900,6 +2680,14 @@ func AlarmsReport(
 		nagios.CheckOutputEOL,
 	)
 
+	_, _ = fmt.Fprintf(
+		&report,
+		"** entity tags (%d): [%v]%s",
+		len(triggeredAlarmFilters.IncludedAlarmEntityTags),
+		strings.Join(triggeredAlarmFilters.IncludedAlarmEntityTags, ", "),
+		nagios.CheckOutputEOL,
+	)
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"** names (%d): [%v]%s",
@@ -1954,6 +2742,14 @@ func AlarmsReport(
 		nagios.CheckOutputEOL,
 	)
 
+	_, _ = fmt.Fprintf(
+		&report,
+		"** entity tags (%d): [%v]%s",
+		len(triggeredAlarmFilters.ExcludedAlarmEntityTags),
+		strings.Join(triggeredAlarmFilters.ExcludedAlarmEntityTags, ", "),
+		nagios.CheckOutputEOL,
+	)
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"** names (%d): [%v]%s",
@@ -2002,6 +2798,27 @@ func AlarmsReport(
 		nagios.CheckOutputEOL,
 	)
 
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Triggered Alarms per Datacenter (all, unfiltered)%s",
+		nagios.CheckOutputEOL,
+	)
+
+	for _, summary := range triggeredAlarms.SummaryPerDatacenter() {
+		_, _ = fmt.Fprintf(
+			&report,
+			"** %s: %d (red: %d, yellow: %d, gray: %d, acknowledged: %d, unacknowledged: %d)%s",
+			summary.Datacenter,
+			summary.Total,
+			summary.Red,
+			summary.Yellow,
+			summary.Gray,
+			summary.Acknowledged,
+			summary.Unacknowledged,
+			nagios.CheckOutputEOL,
+		)
+	}
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"* Resource Pools with Triggered Alarms (%d): [%v]%s",
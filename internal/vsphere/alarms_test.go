@@ -0,0 +1,591 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestTriggeredAlarmsFilterByAge(t *testing.T) {
+
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	// triggeredAlarms returns a collection of TriggeredAlarms triggered at
+	// the given offsets from the reference time.
+	triggeredAlarms := func(offsets ...time.Duration) TriggeredAlarms {
+		tas := make(TriggeredAlarms, 0, len(offsets))
+		for _, offset := range offsets {
+			tas = append(tas, TriggeredAlarm{
+				Name: offset.String(),
+				Time: now.Add(offset),
+			})
+		}
+
+		return tas
+	}
+
+	// setup table tests
+	tests := []struct {
+
+		// testName is the human readable name of the test case
+		testName string
+
+		// alarms is the collection of TriggeredAlarms to filter
+		alarms TriggeredAlarms
+
+		// since is the start of the time window; zero disables it
+		since time.Time
+
+		// before is the end of the time window; zero disables it
+		before time.Time
+
+		// wantIncluded is the collection of TriggeredAlarm names expected to
+		// remain after filtering
+		wantIncluded []string
+	}{
+		{
+			testName:     "since only",
+			alarms:       triggeredAlarms(-3*time.Hour, -time.Hour, -time.Minute),
+			since:        now.Add(-2 * time.Hour),
+			wantIncluded: []string{"-1h0m0s", "-1m0s"},
+		},
+		{
+			testName:     "before only",
+			alarms:       triggeredAlarms(-3*time.Hour, -time.Hour, -time.Minute),
+			before:       now.Add(-30 * time.Minute),
+			wantIncluded: []string{"-3h0m0s", "-1h0m0s"},
+		},
+		{
+			testName:     "since and before",
+			alarms:       triggeredAlarms(-3*time.Hour, -time.Hour, -time.Minute),
+			since:        now.Add(-2 * time.Hour),
+			before:       now.Add(-30 * time.Minute),
+			wantIncluded: []string{"-1h0m0s"},
+		},
+		{
+			testName:     "no time window",
+			alarms:       triggeredAlarms(-3*time.Hour, -time.Hour, -time.Minute),
+			wantIncluded: []string{"-3h0m0s", "-1h0m0s", "-1m0s"},
+		},
+		{
+			testName:     "empty time window",
+			alarms:       triggeredAlarms(-3*time.Hour, -time.Hour, -time.Minute),
+			since:        now.Add(-2 * time.Hour),
+			before:       now.Add(-2 * time.Hour),
+			wantIncluded: []string{},
+		},
+		{
+			testName:     "no triggered alarms",
+			alarms:       TriggeredAlarms{},
+			since:        now.Add(-2 * time.Hour),
+			before:       now.Add(-30 * time.Minute),
+			wantIncluded: []string{},
+		},
+		{
+			testName:     "since boundary is inclusive",
+			alarms:       triggeredAlarms(-2*time.Hour-time.Nanosecond, -2*time.Hour),
+			since:        now.Add(-2 * time.Hour),
+			wantIncluded: []string{"-2h0m0s"},
+		},
+		{
+			testName:     "before boundary is inclusive",
+			alarms:       triggeredAlarms(-30*time.Minute, -30*time.Minute+time.Nanosecond),
+			before:       now.Add(-30 * time.Minute),
+			wantIncluded: []string{"-30m0s"},
+		},
+		{
+			testName:     "since equal to before keeps exact match",
+			alarms:       triggeredAlarms(-time.Hour-time.Second, -time.Hour, -time.Hour+time.Second),
+			since:        now.Add(-time.Hour),
+			before:       now.Add(-time.Hour),
+			wantIncluded: []string{"-1h0m0s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			tt.alarms.filterByAge(tt.since, tt.before)
+
+			got := make([]string, 0, len(tt.alarms))
+			for _, ta := range tt.alarms {
+				switch {
+				case !ta.Exclude:
+					got = append(got, ta.Name)
+				case ta.ExcludeReason != alarmExcludeReasonAlarmAge:
+					t.Errorf(
+						"want exclude reason %q for %s; got %q",
+						alarmExcludeReasonAlarmAge,
+						ta.Name,
+						ta.ExcludeReason,
+					)
+				}
+			}
+
+			if d := cmp.Diff(tt.wantIncluded, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestTriggeredAlarmsFilterByEntityTag(t *testing.T) {
+	nightly := Tags{{Name: "Nightly", CategoryName: "Backup"}}
+	finance := Tags{{Name: "Finance", CategoryName: "Owner"}}
+
+	triggeredAlarms := func() TriggeredAlarms {
+		return TriggeredAlarms{
+			{Name: "nightly", Entity: AlarmEntity{Tags: nightly}},
+			{Name: "finance", Entity: AlarmEntity{Tags: finance}},
+			{Name: "untagged"},
+			{Name: "finance-included", Entity: AlarmEntity{Tags: finance}, ExplicitlyIncluded: true},
+			{Name: "nightly-excluded", Entity: AlarmEntity{Tags: nightly}, Exclude: true, ExplicitlyExcluded: true},
+		}
+	}
+
+	tests := map[string]struct {
+		include              []string
+		exclude              []string
+		wantIncluded         []string
+		wantExplicitIncluded []string
+		wantExplicitExcluded []string
+	}{
+		"no tag filters": {
+			wantIncluded:         []string{"nightly", "finance", "untagged", "finance-included"},
+			wantExplicitIncluded: []string{"finance-included"},
+			wantExplicitExcluded: []string{"nightly-excluded"},
+		},
+		"include by tag": {
+			include:              []string{"Backup:Nightly"},
+			wantIncluded:         []string{"nightly", "finance-included"},
+			wantExplicitIncluded: []string{"nightly", "finance-included"},
+			wantExplicitExcluded: []string{"nightly-excluded"},
+		},
+		"include without matches": {
+			include:              []string{"Owner:HR"},
+			wantIncluded:         []string{"finance-included"},
+			wantExplicitIncluded: []string{"finance-included"},
+			wantExplicitExcluded: []string{"nightly-excluded"},
+		},
+		"exclude by tag": {
+			exclude:              []string{"finance"},
+			wantIncluded:         []string{"nightly", "untagged"},
+			wantExplicitIncluded: []string{"finance-included"},
+			wantExplicitExcluded: []string{"finance", "finance-included", "nightly-excluded"},
+		},
+	}
+
+	names := func(tas TriggeredAlarms, keep func(TriggeredAlarm) bool) []string {
+		list := make([]string, 0, len(tas))
+		for _, ta := range tas {
+			if keep(ta) {
+				list = append(list, ta.Name)
+			}
+		}
+
+		return list
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tas := triggeredAlarms()
+			tas.filterByEntityTag(tt.include, tt.exclude)
+
+			gotIncluded := names(tas, func(ta TriggeredAlarm) bool { return !ta.Exclude })
+			if d := cmp.Diff(tt.wantIncluded, gotIncluded); d != "" {
+				t.Errorf("included (-want, +got):\n%s", d)
+			}
+
+			gotExplicitIncluded := names(tas, func(ta TriggeredAlarm) bool { return ta.ExplicitlyIncluded })
+			if d := cmp.Diff(tt.wantExplicitIncluded, gotExplicitIncluded); d != "" {
+				t.Errorf("explicitly included (-want, +got):\n%s", d)
+			}
+
+			gotExplicitExcluded := names(tas, func(ta TriggeredAlarm) bool { return ta.ExplicitlyExcluded })
+			if d := cmp.Diff(tt.wantExplicitExcluded, gotExplicitExcluded); d != "" {
+				t.Errorf("explicitly excluded (-want, +got):\n%s", d)
+			}
+
+			for _, ta := range tas {
+				if ta.Exclude && ta.Name != "nightly-excluded" && ta.ExcludeReason != alarmExcludeReasonEntityTag {
+					t.Errorf(
+						"want exclude reason %q for %s; got %q",
+						alarmExcludeReasonEntityTag,
+						ta.Name,
+						ta.ExcludeReason,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestTriggeredAlarmsFilterByMaintenanceMode(t *testing.T) {
+	triggeredAlarms := func() TriggeredAlarms {
+		return TriggeredAlarms{
+			{Name: "host-mm", Entity: AlarmEntity{InMaintenanceMode: true}},
+			{Name: "vm-mm-acked", Entity: AlarmEntity{InMaintenanceMode: true}, MaxState: "ok"},
+			{Name: "vm-mm-critical-max", Entity: AlarmEntity{InMaintenanceMode: true}, MaxState: "critical"},
+			{Name: "host"},
+		}
+	}
+
+	tests := map[string]struct {
+		exclude       bool
+		maxState      string
+		wantIncluded  []string
+		wantMaxStates map[string]string
+	}{
+		"not requested": {
+			wantIncluded: []string{"host-mm", "vm-mm-acked", "vm-mm-critical-max", "host"},
+			wantMaxStates: map[string]string{
+				"host-mm":            "",
+				"vm-mm-acked":        "ok",
+				"vm-mm-critical-max": "critical",
+				"host":               "",
+			},
+		},
+		"exclude": {
+			exclude:      true,
+			wantIncluded: []string{"host"},
+			wantMaxStates: map[string]string{
+				"host-mm":            "",
+				"vm-mm-acked":        "ok",
+				"vm-mm-critical-max": "critical",
+				"host":               "",
+			},
+		},
+		"max state warning keeps more restrictive state": {
+			maxState:     "warning",
+			wantIncluded: []string{"host-mm", "vm-mm-acked", "vm-mm-critical-max", "host"},
+			wantMaxStates: map[string]string{
+				"host-mm":            "warning",
+				"vm-mm-acked":        "ok",
+				"vm-mm-critical-max": "warning",
+				"host":               "",
+			},
+		},
+		"max state ok": {
+			maxState:     "ok",
+			wantIncluded: []string{"host-mm", "vm-mm-acked", "vm-mm-critical-max", "host"},
+			wantMaxStates: map[string]string{
+				"host-mm":            "ok",
+				"vm-mm-acked":        "ok",
+				"vm-mm-critical-max": "ok",
+				"host":               "",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tas := triggeredAlarms()
+			tas.filterByMaintenanceMode(tt.exclude, tt.maxState)
+
+			gotIncluded := make([]string, 0, len(tas))
+			gotMaxStates := make(map[string]string, len(tas))
+			for _, ta := range tas {
+				gotMaxStates[ta.Name] = ta.MaxState
+
+				switch {
+				case !ta.Exclude:
+					gotIncluded = append(gotIncluded, ta.Name)
+				case ta.ExcludeReason != alarmExcludeReasonMaintenanceMode:
+					t.Errorf(
+						"want exclude reason %q for %s; got %q",
+						alarmExcludeReasonMaintenanceMode,
+						ta.Name,
+						ta.ExcludeReason,
+					)
+				case !ta.ExplicitlyExcluded:
+					t.Errorf("want %s explicitly excluded", ta.Name)
+				}
+			}
+
+			if d := cmp.Diff(tt.wantIncluded, gotIncluded); d != "" {
+				t.Errorf("included (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantMaxStates, gotMaxStates); d != "" {
+				t.Errorf("max states (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+// datacenterAlarms returns a collection of TriggeredAlarms spread across
+// multiple Datacenters with a mix of statuses and acknowledgement states.
+func datacenterAlarms() TriggeredAlarms {
+	return TriggeredAlarms{
+		{Datacenter: "east", OverallStatus: types.ManagedEntityStatusRed},
+		{Datacenter: "alpha", OverallStatus: types.ManagedEntityStatusYellow, Acknowledged: true},
+		{Datacenter: "DC West", OverallStatus: types.ManagedEntityStatusRed, Acknowledged: true},
+		{Datacenter: "DC West", OverallStatus: types.ManagedEntityStatusGray},
+		{Datacenter: "DC West", OverallStatus: types.ManagedEntityStatusGreen},
+		{Datacenter: "east", OverallStatus: types.ManagedEntityStatusRed, Exclude: true},
+	}
+}
+
+func TestTriggeredAlarmsSummary(t *testing.T) {
+	tests := map[string]struct {
+		alarms TriggeredAlarms
+		want   TriggeredAlarmsSummary
+	}{
+		"no triggered alarms": {
+			alarms: TriggeredAlarms{},
+			want:   TriggeredAlarmsSummary{},
+		},
+		"excluded alarms are counted": {
+			alarms: datacenterAlarms(),
+			want: TriggeredAlarmsSummary{
+				Total:          6,
+				Red:            3,
+				Yellow:         1,
+				Gray:           1,
+				Acknowledged:   2,
+				Unacknowledged: 4,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, tt.alarms.Summary()); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestTriggeredAlarmsSummaryPerDatacenter(t *testing.T) {
+	tests := map[string]struct {
+		alarms TriggeredAlarms
+		want   []TriggeredAlarmsSummary
+	}{
+		"no triggered alarms": {
+			alarms: TriggeredAlarms{},
+			want:   []TriggeredAlarmsSummary{},
+		},
+		"sorted case-insensitively by datacenter": {
+			alarms: datacenterAlarms(),
+			want: []TriggeredAlarmsSummary{
+				{
+					Datacenter:   "alpha",
+					Total:        1,
+					Yellow:       1,
+					Acknowledged: 1,
+				},
+				{
+					Datacenter:     "DC West",
+					Total:          3,
+					Red:            1,
+					Gray:           1,
+					Acknowledged:   1,
+					Unacknowledged: 2,
+				},
+				{
+					Datacenter:     "east",
+					Total:          2,
+					Red:            2,
+					Unacknowledged: 2,
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, tt.alarms.SummaryPerDatacenter()); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestTriggeredAlarmsPerfData(t *testing.T) {
+	tas := TriggeredAlarms{
+		{Datacenter: "DC West", OverallStatus: types.ManagedEntityStatusRed, Acknowledged: true},
+		{Datacenter: "DC West", OverallStatus: types.ManagedEntityStatusYellow},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "DC_West_triggered_alarms", Value: "2", Min: "0"},
+		{Label: "DC_West_triggered_alarms_red", Value: "1", Min: "0"},
+		{Label: "DC_West_triggered_alarms_yellow", Value: "1", Min: "0"},
+		{Label: "DC_West_triggered_alarms_gray", Value: "0", Min: "0"},
+		{Label: "DC_West_triggered_alarms_acknowledged", Value: "1", Min: "0"},
+		{Label: "DC_West_triggered_alarms_unacknowledged", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, tas.PerfData()); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := (TriggeredAlarms{}).PerfData(); len(got) != 0 {
+		t.Errorf("want no perfdata for empty collection; got %d metrics", len(got))
+	}
+}
+
+func TestTriggeredAlarmNagiosState(t *testing.T) {
+	tests := map[string]struct {
+		status       types.ManagedEntityStatus
+		maxState     string
+		wantLabel    string
+		wantExitCode int
+	}{
+		"red without max state": {
+			status:       types.ManagedEntityStatusRed,
+			wantLabel:    nagios.StateCRITICALLabel,
+			wantExitCode: nagios.StateCRITICALExitCode,
+		},
+		"red limited to warning": {
+			status:       types.ManagedEntityStatusRed,
+			maxState:     "warning",
+			wantLabel:    nagios.StateWARNINGLabel,
+			wantExitCode: nagios.StateWARNINGExitCode,
+		},
+		"red limited to ok": {
+			status:       types.ManagedEntityStatusRed,
+			maxState:     "OK",
+			wantLabel:    nagios.StateOKLabel,
+			wantExitCode: nagios.StateOKExitCode,
+		},
+		"red with critical max state": {
+			status:       types.ManagedEntityStatusRed,
+			maxState:     "critical",
+			wantLabel:    nagios.StateCRITICALLabel,
+			wantExitCode: nagios.StateCRITICALExitCode,
+		},
+		"yellow below warning max state": {
+			status:       types.ManagedEntityStatusYellow,
+			maxState:     "warning",
+			wantLabel:    nagios.StateWARNINGLabel,
+			wantExitCode: nagios.StateWARNINGExitCode,
+		},
+		"yellow limited to ok": {
+			status:       types.ManagedEntityStatusYellow,
+			maxState:     "ok",
+			wantLabel:    nagios.StateOKLabel,
+			wantExitCode: nagios.StateOKExitCode,
+		},
+		"gray not raised by max state": {
+			status:       types.ManagedEntityStatusGray,
+			maxState:     "warning",
+			wantLabel:    nagios.StateUNKNOWNLabel,
+			wantExitCode: nagios.StateUNKNOWNExitCode,
+		},
+		"gray limited to ok": {
+			status:       types.ManagedEntityStatusGray,
+			maxState:     "ok",
+			wantLabel:    nagios.StateOKLabel,
+			wantExitCode: nagios.StateOKExitCode,
+		},
+		"unrecognized max state ignored": {
+			status:       types.ManagedEntityStatusRed,
+			maxState:     "unknown",
+			wantLabel:    nagios.StateCRITICALLabel,
+			wantExitCode: nagios.StateCRITICALExitCode,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ta := TriggeredAlarm{OverallStatus: tt.status, MaxState: tt.maxState}
+
+			gotLabel, gotExitCode := ta.NagiosState()
+			if gotLabel != tt.wantLabel || gotExitCode != tt.wantExitCode {
+				t.Errorf(
+					"want %s (%d); got %s (%d)",
+					tt.wantLabel,
+					tt.wantExitCode,
+					gotLabel,
+					gotExitCode,
+				)
+			}
+		})
+	}
+}
+
+func TestTriggeredAlarmsAcknowledgedMaxState(t *testing.T) {
+	triggeredAlarms := func() TriggeredAlarms {
+		return TriggeredAlarms{
+			{Name: "red-acked", OverallStatus: types.ManagedEntityStatusRed, Acknowledged: true},
+			{Name: "yellow-acked", OverallStatus: types.ManagedEntityStatusYellow, Acknowledged: true},
+			{Name: "yellow", OverallStatus: types.ManagedEntityStatusYellow},
+		}
+	}
+
+	tests := map[string]struct {
+		filters      TriggeredAlarmFilters
+		wantIncluded []string
+		wantCritical int
+		wantWarning  int
+		wantOK       int
+	}{
+		"acknowledged alarms not evaluated": {
+			filters:      TriggeredAlarmFilters{},
+			wantIncluded: []string{"yellow"},
+			wantWarning:  1,
+		},
+		"acknowledged alarms fully evaluated": {
+			filters:      TriggeredAlarmFilters{EvaluateAcknowledgedAlarms: true},
+			wantIncluded: []string{"red-acked", "yellow-acked", "yellow"},
+			wantCritical: 1,
+			wantWarning:  2,
+		},
+		"acknowledged alarms limited to warning": {
+			filters:      TriggeredAlarmFilters{AcknowledgedAlarmsMaxState: "warning"},
+			wantIncluded: []string{"red-acked", "yellow-acked", "yellow"},
+			wantWarning:  3,
+		},
+		"acknowledged alarms limited to ok": {
+			filters:      TriggeredAlarmFilters{AcknowledgedAlarmsMaxState: "ok"},
+			wantIncluded: []string{"red-acked", "yellow-acked", "yellow"},
+			wantWarning:  1,
+			wantOK:       2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tas := triggeredAlarms()
+			tas.Filter(tt.filters)
+
+			gotIncluded := make([]string, 0, len(tas))
+			for _, ta := range tas {
+				if !ta.Exclude {
+					gotIncluded = append(gotIncluded, ta.Name)
+				}
+			}
+
+			if d := cmp.Diff(tt.wantIncluded, gotIncluded); d != "" {
+				t.Errorf("included (-want, +got):\n%s", d)
+			}
+
+			if got := tas.NumCriticalState(false); got != tt.wantCritical {
+				t.Errorf("want %d CRITICAL alarms; got %d", tt.wantCritical, got)
+			}
+
+			if got := tas.NumWarningState(false); got != tt.wantWarning {
+				t.Errorf("want %d WARNING alarms; got %d", tt.wantWarning, got)
+			}
+
+			if got := tas.NumOKState(false); got != tt.wantOK {
+				t.Errorf("want %d OK alarms; got %d", tt.wantOK, got)
+			}
+
+			if got := tas.HasCriticalState(false); got != (tt.wantCritical > 0) {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical > 0, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,531 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrClusterDASIsolationCheckFailed indicates that the vSphere HA isolation
+// settings for one or more evaluated clusters do not match the specified
+// policy.
+var ErrClusterDASIsolationCheckFailed = errors.New("cluster HA isolation settings check failed")
+
+// vSphere HA advanced options used to configure host isolation detection.
+const (
+	dasIsolationAddressOptionPrefix     string = "das.isolationaddress"
+	dasUseDefaultIsolationAddressOption string = "das.usedefaultisolationaddress"
+)
+
+// ClusterDASIsolationPolicy represents the user-specified vSphere HA
+// isolation settings expected for evaluated clusters.
+type ClusterDASIsolationPolicy struct {
+
+	// ExpectedAddresses is the collection of isolation addresses which are
+	// required to be configured for each cluster.
+	ExpectedAddresses []string
+
+	// AllowedResponses is the collection of host isolation responses (e.g.,
+	// powerOff, shutdown) permitted for each cluster.
+	AllowedResponses []string
+
+	// MinAddresses is the minimum number of custom isolation addresses
+	// (das.isolationaddressX) required to be configured for each cluster.
+	MinAddresses int
+
+	// AllowDefaultAddress indicates whether use of the default isolation
+	// address (the default gateway of the management network) is permitted.
+	AllowDefaultAddress bool
+}
+
+// ClusterDASIsolation tracks the vSphere HA isolation settings for a specific
+// ClusterComputeResource along with any policy violations.
+type ClusterDASIsolation struct {
+
+	// Cluster is the name of the cluster.
+	Cluster string
+
+	// HAEnabled indicates whether vSphere HA is enabled for the cluster.
+	// Isolation settings are not evaluated for clusters with HA disabled.
+	HAEnabled bool
+
+	// IsolationResponse is the default host isolation response for VMs in
+	// the cluster.
+	IsolationResponse string
+
+	// UseDefaultAddress indicates whether the default isolation address (the
+	// default gateway of the management network) is used.
+	UseDefaultAddress bool
+
+	// Addresses is the collection of custom isolation addresses configured
+	// for the cluster.
+	Addresses []string
+
+	// Critical is the collection of issues detected which map to a
+	// CRITICAL state.
+	Critical []string
+
+	// Warning is the collection of issues detected which map to a WARNING
+	// state.
+	Warning []string
+}
+
+// ClusterDASIsolationSet is a collection of ClusterDASIsolation values.
+type ClusterDASIsolationSet []ClusterDASIsolation
+
+// SupportedDASIsolationResponses returns the host isolation responses which
+// may be configured for a vSphere HA cluster.
+func SupportedDASIsolationResponses() []string {
+	return []string{
+		string(types.ClusterDasVmSettingsIsolationResponseNone),
+		string(types.ClusterDasVmSettingsIsolationResponsePowerOff),
+		string(types.ClusterDasVmSettingsIsolationResponseShutdown),
+	}
+}
+
+// clusterDasIsolationOptions returns the custom isolation addresses and
+// whether the default isolation address is used from the given vSphere HA
+// advanced options. Isolation addresses are returned in option key order.
+func clusterDasIsolationOptions(options []types.BaseOptionValue) ([]string, bool) {
+	useDefault := true
+	addressesByKey := make(map[string]string)
+
+	for _, option := range options {
+		if option == nil {
+			continue
+		}
+
+		opt := option.GetOptionValue()
+		key := strings.ToLower(strings.TrimSpace(opt.Key))
+		value := strings.TrimSpace(fmt.Sprint(opt.Value))
+
+		switch {
+		case key == dasUseDefaultIsolationAddressOption:
+			if b, err := strconv.ParseBool(value); err == nil {
+				useDefault = b
+			}
+
+		case strings.HasPrefix(key, dasIsolationAddressOptionPrefix):
+			if value != "" {
+				addressesByKey[key] = value
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(addressesByKey))
+	for key := range addressesByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	addresses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		addresses = append(addresses, addressesByKey[key])
+	}
+
+	return addresses, useDefault
+}
+
+// NewClusterDASIsolation receives a ClusterComputeResource and evaluates the
+// vSphere HA isolation response and isolation address settings against the
+// given policy. Clusters with vSphere HA disabled are not evaluated.
+func NewClusterDASIsolation(cluster mo.ClusterComputeResource, policy ClusterDASIsolationPolicy) ClusterDASIsolation {
+
+	dasConfig := clusterDasConfig(cluster)
+
+	isolation := ClusterDASIsolation{
+		Cluster:   cluster.Name,
+		HAEnabled: IsHAEnabled(cluster),
+	}
+
+	isolation.Addresses, isolation.UseDefaultAddress = clusterDasIsolationOptions(dasConfig.Option)
+
+	isolation.IsolationResponse = string(types.ClusterDasVmSettingsIsolationResponseNone)
+	if dasConfig.DefaultVmSettings != nil && dasConfig.DefaultVmSettings.IsolationResponse != "" {
+		isolation.IsolationResponse = dasConfig.DefaultVmSettings.IsolationResponse
+	}
+
+	if !isolation.HAEnabled {
+		return isolation
+	}
+
+	if !isolation.UseDefaultAddress && len(isolation.Addresses) == 0 {
+		isolation.Critical = append(
+			isolation.Critical,
+			"default isolation address disabled without custom isolation addresses; host isolation cannot be detected",
+		)
+	}
+
+	if isolation.UseDefaultAddress && !policy.AllowDefaultAddress {
+		isolation.Warning = append(isolation.Warning, fmt.Sprintf(
+			"default gateway used as isolation address (%s is not false)",
+			dasUseDefaultIsolationAddressOption,
+		))
+	}
+
+	if len(isolation.Addresses) < policy.MinAddresses {
+		isolation.Warning = append(isolation.Warning, fmt.Sprintf(
+			"%d custom isolation addresses configured, %d required",
+			len(isolation.Addresses),
+			policy.MinAddresses,
+		))
+	}
+
+	for _, address := range policy.ExpectedAddresses {
+		if !textutils.InList(address, isolation.Addresses, true) {
+			isolation.Warning = append(isolation.Warning, fmt.Sprintf(
+				"expected isolation address %s not configured",
+				address,
+			))
+		}
+	}
+
+	if len(policy.AllowedResponses) > 0 &&
+		!textutils.InList(isolation.IsolationResponse, policy.AllowedResponses, true) {
+		isolation.Warning = append(isolation.Warning, fmt.Sprintf(
+			"host isolation response is %s (allowed: %s)",
+			isolation.IsolationResponse,
+			strings.Join(policy.AllowedResponses, ", "),
+		))
+	}
+
+	return isolation
+
+}
+
+// NewClusterDASIsolationSet evaluates the vSphere HA isolation settings for
+// each of the given clusters against the given policy.
+func NewClusterDASIsolationSet(clusters []mo.ClusterComputeResource, policy ClusterDASIsolationPolicy) ClusterDASIsolationSet {
+	set := make(ClusterDASIsolationSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		set = append(set, NewClusterDASIsolation(cluster, policy))
+	}
+
+	return set
+}
+
+// IsCriticalState indicates whether any issues mapping to a CRITICAL state
+// were detected for the cluster.
+func (cdi ClusterDASIsolation) IsCriticalState() bool {
+	return len(cdi.Critical) > 0
+}
+
+// IsWarningState indicates whether any issues mapping to a WARNING state
+// (and none mapping to a CRITICAL state) were detected for the cluster.
+func (cdi ClusterDASIsolation) IsWarningState() bool {
+	return !cdi.IsCriticalState() && len(cdi.Warning) > 0
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterDASIsolationSet) NumCritical() int {
+	var num int
+	for _, cdi := range set {
+		if cdi.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterDASIsolationSet) NumWarning() int {
+	var num int
+	for _, cdi := range set {
+		if cdi.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHADisabled returns the number of clusters with vSphere HA disabled.
+func (set ClusterDASIsolationSet) NumHADisabled() int {
+	var num int
+	for _, cdi := range set {
+		if !cdi.HAEnabled {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumDefaultAddress returns the number of clusters with vSphere HA enabled
+// which use the default isolation address.
+func (set ClusterDASIsolationSet) NumDefaultAddress() int {
+	var num int
+	for _, cdi := range set {
+		if cdi.HAEnabled && cdi.UseDefaultAddress {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set ClusterDASIsolationSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set ClusterDASIsolationSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ClusterDASIsolationPerfData generates performance data metrics from the
+// given collection of evaluated clusters. The number of custom isolation
+// addresses is emitted for each cluster with vSphere HA enabled.
+func ClusterDASIsolationPerfData(set ClusterDASIsolationSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_ha_disabled",
+			Value: fmt.Sprintf("%d", set.NumHADisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_default_isolation_address",
+			Value: fmt.Sprintf("%d", set.NumDefaultAddress()),
+			Min:   "0",
+		},
+	}
+
+	for _, cdi := range set {
+		if !cdi.HAEnabled {
+			continue
+		}
+
+		pd = append(pd, nagios.PerformanceData{
+			Label: PerfDataLabel(cdi.Cluster, "isolation_addresses"),
+			Value: fmt.Sprintf("%d", len(cdi.Addresses)),
+			Min:   "0",
+		})
+	}
+
+	return pd
+
+}
+
+// ClusterDASIsolationOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ClusterDASIsolationOneLineCheckSummary(
+	stateLabel string,
+	set ClusterDASIsolationSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDASIsolationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with HA isolation settings not matching policy detected (evaluated %d clusters, %d with HA disabled)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumHADisabled(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No clusters with HA isolation settings not matching policy detected (evaluated %d clusters, %d with HA disabled)",
+			stateLabel,
+			len(set),
+			set.NumHADisabled(),
+		)
+	}
+}
+
+// ClusterDASIsolationReport generates a summary of vSphere HA isolation
+// settings for the evaluated clusters along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func ClusterDASIsolationReport(
+	c *vim25.Client,
+	set ClusterDASIsolationSet,
+	policy ClusterDASIsolationPolicy,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDASIsolationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, cdi := range set {
+		if !cdi.HAEnabled {
+			_, _ = fmt.Fprintf(
+				&report,
+				"Cluster %s [%s]: HA disabled; not evaluated%s%s",
+				cdi.Cluster,
+				nagios.StateOKLabel,
+				nagios.CheckOutputEOL,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		var state string
+		switch {
+		case cdi.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case cdi.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		addresses := "none"
+		if len(cdi.Addresses) > 0 {
+			addresses = strings.Join(cdi.Addresses, ", ")
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s [%s]:%s"+
+				"* Host isolation response: %s%s"+
+				"* Default isolation address used: %t%s"+
+				"* Custom isolation addresses (%d): %s%s",
+			cdi.Cluster,
+			state,
+			nagios.CheckOutputEOL,
+			cdi.IsolationResponse,
+			nagios.CheckOutputEOL,
+			cdi.UseDefaultAddress,
+			nagios.CheckOutputEOL,
+			len(cdi.Addresses),
+			addresses,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, issue := range cdi.Critical {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateCRITICALLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range cdi.Warning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateWARNINGLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Allowed host isolation responses: [%s]%s",
+		strings.Join(policy.AllowedResponses, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Expected isolation addresses (%d): [%s]%s",
+		len(policy.ExpectedAddresses),
+		strings.Join(policy.ExpectedAddresses, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Minimum custom isolation addresses: %d%s",
+		policy.MinAddresses,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Default isolation address allowed: %t%s",
+		policy.AllowDefaultAddress,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,214 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// dasOptions converts the given KEY, VALUE pairs to vSphere HA advanced
+// options.
+func dasOptions(pairs ...string) []types.BaseOptionValue {
+	options := make([]types.BaseOptionValue, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		options = append(options, &types.OptionValue{Key: pairs[i], Value: pairs[i+1]})
+	}
+
+	return options
+}
+
+func dasIsolationCluster(haEnabled bool, response string, options ...string) mo.ClusterComputeResource {
+	return mo.ClusterComputeResource{
+		ComputeResource: mo.ComputeResource{
+			ManagedEntity: mo.ManagedEntity{Name: "cluster1"},
+			ConfigurationEx: &types.ClusterConfigInfoEx{
+				DasConfig: types.ClusterDasConfigInfo{
+					Enabled: &haEnabled,
+					Option:  dasOptions(options...),
+					DefaultVmSettings: &types.ClusterDasVmSettings{
+						IsolationResponse: response,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestClusterDasIsolationOptions(t *testing.T) {
+	tests := map[string]struct {
+		options        []types.BaseOptionValue
+		wantAddresses  []string
+		wantUseDefault bool
+	}{
+		"no options": {
+			wantAddresses:  []string{},
+			wantUseDefault: true,
+		},
+		"addresses in key order": {
+			options: dasOptions(
+				"das.isolationaddress1", "192.168.2.1",
+				"das.isolationaddress0", "192.168.1.1",
+				"das.usedefaultisolationaddress", "false",
+			),
+			wantAddresses: []string{"192.168.1.1", "192.168.2.1"},
+		},
+		"keys are case-insensitive and trimmed": {
+			options: dasOptions(
+				" das.IsolationAddress0 ", " 192.168.1.1 ",
+				"das.UseDefaultIsolationAddress", "FALSE",
+			),
+			wantAddresses: []string{"192.168.1.1"},
+		},
+		"empty address ignored": {
+			options:        dasOptions("das.isolationaddress0", " "),
+			wantAddresses:  []string{},
+			wantUseDefault: true,
+		},
+		"invalid boolean keeps default": {
+			options:        dasOptions("das.usedefaultisolationaddress", "no"),
+			wantAddresses:  []string{},
+			wantUseDefault: true,
+		},
+		"unrelated options ignored": {
+			options:        dasOptions("das.heartbeatDsPerHost", "4"),
+			wantAddresses:  []string{},
+			wantUseDefault: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			addresses, useDefault := clusterDasIsolationOptions(tt.options)
+
+			if useDefault != tt.wantUseDefault {
+				t.Errorf("want use default %t; got %t", tt.wantUseDefault, useDefault)
+			}
+			if d := cmp.Diff(tt.wantAddresses, addresses); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewClusterDASIsolation(t *testing.T) {
+	policy := ClusterDASIsolationPolicy{
+		ExpectedAddresses: []string{"192.168.1.1"},
+		AllowedResponses:  []string{"powerOff", "shutdown"},
+		MinAddresses:      2,
+	}
+
+	tests := map[string]struct {
+		cluster      mo.ClusterComputeResource
+		policy       ClusterDASIsolationPolicy
+		wantCritical int
+		wantWarning  int
+		wantResponse string
+	}{
+		"compliant": {
+			cluster: dasIsolationCluster(true, "powerOff",
+				"das.usedefaultisolationaddress", "false",
+				"das.isolationaddress0", "192.168.1.1",
+				"das.isolationaddress1", "192.168.2.1",
+			),
+			policy:       policy,
+			wantResponse: "powerOff",
+		},
+		"no isolation address": {
+			cluster:      dasIsolationCluster(true, "powerOff", "das.usedefaultisolationaddress", "false"),
+			policy:       policy,
+			wantCritical: 1,
+			wantWarning:  2,
+			wantResponse: "powerOff",
+		},
+		"default isolation address": {
+			cluster:      dasIsolationCluster(true, "powerOff"),
+			policy:       policy,
+			wantWarning:  3,
+			wantResponse: "powerOff",
+		},
+		"default isolation address allowed": {
+			cluster:      dasIsolationCluster(true, "powerOff"),
+			policy:       ClusterDASIsolationPolicy{AllowDefaultAddress: true},
+			wantResponse: "powerOff",
+		},
+		"isolation response not allowed": {
+			cluster: dasIsolationCluster(true, "none",
+				"das.usedefaultisolationaddress", "false",
+				"das.isolationaddress0", "192.168.1.1",
+				"das.isolationaddress1", "192.168.2.1",
+			),
+			policy:       policy,
+			wantWarning:  1,
+			wantResponse: "none",
+		},
+		"isolation response defaults to none": {
+			cluster:      dasIsolationCluster(true, "", "das.isolationaddress0", "192.168.1.1"),
+			policy:       ClusterDASIsolationPolicy{AllowDefaultAddress: true, AllowedResponses: []string{"NONE"}},
+			wantResponse: "none",
+		},
+		"expected address missing": {
+			cluster: dasIsolationCluster(true, "shutdown",
+				"das.usedefaultisolationaddress", "false",
+				"das.isolationaddress0", "192.168.3.1",
+				"das.isolationaddress1", "192.168.2.1",
+			),
+			policy:       policy,
+			wantWarning:  1,
+			wantResponse: "shutdown",
+		},
+		"HA disabled": {
+			cluster:      dasIsolationCluster(false, "none"),
+			policy:       policy,
+			wantResponse: "none",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cdi := NewClusterDASIsolation(tt.cluster, tt.policy)
+
+			if len(cdi.Critical) != tt.wantCritical {
+				t.Errorf("want %d CRITICAL issues; got %v", tt.wantCritical, cdi.Critical)
+			}
+			if len(cdi.Warning) != tt.wantWarning {
+				t.Errorf("want %d WARNING issues; got %v", tt.wantWarning, cdi.Warning)
+			}
+			if cdi.IsolationResponse != tt.wantResponse {
+				t.Errorf("want isolation response %q; got %q", tt.wantResponse, cdi.IsolationResponse)
+			}
+		})
+	}
+}
+
+func TestClusterDASIsolationSetCounts(t *testing.T) {
+	set := NewClusterDASIsolationSet(
+		[]mo.ClusterComputeResource{
+			dasIsolationCluster(true, "powerOff", "das.usedefaultisolationaddress", "false"),
+			dasIsolationCluster(true, "powerOff"),
+			dasIsolationCluster(false, "powerOff"),
+		},
+		ClusterDASIsolationPolicy{AllowDefaultAddress: true},
+	)
+
+	if got := set.NumCritical(); got != 1 {
+		t.Errorf("want 1 CRITICAL cluster; got %d", got)
+	}
+	if got := set.NumWarning(); got != 0 {
+		t.Errorf("want 0 WARNING clusters; got %d", got)
+	}
+	if got := set.NumHADisabled(); got != 1 {
+		t.Errorf("want 1 cluster with HA disabled; got %d", got)
+	}
+	if got := set.NumDefaultAddress(); got != 1 {
+		t.Errorf("want 1 cluster using the default isolation address; got %d", got)
+	}
+}
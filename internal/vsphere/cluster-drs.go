@@ -0,0 +1,501 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrClusterDRSStatusCheckFailed indicates that DRS is disabled, is not
+// configured with the required automation level or is reporting an
+// imbalance or excessive recommendations for one or more evaluated clusters.
+var ErrClusterDRSStatusCheckFailed = errors.New("cluster DRS status check failed")
+
+// drsBehaviorRank provides an ordering of DRS automation levels from least
+// to most automated.
+var drsBehaviorRank = map[types.DrsBehavior]int{
+	types.DrsBehaviorManual:             0,
+	types.DrsBehaviorPartiallyAutomated: 1,
+	types.DrsBehaviorFullyAutomated:     2,
+}
+
+// ClusterDRSStatusThresholds represents the user-specified DRS requirements
+// and pending recommendation count thresholds.
+type ClusterDRSStatusThresholds struct {
+	RequiredBehavior        string
+	RecommendationsWarning  int
+	RecommendationsCritical int
+}
+
+// ClusterDRSStatus tracks DRS configuration and balance details for a
+// specific ClusterComputeResource.
+type ClusterDRSStatus struct {
+	Cluster mo.ClusterComputeResource
+
+	// Critical is the collection of issues detected which map to a
+	// CRITICAL state.
+	Critical []string
+
+	// Warning is the collection of issues detected which map to a WARNING
+	// state.
+	Warning []string
+
+	Thresholds ClusterDRSStatusThresholds
+}
+
+// ClusterDRSStatusSet is a collection of ClusterDRSStatus values.
+type ClusterDRSStatusSet []ClusterDRSStatus
+
+// clusterDrsConfig returns the DRS configuration for the given cluster. The
+// extended configuration details are preferred, falling back to the
+// (deprecated) configuration property if the extended details were not
+// retrieved.
+func clusterDrsConfig(cluster mo.ClusterComputeResource) types.ClusterDrsConfigInfo {
+	drsConfig := cluster.Configuration.DrsConfig
+	if cfgEx, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx); ok {
+		drsConfig = cfgEx.DrsConfig
+	}
+
+	return drsConfig
+}
+
+// clusterSummary returns the summary details for the given cluster. A zero
+// value is returned if the summary details were not retrieved.
+func clusterSummary(cluster mo.ClusterComputeResource) types.ClusterComputeResourceSummary {
+	if summary, ok := cluster.Summary.(*types.ClusterComputeResourceSummary); ok {
+		return *summary
+	}
+
+	return types.ClusterComputeResourceSummary{}
+}
+
+// IsDRSEnabled indicates whether DRS is enabled for the given cluster.
+func IsDRSEnabled(cluster mo.ClusterComputeResource) bool {
+	drsConfig := clusterDrsConfig(cluster)
+
+	return drsConfig.Enabled != nil && *drsConfig.Enabled
+}
+
+// DRSBehavior returns the default DRS automation level for the given
+// cluster.
+func DRSBehavior(cluster mo.ClusterComputeResource) types.DrsBehavior {
+	return clusterDrsConfig(cluster).DefaultVmBehavior
+}
+
+// NewClusterDRSStatus receives a ClusterComputeResource and evaluates DRS
+// configuration, balance and pending recommendations against the specified
+// thresholds.
+func NewClusterDRSStatus(cluster mo.ClusterComputeResource, thresholds ClusterDRSStatusThresholds) ClusterDRSStatus {
+
+	status := ClusterDRSStatus{
+		Cluster:    cluster,
+		Thresholds: thresholds,
+	}
+
+	if !IsDRSEnabled(cluster) {
+		status.Critical = append(status.Critical, "DRS is disabled")
+
+		// Remaining checks are not applicable if DRS is disabled.
+		return status
+	}
+
+	behavior := DRSBehavior(cluster)
+	required := types.DrsBehavior(thresholds.RequiredBehavior)
+	if drsBehaviorRank[behavior] < drsBehaviorRank[required] {
+		status.Warning = append(status.Warning, fmt.Sprintf(
+			"DRS automation level is %s, %s required",
+			behavior,
+			required,
+		))
+	}
+
+	summary := clusterSummary(cluster)
+
+	// The target and current balance values are only reported by older
+	// vCenter releases; newer releases report a DRS score instead.
+	if summary.TargetBalance > 0 && summary.CurrentBalance > summary.TargetBalance {
+		status.Warning = append(status.Warning, fmt.Sprintf(
+			"cluster is imbalanced (current balance %d exceeds target balance %d)",
+			summary.CurrentBalance,
+			summary.TargetBalance,
+		))
+	}
+
+	numRecommendations := status.NumRecommendations()
+	switch {
+	case numRecommendations > thresholds.RecommendationsCritical:
+		status.Critical = append(status.Critical, fmt.Sprintf(
+			"%d pending DRS recommendations exceeds CRITICAL threshold of %d",
+			numRecommendations,
+			thresholds.RecommendationsCritical,
+		))
+
+	case numRecommendations > thresholds.RecommendationsWarning:
+		status.Warning = append(status.Warning, fmt.Sprintf(
+			"%d pending DRS recommendations exceeds WARNING threshold of %d",
+			numRecommendations,
+			thresholds.RecommendationsWarning,
+		))
+	}
+
+	return status
+
+}
+
+// NewClusterDRSStatusSet evaluates DRS configuration, balance and pending
+// recommendations for each of the given clusters.
+func NewClusterDRSStatusSet(clusters []mo.ClusterComputeResource, thresholds ClusterDRSStatusThresholds) ClusterDRSStatusSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewClusterDRSStatusSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterDRSStatusSet, 0, len(clusters))
+	for _, cluster := range clusters {
+		set = append(set, NewClusterDRSStatus(cluster, thresholds))
+	}
+
+	return set
+
+}
+
+// NumRecommendations returns the number of pending DRS recommendations for
+// the cluster.
+func (cds ClusterDRSStatus) NumRecommendations() int {
+	return len(cds.Cluster.Recommendation)
+}
+
+// IsCriticalState indicates whether any issues mapping to a CRITICAL state
+// were detected for the cluster.
+func (cds ClusterDRSStatus) IsCriticalState() bool {
+	return len(cds.Critical) > 0
+}
+
+// IsWarningState indicates whether any issues mapping to a WARNING state
+// (and none mapping to a CRITICAL state) were detected for the cluster.
+func (cds ClusterDRSStatus) IsWarningState() bool {
+	return !cds.IsCriticalState() && len(cds.Warning) > 0
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterDRSStatusSet) NumCritical() int {
+	var num int
+	for _, cds := range set {
+		if cds.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterDRSStatusSet) NumWarning() int {
+	var num int
+	for _, cds := range set {
+		if cds.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumDRSDisabled returns the number of clusters with DRS disabled.
+func (set ClusterDRSStatusSet) NumDRSDisabled() int {
+	var num int
+	for _, cds := range set {
+		if !IsDRSEnabled(cds.Cluster) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumRecommendations returns the number of pending DRS recommendations
+// across all evaluated clusters.
+func (set ClusterDRSStatusSet) NumRecommendations() int {
+	var num int
+	for _, cds := range set {
+		num += cds.NumRecommendations()
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set ClusterDRSStatusSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set ClusterDRSStatusSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ClusterDRSStatusPerfData generates performance data metrics from the given
+// collection of evaluated clusters. If a single cluster is evaluated
+// detailed balance metrics are emitted, otherwise the pending
+// recommendations count is emitted per cluster.
+func ClusterDRSStatusPerfData(set ClusterDRSStatusSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_drs_disabled",
+			Value: fmt.Sprintf("%d", set.NumDRSDisabled()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) == 1 {
+		cds := set[0]
+		summary := clusterSummary(cds.Cluster)
+
+		return append(pd,
+			nagios.PerformanceData{
+				Label: "drs_recommendations",
+				Value: fmt.Sprintf("%d", cds.NumRecommendations()),
+				Warn:  fmt.Sprintf("%d", cds.Thresholds.RecommendationsWarning),
+				Crit:  fmt.Sprintf("%d", cds.Thresholds.RecommendationsCritical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label:             "drs_score",
+				Value:             fmt.Sprintf("%d", summary.DrsScore),
+				UnitOfMeasurement: "%",
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label: "current_balance",
+				Value: fmt.Sprintf("%d", summary.CurrentBalance),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: "target_balance",
+				Value: fmt.Sprintf("%d", summary.TargetBalance),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: "vmotions",
+				Value: fmt.Sprintf("%d", summary.NumVmotions),
+				Min:   "0",
+			},
+		)
+	}
+
+	for _, cds := range set {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: PerfDataLabel(cds.Cluster.Name, "drs_recommendations"),
+				Value: fmt.Sprintf("%d", cds.NumRecommendations()),
+				Warn:  fmt.Sprintf("%d", cds.Thresholds.RecommendationsWarning),
+				Crit:  fmt.Sprintf("%d", cds.Thresholds.RecommendationsCritical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(cds.Cluster.Name, "drs_score"),
+				Value:             fmt.Sprintf("%d", clusterSummary(cds.Cluster).DrsScore),
+				UnitOfMeasurement: "%",
+				Min:               "0",
+				Max:               "100",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// ClusterDRSStatusOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func ClusterDRSStatusOneLineCheckSummary(
+	stateLabel string,
+	set ClusterDRSStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDRSStatusOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with DRS issues detected (evaluated %d clusters, %d pending recommendations)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumRecommendations(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No DRS issues detected (evaluated %d clusters, %d pending recommendations)",
+			stateLabel,
+			len(set),
+			set.NumRecommendations(),
+		)
+	}
+}
+
+// ClusterDRSStatusReport generates a summary of DRS status for the evaluated
+// clusters along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func ClusterDRSStatusReport(
+	c *vim25.Client,
+	set ClusterDRSStatusSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDRSStatusReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, cds := range set {
+		var state string
+		switch {
+		case cds.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case cds.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		summary := clusterSummary(cds.Cluster)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s [%s]:%s"+
+				"* DRS enabled: %t%s"+
+				"* Automation level: %s%s"+
+				"* DRS score: %d%%%s"+
+				"* Balance (current/target): %d/%d%s"+
+				"* Pending recommendations: %d%s",
+			cds.Cluster.Name,
+			state,
+			nagios.CheckOutputEOL,
+			IsDRSEnabled(cds.Cluster),
+			nagios.CheckOutputEOL,
+			DRSBehavior(cds.Cluster),
+			nagios.CheckOutputEOL,
+			summary.DrsScore,
+			nagios.CheckOutputEOL,
+			summary.CurrentBalance,
+			summary.TargetBalance,
+			nagios.CheckOutputEOL,
+			cds.NumRecommendations(),
+			nagios.CheckOutputEOL,
+		)
+
+		for _, recommendation := range cds.Cluster.Recommendation {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Recommendation: %s (rating: %d)%s",
+				recommendation.ReasonText,
+				recommendation.Rating,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range cds.Critical {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateCRITICALLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range cds.Warning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateWARNINGLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,178 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// drsCluster returns a cluster with the given DRS state, automation level,
+// balance values and number of pending DRS recommendations.
+func drsCluster(
+	drsEnabled bool,
+	behavior types.DrsBehavior,
+	currentBalance int32,
+	targetBalance int32,
+	numRecommendations int,
+) mo.ClusterComputeResource {
+	return mo.ClusterComputeResource{
+		ComputeResource: mo.ComputeResource{
+			ManagedEntity: mo.ManagedEntity{Name: "cluster1"},
+			ConfigurationEx: &types.ClusterConfigInfoEx{
+				DrsConfig: types.ClusterDrsConfigInfo{
+					Enabled:           &drsEnabled,
+					DefaultVmBehavior: behavior,
+				},
+			},
+			Summary: &types.ClusterComputeResourceSummary{
+				CurrentBalance: currentBalance,
+				TargetBalance:  targetBalance,
+			},
+		},
+		Recommendation: make([]types.ClusterRecommendation, numRecommendations),
+	}
+}
+
+func TestClusterDrsConfig(t *testing.T) {
+	enabled, disabled := true, false
+
+	legacy := mo.ClusterComputeResource{
+		Configuration: types.ClusterConfigInfo{
+			DrsConfig: types.ClusterDrsConfigInfo{
+				Enabled:           &enabled,
+				DefaultVmBehavior: types.DrsBehaviorManual,
+			},
+		},
+	}
+
+	if !IsDRSEnabled(legacy) || DRSBehavior(legacy) != types.DrsBehaviorManual {
+		t.Errorf("want legacy configuration used when extended details are missing; got %+v", clusterDrsConfig(legacy))
+	}
+
+	extended := legacy
+	extended.ConfigurationEx = &types.ClusterConfigInfoEx{
+		DrsConfig: types.ClusterDrsConfigInfo{
+			Enabled:           &disabled,
+			DefaultVmBehavior: types.DrsBehaviorFullyAutomated,
+		},
+	}
+
+	if IsDRSEnabled(extended) || DRSBehavior(extended) != types.DrsBehaviorFullyAutomated {
+		t.Errorf("want extended configuration preferred; got %+v", clusterDrsConfig(extended))
+	}
+
+	if IsDRSEnabled(mo.ClusterComputeResource{}) {
+		t.Error("want DRS disabled when not reported; got enabled")
+	}
+
+	if got := clusterSummary(mo.ClusterComputeResource{}); got.TargetBalance != 0 || got.CurrentBalance != 0 {
+		t.Errorf("want zero value summary when not retrieved; got %+v", got)
+	}
+}
+
+func TestNewClusterDRSStatus(t *testing.T) {
+	thresholds := ClusterDRSStatusThresholds{
+		RequiredBehavior:        string(types.DrsBehaviorPartiallyAutomated),
+		RecommendationsWarning:  2,
+		RecommendationsCritical: 5,
+	}
+
+	tests := map[string]struct {
+		cluster      mo.ClusterComputeResource
+		wantCritical []string
+		wantWarning  []string
+	}{
+		"fully automated and balanced": {
+			cluster: drsCluster(true, types.DrsBehaviorFullyAutomated, 10, 20, 0),
+		},
+		"required automation level and recommendations at WARNING threshold": {
+			cluster: drsCluster(true, types.DrsBehaviorPartiallyAutomated, 0, 0, 2),
+		},
+		"balance not reported": {
+			cluster: drsCluster(true, types.DrsBehaviorFullyAutomated, 30, 0, 0),
+		},
+		"DRS disabled skips remaining checks": {
+			cluster:      drsCluster(false, types.DrsBehaviorManual, 30, 20, 10),
+			wantCritical: []string{"DRS is disabled"},
+		},
+		"automation level below required": {
+			cluster:     drsCluster(true, types.DrsBehaviorManual, 0, 0, 0),
+			wantWarning: []string{"DRS automation level is manual, partiallyAutomated required"},
+		},
+		"imbalanced": {
+			cluster:     drsCluster(true, types.DrsBehaviorFullyAutomated, 30, 20, 0),
+			wantWarning: []string{"cluster is imbalanced (current balance 30 exceeds target balance 20)"},
+		},
+		"recommendations at CRITICAL threshold": {
+			cluster:     drsCluster(true, types.DrsBehaviorFullyAutomated, 0, 0, 5),
+			wantWarning: []string{"5 pending DRS recommendations exceeds WARNING threshold of 2"},
+		},
+		"recommendations above CRITICAL threshold": {
+			cluster:      drsCluster(true, types.DrsBehaviorManual, 0, 0, 6),
+			wantCritical: []string{"6 pending DRS recommendations exceeds CRITICAL threshold of 5"},
+			wantWarning:  []string{"DRS automation level is manual, partiallyAutomated required"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cds := NewClusterDRSStatus(tt.cluster, thresholds)
+
+			if d := cmp.Diff(tt.wantCritical, cds.Critical); d != "" {
+				t.Errorf("CRITICAL issues (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantWarning, cds.Warning); d != "" {
+				t.Errorf("WARNING issues (-want, +got):\n%s", d)
+			}
+
+			if got, want := cds.IsCriticalState(), len(tt.wantCritical) > 0; got != want {
+				t.Errorf("want CRITICAL state %t; got %t", want, got)
+			}
+
+			if got, want := cds.IsWarningState(), len(tt.wantCritical) == 0 && len(tt.wantWarning) > 0; got != want {
+				t.Errorf("want WARNING state %t; got %t", want, got)
+			}
+		})
+	}
+}
+
+func TestClusterDRSStatusSetCounts(t *testing.T) {
+	set := NewClusterDRSStatusSet(
+		[]mo.ClusterComputeResource{
+			drsCluster(false, types.DrsBehaviorFullyAutomated, 0, 0, 0),
+			drsCluster(true, types.DrsBehaviorFullyAutomated, 0, 0, 3),
+			drsCluster(true, types.DrsBehaviorFullyAutomated, 0, 0, 1),
+		},
+		ClusterDRSStatusThresholds{
+			RequiredBehavior:        string(types.DrsBehaviorFullyAutomated),
+			RecommendationsWarning:  2,
+			RecommendationsCritical: 5,
+		},
+	)
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL cluster; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING cluster; got %d", got)
+	}
+
+	if got := set.NumDRSDisabled(); got != 1 {
+		t.Errorf("want 1 cluster with DRS disabled; got %d", got)
+	}
+
+	if got := set.NumRecommendations(); got != 4 {
+		t.Errorf("want 4 pending recommendations; got %d", got)
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrClusterHAHostFailuresThresholdCrossed indicates that the number of
+// vSphere HA host failure events recorded for one or more clusters within
+// the history window has crossed a specified threshold.
+var ErrClusterHAHostFailuresThresholdCrossed = errors.New("HA host failure events exceed specified threshold")
+
+// HAHostFailureEventTypeIDs returns the event type IDs recorded by vSphere
+// HA when a host failure is detected or a failover is initiated.
+func HAHostFailureEventTypeIDs() []string {
+	return []string{
+		"DasHostFailedEvent",
+		"DasHostIsolatedEvent",
+		"com.vmware.vc.HA.DasHostCompleteDatastoreFailureEvent",
+		"com.vmware.vc.HA.DasHostCompleteNetworkFailureEvent",
+		"com.vmware.vc.HA.ClusterFailoverActionInitiatedEvent",
+	}
+}
+
+// HAVMRestartEventTypeIDs returns the event type IDs recorded by vSphere HA
+// when a VM is restarted as part of a failover.
+func HAVMRestartEventTypeIDs() []string {
+	return []string{
+		"com.vmware.vc.ha.VmRestartedByHAEvent",
+	}
+}
+
+// ClusterHAHostFailures tracks the vSphere HA host failure and VM restart
+// events recorded for a specific ClusterComputeResource within the history
+// window.
+type ClusterHAHostFailures struct {
+
+	// Cluster is the name of the cluster.
+	Cluster string
+
+	// HAEnabled indicates whether vSphere HA is currently enabled for the
+	// cluster. Events recorded while HA was enabled are evaluated regardless.
+	HAEnabled bool
+
+	// HostFailures is the collection of host failure and failover events
+	// recorded for the cluster.
+	HostFailures VCenterEvents
+
+	// VMRestarts is the collection of VM restart events recorded for the
+	// cluster.
+	VMRestarts VCenterEvents
+
+	// Thresholds are the user-specified number of host failure events above
+	// which a WARNING or CRITICAL state is reached.
+	Thresholds VCenterEventThresholds
+}
+
+// ClusterHAHostFailuresSet is a collection of ClusterHAHostFailures values
+// evaluated as part of a single plugin execution.
+type ClusterHAHostFailuresSet struct {
+	Clusters []ClusterHAHostFailures
+
+	// Lookback is the history window evaluated for vSphere HA events.
+	Lookback time.Duration
+
+	// Thresholds are the user-specified number of host failure events per
+	// cluster above which a WARNING or CRITICAL state is reached.
+	Thresholds VCenterEventThresholds
+}
+
+// haEventHostName returns the name of the host associated with the given
+// vSphere HA event.
+func haEventHostName(event types.BaseEvent) string {
+	switch e := event.(type) {
+	case *types.DasHostFailedEvent:
+		return e.FailedHost.Name
+	case *types.DasHostIsolatedEvent:
+		return e.IsolatedHost.Name
+	}
+
+	return eventEntityName(event)
+}
+
+// NewClusterHAHostFailuresSet retrieves the vSphere HA host failure and VM
+// restart events recorded within the specified history window and groups
+// them by cluster for each of the given clusters. Events associated with
+// clusters other than those given are ignored.
+func NewClusterHAHostFailuresSet(
+	ctx context.Context,
+	c *vim25.Client,
+	clusters []mo.ClusterComputeResource,
+	lookback time.Duration,
+	thresholds VCenterEventThresholds,
+) (ClusterHAHostFailuresSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewClusterHAHostFailuresSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := ClusterHAHostFailuresSet{
+		Clusters:   make([]ClusterHAHostFailures, 0, len(clusters)),
+		Lookback:   lookback,
+		Thresholds: thresholds,
+	}
+
+	begin := time.Now().Add(-lookback)
+
+	filter := types.EventFilterSpec{
+		Time: &types.EventFilterSpecByTime{
+			BeginTime: &begin,
+		},
+		EventTypeId: append(HAHostFailureEventTypeIDs(), HAVMRestartEventTypeIDs()...),
+	}
+
+	events, err := GetEvents(ctx, c, filter)
+	if err != nil {
+		return ClusterHAHostFailuresSet{}, fmt.Errorf(
+			"failed to retrieve HA events: %w",
+			err,
+		)
+	}
+
+	index := make(map[string]int, len(clusters))
+	for i, cluster := range clusters {
+		index[cluster.Self.Value] = i
+		set.Clusters = append(set.Clusters, ClusterHAHostFailures{
+			Cluster:    cluster.Name,
+			HAEnabled:  IsHAEnabled(cluster),
+			Thresholds: thresholds,
+		})
+	}
+
+	for _, event := range events {
+		e := event.GetEvent()
+		if e.ComputeResource == nil {
+			continue
+		}
+
+		i, ok := index[e.ComputeResource.ComputeResource.Value]
+		if !ok {
+			continue
+		}
+
+		vce := NewVCenterEvent(event)
+
+		switch {
+		case textutils.InList(vce.TypeID, HAVMRestartEventTypeIDs(), true):
+			set.Clusters[i].VMRestarts = append(set.Clusters[i].VMRestarts, vce)
+
+		default:
+			vce.EntityName = haEventHostName(event)
+			set.Clusters[i].HostFailures = append(set.Clusters[i].HostFailures, vce)
+		}
+	}
+
+	return set, nil
+}
+
+// IsCriticalState indicates whether the number of host failure events
+// recorded for the cluster has crossed the CRITICAL threshold.
+func (chf ClusterHAHostFailures) IsCriticalState() bool {
+	return len(chf.HostFailures) > chf.Thresholds.Critical
+}
+
+// IsWarningState indicates whether the number of host failure events
+// recorded for the cluster has crossed the WARNING threshold (but not the
+// CRITICAL threshold).
+func (chf ClusterHAHostFailures) IsWarningState() bool {
+	return !chf.IsCriticalState() &&
+		len(chf.HostFailures) > chf.Thresholds.Warning
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterHAHostFailuresSet) NumCritical() int {
+	var num int
+	for _, chf := range set.Clusters {
+		if chf.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterHAHostFailuresSet) NumWarning() int {
+	var num int
+	for _, chf := range set.Clusters {
+		if chf.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHADisabled returns the number of clusters with vSphere HA disabled.
+func (set ClusterHAHostFailuresSet) NumHADisabled() int {
+	var num int
+	for _, chf := range set.Clusters {
+		if !chf.HAEnabled {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostFailures returns the number of host failure events recorded for
+// all evaluated clusters.
+func (set ClusterHAHostFailuresSet) NumHostFailures() int {
+	var num int
+	for _, chf := range set.Clusters {
+		num += len(chf.HostFailures)
+	}
+
+	return num
+}
+
+// NumVMRestarts returns the number of VM restart events recorded for all
+// evaluated clusters.
+func (set ClusterHAHostFailuresSet) NumVMRestarts() int {
+	var num int
+	for _, chf := range set.Clusters {
+		num += len(chf.VMRestarts)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set ClusterHAHostFailuresSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set ClusterHAHostFailuresSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ClusterHAHostFailuresPerfData generates performance data metrics from the
+// given collection of evaluated clusters. The number of host failure and VM
+// restart events is emitted for each cluster.
+func ClusterHAHostFailuresPerfData(set ClusterHAHostFailuresSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set.Clusters)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_ha_disabled",
+			Value: fmt.Sprintf("%d", set.NumHADisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "ha_host_failures",
+			Value: fmt.Sprintf("%d", set.NumHostFailures()),
+			Min:   "0",
+		},
+		{
+			Label: "ha_vm_restarts",
+			Value: fmt.Sprintf("%d", set.NumVMRestarts()),
+			Min:   "0",
+		},
+	}
+
+	for _, chf := range set.Clusters {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: PerfDataLabel(chf.Cluster, "ha_host_failures"),
+				Value: fmt.Sprintf("%d", len(chf.HostFailures)),
+				Warn:  fmt.Sprintf("%d", chf.Thresholds.Warning),
+				Crit:  fmt.Sprintf("%d", chf.Thresholds.Critical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(chf.Cluster, "ha_vm_restarts"),
+				Value: fmt.Sprintf("%d", len(chf.VMRestarts)),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// ClusterHAHostFailuresOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ClusterHAHostFailuresOneLineCheckSummary(
+	stateLabel string,
+	set ClusterHAHostFailuresSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterHAHostFailuresOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with HA host failures exceeding threshold in the last %s (%d host failures, %d VM restarts; evaluated %d clusters)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.Lookback,
+			set.NumHostFailures(),
+			set.NumVMRestarts(),
+			len(set.Clusters),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No clusters with HA host failures exceeding threshold in the last %s (%d host failures, %d VM restarts; evaluated %d clusters)",
+			stateLabel,
+			set.Lookback,
+			set.NumHostFailures(),
+			set.NumVMRestarts(),
+			len(set.Clusters),
+		)
+	}
+}
+
+// ClusterHAHostFailuresReport generates a summary of vSphere HA host failure
+// and VM restart events for the evaluated clusters along with various
+// verbose details intended to aid in troubleshooting check results at a
+// glance. This information is provided for use with the Long Service Output
+// field commonly displayed on the detailed service check results display in
+// the web UI or in the body of many notifications.
+func ClusterHAHostFailuresReport(
+	c *vim25.Client,
+	set ClusterHAHostFailuresSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterHAHostFailuresReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, chf := range set.Clusters {
+		var state string
+		switch {
+		case chf.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case chf.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s [%s]: %d host failures, %d VM restarts (HA enabled: %t)%s",
+			chf.Cluster,
+			state,
+			len(chf.HostFailures),
+			len(chf.VMRestarts),
+			chf.HAEnabled,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, vce := range chf.HostFailures {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: %s (host: %s): %s%s",
+				vce.Created.Local().Format(time.RFC3339),
+				vce.TypeID,
+				vce.EntityName,
+				vce.Message,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, vce := range chf.VMRestarts {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: VM %s restarted by HA%s",
+				vce.Created.Local().Format(time.RFC3339),
+				vce.EntityName,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	if len(set.Clusters) == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No clusters found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* History window: %s%s",
+		set.Lookback,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Host failures thresholds (per cluster): warning above %d, critical above %d%s",
+		set.Thresholds.Warning,
+		set.Thresholds.Critical,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Evaluated event types: [%s]%s",
+		strings.Join(append(HAHostFailureEventTypeIDs(), HAVMRestartEventTypeIDs()...), ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// haHostFailureEvents returns the given number of vSphere HA host failure
+// events.
+func haHostFailureEvents(num int) VCenterEvents {
+	events := make(VCenterEvents, 0, num)
+	for i := 0; i < num; i++ {
+		events = append(events, VCenterEvent{TypeID: "DasHostFailedEvent"})
+	}
+
+	return events
+}
+
+func TestHAEventHostName(t *testing.T) {
+	host := &types.HostEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "esx1"},
+	}
+
+	tests := map[string]struct {
+		event types.BaseEvent
+		want  string
+	}{
+		"host failed": {
+			event: &types.DasHostFailedEvent{
+				ClusterEvent: types.ClusterEvent{Event: types.Event{Host: host}},
+				FailedHost: types.HostEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "esx2"},
+				},
+			},
+			want: "esx2",
+		},
+		"host isolated": {
+			event: &types.DasHostIsolatedEvent{
+				ClusterEvent: types.ClusterEvent{Event: types.Event{Host: host}},
+				IsolatedHost: types.HostEventArgument{
+					EntityEventArgument: types.EntityEventArgument{Name: "esx3"},
+				},
+			},
+			want: "esx3",
+		},
+		"other HA event uses event entity": {
+			event: &types.EventEx{
+				Event: types.Event{Host: host},
+			},
+			want: "esx1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := haEventHostName(tt.event); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClusterHAHostFailuresState(t *testing.T) {
+	thresholds := VCenterEventThresholds{Warning: 0, Critical: 2}
+
+	tests := map[string]struct {
+		numHostFailures int
+		wantCritical    bool
+		wantWarning     bool
+	}{
+		"no host failures":         {numHostFailures: 0},
+		"above WARNING threshold":  {numHostFailures: 1, wantWarning: true},
+		"at CRITICAL threshold":    {numHostFailures: 2, wantWarning: true},
+		"above CRITICAL threshold": {numHostFailures: 3, wantCritical: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chf := ClusterHAHostFailures{
+				Cluster:      "cluster1",
+				HAEnabled:    true,
+				HostFailures: haHostFailureEvents(tt.numHostFailures),
+				Thresholds:   thresholds,
+			}
+
+			if got := chf.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := chf.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestClusterHAHostFailuresSetCounts(t *testing.T) {
+	thresholds := VCenterEventThresholds{Warning: 0, Critical: 1}
+
+	set := ClusterHAHostFailuresSet{
+		Clusters: []ClusterHAHostFailures{
+			{
+				Cluster:      "cluster1",
+				HAEnabled:    true,
+				HostFailures: haHostFailureEvents(2),
+				VMRestarts: VCenterEvents{
+					{TypeID: "com.vmware.vc.ha.VmRestartedByHAEvent"},
+					{TypeID: "com.vmware.vc.ha.VmRestartedByHAEvent"},
+					{TypeID: "com.vmware.vc.ha.VmRestartedByHAEvent"},
+				},
+				Thresholds: thresholds,
+			},
+			{
+				Cluster:      "cluster2",
+				HAEnabled:    true,
+				HostFailures: haHostFailureEvents(1),
+				Thresholds:   thresholds,
+			},
+			{
+				Cluster:    "cluster3",
+				HAEnabled:  false,
+				Thresholds: thresholds,
+			},
+		},
+		Thresholds: thresholds,
+	}
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL cluster; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING cluster; got %d", got)
+	}
+
+	if got := set.NumHADisabled(); got != 1 {
+		t.Errorf("want 1 cluster with HA disabled; got %d", got)
+	}
+
+	if got := set.NumHostFailures(); got != 3 {
+		t.Errorf("want 3 host failure events; got %d", got)
+	}
+
+	if got := set.NumVMRestarts(); got != 3 {
+		t.Errorf("want 3 VM restart events; got %d", got)
+	}
+
+	// Six summary metrics plus two metrics per cluster.
+	if got := len(ClusterHAHostFailuresPerfData(set)); got != 12 {
+		t.Errorf("want 12 performance data metrics; got %d", got)
+	}
+}
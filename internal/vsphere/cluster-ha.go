@@ -0,0 +1,449 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrClusterHAStatusCheckFailed indicates that vSphere HA is disabled or
+// degraded for one or more evaluated clusters.
+var ErrClusterHAStatusCheckFailed = errors.New("cluster HA status check failed")
+
+// ClusterHAStatus tracks vSphere HA configuration and runtime details for a
+// specific ClusterComputeResource and its member hosts.
+type ClusterHAStatus struct {
+	Cluster mo.ClusterComputeResource
+	Hosts   []mo.HostSystem
+	Levels  ClusterFailoverLevels
+
+	// Critical is the collection of issues detected which map to a
+	// CRITICAL state.
+	Critical []string
+
+	// Warning is the collection of issues detected which map to a WARNING
+	// state.
+	Warning []string
+}
+
+// ClusterHAStatusSet is a collection of ClusterHAStatus values.
+type ClusterHAStatusSet []ClusterHAStatus
+
+// clusterDasConfig returns the HA configuration for the given cluster. The
+// extended configuration details are preferred, falling back to the
+// (deprecated) configuration property if the extended details were not
+// retrieved.
+func clusterDasConfig(cluster mo.ClusterComputeResource) types.ClusterDasConfigInfo {
+	dasConfig := cluster.Configuration.DasConfig
+	if cfgEx, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx); ok {
+		dasConfig = cfgEx.DasConfig
+	}
+
+	return dasConfig
+}
+
+// IsHAEnabled indicates whether vSphere HA is enabled for the given cluster.
+func IsHAEnabled(cluster mo.ClusterComputeResource) bool {
+	dasConfig := clusterDasConfig(cluster)
+
+	return dasConfig.Enabled != nil && *dasConfig.Enabled
+}
+
+// IsHAAdmissionControlEnabled indicates whether vSphere HA admission control
+// is enabled for the given cluster.
+func IsHAAdmissionControlEnabled(cluster mo.ClusterComputeResource) bool {
+	dasConfig := clusterDasConfig(cluster)
+
+	return dasConfig.AdmissionControlEnabled != nil && *dasConfig.AdmissionControlEnabled
+}
+
+// haAgentStateSeverity maps the vSphere HA agent (FDM) availability state for
+// a host to a Nagios state label. An empty string is returned for healthy
+// states.
+func haAgentStateSeverity(state string) string {
+	switch types.ClusterDasFdmAvailabilityState(state) {
+	case types.ClusterDasFdmAvailabilityStateMaster,
+		types.ClusterDasFdmAvailabilityStateConnectedToMaster:
+		return ""
+
+	case types.ClusterDasFdmAvailabilityStateHostDown,
+		types.ClusterDasFdmAvailabilityStateInitializationError,
+		types.ClusterDasFdmAvailabilityStateUninitializationError,
+		types.ClusterDasFdmAvailabilityStateFdmUnreachable,
+		types.ClusterDasFdmAvailabilityStateNetworkIsolated:
+		return nagios.StateCRITICALLabel
+
+	default:
+		// election, uninitialized, networkPartitionedFromMaster, retry and
+		// any states not known at the time this was written.
+		return nagios.StateWARNINGLabel
+	}
+}
+
+// NewClusterHAStatus receives a ClusterComputeResource and the HostSystems
+// which are members of the cluster and evaluates vSphere HA configuration and
+// runtime details. Hosts in maintenance mode are not evaluated.
+func NewClusterHAStatus(cluster mo.ClusterComputeResource, hss []mo.HostSystem) ClusterHAStatus {
+
+	status := ClusterHAStatus{
+		Cluster: cluster,
+		Hosts:   hss,
+		Levels:  NewClusterFailoverLevels(cluster),
+	}
+
+	if !IsHAEnabled(cluster) {
+		status.Critical = append(status.Critical, "vSphere HA is disabled")
+
+		// Remaining checks are not applicable if HA is disabled.
+		return status
+	}
+
+	if !IsHAAdmissionControlEnabled(cluster) {
+		status.Warning = append(status.Warning, "HA admission control is disabled")
+	}
+
+	if status.Levels.Configured > 0 && status.Levels.Current < status.Levels.Configured {
+		status.Critical = append(status.Critical, fmt.Sprintf(
+			"configured failover capacity exceeded (can tolerate %d of %d configured host failures)",
+			status.Levels.Current,
+			status.Levels.Configured,
+		))
+	}
+
+	if policy, ok := clusterDasConfig(cluster).AdmissionControlPolicy.(*types.ClusterFailoverResourcesAdmissionControlPolicy); ok {
+		if summary, ok := cluster.Summary.(*types.ClusterComputeResourceSummary); ok {
+			if info, ok := summary.AdmissionControlInfo.(*types.ClusterFailoverResourcesAdmissionControlInfo); ok {
+				if info.CurrentCpuFailoverResourcesPercent < policy.CpuFailoverResourcesPercent {
+					status.Critical = append(status.Critical, fmt.Sprintf(
+						"configured CPU failover capacity exceeded (%d%% available, %d%% reserved)",
+						info.CurrentCpuFailoverResourcesPercent,
+						policy.CpuFailoverResourcesPercent,
+					))
+				}
+
+				if info.CurrentMemoryFailoverResourcesPercent < policy.MemoryFailoverResourcesPercent {
+					status.Critical = append(status.Critical, fmt.Sprintf(
+						"configured memory failover capacity exceeded (%d%% available, %d%% reserved)",
+						info.CurrentMemoryFailoverResourcesPercent,
+						policy.MemoryFailoverResourcesPercent,
+					))
+				}
+			}
+		}
+	}
+
+	for _, hs := range hss {
+		if hs.Runtime.InMaintenanceMode {
+			continue
+		}
+
+		state := "unknown"
+		if hs.Runtime.DasHostState != nil {
+			state = hs.Runtime.DasHostState.State
+		}
+
+		issue := fmt.Sprintf("host %s HA agent state is %s", hs.Name, state)
+
+		switch haAgentStateSeverity(state) {
+		case nagios.StateCRITICALLabel:
+			status.Critical = append(status.Critical, issue)
+		case nagios.StateWARNINGLabel:
+			status.Warning = append(status.Warning, issue)
+		}
+	}
+
+	return status
+
+}
+
+// GetClusterHAStatusSet retrieves the member hosts for each of the given
+// clusters and evaluates vSphere HA configuration and runtime details.
+func GetClusterHAStatusSet(ctx context.Context, c *vim25.Client, clusters []mo.ClusterComputeResource) (ClusterHAStatusSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterHAStatusSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterHAStatusSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		hss, err := GetClusterHostSystems(ctx, c, cluster, true)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, NewClusterHAStatus(cluster, hss))
+	}
+
+	return set, nil
+
+}
+
+// IsCriticalState indicates whether any issues mapping to a CRITICAL state
+// were detected for the cluster.
+func (chs ClusterHAStatus) IsCriticalState() bool {
+	return len(chs.Critical) > 0
+}
+
+// IsWarningState indicates whether any issues mapping to a WARNING state
+// (and none mapping to a CRITICAL state) were detected for the cluster.
+func (chs ClusterHAStatus) IsWarningState() bool {
+	return !chs.IsCriticalState() && len(chs.Warning) > 0
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterHAStatusSet) NumCritical() int {
+	var num int
+	for _, chs := range set {
+		if chs.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterHAStatusSet) NumWarning() int {
+	var num int
+	for _, chs := range set {
+		if chs.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHADisabled returns the number of clusters with vSphere HA disabled.
+func (set ClusterHAStatusSet) NumHADisabled() int {
+	var num int
+	for _, chs := range set {
+		if !IsHAEnabled(chs.Cluster) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHosts returns the number of member hosts across all evaluated clusters.
+func (set ClusterHAStatusSet) NumHosts() int {
+	var num int
+	for _, chs := range set {
+		num += len(chs.Hosts)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set ClusterHAStatusSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set ClusterHAStatusSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ClusterHAStatusPerfData generates performance data metrics from the given
+// collection of evaluated clusters. If a single cluster is evaluated the
+// current and configured HA failover levels are also emitted.
+func ClusterHAStatusPerfData(set ClusterHAStatusSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_ha_disabled",
+			Value: fmt.Sprintf("%d", set.NumHADisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", set.NumHosts()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) == 1 {
+		pd = append(pd, ClusterFailoverLevelsPerfData(set[0].Cluster)...)
+	}
+
+	return pd
+
+}
+
+// ClusterHAStatusOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func ClusterHAStatusOneLineCheckSummary(
+	stateLabel string,
+	set ClusterHAStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterHAStatusOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with HA issues detected (evaluated %d clusters, %d hosts)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumHosts(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No HA issues detected (evaluated %d clusters, %d hosts)",
+			stateLabel,
+			len(set),
+			set.NumHosts(),
+		)
+	}
+}
+
+// ClusterHAStatusReport generates a summary of vSphere HA status for the
+// evaluated clusters along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func ClusterHAStatusReport(
+	c *vim25.Client,
+	set ClusterHAStatusSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterHAStatusReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, chs := range set {
+		var state string
+		switch {
+		case chs.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case chs.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s [%s]:%s"+
+				"* HA enabled: %t%s"+
+				"* Admission control enabled: %t%s"+
+				"* Failover level (current/configured): %d/%d%s",
+			chs.Cluster.Name,
+			state,
+			nagios.CheckOutputEOL,
+			IsHAEnabled(chs.Cluster),
+			nagios.CheckOutputEOL,
+			IsHAAdmissionControlEnabled(chs.Cluster),
+			nagios.CheckOutputEOL,
+			chs.Levels.Current,
+			chs.Levels.Configured,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, issue := range chs.Critical {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateCRITICALLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range chs.Warning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateWARNINGLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// haStatusClusterConfig describes the vSphere HA configuration and runtime
+// details used to build a test cluster.
+type haStatusClusterConfig struct {
+	haEnabled               bool
+	admissionControlEnabled bool
+	failoverLevelConfigured int32
+	failoverLevelCurrent    int32
+	cpuReservedPercent      int32
+	cpuAvailablePercent     int32
+}
+
+// haStatusCluster returns a cluster with the given vSphere HA configuration
+// and runtime details.
+func haStatusCluster(cfg haStatusClusterConfig) mo.ClusterComputeResource {
+	return mo.ClusterComputeResource{
+		ComputeResource: mo.ComputeResource{
+			ManagedEntity: mo.ManagedEntity{Name: "cluster1"},
+			ConfigurationEx: &types.ClusterConfigInfoEx{
+				DasConfig: types.ClusterDasConfigInfo{
+					Enabled:                 &cfg.haEnabled,
+					AdmissionControlEnabled: &cfg.admissionControlEnabled,
+					AdmissionControlPolicy: &types.ClusterFailoverResourcesAdmissionControlPolicy{
+						FailoverLevel:                  cfg.failoverLevelConfigured,
+						CpuFailoverResourcesPercent:    cfg.cpuReservedPercent,
+						MemoryFailoverResourcesPercent: 25,
+					},
+				},
+			},
+			Summary: &types.ClusterComputeResourceSummary{
+				CurrentFailoverLevel: cfg.failoverLevelCurrent,
+				AdmissionControlInfo: &types.ClusterFailoverResourcesAdmissionControlInfo{
+					CurrentCpuFailoverResourcesPercent:    cfg.cpuAvailablePercent,
+					CurrentMemoryFailoverResourcesPercent: 50,
+				},
+			},
+		},
+	}
+}
+
+// haStatusHost returns a HostSystem with the given HA agent state and
+// maintenance mode state.
+func haStatusHost(name string, state types.ClusterDasFdmAvailabilityState, inMaintenance bool) mo.HostSystem {
+	return mo.HostSystem{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Runtime: types.HostRuntimeInfo{
+			InMaintenanceMode: inMaintenance,
+			DasHostState: &types.ClusterDasFdmHostState{
+				State: string(state),
+			},
+		},
+	}
+}
+
+func TestHAAgentStateSeverity(t *testing.T) {
+	tests := map[string]struct {
+		state types.ClusterDasFdmAvailabilityState
+		want  string
+	}{
+		"master":              {state: types.ClusterDasFdmAvailabilityStateMaster},
+		"connected to master": {state: types.ClusterDasFdmAvailabilityStateConnectedToMaster},
+		"host down":           {state: types.ClusterDasFdmAvailabilityStateHostDown, want: nagios.StateCRITICALLabel},
+		"network isolated":    {state: types.ClusterDasFdmAvailabilityStateNetworkIsolated, want: nagios.StateCRITICALLabel},
+		"FDM unreachable":     {state: types.ClusterDasFdmAvailabilityStateFdmUnreachable, want: nagios.StateCRITICALLabel},
+		"election":            {state: types.ClusterDasFdmAvailabilityStateElection, want: nagios.StateWARNINGLabel},
+		"partitioned":         {state: types.ClusterDasFdmAvailabilityStateNetworkPartitionedFromMaster, want: nagios.StateWARNINGLabel},
+		"unknown state":       {state: "unknown", want: nagios.StateWARNINGLabel},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := haAgentStateSeverity(string(tt.state)); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewClusterHAStatus(t *testing.T) {
+	healthy := haStatusClusterConfig{
+		haEnabled:               true,
+		admissionControlEnabled: true,
+		failoverLevelConfigured: 1,
+		failoverLevelCurrent:    1,
+		cpuReservedPercent:      25,
+		cpuAvailablePercent:     40,
+	}
+
+	healthyHosts := []mo.HostSystem{
+		haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateMaster, false),
+		haStatusHost("esx2", types.ClusterDasFdmAvailabilityStateConnectedToMaster, false),
+	}
+
+	with := func(modify func(*haStatusClusterConfig)) haStatusClusterConfig {
+		cfg := healthy
+		modify(&cfg)
+		return cfg
+	}
+
+	tests := map[string]struct {
+		cluster      haStatusClusterConfig
+		hosts        []mo.HostSystem
+		wantCritical []string
+		wantWarning  []string
+	}{
+		"healthy": {
+			cluster: healthy,
+			hosts:   healthyHosts,
+		},
+		"HA disabled skips remaining checks": {
+			cluster: haStatusClusterConfig{},
+			hosts: []mo.HostSystem{
+				haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateHostDown, false),
+			},
+			wantCritical: []string{"vSphere HA is disabled"},
+		},
+		"admission control disabled": {
+			cluster:     with(func(cfg *haStatusClusterConfig) { cfg.admissionControlEnabled = false }),
+			hosts:       healthyHosts,
+			wantWarning: []string{"HA admission control is disabled"},
+		},
+		"failover level exceeded": {
+			cluster: with(func(cfg *haStatusClusterConfig) { cfg.failoverLevelCurrent = 0 }),
+			hosts:   healthyHosts,
+			wantCritical: []string{
+				"configured failover capacity exceeded (can tolerate 0 of 1 configured host failures)",
+			},
+		},
+		"CPU failover capacity exceeded": {
+			cluster: with(func(cfg *haStatusClusterConfig) { cfg.cpuAvailablePercent = 10 }),
+			hosts:   healthyHosts,
+			wantCritical: []string{
+				"configured CPU failover capacity exceeded (10% available, 25% reserved)",
+			},
+		},
+		"HA agent election in progress": {
+			cluster: healthy,
+			hosts: []mo.HostSystem{
+				haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateElection, false),
+			},
+			wantWarning: []string{"host esx1 HA agent state is election"},
+		},
+		"HA agent network isolated": {
+			cluster: healthy,
+			hosts: []mo.HostSystem{
+				haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateMaster, false),
+				haStatusHost("esx2", types.ClusterDasFdmAvailabilityStateNetworkIsolated, false),
+			},
+			wantCritical: []string{"host esx2 HA agent state is networkIsolated"},
+		},
+		"HA agent state not reported": {
+			cluster:     healthy,
+			hosts:       []mo.HostSystem{{ManagedEntity: mo.ManagedEntity{Name: "esx1"}}},
+			wantWarning: []string{"host esx1 HA agent state is unknown"},
+		},
+		"host in maintenance mode not evaluated": {
+			cluster: healthy,
+			hosts: []mo.HostSystem{
+				haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateMaster, false),
+				haStatusHost("esx2", types.ClusterDasFdmAvailabilityStateHostDown, true),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chs := NewClusterHAStatus(haStatusCluster(tt.cluster), tt.hosts)
+
+			if d := cmp.Diff(tt.wantCritical, chs.Critical); d != "" {
+				t.Errorf("CRITICAL issues (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantWarning, chs.Warning); d != "" {
+				t.Errorf("WARNING issues (-want, +got):\n%s", d)
+			}
+
+			if got, want := chs.IsCriticalState(), len(tt.wantCritical) > 0; got != want {
+				t.Errorf("want CRITICAL state %t; got %t", want, got)
+			}
+
+			if got, want := chs.IsWarningState(), len(tt.wantCritical) == 0 && len(tt.wantWarning) > 0; got != want {
+				t.Errorf("want WARNING state %t; got %t", want, got)
+			}
+		})
+	}
+}
+
+func TestClusterHAStatusSetCounts(t *testing.T) {
+	hosts := []mo.HostSystem{
+		haStatusHost("esx1", types.ClusterDasFdmAvailabilityStateMaster, false),
+		haStatusHost("esx2", types.ClusterDasFdmAvailabilityStateConnectedToMaster, false),
+	}
+
+	set := ClusterHAStatusSet{
+		NewClusterHAStatus(haStatusCluster(haStatusClusterConfig{}), hosts),
+		NewClusterHAStatus(haStatusCluster(haStatusClusterConfig{haEnabled: true}), hosts),
+	}
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL cluster; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING cluster; got %d", got)
+	}
+
+	if got := set.NumHADisabled(); got != 1 {
+		t.Errorf("want 1 cluster with HA disabled; got %d", got)
+	}
+
+	if got := set.NumHosts(); got != 4 {
+		t.Errorf("want 4 member hosts; got %d", got)
+	}
+}
@@ -0,0 +1,543 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrClusterResourceUsageThresholdCrossed indicates that specified cluster
+// CPU or memory usage thresholds have been exceeded for one or more
+// clusters.
+var ErrClusterResourceUsageThresholdCrossed = errors.New("cluster CPU or memory usage exceeds specified threshold")
+
+// ClusterResourceUsageThresholds represents the user-specified cluster CPU
+// and memory usage percentage thresholds.
+type ClusterResourceUsageThresholds struct {
+	CPUWarning     int
+	CPUCritical    int
+	MemoryWarning  int
+	MemoryCritical int
+}
+
+// ClusterResourceUsage tracks CPU and memory usage details for a specific
+// ClusterComputeResource. Usage is calculated as the resources consumed by
+// all connected member hosts versus the effective resources of the cluster
+// (the aggregate resources of all hosts available for running VMs).
+type ClusterResourceUsage struct {
+	Cluster mo.ClusterComputeResource
+
+	// CPUEffective is the effective CPU capacity of the cluster in Hz.
+	CPUEffective float64
+
+	// CPUUsed is the amount of CPU used by all connected member hosts in Hz.
+	CPUUsed float64
+
+	// MemoryEffective is the effective memory capacity of the cluster in
+	// bytes.
+	MemoryEffective int64
+
+	// MemoryUsed is the amount of memory used by all connected member hosts
+	// in bytes.
+	MemoryUsed int64
+
+	// HostCPUSummaries is the per-host CPU usage breakdown.
+	HostCPUSummaries []HostSystemCPUSummary
+
+	// HostMemorySummaries is the per-host memory usage breakdown.
+	HostMemorySummaries []HostSystemMemorySummary
+
+	// HostsUnavailable is the collection of member hosts which are not
+	// connected and are not included in the usage figures.
+	HostsUnavailable []mo.HostSystem
+
+	Thresholds ClusterResourceUsageThresholds
+}
+
+// ClusterResourceUsageSet is a collection of ClusterResourceUsage values.
+type ClusterResourceUsageSet []ClusterResourceUsage
+
+// NewClusterResourceUsage receives a ClusterComputeResource and the
+// HostSystems which are members of the cluster and generates summary
+// information used to determine if usage levels have crossed user-specified
+// thresholds.
+func NewClusterResourceUsage(cluster mo.ClusterComputeResource, hss []mo.HostSystem, thresholds ClusterResourceUsageThresholds) (ClusterResourceUsage, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewClusterResourceUsage func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	summary, ok := cluster.Summary.(*types.ClusterComputeResourceSummary)
+	if !ok {
+		return ClusterResourceUsage{}, fmt.Errorf(
+			"summary for cluster %s unavailable: %w",
+			cluster.Name,
+			ErrHostSystemHardwarePropertiesUnavailable,
+		)
+	}
+
+	usage := ClusterResourceUsage{
+		Cluster: cluster,
+
+		// base value in MHz, convert to Hz
+		CPUEffective: float64(summary.EffectiveCpu) * MHz,
+
+		// base value in MB, convert to bytes
+		MemoryEffective: summary.EffectiveMemory * units.MB,
+
+		Thresholds: thresholds,
+	}
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			usage.HostsUnavailable = append(usage.HostsUnavailable, hs)
+
+			continue
+		}
+
+		// Per-host thresholds are not evaluated; the cluster thresholds are
+		// recorded for reference only.
+		cpuSummary, err := NewHostSystemCPUUsageSummary(
+			hs,
+			thresholds.CPUCritical,
+			thresholds.CPUWarning,
+		)
+		if err != nil {
+			return ClusterResourceUsage{}, fmt.Errorf(
+				"failed to evaluate CPU usage for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		memorySummary, err := NewHostSystemMemoryUsageSummary(
+			hs,
+			thresholds.MemoryCritical,
+			thresholds.MemoryWarning,
+		)
+		if err != nil {
+			return ClusterResourceUsage{}, fmt.Errorf(
+				"failed to evaluate memory usage for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		usage.CPUUsed += cpuSummary.CPUUsed
+		usage.MemoryUsed += memorySummary.MemoryUsed
+
+		usage.HostCPUSummaries = append(usage.HostCPUSummaries, cpuSummary)
+		usage.HostMemorySummaries = append(usage.HostMemorySummaries, memorySummary)
+	}
+
+	return usage, nil
+
+}
+
+// GetClusterResourceUsageSet retrieves the member hosts for each of the
+// given clusters and evaluates cluster CPU and memory usage against the
+// specified thresholds.
+func GetClusterResourceUsageSet(ctx context.Context, c *vim25.Client, clusters []mo.ClusterComputeResource, thresholds ClusterResourceUsageThresholds) (ClusterResourceUsageSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterResourceUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterResourceUsageSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		hss, err := GetClusterHostSystems(ctx, c, cluster, true)
+		if err != nil {
+			return nil, err
+		}
+
+		usage, err := NewClusterResourceUsage(cluster, hss, thresholds)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, usage)
+	}
+
+	return set, nil
+
+}
+
+// CPUUsedPercent returns the percentage of effective cluster CPU capacity
+// used by member hosts.
+func (cru ClusterResourceUsage) CPUUsedPercent() float64 {
+	if cru.CPUEffective == 0 {
+		return 0
+	}
+
+	return cru.CPUUsed / cru.CPUEffective * 100
+}
+
+// MemoryUsedPercent returns the percentage of effective cluster memory used
+// by member hosts.
+func (cru ClusterResourceUsage) MemoryUsedPercent() float64 {
+	if cru.MemoryEffective == 0 {
+		return 0
+	}
+
+	return float64(cru.MemoryUsed) / float64(cru.MemoryEffective) * 100
+}
+
+// IsCriticalState indicates whether cluster CPU or memory usage has crossed
+// the CRITICAL level threshold.
+func (cru ClusterResourceUsage) IsCriticalState() bool {
+	return cru.CPUUsedPercent() > float64(cru.Thresholds.CPUCritical) ||
+		cru.MemoryUsedPercent() > float64(cru.Thresholds.MemoryCritical)
+}
+
+// IsWarningState indicates whether cluster CPU or memory usage has crossed
+// the WARNING level threshold.
+func (cru ClusterResourceUsage) IsWarningState() bool {
+	return !cru.IsCriticalState() &&
+		(cru.CPUUsedPercent() > float64(cru.Thresholds.CPUWarning) ||
+			cru.MemoryUsedPercent() > float64(cru.Thresholds.MemoryWarning))
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterResourceUsageSet) NumCritical() int {
+	var num int
+	for _, cru := range set {
+		if cru.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterResourceUsageSet) NumWarning() int {
+	var num int
+	for _, cru := range set {
+		if cru.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHosts returns the number of member hosts across all evaluated clusters.
+func (set ClusterResourceUsageSet) NumHosts() int {
+	var num int
+	for _, cru := range set {
+		num += len(cru.HostCPUSummaries) + len(cru.HostsUnavailable)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set ClusterResourceUsageSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set ClusterResourceUsageSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ClusterResourceUsagePerfData generates performance data metrics from the
+// given collection of evaluated clusters. If a single cluster is evaluated
+// detailed usage metrics are emitted, otherwise usage percentage metrics are
+// emitted per cluster.
+func ClusterResourceUsagePerfData(set ClusterResourceUsageSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", set.NumHosts()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) == 1 {
+		cru := set[0]
+
+		return append(pd,
+			nagios.PerformanceData{
+				Label:             "cpu_usage",
+				Value:             fmt.Sprintf("%.2f", cru.CPUUsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", cru.Thresholds.CPUWarning),
+				Crit:              fmt.Sprintf("%d", cru.Thresholds.CPUCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             "cpu_effective",
+				Value:             fmt.Sprintf("%.2f", cru.CPUEffective),
+				UnitOfMeasurement: "Hz",
+				Min:               "0",
+			},
+			nagios.PerformanceData{
+				Label:             "cpu_used",
+				Value:             fmt.Sprintf("%.2f", cru.CPUUsed),
+				UnitOfMeasurement: "Hz",
+				Min:               "0",
+			},
+			nagios.PerformanceData{
+				Label:             "memory_usage",
+				Value:             fmt.Sprintf("%.2f", cru.MemoryUsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", cru.Thresholds.MemoryWarning),
+				Crit:              fmt.Sprintf("%d", cru.Thresholds.MemoryCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             "memory_effective",
+				Value:             fmt.Sprintf("%d", cru.MemoryEffective),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+			},
+			nagios.PerformanceData{
+				Label:             "memory_used",
+				Value:             fmt.Sprintf("%d", cru.MemoryUsed),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+			},
+		)
+	}
+
+	for _, cru := range set {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(cru.Cluster.Name, "cpu_usage"),
+				Value:             fmt.Sprintf("%.2f", cru.CPUUsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", cru.Thresholds.CPUWarning),
+				Crit:              fmt.Sprintf("%d", cru.Thresholds.CPUCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(cru.Cluster.Name, "memory_usage"),
+				Value:             fmt.Sprintf("%.2f", cru.MemoryUsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", cru.Thresholds.MemoryWarning),
+				Crit:              fmt.Sprintf("%d", cru.Thresholds.MemoryCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// ClusterResourceUsageOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ClusterResourceUsageOneLineCheckSummary(
+	stateLabel string,
+	set ClusterResourceUsageSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterResourceUsageOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if len(set) == 1 {
+		return fmt.Sprintf(
+			"%s: Cluster %s CPU usage is %.2f%% and memory usage is %.2f%% of effective capacity (%d hosts)",
+			stateLabel,
+			set[0].Cluster.Name,
+			set[0].CPUUsedPercent(),
+			set[0].MemoryUsedPercent(),
+			set.NumHosts(),
+		)
+	}
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with CPU or memory usage exceeding specified thresholds (evaluated %d clusters, %d hosts)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumHosts(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No clusters with CPU or memory usage exceeding specified thresholds (evaluated %d clusters, %d hosts)",
+			stateLabel,
+			len(set),
+			set.NumHosts(),
+		)
+	}
+}
+
+// ClusterResourceUsageReport generates a summary of cluster CPU and memory
+// usage along with a per-host breakdown and various verbose details intended
+// to aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body of
+// many notifications.
+func ClusterResourceUsageReport(
+	c *vim25.Client,
+	set ClusterResourceUsageSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterResourceUsageReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, cru := range set {
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s:%s%s"+
+				"* CPU%s"+
+				"** Effective: %s%s"+
+				"** Used: %s (%.2f%%)%s"+
+				"* Memory%s"+
+				"** Effective: %s%s"+
+				"** Used: %s (%.2f%%)%s",
+			cru.Cluster.Name,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			CPUSpeed(cru.CPUEffective),
+			nagios.CheckOutputEOL,
+			CPUSpeed(cru.CPUUsed),
+			cru.CPUUsedPercent(),
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			units.ByteSize(cru.MemoryEffective),
+			nagios.CheckOutputEOL,
+			units.ByteSize(cru.MemoryUsed),
+			cru.MemoryUsedPercent(),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Hosts%s",
+			nagios.CheckOutputEOL,
+		)
+
+		for i := range cru.HostCPUSummaries {
+			cpuSummary := cru.HostCPUSummaries[i]
+			memorySummary := cru.HostMemorySummaries[i]
+
+			var maintenanceMode string
+			if cpuSummary.HostSystem.Runtime.InMaintenanceMode {
+				maintenanceMode = " [maintenance mode]"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (CPU: %.2f%%, Memory: %.2f%%)%s%s",
+				cpuSummary.HostSystem.Name,
+				cpuSummary.CPUUsedPercent,
+				memorySummary.MemoryUsedPercent,
+				maintenanceMode,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, hs := range cru.HostsUnavailable {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (unavailable: %s)%s",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if len(cru.HostCPUSummaries)+len(cru.HostsUnavailable) == 0 {
+			_, _ = fmt.Fprintf(&report, "** None%s", nagios.CheckOutputEOL)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,179 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// resourceUsageCluster returns a cluster with the given effective CPU (MHz)
+// and memory (MB) capacity.
+func resourceUsageCluster(effectiveCPU int32, effectiveMemory int64) mo.ClusterComputeResource {
+	return mo.ClusterComputeResource{
+		ComputeResource: mo.ComputeResource{
+			ManagedEntity: mo.ManagedEntity{Name: "cluster1"},
+			Summary: &types.ClusterComputeResourceSummary{
+				ComputeResourceSummary: types.ComputeResourceSummary{
+					EffectiveCpu:    effectiveCPU,
+					EffectiveMemory: effectiveMemory,
+				},
+			},
+		},
+	}
+}
+
+// resourceUsageHost returns a HostSystem with 10 CPU cores of 1000 MHz, 64 GB
+// of memory and the given CPU (MHz) and memory (MB) usage.
+func resourceUsageHost(name string, cpuUsage int32, memoryUsage int32, state types.HostSystemConnectionState) mo.HostSystem {
+	return mo.HostSystem{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Runtime: types.HostRuntimeInfo{
+			ConnectionState: state,
+		},
+		Hardware: &types.HostHardwareInfo{
+			MemorySize: 64 * units.GB,
+		},
+		Summary: types.HostListSummary{
+			Hardware: &types.HostHardwareSummary{
+				NumCpuCores: 10,
+				CpuMhz:      1000,
+			},
+			QuickStats: types.HostListSummaryQuickStats{
+				OverallCpuUsage:    cpuUsage,
+				OverallMemoryUsage: memoryUsage,
+			},
+		},
+	}
+}
+
+func TestNewClusterResourceUsage(t *testing.T) {
+	thresholds := ClusterResourceUsageThresholds{
+		CPUWarning:     80,
+		CPUCritical:    90,
+		MemoryWarning:  80,
+		MemoryCritical: 90,
+	}
+
+	connected := types.HostSystemConnectionStateConnected
+
+	tests := map[string]struct {
+		hosts             []mo.HostSystem
+		wantCPUPercent    float64
+		wantMemoryPercent float64
+		wantUnavailable   int
+		wantCritical      bool
+		wantWarning       bool
+	}{
+		"below thresholds": {
+			hosts: []mo.HostSystem{
+				resourceUsageHost("esx1", 5000, 25000, connected),
+				resourceUsageHost("esx2", 5000, 25000, connected),
+			},
+			wantCPUPercent:    50,
+			wantMemoryPercent: 50,
+		},
+		"CPU above WARNING threshold": {
+			hosts: []mo.HostSystem{
+				resourceUsageHost("esx1", 8500, 25000, connected),
+				resourceUsageHost("esx2", 8500, 25000, connected),
+			},
+			wantCPUPercent:    85,
+			wantMemoryPercent: 50,
+			wantWarning:       true,
+		},
+		"CPU at CRITICAL threshold": {
+			hosts: []mo.HostSystem{
+				resourceUsageHost("esx1", 9000, 25000, connected),
+				resourceUsageHost("esx2", 9000, 25000, connected),
+			},
+			wantCPUPercent:    90,
+			wantMemoryPercent: 50,
+			wantWarning:       true,
+		},
+		"memory above CRITICAL threshold": {
+			hosts: []mo.HostSystem{
+				resourceUsageHost("esx1", 5000, 47500, connected),
+				resourceUsageHost("esx2", 5000, 47500, connected),
+			},
+			wantCPUPercent:    50,
+			wantMemoryPercent: 95,
+			wantCritical:      true,
+		},
+		"disconnected host not counted": {
+			hosts: []mo.HostSystem{
+				resourceUsageHost("esx1", 5000, 25000, connected),
+				resourceUsageHost("esx2", 20000, 100000, types.HostSystemConnectionStateNotResponding),
+			},
+			wantCPUPercent:    25,
+			wantMemoryPercent: 25,
+			wantUnavailable:   1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// 20 GHz and 100000 MB effective capacity.
+			cru, err := NewClusterResourceUsage(resourceUsageCluster(20000, 100000), tt.hosts, thresholds)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if got := cru.CPUUsedPercent(); math.Abs(got-tt.wantCPUPercent) > 0.01 {
+				t.Errorf("want CPU usage %.2f%%; got %.2f%%", tt.wantCPUPercent, got)
+			}
+
+			if got := cru.MemoryUsedPercent(); math.Abs(got-tt.wantMemoryPercent) > 0.01 {
+				t.Errorf("want memory usage %.2f%%; got %.2f%%", tt.wantMemoryPercent, got)
+			}
+
+			if got := len(cru.HostsUnavailable); got != tt.wantUnavailable {
+				t.Errorf("want %d unavailable hosts; got %d", tt.wantUnavailable, got)
+			}
+
+			if got, want := len(cru.HostCPUSummaries), len(tt.hosts)-tt.wantUnavailable; got != want {
+				t.Errorf("want %d host CPU summaries; got %d", want, got)
+			}
+
+			if got := cru.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := cru.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestClusterResourceUsageZeroCapacity(t *testing.T) {
+	cru, err := NewClusterResourceUsage(resourceUsageCluster(0, 0), nil, ClusterResourceUsageThresholds{})
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if cru.CPUUsedPercent() != 0 || cru.MemoryUsedPercent() != 0 {
+		t.Errorf("want 0%% usage without effective capacity; got CPU %.2f%%, memory %.2f%%",
+			cru.CPUUsedPercent(), cru.MemoryUsedPercent())
+	}
+}
+
+func TestClusterResourceUsageMissingSummary(t *testing.T) {
+	cluster := resourceUsageCluster(20000, 100000)
+	cluster.Summary = nil
+
+	_, err := NewClusterResourceUsage(cluster, nil, ClusterResourceUsageThresholds{})
+	if !errors.Is(err, ErrHostSystemHardwarePropertiesUnavailable) {
+		t.Errorf("want %v error; got %v", ErrHostSystemHardwarePropertiesUnavailable, err)
+	}
+}
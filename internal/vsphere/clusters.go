@@ -0,0 +1,495 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ClusterFailoverLevels tracks the vSphere HA failover level details for a
+// specific ClusterComputeResource.
+type ClusterFailoverLevels struct {
+	// Current is the number of host failures that the cluster can currently
+	// tolerate while still satisfying the failover requirements of all
+	// powered on VirtualMachines.
+	Current int32
+
+	// Configured is the number of host failures that the cluster has been
+	// configured to tolerate via HA admission control.
+	Configured int32
+}
+
+// GetClusters accepts a context, a connected client and a boolean value
+// indicating whether a subset of properties per ClusterComputeResource are
+// retrieved. A collection of ClusterComputeResources with requested
+// properties is returned. If requested, a subset of all available properties
+// will be retrieved (faster) instead of recursively fetching all properties
+// (about 2x as slow).
+func GetClusters(ctx context.Context, c *vim25.Client, propsSubset bool) ([]mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var clusters []mo.ClusterComputeResource
+
+	defer func(clusters *[]mo.ClusterComputeResource) {
+		logger.Printf(
+			"It took %v to execute GetClusters func (and retrieve %d ClusterComputeResources).\n",
+			time.Since(funcTimeStart),
+			len(*clusters),
+		)
+	}(&clusters)
+
+	err := getObjects(ctx, c, &clusters, c.ServiceContent.RootFolder, propsSubset, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve ClusterComputeResources: %w", err)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return strings.ToLower(clusters[i].Name) < strings.ToLower(clusters[j].Name)
+	})
+
+	return clusters, nil
+}
+
+// GetClusterByName accepts the name of a ClusterComputeResource, the name of
+// a datacenter and a boolean value indicating whether only a subset of
+// properties for the ClusterComputeResource should be returned. If requested,
+// a subset of all available properties will be retrieved (faster) instead of
+// recursively fetching all properties (about 2x as slow). If the datacenter
+// name is an empty string then the default datacenter will be used.
+func GetClusterByName(ctx context.Context, c *vim25.Client, clusterName string, datacenter string, propsSubset bool) (mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterByName func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var cluster mo.ClusterComputeResource
+	err := getObjectByName(ctx, c, &cluster, clusterName, datacenter, propsSubset)
+
+	if err != nil {
+		return mo.ClusterComputeResource{}, err
+	}
+
+	return cluster, nil
+
+}
+
+// GetHostSystemCluster accepts a HostSystem and retrieves the
+// ClusterComputeResource that the HostSystem is a member of. A boolean value
+// is returned indicating whether the HostSystem is a cluster member. For
+// standalone hosts (those whose parent is a ComputeResource) false is
+// returned without error.
+func GetHostSystemCluster(ctx context.Context, c *vim25.Client, hs mo.HostSystem, propsSubset bool) (mo.ClusterComputeResource, bool, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostSystemCluster func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if hs.Parent == nil {
+		return mo.ClusterComputeResource{}, false, fmt.Errorf(
+			"parent for host %s unavailable: %w",
+			hs.Name,
+			ErrHostSystemHardwarePropertiesUnavailable,
+		)
+	}
+
+	if hs.Parent.Type != MgObjRefTypeClusterComputeResource {
+		logger.Printf(
+			"host %s parent is of type %s; not a cluster member",
+			hs.Name,
+			hs.Parent.Type,
+		)
+
+		return mo.ClusterComputeResource{}, false, nil
+	}
+
+	// If the properties slice is nil, all properties are loaded.
+	var props []string
+	if propsSubset {
+		props = getClusterComputeResourcePropsSubset()
+	}
+
+	var cluster mo.ClusterComputeResource
+	err := property.DefaultCollector(c).RetrieveOne(ctx, *hs.Parent, props, &cluster)
+	if err != nil {
+		return mo.ClusterComputeResource{}, false, fmt.Errorf(
+			"failed to retrieve cluster for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	return cluster, true, nil
+
+}
+
+// NewClusterFailoverLevels receives a ClusterComputeResource and returns the
+// current and configured HA failover levels for the cluster. If HA admission
+// control is not configured using a policy that specifies a failover level
+// the configured level is reported as zero.
+func NewClusterFailoverLevels(cluster mo.ClusterComputeResource) ClusterFailoverLevels {
+
+	var levels ClusterFailoverLevels
+
+	if summary, ok := cluster.Summary.(*types.ClusterComputeResourceSummary); ok {
+		levels.Current = summary.CurrentFailoverLevel
+	}
+
+	dasConfig := clusterDasConfig(cluster)
+
+	levels.Configured = dasConfig.FailoverLevel
+
+	switch policy := dasConfig.AdmissionControlPolicy.(type) {
+	case *types.ClusterFailoverLevelAdmissionControlPolicy:
+		levels.Configured = policy.FailoverLevel
+	case *types.ClusterFailoverResourcesAdmissionControlPolicy:
+		levels.Configured = policy.FailoverLevel
+	case *types.ClusterFailoverHostAdmissionControlPolicy:
+		levels.Configured = policy.FailoverLevel
+	}
+
+	return levels
+
+}
+
+// ClusterFailoverLevelsPerfData receives a ClusterComputeResource and
+// generates performance data metrics for the current and configured HA
+// failover levels of the cluster.
+func ClusterFailoverLevelsPerfData(cluster mo.ClusterComputeResource) []nagios.PerformanceData {
+
+	levels := NewClusterFailoverLevels(cluster)
+
+	return []nagios.PerformanceData{
+		{
+			Label: "cluster_failover_level_current",
+			Value: fmt.Sprintf("%d", levels.Current),
+			Min:   "0",
+		},
+		{
+			Label: "cluster_failover_level_configured",
+			Value: fmt.Sprintf("%d", levels.Configured),
+			Min:   "0",
+		},
+	}
+
+}
+
+// ClustersFailoverLevelsPerfData receives a collection of
+// ClusterComputeResources and generates performance data metrics for the
+// current and configured HA failover levels of each cluster. Each metric
+// label is prefixed with the name of the cluster.
+func ClustersFailoverLevelsPerfData(clusters []mo.ClusterComputeResource) []nagios.PerformanceData {
+
+	pd := make([]nagios.PerformanceData, 0, len(clusters)*2)
+
+	for _, cluster := range clusters {
+		for _, metric := range ClusterFailoverLevelsPerfData(cluster) {
+			metric.Label = PerfDataLabel(cluster.Name, metric.Label)
+			pd = append(pd, metric)
+		}
+	}
+
+	return pd
+
+}
+
+// GetDatacenterClusters accepts a collection of Datacenters and a boolean
+// value indicating whether a subset of properties per ClusterComputeResource
+// are retrieved. A collection of the ClusterComputeResources within the
+// given Datacenters is returned.
+func GetDatacenterClusters(ctx context.Context, c *vim25.Client, dcs []mo.Datacenter, propsSubset bool) ([]mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var clusters []mo.ClusterComputeResource
+
+	defer func(clusters *[]mo.ClusterComputeResource) {
+		logger.Printf(
+			"It took %v to execute GetDatacenterClusters func (and retrieve %d ClusterComputeResources).\n",
+			time.Since(funcTimeStart),
+			len(*clusters),
+		)
+	}(&clusters)
+
+	for _, dc := range dcs {
+		var dcClusters []mo.ClusterComputeResource
+
+		err := getObjects(ctx, c, &dcClusters, dc.Reference(), propsSubset, true)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve ClusterComputeResources for datacenter %s: %w",
+				dc.Name,
+				err,
+			)
+		}
+
+		clusters = append(clusters, dcClusters...)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return strings.ToLower(clusters[i].Name) < strings.ToLower(clusters[j].Name)
+	})
+
+	return clusters, nil
+
+}
+
+// GetHostSystemsClusters accepts a collection of HostSystems and a boolean
+// value indicating whether a subset of properties per
+// ClusterComputeResource are retrieved. A collection of the
+// ClusterComputeResources with one or more of the given HostSystems as
+// members is returned, sorted by name. Standalone hosts are ignored.
+func GetHostSystemsClusters(ctx context.Context, c *vim25.Client, hss []mo.HostSystem, propsSubset bool) ([]mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var hsClusters []mo.ClusterComputeResource
+
+	defer func(clusters *[]mo.ClusterComputeResource) {
+		logger.Printf(
+			"It took %v to execute GetHostSystemsClusters func (and retrieve %d ClusterComputeResources).\n",
+			time.Since(funcTimeStart),
+			len(*clusters),
+		)
+	}(&hsClusters)
+
+	if len(hss) == 0 {
+		return hsClusters, nil
+	}
+
+	hostIDs := make(map[string]struct{}, len(hss))
+	for _, hs := range hss {
+		hostIDs[hs.Self.Value] = struct{}{}
+	}
+
+	clusters, err := GetClusters(ctx, c, propsSubset)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cluster := range clusters {
+		for _, host := range cluster.Host {
+			if _, ok := hostIDs[host.Value]; ok {
+				hsClusters = append(hsClusters, cluster)
+
+				break
+			}
+		}
+	}
+
+	return hsClusters, nil
+}
+
+// GetClusterHostSystems accepts a ClusterComputeResource and a boolean value
+// indicating whether a subset of properties per HostSystem are retrieved. A
+// collection of the HostSystems which are members of the cluster is returned.
+func GetClusterHostSystems(ctx context.Context, c *vim25.Client, cluster mo.ClusterComputeResource, propsSubset bool) ([]mo.HostSystem, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var hss []mo.HostSystem
+
+	defer func(hss *[]mo.HostSystem) {
+		logger.Printf(
+			"It took %v to execute GetClusterHostSystems func (and retrieve %d HostSystems).\n",
+			time.Since(funcTimeStart),
+			len(*hss),
+		)
+	}(&hss)
+
+	if len(cluster.Host) == 0 {
+		return hss, nil
+	}
+
+	// If the properties slice is nil, all properties are loaded.
+	var props []string
+	if propsSubset {
+		props = getHostSystemPropsSubset()
+	}
+
+	err := property.DefaultCollector(c).Retrieve(ctx, cluster.Host, props, &hss)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve hosts for cluster %s: %w",
+			cluster.Name,
+			err,
+		)
+	}
+
+	sort.Slice(hss, func(i, j int) bool {
+		return strings.ToLower(hss[i].Name) < strings.ToLower(hss[j].Name)
+	})
+
+	return hss, nil
+
+}
+
+// GetClustersByNames accepts a list of ClusterComputeResource names, the
+// name of a datacenter and a boolean value indicating whether only a subset
+// of properties for each ClusterComputeResource should be returned. If the
+// list of names is empty all visible clusters are returned. If the
+// datacenter name is an empty string then the default datacenter will be
+// used when retrieving clusters by name.
+func GetClustersByNames(ctx context.Context, c *vim25.Client, clusterNames []string, datacenter string, propsSubset bool) ([]mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClustersByNames func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if len(clusterNames) == 0 {
+		return GetClusters(ctx, c, propsSubset)
+	}
+
+	clusters := make([]mo.ClusterComputeResource, 0, len(clusterNames))
+	for _, clusterName := range clusterNames {
+		cluster, err := GetClusterByName(ctx, c, clusterName, datacenter, propsSubset)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve cluster %s: %w",
+				clusterName,
+				err,
+			)
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+
+}
+
+// GetNumTotalClusters returns the count of all ClusterComputeResources in
+// the inventory.
+func GetNumTotalClusters(ctx context.Context, client *vim25.Client) (int, error) {
+	funcTimeStart := time.Now()
+
+	var numAllClusters int
+
+	defer func(allClusters *int) {
+		logger.Printf(
+			"It took %v to execute GetNumTotalClusters func (and count %d Clusters).\n",
+			time.Since(funcTimeStart),
+			*allClusters,
+		)
+	}(&numAllClusters)
+
+	clusters, retrieveErr := GetClusters(ctx, client, true)
+	if retrieveErr != nil {
+		logger.Printf(
+			"error retrieving list of all clusters: %v",
+			retrieveErr,
+		)
+
+		return 0, fmt.Errorf(
+			"error retrieving list of all clusters: %w",
+			retrieveErr,
+		)
+	}
+
+	numAllClusters = len(clusters)
+
+	return numAllClusters, nil
+}
+
+// validateClusters verifies that all explicitly specified
+// ClusterComputeResources exist in the inventory.
+func validateClusters(ctx context.Context, client *vim25.Client, filterOptions VMsFilterOptions) error {
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute validateClusters func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.ClusterNamesIncluded) > 0 || len(filterOptions.ClusterNamesExcluded) > 0:
+		logger.Println("Validating clusters")
+
+		clusters, retrieveErr := GetClusters(ctx, client, true)
+		if retrieveErr != nil {
+			return fmt.Errorf(
+				"%v: %v",
+				ErrValidationOfIncludeExcludeClusterLists,
+				retrieveErr,
+			)
+		}
+
+		clusterNamesFound := make([]string, 0, len(clusters))
+		for _, cluster := range clusters {
+			clusterNamesFound = append(clusterNamesFound, cluster.Name)
+		}
+
+		specified := make([]string, 0, len(filterOptions.ClusterNamesIncluded)+len(filterOptions.ClusterNamesExcluded))
+		specified = append(specified, filterOptions.ClusterNamesIncluded...)
+		specified = append(specified, filterOptions.ClusterNamesExcluded...)
+
+		var notFound []string
+		for _, name := range specified {
+			if !textutils.InList(name, clusterNamesFound, true) {
+				notFound = append(notFound, name)
+			}
+		}
+
+		if len(notFound) > 0 {
+			logger.Printf(
+				"%v: specified Clusters not found: %v",
+				ErrValidationOfIncludeExcludeClusterLists,
+				notFound,
+			)
+
+			return fmt.Errorf(
+				"%v: specified Clusters not found: %v",
+				ErrValidationOfIncludeExcludeClusterLists,
+				notFound,
+			)
+		}
+		logger.Println("Successfully validated clusters")
+
+		return nil
+	default:
+		logger.Println("Skipping cluster validation; cluster filtering not requested")
+		return nil
+	}
+}
@@ -7,6 +7,8 @@
 
 package vsphere
 
+import "time"
+
 // ParentResourcePool represents the hidden resource pool named Resources
 // which is present on virtual machine hosts. This resource pool is a parent
 // of all resource pools of the host. Including this pool in "eligible"
@@ -32,16 +34,17 @@ const CustomAttributeValNotSet string = "NotSet"
 
 // Managed Object Reference types
 const (
-	MgObjRefTypeAlarm           string = "Alarm"
-	MgObjRefTypeFolder          string = "Folder"
-	MgObjRefTypeDatacenter      string = "Datacenter"
-	MgObjRefTypeDatastore       string = "Datastore"
-	MgObjRefTypeComputeResource string = "ComputeResource"
-	MgObjRefTypeResourcePool    string = "ResourcePool"
-	MgObjRefTypeHostSystem      string = "HostSystem"
-	MgObjRefTypeNetwork         string = "Network"
-	MgObjRefTypeVirtualMachine  string = "VirtualMachine"
-	MgObjRefTypeVirtualApp      string = "VirtualApp"
+	MgObjRefTypeAlarm                  string = "Alarm"
+	MgObjRefTypeFolder                 string = "Folder"
+	MgObjRefTypeDatacenter             string = "Datacenter"
+	MgObjRefTypeDatastore              string = "Datastore"
+	MgObjRefTypeComputeResource        string = "ComputeResource"
+	MgObjRefTypeClusterComputeResource string = "ClusterComputeResource"
+	MgObjRefTypeResourcePool           string = "ResourcePool"
+	MgObjRefTypeHostSystem             string = "HostSystem"
+	MgObjRefTypeNetwork                string = "Network"
+	MgObjRefTypeVirtualMachine         string = "VirtualMachine"
+	MgObjRefTypeVirtualApp             string = "VirtualApp"
 )
 
 // used with snapshots reports that provide Long Service Output
@@ -76,6 +79,26 @@ const (
 	alarmExcludeReasonEntityType         = "object type"
 	alarmExcludeReasonEntityName         = "object name"
 	alarmExcludeReasonEntityResourcePool = "resource pool"
+	alarmExcludeReasonAlarmAge           = "alarm age"
+	alarmExcludeReasonEntityTag          = "object tag"
+	alarmExcludeReasonMaintenanceMode    = "maintenance mode"
+)
+
+// Substring filtering keywords supported by VCenterEvents.filterBySubstring()
+// method
+const (
+	eventMessage  string = "EventMessage"
+	eventEntity   string = "EventEntity"
+	eventUserName string = "EventUserName"
+)
+
+// used to track why a VCenterEvent was excluded, displayed in
+// LongServiceOutput/report.
+const (
+	eventExcludeReasonTypeID     = "event type"
+	eventExcludeReasonMessage    = "event message"
+	eventExcludeReasonEntityName = "object name"
+	eventExcludeReasonUserName   = "user name"
 )
 
 // Datastore Performance metrics
@@ -90,3 +113,13 @@ const (
 	// readIOPS     string = "ReadIOPS"
 	// writeIOPS    string = "WriteIOPS"
 )
+
+// datastoreVMRetrievalAttempts is the number of attempts made to resolve a
+// VirtualMachine reference listed by a Datastore before the reference is
+// skipped as unresolvable (e.g., an orphaned entry or a VM removed while
+// properties were being retrieved).
+const datastoreVMRetrievalAttempts int = 3
+
+// datastoreVMRetrievalRetryDelay is the delay between attempts to resolve a
+// VirtualMachine reference listed by a Datastore.
+const datastoreVMRetrievalRetryDelay time.Duration = 500 * time.Millisecond
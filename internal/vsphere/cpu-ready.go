@@ -0,0 +1,382 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineCPUReadyThresholdCrossed indicates that specified CPU
+// ready or co-stop thresholds have been exceeded for one or more
+// VirtualMachines.
+var ErrVirtualMachineCPUReadyThresholdCrossed = errors.New("cpu ready or co-stop exceeds specified threshold")
+
+// Performance counters used to evaluate CPU scheduling contention.
+const (
+	PerfCounterCPUReady  string = "cpu.ready.summation"
+	PerfCounterCPUCoStop string = "cpu.costop.summation"
+)
+
+// CPUReadySampleCount is the number of real-time samples evaluated for each
+// VirtualMachine. At the 20 second real-time sampling interval this covers
+// the most recent five minutes.
+const CPUReadySampleCount int32 = 15
+
+// VMCPUReadyThresholds represents the user-specified CPU ready and co-stop
+// percentage thresholds.
+type VMCPUReadyThresholds struct {
+	ReadyWarning   int
+	ReadyCritical  int
+	CoStopWarning  int
+	CoStopCritical int
+}
+
+// VMCPUReadyMetrics represents the CPU ready and co-stop values for a
+// VirtualMachine averaged over the evaluated samples and normalized per
+// vCPU.
+type VMCPUReadyMetrics struct {
+	VM            mo.VirtualMachine
+	ReadyPercent  float64
+	CoStopPercent float64
+	Samples       int
+	Thresholds    VMCPUReadyThresholds
+}
+
+// VMCPUReadySet is a collection of VMCPUReadyMetrics values.
+type VMCPUReadySet []VMCPUReadyMetrics
+
+// cpuSchedulingPercent converts a collection of summation samples (in
+// milliseconds per sampling interval) to a percentage of the available time
+// per vCPU.
+func cpuSchedulingPercent(samples []int64, numCPU int32) float64 {
+	if len(samples) == 0 || numCPU < 1 {
+		return 0
+	}
+
+	var total int64
+	for _, sample := range samples {
+		total += sample
+	}
+
+	available := float64(len(samples)) *
+		float64(PerfRealtimeIntervalSeconds) * 1000 *
+		float64(numCPU)
+
+	return float64(total) / available * 100
+}
+
+// GetVMCPUReadySet retrieves recent CPU ready and co-stop samples for the
+// given VirtualMachines and evaluates them against the specified
+// thresholds. VirtualMachines without available samples (e.g., powered off)
+// are omitted from the results.
+func GetVMCPUReadySet(ctx context.Context, c *vim25.Client, vms []mo.VirtualMachine, thresholds VMCPUReadyThresholds) (VMCPUReadySet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVMCPUReadySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	counterIDs, err := GetPerfCounterIDs(ctx, c, PerfCounterCPUReady, PerfCounterCPUCoStop)
+	if err != nil {
+		return nil, err
+	}
+	readyID := counterIDs[PerfCounterCPUReady]
+	coStopID := counterIDs[PerfCounterCPUCoStop]
+
+	entities := make([]types.ManagedObjectReference, 0, len(vms))
+	for _, vm := range vms {
+		entities = append(entities, vm.Reference())
+	}
+
+	samples, err := QueryRealtimePerfSamples(
+		ctx,
+		c,
+		entities,
+		[]int32{readyID, coStopID},
+		CPUReadySampleCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(VMCPUReadySet, 0, len(vms))
+	for _, vm := range vms {
+		vmSamples, ok := samples[vm.Self.Value]
+		if !ok {
+			logger.Printf("no performance samples for VM %s; skipping", vm.Name)
+
+			continue
+		}
+
+		numCPU := vm.Summary.Config.NumCpu
+
+		set = append(set, VMCPUReadyMetrics{
+			VM:            vm,
+			ReadyPercent:  cpuSchedulingPercent(vmSamples[readyID], numCPU),
+			CoStopPercent: cpuSchedulingPercent(vmSamples[coStopID], numCPU),
+			Samples:       len(vmSamples[readyID]),
+			Thresholds:    thresholds,
+		})
+	}
+
+	return set, nil
+
+}
+
+// IsCriticalState indicates whether CPU ready or co-stop values have crossed
+// the CRITICAL level threshold.
+func (m VMCPUReadyMetrics) IsCriticalState() bool {
+	return m.ReadyPercent > float64(m.Thresholds.ReadyCritical) ||
+		m.CoStopPercent > float64(m.Thresholds.CoStopCritical)
+}
+
+// IsWarningState indicates whether CPU ready or co-stop values have crossed
+// the WARNING level threshold.
+func (m VMCPUReadyMetrics) IsWarningState() bool {
+	return !m.IsCriticalState() &&
+		(m.ReadyPercent > float64(m.Thresholds.ReadyWarning) ||
+			m.CoStopPercent > float64(m.Thresholds.CoStopWarning))
+}
+
+// Offending returns the VirtualMachines whose CPU ready or co-stop values
+// have crossed the WARNING or CRITICAL level thresholds, sorted by CPU ready
+// value in descending order.
+func (set VMCPUReadySet) Offending() VMCPUReadySet {
+	offending := make(VMCPUReadySet, 0, len(set))
+	for _, m := range set {
+		if m.IsCriticalState() || m.IsWarningState() {
+			offending = append(offending, m)
+		}
+	}
+
+	sort.Slice(offending, func(i, j int) bool {
+		return offending[i].ReadyPercent > offending[j].ReadyPercent
+	})
+
+	return offending
+}
+
+// NumCritical returns the number of VirtualMachines in a CRITICAL state.
+func (set VMCPUReadySet) NumCritical() int {
+	var num int
+	for _, m := range set {
+		if m.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VirtualMachines in a WARNING state.
+func (set VMCPUReadySet) NumWarning() int {
+	var num int
+	for _, m := range set {
+		if m.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated VirtualMachine is in a
+// CRITICAL state.
+func (set VMCPUReadySet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated VirtualMachine is in a
+// WARNING state.
+func (set VMCPUReadySet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VMCPUReadyPerfData generates performance data metrics from the given
+// collection of evaluated VirtualMachines. Summary metrics are always
+// emitted, per-VM metrics are emitted for offending VirtualMachines only.
+func VMCPUReadyPerfData(set VMCPUReadySet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "vms_with_cpu_ready_samples",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "vms_cpu_ready_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_cpu_ready_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+	}
+
+	for _, m := range set.Offending() {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "cpu_ready"),
+				Value:             fmt.Sprintf("%.2f", m.ReadyPercent),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.ReadyWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.ReadyCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "cpu_costop"),
+				Value:             fmt.Sprintf("%.2f", m.CoStopPercent),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.CoStopWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.CoStopCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// VMCPUReadyOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMCPUReadyOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMCPUReadySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMCPUReadyOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with high CPU ready or co-stop detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set.Offending()),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with high CPU ready or co-stop detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMCPUReadyReport generates a summary of VMs with high CPU ready or co-stop
+// values along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMCPUReadyReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMCPUReadySet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMCPUReadyReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with high CPU ready or co-stop:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	offending := set.Offending()
+
+	switch {
+	case len(offending) > 0:
+
+		for _, m := range offending {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (vCPUs: %d, ready: %.2f%%, co-stop: %.2f%%)%s",
+				m.VM.Name,
+				m.VM.Summary.Config.NumCpu,
+				m.ReadyPercent,
+				m.CoStopPercent,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+	default:
+
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+	}
+
+	if len(set) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sVMs with CPU ready samples: %d (sample period: %ds x %d)%s",
+			nagios.CheckOutputEOL,
+			len(set),
+			PerfRealtimeIntervalSeconds,
+			CPUReadySampleCount,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
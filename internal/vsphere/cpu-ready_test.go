@@ -0,0 +1,189 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// cpuReadyMetrics returns CPU ready metrics for a VM with the given name and
+// CPU ready and co-stop percentages.
+func cpuReadyMetrics(name string, ready float64, coStop float64) VMCPUReadyMetrics {
+	var vm mo.VirtualMachine
+	vm.Name = name
+
+	return VMCPUReadyMetrics{
+		VM:            vm,
+		ReadyPercent:  ready,
+		CoStopPercent: coStop,
+		Samples:       int(CPUReadySampleCount),
+		Thresholds: VMCPUReadyThresholds{
+			ReadyWarning:   5,
+			ReadyCritical:  10,
+			CoStopWarning:  3,
+			CoStopCritical: 6,
+		},
+	}
+}
+
+func TestCPUSchedulingPercent(t *testing.T) {
+	tests := map[string]struct {
+		samples []int64
+		numCPU  int32
+		want    float64
+	}{
+		"single sample, single vCPU": {
+			samples: []int64{2000},
+			numCPU:  1,
+			want:    10,
+		},
+		"multiple samples averaged": {
+			samples: []int64{1000, 2000, 3000},
+			numCPU:  1,
+			want:    10,
+		},
+		"normalized per vCPU": {
+			samples: []int64{1000, 2000, 3000},
+			numCPU:  2,
+			want:    5,
+		},
+		"entire interval": {
+			samples: []int64{20000, 20000},
+			numCPU:  1,
+			want:    100,
+		},
+		"no contention": {
+			samples: []int64{0, 0, 0},
+			numCPU:  4,
+		},
+		"no samples": {
+			numCPU: 2,
+		},
+		"vCPU count not reported": {
+			samples: []int64{2000},
+		},
+		"invalid vCPU count": {
+			samples: []int64{2000},
+			numCPU:  -1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := cpuSchedulingPercent(tt.samples, tt.numCPU)
+			if math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("want %.4f%%; got %.4f%%", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMCPUReadyMetricsState(t *testing.T) {
+	tests := map[string]struct {
+		ready        float64
+		coStop       float64
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below thresholds":                {ready: 1.5, coStop: 0.5},
+		"at WARNING thresholds":           {ready: 5, coStop: 3},
+		"ready above WARNING threshold":   {ready: 7.5, wantWarning: true},
+		"co-stop above WARNING threshold": {ready: 1, coStop: 4, wantWarning: true},
+		"at CRITICAL thresholds":          {ready: 10, coStop: 6, wantWarning: true},
+		"ready above CRITICAL threshold":  {ready: 12, wantCritical: true},
+		"co-stop above CRITICAL threshold": {
+			ready:        7,
+			coStop:       8,
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := cpuReadyMetrics("vm1", tt.ready, tt.coStop)
+
+			if got := m.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := m.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVMCPUReadySetOffending(t *testing.T) {
+	set := VMCPUReadySet{
+		cpuReadyMetrics("vm1", 6, 0),
+		cpuReadyMetrics("vm2", 1, 0),
+		cpuReadyMetrics("vm3", 15, 0),
+		cpuReadyMetrics("vm4", 2, 4),
+	}
+
+	var got []string
+	for _, m := range set.Offending() {
+		got = append(got, m.VM.Name)
+	}
+
+	if d := cmp.Diff([]string{"vm3", "vm1", "vm4"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if set.NumCritical() != 1 || set.NumWarning() != 2 {
+		t.Errorf("want 1 CRITICAL and 2 WARNING VMs; got %d and %d", set.NumCritical(), set.NumWarning())
+	}
+
+	if !set.HasCriticalState() || !set.HasWarningState() {
+		t.Error("want CRITICAL and WARNING state")
+	}
+
+	if empty := (VMCPUReadySet{}); empty.HasCriticalState() || empty.HasWarningState() {
+		t.Error("want no CRITICAL or WARNING state without samples")
+	}
+}
+
+func TestVMCPUReadyPerfData(t *testing.T) {
+	set := VMCPUReadySet{
+		cpuReadyMetrics("vm1", 1, 0.5),
+		cpuReadyMetrics("vm2", 12.345, 2),
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_cpu_ready_samples", Value: "2", Min: "0"},
+		{Label: "vms_cpu_ready_critical", Value: "1", Min: "0"},
+		{Label: "vms_cpu_ready_warning", Value: "0", Min: "0"},
+		{
+			Label:             "vm2_cpu_ready",
+			Value:             "12.35",
+			UnitOfMeasurement: "%",
+			Warn:              "5",
+			Crit:              "10",
+			Min:               "0",
+			Max:               "100",
+		},
+		{
+			Label:             "vm2_cpu_costop",
+			Value:             "2.00",
+			UnitOfMeasurement: "%",
+			Warn:              "3",
+			Crit:              "6",
+			Min:               "0",
+			Max:               "100",
+		},
+	}
+
+	if d := cmp.Diff(want, VMCPUReadyPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -165,3 +165,79 @@ func GetDatacenters(ctx context.Context, c *vim25.Client, dcNames []string, prop
 	}
 
 }
+
+// GetNumTotalDatacenters returns the count of all Datacenters in the
+// inventory.
+func GetNumTotalDatacenters(ctx context.Context, client *vim25.Client) (int, error) {
+	funcTimeStart := time.Now()
+
+	var numAllDatacenters int
+
+	defer func(allDatacenters *int) {
+		logger.Printf(
+			"It took %v to execute GetNumTotalDatacenters func (and count %d Datacenters).\n",
+			time.Since(funcTimeStart),
+			*allDatacenters,
+		)
+	}(&numAllDatacenters)
+
+	dcs, retrieveErr := GetDatacenters(ctx, client, nil, true)
+	if retrieveErr != nil {
+		logger.Printf(
+			"error retrieving list of all datacenters: %v",
+			retrieveErr,
+		)
+
+		return 0, fmt.Errorf(
+			"error retrieving list of all datacenters: %w",
+			retrieveErr,
+		)
+	}
+
+	numAllDatacenters = len(dcs)
+
+	return numAllDatacenters, nil
+}
+
+// validateDatacenters verifies that all explicitly specified Datacenters
+// exist in the inventory.
+func validateDatacenters(ctx context.Context, client *vim25.Client, filterOptions VMsFilterOptions) error {
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute validateDatacenters func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.DatacentersIncluded) > 0 || len(filterOptions.DatacentersExcluded) > 0:
+		logger.Println("Validating datacenters")
+
+		datacenters := make([]string, 0, len(filterOptions.DatacentersIncluded)+len(filterOptions.DatacentersExcluded))
+		datacenters = append(datacenters, filterOptions.DatacentersIncluded...)
+		datacenters = append(datacenters, filterOptions.DatacentersExcluded...)
+
+		validateErr := ValidateDCs(ctx, client, datacenters)
+		if validateErr != nil {
+			logger.Printf(
+				"%v: %v",
+				ErrValidationOfIncludeExcludeDatacenterLists,
+				validateErr,
+			)
+
+			return fmt.Errorf(
+				"%v: %w",
+				ErrValidationOfIncludeExcludeDatacenterLists,
+				validateErr,
+			)
+		}
+		logger.Println("Successfully validated datacenters")
+
+		return nil
+	default:
+		logger.Println("Skipping datacenter validation; datacenter filtering not requested")
+		return nil
+	}
+}
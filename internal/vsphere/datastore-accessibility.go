@@ -0,0 +1,594 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrDatastoreAccessibilityCheckFailed indicates that one or more evaluated
+// datastores are inaccessible from one or more hosts, unexpectedly in
+// maintenance mode or mounted read-only.
+var ErrDatastoreAccessibilityCheckFailed = errors.New("datastore accessibility check failed")
+
+// DatastoreAccessibilityHostIssue represents a problem with the mount of a
+// Datastore on a specific host.
+type DatastoreAccessibilityHostIssue struct {
+	Host   string
+	Reason string
+}
+
+// DatastoreAccessibility tracks the accessibility and maintenance status of
+// a specific Datastore across all hosts which mount it.
+type DatastoreAccessibility struct {
+	Datastore mo.Datastore
+
+	// NumHosts is the number of connected hosts which mount the datastore.
+	NumHosts int
+
+	// NumHostsSkipped is the number of hosts which mount the datastore but
+	// were not evaluated due to not being connected.
+	NumHostsSkipped int
+
+	// Inaccessible is the collection of hosts from which the datastore is
+	// inaccessible.
+	Inaccessible []DatastoreAccessibilityHostIssue
+
+	// ReadOnly is the collection of hosts which mount the datastore
+	// read-only.
+	ReadOnly []string
+
+	// MaintenanceMode is the maintenance mode state of the datastore (e.g.,
+	// normal, enteringMaintenance, inMaintenance).
+	MaintenanceMode string
+
+	// AllowMaintenance indicates whether the datastore is permitted to be
+	// in maintenance mode.
+	AllowMaintenance bool
+
+	// AllowReadOnly indicates whether the datastore is permitted to be
+	// mounted read-only.
+	AllowReadOnly bool
+}
+
+// DatastoreAccessibilitySet is a collection of DatastoreAccessibility values
+// evaluated as part of a single plugin execution.
+type DatastoreAccessibilitySet struct {
+	Datastores []DatastoreAccessibility
+
+	// NumExcluded is the number of datastores in scope which were excluded
+	// from evaluation by name.
+	NumExcluded int
+}
+
+// datastoreMountHosts retrieves the name and connection state of each host
+// which mounts any of the given datastores indexed by host MOID.
+func datastoreMountHosts(ctx context.Context, c *vim25.Client, dss []mo.Datastore) (map[string]mo.HostSystem, error) {
+	seen := make(map[string]bool)
+
+	var refs []types.ManagedObjectReference
+	for _, ds := range dss {
+		for _, hostMount := range ds.Host {
+			if seen[hostMount.Key.Value] {
+				continue
+			}
+			seen[hostMount.Key.Value] = true
+			refs = append(refs, hostMount.Key)
+		}
+	}
+
+	hosts := make(map[string]mo.HostSystem, len(refs))
+	if len(refs) == 0 {
+		return hosts, nil
+	}
+
+	var hss []mo.HostSystem
+	pc := property.DefaultCollector(c)
+	if err := pc.Retrieve(ctx, refs, []string{"name", "runtime.connectionState"}, &hss); err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve hosts mounting datastores: %w",
+			err,
+		)
+	}
+
+	for _, hs := range hss {
+		hosts[hs.Self.Value] = hs
+	}
+
+	return hosts, nil
+}
+
+// NewDatastoreAccessibility evaluates the accessibility and maintenance
+// status of the given Datastore across all connected hosts which mount it.
+// Hosts are resolved by MOID from the given collection.
+func NewDatastoreAccessibility(
+	ds mo.Datastore,
+	hosts map[string]mo.HostSystem,
+	allowMaintenance bool,
+	allowReadOnly bool,
+) DatastoreAccessibility {
+
+	dsa := DatastoreAccessibility{
+		Datastore:        ds,
+		MaintenanceMode:  ds.Summary.MaintenanceMode,
+		AllowMaintenance: allowMaintenance,
+		AllowReadOnly:    allowReadOnly,
+	}
+
+	for _, hostMount := range ds.Host {
+		hs, ok := hosts[hostMount.Key.Value]
+		hostName := hs.Name
+		if !ok || hostName == "" {
+			hostName = hostMount.Key.Value
+		}
+
+		// Mount details reported for disconnected hosts are unreliable.
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			dsa.NumHostsSkipped++
+
+			continue
+		}
+
+		mountInfo := hostMount.MountInfo
+
+		// Datastores which are explicitly unmounted from a host are not
+		// expected to be accessible from the host.
+		if mountInfo.Mounted != nil && !*mountInfo.Mounted {
+			continue
+		}
+
+		dsa.NumHosts++
+
+		if mountInfo.Accessible != nil && !*mountInfo.Accessible {
+			reason := mountInfo.InaccessibleReason
+			if reason == "" {
+				reason = "unknown"
+			}
+
+			dsa.Inaccessible = append(dsa.Inaccessible, DatastoreAccessibilityHostIssue{
+				Host:   hostName,
+				Reason: reason,
+			})
+		}
+
+		if mountInfo.AccessMode == string(types.HostMountModeReadOnly) {
+			dsa.ReadOnly = append(dsa.ReadOnly, hostName)
+		}
+	}
+
+	sort.Slice(dsa.Inaccessible, func(i, j int) bool {
+		return strings.ToLower(dsa.Inaccessible[i].Host) < strings.ToLower(dsa.Inaccessible[j].Host)
+	})
+
+	sort.Slice(dsa.ReadOnly, func(i, j int) bool {
+		return strings.ToLower(dsa.ReadOnly[i]) < strings.ToLower(dsa.ReadOnly[j])
+	})
+
+	return dsa
+}
+
+// NewDatastoreAccessibilitySet receives a collection of Datastores and
+// evaluates the accessibility and maintenance status of each Datastore
+// matching the given lists of datastore names to include or exclude.
+// Datastores matching the given lists of datastore names permitted to be in
+// maintenance mode or mounted read-only are not flagged for those states.
+func NewDatastoreAccessibilitySet(
+	ctx context.Context,
+	c *vim25.Client,
+	dss []mo.Datastore,
+	includedDatastores []string,
+	excludedDatastores []string,
+	maintenanceDatastores []string,
+	readOnlyDatastores []string,
+) (DatastoreAccessibilitySet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewDatastoreAccessibilitySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var set DatastoreAccessibilitySet
+
+	evaluated := make([]mo.Datastore, 0, len(dss))
+	for _, ds := range dss {
+		switch {
+		case len(includedDatastores) > 0 &&
+			!inPatternList(ds.Name, includedDatastores):
+			set.NumExcluded++
+
+			continue
+
+		case len(excludedDatastores) > 0 &&
+			inPatternList(ds.Name, excludedDatastores):
+			set.NumExcluded++
+
+			continue
+		}
+
+		evaluated = append(evaluated, ds)
+	}
+
+	hosts, err := datastoreMountHosts(ctx, c, evaluated)
+	if err != nil {
+		return DatastoreAccessibilitySet{}, err
+	}
+
+	set.Datastores = make([]DatastoreAccessibility, 0, len(evaluated))
+	for _, ds := range evaluated {
+		set.Datastores = append(set.Datastores, NewDatastoreAccessibility(
+			ds,
+			hosts,
+			inPatternList(ds.Name, maintenanceDatastores),
+			inPatternList(ds.Name, readOnlyDatastores),
+		))
+	}
+
+	return set, nil
+}
+
+// InMaintenance indicates whether the datastore is in (or entering)
+// maintenance mode.
+func (dsa DatastoreAccessibility) InMaintenance() bool {
+	return dsa.MaintenanceMode == string(types.DatastoreSummaryMaintenanceModeStateInMaintenance) ||
+		dsa.MaintenanceMode == string(types.DatastoreSummaryMaintenanceModeStateEnteringMaintenance)
+}
+
+// IsCriticalState indicates whether the datastore is inaccessible from any
+// host which mounts it.
+func (dsa DatastoreAccessibility) IsCriticalState() bool {
+	return !dsa.Datastore.Summary.Accessible || len(dsa.Inaccessible) > 0
+}
+
+// IsWarningState indicates whether the datastore is unexpectedly in
+// maintenance mode or mounted read-only on any host (and not inaccessible).
+func (dsa DatastoreAccessibility) IsWarningState() bool {
+	if dsa.IsCriticalState() {
+		return false
+	}
+
+	return (dsa.InMaintenance() && !dsa.AllowMaintenance) ||
+		(len(dsa.ReadOnly) > 0 && !dsa.AllowReadOnly)
+}
+
+// NumEvaluated returns the number of datastores in the set.
+func (set DatastoreAccessibilitySet) NumEvaluated() int {
+	return len(set.Datastores)
+}
+
+// NumCritical returns the number of datastores in a CRITICAL state.
+func (set DatastoreAccessibilitySet) NumCritical() int {
+	var num int
+	for _, dsa := range set.Datastores {
+		if dsa.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores in a WARNING state.
+func (set DatastoreAccessibilitySet) NumWarning() int {
+	var num int
+	for _, dsa := range set.Datastores {
+		if dsa.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInMaintenance returns the number of datastores in (or entering)
+// maintenance mode, including those permitted to be in maintenance mode.
+func (set DatastoreAccessibilitySet) NumInMaintenance() int {
+	var num int
+	for _, dsa := range set.Datastores {
+		if dsa.InMaintenance() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumReadOnly returns the number of datastores mounted read-only on one or
+// more hosts, including those permitted to be mounted read-only.
+func (set DatastoreAccessibilitySet) NumReadOnly() int {
+	var num int
+	for _, dsa := range set.Datastores {
+		if len(dsa.ReadOnly) > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInaccessibleMounts returns the number of host mounts across all
+// evaluated datastores from which the datastore is inaccessible.
+func (set DatastoreAccessibilitySet) NumInaccessibleMounts() int {
+	var num int
+	for _, dsa := range set.Datastores {
+		num += len(dsa.Inaccessible)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated datastore is in a
+// CRITICAL state.
+func (set DatastoreAccessibilitySet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated datastore is in a WARNING
+// state.
+func (set DatastoreAccessibilitySet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// DatastoreAccessibilityPerfData generates performance data metrics from the
+// given collection of evaluated datastores.
+func DatastoreAccessibilityPerfData(set DatastoreAccessibilitySet) []nagios.PerformanceData {
+
+	return []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_in_maintenance",
+			Value: fmt.Sprintf("%d", set.NumInMaintenance()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_read_only",
+			Value: fmt.Sprintf("%d", set.NumReadOnly()),
+			Min:   "0",
+		},
+		{
+			Label: "inaccessible_mounts",
+			Value: fmt.Sprintf("%d", set.NumInaccessibleMounts()),
+			Min:   "0",
+		},
+	}
+
+}
+
+// DatastoreAccessibilityOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func DatastoreAccessibilityOneLineCheckSummary(
+	stateLabel string,
+	set DatastoreAccessibilitySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreAccessibilityOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState():
+		return fmt.Sprintf(
+			"%s: %d datastores inaccessible from one or more hosts and %d datastores with other issues detected (evaluated %d datastores)",
+			stateLabel,
+			set.NumCritical(),
+			set.NumWarning(),
+			set.NumEvaluated(),
+		)
+
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d datastores unexpectedly in maintenance mode or mounted read-only detected (evaluated %d datastores)",
+			stateLabel,
+			set.NumWarning(),
+			set.NumEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No datastore accessibility issues detected (evaluated %d datastores)",
+			stateLabel,
+			set.NumEvaluated(),
+		)
+	}
+}
+
+// DatastoreAccessibilityReport generates a summary of datastore
+// accessibility issues (including the affected hosts) along with various
+// verbose details intended to aid in troubleshooting check results at a
+// glance. This information is provided for use with the Long Service Output
+// field commonly displayed on the detailed service check results display in
+// the web UI or in the body of many notifications.
+func DatastoreAccessibilityReport(
+	c *vim25.Client,
+	set DatastoreAccessibilitySet,
+	includedDatastores []string,
+	excludedDatastores []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreAccessibilityReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No datastores found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	var numIssues int
+	for _, dsa := range set.Datastores {
+		var state string
+		switch {
+		case dsa.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case dsa.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			continue
+		}
+		numIssues++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: maintenance mode: %s, mounted on %d hosts (%d skipped)%s",
+			dsa.Datastore.Name,
+			state,
+			dsa.MaintenanceMode,
+			dsa.NumHosts,
+			dsa.NumHostsSkipped,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, issue := range dsa.Inaccessible {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** inaccessible from host %s: %s%s",
+				issue.Host,
+				issue.Reason,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if len(dsa.ReadOnly) > 0 && !dsa.AllowReadOnly {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** mounted read-only on hosts: [%s]%s",
+				strings.Join(dsa.ReadOnly, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numIssues == 0 && set.NumEvaluated() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No datastore accessibility issues detected%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all datastores in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("datastores available to cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all datastores in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d excluded, %d in maintenance mode, %d mounted read-only)%s",
+		set.NumEvaluated(),
+		set.NumExcluded,
+		set.NumInMaintenance(),
+		set.NumReadOnly(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,265 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// accessibilityMount describes the mount of a datastore on a specific host.
+type accessibilityMount struct {
+	hostID     string
+	mounted    bool
+	accessible bool
+	accessMode types.HostMountMode
+	reason     string
+}
+
+// accessibilityDatastore returns a datastore with the given maintenance mode
+// and host mounts.
+func accessibilityDatastore(maintenanceMode string, mounts ...accessibilityMount) mo.Datastore {
+	ds := mo.Datastore{
+		ManagedEntity: mo.ManagedEntity{Name: "ds1"},
+		Summary: types.DatastoreSummary{
+			Name:            "ds1",
+			Accessible:      true,
+			MaintenanceMode: maintenanceMode,
+		},
+	}
+
+	for _, m := range mounts {
+		mounted := m.mounted
+		accessible := m.accessible
+		ds.Host = append(ds.Host, types.DatastoreHostMount{
+			Key: types.ManagedObjectReference{Type: MgObjRefTypeHostSystem, Value: m.hostID},
+			MountInfo: types.HostMountInfo{
+				AccessMode:         string(m.accessMode),
+				Mounted:            &mounted,
+				Accessible:         &accessible,
+				InaccessibleReason: m.reason,
+			},
+		})
+	}
+
+	return ds
+}
+
+func TestNewDatastoreAccessibility(t *testing.T) {
+	host := func(name string, state types.HostSystemConnectionState) mo.HostSystem {
+		return mo.HostSystem{
+			ManagedEntity: mo.ManagedEntity{Name: name},
+			Runtime:       types.HostRuntimeInfo{ConnectionState: state},
+		}
+	}
+
+	hosts := map[string]mo.HostSystem{
+		"host-1": host("esx1", types.HostSystemConnectionStateConnected),
+		"host-2": host("ESX2", types.HostSystemConnectionStateConnected),
+		"host-3": host("esx3", types.HostSystemConnectionStateDisconnected),
+		"host-4": host("", types.HostSystemConnectionStateConnected),
+	}
+
+	normal := string(types.DatastoreSummaryMaintenanceModeStateNormal)
+	entering := string(types.DatastoreSummaryMaintenanceModeStateEnteringMaintenance)
+	inMaintenance := string(types.DatastoreSummaryMaintenanceModeStateInMaintenance)
+	readWrite := types.HostMountModeReadWrite
+	readOnly := types.HostMountModeReadOnly
+
+	tests := map[string]struct {
+		ds               mo.Datastore
+		allowMaintenance bool
+		allowReadOnly    bool
+		wantInaccessible []DatastoreAccessibilityHostIssue
+		wantReadOnly     []string
+		wantHosts        int
+		wantSkipped      int
+		wantCritical     bool
+		wantWarning      bool
+	}{
+		"accessible from all hosts": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+				accessibilityMount{"host-2", true, true, readWrite, ""},
+			),
+			wantHosts: 2,
+		},
+		"inaccessible hosts sorted with reasons": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-2", true, false, readWrite, "AllPathsDown_Start"},
+				accessibilityMount{"host-1", true, false, readWrite, ""},
+			),
+			wantInaccessible: []DatastoreAccessibilityHostIssue{
+				{Host: "esx1", Reason: "unknown"},
+				{Host: "ESX2", Reason: "AllPathsDown_Start"},
+			},
+			wantHosts:    2,
+			wantCritical: true,
+		},
+		"host without name uses MOID": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-4", true, false, readWrite, "PermanentDeviceLoss"},
+			),
+			wantInaccessible: []DatastoreAccessibilityHostIssue{
+				{Host: "host-4", Reason: "PermanentDeviceLoss"},
+			},
+			wantHosts:    1,
+			wantCritical: true,
+		},
+		"disconnected and unknown hosts are skipped": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+				accessibilityMount{"host-3", true, false, readWrite, ""},
+				accessibilityMount{"host-9", true, false, readWrite, ""},
+			),
+			wantHosts:   1,
+			wantSkipped: 2,
+		},
+		"unmounted host is ignored": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+				accessibilityMount{"host-2", false, false, readWrite, ""},
+			),
+			wantHosts: 1,
+		},
+		"in maintenance mode": {
+			ds: accessibilityDatastore(inMaintenance,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+			),
+			wantHosts:   1,
+			wantWarning: true,
+		},
+		"entering maintenance mode": {
+			ds: accessibilityDatastore(entering,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+			),
+			wantHosts:   1,
+			wantWarning: true,
+		},
+		"in permitted maintenance mode": {
+			ds: accessibilityDatastore(inMaintenance,
+				accessibilityMount{"host-1", true, true, readWrite, ""},
+			),
+			allowMaintenance: true,
+			wantHosts:        1,
+		},
+		"mounted read-only": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-2", true, true, readOnly, ""},
+				accessibilityMount{"host-1", true, true, readOnly, ""},
+			),
+			wantReadOnly: []string{"esx1", "ESX2"},
+			wantHosts:    2,
+			wantWarning:  true,
+		},
+		"mounted permitted read-only": {
+			ds: accessibilityDatastore(normal,
+				accessibilityMount{"host-1", true, true, readOnly, ""},
+			),
+			allowReadOnly: true,
+			wantReadOnly:  []string{"esx1"},
+			wantHosts:     1,
+		},
+		"inaccessible and in maintenance mode": {
+			ds: accessibilityDatastore(inMaintenance,
+				accessibilityMount{"host-1", true, false, readWrite, ""},
+			),
+			wantInaccessible: []DatastoreAccessibilityHostIssue{
+				{Host: "esx1", Reason: "unknown"},
+			},
+			wantHosts:    1,
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dsa := NewDatastoreAccessibility(tt.ds, hosts, tt.allowMaintenance, tt.allowReadOnly)
+
+			if d := cmp.Diff(tt.wantInaccessible, dsa.Inaccessible); d != "" {
+				t.Errorf("inaccessible (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantReadOnly, dsa.ReadOnly); d != "" {
+				t.Errorf("read-only (-want, +got):\n%s", d)
+			}
+
+			if dsa.NumHosts != tt.wantHosts {
+				t.Errorf("want %d evaluated hosts; got %d", tt.wantHosts, dsa.NumHosts)
+			}
+
+			if dsa.NumHostsSkipped != tt.wantSkipped {
+				t.Errorf("want %d skipped hosts; got %d", tt.wantSkipped, dsa.NumHostsSkipped)
+			}
+
+			if got := dsa.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := dsa.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestDatastoreAccessibilitySetCounts(t *testing.T) {
+	unavailable := accessibilityDatastore("")
+	unavailable.Summary.Accessible = false
+
+	set := DatastoreAccessibilitySet{
+		Datastores: []DatastoreAccessibility{
+			{Datastore: unavailable},
+			{
+				Datastore: accessibilityDatastore(""),
+				Inaccessible: []DatastoreAccessibilityHostIssue{
+					{Host: "esx1"},
+					{Host: "esx2"},
+				},
+			},
+			{
+				Datastore:       accessibilityDatastore(""),
+				MaintenanceMode: string(types.DatastoreSummaryMaintenanceModeStateInMaintenance),
+				ReadOnly:        []string{"esx1"},
+				AllowReadOnly:   true,
+			},
+			{
+				Datastore:        accessibilityDatastore(""),
+				MaintenanceMode:  string(types.DatastoreSummaryMaintenanceModeStateInMaintenance),
+				AllowMaintenance: true,
+			},
+		},
+	}
+
+	if got := set.NumEvaluated(); got != 4 {
+		t.Errorf("want 4 evaluated datastores; got %d", got)
+	}
+
+	if got := set.NumCritical(); got != 2 || !set.HasCriticalState() {
+		t.Errorf("want 2 CRITICAL datastores; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING datastore; got %d", got)
+	}
+
+	if got := set.NumInMaintenance(); got != 2 {
+		t.Errorf("want 2 datastores in maintenance mode; got %d", got)
+	}
+
+	if got := set.NumReadOnly(); got != 1 {
+		t.Errorf("want 1 read-only datastore; got %d", got)
+	}
+
+	if got := set.NumInaccessibleMounts(); got != 2 {
+		t.Errorf("want 2 inaccessible mounts; got %d", got)
+	}
+}
@@ -0,0 +1,541 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrDatastoreOvercommitThresholdCrossed indicates that the space
+// provisioned on a datastore has exceeded a specified percentage of the
+// datastore capacity.
+var ErrDatastoreOvercommitThresholdCrossed = errors.New("datastore overcommitment exceeds specified threshold")
+
+// DatastoreOvercommitSummary tracks the provisioned space for a specific
+// Datastore relative to the capacity of the Datastore.
+type DatastoreOvercommitSummary struct {
+	Datastore mo.Datastore
+
+	// Capacity is the maximum capacity of the datastore in bytes.
+	Capacity int64
+
+	// Used is the space currently used on the datastore in bytes. This
+	// includes space used by VM files (including snapshots) and files not
+	// associated with VMs.
+	Used int64
+
+	// Uncommitted is the additional space in bytes which may be used by VM
+	// files on the datastore (e.g., space not yet used by thin provisioned
+	// disks).
+	Uncommitted int64
+
+	// Provisioned is the total space in bytes which may eventually be used
+	// on the datastore (used plus uncommitted space).
+	Provisioned int64
+
+	// ProvisionedPercent is the provisioned space as a percentage of the
+	// datastore capacity. Values over 100 indicate overcommitment.
+	ProvisionedPercent float64
+
+	CriticalThreshold int
+	WarningThreshold  int
+}
+
+// DatastoreOvercommitSet is a collection of Datastore overcommit summaries
+// evaluated as part of a single plugin execution.
+type DatastoreOvercommitSet struct {
+
+	// Summaries is the collection of overcommit summaries for accessible
+	// datastores, sorted by provisioned space percentage (highest first).
+	Summaries []DatastoreOvercommitSummary
+
+	// Inaccessible is the collection of datastores which could not be
+	// evaluated due to being inaccessible. Inaccessible datastores are
+	// considered to be in a CRITICAL state.
+	Inaccessible []DatastoreSpaceUsageInaccessible
+
+	// NumExcluded is the number of datastores in scope which were excluded
+	// from evaluation by name.
+	NumExcluded int
+
+	CriticalThreshold int
+	WarningThreshold  int
+}
+
+// NewDatastoreOvercommitSummary generates a provisioned space summary for
+// the given Datastore using the capacity, free space and uncommitted space
+// values recorded in the Datastore summary.
+func NewDatastoreOvercommitSummary(
+	ds mo.Datastore,
+	criticalThreshold int,
+	warningThreshold int,
+) DatastoreOvercommitSummary {
+
+	summary := DatastoreOvercommitSummary{
+		Datastore:         ds,
+		Capacity:          ds.Summary.Capacity,
+		Used:              ds.Summary.Capacity - ds.Summary.FreeSpace,
+		Uncommitted:       ds.Summary.Uncommitted,
+		CriticalThreshold: criticalThreshold,
+		WarningThreshold:  warningThreshold,
+	}
+
+	summary.Provisioned = summary.Used + summary.Uncommitted
+
+	if summary.Capacity > 0 {
+		summary.ProvisionedPercent = float64(summary.Provisioned) /
+			float64(summary.Capacity) * 100
+	}
+
+	return summary
+}
+
+// IsCriticalState indicates whether the provisioned space percentage of the
+// datastore has exceeded the CRITICAL threshold.
+func (dos DatastoreOvercommitSummary) IsCriticalState() bool {
+	return dos.ProvisionedPercent >= float64(dos.CriticalThreshold)
+}
+
+// IsWarningState indicates whether the provisioned space percentage of the
+// datastore has exceeded the WARNING threshold but not the CRITICAL
+// threshold.
+func (dos DatastoreOvercommitSummary) IsWarningState() bool {
+	return dos.ProvisionedPercent >= float64(dos.WarningThreshold) &&
+		dos.ProvisionedPercent < float64(dos.CriticalThreshold)
+}
+
+// Overcommitted returns the provisioned space in bytes in excess of the
+// datastore capacity. Zero is returned if the datastore is not
+// overcommitted.
+func (dos DatastoreOvercommitSummary) Overcommitted() int64 {
+	if dos.Provisioned <= dos.Capacity {
+		return 0
+	}
+
+	return dos.Provisioned - dos.Capacity
+}
+
+// NewDatastoreOvercommitSet receives a collection of Datastores and
+// generates overcommit summaries for each Datastore matching the given lists
+// of datastore names to include or exclude. Inaccessible datastores are
+// recorded separately as their metadata is unreliable.
+func NewDatastoreOvercommitSet(
+	dss []mo.Datastore,
+	includedDatastores []string,
+	excludedDatastores []string,
+	criticalThreshold int,
+	warningThreshold int,
+) DatastoreOvercommitSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewDatastoreOvercommitSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := DatastoreOvercommitSet{
+		Summaries:         make([]DatastoreOvercommitSummary, 0, len(dss)),
+		CriticalThreshold: criticalThreshold,
+		WarningThreshold:  warningThreshold,
+	}
+
+	for _, ds := range dss {
+		switch {
+		case len(includedDatastores) > 0 &&
+			!inPatternList(ds.Name, includedDatastores):
+			set.NumExcluded++
+
+			continue
+
+		case len(excludedDatastores) > 0 &&
+			inPatternList(ds.Name, excludedDatastores):
+			set.NumExcluded++
+
+			continue
+		}
+
+		if reasons, err := ValidateDatastoreAccessibility(ds); err != nil {
+			logger.Printf(
+				"datastore %s is inaccessible due to: [%s]",
+				ds.Name,
+				strings.Join(reasons, ", "),
+			)
+
+			set.Inaccessible = append(
+				set.Inaccessible,
+				DatastoreSpaceUsageInaccessible{
+					Datastore: ds.Name,
+					Reasons:   reasons,
+				},
+			)
+
+			continue
+		}
+
+		set.Summaries = append(
+			set.Summaries,
+			NewDatastoreOvercommitSummary(ds, criticalThreshold, warningThreshold),
+		)
+	}
+
+	// Highest provisioned space percentage first.
+	sort.Slice(set.Summaries, func(i, j int) bool {
+		if set.Summaries[i].ProvisionedPercent != set.Summaries[j].ProvisionedPercent {
+			return set.Summaries[i].ProvisionedPercent > set.Summaries[j].ProvisionedPercent
+		}
+
+		return strings.ToLower(set.Summaries[i].Datastore.Name) <
+			strings.ToLower(set.Summaries[j].Datastore.Name)
+	})
+
+	return set
+}
+
+// NumEvaluated returns the number of datastores in the set, including those
+// which are inaccessible.
+func (set DatastoreOvercommitSet) NumEvaluated() int {
+	return len(set.Summaries) + len(set.Inaccessible)
+}
+
+// NumCritical returns the number of datastores with provisioned space
+// exceeding the CRITICAL threshold.
+func (set DatastoreOvercommitSet) NumCritical() int {
+	var num int
+	for _, dos := range set.Summaries {
+		if dos.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores with provisioned space
+// exceeding the WARNING threshold, but not the CRITICAL threshold.
+func (set DatastoreOvercommitSet) NumWarning() int {
+	var num int
+	for _, dos := range set.Summaries {
+		if dos.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumOvercommitted returns the number of datastores with provisioned space
+// exceeding the datastore capacity.
+func (set DatastoreOvercommitSet) NumOvercommitted() int {
+	var num int
+	for _, dos := range set.Summaries {
+		if dos.Overcommitted() > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// MaxProvisionedPercent returns the highest provisioned space percentage of
+// all evaluated datastores.
+func (set DatastoreOvercommitSet) MaxProvisionedPercent() float64 {
+	var highest float64
+	for _, dos := range set.Summaries {
+		if dos.ProvisionedPercent > highest {
+			highest = dos.ProvisionedPercent
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any datastore in the set is inaccessible
+// or has provisioned space exceeding the CRITICAL threshold.
+func (set DatastoreOvercommitSet) IsCriticalState() bool {
+	return len(set.Inaccessible) > 0 || set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any datastore in the set has provisioned
+// space exceeding the WARNING threshold.
+func (set DatastoreOvercommitSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// DatastoreOvercommitPerfData generates performance data metrics from the
+// given collection of datastore overcommit summaries. Aggregate metrics are
+// emitted along with provisioned space metrics for each datastore.
+func DatastoreOvercommitPerfData(set DatastoreOvercommitSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_inaccessible",
+			Value: fmt.Sprintf("%d", len(set.Inaccessible)),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_overcommitted",
+			Value: fmt.Sprintf("%d", set.NumOvercommitted()),
+			Min:   "0",
+		},
+		{
+			Label:             "datastore_provisioned_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxProvisionedPercent()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", set.WarningThreshold),
+			Crit:              fmt.Sprintf("%d", set.CriticalThreshold),
+			Min:               "0",
+		},
+	}
+
+	for _, dos := range set.Summaries {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(dos.Datastore.Name, "provisioned"),
+				Value:             fmt.Sprintf("%.2f", dos.ProvisionedPercent),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", dos.WarningThreshold),
+				Crit:              fmt.Sprintf("%d", dos.CriticalThreshold),
+				Min:               "0",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(dos.Datastore.Name, "uncommitted"),
+				Value:             fmt.Sprintf("%d", dos.Uncommitted),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// DatastoreOvercommitOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func DatastoreOvercommitOneLineCheckSummary(
+	stateLabel string,
+	set DatastoreOvercommitSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreOvercommitOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d datastores with provisioned space exceeding %d%% of capacity and %d inaccessible datastores detected (evaluated %d datastores, highest %.2f%%)",
+			stateLabel,
+			set.NumCritical(),
+			set.CriticalThreshold,
+			len(set.Inaccessible),
+			set.NumEvaluated(),
+			set.MaxProvisionedPercent(),
+		)
+
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d datastores with provisioned space exceeding %d%% of capacity detected (evaluated %d datastores, highest %.2f%%)",
+			stateLabel,
+			set.NumWarning(),
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxProvisionedPercent(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No datastores with provisioned space exceeding %d%% of capacity detected (evaluated %d datastores, highest %.2f%%)",
+			stateLabel,
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxProvisionedPercent(),
+		)
+	}
+}
+
+// DatastoreOvercommitReport generates a summary of per-datastore provisioned
+// space along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func DatastoreOvercommitReport(
+	c *vim25.Client,
+	set DatastoreOvercommitSet,
+	includedDatastores []string,
+	excludedDatastores []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreOvercommitReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No datastores found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, inaccessible := range set.Inaccessible {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: inaccessible due to: [%s]%s",
+			inaccessible.Datastore,
+			nagios.StateCRITICALLabel,
+			strings.Join(inaccessible.Reasons, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, dos := range set.Summaries {
+		var state string
+		switch {
+		case dos.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case dos.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %.2f%% provisioned (%s of %s capacity; %s used, %s uncommitted, %s overcommitted, %d VMs)%s",
+			dos.Datastore.Name,
+			state,
+			dos.ProvisionedPercent,
+			units.ByteSize(dos.Provisioned),
+			units.ByteSize(dos.Capacity),
+			units.ByteSize(dos.Used),
+			units.ByteSize(dos.Uncommitted),
+			units.ByteSize(dos.Overcommitted()),
+			len(dos.Datastore.Vm),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all datastores in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("datastores available to cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all datastores in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d excluded, %d inaccessible, %d overcommitted)%s",
+		set.NumEvaluated(),
+		set.NumExcluded,
+		len(set.Inaccessible),
+		set.NumOvercommitted(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// overcommitDatastore returns an accessible datastore with the given
+// capacity, free and uncommitted space values in GiB.
+func overcommitDatastore(name string, capacity, free, uncommitted int64) mo.Datastore {
+	return mo.Datastore{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Summary: types.DatastoreSummary{
+			Name:        name,
+			Accessible:  true,
+			Capacity:    capacity * units.GB,
+			FreeSpace:   free * units.GB,
+			Uncommitted: uncommitted * units.GB,
+		},
+	}
+}
+
+func TestNewDatastoreOvercommitSummary(t *testing.T) {
+	tests := map[string]struct {
+		ds                mo.Datastore
+		wantProvisioned   int64
+		wantPercent       float64
+		wantOvercommitted int64
+		wantCriticalState bool
+		wantWarningState  bool
+	}{
+		"thin provisioned below capacity": {
+			ds:              overcommitDatastore("ds1", 100, 60, 20),
+			wantProvisioned: 60 * units.GB,
+			wantPercent:     60,
+		},
+		"provisioned at capacity": {
+			ds:               overcommitDatastore("ds1", 100, 50, 50),
+			wantProvisioned:  100 * units.GB,
+			wantPercent:      100,
+			wantWarningState: true,
+		},
+		"overcommitted": {
+			ds:                overcommitDatastore("ds1", 100, 50, 80),
+			wantProvisioned:   130 * units.GB,
+			wantPercent:       130,
+			wantOvercommitted: 30 * units.GB,
+			wantWarningState:  true,
+		},
+		"overcommitted at CRITICAL threshold": {
+			ds:                overcommitDatastore("ds1", 100, 50, 100),
+			wantProvisioned:   150 * units.GB,
+			wantPercent:       150,
+			wantOvercommitted: 50 * units.GB,
+			wantCriticalState: true,
+		},
+		"capacity not reported": {
+			ds:                overcommitDatastore("ds1", 0, 0, 20),
+			wantProvisioned:   20 * units.GB,
+			wantOvercommitted: 20 * units.GB,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dos := NewDatastoreOvercommitSummary(tt.ds, 150, 100)
+
+			if dos.Provisioned != tt.wantProvisioned {
+				t.Errorf("want %d bytes provisioned; got %d", tt.wantProvisioned, dos.Provisioned)
+			}
+
+			if dos.ProvisionedPercent != tt.wantPercent {
+				t.Errorf("want %.2f%% provisioned; got %.2f%%", tt.wantPercent, dos.ProvisionedPercent)
+			}
+
+			if got := dos.Overcommitted(); got != tt.wantOvercommitted {
+				t.Errorf("want %d bytes overcommitted; got %d", tt.wantOvercommitted, got)
+			}
+
+			if got := dos.IsCriticalState(); got != tt.wantCriticalState {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCriticalState, got)
+			}
+
+			if got := dos.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
+
+func TestNewDatastoreOvercommitSet(t *testing.T) {
+	inaccessible := overcommitDatastore("ds-offline", 100, 50, 0)
+	inaccessible.Summary.Accessible = false
+
+	dss := []mo.Datastore{
+		overcommitDatastore("ds2", 100, 60, 20),
+		overcommitDatastore("DS3", 100, 60, 20),
+		overcommitDatastore("ds1", 100, 50, 100),
+		inaccessible,
+	}
+
+	tests := map[string]struct {
+		included          []string
+		excluded          []string
+		wantOrder         []string
+		wantExcluded      int
+		wantInaccessible  int
+		wantOvercommitted int
+		wantMaxPercent    float64
+		wantCritical      bool
+	}{
+		"sorted by provisioned percentage then name": {
+			wantOrder:         []string{"ds1", "ds2", "DS3"},
+			wantInaccessible:  1,
+			wantOvercommitted: 1,
+			wantMaxPercent:    150,
+			wantCritical:      true,
+		},
+		"overcommitted datastore excluded": {
+			excluded:       []string{"ds1", "ds-offline"},
+			wantOrder:      []string{"ds2", "DS3"},
+			wantExcluded:   2,
+			wantMaxPercent: 60,
+		},
+		"included datastores only": {
+			included:       []string{"ds2"},
+			wantOrder:      []string{"ds2"},
+			wantExcluded:   3,
+			wantMaxPercent: 60,
+		},
+		"inaccessible datastore only": {
+			included:         []string{"ds-offline"},
+			wantExcluded:     3,
+			wantInaccessible: 1,
+			wantCritical:     true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewDatastoreOvercommitSet(dss, tt.included, tt.excluded, 150, 100)
+
+			var order []string
+			for _, dos := range set.Summaries {
+				order = append(order, dos.Datastore.Name)
+			}
+
+			if d := cmp.Diff(tt.wantOrder, order); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if set.NumExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded; got %d", tt.wantExcluded, set.NumExcluded)
+			}
+
+			if got := len(set.Inaccessible); got != tt.wantInaccessible {
+				t.Errorf("want %d inaccessible; got %d", tt.wantInaccessible, got)
+			}
+
+			if got, want := set.NumEvaluated()+set.NumExcluded, len(dss); got != want {
+				t.Errorf("want %d datastores accounted for; got %d", want, got)
+			}
+
+			if got := set.NumOvercommitted(); got != tt.wantOvercommitted {
+				t.Errorf("want %d overcommitted datastores; got %d", tt.wantOvercommitted, got)
+			}
+
+			if got := set.MaxProvisionedPercent(); got != tt.wantMaxPercent {
+				t.Errorf("want highest provisioned percentage %.2f; got %.2f", tt.wantMaxPercent, got)
+			}
+
+			if got := set.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+		})
+	}
+}
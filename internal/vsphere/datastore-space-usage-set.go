@@ -0,0 +1,592 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// DatastoreSpaceUsageInaccessible represents a Datastore which could not be
+// evaluated for space usage due to being inaccessible.
+type DatastoreSpaceUsageInaccessible struct {
+
+	// Datastore is the name of the datastore.
+	Datastore string
+
+	// Reasons is the collection of reasons given for the datastore being
+	// inaccessible.
+	Reasons []string
+}
+
+// DatastoreSpaceUsageSet is a collection of Datastore space usage summaries
+// evaluated as part of a single plugin execution.
+type DatastoreSpaceUsageSet struct {
+
+	// Summaries is the collection of space usage summaries for accessible
+	// datastores, sorted by space usage (highest first).
+	Summaries []DatastoreSpaceUsageSummary
+
+	// Inaccessible is the collection of datastores which could not be
+	// evaluated due to being inaccessible. Inaccessible datastores are
+	// considered to be in a CRITICAL state.
+	Inaccessible []DatastoreSpaceUsageInaccessible
+
+	// NumExcluded is the number of datastores in scope which were excluded
+	// from evaluation by name.
+	NumExcluded int
+
+	CriticalThreshold int
+	WarningThreshold  int
+}
+
+// GetDatastoresInScope retrieves the Datastores available to the specified
+// cluster or, if a cluster name is not specified, all Datastores within the
+// specified datacenter. If the datacenter name is an empty string then the
+// default datacenter will be used. If requested, a subset of all available
+// properties will be retrieved (faster) instead of recursively fetching all
+// properties (about 2x as slow).
+func GetDatastoresInScope(ctx context.Context, c *vim25.Client, clusterName string, datacenter string, propsSubset bool) ([]mo.Datastore, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var dss []mo.Datastore
+
+	defer func(dss *[]mo.Datastore) {
+		logger.Printf(
+			"It took %v to execute GetDatastoresInScope func (and retrieve %d Datastores).\n",
+			time.Since(funcTimeStart),
+			len(*dss),
+		)
+	}(&dss)
+
+	switch {
+	case clusterName != "":
+		cluster, err := GetClusterByName(ctx, c, clusterName, datacenter, true)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve cluster %s: %w",
+				clusterName,
+				err,
+			)
+		}
+
+		// The datastore property is not included in the cluster properties
+		// subset; retrieve it separately.
+		pc := property.DefaultCollector(c)
+		if err := pc.RetrieveOne(ctx, cluster.Self, []string{"datastore"}, &cluster); err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve datastores for cluster %s: %w",
+				clusterName,
+				err,
+			)
+		}
+
+		if len(cluster.Datastore) == 0 {
+			return dss, nil
+		}
+
+		var props []string
+		if propsSubset {
+			props = getDatastorePropsSubset()
+		}
+
+		if err := pc.Retrieve(ctx, cluster.Datastore, props, &dss); err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve datastores for cluster %s: %w",
+				clusterName,
+				err,
+			)
+		}
+
+	default:
+		finder := find.NewFinder(c, true)
+
+		dc, findDCErr := finder.DatacenterOrDefault(ctx, datacenter)
+		if findDCErr != nil {
+			errMsg := dcFailedToUseFailedToFallback
+			if datacenter == "" {
+				errMsg = dcNotProvidedFailedToFallback
+			}
+
+			return nil, fmt.Errorf("%s: %w", errMsg, findDCErr)
+		}
+
+		err := getObjects(ctx, c, &dss, dc.Reference(), propsSubset, true)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve Datastores for datacenter %s: %w",
+				dc.Name(),
+				err,
+			)
+		}
+	}
+
+	sort.Slice(dss, func(i, j int) bool {
+		return strings.ToLower(dss[i].Name) < strings.ToLower(dss[j].Name)
+	})
+
+	return dss, nil
+}
+
+// NewDatastoreSpaceUsageSet receives a collection of Datastores and generates
+// space usage summaries for each Datastore matching the given lists of
+// datastore names to include or exclude. Inaccessible datastores are recorded
+// separately as their metadata is unreliable.
+func NewDatastoreSpaceUsageSet(
+	ctx context.Context,
+	c *vim25.Client,
+	dss []mo.Datastore,
+	includedDatastores []string,
+	excludedDatastores []string,
+	criticalThreshold int,
+	warningThreshold int,
+) (DatastoreSpaceUsageSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewDatastoreSpaceUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := DatastoreSpaceUsageSet{
+		Summaries:         make([]DatastoreSpaceUsageSummary, 0, len(dss)),
+		CriticalThreshold: criticalThreshold,
+		WarningThreshold:  warningThreshold,
+	}
+
+	for _, ds := range dss {
+		switch {
+		case len(includedDatastores) > 0 &&
+			!inPatternList(ds.Name, includedDatastores):
+			set.NumExcluded++
+
+			continue
+
+		case len(excludedDatastores) > 0 &&
+			inPatternList(ds.Name, excludedDatastores):
+			set.NumExcluded++
+
+			continue
+		}
+
+		if reasons, err := ValidateDatastoreAccessibility(ds); err != nil {
+			logger.Printf(
+				"datastore %s is inaccessible due to: [%s]",
+				ds.Name,
+				strings.Join(reasons, ", "),
+			)
+
+			set.Inaccessible = append(
+				set.Inaccessible,
+				DatastoreSpaceUsageInaccessible{
+					Datastore: ds.Name,
+					Reasons:   reasons,
+				},
+			)
+
+			continue
+		}
+
+		dsUsage, err := NewDatastoreSpaceUsageSummary(
+			ctx,
+			c,
+			ds,
+			criticalThreshold,
+			warningThreshold,
+		)
+		if err != nil {
+			return DatastoreSpaceUsageSet{}, fmt.Errorf(
+				"failed to generate usage summary for datastore %s: %w",
+				ds.Name,
+				err,
+			)
+		}
+
+		set.Summaries = append(set.Summaries, dsUsage)
+	}
+
+	// Highest space usage first.
+	sort.Slice(set.Summaries, func(i, j int) bool {
+		if set.Summaries[i].StorageUsedPercent != set.Summaries[j].StorageUsedPercent {
+			return set.Summaries[i].StorageUsedPercent > set.Summaries[j].StorageUsedPercent
+		}
+
+		return strings.ToLower(set.Summaries[i].Datastore.Name) <
+			strings.ToLower(set.Summaries[j].Datastore.Name)
+	})
+
+	return set, nil
+}
+
+// NumEvaluated returns the number of datastores in the set, including those
+// which are inaccessible.
+func (set DatastoreSpaceUsageSet) NumEvaluated() int {
+	return len(set.Summaries) + len(set.Inaccessible)
+}
+
+// NumCritical returns the number of datastores with space usage exceeding
+// the CRITICAL threshold.
+func (set DatastoreSpaceUsageSet) NumCritical() int {
+	var num int
+	for _, dsUsage := range set.Summaries {
+		if dsUsage.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores with space usage exceeding the
+// WARNING threshold, but not the CRITICAL threshold.
+func (set DatastoreSpaceUsageSet) NumWarning() int {
+	var num int
+	for _, dsUsage := range set.Summaries {
+		if dsUsage.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumVMs returns the number of VirtualMachines across all evaluated
+// datastores. VirtualMachines with files on multiple datastores are counted
+// once per datastore.
+func (set DatastoreSpaceUsageSet) NumVMs() int {
+	var num int
+	for _, dsUsage := range set.Summaries {
+		num += len(dsUsage.VMs)
+	}
+
+	return num
+}
+
+// NumUnresolvedVMs returns the number of VirtualMachine references across all
+// evaluated datastores which could not be resolved and were skipped.
+func (set DatastoreSpaceUsageSet) NumUnresolvedVMs() int {
+	var num int
+	for _, dsUsage := range set.Summaries {
+		num += len(dsUsage.UnresolvedVMIDs)
+	}
+
+	return num
+}
+
+// MaxUsedPercent returns the highest space usage percentage of all evaluated
+// datastores.
+func (set DatastoreSpaceUsageSet) MaxUsedPercent() float64 {
+	var highest float64
+	for _, dsUsage := range set.Summaries {
+		if dsUsage.StorageUsedPercent > highest {
+			highest = dsUsage.StorageUsedPercent
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any datastore in the set is inaccessible
+// or has space usage exceeding the CRITICAL threshold.
+func (set DatastoreSpaceUsageSet) IsCriticalState() bool {
+	return len(set.Inaccessible) > 0 || set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any datastore in the set has space usage
+// exceeding the WARNING threshold.
+func (set DatastoreSpaceUsageSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// DatastoreSpaceUsageSetPerfData generates performance data metrics from the
+// given collection of datastore space usage summaries. Aggregate metrics are
+// emitted along with space usage metrics for each datastore.
+func DatastoreSpaceUsageSetPerfData(set DatastoreSpaceUsageSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_inaccessible",
+			Value: fmt.Sprintf("%d", len(set.Inaccessible)),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "datastore_space_usage_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxUsedPercent()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", set.WarningThreshold),
+			Crit:              fmt.Sprintf("%d", set.CriticalThreshold),
+			Min:               "0",
+			Max:               "100",
+		},
+	}
+
+	for _, dsUsage := range set.Summaries {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(dsUsage.Datastore.Name, "space_usage"),
+				Value:             fmt.Sprintf("%.2f", dsUsage.StorageUsedPercent),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", dsUsage.WarningThreshold),
+				Crit:              fmt.Sprintf("%d", dsUsage.CriticalThreshold),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(dsUsage.Datastore.Name, "space_remaining"),
+				Value:             fmt.Sprintf("%d", dsUsage.StorageRemaining),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", dsUsage.StorageTotal),
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(dsUsage.Datastore.Name, "vms"),
+				Value: fmt.Sprintf("%d", len(dsUsage.VMs)),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// DatastoreSpaceUsageSetOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func DatastoreSpaceUsageSetOneLineCheckSummary(
+	stateLabel string,
+	set DatastoreSpaceUsageSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreSpaceUsageSetOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d datastores with space usage exceeding %d%% and %d inaccessible datastores detected (evaluated %d datastores, highest usage %.2f%%)",
+			stateLabel,
+			set.NumCritical(),
+			set.CriticalThreshold,
+			len(set.Inaccessible),
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d datastores with space usage exceeding %d%% detected (evaluated %d datastores, highest usage %.2f%%)",
+			stateLabel,
+			set.NumWarning(),
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No datastores with space usage exceeding %d%% detected (evaluated %d datastores, highest usage %.2f%%)",
+			stateLabel,
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+	}
+}
+
+// DatastoreSpaceUsageSetReport generates a summary of per-datastore space
+// usage along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func DatastoreSpaceUsageSetReport(
+	c *vim25.Client,
+	set DatastoreSpaceUsageSet,
+	includedDatastores []string,
+	excludedDatastores []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreSpaceUsageSetReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No datastores found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, inaccessible := range set.Inaccessible {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: inaccessible due to: [%s]%s",
+			inaccessible.Datastore,
+			nagios.StateCRITICALLabel,
+			strings.Join(inaccessible.Reasons, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, dsUsage := range set.Summaries {
+		var state string
+		switch {
+		case dsUsage.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case dsUsage.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %.2f%% of %s used, %s remaining (%d VMs: %d on, %d off)%s",
+			dsUsage.Datastore.Name,
+			state,
+			dsUsage.StorageUsedPercent,
+			units.ByteSize(dsUsage.StorageTotal),
+			units.ByteSize(dsUsage.StorageRemaining),
+			len(dsUsage.VMs),
+			dsUsage.VMs.NumVMsPoweredOn(),
+			dsUsage.VMs.NumVMsPoweredOff(),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numUnresolved := set.NumUnresolvedVMs(); numUnresolved > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s%d unresolved VM references skipped (see debug logging for details)%s",
+			nagios.CheckOutputEOL,
+			numUnresolved,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all datastores in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("datastores available to cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all datastores in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d excluded, %d inaccessible)%s",
+		set.NumEvaluated(),
+		set.NumExcluded,
+		len(set.Inaccessible),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,582 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrDatastoreVMDensityThresholdCrossed indicates that the number of VMs or
+// virtual disks (VMDKs) registered on a datastore has exceeded a specified
+// threshold.
+var ErrDatastoreVMDensityThresholdCrossed = errors.New("datastore VM density exceeds specified threshold")
+
+// DatastoreVMDensityThresholds represents the user-specified thresholds for
+// the number of VMs and virtual disks (VMDKs) registered on a datastore.
+type DatastoreVMDensityThresholds struct {
+	VMsWarning    int
+	VMsCritical   int
+	VMDKsWarning  int
+	VMDKsCritical int
+}
+
+// DatastoreVMDensitySummary tracks the number of VMs and virtual disks
+// (VMDKs) registered on a specific Datastore.
+type DatastoreVMDensitySummary struct {
+	Datastore mo.Datastore
+
+	// NumVMs is the number of VMs (including templates) with files on the
+	// datastore.
+	NumVMs int
+
+	// NumVMDKs is the number of virtual disks attached to VMs which are
+	// backed by files on the datastore.
+	NumVMDKs int
+
+	Thresholds DatastoreVMDensityThresholds
+}
+
+// DatastoreVMDensitySet is a collection of Datastore VM density summaries
+// evaluated as part of a single plugin execution.
+type DatastoreVMDensitySet struct {
+
+	// Summaries is the collection of VM density summaries for accessible
+	// datastores, sorted by number of VMs (highest first).
+	Summaries []DatastoreVMDensitySummary
+
+	// Inaccessible is the collection of datastores which could not be
+	// evaluated due to being inaccessible. Inaccessible datastores are
+	// considered to be in a CRITICAL state.
+	Inaccessible []DatastoreSpaceUsageInaccessible
+
+	// NumExcluded is the number of datastores in scope which were excluded
+	// from evaluation by name.
+	NumExcluded int
+
+	Thresholds DatastoreVMDensityThresholds
+}
+
+// NewDatastoreVMDensitySummary generates a VM density summary for the given
+// Datastore using the given index of VirtualMachines (keyed by
+// VirtualMachine ID) to count virtual disks backed by files on the
+// Datastore.
+func NewDatastoreVMDensitySummary(
+	ds mo.Datastore,
+	vmsIdx map[string]mo.VirtualMachine,
+	thresholds DatastoreVMDensityThresholds,
+) DatastoreVMDensitySummary {
+
+	summary := DatastoreVMDensitySummary{
+		Datastore:  ds,
+		NumVMs:     len(ds.Vm),
+		Thresholds: thresholds,
+	}
+
+	for _, vmRef := range ds.Vm {
+		vm, ok := vmsIdx[vmRef.Value]
+		if !ok {
+			logger.Printf(
+				"VM with ID %s registered on datastore %s not found; skipping virtual disk count",
+				vmRef.Value,
+				ds.Name,
+			)
+
+			continue
+		}
+
+		summary.NumVMDKs += numVMDKsOnDatastore(vm, ds)
+	}
+
+	return summary
+}
+
+// numVMDKsOnDatastore returns the number of virtual disks attached to the
+// given VirtualMachine which are backed by files on the given Datastore.
+func numVMDKsOnDatastore(vm mo.VirtualMachine, ds mo.Datastore) int {
+	if vm.Config == nil {
+		return 0
+	}
+
+	dsPrefix := fmt.Sprintf("[%s] ", ds.Name)
+
+	var num int
+	for _, device := range vm.Config.Hardware.Device {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+
+		backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+		if !ok {
+			continue
+		}
+
+		fileBacking := backing.GetVirtualDeviceFileBackingInfo()
+
+		switch {
+		case fileBacking.Datastore != nil:
+			if fileBacking.Datastore.Value == ds.Self.Value {
+				num++
+			}
+
+		case strings.HasPrefix(fileBacking.FileName, dsPrefix):
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether the number of VMs or virtual disks
+// registered on the datastore has exceeded the CRITICAL threshold.
+func (dvd DatastoreVMDensitySummary) IsCriticalState() bool {
+	return dvd.NumVMs >= dvd.Thresholds.VMsCritical ||
+		dvd.NumVMDKs >= dvd.Thresholds.VMDKsCritical
+}
+
+// IsWarningState indicates whether the number of VMs or virtual disks
+// registered on the datastore has exceeded the WARNING threshold but not the
+// CRITICAL threshold.
+func (dvd DatastoreVMDensitySummary) IsWarningState() bool {
+	if dvd.IsCriticalState() {
+		return false
+	}
+
+	return dvd.NumVMs >= dvd.Thresholds.VMsWarning ||
+		dvd.NumVMDKs >= dvd.Thresholds.VMDKsWarning
+}
+
+// NewDatastoreVMDensitySet receives a collection of Datastores and
+// VirtualMachines and generates VM density summaries for each Datastore
+// matching the given lists of datastore names to include or exclude.
+// Inaccessible datastores are recorded separately as their metadata is
+// unreliable.
+func NewDatastoreVMDensitySet(
+	dss []mo.Datastore,
+	vms []mo.VirtualMachine,
+	includedDatastores []string,
+	excludedDatastores []string,
+	thresholds DatastoreVMDensityThresholds,
+) DatastoreVMDensitySet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewDatastoreVMDensitySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	vmsIdx := make(map[string]mo.VirtualMachine, len(vms))
+	for _, vm := range vms {
+		vmsIdx[vm.Self.Value] = vm
+	}
+
+	set := DatastoreVMDensitySet{
+		Summaries:  make([]DatastoreVMDensitySummary, 0, len(dss)),
+		Thresholds: thresholds,
+	}
+
+	for _, ds := range dss {
+		switch {
+		case len(includedDatastores) > 0 &&
+			!inPatternList(ds.Name, includedDatastores):
+			set.NumExcluded++
+
+			continue
+
+		case len(excludedDatastores) > 0 &&
+			inPatternList(ds.Name, excludedDatastores):
+			set.NumExcluded++
+
+			continue
+		}
+
+		if reasons, err := ValidateDatastoreAccessibility(ds); err != nil {
+			logger.Printf(
+				"datastore %s is inaccessible due to: [%s]",
+				ds.Name,
+				strings.Join(reasons, ", "),
+			)
+
+			set.Inaccessible = append(
+				set.Inaccessible,
+				DatastoreSpaceUsageInaccessible{
+					Datastore: ds.Name,
+					Reasons:   reasons,
+				},
+			)
+
+			continue
+		}
+
+		set.Summaries = append(
+			set.Summaries,
+			NewDatastoreVMDensitySummary(ds, vmsIdx, thresholds),
+		)
+	}
+
+	// Highest number of VMs first, then highest number of virtual disks.
+	sort.Slice(set.Summaries, func(i, j int) bool {
+		if set.Summaries[i].NumVMs != set.Summaries[j].NumVMs {
+			return set.Summaries[i].NumVMs > set.Summaries[j].NumVMs
+		}
+
+		if set.Summaries[i].NumVMDKs != set.Summaries[j].NumVMDKs {
+			return set.Summaries[i].NumVMDKs > set.Summaries[j].NumVMDKs
+		}
+
+		return strings.ToLower(set.Summaries[i].Datastore.Name) <
+			strings.ToLower(set.Summaries[j].Datastore.Name)
+	})
+
+	return set
+}
+
+// NumEvaluated returns the number of datastores in the set, including those
+// which are inaccessible.
+func (set DatastoreVMDensitySet) NumEvaluated() int {
+	return len(set.Summaries) + len(set.Inaccessible)
+}
+
+// NumCritical returns the number of datastores with VM or virtual disk
+// counts exceeding the CRITICAL threshold.
+func (set DatastoreVMDensitySet) NumCritical() int {
+	var num int
+	for _, dvd := range set.Summaries {
+		if dvd.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores with VM or virtual disk
+// counts exceeding the WARNING threshold, but not the CRITICAL threshold.
+func (set DatastoreVMDensitySet) NumWarning() int {
+	var num int
+	for _, dvd := range set.Summaries {
+		if dvd.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// MaxVMs returns the highest number of VMs registered on any evaluated
+// datastore.
+func (set DatastoreVMDensitySet) MaxVMs() int {
+	var highest int
+	for _, dvd := range set.Summaries {
+		if dvd.NumVMs > highest {
+			highest = dvd.NumVMs
+		}
+	}
+
+	return highest
+}
+
+// MaxVMDKs returns the highest number of virtual disks backed by files on
+// any evaluated datastore.
+func (set DatastoreVMDensitySet) MaxVMDKs() int {
+	var highest int
+	for _, dvd := range set.Summaries {
+		if dvd.NumVMDKs > highest {
+			highest = dvd.NumVMDKs
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any datastore in the set is inaccessible
+// or has VM or virtual disk counts exceeding the CRITICAL threshold.
+func (set DatastoreVMDensitySet) IsCriticalState() bool {
+	return len(set.Inaccessible) > 0 || set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any datastore in the set has VM or
+// virtual disk counts exceeding the WARNING threshold.
+func (set DatastoreVMDensitySet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// DatastoreVMDensityPerfData generates performance data metrics from the
+// given collection of datastore VM density summaries. Aggregate metrics are
+// emitted along with VM and virtual disk counts for each datastore.
+func DatastoreVMDensityPerfData(set DatastoreVMDensitySet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_inaccessible",
+			Value: fmt.Sprintf("%d", len(set.Inaccessible)),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "datastore_vms_max",
+			Value: fmt.Sprintf("%d", set.MaxVMs()),
+			Warn:  fmt.Sprintf("%d", set.Thresholds.VMsWarning),
+			Crit:  fmt.Sprintf("%d", set.Thresholds.VMsCritical),
+			Min:   "0",
+		},
+		{
+			Label: "datastore_vmdks_max",
+			Value: fmt.Sprintf("%d", set.MaxVMDKs()),
+			Warn:  fmt.Sprintf("%d", set.Thresholds.VMDKsWarning),
+			Crit:  fmt.Sprintf("%d", set.Thresholds.VMDKsCritical),
+			Min:   "0",
+		},
+	}
+
+	for _, dvd := range set.Summaries {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: PerfDataLabel(dvd.Datastore.Name, "vms"),
+				Value: fmt.Sprintf("%d", dvd.NumVMs),
+				Warn:  fmt.Sprintf("%d", dvd.Thresholds.VMsWarning),
+				Crit:  fmt.Sprintf("%d", dvd.Thresholds.VMsCritical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(dvd.Datastore.Name, "vmdks"),
+				Value: fmt.Sprintf("%d", dvd.NumVMDKs),
+				Warn:  fmt.Sprintf("%d", dvd.Thresholds.VMDKsWarning),
+				Crit:  fmt.Sprintf("%d", dvd.Thresholds.VMDKsCritical),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// DatastoreVMDensityOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func DatastoreVMDensityOneLineCheckSummary(
+	stateLabel string,
+	set DatastoreVMDensitySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreVMDensityOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d datastores with %d+ VMs or %d+ VMDKs and %d inaccessible datastores detected (evaluated %d datastores, highest %d VMs, %d VMDKs)",
+			stateLabel,
+			set.NumCritical(),
+			set.Thresholds.VMsCritical,
+			set.Thresholds.VMDKsCritical,
+			len(set.Inaccessible),
+			set.NumEvaluated(),
+			set.MaxVMs(),
+			set.MaxVMDKs(),
+		)
+
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d datastores with %d+ VMs or %d+ VMDKs detected (evaluated %d datastores, highest %d VMs, %d VMDKs)",
+			stateLabel,
+			set.NumWarning(),
+			set.Thresholds.VMsWarning,
+			set.Thresholds.VMDKsWarning,
+			set.NumEvaluated(),
+			set.MaxVMs(),
+			set.MaxVMDKs(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No datastores with %d+ VMs or %d+ VMDKs detected (evaluated %d datastores, highest %d VMs, %d VMDKs)",
+			stateLabel,
+			set.Thresholds.VMsWarning,
+			set.Thresholds.VMDKsWarning,
+			set.NumEvaluated(),
+			set.MaxVMs(),
+			set.MaxVMDKs(),
+		)
+	}
+}
+
+// DatastoreVMDensityReport generates a summary of per-datastore VM and
+// virtual disk counts along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func DatastoreVMDensityReport(
+	c *vim25.Client,
+	set DatastoreVMDensitySet,
+	includedDatastores []string,
+	excludedDatastores []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute DatastoreVMDensityReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No datastores found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, inaccessible := range set.Inaccessible {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: inaccessible due to: [%s]%s",
+			inaccessible.Datastore,
+			nagios.StateCRITICALLabel,
+			strings.Join(inaccessible.Reasons, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, dvd := range set.Summaries {
+		var state string
+		switch {
+		case dvd.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case dvd.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %d VMs, %d VMDKs%s",
+			dvd.Datastore.Name,
+			state,
+			dvd.NumVMs,
+			dvd.NumVMDKs,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all datastores in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("datastores available to cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all datastores in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d excluded, %d inaccessible)%s",
+		set.NumEvaluated(),
+		set.NumExcluded,
+		len(set.Inaccessible),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
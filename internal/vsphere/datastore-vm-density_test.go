@@ -0,0 +1,305 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// densityVM returns a VM with the given number of virtual disks backed by
+// files on the named datastore. Disks are alternately referenced by
+// datastore MOID and by datastore file path.
+func densityVM(id string, dsID string, dsName string, numDisks int) mo.VirtualMachine {
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{
+			ExtensibleManagedObject: mo.ExtensibleManagedObject{
+				Self: types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: id},
+			},
+			Name: id,
+		},
+		Config: &types.VirtualMachineConfigInfo{},
+	}
+
+	for i := 0; i < numDisks; i++ {
+		backing := types.VirtualDiskFlatVer2BackingInfo{}
+		backing.FileName = fmt.Sprintf("[%s] %s/%s_%d.vmdk", dsName, id, id, i)
+		if i%2 == 0 {
+			backing.Datastore = &types.ManagedObjectReference{Type: MgObjRefTypeDatastore, Value: dsID}
+		}
+
+		disk := types.VirtualDisk{}
+		disk.Backing = &backing
+
+		vm.Config.Hardware.Device = append(vm.Config.Hardware.Device, &disk)
+	}
+
+	return vm
+}
+
+// densityDatastore returns an accessible datastore with the given VMs
+// registered on it.
+func densityDatastore(id string, name string, vms ...mo.VirtualMachine) mo.Datastore {
+	ds := mo.Datastore{
+		ManagedEntity: mo.ManagedEntity{
+			ExtensibleManagedObject: mo.ExtensibleManagedObject{
+				Self: types.ManagedObjectReference{Type: MgObjRefTypeDatastore, Value: id},
+			},
+			Name: name,
+		},
+		Summary: types.DatastoreSummary{
+			Name:       name,
+			Accessible: true,
+		},
+	}
+
+	for _, vm := range vms {
+		ds.Vm = append(ds.Vm, vm.Self)
+	}
+
+	return ds
+}
+
+func TestNumVMDKsOnDatastore(t *testing.T) {
+	ds := densityDatastore("datastore-1", "ds1")
+
+	// otherDatastoreByMOID has a file path on ds1 but a datastore reference
+	// to another datastore; the reference is authoritative.
+	otherDatastoreByMOID := densityVM("vm-1", "datastore-1", "ds1", 0)
+	otherDatastoreByMOID.Config.Hardware.Device = append(
+		otherDatastoreByMOID.Config.Hardware.Device,
+		&types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Backing: &types.VirtualDiskFlatVer2BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName:  "[ds1] vm-1/vm-1.vmdk",
+						Datastore: &types.ManagedObjectReference{Type: MgObjRefTypeDatastore, Value: "datastore-2"},
+					},
+				},
+			},
+		},
+	)
+
+	// otherDevices has a CD-ROM backed by an ISO on ds1, a disk backed by a
+	// raw device and a disk on a datastore with a similar name.
+	otherDevices := densityVM("vm-1", "datastore-1", "ds1", 1)
+	otherDevices.Config.Hardware.Device = append(
+		otherDevices.Config.Hardware.Device,
+		&types.VirtualCdrom{
+			VirtualDevice: types.VirtualDevice{
+				Backing: &types.VirtualCdromIsoBackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName: "[ds1] iso/image.iso",
+					},
+				},
+			},
+		},
+		&types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Backing: &types.VirtualDiskRawDiskVer2BackingInfo{},
+			},
+		},
+		&types.VirtualDisk{
+			VirtualDevice: types.VirtualDevice{
+				Backing: &types.VirtualDiskFlatVer2BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName: "[ds10] vm-1/vm-1.vmdk",
+					},
+				},
+			},
+		},
+	)
+
+	tests := map[string]struct {
+		vm   mo.VirtualMachine
+		want int
+	}{
+		"config not retrieved":               {vm: mo.VirtualMachine{}},
+		"disks by MOID and file path":        {vm: densityVM("vm-1", "datastore-1", "ds1", 4), want: 4},
+		"disks on other datastore":           {vm: densityVM("vm-1", "datastore-2", "ds2", 4)},
+		"datastore reference takes priority": {vm: otherDatastoreByMOID},
+		"other devices not counted":          {vm: otherDevices, want: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := numVMDKsOnDatastore(tt.vm, ds); got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewDatastoreVMDensitySummary(t *testing.T) {
+	thresholds := DatastoreVMDensityThresholds{
+		VMsWarning:    3,
+		VMsCritical:   4,
+		VMDKsWarning:  6,
+		VMDKsCritical: 8,
+	}
+
+	// densityVMs returns the given number of VMs, each with the given
+	// number of virtual disks on datastore ds1.
+	densityVMs := func(numVMs int, numDisks int) []mo.VirtualMachine {
+		vms := make([]mo.VirtualMachine, 0, numVMs)
+		for i := 0; i < numVMs; i++ {
+			vms = append(vms, densityVM(fmt.Sprintf("vm-%d", i), "datastore-1", "ds1", numDisks))
+		}
+
+		return vms
+	}
+
+	tests := map[string]struct {
+		vms          []mo.VirtualMachine
+		wantVMs      int
+		wantVMDKs    int
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below thresholds": {
+			vms:       densityVMs(2, 2),
+			wantVMs:   2,
+			wantVMDKs: 4,
+		},
+		"VMs at WARNING threshold": {
+			vms:         densityVMs(3, 1),
+			wantVMs:     3,
+			wantVMDKs:   3,
+			wantWarning: true,
+		},
+		"VMs at CRITICAL threshold": {
+			vms:          densityVMs(4, 1),
+			wantVMs:      4,
+			wantVMDKs:    4,
+			wantCritical: true,
+		},
+		"VMDKs at WARNING threshold": {
+			vms:         densityVMs(2, 3),
+			wantVMs:     2,
+			wantVMDKs:   6,
+			wantWarning: true,
+		},
+		"VMDKs at CRITICAL threshold": {
+			vms:          densityVMs(1, 8),
+			wantVMs:      1,
+			wantVMDKs:    8,
+			wantCritical: true,
+		},
+		"VMDKs on other datastores are not counted": {
+			vms: []mo.VirtualMachine{
+				densityVM("vm-1", "datastore-2", "ds2", 8),
+				densityVM("vm-2", "datastore-1", "ds1", 2),
+			},
+			wantVMs:   2,
+			wantVMDKs: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vmsIdx := make(map[string]mo.VirtualMachine, len(tt.vms))
+			for _, vm := range tt.vms {
+				vmsIdx[vm.Self.Value] = vm
+			}
+
+			dvd := NewDatastoreVMDensitySummary(densityDatastore("datastore-1", "ds1", tt.vms...), vmsIdx, thresholds)
+
+			if dvd.NumVMs != tt.wantVMs {
+				t.Errorf("want %d VMs; got %d", tt.wantVMs, dvd.NumVMs)
+			}
+
+			if dvd.NumVMDKs != tt.wantVMDKs {
+				t.Errorf("want %d VMDKs; got %d", tt.wantVMDKs, dvd.NumVMDKs)
+			}
+
+			if got := dvd.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := dvd.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestNewDatastoreVMDensitySet(t *testing.T) {
+	thresholds := DatastoreVMDensityThresholds{
+		VMsWarning:    3,
+		VMsCritical:   4,
+		VMDKsWarning:  6,
+		VMDKsCritical: 8,
+	}
+
+	vm1 := densityVM("vm-1", "datastore-1", "ds1", 2)
+	vm2 := densityVM("vm-2", "datastore-2", "ds2", 1)
+	vm3 := densityVM("vm-3", "datastore-3", "DS3", 3)
+
+	inaccessible := densityDatastore("datastore-4", "ds-offline")
+	inaccessible.Summary.Accessible = false
+
+	dss := []mo.Datastore{
+		densityDatastore("datastore-1", "ds1", vm1),
+		densityDatastore("datastore-2", "ds2", vm2),
+		densityDatastore("datastore-3", "DS3", vm3),
+		inaccessible,
+	}
+
+	// vm-9 is registered on ds2 but was not retrieved.
+	dss[1].Vm = append(dss[1].Vm, types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: "vm-9"})
+
+	vms := []mo.VirtualMachine{vm1, vm2, vm3}
+
+	tests := map[string]struct {
+		excluded     []string
+		wantOrder    []string
+		wantExcluded int
+		wantCritical bool
+	}{
+		"sorted by VMs then VMDKs then name": {
+			wantOrder:    []string{"ds2", "DS3", "ds1"},
+			wantCritical: true,
+		},
+		"inaccessible datastore excluded": {
+			excluded:     []string{"ds-offline", "ds2"},
+			wantOrder:    []string{"DS3", "ds1"},
+			wantExcluded: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewDatastoreVMDensitySet(dss, vms, nil, tt.excluded, thresholds)
+
+			var order []string
+			for _, dvd := range set.Summaries {
+				order = append(order, dvd.Datastore.Name)
+			}
+
+			if d := cmp.Diff(tt.wantOrder, order); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if set.NumExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded; got %d", tt.wantExcluded, set.NumExcluded)
+			}
+
+			if got, want := set.NumEvaluated()+set.NumExcluded, len(dss); got != want {
+				t.Errorf("want %d datastores accounted for; got %d", want, got)
+			}
+
+			if got := set.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+		})
+	}
+}
@@ -128,6 +128,10 @@ type DatastoreSpaceUsageSummary struct {
 	CriticalThreshold       int
 	WarningThreshold        int
 	VMs                     DatastoreVMs
+
+	// UnresolvedVMIDs is the collection of VirtualMachine IDs listed by the
+	// Datastore which could not be resolved and were skipped.
+	UnresolvedVMIDs []string
 }
 
 // DatastorePerformanceThresholds is a collection of threshold values used to
@@ -205,6 +209,10 @@ type DatastorePerformanceSet struct {
 	// specified datastore.
 	VMs DatastoreVMs
 
+	// UnresolvedVMIDs is the collection of VirtualMachine IDs listed by the
+	// Datastore which could not be resolved and were skipped.
+	UnresolvedVMIDs []string
+
 	// Intervals is a collection of percentile to Datastore performance metric
 	// indexes. Each element of this collection represents an interval or
 	// window of time where metrics were collected. The first element contains
@@ -346,6 +354,30 @@ func printVMSummary(w io.Writer, dsVMs DatastoreVMs, powerState types.VirtualMac
 	}
 }
 
+// printUnresolvedVMs is a helper function used by Datastore report functions
+// to list VirtualMachine references present on a specific datastore which
+// could not be resolved and were skipped.
+func printUnresolvedVMs(w io.Writer, vmIDs []string) {
+	// Skip listing unresolved VMs if there is nothing to show.
+	if len(vmIDs) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(
+		w,
+		"%d unresolved VM references on datastore (skipped):%s%s",
+		len(vmIDs),
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, id := range vmIDs {
+		_, _ = fmt.Fprintf(w, "* %s%s", id, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(w, nagios.CheckOutputEOL)
+}
+
 // ValidateDatastoreAccessibility evaluates a given Datastore's accessibility
 // and returns a list of reasons why and an error if the datastore is
 // inaccessible. If the Datastore is accessible, nil is returned for both
@@ -629,7 +661,7 @@ func NewDatastorePerformanceSet(
 		return DatastorePerformanceSet{}, ErrDatastorePerformanceMetricsMissing
 	}
 
-	dsVMs, err := GetVMsFromDatastore(ctx, c, ds, true)
+	dsVMs, unresolvedVMIDs, err := GetVMsFromDatastore(ctx, c, ds, true)
 	if err != nil {
 		return DatastorePerformanceSet{}, fmt.Errorf(
 			"error retrieving VMs for Datastore Performance Set: %w", err,
@@ -704,9 +736,10 @@ func NewDatastorePerformanceSet(
 	}
 
 	dsPerfSet := DatastorePerformanceSet{
-		Datastore: ds,
-		VMs:       DatastoreVMsSummary(ds, dsVMs),
-		Intervals: perfSummaryIndexes,
+		Datastore:       ds,
+		VMs:             DatastoreVMsSummary(ds, dsVMs),
+		UnresolvedVMIDs: unresolvedVMIDs,
+		Intervals:       perfSummaryIndexes,
 	}
 
 	return dsPerfSet, nil
@@ -957,7 +990,7 @@ func NewDatastoreSpaceUsageSummary(
 	storageTotal := ds.Summary.Capacity
 	storageUsed := storageTotal - storageRemaining
 
-	dsVMs, err := GetVMsFromDatastore(ctx, c, ds, true)
+	dsVMs, unresolvedVMIDs, err := GetVMsFromDatastore(ctx, c, ds, true)
 	if err != nil {
 		return DatastoreSpaceUsageSummary{}, err
 	}
@@ -965,6 +998,7 @@ func NewDatastoreSpaceUsageSummary(
 	dsUsage := DatastoreSpaceUsageSummary{
 		Datastore:               ds,
 		VMs:                     DatastoreVMsSummary(ds, dsVMs),
+		UnresolvedVMIDs:         unresolvedVMIDs,
 		StorageRemainingPercent: storageRemainingPercentage,
 		StorageUsedPercent:      storageUsedPercentage,
 		StorageTotal:            storageTotal,
@@ -1301,6 +1335,10 @@ func DatastoreSpaceUsageOneLineCheckSummary(
 	dsUsageSummary DatastoreSpaceUsageSummary,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"dsUsageSummary": dsUsageSummary,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1371,6 +1409,8 @@ func DatastoreSpaceUsageReport(
 
 	printVMSummary(&report, dsUsageSummary.VMs, types.VirtualMachinePowerStatePoweredOff)
 
+	printUnresolvedVMs(&report, dsUsageSummary.UnresolvedVMIDs)
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"%s---%s%s",
@@ -1404,6 +1444,10 @@ func DatastorePerformanceOneLineCheckSummary(
 	dsPerfSet DatastorePerformanceSet,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"dsPerfSet": dsPerfSet,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1639,6 +1683,8 @@ func DatastorePerformanceReport(
 
 	printVMSummary(&report, dsPerfSet.VMs, types.VirtualMachinePowerStatePoweredOff)
 
+	printUnresolvedVMs(&report, dsPerfSet.UnresolvedVMIDs)
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"%s---%s%s",
@@ -0,0 +1,88 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// EmergencyLabelPrefix is prepended to the one-line service check results
+// summary when an emergency threshold is crossed. This allows notification
+// routing (e.g., multi-tier paging policies) to distinguish between a
+// CRITICAL state and an emergency CRITICAL state.
+const EmergencyLabelPrefix string = "[EMERGENCY] "
+
+// EmergencyThreshold represents an optional user-specified threshold above
+// the CRITICAL threshold. Crossing this threshold does not change the exit
+// state (CRITICAL), but flags the check result as an emergency.
+type EmergencyThreshold struct {
+	// Value is the user-specified emergency threshold.
+	Value int
+
+	// Enabled indicates whether an emergency threshold was specified.
+	Enabled bool
+}
+
+// IsCrossed indicates whether the given value has crossed the emergency
+// threshold. False is returned if an emergency threshold was not specified.
+func (et EmergencyThreshold) IsCrossed(value float64) bool {
+	return et.Enabled && value > float64(et.Value)
+}
+
+// PerfData generates a performance data metric indicating whether the given
+// value has crossed the emergency threshold (1) or not (0). The metric uses a
+// CRITICAL threshold of 0 so that a crossed emergency threshold is flagged
+// by tools evaluating performance data thresholds. No metrics are returned if
+// an emergency threshold was not specified.
+func (et EmergencyThreshold) PerfData(value float64) []nagios.PerformanceData {
+	if !et.Enabled {
+		return nil
+	}
+
+	var crossed int
+	if et.IsCrossed(value) {
+		crossed = 1
+	}
+
+	return []nagios.PerformanceData{
+		{
+			Label: "emergency",
+			Value: fmt.Sprintf("%d", crossed),
+			Crit:  "0",
+			Min:   "0",
+			Max:   "1",
+		},
+	}
+}
+
+// Annotate flags the plugin results as an emergency if the given value has
+// crossed the emergency threshold. The plugin state is set to CRITICAL, the
+// one-line service check results summary is prefixed with
+// EmergencyLabelPrefix and a note recording the emergency threshold is
+// appended to the Long Service Output. Crossing the threshold is an expected
+// check result and is not recorded as an error. This is intended to be
+// called after the one-line summary and Long Service Output for a CRITICAL
+// state are set.
+func (et EmergencyThreshold) Annotate(plugin *nagios.Plugin, value float64) {
+	if !et.IsCrossed(value) {
+		return
+	}
+
+	plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+	plugin.ServiceOutput = EmergencyLabelPrefix + plugin.ServiceOutput
+
+	plugin.LongServiceOutput += fmt.Sprintf(
+		"%sNOTE: Emergency threshold of %d crossed (value: %.2f)%s",
+		nagios.CheckOutputEOL,
+		et.Value,
+		value,
+		nagios.CheckOutputEOL,
+	)
+}
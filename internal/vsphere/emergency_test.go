@@ -0,0 +1,141 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEmergencyThresholdIsCrossed(t *testing.T) {
+	tests := map[string]struct {
+		threshold EmergencyThreshold
+		value     float64
+		want      bool
+	}{
+		"not enabled":         {threshold: EmergencyThreshold{Value: 95}, value: 99},
+		"below threshold":     {threshold: EmergencyThreshold{Value: 95, Enabled: true}, value: 94.9},
+		"equal to threshold":  {threshold: EmergencyThreshold{Value: 95, Enabled: true}, value: 95},
+		"just above":          {threshold: EmergencyThreshold{Value: 95, Enabled: true}, value: 95.01, want: true},
+		"well above":          {threshold: EmergencyThreshold{Value: 95, Enabled: true}, value: 120, want: true},
+		"zero threshold":      {threshold: EmergencyThreshold{Value: 0, Enabled: true}, value: 0.5, want: true},
+		"zero value disabled": {threshold: EmergencyThreshold{}, value: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.threshold.IsCrossed(tt.value); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEmergencyThresholdPerfData(t *testing.T) {
+	tests := map[string]struct {
+		threshold EmergencyThreshold
+		value     float64
+		want      []nagios.PerformanceData
+	}{
+		"not enabled": {
+			threshold: EmergencyThreshold{Value: 95},
+			value:     99,
+		},
+		"not crossed": {
+			threshold: EmergencyThreshold{Value: 95, Enabled: true},
+			value:     90,
+			want: []nagios.PerformanceData{
+				{Label: "emergency", Value: "0", Crit: "0", Min: "0", Max: "1"},
+			},
+		},
+		"crossed": {
+			threshold: EmergencyThreshold{Value: 95, Enabled: true},
+			value:     99,
+			want: []nagios.PerformanceData{
+				{Label: "emergency", Value: "1", Crit: "0", Min: "0", Max: "1"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tt.threshold.PerfData(tt.value)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestEmergencyThresholdAnnotate(t *testing.T) {
+	const summary string = "CRITICAL: Datastore ds1 space usage 97.50%"
+
+	tests := map[string]struct {
+		threshold    EmergencyThreshold
+		value        float64
+		wantExitCode int
+		wantOutput   string
+		wantNote     string
+	}{
+		"not enabled": {
+			threshold:    EmergencyThreshold{Value: 95},
+			value:        97.5,
+			wantExitCode: nagios.StateWARNINGExitCode,
+			wantOutput:   summary,
+		},
+		"not crossed": {
+			threshold:    EmergencyThreshold{Value: 98, Enabled: true},
+			value:        97.5,
+			wantExitCode: nagios.StateWARNINGExitCode,
+			wantOutput:   summary,
+		},
+		"crossed": {
+			threshold:    EmergencyThreshold{Value: 95, Enabled: true},
+			value:        97.5,
+			wantExitCode: nagios.StateCRITICALExitCode,
+			wantOutput:   EmergencyLabelPrefix + summary,
+			wantNote:     "NOTE: Emergency threshold of 95 crossed (value: 97.50)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			plugin := nagios.NewPlugin()
+
+			// A non-CRITICAL exit code is used to assert that crossing the
+			// emergency threshold sets the CRITICAL state.
+			plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+			plugin.ServiceOutput = summary
+			plugin.LongServiceOutput = "report"
+
+			tt.threshold.Annotate(plugin, tt.value)
+
+			if plugin.ExitStatusCode != tt.wantExitCode {
+				t.Errorf("want exit code %d; got %d", tt.wantExitCode, plugin.ExitStatusCode)
+			}
+
+			if plugin.ServiceOutput != tt.wantOutput {
+				t.Errorf("want service output %q; got %q", tt.wantOutput, plugin.ServiceOutput)
+			}
+
+			if len(plugin.Errors) != 0 {
+				t.Errorf("want no recorded errors; got %v", plugin.Errors)
+			}
+
+			switch {
+			case tt.wantNote == "" && plugin.LongServiceOutput != "report":
+				t.Errorf("want unchanged long service output; got %q", plugin.LongServiceOutput)
+			case tt.wantNote != "" && !strings.Contains(plugin.LongServiceOutput, tt.wantNote):
+				t.Errorf("want long service output containing %q; got %q", tt.wantNote, plugin.LongServiceOutput)
+			}
+		})
+	}
+}
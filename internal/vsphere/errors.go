@@ -8,6 +8,9 @@
 package vsphere
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/atc0005/go-nagios"
@@ -48,4 +51,13 @@ func AnnotateError(plugin *nagios.Plugin) {
 
 	// Apply error advice annotations.
 	plugin.AnnotateRecordedErrors(errorAdviceMap)
+
+	// Note which operations were performed (and how long each took) if the
+	// plugin runtime timeout was reached so that sysadmins have a better idea
+	// of how to tune the timeout value.
+	for i, err := range plugin.Errors {
+		if errors.Is(err, context.DeadlineExceeded) {
+			plugin.Errors[i] = fmt.Errorf("%w; %s", err, TimeoutDiagnostics())
+		}
+	}
 }
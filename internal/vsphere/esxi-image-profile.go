@@ -0,0 +1,638 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrESXiImageProfileDrift indicates that the image profile or ESXi build
+// for one or more ESXi hosts does not match the expected image for the
+// cluster.
+var ErrESXiImageProfileDrift = errors.New("ESXi image profile drift detected")
+
+// hostImageProfileUnknown is used in place of an empty value when reporting
+// host image profile details.
+const hostImageProfileUnknown string = "(unknown)"
+
+// HostImageProfile tracks the image profile and ESXi build for a specific
+// HostSystem along with any differences from the expected image for the
+// cluster.
+type HostImageProfile struct {
+	// Host is the HostSystem that the image details were retrieved from.
+	Host mo.HostSystem
+
+	// ProfileName is the name of the image profile installed on the
+	// HostSystem (e.g., ESXi-7.0U3i-20842708-standard).
+	ProfileName string
+
+	// ProfileVendor is the organization publishing the image profile.
+	ProfileVendor string
+
+	// Version is the ESXi version (e.g., 7.0.3) of the HostSystem.
+	Version string
+
+	// Build is the ESXi build number (e.g., 20842708) of the HostSystem.
+	Build string
+
+	// ProfileDrift indicates whether the image profile name does not match
+	// the expected image profile for the cluster.
+	ProfileDrift bool
+
+	// BuildDrift indicates whether the ESXi build does not match the
+	// expected build for the cluster.
+	BuildDrift bool
+
+	// Unavailable indicates whether image details could not be retrieved
+	// for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// ClusterImageProfile tracks the image profile and ESXi build for all
+// HostSystems in a specific cluster.
+type ClusterImageProfile struct {
+	// Cluster is the cluster that the HostSystems are members of.
+	Cluster mo.ClusterComputeResource
+
+	// ExpectedProfile is the image profile name that all HostSystems in the
+	// cluster are expected to use.
+	ExpectedProfile string
+
+	// ExpectedBuild is the ESXi build number that all HostSystems in the
+	// cluster are expected to run.
+	ExpectedBuild string
+
+	// DesiredImage is the vLCM desired image for the cluster. This is nil
+	// if vLCM desired image details were not requested or if the cluster is
+	// not managed with a single image.
+	DesiredImage *VLCMDesiredImage
+
+	// Hosts is the collection of evaluated HostSystems in the cluster.
+	Hosts []HostImageProfile
+}
+
+// ClusterImageProfileSet is a collection of ClusterImageProfile values.
+type ClusterImageProfileSet []ClusterImageProfile
+
+// hostImageProfileValue returns the given value for reporting, substituting
+// a placeholder for empty values.
+func hostImageProfileValue(value string) string {
+	if value == "" {
+		return hostImageProfileUnknown
+	}
+
+	return value
+}
+
+// GetHostImageProfile uses the HostImageConfigManager for the specified
+// HostSystem to retrieve the image profile installed on the HostSystem. The
+// ESXi version and build details are obtained from the HostSystem summary.
+func GetHostImageProfile(ctx context.Context, c *vim25.Client, hs mo.HostSystem) (HostImageProfile, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostImageProfile func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	hip := HostImageProfile{
+		Host: hs,
+	}
+
+	if hs.Summary.Config.Product != nil {
+		hip.Version = hs.Summary.Config.Product.Version
+		hip.Build = hs.Summary.Config.Product.Build
+	}
+
+	var host mo.HostSystem
+
+	pc := property.DefaultCollector(c)
+	err := pc.RetrieveOne(
+		ctx,
+		hs.Reference(),
+		[]string{"configManager.imageConfigManager"},
+		&host,
+	)
+	if err != nil {
+		return hip, fmt.Errorf(
+			"failed to retrieve image config manager for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	if host.ConfigManager.ImageConfigManager == nil {
+		logger.Printf(
+			"image config manager not available for host %s",
+			hs.Name,
+		)
+
+		return hip, nil
+	}
+
+	req := types.HostImageConfigGetProfile{
+		This: *host.ConfigManager.ImageConfigManager,
+	}
+
+	res, err := methods.HostImageConfigGetProfile(ctx, c, &req)
+	if err != nil {
+		return hip, fmt.Errorf(
+			"failed to retrieve image profile for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	hip.ProfileName = res.Returnval.Name
+	hip.ProfileVendor = res.Returnval.Vendor
+
+	return hip, nil
+
+}
+
+// GetClusterImageProfileSet retrieves the image profile and ESXi build for
+// each HostSystem in the given clusters and evaluates them against the
+// expected image for each cluster.
+//
+// Unless overridden by the given expected profile name, the expected image
+// profile is the most common image profile among the connected HostSystems
+// in the cluster. If a vLCM desired image is provided for the cluster
+// (indexed by cluster MOID) the ESXi build from that image is used as the
+// expected build, otherwise the most common ESXi build is used. HostSystems
+// which are not connected are flagged as unavailable and are not evaluated.
+func GetClusterImageProfileSet(
+	ctx context.Context,
+	c *vim25.Client,
+	clusters []mo.ClusterComputeResource,
+	expectedProfile string,
+	desiredImages map[string]*VLCMDesiredImage,
+) (ClusterImageProfileSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterImageProfileSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterImageProfileSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		hss, err := GetClusterHostSystems(ctx, c, cluster, true)
+		if err != nil {
+			return nil, err
+		}
+
+		cip := ClusterImageProfile{
+			Cluster:      cluster,
+			DesiredImage: desiredImages[cluster.Reference().Value],
+			Hosts:        make([]HostImageProfile, 0, len(hss)),
+		}
+
+		evaluated := make([]HostImageProfile, 0, len(hss))
+
+		for _, hs := range hss {
+			if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+				logger.Printf(
+					"host %s connection state is %s; skipping image profile evaluation",
+					hs.Name,
+					hs.Runtime.ConnectionState,
+				)
+
+				cip.Hosts = append(
+					cip.Hosts,
+					HostImageProfile{Host: hs, Unavailable: true},
+				)
+
+				continue
+			}
+
+			hip, err := GetHostImageProfile(ctx, c, hs)
+			if err != nil {
+				return nil, err
+			}
+
+			evaluated = append(evaluated, hip)
+		}
+
+		evaluateClusterImageProfile(&cip, evaluated, expectedProfile)
+
+		set = append(set, cip)
+	}
+
+	return set, nil
+
+}
+
+// evaluateClusterImageProfile determines the expected image profile and
+// ESXi build for the given cluster and flags any differences for the given
+// evaluated (connected) HostSystems before adding them to the cluster.
+func evaluateClusterImageProfile(cip *ClusterImageProfile, evaluated []HostImageProfile, expectedProfile string) {
+	profiles := make([]string, 0, len(evaluated))
+	builds := make([]string, 0, len(evaluated))
+	for _, hip := range evaluated {
+		profiles = append(profiles, hip.ProfileName)
+		builds = append(builds, hip.Build)
+	}
+
+	cip.ExpectedProfile = mostCommonValue(profiles)
+	if expectedProfile != "" {
+		cip.ExpectedProfile = expectedProfile
+	}
+
+	cip.ExpectedBuild = mostCommonValue(builds)
+	if cip.DesiredImage != nil && cip.DesiredImage.Build() != "" {
+		cip.ExpectedBuild = cip.DesiredImage.Build()
+	}
+
+	for _, hip := range evaluated {
+		hip.ProfileDrift = !strings.EqualFold(hip.ProfileName, cip.ExpectedProfile)
+		hip.BuildDrift = hip.Build != cip.ExpectedBuild
+
+		cip.Hosts = append(cip.Hosts, hip)
+	}
+
+	sort.Slice(cip.Hosts, func(i, j int) bool {
+		return strings.ToLower(cip.Hosts[i].Host.Name) <
+			strings.ToLower(cip.Hosts[j].Host.Name)
+	})
+}
+
+// HasCriticalState indicates whether the HostSystem is running an ESXi
+// build which does not match the expected build for the cluster.
+func (hip HostImageProfile) HasCriticalState() bool {
+	return !hip.Unavailable && hip.BuildDrift
+}
+
+// HasWarningState indicates whether the HostSystem has an image profile
+// installed which does not match the expected image profile for the
+// cluster.
+func (hip HostImageProfile) HasWarningState() bool {
+	return !hip.Unavailable && hip.ProfileDrift
+}
+
+// NumHostsUnavailable returns the number of HostSystems in the cluster
+// whose image details could not be evaluated due to their connection state.
+func (cip ClusterImageProfile) NumHostsUnavailable() int {
+	var num int
+	for _, hip := range cip.Hosts {
+		if hip.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem is running an
+// ESXi build which does not match the expected build for the cluster.
+func (set ClusterImageProfileSet) HasCriticalState() bool {
+	return set.NumHostsBuildDrift() > 0
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has an image
+// profile installed which does not match the expected image profile for the
+// cluster.
+func (set ClusterImageProfileSet) HasWarningState() bool {
+	return set.NumHostsProfileDrift() > 0
+}
+
+// NumHosts returns the number of HostSystems across all clusters.
+func (set ClusterImageProfileSet) NumHosts() int {
+	var num int
+	for _, cip := range set {
+		num += len(cip.Hosts)
+	}
+
+	return num
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose image details
+// were evaluated.
+func (set ClusterImageProfileSet) NumHostsEvaluated() int {
+	var num int
+	for _, cip := range set {
+		num += len(cip.Hosts) - cip.NumHostsUnavailable()
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose image details
+// could not be evaluated due to their connection state.
+func (set ClusterImageProfileSet) NumHostsUnavailable() int {
+	return set.NumHosts() - set.NumHostsEvaluated()
+}
+
+// NumHostsBuildDrift returns the number of HostSystems running an ESXi build
+// which does not match the expected build for the cluster.
+func (set ClusterImageProfileSet) NumHostsBuildDrift() int {
+	var num int
+	for _, cip := range set {
+		for _, hip := range cip.Hosts {
+			if hip.HasCriticalState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumHostsProfileDrift returns the number of HostSystems with an image
+// profile installed which does not match the expected image profile for the
+// cluster.
+func (set ClusterImageProfileSet) NumHostsProfileDrift() int {
+	var num int
+	for _, cip := range set {
+		for _, hip := range cip.Hosts {
+			if hip.HasWarningState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumClustersVLCMManaged returns the number of clusters with a vLCM desired
+// image used to determine the expected ESXi build.
+func (set ClusterImageProfileSet) NumClustersVLCMManaged() int {
+	var num int
+	for _, cip := range set {
+		if cip.DesiredImage != nil {
+			num++
+		}
+	}
+
+	return num
+}
+
+// ClusterImageProfilePerfData generates performance data metrics from the
+// given collection of evaluated cluster image profiles.
+func ClusterImageProfilePerfData(set ClusterImageProfileSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "clusters",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_vlcm_image",
+			Value: fmt.Sprintf("%d", set.NumClustersVLCMManaged()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", set.NumHosts()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_build_drift",
+			Value: fmt.Sprintf("%d", set.NumHostsBuildDrift()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_image_profile_drift",
+			Value: fmt.Sprintf("%d", set.NumHostsProfileDrift()),
+			Min:   "0",
+		},
+	}
+}
+
+// ClusterImageProfileOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ClusterImageProfileOneLineCheckSummary(
+	stateLabel string,
+	set ClusterImageProfileSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterImageProfileOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with ESXi build drift and %d hosts with image profile drift (evaluated %d hosts, %d clusters)",
+			stateLabel,
+			set.NumHostsBuildDrift(),
+			set.NumHostsProfileDrift(),
+			set.NumHostsEvaluated(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No ESXi image profile drift detected (evaluated %d hosts, %d clusters)",
+			stateLabel,
+			set.NumHostsEvaluated(),
+			len(set),
+		)
+	}
+}
+
+// ClusterImageProfileReport generates a summary of ESXi image profile and
+// build drift for evaluated clusters along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func ClusterImageProfileReport(
+	c *vim25.Client,
+	set ClusterImageProfileSet,
+	expectedProfile string,
+	useDesiredImage bool,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterImageProfileReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts deviating from expected cluster image:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, cip := range set {
+		for _, hip := range cip.Hosts {
+			if !hip.HasCriticalState() && !hip.HasWarningState() {
+				continue
+			}
+			numProblemHosts++
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (cluster: %s)%s",
+				hip.Host.Name,
+				cip.Cluster.Name,
+				nagios.CheckOutputEOL,
+			)
+
+			if hip.BuildDrift {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** ESXi build: %s (version %s, expected build: %s)%s",
+					hostImageProfileValue(hip.Build),
+					hostImageProfileValue(hip.Version),
+					hostImageProfileValue(cip.ExpectedBuild),
+					nagios.CheckOutputEOL,
+				)
+			}
+
+			if hip.ProfileDrift {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** image profile: %s (expected: %s)%s",
+					hostImageProfileValue(hip.ProfileName),
+					hostImageProfileValue(cip.ExpectedProfile),
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sExpected image per cluster:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, cip := range set {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%d hosts, %d unavailable)%s",
+			cip.Cluster.Name,
+			len(cip.Hosts),
+			cip.NumHostsUnavailable(),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"** image profile: %s%s",
+			hostImageProfileValue(cip.ExpectedProfile),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"** ESXi build: %s%s",
+			hostImageProfileValue(cip.ExpectedBuild),
+			nagios.CheckOutputEOL,
+		)
+
+		if cip.DesiredImage != nil {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** vLCM desired image: %s%s",
+				cip.DesiredImage,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	if expectedProfile == "" {
+		expectedProfile = "not specified; using most common per cluster"
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified expected image profile: %s%s",
+		expectedProfile,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vLCM desired image used as expected build: %t%s",
+		useDesiredImage,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// imageProfileHost returns the image details for a host with the given name,
+// image profile and ESXi build.
+func imageProfileHost(name string, profile string, build string) HostImageProfile {
+	return HostImageProfile{
+		Host:        mo.HostSystem{ManagedEntity: mo.ManagedEntity{Name: name}},
+		ProfileName: profile,
+		Build:       build,
+	}
+}
+
+func TestEvaluateClusterImageProfile(t *testing.T) {
+	const (
+		u3i string = "ESXi-7.0U3i-20842708-standard"
+		u3g string = "ESXi-7.0U3g-20328353-standard"
+	)
+
+	hosts := []HostImageProfile{
+		imageProfileHost("esx3", u3i, "20842708"),
+		imageProfileHost("ESX2", u3g, "20328353"),
+		imageProfileHost("esx1", u3i, "20842708"),
+	}
+
+	tests := map[string]struct {
+		expectedProfile     string
+		desiredImage        *VLCMDesiredImage
+		wantExpectedProfile string
+		wantExpectedBuild   string
+		wantProfileDrift    []string
+		wantBuildDrift      []string
+	}{
+		"most common profile and build": {
+			wantExpectedProfile: u3i,
+			wantExpectedBuild:   "20842708",
+			wantProfileDrift:    []string{"ESX2"},
+			wantBuildDrift:      []string{"ESX2"},
+		},
+		"expected profile matched case-insensitively": {
+			expectedProfile:     "esxi-7.0u3g-20328353-standard",
+			wantExpectedProfile: "esxi-7.0u3g-20328353-standard",
+			wantExpectedBuild:   "20842708",
+			wantProfileDrift:    []string{"esx1", "esx3"},
+			wantBuildDrift:      []string{"ESX2"},
+		},
+		"vLCM desired image build": {
+			desiredImage:        &VLCMDesiredImage{Version: "7.0.3-0.50.20328353"},
+			wantExpectedProfile: u3i,
+			wantExpectedBuild:   "20328353",
+			wantProfileDrift:    []string{"ESX2"},
+			wantBuildDrift:      []string{"esx1", "esx3"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cip := ClusterImageProfile{
+				DesiredImage: tt.desiredImage,
+				Hosts: []HostImageProfile{
+					{Host: mo.HostSystem{ManagedEntity: mo.ManagedEntity{Name: "esx0"}}, Unavailable: true},
+				},
+			}
+
+			evaluateClusterImageProfile(&cip, hosts, tt.expectedProfile)
+
+			if cip.ExpectedProfile != tt.wantExpectedProfile {
+				t.Errorf("want expected profile %q; got %q", tt.wantExpectedProfile, cip.ExpectedProfile)
+			}
+
+			if cip.ExpectedBuild != tt.wantExpectedBuild {
+				t.Errorf("want expected build %q; got %q", tt.wantExpectedBuild, cip.ExpectedBuild)
+			}
+
+			var order, profileDrift, buildDrift []string
+			for _, hip := range cip.Hosts {
+				order = append(order, hip.Host.Name)
+				if hip.HasWarningState() {
+					profileDrift = append(profileDrift, hip.Host.Name)
+				}
+				if hip.HasCriticalState() {
+					buildDrift = append(buildDrift, hip.Host.Name)
+				}
+			}
+
+			if d := cmp.Diff([]string{"esx0", "esx1", "ESX2", "esx3"}, order); d != "" {
+				t.Errorf("host order (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantProfileDrift, profileDrift); d != "" {
+				t.Errorf("profile drift (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantBuildDrift, buildDrift); d != "" {
+				t.Errorf("build drift (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestClusterImageProfileSetCounts(t *testing.T) {
+	set := ClusterImageProfileSet{
+		{
+			DesiredImage: &VLCMDesiredImage{Version: "7.0.3-0.65.20842708"},
+			Hosts: []HostImageProfile{
+				{ProfileDrift: true},
+				{BuildDrift: true, ProfileDrift: true},
+				{Unavailable: true, BuildDrift: true, ProfileDrift: true},
+			},
+		},
+		{
+			Hosts: []HostImageProfile{{}, {}},
+		},
+	}
+
+	if got := set.NumHosts(); got != 5 {
+		t.Errorf("want 5 hosts; got %d", got)
+	}
+
+	if got := set.NumHostsEvaluated(); got != 4 {
+		t.Errorf("want 4 evaluated hosts; got %d", got)
+	}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumHostsBuildDrift(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 host with build drift; got %d", got)
+	}
+
+	if got := set.NumHostsProfileDrift(); got != 2 || !set.HasWarningState() {
+		t.Errorf("want 2 hosts with profile drift; got %d", got)
+	}
+
+	if got := set.NumClustersVLCMManaged(); got != 1 {
+		t.Errorf("want 1 vLCM managed cluster; got %d", got)
+	}
+}
+
+func TestHostImageProfileValue(t *testing.T) {
+	if got := hostImageProfileValue(""); got != hostImageProfileUnknown {
+		t.Errorf("want %q; got %q", hostImageProfileUnknown, got)
+	}
+
+	if got := hostImageProfileValue("20842708"); got != "20842708" {
+		t.Errorf("want %q; got %q", "20842708", got)
+	}
+}
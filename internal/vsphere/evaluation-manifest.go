@@ -0,0 +1,388 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// Reasons recorded in an evaluation manifest for VirtualMachines skipped by
+// the shared VirtualMachine filtering logic. Each reason corresponds to a
+// filtering step applied by the FilterVMs function.
+const (
+	ManifestSkipReasonResourcePool      string = "resource_pool"
+	ManifestSkipReasonDatacenter        string = "datacenter"
+	ManifestSkipReasonCluster           string = "cluster"
+	ManifestSkipReasonHost              string = "host"
+	ManifestSkipReasonFolder            string = "folder"
+	ManifestSkipReasonTag               string = "tag"
+	ManifestSkipReasonName              string = "name"
+	ManifestSkipReasonPowerState        string = "power_state"
+	ManifestSkipReasonMaintenanceWindow string = "maintenance_window"
+)
+
+// ManifestVM is a VirtualMachine listed in an evaluation manifest.
+type ManifestVM struct {
+	// Name is the name of the VirtualMachine.
+	Name string `json:"name"`
+
+	// ID is the Managed Object ID of the VirtualMachine (e.g., vm-123).
+	ID string `json:"id"`
+
+	// Reason is the filtering step which skipped the VirtualMachine. This is
+	// empty for evaluated VirtualMachines.
+	Reason string `json:"reason,omitempty"`
+}
+
+// ManifestVMFilters is the collection of VirtualMachine filtering results
+// recorded in an evaluation manifest.
+type ManifestVMFilters struct {
+	// Options is the set of filtering options applied.
+	Options ManifestVMFilterOptions `json:"options"`
+
+	// NumVMsAll is the number of all (non-template) VirtualMachines in the
+	// inventory.
+	NumVMsAll int `json:"vms_all"`
+
+	// NumVMsEvaluated is the number of VirtualMachines remaining after all
+	// filtering was applied.
+	NumVMsEvaluated int `json:"vms_evaluated"`
+
+	// NumVMsSkipped is the number of VirtualMachines skipped by filtering.
+	NumVMsSkipped int `json:"vms_skipped"`
+
+	// Evaluated is the collection of VirtualMachines remaining after all
+	// filtering was applied.
+	Evaluated []ManifestVM `json:"evaluated"`
+
+	// Skipped is the collection of VirtualMachines skipped by filtering
+	// along with the reason each was skipped.
+	Skipped []ManifestVM `json:"skipped"`
+}
+
+// ManifestVMFilterOptions is the set of VirtualMachine filtering options
+// recorded in an evaluation manifest.
+type ManifestVMFilterOptions struct {
+	ResourcePoolsIncluded       []string `json:"include_rp,omitempty"`
+	ResourcePoolsExcluded       []string `json:"exclude_rp,omitempty"`
+	DatacentersIncluded         []string `json:"include_datacenter,omitempty"`
+	DatacentersExcluded         []string `json:"exclude_datacenter,omitempty"`
+	ClusterNamesIncluded        []string `json:"include_cluster,omitempty"`
+	ClusterNamesExcluded        []string `json:"exclude_cluster,omitempty"`
+	HostNamesIncluded           []string `json:"include_host,omitempty"`
+	HostNamesExcluded           []string `json:"exclude_host,omitempty"`
+	FoldersIncluded             []string `json:"include_folder_id,omitempty"`
+	FoldersExcluded             []string `json:"exclude_folder_id,omitempty"`
+	TagsIncluded                []string `json:"include_tag,omitempty"`
+	TagsExcluded                []string `json:"exclude_tag,omitempty"`
+	VirtualMachineNamesExcluded []string `json:"ignore_vm,omitempty"`
+	IncludePoweredOff           bool     `json:"powered_off"`
+	MaintenanceCAName           string   `json:"maintenance_ca,omitempty"`
+}
+
+// EvaluationManifest is a machine-readable description of a single plugin
+// execution: what was evaluated, what was skipped and why. This is intended
+// for use in audits confirming that monitoring coverage includes all
+// expected VirtualMachines.
+type EvaluationManifest struct {
+	// Plugin is the name of the plugin (e.g., vm-rdm-usage).
+	Plugin string `json:"plugin"`
+
+	// Server is the ESXi host or vCenter instance evaluated.
+	Server string `json:"server"`
+
+	// Generated is when the manifest was generated.
+	Generated time.Time `json:"generated"`
+
+	// State is the Nagios state label for the final plugin state.
+	State string `json:"state"`
+
+	// ExitCode is the Nagios exit code for the final plugin state.
+	ExitCode int `json:"exit_code"`
+
+	// Summary is the one-line service check results summary.
+	Summary string `json:"summary"`
+
+	// VMFilters is the collection of VirtualMachine filtering results. This
+	// is omitted for plugins which do not use the shared VirtualMachine
+	// filtering logic.
+	VMFilters *ManifestVMFilters `json:"vm_filters,omitempty"`
+}
+
+// evaluationManifest holds the user-specified evaluation manifest settings
+// and the VirtualMachine filtering results recorded for the current plugin
+// execution.
+var evaluationManifest = struct {
+	sync.Mutex
+	filename  string
+	plugin    string
+	server    string
+	vmFilters *ManifestVMFilters
+}{}
+
+// SetEvaluationManifest enables recording of an evaluation manifest for the
+// current plugin execution. The manifest is written to the specified file
+// by WriteEvaluationManifest. If the specified path is an existing
+// directory, a new file named after the plugin, server and current time is
+// created in that directory for each plugin execution. Recording is
+// disabled if the filename is empty.
+func SetEvaluationManifest(filename string, pluginName string, server string) {
+	evaluationManifest.Lock()
+	defer evaluationManifest.Unlock()
+
+	evaluationManifest.filename = filename
+	evaluationManifest.plugin = pluginName
+	evaluationManifest.server = server
+
+	if filename != "" {
+		logger.Printf("evaluation manifest enabled using path %q", filename)
+	}
+}
+
+// evaluationManifestEnabled indicates whether an evaluation manifest was
+// requested for the current plugin execution.
+func evaluationManifestEnabled() bool {
+	evaluationManifest.Lock()
+	defer evaluationManifest.Unlock()
+
+	return evaluationManifest.filename != ""
+}
+
+// vmsFilterStep is a VirtualMachine filtering step applied by the FilterVMs
+// function along with the VirtualMachines remaining after the step.
+type vmsFilterStep struct {
+	reason string
+	after  []mo.VirtualMachine
+}
+
+// getNonTemplateVMNames retrieves the name of all non-template
+// VirtualMachines in the inventory. Only the name property is retrieved in
+// order to limit the cost of recording an evaluation manifest.
+func getNonTemplateVMNames(ctx context.Context, c *vim25.Client) ([]mo.VirtualMachine, error) {
+	m := view.NewManager(c)
+
+	kind := []string{MgObjRefTypeVirtualMachine}
+	v, createViewErr := m.CreateContainerView(
+		ctx,
+		c.ServiceContent.RootFolder,
+		kind,
+		true,
+	)
+	if createViewErr != nil {
+		return nil, createViewErr
+	}
+	defer func() {
+		if err := v.Destroy(ctx); err != nil {
+			logger.Printf("Error occurred while destroying virtual machine view: %s", err)
+		}
+	}()
+
+	var vms []mo.VirtualMachine
+	filter := property.Match{"config.template": false}
+	if err := v.RetrieveWithFilter(ctx, kind, []string{"name"}, &vms, filter); err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve VMs list: %w",
+			err,
+		)
+	}
+
+	return vms, nil
+}
+
+// recordVMsFilterManifest records the results of each VirtualMachine
+// filtering step for use in an evaluation manifest. VirtualMachines present
+// before a step but not after are recorded as skipped by that step.
+// Failure to retrieve the list of all VirtualMachines is logged and the
+// VirtualMachines skipped by resource pool filtering are omitted.
+func recordVMsFilterManifest(
+	ctx context.Context,
+	c *vim25.Client,
+	filterOptions VMsFilterOptions,
+	numVMsAll int,
+	steps []vmsFilterStep,
+) {
+	allVMs, err := getNonTemplateVMNames(ctx, c)
+	if err != nil {
+		logger.Printf("failed to retrieve VMs for evaluation manifest: %v", err)
+
+		if len(steps) > 0 {
+			allVMs = steps[0].after
+		}
+	}
+
+	vmFilters := ManifestVMFilters{
+		Options: ManifestVMFilterOptions{
+			ResourcePoolsIncluded:       filterOptions.ResourcePoolsIncluded,
+			ResourcePoolsExcluded:       filterOptions.ResourcePoolsExcluded,
+			DatacentersIncluded:         filterOptions.DatacentersIncluded,
+			DatacentersExcluded:         filterOptions.DatacentersExcluded,
+			ClusterNamesIncluded:        filterOptions.ClusterNamesIncluded,
+			ClusterNamesExcluded:        filterOptions.ClusterNamesExcluded,
+			HostNamesIncluded:           filterOptions.HostNamesIncluded,
+			HostNamesExcluded:           filterOptions.HostNamesExcluded,
+			FoldersIncluded:             filterOptions.FoldersIncluded,
+			FoldersExcluded:             filterOptions.FoldersExcluded,
+			TagsIncluded:                filterOptions.TagsIncluded,
+			TagsExcluded:                filterOptions.TagsExcluded,
+			VirtualMachineNamesExcluded: filterOptions.VirtualMachineNamesExcluded,
+			IncludePoweredOff:           filterOptions.IncludePoweredOff,
+			MaintenanceCAName:           filterOptions.MaintenanceCAName,
+		},
+		NumVMsAll: numVMsAll,
+		Evaluated: []ManifestVM{},
+		Skipped:   []ManifestVM{},
+	}
+
+	before := allVMs
+	for _, step := range steps {
+		remaining := make(map[string]struct{}, len(step.after))
+		for _, vm := range step.after {
+			remaining[vm.Self.Value] = struct{}{}
+		}
+
+		for _, vm := range before {
+			if _, ok := remaining[vm.Self.Value]; !ok {
+				vmFilters.Skipped = append(vmFilters.Skipped, ManifestVM{
+					Name:   vm.Name,
+					ID:     vm.Self.Value,
+					Reason: step.reason,
+				})
+			}
+		}
+
+		before = step.after
+	}
+
+	for _, vm := range before {
+		vmFilters.Evaluated = append(vmFilters.Evaluated, ManifestVM{
+			Name: vm.Name,
+			ID:   vm.Self.Value,
+		})
+	}
+
+	sortManifestVMs := func(vms []ManifestVM) {
+		sort.Slice(vms, func(i, j int) bool {
+			return strings.ToLower(vms[i].Name) < strings.ToLower(vms[j].Name)
+		})
+	}
+	sortManifestVMs(vmFilters.Evaluated)
+	sortManifestVMs(vmFilters.Skipped)
+
+	vmFilters.NumVMsEvaluated = len(vmFilters.Evaluated)
+	vmFilters.NumVMsSkipped = len(vmFilters.Skipped)
+
+	evaluationManifest.Lock()
+	defer evaluationManifest.Unlock()
+
+	evaluationManifest.vmFilters = &vmFilters
+}
+
+// manifestPath returns the path of the evaluation manifest file. If the
+// user-specified path is an existing directory, a file name based on the
+// plugin name, server and given time is used.
+func manifestPath(filename string, pluginName string, server string, now time.Time) string {
+	info, err := os.Stat(filename)
+	if err != nil || !info.IsDir() {
+		return filename
+	}
+
+	name := fmt.Sprintf(
+		"%s-%s-%s.json",
+		pluginName,
+		server,
+		now.UTC().Format("20060102T150405.000000000Z"),
+	)
+
+	return filepath.Join(filename, name)
+}
+
+// WriteEvaluationManifest is a helper function used to write an evaluation
+// manifest (if requested) describing the current plugin execution. The
+// manifest is first written to a temporary file and then renamed in order
+// to prevent readers from observing a partially written file. Errors are
+// logged and do not affect the plugin state. This is intended to be
+// deferred so that it runs after the final plugin state (including any
+// state mappings) has been determined.
+func WriteEvaluationManifest(plugin *nagios.Plugin) {
+	evaluationManifest.Lock()
+	filename := evaluationManifest.filename
+	manifest := EvaluationManifest{
+		Plugin:    evaluationManifest.plugin,
+		Server:    evaluationManifest.server,
+		Generated: time.Now(),
+		VMFilters: evaluationManifest.vmFilters,
+	}
+	evaluationManifest.Unlock()
+
+	if filename == "" {
+		return
+	}
+
+	manifest.ExitCode = plugin.ExitStatusCode
+	manifest.Summary = plugin.ServiceOutput
+	for label, exitCode := range stateLabelExitCodes {
+		if exitCode == plugin.ExitStatusCode {
+			manifest.State = label
+			break
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		logger.Printf("failed to encode evaluation manifest: %v", err)
+
+		return
+	}
+
+	path := manifestPath(filename, manifest.Plugin, manifest.Server, manifest.Generated)
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		logger.Printf("failed to create evaluation manifest file: %v", err)
+
+		return
+	}
+
+	_, writeErr := tmpFile.Write(append(data, '\n'))
+	closeErr := tmpFile.Close()
+
+	if writeErr != nil || closeErr != nil {
+		logger.Printf(
+			"failed to write evaluation manifest file %q: %v",
+			tmpFile.Name(),
+			errors.Join(writeErr, closeErr),
+		)
+		_ = os.Remove(tmpFile.Name())
+
+		return
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		logger.Printf("failed to write evaluation manifest file %q: %v", path, err)
+		_ = os.Remove(tmpFile.Name())
+
+		return
+	}
+
+	logger.Printf("evaluation manifest written to %q", path)
+}
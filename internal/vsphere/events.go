@@ -0,0 +1,122 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrEventManagerUnavailable indicates that the EventManager is not
+// available for the connected vSphere environment.
+var ErrEventManagerUnavailable = errors.New("event manager unavailable")
+
+// eventCollectorPageSize is the number of events retrieved from the event
+// history collector per request.
+const eventCollectorPageSize int32 = 100
+
+// GetEvents uses an event history collector to retrieve all events matching
+// the given filter. Events are returned in the order provided by the
+// collector (oldest first).
+func GetEvents(ctx context.Context, c *vim25.Client, filter types.EventFilterSpec) ([]types.BaseEvent, error) {
+
+	funcTimeStart := time.Now()
+
+	var events []types.BaseEvent
+
+	defer func(events *[]types.BaseEvent) {
+		logger.Printf(
+			"It took %v to execute GetEvents func (and retrieve %d events).\n",
+			time.Since(funcTimeStart),
+			len(*events),
+		)
+	}(&events)
+
+	if c.ServiceContent.EventManager == nil {
+		return nil, ErrEventManagerUnavailable
+	}
+
+	createRes, err := methods.CreateCollectorForEvents(ctx, c, &types.CreateCollectorForEvents{
+		This:   *c.ServiceContent.EventManager,
+		Filter: filter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create event history collector: %w",
+			err,
+		)
+	}
+
+	collector := createRes.Returnval
+
+	defer func() {
+		// The collector is a server-side object; destroy it once we're done
+		// to free resources on the server.
+		_, err := methods.DestroyCollector(ctx, c, &types.DestroyCollector{
+			This: collector,
+		})
+		if err != nil {
+			logger.Printf("Error occurred while destroying event collector: %s", err)
+		}
+	}()
+
+	// Position the collector at the oldest matching event before reading
+	// forward through the collected events.
+	if _, err := methods.RewindCollector(ctx, c, &types.RewindCollector{
+		This: collector,
+	}); err != nil {
+		return nil, fmt.Errorf(
+			"failed to rewind event history collector: %w",
+			err,
+		)
+	}
+
+	for {
+		res, err := methods.ReadNextEvents(ctx, c, &types.ReadNextEvents{
+			This:     collector,
+			MaxCount: eventCollectorPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve events: %w",
+				err,
+			)
+		}
+
+		if len(res.Returnval) == 0 {
+			break
+		}
+
+		events = append(events, res.Returnval...)
+	}
+
+	return events, nil
+
+}
+
+// EventTypeID returns the type ID for the given event. For extended events
+// (e.g., esx.audit.ssh.enabled) this is the event type ID provided by the
+// event; for all other events this is the name of the event type (e.g.,
+// HostConnectionLostEvent).
+func EventTypeID(event types.BaseEvent) string {
+	switch e := event.(type) {
+	case *types.EventEx:
+		return e.EventTypeId
+	case *types.ExtendedEvent:
+		return e.EventTypeId
+	default:
+		return reflect.TypeOf(event).Elem().Name()
+	}
+}
@@ -0,0 +1,393 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrFolderVMCountThresholdCrossed indicates that specified folder VM count
+// thresholds have been crossed for one or more folders.
+var ErrFolderVMCountThresholdCrossed = errors.New("folder VM count outside of specified thresholds")
+
+// FolderVMCountThresholds represents the thresholds used to evaluate the
+// number of VMs in a folder. Thresholds not specified are nil and are not
+// evaluated.
+type FolderVMCountThresholds struct {
+	MaxWarning  *int
+	MaxCritical *int
+	MinWarning  *int
+	MinCritical *int
+}
+
+// FolderVMCount tracks the VMs found within a specific Folder (including
+// nested folders) along with the thresholds used to evaluate the count.
+type FolderVMCount struct {
+	Folder     mo.Folder
+	VMs        []mo.VirtualMachine
+	Thresholds FolderVMCountThresholds
+}
+
+// FolderVMCountSet is a collection of FolderVMCount values.
+type FolderVMCountSet []FolderVMCount
+
+// GetFolderVMCountSet retrieves the VMs within each of the given Folders
+// (including nested folders), excluding any VMs in the specified ignore
+// list, and evaluates the count of VMs against the specified thresholds.
+func GetFolderVMCountSet(
+	ctx context.Context,
+	c *vim25.Client,
+	folders []mo.Folder,
+	ignoredVMs []string,
+	thresholds FolderVMCountThresholds,
+) (FolderVMCountSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetFolderVMCountSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(FolderVMCountSet, 0, len(folders))
+
+	for _, folder := range folders {
+		vms, err := GetVMsFromContainer(ctx, c, true, folder.ManagedEntity)
+		if err != nil {
+			return nil, err
+		}
+
+		vms, _ = ExcludeVMsByName(vms, ignoredVMs)
+
+		set = append(set, FolderVMCount{
+			Folder:     folder,
+			VMs:        vms,
+			Thresholds: thresholds,
+		})
+	}
+
+	return set, nil
+
+}
+
+// IsCriticalState indicates whether the number of VMs in the folder has
+// crossed a CRITICAL level threshold.
+func (fvc FolderVMCount) IsCriticalState() bool {
+	switch {
+	case fvc.Thresholds.MaxCritical != nil && len(fvc.VMs) > *fvc.Thresholds.MaxCritical:
+		return true
+	case fvc.Thresholds.MinCritical != nil && len(fvc.VMs) < *fvc.Thresholds.MinCritical:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsWarningState indicates whether the number of VMs in the folder has
+// crossed a WARNING level threshold.
+func (fvc FolderVMCount) IsWarningState() bool {
+	if fvc.IsCriticalState() {
+		return false
+	}
+
+	switch {
+	case fvc.Thresholds.MaxWarning != nil && len(fvc.VMs) > *fvc.Thresholds.MaxWarning:
+		return true
+	case fvc.Thresholds.MinWarning != nil && len(fvc.VMs) < *fvc.Thresholds.MinWarning:
+		return true
+	default:
+		return false
+	}
+}
+
+// NumCritical returns the number of folders in a CRITICAL state.
+func (set FolderVMCountSet) NumCritical() int {
+	var num int
+	for _, fvc := range set {
+		if fvc.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of folders in a WARNING state.
+func (set FolderVMCountSet) NumWarning() int {
+	var num int
+	for _, fvc := range set {
+		if fvc.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumVMs returns the number of VMs across all evaluated folders.
+func (set FolderVMCountSet) NumVMs() int {
+	var num int
+	for _, fvc := range set {
+		num += len(fvc.VMs)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated folder is in a CRITICAL
+// state.
+func (set FolderVMCountSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated folder is in a WARNING
+// state.
+func (set FolderVMCountSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// FolderVMCountPerfData generates performance data metrics from the given
+// collection of evaluated folders. Summary metrics are emitted along with a
+// VM count metric per folder.
+func FolderVMCountPerfData(set FolderVMCountSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "folders_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "folders_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "folders_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "vms",
+			Value: fmt.Sprintf("%d", set.NumVMs()),
+			Min:   "0",
+		},
+	}
+
+	for _, fvc := range set {
+		pd = append(pd, nagios.PerformanceData{
+			Label: PerfDataLabel(fvc.Folder.Name, "vms"),
+			Value: fmt.Sprintf("%d", len(fvc.VMs)),
+			Warn:  folderVMCountThresholdRange(fvc.Thresholds.MinWarning, fvc.Thresholds.MaxWarning),
+			Crit:  folderVMCountThresholdRange(fvc.Thresholds.MinCritical, fvc.Thresholds.MaxCritical),
+			Min:   "0",
+		})
+	}
+
+	return pd
+
+}
+
+// folderVMCountThresholdRange returns the given minimum and maximum VM
+// count thresholds using the Nagios threshold range format (e.g., "5:20").
+// An empty string is returned if neither threshold is specified.
+func folderVMCountThresholdRange(minimum *int, maximum *int) string {
+	switch {
+	case minimum != nil && maximum != nil:
+		return fmt.Sprintf("%d:%d", *minimum, *maximum)
+	case minimum != nil:
+		return fmt.Sprintf("%d:", *minimum)
+	case maximum != nil:
+		return fmt.Sprintf("%d", *maximum)
+	default:
+		return ""
+	}
+}
+
+// folderVMCountThresholdsDesc returns a human readable description of the
+// given minimum and maximum folder VM count thresholds.
+func folderVMCountThresholdsDesc(minThreshold *int, maxThreshold *int) string {
+	var desc []string
+
+	if minThreshold != nil {
+		desc = append(desc, fmt.Sprintf("fewer than %d VMs", *minThreshold))
+	}
+
+	if maxThreshold != nil {
+		desc = append(desc, fmt.Sprintf("more than %d VMs", *maxThreshold))
+	}
+
+	if len(desc) == 0 {
+		return "Not specified."
+	}
+
+	return strings.Join(desc, " or ") + " in a folder"
+}
+
+// CriticalThresholdDesc returns a human readable description of the CRITICAL
+// level thresholds.
+func (t FolderVMCountThresholds) CriticalThresholdDesc() string {
+	return folderVMCountThresholdsDesc(t.MinCritical, t.MaxCritical)
+}
+
+// WarningThresholdDesc returns a human readable description of the WARNING
+// level thresholds.
+func (t FolderVMCountThresholds) WarningThresholdDesc() string {
+	return folderVMCountThresholdsDesc(t.MinWarning, t.MaxWarning)
+}
+
+// FolderVMCountOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func FolderVMCountOneLineCheckSummary(
+	stateLabel string,
+	set FolderVMCountSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FolderVMCountOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d folders with VM counts outside of specified thresholds (evaluated %d folders, %d VMs)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumVMs(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No folders with VM counts outside of specified thresholds (evaluated %d folders, %d VMs)",
+			stateLabel,
+			len(set),
+			set.NumVMs(),
+		)
+	}
+}
+
+// FolderVMCountReport generates a summary of VM counts per folder along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func FolderVMCountReport(
+	c *vim25.Client,
+	set FolderVMCountSet,
+	ignoredVMs []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FolderVMCountReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VM counts per folder:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, fvc := range set {
+		var state string
+		switch {
+		case fvc.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case fvc.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (ID: %s): %d VMs [%s]%s",
+			fvc.Folder.Name,
+			fvc.Folder.Self.Value,
+			len(fvc.VMs),
+			state,
+			nagios.CheckOutputEOL,
+		)
+
+		// Only list VMs for folders outside of thresholds to keep the
+		// report manageable.
+		if state == nagios.StateOKLabel {
+			continue
+		}
+
+		for _, vm := range fvc.VMs {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (%s)%s",
+				vm.Name,
+				vm.Runtime.PowerState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs to exclude (%d): [%v]%s",
+		len(ignoredVMs),
+		strings.Join(ignoredVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
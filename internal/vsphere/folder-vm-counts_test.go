@@ -0,0 +1,177 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// folderThreshold returns a pointer to the given threshold value.
+func folderThreshold(v int) *int {
+	return &v
+}
+
+func TestFolderVMCountState(t *testing.T) {
+	tests := map[string]struct {
+		numVMs       int
+		thresholds   FolderVMCountThresholds
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no thresholds": {
+			numVMs: 100,
+		},
+		"within all thresholds": {
+			numVMs: 5,
+			thresholds: FolderVMCountThresholds{
+				MaxWarning:  folderThreshold(10),
+				MaxCritical: folderThreshold(20),
+				MinWarning:  folderThreshold(2),
+				MinCritical: folderThreshold(1),
+			},
+		},
+		"at maximum WARNING threshold": {
+			numVMs: 10,
+			thresholds: FolderVMCountThresholds{
+				MaxWarning:  folderThreshold(10),
+				MaxCritical: folderThreshold(20),
+			},
+		},
+		"above maximum WARNING threshold": {
+			numVMs: 11,
+			thresholds: FolderVMCountThresholds{
+				MaxWarning:  folderThreshold(10),
+				MaxCritical: folderThreshold(20),
+			},
+			wantWarning: true,
+		},
+		"above maximum CRITICAL threshold": {
+			numVMs: 21,
+			thresholds: FolderVMCountThresholds{
+				MaxWarning:  folderThreshold(10),
+				MaxCritical: folderThreshold(20),
+			},
+			wantCritical: true,
+		},
+		"at minimum WARNING threshold": {
+			numVMs: 2,
+			thresholds: FolderVMCountThresholds{
+				MinWarning:  folderThreshold(2),
+				MinCritical: folderThreshold(1),
+			},
+		},
+		"below minimum WARNING threshold": {
+			numVMs: 1,
+			thresholds: FolderVMCountThresholds{
+				MinWarning:  folderThreshold(2),
+				MinCritical: folderThreshold(1),
+			},
+			wantWarning: true,
+		},
+		"below minimum CRITICAL threshold": {
+			numVMs: 0,
+			thresholds: FolderVMCountThresholds{
+				MinWarning:  folderThreshold(2),
+				MinCritical: folderThreshold(1),
+			},
+			wantCritical: true,
+		},
+		"only CRITICAL threshold specified": {
+			numVMs: 11,
+			thresholds: FolderVMCountThresholds{
+				MaxCritical: folderThreshold(10),
+			},
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fvc := FolderVMCount{
+				VMs:        make([]mo.VirtualMachine, tt.numVMs),
+				Thresholds: tt.thresholds,
+			}
+
+			if got := fvc.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := fvc.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestFolderVMCountSetCounts(t *testing.T) {
+	thresholds := FolderVMCountThresholds{
+		MaxWarning:  folderThreshold(2),
+		MaxCritical: folderThreshold(4),
+	}
+
+	set := FolderVMCountSet{
+		{VMs: make([]mo.VirtualMachine, 5), Thresholds: thresholds},
+		{VMs: make([]mo.VirtualMachine, 3), Thresholds: thresholds},
+		{VMs: make([]mo.VirtualMachine, 1), Thresholds: thresholds},
+	}
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL folder; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING folder; got %d", got)
+	}
+
+	if got := set.NumVMs(); got != 9 {
+		t.Errorf("want 9 VMs; got %d", got)
+	}
+}
+
+func TestFolderVMCountThresholdFormatting(t *testing.T) {
+	tests := map[string]struct {
+		minimum   *int
+		maximum   *int
+		wantRange string
+		wantDesc  string
+	}{
+		"not specified": {
+			wantDesc: "Not specified.",
+		},
+		"minimum only": {
+			minimum:   folderThreshold(5),
+			wantRange: "5:",
+			wantDesc:  "fewer than 5 VMs in a folder",
+		},
+		"maximum only": {
+			maximum:   folderThreshold(20),
+			wantRange: "20",
+			wantDesc:  "more than 20 VMs in a folder",
+		},
+		"minimum and maximum": {
+			minimum:   folderThreshold(5),
+			maximum:   folderThreshold(20),
+			wantRange: "5:20",
+			wantDesc:  "fewer than 5 VMs or more than 20 VMs in a folder",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := folderVMCountThresholdRange(tt.minimum, tt.maximum); got != tt.wantRange {
+				t.Errorf("want range %q; got %q", tt.wantRange, got)
+			}
+
+			if got := folderVMCountThresholdsDesc(tt.minimum, tt.maximum); got != tt.wantDesc {
+				t.Errorf("want description %q; got %q", tt.wantDesc, got)
+			}
+		})
+	}
+}
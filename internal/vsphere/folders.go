@@ -14,7 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/atc0005/check-vmware/internal/textutils"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -241,7 +240,7 @@ func ValidateFolders(ctx context.Context, c *vim25.Client, includeFolders []stri
 	switch {
 	case len(includeFolders) > 0:
 		for _, iFolderID := range includeFolders {
-			if !textutils.InList(iFolderID, folderIDsFound, true) {
+			if !patternMatchesAny(iFolderID, folderIDsFound) {
 				notFound = append(
 					notFound,
 					fmt.Sprintf(
@@ -265,7 +264,7 @@ func ValidateFolders(ctx context.Context, c *vim25.Client, includeFolders []stri
 
 	case len(excludeFolders) > 0:
 		for _, eFolderID := range excludeFolders {
-			if !textutils.InList(eFolderID, folderIDsFound, true) {
+			if !patternMatchesAny(eFolderID, folderIDsFound) {
 				notFound = append(
 					notFound,
 					fmt.Sprintf(
@@ -357,14 +356,14 @@ func GetEligibleFolders(ctx context.Context, c *vim25.Client, includeFolders []s
 		// If specified, only include folders that have been intentionally
 		// included (aka, "whitelisted").
 		case len(includeFolders) > 0:
-			if textutils.InList(folderID, includeFolders, true) {
+			if inPatternList(folderID, includeFolders) {
 				eligibleFolders = append(eligibleFolders, folder)
 			}
 
 		// If specified, don't include folders that have been intentionally
 		// excluded (aka, "blacklisted").
 		case len(excludeFolders) > 0:
-			if !textutils.InList(folderID, excludeFolders, true) {
+			if !inPatternList(folderID, excludeFolders) {
 				eligibleFolders = append(eligibleFolders, folder)
 			}
 
@@ -448,7 +447,7 @@ func GetFoldersByIDs(ctx context.Context, c *vim25.Client, folderIDs []string, p
 	)
 
 	for folderID, folder := range foldersFound {
-		if textutils.InList(folderID, folderIDs, true) {
+		if inPatternList(folderID, folderIDs) {
 			folders = append(folders, folder)
 		}
 	}
@@ -0,0 +1,467 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineFTLatencyThresholdCrossed indicates that specified Fault
+// Tolerance secondary latency or logging bandwidth thresholds have been
+// exceeded (or a non-green latency status reported) for one or more
+// VirtualMachines.
+var ErrVirtualMachineFTLatencyThresholdCrossed = errors.New("fault tolerance secondary latency or logging bandwidth exceeds specified threshold")
+
+// ftPrimaryRole is the Fault Tolerance role index used by the primary
+// VirtualMachine in a Fault Tolerance group.
+const ftPrimaryRole int32 = 1
+
+// VMFTLatencyThresholds represents the user-specified Fault Tolerance
+// secondary latency (milliseconds) and logging bandwidth (Mbps) thresholds.
+type VMFTLatencyThresholds struct {
+	LatencyWarning    int
+	LatencyCritical   int
+	BandwidthWarning  int
+	BandwidthCritical int
+}
+
+// VMFTLatencyMetrics represents the Fault Tolerance secondary latency and
+// logging (checkpoint) bandwidth values for a Fault Tolerance primary
+// VirtualMachine as reported by the quick stats collection.
+type VMFTLatencyMetrics struct {
+	VM mo.VirtualMachine
+
+	// LatencyMS is the amount of time in milliseconds that the secondary
+	// VirtualMachine is behind the primary VirtualMachine.
+	LatencyMS int64
+
+	// LatencyStatus is the latency status (green, yellow, red, gray) as
+	// determined by vSphere from the secondary latency value.
+	LatencyStatus types.ManagedEntityStatus
+
+	// BandwidthKBps is the network bandwidth in kilobytes per second used
+	// for logging between the primary and secondary VirtualMachines.
+	BandwidthKBps int64
+
+	Thresholds VMFTLatencyThresholds
+}
+
+// VMFTLatencySet is a collection of VMFTLatencyMetrics values.
+type VMFTLatencySet []VMFTLatencyMetrics
+
+// IsFTPrimary indicates whether the given VirtualMachine is the primary
+// VirtualMachine of a Fault Tolerance group.
+func IsFTPrimary(vm mo.VirtualMachine) bool {
+	if vm.Config == nil || vm.Config.FtInfo == nil {
+		return false
+	}
+
+	if vm.Runtime.FaultToleranceState == "" ||
+		vm.Runtime.FaultToleranceState == types.VirtualMachineFaultToleranceStateNotConfigured {
+		return false
+	}
+
+	return vm.Config.FtInfo.GetFaultToleranceConfigInfo().Role == ftPrimaryRole
+}
+
+// FilterFTPrimaryVMs returns the Fault Tolerance primary VirtualMachines
+// from the given collection along with the number of VirtualMachines
+// omitted as they are not Fault Tolerance primary VirtualMachines.
+func FilterFTPrimaryVMs(vms []mo.VirtualMachine) ([]mo.VirtualMachine, int) {
+	primaries := make([]mo.VirtualMachine, 0, len(vms))
+	for _, vm := range vms {
+		if IsFTPrimary(vm) {
+			primaries = append(primaries, vm)
+		}
+	}
+
+	return primaries, len(vms) - len(primaries)
+}
+
+// NewVMFTLatencySet evaluates the Fault Tolerance secondary latency and
+// logging bandwidth values for the given Fault Tolerance primary
+// VirtualMachines against the specified thresholds.
+func NewVMFTLatencySet(vms []mo.VirtualMachine, thresholds VMFTLatencyThresholds) VMFTLatencySet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMFTLatencySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMFTLatencySet, 0, len(vms))
+	for _, vm := range vms {
+		quickStats := vm.Summary.QuickStats
+
+		set = append(set, VMFTLatencyMetrics{
+			VM:            vm,
+			LatencyMS:     int64(quickStats.FtSecondaryLatency),
+			LatencyStatus: quickStats.FtLatencyStatus,
+			BandwidthKBps: int64(quickStats.FtLogBandwidth),
+			Thresholds:    thresholds,
+		})
+	}
+
+	return set
+
+}
+
+// IsRunning indicates whether the Fault Tolerance primary and secondary
+// VirtualMachines are running and protected. Latency and bandwidth values
+// are only meaningful for running Fault Tolerance groups.
+func (m VMFTLatencyMetrics) IsRunning() bool {
+	return m.VM.Runtime.FaultToleranceState == types.VirtualMachineFaultToleranceStateRunning
+}
+
+// BandwidthMbps returns the Fault Tolerance logging bandwidth in megabits
+// per second.
+func (m VMFTLatencyMetrics) BandwidthMbps() float64 {
+	return float64(m.BandwidthKBps) * 8 / 1000
+}
+
+// IsCriticalState indicates whether the secondary latency or logging
+// bandwidth values have crossed the CRITICAL level threshold or if vSphere
+// reports a red latency status.
+func (m VMFTLatencyMetrics) IsCriticalState() bool {
+	if !m.IsRunning() {
+		return false
+	}
+
+	return m.LatencyStatus == types.ManagedEntityStatusRed ||
+		m.LatencyMS > int64(m.Thresholds.LatencyCritical) ||
+		m.BandwidthMbps() > float64(m.Thresholds.BandwidthCritical)
+}
+
+// IsWarningState indicates whether the secondary latency or logging
+// bandwidth values have crossed the WARNING level threshold or if vSphere
+// reports a yellow latency status.
+func (m VMFTLatencyMetrics) IsWarningState() bool {
+	if !m.IsRunning() || m.IsCriticalState() {
+		return false
+	}
+
+	return m.LatencyStatus == types.ManagedEntityStatusYellow ||
+		m.LatencyMS > int64(m.Thresholds.LatencyWarning) ||
+		m.BandwidthMbps() > float64(m.Thresholds.BandwidthWarning)
+}
+
+// Offending returns the VirtualMachines whose secondary latency or logging
+// bandwidth values have crossed the WARNING or CRITICAL level thresholds,
+// sorted by secondary latency in descending order.
+func (set VMFTLatencySet) Offending() VMFTLatencySet {
+	offending := make(VMFTLatencySet, 0, len(set))
+	for _, m := range set {
+		if m.IsCriticalState() || m.IsWarningState() {
+			offending = append(offending, m)
+		}
+	}
+
+	sort.Slice(offending, func(i, j int) bool {
+		if offending[i].LatencyMS != offending[j].LatencyMS {
+			return offending[i].LatencyMS > offending[j].LatencyMS
+		}
+
+		return offending[i].BandwidthKBps > offending[j].BandwidthKBps
+	})
+
+	return offending
+}
+
+// NotRunning returns the VirtualMachines whose Fault Tolerance state is not
+// running. These VirtualMachines are not evaluated against thresholds.
+func (set VMFTLatencySet) NotRunning() VMFTLatencySet {
+	notRunning := make(VMFTLatencySet, 0, len(set))
+	for _, m := range set {
+		if !m.IsRunning() {
+			notRunning = append(notRunning, m)
+		}
+	}
+
+	return notRunning
+}
+
+// NumCritical returns the number of VirtualMachines in a CRITICAL state.
+func (set VMFTLatencySet) NumCritical() int {
+	var num int
+	for _, m := range set {
+		if m.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VirtualMachines in a WARNING state.
+func (set VMFTLatencySet) NumWarning() int {
+	var num int
+	for _, m := range set {
+		if m.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated VirtualMachine is in a
+// CRITICAL state.
+func (set VMFTLatencySet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated VirtualMachine is in a
+// WARNING state.
+func (set VMFTLatencySet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// MaxLatencyMS returns the highest secondary latency in milliseconds across
+// all evaluated VirtualMachines.
+func (set VMFTLatencySet) MaxLatencyMS() int64 {
+	var highest int64
+	for _, m := range set {
+		if m.LatencyMS > highest {
+			highest = m.LatencyMS
+		}
+	}
+
+	return highest
+}
+
+// MaxBandwidthMbps returns the highest logging bandwidth in Mbps across all
+// evaluated VirtualMachines.
+func (set VMFTLatencySet) MaxBandwidthMbps() float64 {
+	var highest float64
+	for _, m := range set {
+		if m.BandwidthMbps() > highest {
+			highest = m.BandwidthMbps()
+		}
+	}
+
+	return highest
+}
+
+// VMFTLatencyPerfData generates performance data metrics from the given
+// collection of evaluated VirtualMachines. Summary metrics are always
+// emitted, per-VM metrics are emitted for offending VirtualMachines only.
+func VMFTLatencyPerfData(set VMFTLatencySet, numNotFTPrimary int) []nagios.PerformanceData {
+
+	var thresholds VMFTLatencyThresholds
+	if len(set) > 0 {
+		thresholds = set[0].Thresholds
+	}
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "vms_ft_primary",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "vms_not_ft_primary",
+			Value: fmt.Sprintf("%d", numNotFTPrimary),
+			Min:   "0",
+		},
+		{
+			Label: "vms_ft_not_running",
+			Value: fmt.Sprintf("%d", len(set.NotRunning())),
+			Min:   "0",
+		},
+		{
+			Label: "vms_ft_latency_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_ft_latency_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "ft_secondary_latency_max",
+			Value:             fmt.Sprintf("%d", set.MaxLatencyMS()),
+			UnitOfMeasurement: "ms",
+			Warn:              fmt.Sprintf("%d", thresholds.LatencyWarning),
+			Crit:              fmt.Sprintf("%d", thresholds.LatencyCritical),
+			Min:               "0",
+		},
+		{
+			Label: "ft_log_bandwidth_max",
+			Value: fmt.Sprintf("%.2f", set.MaxBandwidthMbps()),
+			Warn:  fmt.Sprintf("%d", thresholds.BandwidthWarning),
+			Crit:  fmt.Sprintf("%d", thresholds.BandwidthCritical),
+			Min:   "0",
+		},
+	}
+
+	for _, m := range set.Offending() {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "ft_secondary_latency"),
+				Value:             fmt.Sprintf("%d", m.LatencyMS),
+				UnitOfMeasurement: "ms",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.LatencyWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.LatencyCritical),
+				Min:               "0",
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(m.VM.Name, "ft_log_bandwidth"),
+				Value: fmt.Sprintf("%.2f", m.BandwidthMbps()),
+				Warn:  fmt.Sprintf("%d", m.Thresholds.BandwidthWarning),
+				Crit:  fmt.Sprintf("%d", m.Thresholds.BandwidthCritical),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// VMFTLatencyOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMFTLatencyOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMFTLatencySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMFTLatencyOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d FT protected VMs with degraded secondary latency or logging bandwidth detected (evaluated %d FT protected VMs, %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set.Offending()),
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No FT protected VMs with degraded secondary latency or logging bandwidth detected (evaluated %d FT protected VMs, %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMFTLatencyReport generates a summary of Fault Tolerance protected VMs
+// with secondary latency or logging bandwidth values exceeding thresholds
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VMFTLatencyReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMFTLatencySet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMFTLatencyReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"FT protected VMs with degraded secondary latency or logging bandwidth:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	offending := set.Offending()
+
+	switch {
+	case len(offending) > 0:
+
+		for _, m := range offending {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (latency: %d ms, latency status: %s, logging bandwidth: %.2f Mbps)%s",
+				m.VM.Name,
+				m.LatencyMS,
+				m.LatencyStatus,
+				m.BandwidthMbps(),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+	default:
+
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+	}
+
+	if notRunning := set.NotRunning(); len(notRunning) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sFT protected VMs not evaluated (FT state not running):%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, m := range notRunning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (FT state: %s)%s",
+				m.VM.Name,
+				m.VM.Runtime.FaultToleranceState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,231 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var ftThresholds = VMFTLatencyThresholds{
+	LatencyWarning:    2000,
+	LatencyCritical:   5000,
+	BandwidthWarning:  800,
+	BandwidthCritical: 950,
+}
+
+func ftVM(
+	name string,
+	role int32,
+	state types.VirtualMachineFaultToleranceState,
+	latencyMS int32,
+	status types.ManagedEntityStatus,
+	bandwidthKBps int32,
+) mo.VirtualMachine {
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config:        &types.VirtualMachineConfigInfo{},
+	}
+	vm.Runtime.FaultToleranceState = state
+	vm.Summary.QuickStats.FtSecondaryLatency = latencyMS
+	vm.Summary.QuickStats.FtLatencyStatus = status
+	vm.Summary.QuickStats.FtLogBandwidth = bandwidthKBps
+
+	if role != 0 {
+		vm.Config.FtInfo = &types.FaultToleranceConfigInfo{Role: role}
+	}
+
+	return vm
+}
+
+func TestIsFTPrimary(t *testing.T) {
+	running := types.VirtualMachineFaultToleranceStateRunning
+
+	noConfig := ftVM("vm1", 1, running, 0, "", 0)
+	noConfig.Config = nil
+
+	tests := map[string]struct {
+		vm   mo.VirtualMachine
+		want bool
+	}{
+		"primary running": {vm: ftVM("vm1", 1, running, 0, "", 0), want: true},
+		"primary needs secondary": {
+			vm:   ftVM("vm1", 1, types.VirtualMachineFaultToleranceStateNeedSecondary, 0, "", 0),
+			want: true,
+		},
+		"secondary":         {vm: ftVM("vm1", 2, running, 0, "", 0)},
+		"no FT info":        {vm: ftVM("vm1", 0, running, 0, "", 0)},
+		"empty state":       {vm: ftVM("vm1", 1, "", 0, "", 0)},
+		"not configured":    {vm: ftVM("vm1", 1, types.VirtualMachineFaultToleranceStateNotConfigured, 0, "", 0)},
+		"missing VM config": {vm: noConfig},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := IsFTPrimary(tt.vm); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterFTPrimaryVMs(t *testing.T) {
+	running := types.VirtualMachineFaultToleranceStateRunning
+
+	primaries, omitted := FilterFTPrimaryVMs([]mo.VirtualMachine{
+		ftVM("vm1", 1, running, 0, "", 0),
+		ftVM("vm1-secondary", 2, running, 0, "", 0),
+		ftVM("vm2", 0, "", 0, "", 0),
+		ftVM("vm3", 1, types.VirtualMachineFaultToleranceStateNotConfigured, 0, "", 0),
+	})
+
+	if len(primaries) != 1 || primaries[0].Name != "vm1" {
+		t.Errorf("want primary VMs [vm1]; got %d VMs", len(primaries))
+	}
+	if omitted != 3 {
+		t.Errorf("want 3 omitted VMs; got %d", omitted)
+	}
+}
+
+func TestVMFTLatencyMetricsBandwidthMbps(t *testing.T) {
+	tests := map[string]struct {
+		bandwidthKBps int64
+		want          float64
+	}{
+		"zero":         {bandwidthKBps: 0, want: 0},
+		"110,000 KBps": {bandwidthKBps: 110000, want: 880},
+		"125,000 KBps": {bandwidthKBps: 125000, want: 1000},
+		"1 KBps":       {bandwidthKBps: 1, want: 0.008},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := VMFTLatencyMetrics{BandwidthKBps: tt.bandwidthKBps}
+			if got := m.BandwidthMbps(); math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("want %.3f; got %.3f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMFTLatencySetState(t *testing.T) {
+	const (
+		running    = types.VirtualMachineFaultToleranceStateRunning
+		needSecond = types.VirtualMachineFaultToleranceStateNeedSecondary
+		green      = types.ManagedEntityStatusGreen
+		yellow     = types.ManagedEntityStatusYellow
+		red        = types.ManagedEntityStatusRed
+	)
+
+	tests := map[string]struct {
+		vm             mo.VirtualMachine
+		wantCritical   bool
+		wantWarning    bool
+		wantNotRunning int
+	}{
+		"below thresholds":                   {vm: ftVM("vm1", 1, running, 100, green, 10000)},
+		"latency equal to warning threshold": {vm: ftVM("vm1", 1, running, 2000, green, 10000)},
+		"latency above warning threshold":    {vm: ftVM("vm1", 1, running, 3000, green, 10000), wantWarning: true},
+		"latency above critical threshold":   {vm: ftVM("vm1", 1, running, 6000, green, 10000), wantCritical: true},
+		"bandwidth above warning threshold":  {vm: ftVM("vm1", 1, running, 100, green, 110000), wantWarning: true},
+		"bandwidth above critical threshold": {vm: ftVM("vm1", 1, running, 100, green, 125000), wantCritical: true},
+		"yellow latency status":              {vm: ftVM("vm1", 1, running, 100, yellow, 10000), wantWarning: true},
+		"red latency status":                 {vm: ftVM("vm1", 1, running, 100, red, 10000), wantCritical: true},
+		"not running is not evaluated": {
+			vm:             ftVM("vm1", 1, needSecond, 6000, red, 125000),
+			wantNotRunning: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMFTLatencySet([]mo.VirtualMachine{tt.vm}, ftThresholds)
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+			if got := len(set.NotRunning()); got != tt.wantNotRunning {
+				t.Errorf("want %d VMs not running; got %d", tt.wantNotRunning, got)
+			}
+		})
+	}
+}
+
+func TestVMFTLatencySetOffending(t *testing.T) {
+	running := types.VirtualMachineFaultToleranceStateRunning
+	green := types.ManagedEntityStatusGreen
+
+	set := NewVMFTLatencySet(
+		[]mo.VirtualMachine{
+			ftVM("vm1", 1, running, 3000, green, 10000),
+			ftVM("vm2", 1, running, 100, green, 10000),
+			ftVM("vm3", 1, running, 6000, green, 10000),
+			ftVM("vm4", 1, running, 3000, green, 120000),
+		},
+		ftThresholds,
+	)
+
+	var got []string
+	for _, m := range set.Offending() {
+		got = append(got, m.VM.Name)
+	}
+
+	if d := cmp.Diff([]string{"vm3", "vm4", "vm1"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := set.NumCritical(); got != 2 {
+		t.Errorf("want 2 critical; got %d", got)
+	}
+	if got := set.NumWarning(); got != 1 {
+		t.Errorf("want 1 warning; got %d", got)
+	}
+	if got := set.MaxLatencyMS(); got != 6000 {
+		t.Errorf("want max latency 6000; got %d", got)
+	}
+	if got := set.MaxBandwidthMbps(); math.Abs(got-960) > 0.0001 {
+		t.Errorf("want max bandwidth 960; got %.2f", got)
+	}
+}
+
+func TestVMFTLatencyPerfData(t *testing.T) {
+	running := types.VirtualMachineFaultToleranceStateRunning
+	green := types.ManagedEntityStatusGreen
+
+	set := NewVMFTLatencySet(
+		[]mo.VirtualMachine{
+			ftVM("vm1", 1, running, 3000, green, 10000),
+			ftVM("vm2", 1, running, 100, green, 10000),
+		},
+		ftThresholds,
+	)
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_ft_primary", Value: "2", Min: "0"},
+		{Label: "vms_not_ft_primary", Value: "3", Min: "0"},
+		{Label: "vms_ft_not_running", Value: "0", Min: "0"},
+		{Label: "vms_ft_latency_critical", Value: "0", Min: "0"},
+		{Label: "vms_ft_latency_warning", Value: "1", Min: "0"},
+		{Label: "ft_secondary_latency_max", Value: "3000", UnitOfMeasurement: "ms", Warn: "2000", Crit: "5000", Min: "0"},
+		{Label: "ft_log_bandwidth_max", Value: "80.00", Warn: "800", Crit: "950", Min: "0"},
+		{Label: "vm1_ft_secondary_latency", Value: "3000", UnitOfMeasurement: "ms", Warn: "2000", Crit: "5000", Min: "0"},
+		{Label: "vm1_ft_log_bandwidth", Value: "80.00", Warn: "800", Crit: "950", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMFTLatencyPerfData(set, 3)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
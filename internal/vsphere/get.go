@@ -77,6 +77,7 @@ func getHostSystemPropsSubset() []string {
 		"customValue",
 		"availableField",
 		"parent", // used to obtain ComputeResource
+		"alarmActionsEnabled",
 	}
 }
 func getDatastorePropsSubset() []string {
@@ -99,6 +100,7 @@ func getDatacenterPropsSubset() []string {
 		"name",
 		"overallStatus",
 		"triggeredAlarmState",
+		"alarmActionsEnabled",
 	}
 }
 func getAlarmPropsSubset() []string {
@@ -108,6 +110,20 @@ func getAlarmPropsSubset() []string {
 		"info",
 	}
 }
+func getClusterComputeResourcePropsSubset() []string {
+	// https://code.vmware.com/apis/1067/vsphere
+	// https://vdc-download.vmware.com/vmwb-repository/dcr-public/a5f4000f-1ea8-48a9-9221-586adff3c557/7ff50256-2cf2-45ea-aacd-87d231ab1ac7/vim.ClusterComputeResource.html
+	return []string{
+		"name",
+		"summary",         // current failover level, usage summary
+		"configurationEx", // HA/DRS configuration
+		"recommendation",  // DRS recommendations
+		"host",            // hosts in the cluster
+		"overallStatus",
+		"parent",
+		"alarmActionsEnabled",
+	}
+}
 func getFolderPropsSubset() []string {
 	// https://code.vmware.com/apis/1067/vsphere
 	// https://vdc-download.vmware.com/vmwb-repository/dcr-public/a5f4000f-1ea8-48a9-9221-586adff3c557/7ff50256-2cf2-45ea-aacd-87d231ab1ac7/vim.Folder.html
@@ -126,7 +142,7 @@ func getObjects(
 	objRef types.ManagedObjectReference,
 	propsSubset bool,
 	recursive bool,
-) error {
+) (err error) {
 
 	funcTimeStart := time.Now()
 
@@ -148,6 +164,21 @@ func getObjects(
 		)
 	}(&objCount, &objKind)
 
+	defer func() {
+		err = recordPhase(
+			ctx,
+			fmt.Sprintf("retrieval of %s objects", objKind),
+			funcTimeStart,
+			err,
+		)
+	}()
+
+	defer func() {
+		if objKind != "" {
+			recordPropertyRetrieval(objKind, time.Since(funcTimeStart))
+		}
+	}()
+
 	// Create a view of caller-specified objects
 	m := view.NewManager(c)
 
@@ -158,6 +189,7 @@ func getObjects(
 	case MgObjRefTypeFolder:
 	case MgObjRefTypeDatacenter:
 	case MgObjRefTypeComputeResource:
+	case MgObjRefTypeClusterComputeResource:
 	case MgObjRefTypeResourcePool:
 	case MgObjRefTypeHostSystem:
 
@@ -257,6 +289,16 @@ func getObjects(
 			props = getVirtualAppPropsSubset()
 		}
 
+	case *[]mo.ClusterComputeResource:
+		defer func() {
+			objCount = len(*u)
+		}()
+		objKind = MgObjRefTypeClusterComputeResource
+
+		if propsSubset {
+			props = getClusterComputeResourcePropsSubset()
+		}
+
 	case *[]mo.Folder:
 		defer func() {
 			objCount = len(*u)
@@ -307,7 +349,7 @@ func getObjects(
 
 }
 
-func getObjectByName(ctx context.Context, c *vim25.Client, dst interface{}, objName string, datacenter string, propsSubset bool) error {
+func getObjectByName(ctx context.Context, c *vim25.Client, dst interface{}, objName string, datacenter string, propsSubset bool) (err error) {
 
 	funcTimeStart := time.Now()
 
@@ -321,6 +363,21 @@ func getObjectByName(ctx context.Context, c *vim25.Client, dst interface{}, objN
 		)
 	}(&objKind)
 
+	defer func() {
+		err = recordPhase(
+			ctx,
+			fmt.Sprintf("retrieval of %s object %q", objKind, objName),
+			funcTimeStart,
+			err,
+		)
+	}()
+
+	defer func() {
+		if objKind != "" {
+			recordPropertyRetrieval(objKind, time.Since(funcTimeStart))
+		}
+	}()
+
 	finder := find.NewFinder(c, true)
 
 	switch {
@@ -460,6 +517,29 @@ func getObjectByName(ctx context.Context, c *vim25.Client, dst interface{}, objN
 			return err
 		}
 
+	case *mo.ClusterComputeResource:
+
+		objKind = MgObjRefTypeClusterComputeResource
+		if propsSubset {
+			props = getClusterComputeResourcePropsSubset()
+		}
+
+		obj, err := finder.ClusterComputeResource(ctx, objName)
+		if err != nil {
+			return err
+		}
+
+		err = pc.RetrieveOne(
+			ctx,
+			obj.Reference(),
+			props,
+			u,
+		)
+
+		if err != nil {
+			return err
+		}
+
 	default:
 
 		objKind = "unknown"
@@ -528,6 +528,11 @@ func VirtualHardwareOneLineCheckSummary(
 	minHardwareVersion int,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults":   vmsFilterResults,
+		"minHardwareVersion": minHardwareVersion,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -0,0 +1,662 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostAccelerationFeaturesDisabled indicates that one or more required
+// host acceleration features (CBRC or memory tiering) are disabled on one or
+// more ESXi hosts.
+var ErrHostAccelerationFeaturesDisabled = errors.New("required host acceleration features disabled")
+
+// HostAdvancedOptionCBRCEnable is the ESXi host advanced setting used to
+// enable Content-Based Read Cache (CBRC).
+const HostAdvancedOptionCBRCEnable string = "CBRC.Enable"
+
+// HostAccelerationRequirements represents the user-specified host
+// acceleration features required to be enabled.
+type HostAccelerationRequirements struct {
+	RequireCBRC          bool
+	RequireMemoryTiering bool
+}
+
+// HostAccelerationStatus tracks the Content-Based Read Cache (CBRC) and
+// memory tiering configuration for a specific HostSystem.
+type HostAccelerationStatus struct {
+	// Host is the HostSystem that the configuration was retrieved from.
+	Host mo.HostSystem
+
+	// CBRCSupported indicates whether the CBRC advanced setting is
+	// available on the HostSystem.
+	CBRCSupported bool
+
+	// CBRCEnabled indicates whether CBRC is enabled on the HostSystem.
+	CBRCEnabled bool
+
+	// Requirements are the user-specified required features.
+	Requirements HostAccelerationRequirements
+
+	// Unavailable indicates whether configuration details could not be
+	// retrieved for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// HostAccelerationStatusSet is a collection of HostAccelerationStatus
+// values.
+type HostAccelerationStatusSet []HostAccelerationStatus
+
+// GetHostCBRCStatus uses the advanced option manager for the specified
+// HostSystem to determine whether Content-Based Read Cache (CBRC) is
+// supported and enabled. CBRC is considered unsupported if the advanced
+// setting is not available on the HostSystem.
+func GetHostCBRCStatus(ctx context.Context, c *vim25.Client, hs mo.HostSystem) (bool, bool, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostCBRCStatus func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	host := object.NewHostSystem(c, hs.Reference())
+
+	om, err := host.ConfigManager().OptionManager(ctx)
+	if err != nil {
+		return false, false, fmt.Errorf(
+			"failed to retrieve option manager for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	options, err := om.Query(ctx, HostAdvancedOptionCBRCEnable)
+	switch {
+	case fault.Is(err, &types.InvalidName{}):
+		logger.Printf(
+			"advanced setting %s not available on host %s",
+			HostAdvancedOptionCBRCEnable,
+			hs.Name,
+		)
+
+		return false, false, nil
+
+	case err != nil:
+		return false, false, fmt.Errorf(
+			"failed to retrieve advanced setting %s for host %s: %w",
+			HostAdvancedOptionCBRCEnable,
+			hs.Name,
+			err,
+		)
+	}
+
+	for _, option := range options {
+		ov := option.GetOptionValue()
+		if !strings.EqualFold(ov.Key, HostAdvancedOptionCBRCEnable) {
+			continue
+		}
+
+		switch v := ov.Value.(type) {
+		case bool:
+			return true, v, nil
+		case int32:
+			return true, v != 0, nil
+		case int64:
+			return true, v != 0, nil
+		default:
+			return true, strings.EqualFold(fmt.Sprint(v), "true") ||
+				fmt.Sprint(v) == "1", nil
+		}
+	}
+
+	return false, false, nil
+
+}
+
+// GetHostAccelerationStatusSet retrieves the CBRC and memory tiering
+// configuration for each given HostSystem. HostSystems which are not
+// connected are flagged as unavailable and are not evaluated.
+func GetHostAccelerationStatusSet(
+	ctx context.Context,
+	c *vim25.Client,
+	hss []mo.HostSystem,
+	requirements HostAccelerationRequirements,
+) (HostAccelerationStatusSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostAccelerationStatusSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostAccelerationStatusSet, 0, len(hss))
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping acceleration feature evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostAccelerationStatus{
+				Host:         hs,
+				Requirements: requirements,
+				Unavailable:  true,
+			})
+
+			continue
+		}
+
+		cbrcSupported, cbrcEnabled, err := GetHostCBRCStatus(ctx, c, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, HostAccelerationStatus{
+			Host:          hs,
+			CBRCSupported: cbrcSupported,
+			CBRCEnabled:   cbrcEnabled,
+			Requirements:  requirements,
+		})
+	}
+
+	return set, nil
+
+}
+
+// MemoryTieringType returns the type of memory tiering configured on the
+// HostSystem. An empty string is returned if the HostSystem does not
+// support memory tiering.
+func (has HostAccelerationStatus) MemoryTieringType() string {
+	if has.Host.Hardware == nil {
+		return ""
+	}
+
+	return has.Host.Hardware.MemoryTieringType
+}
+
+// MemoryTieringSupported indicates whether the HostSystem reports support
+// for memory tiering.
+func (has HostAccelerationStatus) MemoryTieringSupported() bool {
+	return has.MemoryTieringType() != ""
+}
+
+// MemoryTieringEnabled indicates whether memory tiering (e.g., NVMe
+// tiering) is enabled on the HostSystem.
+func (has HostAccelerationStatus) MemoryTieringEnabled() bool {
+	tieringType := has.MemoryTieringType()
+
+	return tieringType != "" &&
+		tieringType != string(types.HostMemoryTieringTypeNoTiering)
+}
+
+// NVMeTierBytes returns the size in bytes of any NVMe memory tiers
+// configured on the HostSystem.
+func (has HostAccelerationStatus) NVMeTierBytes() int64 {
+	if has.Host.Hardware == nil {
+		return 0
+	}
+
+	var size int64
+	for _, tier := range has.Host.Hardware.MemoryTierInfo {
+		if tier.Type == string(types.HostMemoryTierTypeNVMe) {
+			size += tier.Size
+		}
+	}
+
+	return size
+}
+
+// CBRCDisabled indicates whether CBRC is required, supported by the
+// HostSystem and disabled.
+func (has HostAccelerationStatus) CBRCDisabled() bool {
+	return !has.Unavailable &&
+		has.Requirements.RequireCBRC &&
+		has.CBRCSupported &&
+		!has.CBRCEnabled
+}
+
+// MemoryTieringDisabled indicates whether memory tiering is required,
+// supported by the HostSystem and disabled.
+func (has HostAccelerationStatus) MemoryTieringDisabled() bool {
+	return !has.Unavailable &&
+		has.Requirements.RequireMemoryTiering &&
+		has.MemoryTieringSupported() &&
+		!has.MemoryTieringEnabled()
+}
+
+// HasWarningState indicates whether any required acceleration feature is
+// disabled on the HostSystem.
+func (has HostAccelerationStatus) HasWarningState() bool {
+	return has.CBRCDisabled() || has.MemoryTieringDisabled()
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has a required
+// acceleration feature disabled.
+func (set HostAccelerationStatusSet) HasWarningState() bool {
+	for _, has := range set {
+		if has.HasWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose configuration
+// was evaluated.
+func (set HostAccelerationStatusSet) NumHostsEvaluated() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose configuration
+// could not be evaluated due to their connection state.
+func (set HostAccelerationStatusSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumHostsWithDisabledFeatures returns the number of evaluated HostSystems
+// with a required acceleration feature disabled.
+func (set HostAccelerationStatusSet) NumHostsWithDisabledFeatures() int {
+	var num int
+	for _, has := range set {
+		if has.HasWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCBRCEnabled returns the number of evaluated HostSystems with CBRC
+// enabled.
+func (set HostAccelerationStatusSet) NumCBRCEnabled() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && has.CBRCSupported && has.CBRCEnabled {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCBRCDisabled returns the number of evaluated HostSystems with CBRC
+// supported but disabled.
+func (set HostAccelerationStatusSet) NumCBRCDisabled() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && has.CBRCSupported && !has.CBRCEnabled {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCBRCUnsupported returns the number of evaluated HostSystems which do
+// not support CBRC.
+func (set HostAccelerationStatusSet) NumCBRCUnsupported() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && !has.CBRCSupported {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumMemoryTieringEnabled returns the number of evaluated HostSystems with
+// memory tiering enabled.
+func (set HostAccelerationStatusSet) NumMemoryTieringEnabled() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && has.MemoryTieringEnabled() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumMemoryTieringDisabled returns the number of evaluated HostSystems with
+// memory tiering supported but disabled.
+func (set HostAccelerationStatusSet) NumMemoryTieringDisabled() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && has.MemoryTieringSupported() && !has.MemoryTieringEnabled() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumMemoryTieringUnsupported returns the number of evaluated HostSystems
+// which do not support memory tiering.
+func (set HostAccelerationStatusSet) NumMemoryTieringUnsupported() int {
+	var num int
+	for _, has := range set {
+		if !has.Unavailable && !has.MemoryTieringSupported() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HostAccelerationPerfData generates performance data metrics from the
+// given collection of evaluated HostSystem acceleration features.
+func HostAccelerationPerfData(set HostAccelerationStatusSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_with_disabled_features",
+			Value: fmt.Sprintf("%d", set.NumHostsWithDisabledFeatures()),
+			Min:   "0",
+		},
+		{
+			Label: "cbrc_enabled",
+			Value: fmt.Sprintf("%d", set.NumCBRCEnabled()),
+			Min:   "0",
+		},
+		{
+			Label: "cbrc_disabled",
+			Value: fmt.Sprintf("%d", set.NumCBRCDisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "cbrc_unsupported",
+			Value: fmt.Sprintf("%d", set.NumCBRCUnsupported()),
+			Min:   "0",
+		},
+		{
+			Label: "memory_tiering_enabled",
+			Value: fmt.Sprintf("%d", set.NumMemoryTieringEnabled()),
+			Min:   "0",
+		},
+		{
+			Label: "memory_tiering_disabled",
+			Value: fmt.Sprintf("%d", set.NumMemoryTieringDisabled()),
+			Min:   "0",
+		},
+		{
+			Label: "memory_tiering_unsupported",
+			Value: fmt.Sprintf("%d", set.NumMemoryTieringUnsupported()),
+			Min:   "0",
+		},
+	}
+}
+
+// HostAccelerationOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostAccelerationOneLineCheckSummary(
+	stateLabel string,
+	set HostAccelerationStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostAccelerationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with required acceleration features disabled (evaluated %d hosts)",
+			stateLabel,
+			set.NumHostsWithDisabledFeatures(),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts with required acceleration features disabled (evaluated %d hosts)",
+			stateLabel,
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// hostAccelerationFeatureLabel returns a human readable status for a host
+// acceleration feature.
+func hostAccelerationFeatureLabel(supported bool, enabled bool, required bool) string {
+	var label string
+	switch {
+	case !supported:
+		label = "unsupported"
+	case enabled:
+		label = "enabled"
+	default:
+		label = "disabled"
+	}
+
+	if required {
+		label += " (required)"
+	}
+
+	return label
+}
+
+// HostAccelerationReport generates a summary of CBRC and memory tiering
+// configuration for evaluated HostSystems along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func HostAccelerationReport(
+	c *vim25.Client,
+	set HostAccelerationStatusSet,
+	requirements HostAccelerationRequirements,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostAccelerationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with required acceleration features disabled:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if !set.HasWarningState() {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, has := range set {
+		if !has.HasWarningState() {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			has.Host.Name,
+			nagios.CheckOutputEOL,
+		)
+
+		if has.CBRCDisabled() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** CBRC disabled (%s)%s",
+				HostAdvancedOptionCBRCEnable,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if has.MemoryTieringDisabled() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Memory tiering disabled (type: %s)%s",
+				has.MemoryTieringType(),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sAll evaluated hosts:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, has := range set {
+		if has.Unavailable {
+			continue
+		}
+
+		memoryTiering := hostAccelerationFeatureLabel(
+			has.MemoryTieringSupported(),
+			has.MemoryTieringEnabled(),
+			requirements.RequireMemoryTiering,
+		)
+		if nvmeBytes := has.NVMeTierBytes(); nvmeBytes > 0 {
+			memoryTiering += fmt.Sprintf(", NVMe tier: %s", units.ByteSize(nvmeBytes))
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [CBRC: %s, Memory tiering: %s]%s",
+			has.Host.Name,
+			hostAccelerationFeatureLabel(
+				has.CBRCSupported,
+				has.CBRCEnabled,
+				requirements.RequireCBRC,
+			),
+			memoryTiering,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if set.NumHostsUnavailable() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sUnavailable hosts:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, has := range set {
+			if !has.Unavailable {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (connection state: %s)%s",
+				has.Host.Name,
+				has.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* CBRC required: %t%s",
+		requirements.RequireCBRC,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Memory tiering required: %t%s",
+		requirements.RequireMemoryTiering,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func tieredHost(name string, tieringType types.HostMemoryTieringType, tiers ...types.HostMemoryTierInfo) mo.HostSystem {
+	return mo.HostSystem{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Hardware: &types.HostHardwareInfo{
+			MemoryTieringType: string(tieringType),
+			MemoryTierInfo:    tiers,
+		},
+	}
+}
+
+func TestHostAccelerationStatusMemoryTiering(t *testing.T) {
+	dram := types.HostMemoryTierInfo{Type: string(types.HostMemoryTierTypeDRAM), Size: 512 << 30}
+	nvme := types.HostMemoryTierInfo{Type: string(types.HostMemoryTierTypeNVMe), Size: 1 << 40}
+
+	tests := map[string]struct {
+		host          mo.HostSystem
+		wantSupported bool
+		wantEnabled   bool
+		wantNVMeBytes int64
+	}{
+		"software tiering": {
+			host:          tieredHost("esx1", types.HostMemoryTieringTypeSoftwareTiering, dram, nvme),
+			wantSupported: true,
+			wantEnabled:   true,
+			wantNVMeBytes: 1 << 40,
+		},
+		"hardware tiering with multiple NVMe tiers": {
+			host:          tieredHost("esx1", types.HostMemoryTieringTypeHardwareTiering, dram, nvme, nvme),
+			wantSupported: true,
+			wantEnabled:   true,
+			wantNVMeBytes: 2 << 40,
+		},
+		"no tiering": {
+			host:          tieredHost("esx1", types.HostMemoryTieringTypeNoTiering, dram),
+			wantSupported: true,
+		},
+		"not reported": {
+			host: tieredHost("esx1", "", dram),
+		},
+		"hardware not retrieved": {
+			host: mo.HostSystem{ManagedEntity: mo.ManagedEntity{Name: "esx1"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			has := HostAccelerationStatus{Host: tt.host}
+
+			if got := has.MemoryTieringSupported(); got != tt.wantSupported {
+				t.Errorf("want supported %t; got %t", tt.wantSupported, got)
+			}
+			if got := has.MemoryTieringEnabled(); got != tt.wantEnabled {
+				t.Errorf("want enabled %t; got %t", tt.wantEnabled, got)
+			}
+			if got := has.NVMeTierBytes(); got != tt.wantNVMeBytes {
+				t.Errorf("want %d NVMe tier bytes; got %d", tt.wantNVMeBytes, got)
+			}
+		})
+	}
+}
+
+func TestHostAccelerationStatusDisabledFeatures(t *testing.T) {
+	required := HostAccelerationRequirements{RequireCBRC: true, RequireMemoryTiering: true}
+
+	tests := map[string]struct {
+		status              HostAccelerationStatus
+		wantCBRCDisabled    bool
+		wantTieringDisabled bool
+	}{
+		"all features enabled": {
+			status: HostAccelerationStatus{
+				Host:          tieredHost("esx1", types.HostMemoryTieringTypeSoftwareTiering),
+				CBRCSupported: true,
+				CBRCEnabled:   true,
+				Requirements:  required,
+			},
+		},
+		"CBRC disabled": {
+			status: HostAccelerationStatus{
+				Host:          tieredHost("esx1", types.HostMemoryTieringTypeHardwareTiering),
+				CBRCSupported: true,
+				Requirements:  required,
+			},
+			wantCBRCDisabled: true,
+		},
+		"memory tiering disabled": {
+			status: HostAccelerationStatus{
+				Host:          tieredHost("esx1", types.HostMemoryTieringTypeNoTiering),
+				CBRCSupported: true,
+				CBRCEnabled:   true,
+				Requirements:  required,
+			},
+			wantTieringDisabled: true,
+		},
+		"disabled features not required": {
+			status: HostAccelerationStatus{
+				Host:          tieredHost("esx1", types.HostMemoryTieringTypeNoTiering),
+				CBRCSupported: true,
+			},
+		},
+		"unsupported features": {
+			status: HostAccelerationStatus{
+				Host:         tieredHost("esx1", ""),
+				Requirements: required,
+			},
+		},
+		"unavailable host": {
+			status: HostAccelerationStatus{
+				Host:          tieredHost("esx1", types.HostMemoryTieringTypeNoTiering),
+				CBRCSupported: true,
+				Requirements:  required,
+				Unavailable:   true,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.status.CBRCDisabled(); got != tt.wantCBRCDisabled {
+				t.Errorf("want CBRC disabled %t; got %t", tt.wantCBRCDisabled, got)
+			}
+			if got := tt.status.MemoryTieringDisabled(); got != tt.wantTieringDisabled {
+				t.Errorf("want memory tiering disabled %t; got %t", tt.wantTieringDisabled, got)
+			}
+
+			want := tt.wantCBRCDisabled || tt.wantTieringDisabled
+			if got := (HostAccelerationStatusSet{tt.status}).HasWarningState(); got != want {
+				t.Errorf("want WARNING state %t; got %t", want, got)
+			}
+		})
+	}
+}
+
+func TestHostAccelerationStatusSetCounts(t *testing.T) {
+	set := HostAccelerationStatusSet{
+		{Host: tieredHost("esx1", types.HostMemoryTieringTypeSoftwareTiering), CBRCSupported: true, CBRCEnabled: true},
+		{Host: tieredHost("esx2", types.HostMemoryTieringTypeNoTiering), CBRCSupported: true},
+		{Host: tieredHost("esx3", "")},
+		{Host: tieredHost("esx4", types.HostMemoryTieringTypeNoTiering), CBRCSupported: true, Unavailable: true},
+	}
+
+	tests := map[string]struct {
+		got  func() int
+		want int
+	}{
+		"evaluated":                  {got: set.NumHostsEvaluated, want: 3},
+		"unavailable":                {got: set.NumHostsUnavailable, want: 1},
+		"CBRC enabled":               {got: set.NumCBRCEnabled, want: 1},
+		"CBRC disabled":              {got: set.NumCBRCDisabled, want: 1},
+		"CBRC unsupported":           {got: set.NumCBRCUnsupported, want: 1},
+		"memory tiering enabled":     {got: set.NumMemoryTieringEnabled, want: 1},
+		"memory tiering disabled":    {got: set.NumMemoryTieringDisabled, want: 1},
+		"memory tiering unsupported": {got: set.NumMemoryTieringUnsupported, want: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.got(); got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHostAccelerationFeatureLabel(t *testing.T) {
+	tests := map[string]struct {
+		supported, enabled, required bool
+		want                         string
+	}{
+		"unsupported":          {want: "unsupported"},
+		"unsupported required": {enabled: true, required: true, want: "unsupported (required)"},
+		"enabled":              {supported: true, enabled: true, want: "enabled"},
+		"disabled required":    {supported: true, required: true, want: "disabled (required)"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := hostAccelerationFeatureLabel(tt.supported, tt.enabled, tt.required); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,491 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostNotConnected indicates that one or more evaluated ESXi hosts are
+// disconnected or not responding.
+var ErrHostNotConnected = errors.New("host disconnected or not responding")
+
+// ErrHostUnexpectedMaintenance indicates that one or more evaluated ESXi
+// hosts are unexpectedly in maintenance mode or standby mode.
+var ErrHostUnexpectedMaintenance = errors.New("host unexpectedly in maintenance or standby mode")
+
+// HostConnectionState tracks the connection, maintenance mode and standby
+// mode state of a specific HostSystem.
+type HostConnectionState struct {
+	Host mo.HostSystem
+
+	// AllowMaintenance indicates whether the host is permitted to be in
+	// maintenance mode.
+	AllowMaintenance bool
+
+	// AllowStandby indicates whether the host is permitted to be in standby
+	// mode.
+	AllowStandby bool
+}
+
+// HostConnectionStateSet is a collection of HostConnectionState values
+// evaluated as part of a single plugin execution.
+type HostConnectionStateSet struct {
+	Hosts []HostConnectionState
+
+	// NumExcluded is the number of hosts in scope which were excluded from
+	// evaluation by name.
+	NumExcluded int
+}
+
+// NewHostConnectionStateSet receives a collection of HostSystems and records
+// the connection state of each HostSystem matching the given lists of host
+// names to include or exclude. HostSystems matching the given lists of host
+// names permitted to be in maintenance mode or standby mode are not flagged
+// for those states.
+func NewHostConnectionStateSet(
+	hss []mo.HostSystem,
+	includedHosts []string,
+	excludedHosts []string,
+	maintenanceHosts []string,
+	standbyHosts []string,
+) HostConnectionStateSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewHostConnectionStateSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := HostConnectionStateSet{
+		Hosts: make([]HostConnectionState, 0, len(hss)),
+	}
+
+	for _, hs := range hss {
+		if hostSystemExcluded(hs, includedHosts, excludedHosts) {
+			set.NumExcluded++
+
+			continue
+		}
+
+		set.Hosts = append(set.Hosts, HostConnectionState{
+			Host:             hs,
+			AllowMaintenance: inPatternList(hs.Name, maintenanceHosts),
+			AllowStandby:     inPatternList(hs.Name, standbyHosts),
+		})
+	}
+
+	return set
+}
+
+// NotConnected indicates whether the host is disconnected or not responding.
+func (hcs HostConnectionState) NotConnected() bool {
+	return hcs.Host.Runtime.ConnectionState != types.HostSystemConnectionStateConnected
+}
+
+// InMaintenance indicates whether the host is in maintenance mode.
+func (hcs HostConnectionState) InMaintenance() bool {
+	return hcs.Host.Runtime.InMaintenanceMode
+}
+
+// InStandby indicates whether the host is in (or entering) standby mode.
+func (hcs HostConnectionState) InStandby() bool {
+	return hcs.Host.Runtime.PowerState == types.HostSystemPowerStateStandBy ||
+		hcs.Host.Runtime.StandbyMode == string(types.HostStandbyModeIn) ||
+		hcs.Host.Runtime.StandbyMode == string(types.HostStandbyModeEntering)
+}
+
+// IsPlanned indicates whether the host is in maintenance mode or standby
+// mode and is permitted to be in that state.
+func (hcs HostConnectionState) IsPlanned() bool {
+	return (hcs.InMaintenance() && hcs.AllowMaintenance) ||
+		(hcs.InStandby() && hcs.AllowStandby)
+}
+
+// IsCriticalState indicates whether the host is disconnected or not
+// responding without being permitted to be in maintenance mode or standby
+// mode.
+func (hcs HostConnectionState) IsCriticalState() bool {
+	return hcs.NotConnected() && !hcs.IsPlanned()
+}
+
+// IsWarningState indicates whether the host is unexpectedly in maintenance
+// mode or standby mode (and is connected).
+func (hcs HostConnectionState) IsWarningState() bool {
+	if hcs.IsCriticalState() {
+		return false
+	}
+
+	return (hcs.InMaintenance() && !hcs.AllowMaintenance) ||
+		(hcs.InStandby() && !hcs.AllowStandby)
+}
+
+// NumEvaluated returns the number of hosts in the set.
+func (set HostConnectionStateSet) NumEvaluated() int {
+	return len(set.Hosts)
+}
+
+// NumCritical returns the number of hosts in a CRITICAL state.
+func (set HostConnectionStateSet) NumCritical() int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of hosts in a WARNING state.
+func (set HostConnectionStateSet) NumWarning() int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumConnectionState returns the number of hosts with the specified
+// connection state.
+func (set HostConnectionStateSet) NumConnectionState(state types.HostSystemConnectionState) int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.Host.Runtime.ConnectionState == state {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInMaintenance returns the number of hosts in maintenance mode,
+// including those permitted to be in maintenance mode.
+func (set HostConnectionStateSet) NumInMaintenance() int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.InMaintenance() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInStandby returns the number of hosts in (or entering) standby mode,
+// including those permitted to be in standby mode.
+func (set HostConnectionStateSet) NumInStandby() int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.InStandby() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumPlanned returns the number of hosts permitted to be in their current
+// maintenance mode or standby mode state.
+func (set HostConnectionStateSet) NumPlanned() int {
+	var num int
+	for _, hcs := range set.Hosts {
+		if hcs.IsPlanned() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated host is in a CRITICAL
+// state.
+func (set HostConnectionStateSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated host is in a WARNING
+// state.
+func (set HostConnectionStateSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// HostConnectionStatePerfData generates performance data metrics from the
+// given collection of evaluated hosts.
+func HostConnectionStatePerfData(set HostConnectionStateSet) []nagios.PerformanceData {
+
+	return []nagios.PerformanceData{
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_connected",
+			Value: fmt.Sprintf("%d", set.NumConnectionState(types.HostSystemConnectionStateConnected)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_disconnected",
+			Value: fmt.Sprintf("%d", set.NumConnectionState(types.HostSystemConnectionStateDisconnected)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_not_responding",
+			Value: fmt.Sprintf("%d", set.NumConnectionState(types.HostSystemConnectionStateNotResponding)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_in_maintenance",
+			Value: fmt.Sprintf("%d", set.NumInMaintenance()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_in_standby",
+			Value: fmt.Sprintf("%d", set.NumInStandby()),
+			Min:   "0",
+		},
+	}
+
+}
+
+// HostConnectionStateOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func HostConnectionStateOneLineCheckSummary(
+	stateLabel string,
+	set HostConnectionStateSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostConnectionStateOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState():
+		return fmt.Sprintf(
+			"%s: %d hosts disconnected or not responding and %d hosts unexpectedly in maintenance or standby mode detected (evaluated %d hosts)",
+			stateLabel,
+			set.NumCritical(),
+			set.NumWarning(),
+			set.NumEvaluated(),
+		)
+
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts unexpectedly in maintenance or standby mode detected (evaluated %d hosts)",
+			stateLabel,
+			set.NumWarning(),
+			set.NumEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No host connection state issues detected (evaluated %d hosts, %d in planned maintenance or standby)",
+			stateLabel,
+			set.NumEvaluated(),
+			set.NumPlanned(),
+		)
+	}
+}
+
+// HostConnectionStateReport generates a summary of host connection state
+// issues along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostConnectionStateReport(
+	c *vim25.Client,
+	set HostConnectionStateSet,
+	includedHosts []string,
+	excludedHosts []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostConnectionStateReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No hosts found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	hostDetails := func(hcs HostConnectionState, state string) {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: connection state: %s, power state: %s, maintenance mode: %t, standby mode: %s%s",
+			hcs.Host.Name,
+			state,
+			hcs.Host.Runtime.ConnectionState,
+			hcs.Host.Runtime.PowerState,
+			hcs.InMaintenance(),
+			hcs.Host.Runtime.StandbyMode,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	var numIssues int
+	for _, hcs := range set.Hosts {
+		var state string
+		switch {
+		case hcs.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case hcs.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			continue
+		}
+		numIssues++
+
+		hostDetails(hcs, state)
+	}
+
+	if numIssues == 0 && set.NumEvaluated() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No host connection state issues detected%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if set.NumPlanned() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sHosts in planned maintenance or standby:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, hcs := range set.Hosts {
+			if hcs.IsPlanned() {
+				hostDetails(hcs, nagios.StateOKLabel)
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all hosts in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("all hosts in cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all hosts in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Hosts evaluated: %d (%d excluded, %d connected, %d disconnected, %d not responding, %d in maintenance mode, %d in standby mode)%s",
+		set.NumEvaluated(),
+		set.NumExcluded,
+		set.NumConnectionState(types.HostSystemConnectionStateConnected),
+		set.NumConnectionState(types.HostSystemConnectionStateDisconnected),
+		set.NumConnectionState(types.HostSystemConnectionStateNotResponding),
+		set.NumInMaintenance(),
+		set.NumInStandby(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Hosts to explicitly include (%d): [%v]%s",
+		len(includedHosts),
+		strings.Join(includedHosts, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Hosts to explicitly exclude (%d): [%v]%s",
+		len(excludedHosts),
+		strings.Join(excludedHosts, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// connectionStateHost returns a host with the given name and runtime
+// details.
+func connectionStateHost(
+	name string,
+	connectionState types.HostSystemConnectionState,
+	inMaintenance bool,
+	powerState types.HostSystemPowerState,
+	standbyMode types.HostStandbyMode,
+) mo.HostSystem {
+	return mo.HostSystem{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Runtime: types.HostRuntimeInfo{
+			ConnectionState:   connectionState,
+			InMaintenanceMode: inMaintenance,
+			PowerState:        powerState,
+			StandbyMode:       string(standbyMode),
+		},
+	}
+}
+
+func TestNewHostConnectionStateSet(t *testing.T) {
+	connected := types.HostSystemConnectionStateConnected
+	notResponding := types.HostSystemConnectionStateNotResponding
+	poweredOn := types.HostSystemPowerStatePoweredOn
+	standbyNone := types.HostStandbyModeNone
+
+	tests := map[string]struct {
+		hs               mo.HostSystem
+		maintenanceHosts []string
+		standbyHosts     []string
+		excludedHosts    []string
+		wantPlanned      bool
+		wantCritical     bool
+		wantWarning      bool
+	}{
+		"connected": {
+			hs: connectionStateHost("esx1", connected, false, poweredOn, standbyNone),
+		},
+		"not responding": {
+			hs:           connectionStateHost("esx1", notResponding, false, poweredOn, standbyNone),
+			wantCritical: true,
+		},
+		"in maintenance mode": {
+			hs:          connectionStateHost("esx1", connected, true, poweredOn, standbyNone),
+			wantWarning: true,
+		},
+		"in permitted maintenance mode": {
+			hs:               connectionStateHost("esx1", connected, true, poweredOn, standbyNone),
+			maintenanceHosts: []string{"ESX1"},
+			wantPlanned:      true,
+		},
+		"not responding in permitted maintenance mode": {
+			hs:               connectionStateHost("esx1", notResponding, true, poweredOn, standbyNone),
+			maintenanceHosts: []string{"esx1"},
+			wantPlanned:      true,
+		},
+		"entering standby mode": {
+			hs:          connectionStateHost("esx1", connected, false, poweredOn, types.HostStandbyModeEntering),
+			wantWarning: true,
+		},
+		"powered off to standby": {
+			hs:          connectionStateHost("esx1", connected, false, types.HostSystemPowerStateStandBy, standbyNone),
+			wantWarning: true,
+		},
+		"not responding in standby mode": {
+			hs:           connectionStateHost("esx1", notResponding, false, poweredOn, types.HostStandbyModeIn),
+			wantCritical: true,
+		},
+		"not responding in permitted standby mode": {
+			hs:           connectionStateHost("esx1", notResponding, false, poweredOn, types.HostStandbyModeIn),
+			standbyHosts: []string{"esx1"},
+			wantPlanned:  true,
+		},
+		"standby permitted but in maintenance mode": {
+			hs:           connectionStateHost("esx1", connected, true, poweredOn, standbyNone),
+			standbyHosts: []string{"esx1"},
+			wantWarning:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewHostConnectionStateSet(
+				[]mo.HostSystem{tt.hs},
+				nil,
+				tt.excludedHosts,
+				tt.maintenanceHosts,
+				tt.standbyHosts,
+			)
+
+			if got := set.NumEvaluated(); got != 1 {
+				t.Fatalf("want 1 evaluated host; got %d", got)
+			}
+
+			hcs := set.Hosts[0]
+
+			if got := hcs.IsPlanned(); got != tt.wantPlanned {
+				t.Errorf("want planned %t; got %t", tt.wantPlanned, got)
+			}
+
+			if got := hcs.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hcs.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostConnectionStateSetCounts(t *testing.T) {
+	connected := types.HostSystemConnectionStateConnected
+	poweredOn := types.HostSystemPowerStatePoweredOn
+	standbyNone := types.HostStandbyModeNone
+
+	hss := []mo.HostSystem{
+		connectionStateHost("esx1", connected, false, poweredOn, standbyNone),
+		connectionStateHost("esx2", types.HostSystemConnectionStateNotResponding, false, poweredOn, standbyNone),
+		connectionStateHost("esx3", types.HostSystemConnectionStateDisconnected, true, poweredOn, standbyNone),
+		connectionStateHost("esx4", connected, false, poweredOn, types.HostStandbyModeIn),
+		connectionStateHost("esx5", connected, true, poweredOn, standbyNone),
+		connectionStateHost("lab-esx1", types.HostSystemConnectionStateNotResponding, false, poweredOn, standbyNone),
+	}
+
+	set := NewHostConnectionStateSet(hss, nil, []string{"lab-esx1"}, []string{"esx3"}, nil)
+
+	if set.NumExcluded != 1 {
+		t.Errorf("want 1 excluded host; got %d", set.NumExcluded)
+	}
+
+	if got := set.NumEvaluated(); got != 5 {
+		t.Errorf("want 5 evaluated hosts; got %d", got)
+	}
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL host; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 2 || !set.HasWarningState() {
+		t.Errorf("want 2 WARNING hosts; got %d", got)
+	}
+
+	if got := set.NumConnectionState(connected); got != 3 {
+		t.Errorf("want 3 connected hosts; got %d", got)
+	}
+
+	if got := set.NumInMaintenance(); got != 2 {
+		t.Errorf("want 2 hosts in maintenance mode; got %d", got)
+	}
+
+	if got := set.NumInStandby(); got != 1 {
+		t.Errorf("want 1 host in standby mode; got %d", got)
+	}
+
+	if got := set.NumPlanned(); got != 1 {
+		t.Errorf("want 1 planned host; got %d", got)
+	}
+}
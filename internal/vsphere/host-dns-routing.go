@@ -0,0 +1,741 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostDNSRoutingDrift indicates that the DNS or default gateway
+// configuration for one or more ESXi hosts does not match the expected
+// values for the cluster.
+var ErrHostDNSRoutingDrift = errors.New("host DNS or routing configuration drift detected")
+
+// Host DNS and routing configuration settings evaluated for drift.
+const (
+	HostDNSRoutingSettingDNSServers    string = "DNS servers"
+	HostDNSRoutingSettingSearchDomains string = "search domains"
+	HostDNSRoutingSettingIPv4Gateway   string = "IPv4 default gateway"
+	HostDNSRoutingSettingIPv6Gateway   string = "IPv6 default gateway"
+)
+
+// hostDNSRoutingNotSet is used in place of an empty value when reporting
+// host DNS and routing configuration settings.
+const hostDNSRoutingNotSet string = "(not set)"
+
+// HostDNSRoutingConfig represents the DNS and default gateway configuration
+// for an ESXi host. DNS servers and search domains are recorded in sorted
+// order so that ordering differences are not treated as drift.
+type HostDNSRoutingConfig struct {
+	DNSServers    []string
+	SearchDomains []string
+	IPv4Gateway   string
+	IPv6Gateway   string
+}
+
+// HostDNSRoutingDrift represents a difference between the actual and
+// expected value for a specific DNS or default gateway setting.
+type HostDNSRoutingDrift struct {
+	Setting  string
+	Expected string
+	Actual   string
+}
+
+// HostDNSRouting tracks the DNS and default gateway configuration for a
+// specific HostSystem along with any differences from the expected values.
+type HostDNSRouting struct {
+	// Host is the HostSystem that the configuration was retrieved from.
+	Host mo.HostSystem
+
+	// Config is the DNS and default gateway configuration for the
+	// HostSystem.
+	Config HostDNSRoutingConfig
+
+	// Drift is the collection of settings which do not match the expected
+	// values for the cluster.
+	Drift []HostDNSRoutingDrift
+
+	// Unavailable indicates whether configuration details could not be
+	// retrieved for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// ClusterDNSRouting tracks the DNS and default gateway configuration for all
+// HostSystems in a specific cluster.
+type ClusterDNSRouting struct {
+	// Cluster is the cluster that the HostSystems are members of.
+	Cluster mo.ClusterComputeResource
+
+	// Expected is the DNS and default gateway configuration that all
+	// HostSystems in the cluster are expected to use.
+	Expected HostDNSRoutingConfig
+
+	// Hosts is the collection of evaluated HostSystems in the cluster.
+	Hosts []HostDNSRouting
+}
+
+// ClusterDNSRoutingSet is a collection of ClusterDNSRouting values.
+type ClusterDNSRoutingSet []ClusterDNSRouting
+
+// sortedHostDNSRoutingList returns a sorted copy of the given list with
+// empty entries removed.
+func sortedHostDNSRoutingList(list []string) []string {
+	sorted := make([]string, 0, len(list))
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		sorted = append(sorted, item)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// hostDNSRoutingValue returns the given value for reporting, substituting a
+// placeholder for empty values.
+func hostDNSRoutingValue(value string) string {
+	if value == "" {
+		return hostDNSRoutingNotSet
+	}
+
+	return value
+}
+
+// settings returns the DNS and default gateway settings for the
+// configuration as strings indexed by setting name.
+func (cfg HostDNSRoutingConfig) settings() map[string]string {
+	return map[string]string{
+		HostDNSRoutingSettingDNSServers:    strings.Join(cfg.DNSServers, ", "),
+		HostDNSRoutingSettingSearchDomains: strings.Join(cfg.SearchDomains, ", "),
+		HostDNSRoutingSettingIPv4Gateway:   cfg.IPv4Gateway,
+		HostDNSRoutingSettingIPv6Gateway:   cfg.IPv6Gateway,
+	}
+}
+
+// hostDNSRoutingSettings is the list of evaluated settings in the order
+// that they are reported.
+func hostDNSRoutingSettings() []string {
+	return []string{
+		HostDNSRoutingSettingDNSServers,
+		HostDNSRoutingSettingSearchDomains,
+		HostDNSRoutingSettingIPv4Gateway,
+		HostDNSRoutingSettingIPv6Gateway,
+	}
+}
+
+// isHostDNSRoutingGatewaySetting indicates whether the given setting is a
+// default gateway setting. Default gateway drift affects the HA isolation
+// response for a cluster as the default gateway is used as the isolation
+// address unless otherwise configured.
+func isHostDNSRoutingGatewaySetting(setting string) bool {
+	return setting == HostDNSRoutingSettingIPv4Gateway ||
+		setting == HostDNSRoutingSettingIPv6Gateway
+}
+
+// GetHostDNSRoutingConfig uses the HostNetworkSystem for the specified
+// HostSystem to retrieve the DNS and default gateway configuration for the
+// HostSystem.
+func GetHostDNSRoutingConfig(ctx context.Context, c *vim25.Client, hs mo.HostSystem) (HostDNSRoutingConfig, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostDNSRoutingConfig func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var cfg HostDNSRoutingConfig
+
+	host := object.NewHostSystem(c, hs.Reference())
+
+	ns, err := host.ConfigManager().NetworkSystem(ctx)
+	if err != nil {
+		return cfg, fmt.Errorf(
+			"failed to retrieve network system for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	var networkSystem mo.HostNetworkSystem
+
+	pc := property.DefaultCollector(c)
+	err = pc.RetrieveOne(
+		ctx,
+		ns.Reference(),
+		[]string{"dnsConfig", "ipRouteConfig"},
+		&networkSystem,
+	)
+	if err != nil {
+		return cfg, fmt.Errorf(
+			"failed to retrieve DNS and routing details for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	if networkSystem.DnsConfig != nil {
+		dnsConfig := networkSystem.DnsConfig.GetHostDnsConfig()
+		cfg.DNSServers = sortedHostDNSRoutingList(dnsConfig.Address)
+		cfg.SearchDomains = sortedHostDNSRoutingList(dnsConfig.SearchDomain)
+	}
+
+	if networkSystem.IpRouteConfig != nil {
+		routeConfig := networkSystem.IpRouteConfig.GetHostIpRouteConfig()
+		cfg.IPv4Gateway = routeConfig.DefaultGateway
+		cfg.IPv6Gateway = routeConfig.IpV6DefaultGateway
+	}
+
+	return cfg, nil
+
+}
+
+// NewHostDNSRouting evaluates the given DNS and default gateway
+// configuration for a HostSystem against the expected configuration.
+func NewHostDNSRouting(hs mo.HostSystem, cfg HostDNSRoutingConfig, expected HostDNSRoutingConfig) HostDNSRouting {
+
+	hostDNSRouting := HostDNSRouting{
+		Host:   hs,
+		Config: cfg,
+	}
+
+	actualSettings := cfg.settings()
+	expectedSettings := expected.settings()
+
+	for _, setting := range hostDNSRoutingSettings() {
+		if !strings.EqualFold(actualSettings[setting], expectedSettings[setting]) {
+			hostDNSRouting.Drift = append(hostDNSRouting.Drift, HostDNSRoutingDrift{
+				Setting:  setting,
+				Expected: hostDNSRoutingValue(expectedSettings[setting]),
+				Actual:   hostDNSRoutingValue(actualSettings[setting]),
+			})
+		}
+	}
+
+	return hostDNSRouting
+
+}
+
+// mostCommonValue returns the most common value from the given list. Ties
+// are broken by preferring non-empty values and then by returning the lowest
+// sorted value.
+func mostCommonValue(values []string) string {
+	counts := make(map[string]int, len(values))
+	for _, value := range values {
+		counts[value]++
+	}
+
+	var mostCommon string
+	var mostCommonCount int
+	for value, count := range counts {
+		switch {
+		case count > mostCommonCount:
+			mostCommon = value
+			mostCommonCount = count
+		case count < mostCommonCount:
+		case mostCommon == "":
+			mostCommon = value
+		case value != "" && value < mostCommon:
+			mostCommon = value
+		}
+	}
+
+	return mostCommon
+}
+
+// expectedHostDNSRoutingConfig determines the expected DNS and default
+// gateway configuration for a cluster. Settings specified in the given
+// override configuration are used as-is; all other settings use the most
+// common value among the given configurations.
+func expectedHostDNSRoutingConfig(configs []HostDNSRoutingConfig, override HostDNSRoutingConfig) HostDNSRoutingConfig {
+
+	var dnsServers, searchDomains, ipv4Gateways, ipv6Gateways []string
+	for _, cfg := range configs {
+		dnsServers = append(dnsServers, strings.Join(cfg.DNSServers, ","))
+		searchDomains = append(searchDomains, strings.Join(cfg.SearchDomains, ","))
+		ipv4Gateways = append(ipv4Gateways, cfg.IPv4Gateway)
+		ipv6Gateways = append(ipv6Gateways, cfg.IPv6Gateway)
+	}
+
+	expected := HostDNSRoutingConfig{
+		DNSServers:    sortedHostDNSRoutingList(strings.Split(mostCommonValue(dnsServers), ",")),
+		SearchDomains: sortedHostDNSRoutingList(strings.Split(mostCommonValue(searchDomains), ",")),
+		IPv4Gateway:   mostCommonValue(ipv4Gateways),
+		IPv6Gateway:   mostCommonValue(ipv6Gateways),
+	}
+
+	if len(override.DNSServers) > 0 {
+		expected.DNSServers = sortedHostDNSRoutingList(override.DNSServers)
+	}
+
+	if len(override.SearchDomains) > 0 {
+		expected.SearchDomains = sortedHostDNSRoutingList(override.SearchDomains)
+	}
+
+	if override.IPv4Gateway != "" {
+		expected.IPv4Gateway = override.IPv4Gateway
+	}
+
+	if override.IPv6Gateway != "" {
+		expected.IPv6Gateway = override.IPv6Gateway
+	}
+
+	return expected
+
+}
+
+// GetClusterDNSRoutingSet retrieves the DNS and default gateway
+// configuration for each HostSystem in the given clusters and evaluates the
+// configuration against the expected values for each cluster. Unless
+// overridden by the given expected configuration, the expected value for a
+// setting is the most common value among the connected HostSystems in the
+// cluster. HostSystems which are not connected are flagged as unavailable
+// and are not evaluated.
+func GetClusterDNSRoutingSet(
+	ctx context.Context,
+	c *vim25.Client,
+	clusters []mo.ClusterComputeResource,
+	override HostDNSRoutingConfig,
+) (ClusterDNSRoutingSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterDNSRoutingSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterDNSRoutingSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		hss, err := GetClusterHostSystems(ctx, c, cluster, true)
+		if err != nil {
+			return nil, err
+		}
+
+		connected := make([]mo.HostSystem, 0, len(hss))
+		configs := make([]HostDNSRoutingConfig, 0, len(hss))
+
+		clusterDNSRouting := ClusterDNSRouting{
+			Cluster: cluster,
+			Hosts:   make([]HostDNSRouting, 0, len(hss)),
+		}
+
+		for _, hs := range hss {
+			if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+				logger.Printf(
+					"host %s connection state is %s; skipping DNS and routing evaluation",
+					hs.Name,
+					hs.Runtime.ConnectionState,
+				)
+
+				clusterDNSRouting.Hosts = append(
+					clusterDNSRouting.Hosts,
+					HostDNSRouting{Host: hs, Unavailable: true},
+				)
+
+				continue
+			}
+
+			cfg, err := GetHostDNSRoutingConfig(ctx, c, hs)
+			if err != nil {
+				return nil, err
+			}
+
+			connected = append(connected, hs)
+			configs = append(configs, cfg)
+		}
+
+		clusterDNSRouting.Expected = expectedHostDNSRoutingConfig(configs, override)
+
+		for i, hs := range connected {
+			clusterDNSRouting.Hosts = append(
+				clusterDNSRouting.Hosts,
+				NewHostDNSRouting(hs, configs[i], clusterDNSRouting.Expected),
+			)
+		}
+
+		sort.Slice(clusterDNSRouting.Hosts, func(i, j int) bool {
+			return strings.ToLower(clusterDNSRouting.Hosts[i].Host.Name) <
+				strings.ToLower(clusterDNSRouting.Hosts[j].Host.Name)
+		})
+
+		set = append(set, clusterDNSRouting)
+	}
+
+	return set, nil
+
+}
+
+// HasCriticalState indicates whether the default gateway configuration for
+// the HostSystem does not match the expected values or whether the
+// HostSystem has no default gateway configured.
+func (hdr HostDNSRouting) HasCriticalState() bool {
+	if hdr.Unavailable {
+		return false
+	}
+
+	if hdr.Config.IPv4Gateway == "" && hdr.Config.IPv6Gateway == "" {
+		return true
+	}
+
+	for _, drift := range hdr.Drift {
+		if isHostDNSRoutingGatewaySetting(drift.Setting) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether the DNS configuration for the
+// HostSystem does not match the expected values.
+func (hdr HostDNSRouting) HasWarningState() bool {
+	for _, drift := range hdr.Drift {
+		if !isHostDNSRoutingGatewaySetting(drift.Setting) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsUnavailable returns the number of HostSystems in the cluster
+// whose DNS and default gateway configuration could not be evaluated due to
+// their connection state.
+func (cdr ClusterDNSRouting) NumHostsUnavailable() int {
+	var num int
+	for _, hdr := range cdr.Hosts {
+		if hdr.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem has default
+// gateway drift or no default gateway configured.
+func (set ClusterDNSRoutingSet) HasCriticalState() bool {
+	return set.NumHostsCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has DNS
+// configuration drift.
+func (set ClusterDNSRoutingSet) HasWarningState() bool {
+	return set.NumHostsWarning() > 0
+}
+
+// NumHosts returns the number of HostSystems across all clusters.
+func (set ClusterDNSRoutingSet) NumHosts() int {
+	var num int
+	for _, cdr := range set {
+		num += len(cdr.Hosts)
+	}
+
+	return num
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose DNS and default
+// gateway configuration was evaluated.
+func (set ClusterDNSRoutingSet) NumHostsEvaluated() int {
+	var num int
+	for _, cdr := range set {
+		for _, hdr := range cdr.Hosts {
+			if !hdr.Unavailable {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose DNS and
+// default gateway configuration could not be evaluated due to their
+// connection state.
+func (set ClusterDNSRoutingSet) NumHostsUnavailable() int {
+	return set.NumHosts() - set.NumHostsEvaluated()
+}
+
+// NumHostsCritical returns the number of HostSystems with default gateway
+// drift or no default gateway configured.
+func (set ClusterDNSRoutingSet) NumHostsCritical() int {
+	var num int
+	for _, cdr := range set {
+		for _, hdr := range cdr.Hosts {
+			if hdr.HasCriticalState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumHostsWarning returns the number of HostSystems with DNS configuration
+// drift.
+func (set ClusterDNSRoutingSet) NumHostsWarning() int {
+	var num int
+	for _, cdr := range set {
+		for _, hdr := range cdr.Hosts {
+			if hdr.HasWarningState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumHostsDrifted returns the number of HostSystems with any DNS or default
+// gateway configuration drift.
+func (set ClusterDNSRoutingSet) NumHostsDrifted() int {
+	var num int
+	for _, cdr := range set {
+		for _, hdr := range cdr.Hosts {
+			if len(hdr.Drift) > 0 {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// ClusterDNSRoutingPerfData generates performance data metrics from the
+// given collection of evaluated cluster DNS and default gateway
+// configurations.
+func ClusterDNSRoutingPerfData(set ClusterDNSRoutingSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "clusters",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", set.NumHosts()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_drifted",
+			Value: fmt.Sprintf("%d", set.NumHostsDrifted()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_gateway_drift",
+			Value: fmt.Sprintf("%d", set.NumHostsCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_dns_drift",
+			Value: fmt.Sprintf("%d", set.NumHostsWarning()),
+			Min:   "0",
+		},
+	}
+}
+
+// ClusterDNSRoutingOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func ClusterDNSRoutingOneLineCheckSummary(
+	stateLabel string,
+	set ClusterDNSRoutingSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDNSRoutingOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with default gateway issues and %d hosts with DNS drift (evaluated %d hosts, %d clusters)",
+			stateLabel,
+			set.NumHostsCritical(),
+			set.NumHostsWarning(),
+			set.NumHostsEvaluated(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No host DNS or routing drift detected (evaluated %d hosts, %d clusters)",
+			stateLabel,
+			set.NumHostsEvaluated(),
+			len(set),
+		)
+	}
+}
+
+// ClusterDNSRoutingReport generates a summary of host DNS and default
+// gateway configuration drift for evaluated clusters along with various
+// verbose details intended to aid in troubleshooting check results at a
+// glance. This information is provided for use with the Long Service Output
+// field commonly displayed on the detailed service check results display in
+// the web UI or in the body of many notifications.
+func ClusterDNSRoutingReport(
+	c *vim25.Client,
+	set ClusterDNSRoutingSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterDNSRoutingReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with DNS or routing issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, cdr := range set {
+		for _, hdr := range cdr.Hosts {
+			if !hdr.HasCriticalState() && !hdr.HasWarningState() {
+				continue
+			}
+			numProblemHosts++
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (cluster: %s)%s",
+				hdr.Host.Name,
+				cdr.Cluster.Name,
+				nagios.CheckOutputEOL,
+			)
+
+			if hdr.Config.IPv4Gateway == "" && hdr.Config.IPv6Gateway == "" {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** no default gateway configured%s",
+					nagios.CheckOutputEOL,
+				)
+			}
+
+			for _, drift := range hdr.Drift {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s: %s (expected: %s)%s",
+					drift.Setting,
+					drift.Actual,
+					drift.Expected,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sExpected configuration per cluster:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, cdr := range set {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%d hosts, %d unavailable)%s",
+			cdr.Cluster.Name,
+			len(cdr.Hosts),
+			cdr.NumHostsUnavailable(),
+			nagios.CheckOutputEOL,
+		)
+
+		expectedSettings := cdr.Expected.settings()
+		for _, setting := range hostDNSRoutingSettings() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s: %s%s",
+				setting,
+				hostDNSRoutingValue(expectedSettings[setting]),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,251 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+func TestMostCommonValue(t *testing.T) {
+	tests := map[string]struct {
+		values []string
+		want   string
+	}{
+		"no values":                   {},
+		"single value":                {values: []string{"a"}, want: "a"},
+		"most common":                 {values: []string{"b", "a", "b"}, want: "b"},
+		"tie resolved alphabetically": {values: []string{"b", "a", "b", "a"}, want: "a"},
+		"empty value preferred less":  {values: []string{"", "b", "", "b"}, want: "b"},
+		"empty value most common":     {values: []string{"", "", "b"}, want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := mostCommonValue(tt.values); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpectedHostDNSRoutingConfig(t *testing.T) {
+	configs := []HostDNSRoutingConfig{
+		{
+			DNSServers:    []string{"192.168.1.10", "192.168.1.11"},
+			SearchDomains: []string{"example.com"},
+			IPv4Gateway:   "192.168.1.1",
+		},
+		{
+			DNSServers:    []string{"192.168.1.10", "192.168.1.11"},
+			SearchDomains: []string{"example.com"},
+			IPv4Gateway:   "192.168.1.1",
+		},
+		{
+			DNSServers:  []string{"192.168.1.12"},
+			IPv4Gateway: "192.168.1.254",
+			IPv6Gateway: "fe80::1",
+		},
+	}
+
+	tests := map[string]struct {
+		override HostDNSRoutingConfig
+		want     HostDNSRoutingConfig
+	}{
+		"most common settings": {
+			want: HostDNSRoutingConfig{
+				DNSServers:    []string{"192.168.1.10", "192.168.1.11"},
+				SearchDomains: []string{"example.com"},
+				IPv4Gateway:   "192.168.1.1",
+			},
+		},
+		"override settings sorted": {
+			override: HostDNSRoutingConfig{
+				DNSServers:    []string{"192.168.1.21", " 192.168.1.20", ""},
+				SearchDomains: []string{"lab.example.com"},
+				IPv4Gateway:   "192.168.1.254",
+				IPv6Gateway:   "fe80::1",
+			},
+			want: HostDNSRoutingConfig{
+				DNSServers:    []string{"192.168.1.20", "192.168.1.21"},
+				SearchDomains: []string{"lab.example.com"},
+				IPv4Gateway:   "192.168.1.254",
+				IPv6Gateway:   "fe80::1",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := expectedHostDNSRoutingConfig(configs, tt.override)
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewHostDNSRouting(t *testing.T) {
+	expected := HostDNSRoutingConfig{
+		DNSServers:    []string{"192.168.1.10", "192.168.1.11"},
+		SearchDomains: []string{"example.com"},
+		IPv4Gateway:   "192.168.1.1",
+	}
+
+	hs := mo.HostSystem{ManagedEntity: mo.ManagedEntity{Name: "esx1"}}
+
+	tests := map[string]struct {
+		cfg          HostDNSRoutingConfig
+		wantDrift    []HostDNSRoutingDrift
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"matches expected configuration": {
+			cfg: expected,
+		},
+		"search domain differs only by case": {
+			cfg: HostDNSRoutingConfig{
+				DNSServers:    expected.DNSServers,
+				SearchDomains: []string{"EXAMPLE.COM"},
+				IPv4Gateway:   expected.IPv4Gateway,
+			},
+		},
+		"DNS server drift": {
+			cfg: HostDNSRoutingConfig{
+				DNSServers:    []string{"192.168.1.10"},
+				SearchDomains: expected.SearchDomains,
+				IPv4Gateway:   expected.IPv4Gateway,
+			},
+			wantDrift: []HostDNSRoutingDrift{
+				{
+					Setting:  HostDNSRoutingSettingDNSServers,
+					Expected: "192.168.1.10, 192.168.1.11",
+					Actual:   "192.168.1.10",
+				},
+			},
+			wantWarning: true,
+		},
+		"default gateway drift": {
+			cfg: HostDNSRoutingConfig{
+				DNSServers:    expected.DNSServers,
+				SearchDomains: expected.SearchDomains,
+				IPv4Gateway:   "192.168.1.254",
+			},
+			wantDrift: []HostDNSRoutingDrift{
+				{
+					Setting:  HostDNSRoutingSettingIPv4Gateway,
+					Expected: "192.168.1.1",
+					Actual:   "192.168.1.254",
+				},
+			},
+			wantCritical: true,
+		},
+		"no default gateway configured": {
+			cfg: HostDNSRoutingConfig{
+				DNSServers:    expected.DNSServers,
+				SearchDomains: expected.SearchDomains,
+			},
+			wantDrift: []HostDNSRoutingDrift{
+				{
+					Setting:  HostDNSRoutingSettingIPv4Gateway,
+					Expected: "192.168.1.1",
+					Actual:   hostDNSRoutingNotSet,
+				},
+			},
+			wantCritical: true,
+		},
+		"DNS and default gateway drift": {
+			cfg: HostDNSRoutingConfig{
+				DNSServers:  expected.DNSServers,
+				IPv4Gateway: "192.168.1.254",
+				IPv6Gateway: "fe80::1",
+			},
+			wantDrift: []HostDNSRoutingDrift{
+				{
+					Setting:  HostDNSRoutingSettingSearchDomains,
+					Expected: "example.com",
+					Actual:   hostDNSRoutingNotSet,
+				},
+				{
+					Setting:  HostDNSRoutingSettingIPv4Gateway,
+					Expected: "192.168.1.1",
+					Actual:   "192.168.1.254",
+				},
+				{
+					Setting:  HostDNSRoutingSettingIPv6Gateway,
+					Expected: hostDNSRoutingNotSet,
+					Actual:   "fe80::1",
+				},
+			},
+			wantCritical: true,
+			wantWarning:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hdr := NewHostDNSRouting(hs, tt.cfg, expected)
+
+			if d := cmp.Diff(tt.wantDrift, hdr.Drift); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := hdr.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hdr.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestClusterDNSRoutingSetCounts(t *testing.T) {
+	gatewayDrift := HostDNSRoutingDrift{Setting: HostDNSRoutingSettingIPv4Gateway}
+	dnsDrift := HostDNSRoutingDrift{Setting: HostDNSRoutingSettingDNSServers}
+	gateway := HostDNSRoutingConfig{IPv4Gateway: "192.168.1.1"}
+
+	set := ClusterDNSRoutingSet{
+		{
+			Hosts: []HostDNSRouting{
+				{Config: gateway},
+				{Config: gateway, Drift: []HostDNSRoutingDrift{gatewayDrift, dnsDrift}},
+				{Unavailable: true},
+			},
+		},
+		{
+			Hosts: []HostDNSRouting{
+				{Config: gateway, Drift: []HostDNSRoutingDrift{dnsDrift}},
+			},
+		},
+	}
+
+	if got := set.NumHosts(); got != 4 {
+		t.Errorf("want 4 hosts; got %d", got)
+	}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumHostsCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL host; got %d", got)
+	}
+
+	if got := set.NumHostsWarning(); got != 2 || !set.HasWarningState() {
+		t.Errorf("want 2 WARNING hosts; got %d", got)
+	}
+
+	if got := set.NumHostsDrifted(); got != 2 {
+		t.Errorf("want 2 hosts with drift; got %d", got)
+	}
+}
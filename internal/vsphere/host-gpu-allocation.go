@@ -0,0 +1,1133 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostGPUAllocationThresholdCrossed indicates that specified GPU
+// allocation or utilization thresholds have been crossed for one or more
+// ESXi hosts.
+var ErrHostGPUAllocationThresholdCrossed = errors.New("GPU allocation or utilization exceeds specified threshold")
+
+// PerfCounterGPUUtilization is the performance counter used to evaluate GPU
+// utilization. Values are reported per GPU instance in hundredths of a
+// percent.
+const PerfCounterGPUUtilization string = "gpu.utilization.average"
+
+// GPUUtilizationSampleCount is the number of real-time samples evaluated for
+// each GPU. At the 20 second real-time sampling interval this covers the
+// most recent minute.
+const GPUUtilizationSampleCount int32 = 3
+
+// vgpuProfileMemoryRegex matches the framebuffer size (in GB) specified at
+// the end of NVIDIA vGPU profile names (e.g., grid_t4-4q, grid_a100-3-20c).
+var vgpuProfileMemoryRegex = regexp.MustCompile(`-(\d+)[a-z]+$`)
+
+// HostGPUAllocationThresholds represents the user-specified GPU allocation
+// and utilization percentage thresholds.
+type HostGPUAllocationThresholds struct {
+	AllocationWarning   int
+	AllocationCritical  int
+	UtilizationWarning  int
+	UtilizationCritical int
+}
+
+// HostGPUDevice represents a graphics device on an ESXi host along with the
+// VMs currently using it.
+type HostGPUDevice struct {
+
+	// ID is the PCI ID of the device (e.g., 0000:3b:00.0).
+	ID string
+
+	// Name is the device name.
+	Name string
+
+	// Vendor is the device vendor name.
+	Vendor string
+
+	// GraphicsType is the configured graphics type for the device (basic,
+	// shared, direct or sharedDirect).
+	GraphicsType string
+
+	// MemoryBytes is the framebuffer memory capacity of the device or zero
+	// if not available.
+	MemoryBytes int64
+
+	// AllocatedBytes is the framebuffer memory allocated to VMs using the
+	// device.
+	AllocatedBytes int64
+
+	// VMs is the collection of VM names using the device.
+	VMs []string
+}
+
+// VMGPUDevice represents a vGPU or DirectPath I/O GPU device configured for
+// a VM registered on an ESXi host.
+type VMGPUDevice struct {
+
+	// VMName is the name of the VM.
+	VMName string
+
+	// PowerState is the power state of the VM.
+	PowerState types.VirtualMachinePowerState
+
+	// DeviceLabel is the label of the VM device (e.g., PCI device 0).
+	DeviceLabel string
+
+	// Profile is the vGPU profile (e.g., grid_t4-4q). This is empty for
+	// DirectPath I/O GPU devices.
+	Profile string
+
+	// GPU is the PCI ID of the host GPU the device is placed on. This is
+	// empty if the device is not placed on a GPU (e.g., VM powered off).
+	GPU string
+
+	// MemoryBytes is the framebuffer memory allocated for the device or
+	// zero if not known.
+	MemoryBytes int64
+}
+
+// HostGPUAllocation represents the GPU allocation and utilization for a
+// HostSystem along with the GPU devices configured for VMs registered on the
+// host.
+type HostGPUAllocation struct {
+
+	// Host is the HostSystem that the GPU details were retrieved from.
+	Host mo.HostSystem
+
+	// GPUs is the collection of graphics devices on the host which may be
+	// allocated to VMs (i.e., not configured for basic graphics).
+	GPUs []HostGPUDevice
+
+	// VMDevices is the collection of vGPU and DirectPath I/O GPU devices
+	// configured for (non-template) VMs registered on the host.
+	VMDevices []VMGPUDevice
+
+	// Utilization is the highest average utilization percentage across all
+	// GPUs on the host.
+	Utilization float64
+
+	// UtilizationAvailable indicates whether GPU utilization samples were
+	// available for the host.
+	UtilizationAvailable bool
+
+	// Unavailable indicates whether GPU details could not be retrieved for
+	// the HostSystem due to its connection state.
+	Unavailable bool
+
+	// Thresholds are the user-specified allocation and utilization
+	// thresholds.
+	Thresholds HostGPUAllocationThresholds
+}
+
+// HostGPUAllocationSet is a collection of HostGPUAllocation values.
+type HostGPUAllocationSet []HostGPUAllocation
+
+// vgpuProfileMemory returns the framebuffer memory in bytes for the given
+// vGPU profile or zero if the size cannot be determined from the profile
+// name.
+func vgpuProfileMemory(profile string) int64 {
+	matches := vgpuProfileMemoryRegex.FindStringSubmatch(strings.ToLower(profile))
+	if len(matches) != 2 {
+		return 0
+	}
+
+	gb, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return gb * int64(units.GB)
+}
+
+// gpuUtilizationPercent returns the highest average utilization percentage
+// across the given per-instance samples (in hundredths of a percent).
+func gpuUtilizationPercent(instances map[string][]int64) float64 {
+	var highest float64
+	for _, samples := range instances {
+		if len(samples) == 0 {
+			continue
+		}
+
+		var total int64
+		for _, sample := range samples {
+			total += sample
+		}
+
+		if avg := float64(total) / float64(len(samples)) / 100; avg > highest {
+			highest = avg
+		}
+	}
+
+	return highest
+}
+
+// NewHostGPUAllocation evaluates the graphics device details for the given
+// HostSystem against the GPU devices configured for the given VMs registered
+// on the host.
+func NewHostGPUAllocation(
+	hs mo.HostSystem,
+	graphicsInfo []types.HostGraphicsInfo,
+	vms []mo.VirtualMachine,
+	thresholds HostGPUAllocationThresholds,
+) HostGPUAllocation {
+
+	hga := HostGPUAllocation{
+		Host:       hs,
+		Thresholds: thresholds,
+	}
+
+	// Index the host GPU used by each VM. A VM with multiple GPU devices is
+	// listed for each GPU it uses.
+	vmGPUs := make(map[string][]int)
+
+	for _, info := range graphicsInfo {
+		if info.GraphicsType == string(types.HostGraphicsInfoGraphicsTypeBasic) {
+			continue
+		}
+
+		idx := len(hga.GPUs)
+		hga.GPUs = append(hga.GPUs, HostGPUDevice{
+			ID:           normalizePCIID(info.PciId),
+			Name:         info.DeviceName,
+			Vendor:       info.VendorName,
+			GraphicsType: info.GraphicsType,
+			MemoryBytes:  info.MemorySizeInKB * int64(units.KB),
+		})
+
+		for _, vm := range info.Vm {
+			vmGPUs[vm.Value] = append(vmGPUs[vm.Value], idx)
+		}
+	}
+
+	for _, vm := range vms {
+		if vm.Config == nil || vm.Config.Template {
+			continue
+		}
+
+		gpus := vmGPUs[vm.Self.Value]
+
+		for _, device := range vm.Config.Hardware.Device {
+			pci, ok := device.(*types.VirtualPCIPassthrough)
+			if !ok {
+				continue
+			}
+
+			vmDevice := VMGPUDevice{
+				VMName:      vm.Name,
+				PowerState:  vm.Runtime.PowerState,
+				DeviceLabel: deviceLabel(pci),
+			}
+
+			switch backing := pci.Backing.(type) {
+			case *types.VirtualPCIPassthroughVmiopBackingInfo:
+				vmDevice.Profile = backing.Vgpu
+				vmDevice.MemoryBytes = vgpuProfileMemory(backing.Vgpu)
+
+				// Place the vGPU on the next shared GPU used by the VM.
+				for i, idx := range gpus {
+					if hga.GPUs[idx].GraphicsType == string(types.HostGraphicsInfoGraphicsTypeDirect) {
+						continue
+					}
+
+					vmDevice.GPU = hga.GPUs[idx].ID
+					gpus = append(gpus[:i:i], gpus[i+1:]...)
+
+					break
+				}
+
+			case *types.VirtualPCIPassthroughDeviceBackingInfo:
+				id := normalizePCIID(backing.Id)
+
+				var isGPU bool
+				for i, idx := range gpus {
+					if hga.GPUs[idx].ID != id {
+						continue
+					}
+
+					isGPU = true
+					vmDevice.GPU = id
+					vmDevice.MemoryBytes = hga.GPUs[idx].MemoryBytes
+					gpus = append(gpus[:i:i], gpus[i+1:]...)
+
+					break
+				}
+
+				// Skip DirectPath I/O devices which are not GPUs (or which
+				// are not currently in use by the VM).
+				if !isGPU {
+					continue
+				}
+
+			default:
+				continue
+			}
+
+			hga.VMDevices = append(hga.VMDevices, vmDevice)
+		}
+
+		// Record VM usage for host GPUs not matched to a specific VM device
+		// (e.g., details for the VM device are unavailable).
+		for _, idx := range gpus {
+			hga.GPUs[idx].VMs = append(hga.GPUs[idx].VMs, vm.Name)
+		}
+	}
+
+	for _, vmDevice := range hga.VMDevices {
+		if vmDevice.GPU == "" {
+			continue
+		}
+
+		for i := range hga.GPUs {
+			if hga.GPUs[i].ID != vmDevice.GPU {
+				continue
+			}
+
+			hga.GPUs[i].VMs = append(hga.GPUs[i].VMs, vmDevice.VMName)
+			hga.GPUs[i].AllocatedBytes += vmDevice.MemoryBytes
+
+			break
+		}
+	}
+
+	// GPUs in use by VMs without a known framebuffer allocation (e.g., vGPU
+	// profiles with an unrecognized name) are considered fully allocated.
+	for i := range hga.GPUs {
+		gpu := &hga.GPUs[i]
+		if len(gpu.VMs) > 0 && (gpu.AllocatedBytes == 0 ||
+			gpu.GraphicsType == string(types.HostGraphicsInfoGraphicsTypeDirect)) {
+			gpu.AllocatedBytes = gpu.MemoryBytes
+		}
+
+		sort.Strings(gpu.VMs)
+	}
+
+	sort.Slice(hga.GPUs, func(i, j int) bool {
+		return hga.GPUs[i].ID < hga.GPUs[j].ID
+	})
+
+	sort.Slice(hga.VMDevices, func(i, j int) bool {
+		if hga.VMDevices[i].VMName != hga.VMDevices[j].VMName {
+			return hga.VMDevices[i].VMName < hga.VMDevices[j].VMName
+		}
+
+		return hga.VMDevices[i].DeviceLabel < hga.VMDevices[j].DeviceLabel
+	})
+
+	return hga
+
+}
+
+// GetHostGPUAllocationSet retrieves and evaluates the graphics device
+// details for each given HostSystem along with the GPU devices configured
+// for VMs registered on each host. Recent GPU utilization samples are
+// retrieved for each host if available. HostSystems which are not connected
+// are flagged as unavailable and are not evaluated.
+func GetHostGPUAllocationSet(
+	ctx context.Context,
+	c *vim25.Client,
+	hss []mo.HostSystem,
+	thresholds HostGPUAllocationThresholds,
+) (HostGPUAllocationSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostGPUAllocationSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostGPUAllocationSet, 0, len(hss))
+
+	pc := property.DefaultCollector(c)
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping GPU evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostGPUAllocation{
+				Host:        hs,
+				Unavailable: true,
+				Thresholds:  thresholds,
+			})
+
+			continue
+		}
+
+		var hostConfig mo.HostSystem
+		err := pc.RetrieveOne(
+			ctx,
+			hs.Reference(),
+			[]string{"config.graphicsInfo"},
+			&hostConfig,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve graphics details for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		var graphicsInfo []types.HostGraphicsInfo
+		if hostConfig.Config != nil {
+			graphicsInfo = hostConfig.Config.GraphicsInfo
+		}
+
+		var vms []mo.VirtualMachine
+		if len(hs.Vm) > 0 {
+			err := pc.Retrieve(
+				ctx,
+				hs.Vm,
+				[]string{"name", "config.template", "config.hardware.device", "runtime.powerState"},
+				&vms,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to retrieve VM devices for host %s: %w",
+					hs.Name,
+					err,
+				)
+			}
+		}
+
+		set = append(set, NewHostGPUAllocation(hs, graphicsInfo, vms, thresholds))
+	}
+
+	entities := make([]types.ManagedObjectReference, 0, len(set))
+	for _, hga := range set {
+		if !hga.Unavailable && len(hga.GPUs) > 0 {
+			entities = append(entities, hga.Host.Reference())
+		}
+	}
+
+	if len(entities) == 0 {
+		return set, nil
+	}
+
+	counterIDs, err := GetPerfCounterIDs(ctx, c, PerfCounterGPUUtilization)
+	switch {
+	case errors.Is(err, ErrPerfCounterNotFound) || errors.Is(err, ErrPerformanceManagerUnavailable):
+		logger.Printf("GPU utilization counters unavailable; skipping utilization evaluation: %v", err)
+
+		return set, nil
+
+	case err != nil:
+		return nil, err
+	}
+	utilizationID := counterIDs[PerfCounterGPUUtilization]
+
+	samples, err := QueryRealtimePerfInstanceSamples(
+		ctx,
+		c,
+		entities,
+		[]int32{utilizationID},
+		GPUUtilizationSampleCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range set {
+		hostSamples, ok := samples[set[i].Host.Self.Value]
+		if !ok || len(hostSamples[utilizationID]) == 0 {
+			continue
+		}
+
+		set[i].Utilization = gpuUtilizationPercent(hostSamples[utilizationID])
+		set[i].UtilizationAvailable = true
+	}
+
+	return set, nil
+
+}
+
+// MemoryTotal returns the framebuffer memory capacity in bytes across all
+// GPUs on the HostSystem.
+func (hga HostGPUAllocation) MemoryTotal() int64 {
+	var total int64
+	for _, gpu := range hga.GPUs {
+		total += gpu.MemoryBytes
+	}
+
+	return total
+}
+
+// MemoryAllocated returns the framebuffer memory in bytes allocated to VMs
+// across all GPUs on the HostSystem.
+func (hga HostGPUAllocation) MemoryAllocated() int64 {
+	var allocated int64
+	for _, gpu := range hga.GPUs {
+		allocated += gpu.AllocatedBytes
+	}
+
+	return allocated
+}
+
+// MemoryUnallocated returns the framebuffer memory in bytes not allocated to
+// VMs across all GPUs on the HostSystem.
+func (hga HostGPUAllocation) MemoryUnallocated() int64 {
+	unallocated := hga.MemoryTotal() - hga.MemoryAllocated()
+	if unallocated < 0 {
+		return 0
+	}
+
+	return unallocated
+}
+
+// Allocation returns the percentage of GPU framebuffer memory allocated to
+// VMs on the HostSystem. If framebuffer capacity is not available the
+// percentage of GPUs in use is returned instead.
+func (hga HostGPUAllocation) Allocation() float64 {
+	if len(hga.GPUs) == 0 {
+		return 0
+	}
+
+	if total := hga.MemoryTotal(); total > 0 {
+		return float64(hga.MemoryAllocated()) / float64(total) * 100
+	}
+
+	return float64(hga.NumAssignedGPUs()) / float64(len(hga.GPUs)) * 100
+}
+
+// NumAssignedGPUs returns the number of GPUs on the HostSystem in use by one
+// or more VMs.
+func (hga HostGPUAllocation) NumAssignedGPUs() int {
+	var num int
+	for _, gpu := range hga.GPUs {
+		if len(gpu.VMs) > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumUnassignedGPUs returns the number of GPUs on the HostSystem not in use
+// by any VM.
+func (hga HostGPUAllocation) NumUnassignedGPUs() int {
+	return len(hga.GPUs) - hga.NumAssignedGPUs()
+}
+
+// NumVMs returns the number of VMs registered on the HostSystem with vGPU or
+// DirectPath I/O GPU devices.
+func (hga HostGPUAllocation) NumVMs() int {
+	vms := make(map[string]struct{}, len(hga.VMDevices))
+	for _, d := range hga.VMDevices {
+		vms[d.VMName] = struct{}{}
+	}
+
+	return len(vms)
+}
+
+// NumUnplacedVMDevices returns the number of VM GPU devices which are not
+// currently placed on a host GPU (e.g., VM powered off).
+func (hga HostGPUAllocation) NumUnplacedVMDevices() int {
+	var num int
+	for _, d := range hga.VMDevices {
+		if d.GPU == "" {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether GPU allocation or utilization has
+// crossed the CRITICAL level threshold.
+func (hga HostGPUAllocation) IsCriticalState() bool {
+	if hga.Unavailable || len(hga.GPUs) == 0 {
+		return false
+	}
+
+	return hga.Allocation() >= float64(hga.Thresholds.AllocationCritical) ||
+		(hga.UtilizationAvailable &&
+			hga.Utilization >= float64(hga.Thresholds.UtilizationCritical))
+}
+
+// IsWarningState indicates whether GPU allocation or utilization has
+// crossed the WARNING level threshold.
+func (hga HostGPUAllocation) IsWarningState() bool {
+	if hga.Unavailable || len(hga.GPUs) == 0 || hga.IsCriticalState() {
+		return false
+	}
+
+	return hga.Allocation() >= float64(hga.Thresholds.AllocationWarning) ||
+		(hga.UtilizationAvailable &&
+			hga.Utilization >= float64(hga.Thresholds.UtilizationWarning))
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem is in a
+// CRITICAL state.
+func (set HostGPUAllocationSet) HasCriticalState() bool {
+	for _, hga := range set {
+		if hga.IsCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated HostSystem is in a
+// WARNING state.
+func (set HostGPUAllocationSet) HasWarningState() bool {
+	for _, hga := range set {
+		if hga.IsWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose GPU details were
+// evaluated.
+func (set HostGPUAllocationSet) NumHostsEvaluated() int {
+	var num int
+	for _, hga := range set {
+		if !hga.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose GPU details
+// could not be evaluated.
+func (set HostGPUAllocationSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumHostsWithGPUs returns the number of evaluated HostSystems with GPUs
+// which may be allocated to VMs.
+func (set HostGPUAllocationSet) NumHostsWithGPUs() int {
+	var num int
+	for _, hga := range set {
+		if len(hga.GPUs) > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsWithIssues returns the number of HostSystems in a WARNING or
+// CRITICAL state.
+func (set HostGPUAllocationSet) NumHostsWithIssues() int {
+	var num int
+	for _, hga := range set {
+		if hga.IsCriticalState() || hga.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumGPUs returns the number of GPUs across all evaluated HostSystems.
+func (set HostGPUAllocationSet) NumGPUs() int {
+	var num int
+	for _, hga := range set {
+		num += len(hga.GPUs)
+	}
+
+	return num
+}
+
+// NumAssignedGPUs returns the number of GPUs in use by one or more VMs
+// across all evaluated HostSystems.
+func (set HostGPUAllocationSet) NumAssignedGPUs() int {
+	var num int
+	for _, hga := range set {
+		num += hga.NumAssignedGPUs()
+	}
+
+	return num
+}
+
+// NumUnassignedGPUs returns the number of GPUs not in use by any VM across
+// all evaluated HostSystems.
+func (set HostGPUAllocationSet) NumUnassignedGPUs() int {
+	return set.NumGPUs() - set.NumAssignedGPUs()
+}
+
+// MemoryTotal returns the GPU framebuffer memory capacity in bytes across
+// all evaluated HostSystems.
+func (set HostGPUAllocationSet) MemoryTotal() int64 {
+	var total int64
+	for _, hga := range set {
+		total += hga.MemoryTotal()
+	}
+
+	return total
+}
+
+// MemoryAllocated returns the GPU framebuffer memory in bytes allocated to
+// VMs across all evaluated HostSystems.
+func (set HostGPUAllocationSet) MemoryAllocated() int64 {
+	var allocated int64
+	for _, hga := range set {
+		allocated += hga.MemoryAllocated()
+	}
+
+	return allocated
+}
+
+// MemoryUnallocated returns the GPU framebuffer memory in bytes not
+// allocated to VMs across all evaluated HostSystems.
+func (set HostGPUAllocationSet) MemoryUnallocated() int64 {
+	var unallocated int64
+	for _, hga := range set {
+		unallocated += hga.MemoryUnallocated()
+	}
+
+	return unallocated
+}
+
+// MaxAllocation returns the highest GPU allocation percentage of any
+// evaluated HostSystem.
+func (set HostGPUAllocationSet) MaxAllocation() float64 {
+	var highest float64
+	for _, hga := range set {
+		if allocation := hga.Allocation(); allocation > highest {
+			highest = allocation
+		}
+	}
+
+	return highest
+}
+
+// MaxUtilization returns the highest GPU utilization percentage of any
+// evaluated HostSystem.
+func (set HostGPUAllocationSet) MaxUtilization() float64 {
+	var highest float64
+	for _, hga := range set {
+		if hga.UtilizationAvailable && hga.Utilization > highest {
+			highest = hga.Utilization
+		}
+	}
+
+	return highest
+}
+
+// NumVMs returns the number of VMs with vGPU or DirectPath I/O GPU devices
+// across all evaluated HostSystems.
+func (set HostGPUAllocationSet) NumVMs() int {
+	var num int
+	for _, hga := range set {
+		num += hga.NumVMs()
+	}
+
+	return num
+}
+
+// NumUnplacedVMDevices returns the number of VM GPU devices not currently
+// placed on a host GPU across all evaluated HostSystems.
+func (set HostGPUAllocationSet) NumUnplacedVMDevices() int {
+	var num int
+	for _, hga := range set {
+		num += hga.NumUnplacedVMDevices()
+	}
+
+	return num
+}
+
+// HostGPUAllocationPerfData generates performance data metrics from the
+// given collection of evaluated HostSystems. If multiple HostSystems are
+// evaluated, allocation and unassigned capacity metrics are also emitted per
+// HostSystem with GPUs.
+func HostGPUAllocationPerfData(
+	set HostGPUAllocationSet,
+	thresholds HostGPUAllocationThresholds,
+) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_with_gpus",
+			Value: fmt.Sprintf("%d", set.NumHostsWithGPUs()),
+			Min:   "0",
+		},
+		{
+			Label: "gpus_total",
+			Value: fmt.Sprintf("%d", set.NumGPUs()),
+			Min:   "0",
+		},
+		{
+			Label: "gpus_assigned",
+			Value: fmt.Sprintf("%d", set.NumAssignedGPUs()),
+			Min:   "0",
+		},
+		{
+			Label: "gpus_unassigned",
+			Value: fmt.Sprintf("%d", set.NumUnassignedGPUs()),
+			Min:   "0",
+		},
+		{
+			Label:             "gpu_memory_total",
+			Value:             fmt.Sprintf("%d", set.MemoryTotal()),
+			UnitOfMeasurement: "B",
+			Min:               "0",
+		},
+		{
+			Label:             "gpu_memory_allocated",
+			Value:             fmt.Sprintf("%d", set.MemoryAllocated()),
+			UnitOfMeasurement: "B",
+			Min:               "0",
+		},
+		{
+			Label:             "gpu_memory_unallocated",
+			Value:             fmt.Sprintf("%d", set.MemoryUnallocated()),
+			UnitOfMeasurement: "B",
+			Min:               "0",
+		},
+		{
+			Label:             "gpu_allocation_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxAllocation()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", thresholds.AllocationWarning),
+			Crit:              fmt.Sprintf("%d", thresholds.AllocationCritical),
+			Min:               "0",
+			Max:               "100",
+		},
+		{
+			Label:             "gpu_utilization_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxUtilization()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", thresholds.UtilizationWarning),
+			Crit:              fmt.Sprintf("%d", thresholds.UtilizationCritical),
+			Min:               "0",
+			Max:               "100",
+		},
+		{
+			Label: "vms_with_gpus",
+			Value: fmt.Sprintf("%d", set.NumVMs()),
+			Min:   "0",
+		},
+		{
+			Label: "vm_gpu_devices_unplaced",
+			Value: fmt.Sprintf("%d", set.NumUnplacedVMDevices()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) > 1 {
+		for _, hga := range set {
+			if hga.Unavailable || len(hga.GPUs) == 0 {
+				continue
+			}
+
+			pd = append(pd,
+				nagios.PerformanceData{
+					Label:             PerfDataLabel(hga.Host.Name, "gpu_allocation"),
+					Value:             fmt.Sprintf("%.2f", hga.Allocation()),
+					UnitOfMeasurement: "%",
+					Min:               "0",
+					Max:               "100",
+				},
+				nagios.PerformanceData{
+					Label: PerfDataLabel(hga.Host.Name, "gpus_unassigned"),
+					Value: fmt.Sprintf("%d", hga.NumUnassignedGPUs()),
+					Min:   "0",
+				},
+				nagios.PerformanceData{
+					Label:             PerfDataLabel(hga.Host.Name, "gpu_memory_unallocated"),
+					Value:             fmt.Sprintf("%d", hga.MemoryUnallocated()),
+					UnitOfMeasurement: "B",
+					Min:               "0",
+				},
+			)
+		}
+	}
+
+	return pd
+
+}
+
+// HostGPUAllocationOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostGPUAllocationOneLineCheckSummary(
+	stateLabel string,
+	set HostGPUAllocationSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostGPUAllocationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with GPU allocation or utilization above thresholds (%d of %d GPUs assigned, %s unallocated, evaluated %d hosts)",
+			stateLabel,
+			set.NumHostsWithIssues(),
+			set.NumAssignedGPUs(),
+			set.NumGPUs(),
+			units.ByteSize(set.MemoryUnallocated()),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts with GPU allocation or utilization above thresholds (%d of %d GPUs assigned, %s unallocated, evaluated %d hosts)",
+			stateLabel,
+			set.NumAssignedGPUs(),
+			set.NumGPUs(),
+			units.ByteSize(set.MemoryUnallocated()),
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostGPUAllocationReport generates a summary of GPU allocation and
+// utilization for evaluated HostSystems and VMs along with various verbose
+// details intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func HostGPUAllocationReport(
+	c *vim25.Client,
+	set HostGPUAllocationSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostGPUAllocationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with GPU allocation or utilization above thresholds:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	utilization := func(hga HostGPUAllocation) string {
+		if !hga.UtilizationAvailable {
+			return "unavailable"
+		}
+
+		return fmt.Sprintf("%.2f%%", hga.Utilization)
+	}
+
+	var numProblemHosts int
+	for _, hga := range set {
+		if !hga.IsCriticalState() && !hga.IsWarningState() {
+			continue
+		}
+		numProblemHosts++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (allocation: %.2f%%, utilization: %s)%s",
+			hga.Host.Name,
+			hga.Allocation(),
+			utilization(hga),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sGPU allocation per host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numHostsListed int
+	for _, hga := range set {
+		if hga.Unavailable {
+			numHostsListed++
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: unavailable (connection state: %s)%s",
+				hga.Host.Name,
+				hga.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		if len(hga.GPUs) == 0 && len(hga.VMDevices) == 0 {
+			continue
+		}
+		numHostsListed++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %d of %d GPUs assigned, %s of %s allocated (%.2f%%), %s unallocated, utilization: %s%s",
+			hga.Host.Name,
+			hga.NumAssignedGPUs(),
+			len(hga.GPUs),
+			units.ByteSize(hga.MemoryAllocated()),
+			units.ByteSize(hga.MemoryTotal()),
+			hga.Allocation(),
+			units.ByteSize(hga.MemoryUnallocated()),
+			utilization(hga),
+			nagios.CheckOutputEOL,
+		)
+
+		for _, gpu := range hga.GPUs {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** GPU %s (%s %s, %s): %s of %s allocated, %d VMs%s",
+				gpu.ID,
+				gpu.Vendor,
+				gpu.Name,
+				gpu.GraphicsType,
+				units.ByteSize(gpu.AllocatedBytes),
+				units.ByteSize(gpu.MemoryBytes),
+				len(gpu.VMs),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numHostsListed == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sGPU allocation per VM:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numVMDevicesListed int
+	for _, hga := range set {
+		for _, d := range hga.VMDevices {
+			numVMDevicesListed++
+
+			profile := d.Profile
+			if profile == "" {
+				profile = "DirectPath I/O"
+			}
+
+			gpu := d.GPU
+			if gpu == "" {
+				gpu = "not placed"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (%s, %s): %s, %s on %s (GPU: %s)%s",
+				d.VMName,
+				d.PowerState,
+				d.DeviceLabel,
+				profile,
+				units.ByteSize(d.MemoryBytes),
+				hga.Host.Name,
+				gpu,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numVMDevicesListed == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
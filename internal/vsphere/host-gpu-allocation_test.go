@@ -0,0 +1,330 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// gpuMemoryKB is the framebuffer memory capacity (16 GB) of each test GPU in
+// KB.
+const gpuMemoryKB int64 = 16 * 1024 * 1024
+
+// gpuTestVM describes a VM with a single vGPU or DirectPath I/O device.
+type gpuTestVM struct {
+	id      string
+	profile string
+
+	// passthrough is the PCI ID of the DirectPath I/O device; used instead
+	// of a vGPU profile if set.
+	passthrough string
+
+	// gpu is the PCI ID of the host GPU used by the VM; empty if the VM is
+	// powered off.
+	gpu string
+}
+
+// gpuTestAllocation evaluates a host with two shared GPUs, one direct GPU
+// and one basic graphics device against the given VMs.
+func gpuTestAllocation(vms []gpuTestVM, thresholds HostGPUAllocationThresholds) HostGPUAllocation {
+	hs := mo.HostSystem{ManagedEntity: mo.ManagedEntity{Name: "esx1"}}
+
+	graphicsInfo := []types.HostGraphicsInfo{
+		{
+			PciId:          "0000:AF:00.0",
+			DeviceName:     "Tesla T4",
+			GraphicsType:   string(types.HostGraphicsInfoGraphicsTypeSharedDirect),
+			MemorySizeInKB: gpuMemoryKB,
+		},
+		{
+			PciId:          "0000:3b:00.0",
+			DeviceName:     "Tesla T4",
+			GraphicsType:   string(types.HostGraphicsInfoGraphicsTypeSharedDirect),
+			MemorySizeInKB: gpuMemoryKB,
+		},
+		{
+			PciId:          "0000:d8:00.0",
+			DeviceName:     "Tesla T4",
+			GraphicsType:   string(types.HostGraphicsInfoGraphicsTypeDirect),
+			MemorySizeInKB: gpuMemoryKB,
+		},
+		{
+			PciId:        "0000:03:00.0",
+			DeviceName:   "Matrox G200eR2",
+			GraphicsType: string(types.HostGraphicsInfoGraphicsTypeBasic),
+		},
+	}
+
+	moVMs := make([]mo.VirtualMachine, 0, len(vms))
+	for _, v := range vms {
+		vm := mo.VirtualMachine{
+			ManagedEntity: mo.ManagedEntity{
+				ExtensibleManagedObject: mo.ExtensibleManagedObject{
+					Self: types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: v.id},
+				},
+				Name: v.id,
+			},
+			Config: &types.VirtualMachineConfigInfo{},
+		}
+
+		pci := types.VirtualPCIPassthrough{}
+		switch {
+		case v.passthrough != "":
+			pci.Backing = &types.VirtualPCIPassthroughDeviceBackingInfo{Id: v.passthrough}
+		default:
+			pci.Backing = &types.VirtualPCIPassthroughVmiopBackingInfo{Vgpu: v.profile}
+		}
+		vm.Config.Hardware.Device = append(vm.Config.Hardware.Device, &pci)
+
+		for i := range graphicsInfo {
+			if normalizePCIID(graphicsInfo[i].PciId) == v.gpu {
+				graphicsInfo[i].Vm = append(graphicsInfo[i].Vm, vm.Self)
+			}
+		}
+
+		moVMs = append(moVMs, vm)
+	}
+
+	return NewHostGPUAllocation(hs, graphicsInfo, moVMs, thresholds)
+}
+
+func TestVGPUProfileMemory(t *testing.T) {
+	tests := map[string]struct {
+		profile string
+		want    int64
+	}{
+		"quadro profile":        {profile: "grid_t4-4q", want: 4 * units.GB},
+		"compute profile":       {profile: "grid_a100-40c", want: 40 * units.GB},
+		"upper case profile":    {profile: "GRID_T4-16Q", want: 16 * units.GB},
+		"multi-letter suffix":   {profile: "grid_a100d-3-20c", want: 20 * units.GB},
+		"unrecognized profile":  {profile: "custom"},
+		"missing memory suffix": {profile: "grid_t4-q"},
+		"empty profile":         {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := vgpuProfileMemory(tt.profile); got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGPUUtilizationPercent(t *testing.T) {
+	tests := map[string]struct {
+		instances map[string][]int64
+		want      float64
+	}{
+		"no instances": {},
+		"single GPU": {
+			instances: map[string][]int64{"gpu0": {5000, 7000, 6000}},
+			want:      60,
+		},
+		"highest average of multiple GPUs": {
+			instances: map[string][]int64{
+				"gpu0": {1000, 2000},
+				"gpu1": {9000, 8000},
+			},
+			want: 85,
+		},
+		"GPU without samples ignored": {
+			instances: map[string][]int64{
+				"gpu0": {},
+				"gpu1": {2500},
+			},
+			want: 25,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := gpuUtilizationPercent(tt.instances); got != tt.want {
+				t.Errorf("want %.2f; got %.2f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewHostGPUAllocation(t *testing.T) {
+	thresholds := HostGPUAllocationThresholds{
+		AllocationWarning:   50,
+		AllocationCritical:  90,
+		UtilizationWarning:  70,
+		UtilizationCritical: 90,
+	}
+
+	const (
+		gpu1 string = "0000:3b:00.0"
+		gpu2 string = "0000:af:00.0"
+		gpu3 string = "0000:d8:00.0"
+	)
+
+	tests := map[string]struct {
+		vms            []gpuTestVM
+		wantAllocated  map[string]int64
+		wantAllocation float64
+		wantUnplaced   int
+		wantCritical   bool
+		wantWarning    bool
+	}{
+		"no VMs": {
+			wantAllocated: map[string]int64{},
+		},
+		"vGPU profiles allocated per GPU": {
+			vms: []gpuTestVM{
+				{id: "vm-1", profile: "grid_t4-4q", gpu: gpu1},
+				{id: "vm-2", profile: "grid_t4-8q", gpu: gpu1},
+				{id: "vm-3", profile: "grid_t4-8q", gpu: gpu2},
+			},
+			wantAllocated:  map[string]int64{gpu1: 12 * units.GB, gpu2: 8 * units.GB},
+			wantAllocation: 20 * 100 / 48.0,
+		},
+		"powered off VMs are not allocated": {
+			vms: []gpuTestVM{
+				{id: "vm-1", profile: "grid_t4-4q", gpu: gpu1},
+				{id: "vm-2", profile: "grid_t4-16q"},
+			},
+			wantAllocated:  map[string]int64{gpu1: 4 * units.GB},
+			wantAllocation: 4 * 100 / 48.0,
+			wantUnplaced:   1,
+		},
+		"unrecognized vGPU profile fully allocates GPU": {
+			vms: []gpuTestVM{
+				{id: "vm-1", profile: "custom", gpu: gpu1},
+			},
+			wantAllocated:  map[string]int64{gpu1: 16 * units.GB},
+			wantAllocation: 16 * 100 / 48.0,
+		},
+		"DirectPath I/O GPU fully allocated": {
+			vms: []gpuTestVM{
+				{id: "vm-1", passthrough: "0000:D8:00.0", gpu: gpu3},
+				{id: "vm-2", profile: "grid_t4-8q", gpu: gpu1},
+			},
+			wantAllocated:  map[string]int64{gpu1: 8 * units.GB, gpu3: 16 * units.GB},
+			wantAllocation: 50,
+			wantWarning:    true,
+		},
+		"DirectPath I/O device which is not a GPU ignored": {
+			vms: []gpuTestVM{
+				{id: "vm-1", passthrough: "0000:5e:00.0"},
+			},
+			wantAllocated: map[string]int64{},
+		},
+		"allocation at CRITICAL threshold": {
+			vms: []gpuTestVM{
+				{id: "vm-1", profile: "grid_t4-16q", gpu: gpu1},
+				{id: "vm-2", profile: "grid_t4-16q", gpu: gpu2},
+				{id: "vm-3", passthrough: gpu3, gpu: gpu3},
+			},
+			wantAllocated:  map[string]int64{gpu1: 16 * units.GB, gpu2: 16 * units.GB, gpu3: 16 * units.GB},
+			wantAllocation: 100,
+			wantCritical:   true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hga := gpuTestAllocation(tt.vms, thresholds)
+
+			ids := make([]string, 0, len(hga.GPUs))
+			allocated := make(map[string]int64)
+			for _, gpu := range hga.GPUs {
+				ids = append(ids, gpu.ID)
+				if gpu.AllocatedBytes > 0 {
+					allocated[gpu.ID] = gpu.AllocatedBytes
+				}
+			}
+
+			// Basic graphics devices are not evaluated and PCI IDs are
+			// normalized and sorted.
+			if d := cmp.Diff([]string{gpu1, gpu2, gpu3}, ids); d != "" {
+				t.Errorf("GPU IDs (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantAllocated, allocated); d != "" {
+				t.Errorf("allocated bytes (-want, +got):\n%s", d)
+			}
+
+			if got := hga.Allocation(); math.Abs(got-tt.wantAllocation) > 0.01 {
+				t.Errorf("want allocation %.2f%%; got %.2f%%", tt.wantAllocation, got)
+			}
+
+			if got := hga.NumUnplacedVMDevices(); got != tt.wantUnplaced {
+				t.Errorf("want %d unplaced VM devices; got %d", tt.wantUnplaced, got)
+			}
+
+			if got := hga.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hga.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostGPUAllocationUtilizationState(t *testing.T) {
+	thresholds := HostGPUAllocationThresholds{
+		AllocationWarning:   50,
+		AllocationCritical:  90,
+		UtilizationWarning:  70,
+		UtilizationCritical: 90,
+	}
+
+	tests := map[string]struct {
+		utilization  float64
+		available    bool
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"not available":                 {utilization: 95},
+		"below WARNING threshold":       {utilization: 69, available: true},
+		"utilization at WARNING level":  {utilization: 70, available: true, wantWarning: true},
+		"utilization at CRITICAL level": {utilization: 90, available: true, wantCritical: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hga := gpuTestAllocation([]gpuTestVM{{id: "vm-1", profile: "grid_t4-4q", gpu: "0000:3b:00.0"}}, thresholds)
+			hga.Utilization = tt.utilization
+			hga.UtilizationAvailable = tt.available
+
+			if got := hga.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hga.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostGPUAllocationUnavailable(t *testing.T) {
+	hga := HostGPUAllocation{
+		GPUs: []HostGPUDevice{
+			{ID: "0000:3b:00.0", MemoryBytes: 1, AllocatedBytes: 1, VMs: []string{"vm-1"}},
+		},
+		Unavailable: true,
+	}
+
+	set := HostGPUAllocationSet{hga}
+
+	if set.HasCriticalState() || set.HasWarningState() {
+		t.Errorf("want unavailable host to be OK; got CRITICAL %t, WARNING %t",
+			set.HasCriticalState(), set.HasWarningState())
+	}
+}
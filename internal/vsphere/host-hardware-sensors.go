@@ -0,0 +1,608 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostHardwareSensorsUnhealthy indicates that one or more ESXi host
+// hardware sensors are reporting a non-healthy state.
+var ErrHostHardwareSensorsUnhealthy = errors.New("host hardware sensors report non-healthy state")
+
+// Hardware sensor health states as reported by the host health system.
+const (
+	HostHardwareSensorStateGreen   string = "green"
+	HostHardwareSensorStateYellow  string = "yellow"
+	HostHardwareSensorStateRed     string = "red"
+	HostHardwareSensorStateUnknown string = "unknown"
+)
+
+// hostHardwareSensorTypeTemperature is the numeric sensor type used for
+// temperature sensors.
+const hostHardwareSensorTypeTemperature string = "temperature"
+
+// HostHardwareSensor represents a numeric sensor or hardware status element
+// reported by the health system of an ESXi host.
+type HostHardwareSensor struct {
+	// Name is the name of the sensor or hardware element.
+	Name string
+
+	// Type is the numeric sensor type (e.g., fan, temperature) or the
+	// hardware element category (e.g., cpu, memory, storage).
+	Type string
+
+	// State is the health state of the sensor (green, yellow, red or
+	// unknown).
+	State string
+
+	// Reading is the current reading for a numeric sensor, adjusted by the
+	// unit modifier.
+	Reading float64
+
+	// Units is the unit of measurement for the current reading. This is
+	// empty for hardware status elements.
+	Units string
+
+	// Numeric indicates whether the sensor is a numeric sensor with a
+	// current reading.
+	Numeric bool
+}
+
+// HostHardwareSensors tracks the hardware sensors for a specific HostSystem.
+type HostHardwareSensors struct {
+	// Host is the HostSystem that the sensors were retrieved from.
+	Host mo.HostSystem
+
+	// Sensors is the collection of sensors evaluated after applying the
+	// sensor name filters.
+	Sensors []HostHardwareSensor
+
+	// Excluded is the number of sensors excluded from evaluation by the
+	// sensor name filters.
+	Excluded int
+
+	// Unavailable indicates whether sensor details could not be retrieved
+	// for the HostSystem due to its connection state or missing health
+	// system details.
+	Unavailable bool
+}
+
+// HostHardwareSensorsSet is a collection of HostHardwareSensors values.
+type HostHardwareSensorsSet []HostHardwareSensors
+
+// hostHardwareSensorState returns the normalized health state from the
+// given element description.
+func hostHardwareSensorState(desc types.BaseElementDescription) string {
+	if desc == nil {
+		return HostHardwareSensorStateUnknown
+	}
+
+	state := strings.ToLower(desc.GetElementDescription().Key)
+	switch state {
+	case HostHardwareSensorStateGreen,
+		HostHardwareSensorStateYellow,
+		HostHardwareSensorStateRed:
+		return state
+	default:
+		return HostHardwareSensorStateUnknown
+	}
+}
+
+// hostHardwareSensorMatches indicates whether the given sensor name
+// case-insensitively contains any of the specified substrings.
+func hostHardwareSensorMatches(name string, substrings []string) bool {
+	for _, substr := range substrings {
+		if strings.Contains(strings.ToLower(name), strings.ToLower(substr)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewHostHardwareSensors receives a HostSystem and evaluates the numeric
+// sensor and hardware status details reported by the host health system.
+// Sensors are limited to those whose name matches one of the included
+// substrings (if specified) and does not match any of the excluded
+// substrings. Explicit exclusions have precedence over explicit inclusions.
+func NewHostHardwareSensors(hs mo.HostSystem, include []string, exclude []string) HostHardwareSensors {
+
+	hhs := HostHardwareSensors{
+		Host: hs,
+	}
+
+	if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected ||
+		hs.Runtime.HealthSystemRuntime == nil {
+		logger.Printf(
+			"host %s connection state is %s or health system details unavailable; skipping sensor evaluation",
+			hs.Name,
+			hs.Runtime.ConnectionState,
+		)
+
+		hhs.Unavailable = true
+
+		return hhs
+	}
+
+	var sensors []HostHardwareSensor
+
+	health := hs.Runtime.HealthSystemRuntime
+
+	if health.SystemHealthInfo != nil {
+		for _, sensor := range health.SystemHealthInfo.NumericSensorInfo {
+			units := sensor.BaseUnits
+			if sensor.RateUnits != "" {
+				units += "/" + sensor.RateUnits
+			}
+
+			sensors = append(sensors, HostHardwareSensor{
+				Name:    sensor.Name,
+				Type:    sensor.SensorType,
+				State:   hostHardwareSensorState(sensor.HealthState),
+				Reading: float64(sensor.CurrentReading) * math.Pow10(int(sensor.UnitModifier)),
+				Units:   units,
+				Numeric: true,
+			})
+		}
+	}
+
+	if health.HardwareStatusInfo != nil {
+		addElement := func(elementType string, element types.HostHardwareElementInfo) {
+			sensors = append(sensors, HostHardwareSensor{
+				Name:  element.Name,
+				Type:  elementType,
+				State: hostHardwareSensorState(element.Status),
+			})
+		}
+
+		for _, element := range health.HardwareStatusInfo.CpuStatusInfo {
+			addElement("cpu", *element.GetHostHardwareElementInfo())
+		}
+
+		for _, element := range health.HardwareStatusInfo.MemoryStatusInfo {
+			addElement("memory", *element.GetHostHardwareElementInfo())
+		}
+
+		for _, element := range health.HardwareStatusInfo.StorageStatusInfo {
+			addElement("storage", element.HostHardwareElementInfo)
+		}
+	}
+
+	for _, sensor := range sensors {
+		switch {
+		case hostHardwareSensorMatches(sensor.Name, exclude):
+			hhs.Excluded++
+
+		case len(include) > 0 && !hostHardwareSensorMatches(sensor.Name, include):
+			hhs.Excluded++
+
+		default:
+			hhs.Sensors = append(hhs.Sensors, sensor)
+		}
+	}
+
+	return hhs
+
+}
+
+// NewHostHardwareSensorsSet evaluates the hardware sensors for each of the
+// given HostSystems using the specified sensor name filters.
+func NewHostHardwareSensorsSet(hss []mo.HostSystem, include []string, exclude []string) HostHardwareSensorsSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewHostHardwareSensorsSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostHardwareSensorsSet, 0, len(hss))
+	for _, hs := range hss {
+		set = append(set, NewHostHardwareSensors(hs, include, exclude))
+	}
+
+	return set
+
+}
+
+// IsCriticalState indicates whether the sensor is reporting a red health
+// state.
+func (s HostHardwareSensor) IsCriticalState() bool {
+	return s.State == HostHardwareSensorStateRed
+}
+
+// IsWarningState indicates whether the sensor is reporting a yellow health
+// state.
+func (s HostHardwareSensor) IsWarningState() bool {
+	return s.State == HostHardwareSensorStateYellow
+}
+
+// ReadingString returns the current reading along with the unit of
+// measurement for numeric sensors. An empty string is returned for hardware
+// status elements.
+func (s HostHardwareSensor) ReadingString() string {
+	if !s.Numeric {
+		return ""
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", s.Reading, s.Units))
+}
+
+// numSensorsInState returns the number of evaluated sensors in the given
+// health state.
+func (hhs HostHardwareSensors) numSensorsInState(state string) int {
+	var num int
+	for _, sensor := range hhs.Sensors {
+		if sensor.State == state {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated sensor for the HostSystem
+// is reporting a red health state.
+func (hhs HostHardwareSensors) HasCriticalState() bool {
+	return hhs.numSensorsInState(HostHardwareSensorStateRed) > 0
+}
+
+// HasWarningState indicates whether any evaluated sensor for the HostSystem
+// is reporting a yellow health state.
+func (hhs HostHardwareSensors) HasWarningState() bool {
+	return hhs.numSensorsInState(HostHardwareSensorStateYellow) > 0
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem has sensors
+// reporting a red health state.
+func (set HostHardwareSensorsSet) HasCriticalState() bool {
+	return set.NumSensorsCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has sensors
+// reporting a yellow health state.
+func (set HostHardwareSensorsSet) HasWarningState() bool {
+	return set.NumSensorsWarning() > 0
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose sensors were
+// evaluated.
+func (set HostHardwareSensorsSet) NumHostsEvaluated() int {
+	var num int
+	for _, hhs := range set {
+		if !hhs.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose sensors could
+// not be evaluated.
+func (set HostHardwareSensorsSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// numSensorsInState returns the number of evaluated sensors in the given
+// health state across all evaluated HostSystems.
+func (set HostHardwareSensorsSet) numSensorsInState(state string) int {
+	var num int
+	for _, hhs := range set {
+		num += hhs.numSensorsInState(state)
+	}
+
+	return num
+}
+
+// NumSensors returns the number of sensors evaluated across all HostSystems.
+func (set HostHardwareSensorsSet) NumSensors() int {
+	var num int
+	for _, hhs := range set {
+		num += len(hhs.Sensors)
+	}
+
+	return num
+}
+
+// NumSensorsCritical returns the number of sensors reporting a red health
+// state across all evaluated HostSystems.
+func (set HostHardwareSensorsSet) NumSensorsCritical() int {
+	return set.numSensorsInState(HostHardwareSensorStateRed)
+}
+
+// NumSensorsWarning returns the number of sensors reporting a yellow health
+// state across all evaluated HostSystems.
+func (set HostHardwareSensorsSet) NumSensorsWarning() int {
+	return set.numSensorsInState(HostHardwareSensorStateYellow)
+}
+
+// NumSensorsUnknown returns the number of sensors reporting an unknown
+// health state across all evaluated HostSystems.
+func (set HostHardwareSensorsSet) NumSensorsUnknown() int {
+	return set.numSensorsInState(HostHardwareSensorStateUnknown)
+}
+
+// NumSensorsExcluded returns the number of sensors excluded from evaluation
+// by the sensor name filters across all evaluated HostSystems.
+func (set HostHardwareSensorsSet) NumSensorsExcluded() int {
+	var num int
+	for _, hhs := range set {
+		num += hhs.Excluded
+	}
+
+	return num
+}
+
+// HostHardwareSensorsPerfData generates performance data metrics from the
+// given collection of evaluated HostSystem sensors. If a single HostSystem
+// is evaluated, temperature sensor readings are also emitted.
+func HostHardwareSensorsPerfData(set HostHardwareSensorsSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "sensors",
+			Value: fmt.Sprintf("%d", set.NumSensors()),
+			Min:   "0",
+		},
+		{
+			Label: "sensors_critical",
+			Value: fmt.Sprintf("%d", set.NumSensorsCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "sensors_warning",
+			Value: fmt.Sprintf("%d", set.NumSensorsWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "sensors_unknown",
+			Value: fmt.Sprintf("%d", set.NumSensorsUnknown()),
+			Min:   "0",
+		},
+		{
+			Label: "sensors_excluded",
+			Value: fmt.Sprintf("%d", set.NumSensorsExcluded()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) == 1 {
+		for _, sensor := range set[0].Sensors {
+			if !sensor.Numeric || sensor.Type != hostHardwareSensorTypeTemperature {
+				continue
+			}
+
+			pd = append(pd, nagios.PerformanceData{
+				Label: PerfDataLabel(sensor.Name, hostHardwareSensorTypeTemperature),
+				Value: fmt.Sprintf("%.2f", sensor.Reading),
+			})
+		}
+	}
+
+	return pd
+
+}
+
+// HostHardwareSensorsOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func HostHardwareSensorsOneLineCheckSummary(
+	stateLabel string,
+	set HostHardwareSensorsSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostHardwareSensorsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d sensors in red state and %d sensors in yellow state (evaluated %d sensors, %d hosts)",
+			stateLabel,
+			set.NumSensorsCritical(),
+			set.NumSensorsWarning(),
+			set.NumSensors(),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hardware sensor issues detected (evaluated %d sensors, %d hosts)",
+			stateLabel,
+			set.NumSensors(),
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostHardwareSensorsReport generates a summary of hardware sensor health
+// for evaluated HostSystems along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body
+// of many notifications.
+func HostHardwareSensorsReport(
+	c *vim25.Client,
+	set HostHardwareSensorsSet,
+	include []string,
+	exclude []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostHardwareSensorsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with hardware sensor issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, hhs := range set {
+		if !hhs.HasCriticalState() && !hhs.HasWarningState() {
+			continue
+		}
+		numProblemHosts++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			hhs.Host.Name,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, sensor := range hhs.Sensors {
+			if !sensor.IsCriticalState() && !sensor.IsWarningState() {
+				continue
+			}
+
+			reading := sensor.ReadingString()
+			if reading != "" {
+				reading = ", reading: " + reading
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (type: %s, state: %s%s)%s",
+				sensor.Name,
+				sensor.Type,
+				sensor.State,
+				reading,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sSensor status per host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, hhs := range set {
+		if hhs.Unavailable {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: unavailable (connection state: %s)%s",
+				hhs.Host.Name,
+				hhs.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %d sensors (%d green, %d yellow, %d red, %d unknown, %d excluded)%s",
+			hhs.Host.Name,
+			len(hhs.Sensors),
+			hhs.numSensorsInState(HostHardwareSensorStateGreen),
+			hhs.numSensorsInState(HostHardwareSensorStateYellow),
+			hhs.numSensorsInState(HostHardwareSensorStateRed),
+			hhs.numSensorsInState(HostHardwareSensorStateUnknown),
+			hhs.Excluded,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Sensors included: %v%s",
+		include,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Sensors excluded: %v%s",
+		exclude,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,244 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// sensorState returns an element description for the given health state key.
+func sensorState(key string) types.BaseElementDescription {
+	return &types.ElementDescription{
+		Description: types.Description{Label: key},
+		Key:         key,
+	}
+}
+
+// sensorsHost returns a connected host reporting a fan and temperature
+// sensor along with CPU and memory hardware status elements in the given
+// health states.
+func sensorsHost(fan, temperature, cpu, memory string) mo.HostSystem {
+	var hs mo.HostSystem
+	hs.Name = "esx1"
+	hs.Runtime.ConnectionState = types.HostSystemConnectionStateConnected
+	hs.Runtime.HealthSystemRuntime = &types.HealthSystemRuntime{
+		SystemHealthInfo: &types.HostSystemHealthInfo{
+			NumericSensorInfo: []types.HostNumericSensorInfo{
+				{
+					Name:           "Fan Device 1 System Fan 1",
+					SensorType:     "fan",
+					HealthState:    sensorState(fan),
+					CurrentReading: 540000,
+					UnitModifier:   -2,
+					BaseUnits:      "RPM",
+				},
+				{
+					Name:           "System Board 1 Inlet Temp",
+					SensorType:     "temperature",
+					HealthState:    sensorState(temperature),
+					CurrentReading: 2300,
+					UnitModifier:   -2,
+					BaseUnits:      "Degrees C",
+				},
+			},
+		},
+		HardwareStatusInfo: &types.HostHardwareStatusInfo{
+			CpuStatusInfo: []types.BaseHostHardwareElementInfo{
+				&types.HostHardwareElementInfo{Name: "CPU socket #0", Status: sensorState(cpu)},
+			},
+			MemoryStatusInfo: []types.BaseHostHardwareElementInfo{
+				&types.HostHardwareElementInfo{Name: "Memory DIMM A1", Status: sensorState(memory)},
+			},
+		},
+	}
+
+	return hs
+}
+
+func TestHostHardwareSensorState(t *testing.T) {
+	tests := map[string]struct {
+		desc types.BaseElementDescription
+		want string
+	}{
+		"green":         {desc: sensorState("green"), want: HostHardwareSensorStateGreen},
+		"yellow":        {desc: sensorState("Yellow"), want: HostHardwareSensorStateYellow},
+		"red uppercase": {desc: sensorState("RED"), want: HostHardwareSensorStateRed},
+		"unrecognized":  {desc: sensorState("gray"), want: HostHardwareSensorStateUnknown},
+		"not reported":  {want: HostHardwareSensorStateUnknown},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := hostHardwareSensorState(tt.desc); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewHostHardwareSensors(t *testing.T) {
+	green := HostHardwareSensorStateGreen
+	yellow := HostHardwareSensorStateYellow
+	red := HostHardwareSensorStateRed
+
+	tests := map[string]struct {
+		hs           mo.HostSystem
+		include      []string
+		exclude      []string
+		wantSensors  []string
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"all sensors healthy": {
+			hs: sensorsHost(green, green, green, green),
+			wantSensors: []string{
+				"Fan Device 1 System Fan 1",
+				"System Board 1 Inlet Temp",
+				"CPU socket #0",
+				"Memory DIMM A1",
+			},
+		},
+		"numeric sensor WARNING state": {
+			hs:          sensorsHost(yellow, green, green, green),
+			include:     []string{"fan"},
+			wantSensors: []string{"Fan Device 1 System Fan 1"},
+			wantWarning: true,
+		},
+		"hardware element CRITICAL state": {
+			hs:           sensorsHost(green, green, green, red),
+			include:      []string{"DIMM"},
+			wantSensors:  []string{"Memory DIMM A1"},
+			wantCritical: true,
+		},
+		"unknown state is not flagged": {
+			hs:          sensorsHost("", green, green, green),
+			include:     []string{"fan"},
+			wantSensors: []string{"Fan Device 1 System Fan 1"},
+		},
+		"excluded sensor is not flagged": {
+			hs:      sensorsHost(red, green, green, green),
+			exclude: []string{"SYSTEM FAN"},
+			wantSensors: []string{
+				"System Board 1 Inlet Temp",
+				"CPU socket #0",
+				"Memory DIMM A1",
+			},
+		},
+		"only included sensors are evaluated": {
+			hs:          sensorsHost(red, yellow, green, green),
+			include:     []string{"temp", "cpu"},
+			wantSensors: []string{"System Board 1 Inlet Temp", "CPU socket #0"},
+			wantWarning: true,
+		},
+		"exclusion has precedence over inclusion": {
+			hs:      sensorsHost(green, red, green, green),
+			include: []string{"temp"},
+			exclude: []string{"inlet"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hhs := NewHostHardwareSensors(tt.hs, tt.include, tt.exclude)
+
+			var got []string
+			for _, sensor := range hhs.Sensors {
+				got = append(got, sensor.Name)
+			}
+
+			if d := cmp.Diff(tt.wantSensors, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if want := 4 - len(tt.wantSensors); hhs.Excluded != want {
+				t.Errorf("want %d excluded sensors; got %d", want, hhs.Excluded)
+			}
+
+			if got := hhs.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hhs.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostHardwareSensorReadingString(t *testing.T) {
+	hhs := NewHostHardwareSensors(
+		sensorsHost(HostHardwareSensorStateGreen, HostHardwareSensorStateGreen,
+			HostHardwareSensorStateGreen, HostHardwareSensorStateGreen),
+		nil,
+		nil,
+	)
+
+	want := []string{"5400.00 RPM", "23.00 Degrees C", "", ""}
+
+	var got []string
+	for _, sensor := range hhs.Sensors {
+		got = append(got, sensor.ReadingString())
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestHostHardwareSensorsSetCounts(t *testing.T) {
+	red := HostHardwareSensorStateRed
+	yellow := HostHardwareSensorStateYellow
+
+	unavailable := sensorsHost(red, red, red, red)
+	unavailable.Runtime.ConnectionState = types.HostSystemConnectionStateNotResponding
+
+	noHealth := sensorsHost(red, red, red, red)
+	noHealth.Runtime.HealthSystemRuntime = nil
+
+	set := NewHostHardwareSensorsSet(
+		[]mo.HostSystem{
+			sensorsHost(red, yellow, "", HostHardwareSensorStateGreen),
+			unavailable,
+			noHealth,
+		},
+		nil,
+		[]string{"dimm"},
+	)
+
+	if got := set.NumHostsEvaluated(); got != 1 {
+		t.Errorf("want 1 evaluated host; got %d", got)
+	}
+
+	if got := set.NumHostsUnavailable(); got != 2 {
+		t.Errorf("want 2 unavailable hosts; got %d", got)
+	}
+
+	if got := set.NumSensors(); got != 3 {
+		t.Errorf("want 3 sensors; got %d", got)
+	}
+
+	if got := set.NumSensorsCritical(); got != 1 {
+		t.Errorf("want 1 CRITICAL sensor; got %d", got)
+	}
+
+	if got := set.NumSensorsWarning(); got != 1 {
+		t.Errorf("want 1 WARNING sensor; got %d", got)
+	}
+
+	if got := set.NumSensorsUnknown(); got != 1 {
+		t.Errorf("want 1 unknown sensor; got %d", got)
+	}
+
+	if got := set.NumSensorsExcluded(); got != 1 {
+		t.Errorf("want 1 excluded sensor; got %d", got)
+	}
+}
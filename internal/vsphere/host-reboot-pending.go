@@ -0,0 +1,551 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostRebootPendingThresholdCrossed indicates that one or more ESXi hosts
+// have required a reboot for longer than permitted.
+var ErrHostRebootPendingThresholdCrossed = errors.New("host reboot pending longer than specified threshold")
+
+// HostRebootPendingThresholds represents the user-specified thresholds (in
+// hours) for how long a required reboot may remain pending.
+type HostRebootPendingThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// HostRebootPending tracks the pending reboot state for a specific
+// HostSystem.
+type HostRebootPending struct {
+	// Host is the evaluated HostSystem.
+	Host mo.HostSystem
+
+	// PendingSince is when the required reboot was first observed. vSphere
+	// does not record when a reboot became required, so this is the time of
+	// the first plugin run which observed the pending reboot.
+	PendingSince time.Time
+
+	// Unavailable indicates whether the pending reboot state could not be
+	// determined for the HostSystem due to its connection state.
+	Unavailable bool
+
+	// Thresholds are the user-specified permitted pending durations.
+	Thresholds HostRebootPendingThresholds
+}
+
+// HostRebootPendingBaseline is when a pending reboot was first observed for
+// each HostSystem as recorded by a plugin run.
+type HostRebootPendingBaseline struct {
+	// Recorded is when the baseline was recorded.
+	Recorded time.Time `json:"recorded"`
+
+	// Hosts is when a pending reboot was first observed keyed by HostSystem
+	// Managed Object ID.
+	Hosts map[string]time.Time `json:"hosts"`
+}
+
+// HostRebootPendingSummary tracks the pending reboot state for a collection
+// of HostSystems.
+type HostRebootPendingSummary struct {
+	// Hosts is the collection of HostSystems with a pending reboot or with
+	// an unknown pending reboot state.
+	Hosts []HostRebootPending
+
+	// HostsEvaluated is the number of HostSystems evaluated for a pending
+	// reboot.
+	HostsEvaluated int
+
+	// Baseline is when pending reboots were first observed as recorded by a
+	// previous plugin run. This is nil if a baseline was not previously
+	// recorded.
+	Baseline *HostRebootPendingBaseline
+
+	// Recorded is when the pending reboot state was evaluated.
+	Recorded time.Time
+
+	// Thresholds are the user-specified permitted pending durations.
+	Thresholds HostRebootPendingThresholds
+}
+
+// Age returns how long the reboot has been pending.
+func (hrp HostRebootPending) Age() time.Duration {
+	return time.Since(hrp.PendingSince)
+}
+
+// IsCriticalState indicates whether the reboot has been pending for at least
+// the CRITICAL threshold.
+func (hrp HostRebootPending) IsCriticalState() bool {
+	return !hrp.Unavailable &&
+		hrp.Age() >= time.Duration(hrp.Thresholds.Critical)*time.Hour
+}
+
+// IsWarningState indicates whether the reboot has been pending for at least
+// the WARNING threshold (but less than the CRITICAL threshold).
+func (hrp HostRebootPending) IsWarningState() bool {
+	return !hrp.Unavailable &&
+		!hrp.IsCriticalState() &&
+		hrp.Age() >= time.Duration(hrp.Thresholds.Warning)*time.Hour
+}
+
+// NewHostRebootPendingSummary evaluates the given HostSystems for a pending
+// reboot. The time a pending reboot was first observed is taken from the
+// given baseline if available. HostSystems which are not connected are
+// flagged as unavailable and are not evaluated.
+func NewHostRebootPendingSummary(
+	hss []mo.HostSystem,
+	baseline *HostRebootPendingBaseline,
+	thresholds HostRebootPendingThresholds,
+) HostRebootPendingSummary {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewHostRebootPendingSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	summary := HostRebootPendingSummary{
+		Baseline:   baseline,
+		Recorded:   time.Now(),
+		Thresholds: thresholds,
+	}
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping pending reboot evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			hrp := HostRebootPending{
+				Host:        hs,
+				Unavailable: true,
+				Thresholds:  thresholds,
+			}
+
+			// Retain the previously recorded time so that the pending
+			// duration is not reset by a temporary connection issue.
+			if baseline != nil {
+				hrp.PendingSince = baseline.Hosts[hs.Self.Value]
+			}
+
+			summary.Hosts = append(summary.Hosts, hrp)
+
+			continue
+		}
+
+		summary.HostsEvaluated++
+
+		if !hs.Summary.RebootRequired {
+			continue
+		}
+
+		pendingSince := summary.Recorded
+		if baseline != nil {
+			if firstSeen, ok := baseline.Hosts[hs.Self.Value]; ok {
+				pendingSince = firstSeen
+			}
+		}
+
+		summary.Hosts = append(summary.Hosts, HostRebootPending{
+			Host:         hs,
+			PendingSince: pendingSince,
+			Thresholds:   thresholds,
+		})
+	}
+
+	sort.Slice(summary.Hosts, func(i, j int) bool {
+		return strings.ToLower(summary.Hosts[i].Host.Name) < strings.ToLower(summary.Hosts[j].Host.Name)
+	})
+
+	return summary
+}
+
+// LoadHostRebootPendingBaseline reads the pending reboot baseline from the
+// specified state file. A nil baseline is returned if the state file does
+// not exist.
+func LoadHostRebootPendingBaseline(filename string) (*HostRebootPendingBaseline, error) {
+	data, err := readStateFile(filename)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to load host pending reboot baseline: %w", err)
+
+	case data == nil:
+		return nil, nil
+	}
+
+	var baseline HostRebootPendingBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse host pending reboot state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return &baseline, nil
+}
+
+// SaveHostRebootPendingBaseline writes the given pending reboot details to
+// the specified state file for use as the baseline by the next plugin run.
+func SaveHostRebootPendingBaseline(filename string, baseline HostRebootPendingBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode host pending reboot baseline: %w", err)
+	}
+
+	if err := writeStateFile(filename, data); err != nil {
+		return fmt.Errorf("failed to save host pending reboot baseline: %w", err)
+	}
+
+	return nil
+}
+
+// NextBaseline returns the pending reboot details to record as the baseline
+// for the next plugin run. HostSystems which no longer require a reboot are
+// dropped so that a later pending reboot is measured from when it is first
+// observed.
+func (s HostRebootPendingSummary) NextBaseline() HostRebootPendingBaseline {
+	next := HostRebootPendingBaseline{
+		Recorded: s.Recorded,
+		Hosts:    make(map[string]time.Time, len(s.Hosts)),
+	}
+
+	for _, hrp := range s.Hosts {
+		if hrp.PendingSince.IsZero() {
+			continue
+		}
+
+		next.Hosts[hrp.Host.Self.Value] = hrp.PendingSince
+	}
+
+	return next
+}
+
+// HasBaseline indicates whether a baseline was available for comparison.
+func (s HostRebootPendingSummary) HasBaseline() bool {
+	return s.Baseline != nil
+}
+
+// NumHostsPending returns the number of evaluated HostSystems with a pending
+// reboot.
+func (s HostRebootPendingSummary) NumHostsPending() int {
+	var num int
+	for _, hrp := range s.Hosts {
+		if !hrp.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems which could not be
+// evaluated due to their connection state.
+func (s HostRebootPendingSummary) NumHostsUnavailable() int {
+	var num int
+	for _, hrp := range s.Hosts {
+		if hrp.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsCritical returns the number of HostSystems with a reboot pending
+// for at least the CRITICAL threshold.
+func (s HostRebootPendingSummary) NumHostsCritical() int {
+	var num int
+	for _, hrp := range s.Hosts {
+		if hrp.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsWarning returns the number of HostSystems with a reboot pending for
+// at least the WARNING threshold (but less than the CRITICAL threshold).
+func (s HostRebootPendingSummary) NumHostsWarning() int {
+	var num int
+	for _, hrp := range s.Hosts {
+		if hrp.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// MaxAge returns the longest pending reboot duration of the evaluated
+// HostSystems.
+func (s HostRebootPendingSummary) MaxAge() time.Duration {
+	var maxAge time.Duration
+	for _, hrp := range s.Hosts {
+		if hrp.Unavailable {
+			continue
+		}
+
+		if age := hrp.Age(); age > maxAge {
+			maxAge = age
+		}
+	}
+
+	return maxAge
+}
+
+// IsCriticalState indicates whether any evaluated HostSystems have a reboot
+// pending for at least the CRITICAL threshold.
+func (s HostRebootPendingSummary) IsCriticalState() bool {
+	return s.NumHostsCritical() > 0
+}
+
+// IsWarningState indicates whether any evaluated HostSystems have a reboot
+// pending for at least the WARNING threshold.
+func (s HostRebootPendingSummary) IsWarningState() bool {
+	return s.NumHostsWarning() > 0
+}
+
+// HostRebootPendingPerfData generates performance data metrics from the
+// given evaluation results.
+func HostRebootPendingPerfData(s HostRebootPendingSummary) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", s.HostsEvaluated),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", s.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_reboot_pending",
+			Value: fmt.Sprintf("%d", s.NumHostsPending()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_reboot_pending_critical",
+			Value: fmt.Sprintf("%d", s.NumHostsCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_reboot_pending_warning",
+			Value: fmt.Sprintf("%d", s.NumHostsWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "max_reboot_pending_age",
+			Value:             fmt.Sprintf("%.0f", s.MaxAge().Seconds()),
+			UnitOfMeasurement: "s",
+			Warn:              fmt.Sprintf("%d", s.Thresholds.Warning*int(time.Hour/time.Second)),
+			Crit:              fmt.Sprintf("%d", s.Thresholds.Critical*int(time.Hour/time.Second)),
+			Min:               "0",
+		},
+	}
+}
+
+// HostRebootPendingOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostRebootPendingOneLineCheckSummary(
+	stateLabel string,
+	s HostRebootPendingSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"s": s,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostRebootPendingOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case s.IsCriticalState() || s.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with reboot pending longer than threshold (%d CRITICAL, %d WARNING; %d pending, evaluated %d hosts)",
+			stateLabel,
+			s.NumHostsCritical()+s.NumHostsWarning(),
+			s.NumHostsCritical(),
+			s.NumHostsWarning(),
+			s.NumHostsPending(),
+			s.HostsEvaluated,
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts with reboot pending longer than threshold (%d pending, evaluated %d hosts)",
+			stateLabel,
+			s.NumHostsPending(),
+			s.HostsEvaluated,
+		)
+	}
+}
+
+// HostRebootPendingReport generates a summary of HostSystems with a pending
+// reboot along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostRebootPendingReport(
+	c *vim25.Client,
+	s HostRebootPendingSummary,
+	stateFile string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostRebootPendingReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	stateSuffix := func(critical bool, warning bool) string {
+		switch {
+		case critical:
+			return " [" + nagios.StateCRITICALLabel + "]"
+		case warning:
+			return " [" + nagios.StateWARNINGLabel + "]"
+		default:
+			return ""
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with a pending reboot:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if s.NumHostsPending() == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, hrp := range s.Hosts {
+		if hrp.Unavailable {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s: pending for %s (since %s, in maintenance mode: %t)%s",
+			hrp.Host.Name,
+			stateSuffix(hrp.IsCriticalState(), hrp.IsWarningState()),
+			hrp.Age().Truncate(time.Minute),
+			hrp.PendingSince.Format(time.RFC3339),
+			hrp.Host.Runtime.InMaintenanceMode,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if s.NumHostsUnavailable() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sUnavailable hosts:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, hrp := range s.Hosts {
+			if !hrp.Unavailable {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (connection state: %s)%s",
+				hrp.Host.Name,
+				hrp.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Pending age thresholds (hours): warning %d, critical %d%s",
+		s.Thresholds.Warning,
+		s.Thresholds.Critical,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* State file: %s%s",
+		stateFile,
+		nagios.CheckOutputEOL,
+	)
+
+	baselineRecorded := "none (baseline recorded by this run)"
+	if s.HasBaseline() {
+		baselineRecorded = s.Baseline.Recorded.Format(time.RFC3339)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Baseline recorded: %s%s",
+		baselineRecorded,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,224 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// rebootPendingHost returns a host with the given connection state and
+// pending reboot status.
+func rebootPendingHost(id string, state types.HostSystemConnectionState, rebootRequired bool) mo.HostSystem {
+	var hs mo.HostSystem
+	hs.Self = types.ManagedObjectReference{Type: MgObjRefTypeHostSystem, Value: id}
+	hs.Name = id
+	hs.Runtime.ConnectionState = state
+	hs.Summary.RebootRequired = rebootRequired
+
+	return hs
+}
+
+func TestNewHostRebootPendingSummary(t *testing.T) {
+	thresholds := HostRebootPendingThresholds{
+		Warning:  24,
+		Critical: 72,
+	}
+
+	connected := types.HostSystemConnectionStateConnected
+	notResponding := types.HostSystemConnectionStateNotResponding
+
+	firstSeen := time.Now().Add(-48 * time.Hour)
+	baseline := &HostRebootPendingBaseline{
+		Recorded: time.Now().Add(-time.Hour),
+		Hosts: map[string]time.Time{
+			"host-2": firstSeen,
+			"host-3": firstSeen,
+			"host-4": firstSeen,
+		},
+	}
+
+	tests := map[string]struct {
+		baseline      *HostRebootPendingBaseline
+		wantPending   int
+		wantWarning   int
+		wantBaseline  []string
+		wantUnchanged []string
+	}{
+		"no baseline": {
+			wantPending:  2,
+			wantBaseline: []string{"host-2", "host-5"},
+		},
+		"with baseline": {
+			baseline:      baseline,
+			wantPending:   2,
+			wantWarning:   1,
+			wantBaseline:  []string{"host-2", "host-3", "host-5"},
+			wantUnchanged: []string{"host-2", "host-3"},
+		},
+	}
+
+	hss := []mo.HostSystem{
+		rebootPendingHost("host-5", connected, true),
+		rebootPendingHost("host-1", connected, false),
+		rebootPendingHost("host-2", connected, true),
+		rebootPendingHost("host-3", notResponding, true),
+		rebootPendingHost("host-4", connected, false),
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := NewHostRebootPendingSummary(hss, tt.baseline, thresholds)
+
+			if summary.HostsEvaluated != 4 {
+				t.Errorf("want 4 evaluated hosts; got %d", summary.HostsEvaluated)
+			}
+
+			var names []string
+			for _, hrp := range summary.Hosts {
+				names = append(names, hrp.Host.Name)
+			}
+			if d := cmp.Diff([]string{"host-2", "host-3", "host-5"}, names); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := summary.NumHostsPending(); got != tt.wantPending {
+				t.Errorf("want %d hosts pending reboot; got %d", tt.wantPending, got)
+			}
+
+			if got := summary.NumHostsUnavailable(); got != 1 {
+				t.Errorf("want 1 unavailable host; got %d", got)
+			}
+
+			if got := summary.NumHostsWarning(); got != tt.wantWarning {
+				t.Errorf("want %d WARNING hosts; got %d", tt.wantWarning, got)
+			}
+
+			next := summary.NextBaseline()
+
+			var got []string
+			for id := range next.Hosts {
+				got = append(got, id)
+			}
+			sort.Strings(got)
+			if d := cmp.Diff(tt.wantBaseline, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			for _, id := range tt.wantUnchanged {
+				if !next.Hosts[id].Equal(firstSeen) {
+					t.Errorf("want %s first observed %v; got %v", id, firstSeen, next.Hosts[id])
+				}
+			}
+
+			if !next.Hosts["host-5"].Equal(summary.Recorded) {
+				t.Errorf("want host-5 first observed %v; got %v", summary.Recorded, next.Hosts["host-5"])
+			}
+		})
+	}
+}
+
+func TestHostRebootPendingState(t *testing.T) {
+	thresholds := HostRebootPendingThresholds{
+		Warning:  24,
+		Critical: 72,
+	}
+
+	tests := map[string]struct {
+		age          time.Duration
+		unavailable  bool
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"newly observed":                {},
+		"within WARNING threshold":      {age: 23 * time.Hour},
+		"at WARNING threshold":          {age: 24 * time.Hour, wantWarning: true},
+		"longer than WARNING threshold": {age: 48 * time.Hour, wantWarning: true},
+		"at CRITICAL threshold":         {age: 72 * time.Hour, wantCritical: true},
+		"unavailable host":              {age: 96 * time.Hour, unavailable: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hrp := HostRebootPending{
+				PendingSince: time.Now().Add(-tt.age),
+				Unavailable:  tt.unavailable,
+				Thresholds:   thresholds,
+			}
+
+			if got := hrp.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hrp.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			summary := HostRebootPendingSummary{Hosts: []HostRebootPending{hrp}}
+
+			if got := summary.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want summary CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := summary.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want summary WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			if tt.unavailable && summary.MaxAge() != 0 {
+				t.Errorf("want unavailable host excluded from max age; got %v", summary.MaxAge())
+			}
+		})
+	}
+}
+
+func TestHostRebootPendingBaselineRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "reboot-pending.json")
+
+	missing, err := LoadHostRebootPendingBaseline(filename)
+	if err != nil {
+		t.Fatalf("want nil error for missing state file; got %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("want nil baseline for missing state file; got %+v", missing)
+	}
+
+	want := HostRebootPendingBaseline{
+		Recorded: time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC),
+		Hosts: map[string]time.Time{
+			"host-1": time.Date(2021, time.May, 30, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	if err := SaveHostRebootPendingBaseline(filename, want); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	loaded, err := LoadHostRebootPendingBaseline(filename)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if d := cmp.Diff(want, *loaded); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if err := os.WriteFile(filename, []byte("{"), 0o600); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	if _, err := LoadHostRebootPendingBaseline(filename); err == nil {
+		t.Error("want error for invalid state file; got nil")
+	}
+}
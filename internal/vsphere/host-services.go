@@ -0,0 +1,545 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrHostServiceRequiredNotRunning indicates that one or more services
+// required to be running on an ESXi host were found to be stopped or
+// missing.
+var ErrHostServiceRequiredNotRunning = errors.New("required host service not running")
+
+// ErrHostServiceDisallowedEnabled indicates that one or more services
+// expected to be disabled on an ESXi host were found to be running or
+// configured to start with the host.
+var ErrHostServiceDisallowedEnabled = errors.New("disallowed host service enabled")
+
+// HostServicePolicyOn is the startup policy for a host service which is
+// started and stopped along with the host.
+const HostServicePolicyOn string = "on"
+
+// HostSystemServices tracks the services for a specific HostSystem along with
+// the results of evaluating those services against the list of required and
+// disallowed services.
+type HostSystemServices struct {
+	// Host is the HostSystem that the services were retrieved from.
+	Host mo.HostSystem
+
+	// Services is the collection of all services reported by the
+	// HostServiceSystem for the HostSystem.
+	Services []types.HostService
+
+	// RequiredNotRunning is the collection of required services found on the
+	// HostSystem that are not running.
+	RequiredNotRunning []types.HostService
+
+	// RequiredMissing is the collection of required service names not found
+	// on the HostSystem.
+	RequiredMissing []string
+
+	// DisallowedEnabled is the collection of disallowed services found
+	// running or configured to start with the HostSystem.
+	DisallowedEnabled []types.HostService
+
+	// Unavailable indicates whether service details could not be retrieved
+	// for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// HostSystemServicesSet is a collection of HostSystemServices values.
+type HostSystemServicesSet []HostSystemServices
+
+// hostServiceMatches indicates whether the given HostService matches any of
+// the specified service names. Matching is performed (case-insensitively)
+// against the service key (e.g., TSM-SSH) and the service label (e.g., SSH).
+func hostServiceMatches(svc types.HostService, names []string) bool {
+	return textutils.InList(svc.Key, names, true) ||
+		textutils.InList(svc.Label, names, true)
+}
+
+// GetHostSystemServices uses the HostServiceSystem for the specified
+// HostSystem to retrieve the collection of services for the HostSystem.
+func GetHostSystemServices(ctx context.Context, c *vim25.Client, hs mo.HostSystem) ([]types.HostService, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostSystemServices func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	host := object.NewHostSystem(c, hs.Reference())
+
+	serviceSystem, err := host.ConfigManager().ServiceSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve service system for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	services, err := serviceSystem.Service(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve services for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	return services, nil
+
+}
+
+// NewHostSystemServices evaluates the given services for a HostSystem against
+// the specified lists of required and disallowed service names.
+func NewHostSystemServices(hs mo.HostSystem, services []types.HostService, required []string, disallowed []string) HostSystemServices {
+
+	hostServices := HostSystemServices{
+		Host:     hs,
+		Services: services,
+	}
+
+	for _, name := range required {
+		var found bool
+		for _, svc := range services {
+			if !hostServiceMatches(svc, []string{name}) {
+				continue
+			}
+			found = true
+
+			if !svc.Running {
+				hostServices.RequiredNotRunning = append(hostServices.RequiredNotRunning, svc)
+			}
+		}
+
+		if !found {
+			hostServices.RequiredMissing = append(hostServices.RequiredMissing, name)
+		}
+	}
+
+	for _, svc := range services {
+		if !hostServiceMatches(svc, disallowed) {
+			continue
+		}
+
+		if svc.Running || strings.EqualFold(svc.Policy, HostServicePolicyOn) {
+			hostServices.DisallowedEnabled = append(hostServices.DisallowedEnabled, svc)
+		}
+	}
+
+	return hostServices
+
+}
+
+// GetHostSystemServicesSet retrieves and evaluates the services for each
+// given HostSystem against the specified lists of required and disallowed
+// service names. HostSystems which are not connected are flagged as
+// unavailable and are not evaluated.
+func GetHostSystemServicesSet(ctx context.Context, c *vim25.Client, hss []mo.HostSystem, required []string, disallowed []string) (HostSystemServicesSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostSystemServicesSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostSystemServicesSet, 0, len(hss))
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping service evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostSystemServices{Host: hs, Unavailable: true})
+
+			continue
+		}
+
+		services, err := GetHostSystemServices(ctx, c, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, NewHostSystemServices(hs, services, required, disallowed))
+	}
+
+	return set, nil
+
+}
+
+// HasCriticalState indicates whether any required services were found to be
+// stopped or missing on an evaluated HostSystem.
+func (hss HostSystemServices) HasCriticalState() bool {
+	return len(hss.RequiredNotRunning) > 0 || len(hss.RequiredMissing) > 0
+}
+
+// HasWarningState indicates whether any disallowed services were found to be
+// enabled on an evaluated HostSystem.
+func (hss HostSystemServices) HasWarningState() bool {
+	return len(hss.DisallowedEnabled) > 0
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem has required
+// services which are stopped or missing.
+func (set HostSystemServicesSet) HasCriticalState() bool {
+	for _, hss := range set {
+		if hss.HasCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has disallowed
+// services which are enabled.
+func (set HostSystemServicesSet) HasWarningState() bool {
+	for _, hss := range set {
+		if hss.HasWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose services were
+// evaluated.
+func (set HostSystemServicesSet) NumHostsEvaluated() int {
+	var num int
+	for _, hss := range set {
+		if !hss.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose services could
+// not be evaluated due to their connection state.
+func (set HostSystemServicesSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumRequiredNotRunning returns the number of required services stopped or
+// missing across all evaluated HostSystems.
+func (set HostSystemServicesSet) NumRequiredNotRunning() int {
+	var num int
+	for _, hss := range set {
+		num += len(hss.RequiredNotRunning) + len(hss.RequiredMissing)
+	}
+
+	return num
+}
+
+// NumDisallowedEnabled returns the number of disallowed services enabled
+// across all evaluated HostSystems.
+func (set HostSystemServicesSet) NumDisallowedEnabled() int {
+	var num int
+	for _, hss := range set {
+		num += len(hss.DisallowedEnabled)
+	}
+
+	return num
+}
+
+// NumServicesRunning returns the number of running services across all
+// evaluated HostSystems.
+func (set HostSystemServicesSet) NumServicesRunning() int {
+	var num int
+	for _, hss := range set {
+		for _, svc := range hss.Services {
+			if svc.Running {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// HostServicesPerfData generates performance data metrics from the given
+// collection of evaluated HostSystem services.
+func HostServicesPerfData(set HostSystemServicesSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "host_services_running",
+			Value: fmt.Sprintf("%d", set.NumServicesRunning()),
+			Min:   "0",
+		},
+		{
+			Label: "host_services_required_not_running",
+			Value: fmt.Sprintf("%d", set.NumRequiredNotRunning()),
+			Min:   "0",
+		},
+		{
+			Label: "host_services_disallowed_enabled",
+			Value: fmt.Sprintf("%d", set.NumDisallowedEnabled()),
+			Min:   "0",
+		},
+	}
+}
+
+// HostServicesOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostServicesOneLineCheckSummary(
+	stateLabel string,
+	set HostSystemServicesSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostServicesOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d required services not running and %d disallowed services enabled (evaluated %d hosts)",
+			stateLabel,
+			set.NumRequiredNotRunning(),
+			set.NumDisallowedEnabled(),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No host service issues detected (evaluated %d hosts)",
+			stateLabel,
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostServicesReport generates a summary of host service status for
+// evaluated HostSystems along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostServicesReport(
+	c *vim25.Client,
+	set HostSystemServicesSet,
+	required []string,
+	disallowed []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostServicesReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with service issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, hss := range set {
+		if !hss.HasCriticalState() && !hss.HasWarningState() {
+			continue
+		}
+		numProblemHosts++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			hss.Host.Name,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, name := range hss.RequiredMissing {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s: required, not found%s",
+				name,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, svc := range hss.RequiredNotRunning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (%s): required, not running (policy: %s)%s",
+				svc.Label,
+				svc.Key,
+				svc.Policy,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, svc := range hss.DisallowedEnabled {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (%s): disallowed, enabled (running: %t, policy: %s)%s",
+				svc.Label,
+				svc.Key,
+				svc.Running,
+				svc.Policy,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sService status per host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, hss := range set {
+		if hss.Unavailable {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: unavailable (connection state: %s)%s",
+				hss.Host.Name,
+				hss.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			hss.Host.Name,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, svc := range hss.Services {
+			if !svc.Running &&
+				!hostServiceMatches(svc, required) &&
+				!hostServiceMatches(svc, disallowed) {
+				continue
+			}
+
+			status := "stopped"
+			if svc.Running {
+				status = "running"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (%s): %s (policy: %s)%s",
+				svc.Label,
+				svc.Key,
+				status,
+				svc.Policy,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified services required to be running (%d): [%v]%s",
+		len(required),
+		strings.Join(required, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified services required to be disabled (%d): [%v]%s",
+		len(disallowed),
+		strings.Join(disallowed, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hostService returns a host service with the given running state and
+// startup policy.
+func hostService(key string, label string, running bool, policy string) types.HostService {
+	return types.HostService{
+		Key:     key,
+		Label:   label,
+		Running: running,
+		Policy:  policy,
+	}
+}
+
+// hostServiceKeys returns the keys for the given host services.
+func hostServiceKeys(services []types.HostService) []string {
+	var keys []string
+	for _, svc := range services {
+		keys = append(keys, svc.Key)
+	}
+
+	return keys
+}
+
+func TestNewHostSystemServices(t *testing.T) {
+	var hs mo.HostSystem
+	hs.Name = "esx1"
+
+	ntpRunning := hostService("ntpd", "NTP Daemon", true, HostServicePolicyOn)
+	ntpStopped := hostService("ntpd", "NTP Daemon", false, HostServicePolicyOn)
+	sshRunning := hostService("TSM-SSH", "SSH", true, "off")
+	sshOnPolicy := hostService("TSM-SSH", "SSH", false, HostServicePolicyOn)
+	sshStopped := hostService("TSM-SSH", "SSH", false, "off")
+
+	tests := map[string]struct {
+		services               []types.HostService
+		required               []string
+		disallowed             []string
+		wantRequiredNotRunning []string
+		wantRequiredMissing    []string
+		wantDisallowedEnabled  []string
+		wantCritical           bool
+		wantWarning            bool
+	}{
+		"required service running": {
+			services: []types.HostService{ntpRunning},
+			required: []string{"ntpd"},
+		},
+		"required service matched by label": {
+			services: []types.HostService{ntpRunning},
+			required: []string{"ntp daemon"},
+		},
+		"required service stopped": {
+			services:               []types.HostService{ntpStopped},
+			required:               []string{"NTPD"},
+			wantRequiredNotRunning: []string{"ntpd"},
+			wantCritical:           true,
+		},
+		"required service missing": {
+			services:            []types.HostService{ntpRunning},
+			required:            []string{"snmpd"},
+			wantRequiredMissing: []string{"snmpd"},
+			wantCritical:        true,
+		},
+		"disallowed service running": {
+			services:              []types.HostService{sshRunning},
+			disallowed:            []string{"tsm-ssh"},
+			wantDisallowedEnabled: []string{"TSM-SSH"},
+			wantWarning:           true,
+		},
+		"disallowed service starts with host": {
+			services:              []types.HostService{sshOnPolicy},
+			disallowed:            []string{"SSH"},
+			wantDisallowedEnabled: []string{"TSM-SSH"},
+			wantWarning:           true,
+		},
+		"disallowed service stopped": {
+			services:   []types.HostService{sshStopped},
+			disallowed: []string{"TSM-SSH"},
+		},
+		"required service stopped and disallowed service running": {
+			services:               []types.HostService{ntpStopped, sshRunning},
+			required:               []string{"ntpd"},
+			disallowed:             []string{"TSM-SSH"},
+			wantRequiredNotRunning: []string{"ntpd"},
+			wantDisallowedEnabled:  []string{"TSM-SSH"},
+			wantCritical:           true,
+			wantWarning:            true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewHostSystemServices(hs, tt.services, tt.required, tt.disallowed)
+
+			if d := cmp.Diff(tt.wantRequiredNotRunning, hostServiceKeys(got.RequiredNotRunning)); d != "" {
+				t.Errorf("required not running (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantRequiredMissing, got.RequiredMissing); d != "" {
+				t.Errorf("required missing (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantDisallowedEnabled, hostServiceKeys(got.DisallowedEnabled)); d != "" {
+				t.Errorf("disallowed enabled (-want, +got):\n%s", d)
+			}
+
+			if got := got.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := got.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostSystemServicesSetCounts(t *testing.T) {
+	var hs mo.HostSystem
+
+	set := HostSystemServicesSet{
+		NewHostSystemServices(
+			hs,
+			[]types.HostService{
+				hostService("ntpd", "NTP Daemon", false, HostServicePolicyOn),
+				hostService("TSM-SSH", "SSH", true, "off"),
+				hostService("TSM", "ESXi Shell", true, "off"),
+			},
+			[]string{"ntpd", "snmpd"},
+			[]string{"TSM-SSH", "TSM"},
+		),
+		NewHostSystemServices(
+			hs,
+			[]types.HostService{hostService("ntpd", "NTP Daemon", true, HostServicePolicyOn)},
+			[]string{"ntpd", "snmpd"},
+			[]string{"TSM-SSH", "TSM"},
+		),
+		{Unavailable: true},
+	}
+
+	if !set.HasCriticalState() || !set.HasWarningState() {
+		t.Errorf("want CRITICAL and WARNING states; got %t, %t",
+			set.HasCriticalState(), set.HasWarningState())
+	}
+
+	if got := set.NumHostsEvaluated(); got != 2 {
+		t.Errorf("want 2 evaluated hosts; got %d", got)
+	}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumRequiredNotRunning(); got != 3 {
+		t.Errorf("want 3 required services not running; got %d", got)
+	}
+
+	if got := set.NumDisallowedEnabled(); got != 2 {
+		t.Errorf("want 2 disallowed services enabled; got %d", got)
+	}
+
+	if got := set.NumServicesRunning(); got != 3 {
+		t.Errorf("want 3 running services; got %d", got)
+	}
+}
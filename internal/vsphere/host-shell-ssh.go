@@ -0,0 +1,702 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrHostShellSSHRunningThresholdCrossed indicates that the ESXi Shell or
+// SSH service has been running on one or more ESXi hosts longer than
+// permitted.
+var ErrHostShellSSHRunningThresholdCrossed = errors.New("ESXi Shell or SSH running longer than permitted")
+
+// Service keys for the ESXi Shell and SSH services as reported by the
+// HostServiceSystem.
+const (
+	HostServiceKeyESXiShell string = "TSM"
+	HostServiceKeySSH       string = "TSM-SSH"
+)
+
+// Event type IDs recorded by ESXi hosts when the ESXi Shell or SSH service is
+// enabled (started).
+const (
+	eventTypeIDHostESXiShellEnabled string = "esx.audit.shell.enabled"
+	eventTypeIDHostSSHEnabled       string = "esx.audit.ssh.enabled"
+)
+
+// Sources used to determine when an ESXi Shell or SSH service was started.
+const (
+	HostShellSSHSinceSourceEvent    string = "enable event"
+	HostShellSSHSinceSourceBootTime string = "host boot time"
+)
+
+// HostShellSSHThresholds represents the user-specified thresholds (in
+// minutes) for how long the ESXi Shell or SSH service is permitted to run.
+type HostShellSSHThresholds struct {
+	RunningWarning  int
+	RunningCritical int
+}
+
+// HostShellSSHService represents a running ESXi Shell or SSH service on an
+// ESXi host along with when the service was (most recently) started.
+type HostShellSSHService struct {
+	// Service is the running ESXi Shell or SSH service.
+	Service types.HostService
+
+	// Since is when the service was started. This is the zero value if the
+	// start time could not be determined within the evaluated window.
+	Since time.Time
+
+	// SinceSource indicates how the start time for the service was
+	// determined.
+	SinceSource string
+
+	// Thresholds are the user-specified permitted running times.
+	Thresholds HostShellSSHThresholds
+}
+
+// HostShellSSHStatus tracks the running ESXi Shell and SSH services for a
+// specific HostSystem.
+type HostShellSSHStatus struct {
+	// Host is the HostSystem that the services were retrieved from.
+	Host mo.HostSystem
+
+	// Running is the collection of ESXi Shell and SSH services found running
+	// on the HostSystem.
+	Running []HostShellSSHService
+
+	// Allowed indicates whether the HostSystem was listed as permitted to
+	// run the ESXi Shell or SSH service indefinitely.
+	Allowed bool
+
+	// Unavailable indicates whether service details could not be retrieved
+	// for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// HostShellSSHStatusSet is a collection of HostShellSSHStatus values.
+type HostShellSSHStatusSet []HostShellSSHStatus
+
+// isHostShellSSHService indicates whether the given HostService is the ESXi
+// Shell or SSH service.
+func isHostShellSSHService(svc types.HostService) bool {
+	return strings.EqualFold(svc.Key, HostServiceKeyESXiShell) ||
+		strings.EqualFold(svc.Key, HostServiceKeySSH)
+}
+
+// hostShellSSHEnabledEventTypeID returns the enable event type ID for the
+// given ESXi Shell or SSH HostService.
+func hostShellSSHEnabledEventTypeID(svc types.HostService) string {
+	if strings.EqualFold(svc.Key, HostServiceKeySSH) {
+		return eventTypeIDHostSSHEnabled
+	}
+
+	return eventTypeIDHostESXiShellEnabled
+}
+
+// GetHostShellSSHEnabledTimes retrieves the most recent ESXi Shell and SSH
+// enable event times recorded after the specified time. The results are
+// indexed by HostSystem managed object ID and then by event type ID.
+func GetHostShellSSHEnabledTimes(ctx context.Context, c *vim25.Client, begin time.Time) (map[string]map[string]time.Time, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostShellSSHEnabledTimes func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	filter := types.EventFilterSpec{
+		EventTypeId: []string{
+			eventTypeIDHostESXiShellEnabled,
+			eventTypeIDHostSSHEnabled,
+		},
+		Time: &types.EventFilterSpecByTime{
+			BeginTime: &begin,
+		},
+	}
+
+	events, err := GetEvents(ctx, c, filter)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve ESXi Shell and SSH enable events: %w",
+			err,
+		)
+	}
+
+	enabledTimes := make(map[string]map[string]time.Time)
+	for _, event := range events {
+		e := event.GetEvent()
+		if e.Host == nil {
+			continue
+		}
+
+		hostID := e.Host.Host.Value
+		if enabledTimes[hostID] == nil {
+			enabledTimes[hostID] = make(map[string]time.Time)
+		}
+
+		typeID := EventTypeID(event)
+		if e.CreatedTime.After(enabledTimes[hostID][typeID]) {
+			enabledTimes[hostID][typeID] = e.CreatedTime
+		}
+	}
+
+	return enabledTimes, nil
+
+}
+
+// GetHostShellSSHStatusSet retrieves the running ESXi Shell and SSH services
+// for each given HostSystem and determines how long each has been running.
+// HostSystems which are not connected are flagged as unavailable and are not
+// evaluated. HostSystems listed as allowed are noted but not evaluated
+// further.
+//
+// The start time for a running service is determined from the most recent
+// enable event or the HostSystem boot time (whichever is later) within the
+// CRITICAL threshold window. If neither is found the service is considered
+// to have been running longer than the CRITICAL threshold.
+func GetHostShellSSHStatusSet(
+	ctx context.Context,
+	c *vim25.Client,
+	hss []mo.HostSystem,
+	allowedHosts []string,
+	thresholds HostShellSSHThresholds,
+) (HostShellSSHStatusSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostShellSSHStatusSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostShellSSHStatusSet, 0, len(hss))
+
+	var needEvents bool
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping service evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostShellSSHStatus{Host: hs, Unavailable: true})
+
+			continue
+		}
+
+		services, err := GetHostSystemServices(ctx, c, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		status := HostShellSSHStatus{
+			Host:    hs,
+			Allowed: textutils.InList(hs.Name, allowedHosts, true),
+		}
+
+		for _, svc := range services {
+			if !isHostShellSSHService(svc) || !svc.Running {
+				continue
+			}
+
+			status.Running = append(status.Running, HostShellSSHService{
+				Service:    svc,
+				Thresholds: thresholds,
+			})
+		}
+
+		if len(status.Running) > 0 && !status.Allowed {
+			needEvents = true
+		}
+
+		set = append(set, status)
+	}
+
+	// Skip event retrieval if there are no running services to evaluate.
+	if !needEvents {
+		return set, nil
+	}
+
+	windowStart := time.Now().Add(-time.Duration(thresholds.RunningCritical) * time.Minute)
+
+	enabledTimes, err := GetHostShellSSHEnabledTimes(ctx, c, windowStart)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range set {
+		if set[i].Allowed {
+			continue
+		}
+
+		for j := range set[i].Running {
+			svc := &set[i].Running[j]
+
+			typeID := hostShellSSHEnabledEventTypeID(svc.Service)
+			if enabled, ok := enabledTimes[set[i].Host.Reference().Value][typeID]; ok {
+				svc.Since = enabled
+				svc.SinceSource = HostShellSSHSinceSourceEvent
+			}
+
+			// A service running after a host reboot was started along with
+			// the host (per its startup policy) regardless of any earlier
+			// enable event.
+			bootTime := set[i].Host.Runtime.BootTime
+			if bootTime != nil && bootTime.After(windowStart) && bootTime.After(svc.Since) {
+				svc.Since = *bootTime
+				svc.SinceSource = HostShellSSHSinceSourceBootTime
+			}
+		}
+	}
+
+	return set, nil
+
+}
+
+// SinceKnown indicates whether the start time for the service was
+// determined.
+func (hsvc HostShellSSHService) SinceKnown() bool {
+	return !hsvc.Since.IsZero()
+}
+
+// RunningMinutes returns the number of minutes that the service has been
+// running. If the start time is unknown the CRITICAL threshold is returned
+// as the service has been running at least that long.
+func (hsvc HostShellSSHService) RunningMinutes() int {
+	if !hsvc.SinceKnown() {
+		return hsvc.Thresholds.RunningCritical
+	}
+
+	return int(time.Since(hsvc.Since).Minutes())
+}
+
+// IsCriticalState indicates whether the service has been running longer
+// than the CRITICAL threshold.
+func (hsvc HostShellSSHService) IsCriticalState() bool {
+	if !hsvc.SinceKnown() {
+		return true
+	}
+
+	return hsvc.RunningMinutes() > hsvc.Thresholds.RunningCritical
+}
+
+// IsWarningState indicates whether the service has been running longer than
+// the WARNING threshold, but not longer than the CRITICAL threshold.
+func (hsvc HostShellSSHService) IsWarningState() bool {
+	if hsvc.IsCriticalState() {
+		return false
+	}
+
+	return hsvc.RunningMinutes() > hsvc.Thresholds.RunningWarning
+}
+
+// HasCriticalState indicates whether any evaluated service on the HostSystem
+// has been running longer than the CRITICAL threshold.
+func (hss HostShellSSHStatus) HasCriticalState() bool {
+	if hss.Allowed {
+		return false
+	}
+
+	for _, svc := range hss.Running {
+		if svc.IsCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated service on the HostSystem
+// has been running longer than the WARNING threshold.
+func (hss HostShellSSHStatus) HasWarningState() bool {
+	if hss.Allowed {
+		return false
+	}
+
+	for _, svc := range hss.Running {
+		if svc.IsWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem has an ESXi
+// Shell or SSH service running longer than the CRITICAL threshold.
+func (set HostShellSSHStatusSet) HasCriticalState() bool {
+	for _, hss := range set {
+		if hss.HasCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has an ESXi
+// Shell or SSH service running longer than the WARNING threshold.
+func (set HostShellSSHStatusSet) HasWarningState() bool {
+	for _, hss := range set {
+		if hss.HasWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose services were
+// evaluated.
+func (set HostShellSSHStatusSet) NumHostsEvaluated() int {
+	var num int
+	for _, hss := range set {
+		if !hss.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose services could
+// not be evaluated due to their connection state.
+func (set HostShellSSHStatusSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumHostsAllowed returns the number of HostSystems listed as permitted to
+// run the ESXi Shell or SSH service.
+func (set HostShellSSHStatusSet) NumHostsAllowed() int {
+	var num int
+	for _, hss := range set {
+		if hss.Allowed {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumServicesRunning returns the number of ESXi Shell and SSH services
+// running across all evaluated HostSystems not listed as allowed.
+func (set HostShellSSHStatusSet) NumServicesRunning() int {
+	var num int
+	for _, hss := range set {
+		if hss.Allowed {
+			continue
+		}
+		num += len(hss.Running)
+	}
+
+	return num
+}
+
+// NumServicesCritical returns the number of ESXi Shell and SSH services
+// running longer than the CRITICAL threshold.
+func (set HostShellSSHStatusSet) NumServicesCritical() int {
+	var num int
+	for _, hss := range set {
+		if hss.Allowed {
+			continue
+		}
+		for _, svc := range hss.Running {
+			if svc.IsCriticalState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumServicesWarning returns the number of ESXi Shell and SSH services
+// running longer than the WARNING threshold, but not longer than the
+// CRITICAL threshold.
+func (set HostShellSSHStatusSet) NumServicesWarning() int {
+	var num int
+	for _, hss := range set {
+		if hss.Allowed {
+			continue
+		}
+		for _, svc := range hss.Running {
+			if svc.IsWarningState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// HostShellSSHPerfData generates performance data metrics from the given
+// collection of evaluated HostSystem ESXi Shell and SSH services.
+func HostShellSSHPerfData(set HostShellSSHStatusSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_allowed",
+			Value: fmt.Sprintf("%d", set.NumHostsAllowed()),
+			Min:   "0",
+		},
+		{
+			Label: "shell_ssh_running",
+			Value: fmt.Sprintf("%d", set.NumServicesRunning()),
+			Min:   "0",
+		},
+		{
+			Label: "shell_ssh_running_warning",
+			Value: fmt.Sprintf("%d", set.NumServicesWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "shell_ssh_running_critical",
+			Value: fmt.Sprintf("%d", set.NumServicesCritical()),
+			Min:   "0",
+		},
+	}
+}
+
+// HostShellSSHOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostShellSSHOneLineCheckSummary(
+	stateLabel string,
+	set HostShellSSHStatusSet,
+	thresholds HostShellSSHThresholds,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set":        set,
+		"thresholds": thresholds,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostShellSSHOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState():
+		return fmt.Sprintf(
+			"%s: %d ESXi Shell or SSH services running longer than %d minutes (evaluated %d hosts)",
+			stateLabel,
+			set.NumServicesCritical(),
+			thresholds.RunningCritical,
+			set.NumHostsEvaluated(),
+		)
+
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d ESXi Shell or SSH services running longer than %d minutes (evaluated %d hosts)",
+			stateLabel,
+			set.NumServicesWarning(),
+			thresholds.RunningWarning,
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No ESXi Shell or SSH services running longer than permitted (%d running, evaluated %d hosts)",
+			stateLabel,
+			set.NumServicesRunning(),
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostShellSSHReport generates a summary of ESXi Shell and SSH service
+// status for evaluated HostSystems along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func HostShellSSHReport(
+	c *vim25.Client,
+	set HostShellSSHStatusSet,
+	allowedHosts []string,
+	thresholds HostShellSSHThresholds,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostShellSSHReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with ESXi Shell or SSH running:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numRunningHosts int
+	for _, hss := range set {
+		if len(hss.Running) == 0 {
+			continue
+		}
+		numRunningHosts++
+
+		hostLabel := hss.Host.Name
+		if hss.Allowed {
+			hostLabel += " (allowed)"
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			hostLabel,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, svc := range hss.Running {
+			switch {
+			case hss.Allowed:
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s (%s): running (policy: %s)%s",
+					svc.Service.Label,
+					svc.Service.Key,
+					svc.Service.Policy,
+					nagios.CheckOutputEOL,
+				)
+
+			case !svc.SinceKnown():
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s (%s): running for more than %d minutes (policy: %s)%s",
+					svc.Service.Label,
+					svc.Service.Key,
+					thresholds.RunningCritical,
+					svc.Service.Policy,
+					nagios.CheckOutputEOL,
+				)
+
+			default:
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s (%s): running for %d minutes (since %s via %s, policy: %s)%s",
+					svc.Service.Label,
+					svc.Service.Key,
+					svc.RunningMinutes(),
+					svc.Since.Local().Format(time.RFC3339),
+					svc.SinceSource,
+					svc.Service.Policy,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+	}
+
+	if numRunningHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if set.NumHostsUnavailable() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sUnavailable hosts:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, hss := range set {
+			if !hss.Unavailable {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (connection state: %s)%s",
+				hss.Host.Name,
+				hss.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified hosts allowed to run ESXi Shell or SSH (%d): [%v]%s",
+		len(allowedHosts),
+		strings.Join(allowedHosts, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
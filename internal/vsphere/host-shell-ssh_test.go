@@ -0,0 +1,163 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// shellSSHService returns a running ESXi Shell or SSH service with the given
+// key started the given duration ago. A zero duration indicates an unknown
+// start time.
+func shellSSHService(key string, d time.Duration) HostShellSSHService {
+	svc := HostShellSSHService{
+		Service: types.HostService{Key: key, Running: true},
+		Thresholds: HostShellSSHThresholds{
+			RunningWarning:  30,
+			RunningCritical: 120,
+		},
+	}
+
+	if d > 0 {
+		svc.Since = time.Now().Add(-d)
+		svc.SinceSource = HostShellSSHSinceSourceEvent
+	}
+
+	return svc
+}
+
+func TestHostShellSSHServiceKeys(t *testing.T) {
+	tests := map[string]struct {
+		key         string
+		wantService bool
+		wantEventID string
+	}{
+		"ESXi Shell":      {key: HostServiceKeyESXiShell, wantService: true, wantEventID: eventTypeIDHostESXiShellEnabled},
+		"SSH":             {key: HostServiceKeySSH, wantService: true, wantEventID: eventTypeIDHostSSHEnabled},
+		"SSH lowercase":   {key: "tsm-ssh", wantService: true, wantEventID: eventTypeIDHostSSHEnabled},
+		"unrelated value": {key: "ntpd", wantEventID: eventTypeIDHostESXiShellEnabled},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := types.HostService{Key: tt.key}
+
+			if got := isHostShellSSHService(svc); got != tt.wantService {
+				t.Errorf("want ESXi Shell or SSH service %t; got %t", tt.wantService, got)
+			}
+
+			if got := hostShellSSHEnabledEventTypeID(svc); got != tt.wantEventID {
+				t.Errorf("want event type ID %q; got %q", tt.wantEventID, got)
+			}
+		})
+	}
+}
+
+func TestHostShellSSHServiceState(t *testing.T) {
+	tests := map[string]struct {
+		running      time.Duration
+		wantMinutes  int
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"within WARNING threshold": {
+			running:     10 * time.Minute,
+			wantMinutes: 10,
+		},
+		"equal to WARNING threshold": {
+			running:     30*time.Minute + 30*time.Second,
+			wantMinutes: 30,
+		},
+		"longer than WARNING threshold": {
+			running:     45 * time.Minute,
+			wantMinutes: 45,
+			wantWarning: true,
+		},
+		"longer than CRITICAL threshold": {
+			running:      3 * time.Hour,
+			wantMinutes:  180,
+			wantCritical: true,
+		},
+		"unknown start time": {
+			wantMinutes:  120,
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			svc := shellSSHService(HostServiceKeySSH, tt.running)
+
+			if got := svc.RunningMinutes(); got != tt.wantMinutes {
+				t.Errorf("want %d running minutes; got %d", tt.wantMinutes, got)
+			}
+
+			if got := svc.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := svc.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostShellSSHStatusSetCounts(t *testing.T) {
+	set := HostShellSSHStatusSet{
+		{},
+		{
+			Running: []HostShellSSHService{
+				shellSSHService(HostServiceKeyESXiShell, 45*time.Minute),
+				shellSSHService(HostServiceKeySSH, 0),
+			},
+		},
+		{
+			Running: []HostShellSSHService{shellSSHService(HostServiceKeySSH, 0)},
+			Allowed: true,
+		},
+		{Unavailable: true},
+	}
+
+	if !set.HasCriticalState() || !set.HasWarningState() {
+		t.Errorf("want CRITICAL and WARNING states; got %t, %t",
+			set.HasCriticalState(), set.HasWarningState())
+	}
+
+	if got := set.NumHostsEvaluated(); got != 3 {
+		t.Errorf("want 3 evaluated hosts; got %d", got)
+	}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumHostsAllowed(); got != 1 {
+		t.Errorf("want 1 allowed host; got %d", got)
+	}
+
+	if got := set.NumServicesRunning(); got != 2 {
+		t.Errorf("want 2 running services; got %d", got)
+	}
+
+	if got := set.NumServicesCritical(); got != 1 {
+		t.Errorf("want 1 CRITICAL service; got %d", got)
+	}
+
+	if got := set.NumServicesWarning(); got != 1 {
+		t.Errorf("want 1 WARNING service; got %d", got)
+	}
+
+	allowed := HostShellSSHStatusSet{set[2]}
+	if allowed.HasCriticalState() || allowed.HasWarningState() {
+		t.Error("want no CRITICAL or WARNING state for allowed host")
+	}
+}
@@ -0,0 +1,1060 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostSRIOVPassthroughCapacity indicates that one or more ESXi hosts have
+// insufficient SR-IOV virtual functions or DirectPath I/O devices for the
+// VMs configured to use them.
+var ErrHostSRIOVPassthroughCapacity = errors.New("SR-IOV or DirectPath I/O device capacity issues found")
+
+// sriovAutomaticPrefix is the prefix used for the physical function ID of
+// SR-IOV network adapters configured for automatic physical function
+// assignment. If a physical function has not yet been assigned the prefix is
+// followed by a placeholder ID.
+const (
+	sriovAutomaticPrefix         string = "Automatic-"
+	sriovAutomaticUnassignedPFID string = "0000:00:00.0"
+)
+
+// HostSRIOVDevice represents an SR-IOV enabled physical function (PF) on an
+// ESXi host along with the number of virtual functions (VFs) demanded by
+// VMs registered on the host.
+type HostSRIOVDevice struct {
+
+	// ID is the PCI ID of the physical function (e.g., 0000:3b:00.0).
+	ID string
+
+	// Label is the hardware label of the device, if available.
+	Label string
+
+	// Active indicates whether SR-IOV is active for the device (i.e.,
+	// enabled and the host has been rebooted).
+	Active bool
+
+	// NumVFs is the number of virtual functions present on the device.
+	NumVFs int
+
+	// Demand is the number of SR-IOV network adapters (for VMs registered
+	// on the host) assigned to this physical function.
+	Demand int
+
+	// VMs is the collection of VM names with SR-IOV network adapters
+	// assigned to this physical function.
+	VMs []string
+}
+
+// HostPassthroughDevice represents a PCI device on an ESXi host enabled for
+// DirectPath I/O along with the VMs statically configured to use it.
+type HostPassthroughDevice struct {
+
+	// ID is the PCI ID of the device (e.g., 0000:af:00.0).
+	ID string
+
+	// Label is the hardware label of the device, if available.
+	Label string
+
+	// Active indicates whether passthrough is active for the device (i.e.,
+	// enabled and the host has been rebooted).
+	Active bool
+
+	// VendorID is the PCI vendor ID of the device.
+	VendorID uint16
+
+	// DeviceID is the PCI device ID of the device.
+	DeviceID uint16
+
+	// VMs is the collection of VM names statically configured to use this
+	// device.
+	VMs []string
+}
+
+// HostDeviceReference represents a VM device which references an SR-IOV
+// physical function or DirectPath I/O device which is not available on the
+// ESXi host (e.g., not present or not enabled for passthrough).
+type HostDeviceReference struct {
+
+	// VMName is the name of the VM.
+	VMName string
+
+	// DeviceLabel is the label of the VM device (e.g., PCI device 0).
+	DeviceLabel string
+
+	// PCIID is the PCI ID of the referenced host device.
+	PCIID string
+
+	// Kind is the type of reference (SR-IOV or DirectPath I/O).
+	Kind string
+}
+
+// Kinds of host device references.
+const (
+	HostDeviceKindSRIOV       string = "SR-IOV"
+	HostDeviceKindPassthrough string = "DirectPath I/O"
+)
+
+// HostSRIOVPassthroughCapacity represents the SR-IOV virtual function and
+// DirectPath I/O device capacity for a HostSystem versus the demand of VMs
+// registered on the host (powered on or not).
+type HostSRIOVPassthroughCapacity struct {
+
+	// Host is the HostSystem that the device details were retrieved from.
+	Host mo.HostSystem
+
+	// SRIOVDevices is the collection of SR-IOV enabled physical functions.
+	SRIOVDevices []HostSRIOVDevice
+
+	// PassthroughDevices is the collection of devices enabled for
+	// DirectPath I/O (excluding SR-IOV physical functions).
+	PassthroughDevices []HostPassthroughDevice
+
+	// AutoVFDemand is the number of SR-IOV network adapters configured for
+	// automatic physical function assignment which have not yet been
+	// assigned a physical function.
+	AutoVFDemand int
+
+	// DynamicDemand is the number of Dynamic DirectPath I/O devices
+	// configured for VMs registered on the host.
+	DynamicDemand int
+
+	// DynamicUnsatisfied is the number of Dynamic DirectPath I/O devices
+	// which cannot be satisfied by an unassigned passthrough device on the
+	// host.
+	DynamicUnsatisfied int
+
+	// MissingDevices is the collection of VM devices which reference host
+	// devices which are not available.
+	MissingDevices []HostDeviceReference
+
+	// NumVMs is the number of (non-template) VMs registered on the host.
+	NumVMs int
+
+	// Unavailable indicates whether device details could not be retrieved
+	// for the HostSystem due to its connection state.
+	Unavailable bool
+
+	// WarningThreshold is the percentage of SR-IOV virtual functions
+	// demanded by VMs when a WARNING state is reached.
+	WarningThreshold int
+
+	// CriticalThreshold is the percentage of SR-IOV virtual functions
+	// demanded by VMs when a CRITICAL state is reached.
+	CriticalThreshold int
+}
+
+// HostSRIOVPassthroughCapacitySet is a collection of
+// HostSRIOVPassthroughCapacity values.
+type HostSRIOVPassthroughCapacitySet []HostSRIOVPassthroughCapacity
+
+// normalizePCIID normalizes the given PCI ID for comparison.
+func normalizePCIID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// sriovPhysicalFunctionID returns the PCI ID of the physical function
+// assigned to the given SR-IOV network adapter. An empty string is returned
+// if the adapter is configured for automatic assignment and a physical
+// function has not yet been assigned.
+func sriovPhysicalFunctionID(nic *types.VirtualSriovEthernetCard) string {
+	if nic.SriovBacking == nil || nic.SriovBacking.PhysicalFunctionBacking == nil {
+		return ""
+	}
+
+	id := nic.SriovBacking.PhysicalFunctionBacking.Id
+	if strings.HasPrefix(id, sriovAutomaticPrefix) {
+		id = strings.TrimPrefix(id, sriovAutomaticPrefix)
+		if id == sriovAutomaticUnassignedPFID {
+			return ""
+		}
+	}
+
+	return normalizePCIID(id)
+}
+
+// deviceLabel returns the label for the given virtual device.
+func deviceLabel(device types.BaseVirtualDevice) string {
+	if info := device.GetVirtualDevice().DeviceInfo; info != nil {
+		return info.GetDescription().Label
+	}
+
+	return ""
+}
+
+// pciDeviceMatchesAllowed indicates whether the given host PCI device
+// matches any of the given Dynamic DirectPath I/O allowed devices.
+func pciDeviceMatchesAllowed(device HostPassthroughDevice, allowed []types.VirtualPCIPassthroughAllowedDevice) bool {
+	for _, a := range allowed {
+		if uint16(a.VendorId) == device.VendorID && uint16(a.DeviceId) == device.DeviceID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewHostSRIOVPassthroughCapacity evaluates the SR-IOV and DirectPath I/O
+// device details for the given HostSystem against the devices configured
+// for the given VMs registered on the host.
+func NewHostSRIOVPassthroughCapacity(
+	hs mo.HostSystem,
+	passthruInfo []types.BaseHostPciPassthruInfo,
+	vms []mo.VirtualMachine,
+	criticalThreshold int,
+	warningThreshold int,
+) HostSRIOVPassthroughCapacity {
+
+	hspc := HostSRIOVPassthroughCapacity{
+		Host:              hs,
+		WarningThreshold:  warningThreshold,
+		CriticalThreshold: criticalThreshold,
+	}
+
+	// Index PCI device vendor and device IDs so that Dynamic DirectPath I/O
+	// devices can be matched to passthrough devices.
+	type pciIDs struct {
+		vendorID uint16
+		deviceID uint16
+	}
+	pciDevices := make(map[string]pciIDs)
+	if hs.Hardware != nil {
+		for _, d := range hs.Hardware.PciDevice {
+			pciDevices[normalizePCIID(d.Id)] = pciIDs{
+				vendorID: uint16(d.VendorId),
+				deviceID: uint16(d.DeviceId),
+			}
+		}
+	}
+
+	sriovIdx := make(map[string]int)
+	passthroughIdx := make(map[string]int)
+
+	for _, info := range passthruInfo {
+		if sriov, ok := info.(*types.HostSriovInfo); ok && sriov.SriovEnabled {
+			id := normalizePCIID(sriov.Id)
+			sriovIdx[id] = len(hspc.SRIOVDevices)
+			hspc.SRIOVDevices = append(hspc.SRIOVDevices, HostSRIOVDevice{
+				ID:     id,
+				Label:  sriov.HardwareLabel,
+				Active: sriov.SriovActive,
+				NumVFs: int(sriov.NumVirtualFunction),
+			})
+
+			continue
+		}
+
+		pt := info.GetHostPciPassthruInfo()
+		if !pt.PassthruEnabled {
+			continue
+		}
+
+		id := normalizePCIID(pt.Id)
+		passthroughIdx[id] = len(hspc.PassthroughDevices)
+		hspc.PassthroughDevices = append(hspc.PassthroughDevices, HostPassthroughDevice{
+			ID:       id,
+			Label:    pt.HardwareLabel,
+			Active:   pt.PassthruActive,
+			VendorID: pciDevices[id].vendorID,
+			DeviceID: pciDevices[id].deviceID,
+		})
+	}
+
+	var dynamicRequests [][]types.VirtualPCIPassthroughAllowedDevice
+
+	for _, vm := range vms {
+		if vm.Config == nil || vm.Config.Template {
+			continue
+		}
+
+		hspc.NumVMs++
+
+		for _, device := range vm.Config.Hardware.Device {
+			switch d := device.(type) {
+			case *types.VirtualSriovEthernetCard:
+				pfID := sriovPhysicalFunctionID(d)
+				if pfID == "" {
+					hspc.AutoVFDemand++
+
+					continue
+				}
+
+				idx, ok := sriovIdx[pfID]
+				if !ok {
+					hspc.MissingDevices = append(hspc.MissingDevices, HostDeviceReference{
+						VMName:      vm.Name,
+						DeviceLabel: deviceLabel(d),
+						PCIID:       pfID,
+						Kind:        HostDeviceKindSRIOV,
+					})
+
+					continue
+				}
+
+				hspc.SRIOVDevices[idx].Demand++
+				hspc.SRIOVDevices[idx].VMs = append(hspc.SRIOVDevices[idx].VMs, vm.Name)
+
+			case *types.VirtualPCIPassthrough:
+				switch backing := d.Backing.(type) {
+				case *types.VirtualPCIPassthroughDeviceBackingInfo:
+					id := normalizePCIID(backing.Id)
+
+					idx, ok := passthroughIdx[id]
+					if !ok {
+						hspc.MissingDevices = append(hspc.MissingDevices, HostDeviceReference{
+							VMName:      vm.Name,
+							DeviceLabel: deviceLabel(d),
+							PCIID:       id,
+							Kind:        HostDeviceKindPassthrough,
+						})
+
+						continue
+					}
+
+					hspc.PassthroughDevices[idx].VMs = append(hspc.PassthroughDevices[idx].VMs, vm.Name)
+
+				case *types.VirtualPCIPassthroughDynamicBackingInfo:
+					hspc.DynamicDemand++
+					dynamicRequests = append(dynamicRequests, backing.AllowedDevice)
+				}
+			}
+		}
+	}
+
+	// Assign Dynamic DirectPath I/O requests to passthrough devices which
+	// are not statically assigned to a VM.
+	assigned := make(map[string]bool)
+	for _, allowed := range dynamicRequests {
+		var satisfied bool
+		for _, device := range hspc.PassthroughDevices {
+			if len(device.VMs) > 0 || assigned[device.ID] {
+				continue
+			}
+
+			if pciDeviceMatchesAllowed(device, allowed) {
+				assigned[device.ID] = true
+				satisfied = true
+
+				break
+			}
+		}
+
+		if !satisfied {
+			hspc.DynamicUnsatisfied++
+		}
+	}
+
+	sort.Slice(hspc.SRIOVDevices, func(i, j int) bool {
+		return hspc.SRIOVDevices[i].ID < hspc.SRIOVDevices[j].ID
+	})
+
+	sort.Slice(hspc.PassthroughDevices, func(i, j int) bool {
+		return hspc.PassthroughDevices[i].ID < hspc.PassthroughDevices[j].ID
+	})
+
+	return hspc
+
+}
+
+// GetHostSRIOVPassthroughCapacitySet retrieves and evaluates the SR-IOV and
+// DirectPath I/O device details for each given HostSystem along with the
+// devices configured for VMs registered on each host. HostSystems which are
+// not connected are flagged as unavailable and are not evaluated.
+func GetHostSRIOVPassthroughCapacitySet(
+	ctx context.Context,
+	c *vim25.Client,
+	hss []mo.HostSystem,
+	criticalThreshold int,
+	warningThreshold int,
+) (HostSRIOVPassthroughCapacitySet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostSRIOVPassthroughCapacitySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostSRIOVPassthroughCapacitySet, 0, len(hss))
+
+	pc := property.DefaultCollector(c)
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping device evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostSRIOVPassthroughCapacity{
+				Host:              hs,
+				Unavailable:       true,
+				WarningThreshold:  warningThreshold,
+				CriticalThreshold: criticalThreshold,
+			})
+
+			continue
+		}
+
+		var hostConfig mo.HostSystem
+		err := pc.RetrieveOne(
+			ctx,
+			hs.Reference(),
+			[]string{"config.pciPassthruInfo"},
+			&hostConfig,
+		)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve PCI passthrough details for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		var passthruInfo []types.BaseHostPciPassthruInfo
+		if hostConfig.Config != nil {
+			passthruInfo = hostConfig.Config.PciPassthruInfo
+		}
+
+		var vms []mo.VirtualMachine
+		if len(hs.Vm) > 0 {
+			err := pc.Retrieve(
+				ctx,
+				hs.Vm,
+				[]string{"name", "config.template", "config.hardware.device"},
+				&vms,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to retrieve VM devices for host %s: %w",
+					hs.Name,
+					err,
+				)
+			}
+		}
+
+		set = append(set, NewHostSRIOVPassthroughCapacity(
+			hs,
+			passthruInfo,
+			vms,
+			criticalThreshold,
+			warningThreshold,
+		))
+	}
+
+	return set, nil
+
+}
+
+// NumVFs returns the number of SR-IOV virtual functions present across all
+// SR-IOV enabled physical functions on the HostSystem.
+func (hspc HostSRIOVPassthroughCapacity) NumVFs() int {
+	var num int
+	for _, d := range hspc.SRIOVDevices {
+		num += d.NumVFs
+	}
+
+	return num
+}
+
+// NumVFDemand returns the number of SR-IOV virtual functions demanded by
+// VMs registered on the HostSystem.
+func (hspc HostSRIOVPassthroughCapacity) NumVFDemand() int {
+	num := hspc.AutoVFDemand
+	for _, d := range hspc.SRIOVDevices {
+		num += d.Demand
+	}
+
+	return num
+}
+
+// VFUsage returns the percentage of SR-IOV virtual functions demanded by VMs
+// registered on the HostSystem. If VFs are demanded but none are present
+// 100 is returned.
+func (hspc HostSRIOVPassthroughCapacity) VFUsage() float64 {
+	numVFs := hspc.NumVFs()
+	demand := hspc.NumVFDemand()
+
+	switch {
+	case demand == 0:
+		return 0
+	case numVFs == 0:
+		return 100
+	default:
+		return float64(demand) / float64(numVFs) * 100
+	}
+}
+
+// OverallocatedSRIOVDevices returns the SR-IOV physical functions with more
+// assigned network adapters than virtual functions.
+func (hspc HostSRIOVPassthroughCapacity) OverallocatedSRIOVDevices() []HostSRIOVDevice {
+	var devices []HostSRIOVDevice
+	for _, d := range hspc.SRIOVDevices {
+		if d.Demand > d.NumVFs {
+			devices = append(devices, d)
+		}
+	}
+
+	return devices
+}
+
+// NumAssignedPassthroughDevices returns the number of DirectPath I/O devices
+// statically assigned to one or more VMs.
+func (hspc HostSRIOVPassthroughCapacity) NumAssignedPassthroughDevices() int {
+	var num int
+	for _, d := range hspc.PassthroughDevices {
+		if len(d.VMs) > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// SharedPassthroughDevices returns the DirectPath I/O devices statically
+// assigned to more than one VM. Only one of these VMs can be powered on at
+// a time.
+func (hspc HostSRIOVPassthroughCapacity) SharedPassthroughDevices() []HostPassthroughDevice {
+	var devices []HostPassthroughDevice
+	for _, d := range hspc.PassthroughDevices {
+		if len(d.VMs) > 1 {
+			devices = append(devices, d)
+		}
+	}
+
+	return devices
+}
+
+// HasDevices indicates whether the HostSystem has SR-IOV or DirectPath I/O
+// devices or VMs which demand them.
+func (hspc HostSRIOVPassthroughCapacity) HasDevices() bool {
+	return len(hspc.SRIOVDevices) > 0 ||
+		len(hspc.PassthroughDevices) > 0 ||
+		hspc.NumVFDemand() > 0 ||
+		hspc.DynamicDemand > 0 ||
+		len(hspc.MissingDevices) > 0
+}
+
+// IsCriticalState indicates whether SR-IOV virtual function demand has
+// crossed the CRITICAL threshold or exceeds capacity, or VMs registered on
+// the HostSystem demand devices which are not available.
+func (hspc HostSRIOVPassthroughCapacity) IsCriticalState() bool {
+	if hspc.Unavailable {
+		return false
+	}
+
+	return (hspc.NumVFDemand() > 0 && hspc.VFUsage() >= float64(hspc.CriticalThreshold)) ||
+		len(hspc.OverallocatedSRIOVDevices()) > 0 ||
+		hspc.DynamicUnsatisfied > 0 ||
+		len(hspc.MissingDevices) > 0
+}
+
+// IsWarningState indicates whether SR-IOV virtual function demand has
+// crossed the WARNING threshold or DirectPath I/O devices are statically
+// assigned to multiple VMs.
+func (hspc HostSRIOVPassthroughCapacity) IsWarningState() bool {
+	if hspc.Unavailable {
+		return false
+	}
+
+	return (hspc.NumVFDemand() > 0 && hspc.VFUsage() >= float64(hspc.WarningThreshold)) ||
+		len(hspc.SharedPassthroughDevices()) > 0
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem is in a
+// CRITICAL state.
+func (set HostSRIOVPassthroughCapacitySet) HasCriticalState() bool {
+	for _, hspc := range set {
+		if hspc.IsCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated HostSystem is in a
+// WARNING state.
+func (set HostSRIOVPassthroughCapacitySet) HasWarningState() bool {
+	for _, hspc := range set {
+		if hspc.IsWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose device details
+// were evaluated.
+func (set HostSRIOVPassthroughCapacitySet) NumHostsEvaluated() int {
+	var num int
+	for _, hspc := range set {
+		if !hspc.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose device
+// details could not be evaluated.
+func (set HostSRIOVPassthroughCapacitySet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumHostsWithIssues returns the number of HostSystems in a WARNING or
+// CRITICAL state.
+func (set HostSRIOVPassthroughCapacitySet) NumHostsWithIssues() int {
+	var num int
+	for _, hspc := range set {
+		if hspc.IsCriticalState() || hspc.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumVFs returns the number of SR-IOV virtual functions across all
+// evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumVFs() int {
+	var num int
+	for _, hspc := range set {
+		num += hspc.NumVFs()
+	}
+
+	return num
+}
+
+// NumVFDemand returns the number of SR-IOV virtual functions demanded by VMs
+// across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumVFDemand() int {
+	var num int
+	for _, hspc := range set {
+		num += hspc.NumVFDemand()
+	}
+
+	return num
+}
+
+// MaxVFUsage returns the highest percentage of SR-IOV virtual functions
+// demanded by VMs on any evaluated HostSystem.
+func (set HostSRIOVPassthroughCapacitySet) MaxVFUsage() float64 {
+	var highest float64
+	for _, hspc := range set {
+		if usage := hspc.VFUsage(); usage > highest {
+			highest = usage
+		}
+	}
+
+	return highest
+}
+
+// NumPassthroughDevices returns the number of DirectPath I/O enabled devices
+// across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumPassthroughDevices() int {
+	var num int
+	for _, hspc := range set {
+		num += len(hspc.PassthroughDevices)
+	}
+
+	return num
+}
+
+// NumAssignedPassthroughDevices returns the number of statically assigned
+// DirectPath I/O devices across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumAssignedPassthroughDevices() int {
+	var num int
+	for _, hspc := range set {
+		num += hspc.NumAssignedPassthroughDevices()
+	}
+
+	return num
+}
+
+// NumSharedPassthroughDevices returns the number of DirectPath I/O devices
+// statically assigned to more than one VM across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumSharedPassthroughDevices() int {
+	var num int
+	for _, hspc := range set {
+		num += len(hspc.SharedPassthroughDevices())
+	}
+
+	return num
+}
+
+// NumDynamicDemand returns the number of Dynamic DirectPath I/O devices
+// configured for VMs across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumDynamicDemand() int {
+	var num int
+	for _, hspc := range set {
+		num += hspc.DynamicDemand
+	}
+
+	return num
+}
+
+// NumDynamicUnsatisfied returns the number of Dynamic DirectPath I/O devices
+// which cannot be satisfied across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumDynamicUnsatisfied() int {
+	var num int
+	for _, hspc := range set {
+		num += hspc.DynamicUnsatisfied
+	}
+
+	return num
+}
+
+// NumMissingDevices returns the number of VM devices referencing host
+// devices which are not available across all evaluated HostSystems.
+func (set HostSRIOVPassthroughCapacitySet) NumMissingDevices() int {
+	var num int
+	for _, hspc := range set {
+		num += len(hspc.MissingDevices)
+	}
+
+	return num
+}
+
+// HostSRIOVPassthroughCapacityPerfData generates performance data metrics
+// from the given collection of evaluated HostSystems. If multiple
+// HostSystems are evaluated, virtual function counts are also emitted per
+// HostSystem.
+func HostSRIOVPassthroughCapacityPerfData(
+	set HostSRIOVPassthroughCapacitySet,
+	criticalThreshold int,
+	warningThreshold int,
+) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "sriov_vfs_total",
+			Value: fmt.Sprintf("%d", set.NumVFs()),
+			Min:   "0",
+		},
+		{
+			Label: "sriov_vfs_demand",
+			Value: fmt.Sprintf("%d", set.NumVFDemand()),
+			Min:   "0",
+		},
+		{
+			Label:             "sriov_vfs_max_usage",
+			Value:             fmt.Sprintf("%.2f", set.MaxVFUsage()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", warningThreshold),
+			Crit:              fmt.Sprintf("%d", criticalThreshold),
+			Min:               "0",
+		},
+		{
+			Label: "passthrough_devices_total",
+			Value: fmt.Sprintf("%d", set.NumPassthroughDevices()),
+			Min:   "0",
+		},
+		{
+			Label: "passthrough_devices_assigned",
+			Value: fmt.Sprintf("%d", set.NumAssignedPassthroughDevices()),
+			Min:   "0",
+		},
+		{
+			Label: "passthrough_devices_shared",
+			Value: fmt.Sprintf("%d", set.NumSharedPassthroughDevices()),
+			Min:   "0",
+		},
+		{
+			Label: "dynamic_passthrough_demand",
+			Value: fmt.Sprintf("%d", set.NumDynamicDemand()),
+			Min:   "0",
+		},
+		{
+			Label: "dynamic_passthrough_unsatisfied",
+			Value: fmt.Sprintf("%d", set.NumDynamicUnsatisfied()),
+			Min:   "0",
+		},
+		{
+			Label: "vm_devices_unavailable",
+			Value: fmt.Sprintf("%d", set.NumMissingDevices()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) > 1 {
+		for _, hspc := range set {
+			if hspc.Unavailable {
+				continue
+			}
+
+			pd = append(pd,
+				nagios.PerformanceData{
+					Label: PerfDataLabel(hspc.Host.Name, "sriov_vfs_total"),
+					Value: fmt.Sprintf("%d", hspc.NumVFs()),
+					Min:   "0",
+				},
+				nagios.PerformanceData{
+					Label: PerfDataLabel(hspc.Host.Name, "sriov_vfs_demand"),
+					Value: fmt.Sprintf("%d", hspc.NumVFDemand()),
+					Min:   "0",
+				},
+			)
+		}
+	}
+
+	return pd
+
+}
+
+// HostSRIOVPassthroughCapacityOneLineCheckSummary is used to generate a
+// one-line Nagios service check results summary. This is the line most
+// prominent in notifications.
+func HostSRIOVPassthroughCapacityOneLineCheckSummary(
+	stateLabel string,
+	set HostSRIOVPassthroughCapacitySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSRIOVPassthroughCapacityOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with SR-IOV or DirectPath I/O capacity issues detected (%d of %d VFs demanded, %d unavailable VM devices, evaluated %d hosts)",
+			stateLabel,
+			set.NumHostsWithIssues(),
+			set.NumVFDemand(),
+			set.NumVFs(),
+			set.NumMissingDevices()+set.NumDynamicUnsatisfied(),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No SR-IOV or DirectPath I/O capacity issues detected (%d of %d VFs demanded, evaluated %d hosts)",
+			stateLabel,
+			set.NumVFDemand(),
+			set.NumVFs(),
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostSRIOVPassthroughCapacityReport generates a summary of SR-IOV and
+// DirectPath I/O capacity issues for evaluated HostSystems along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func HostSRIOVPassthroughCapacityReport(
+	c *vim25.Client,
+	set HostSRIOVPassthroughCapacitySet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSRIOVPassthroughCapacityReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with SR-IOV or DirectPath I/O capacity issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, hspc := range set {
+		if !hspc.IsCriticalState() && !hspc.IsWarningState() {
+			continue
+		}
+		numProblemHosts++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%d of %d VFs demanded, %.2f%%)%s",
+			hspc.Host.Name,
+			hspc.NumVFDemand(),
+			hspc.NumVFs(),
+			hspc.VFUsage(),
+			nagios.CheckOutputEOL,
+		)
+
+		for _, d := range hspc.OverallocatedSRIOVDevices() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** SR-IOV device %s: %d adapters assigned, %d VFs present (VMs: %s)%s",
+				d.ID,
+				d.Demand,
+				d.NumVFs,
+				strings.Join(d.VMs, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, d := range hspc.SharedPassthroughDevices() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** DirectPath I/O device %s assigned to multiple VMs: %s%s",
+				d.ID,
+				strings.Join(d.VMs, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if hspc.DynamicUnsatisfied > 0 {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %d of %d Dynamic DirectPath I/O devices cannot be satisfied by an unassigned device%s",
+				hspc.DynamicUnsatisfied,
+				hspc.DynamicDemand,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, ref := range hspc.MissingDevices {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** VM %s: %s (%s) references unavailable %s device %s%s",
+				ref.VMName,
+				ref.DeviceLabel,
+				ref.Kind,
+				ref.Kind,
+				ref.PCIID,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sSR-IOV and DirectPath I/O devices per host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numHostsListed int
+	for _, hspc := range set {
+		if hspc.Unavailable {
+			numHostsListed++
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: unavailable (connection state: %s)%s",
+				hspc.Host.Name,
+				hspc.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		if !hspc.HasDevices() {
+			continue
+		}
+		numHostsListed++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %d SR-IOV devices (%d of %d VFs demanded), %d DirectPath I/O devices (%d assigned), %d Dynamic DirectPath I/O devices%s",
+			hspc.Host.Name,
+			len(hspc.SRIOVDevices),
+			hspc.NumVFDemand(),
+			hspc.NumVFs(),
+			len(hspc.PassthroughDevices),
+			hspc.NumAssignedPassthroughDevices(),
+			hspc.DynamicDemand,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numHostsListed == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
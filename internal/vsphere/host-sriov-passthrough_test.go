@@ -0,0 +1,388 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	sriovTestPFID       string = "0000:3b:00.0"
+	sriovTestGPUID      string = "0000:af:00.0"
+	sriovTestGPUVendor  int16  = 0x10de
+	sriovTestGPUDevice  int16  = 0x1db4
+	sriovTestNumVFs     int32  = 4
+	sriovTestCritical   int    = 90
+	sriovTestWarning    int    = 75
+	sriovTestAutoPFID   string = sriovAutomaticPrefix + sriovAutomaticUnassignedPFID
+	sriovTestUnknownPF  string = "0000:5e:00.0"
+	sriovTestUnknownGPU string = "0000:d8:00.0"
+)
+
+// sriovHost returns a host named esx1 with a single PCI device (the GPU).
+func sriovHost() mo.HostSystem {
+	var hs mo.HostSystem
+	hs.Name = "esx1"
+	hs.Runtime.ConnectionState = types.HostSystemConnectionStateConnected
+	hs.Hardware = &types.HostHardwareInfo{
+		PciDevice: []types.HostPciDevice{
+			{
+				Id:       sriovTestGPUID,
+				VendorId: sriovTestGPUVendor,
+				DeviceId: sriovTestGPUDevice,
+			},
+		},
+	}
+
+	return hs
+}
+
+// sriovPassthruInfo returns the passthrough details for the host: an SR-IOV
+// enabled physical function, a GPU enabled for DirectPath I/O and a device
+// which is passthrough capable but not enabled.
+func sriovPassthruInfo() []types.BaseHostPciPassthruInfo {
+	return []types.BaseHostPciPassthruInfo{
+		&types.HostSriovInfo{
+			HostPciPassthruInfo: types.HostPciPassthruInfo{
+				Id:              sriovTestPFID,
+				PassthruCapable: true,
+			},
+			SriovEnabled:       true,
+			SriovCapable:       true,
+			SriovActive:        true,
+			NumVirtualFunction: sriovTestNumVFs,
+		},
+		&types.HostPciPassthruInfo{
+			Id:              "0000:AF:00.0",
+			PassthruEnabled: true,
+			PassthruCapable: true,
+			PassthruActive:  true,
+		},
+		&types.HostPciPassthruInfo{
+			Id:              "0000:86:00.0",
+			PassthruCapable: true,
+		},
+	}
+}
+
+// sriovNIC returns an SR-IOV network adapter assigned to the given physical
+// function.
+func sriovNIC(pfID string) types.BaseVirtualDevice {
+	return &types.VirtualSriovEthernetCard{
+		SriovBacking: &types.VirtualSriovEthernetCardSriovBackingInfo{
+			PhysicalFunctionBacking: &types.VirtualPCIPassthroughDeviceBackingInfo{
+				Id: pfID,
+			},
+		},
+	}
+}
+
+// sriovPassthroughDevice returns a DirectPath I/O device statically
+// configured to use the given host PCI device.
+func sriovPassthroughDevice(id string) types.BaseVirtualDevice {
+	var d types.VirtualPCIPassthrough
+	d.DeviceInfo = &types.Description{Label: "PCI device 0"}
+	d.Backing = &types.VirtualPCIPassthroughDeviceBackingInfo{Id: id}
+
+	return &d
+}
+
+// sriovDynamicDevice returns a Dynamic DirectPath I/O device which allows
+// the given vendor and device IDs.
+func sriovDynamicDevice(vendorID int16, deviceID int16) types.BaseVirtualDevice {
+	var d types.VirtualPCIPassthrough
+	d.Backing = &types.VirtualPCIPassthroughDynamicBackingInfo{
+		AllowedDevice: []types.VirtualPCIPassthroughAllowedDevice{
+			{
+				VendorId: int32(vendorID),
+				DeviceId: int32(deviceID),
+			},
+		},
+	}
+
+	return &d
+}
+
+// sriovVM returns a VM with the given devices.
+func sriovVM(name string, devices ...types.BaseVirtualDevice) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Config = &types.VirtualMachineConfigInfo{}
+	vm.Config.Hardware.Device = devices
+
+	return vm
+}
+
+func TestSRIOVPhysicalFunctionID(t *testing.T) {
+	tests := map[string]struct {
+		nic  *types.VirtualSriovEthernetCard
+		want string
+	}{
+		"assigned physical function": {
+			nic:  sriovNIC(" 0000:3B:00.0 ").(*types.VirtualSriovEthernetCard),
+			want: sriovTestPFID,
+		},
+		"automatic assignment with assigned physical function": {
+			nic:  sriovNIC(sriovAutomaticPrefix + sriovTestPFID).(*types.VirtualSriovEthernetCard),
+			want: sriovTestPFID,
+		},
+		"automatic assignment without assigned physical function": {
+			nic: sriovNIC(sriovTestAutoPFID).(*types.VirtualSriovEthernetCard),
+		},
+		"no SR-IOV backing": {
+			nic: &types.VirtualSriovEthernetCard{},
+		},
+		"no physical function backing": {
+			nic: &types.VirtualSriovEthernetCard{
+				SriovBacking: &types.VirtualSriovEthernetCardSriovBackingInfo{},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := sriovPhysicalFunctionID(tt.nic); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewHostSRIOVPassthroughCapacity(t *testing.T) {
+	template := sriovVM("template1", sriovNIC(sriovTestUnknownPF))
+	template.Config.Template = true
+
+	vms := []mo.VirtualMachine{
+		sriovVM("vm1", sriovNIC(sriovTestPFID), sriovNIC(sriovTestAutoPFID)),
+		sriovVM("vm2", sriovNIC(sriovTestPFID), sriovPassthroughDevice(sriovTestGPUID)),
+		sriovVM("vm3", sriovPassthroughDevice(sriovTestUnknownGPU)),
+		sriovVM("vm4", sriovDynamicDevice(sriovTestGPUVendor, sriovTestGPUDevice)),
+		template,
+		{},
+	}
+
+	hspc := NewHostSRIOVPassthroughCapacity(
+		sriovHost(),
+		sriovPassthruInfo(),
+		vms,
+		sriovTestCritical,
+		sriovTestWarning,
+	)
+
+	wantSRIOV := []HostSRIOVDevice{
+		{ID: sriovTestPFID, Active: true, NumVFs: 4, Demand: 2, VMs: []string{"vm1", "vm2"}},
+	}
+	if d := cmp.Diff(wantSRIOV, hspc.SRIOVDevices); d != "" {
+		t.Errorf("SR-IOV devices (-want, +got):\n%s", d)
+	}
+
+	wantPassthrough := []HostPassthroughDevice{
+		{
+			ID:       sriovTestGPUID,
+			Active:   true,
+			VendorID: uint16(sriovTestGPUVendor),
+			DeviceID: uint16(sriovTestGPUDevice),
+			VMs:      []string{"vm2"},
+		},
+	}
+	if d := cmp.Diff(wantPassthrough, hspc.PassthroughDevices); d != "" {
+		t.Errorf("passthrough devices (-want, +got):\n%s", d)
+	}
+
+	wantMissing := []HostDeviceReference{
+		{
+			VMName:      "vm3",
+			DeviceLabel: "PCI device 0",
+			PCIID:       sriovTestUnknownGPU,
+			Kind:        HostDeviceKindPassthrough,
+		},
+	}
+	if d := cmp.Diff(wantMissing, hspc.MissingDevices); d != "" {
+		t.Errorf("missing devices (-want, +got):\n%s", d)
+	}
+
+	if hspc.NumVMs != 4 {
+		t.Errorf("want 4 VMs; got %d", hspc.NumVMs)
+	}
+
+	if hspc.AutoVFDemand != 1 {
+		t.Errorf("want 1 automatic VF demand; got %d", hspc.AutoVFDemand)
+	}
+
+	if hspc.DynamicDemand != 1 || hspc.DynamicUnsatisfied != 1 {
+		t.Errorf("want 1 unsatisfied dynamic demand; got %d of %d",
+			hspc.DynamicUnsatisfied, hspc.DynamicDemand)
+	}
+
+	if got := hspc.NumVFDemand(); got != 3 {
+		t.Errorf("want 3 VFs demanded; got %d", got)
+	}
+
+	if got := hspc.VFUsage(); got != 75 {
+		t.Errorf("want 75%% VF usage; got %.2f%%", got)
+	}
+}
+
+func TestHostSRIOVPassthroughCapacityState(t *testing.T) {
+	template := sriovVM("template1", sriovNIC(sriovTestUnknownPF))
+	template.Config.Template = true
+
+	tests := map[string]struct {
+		vms          []mo.VirtualMachine
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no device demand": {
+			vms: []mo.VirtualMachine{sriovVM("vm1")},
+		},
+		"virtual function demand below WARNING threshold": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovNIC(sriovTestPFID)),
+				sriovVM("vm2", sriovNIC(sriovTestPFID)),
+			},
+		},
+		"virtual function demand at WARNING threshold": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovNIC(sriovTestPFID), sriovNIC(sriovTestPFID)),
+				sriovVM("vm2", sriovNIC(sriovTestPFID)),
+			},
+			wantWarning: true,
+		},
+		"unassigned automatic adapters at WARNING threshold": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovNIC(sriovTestAutoPFID), sriovNIC(sriovTestAutoPFID)),
+				sriovVM("vm2", sriovNIC(sriovTestAutoPFID)),
+			},
+			wantWarning: true,
+		},
+		"virtual functions overallocated": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovNIC(sriovTestPFID), sriovNIC(sriovTestPFID)),
+				sriovVM("vm2", sriovNIC(sriovTestPFID), sriovNIC(sriovTestPFID)),
+				sriovVM("vm3", sriovNIC(sriovTestPFID)),
+			},
+			wantCritical: true,
+			wantWarning:  true,
+		},
+		"missing physical function": {
+			vms:          []mo.VirtualMachine{sriovVM("vm1", sriovNIC(sriovTestUnknownPF))},
+			wantCritical: true,
+		},
+		"missing passthrough device": {
+			vms:          []mo.VirtualMachine{sriovVM("vm1", sriovPassthroughDevice(sriovTestUnknownGPU))},
+			wantCritical: true,
+		},
+		"passthrough device shared by multiple VMs": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovPassthroughDevice(sriovTestGPUID)),
+				sriovVM("vm2", sriovPassthroughDevice(sriovTestGPUID)),
+			},
+			wantWarning: true,
+		},
+		"dynamic device satisfied by unassigned device": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovDynamicDevice(sriovTestGPUVendor, sriovTestGPUDevice)),
+			},
+		},
+		"dynamic devices exceed unassigned devices": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovDynamicDevice(sriovTestGPUVendor, sriovTestGPUDevice)),
+				sriovVM("vm2", sriovDynamicDevice(sriovTestGPUVendor, sriovTestGPUDevice)),
+			},
+			wantCritical: true,
+		},
+		"dynamic device for other device model": {
+			vms: []mo.VirtualMachine{
+				sriovVM("vm1", sriovDynamicDevice(sriovTestGPUVendor, 0x1eb8)),
+			},
+			wantCritical: true,
+		},
+		"template ignored": {
+			vms: []mo.VirtualMachine{template},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hspc := NewHostSRIOVPassthroughCapacity(
+				sriovHost(),
+				sriovPassthruInfo(),
+				tt.vms,
+				sriovTestCritical,
+				sriovTestWarning,
+			)
+
+			if got := hspc.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hspc.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestHostSRIOVPassthroughCapacityVFUsage(t *testing.T) {
+	tests := map[string]struct {
+		hspc HostSRIOVPassthroughCapacity
+		want float64
+	}{
+		"no demand": {
+			hspc: HostSRIOVPassthroughCapacity{
+				SRIOVDevices: []HostSRIOVDevice{{NumVFs: 8}},
+			},
+		},
+		"demand across devices": {
+			hspc: HostSRIOVPassthroughCapacity{
+				SRIOVDevices: []HostSRIOVDevice{
+					{NumVFs: 8, Demand: 2},
+					{NumVFs: 8, Demand: 4},
+				},
+				AutoVFDemand: 2,
+			},
+			want: 50,
+		},
+		"demand without virtual functions": {
+			hspc: HostSRIOVPassthroughCapacity{AutoVFDemand: 1},
+			want: 100,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.hspc.VFUsage(); got != tt.want {
+				t.Errorf("want %.2f%%; got %.2f%%", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHostSRIOVPassthroughCapacityUnavailable(t *testing.T) {
+	set := HostSRIOVPassthroughCapacitySet{
+		{
+			Host:              sriovHost(),
+			Unavailable:       true,
+			AutoVFDemand:      2,
+			CriticalThreshold: sriovTestCritical,
+			WarningThreshold:  sriovTestWarning,
+		},
+	}
+
+	if set.HasCriticalState() || set.HasWarningState() {
+		t.Error("want unavailable host to be excluded from state evaluation")
+	}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+}
@@ -0,0 +1,741 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostStoragePathsDegraded indicates that one or more dead storage paths
+// or datastores with a single (or no) live storage path were found on an
+// ESXi host.
+var ErrHostStoragePathsDegraded = errors.New("host storage paths degraded")
+
+// Multipath storage path states as reported by the HostStorageSystem.
+const (
+	HostStoragePathStateActive   string = "active"
+	HostStoragePathStateStandby  string = "standby"
+	HostStoragePathStateDisabled string = "disabled"
+	HostStoragePathStateDead     string = "dead"
+	HostStoragePathStateUnknown  string = "unknown"
+)
+
+// HostStorageLUN represents the multipathing state for a storage device
+// (LUN) attached to an ESXi host.
+type HostStorageLUN struct {
+	// CanonicalName is the canonical name of the storage device (e.g.,
+	// naa.600508b1001c3a6a).
+	CanonicalName string
+
+	// Local indicates whether the storage device is local to the host.
+	// Local storage devices are expected to have a single path.
+	Local bool
+
+	// Paths is the total number of paths to the storage device.
+	Paths int
+
+	// LivePaths is the number of active or standby paths to the storage
+	// device.
+	LivePaths int
+
+	// DeadPaths is the number of dead paths to the storage device.
+	DeadPaths int
+}
+
+// HostStorageDatastore represents the multipathing state for a VMFS
+// datastore mounted on an ESXi host.
+type HostStorageDatastore struct {
+	// Name is the name of the datastore.
+	Name string
+
+	// Local indicates whether the datastore is backed by local storage.
+	Local bool
+
+	// LUNs is the collection of canonical names for the storage devices
+	// backing the datastore extents.
+	LUNs []string
+
+	// LivePaths is the lowest number of live paths across all storage
+	// devices backing the datastore.
+	LivePaths int
+}
+
+// HostStoragePaths tracks the multipathing state for a specific HostSystem.
+type HostStoragePaths struct {
+	// Host is the HostSystem that the multipathing state was retrieved from.
+	Host mo.HostSystem
+
+	// LUNs is the collection of storage devices with multipathing details.
+	LUNs []HostStorageLUN
+
+	// Datastores is the collection of mounted VMFS datastores.
+	Datastores []HostStorageDatastore
+
+	// Unavailable indicates whether multipathing details could not be
+	// retrieved for the HostSystem due to its connection state.
+	Unavailable bool
+}
+
+// HostStoragePathsSet is a collection of HostStoragePaths values.
+type HostStoragePathsSet []HostStoragePaths
+
+// GetHostStorageSystem uses the HostStorageSystem for the specified
+// HostSystem to retrieve storage device and file system volume details.
+func GetHostStorageSystem(ctx context.Context, c *vim25.Client, hs mo.HostSystem) (mo.HostStorageSystem, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostStorageSystem func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var storageSystem mo.HostStorageSystem
+
+	host := object.NewHostSystem(c, hs.Reference())
+
+	ss, err := host.ConfigManager().StorageSystem(ctx)
+	if err != nil {
+		return storageSystem, fmt.Errorf(
+			"failed to retrieve storage system for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	pc := property.DefaultCollector(c)
+	err = pc.RetrieveOne(
+		ctx,
+		ss.Reference(),
+		[]string{"storageDeviceInfo", "fileSystemVolumeInfo"},
+		&storageSystem,
+	)
+	if err != nil {
+		return storageSystem, fmt.Errorf(
+			"failed to retrieve storage device details for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	return storageSystem, nil
+
+}
+
+// NewHostStoragePaths evaluates the multipathing details from the given
+// HostStorageSystem for a HostSystem.
+func NewHostStoragePaths(hs mo.HostSystem, storageSystem mo.HostStorageSystem) HostStoragePaths {
+
+	hsp := HostStoragePaths{
+		Host: hs,
+	}
+
+	if storageSystem.StorageDeviceInfo == nil {
+		return hsp
+	}
+
+	deviceInfo := storageSystem.StorageDeviceInfo
+
+	// Index storage devices by key so that multipath details can be matched
+	// to canonical names.
+	type scsiLunDetails struct {
+		canonicalName string
+		local         bool
+	}
+	scsiLuns := make(map[string]scsiLunDetails, len(deviceInfo.ScsiLun))
+	for _, lun := range deviceInfo.ScsiLun {
+		details := scsiLunDetails{
+			canonicalName: lun.GetScsiLun().CanonicalName,
+		}
+
+		if disk, ok := lun.(*types.HostScsiDisk); ok && disk.LocalDisk != nil {
+			details.local = *disk.LocalDisk
+		}
+
+		scsiLuns[lun.GetScsiLun().Key] = details
+	}
+
+	livePathsByName := make(map[string]int)
+
+	if deviceInfo.MultipathInfo != nil {
+		for _, lu := range deviceInfo.MultipathInfo.Lun {
+			details, ok := scsiLuns[lu.Lun]
+			if !ok {
+				details = scsiLunDetails{canonicalName: lu.Id}
+			}
+
+			lun := HostStorageLUN{
+				CanonicalName: details.canonicalName,
+				Local:         details.local,
+				Paths:         len(lu.Path),
+			}
+
+			for _, path := range lu.Path {
+				switch strings.ToLower(path.PathState) {
+				case HostStoragePathStateActive, HostStoragePathStateStandby:
+					lun.LivePaths++
+				case HostStoragePathStateDead:
+					lun.DeadPaths++
+				}
+			}
+
+			livePathsByName[lun.CanonicalName] = lun.LivePaths
+			hsp.LUNs = append(hsp.LUNs, lun)
+		}
+	}
+
+	for _, mount := range storageSystem.FileSystemVolumeInfo.MountInfo {
+		vmfs, ok := mount.Volume.(*types.HostVmfsVolume)
+		if !ok {
+			continue
+		}
+
+		ds := HostStorageDatastore{
+			Name:      vmfs.Name,
+			LivePaths: -1,
+		}
+
+		if vmfs.Local != nil {
+			ds.Local = *vmfs.Local
+		}
+
+		for _, extent := range vmfs.Extent {
+			ds.LUNs = append(ds.LUNs, extent.DiskName)
+
+			livePaths := livePathsByName[extent.DiskName]
+			if ds.LivePaths == -1 || livePaths < ds.LivePaths {
+				ds.LivePaths = livePaths
+			}
+		}
+
+		if ds.LivePaths == -1 {
+			ds.LivePaths = 0
+		}
+
+		hsp.Datastores = append(hsp.Datastores, ds)
+	}
+
+	sort.Slice(hsp.Datastores, func(i, j int) bool {
+		return strings.ToLower(hsp.Datastores[i].Name) < strings.ToLower(hsp.Datastores[j].Name)
+	})
+
+	return hsp
+
+}
+
+// GetHostStoragePathsSet retrieves and evaluates the multipathing details
+// for each given HostSystem. HostSystems which are not connected are flagged
+// as unavailable and are not evaluated.
+func GetHostStoragePathsSet(ctx context.Context, c *vim25.Client, hss []mo.HostSystem) (HostStoragePathsSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostStoragePathsSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(HostStoragePathsSet, 0, len(hss))
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping storage path evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, HostStoragePaths{Host: hs, Unavailable: true})
+
+			continue
+		}
+
+		storageSystem, err := GetHostStorageSystem(ctx, c, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, NewHostStoragePaths(hs, storageSystem))
+	}
+
+	return set, nil
+
+}
+
+// IsNoPath indicates whether the (non-local) datastore has no live storage
+// paths.
+func (ds HostStorageDatastore) IsNoPath() bool {
+	return !ds.Local && ds.LivePaths == 0
+}
+
+// IsSinglePath indicates whether the (non-local) datastore has a single
+// live storage path.
+func (ds HostStorageDatastore) IsSinglePath() bool {
+	return !ds.Local && ds.LivePaths == 1
+}
+
+// NumPaths returns the total number of storage paths for the HostSystem.
+func (hsp HostStoragePaths) NumPaths() int {
+	var num int
+	for _, lun := range hsp.LUNs {
+		num += lun.Paths
+	}
+
+	return num
+}
+
+// NumLivePaths returns the number of active or standby storage paths for
+// the HostSystem.
+func (hsp HostStoragePaths) NumLivePaths() int {
+	var num int
+	for _, lun := range hsp.LUNs {
+		num += lun.LivePaths
+	}
+
+	return num
+}
+
+// NumDeadPaths returns the number of dead storage paths for the HostSystem.
+func (hsp HostStoragePaths) NumDeadPaths() int {
+	var num int
+	for _, lun := range hsp.LUNs {
+		num += lun.DeadPaths
+	}
+
+	return num
+}
+
+// NoPathDatastores returns the (non-local) datastores with no live storage
+// paths.
+func (hsp HostStoragePaths) NoPathDatastores() []HostStorageDatastore {
+	var dss []HostStorageDatastore
+	for _, ds := range hsp.Datastores {
+		if ds.IsNoPath() {
+			dss = append(dss, ds)
+		}
+	}
+
+	return dss
+}
+
+// SinglePathDatastores returns the (non-local) datastores with a single
+// live storage path.
+func (hsp HostStoragePaths) SinglePathDatastores() []HostStorageDatastore {
+	var dss []HostStorageDatastore
+	for _, ds := range hsp.Datastores {
+		if ds.IsSinglePath() {
+			dss = append(dss, ds)
+		}
+	}
+
+	return dss
+}
+
+// HasCriticalState indicates whether any (non-local) datastore mounted on
+// the HostSystem has no live storage paths.
+func (hsp HostStoragePaths) HasCriticalState() bool {
+	return len(hsp.NoPathDatastores()) > 0
+}
+
+// HasWarningState indicates whether the HostSystem has dead storage paths
+// or any (non-local) datastore with a single live storage path.
+func (hsp HostStoragePaths) HasWarningState() bool {
+	return hsp.NumDeadPaths() > 0 || len(hsp.SinglePathDatastores()) > 0
+}
+
+// HasCriticalState indicates whether any evaluated HostSystem has
+// (non-local) datastores with no live storage paths.
+func (set HostStoragePathsSet) HasCriticalState() bool {
+	for _, hsp := range set {
+		if hsp.HasCriticalState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasWarningState indicates whether any evaluated HostSystem has dead
+// storage paths or (non-local) datastores with a single live storage path.
+func (set HostStoragePathsSet) HasWarningState() bool {
+	for _, hsp := range set {
+		if hsp.HasWarningState() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NumHostsEvaluated returns the number of HostSystems whose multipathing
+// details were evaluated.
+func (set HostStoragePathsSet) NumHostsEvaluated() int {
+	var num int
+	for _, hsp := range set {
+		if !hsp.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnavailable returns the number of HostSystems whose multipathing
+// details could not be evaluated.
+func (set HostStoragePathsSet) NumHostsUnavailable() int {
+	return len(set) - set.NumHostsEvaluated()
+}
+
+// NumPaths returns the total number of storage paths across all evaluated
+// HostSystems.
+func (set HostStoragePathsSet) NumPaths() int {
+	var num int
+	for _, hsp := range set {
+		num += hsp.NumPaths()
+	}
+
+	return num
+}
+
+// NumLivePaths returns the number of active or standby storage paths across
+// all evaluated HostSystems.
+func (set HostStoragePathsSet) NumLivePaths() int {
+	var num int
+	for _, hsp := range set {
+		num += hsp.NumLivePaths()
+	}
+
+	return num
+}
+
+// NumDeadPaths returns the number of dead storage paths across all
+// evaluated HostSystems.
+func (set HostStoragePathsSet) NumDeadPaths() int {
+	var num int
+	for _, hsp := range set {
+		num += hsp.NumDeadPaths()
+	}
+
+	return num
+}
+
+// NumLUNs returns the number of storage devices across all evaluated
+// HostSystems.
+func (set HostStoragePathsSet) NumLUNs() int {
+	var num int
+	for _, hsp := range set {
+		num += len(hsp.LUNs)
+	}
+
+	return num
+}
+
+// NumSinglePathDatastores returns the number of (non-local) datastores with
+// a single live storage path across all evaluated HostSystems.
+func (set HostStoragePathsSet) NumSinglePathDatastores() int {
+	var num int
+	for _, hsp := range set {
+		num += len(hsp.SinglePathDatastores())
+	}
+
+	return num
+}
+
+// NumNoPathDatastores returns the number of (non-local) datastores with no
+// live storage paths across all evaluated HostSystems.
+func (set HostStoragePathsSet) NumNoPathDatastores() int {
+	var num int
+	for _, hsp := range set {
+		num += len(hsp.NoPathDatastores())
+	}
+
+	return num
+}
+
+// HostStoragePathsPerfData generates performance data metrics from the given
+// collection of evaluated HostSystems. If multiple HostSystems are
+// evaluated, path counts are also emitted per HostSystem.
+func HostStoragePathsPerfData(set HostStoragePathsSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", set.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "luns",
+			Value: fmt.Sprintf("%d", set.NumLUNs()),
+			Min:   "0",
+		},
+		{
+			Label: "paths_total",
+			Value: fmt.Sprintf("%d", set.NumPaths()),
+			Min:   "0",
+		},
+		{
+			Label: "paths_live",
+			Value: fmt.Sprintf("%d", set.NumLivePaths()),
+			Min:   "0",
+		},
+		{
+			Label: "paths_dead",
+			Value: fmt.Sprintf("%d", set.NumDeadPaths()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_single_path",
+			Value: fmt.Sprintf("%d", set.NumSinglePathDatastores()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_no_path",
+			Value: fmt.Sprintf("%d", set.NumNoPathDatastores()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) > 1 {
+		for _, hsp := range set {
+			if hsp.Unavailable {
+				continue
+			}
+
+			pd = append(pd,
+				nagios.PerformanceData{
+					Label: PerfDataLabel(hsp.Host.Name, "paths_total"),
+					Value: fmt.Sprintf("%d", hsp.NumPaths()),
+					Min:   "0",
+				},
+				nagios.PerformanceData{
+					Label: PerfDataLabel(hsp.Host.Name, "paths_dead"),
+					Value: fmt.Sprintf("%d", hsp.NumDeadPaths()),
+					Min:   "0",
+				},
+			)
+		}
+	}
+
+	return pd
+
+}
+
+// HostStoragePathsOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostStoragePathsOneLineCheckSummary(
+	stateLabel string,
+	set HostStoragePathsSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostStoragePathsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d dead paths, %d datastores with a single path and %d datastores with no live paths detected (evaluated %d paths, %d hosts)",
+			stateLabel,
+			set.NumDeadPaths(),
+			set.NumSinglePathDatastores(),
+			set.NumNoPathDatastores(),
+			set.NumPaths(),
+			set.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No storage path issues detected (evaluated %d paths, %d hosts)",
+			stateLabel,
+			set.NumPaths(),
+			set.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostStoragePathsReport generates a summary of storage path issues for
+// evaluated HostSystems along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body
+// of many notifications.
+func HostStoragePathsReport(
+	c *vim25.Client,
+	set HostStoragePathsSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostStoragePathsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Hosts with storage path issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemHosts int
+	for _, hsp := range set {
+		if !hsp.HasCriticalState() && !hsp.HasWarningState() {
+			continue
+		}
+		numProblemHosts++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			hsp.Host.Name,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, lun := range hsp.LUNs {
+			if lun.DeadPaths == 0 {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"** LUN %s: %d of %d paths dead%s",
+				lun.CanonicalName,
+				lun.DeadPaths,
+				lun.Paths,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, ds := range hsp.NoPathDatastores() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Datastore %s: no live paths (LUNs: %s)%s",
+				ds.Name,
+				strings.Join(ds.LUNs, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, ds := range hsp.SinglePathDatastores() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Datastore %s: single live path (LUNs: %s)%s",
+				ds.Name,
+				strings.Join(ds.LUNs, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if numProblemHosts == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sStorage paths per host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, hsp := range set {
+		if hsp.Unavailable {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: unavailable (connection state: %s)%s",
+				hsp.Host.Name,
+				hsp.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %d LUNs, %d paths (%d live, %d dead), %d VMFS datastores%s",
+			hsp.Host.Name,
+			len(hsp.LUNs),
+			hsp.NumPaths(),
+			hsp.NumLivePaths(),
+			hsp.NumDeadPaths(),
+			len(hsp.Datastores),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// storagePathsSystem returns a storage system with a single VMFS datastore
+// backed by a storage device with paths in the given states.
+func storagePathsSystem(local bool, pathStates ...string) mo.HostStorageSystem {
+	paths := make([]types.HostMultipathInfoPath, 0, len(pathStates))
+	for _, state := range pathStates {
+		paths = append(paths, types.HostMultipathInfoPath{PathState: state})
+	}
+
+	disk := types.HostScsiDisk{LocalDisk: &local}
+	disk.Key = "key-vim.host.ScsiDisk-1"
+	disk.CanonicalName = "naa.600508b1001c3a6a"
+
+	vmfs := types.HostVmfsVolume{
+		Extent: []types.HostScsiDiskPartition{
+			{DiskName: disk.CanonicalName, Partition: 1},
+		},
+		Local: &local,
+	}
+	vmfs.Name = "ds1"
+
+	return mo.HostStorageSystem{
+		StorageDeviceInfo: &types.HostStorageDeviceInfo{
+			ScsiLun: []types.BaseScsiLun{&disk},
+			MultipathInfo: &types.HostMultipathInfo{
+				Lun: []types.HostMultipathInfoLogicalUnit{
+					{
+						Key:  "key-vim.host.MultipathInfo.LogicalUnit-1",
+						Id:   "0200000000600508b1001c3a6a",
+						Lun:  disk.Key,
+						Path: paths,
+					},
+				},
+			},
+		},
+		FileSystemVolumeInfo: types.HostFileSystemVolumeInfo{
+			MountInfo: []types.HostFileSystemMountInfo{
+				{Volume: &vmfs},
+			},
+		},
+	}
+}
+
+func TestNewHostStoragePaths(t *testing.T) {
+	active := HostStoragePathStateActive
+	standby := HostStoragePathStateStandby
+	dead := HostStoragePathStateDead
+
+	var hs mo.HostSystem
+	hs.Name = "esx1"
+
+	tests := map[string]struct {
+		storageSystem mo.HostStorageSystem
+		wantLUNs      []HostStorageLUN
+		wantCritical  bool
+		wantWarning   bool
+	}{
+		"multiple live paths": {
+			storageSystem: storagePathsSystem(false, active, standby),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Paths: 2, LivePaths: 2},
+			},
+		},
+		"single live path": {
+			storageSystem: storagePathsSystem(false, "ACTIVE"),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Paths: 1, LivePaths: 1},
+			},
+			wantWarning: true,
+		},
+		"dead path with multiple live paths": {
+			storageSystem: storagePathsSystem(false, active, standby, dead),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Paths: 3, LivePaths: 2, DeadPaths: 1},
+			},
+			wantWarning: true,
+		},
+		"no live paths": {
+			storageSystem: storagePathsSystem(false, dead, dead),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Paths: 2, DeadPaths: 2},
+			},
+			wantCritical: true,
+			wantWarning:  true,
+		},
+		"disabled path is neither live nor dead": {
+			storageSystem: storagePathsSystem(false, active, standby, "disabled"),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Paths: 3, LivePaths: 2},
+			},
+		},
+		"local storage with single path": {
+			storageSystem: storagePathsSystem(true, active),
+			wantLUNs: []HostStorageLUN{
+				{CanonicalName: "naa.600508b1001c3a6a", Local: true, Paths: 1, LivePaths: 1},
+			},
+		},
+		"no storage device details": {
+			storageSystem: mo.HostStorageSystem{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			hsp := NewHostStoragePaths(hs, tt.storageSystem)
+
+			if d := cmp.Diff(tt.wantLUNs, hsp.LUNs); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := hsp.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := hsp.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestNewHostStoragePathsDatastores(t *testing.T) {
+	storageSystem := storagePathsSystem(false,
+		HostStoragePathStateActive,
+		HostStoragePathStateStandby,
+	)
+
+	// Add a second storage device not listed in the SCSI LUN details along
+	// with a datastore spanning both devices.
+	storageSystem.StorageDeviceInfo.MultipathInfo.Lun = append(
+		storageSystem.StorageDeviceInfo.MultipathInfo.Lun,
+		types.HostMultipathInfoLogicalUnit{
+			Key: "key-vim.host.MultipathInfo.LogicalUnit-2",
+			Id:  "naa.600508b1001c3a6b",
+			Lun: "key-vim.host.ScsiDisk-2",
+			Path: []types.HostMultipathInfoPath{
+				{PathState: HostStoragePathStateActive},
+			},
+		},
+	)
+
+	spanned := types.HostVmfsVolume{
+		Extent: []types.HostScsiDiskPartition{
+			{DiskName: "naa.600508b1001c3a6a", Partition: 2},
+			{DiskName: "naa.600508b1001c3a6b", Partition: 1},
+		},
+	}
+	spanned.Name = "Archive"
+
+	missing := types.HostVmfsVolume{}
+	missing.Name = "empty"
+
+	storageSystem.FileSystemVolumeInfo.MountInfo = append(
+		storageSystem.FileSystemVolumeInfo.MountInfo,
+		types.HostFileSystemMountInfo{Volume: &spanned},
+		types.HostFileSystemMountInfo{Volume: &missing},
+		types.HostFileSystemMountInfo{Volume: &types.HostNasVolume{}},
+	)
+
+	hsp := NewHostStoragePaths(mo.HostSystem{}, storageSystem)
+
+	want := []HostStorageDatastore{
+		{Name: "Archive", LUNs: []string{"naa.600508b1001c3a6a", "naa.600508b1001c3a6b"}, LivePaths: 1},
+		{Name: "ds1", LUNs: []string{"naa.600508b1001c3a6a"}, LivePaths: 2},
+		{Name: "empty", LivePaths: 0},
+	}
+
+	if d := cmp.Diff(want, hsp.Datastores); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := hsp.LUNs[1].CanonicalName; got != "naa.600508b1001c3a6b" {
+		t.Errorf("want multipath ID used as canonical name; got %q", got)
+	}
+
+	set := HostStoragePathsSet{hsp, {Unavailable: true}}
+
+	if got := set.NumHostsUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumLUNs(); got != 2 {
+		t.Errorf("want 2 LUNs; got %d", got)
+	}
+
+	if got := set.NumPaths(); got != 3 {
+		t.Errorf("want 3 paths; got %d", got)
+	}
+
+	if got := set.NumSinglePathDatastores(); got != 1 {
+		t.Errorf("want 1 single path datastore; got %d", got)
+	}
+
+	if got := set.NumNoPathDatastores(); got != 1 {
+		t.Errorf("want 1 datastore without paths; got %d", got)
+	}
+}
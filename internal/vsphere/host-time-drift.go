@@ -0,0 +1,608 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostTimeDriftThresholdCrossed indicates that the clock for one or more
+// ESXi hosts (or vCenter) has drifted further than permitted from the
+// reference clock.
+var ErrHostTimeDriftThresholdCrossed = errors.New("host clock drift exceeds specified threshold")
+
+// HostTimeDriftThresholds represents the user-specified thresholds (in
+// seconds) for permitted clock drift.
+type HostTimeDriftThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// HostTimeDrift tracks the clock drift for a specific HostSystem.
+type HostTimeDrift struct {
+	// Host is the HostSystem that the current time was retrieved from.
+	Host mo.HostSystem
+
+	// HostTime is the current time reported by the HostSystem.
+	HostTime time.Time
+
+	// Drift is the difference between the HostSystem clock and the clock of
+	// the system running this plugin. A positive value indicates that the
+	// HostSystem clock is ahead.
+	Drift time.Duration
+
+	// VCenterDrift is the difference between the HostSystem clock and the
+	// vCenter server clock. A positive value indicates that the HostSystem
+	// clock is ahead. This is only set if comparison against the vCenter
+	// server clock was requested.
+	VCenterDrift *time.Duration
+
+	// NTPServers is the list of NTP servers configured for the HostSystem.
+	NTPServers []string
+
+	// Unavailable indicates whether the current time could not be retrieved
+	// for the HostSystem due to its connection state.
+	Unavailable bool
+
+	// Thresholds are the user-specified permitted drift values.
+	Thresholds HostTimeDriftThresholds
+}
+
+// HostTimeDriftSummary tracks the clock drift for a collection of
+// HostSystems along with the (optional) vCenter server clock drift.
+type HostTimeDriftSummary struct {
+	// Hosts is the collection of evaluated HostSystems.
+	Hosts []HostTimeDrift
+
+	// VCenterCompared indicates whether the vCenter server clock was
+	// retrieved for comparison.
+	VCenterCompared bool
+
+	// VCenterDrift is the difference between the vCenter server clock and
+	// the clock of the system running this plugin. A positive value
+	// indicates that the vCenter server clock is ahead.
+	VCenterDrift time.Duration
+
+	// Thresholds are the user-specified permitted drift values.
+	Thresholds HostTimeDriftThresholds
+}
+
+// absDriftSeconds returns the absolute value of the given drift in seconds.
+func absDriftSeconds(drift time.Duration) float64 {
+	return math.Abs(drift.Seconds())
+}
+
+// timeDriftQuery runs the given function to retrieve a remote clock value
+// and returns the difference between that value and the local clock at the
+// midpoint of the request. Using the midpoint limits the effect of request
+// latency on the calculated drift.
+func timeDriftQuery(fn func() (*time.Time, error)) (time.Time, time.Duration, error) {
+	start := time.Now()
+
+	remote, err := fn()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	if remote == nil {
+		return time.Time{}, 0, errors.New("empty time value returned")
+	}
+
+	midpoint := start.Add(time.Since(start) / 2)
+
+	return *remote, remote.Sub(midpoint), nil
+}
+
+// MaxDrift returns the largest absolute drift value (in seconds) for the
+// HostSystem.
+func (htd HostTimeDrift) MaxDrift() float64 {
+	highest := absDriftSeconds(htd.Drift)
+
+	if htd.VCenterDrift != nil {
+		if vc := absDriftSeconds(*htd.VCenterDrift); vc > highest {
+			highest = vc
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether the HostSystem clock drift has crossed
+// the CRITICAL threshold.
+func (htd HostTimeDrift) IsCriticalState() bool {
+	return !htd.Unavailable && htd.MaxDrift() > float64(htd.Thresholds.Critical)
+}
+
+// IsWarningState indicates whether the HostSystem clock drift has crossed
+// the WARNING threshold, but not the CRITICAL threshold.
+func (htd HostTimeDrift) IsWarningState() bool {
+	return !htd.Unavailable &&
+		!htd.IsCriticalState() &&
+		htd.MaxDrift() > float64(htd.Thresholds.Warning)
+}
+
+// GetHostTimeDriftSummary retrieves the current time for each of the given
+// HostSystems and compares it against the clock of the system running this
+// plugin and optionally the vCenter server clock. HostSystems which are not
+// connected are flagged as unavailable and are not evaluated.
+func GetHostTimeDriftSummary(
+	ctx context.Context,
+	c *vim25.Client,
+	hss []mo.HostSystem,
+	compareVCenter bool,
+	thresholds HostTimeDriftThresholds,
+) (HostTimeDriftSummary, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostTimeDriftSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	summary := HostTimeDriftSummary{
+		Hosts:           make([]HostTimeDrift, 0, len(hss)),
+		VCenterCompared: compareVCenter,
+		Thresholds:      thresholds,
+	}
+
+	if compareVCenter {
+		var err error
+		_, summary.VCenterDrift, err = timeDriftQuery(func() (*time.Time, error) {
+			return methods.GetCurrentTime(ctx, c)
+		})
+		if err != nil {
+			return HostTimeDriftSummary{}, fmt.Errorf(
+				"failed to retrieve vCenter server time: %w",
+				err,
+			)
+		}
+	}
+
+	pc := property.DefaultCollector(c)
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping time evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			summary.Hosts = append(summary.Hosts, HostTimeDrift{
+				Host:        hs,
+				Unavailable: true,
+				Thresholds:  thresholds,
+			})
+
+			continue
+		}
+
+		dts, err := object.NewHostSystem(c, hs.Reference()).
+			ConfigManager().DateTimeSystem(ctx)
+		if err != nil {
+			return HostTimeDriftSummary{}, fmt.Errorf(
+				"failed to retrieve date/time system for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		// Retrieve the NTP configuration before querying the current time so
+		// that the property retrieval does not delay the time query.
+		var dtsMo mo.HostDateTimeSystem
+		if err := pc.RetrieveOne(ctx, dts.Reference(), []string{"dateTimeInfo"}, &dtsMo); err != nil {
+			return HostTimeDriftSummary{}, fmt.Errorf(
+				"failed to retrieve date/time configuration for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		hostTime, drift, err := timeDriftQuery(func() (*time.Time, error) {
+			return dts.Query(ctx)
+		})
+		if err != nil {
+			return HostTimeDriftSummary{}, fmt.Errorf(
+				"failed to retrieve current time for host %s: %w",
+				hs.Name,
+				err,
+			)
+		}
+
+		htd := HostTimeDrift{
+			Host:       hs,
+			HostTime:   hostTime,
+			Drift:      drift,
+			Thresholds: thresholds,
+		}
+
+		if dtsMo.DateTimeInfo.NtpConfig != nil {
+			htd.NTPServers = dtsMo.DateTimeInfo.NtpConfig.Server
+		}
+
+		if compareVCenter {
+			// Both drift values are relative to the local clock, so the
+			// difference between them is the drift between the host and
+			// vCenter server clocks.
+			vcDrift := drift - summary.VCenterDrift
+			htd.VCenterDrift = &vcDrift
+		}
+
+		summary.Hosts = append(summary.Hosts, htd)
+	}
+
+	sort.Slice(summary.Hosts, func(i, j int) bool {
+		return strings.ToLower(summary.Hosts[i].Host.Name) < strings.ToLower(summary.Hosts[j].Host.Name)
+	})
+
+	return summary, nil
+}
+
+// VCenterIsCriticalState indicates whether the vCenter server clock drift
+// has crossed the CRITICAL threshold.
+func (s HostTimeDriftSummary) VCenterIsCriticalState() bool {
+	return s.VCenterCompared &&
+		absDriftSeconds(s.VCenterDrift) > float64(s.Thresholds.Critical)
+}
+
+// VCenterIsWarningState indicates whether the vCenter server clock drift has
+// crossed the WARNING threshold, but not the CRITICAL threshold.
+func (s HostTimeDriftSummary) VCenterIsWarningState() bool {
+	return s.VCenterCompared &&
+		!s.VCenterIsCriticalState() &&
+		absDriftSeconds(s.VCenterDrift) > float64(s.Thresholds.Warning)
+}
+
+// NumHostsEvaluated returns the number of HostSystems with a retrieved
+// current time.
+func (s HostTimeDriftSummary) NumHostsEvaluated() int {
+	return len(s.Hosts) - s.NumHostsUnavailable()
+}
+
+// NumHostsUnavailable returns the number of HostSystems which could not be
+// evaluated due to their connection state.
+func (s HostTimeDriftSummary) NumHostsUnavailable() int {
+	var num int
+	for _, htd := range s.Hosts {
+		if htd.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsCritical returns the number of HostSystems with clock drift
+// crossing the CRITICAL threshold.
+func (s HostTimeDriftSummary) NumHostsCritical() int {
+	var num int
+	for _, htd := range s.Hosts {
+		if htd.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumHostsWarning returns the number of HostSystems with clock drift
+// crossing the WARNING threshold, but not the CRITICAL threshold.
+func (s HostTimeDriftSummary) NumHostsWarning() int {
+	var num int
+	for _, htd := range s.Hosts {
+		if htd.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// MaxDrift returns the largest absolute drift value (in seconds) for all
+// evaluated HostSystems and the vCenter server (if compared).
+func (s HostTimeDriftSummary) MaxDrift() float64 {
+	var highest float64
+	for _, htd := range s.Hosts {
+		if htd.Unavailable {
+			continue
+		}
+
+		if drift := htd.MaxDrift(); drift > highest {
+			highest = drift
+		}
+	}
+
+	if s.VCenterCompared {
+		if vc := absDriftSeconds(s.VCenterDrift); vc > highest {
+			highest = vc
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether the clock drift for any evaluated
+// HostSystem or the vCenter server has crossed the CRITICAL threshold.
+func (s HostTimeDriftSummary) IsCriticalState() bool {
+	return s.NumHostsCritical() > 0 || s.VCenterIsCriticalState()
+}
+
+// IsWarningState indicates whether the clock drift for any evaluated
+// HostSystem or the vCenter server has crossed the WARNING threshold.
+func (s HostTimeDriftSummary) IsWarningState() bool {
+	return s.NumHostsWarning() > 0 || s.VCenterIsWarningState()
+}
+
+// HostTimeDriftPerfData generates performance data metrics from the given
+// clock drift evaluation results.
+func HostTimeDriftPerfData(s HostTimeDriftSummary) []nagios.PerformanceData {
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts",
+			Value: fmt.Sprintf("%d", len(s.Hosts)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", s.NumHostsEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", s.NumHostsUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_drift_critical",
+			Value: fmt.Sprintf("%d", s.NumHostsCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_drift_warning",
+			Value: fmt.Sprintf("%d", s.NumHostsWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "max_drift",
+			Value:             fmt.Sprintf("%.3f", s.MaxDrift()),
+			UnitOfMeasurement: "s",
+			Warn:              fmt.Sprintf("%d", s.Thresholds.Warning),
+			Crit:              fmt.Sprintf("%d", s.Thresholds.Critical),
+			Min:               "0",
+		},
+	}
+
+	if s.VCenterCompared {
+		pd = append(pd, nagios.PerformanceData{
+			Label:             "vcenter_drift",
+			Value:             fmt.Sprintf("%.3f", absDriftSeconds(s.VCenterDrift)),
+			UnitOfMeasurement: "s",
+			Warn:              fmt.Sprintf("%d", s.Thresholds.Warning),
+			Crit:              fmt.Sprintf("%d", s.Thresholds.Critical),
+			Min:               "0",
+		})
+	}
+
+	return pd
+}
+
+// HostTimeDriftOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func HostTimeDriftOneLineCheckSummary(
+	stateLabel string,
+	s HostTimeDriftSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"s": s,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostTimeDriftOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var vcenterNote string
+	if s.VCenterIsCriticalState() || s.VCenterIsWarningState() {
+		vcenterNote = fmt.Sprintf(
+			", vCenter drift %.1fs",
+			absDriftSeconds(s.VCenterDrift),
+		)
+	}
+
+	switch {
+	case s.IsCriticalState() || s.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with clock drift exceeding threshold (%d CRITICAL, %d WARNING%s; max drift %.1fs, evaluated %d hosts)",
+			stateLabel,
+			s.NumHostsCritical()+s.NumHostsWarning(),
+			s.NumHostsCritical(),
+			s.NumHostsWarning(),
+			vcenterNote,
+			s.MaxDrift(),
+			s.NumHostsEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts with clock drift exceeding threshold (max drift %.1fs, evaluated %d hosts)",
+			stateLabel,
+			s.MaxDrift(),
+			s.NumHostsEvaluated(),
+		)
+	}
+}
+
+// HostTimeDriftReport generates a summary of clock drift for evaluated
+// HostSystems along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostTimeDriftReport(
+	c *vim25.Client,
+	s HostTimeDriftSummary,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostTimeDriftReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	stateSuffix := func(critical bool, warning bool) string {
+		switch {
+		case critical:
+			return " [" + nagios.StateCRITICALLabel + "]"
+		case warning:
+			return " [" + nagios.StateWARNINGLabel + "]"
+		default:
+			return ""
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Host clock drift (positive values indicate host clock is ahead):%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if s.NumHostsEvaluated() == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, htd := range s.Hosts {
+		if htd.Unavailable {
+			continue
+		}
+
+		ntpServers := "none"
+		if len(htd.NTPServers) > 0 {
+			ntpServers = strings.Join(htd.NTPServers, ", ")
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s: %+.3fs (host time: %s, NTP servers: %s)%s",
+			htd.Host.Name,
+			stateSuffix(htd.IsCriticalState(), htd.IsWarningState()),
+			htd.Drift.Seconds(),
+			htd.HostTime.UTC().Format(time.RFC3339),
+			ntpServers,
+			nagios.CheckOutputEOL,
+		)
+
+		if htd.VCenterDrift != nil {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** drift from vCenter: %+.3fs%s",
+				htd.VCenterDrift.Seconds(),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if s.VCenterCompared {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%svCenter clock drift%s: %+.3fs%s",
+			nagios.CheckOutputEOL,
+			stateSuffix(s.VCenterIsCriticalState(), s.VCenterIsWarningState()),
+			s.VCenterDrift.Seconds(),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if s.NumHostsUnavailable() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sUnavailable hosts:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, htd := range s.Hosts {
+			if !htd.Unavailable {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (connection state: %s)%s",
+				htd.Host.Name,
+				htd.Host.Runtime.ConnectionState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	referenceClock := "plugin host"
+	if s.VCenterCompared {
+		referenceClock += " and vCenter server"
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Reference clock: %s%s",
+		referenceClock,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimeDriftQuery(t *testing.T) {
+	errQuery := errors.New("query failed")
+
+	tests := map[string]struct {
+		offset  time.Duration
+		delay   time.Duration
+		err     error
+		noValue bool
+		wantErr bool
+	}{
+		"remote clock ahead":  {offset: 30 * time.Second},
+		"remote clock behind": {offset: -45 * time.Second},
+		"slow request":        {offset: 10 * time.Second, delay: 200 * time.Millisecond},
+		"query error":         {err: errQuery, wantErr: true},
+		"empty time value":    {noValue: true, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var remote time.Time
+
+			_, drift, err := timeDriftQuery(func() (*time.Time, error) {
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				if tt.noValue {
+					return nil, nil
+				}
+
+				// Report the remote clock value as of the start of the
+				// request; the drift is measured from the midpoint.
+				remote = time.Now().Add(tt.offset)
+				time.Sleep(tt.delay)
+
+				return &remote, nil
+			})
+
+			switch {
+			case tt.wantErr && err == nil:
+				t.Fatal("want error; got nil")
+			case tt.wantErr:
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("want %v error; got %v", tt.err, err)
+				}
+				return
+			case err != nil:
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			want := tt.offset - tt.delay/2
+			if diff := math.Abs((drift - want).Seconds()); diff > 0.05 {
+				t.Errorf("want drift of approximately %v; got %v", want, drift)
+			}
+		})
+	}
+}
+
+func TestHostTimeDriftState(t *testing.T) {
+	thresholds := HostTimeDriftThresholds{
+		Warning:  5,
+		Critical: 10,
+	}
+
+	durationPtr := func(d time.Duration) *time.Duration {
+		return &d
+	}
+
+	tests := map[string]struct {
+		host         HostTimeDrift
+		wantCritical bool
+		wantWarning  bool
+		wantMaxDrift float64
+	}{
+		"drift within thresholds": {
+			host:         HostTimeDrift{Drift: 2 * time.Second},
+			wantMaxDrift: 2,
+		},
+		"drift at WARNING threshold": {
+			host:         HostTimeDrift{Drift: 5 * time.Second},
+			wantMaxDrift: 5,
+		},
+		"host clock behind beyond WARNING threshold": {
+			host:         HostTimeDrift{Drift: -7 * time.Second},
+			wantWarning:  true,
+			wantMaxDrift: 7,
+		},
+		"host clock ahead beyond CRITICAL threshold": {
+			host:         HostTimeDrift{Drift: 11 * time.Second},
+			wantCritical: true,
+			wantMaxDrift: 11,
+		},
+		"host drift from vCenter server beyond CRITICAL threshold": {
+			host: HostTimeDrift{
+				Drift:        time.Second,
+				VCenterDrift: durationPtr(-12 * time.Second),
+			},
+			wantCritical: true,
+			wantMaxDrift: 12,
+		},
+		"unavailable host": {
+			host:         HostTimeDrift{Drift: time.Hour, Unavailable: true},
+			wantMaxDrift: 3600,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.host.Thresholds = thresholds
+
+			if got := tt.host.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := tt.host.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			if got := tt.host.MaxDrift(); got != tt.wantMaxDrift {
+				t.Errorf("want max drift %.2fs; got %.2fs", tt.wantMaxDrift, got)
+			}
+		})
+	}
+}
+
+func TestHostTimeDriftSummaryState(t *testing.T) {
+	thresholds := HostTimeDriftThresholds{
+		Warning:  5,
+		Critical: 10,
+	}
+
+	hosts := []HostTimeDrift{
+		{Drift: 2 * time.Second, Thresholds: thresholds},
+		{Drift: -7 * time.Second, Thresholds: thresholds},
+		{Drift: time.Hour, Unavailable: true, Thresholds: thresholds},
+	}
+
+	tests := map[string]struct {
+		vCenterCompared bool
+		vCenterDrift    time.Duration
+		wantCritical    bool
+		wantVCCritical  bool
+		wantVCWarning   bool
+		wantMaxDrift    float64
+	}{
+		"vCenter server not compared": {
+			vCenterDrift: time.Minute,
+			wantMaxDrift: 7,
+		},
+		"vCenter server drift within thresholds": {
+			vCenterCompared: true,
+			vCenterDrift:    -3 * time.Second,
+			wantMaxDrift:    7,
+		},
+		"vCenter server drift beyond WARNING threshold": {
+			vCenterCompared: true,
+			vCenterDrift:    -6 * time.Second,
+			wantVCWarning:   true,
+			wantMaxDrift:    7,
+		},
+		"vCenter server drift beyond CRITICAL threshold": {
+			vCenterCompared: true,
+			vCenterDrift:    13 * time.Second,
+			wantCritical:    true,
+			wantVCCritical:  true,
+			wantMaxDrift:    13,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := HostTimeDriftSummary{
+				Hosts:           hosts,
+				VCenterCompared: tt.vCenterCompared,
+				VCenterDrift:    tt.vCenterDrift,
+				Thresholds:      thresholds,
+			}
+
+			if got := summary.VCenterIsCriticalState(); got != tt.wantVCCritical {
+				t.Errorf("want vCenter CRITICAL state %t; got %t", tt.wantVCCritical, got)
+			}
+
+			if got := summary.VCenterIsWarningState(); got != tt.wantVCWarning {
+				t.Errorf("want vCenter WARNING state %t; got %t", tt.wantVCWarning, got)
+			}
+
+			if got := summary.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if !summary.IsWarningState() {
+				t.Error("want WARNING state for host drift")
+			}
+
+			if got := summary.MaxDrift(); got != tt.wantMaxDrift {
+				t.Errorf("want max drift %.2fs; got %.2fs", tt.wantMaxDrift, got)
+			}
+
+			if got := summary.NumHostsEvaluated(); got != 2 {
+				t.Errorf("want 2 evaluated hosts; got %d", got)
+			}
+
+			if got := summary.NumHostsUnavailable(); got != 1 {
+				t.Errorf("want 1 unavailable host; got %d", got)
+			}
+
+			if got := summary.NumHostsWarning(); got != 1 {
+				t.Errorf("want 1 WARNING host; got %d", got)
+			}
+		})
+	}
+}
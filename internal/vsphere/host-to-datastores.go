@@ -333,7 +333,7 @@ func GetDatastoresWithCA(allDS []mo.Datastore, ignoredDatastoreNames []string, d
 	// validate the list of ignored datastores
 	if len(ignoredDatastoreNames) > 0 {
 		for _, ignDSName := range ignoredDatastoreNames {
-			if !textutils.InList(ignDSName, dsNames, true) {
+			if !patternMatchesAny(ignDSName, dsNames) {
 
 				validateIgnoredDSErr := errors.New(
 					"error validating list of ignored datastores",
@@ -365,7 +365,7 @@ func GetDatastoresWithCA(allDS []mo.Datastore, ignoredDatastoreNames []string, d
 
 		// if user opted to ignore the Datastore, skip attempts to retrieve
 		// Custom Attribute for it.
-		if textutils.InList(ds.Name, ignoredDatastoreNames, true) {
+		if inPatternList(ds.Name, ignoredDatastoreNames) {
 			continue
 		}
 
@@ -817,7 +817,7 @@ func (hdi HostToDatastoreIndex) ValidateVirtualMachinePairings(
 				)
 
 				// Resolved datastore name is in the ignore list, skip it.
-				if textutils.InList(datastore.Name, dsNamesToIgnore, true) {
+				if inPatternList(datastore.Name, dsNamesToIgnore) {
 					continue
 				}
 			}
@@ -827,7 +827,7 @@ func (hdi HostToDatastoreIndex) ValidateVirtualMachinePairings(
 		}
 
 		switch {
-		case textutils.InList(ds.Name, dsNamesToIgnore, true):
+		case inPatternList(ds.Name, dsNamesToIgnore):
 			// if datastore name is in the ignore list, don't report
 			// the mismatch, move on and check the next datastore
 			continue
@@ -868,6 +868,11 @@ func H2D2VMsOneLineCheckSummary(
 	vmDatastoresPairingIssues VMToMismatchedPairing,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults":          vmsFilterResults,
+		"vmDatastoresPairingIssues": vmDatastoresPairingIssues,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -0,0 +1,837 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrHostSystemUnavailable indicates that one or more hosts in scope are not
+// connected and could not be evaluated.
+var ErrHostSystemUnavailable = errors.New("host unavailable")
+
+// hostUsageSummary is implemented by per-host usage summaries (e.g., CPU or
+// memory) evaluated as part of a HostSystemUsageSet.
+type hostUsageSummary interface {
+	IsCriticalState() bool
+	IsWarningState() bool
+
+	// metricName returns the human readable name of the usage metric (e.g.,
+	// CPU).
+	metricName() string
+
+	// metricLabel returns the performance data label prefix for the usage
+	// metric (e.g., cpu).
+	metricLabel() string
+
+	// hostSystem returns the HostSystem the summary was generated for.
+	hostSystem() mo.HostSystem
+
+	// usedPercent returns the percentage of the host capacity in use.
+	usedPercent() float64
+
+	// totalDesc and remainingDesc return human readable descriptions of the
+	// host capacity and remaining capacity.
+	totalDesc() string
+	remainingDesc() string
+
+	// remainingPerfData returns the performance data metric for the
+	// remaining host capacity.
+	remainingPerfData() nagios.PerformanceData
+}
+
+// HostSystemUsageSet is a collection of HostSystem usage summaries for a
+// single metric (e.g., CPU or memory) evaluated as part of a single plugin
+// execution.
+type HostSystemUsageSet[S hostUsageSummary] struct {
+
+	// Summaries is the collection of usage summaries for connected hosts,
+	// sorted by usage (highest first).
+	Summaries []S
+
+	// Unavailable is the collection of hosts which are not connected and
+	// could not be evaluated. Unavailable hosts are considered to be in a
+	// CRITICAL state.
+	Unavailable []mo.HostSystem
+
+	// NumExcluded is the number of hosts in scope which were excluded from
+	// evaluation by name.
+	NumExcluded int
+
+	CriticalThreshold int
+	WarningThreshold  int
+}
+
+// HostSystemCPUUsageSet is a collection of HostSystem CPU usage summaries
+// evaluated as part of a single plugin execution.
+type HostSystemCPUUsageSet = HostSystemUsageSet[HostSystemCPUSummary]
+
+// HostSystemMemoryUsageSet is a collection of HostSystem memory usage
+// summaries evaluated as part of a single plugin execution.
+type HostSystemMemoryUsageSet = HostSystemUsageSet[HostSystemMemorySummary]
+
+// metricName returns the human readable name of the usage metric.
+func (hss HostSystemCPUSummary) metricName() string {
+	return "CPU"
+}
+
+// metricLabel returns the performance data label prefix for the usage
+// metric.
+func (hss HostSystemCPUSummary) metricLabel() string {
+	return "cpu"
+}
+
+// hostSystem returns the HostSystem the summary was generated for.
+func (hss HostSystemCPUSummary) hostSystem() mo.HostSystem {
+	return hss.HostSystem
+}
+
+// usedPercent returns the percentage of the host CPU capacity in use.
+func (hss HostSystemCPUSummary) usedPercent() float64 {
+	return hss.CPUUsedPercent
+}
+
+// totalDesc returns a human readable description of the host CPU capacity.
+func (hss HostSystemCPUSummary) totalDesc() string {
+	return CPUSpeed(hss.CPUTotal).String()
+}
+
+// remainingDesc returns a human readable description of the remaining host
+// CPU capacity.
+func (hss HostSystemCPUSummary) remainingDesc() string {
+	return CPUSpeed(hss.CPURemaining).String()
+}
+
+// remainingPerfData returns the performance data metric for the remaining
+// host CPU capacity.
+func (hss HostSystemCPUSummary) remainingPerfData() nagios.PerformanceData {
+	return nagios.PerformanceData{
+		Label:             PerfDataLabel(hss.HostSystem.Name, "cpu_remaining"),
+		Value:             fmt.Sprintf("%.2f", hss.CPURemaining),
+		UnitOfMeasurement: "Hz",
+		Min:               "0",
+		Max:               fmt.Sprintf("%.2f", hss.CPUTotal),
+	}
+}
+
+// metricName returns the human readable name of the usage metric.
+func (hss HostSystemMemorySummary) metricName() string {
+	return "memory"
+}
+
+// metricLabel returns the performance data label prefix for the usage
+// metric.
+func (hss HostSystemMemorySummary) metricLabel() string {
+	return "memory"
+}
+
+// hostSystem returns the HostSystem the summary was generated for.
+func (hss HostSystemMemorySummary) hostSystem() mo.HostSystem {
+	return hss.HostSystem
+}
+
+// usedPercent returns the percentage of the host memory in use.
+func (hss HostSystemMemorySummary) usedPercent() float64 {
+	return hss.MemoryUsedPercent
+}
+
+// totalDesc returns a human readable description of the host memory.
+func (hss HostSystemMemorySummary) totalDesc() string {
+	return units.ByteSize(hss.MemoryTotal).String()
+}
+
+// remainingDesc returns a human readable description of the remaining host
+// memory.
+func (hss HostSystemMemorySummary) remainingDesc() string {
+	return units.ByteSize(hss.MemoryRemaining).String()
+}
+
+// remainingPerfData returns the performance data metric for the remaining
+// host memory.
+func (hss HostSystemMemorySummary) remainingPerfData() nagios.PerformanceData {
+	return nagios.PerformanceData{
+		Label:             PerfDataLabel(hss.HostSystem.Name, "memory_remaining"),
+		Value:             fmt.Sprintf("%d", hss.MemoryRemaining),
+		UnitOfMeasurement: "B",
+		Min:               "0",
+		Max:               fmt.Sprintf("%d", hss.MemoryTotal),
+	}
+}
+
+// GetHostSystemsInScope retrieves the HostSystems which are members of the
+// specified cluster or, if a cluster name is not specified, all HostSystems
+// within the specified datacenter. If the datacenter name is an empty string
+// then the default datacenter will be used. If requested, a subset of all
+// available properties will be retrieved (faster) instead of recursively
+// fetching all properties (about 2x as slow).
+func GetHostSystemsInScope(ctx context.Context, c *vim25.Client, clusterName string, datacenter string, propsSubset bool) ([]mo.HostSystem, error) {
+
+	funcTimeStart := time.Now()
+
+	// declare this early so that we can grab a pointer to it in order to
+	// access the entries later
+	var hss []mo.HostSystem
+
+	defer func(hss *[]mo.HostSystem) {
+		logger.Printf(
+			"It took %v to execute GetHostSystemsInScope func (and retrieve %d HostSystems).\n",
+			time.Since(funcTimeStart),
+			len(*hss),
+		)
+	}(&hss)
+
+	switch {
+	case clusterName != "":
+		cluster, err := GetClusterByName(ctx, c, clusterName, datacenter, true)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve cluster %s: %w",
+				clusterName,
+				err,
+			)
+		}
+
+		hss, err = GetClusterHostSystems(ctx, c, cluster, propsSubset)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		finder := find.NewFinder(c, true)
+
+		dc, findDCErr := finder.DatacenterOrDefault(ctx, datacenter)
+		if findDCErr != nil {
+			errMsg := dcFailedToUseFailedToFallback
+			if datacenter == "" {
+				errMsg = dcNotProvidedFailedToFallback
+			}
+
+			return nil, fmt.Errorf("%s: %w", errMsg, findDCErr)
+		}
+
+		err := getObjects(ctx, c, &hss, dc.Reference(), propsSubset, true)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve HostSystems for datacenter %s: %w",
+				dc.Name(),
+				err,
+			)
+		}
+	}
+
+	sort.Slice(hss, func(i, j int) bool {
+		return strings.ToLower(hss[i].Name) < strings.ToLower(hss[j].Name)
+	})
+
+	return hss, nil
+}
+
+// hostSystemExcluded indicates whether the given HostSystem is excluded from
+// evaluation by the given lists of host names to include or exclude.
+func hostSystemExcluded(hs mo.HostSystem, includedHosts []string, excludedHosts []string) bool {
+	switch {
+	case len(includedHosts) > 0 && !inPatternList(hs.Name, includedHosts):
+		return true
+
+	case len(excludedHosts) > 0 && inPatternList(hs.Name, excludedHosts):
+		return true
+
+	default:
+		return false
+	}
+}
+
+// newHostSystemUsageSet receives a collection of HostSystems and generates
+// usage summaries using the given summary function for each HostSystem
+// matching the given lists of host names to include or exclude. Hosts which
+// are not connected are recorded separately as their usage metrics are
+// unavailable.
+func newHostSystemUsageSet[S hostUsageSummary](
+	hss []mo.HostSystem,
+	includedHosts []string,
+	excludedHosts []string,
+	criticalThreshold int,
+	warningThreshold int,
+	summarize func(hs mo.HostSystem, criticalThreshold int, warningThreshold int) (S, error),
+) (HostSystemUsageSet[S], error) {
+
+	set := HostSystemUsageSet[S]{
+		Summaries:         make([]S, 0, len(hss)),
+		CriticalThreshold: criticalThreshold,
+		WarningThreshold:  warningThreshold,
+	}
+
+	for _, hs := range hss {
+		if hostSystemExcluded(hs, includedHosts, excludedHosts) {
+			set.NumExcluded++
+
+			continue
+		}
+
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s is unavailable (connection state: %s)",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set.Unavailable = append(set.Unavailable, hs)
+
+			continue
+		}
+
+		hsUsage, err := summarize(hs, criticalThreshold, warningThreshold)
+		if err != nil {
+			return HostSystemUsageSet[S]{}, fmt.Errorf(
+				"failed to generate %s usage summary for host %s: %w",
+				hsUsage.metricName(),
+				hs.Name,
+				err,
+			)
+		}
+
+		set.Summaries = append(set.Summaries, hsUsage)
+	}
+
+	// Highest usage first.
+	sort.Slice(set.Summaries, func(i, j int) bool {
+		if set.Summaries[i].usedPercent() != set.Summaries[j].usedPercent() {
+			return set.Summaries[i].usedPercent() > set.Summaries[j].usedPercent()
+		}
+
+		return strings.ToLower(set.Summaries[i].hostSystem().Name) <
+			strings.ToLower(set.Summaries[j].hostSystem().Name)
+	})
+
+	return set, nil
+}
+
+// NewHostSystemCPUUsageSet receives a collection of HostSystems and generates
+// CPU usage summaries for each HostSystem matching the given lists of host
+// names to include or exclude. Hosts which are not connected are recorded
+// separately as their usage metrics are unavailable.
+func NewHostSystemCPUUsageSet(
+	hss []mo.HostSystem,
+	includedHosts []string,
+	excludedHosts []string,
+	criticalThreshold int,
+	warningThreshold int,
+) (HostSystemCPUUsageSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewHostSystemCPUUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return newHostSystemUsageSet(
+		hss,
+		includedHosts,
+		excludedHosts,
+		criticalThreshold,
+		warningThreshold,
+		NewHostSystemCPUUsageSummary,
+	)
+}
+
+// NewHostSystemMemoryUsageSet receives a collection of HostSystems and
+// generates memory usage summaries for each HostSystem matching the given
+// lists of host names to include or exclude. Hosts which are not connected
+// are recorded separately as their usage metrics are unavailable.
+func NewHostSystemMemoryUsageSet(
+	hss []mo.HostSystem,
+	includedHosts []string,
+	excludedHosts []string,
+	criticalThreshold int,
+	warningThreshold int,
+) (HostSystemMemoryUsageSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewHostSystemMemoryUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return newHostSystemUsageSet(
+		hss,
+		includedHosts,
+		excludedHosts,
+		criticalThreshold,
+		warningThreshold,
+		NewHostSystemMemoryUsageSummary,
+	)
+}
+
+// NumEvaluated returns the number of hosts in the set, including those which
+// are unavailable.
+func (set HostSystemUsageSet[S]) NumEvaluated() int {
+	return len(set.Summaries) + len(set.Unavailable)
+}
+
+// NumCritical returns the number of hosts with usage exceeding the CRITICAL
+// threshold.
+func (set HostSystemUsageSet[S]) NumCritical() int {
+	var num int
+	for _, hsUsage := range set.Summaries {
+		if hsUsage.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of hosts with usage exceeding the WARNING
+// threshold, but not the CRITICAL threshold.
+func (set HostSystemUsageSet[S]) NumWarning() int {
+	var num int
+	for _, hsUsage := range set.Summaries {
+		if hsUsage.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// MaxUsedPercent returns the highest usage percentage of all evaluated
+// hosts.
+func (set HostSystemUsageSet[S]) MaxUsedPercent() float64 {
+	var highest float64
+	for _, hsUsage := range set.Summaries {
+		if hsUsage.usedPercent() > highest {
+			highest = hsUsage.usedPercent()
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any host in the set is unavailable or
+// has usage exceeding the CRITICAL threshold.
+func (set HostSystemUsageSet[S]) IsCriticalState() bool {
+	return len(set.Unavailable) > 0 || set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any host in the set has usage exceeding
+// the WARNING threshold.
+func (set HostSystemUsageSet[S]) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// hostSystemUsageSetPerfData generates performance data metrics from the
+// given collection of host usage summaries. Aggregate metrics are emitted
+// along with usage metrics for each host.
+func hostSystemUsageSetPerfData[S hostUsageSummary](set HostSystemUsageSet[S]) []nagios.PerformanceData {
+
+	var zero S
+	metricLabel := zero.metricLabel()
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_excluded",
+			Value: fmt.Sprintf("%d", set.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unavailable",
+			Value: fmt.Sprintf("%d", len(set.Unavailable)),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             metricLabel + "_usage_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxUsedPercent()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", set.WarningThreshold),
+			Crit:              fmt.Sprintf("%d", set.CriticalThreshold),
+			Min:               "0",
+			Max:               "100",
+		},
+	}
+
+	for _, hsUsage := range set.Summaries {
+		hs := hsUsage.hostSystem()
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(hs.Name, metricLabel+"_usage"),
+				Value:             fmt.Sprintf("%.2f", hsUsage.usedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", set.WarningThreshold),
+				Crit:              fmt.Sprintf("%d", set.CriticalThreshold),
+				Min:               "0",
+				Max:               "100",
+			},
+			hsUsage.remainingPerfData(),
+			nagios.PerformanceData{
+				Label: PerfDataLabel(hs.Name, "vms"),
+				Value: fmt.Sprintf("%d", len(hs.Vm)),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// HostSystemCPUUsageSetPerfData generates performance data metrics from the
+// given collection of host CPU usage summaries. Aggregate metrics are emitted
+// along with CPU usage metrics for each host.
+func HostSystemCPUUsageSetPerfData(set HostSystemCPUUsageSet) []nagios.PerformanceData {
+	return hostSystemUsageSetPerfData(set)
+}
+
+// HostSystemMemoryUsageSetPerfData generates performance data metrics from
+// the given collection of host memory usage summaries. Aggregate metrics are
+// emitted along with memory usage metrics for each host.
+func HostSystemMemoryUsageSetPerfData(set HostSystemMemoryUsageSet) []nagios.PerformanceData {
+	return hostSystemUsageSetPerfData(set)
+}
+
+// hostSystemUsageSetOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary for the given collection of host
+// usage summaries.
+func hostSystemUsageSetOneLineCheckSummary[S hostUsageSummary](
+	stateLabel string,
+	set HostSystemUsageSet[S],
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	var zero S
+	metricName := zero.metricName()
+
+	switch {
+	case set.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d hosts with %s usage exceeding %d%% and %d unavailable hosts detected (evaluated %d hosts, highest usage %.2f%%)",
+			stateLabel,
+			set.NumCritical(),
+			metricName,
+			set.CriticalThreshold,
+			len(set.Unavailable),
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d hosts with %s usage exceeding %d%% detected (evaluated %d hosts, highest usage %.2f%%)",
+			stateLabel,
+			set.NumWarning(),
+			metricName,
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts with %s usage exceeding %d%% detected (evaluated %d hosts, highest usage %.2f%%)",
+			stateLabel,
+			metricName,
+			set.WarningThreshold,
+			set.NumEvaluated(),
+			set.MaxUsedPercent(),
+		)
+	}
+}
+
+// HostSystemCPUUsageSetOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func HostSystemCPUUsageSetOneLineCheckSummary(
+	stateLabel string,
+	set HostSystemCPUUsageSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSystemCPUUsageSetOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return hostSystemUsageSetOneLineCheckSummary(stateLabel, set)
+}
+
+// HostSystemMemoryUsageSetOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func HostSystemMemoryUsageSetOneLineCheckSummary(
+	stateLabel string,
+	set HostSystemMemoryUsageSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSystemMemoryUsageSetOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return hostSystemUsageSetOneLineCheckSummary(stateLabel, set)
+}
+
+// hostUsageStateLabel returns the Nagios state label for a host with the
+// given usage state.
+func hostUsageStateLabel(critical bool, warning bool) string {
+	switch {
+	case critical:
+		return nagios.StateCRITICALLabel
+	case warning:
+		return nagios.StateWARNINGLabel
+	default:
+		return nagios.StateOKLabel
+	}
+}
+
+// hostMaintenanceModeLabel returns a label noting that the given HostSystem
+// is in maintenance mode, if applicable.
+func hostMaintenanceModeLabel(hs mo.HostSystem) string {
+	if hs.Runtime.InMaintenanceMode {
+		return " [maintenance mode]"
+	}
+
+	return ""
+}
+
+// hostUsageSetReportTrailer writes the unavailable hosts (if any) and the
+// common trailer for multiple host usage reports to the given report.
+func hostUsageSetReportTrailer(
+	report *strings.Builder,
+	c *vim25.Client,
+	unavailable []mo.HostSystem,
+	numEvaluated int,
+	numExcluded int,
+	includedHosts []string,
+	excludedHosts []string,
+	clusterName string,
+	datacenter string,
+) {
+
+	for _, hs := range unavailable {
+		_, _ = fmt.Fprintf(
+			report,
+			"* %s [%s]: unavailable (connection state: %s)%s",
+			hs.Name,
+			nagios.StateCRITICALLabel,
+			hs.Runtime.ConnectionState,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	scope := "all hosts in datacenter"
+	switch {
+	case clusterName != "":
+		scope = fmt.Sprintf("hosts in cluster %s", clusterName)
+	case datacenter != "":
+		scope = fmt.Sprintf("all hosts in datacenter %s", datacenter)
+	}
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* Scope: %s%s",
+		scope,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* Hosts evaluated: %d (%d excluded, %d unavailable)%s",
+		numEvaluated,
+		numExcluded,
+		len(unavailable),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* Specified Hosts to explicitly include (%d): [%v]%s",
+		len(includedHosts),
+		strings.Join(includedHosts, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		report,
+		"* Specified Hosts to explicitly exclude (%d): [%v]%s",
+		len(excludedHosts),
+		strings.Join(excludedHosts, ", "),
+		nagios.CheckOutputEOL,
+	)
+}
+
+// hostSystemUsageSetReport generates a summary of per-host usage (highest
+// usage first) along with various verbose details for the given collection
+// of host usage summaries.
+func hostSystemUsageSetReport[S hostUsageSummary](
+	c *vim25.Client,
+	set HostSystemUsageSet[S],
+	includedHosts []string,
+	excludedHosts []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	var report strings.Builder
+
+	if set.NumEvaluated() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No hosts found matching specified criteria%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, hsUsage := range set.Summaries {
+		hs := hsUsage.hostSystem()
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %.2f%% of %s used, %s remaining (%d VMs)%s%s",
+			hs.Name,
+			hostUsageStateLabel(hsUsage.IsCriticalState(), hsUsage.IsWarningState()),
+			hsUsage.usedPercent(),
+			hsUsage.totalDesc(),
+			hsUsage.remainingDesc(),
+			len(hs.Vm),
+			hostMaintenanceModeLabel(hs),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	hostUsageSetReportTrailer(
+		&report,
+		c,
+		set.Unavailable,
+		set.NumEvaluated(),
+		set.NumExcluded,
+		includedHosts,
+		excludedHosts,
+		clusterName,
+		datacenter,
+	)
+
+	return report.String()
+}
+
+// HostSystemCPUUsageSetReport generates a summary of per-host CPU usage
+// (highest usage first) along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostSystemCPUUsageSetReport(
+	c *vim25.Client,
+	set HostSystemCPUUsageSet,
+	includedHosts []string,
+	excludedHosts []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSystemCPUUsageSetReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return hostSystemUsageSetReport(c, set, includedHosts, excludedHosts, clusterName, datacenter)
+}
+
+// HostSystemMemoryUsageSetReport generates a summary of per-host memory
+// usage (highest usage first) along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body of
+// many notifications.
+func HostSystemMemoryUsageSetReport(
+	c *vim25.Client,
+	set HostSystemMemoryUsageSet,
+	includedHosts []string,
+	excludedHosts []string,
+	clusterName string,
+	datacenter string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute HostSystemMemoryUsageSetReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	return hostSystemUsageSetReport(c, set, includedHosts, excludedHosts, clusterName, datacenter)
+}
@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/atc0005/check-vmware/internal/textutils"
+
 	"github.com/atc0005/go-nagios"
 	"github.com/vmware/govmomi/units"
 	"github.com/vmware/govmomi/vim25"
@@ -427,6 +429,11 @@ func HostSystemMemoryUsageOneLineCheckSummary(
 	hsUsageSummary HostSystemMemorySummary,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"hsVMs":          hsVMs,
+		"hsUsageSummary": hsUsageSummary,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -644,6 +651,11 @@ func HostSystemCPUUsageOneLineCheckSummary(
 	hsUsageSummary HostSystemCPUSummary,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"hsVMs":          hsVMs,
+		"hsUsageSummary": hsUsageSummary,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -851,3 +863,99 @@ func HostSystemCPUUsageReport(
 
 	return report.String()
 }
+
+// GetNumTotalHostSystems returns the count of all HostSystems in the
+// inventory.
+func GetNumTotalHostSystems(ctx context.Context, client *vim25.Client) (int, error) {
+	funcTimeStart := time.Now()
+
+	var numAllHostSystems int
+
+	defer func(allHostSystems *int) {
+		logger.Printf(
+			"It took %v to execute GetNumTotalHostSystems func (and count %d HostSystems).\n",
+			time.Since(funcTimeStart),
+			*allHostSystems,
+		)
+	}(&numAllHostSystems)
+
+	hss, retrieveErr := GetHostSystems(ctx, client, true)
+	if retrieveErr != nil {
+		logger.Printf(
+			"error retrieving list of all hosts: %v",
+			retrieveErr,
+		)
+
+		return 0, fmt.Errorf(
+			"error retrieving list of all hosts: %w",
+			retrieveErr,
+		)
+	}
+
+	numAllHostSystems = len(hss)
+
+	return numAllHostSystems, nil
+}
+
+// validateHostSystems verifies that all explicitly specified HostSystems
+// exist in the inventory.
+func validateHostSystems(ctx context.Context, client *vim25.Client, filterOptions VMsFilterOptions) error {
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute validateHostSystems func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.HostNamesIncluded) > 0 || len(filterOptions.HostNamesExcluded) > 0:
+		logger.Println("Validating hosts")
+
+		hss, retrieveErr := GetHostSystems(ctx, client, true)
+		if retrieveErr != nil {
+			return fmt.Errorf(
+				"%v: %v",
+				ErrValidationOfIncludeExcludeHostLists,
+				retrieveErr,
+			)
+		}
+
+		hostNamesFound := make([]string, 0, len(hss))
+		for _, hs := range hss {
+			hostNamesFound = append(hostNamesFound, hs.Name)
+		}
+
+		specified := make([]string, 0, len(filterOptions.HostNamesIncluded)+len(filterOptions.HostNamesExcluded))
+		specified = append(specified, filterOptions.HostNamesIncluded...)
+		specified = append(specified, filterOptions.HostNamesExcluded...)
+
+		var notFound []string
+		for _, name := range specified {
+			if !textutils.InList(name, hostNamesFound, true) {
+				notFound = append(notFound, name)
+			}
+		}
+
+		if len(notFound) > 0 {
+			logger.Printf(
+				"%v: specified Hosts not found: %v",
+				ErrValidationOfIncludeExcludeHostLists,
+				notFound,
+			)
+
+			return fmt.Errorf(
+				"%v: specified Hosts not found: %v",
+				ErrValidationOfIncludeExcludeHostLists,
+				notFound,
+			)
+		}
+		logger.Println("Successfully validated hosts")
+
+		return nil
+	default:
+		logger.Println("Skipping host validation; host filtering not requested")
+		return nil
+	}
+}
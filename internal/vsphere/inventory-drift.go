@@ -0,0 +1,561 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrInventoryDriftThresholdCrossed indicates that the change in inventory
+// object counts between plugin runs has crossed a specified threshold.
+var ErrInventoryDriftThresholdCrossed = errors.New("inventory drift exceeds specified threshold")
+
+// Inventory object kinds tracked for drift.
+const (
+	InventoryKindHosts      string = "hosts"
+	InventoryKindVMs        string = "vms"
+	InventoryKindDatastores string = "datastores"
+	InventoryKindNetworks   string = "networks"
+)
+
+// inventoryKinds is the ordered collection of inventory object kinds tracked
+// for drift.
+var inventoryKinds = []string{
+	InventoryKindHosts,
+	InventoryKindVMs,
+	InventoryKindDatastores,
+	InventoryKindNetworks,
+}
+
+// InventoryCounts is the number of inventory objects (keyed by kind) within
+// a Datacenter.
+type InventoryCounts map[string]int
+
+// InventoryBaseline is the inventory object counts for each evaluated
+// Datacenter as recorded by a previous plugin run.
+type InventoryBaseline struct {
+	// Recorded is when the baseline was recorded.
+	Recorded time.Time `json:"recorded"`
+
+	// Datacenters is the inventory object counts keyed by Datacenter name.
+	Datacenters map[string]InventoryCounts `json:"datacenters"`
+}
+
+// InventoryDrift is the change in a specific inventory object count for a
+// Datacenter between the baseline and the current plugin run.
+type InventoryDrift struct {
+	Datacenter string
+	Kind       string
+	Baseline   int
+	Current    int
+}
+
+// InventoryDriftThresholds represents the user-specified thresholds for the
+// number of objects of any kind added or removed per Datacenter.
+type InventoryDriftThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// InventoryDriftSet is the result of comparing current inventory object
+// counts against a stored baseline.
+type InventoryDriftSet struct {
+	// Current is the inventory object counts for the current plugin run.
+	Current InventoryBaseline
+
+	// Baseline is the inventory object counts recorded by a previous plugin
+	// run. This is nil if a baseline was not previously recorded.
+	Baseline *InventoryBaseline
+
+	// Drift is the collection of inventory object counts which changed
+	// between the baseline and the current plugin run.
+	Drift []InventoryDrift
+
+	Thresholds InventoryDriftThresholds
+}
+
+// GetInventoryCounts retrieves the number of hosts, virtual machines,
+// datastores and networks within each of the given Datacenters.
+func GetInventoryCounts(ctx context.Context, c *vim25.Client, dcs []mo.Datacenter) (InventoryBaseline, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetInventoryCounts func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	current := InventoryBaseline{
+		Recorded:    time.Now(),
+		Datacenters: make(map[string]InventoryCounts, len(dcs)),
+	}
+
+	m := view.NewManager(c)
+
+	kinds := []string{
+		MgObjRefTypeHostSystem,
+		MgObjRefTypeVirtualMachine,
+		MgObjRefTypeDatastore,
+		MgObjRefTypeNetwork,
+	}
+
+	for _, dc := range dcs {
+		v, createViewErr := m.CreateContainerView(ctx, dc.Reference(), kinds, true)
+		if createViewErr != nil {
+			return InventoryBaseline{}, fmt.Errorf(
+				"failed to create inventory view for datacenter %s: %w",
+				dc.Name,
+				createViewErr,
+			)
+		}
+
+		refs, findErr := v.Find(ctx, kinds, nil)
+
+		if err := v.Destroy(ctx); err != nil {
+			logger.Printf("Error occurred while destroying view: %s", err)
+		}
+
+		if findErr != nil {
+			return InventoryBaseline{}, fmt.Errorf(
+				"failed to retrieve inventory objects for datacenter %s: %w",
+				dc.Name,
+				findErr,
+			)
+		}
+
+		counts := make(InventoryCounts, len(inventoryKinds))
+		for _, kind := range inventoryKinds {
+			counts[kind] = 0
+		}
+
+		for _, ref := range refs {
+			switch ref.Type {
+			case MgObjRefTypeHostSystem:
+				counts[InventoryKindHosts]++
+			case MgObjRefTypeVirtualMachine:
+				counts[InventoryKindVMs]++
+			case MgObjRefTypeDatastore:
+				counts[InventoryKindDatastores]++
+
+			// Distributed port groups are returned as a subtype of Network.
+			default:
+				counts[InventoryKindNetworks]++
+			}
+		}
+
+		current.Datacenters[dc.Name] = counts
+	}
+
+	return current, nil
+
+}
+
+// LoadInventoryBaseline reads the inventory baseline from the specified
+// state file. A nil baseline is returned if the state file does not exist.
+func LoadInventoryBaseline(filename string) (*InventoryBaseline, error) {
+	data, err := readStateFile(filename)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to load inventory baseline: %w", err)
+
+	case data == nil:
+		return nil, nil
+	}
+
+	var baseline InventoryBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse inventory state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return &baseline, nil
+}
+
+// SaveInventoryBaseline writes the given inventory counts to the specified
+// state file for use as the baseline by the next plugin run.
+func SaveInventoryBaseline(filename string, baseline InventoryBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory baseline: %w", err)
+	}
+
+	if err := writeStateFile(filename, data); err != nil {
+		return fmt.Errorf("failed to save inventory baseline: %w", err)
+	}
+
+	return nil
+}
+
+// NewInventoryDriftSet compares the current inventory object counts against
+// the given baseline. Datacenters present in only one of the baseline or the
+// current counts are treated as having zero objects in the other.
+func NewInventoryDriftSet(current InventoryBaseline, baseline *InventoryBaseline, thresholds InventoryDriftThresholds) InventoryDriftSet {
+
+	set := InventoryDriftSet{
+		Current:    current,
+		Baseline:   baseline,
+		Thresholds: thresholds,
+	}
+
+	if baseline == nil {
+		return set
+	}
+
+	dcNames := make(map[string]struct{})
+	for name := range current.Datacenters {
+		dcNames[name] = struct{}{}
+	}
+	for name := range baseline.Datacenters {
+		dcNames[name] = struct{}{}
+	}
+
+	names := make([]string, 0, len(dcNames))
+	for name := range dcNames {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	for _, name := range names {
+		for _, kind := range inventoryKinds {
+			drift := InventoryDrift{
+				Datacenter: name,
+				Kind:       kind,
+				Baseline:   baseline.Datacenters[name][kind],
+				Current:    current.Datacenters[name][kind],
+			}
+
+			if drift.Delta() != 0 {
+				set.Drift = append(set.Drift, drift)
+			}
+		}
+	}
+
+	return set
+
+}
+
+// Delta returns the change in the inventory object count since the
+// baseline. A negative value indicates that objects were removed.
+func (id InventoryDrift) Delta() int {
+	return id.Current - id.Baseline
+}
+
+// AbsDelta returns the number of inventory objects added or removed since
+// the baseline.
+func (id InventoryDrift) AbsDelta() int {
+	if delta := id.Delta(); delta < 0 {
+		return -delta
+	}
+
+	return id.Delta()
+}
+
+// IsCriticalState indicates whether the change in the inventory object count
+// has crossed the CRITICAL threshold.
+func (id InventoryDrift) IsCriticalState(thresholds InventoryDriftThresholds) bool {
+	return id.AbsDelta() > thresholds.Critical
+}
+
+// IsWarningState indicates whether the change in the inventory object count
+// has crossed the WARNING threshold, but not the CRITICAL threshold.
+func (id InventoryDrift) IsWarningState(thresholds InventoryDriftThresholds) bool {
+	return !id.IsCriticalState(thresholds) && id.AbsDelta() > thresholds.Warning
+}
+
+// HasBaseline indicates whether a baseline was available for comparison.
+func (set InventoryDriftSet) HasBaseline() bool {
+	return set.Baseline != nil
+}
+
+// NumCritical returns the number of inventory object counts which crossed
+// the CRITICAL threshold.
+func (set InventoryDriftSet) NumCritical() int {
+	var num int
+	for _, drift := range set.Drift {
+		if drift.IsCriticalState(set.Thresholds) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of inventory object counts which crossed
+// the WARNING threshold.
+func (set InventoryDriftSet) NumWarning() int {
+	var num int
+	for _, drift := range set.Drift {
+		if drift.IsWarningState(set.Thresholds) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any inventory object count crossed the
+// CRITICAL threshold.
+func (set InventoryDriftSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any inventory object count crossed the
+// WARNING threshold.
+func (set InventoryDriftSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// Total returns the current number of inventory objects of the specified
+// kind across all evaluated Datacenters.
+func (set InventoryDriftSet) Total(kind string) int {
+	var num int
+	for _, counts := range set.Current.Datacenters {
+		num += counts[kind]
+	}
+
+	return num
+}
+
+// TotalDelta returns the change in the number of inventory objects of the
+// specified kind across all evaluated Datacenters since the baseline.
+func (set InventoryDriftSet) TotalDelta(kind string) int {
+	var num int
+	for _, drift := range set.Drift {
+		if drift.Kind == kind {
+			num += drift.Delta()
+		}
+	}
+
+	return num
+}
+
+// InventoryDriftPerfData generates performance data metrics from the given
+// inventory drift evaluation results.
+func InventoryDriftPerfData(set InventoryDriftSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datacenters",
+			Value: fmt.Sprintf("%d", len(set.Current.Datacenters)),
+			Min:   "0",
+		},
+	}
+
+	for _, kind := range inventoryKinds {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: kind,
+				Value: fmt.Sprintf("%d", set.Total(kind)),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: kind + "_delta",
+				Value: fmt.Sprintf("%d", set.TotalDelta(kind)),
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// InventoryDriftOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func InventoryDriftOneLineCheckSummary(
+	stateLabel string,
+	set InventoryDriftSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute InventoryDriftOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case !set.HasBaseline():
+		return fmt.Sprintf(
+			"%s: Inventory baseline recorded (evaluated %d datacenters)",
+			stateLabel,
+			len(set.Current.Datacenters),
+		)
+
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d inventory object counts exceeding drift thresholds (evaluated %d datacenters)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set.Current.Datacenters),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No unexpected inventory drift detected (evaluated %d datacenters)",
+			stateLabel,
+			len(set.Current.Datacenters),
+		)
+	}
+}
+
+// InventoryDriftReport generates a summary of inventory object counts and
+// changes since the baseline along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body of
+// many notifications.
+func InventoryDriftReport(
+	c *vim25.Client,
+	set InventoryDriftSet,
+	stateFile string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute InventoryDriftReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Inventory changes since baseline:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, drift := range set.Drift {
+		var state string
+		switch {
+		case drift.IsCriticalState(set.Thresholds):
+			state = nagios.StateCRITICALLabel
+		case drift.IsWarningState(set.Thresholds):
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %s %d -> %d (%+d) [%s]%s",
+			drift.Datacenter,
+			drift.Kind,
+			drift.Baseline,
+			drift.Current,
+			drift.Delta(),
+			state,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(set.Drift) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sCurrent inventory:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	dcNames := make([]string, 0, len(set.Current.Datacenters))
+	for name := range set.Current.Datacenters {
+		dcNames = append(dcNames, name)
+	}
+	sort.Slice(dcNames, func(i, j int) bool {
+		return strings.ToLower(dcNames[i]) < strings.ToLower(dcNames[j])
+	})
+
+	for _, name := range dcNames {
+		counts := set.Current.Datacenters[name]
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %d hosts, %d VMs, %d datastores, %d networks%s",
+			name,
+			counts[InventoryKindHosts],
+			counts[InventoryKindVMs],
+			counts[InventoryKindDatastores],
+			counts[InventoryKindNetworks],
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* State file: %s%s",
+		stateFile,
+		nagios.CheckOutputEOL,
+	)
+
+	baselineRecorded := "none (baseline recorded by this run)"
+	if set.HasBaseline() {
+		baselineRecorded = set.Baseline.Recorded.Format(time.RFC3339)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Baseline recorded: %s%s",
+		baselineRecorded,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewInventoryDriftSet(t *testing.T) {
+	thresholds := InventoryDriftThresholds{Warning: 2, Critical: 5}
+
+	previous := InventoryBaseline{
+		Datacenters: map[string]InventoryCounts{
+			"dc1": {
+				InventoryKindHosts:      4,
+				InventoryKindVMs:        100,
+				InventoryKindDatastores: 10,
+				InventoryKindNetworks:   6,
+			},
+		},
+	}
+
+	// withCounts returns the previous baseline with the given counts
+	// replaced for dc1 and the given additional Datacenters.
+	withCounts := func(dc1 InventoryCounts, others map[string]InventoryCounts) InventoryBaseline {
+		counts := make(InventoryCounts)
+		for kind, num := range previous.Datacenters["dc1"] {
+			counts[kind] = num
+		}
+		for kind, num := range dc1 {
+			counts[kind] = num
+		}
+
+		current := InventoryBaseline{Datacenters: map[string]InventoryCounts{"dc1": counts}}
+		for name, dcCounts := range others {
+			current.Datacenters[name] = dcCounts
+		}
+
+		return current
+	}
+
+	tests := map[string]struct {
+		current      InventoryBaseline
+		baseline     *InventoryBaseline
+		wantCritical bool
+		wantWarning  bool
+		wantDrift    []string
+	}{
+		"no baseline recorded": {
+			current: withCounts(InventoryCounts{InventoryKindVMs: 200}, nil),
+		},
+		"unchanged": {
+			current:  previous,
+			baseline: &previous,
+		},
+		"changes at WARNING threshold": {
+			current:   withCounts(InventoryCounts{InventoryKindVMs: 98}, nil),
+			baseline:  &previous,
+			wantDrift: []string{"dc1:vms:-2"},
+		},
+		"changes above WARNING threshold": {
+			current:     withCounts(InventoryCounts{InventoryKindVMs: 105}, nil),
+			baseline:    &previous,
+			wantWarning: true,
+			wantDrift:   []string{"dc1:vms:5"},
+		},
+		"changes above CRITICAL threshold": {
+			current: withCounts(InventoryCounts{
+				InventoryKindVMs:        94,
+				InventoryKindDatastores: 7,
+			}, nil),
+			baseline:     &previous,
+			wantCritical: true,
+			wantWarning:  true,
+			wantDrift:    []string{"dc1:vms:-6", "dc1:datastores:-3"},
+		},
+		"datacenters added are sorted case-insensitively": {
+			current: withCounts(nil, map[string]InventoryCounts{
+				"DC3": {InventoryKindHosts: 1},
+				"dc2": {InventoryKindNetworks: 1},
+			}),
+			baseline:  &previous,
+			wantDrift: []string{"dc2:networks:1", "DC3:hosts:1"},
+		},
+		"datacenter removed": {
+			current:      InventoryBaseline{},
+			baseline:     &previous,
+			wantCritical: true,
+			wantWarning:  true,
+			wantDrift: []string{
+				"dc1:hosts:-4",
+				"dc1:vms:-100",
+				"dc1:datastores:-10",
+				"dc1:networks:-6",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewInventoryDriftSet(tt.current, tt.baseline, thresholds)
+
+			if got := set.HasBaseline(); got != (tt.baseline != nil) {
+				t.Errorf("want baseline found %t; got %t", tt.baseline != nil, got)
+			}
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			var got []string
+			for _, drift := range set.Drift {
+				got = append(got, fmt.Sprintf("%s:%s:%d", drift.Datacenter, drift.Kind, drift.Delta()))
+			}
+
+			if d := cmp.Diff(tt.wantDrift, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestInventoryDriftSetTotals(t *testing.T) {
+	baseline := InventoryBaseline{
+		Datacenters: map[string]InventoryCounts{
+			"dc1": {InventoryKindVMs: 100},
+			"dc2": {InventoryKindVMs: 50},
+		},
+	}
+
+	current := InventoryBaseline{
+		Datacenters: map[string]InventoryCounts{
+			"dc1": {InventoryKindVMs: 103},
+			"dc2": {InventoryKindVMs: 45},
+		},
+	}
+
+	set := NewInventoryDriftSet(current, &baseline, InventoryDriftThresholds{})
+
+	if got := set.Total(InventoryKindVMs); got != 148 {
+		t.Errorf("want 148 VMs; got %d", got)
+	}
+
+	if got := set.TotalDelta(InventoryKindVMs); got != -2 {
+		t.Errorf("want VM delta of -2; got %d", got)
+	}
+
+	if got := set.TotalDelta(InventoryKindHosts); got != 0 {
+		t.Errorf("want host delta of 0; got %d", got)
+	}
+}
+
+func TestInventoryBaselineRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "inventory.json")
+
+	missing, err := LoadInventoryBaseline(filename)
+	if err != nil {
+		t.Fatalf("want nil error for missing state file; got %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("want nil baseline for missing state file; got %+v", missing)
+	}
+
+	current := InventoryBaseline{
+		Datacenters: map[string]InventoryCounts{
+			"dc1": {InventoryKindHosts: 4, InventoryKindVMs: 100},
+		},
+	}
+
+	if err := SaveInventoryBaseline(filename, current); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	loaded, err := LoadInventoryBaseline(filename)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if d := cmp.Diff(&current, loaded); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if err := os.WriteFile(filename, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	if _, err := LoadInventoryBaseline(filename); err == nil {
+		t.Error("want error for invalid state file; got nil")
+	}
+}
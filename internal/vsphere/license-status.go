@@ -0,0 +1,485 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrLicenseThresholdCrossed indicates that specified license usage or
+// expiration thresholds have been crossed for one or more license keys.
+var ErrLicenseThresholdCrossed = errors.New("license usage or expiration exceeds specified threshold")
+
+// licensePropertyExpirationDate is the key used by license properties which
+// describe the expiration date of the license. Perpetual licenses do not
+// provide this property.
+const licensePropertyExpirationDate string = "expirationDate"
+
+// licenseKeyMaskChar is used to mask all but the last group of characters in
+// a license key.
+const licenseKeyMaskChar string = "*"
+
+// LicenseThresholds represents the user-specified license usage (percentage)
+// and expiration (days remaining) thresholds.
+type LicenseThresholds struct {
+	UsageWarning   int
+	UsageCritical  int
+	ExpiryWarning  int
+	ExpiryCritical int
+}
+
+// LicenseStatus tracks the usage and expiration of a specific license key
+// along with the entities the license is assigned to.
+type LicenseStatus struct {
+	// License is the license key details as reported by the LicenseManager.
+	License types.LicenseManagerLicenseInfo
+
+	// Entities is the collection of entity display names that the license
+	// is assigned to.
+	Entities []string
+
+	// ExpirationDate is the date that the license expires. This is the zero
+	// value for perpetual licenses.
+	ExpirationDate time.Time
+
+	Thresholds LicenseThresholds
+}
+
+// LicenseStatusSet is a collection of LicenseStatus values.
+type LicenseStatusSet []LicenseStatus
+
+// licenseExpirationDate returns the expiration date for the given license
+// and whether the license expires.
+func licenseExpirationDate(license types.LicenseManagerLicenseInfo) (time.Time, bool) {
+	for _, prop := range license.Properties {
+		if prop.Key != licensePropertyExpirationDate {
+			continue
+		}
+
+		switch v := prop.Value.(type) {
+		case time.Time:
+			return v, !v.IsZero()
+		case *time.Time:
+			if v != nil {
+				return *v, !v.IsZero()
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// MaskLicenseKey masks all but the last group of characters in the given
+// license key (e.g., *****-*****-*****-*****-ABCDE) so that license details
+// may be included in plugin output without exposing the full key.
+func MaskLicenseKey(key string) string {
+	groups := strings.Split(key, "-")
+	if len(groups) < 2 {
+		return strings.Repeat(licenseKeyMaskChar, len(key))
+	}
+
+	for i := 0; i < len(groups)-1; i++ {
+		groups[i] = strings.Repeat(licenseKeyMaskChar, len(groups[i]))
+	}
+
+	return strings.Join(groups, "-")
+}
+
+// NewLicenseStatusSet evaluates the given licenses and assignments,
+// returning a collection of license usage and expiration details.
+func NewLicenseStatusSet(
+	licenses []types.LicenseManagerLicenseInfo,
+	assignments map[string][]string,
+	thresholds LicenseThresholds,
+) LicenseStatusSet {
+
+	set := make(LicenseStatusSet, 0, len(licenses))
+
+	for _, license := range licenses {
+		entities := assignments[license.LicenseKey]
+		sort.Strings(entities)
+
+		ls := LicenseStatus{
+			License:    license,
+			Entities:   entities,
+			Thresholds: thresholds,
+		}
+
+		if expires, ok := licenseExpirationDate(license); ok {
+			ls.ExpirationDate = expires
+		}
+
+		set = append(set, ls)
+	}
+
+	return set
+
+}
+
+// MaskedKey returns the license key with all but the last group of
+// characters masked.
+func (ls LicenseStatus) MaskedKey() string {
+	return MaskLicenseKey(ls.License.LicenseKey)
+}
+
+// Unlimited indicates whether the license has no fixed capacity.
+func (ls LicenseStatus) Unlimited() bool {
+	return ls.License.Total <= 0
+}
+
+// UsedPercent returns the percentage of license capacity used. Zero is
+// returned for licenses without a fixed capacity.
+func (ls LicenseStatus) UsedPercent() float64 {
+	if ls.Unlimited() {
+		return 0
+	}
+
+	return float64(ls.License.Used) / float64(ls.License.Total) * 100
+}
+
+// IsOverAllocated indicates whether usage exceeds license capacity.
+func (ls LicenseStatus) IsOverAllocated() bool {
+	return !ls.Unlimited() && ls.License.Used > ls.License.Total
+}
+
+// Expires indicates whether the license has an expiration date.
+func (ls LicenseStatus) Expires() bool {
+	return !ls.ExpirationDate.IsZero()
+}
+
+// DaysRemaining returns the number of days remaining before the license
+// expires and whether the license expires. The number of days is negative
+// for expired licenses.
+func (ls LicenseStatus) DaysRemaining() (int, bool) {
+	if !ls.Expires() {
+		return 0, false
+	}
+
+	return int(time.Until(ls.ExpirationDate).Hours() / 24), true
+}
+
+// IsExpired indicates whether the license has expired.
+func (ls LicenseStatus) IsExpired() bool {
+	return ls.Expires() && time.Now().After(ls.ExpirationDate)
+}
+
+// IsUsageCriticalState indicates whether license usage has crossed the
+// CRITICAL level threshold or exceeds license capacity.
+func (ls LicenseStatus) IsUsageCriticalState() bool {
+	return ls.IsOverAllocated() ||
+		ls.UsedPercent() > float64(ls.Thresholds.UsageCritical)
+}
+
+// IsUsageWarningState indicates whether license usage has crossed the
+// WARNING level threshold.
+func (ls LicenseStatus) IsUsageWarningState() bool {
+	return ls.UsedPercent() > float64(ls.Thresholds.UsageWarning)
+}
+
+// IsExpiryCriticalState indicates whether the license has expired or has
+// crossed the CRITICAL expiration threshold.
+func (ls LicenseStatus) IsExpiryCriticalState() bool {
+	days, ok := ls.DaysRemaining()
+
+	return ls.IsExpired() || (ok && days <= ls.Thresholds.ExpiryCritical)
+}
+
+// IsExpiryWarningState indicates whether the license has crossed the
+// WARNING expiration threshold.
+func (ls LicenseStatus) IsExpiryWarningState() bool {
+	days, ok := ls.DaysRemaining()
+
+	return ok && days <= ls.Thresholds.ExpiryWarning
+}
+
+// IsCriticalState indicates whether license usage or expiration has crossed
+// a CRITICAL level threshold.
+func (ls LicenseStatus) IsCriticalState() bool {
+	return ls.IsUsageCriticalState() || ls.IsExpiryCriticalState()
+}
+
+// IsWarningState indicates whether license usage or expiration has crossed
+// a WARNING level threshold, but not a CRITICAL level threshold.
+func (ls LicenseStatus) IsWarningState() bool {
+	return !ls.IsCriticalState() &&
+		(ls.IsUsageWarningState() || ls.IsExpiryWarningState())
+}
+
+// HasCriticalState indicates whether any evaluated license has crossed a
+// CRITICAL level threshold.
+func (set LicenseStatusSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated license has crossed a
+// WARNING level threshold.
+func (set LicenseStatusSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// NumCritical returns the number of licenses in a CRITICAL state.
+func (set LicenseStatusSet) NumCritical() int {
+	var num int
+	for _, ls := range set {
+		if ls.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of licenses in a WARNING state.
+func (set LicenseStatusSet) NumWarning() int {
+	var num int
+	for _, ls := range set {
+		if ls.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumExpiring returns the number of licenses which have expired or have
+// crossed an expiration threshold.
+func (set LicenseStatusSet) NumExpiring() int {
+	var num int
+	for _, ls := range set {
+		if ls.IsExpiryCriticalState() || ls.IsExpiryWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumExpired returns the number of expired licenses.
+func (set LicenseStatusSet) NumExpired() int {
+	var num int
+	for _, ls := range set {
+		if ls.IsExpired() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumOverAllocated returns the number of licenses with usage exceeding
+// capacity.
+func (set LicenseStatusSet) NumOverAllocated() int {
+	var num int
+	for _, ls := range set {
+		if ls.IsOverAllocated() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// LicenseStatusPerfData generates performance data metrics from the given
+// collection of evaluated licenses.
+func LicenseStatusPerfData(set LicenseStatusSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "licenses",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_expiring",
+			Value: fmt.Sprintf("%d", set.NumExpiring()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_expired",
+			Value: fmt.Sprintf("%d", set.NumExpired()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_over_allocated",
+			Value: fmt.Sprintf("%d", set.NumOverAllocated()),
+			Min:   "0",
+		},
+	}
+}
+
+// LicenseStatusOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func LicenseStatusOneLineCheckSummary(
+	stateLabel string,
+	set LicenseStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute LicenseStatusOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d licenses exceeding usage or expiration thresholds (%d expiring, %d over capacity, evaluated %d licenses)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumExpiring(),
+			set.NumOverAllocated(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No license usage or expiration issues detected (evaluated %d licenses)",
+			stateLabel,
+			len(set),
+		)
+	}
+}
+
+// LicenseStatusReport generates a summary of license usage and expiration
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func LicenseStatusReport(
+	c *vim25.Client,
+	set LicenseStatusSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute LicenseStatusReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Licenses:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, ls := range set {
+		var state string
+		switch {
+		case ls.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case ls.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		usage := fmt.Sprintf(
+			"%d of %d %s used (%.2f%%)",
+			ls.License.Used,
+			ls.License.Total,
+			ls.License.CostUnit,
+			ls.UsedPercent(),
+		)
+		if ls.Unlimited() {
+			usage = fmt.Sprintf(
+				"%d of unlimited %s used",
+				ls.License.Used,
+				ls.License.CostUnit,
+			)
+		}
+
+		expiration := "never"
+		if days, ok := ls.DaysRemaining(); ok {
+			expiration = fmt.Sprintf(
+				"%s (%d days remaining)",
+				ls.ExpirationDate.Format("2006-01-02"),
+				days,
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s) [%s]%s"+
+				"** Key: %s%s"+
+				"** Usage: %s%s"+
+				"** Expires: %s%s"+
+				"** Assignments: %d%s",
+			ls.License.Name,
+			ls.License.EditionKey,
+			state,
+			nagios.CheckOutputEOL,
+			ls.MaskedKey(),
+			nagios.CheckOutputEOL,
+			usage,
+			nagios.CheckOutputEOL,
+			expiration,
+			nagios.CheckOutputEOL,
+			len(ls.Entities),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
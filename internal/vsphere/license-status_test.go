@@ -0,0 +1,252 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// statusLicense returns a license with the given capacity and usage which
+// expires after the given number of days. A zero value indicates a
+// perpetual license.
+func statusLicense(total int32, used int32, expiresInDays int) types.LicenseManagerLicenseInfo {
+	license := types.LicenseManagerLicenseInfo{
+		LicenseKey: "AAAAA-BBBBB-CCCCC-DDDDD-EEEEE",
+		Name:       "VMware vSphere 7 Enterprise Plus",
+		Total:      total,
+		Used:       used,
+	}
+
+	if expiresInDays != 0 {
+		license.Properties = []types.KeyAnyValue{
+			{
+				Key:   licensePropertyExpirationDate,
+				Value: time.Now().Add(time.Duration(expiresInDays)*24*time.Hour + time.Hour),
+			},
+		}
+	}
+
+	return license
+}
+
+func TestMaskLicenseKey(t *testing.T) {
+	tests := map[string]struct {
+		key  string
+		want string
+	}{
+		"full license key": {key: "AAAAA-BBBBB-CCCCC-DDDDD-EEEEE", want: "*****-*****-*****-*****-EEEEE"},
+		"two groups":       {key: "ABC-12345", want: "***-12345"},
+		"no groups":        {key: "ABCDE", want: "*****"},
+		"empty key":        {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := MaskLicenseKey(tt.key); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLicenseExpirationDate(t *testing.T) {
+	expires := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		props    []types.KeyAnyValue
+		want     time.Time
+		wantOkay bool
+	}{
+		"time value": {
+			props:    []types.KeyAnyValue{{Key: licensePropertyExpirationDate, Value: expires}},
+			want:     expires,
+			wantOkay: true,
+		},
+		"time pointer value": {
+			props:    []types.KeyAnyValue{{Key: licensePropertyExpirationDate, Value: &expires}},
+			want:     expires,
+			wantOkay: true,
+		},
+		"nil time pointer value": {
+			props: []types.KeyAnyValue{{Key: licensePropertyExpirationDate, Value: (*time.Time)(nil)}},
+		},
+		"zero time value": {
+			props: []types.KeyAnyValue{{Key: licensePropertyExpirationDate, Value: time.Time{}}},
+		},
+		"unexpected value type": {
+			props: []types.KeyAnyValue{{Key: licensePropertyExpirationDate, Value: "2022-03-01"}},
+		},
+		"other properties only": {
+			props: []types.KeyAnyValue{{Key: "ProductName", Value: "VMware ESX Server"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := licenseExpirationDate(types.LicenseManagerLicenseInfo{Properties: tt.props})
+
+			if ok != tt.wantOkay {
+				t.Errorf("want expiration date found %t; got %t", tt.wantOkay, ok)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewLicenseStatusSet(t *testing.T) {
+	thresholds := LicenseThresholds{
+		UsageWarning:   80,
+		UsageCritical:  95,
+		ExpiryWarning:  30,
+		ExpiryCritical: 7,
+	}
+
+	tests := map[string]struct {
+		license         types.LicenseManagerLicenseInfo
+		wantUsedPercent float64
+		wantDays        int
+		wantExpires     bool
+		wantCritical    bool
+		wantWarning     bool
+	}{
+		"perpetual license within usage thresholds": {
+			license:         statusLicense(100, 50, 0),
+			wantUsedPercent: 50,
+		},
+		"unlimited license": {
+			license: statusLicense(0, 500, 0),
+		},
+		"usage beyond WARNING threshold": {
+			license:         statusLicense(100, 85, 0),
+			wantUsedPercent: 85,
+			wantWarning:     true,
+		},
+		"usage beyond CRITICAL threshold": {
+			license:         statusLicense(100, 96, 0),
+			wantUsedPercent: 96,
+			wantCritical:    true,
+		},
+		"over-allocated": {
+			license:         statusLicense(100, 101, 0),
+			wantUsedPercent: 101,
+			wantCritical:    true,
+		},
+		"expires after WARNING threshold": {
+			license:         statusLicense(100, 50, 60),
+			wantUsedPercent: 50,
+			wantDays:        60,
+			wantExpires:     true,
+		},
+		"expires within WARNING threshold": {
+			license:         statusLicense(100, 50, 20),
+			wantUsedPercent: 50,
+			wantDays:        20,
+			wantExpires:     true,
+			wantWarning:     true,
+		},
+		"expires within CRITICAL threshold": {
+			license:         statusLicense(100, 50, 5),
+			wantUsedPercent: 50,
+			wantDays:        5,
+			wantExpires:     true,
+			wantCritical:    true,
+		},
+		"expired": {
+			license:         statusLicense(100, 50, -2),
+			wantUsedPercent: 50,
+			wantDays:        -1,
+			wantExpires:     true,
+			wantCritical:    true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewLicenseStatusSet(
+				[]types.LicenseManagerLicenseInfo{tt.license},
+				map[string][]string{tt.license.LicenseKey: {"esx2", "esx1"}},
+				thresholds,
+			)
+
+			if len(set) != 1 {
+				t.Fatalf("want 1 license; got %d", len(set))
+			}
+
+			ls := set[0]
+
+			if d := cmp.Diff([]string{"esx1", "esx2"}, ls.Entities); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := ls.UsedPercent(); math.Abs(got-tt.wantUsedPercent) > 0.01 {
+				t.Errorf("want %.2f%% used; got %.2f%%", tt.wantUsedPercent, got)
+			}
+
+			days, expires := ls.DaysRemaining()
+			if expires != tt.wantExpires || days != tt.wantDays {
+				t.Errorf("want %d days remaining (expires %t); got %d (expires %t)",
+					tt.wantDays, tt.wantExpires, days, expires)
+			}
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestLicenseStatusSetCounts(t *testing.T) {
+	set := NewLicenseStatusSet(
+		[]types.LicenseManagerLicenseInfo{
+			statusLicense(100, 101, 0),
+			statusLicense(100, 50, 20),
+			statusLicense(100, 50, 5),
+			statusLicense(100, 50, -2),
+			statusLicense(0, 10, 0),
+		},
+		nil,
+		LicenseThresholds{
+			UsageWarning:   80,
+			UsageCritical:  95,
+			ExpiryWarning:  30,
+			ExpiryCritical: 7,
+		},
+	)
+
+	if got := set.NumCritical(); got != 3 {
+		t.Errorf("want 3 CRITICAL licenses; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 {
+		t.Errorf("want 1 WARNING license; got %d", got)
+	}
+
+	if got := set.NumExpiring(); got != 3 {
+		t.Errorf("want 3 expiring licenses; got %d", got)
+	}
+
+	if got := set.NumExpired(); got != 1 {
+		t.Errorf("want 1 expired license; got %d", got)
+	}
+
+	if got := set.NumOverAllocated(); got != 1 {
+		t.Errorf("want 1 over-allocated license; got %d", got)
+	}
+}
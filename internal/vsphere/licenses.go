@@ -0,0 +1,506 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrLicenseUsageThresholdCrossed indicates that specified license usage
+// thresholds have been exceeded for one or more license keys.
+var ErrLicenseUsageThresholdCrossed = errors.New("license usage exceeds specified threshold")
+
+// ErrLicenseManagerUnavailable indicates that the LicenseManager is not
+// available for the connected vSphere environment.
+var ErrLicenseManagerUnavailable = errors.New("license manager unavailable")
+
+// LicenseEvaluationEditionKey is the edition key used by the built-in
+// evaluation license. This license is excluded from evaluation.
+const LicenseEvaluationEditionKey string = "eval"
+
+// licensePropertyFeature is the key used by license properties which
+// describe a feature provided by the license.
+const licensePropertyFeature string = "feature"
+
+// LicenseFeatureUsage tracks the usage of a specific license key along with
+// the features provided by the license and the entities the license is
+// assigned to.
+type LicenseFeatureUsage struct {
+	// License is the license key details as reported by the LicenseManager.
+	License types.LicenseManagerLicenseInfo
+
+	// Features is the collection of feature names provided by the license.
+	Features []string
+
+	// Entities is the collection of entity display names that the license
+	// is assigned to.
+	Entities []string
+
+	// CriticalThreshold is the percentage of license capacity used when a
+	// CRITICAL state is reached.
+	CriticalThreshold int
+
+	// WarningThreshold is the percentage of license capacity used when a
+	// WARNING state is reached.
+	WarningThreshold int
+}
+
+// LicenseFeatureUsageSet is a collection of LicenseFeatureUsage values.
+type LicenseFeatureUsageSet []LicenseFeatureUsage
+
+// licenseFeatures returns the feature names provided by the given license.
+func licenseFeatures(license types.LicenseManagerLicenseInfo) []string {
+	features := make([]string, 0, len(license.Properties))
+
+	for _, prop := range license.Properties {
+		if prop.Key != licensePropertyFeature {
+			continue
+		}
+
+		switch v := prop.Value.(type) {
+		case types.KeyValue:
+			features = append(features, v.Value)
+		case *types.KeyValue:
+			features = append(features, v.Value)
+		}
+	}
+
+	sort.Strings(features)
+
+	return features
+}
+
+// GetLicenses retrieves all license keys known to the LicenseManager for the
+// connected vSphere environment along with the display names of the entities
+// each license is assigned to. The evaluation license is excluded.
+func GetLicenses(ctx context.Context, c *vim25.Client) ([]types.LicenseManagerLicenseInfo, map[string][]string, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetLicenses func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if c.ServiceContent.LicenseManager == nil {
+		return nil, nil, ErrLicenseManagerUnavailable
+	}
+
+	var lm mo.LicenseManager
+	err := property.DefaultCollector(c).RetrieveOne(
+		ctx,
+		*c.ServiceContent.LicenseManager,
+		[]string{"licenses", "licenseAssignmentManager"},
+		&lm,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to retrieve licenses: %w",
+			err,
+		)
+	}
+
+	licenses := make([]types.LicenseManagerLicenseInfo, 0, len(lm.Licenses))
+	for _, license := range lm.Licenses {
+		if license.EditionKey == LicenseEvaluationEditionKey {
+			continue
+		}
+		licenses = append(licenses, license)
+	}
+
+	sort.Slice(licenses, func(i, j int) bool {
+		return strings.ToLower(licenses[i].Name) < strings.ToLower(licenses[j].Name)
+	})
+
+	assignments := make(map[string][]string)
+
+	// Standalone ESXi hosts do not provide a LicenseAssignmentManager.
+	if lm.LicenseAssignmentManager == nil {
+		return licenses, assignments, nil
+	}
+
+	req := types.QueryAssignedLicenses{
+		This: *lm.LicenseAssignmentManager,
+	}
+
+	res, err := methods.QueryAssignedLicenses(ctx, c, &req)
+	if err != nil {
+		return nil, nil, fmt.Errorf(
+			"failed to retrieve license assignments: %w",
+			err,
+		)
+	}
+
+	for _, assignment := range res.Returnval {
+		key := assignment.AssignedLicense.LicenseKey
+		name := assignment.EntityDisplayName
+		if name == "" {
+			name = assignment.EntityId
+		}
+		assignments[key] = append(assignments[key], name)
+	}
+
+	return licenses, assignments, nil
+
+}
+
+// NewLicenseFeatureUsageSet evaluates the given licenses and assignments,
+// returning a collection of license usage details. If a list of features is
+// specified, only licenses which provide one of the specified features
+// (case-insensitive substring match) are included.
+func NewLicenseFeatureUsageSet(
+	licenses []types.LicenseManagerLicenseInfo,
+	assignments map[string][]string,
+	features []string,
+	criticalThreshold int,
+	warningThreshold int,
+) LicenseFeatureUsageSet {
+
+	set := make(LicenseFeatureUsageSet, 0, len(licenses))
+
+	for _, license := range licenses {
+		licFeatures := licenseFeatures(license)
+
+		if len(features) > 0 && !licenseFeaturesMatch(licFeatures, features) {
+			logger.Printf(
+				"license %s (%s) does not provide specified features; skipping",
+				license.Name,
+				license.EditionKey,
+			)
+
+			continue
+		}
+
+		entities := assignments[license.LicenseKey]
+		sort.Strings(entities)
+
+		set = append(set, LicenseFeatureUsage{
+			License:           license,
+			Features:          licFeatures,
+			Entities:          entities,
+			CriticalThreshold: criticalThreshold,
+			WarningThreshold:  warningThreshold,
+		})
+	}
+
+	return set
+
+}
+
+// licenseFeaturesMatch indicates whether any of the given license features
+// case-insensitively contain any of the specified feature names.
+func licenseFeaturesMatch(licFeatures []string, features []string) bool {
+	for _, licFeature := range licFeatures {
+		for _, feature := range features {
+			if strings.Contains(
+				strings.ToLower(licFeature),
+				strings.ToLower(feature),
+			) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Unlimited indicates whether the license has no fixed capacity.
+func (lfu LicenseFeatureUsage) Unlimited() bool {
+	return lfu.License.Total <= 0
+}
+
+// UsedPercent returns the percentage of license capacity used. Zero is
+// returned for licenses without a fixed capacity.
+func (lfu LicenseFeatureUsage) UsedPercent() float64 {
+	if lfu.Unlimited() {
+		return 0
+	}
+
+	return float64(lfu.License.Used) / float64(lfu.License.Total) * 100
+}
+
+// Remaining returns the remaining license capacity. A negative value
+// indicates that license capacity has been exceeded.
+func (lfu LicenseFeatureUsage) Remaining() int32 {
+	return lfu.License.Total - lfu.License.Used
+}
+
+// IsOverAllocated indicates whether usage exceeds license capacity.
+func (lfu LicenseFeatureUsage) IsOverAllocated() bool {
+	return !lfu.Unlimited() && lfu.License.Used > lfu.License.Total
+}
+
+// IsWarningState indicates whether license usage has crossed the WARNING
+// level threshold.
+func (lfu LicenseFeatureUsage) IsWarningState() bool {
+	return !lfu.IsCriticalState() &&
+		lfu.UsedPercent() > float64(lfu.WarningThreshold)
+}
+
+// IsCriticalState indicates whether license usage has crossed the CRITICAL
+// level threshold or exceeds license capacity.
+func (lfu LicenseFeatureUsage) IsCriticalState() bool {
+	return lfu.IsOverAllocated() ||
+		lfu.UsedPercent() > float64(lfu.CriticalThreshold)
+}
+
+// HasCriticalState indicates whether any evaluated license has crossed the
+// CRITICAL level threshold.
+func (set LicenseFeatureUsageSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated license has crossed the
+// WARNING level threshold.
+func (set LicenseFeatureUsageSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// NumCritical returns the number of licenses in a CRITICAL state.
+func (set LicenseFeatureUsageSet) NumCritical() int {
+	var num int
+	for _, lfu := range set {
+		if lfu.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of licenses in a WARNING state.
+func (set LicenseFeatureUsageSet) NumWarning() int {
+	var num int
+	for _, lfu := range set {
+		if lfu.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumOverAllocated returns the number of licenses with usage exceeding
+// capacity.
+func (set LicenseFeatureUsageSet) NumOverAllocated() int {
+	var num int
+	for _, lfu := range set {
+		if lfu.IsOverAllocated() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// LicenseFeatureUsagePerfData generates performance data metrics from the
+// given collection of evaluated licenses.
+func LicenseFeatureUsagePerfData(set LicenseFeatureUsageSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "licenses",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "licenses_over_allocated",
+			Value: fmt.Sprintf("%d", set.NumOverAllocated()),
+			Min:   "0",
+		},
+	}
+}
+
+// LicenseFeatureUsageOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func LicenseFeatureUsageOneLineCheckSummary(
+	stateLabel string,
+	set LicenseFeatureUsageSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute LicenseFeatureUsageOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d licenses exceeding usage thresholds (%d over capacity, evaluated %d licenses)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumOverAllocated(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No license usage issues detected (evaluated %d licenses)",
+			stateLabel,
+			len(set),
+		)
+	}
+}
+
+// LicenseFeatureUsageReport generates a summary of license usage along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func LicenseFeatureUsageReport(
+	c *vim25.Client,
+	set LicenseFeatureUsageSet,
+	features []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute LicenseFeatureUsageReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Licenses exceeding usage thresholds:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemLicenses int
+	for _, lfu := range set {
+		if !lfu.IsCriticalState() && !lfu.IsWarningState() {
+			continue
+		}
+		numProblemLicenses++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s): %d of %d %s used (%.2f%%, remaining: %d)%s",
+			lfu.License.Name,
+			lfu.License.EditionKey,
+			lfu.License.Used,
+			lfu.License.Total,
+			lfu.License.CostUnit,
+			lfu.UsedPercent(),
+			lfu.Remaining(),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numProblemLicenses == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sLicense usage and features:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, lfu := range set {
+		total := fmt.Sprintf("%d", lfu.License.Total)
+		if lfu.Unlimited() {
+			total = "unlimited"
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s): %d of %s %s used, %d assignments%s",
+			lfu.License.Name,
+			lfu.License.EditionKey,
+			lfu.License.Used,
+			total,
+			lfu.License.CostUnit,
+			len(lfu.Entities),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"** Features (%d): [%v]%s",
+			len(lfu.Features),
+			strings.Join(lfu.Features, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified license features to evaluate (%d): [%v]%s",
+		len(features),
+		strings.Join(features, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// featureLicense returns a license with the given capacity and usage which
+// provides the given features.
+func featureLicense(key string, total int32, used int32, features ...string) types.LicenseManagerLicenseInfo {
+	license := types.LicenseManagerLicenseInfo{
+		LicenseKey: key,
+		Name:       key,
+		Total:      total,
+		Used:       used,
+	}
+
+	for _, feature := range features {
+		license.Properties = append(license.Properties, types.KeyAnyValue{
+			Key:   licensePropertyFeature,
+			Value: types.KeyValue{Key: feature, Value: feature},
+		})
+	}
+
+	return license
+}
+
+func TestLicenseFeatures(t *testing.T) {
+	license := types.LicenseManagerLicenseInfo{
+		Properties: []types.KeyAnyValue{
+			{Key: licensePropertyFeature, Value: types.KeyValue{Key: "vmotion", Value: "vMotion"}},
+			{Key: licensePropertyFeature, Value: &types.KeyValue{Key: "drs", Value: "DRS"}},
+			{Key: licensePropertyFeature, Value: "unexpected"},
+			{Key: "ProductName", Value: types.KeyValue{Key: "product", Value: "VMware ESX Server"}},
+		},
+	}
+
+	want := []string{"DRS", "vMotion"}
+	if d := cmp.Diff(want, licenseFeatures(license)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestLicenseFeaturesMatch(t *testing.T) {
+	licFeatures := []string{"Distributed Resource Scheduler (DRS)", "vMotion"}
+
+	tests := map[string]struct {
+		features []string
+		want     bool
+	}{
+		"exact match":              {features: []string{"vMotion"}, want: true},
+		"case-insensitive partial": {features: []string{"drs"}, want: true},
+		"any listed feature":       {features: []string{"vSAN", "MOTION"}, want: true},
+		"no match":                 {features: []string{"vSAN"}},
+		"no features":              {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := licenseFeaturesMatch(licFeatures, tt.features); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewLicenseFeatureUsageSet(t *testing.T) {
+	const (
+		criticalThreshold = 95
+		warningThreshold  = 80
+	)
+
+	licenses := []types.LicenseManagerLicenseInfo{
+		featureLicense("vsphere", 64, 32, "vMotion", "Distributed Resource Scheduler (DRS)"),
+		featureLicense("vsan", 32, 27, "vSAN"),
+		featureLicense("nsx", 16, 17, "NSX Distributed Firewall"),
+	}
+
+	assignments := map[string][]string{
+		"vsphere": {"esx2", "esx1"},
+	}
+
+	tests := map[string]struct {
+		features     []string
+		wantCritical bool
+		wantWarning  bool
+		wantLicenses []string
+	}{
+		"all licenses": {
+			wantCritical: true,
+			wantWarning:  true,
+			wantLicenses: []string{"vsphere", "vsan", "nsx"},
+		},
+		"feature within thresholds": {
+			features:     []string{"drs"},
+			wantLicenses: []string{"vsphere"},
+		},
+		"feature beyond WARNING threshold": {
+			features:     []string{"VSAN"},
+			wantWarning:  true,
+			wantLicenses: []string{"vsan"},
+		},
+		"feature over-allocated": {
+			features:     []string{"firewall"},
+			wantCritical: true,
+			wantLicenses: []string{"nsx"},
+		},
+		"feature not provided": {
+			features:     []string{"vVols"},
+			wantLicenses: []string{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewLicenseFeatureUsageSet(
+				licenses,
+				assignments,
+				tt.features,
+				criticalThreshold,
+				warningThreshold,
+			)
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			got := make([]string, 0, len(set))
+			for _, lfu := range set {
+				got = append(got, lfu.License.LicenseKey)
+
+				if lfu.License.LicenseKey != "vsphere" {
+					continue
+				}
+
+				if d := cmp.Diff([]string{"esx1", "esx2"}, lfu.Entities); d != "" {
+					t.Errorf("(-want, +got):\n%s", d)
+				}
+			}
+
+			if d := cmp.Diff(tt.wantLicenses, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestLicenseFeatureUsageUsedPercent(t *testing.T) {
+	tests := map[string]struct {
+		license           types.LicenseManagerLicenseInfo
+		wantPercent       float64
+		wantRemaining     int32
+		wantOverAllocated bool
+	}{
+		"partially used": {
+			license:       featureLicense("vsphere", 64, 16),
+			wantPercent:   25,
+			wantRemaining: 48,
+		},
+		"fully used": {
+			license:     featureLicense("vsphere", 64, 64),
+			wantPercent: 100,
+		},
+		"over-allocated": {
+			license:           featureLicense("nsx", 16, 20),
+			wantPercent:       125,
+			wantRemaining:     -4,
+			wantOverAllocated: true,
+		},
+		"unlimited": {
+			license: featureLicense("vcenter", 0, 3),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			lfu := LicenseFeatureUsage{License: tt.license}
+
+			if got := lfu.UsedPercent(); math.Abs(got-tt.wantPercent) > 0.01 {
+				t.Errorf("want %.2f%% used; got %.2f%%", tt.wantPercent, got)
+			}
+
+			if !lfu.Unlimited() {
+				if got := lfu.Remaining(); got != tt.wantRemaining {
+					t.Errorf("want %d remaining; got %d", tt.wantRemaining, got)
+				}
+			}
+
+			if got := lfu.IsOverAllocated(); got != tt.wantOverAllocated {
+				t.Errorf("want over-allocated %t; got %t", tt.wantOverAllocated, got)
+			}
+		})
+	}
+}
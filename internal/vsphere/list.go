@@ -0,0 +1,224 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ObjectNameMatches indicates whether the given object name matches the
+// specified (case-insensitive) glob pattern. An empty pattern matches all
+// names. Invalid patterns do not match any names.
+func ObjectNameMatches(name string, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	if err != nil {
+		return false
+	}
+
+	return matched
+}
+
+// ListObjectNames retrieves the names of all managed objects of the
+// specified kind (e.g., ClusterComputeResource, Datastore, HostSystem,
+// VirtualMachine) matching the given (case-insensitive) glob pattern. If
+// specified, only objects within the named datacenter are listed, otherwise
+// objects from all datacenters are listed. The names are returned in sorted
+// order.
+func ListObjectNames(ctx context.Context, c *vim25.Client, objKind string, datacenter string, pattern string) ([]string, error) {
+
+	funcTimeStart := time.Now()
+
+	var names []string
+
+	defer func(names *[]string) {
+		logger.Printf(
+			"It took %v to execute ListObjectNames func (and list %d %s objects).\n",
+			time.Since(funcTimeStart),
+			len(*names),
+			objKind,
+		)
+	}(&names)
+
+	switch objKind {
+	case MgObjRefTypeClusterComputeResource:
+	case MgObjRefTypeDatastore:
+	case MgObjRefTypeHostSystem:
+	case MgObjRefTypeVirtualMachine:
+	default:
+		return nil, fmt.Errorf(
+			"unsupported object type specified for listing: %s",
+			objKind,
+		)
+	}
+
+	root := c.ServiceContent.RootFolder
+	if datacenter != "" {
+		finder := find.NewFinder(c, true)
+		dc, findDCErr := finder.Datacenter(ctx, datacenter)
+		if findDCErr != nil {
+			return nil, fmt.Errorf(
+				"failed to find datacenter %q: %w",
+				datacenter,
+				findDCErr,
+			)
+		}
+		root = dc.Reference()
+	}
+
+	m := view.NewManager(c)
+
+	v, createViewErr := m.CreateContainerView(ctx, root, []string{objKind}, true)
+	if createViewErr != nil {
+		return nil, createViewErr
+	}
+
+	defer func() {
+		if err := v.Destroy(ctx); err != nil {
+			logger.Printf("Error occurred while destroying view: %s", err)
+		}
+	}()
+
+	var entities []mo.ManagedEntity
+	if err := v.Retrieve(ctx, []string{objKind}, []string{"name"}, &entities); err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve %s objects: %w",
+			objKind,
+			err,
+		)
+	}
+
+	for _, entity := range entities {
+		if ObjectNameMatches(entity.Name, pattern) {
+			names = append(names, entity.Name)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	return names, nil
+
+}
+
+// ListObjectNamesOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary when listing object names instead of
+// performing an evaluation.
+func ListObjectNamesOneLineCheckSummary(
+	stateLabel string,
+	objKind string,
+	names []string,
+) string {
+	recordSummaryData(map[string]interface{}{
+		"objKind": objKind,
+		"names":   names,
+	})
+
+	return fmt.Sprintf(
+		"%s: %d %s objects found (listing only; no evaluation performed)",
+		stateLabel,
+		len(names),
+		objKind,
+	)
+}
+
+// ListObjectNamesReport generates a list of object names intended for use
+// when building monitoring configuration. This information is provided for
+// use with the Long Service Output field.
+func ListObjectNamesReport(
+	c *vim25.Client,
+	objKind string,
+	datacenter string,
+	pattern string,
+	names []string,
+) string {
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s objects:%s%s",
+		objKind,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(names) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+	}
+
+	for _, name := range names {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			name,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if datacenter == "" {
+		datacenter = "all"
+	}
+
+	if pattern == "" {
+		pattern = "none"
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datacenter: %s%s",
+		datacenter,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Name pattern: %s%s",
+		pattern,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
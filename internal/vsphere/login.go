@@ -15,11 +15,48 @@ import (
 	"time"
 
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
 )
 
+// ConnectionOptions holds the optional TLS and proxy settings used by Login
+// when creating new vSphere clients.
+type ConnectionOptions struct {
+	// CAFile is a PEM encoded CA certificate bundle (or a list of bundles
+	// separated by the OS-specific path list separator) used in place of
+	// the system certificate pool.
+	CAFile string
+
+	// TLSMinVersion is the minimum TLS version (e.g., 1.2).
+	TLSMinVersion string
+
+	// SkipHostnameVerify disables verification of the server hostname while
+	// still verifying the certificate chain.
+	SkipHostnameVerify bool
+
+	// Proxy is the URL of the proxy (e.g., http://proxy.example.com:3128 or
+	// socks5://proxy.example.com:1080) used to connect to vSphere.
+	Proxy string
+
+	// NoProxy is a comma-separated list of hosts, domains, IP Addresses or
+	// CIDR ranges which are reached directly.
+	NoProxy string
+}
+
 // Login receives credentials and related settings used to handle creating a
 // new client and logging into a specified vSphere environment. The
-// initialized and logged-in client is returned for further use.
+// initialized and logged-in client is returned for further use. If session
+// caching is enabled a valid cached session is reused and a new session is
+// created (and cached) only if the cached session is missing or expired.
+//
+// The TLS configuration for the client is built from the trustCert setting
+// and the TLS settings (CA bundle, minimum TLS version, hostname
+// verification) from the given connection options. Connections are made via
+// the proxy from the given connection options, if any. If name redaction is
+// enabled via SetNameRedaction the names of objects to redact are retrieved
+// once logged in.
 func Login(
 	ctx context.Context,
 	server string,
@@ -29,7 +66,8 @@ func Login(
 	domain string,
 	password string,
 	userAgent string,
-) (*govmomi.Client, error) {
+	opts ConnectionOptions,
+) (client *govmomi.Client, err error) {
 
 	funcTimeStart := time.Now()
 
@@ -40,6 +78,25 @@ func Login(
 		)
 	}()
 
+	defer func() {
+		err = recordPhase(ctx, "login", funcTimeStart, err)
+	}()
+
+	// Record object names for redaction from plugin output once logged in.
+	// Fail if the names cannot be retrieved to avoid leaking names.
+	defer func() {
+		if err != nil || !nameRedactionEnabled() {
+			return
+		}
+
+		if err = loadRedactedNames(ctx, client.Client); err != nil {
+			if logoutErr := Logout(ctx, client); logoutErr != nil {
+				logger.Printf("failed to logout: %v", logoutErr)
+			}
+			client = nil
+		}
+	}()
+
 	vCenterURL := fmt.Sprintf("https://%s:%d/sdk", server, port)
 
 	// TODO: soap.ParseURL automatically adds missing scheme and path. It may
@@ -53,11 +110,31 @@ func Login(
 		username = strings.Join([]string{username, domain}, "@")
 	}
 
-	c, err := govmomi.NewClient(ctx, u, trustCert)
+	if sessionCache.enabled {
+		u.User = url.UserPassword(username, password)
+
+		return loginCachedSession(ctx, u, trustCert, userAgent, opts)
+	}
+
+	sc := soap.NewClient(u, trustCert)
+	if err := configureTLS(sc, trustCert, opts); err != nil {
+		return nil, err
+	}
+
+	if err := configureProxy(sc, opts); err != nil {
+		return nil, err
+	}
+
+	vc, err := vim25.NewClient(ctx, sc)
 	if err != nil {
 		return nil, err
 	}
 
+	c := &govmomi.Client{
+		Client:         vc,
+		SessionManager: session.NewManager(vc),
+	}
+
 	// Override default user agent
 	c.Client.UserAgent = userAgent
 
@@ -74,3 +151,46 @@ func Login(
 	return c, nil
 
 }
+
+// LoginREST receives an existing (logged-in) client along with credentials
+// and uses them to create a new vSphere Automation (REST) API client and
+// session. The logged-in REST API client is returned for further use. The
+// caller is responsible for logging out of the REST API session.
+func LoginREST(
+	ctx context.Context,
+	c *vim25.Client,
+	username string,
+	domain string,
+	password string,
+) (client *rest.Client, err error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute LoginREST func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	defer func() {
+		err = recordPhase(ctx, "rest-login", funcTimeStart, err)
+	}()
+
+	if domain != "" {
+		username = strings.Join([]string{username, domain}, "@")
+	}
+
+	rc := rest.NewClient(c)
+
+	authErr := rc.Login(ctx, url.UserPassword(username, password))
+	if authErr != nil {
+		return nil, fmt.Errorf(
+			"failed to login to vSphere Automation API: %w",
+			authErr,
+		)
+	}
+
+	return rc, nil
+
+}
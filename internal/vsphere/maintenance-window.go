@@ -0,0 +1,195 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// VMMaintenanceWindow represents a VirtualMachine with an active
+// maintenance window as indicated by a user-specified Custom Attribute.
+type VMMaintenanceWindow struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// Until is the end of the active maintenance window.
+	Until time.Time
+}
+
+// VMMaintenanceWindows is a collection of VirtualMachines with an active
+// maintenance window.
+type VMMaintenanceWindows []VMMaintenanceWindow
+
+// String provides a human readable representation of a VirtualMachine with
+// an active maintenance window.
+func (vmmw VMMaintenanceWindow) String() string {
+	return fmt.Sprintf(
+		"%s (until %s)",
+		vmmw.VMName,
+		vmmw.Until.Format(time.RFC3339),
+	)
+}
+
+// FilterVMsByMaintenanceWindow filters the given VirtualMachines using the
+// value of the specified Custom Attribute. The value is parsed as the end of
+// a maintenance window using the given Go time layout. VirtualMachines with
+// a maintenance window ending after the given reference time are suppressed
+// (excluded from evaluation). VirtualMachines without the Custom Attribute,
+// with an empty value or with a value which cannot be parsed are retained.
+// The retained VirtualMachines are returned along with the suppressed
+// VirtualMachines.
+func FilterVMsByMaintenanceWindow(
+	vms []mo.VirtualMachine,
+	caName string,
+	dateFormat string,
+	now time.Time,
+) ([]mo.VirtualMachine, VMMaintenanceWindows) {
+
+	funcTimeStart := time.Now()
+
+	vmsToKeep := make([]mo.VirtualMachine, 0, len(vms))
+	var suppressed VMMaintenanceWindows
+
+	defer func(vms []mo.VirtualMachine, filteredVMs *[]mo.VirtualMachine) {
+		logger.Printf(
+			"It took %v to execute FilterVMsByMaintenanceWindow func (for %d VMs, yielding %d VMs)\n",
+			time.Since(funcTimeStart),
+			len(vms),
+			len(*filteredVMs),
+		)
+	}(vms, &vmsToKeep)
+
+	for _, vm := range vms {
+		caVal, err := GetObjectCAVal(caName, vm.ManagedEntity)
+		if err != nil {
+			// The Custom Attribute is not defined or not set for this VM.
+			logger.Printf(
+				"Custom Attribute %q not retrieved for VM %s: %v",
+				caName,
+				vm.Name,
+				err,
+			)
+
+			vmsToKeep = append(vmsToKeep, vm)
+
+			continue
+		}
+
+		caVal = strings.TrimSpace(caVal)
+		if caVal == "" {
+			vmsToKeep = append(vmsToKeep, vm)
+
+			continue
+		}
+
+		until, err := time.ParseInLocation(dateFormat, caVal, time.Local)
+		if err != nil {
+			logger.Printf(
+				"failed to parse Custom Attribute %q value %q for VM %s using format %q: %v",
+				caName,
+				caVal,
+				vm.Name,
+				dateFormat,
+				err,
+			)
+
+			vmsToKeep = append(vmsToKeep, vm)
+
+			continue
+		}
+
+		if until.After(now) {
+			suppressed = append(suppressed, VMMaintenanceWindow{
+				VMName: vm.Name,
+				Until:  until,
+			})
+
+			continue
+		}
+
+		vmsToKeep = append(vmsToKeep, vm)
+	}
+
+	return vmsToKeep, suppressed
+}
+
+// filterVMsByMaintenanceWindow uses the given filtering options to suppress
+// VirtualMachines with an active maintenance window.
+func filterVMsByMaintenanceWindow(
+	vms []mo.VirtualMachine,
+	filterOptions VMsFilterOptions,
+) ([]mo.VirtualMachine, VMMaintenanceWindows) {
+
+	if filterOptions.MaintenanceCAName == "" {
+		logger.Println("Skipping filter by maintenance window; maintenance window Custom Attribute not specified")
+
+		return vms, nil
+	}
+
+	filteredVMs, suppressed := FilterVMsByMaintenanceWindow(
+		vms,
+		filterOptions.MaintenanceCAName,
+		filterOptions.MaintenanceCADateFormat,
+		time.Now(),
+	)
+
+	logger.Printf(
+		"VMs after maintenance window filtering: %v (kept: %d, suppressed: %d)",
+		strings.Join(VMNames(filteredVMs), ", "),
+		len(filteredVMs),
+		len(suppressed),
+	)
+
+	return filteredVMs, suppressed
+}
+
+// vmMaintenanceWindowReport writes a summary of the VirtualMachines
+// suppressed due to an active maintenance window. Nothing is written if a
+// maintenance window Custom Attribute was not specified.
+func vmMaintenanceWindowReport(
+	w io.Writer,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+) {
+
+	if vmsFilterOptions.MaintenanceCAName == "" {
+		return
+	}
+
+	suppressed := vmsFilterResults.VMsSuppressedByMaintenanceWindow()
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Maintenance window Custom Attribute: %s%s",
+		vmsFilterOptions.MaintenanceCAName,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* VMs suppressed by active maintenance window (%d):%s",
+		len(suppressed),
+		nagios.CheckOutputEOL,
+	)
+
+	for _, vmmw := range suppressed {
+		_, _ = fmt.Fprintf(
+			w,
+			"** %s%s",
+			vmmw,
+			nagios.CheckOutputEOL,
+		)
+	}
+}
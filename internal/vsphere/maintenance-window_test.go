@@ -0,0 +1,175 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	maintenanceCAName     string = "nagios_maintenance_until"
+	maintenanceDateFormat string = "2006-01-02 15:04"
+)
+
+// maintenanceVM returns a VirtualMachine with the maintenance window Custom
+// Attribute defined. The attribute value is only set if a non-empty value
+// is given.
+func maintenanceVM(name string, value string) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Self = types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: name}
+	vm.AvailableField = []types.CustomFieldDef{
+		{Key: 201, Name: maintenanceCAName},
+	}
+
+	if value != "" {
+		vm.CustomValue = []types.BaseCustomFieldValue{
+			&types.CustomFieldStringValue{
+				CustomFieldValue: types.CustomFieldValue{Key: 201},
+				Value:            value,
+			},
+		}
+	}
+
+	return vm
+}
+
+func TestFilterVMsByMaintenanceWindow(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.Local)
+
+	var noCA mo.VirtualMachine
+	noCA.Name = "no-ca"
+
+	tests := map[string]struct {
+		vm             mo.VirtualMachine
+		wantSuppressed VMMaintenanceWindows
+	}{
+		"active window": {
+			vm: maintenanceVM("vm1", "2021-06-02 08:00"),
+			wantSuppressed: VMMaintenanceWindows{
+				{VMName: "vm1", Until: time.Date(2021, time.June, 2, 8, 0, 0, 0, time.Local)},
+			},
+		},
+		"active window surrounding whitespace": {
+			vm: maintenanceVM("vm1", " 2021-06-01 12:01 "),
+			wantSuppressed: VMMaintenanceWindows{
+				{VMName: "vm1", Until: time.Date(2021, time.June, 1, 12, 1, 0, 0, time.Local)},
+			},
+		},
+		"window ends now": {
+			vm: maintenanceVM("vm1", "2021-06-01 12:00"),
+		},
+		"expired window": {
+			vm: maintenanceVM("vm1", "2021-05-31 23:59"),
+		},
+		"value not set": {
+			vm: maintenanceVM("vm1", ""),
+		},
+		"whitespace value": {
+			vm: maintenanceVM("vm1", "   "),
+		},
+		"value in unexpected format": {
+			vm: maintenanceVM("vm1", "06/02/2021"),
+		},
+		"custom attribute not defined": {
+			vm: noCA,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			kept, suppressed := FilterVMsByMaintenanceWindow(
+				[]mo.VirtualMachine{tt.vm},
+				maintenanceCAName,
+				maintenanceDateFormat,
+				now,
+			)
+
+			if d := cmp.Diff(tt.wantSuppressed, suppressed); d != "" {
+				t.Errorf("suppressed (-want, +got):\n%s", d)
+			}
+
+			if got, want := len(kept), 1-len(tt.wantSuppressed); got != want {
+				t.Errorf("want %d retained VMs; got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestFilterVMsByMaintenanceWindowOrder(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.Local)
+
+	vms := []mo.VirtualMachine{
+		maintenanceVM("vm1", "2021-06-02 08:00"),
+		maintenanceVM("vm2", ""),
+		maintenanceVM("vm3", "2021-06-03 08:00"),
+		maintenanceVM("vm4", "2021-05-01 08:00"),
+	}
+
+	kept, suppressed := FilterVMsByMaintenanceWindow(vms, maintenanceCAName, maintenanceDateFormat, now)
+
+	if d := cmp.Diff([]string{"vm2", "vm4"}, VMNames(kept)); d != "" {
+		t.Errorf("retained (-want, +got):\n%s", d)
+	}
+
+	gotSuppressed := make([]string, 0, len(suppressed))
+	for _, vmmw := range suppressed {
+		gotSuppressed = append(gotSuppressed, vmmw.VMName)
+	}
+
+	if d := cmp.Diff([]string{"vm1", "vm3"}, gotSuppressed); d != "" {
+		t.Errorf("suppressed (-want, +got):\n%s", d)
+	}
+}
+
+func TestVMMaintenanceWindowReport(t *testing.T) {
+	until := time.Date(2021, time.June, 2, 8, 0, 0, 0, time.UTC)
+
+	results := VMsFilterResults{
+		vmsSuppressedByMaintenanceWindow: VMMaintenanceWindows{
+			{VMName: "vm1", Until: until},
+		},
+	}
+
+	tests := map[string]struct {
+		options VMsFilterOptions
+		want    []string
+	}{
+		"not requested": {
+			options: VMsFilterOptions{},
+		},
+		"suppressed VMs listed": {
+			options: VMsFilterOptions{MaintenanceCAName: maintenanceCAName},
+			want: []string{
+				"* Maintenance window Custom Attribute: " + maintenanceCAName,
+				"* VMs suppressed by active maintenance window (1):",
+				"** vm1 (until 2021-06-02T08:00:00Z)",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var report strings.Builder
+			vmMaintenanceWindowReport(&report, tt.options, results)
+
+			got := strings.Fields(report.String())
+			want := strings.Fields(strings.Join(tt.want, " "))
+
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
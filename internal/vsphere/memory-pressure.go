@@ -0,0 +1,404 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrVirtualMachineMemoryPressureThresholdCrossed indicates that specified
+// memory ballooning, swapping or compression thresholds have been exceeded
+// for one or more VirtualMachines.
+var ErrVirtualMachineMemoryPressureThresholdCrossed = errors.New("memory ballooning, swapping or compression exceeds specified threshold")
+
+// VMMemoryPressureThresholds represents the user-specified memory
+// ballooning, swapping and compression percentage thresholds.
+type VMMemoryPressureThresholds struct {
+	BalloonedWarning   int
+	BalloonedCritical  int
+	SwappedWarning     int
+	SwappedCritical    int
+	CompressedWarning  int
+	CompressedCritical int
+}
+
+// VMMemoryPressureMetrics represents the memory ballooning, swapping and
+// compression values for a VirtualMachine as reported by the quick stats
+// collection.
+type VMMemoryPressureMetrics struct {
+	VM           mo.VirtualMachine
+	BalloonedMB  int64
+	SwappedMB    int64
+	CompressedMB int64
+	Thresholds   VMMemoryPressureThresholds
+}
+
+// VMMemoryPressureSet is a collection of VMMemoryPressureMetrics values.
+type VMMemoryPressureSet []VMMemoryPressureMetrics
+
+// NewVMMemoryPressureSet evaluates memory ballooning, swapping and
+// compression values for the given VirtualMachines against the specified
+// thresholds.
+func NewVMMemoryPressureSet(vms []mo.VirtualMachine, thresholds VMMemoryPressureThresholds) VMMemoryPressureSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMMemoryPressureSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMMemoryPressureSet, 0, len(vms))
+	for _, vm := range vms {
+		quickStats := vm.Summary.QuickStats
+
+		set = append(set, VMMemoryPressureMetrics{
+			VM:          vm,
+			BalloonedMB: int64(quickStats.BalloonedMemory),
+			SwappedMB:   int64(quickStats.SwappedMemory),
+
+			// Compressed memory is reported in KB.
+			CompressedMB: quickStats.CompressedMemory / 1024,
+
+			Thresholds: thresholds,
+		})
+	}
+
+	return set
+
+}
+
+// memoryPercent returns the given amount of memory in MB as a percentage of
+// the configured memory for the VirtualMachine.
+func (m VMMemoryPressureMetrics) memoryPercent(valueMB int64) float64 {
+	configuredMB := m.VM.Summary.Config.MemorySizeMB
+	if configuredMB < 1 {
+		return 0
+	}
+
+	return float64(valueMB) / float64(configuredMB) * 100
+}
+
+// BalloonedPercent returns the percentage of configured memory reclaimed by
+// the balloon driver.
+func (m VMMemoryPressureMetrics) BalloonedPercent() float64 {
+	return m.memoryPercent(m.BalloonedMB)
+}
+
+// SwappedPercent returns the percentage of configured memory swapped to
+// disk.
+func (m VMMemoryPressureMetrics) SwappedPercent() float64 {
+	return m.memoryPercent(m.SwappedMB)
+}
+
+// CompressedPercent returns the percentage of configured memory compressed.
+func (m VMMemoryPressureMetrics) CompressedPercent() float64 {
+	return m.memoryPercent(m.CompressedMB)
+}
+
+// IsCriticalState indicates whether memory ballooning, swapping or
+// compression values have crossed the CRITICAL level threshold.
+func (m VMMemoryPressureMetrics) IsCriticalState() bool {
+	return m.BalloonedPercent() > float64(m.Thresholds.BalloonedCritical) ||
+		m.SwappedPercent() > float64(m.Thresholds.SwappedCritical) ||
+		m.CompressedPercent() > float64(m.Thresholds.CompressedCritical)
+}
+
+// IsWarningState indicates whether memory ballooning, swapping or
+// compression values have crossed the WARNING level threshold.
+func (m VMMemoryPressureMetrics) IsWarningState() bool {
+	return !m.IsCriticalState() &&
+		(m.BalloonedPercent() > float64(m.Thresholds.BalloonedWarning) ||
+			m.SwappedPercent() > float64(m.Thresholds.SwappedWarning) ||
+			m.CompressedPercent() > float64(m.Thresholds.CompressedWarning))
+}
+
+// Offending returns the VirtualMachines whose memory ballooning, swapping or
+// compression values have crossed the WARNING or CRITICAL level thresholds,
+// sorted by swapped memory and then ballooned memory in descending order.
+func (set VMMemoryPressureSet) Offending() VMMemoryPressureSet {
+	offending := make(VMMemoryPressureSet, 0, len(set))
+	for _, m := range set {
+		if m.IsCriticalState() || m.IsWarningState() {
+			offending = append(offending, m)
+		}
+	}
+
+	sort.Slice(offending, func(i, j int) bool {
+		if offending[i].SwappedMB != offending[j].SwappedMB {
+			return offending[i].SwappedMB > offending[j].SwappedMB
+		}
+
+		return offending[i].BalloonedMB > offending[j].BalloonedMB
+	})
+
+	return offending
+}
+
+// NumCritical returns the number of VirtualMachines in a CRITICAL state.
+func (set VMMemoryPressureSet) NumCritical() int {
+	var num int
+	for _, m := range set {
+		if m.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VirtualMachines in a WARNING state.
+func (set VMMemoryPressureSet) NumWarning() int {
+	var num int
+	for _, m := range set {
+		if m.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated VirtualMachine is in a
+// CRITICAL state.
+func (set VMMemoryPressureSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated VirtualMachine is in a
+// WARNING state.
+func (set VMMemoryPressureSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// TotalBalloonedMB returns the memory in MB reclaimed by the balloon driver
+// across all evaluated VirtualMachines.
+func (set VMMemoryPressureSet) TotalBalloonedMB() int64 {
+	var total int64
+	for _, m := range set {
+		total += m.BalloonedMB
+	}
+
+	return total
+}
+
+// TotalSwappedMB returns the memory in MB swapped to disk across all
+// evaluated VirtualMachines.
+func (set VMMemoryPressureSet) TotalSwappedMB() int64 {
+	var total int64
+	for _, m := range set {
+		total += m.SwappedMB
+	}
+
+	return total
+}
+
+// TotalCompressedMB returns the memory in MB compressed across all evaluated
+// VirtualMachines.
+func (set VMMemoryPressureSet) TotalCompressedMB() int64 {
+	var total int64
+	for _, m := range set {
+		total += m.CompressedMB
+	}
+
+	return total
+}
+
+// VMMemoryPressurePerfData generates performance data metrics from the given
+// collection of evaluated VirtualMachines. Summary metrics are always
+// emitted, per-VM metrics are emitted for offending VirtualMachines only.
+func VMMemoryPressurePerfData(set VMMemoryPressureSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "vms_memory_pressure_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_memory_pressure_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "memory_ballooned",
+			Value:             fmt.Sprintf("%d", set.TotalBalloonedMB()),
+			UnitOfMeasurement: "MB",
+			Min:               "0",
+		},
+		{
+			Label:             "memory_swapped",
+			Value:             fmt.Sprintf("%d", set.TotalSwappedMB()),
+			UnitOfMeasurement: "MB",
+			Min:               "0",
+		},
+		{
+			Label:             "memory_compressed",
+			Value:             fmt.Sprintf("%d", set.TotalCompressedMB()),
+			UnitOfMeasurement: "MB",
+			Min:               "0",
+		},
+	}
+
+	for _, m := range set.Offending() {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "memory_ballooned"),
+				Value:             fmt.Sprintf("%.2f", m.BalloonedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.BalloonedWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.BalloonedCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "memory_swapped"),
+				Value:             fmt.Sprintf("%.2f", m.SwappedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.SwappedWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.SwappedCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(m.VM.Name, "memory_compressed"),
+				Value:             fmt.Sprintf("%.2f", m.CompressedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", m.Thresholds.CompressedWarning),
+				Crit:              fmt.Sprintf("%d", m.Thresholds.CompressedCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// VMMemoryPressureOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMMemoryPressureOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMMemoryPressureSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMMemoryPressureOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs under memory pressure detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set.Offending()),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs under memory pressure detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMMemoryPressureReport generates a summary of VMs with memory ballooning,
+// swapping or compression values exceeding thresholds along with various
+// verbose details intended to aid in troubleshooting check results at a
+// glance. This information is provided for use with the Long Service Output
+// field commonly displayed on the detailed service check results display in
+// the web UI or in the body of many notifications.
+func VMMemoryPressureReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMMemoryPressureSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMMemoryPressureReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs under memory pressure:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	offending := set.Offending()
+
+	switch {
+	case len(offending) > 0:
+
+		for _, m := range offending {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (memory: %d MB, ballooned: %d MB [%.2f%%], swapped: %d MB [%.2f%%], compressed: %d MB [%.2f%%])%s",
+				m.VM.Name,
+				m.VM.Summary.Config.MemorySizeMB,
+				m.BalloonedMB,
+				m.BalloonedPercent(),
+				m.SwappedMB,
+				m.SwappedPercent(),
+				m.CompressedMB,
+				m.CompressedPercent(),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+	default:
+
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+var pressureThresholds = VMMemoryPressureThresholds{
+	BalloonedWarning:   10,
+	BalloonedCritical:  25,
+	SwappedWarning:     1,
+	SwappedCritical:    5,
+	CompressedWarning:  10,
+	CompressedCritical: 25,
+}
+
+func pressureVM(name string, balloonedMB int32, swappedMB int32, compressedKB int64) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Summary.Config.MemorySizeMB = 1000
+	vm.Summary.QuickStats.BalloonedMemory = balloonedMB
+	vm.Summary.QuickStats.SwappedMemory = swappedMB
+	vm.Summary.QuickStats.CompressedMemory = compressedKB
+
+	return vm
+}
+
+func TestVMMemoryPressureMetricsPercent(t *testing.T) {
+	noMemory := pressureVM("vm1", 100, 100, 100*1024)
+	noMemory.Summary.Config.MemorySizeMB = 0
+
+	tests := map[string]struct {
+		vm             mo.VirtualMachine
+		wantBallooned  float64
+		wantSwapped    float64
+		wantCompressed float64
+	}{
+		"no memory pressure": {
+			vm: pressureVM("vm1", 0, 0, 0),
+		},
+		"compressed KB converted to MB": {
+			vm:             pressureVM("vm1", 100, 50, 150*1024),
+			wantBallooned:  10,
+			wantSwapped:    5,
+			wantCompressed: 15,
+		},
+		"compressed below 1 MB": {
+			vm: pressureVM("vm1", 0, 0, 1023),
+		},
+		"configured memory unknown": {
+			vm: noMemory,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := NewVMMemoryPressureSet([]mo.VirtualMachine{tt.vm}, pressureThresholds)[0]
+
+			if got := m.BalloonedPercent(); math.Abs(got-tt.wantBallooned) > 0.0001 {
+				t.Errorf("want %.2f%% ballooned; got %.2f%%", tt.wantBallooned, got)
+			}
+			if got := m.SwappedPercent(); math.Abs(got-tt.wantSwapped) > 0.0001 {
+				t.Errorf("want %.2f%% swapped; got %.2f%%", tt.wantSwapped, got)
+			}
+			if got := m.CompressedPercent(); math.Abs(got-tt.wantCompressed) > 0.0001 {
+				t.Errorf("want %.2f%% compressed; got %.2f%%", tt.wantCompressed, got)
+			}
+		})
+	}
+}
+
+func TestVMMemoryPressureMetricsState(t *testing.T) {
+	tests := map[string]struct {
+		vm           mo.VirtualMachine
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below thresholds":                    {vm: pressureVM("vm1", 50, 5, 50*1024)},
+		"ballooned equal to warning":          {vm: pressureVM("vm1", 100, 0, 0)},
+		"ballooned above warning threshold":   {vm: pressureVM("vm1", 150, 0, 0), wantWarning: true},
+		"ballooned above critical threshold":  {vm: pressureVM("vm1", 300, 0, 0), wantCritical: true},
+		"swapped above warning threshold":     {vm: pressureVM("vm1", 0, 20, 0), wantWarning: true},
+		"swapped above critical threshold":    {vm: pressureVM("vm1", 0, 60, 0), wantCritical: true},
+		"compressed above warning threshold":  {vm: pressureVM("vm1", 0, 0, 150*1024), wantWarning: true},
+		"compressed above critical threshold": {vm: pressureVM("vm1", 0, 0, 300*1024), wantCritical: true},
+		"critical takes precedence":           {vm: pressureVM("vm1", 150, 60, 0), wantCritical: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMMemoryPressureSet([]mo.VirtualMachine{tt.vm}, pressureThresholds)
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVMMemoryPressureSetOffending(t *testing.T) {
+	set := NewVMMemoryPressureSet(
+		[]mo.VirtualMachine{
+			pressureVM("vm1", 300, 0, 0),
+			pressureVM("vm2", 0, 20, 0),
+			pressureVM("vm3", 500, 0, 0),
+			pressureVM("vm4", 0, 0, 0),
+		},
+		pressureThresholds,
+	)
+
+	var got []string
+	for _, m := range set.Offending() {
+		got = append(got, m.VM.Name)
+	}
+
+	if d := cmp.Diff([]string{"vm2", "vm3", "vm1"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := set.NumCritical(); got != 2 {
+		t.Errorf("want 2 critical; got %d", got)
+	}
+	if got := set.NumWarning(); got != 1 {
+		t.Errorf("want 1 warning; got %d", got)
+	}
+}
+
+func TestVMMemoryPressurePerfData(t *testing.T) {
+	set := NewVMMemoryPressureSet(
+		[]mo.VirtualMachine{
+			pressureVM("vm1", 150, 0, 2048),
+			pressureVM("vm2", 50, 5, 0),
+		},
+		pressureThresholds,
+	)
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_memory_pressure_critical", Value: "0", Min: "0"},
+		{Label: "vms_memory_pressure_warning", Value: "1", Min: "0"},
+		{Label: "memory_ballooned", Value: "200", UnitOfMeasurement: "MB", Min: "0"},
+		{Label: "memory_swapped", Value: "5", UnitOfMeasurement: "MB", Min: "0"},
+		{Label: "memory_compressed", Value: "2", UnitOfMeasurement: "MB", Min: "0"},
+		{Label: "vm1_memory_ballooned", Value: "15.00", UnitOfMeasurement: "%", Warn: "10", Crit: "25", Min: "0", Max: "100"},
+		{Label: "vm1_memory_swapped", Value: "0.00", UnitOfMeasurement: "%", Warn: "1", Crit: "5", Min: "0", Max: "100"},
+		{Label: "vm1_memory_compressed", Value: "0.20", UnitOfMeasurement: "%", Warn: "10", Crit: "25", Min: "0", Max: "100"},
+	}
+
+	if d := cmp.Diff(want, VMMemoryPressurePerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
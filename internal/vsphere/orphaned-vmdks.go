@@ -0,0 +1,569 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrOrphanedVMDKsSizeThresholdCrossed indicates that the cumulative size of
+// VMDK files not referenced by any registered VirtualMachine has exceeded a
+// specified size threshold.
+var ErrOrphanedVMDKsSizeThresholdCrossed = errors.New("orphaned vmdk files exceed specified size threshold")
+
+// ErrDatastoreBrowserSearchFailed indicates that a datastore browser search
+// did not complete successfully.
+var ErrDatastoreBrowserSearchFailed = errors.New("datastore browser search failed")
+
+// OrphanedVMDKThresholds represents the user-specified cumulative orphaned
+// VMDK size thresholds (in GB).
+type OrphanedVMDKThresholds struct {
+	SizeWarning  int
+	SizeCritical int
+}
+
+// DatastoreVMDK represents a VMDK file found on a datastore.
+type DatastoreVMDK struct {
+
+	// Path is the full datastore path for the file (e.g., "[ds1]
+	// vm1/vm1.vmdk").
+	Path string
+
+	// Datastore is the name of the datastore where the file is stored.
+	Datastore string
+
+	// Size is the size of the file (including any extents) in bytes.
+	Size int64
+
+	// Modified is when the file was last modified. This value is the zero
+	// value if not provided by the datastore browser.
+	Modified time.Time
+}
+
+// DatastoreVMDKs is a collection of VMDK files found on datastores.
+type DatastoreVMDKs []DatastoreVMDK
+
+// OrphanedVMDKsSummary represents the results of comparing VMDK files found
+// on datastores against those referenced by registered VirtualMachines.
+type OrphanedVMDKsSummary struct {
+
+	// Orphaned is the collection of VMDK files not referenced by any
+	// registered VirtualMachine.
+	Orphaned DatastoreVMDKs
+
+	// Ignored is the collection of VMDK files not referenced by any
+	// registered VirtualMachine which match a specified path to ignore.
+	Ignored DatastoreVMDKs
+
+	// NumVMDKs is the total number of VMDK files found on evaluated
+	// datastores.
+	NumVMDKs int
+
+	// NumDatastores is the number of datastores evaluated.
+	NumDatastores int
+
+	// NumDatastoresSkipped is the number of datastores skipped due to being
+	// inaccessible.
+	NumDatastoresSkipped int
+
+	Thresholds OrphanedVMDKThresholds
+}
+
+// datastoreFilePath returns the normalized datastore path for a file found
+// within the given datastore browser search folder path.
+func datastoreFilePath(folderPath string, fileName string) string {
+	var dsPath object.DatastorePath
+	if !dsPath.FromString(folderPath) {
+		return path.Join(folderPath, fileName)
+	}
+
+	dsPath.Path = path.Join(dsPath.Path, fileName)
+
+	return dsPath.String()
+}
+
+// FilterDatastoresByNames receives a collection of Datastores and returns
+// only the accessible datastores matching the given lists of datastore names
+// to include or exclude. The number of datastores excluded by name and the
+// number of datastores skipped due to being inaccessible are also returned.
+func FilterDatastoresByNames(dss []mo.Datastore, includedDatastores []string, excludedDatastores []string) ([]mo.Datastore, int, int) {
+	filtered := make([]mo.Datastore, 0, len(dss))
+
+	var numExcluded int
+	var numInaccessible int
+	for _, ds := range dss {
+		switch {
+		case len(includedDatastores) > 0 &&
+			!inPatternList(ds.Name, includedDatastores):
+			numExcluded++
+
+			continue
+
+		case len(excludedDatastores) > 0 &&
+			inPatternList(ds.Name, excludedDatastores):
+			numExcluded++
+
+			continue
+
+		case !ds.Summary.Accessible:
+			numInaccessible++
+
+			continue
+		}
+
+		filtered = append(filtered, ds)
+	}
+
+	return filtered, numExcluded, numInaccessible
+}
+
+// GetDatastoreVMDKs uses the datastore browser for the given Datastore to
+// search all folders for VMDK files.
+func GetDatastoreVMDKs(ctx context.Context, c *vim25.Client, ds mo.Datastore) (DatastoreVMDKs, error) {
+
+	funcTimeStart := time.Now()
+
+	var vmdks DatastoreVMDKs
+
+	defer func(vmdks *DatastoreVMDKs) {
+		logger.Printf(
+			"It took %v to execute GetDatastoreVMDKs func (and retrieve %d VMDKs from %s).\n",
+			time.Since(funcTimeStart),
+			len(*vmdks),
+			ds.Name,
+		)
+	}(&vmdks)
+
+	dsObj := object.NewDatastore(c, ds.Reference())
+
+	browser, err := dsObj.Browser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve datastore browser for datastore %s: %w",
+			ds.Name,
+			err,
+		)
+	}
+
+	searchSpec := types.HostDatastoreBrowserSearchSpec{
+		Query: []types.BaseFileQuery{
+			&types.VmDiskFileQuery{},
+		},
+		Details: &types.FileQueryFlags{
+			FileType:     true,
+			FileSize:     true,
+			Modification: true,
+		},
+		MatchPattern: []string{"*.vmdk"},
+	}
+
+	task, err := browser.SearchDatastoreSubFolders(
+		ctx,
+		fmt.Sprintf("[%s]", ds.Name),
+		&searchSpec,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to search datastore %s: %w",
+			ds.Name,
+			err,
+		)
+	}
+
+	info, err := task.WaitForResult(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w: datastore %s: %v",
+			ErrDatastoreBrowserSearchFailed,
+			ds.Name,
+			err,
+		)
+	}
+
+	results, ok := info.Result.(types.ArrayOfHostDatastoreBrowserSearchResults)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%w: unexpected search results type %T for datastore %s",
+			ErrDatastoreBrowserSearchFailed,
+			info.Result,
+			ds.Name,
+		)
+	}
+
+	for _, result := range results.HostDatastoreBrowserSearchResults {
+		for _, file := range result.File {
+			fi := file.GetFileInfo()
+
+			vmdk := DatastoreVMDK{
+				Path:      datastoreFilePath(result.FolderPath, fi.Path),
+				Datastore: ds.Name,
+				Size:      fi.FileSize,
+			}
+
+			if fi.Modification != nil {
+				vmdk.Modified = *fi.Modification
+			}
+
+			vmdks = append(vmdks, vmdk)
+		}
+	}
+
+	return vmdks, nil
+
+}
+
+// VMReferencedFiles returns an index of all files referenced by the given
+// VirtualMachines (e.g., virtual disks and snapshot delta disks) as reported
+// by the VirtualMachine file layout.
+func VMReferencedFiles(vms []mo.VirtualMachine) map[string]struct{} {
+	files := make(map[string]struct{})
+
+	for _, vm := range vms {
+		if vm.LayoutEx != nil {
+			for _, file := range vm.LayoutEx.File {
+				files[file.Name] = struct{}{}
+			}
+		}
+
+		// Fallback for VirtualMachines without file layout details.
+		if vm.Config != nil {
+			for _, device := range vm.Config.Hardware.Device {
+				disk, ok := device.(*types.VirtualDisk)
+				if !ok {
+					continue
+				}
+
+				backing, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+				if !ok {
+					continue
+				}
+
+				files[backing.GetVirtualDeviceFileBackingInfo().FileName] = struct{}{}
+			}
+		}
+	}
+
+	return files
+}
+
+// NewOrphanedVMDKsSummary compares the given VMDK files against the index
+// of files referenced by registered VirtualMachines. VMDK files which are not
+// referenced are considered orphaned unless their path contains one of the
+// specified (case-insensitive) substrings to ignore.
+func NewOrphanedVMDKsSummary(
+	vmdks DatastoreVMDKs,
+	referencedFiles map[string]struct{},
+	ignoredPaths []string,
+	thresholds OrphanedVMDKThresholds,
+) OrphanedVMDKsSummary {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewOrphanedVMDKsSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	summary := OrphanedVMDKsSummary{
+		NumVMDKs:   len(vmdks),
+		Thresholds: thresholds,
+	}
+
+	for _, vmdk := range vmdks {
+		if _, ok := referencedFiles[vmdk.Path]; ok {
+			continue
+		}
+
+		if containsAnySubstring(vmdk.Path, ignoredPaths) {
+			summary.Ignored = append(summary.Ignored, vmdk)
+
+			continue
+		}
+
+		summary.Orphaned = append(summary.Orphaned, vmdk)
+	}
+
+	// Largest orphaned files first.
+	sort.Slice(summary.Orphaned, func(i, j int) bool {
+		return summary.Orphaned[i].Size > summary.Orphaned[j].Size
+	})
+
+	return summary
+}
+
+// containsAnySubstring indicates whether the given string contains any of
+// the specified substrings (case-insensitive).
+func containsAnySubstring(s string, substrings []string) bool {
+	s = strings.ToLower(s)
+	for _, substring := range substrings {
+		if strings.Contains(s, strings.ToLower(substring)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Size returns the cumulative size of all VMDK files in the collection.
+func (vmdks DatastoreVMDKs) Size() int64 {
+	var sum int64
+	for _, vmdk := range vmdks {
+		sum += vmdk.Size
+	}
+
+	return sum
+}
+
+// SizeHR returns the human readable cumulative size of all VMDK files in the
+// collection.
+func (vmdks DatastoreVMDKs) SizeHR() string {
+	return units.ByteSize(vmdks.Size()).String()
+}
+
+// IsCriticalState indicates whether the cumulative size of orphaned VMDK
+// files has exceeded the CRITICAL threshold.
+func (s OrphanedVMDKsSummary) IsCriticalState() bool {
+	return len(s.Orphaned) > 0 &&
+		ExceedsSize(s.Orphaned.Size(), int64(s.Thresholds.SizeCritical))
+}
+
+// IsWarningState indicates whether the cumulative size of orphaned VMDK
+// files has exceeded the WARNING threshold, but not the CRITICAL threshold.
+func (s OrphanedVMDKsSummary) IsWarningState() bool {
+	return len(s.Orphaned) > 0 &&
+		!s.IsCriticalState() &&
+		ExceedsSize(s.Orphaned.Size(), int64(s.Thresholds.SizeWarning))
+}
+
+// OrphanedVMDKsPerfData generates performance data metrics from the given
+// evaluation results.
+func OrphanedVMDKsPerfData(s OrphanedVMDKsSummary) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", s.NumDatastores),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_skipped",
+			Value: fmt.Sprintf("%d", s.NumDatastoresSkipped),
+			Min:   "0",
+		},
+		{
+			Label: "vmdks",
+			Value: fmt.Sprintf("%d", s.NumVMDKs),
+			Min:   "0",
+		},
+		{
+			Label: "orphaned_vmdks",
+			Value: fmt.Sprintf("%d", len(s.Orphaned)),
+			Min:   "0",
+		},
+		{
+			Label:             "orphaned_vmdks_size",
+			Value:             fmt.Sprintf("%d", s.Orphaned.Size()),
+			UnitOfMeasurement: "B",
+			Warn:              fmt.Sprintf("%d", int64(s.Thresholds.SizeWarning)*units.GB),
+			Crit:              fmt.Sprintf("%d", int64(s.Thresholds.SizeCritical)*units.GB),
+			Min:               "0",
+		},
+		{
+			Label: "ignored_vmdks",
+			Value: fmt.Sprintf("%d", len(s.Ignored)),
+			Min:   "0",
+		},
+	}
+}
+
+// OrphanedVMDKsOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func OrphanedVMDKsOneLineCheckSummary(
+	stateLabel string,
+	s OrphanedVMDKsSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"s": s,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute OrphanedVMDKsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(s.Orphaned) > 0:
+		return fmt.Sprintf(
+			"%s: %d orphaned VMDK files (%s) detected (evaluated %d datastores, %d VMDKs)",
+			stateLabel,
+			len(s.Orphaned),
+			s.Orphaned.SizeHR(),
+			s.NumDatastores,
+			s.NumVMDKs,
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No orphaned VMDK files detected (evaluated %d datastores, %d VMDKs)",
+			stateLabel,
+			s.NumDatastores,
+			s.NumVMDKs,
+		)
+	}
+}
+
+// OrphanedVMDKsReport generates a summary of orphaned VMDK files along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func OrphanedVMDKsReport(
+	c *vim25.Client,
+	s OrphanedVMDKsSummary,
+	includedDatastores []string,
+	excludedDatastores []string,
+	ignoredPaths []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute OrphanedVMDKsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMDKs := func(header string, vmdks DatastoreVMDKs) {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s:%s%s",
+			header,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		if len(vmdks) == 0 {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+			return
+		}
+
+		for _, vmdk := range vmdks {
+			modified := "unknown"
+			if !vmdk.Modified.IsZero() {
+				modified = vmdk.Modified.Format(time.RFC3339)
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (size: %s, modified: %s)%s",
+				vmdk.Path,
+				units.ByteSize(vmdk.Size),
+				modified,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	writeVMDKs(
+		fmt.Sprintf(
+			"Orphaned VMDK files (%s)",
+			s.Orphaned.SizeHR(),
+		),
+		s.Orphaned,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	writeVMDKs(
+		fmt.Sprintf(
+			"Unreferenced VMDK files ignored (%s)",
+			s.Ignored.SizeHR(),
+		),
+		s.Ignored,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d inaccessible datastores skipped)%s",
+		s.NumDatastores,
+		s.NumDatastoresSkipped,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified paths to ignore (%d): [%v]%s",
+		len(ignoredPaths),
+		strings.Join(ignoredPaths, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
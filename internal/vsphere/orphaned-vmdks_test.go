@@ -0,0 +1,260 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// orphanedVMDK returns a VMDK file of the given size in GB.
+func orphanedVMDK(path string, sizeGB int64) DatastoreVMDK {
+	return DatastoreVMDK{Path: path, Size: sizeGB * units.GB}
+}
+
+func TestDatastoreFilePath(t *testing.T) {
+	tests := map[string]struct {
+		folderPath string
+		fileName   string
+		want       string
+	}{
+		"datastore root":   {folderPath: "[ds1]", fileName: "vm1.vmdk", want: "[ds1] vm1.vmdk"},
+		"datastore folder": {folderPath: "[ds1] vm1", fileName: "vm1.vmdk", want: "[ds1] vm1/vm1.vmdk"},
+		"trailing slash":   {folderPath: "[ds1] vm1/", fileName: "vm1.vmdk", want: "[ds1] vm1/vm1.vmdk"},
+		"plain path":       {folderPath: "vm1", fileName: "vm1.vmdk", want: "vm1/vm1.vmdk"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := datastoreFilePath(tt.folderPath, tt.fileName); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMReferencedFiles(t *testing.T) {
+	// vm1 references its disks via file layout details, vm2 via its virtual
+	// disk backing only.
+	vm1 := mo.VirtualMachine{
+		LayoutEx: &types.VirtualMachineFileLayoutEx{
+			File: []types.VirtualMachineFileLayoutExFileInfo{
+				{Name: "[ds1] vm1/vm1.vmdk"},
+				{Name: "[ds1] vm1/vm1-000001.vmdk"},
+			},
+		},
+	}
+
+	disk := types.VirtualDisk{}
+	disk.Backing = &types.VirtualDiskFlatVer2BackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+			FileName: "[ds2] vm2/vm2.vmdk",
+		},
+	}
+
+	rdm := types.VirtualDisk{}
+	rdm.Backing = &types.VirtualDiskRawDiskMappingVer1BackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+			FileName: "[ds2] vm2/vm2_1.vmdk",
+		},
+	}
+
+	vm2 := mo.VirtualMachine{
+		Config: &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{
+				Device: []types.BaseVirtualDevice{
+					&disk,
+					&rdm,
+					&types.VirtualCdrom{},
+				},
+			},
+		},
+	}
+
+	want := map[string]struct{}{
+		"[ds1] vm1/vm1.vmdk":        {},
+		"[ds1] vm1/vm1-000001.vmdk": {},
+		"[ds2] vm2/vm2.vmdk":        {},
+		"[ds2] vm2/vm2_1.vmdk":      {},
+	}
+
+	got := VMReferencedFiles([]mo.VirtualMachine{vm1, vm2, {}})
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestNewOrphanedVMDKsSummary(t *testing.T) {
+	thresholds := OrphanedVMDKThresholds{
+		SizeWarning:  10,
+		SizeCritical: 50,
+	}
+
+	referencedFiles := map[string]struct{}{
+		"[ds1] vm1/vm1.vmdk":        {},
+		"[ds1] vm1/vm1-000001.vmdk": {},
+		"[ds2] vm2/vm2.vmdk":        {},
+	}
+
+	tests := map[string]struct {
+		vmdks        DatastoreVMDKs
+		ignoredPaths []string
+		wantCritical bool
+		wantWarning  bool
+		wantOrphaned []string
+		wantIgnored  []string
+	}{
+		"all VMDKs referenced": {
+			vmdks: DatastoreVMDKs{
+				orphanedVMDK("[ds1] vm1/vm1.vmdk", 100),
+				orphanedVMDK("[ds1] vm1/vm1-000001.vmdk", 100),
+				orphanedVMDK("[ds2] vm2/vm2.vmdk", 100),
+			},
+		},
+		"orphaned VMDKs within WARNING threshold": {
+			vmdks: DatastoreVMDKs{
+				orphanedVMDK("[ds1] vm1/vm1.vmdk", 100),
+				orphanedVMDK("[ds1] old/old.vmdk", 10),
+			},
+			wantOrphaned: []string{"[ds1] old/old.vmdk"},
+		},
+		"orphaned VMDKs beyond WARNING threshold sorted by size": {
+			vmdks: DatastoreVMDKs{
+				orphanedVMDK("[ds1] old/old.vmdk", 6),
+				orphanedVMDK("[ds2] old/old.vmdk", 8),
+			},
+			wantWarning:  true,
+			wantOrphaned: []string{"[ds2] old/old.vmdk", "[ds1] old/old.vmdk"},
+		},
+		"orphaned VMDKs beyond CRITICAL threshold": {
+			vmdks: DatastoreVMDKs{
+				orphanedVMDK("[ds1] vm1/vm1.vmdk", 100),
+				orphanedVMDK("[ds1] old/old.vmdk", 51),
+			},
+			wantCritical: true,
+			wantOrphaned: []string{"[ds1] old/old.vmdk"},
+		},
+		"ignored VMDK paths": {
+			vmdks: DatastoreVMDKs{
+				orphanedVMDK("[ds1] Templates/base.vmdk", 51),
+				orphanedVMDK("[ds1] old/old.vmdk", 1),
+			},
+			ignoredPaths: []string{"templates/"},
+			wantOrphaned: []string{"[ds1] old/old.vmdk"},
+			wantIgnored:  []string{"[ds1] Templates/base.vmdk"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := NewOrphanedVMDKsSummary(
+				tt.vmdks,
+				referencedFiles,
+				tt.ignoredPaths,
+				thresholds,
+			)
+
+			if summary.NumVMDKs != len(tt.vmdks) {
+				t.Errorf("want %d VMDKs; got %d", len(tt.vmdks), summary.NumVMDKs)
+			}
+
+			if got := summary.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := summary.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			var orphaned []string
+			for _, vmdk := range summary.Orphaned {
+				orphaned = append(orphaned, vmdk.Path)
+			}
+			if d := cmp.Diff(tt.wantOrphaned, orphaned); d != "" {
+				t.Errorf("orphaned (-want, +got):\n%s", d)
+			}
+
+			var ignored []string
+			for _, vmdk := range summary.Ignored {
+				ignored = append(ignored, vmdk.Path)
+			}
+			if d := cmp.Diff(tt.wantIgnored, ignored); d != "" {
+				t.Errorf("ignored (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestFilterDatastoresByNames(t *testing.T) {
+	datastore := func(name string, accessible bool) mo.Datastore {
+		var ds mo.Datastore
+		ds.Name = name
+		ds.Summary.Accessible = accessible
+
+		return ds
+	}
+
+	dss := []mo.Datastore{
+		datastore("ds1", true),
+		datastore("ds2", true),
+		datastore("ds3", false),
+		datastore("local-esx1", true),
+	}
+
+	tests := map[string]struct {
+		include          []string
+		exclude          []string
+		want             []string
+		wantExcluded     int
+		wantInaccessible int
+	}{
+		"no filters": {
+			want:             []string{"ds1", "ds2", "local-esx1"},
+			wantInaccessible: 1,
+		},
+		"included datastores": {
+			include:          []string{"DS1", "ds3"},
+			want:             []string{"ds1"},
+			wantExcluded:     2,
+			wantInaccessible: 1,
+		},
+		"excluded datastores": {
+			exclude:          []string{"local-esx1"},
+			want:             []string{"ds1", "ds2"},
+			wantExcluded:     1,
+			wantInaccessible: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, numExcluded, numInaccessible := FilterDatastoresByNames(dss, tt.include, tt.exclude)
+
+			var got []string
+			for _, ds := range filtered {
+				got = append(got, ds.Name)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded; got %d", tt.wantExcluded, numExcluded)
+			}
+
+			if numInaccessible != tt.wantInaccessible {
+				t.Errorf("want %d inaccessible; got %d", tt.wantInaccessible, numInaccessible)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// patternMatchMode is the pattern matching mode applied to user-specified
+// include/exclude lists (e.g., VM names, resource pools, folders, alarm
+// names and datastores). Exact (case-insensitive) matching is used by
+// default.
+var patternMatchMode = textutils.PatternMatchExact
+
+// SetPatternMatchMode sets the pattern matching mode (exact, glob or regex)
+// applied to user-specified include/exclude lists.
+func SetPatternMatchMode(mode string) error {
+	switch mode = strings.ToLower(mode); mode {
+	case "":
+		patternMatchMode = textutils.PatternMatchExact
+
+	case textutils.PatternMatchExact, textutils.PatternMatchGlob, textutils.PatternMatchRegex:
+		patternMatchMode = mode
+
+	default:
+		return fmt.Errorf("unsupported pattern matching mode %q", mode)
+	}
+
+	logger.Printf("pattern matching mode set to %q", patternMatchMode)
+
+	return nil
+}
+
+// inPatternList indicates whether the given value matches any of the given
+// include/exclude list entries using the current pattern matching mode.
+// Comparisons are case-insensitive.
+func inPatternList(value string, list []string) bool {
+	return textutils.MatchesAnyPattern(value, list, patternMatchMode, true)
+}
+
+// patternMatchesAny indicates whether the given include/exclude list entry
+// matches any of the given values using the current pattern matching mode.
+// This is used to validate that user-specified entries match at least one
+// inventory object. Comparisons are case-insensitive.
+func patternMatchesAny(pattern string, values []string) bool {
+	for _, value := range values {
+		if textutils.MatchPattern(value, pattern, patternMatchMode, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesSubstringFilter indicates whether the given field value matches the
+// given filter value. If exact matching is used (the default), a literal,
+// case-insensitive match is attempted first followed by a substring match.
+// Otherwise the filter value is treated as a glob or regex pattern.
+func matchesSubstringFilter(field string, filter string) bool {
+	if patternMatchMode == textutils.PatternMatchExact {
+		return strings.EqualFold(field, filter) ||
+			strings.Contains(strings.ToLower(field), strings.ToLower(filter))
+	}
+
+	return textutils.MatchPattern(field, filter, patternMatchMode, true)
+}
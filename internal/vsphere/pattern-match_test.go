@@ -0,0 +1,56 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// setTestPatternMatchMode sets the pattern matching mode for the duration of
+// the current test.
+func setTestPatternMatchMode(t *testing.T, mode string) {
+	t.Helper()
+
+	orig := patternMatchMode
+	t.Cleanup(func() { patternMatchMode = orig })
+
+	if err := SetPatternMatchMode(mode); err != nil {
+		t.Fatalf("failed to set pattern matching mode: %v", err)
+	}
+}
+
+func TestMatchesSubstringFilter(t *testing.T) {
+	tests := map[string]struct {
+		mode   string
+		field  string
+		filter string
+		want   bool
+	}{
+		"exact match":                {mode: textutils.PatternMatchExact, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "VEEAM BACKUP TEMPORARY SNAPSHOT", want: true},
+		"substring":                  {mode: textutils.PatternMatchExact, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "VEEAM", want: true},
+		"substring case-insensitive": {mode: textutils.PatternMatchExact, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "backup temp", want: true},
+		"no match":                   {mode: textutils.PatternMatchExact, field: "pre-upgrade", filter: "VEEAM", want: false},
+		"empty field":                {mode: textutils.PatternMatchExact, field: "", filter: "VEEAM", want: false},
+		"glob prefix":                {mode: textutils.PatternMatchGlob, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "veeam*", want: true},
+		"glob is not substring":      {mode: textutils.PatternMatchGlob, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "backup*", want: false},
+		"regex":                      {mode: textutils.PatternMatchRegex, field: "VEEAM BACKUP TEMPORARY SNAPSHOT", filter: "^veeam .* snapshot$", want: true},
+		"regex no match":             {mode: textutils.PatternMatchRegex, field: "pre-upgrade", filter: "^veeam", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setTestPatternMatchMode(t, tt.mode)
+
+			if got := matchesSubstringFilter(tt.field, tt.filter); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrPerfCounterNotFound indicates that a requested performance counter is
+// not provided by the PerformanceManager for the connected vSphere
+// environment.
+var ErrPerfCounterNotFound = errors.New("performance counter not found")
+
+// ErrPerformanceManagerUnavailable indicates that the PerformanceManager is
+// not available for the connected vSphere environment.
+var ErrPerformanceManagerUnavailable = errors.New("performance manager unavailable")
+
+// PerfRealtimeIntervalSeconds is the sampling period in seconds for
+// real-time performance statistics.
+const PerfRealtimeIntervalSeconds int32 = 20
+
+// perfAggregateInstance is the instance name used to request the aggregate
+// value of a performance counter across all instances (e.g., all vCPUs).
+const perfAggregateInstance string = ""
+
+// perfAllInstances is the instance name used to request the values of a
+// performance counter for each instance (e.g., each GPU) separately.
+const perfAllInstances string = "*"
+
+// perfLabelReplacer is used to replace characters which are not permitted
+// (or are awkward to work with) in performance data labels.
+var perfLabelReplacer = strings.NewReplacer(
+	"'", "_",
+	"=", "_",
+	" ", "_",
+)
+
+// PerfDataLabel returns a performance data label composed of the given
+// object name and metric suffix with any unsupported characters replaced. If
+// name redaction is enabled the redacted token is used in place of the
+// object name.
+func PerfDataLabel(name string, metric string) string {
+	return perfLabelReplacer.Replace(redactPerfDataName(name)) + "_" + metric
+}
+
+// GetPerfCounterIDs resolves the given performance counter names to their
+// counter IDs. Counter names are in the form of group.name.rollup (e.g.,
+// cpu.ready.summation). An error is returned if any of the requested counters
+// are not found.
+func GetPerfCounterIDs(ctx context.Context, c *vim25.Client, names ...string) (map[string]int32, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetPerfCounterIDs func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if c.ServiceContent.PerfManager == nil {
+		return nil, ErrPerformanceManagerUnavailable
+	}
+
+	var pm mo.PerformanceManager
+	err := property.DefaultCollector(c).RetrieveOne(
+		ctx,
+		*c.ServiceContent.PerfManager,
+		[]string{"perfCounter"},
+		&pm,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve performance counters: %w",
+			err,
+		)
+	}
+
+	available := make(map[string]int32, len(pm.PerfCounter))
+	for _, counter := range pm.PerfCounter {
+		if counter.GroupInfo == nil || counter.NameInfo == nil {
+			continue
+		}
+
+		name := fmt.Sprintf(
+			"%s.%s.%s",
+			counter.GroupInfo.GetElementDescription().Key,
+			counter.NameInfo.GetElementDescription().Key,
+			counter.RollupType,
+		)
+		available[name] = counter.Key
+	}
+
+	ids := make(map[string]int32, len(names))
+	for _, name := range names {
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf(
+				"counter %s: %w",
+				name,
+				ErrPerfCounterNotFound,
+			)
+		}
+		ids[name] = id
+	}
+
+	return ids, nil
+
+}
+
+// QueryRealtimePerfSamples retrieves the most recent real-time samples for
+// the given performance counter IDs for each of the specified entities. The
+// aggregate instance value is requested for each counter. The results are
+// indexed by entity MOID value and then by counter ID. Entities without
+// available samples (e.g., powered off VirtualMachines) are omitted.
+func QueryRealtimePerfSamples(
+	ctx context.Context,
+	c *vim25.Client,
+	entities []types.ManagedObjectReference,
+	counterIDs []int32,
+	maxSamples int32,
+) (map[string]map[int32][]int64, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute QueryRealtimePerfSamples func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	results := make(map[string]map[int32][]int64, len(entities))
+
+	if len(entities) == 0 {
+		return results, nil
+	}
+
+	if c.ServiceContent.PerfManager == nil {
+		return nil, ErrPerformanceManagerUnavailable
+	}
+
+	metricIDs := make([]types.PerfMetricId, 0, len(counterIDs))
+	for _, id := range counterIDs {
+		metricIDs = append(metricIDs, types.PerfMetricId{
+			CounterId: id,
+			Instance:  perfAggregateInstance,
+		})
+	}
+
+	specs := make([]types.PerfQuerySpec, 0, len(entities))
+	for _, entity := range entities {
+		specs = append(specs, types.PerfQuerySpec{
+			Entity:     entity,
+			MaxSample:  maxSamples,
+			MetricId:   metricIDs,
+			IntervalId: PerfRealtimeIntervalSeconds,
+		})
+	}
+
+	req := types.QueryPerf{
+		This:      *c.ServiceContent.PerfManager,
+		QuerySpec: specs,
+	}
+
+	res, err := methods.QueryPerf(ctx, c, &req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to query performance samples: %w",
+			err,
+		)
+	}
+
+	for _, base := range res.Returnval {
+		metric, ok := base.(*types.PerfEntityMetric)
+		if !ok {
+			continue
+		}
+
+		samples := make(map[int32][]int64, len(metric.Value))
+		for _, seriesBase := range metric.Value {
+			series, ok := seriesBase.(*types.PerfMetricIntSeries)
+			if !ok {
+				continue
+			}
+			samples[series.Id.CounterId] = series.Value
+		}
+
+		results[metric.Entity.Value] = samples
+	}
+
+	return results, nil
+
+}
+
+// QueryRealtimePerfInstanceSamples retrieves the most recent real-time
+// samples for the given performance counter IDs for each of the specified
+// entities. Unlike QueryRealtimePerfSamples the values for each counter
+// instance (e.g., each GPU) are requested. The results are indexed by entity
+// MOID value, then by counter ID and then by instance name. Entities without
+// available samples are omitted.
+func QueryRealtimePerfInstanceSamples(
+	ctx context.Context,
+	c *vim25.Client,
+	entities []types.ManagedObjectReference,
+	counterIDs []int32,
+	maxSamples int32,
+) (map[string]map[int32]map[string][]int64, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute QueryRealtimePerfInstanceSamples func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	results := make(map[string]map[int32]map[string][]int64, len(entities))
+
+	if len(entities) == 0 {
+		return results, nil
+	}
+
+	if c.ServiceContent.PerfManager == nil {
+		return nil, ErrPerformanceManagerUnavailable
+	}
+
+	metricIDs := make([]types.PerfMetricId, 0, len(counterIDs))
+	for _, id := range counterIDs {
+		metricIDs = append(metricIDs, types.PerfMetricId{
+			CounterId: id,
+			Instance:  perfAllInstances,
+		})
+	}
+
+	specs := make([]types.PerfQuerySpec, 0, len(entities))
+	for _, entity := range entities {
+		specs = append(specs, types.PerfQuerySpec{
+			Entity:     entity,
+			MaxSample:  maxSamples,
+			MetricId:   metricIDs,
+			IntervalId: PerfRealtimeIntervalSeconds,
+		})
+	}
+
+	req := types.QueryPerf{
+		This:      *c.ServiceContent.PerfManager,
+		QuerySpec: specs,
+	}
+
+	res, err := methods.QueryPerf(ctx, c, &req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to query performance samples: %w",
+			err,
+		)
+	}
+
+	for _, base := range res.Returnval {
+		metric, ok := base.(*types.PerfEntityMetric)
+		if !ok {
+			continue
+		}
+
+		samples := make(map[int32]map[string][]int64, len(counterIDs))
+		for _, seriesBase := range metric.Value {
+			series, ok := seriesBase.(*types.PerfMetricIntSeries)
+			if !ok {
+				continue
+			}
+
+			if samples[series.Id.CounterId] == nil {
+				samples[series.Id.CounterId] = make(map[string][]int64)
+			}
+			samples[series.Id.CounterId][series.Id.Instance] = series.Value
+		}
+
+		results[metric.Entity.Value] = samples
+	}
+
+	return results, nil
+
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// PropertyRetrievalTiming records the cumulative time spent retrieving
+// properties for a specific managed object type (e.g., VirtualMachine) via
+// the PropertyCollector.
+type PropertyRetrievalTiming struct {
+	ObjectType string
+	Duration   time.Duration
+	Retrievals int
+}
+
+// propertyRetrievals records the property retrieval latency per managed
+// object type in the order the object types were first retrieved.
+var propertyRetrievals struct {
+	sync.Mutex
+	timings []PropertyRetrievalTiming
+}
+
+// recordPropertyRetrieval records the time spent retrieving properties for
+// the specified managed object type.
+func recordPropertyRetrieval(objType string, elapsed time.Duration) {
+	propertyRetrievals.Lock()
+	defer propertyRetrievals.Unlock()
+
+	for i := range propertyRetrievals.timings {
+		if propertyRetrievals.timings[i].ObjectType == objType {
+			propertyRetrievals.timings[i].Duration += elapsed
+			propertyRetrievals.timings[i].Retrievals++
+
+			return
+		}
+	}
+
+	propertyRetrievals.timings = append(
+		propertyRetrievals.timings,
+		PropertyRetrievalTiming{
+			ObjectType: objType,
+			Duration:   elapsed,
+			Retrievals: 1,
+		},
+	)
+}
+
+// PropertyRetrievalTimings returns a copy of the property retrieval latency
+// recorded thus far for each managed object type.
+func PropertyRetrievalTimings() []PropertyRetrievalTiming {
+	propertyRetrievals.Lock()
+	defer propertyRetrievals.Unlock()
+
+	timings := make([]PropertyRetrievalTiming, len(propertyRetrievals.timings))
+	copy(timings, propertyRetrievals.timings)
+
+	return timings
+}
+
+// ResetPropertyRetrievalTimings discards the property retrieval latency
+// recorded thus far. This is used by long-running applications (e.g., the
+// exporter) to scope recorded latency to a single collection.
+func ResetPropertyRetrievalTimings() {
+	propertyRetrievals.Lock()
+	defer propertyRetrievals.Unlock()
+
+	propertyRetrievals.timings = nil
+}
+
+// AnnotatePropertyRetrieval is a helper function used to record the total
+// property retrieval latency as a performance data metric. If the latency
+// for any managed object type exceeds the given threshold a notice is
+// appended to the Long Service Output to help identify vCenter inventory
+// service degradation. A threshold of zero disables the notice.
+func AnnotatePropertyRetrieval(plugin *nagios.Plugin, threshold time.Duration) {
+	timings := PropertyRetrievalTimings()
+
+	// Skip if properties were not retrieved (e.g., login failure or a plugin
+	// which does not use the PropertyCollector).
+	if len(timings) == 0 {
+		return
+	}
+
+	var total time.Duration
+	var slow []string
+	for _, timing := range timings {
+		total += timing.Duration
+
+		if threshold > 0 && timing.Duration > threshold {
+			slow = append(slow, fmt.Sprintf(
+				"%s: %v (%d retrievals)",
+				timing.ObjectType,
+				timing.Duration.Round(time.Millisecond),
+				timing.Retrievals,
+			))
+		}
+	}
+
+	pd := nagios.PerformanceData{
+		Label:             "property_retrieval_ms",
+		Value:             fmt.Sprintf("%d", total.Milliseconds()),
+		UnitOfMeasurement: "ms",
+		Min:               "0",
+	}
+	if threshold > 0 {
+		pd.Warn = fmt.Sprintf("%d", threshold.Milliseconds())
+	}
+
+	if err := plugin.AddPerfData(false, pd); err != nil {
+		logger.Printf("failed to add property retrieval performance data: %v", err)
+	}
+
+	if len(slow) == 0 {
+		return
+	}
+
+	logger.Printf("slow property retrieval detected: %s", strings.Join(slow, ", "))
+
+	var notice strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&notice,
+		"%sNOTICE: Slow property retrieval (over %v) detected; this may indicate vCenter inventory service degradation:%s%s",
+		nagios.CheckOutputEOL,
+		threshold,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, entry := range slow {
+		_, _ = fmt.Fprintf(
+			&notice,
+			"* %s%s",
+			entry,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	plugin.LongServiceOutput += notice.String()
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// ErrProxyConfigInvalid indicates that the user-specified proxy settings
+// could not be used.
+var ErrProxyConfigInvalid = errors.New("invalid proxy configuration")
+
+// Environment variables consulted for proxy settings if not explicitly
+// specified. Both upper and lowercase forms are supported, uppercase first.
+const (
+	proxyEnvVar   string = "HTTPS_PROXY"
+	noProxyEnvVar string = "NO_PROXY"
+)
+
+// supportedProxySchemes returns the proxy URL schemes supported for vSphere
+// connections.
+func supportedProxySchemes() []string {
+	return []string{"http", "https", "socks5", "socks5h"}
+}
+
+// getenvAny returns the value of the first non-empty environment variable
+// from the given list.
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// parseProxyURL parses and validates the given proxy URL. A proxy URL
+// without a scheme is assumed to be an HTTP proxy.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse proxy URL: %v: %w",
+			err,
+			ErrProxyConfigInvalid,
+		)
+	}
+
+	var supported bool
+	for _, scheme := range supportedProxySchemes() {
+		if strings.EqualFold(u.Scheme, scheme) {
+			supported = true
+			break
+		}
+	}
+
+	switch {
+	case !supported:
+		return nil, fmt.Errorf(
+			"unsupported proxy scheme %q; supported schemes: %s: %w",
+			u.Scheme,
+			strings.Join(supportedProxySchemes(), ", "),
+			ErrProxyConfigInvalid,
+		)
+
+	case u.Hostname() == "":
+		return nil, fmt.Errorf(
+			"proxy URL %q does not specify a host: %w",
+			u.Redacted(),
+			ErrProxyConfigInvalid,
+		)
+	}
+
+	return u, nil
+}
+
+// configureProxy applies the user-specified proxy settings to the given SOAP
+// client. If either the proxy or the list of hosts reached directly is not
+// specified the HTTPS_PROXY and NO_PROXY environment variables (or their
+// lowercase forms) are used instead. Connections are made directly if no
+// proxy is specified.
+func configureProxy(sc *soap.Client, opts ConnectionOptions) error {
+	t := sc.DefaultTransport()
+
+	proxy := opts.Proxy
+	if proxy == "" {
+		proxy = getenvAny(proxyEnvVar, strings.ToLower(proxyEnvVar))
+	}
+
+	noProxy := opts.NoProxy
+	if noProxy == "" {
+		noProxy = getenvAny(noProxyEnvVar, strings.ToLower(noProxyEnvVar))
+	}
+
+	if proxy == "" {
+		t.Proxy = nil
+
+		return nil
+	}
+
+	proxyURL, err := parseProxyURL(proxy)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("using proxy %q (bypassed for %q)", proxyURL.Redacted(), noProxy)
+
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+
+		return proxyURL, nil
+	}
+
+	return nil
+}
+
+// bypassProxy indicates whether the given host should be reached directly
+// instead of via the proxy. Loopback addresses are always reached directly.
+// The noProxy value is a comma-separated list of entries using the same
+// format as the NO_PROXY environment variable: "*" matches all hosts, an IP
+// Address or CIDR range matches IP Addresses and a domain name matches the
+// domain and all subdomains (a leading "." is optional).
+func bypassProxy(host string, noProxy string) bool {
+	host = strings.ToLower(host)
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+
+		// Ignore any port specified for the entry; vSphere connections are
+		// made to a single port.
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+
+		switch {
+		case entry == "":
+			continue
+
+		case entry == "*":
+			return true
+
+		case ip != nil:
+			if _, network, err := net.ParseCIDR(entry); err == nil {
+				if network.Contains(ip) {
+					return true
+				}
+
+				continue
+			}
+
+			if entryIP := net.ParseIP(entry); entryIP != nil && entryIP.Equal(ip) {
+				return true
+			}
+
+		default:
+			entry = strings.TrimPrefix(entry, ".")
+			if host == entry || strings.HasSuffix(host, "."+entry) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func TestBypassProxy(t *testing.T) {
+	tests := map[string]struct {
+		host    string
+		noProxy string
+		want    bool
+	}{
+		"empty list":                 {host: "vc1.example.com", noProxy: "", want: false},
+		"localhost always direct":    {host: "localhost", noProxy: "", want: true},
+		"IPv4 loopback always":       {host: "127.0.0.1", noProxy: "", want: true},
+		"IPv6 loopback always":       {host: "::1", noProxy: "", want: true},
+		"wildcard":                   {host: "vc1.example.com", noProxy: "*", want: true},
+		"wildcard matches IP":        {host: "192.0.2.10", noProxy: "*", want: true},
+		"wildcard among entries":     {host: "vc1.example.com", noProxy: "other.test, *", want: true},
+		"exact host":                 {host: "vc1.example.com", noProxy: "vc1.example.com", want: true},
+		"host case insensitive":      {host: "VC1.Example.com", noProxy: "vc1.EXAMPLE.com", want: true},
+		"domain matches subdomain":   {host: "vc1.example.com", noProxy: "example.com", want: true},
+		"domain matches itself":      {host: "example.com", noProxy: "example.com", want: true},
+		"leading dot subdomain":      {host: "vc1.example.com", noProxy: ".example.com", want: true},
+		"leading dot matches domain": {host: "example.com", noProxy: ".example.com", want: true},
+		"partial label not matched":  {host: "vc1.badexample.com", noProxy: "example.com", want: false},
+		"subdomain not parent":       {host: "example.com", noProxy: "vc1.example.com", want: false},
+		"entry with port":            {host: "vc1.example.com", noProxy: "vc1.example.com:443", want: true},
+		"domain entry with port":     {host: "vc1.example.com", noProxy: ".example.com:8443", want: true},
+		"whitespace around entries":  {host: "vc1.example.com", noProxy: " other.test ,  example.com ", want: true},
+		"empty entries ignored":      {host: "vc1.example.com", noProxy: ",,", want: false},
+		"exact IPv4":                 {host: "192.0.2.10", noProxy: "192.0.2.10", want: true},
+		"different IPv4":             {host: "192.0.2.11", noProxy: "192.0.2.10", want: false},
+		"IPv4 with port":             {host: "192.0.2.10", noProxy: "192.0.2.10:443", want: true},
+		"IPv4 CIDR match":            {host: "192.0.2.200", noProxy: "192.0.2.0/24", want: true},
+		"IPv4 CIDR no match":         {host: "198.51.100.1", noProxy: "192.0.2.0/24", want: false},
+		"exact IPv6":                 {host: "2001:db8::10", noProxy: "2001:db8::10", want: true},
+		"IPv6 with port":             {host: "2001:db8::10", noProxy: "[2001:db8::10]:443", want: true},
+		"IPv6 CIDR match":            {host: "2001:db8::10", noProxy: "2001:db8::/32", want: true},
+		"IPv6 CIDR no match":         {host: "2001:db9::10", noProxy: "2001:db8::/32", want: false},
+		"CIDR does not match names":  {host: "vc1.example.com", noProxy: "192.0.2.0/24", want: false},
+		"domain does not match IP":   {host: "192.0.2.10", noProxy: "example.com", want: false},
+		"IP suffix is not a domain":  {host: "10.192.0.2", noProxy: "0.2", want: false},
+		"unrelated entries":          {host: "vc1.example.com", noProxy: "other.test,192.0.2.0/24", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := bypassProxy(tt.host, tt.noProxy); got != tt.want {
+				t.Errorf("want %t for host %q with no proxy list %q; got %t", tt.want, tt.host, tt.noProxy, got)
+			}
+		})
+	}
+}
+
+func TestConfigureProxy(t *testing.T) {
+	tests := map[string]struct {
+		opts      ConnectionOptions
+		env       map[string]string
+		target    string
+		wantProxy string
+		wantErr   bool
+	}{
+		"no proxy": {
+			target: "https://vc1.example.com/sdk",
+		},
+		"HTTP proxy": {
+			opts:      ConnectionOptions{Proxy: "http://proxy.example.com:3128"},
+			target:    "https://vc1.example.com/sdk",
+			wantProxy: "http://proxy.example.com:3128",
+		},
+		"proxy without scheme": {
+			opts:      ConnectionOptions{Proxy: "proxy.example.com:3128"},
+			target:    "https://vc1.example.com/sdk",
+			wantProxy: "http://proxy.example.com:3128",
+		},
+		"SOCKS proxy": {
+			opts:      ConnectionOptions{Proxy: "socks5://proxy.example.com:1080"},
+			target:    "https://vc1.example.com/sdk",
+			wantProxy: "socks5://proxy.example.com:1080",
+		},
+		"bypassed host": {
+			opts:   ConnectionOptions{Proxy: "http://proxy.example.com:3128", NoProxy: ".example.com"},
+			target: "https://vc1.example.com/sdk",
+		},
+		"proxy from environment": {
+			env:       map[string]string{"HTTPS_PROXY": "http://env-proxy.example.com:3128"},
+			target:    "https://vc1.example.com/sdk",
+			wantProxy: "http://env-proxy.example.com:3128",
+		},
+		"proxy option overrides environment": {
+			opts:      ConnectionOptions{Proxy: "http://proxy.example.com:3128"},
+			env:       map[string]string{"HTTPS_PROXY": "http://env-proxy.example.com:3128"},
+			target:    "https://vc1.example.com/sdk",
+			wantProxy: "http://proxy.example.com:3128",
+		},
+		"no proxy list from environment": {
+			opts:   ConnectionOptions{Proxy: "http://proxy.example.com:3128"},
+			env:    map[string]string{"no_proxy": "vc1.example.com"},
+			target: "https://vc1.example.com/sdk",
+		},
+		"unsupported scheme": {
+			opts:    ConnectionOptions{Proxy: "ftp://proxy.example.com"},
+			wantErr: true,
+		},
+		"missing host": {
+			opts:    ConnectionOptions{Proxy: "http://:3128"},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, name := range []string{"HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			u, _ := url.Parse("https://vc1.example.com/sdk")
+			sc := soap.NewClient(u, false)
+
+			err := configureProxy(sc, tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrProxyConfigInvalid) {
+					t.Fatalf("want %v error; got %v", ErrProxyConfigInvalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			proxyFunc := sc.DefaultTransport().Proxy
+			if proxyFunc == nil {
+				if tt.wantProxy != "" {
+					t.Fatalf("want proxy %q; got direct connection", tt.wantProxy)
+				}
+				return
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, tt.target, nil)
+			proxyURL, err := proxyFunc(req)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			var got string
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+			if got != tt.wantProxy {
+				t.Errorf("want proxy %q; got %q", tt.wantProxy, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// redactedNameHashLength is the number of hex characters of the name hash
+// used in redacted name tokens.
+const redactedNameHashLength int = 12
+
+// redactionKeyLength is the length in bytes of the secret key used to hash
+// object names.
+const redactionKeyLength int = 32
+
+// redactionKeyFileExt is the file extension of the secret key file stored
+// alongside the redacted name lookup file.
+const redactionKeyFileExt string = ".key"
+
+// redactedNameLookupDirPerms is the file mode used when creating the
+// directory for the redacted name lookup file.
+const redactedNameLookupDirPerms os.FileMode = 0700
+
+// redactedNameKinds maps the managed object types whose names are redacted
+// to the prefix used for redacted name tokens.
+var redactedNameKinds = map[string]string{
+	MgObjRefTypeVirtualMachine: "vm",
+	MgObjRefTypeHostSystem:     "host",
+	MgObjRefTypeDatastore:      "ds",
+}
+
+// nameRedaction holds the user-specified name redaction settings along with
+// the names (and replacement tokens) to redact and the tokens used thus far.
+var nameRedaction = struct {
+	sync.Mutex
+	enabled    bool
+	lookupFile string
+	key        []byte
+	tokens     map[string]string
+	used       map[string]string
+}{}
+
+// SetNameRedaction enables or disables redaction of VirtualMachine,
+// HostSystem and Datastore names in plugin output. When enabled, the names
+// of these objects are retrieved by Login and each name is replaced by
+// RedactNames with a token derived from a keyed hash (HMAC) of the name
+// (e.g., vm-9f86d081884c). The tokens used are recorded in the specified
+// lookup file so that they can be mapped back to names locally. The secret
+// key is stored alongside the lookup file (e.g., redacted-names.key) so that
+// tokens remain stable between plugin executions without allowing names to
+// be confirmed by hashing guessed names.
+func SetNameRedaction(enabled bool, lookupFile string) {
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	nameRedaction.enabled = enabled
+	nameRedaction.lookupFile = lookupFile
+	nameRedaction.key = nil
+	nameRedaction.tokens = make(map[string]string)
+	nameRedaction.used = make(map[string]string)
+
+	if enabled {
+		logger.Printf("name redaction enabled using lookup file %q", lookupFile)
+	}
+}
+
+// nameRedactionEnabled indicates whether name redaction is enabled.
+func nameRedactionEnabled() bool {
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	return nameRedaction.enabled
+}
+
+// redactedNameToken returns the replacement token for the given object name
+// and name token prefix using the given secret key.
+func redactedNameToken(key []byte, prefix string, name string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+
+	return prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:redactedNameHashLength]
+}
+
+// redactionKeyFile returns the path to the secret key file stored alongside
+// the given redacted name lookup file.
+func redactionKeyFile(lookupFile string) string {
+	return strings.TrimSuffix(lookupFile, filepath.Ext(lookupFile)) + redactionKeyFileExt
+}
+
+// loadRedactionKey returns the secret key stored (hex encoded) alongside the
+// given redacted name lookup file, generating and storing a new key if the
+// key file does not exist. A new key is generated for each plugin execution
+// if no lookup file is specified; tokens are not stable between plugin
+// executions in that case.
+func loadRedactionKey(lookupFile string) ([]byte, error) {
+	if lookupFile == "" {
+		logger.Printf("no redacted name lookup file; using temporary key")
+
+		return newRedactionKey()
+	}
+
+	keyFile := redactionKeyFile(lookupFile)
+
+	data, err := readStateFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) < redactionKeyLength {
+			return nil, fmt.Errorf(
+				"invalid redacted name key file %s; remove the file to generate a new key",
+				keyFile,
+			)
+		}
+
+		return key, nil
+	}
+
+	key, err := newRedactionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(keyFile)
+	if err := os.MkdirAll(dir, redactedNameLookupDirPerms); err != nil {
+		return nil, fmt.Errorf(
+			"failed to create redacted name lookup directory %s: %w",
+			dir,
+			err,
+		)
+	}
+
+	if err := writeStateFile(keyFile, []byte(hex.EncodeToString(key)+"\n")); err != nil {
+		return nil, err
+	}
+
+	logger.Printf("generated redacted name key file %s", keyFile)
+
+	return key, nil
+}
+
+// newRedactionKey returns a new random secret key used to hash object names.
+func newRedactionKey() ([]byte, error) {
+	key := make([]byte, redactionKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate redacted name key: %w", err)
+	}
+
+	return key, nil
+}
+
+// loadRedactedNames retrieves the names of all VirtualMachines, HostSystems
+// and Datastores in the inventory and records them for redaction. Only the
+// name property is retrieved in order to limit the cost of redaction.
+func loadRedactedNames(ctx context.Context, c *vim25.Client) error {
+	m := view.NewManager(c)
+
+	kinds := make([]string, 0, len(redactedNameKinds))
+	for kind := range redactedNameKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	v, createViewErr := m.CreateContainerView(
+		ctx,
+		c.ServiceContent.RootFolder,
+		kinds,
+		true,
+	)
+	if createViewErr != nil {
+		return createViewErr
+	}
+	defer func() {
+		if err := v.Destroy(ctx); err != nil {
+			logger.Printf("Error occurred while destroying name redaction view: %s", err)
+		}
+	}()
+
+	var entities []mo.ManagedEntity
+	if err := v.Retrieve(ctx, kinds, []string{"name"}, &entities); err != nil {
+		return fmt.Errorf(
+			"failed to retrieve object names for redaction: %w",
+			err,
+		)
+	}
+
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	if nameRedaction.key == nil {
+		key, err := loadRedactionKey(nameRedaction.lookupFile)
+		if err != nil {
+			return err
+		}
+		nameRedaction.key = key
+	}
+
+	for _, entity := range entities {
+		prefix, ok := redactedNameKinds[entity.Self.Type]
+		if !ok || entity.Name == "" {
+			continue
+		}
+
+		nameRedaction.tokens[entity.Name] = redactedNameToken(nameRedaction.key, prefix, entity.Name)
+	}
+
+	logger.Printf("recorded %d object names for redaction", len(nameRedaction.tokens))
+
+	return nil
+}
+
+// isNameRune indicates whether the given rune is considered part of an
+// object name when determining name boundaries.
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// replaceName replaces each occurrence of name within s with token and
+// records the token as used. Occurrences which are part of a longer name
+// (e.g., VM "db" within "db01") are not replaced.
+func replaceName(s string, name string, token string, used map[string]string) string {
+	first, _ := utf8.DecodeRuneInString(name)
+	last, _ := utf8.DecodeLastRuneInString(name)
+
+	var b strings.Builder
+
+	for {
+		idx := strings.Index(s, name)
+		if idx < 0 {
+			b.WriteString(s)
+
+			return b.String()
+		}
+
+		end := idx + len(name)
+
+		before, _ := utf8.DecodeLastRuneInString(s[:idx])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+
+		// Names which themselves start or end with non-name characters are
+		// matched as-is at that end.
+		startsOK := idx == 0 || !isNameRune(before) || !isNameRune(first)
+		endsOK := end == len(s) || !isNameRune(after) || !isNameRune(last)
+
+		b.WriteString(s[:idx])
+		if startsOK && endsOK {
+			b.WriteString(token)
+			used[token] = name
+		} else {
+			b.WriteString(name)
+		}
+
+		s = s[end:]
+	}
+}
+
+// redactText replaces all recorded object names within the given text with
+// their tokens. Longer names are replaced first so that names containing
+// other names (e.g., host FQDNs and short names) are replaced as a whole.
+func redactText(s string, names []string, used map[string]string) string {
+	for _, name := range names {
+		if !strings.Contains(s, name) {
+			continue
+		}
+
+		s = replaceName(s, name, nameRedaction.tokens[name], used)
+	}
+
+	return s
+}
+
+// redactedNames returns the recorded object names sorted by length in
+// descending order. The caller is expected to hold the nameRedaction lock.
+func redactedNames() []string {
+	names := make([]string, 0, len(nameRedaction.tokens))
+	for name := range nameRedaction.tokens {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// redactPerfDataName returns the token for the given object name if name
+// redaction is enabled and the name is recorded for redaction, otherwise the
+// name is returned as-is.
+func redactPerfDataName(name string) string {
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	if !nameRedaction.enabled {
+		return name
+	}
+
+	token, ok := nameRedaction.tokens[name]
+	if !ok {
+		return name
+	}
+	nameRedaction.used[token] = name
+
+	return token
+}
+
+// redactString returns the given text with recorded object names replaced
+// by their tokens if name redaction is enabled. Any tokens not previously
+// used are recorded in the lookup file.
+func redactString(s string) string {
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	if !nameRedaction.enabled || len(nameRedaction.tokens) == 0 {
+		return s
+	}
+
+	used := make(map[string]string)
+	s = redactText(s, redactedNames(), used)
+
+	var added bool
+	for token, name := range used {
+		if _, ok := nameRedaction.used[token]; !ok {
+			nameRedaction.used[token] = name
+			added = true
+		}
+	}
+
+	if added {
+		if err := updateRedactedNameLookup(nameRedaction.used); err != nil {
+			logger.Printf("failed to update redacted name lookup file: %v", err)
+		}
+	}
+
+	return s
+}
+
+// updateRedactedNameLookup merges the given token to name mappings into the
+// redacted name lookup file. The caller is expected to hold the
+// nameRedaction lock.
+func updateRedactedNameLookup(used map[string]string) error {
+	if len(used) == 0 || nameRedaction.lookupFile == "" {
+		return nil
+	}
+
+	lookup := make(map[string]string)
+
+	data, err := readStateFile(nameRedaction.lookupFile)
+	if err != nil {
+		return err
+	}
+
+	if data != nil {
+		if err := json.Unmarshal(data, &lookup); err != nil {
+			logger.Printf(
+				"failed to parse redacted name lookup file %s; replacing: %v",
+				nameRedaction.lookupFile,
+				err,
+			)
+
+			lookup = make(map[string]string)
+		}
+	}
+
+	var changed bool
+	for token, name := range used {
+		if lookup[token] != name {
+			lookup[token] = name
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	data, err = json.MarshalIndent(lookup, "", "  ")
+	if err != nil {
+		return fmt.Errorf(
+			"failed to encode redacted name lookup data: %w",
+			err,
+		)
+	}
+
+	dir := filepath.Dir(nameRedaction.lookupFile)
+	if err := os.MkdirAll(dir, redactedNameLookupDirPerms); err != nil {
+		return fmt.Errorf(
+			"failed to create redacted name lookup directory %s: %w",
+			dir,
+			err,
+		)
+	}
+
+	return writeStateFile(nameRedaction.lookupFile, data)
+}
+
+// RedactNames is a helper function used to replace VirtualMachine,
+// HostSystem and Datastore names within the plugin output (one-line summary,
+// long service output, thresholds and errors) with redacted name tokens if
+// name redaction is enabled. The tokens used (including those used in
+// performance data labels) are recorded in the lookup file specified via
+// SetNameRedaction. This is applied by the SetupPlugin cleanup function once
+// the plugin results are final and before they are exported (e.g., traces).
+func RedactNames(plugin *nagios.Plugin) {
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	if !nameRedaction.enabled || len(nameRedaction.tokens) == 0 {
+		return
+	}
+
+	names := redactedNames()
+	used := nameRedaction.used
+
+	plugin.ServiceOutput = redactText(plugin.ServiceOutput, names, used)
+	plugin.LongServiceOutput = redactText(plugin.LongServiceOutput, names, used)
+	plugin.WarningThreshold = redactText(plugin.WarningThreshold, names, used)
+	plugin.CriticalThreshold = redactText(plugin.CriticalThreshold, names, used)
+
+	// Errors are replaced with redacted copies; this is safe to do as errors
+	// are no longer inspected once plugin results are being emitted.
+	redactErr := func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		msg := err.Error()
+		redacted := redactText(msg, names, used)
+		if redacted == msg {
+			return err
+		}
+
+		return errors.New(redacted)
+	}
+
+	for i := range plugin.Errors {
+		plugin.Errors[i] = redactErr(plugin.Errors[i])
+	}
+	plugin.LastError = redactErr(plugin.LastError)
+
+	logger.Printf("redacted %d object names in plugin output", len(used))
+
+	if err := updateRedactedNameLookup(used); err != nil {
+		logger.Printf("failed to update redacted name lookup file: %v", err)
+	}
+}
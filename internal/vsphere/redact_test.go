@@ -0,0 +1,303 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+)
+
+// setRedactedNames enables name redaction using the given lookup file and
+// records the given names (mapped to token prefixes) for redaction.
+func setRedactedNames(t *testing.T, lookupFile string, names map[string]string) {
+	t.Helper()
+
+	SetNameRedaction(true, lookupFile)
+	t.Cleanup(func() { SetNameRedaction(false, "") })
+
+	key, err := loadRedactionKey(lookupFile)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	nameRedaction.Lock()
+	defer nameRedaction.Unlock()
+
+	nameRedaction.key = key
+	for name, prefix := range names {
+		nameRedaction.tokens[name] = redactedNameToken(key, prefix, name)
+	}
+}
+
+func TestReplaceName(t *testing.T) {
+	tests := map[string]struct {
+		s        string
+		name     string
+		want     string
+		wantUsed bool
+	}{
+		"whole text": {
+			s:        "db",
+			name:     "db",
+			want:     "TOKEN",
+			wantUsed: true,
+		},
+		"within sentence": {
+			s:        "VM db has 3 snapshots",
+			name:     "db",
+			want:     "VM TOKEN has 3 snapshots",
+			wantUsed: true,
+		},
+		"multiple occurrences": {
+			s:        "db, db (db)",
+			name:     "db",
+			want:     "TOKEN, TOKEN (TOKEN)",
+			wantUsed: true,
+		},
+		"prefix of longer name": {
+			s:    "VM db01 has 3 snapshots",
+			name: "db",
+			want: "VM db01 has 3 snapshots",
+		},
+		"suffix of longer name": {
+			s:    "VM mydb has 3 snapshots",
+			name: "db",
+			want: "VM mydb has 3 snapshots",
+		},
+		"hyphen and underscore are name characters": {
+			s:    "db-01, db_02, app-db",
+			name: "db",
+			want: "db-01, db_02, app-db",
+		},
+		"longer name then whole name": {
+			s:        "db01, db",
+			name:     "db",
+			want:     "db01, TOKEN",
+			wantUsed: true,
+		},
+		"FQDN followed by punctuation": {
+			s:        "host esx1.example.com: not responding",
+			name:     "esx1.example.com",
+			want:     "host TOKEN: not responding",
+			wantUsed: true,
+		},
+		"name starting with non-name character": {
+			s:        "VM x(old) powered off",
+			name:     "(old)",
+			want:     "VM xTOKEN powered off",
+			wantUsed: true,
+		},
+		"name ending with non-name character": {
+			s:        "VM db (old)y",
+			name:     "db (old)",
+			want:     "VM TOKENy",
+			wantUsed: true,
+		},
+		"name with multibyte characters": {
+			s:        "VM café, cafés",
+			name:     "café",
+			want:     "VM TOKEN, cafés",
+			wantUsed: true,
+		},
+		"not present": {
+			s:    "no problems found",
+			name: "db",
+			want: "no problems found",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			used := make(map[string]string)
+
+			got := replaceName(tt.s, tt.name, "TOKEN", used)
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+
+			_, gotUsed := used["TOKEN"]
+			if gotUsed != tt.wantUsed {
+				t.Errorf("want token used %t; got %t", tt.wantUsed, gotUsed)
+			}
+			if gotUsed && used["TOKEN"] != tt.name {
+				t.Errorf("want token mapped to %q; got %q", tt.name, used["TOKEN"])
+			}
+		})
+	}
+}
+
+func TestRedactedNameToken(t *testing.T) {
+	key := []byte(strings.Repeat("k", redactionKeyLength))
+	otherKey := []byte(strings.Repeat("o", redactionKeyLength))
+
+	token := redactedNameToken(key, "vm", "db")
+
+	if !strings.HasPrefix(token, "vm-") || len(token) != len("vm-")+redactedNameHashLength {
+		t.Errorf("want vm- prefix and %d hash characters; got %q", redactedNameHashLength, token)
+	}
+
+	if got := redactedNameToken(key, "vm", "db"); got != token {
+		t.Errorf("want stable token %q; got %q", token, got)
+	}
+
+	if got := redactedNameToken(key, "vm", "db01"); got == token {
+		t.Errorf("want different tokens for different names; got %q", got)
+	}
+
+	if got := redactedNameToken(otherKey, "vm", "db"); got == token {
+		t.Errorf("want different tokens for different keys; got %q", got)
+	}
+}
+
+func TestLoadRedactionKey(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "check-vmware")
+	lookupFile := filepath.Join(dir, "redacted-names.json")
+	keyFile := filepath.Join(dir, "redacted-names.key")
+
+	key, err := loadRedactionKey(lookupFile)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+	if len(key) != redactionKeyLength {
+		t.Errorf("want %d byte key; got %d bytes", redactionKeyLength, len(key))
+	}
+
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("want key file %s; got %v", keyFile, err)
+	}
+	if perms := info.Mode().Perm(); perms != 0o600 {
+		t.Errorf("want key file permissions 0600; got %#o", perms)
+	}
+
+	reloaded, err := loadRedactionKey(lookupFile)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+	if string(reloaded) != string(key) {
+		t.Error("want existing key to be reused")
+	}
+
+	temporary, err := loadRedactionKey("")
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+	if len(temporary) != redactionKeyLength || string(temporary) == string(key) {
+		t.Error("want new temporary key without lookup file")
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key\n"), 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+	if _, err := loadRedactionKey(lookupFile); err == nil {
+		t.Error("want error for invalid key file; got nil")
+	}
+}
+
+func TestRedactNames(t *testing.T) {
+	lookupFile := filepath.Join(t.TempDir(), "redacted-names.json")
+
+	setRedactedNames(t, lookupFile, map[string]string{
+		"db":               "vm",
+		"esx1.example.com": "host",
+		"esx1":             "host",
+	})
+
+	nameRedaction.Lock()
+	dbToken := nameRedaction.tokens["db"]
+	hostToken := nameRedaction.tokens["esx1.example.com"]
+	nameRedaction.Unlock()
+
+	pluginErr := errors.New("VM db on esx1.example.com is not responding")
+	plugin := nagios.NewPlugin()
+	plugin.ServiceOutput = "WARNING: VM db has 3 snapshots"
+	plugin.LongServiceOutput = "* db01\n* db (esx1.example.com)\n"
+	plugin.AddError(pluginErr)
+
+	RedactNames(plugin)
+
+	if want := "WARNING: VM " + dbToken + " has 3 snapshots"; plugin.ServiceOutput != want {
+		t.Errorf("want summary %q; got %q", want, plugin.ServiceOutput)
+	}
+
+	if want := "* db01\n* " + dbToken + " (" + hostToken + ")\n"; plugin.LongServiceOutput != want {
+		t.Errorf("want long service output %q; got %q", want, plugin.LongServiceOutput)
+	}
+
+	if want := "VM " + dbToken + " on " + hostToken + " is not responding"; plugin.Errors[0].Error() != want {
+		t.Errorf("want error %q; got %q", want, plugin.Errors[0].Error())
+	}
+
+	data, err := os.ReadFile(lookupFile)
+	if err != nil {
+		t.Fatalf("want lookup file; got %v", err)
+	}
+
+	var lookup map[string]string
+	if err := json.Unmarshal(data, &lookup); err != nil {
+		t.Fatalf("want valid lookup file; got %v", err)
+	}
+
+	want := map[string]string{
+		dbToken:   "db",
+		hostToken: "esx1.example.com",
+	}
+	if d := cmp.Diff(want, lookup); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestRedactNamesDisabled(t *testing.T) {
+	SetNameRedaction(false, "")
+
+	plugin := nagios.NewPlugin()
+	plugin.ServiceOutput = "WARNING: VM db has 3 snapshots"
+
+	RedactNames(plugin)
+
+	if want := "WARNING: VM db has 3 snapshots"; plugin.ServiceOutput != want {
+		t.Errorf("want summary %q; got %q", want, plugin.ServiceOutput)
+	}
+}
+
+func TestExportTracesRedactsNames(t *testing.T) {
+	lookupFile := filepath.Join(t.TempDir(), "redacted-names.json")
+	setRedactedNames(t, lookupFile, map[string]string{"db": "vm"})
+
+	nameRedaction.Lock()
+	dbToken := nameRedaction.tokens["db"]
+	nameRedaction.Unlock()
+
+	tc, srv := newTraceCollector(t, 0)
+
+	SetTracing(srv.URL, "check_vmware_snapshots_age", "vc1.example.com", time.Now().Add(time.Minute))
+	defer SetTracing("", "", "", time.Time{})
+
+	_ = TrackPhase(context.Background(), "retrieve VMs", func() error { return errors.New("VM db not found") })
+
+	ExportTraces(nagios.NewPlugin())
+
+	requests := tc.received()
+	if len(requests) != 1 {
+		t.Fatalf("want 1 export request; got %d", len(requests))
+	}
+
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	if want := "VM " + dbToken + " not found"; spans[1].Status.Message != want {
+		t.Errorf("want span error message %q; got %q", want, spans[1].Status.Message)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Identifiers for optional sections of the long service output generated by
+// report builders. Sections may be omitted per user preference in order to
+// keep notifications focused.
+const (
+	// ReportSectionRecentlyStartedVMs is the list of VirtualMachines most
+	// recently started (powered on).
+	ReportSectionRecentlyStartedVMs string = "recently-started-vms"
+
+	// ReportSectionTopConsumers is the list of VirtualMachines consuming the
+	// most of an evaluated resource (e.g., vCPUs, memory).
+	ReportSectionTopConsumers string = "top-consumers"
+
+	// ReportSectionTopOKVMs is the list of VirtualMachines closest to (but
+	// not yet exceeding) specified thresholds.
+	ReportSectionTopOKVMs string = "top-ok-vms"
+)
+
+// reportSections is the registry of optional report sections which may be
+// omitted from the long service output, indexed by section identifier.
+var reportSections = map[string]string{
+	ReportSectionRecentlyStartedVMs: "Ten most recently started VMs",
+	ReportSectionTopConsumers:       "Top 10 resource consumers",
+	ReportSectionTopOKVMs:           "Top 10 VMs not yet exceeding thresholds",
+}
+
+// omittedReportSections is the collection of report sections the user has
+// requested be omitted from the long service output.
+var omittedReportSections = struct {
+	sync.Mutex
+	sections map[string]struct{}
+}{}
+
+// SupportedReportSections returns a sorted list of the identifiers for
+// optional report sections which may be omitted from the long service
+// output.
+func SupportedReportSections() []string {
+	sections := make([]string, 0, len(reportSections))
+	for section := range reportSections {
+		sections = append(sections, section)
+	}
+
+	sort.Strings(sections)
+
+	return sections
+}
+
+// SetOmittedReportSections records the given report sections as omitted from
+// the long service output generated by report builders. Unknown section
+// identifiers are ignored. All sections are included if the given list is
+// empty.
+func SetOmittedReportSections(sections []string) {
+	omittedReportSections.Lock()
+	defer omittedReportSections.Unlock()
+
+	omittedReportSections.sections = make(map[string]struct{}, len(sections))
+
+	for _, section := range sections {
+		section = strings.ToLower(strings.TrimSpace(section))
+
+		description, ok := reportSections[section]
+		if !ok {
+			logger.Printf("ignoring unknown report section %q", section)
+
+			continue
+		}
+
+		logger.Printf("omitting report section %q (%s)", section, description)
+		omittedReportSections.sections[section] = struct{}{}
+	}
+}
+
+// reportSectionEnabled indicates whether the given report section should be
+// included in the long service output.
+func reportSectionEnabled(section string) bool {
+	omittedReportSections.Lock()
+	defer omittedReportSections.Unlock()
+
+	_, omitted := omittedReportSections.sections[section]
+
+	return !omitted
+}
@@ -0,0 +1,547 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrResourcePoolRunawayVMThresholdCrossed indicates that a single VM has
+// consumed more than a specified percentage of a Resource Pool's CPU or
+// memory usage.
+var ErrResourcePoolRunawayVMThresholdCrossed = errors.New("vm resource pool usage share exceeds specified threshold")
+
+// RunawayVMThresholds represents the user-specified thresholds for the
+// percentage of a Resource Pool's CPU or memory usage consumed by a single
+// VM.
+type RunawayVMThresholds struct {
+	CPUShareWarning     int
+	CPUShareCritical    int
+	MemoryShareWarning  int
+	MemoryShareCritical int
+}
+
+// ResourcePoolVMUsage represents the CPU and memory usage of a VM and its
+// share of the usage of the Resource Pool it belongs to.
+type ResourcePoolVMUsage struct {
+
+	// Name is the name of the VirtualMachine.
+	Name string
+
+	// CPUUsage is the CPU usage of the VirtualMachine in MHz.
+	CPUUsage int64
+
+	// MemoryUsage is the host memory consumed by the VirtualMachine in
+	// bytes.
+	MemoryUsage int64
+
+	// CPUShare is the percentage of the Resource Pool's CPU usage consumed
+	// by the VirtualMachine.
+	CPUShare float64
+
+	// MemoryShare is the percentage of the Resource Pool's memory usage
+	// consumed by the VirtualMachine.
+	MemoryShare float64
+
+	Thresholds RunawayVMThresholds
+}
+
+// ResourcePoolUsage tracks the combined CPU and memory usage of the powered
+// on VMs in a Resource Pool.
+type ResourcePoolUsage struct {
+
+	// Name is the name of the Resource Pool.
+	Name string
+
+	// CPUUsage is the combined CPU usage of all powered on VMs in the
+	// Resource Pool in MHz.
+	CPUUsage int64
+
+	// MemoryUsage is the combined host memory consumed by all powered on
+	// VMs in the Resource Pool in bytes.
+	MemoryUsage int64
+
+	// VMs is the collection of evaluated (non-ignored) VMs in the Resource
+	// Pool.
+	VMs []ResourcePoolVMUsage
+
+	// NumVMs is the number of powered on VMs in the Resource Pool, including
+	// any which are ignored.
+	NumVMs int
+}
+
+// ResourcePoolUsageSet is a collection of ResourcePoolUsage values.
+type ResourcePoolUsageSet []ResourcePoolUsage
+
+// MemoryUsageHR returns the human readable host memory consumed by the
+// VirtualMachine.
+func (rpvu ResourcePoolVMUsage) MemoryUsageHR() string {
+	return units.ByteSize(rpvu.MemoryUsage).String()
+}
+
+// IsCriticalState indicates whether the VirtualMachine's share of the
+// Resource Pool's CPU or memory usage has exceeded the CRITICAL threshold.
+func (rpvu ResourcePoolVMUsage) IsCriticalState() bool {
+	return rpvu.CPUShare > float64(rpvu.Thresholds.CPUShareCritical) ||
+		rpvu.MemoryShare > float64(rpvu.Thresholds.MemoryShareCritical)
+}
+
+// IsWarningState indicates whether the VirtualMachine's share of the
+// Resource Pool's CPU or memory usage has exceeded the WARNING threshold,
+// but not the CRITICAL threshold.
+func (rpvu ResourcePoolVMUsage) IsWarningState() bool {
+	return !rpvu.IsCriticalState() &&
+		(rpvu.CPUShare > float64(rpvu.Thresholds.CPUShareWarning) ||
+			rpvu.MemoryShare > float64(rpvu.Thresholds.MemoryShareWarning))
+}
+
+// MemoryUsageHR returns the human readable combined host memory consumed by
+// all powered on VMs in the Resource Pool.
+func (rpu ResourcePoolUsage) MemoryUsageHR() string {
+	return units.ByteSize(rpu.MemoryUsage).String()
+}
+
+// TopCPU returns up to the specified number of VMs in the Resource Pool
+// with the highest CPU usage.
+func (rpu ResourcePoolUsage) TopCPU(num int) []ResourcePoolVMUsage {
+	vms := make([]ResourcePoolVMUsage, len(rpu.VMs))
+	copy(vms, rpu.VMs)
+
+	sort.SliceStable(vms, func(i, j int) bool {
+		return vms[i].CPUUsage > vms[j].CPUUsage
+	})
+
+	if len(vms) > num {
+		vms = vms[:num]
+	}
+
+	return vms
+}
+
+// TopMemory returns up to the specified number of VMs in the Resource Pool
+// with the highest host memory consumption.
+func (rpu ResourcePoolUsage) TopMemory(num int) []ResourcePoolVMUsage {
+	vms := make([]ResourcePoolVMUsage, len(rpu.VMs))
+	copy(vms, rpu.VMs)
+
+	sort.SliceStable(vms, func(i, j int) bool {
+		return vms[i].MemoryUsage > vms[j].MemoryUsage
+	})
+
+	if len(vms) > num {
+		vms = vms[:num]
+	}
+
+	return vms
+}
+
+// NewResourcePoolUsageSet evaluates the CPU and memory usage of the powered
+// on VMs in each of the given Resource Pools. The combined usage of all
+// powered on VMs in a Resource Pool (including ignored VMs) is used to
+// determine each VM's share of the pool's usage. Resource Pools with fewer
+// than two powered on VMs are not evaluated as a single VM always accounts
+// for all usage of the pool.
+func NewResourcePoolUsageSet(
+	rps []mo.ResourcePool,
+	vms []mo.VirtualMachine,
+	ignoredVMs []string,
+	thresholds RunawayVMThresholds,
+) ResourcePoolUsageSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewResourcePoolUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	index := make(map[string]*ResourcePoolUsage, len(rps))
+	for _, rp := range rps {
+		index[rp.Self.Value] = &ResourcePoolUsage{Name: rp.Name}
+	}
+
+	type vmUsage struct {
+		name   string
+		cpu    int64
+		memory int64
+	}
+
+	poolVMs := make(map[string][]vmUsage, len(rps))
+
+	for _, vm := range vms {
+		if vm.ResourcePool == nil ||
+			vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			continue
+		}
+
+		rpu, ok := index[vm.ResourcePool.Value]
+		if !ok {
+			continue
+		}
+
+		usage := vmUsage{
+			name:   vm.Name,
+			cpu:    int64(vm.Summary.QuickStats.OverallCpuUsage),
+			memory: int64(vm.Summary.QuickStats.HostMemoryUsage) * units.MB,
+		}
+
+		rpu.CPUUsage += usage.cpu
+		rpu.MemoryUsage += usage.memory
+		rpu.NumVMs++
+
+		poolVMs[vm.ResourcePool.Value] = append(poolVMs[vm.ResourcePool.Value], usage)
+	}
+
+	share := func(value int64, total int64) float64 {
+		if total <= 0 {
+			return 0
+		}
+
+		return float64(value) / float64(total) * 100
+	}
+
+	set := make(ResourcePoolUsageSet, 0, len(index))
+	for id, rpu := range index {
+		if rpu.NumVMs < 2 {
+			continue
+		}
+
+		for _, usage := range poolVMs[id] {
+			if inPatternList(usage.name, ignoredVMs) {
+				continue
+			}
+
+			rpu.VMs = append(rpu.VMs, ResourcePoolVMUsage{
+				Name:        usage.name,
+				CPUUsage:    usage.cpu,
+				MemoryUsage: usage.memory,
+				CPUShare:    share(usage.cpu, rpu.CPUUsage),
+				MemoryShare: share(usage.memory, rpu.MemoryUsage),
+				Thresholds:  thresholds,
+			})
+		}
+
+		set = append(set, *rpu)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].Name) < strings.ToLower(set[j].Name)
+	})
+
+	return set
+}
+
+// RunawayVMs returns the VMs in the set which have exceeded a WARNING or
+// CRITICAL threshold along with the name of their Resource Pool.
+func (set ResourcePoolUsageSet) RunawayVMs() map[string][]ResourcePoolVMUsage {
+	runaways := make(map[string][]ResourcePoolVMUsage)
+	for _, rpu := range set {
+		for _, vm := range rpu.VMs {
+			if vm.IsCriticalState() || vm.IsWarningState() {
+				runaways[rpu.Name] = append(runaways[rpu.Name], vm)
+			}
+		}
+	}
+
+	return runaways
+}
+
+// NumCritical returns the number of VMs which have exceeded a CRITICAL
+// threshold.
+func (set ResourcePoolUsageSet) NumCritical() int {
+	var num int
+	for _, rpu := range set {
+		for _, vm := range rpu.VMs {
+			if vm.IsCriticalState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VMs which have exceeded a WARNING
+// threshold, but not a CRITICAL threshold.
+func (set ResourcePoolUsageSet) NumWarning() int {
+	var num int
+	for _, rpu := range set {
+		for _, vm := range rpu.VMs {
+			if vm.IsWarningState() {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// MaxCPUShare returns the highest share of a Resource Pool's CPU usage
+// consumed by a single evaluated VM.
+func (set ResourcePoolUsageSet) MaxCPUShare() float64 {
+	var highest float64
+	for _, rpu := range set {
+		for _, vm := range rpu.VMs {
+			if vm.CPUShare > highest {
+				highest = vm.CPUShare
+			}
+		}
+	}
+
+	return highest
+}
+
+// MaxMemoryShare returns the highest share of a Resource Pool's memory usage
+// consumed by a single evaluated VM.
+func (set ResourcePoolUsageSet) MaxMemoryShare() float64 {
+	var highest float64
+	for _, rpu := range set {
+		for _, vm := range rpu.VMs {
+			if vm.MemoryShare > highest {
+				highest = vm.MemoryShare
+			}
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any VM in the set has exceeded a
+// CRITICAL threshold.
+func (set ResourcePoolUsageSet) IsCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any VM in the set has exceeded a WARNING
+// threshold.
+func (set ResourcePoolUsageSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// ResourcePoolRunawayVMPerfData generates performance data metrics from the
+// given evaluation results.
+func ResourcePoolRunawayVMPerfData(
+	set ResourcePoolUsageSet,
+	thresholds RunawayVMThresholds,
+) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "resource_pools_with_multiple_vms",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "runaway_vms_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "runaway_vms_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "vm_cpu_share_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxCPUShare()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", thresholds.CPUShareWarning),
+			Crit:              fmt.Sprintf("%d", thresholds.CPUShareCritical),
+			Min:               "0",
+		},
+		{
+			Label:             "vm_memory_share_max",
+			Value:             fmt.Sprintf("%.2f", set.MaxMemoryShare()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", thresholds.MemoryShareWarning),
+			Crit:              fmt.Sprintf("%d", thresholds.MemoryShareCritical),
+			Min:               "0",
+		},
+	}
+}
+
+// ResourcePoolRunawayVMOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ResourcePoolRunawayVMOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set ResourcePoolUsageSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ResourcePoolRunawayVMOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState() || set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs consuming excessive share of Resource Pool usage (%d CRITICAL, %d WARNING; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumCritical(),
+			set.NumWarning(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs consuming excessive share of Resource Pool usage (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// ResourcePoolRunawayVMReport generates a summary of VMs consuming an
+// excessive share of Resource Pool usage along with the top CPU and memory
+// consuming VMs for each Resource Pool. This information is provided for use
+// with the Long Service Output field commonly displayed on the detailed
+// service check results display in the web UI or in the body of many
+// notifications.
+func ResourcePoolRunawayVMReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set ResourcePoolUsageSet,
+	topCount int,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ResourcePoolRunawayVMReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	vmState := func(vm ResourcePoolVMUsage) string {
+		switch {
+		case vm.IsCriticalState():
+			return " [" + nagios.StateCRITICALLabel + "]"
+		case vm.IsWarningState():
+			return " [" + nagios.StateWARNINGLabel + "]"
+		default:
+			return ""
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs consuming excessive share of Resource Pool usage:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	runaways := set.RunawayVMs()
+	if len(runaways) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+	}
+
+	for _, rpu := range set {
+		for _, vm := range runaways[rpu.Name] {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (pool: %s)%s: CPU %.2f%% (%d MHz), memory %.2f%% (%s)%s",
+				vm.Name,
+				rpu.Name,
+				vmState(vm),
+				vm.CPUShare,
+				vm.CPUUsage,
+				vm.MemoryShare,
+				vm.MemoryUsageHR(),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sTop %d CPU and memory consuming VMs per Resource Pool:%s%s",
+		nagios.CheckOutputEOL,
+		topCount,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+	}
+
+	for _, rpu := range set {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (VMs: %d, CPU: %d MHz, memory: %s)%s",
+			rpu.Name,
+			rpu.NumVMs,
+			rpu.CPUUsage,
+			rpu.MemoryUsageHR(),
+			nagios.CheckOutputEOL,
+		)
+
+		for _, vm := range rpu.TopCPU(topCount) {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** CPU: %s%s %.2f%% (%d MHz)%s",
+				vm.Name,
+				vmState(vm),
+				vm.CPUShare,
+				vm.CPUUsage,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, vm := range rpu.TopMemory(topCount) {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Memory: %s%s %.2f%% (%s)%s",
+				vm.Name,
+				vmState(vm),
+				vm.MemoryShare,
+				vm.MemoryUsageHR(),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// runawayPool returns a Resource Pool with the given name and ID.
+func runawayPool(name string, id string) mo.ResourcePool {
+	var rp mo.ResourcePool
+	rp.Name = name
+	rp.Self = types.ManagedObjectReference{Type: MgObjRefTypeResourcePool, Value: id}
+
+	return rp
+}
+
+// runawayVM returns a powered on VM in the given Resource Pool with the
+// given CPU (MHz) and host memory (MB) usage.
+func runawayVM(name string, rpID string, cpu int32, memory int32) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.ResourcePool = &types.ManagedObjectReference{Type: MgObjRefTypeResourcePool, Value: rpID}
+	vm.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOn
+	vm.Summary.QuickStats.OverallCpuUsage = cpu
+	vm.Summary.QuickStats.HostMemoryUsage = memory
+
+	return vm
+}
+
+func TestNewResourcePoolUsageSet(t *testing.T) {
+	thresholds := RunawayVMThresholds{
+		CPUShareWarning:     60,
+		CPUShareCritical:    80,
+		MemoryShareWarning:  60,
+		MemoryShareCritical: 80,
+	}
+
+	rps := []mo.ResourcePool{
+		runawayPool("Production", "resgroup-1"),
+		runawayPool("development", "resgroup-2"),
+		runawayPool("Test", "resgroup-3"),
+	}
+
+	poweredOff := runawayVM("vm5", "resgroup-3", 9000, 9000)
+	poweredOff.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOff
+
+	noPool := runawayVM("vm7", "", 9000, 9000)
+	noPool.ResourcePool = nil
+
+	vms := []mo.VirtualMachine{
+		runawayVM("vm1", "resgroup-1", 750, 1024),
+		runawayVM("vm2", "resgroup-1", 250, 3072),
+		runawayVM("vm3", "resgroup-2", 900, 9000),
+		runawayVM("vm4", "resgroup-2", 100, 1000),
+		runawayVM("vm5", "resgroup-3", 900, 9000),
+		poweredOff,
+		runawayVM("vm6", "resgroup-4", 900, 9000),
+		noPool,
+	}
+
+	set := NewResourcePoolUsageSet(rps, vms, []string{"VM3"}, thresholds)
+
+	want := ResourcePoolUsageSet{
+		{
+			Name:        "development",
+			CPUUsage:    1000,
+			MemoryUsage: 10000 * units.MB,
+			NumVMs:      2,
+			VMs: []ResourcePoolVMUsage{
+				{
+					Name:        "vm4",
+					CPUUsage:    100,
+					MemoryUsage: 1000 * units.MB,
+					CPUShare:    10,
+					MemoryShare: 10,
+					Thresholds:  thresholds,
+				},
+			},
+		},
+		{
+			Name:        "Production",
+			CPUUsage:    1000,
+			MemoryUsage: 4096 * units.MB,
+			NumVMs:      2,
+			VMs: []ResourcePoolVMUsage{
+				{
+					Name:        "vm1",
+					CPUUsage:    750,
+					MemoryUsage: 1024 * units.MB,
+					CPUShare:    75,
+					MemoryShare: 25,
+					Thresholds:  thresholds,
+				},
+				{
+					Name:        "vm2",
+					CPUUsage:    250,
+					MemoryUsage: 3072 * units.MB,
+					CPUShare:    25,
+					MemoryShare: 75,
+					Thresholds:  thresholds,
+				},
+			},
+		},
+	}
+
+	if d := cmp.Diff(want, set); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := set.MaxCPUShare(); got != 75 {
+		t.Errorf("want max CPU share 75; got %v", got)
+	}
+
+	if got := set.MaxMemoryShare(); got != 75 {
+		t.Errorf("want max memory share 75; got %v", got)
+	}
+
+	if got := set.NumWarning(); got != 2 || !set.IsWarningState() {
+		t.Errorf("want 2 WARNING VMs; got %d", got)
+	}
+
+	if set.IsCriticalState() {
+		t.Errorf("want no CRITICAL state; got %d CRITICAL VMs", set.NumCritical())
+	}
+
+	runaways := set.RunawayVMs()
+	if len(runaways) != 1 || len(runaways["Production"]) != 2 {
+		t.Errorf("want 2 runaway VMs in Production; got %v", runaways)
+	}
+}
+
+func TestResourcePoolVMUsageState(t *testing.T) {
+	thresholds := RunawayVMThresholds{
+		CPUShareWarning:     60,
+		CPUShareCritical:    80,
+		MemoryShareWarning:  60,
+		MemoryShareCritical: 80,
+	}
+
+	tests := map[string]struct {
+		cpuShare     float64
+		memoryShare  float64
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"balanced usage":              {cpuShare: 50, memoryShare: 50},
+		"cpu share at warning":        {cpuShare: 60, memoryShare: 10},
+		"cpu share above warning":     {cpuShare: 70, memoryShare: 30, wantWarning: true},
+		"memory share above warning":  {cpuShare: 10, memoryShare: 61, wantWarning: true},
+		"memory share above critical": {cpuShare: 50, memoryShare: 90, wantCritical: true},
+		"cpu share above critical":    {cpuShare: 81, memoryShare: 70, wantCritical: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rpvu := ResourcePoolVMUsage{
+				CPUShare:    tt.cpuShare,
+				MemoryShare: tt.memoryShare,
+				Thresholds:  thresholds,
+			}
+
+			if got := rpvu.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := rpvu.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestResourcePoolUsageTopConsumers(t *testing.T) {
+	rpu := ResourcePoolUsage{
+		VMs: []ResourcePoolVMUsage{
+			{Name: "vm1", CPUUsage: 100, MemoryUsage: 300},
+			{Name: "vm2", CPUUsage: 300, MemoryUsage: 100},
+			{Name: "vm3", CPUUsage: 200, MemoryUsage: 200},
+		},
+	}
+
+	names := func(vms []ResourcePoolVMUsage) []string {
+		var list []string
+		for _, vm := range vms {
+			list = append(list, vm.Name)
+		}
+
+		return list
+	}
+
+	if d := cmp.Diff([]string{"vm2", "vm3"}, names(rpu.TopCPU(2))); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if d := cmp.Diff([]string{"vm1", "vm3", "vm2"}, names(rpu.TopMemory(5))); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if d := cmp.Diff([]string{"vm1", "vm2", "vm3"}, names(rpu.VMs)); d != "" {
+		t.Errorf("want original order retained (-want, +got):\n%s", d)
+	}
+}
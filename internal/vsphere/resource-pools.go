@@ -15,8 +15,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/atc0005/check-vmware/internal/textutils"
-
 	"github.com/atc0005/go-nagios"
 
 	"github.com/vmware/govmomi/units"
@@ -219,7 +217,7 @@ func ValidateRPs(ctx context.Context, c *vim25.Client, includeRPs []string, excl
 	switch {
 	case len(includeRPs) > 0:
 		for _, iRP := range includeRPs {
-			if !textutils.InList(iRP, poolNamesFound, true) {
+			if !patternMatchesAny(iRP, poolNamesFound) {
 				notFound = append(notFound, iRP)
 			}
 		}
@@ -236,7 +234,7 @@ func ValidateRPs(ctx context.Context, c *vim25.Client, includeRPs []string, excl
 
 	case len(excludeRPs) > 0:
 		for _, eRP := range excludeRPs {
-			if !textutils.InList(eRP, poolNamesFound, true) {
+			if !patternMatchesAny(eRP, poolNamesFound) {
 				notFound = append(notFound, eRP)
 			}
 		}
@@ -392,14 +390,14 @@ func GetEligibleRPs(ctx context.Context, c *vim25.Client, includeRPs []string, e
 		// if specified, only include resource pools that have been
 		// intentionally included (aka, "whitelisted")
 		case len(includeRPs) > 0:
-			if textutils.InList(rp.Name, includeRPs, true) {
+			if inPatternList(rp.Name, includeRPs) {
 				rps = append(rps, rp)
 			}
 
 		// if specified, don't include resource pools that have been
 		// intentionally excluded (aka, "blacklisted")
 		case len(excludeRPs) > 0:
-			if !textutils.InList(rp.Name, excludeRPs, true) {
+			if !inPatternList(rp.Name, excludeRPs) {
 				rps = append(rps, rp)
 			}
 
@@ -586,6 +584,13 @@ func RPMemoryUsageOneLineCheckSummary(
 	clusterMemoryInBytes int64,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults":            vmsFilterResults,
+		"aggregateMemoryUsageInBytes": aggregateMemoryUsageInBytes,
+		"maxMemoryUsageInBytes":       maxMemoryUsageInBytes,
+		"clusterMemoryInBytes":        clusterMemoryInBytes,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -714,97 +719,101 @@ func ResourcePoolsMemoryReport(
 	poweredVMs, numVMsPoweredOff := FilterVMsByPowerState(vms, false)
 	numVMsPoweredOn := len(poweredVMs)
 
-	_, _ = fmt.Fprintf(
-		&report,
-		"%sTen VMS consuming most memory:%s%s",
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-	)
-
-	switch {
-	case numVMsPoweredOn == 0:
+	if reportSectionEnabled(ReportSectionTopConsumers) {
 		_, _ = fmt.Fprintf(
 			&report,
-			"* None (visible); %d powered off%s",
-			numVMsPoweredOff,
+			"%sTen VMS consuming most memory:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
 			nagios.CheckOutputEOL,
 		)
 
-	default:
-
-		sort.Slice(poweredVMs, func(i, j int) bool {
-			return poweredVMs[i].Summary.QuickStats.HostMemoryUsage > poweredVMs[j].Summary.QuickStats.HostMemoryUsage
-		})
-
-		// grab up to the first 10 VMs, presorted by most memory usage
-		sampleSize := len(poweredVMs)
-		if sampleSize > 10 {
-			sampleSize = 10
-		}
-
-		for _, vm := range poweredVMs[:sampleSize] {
-			hostMemUsedBytes := int64(vm.Summary.QuickStats.HostMemoryUsage) * units.MB
-			rpName := rpIDtoNameIdx[vm.ResourcePool.Value]
-
+		switch {
+		case numVMsPoweredOn == 0:
 			_, _ = fmt.Fprintf(
 				&report,
-				"* %s [Mem: %s, Pool: %s]%s",
-				vm.Name,
-				units.ByteSize(hostMemUsedBytes),
-				rpName,
+				"* None (visible); %d powered off%s",
+				numVMsPoweredOff,
 				nagios.CheckOutputEOL,
 			)
-		}
-
-	}
 
-	_, _ = fmt.Fprintf(
-		&report,
-		"%sTen VMs most recently powered on:%s%s",
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-	)
+		default:
 
-	switch {
-	case len(poweredVMs) == 0:
-		_, _ = fmt.Fprintf(
-			&report,
-			"* None (visible); %d powered off%s",
-			numVMsPoweredOff,
-			nagios.CheckOutputEOL,
-		)
+			sort.Slice(poweredVMs, func(i, j int) bool {
+				return poweredVMs[i].Summary.QuickStats.HostMemoryUsage > poweredVMs[j].Summary.QuickStats.HostMemoryUsage
+			})
 
-	default:
+			// grab up to the first 10 VMs, presorted by most memory usage
+			sampleSize := len(poweredVMs)
+			if sampleSize > 10 {
+				sampleSize = 10
+			}
 
-		sort.Slice(poweredVMs, func(i, j int) bool {
-			return poweredVMs[i].Summary.QuickStats.UptimeSeconds < poweredVMs[j].Summary.QuickStats.UptimeSeconds
-		})
+			for _, vm := range poweredVMs[:sampleSize] {
+				hostMemUsedBytes := int64(vm.Summary.QuickStats.HostMemoryUsage) * units.MB
+				rpName := rpIDtoNameIdx[vm.ResourcePool.Value]
+
+				_, _ = fmt.Fprintf(
+					&report,
+					"* %s [Mem: %s, Pool: %s]%s",
+					vm.Name,
+					units.ByteSize(hostMemUsedBytes),
+					rpName,
+					nagios.CheckOutputEOL,
+				)
+			}
 
-		// grab up to the first 10 VMs, presorted by least uptime
-		sampleSize := len(poweredVMs)
-		if sampleSize > 10 {
-			sampleSize = 10
 		}
+	}
 
-		for _, vm := range poweredVMs[:sampleSize] {
-			hostMemUsedBytes := int64(vm.Summary.QuickStats.HostMemoryUsage) * units.MB
-			uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
-			uptimeDays := uptime.Hours() / 24
-			rpName := rpIDtoNameIdx[vm.ResourcePool.Value]
+	if reportSectionEnabled(ReportSectionRecentlyStartedVMs) {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sTen VMs most recently powered on:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
 
+		switch {
+		case len(poweredVMs) == 0:
 			_, _ = fmt.Fprintf(
 				&report,
-				"* %s: [Uptime: %.2f days, Mem: %s, Pool: %s]%s",
-				vm.Name,
-				uptimeDays,
-				units.ByteSize(hostMemUsedBytes),
-				rpName,
+				"* None (visible); %d powered off%s",
+				numVMsPoweredOff,
 				nagios.CheckOutputEOL,
 			)
-		}
 
+		default:
+
+			sort.Slice(poweredVMs, func(i, j int) bool {
+				return poweredVMs[i].Summary.QuickStats.UptimeSeconds < poweredVMs[j].Summary.QuickStats.UptimeSeconds
+			})
+
+			// grab up to the first 10 VMs, presorted by least uptime
+			sampleSize := len(poweredVMs)
+			if sampleSize > 10 {
+				sampleSize = 10
+			}
+
+			for _, vm := range poweredVMs[:sampleSize] {
+				hostMemUsedBytes := int64(vm.Summary.QuickStats.HostMemoryUsage) * units.MB
+				uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+				uptimeDays := uptime.Hours() / 24
+				rpName := rpIDtoNameIdx[vm.ResourcePool.Value]
+
+				_, _ = fmt.Fprintf(
+					&report,
+					"* %s: [Uptime: %.2f days, Mem: %s, Pool: %s]%s",
+					vm.Name,
+					uptimeDays,
+					units.ByteSize(hostMemUsedBytes),
+					rpName,
+					nagios.CheckOutputEOL,
+				)
+			}
+
+		}
 	}
 
 	_, _ = fmt.Fprintf(
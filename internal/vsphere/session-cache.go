@@ -0,0 +1,91 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/session/cache"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// sessionCache holds the user-specified vSphere session caching settings.
+var sessionCache = struct {
+	enabled bool
+	dir     string
+}{}
+
+// SetSessionCache enables or disables caching of vSphere (SOAP) sessions
+// between plugin executions. Sessions are persisted to files (readable only
+// by the current user) in the specified directory keyed by server, username
+// and certificate trust setting. When enabled, Login reuses a valid cached
+// session (performing a new login if the cached session has expired) and
+// Logout leaves the session active for later reuse.
+func SetSessionCache(enabled bool, dir string) {
+	sessionCache.enabled = enabled
+	sessionCache.dir = dir
+
+	if enabled {
+		logger.Printf("session caching enabled using directory %q", dir)
+	}
+}
+
+// loginCachedSession reuses a valid cached session for the specified URL
+// (which includes user credentials) or performs a new login and caches the
+// resulting session.
+func loginCachedSession(
+	ctx context.Context,
+	u *url.URL,
+	trustCert bool,
+	userAgent string,
+	opts ConnectionOptions,
+) (*govmomi.Client, error) {
+
+	s := cache.Session{
+		URL:      u,
+		DirSOAP:  sessionCache.dir,
+		Insecure: trustCert,
+	}
+
+	vc := new(vim25.Client)
+	configureClient := func(sc *soap.Client) error {
+		sc.UserAgent = userAgent
+
+		if err := configureTLS(sc, trustCert, opts); err != nil {
+			return err
+		}
+
+		return configureProxy(sc, opts)
+	}
+
+	if err := s.Login(ctx, vc, configureClient); err != nil {
+		return nil, err
+	}
+
+	return &govmomi.Client{
+		Client:         vc,
+		SessionManager: session.NewManager(vc),
+	}, nil
+}
+
+// Logout logs out of the vSphere session for the given client unless session
+// caching is enabled, in which case the session is left active for reuse by
+// later plugin executions.
+func Logout(ctx context.Context, c *govmomi.Client) error {
+	if sessionCache.enabled {
+		logger.Printf("session caching enabled; skipping logout")
+
+		return nil
+	}
+
+	return c.Logout(ctx)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// newLogoutTestClient returns a client for a test SOAP endpoint which
+// answers Logout requests, along with a counter of the Logout requests
+// received.
+func newLogoutTestClient(t *testing.T) (*govmomi.Client, *int32) {
+	t.Helper()
+
+	var logouts int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "<Logout") {
+			atomic.AddInt32(&logouts, 1)
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">
+<soapenv:Body><LogoutResponse xmlns="urn:vim25"></LogoutResponse></soapenv:Body>
+</soapenv:Envelope>`)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL + "/sdk")
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+
+	sc := soap.NewClient(u, true)
+	vc := &vim25.Client{
+		Client:       sc,
+		RoundTripper: sc,
+		ServiceContent: types.ServiceContent{
+			SessionManager: &types.ManagedObjectReference{
+				Type:  "SessionManager",
+				Value: "SessionManager",
+			},
+		},
+	}
+
+	return &govmomi.Client{
+		Client:         vc,
+		SessionManager: session.NewManager(vc),
+	}, &logouts
+}
+
+func TestLogout(t *testing.T) {
+	defer SetSessionCache(false, "")
+
+	tests := map[string]struct {
+		cacheEnabled bool
+		wantLogouts  int32
+	}{
+		"session cache disabled": {cacheEnabled: false, wantLogouts: 1},
+		"session cache enabled":  {cacheEnabled: true, wantLogouts: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, logouts := newLogoutTestClient(t)
+
+			SetSessionCache(tt.cacheEnabled, t.TempDir())
+
+			if err := Logout(context.Background(), c); err != nil {
+				t.Fatalf("unexpected error logging out: %v", err)
+			}
+
+			if got := atomic.LoadInt32(logouts); got != tt.wantLogouts {
+				t.Errorf("want %d Logout requests; got %d", tt.wantLogouts, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+
+	"github.com/atc0005/check-vmware/internal/config"
+)
+
+// SetupPlugin applies the plugin-wide settings shared by all plugins (e.g.,
+// evaluation manifest, tracing, session caching) from the given
+// configuration. The returned cleanup function annotates errors with
+// remediation advice, applies user-specified output adjustments (state
+// mappings, summary template, name redaction) and records results (property
+// retrieval latency, traces, evaluation manifest) once the final plugin state
+// has been determined. Name redaction is applied before results are exported.
+// The cleanup function should be deferred immediately after calling
+// SetupPlugin.
+func SetupPlugin(cfg *config.Config, plugin *nagios.Plugin) (cleanup func()) {
+	SetEvaluationManifest(cfg.EvaluationManifest, cfg.App.Plugin, cfg.Server)
+
+	// Trace export is limited to the plugin runtime timeout.
+	SetTracing(cfg.OTLPEndpoint, cfg.App.Plugin, cfg.Server, time.Now().Add(cfg.Timeout()))
+
+	SetOmittedReportSections(cfg.OmitReportSections)
+	SetSessionCache(cfg.SessionCache, cfg.SessionCacheDir())
+	SetNameRedaction(cfg.RedactNames, cfg.RedactNamesLookupFile())
+
+	return func() {
+		AnnotatePropertyRetrieval(plugin, cfg.PropertyRetrievalWarning())
+		applyStateMap(plugin, cfg.StateMap)
+		ApplySummaryTemplate(plugin, cfg.SummaryTemplate)
+
+		// Errors are annotated before object names are redacted as redacted
+		// errors no longer match the errors used to select advice.
+		AnnotateError(plugin)
+		RedactNames(plugin)
+
+		ExportTraces(plugin)
+		WriteEvaluationManifest(plugin)
+	}
+}
+
+// SetupREST logs into the vSphere Automation (REST) API using the given
+// logged-in client and the credentials from the given configuration. If
+// login fails the plugin results are updated to note the failure and the
+// error is returned. The returned logout function should be deferred if
+// login succeeds.
+func SetupREST(
+	ctx context.Context,
+	c *vim25.Client,
+	cfg *config.Config,
+	plugin *nagios.Plugin,
+) (*rest.Client, func(), error) {
+
+	logger.Println("Logging into vSphere Automation API")
+	rc, err := LoginREST(ctx, c, cfg.Username, cfg.Domain, cfg.Password)
+	if err != nil {
+		plugin.AddError(err)
+		plugin.ServiceOutput = fmt.Sprintf(
+			"%s: Error logging into vSphere Automation API on %q",
+			nagios.StateCRITICALLabel,
+			cfg.Server,
+		)
+		plugin.ExitStatusCode = nagios.StateCRITICALExitCode
+
+		return nil, func() {}, err
+	}
+	logger.Println("Successfully logged into vSphere Automation API")
+
+	logout := func() {
+		if err := rc.Logout(ctx); err != nil {
+			logger.Printf("failed to logout of vSphere Automation API: %v", err)
+		}
+	}
+
+	return rc, logout, nil
+}
+
+// SetupTagging logs into the vSphere Automation (REST) API via SetupREST if
+// filtering by vSphere Tags was requested. The tagging service used for tag
+// filtering requires vCenter 6.5 or later; the /api endpoint is used for
+// vCenter 7.0 Update 2 or later and the /rest endpoint for earlier releases.
+// A nil client and a no-op logout function are returned if tag filtering was
+// not requested.
+func SetupTagging(
+	ctx context.Context,
+	c *vim25.Client,
+	cfg *config.Config,
+	plugin *nagios.Plugin,
+) (*rest.Client, func(), error) {
+
+	if len(cfg.IncludedTags) == 0 && len(cfg.ExcludedTags) == 0 {
+		return nil, func() {}, nil
+	}
+
+	return SetupREST(ctx, c, cfg, plugin)
+}
+
+// NewConnectionOptions returns the user-specified TLS and proxy settings
+// from the given configuration for use with Login.
+func NewConnectionOptions(cfg *config.Config) ConnectionOptions {
+	return ConnectionOptions{
+		CAFile:             cfg.CAFile,
+		TLSMinVersion:      cfg.TLSMinVersion,
+		SkipHostnameVerify: cfg.InsecureSkipHostnameVerify,
+		Proxy:              cfg.Proxy,
+		NoProxy:            cfg.NoProxy,
+	}
+}
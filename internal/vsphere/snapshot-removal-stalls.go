@@ -0,0 +1,423 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrSnapshotRemovalStalled indicates that one or more in-progress snapshot
+// removal or disk consolidation tasks have been running longer than a
+// specified threshold.
+var ErrSnapshotRemovalStalled = errors.New("snapshot removal tasks running longer than specified threshold")
+
+// snapshotRemovalTaskDescriptionIDs is the collection of task description
+// IDs for tasks which remove snapshots or consolidate (commit) snapshot
+// delta disks.
+var snapshotRemovalTaskDescriptionIDs = []string{
+	"VirtualMachineSnapshot.remove",
+	"VirtualMachine.removeAllSnapshots",
+	"VirtualMachine.consolidateDisks",
+}
+
+// SnapshotRemovalStallThresholds represents the user-specified thresholds
+// for how long an in-progress snapshot removal task may run.
+type SnapshotRemovalStallThresholds struct {
+	Warning  time.Duration
+	Critical time.Duration
+}
+
+// SnapshotRemovalTask is a summary of an in-progress snapshot removal or
+// disk consolidation task.
+type SnapshotRemovalTask struct {
+
+	// VMName is the name of the VirtualMachine the task is running against.
+	VMName string
+
+	// DescriptionID identifies the type of task (e.g.,
+	// VirtualMachine.consolidateDisks).
+	DescriptionID string
+
+	// Progress is the reported task completion percentage.
+	Progress int32
+
+	// Started is when the task started running (or was queued if a start
+	// time is not yet available).
+	Started time.Time
+
+	// Elapsed is how long the task has been running.
+	Elapsed time.Duration
+
+	// Initiator is the user, scheduled task or alarm which started the
+	// task.
+	Initiator string
+}
+
+// SnapshotRemovalStallsSummary is the result of evaluating in-progress
+// snapshot removal and disk consolidation tasks.
+type SnapshotRemovalStallsSummary struct {
+
+	// Tasks is the collection of in-progress snapshot removal tasks which
+	// were not excluded.
+	Tasks []SnapshotRemovalTask
+
+	// NumExcluded is the number of in-progress snapshot removal tasks
+	// excluded by request.
+	NumExcluded int
+
+	Thresholds SnapshotRemovalStallThresholds
+}
+
+// GetSnapshotRemovalTasks uses a task history collector to retrieve all
+// running snapshot removal and disk consolidation tasks.
+func GetSnapshotRemovalTasks(ctx context.Context, c *vim25.Client) ([]types.TaskInfo, error) {
+
+	funcTimeStart := time.Now()
+
+	var removalTasks []types.TaskInfo
+
+	defer func(tasks *[]types.TaskInfo) {
+		logger.Printf(
+			"It took %v to execute GetSnapshotRemovalTasks func (and retrieve %d tasks).\n",
+			time.Since(funcTimeStart),
+			len(*tasks),
+		)
+	}(&removalTasks)
+
+	filter := types.TaskFilterSpec{
+		State: []types.TaskInfoState{
+			types.TaskInfoStateRunning,
+		},
+	}
+
+	runningTasks, err := collectTasks(ctx, c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ti := range runningTasks {
+		if textutils.InList(ti.DescriptionId, snapshotRemovalTaskDescriptionIDs, true) {
+			removalTasks = append(removalTasks, ti)
+		}
+	}
+
+	return removalTasks, nil
+
+}
+
+// NewSnapshotRemovalStallsSummary evaluates the given in-progress snapshot
+// removal tasks, excluding tasks for the specified VMs, against the specified
+// thresholds. Elapsed time is calculated relative to the given time.
+func NewSnapshotRemovalStallsSummary(
+	tasks []types.TaskInfo,
+	ignoredVMs []string,
+	now time.Time,
+	thresholds SnapshotRemovalStallThresholds,
+) SnapshotRemovalStallsSummary {
+
+	summary := SnapshotRemovalStallsSummary{
+		Tasks:      make([]SnapshotRemovalTask, 0, len(tasks)),
+		Thresholds: thresholds,
+	}
+
+	for _, ti := range tasks {
+		if len(ignoredVMs) > 0 && inPatternList(ti.EntityName, ignoredVMs) {
+			summary.NumExcluded++
+
+			continue
+		}
+
+		started := ti.QueueTime
+		if ti.StartTime != nil {
+			started = *ti.StartTime
+		}
+
+		summary.Tasks = append(summary.Tasks, SnapshotRemovalTask{
+			VMName:        ti.EntityName,
+			DescriptionID: ti.DescriptionId,
+			Progress:      ti.Progress,
+			Started:       started,
+			Elapsed:       now.Sub(started),
+			Initiator:     TaskInitiator(ti),
+		})
+	}
+
+	// Longest running tasks first.
+	sort.Slice(summary.Tasks, func(i, j int) bool {
+		return summary.Tasks[i].Elapsed > summary.Tasks[j].Elapsed
+	})
+
+	return summary
+
+}
+
+// IsCriticalState indicates whether the task has been running longer than
+// the CRITICAL threshold.
+func (srt SnapshotRemovalTask) IsCriticalState(thresholds SnapshotRemovalStallThresholds) bool {
+	return srt.Elapsed > thresholds.Critical
+}
+
+// IsWarningState indicates whether the task has been running longer than
+// the WARNING threshold, but not the CRITICAL threshold.
+func (srt SnapshotRemovalTask) IsWarningState(thresholds SnapshotRemovalStallThresholds) bool {
+	return !srt.IsCriticalState(thresholds) && srt.Elapsed > thresholds.Warning
+}
+
+// NumCritical returns the number of tasks running longer than the CRITICAL
+// threshold.
+func (srs SnapshotRemovalStallsSummary) NumCritical() int {
+	var num int
+	for _, srt := range srs.Tasks {
+		if srt.IsCriticalState(srs.Thresholds) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of tasks running longer than the WARNING
+// threshold, but not the CRITICAL threshold.
+func (srs SnapshotRemovalStallsSummary) NumWarning() int {
+	var num int
+	for _, srt := range srs.Tasks {
+		if srt.IsWarningState(srs.Thresholds) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether any task has been running longer than
+// the CRITICAL threshold.
+func (srs SnapshotRemovalStallsSummary) IsCriticalState() bool {
+	return srs.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any task has been running longer than
+// the WARNING threshold, but no task has crossed the CRITICAL threshold.
+func (srs SnapshotRemovalStallsSummary) IsWarningState() bool {
+	return !srs.IsCriticalState() && srs.NumWarning() > 0
+}
+
+// MaxElapsed returns the longest elapsed time of all in-progress tasks.
+func (srs SnapshotRemovalStallsSummary) MaxElapsed() time.Duration {
+	var highest time.Duration
+	for _, srt := range srs.Tasks {
+		if srt.Elapsed > highest {
+			highest = srt.Elapsed
+		}
+	}
+
+	return highest
+}
+
+// SnapshotRemovalStallsPerfData generates performance data metrics from the
+// given snapshot removal stalls summary.
+func SnapshotRemovalStallsPerfData(srs SnapshotRemovalStallsSummary) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "snapshot_removal_tasks",
+			Value: fmt.Sprintf("%d", len(srs.Tasks)),
+			Min:   "0",
+		},
+		{
+			Label: "snapshot_removal_tasks_critical",
+			Value: fmt.Sprintf("%d", srs.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "snapshot_removal_tasks_warning",
+			Value: fmt.Sprintf("%d", srs.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "snapshot_removal_tasks_excluded",
+			Value: fmt.Sprintf("%d", srs.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label:             "snapshot_removal_max_elapsed",
+			Value:             fmt.Sprintf("%d", int64(srs.MaxElapsed().Seconds())),
+			UnitOfMeasurement: "s",
+			Warn:              fmt.Sprintf("%d", int64(srs.Thresholds.Warning.Seconds())),
+			Crit:              fmt.Sprintf("%d", int64(srs.Thresholds.Critical.Seconds())),
+			Min:               "0",
+		},
+	}
+}
+
+// SnapshotRemovalStallsOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func SnapshotRemovalStallsOneLineCheckSummary(
+	stateLabel string,
+	srs SnapshotRemovalStallsSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"srs": srs,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SnapshotRemovalStallsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case srs.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d snapshot removal tasks running longer than %v (%d in progress, %d excluded)",
+			stateLabel,
+			srs.NumCritical(),
+			srs.Thresholds.Critical,
+			len(srs.Tasks),
+			srs.NumExcluded,
+		)
+
+	case srs.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d snapshot removal tasks running longer than %v (%d in progress, %d excluded)",
+			stateLabel,
+			srs.NumWarning(),
+			srs.Thresholds.Warning,
+			len(srs.Tasks),
+			srs.NumExcluded,
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No snapshot removal tasks running longer than %v (%d in progress, %d excluded)",
+			stateLabel,
+			srs.Thresholds.Warning,
+			len(srs.Tasks),
+			srs.NumExcluded,
+		)
+	}
+}
+
+// SnapshotRemovalStallsReport generates a summary of in-progress snapshot
+// removal tasks along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func SnapshotRemovalStallsReport(
+	c *vim25.Client,
+	srs SnapshotRemovalStallsSummary,
+	ignoredVMs []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SnapshotRemovalStallsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"In-progress snapshot removal tasks:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, srt := range srs.Tasks {
+		var state string
+		switch {
+		case srt.IsCriticalState(srs.Thresholds):
+			state = nagios.StateCRITICALLabel
+		case srt.IsWarningState(srs.Thresholds):
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %s %d%% complete, running for %v (started: %s, initiated by: %s)%s",
+			srt.VMName,
+			state,
+			srt.DescriptionID,
+			srt.Progress,
+			srt.Elapsed.Truncate(time.Second),
+			srt.Started.Local().Format(time.RFC3339),
+			srt.Initiator,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(srs.Tasks) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Evaluated task types: [%v]%s",
+		strings.Join(snapshotRemovalTaskDescriptionIDs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Tasks excluded: %d%s",
+		srs.NumExcluded,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs to exclude (%d): [%v]%s",
+		len(ignoredVMs),
+		strings.Join(ignoredVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
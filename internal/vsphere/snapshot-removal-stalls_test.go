@@ -0,0 +1,143 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// snapshotRemovalTask returns a running snapshot removal task for the given
+// VM which started the given duration before now.
+func snapshotRemovalTask(vmName string, now time.Time, running time.Duration) types.TaskInfo {
+	started := now.Add(-running)
+
+	return types.TaskInfo{
+		EntityName:    vmName,
+		DescriptionId: "VirtualMachineSnapshot.remove",
+		State:         types.TaskInfoStateRunning,
+		QueueTime:     started.Add(-time.Minute),
+		StartTime:     &started,
+		Progress:      42,
+		Reason:        &types.TaskReasonUser{UserName: "VSPHERE.LOCAL\\backup"},
+	}
+}
+
+func TestNewSnapshotRemovalStallsSummary(t *testing.T) {
+	now := time.Now()
+
+	thresholds := SnapshotRemovalStallThresholds{
+		Warning:  30 * time.Minute,
+		Critical: 60 * time.Minute,
+	}
+
+	queued := snapshotRemovalTask("vm4", now, 0)
+	queued.StartTime = nil
+	queued.QueueTime = now.Add(-2 * time.Hour)
+	queued.Reason = &types.TaskReasonSystem{}
+
+	tasks := []types.TaskInfo{
+		snapshotRemovalTask("vm1", now, 5*time.Minute),
+		snapshotRemovalTask("vm2", now, 50*time.Minute),
+		snapshotRemovalTask("vm3", now, 90*time.Minute),
+		queued,
+	}
+
+	summary := NewSnapshotRemovalStallsSummary(tasks, []string{"VM3"}, now, thresholds)
+
+	want := []SnapshotRemovalTask{
+		{
+			VMName:        "vm4",
+			DescriptionID: "VirtualMachineSnapshot.remove",
+			Progress:      42,
+			Started:       queued.QueueTime,
+			Elapsed:       2 * time.Hour,
+			Initiator:     TaskInitiatorSystem,
+		},
+		{
+			VMName:        "vm2",
+			DescriptionID: "VirtualMachineSnapshot.remove",
+			Progress:      42,
+			Started:       now.Add(-50 * time.Minute),
+			Elapsed:       50 * time.Minute,
+			Initiator:     "VSPHERE.LOCAL\\backup",
+		},
+		{
+			VMName:        "vm1",
+			DescriptionID: "VirtualMachineSnapshot.remove",
+			Progress:      42,
+			Started:       now.Add(-5 * time.Minute),
+			Elapsed:       5 * time.Minute,
+			Initiator:     "VSPHERE.LOCAL\\backup",
+		},
+	}
+
+	if d := cmp.Diff(want, summary.Tasks); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if summary.NumExcluded != 1 {
+		t.Errorf("want 1 excluded task; got %d", summary.NumExcluded)
+	}
+
+	if got := summary.MaxElapsed(); got != 2*time.Hour {
+		t.Errorf("want max elapsed %v; got %v", 2*time.Hour, got)
+	}
+
+	if got := summary.NumCritical(); got != 1 {
+		t.Errorf("want 1 CRITICAL task; got %d", got)
+	}
+
+	if got := summary.NumWarning(); got != 1 {
+		t.Errorf("want 1 WARNING task; got %d", got)
+	}
+}
+
+func TestSnapshotRemovalStallsSummaryState(t *testing.T) {
+	thresholds := SnapshotRemovalStallThresholds{
+		Warning:  30 * time.Minute,
+		Critical: 60 * time.Minute,
+	}
+
+	tests := map[string]struct {
+		elapsed      []time.Duration
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no running tasks":              {},
+		"task below WARNING threshold":  {elapsed: []time.Duration{10 * time.Minute}},
+		"task at WARNING threshold":     {elapsed: []time.Duration{30 * time.Minute}},
+		"task above WARNING threshold":  {elapsed: []time.Duration{45 * time.Minute}, wantWarning: true},
+		"task at CRITICAL threshold":    {elapsed: []time.Duration{60 * time.Minute}, wantWarning: true},
+		"task above CRITICAL threshold": {elapsed: []time.Duration{61 * time.Minute}, wantCritical: true},
+		"tasks above WARNING and CRITICAL thresholds": {
+			elapsed:      []time.Duration{45 * time.Minute, 90 * time.Minute},
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := SnapshotRemovalStallsSummary{Thresholds: thresholds}
+			for _, elapsed := range tt.elapsed {
+				summary.Tasks = append(summary.Tasks, SnapshotRemovalTask{Elapsed: elapsed})
+			}
+
+			if got := summary.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := summary.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
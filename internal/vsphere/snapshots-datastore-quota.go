@@ -0,0 +1,499 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrSnapshotsDatastoreQuotaThresholdCrossed indicates that the cumulative
+// size of all snapshots stored on a datastore has exceeded a specified size
+// threshold.
+var ErrSnapshotsDatastoreQuotaThresholdCrossed = errors.New("datastore snapshots exceed specified size threshold")
+
+// SnapshotsDatastoreQuotaThresholds represents the user-specified cumulative
+// snapshot size thresholds (in GB) applied to each datastore.
+type SnapshotsDatastoreQuotaThresholds struct {
+	SizeWarning  int
+	SizeCritical int
+}
+
+// DatastoreSnapshotUsage tracks the cumulative size of all snapshots stored
+// on a specific datastore.
+type DatastoreSnapshotUsage struct {
+
+	// Datastore is the name of the datastore.
+	Datastore string
+
+	// Capacity is the maximum capacity of the datastore in bytes. This value
+	// is zero if the datastore could not be matched to a known datastore.
+	Capacity int64
+
+	// Size is the cumulative size of all snapshots stored on the datastore.
+	Size int64
+
+	// Snapshots is the number of snapshots stored on the datastore.
+	Snapshots int
+
+	// VMs is the collection of names for VirtualMachines with snapshots
+	// stored on the datastore.
+	VMs []string
+
+	Thresholds SnapshotsDatastoreQuotaThresholds
+}
+
+// DatastoreSnapshotUsageSet is a collection of DatastoreSnapshotUsage
+// values.
+type DatastoreSnapshotUsageSet []DatastoreSnapshotUsage
+
+// SizeHR returns the human readable cumulative size of all snapshots stored
+// on the datastore.
+func (dsu DatastoreSnapshotUsage) SizeHR() string {
+	return units.ByteSize(dsu.Size).String()
+}
+
+// CapacityPercent returns the percentage of the datastore's capacity
+// consumed by snapshots. Zero is returned if the datastore capacity is
+// unknown.
+func (dsu DatastoreSnapshotUsage) CapacityPercent() float64 {
+	if dsu.Capacity <= 0 {
+		return 0
+	}
+
+	return float64(dsu.Size) / float64(dsu.Capacity) * 100
+}
+
+// IsCriticalState indicates whether the cumulative size of snapshots stored
+// on the datastore has exceeded the CRITICAL threshold.
+func (dsu DatastoreSnapshotUsage) IsCriticalState() bool {
+	return ExceedsSize(dsu.Size, int64(dsu.Thresholds.SizeCritical))
+}
+
+// IsWarningState indicates whether the cumulative size of snapshots stored
+// on the datastore has exceeded the WARNING threshold, but not the CRITICAL
+// threshold.
+func (dsu DatastoreSnapshotUsage) IsWarningState() bool {
+	return !dsu.IsCriticalState() &&
+		ExceedsSize(dsu.Size, int64(dsu.Thresholds.SizeWarning))
+}
+
+// NewDatastoreSnapshotUsageSet aggregates the snapshots in the given
+// snapshot sets by the datastore where they are stored. All datastores in the
+// given collection are represented in the results (even those without
+// snapshots) unless excluded by the given lists of datastore names to include
+// or exclude. The number of datastores excluded is also returned.
+func NewDatastoreSnapshotUsageSet(
+	snapshotSets SnapshotSummarySets,
+	dss []mo.Datastore,
+	includedDatastores []string,
+	excludedDatastores []string,
+	thresholds SnapshotsDatastoreQuotaThresholds,
+) (DatastoreSnapshotUsageSet, int) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewDatastoreSnapshotUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	evaluate := func(dsName string) bool {
+		switch {
+		case len(includedDatastores) > 0:
+			return inPatternList(dsName, includedDatastores)
+		case len(excludedDatastores) > 0:
+			return !inPatternList(dsName, excludedDatastores)
+		default:
+			return true
+		}
+	}
+
+	index := make(map[string]*DatastoreSnapshotUsage, len(dss))
+	excluded := make(map[string]struct{})
+
+	for _, ds := range dss {
+		if !evaluate(ds.Name) {
+			excluded[ds.Name] = struct{}{}
+			continue
+		}
+
+		index[ds.Name] = &DatastoreSnapshotUsage{
+			Datastore:  ds.Name,
+			Capacity:   ds.Summary.Capacity,
+			Thresholds: thresholds,
+		}
+	}
+
+	for _, set := range snapshotSets {
+		for _, snap := range set.Snapshots {
+			if !evaluate(snap.DatastoreName) {
+				excluded[snap.DatastoreName] = struct{}{}
+				continue
+			}
+
+			dsu, ok := index[snap.DatastoreName]
+			if !ok {
+				dsu = &DatastoreSnapshotUsage{
+					Datastore:  snap.DatastoreName,
+					Thresholds: thresholds,
+				}
+				index[snap.DatastoreName] = dsu
+			}
+
+			dsu.Size += snap.Size
+			dsu.Snapshots++
+
+			if !textutils.InList(set.VMName, dsu.VMs, true) {
+				dsu.VMs = append(dsu.VMs, set.VMName)
+			}
+		}
+	}
+
+	usageSet := make(DatastoreSnapshotUsageSet, 0, len(index))
+	for _, dsu := range index {
+		sort.Strings(dsu.VMs)
+		usageSet = append(usageSet, *dsu)
+	}
+
+	// Largest snapshot consumers first.
+	sort.Slice(usageSet, func(i, j int) bool {
+		if usageSet[i].Size != usageSet[j].Size {
+			return usageSet[i].Size > usageSet[j].Size
+		}
+
+		return strings.ToLower(usageSet[i].Datastore) < strings.ToLower(usageSet[j].Datastore)
+	})
+
+	return usageSet, len(excluded)
+}
+
+// NumCritical returns the number of datastores with snapshots exceeding the
+// CRITICAL threshold.
+func (set DatastoreSnapshotUsageSet) NumCritical() int {
+	var num int
+	for _, dsu := range set {
+		if dsu.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores with snapshots exceeding the
+// WARNING threshold, but not the CRITICAL threshold.
+func (set DatastoreSnapshotUsageSet) NumWarning() int {
+	var num int
+	for _, dsu := range set {
+		if dsu.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWithSnapshots returns the number of datastores with at least one
+// snapshot stored on them.
+func (set DatastoreSnapshotUsageSet) NumWithSnapshots() int {
+	var num int
+	for _, dsu := range set {
+		if dsu.Snapshots > 0 {
+			num++
+		}
+	}
+
+	return num
+}
+
+// Snapshots returns the total number of snapshots across all datastores in
+// the set.
+func (set DatastoreSnapshotUsageSet) Snapshots() int {
+	var num int
+	for _, dsu := range set {
+		num += dsu.Snapshots
+	}
+
+	return num
+}
+
+// Size returns the cumulative size of all snapshots across all datastores in
+// the set.
+func (set DatastoreSnapshotUsageSet) Size() int64 {
+	var sum int64
+	for _, dsu := range set {
+		sum += dsu.Size
+	}
+
+	return sum
+}
+
+// IsCriticalState indicates whether any datastore in the set has snapshots
+// exceeding the CRITICAL threshold.
+func (set DatastoreSnapshotUsageSet) IsCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any datastore in the set has snapshots
+// exceeding the WARNING threshold.
+func (set DatastoreSnapshotUsageSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// SnapshotsDatastoreQuotaPerfData generates performance data metrics from
+// the given collection of per-datastore snapshot usage values. Aggregate
+// metrics are emitted along with snapshot size metrics for each datastore.
+func SnapshotsDatastoreQuotaPerfData(set DatastoreSnapshotUsageSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_with_snapshots",
+			Value: fmt.Sprintf("%d", set.NumWithSnapshots()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "snapshots",
+			Value: fmt.Sprintf("%d", set.Snapshots()),
+			Min:   "0",
+		},
+		{
+			Label:             "snapshots_size",
+			Value:             fmt.Sprintf("%d", set.Size()),
+			UnitOfMeasurement: "B",
+			Min:               "0",
+		},
+	}
+
+	for _, dsu := range set {
+		// Capacity is unknown for datastores which could not be matched.
+		var capacity string
+		if dsu.Capacity > 0 {
+			capacity = fmt.Sprintf("%d", dsu.Capacity)
+		}
+
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(dsu.Datastore, "snapshots_size"),
+				Value:             fmt.Sprintf("%d", dsu.Size),
+				UnitOfMeasurement: "B",
+				Warn:              fmt.Sprintf("%d", int64(dsu.Thresholds.SizeWarning)*units.GB),
+				Crit:              fmt.Sprintf("%d", int64(dsu.Thresholds.SizeCritical)*units.GB),
+				Min:               "0",
+				Max:               capacity,
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(dsu.Datastore, "snapshots"),
+				Value: fmt.Sprintf("%d", dsu.Snapshots),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// SnapshotsDatastoreQuotaOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func SnapshotsDatastoreQuotaOneLineCheckSummary(
+	stateLabel string,
+	set DatastoreSnapshotUsageSet,
+	thresholds SnapshotsDatastoreQuotaThresholds,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set":        set,
+		"thresholds": thresholds,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SnapshotsDatastoreQuotaOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState():
+		return fmt.Sprintf(
+			"%s: %d datastores with combined snapshots exceeding %d %s detected (evaluated %d datastores, %d Snapshots)",
+			stateLabel,
+			set.NumCritical(),
+			thresholds.SizeCritical,
+			snapshotThresholdTypeSizeSuffix,
+			len(set),
+			set.Snapshots(),
+		)
+
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d datastores with combined snapshots exceeding %d %s detected (evaluated %d datastores, %d Snapshots)",
+			stateLabel,
+			set.NumWarning(),
+			thresholds.SizeWarning,
+			snapshotThresholdTypeSizeSuffix,
+			len(set),
+			set.Snapshots(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No datastores with combined snapshots exceeding %d %s detected (evaluated %d datastores, %d Snapshots)",
+			stateLabel,
+			thresholds.SizeWarning,
+			snapshotThresholdTypeSizeSuffix,
+			len(set),
+			set.Snapshots(),
+		)
+	}
+}
+
+// SnapshotsDatastoreQuotaReport generates a summary of per-datastore
+// snapshot usage along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func SnapshotsDatastoreQuotaReport(
+	c *vim25.Client,
+	set DatastoreSnapshotUsageSet,
+	includedDatastores []string,
+	excludedDatastores []string,
+	numDatastoresExcluded int,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute SnapshotsDatastoreQuotaReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if set.NumWithSnapshots() == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No snapshots found on evaluated datastores%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, dsu := range set {
+		if dsu.Snapshots == 0 {
+			continue
+		}
+
+		var state string
+		switch {
+		case dsu.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case dsu.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		capacity := "unknown"
+		if dsu.Capacity > 0 {
+			capacity = fmt.Sprintf(
+				"%.2f%% of %s",
+				dsu.CapacityPercent(),
+				units.ByteSize(dsu.Capacity),
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %d snapshots, %s (%s)%s",
+			dsu.Datastore,
+			state,
+			dsu.Snapshots,
+			dsu.SizeHR(),
+			capacity,
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"** VMs (%d): %s%s",
+			len(dsu.VMs),
+			strings.Join(dsu.VMs, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores evaluated: %d (%d excluded)%s",
+		len(set),
+		numDatastoresExcluded,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly include (%d): [%v]%s",
+		len(includedDatastores),
+		strings.Join(includedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified Datastores to explicitly exclude (%d): [%v]%s",
+		len(excludedDatastores),
+		strings.Join(excludedDatastores, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// quotaDatastore returns a datastore with the given name and capacity (GB).
+func quotaDatastore(name string, capacityGB int64) mo.Datastore {
+	var ds mo.Datastore
+	ds.Name = name
+	ds.Summary.Name = name
+	ds.Summary.Capacity = capacityGB * units.GB
+
+	return ds
+}
+
+// quotaSnapshotSet returns a snapshot set for the given VM with one snapshot
+// of the given size (GB) stored on each of the given datastores.
+func quotaSnapshotSet(vmName string, sizeGB int64, datastores ...string) SnapshotSummarySet {
+	set := SnapshotSummarySet{VMName: vmName}
+	for _, ds := range datastores {
+		set.Snapshots = append(set.Snapshots, SnapshotSummary{
+			Name:          "snap-" + ds,
+			VMName:        vmName,
+			DatastoreName: ds,
+			Size:          sizeGB * units.GB,
+		})
+	}
+
+	return set
+}
+
+func TestNewDatastoreSnapshotUsageSet(t *testing.T) {
+	thresholds := SnapshotsDatastoreQuotaThresholds{
+		SizeWarning:  10,
+		SizeCritical: 20,
+	}
+
+	dss := []mo.Datastore{
+		quotaDatastore("ds1", 1000),
+		quotaDatastore("ds2", 1000),
+		quotaDatastore("Archive", 500),
+	}
+
+	snapshotSets := SnapshotSummarySets{
+		quotaSnapshotSet("vm2", 6, "ds1"),
+		quotaSnapshotSet("vm1", 6, "ds1", "ds2"),
+		quotaSnapshotSet("vm1", 2, "ds1"),
+		quotaSnapshotSet("vm3", 25, "ds3"),
+	}
+
+	tests := map[string]struct {
+		include      []string
+		exclude      []string
+		want         DatastoreSnapshotUsageSet
+		wantExcluded int
+	}{
+		"all datastores": {
+			want: DatastoreSnapshotUsageSet{
+				{Datastore: "ds3", Size: 25 * units.GB, Snapshots: 1, VMs: []string{"vm3"}, Thresholds: thresholds},
+				{Datastore: "ds1", Capacity: 1000 * units.GB, Size: 14 * units.GB, Snapshots: 3, VMs: []string{"vm1", "vm2"}, Thresholds: thresholds},
+				{Datastore: "ds2", Capacity: 1000 * units.GB, Size: 6 * units.GB, Snapshots: 1, VMs: []string{"vm1"}, Thresholds: thresholds},
+				{Datastore: "Archive", Capacity: 500 * units.GB, Thresholds: thresholds},
+			},
+		},
+		"excluded datastores": {
+			exclude: []string{"DS3", "archive"},
+			want: DatastoreSnapshotUsageSet{
+				{Datastore: "ds1", Capacity: 1000 * units.GB, Size: 14 * units.GB, Snapshots: 3, VMs: []string{"vm1", "vm2"}, Thresholds: thresholds},
+				{Datastore: "ds2", Capacity: 1000 * units.GB, Size: 6 * units.GB, Snapshots: 1, VMs: []string{"vm1"}, Thresholds: thresholds},
+			},
+			wantExcluded: 2,
+		},
+		"included datastores": {
+			include: []string{"ds2"},
+			want: DatastoreSnapshotUsageSet{
+				{Datastore: "ds2", Capacity: 1000 * units.GB, Size: 6 * units.GB, Snapshots: 1, VMs: []string{"vm1"}, Thresholds: thresholds},
+			},
+			wantExcluded: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, numExcluded := NewDatastoreSnapshotUsageSet(
+				snapshotSets,
+				dss,
+				tt.include,
+				tt.exclude,
+				thresholds,
+			)
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded datastores; got %d", tt.wantExcluded, numExcluded)
+			}
+		})
+	}
+}
+
+func TestDatastoreSnapshotUsageState(t *testing.T) {
+	thresholds := SnapshotsDatastoreQuotaThresholds{
+		SizeWarning:  10,
+		SizeCritical: 20,
+	}
+
+	tests := map[string]struct {
+		sizeGB       int64
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no snapshots":                  {},
+		"below WARNING threshold":       {sizeGB: 8},
+		"at WARNING threshold":          {sizeGB: 10},
+		"above WARNING threshold":       {sizeGB: 12, wantWarning: true},
+		"at CRITICAL threshold":         {sizeGB: 20, wantWarning: true},
+		"above CRITICAL threshold":      {sizeGB: 24, wantCritical: true},
+		"well above CRITICAL threshold": {sizeGB: 500, wantCritical: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dsu := DatastoreSnapshotUsage{Size: tt.sizeGB * units.GB, Thresholds: thresholds}
+			set := DatastoreSnapshotUsageSet{dsu}
+
+			if got := set.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestDatastoreSnapshotUsageSetTotals(t *testing.T) {
+	usageSet, _ := NewDatastoreSnapshotUsageSet(
+		SnapshotSummarySets{
+			quotaSnapshotSet("vm1", 5, "ds1"),
+			quotaSnapshotSet("vm2", 20, "ds1"),
+		},
+		[]mo.Datastore{
+			quotaDatastore("ds1", 100),
+			quotaDatastore("ds2", 100),
+		},
+		nil,
+		nil,
+		SnapshotsDatastoreQuotaThresholds{SizeWarning: 50, SizeCritical: 60},
+	)
+
+	if got := usageSet.NumWithSnapshots(); got != 1 {
+		t.Errorf("want 1 datastore with snapshots; got %d", got)
+	}
+
+	if got := usageSet.Snapshots(); got != 2 {
+		t.Errorf("want 2 snapshots; got %d", got)
+	}
+
+	if got := usageSet.Size(); got != 25*units.GB {
+		t.Errorf("want %d bytes; got %d", 25*units.GB, got)
+	}
+
+	if got := usageSet[0].CapacityPercent(); math.Abs(got-25) > 0.01 {
+		t.Errorf("want 25%% of ds1 capacity used by snapshots; got %.2f%%", got)
+	}
+
+	if got := (DatastoreSnapshotUsage{Size: units.GB}).CapacityPercent(); got != 0 {
+		t.Errorf("want 0%% capacity used for unknown datastore; got %.2f%%", got)
+	}
+}
@@ -23,6 +23,11 @@ var ErrSnapshotAgeThresholdCrossed = errors.New("snapshot exceeds specified age
 // VM has exceeded a specified count threshold.
 var ErrSnapshotCountThresholdCrossed = errors.New("snapshots exceed specified count threshold")
 
+// ErrSnapshotTotalCountThresholdCrossed indicates that the total number of
+// snapshots across all evaluated VMs has exceeded a specified count
+// threshold.
+var ErrSnapshotTotalCountThresholdCrossed = errors.New("snapshots exceed specified total count threshold")
+
 // ErrSnapshotSizeThresholdCrossed indicates that a snapshot is larger than a
 // specified size threshold
 var ErrSnapshotSizeThresholdCrossed = errors.New("snapshot exceeds specified size threshold")
@@ -76,6 +81,12 @@ type SnapshotThresholds struct {
 	SizeWarning   int
 	CountCritical int
 	CountWarning  int
+
+	// TotalCountCritical and TotalCountWarning are the optional aggregate
+	// snapshot count thresholds applied to all evaluated VMs. Aggregate
+	// evaluation is disabled if these values are not set.
+	TotalCountCritical int
+	TotalCountWarning  int
 }
 
 // SnapshotSummary is intended to be a summary of the most commonly used
@@ -284,6 +295,87 @@ func (sss SnapshotSummarySets) FilterByCount(count int) SnapshotSummarySets {
 	return sets
 }
 
+// FilterByNameOrDescription returns a SnapshotSummarySets value containing
+// only snapshots with a name or description matching one of the specified
+// include values (if any) and not matching any of the specified exclude
+// values. Values are matched as case-insensitive substrings unless glob or
+// regex pattern matching is enabled. Explicit exclusions have precedence over
+// explicit inclusions. Sets without remaining snapshots are omitted. The
+// number of snapshots excluded is also returned.
+func (sss SnapshotSummarySets) FilterByNameOrDescription(include []string, exclude []string) (SnapshotSummarySets, int) {
+
+	if len(include) == 0 && len(exclude) == 0 {
+		return sss, 0
+	}
+
+	var sets SnapshotSummarySets
+	var numExcluded int
+
+	for _, set := range sss {
+		snapshots := make([]SnapshotSummary, 0, len(set.Snapshots))
+
+		for _, snapshot := range set.Snapshots {
+			switch {
+			case len(exclude) > 0 && snapshot.matchesNameOrDescription(exclude):
+				logger.Printf(
+					"snapshot %q for VM %s explicitly excluded by name or description",
+					snapshot.Name,
+					snapshot.VMName,
+				)
+				numExcluded++
+
+			case len(include) > 0 && !snapshot.matchesNameOrDescription(include):
+				logger.Printf(
+					"snapshot %q for VM %s not explicitly included by name or description",
+					snapshot.Name,
+					snapshot.VMName,
+				)
+				numExcluded++
+
+			default:
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+
+		if len(snapshots) > 0 {
+			sets = append(sets, set.withSnapshots(snapshots))
+		}
+	}
+
+	return sets, numExcluded
+}
+
+// matchesNameOrDescription indicates whether the snapshot name or
+// description matches any of the given filter values.
+func (ss SnapshotSummary) matchesNameOrDescription(filters []string) bool {
+	for _, filter := range filters {
+		if matchesSubstringFilter(ss.Name, filter) ||
+			matchesSubstringFilter(ss.Description, filter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withSnapshots returns a copy of the SnapshotSummarySet containing only the
+// given snapshots. Set level threshold states are recalculated for the given
+// snapshots.
+func (sss SnapshotSummarySet) withSnapshots(snapshots []SnapshotSummary) SnapshotSummarySet {
+	var setSize int64
+	for _, snap := range snapshots {
+		setSize += snap.Size
+	}
+
+	sss.Snapshots = snapshots
+	sss.setSizeWarningThresholdCrossed = ExceedsSize(setSize, int64(sss.thresholds.SizeWarning))
+	sss.setSizeCriticalThresholdCrossed = ExceedsSize(setSize, int64(sss.thresholds.SizeCritical))
+	sss.setCountWarningThresholdCrossed = len(snapshots) > sss.thresholds.CountWarning
+	sss.setCountCriticalThresholdCrossed = len(snapshots) > sss.thresholds.CountCritical
+
+	return sss
+}
+
 // ExceedsSize indicates how many sets and number of snapshots from all of
 // those sets have cumulative snapshots larger than the specified size in GB.
 func (sss SnapshotSummarySets) ExceedsSize(sizeGB int) (int, int) {
@@ -571,6 +663,43 @@ func (sss SnapshotSummarySets) IsCountWarningState() bool {
 	return false
 }
 
+// IsTotalCountWarningState indicates whether the total number of snapshots
+// across all snapshot sets has exceeded the given aggregate count WARNING
+// threshold, but NOT the aggregate count CRITICAL threshold. False is
+// returned if aggregate thresholds are not set.
+func (sss SnapshotSummarySets) IsTotalCountWarningState(thresholds SnapshotThresholds) bool {
+	if thresholds.TotalCountWarning < 1 {
+		return false
+	}
+
+	return sss.Snapshots() > thresholds.TotalCountWarning &&
+		!sss.IsTotalCountCriticalState(thresholds)
+}
+
+// IsTotalCountCriticalState indicates whether the total number of snapshots
+// across all snapshot sets has exceeded the given aggregate count CRITICAL
+// threshold. False is returned if aggregate thresholds are not set.
+func (sss SnapshotSummarySets) IsTotalCountCriticalState(thresholds SnapshotThresholds) bool {
+	if thresholds.TotalCountCritical < 1 {
+		return false
+	}
+
+	return sss.Snapshots() > thresholds.TotalCountCritical
+}
+
+// MaxSnapshotsPerVM returns the highest number of snapshots for any single
+// VirtualMachine in the snapshot sets.
+func (sss SnapshotSummarySets) MaxSnapshotsPerVM() int {
+	var highest int
+	for i := range sss {
+		if len(sss[i].Snapshots) > highest {
+			highest = len(sss[i].Snapshots)
+		}
+	}
+
+	return highest
+}
+
 // IsCountCriticalState indicates whether the snapshot sets have exceeded the
 // count CRITICAL threshold.
 func (sss SnapshotSummarySets) IsCountCriticalState() bool {
@@ -1200,6 +1329,12 @@ func SnapshotsAgeOneLineCheckSummary(
 	vmsFilterResults VMsFilterResults,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"snapshotSets":       snapshotSets,
+		"snapshotThresholds": snapshotThresholds,
+		"vmsFilterResults":   vmsFilterResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1265,6 +1400,12 @@ func SnapshotsCountOneLineCheckSummary(
 	vmsFilterResults VMsFilterResults,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"snapshotSets":       snapshotSets,
+		"snapshotThresholds": snapshotThresholds,
+		"vmsFilterResults":   vmsFilterResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1291,6 +1432,18 @@ func SnapshotsCountOneLineCheckSummary(
 			vmsFilterResults.NumRPsAfterFiltering(),
 		)
 
+	case snapshotSets.IsTotalCountCriticalState(snapshotThresholds):
+
+		return fmt.Sprintf(
+			"%s: %d total snapshots exceeds aggregate threshold of %d (evaluated %d VMs, %d Snapshots, %d Resource Pools)",
+			stateLabel,
+			snapshotSets.Snapshots(),
+			snapshotThresholds.TotalCountCritical,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			snapshotSets.Snapshots(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
 	case snapshotSets.IsCountWarningState():
 
 		vms, snapsExcess, _ := snapshotSets.ExcessSnapshots(snapshotThresholds.CountWarning)
@@ -1306,6 +1459,18 @@ func SnapshotsCountOneLineCheckSummary(
 			vmsFilterResults.NumRPsAfterFiltering(),
 		)
 
+	case snapshotSets.IsTotalCountWarningState(snapshotThresholds):
+
+		return fmt.Sprintf(
+			"%s: %d total snapshots exceeds aggregate threshold of %d (evaluated %d VMs, %d Snapshots, %d Resource Pools)",
+			stateLabel,
+			snapshotSets.Snapshots(),
+			snapshotThresholds.TotalCountWarning,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			snapshotSets.Snapshots(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
 	default:
 
 		return fmt.Sprintf(
@@ -1330,6 +1495,12 @@ func SnapshotsSizeOneLineCheckSummary(
 	vmsFilterResults VMsFilterResults,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"snapshotSets":       snapshotSets,
+		"snapshotThresholds": snapshotThresholds,
+		"vmsFilterResults":   vmsFilterResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -1618,13 +1789,18 @@ func writeSnapshotsListEntries(
 // various verbose details intended to aid in troubleshooting check results at
 // a glance. This information is provided for use with the Long Service Output
 // field commonly displayed on the detailed service check results display in
-// the web UI or in the body of many notifications.
+// the web UI or in the body of many notifications. Snapshot name or
+// description filters (if any) are noted along with the number of snapshots
+// excluded by them.
 func SnapshotsAgeReport(
 	c *vim25.Client,
 	snapshotSummarySets SnapshotSummarySets,
 	snapshotThresholds SnapshotThresholds,
 	vmsFilterOptions VMsFilterOptions,
 	vmsFilterResults VMsFilterResults,
+	snapshotsIncluded []string,
+	snapshotsExcluded []string,
+	numSnapshotsExcluded int,
 ) string {
 
 	funcTimeStart := time.Now()
@@ -1655,6 +1831,31 @@ func SnapshotsAgeReport(
 		true,
 	)
 
+	if len(snapshotsIncluded) > 0 || len(snapshotsExcluded) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Specified snapshot names or descriptions to explicitly include (%d): [%v]%s",
+			len(snapshotsIncluded),
+			strings.Join(snapshotsIncluded, ", "),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Specified snapshot names or descriptions to explicitly exclude (%d): [%v]%s",
+			len(snapshotsExcluded),
+			strings.Join(snapshotsExcluded, ", "),
+			nagios.CheckOutputEOL,
+		)
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Snapshots excluded by name or description: %d%s",
+			numSnapshotsExcluded,
+			nagios.CheckOutputEOL,
+		)
+	}
+
 	return report.String()
 }
 
@@ -1736,6 +1937,17 @@ func SnapshotsCountReport(
 		snapshotSummarySets,
 	)
 
+	if snapshotThresholds.TotalCountWarning > 0 || snapshotThresholds.TotalCountCritical > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"Total snapshots: %d (aggregate thresholds: %d WARNING, %d CRITICAL)%s",
+			snapshotSummarySets.Snapshots(),
+			snapshotThresholds.TotalCountWarning,
+			snapshotThresholds.TotalCountCritical,
+			nagios.CheckOutputEOL,
+		)
+	}
+
 	vmFilterResultsReportTrailer(
 		&report,
 		c,
@@ -0,0 +1,318 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+)
+
+// namedSnapshotSet returns a SnapshotSummarySet for the given VM with one 1
+// GB snapshot for each given name and description pair.
+func namedSnapshotSet(vmName string, thresholds SnapshotThresholds, namesAndDescriptions ...[2]string) SnapshotSummarySet {
+	set := SnapshotSummarySet{
+		VMName:     vmName,
+		thresholds: thresholds,
+	}
+
+	for _, nd := range namesAndDescriptions {
+		set.Snapshots = append(set.Snapshots, SnapshotSummary{
+			Name:        nd[0],
+			Description: nd[1],
+			VMName:      vmName,
+			Size:        units.GB,
+		})
+	}
+
+	return set
+}
+
+// snapshotSetNames returns the snapshot names for each set in the given
+// collection indexed by VM name.
+func snapshotSetNames(sss SnapshotSummarySets) map[string][]string {
+	names := make(map[string][]string, len(sss))
+	for _, set := range sss {
+		for _, snap := range set.Snapshots {
+			names[set.VMName] = append(names[set.VMName], snap.Name)
+		}
+	}
+
+	return names
+}
+
+func TestSnapshotSummarySetsFilterByNameOrDescription(t *testing.T) {
+	sets := SnapshotSummarySets{
+		namedSnapshotSet(
+			"vm1", SnapshotThresholds{},
+			[2]string{"VEEAM BACKUP TEMPORARY SNAPSHOT", "Please do not delete"},
+			[2]string{"pre-upgrade", "Before patching"},
+		),
+		namedSnapshotSet(
+			"vm2", SnapshotThresholds{},
+			[2]string{"nightly", "Created by Veeam"},
+		),
+		namedSnapshotSet(
+			"vm3", SnapshotThresholds{},
+			[2]string{"pre-upgrade", ""},
+			[2]string{"app-release", "release 1.2"},
+		),
+	}
+
+	tests := map[string]struct {
+		mode         string
+		include      []string
+		exclude      []string
+		want         map[string][]string
+		wantExcluded int
+	}{
+		"no filters": {
+			want: map[string][]string{
+				"vm1": {"VEEAM BACKUP TEMPORARY SNAPSHOT", "pre-upgrade"},
+				"vm2": {"nightly"},
+				"vm3": {"pre-upgrade", "app-release"},
+			},
+		},
+		"exclude by name or description": {
+			exclude: []string{"veeam"},
+			want: map[string][]string{
+				"vm1": {"pre-upgrade"},
+				"vm3": {"pre-upgrade", "app-release"},
+			},
+			wantExcluded: 2,
+		},
+		"include by name": {
+			include: []string{"pre-upgrade"},
+			want: map[string][]string{
+				"vm1": {"pre-upgrade"},
+				"vm3": {"pre-upgrade"},
+			},
+			wantExcluded: 3,
+		},
+		"include by description": {
+			include: []string{"RELEASE"},
+			want: map[string][]string{
+				"vm3": {"app-release"},
+			},
+			wantExcluded: 4,
+		},
+		"exclude has precedence over include": {
+			include: []string{"pre-upgrade", "nightly"},
+			exclude: []string{"patching"},
+			want: map[string][]string{
+				"vm2": {"nightly"},
+				"vm3": {"pre-upgrade"},
+			},
+			wantExcluded: 3,
+		},
+		"glob exclude": {
+			mode:    textutils.PatternMatchGlob,
+			exclude: []string{"VEEAM*"},
+			want: map[string][]string{
+				"vm1": {"pre-upgrade"},
+				"vm2": {"nightly"},
+				"vm3": {"pre-upgrade", "app-release"},
+			},
+			wantExcluded: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.mode != "" {
+				setTestPatternMatchMode(t, tt.mode)
+			}
+
+			got, numExcluded := sets.FilterByNameOrDescription(tt.include, tt.exclude)
+
+			if d := cmp.Diff(tt.want, snapshotSetNames(got)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded snapshots; got %d", tt.wantExcluded, numExcluded)
+			}
+		})
+	}
+}
+
+func TestSnapshotSummarySetsFilterByNameOrDescriptionRecalculatesState(t *testing.T) {
+	thresholds := SnapshotThresholds{
+		SizeWarning:   1,
+		SizeCritical:  2,
+		CountWarning:  1,
+		CountCritical: 2,
+	}
+
+	set := namedSnapshotSet(
+		"vm1", thresholds,
+		[2]string{"VEEAM BACKUP TEMPORARY SNAPSHOT", ""},
+		[2]string{"VEEAM BACKUP TEMPORARY SNAPSHOT 2", ""},
+		[2]string{"pre-upgrade", ""},
+	)
+	set.setSizeWarningThresholdCrossed = true
+	set.setSizeCriticalThresholdCrossed = true
+	set.setCountWarningThresholdCrossed = true
+	set.setCountCriticalThresholdCrossed = true
+
+	got, _ := SnapshotSummarySets{set}.FilterByNameOrDescription(nil, []string{"veeam"})
+	if len(got) != 1 {
+		t.Fatalf("want 1 snapshot set; got %d", len(got))
+	}
+
+	if got[0].setSizeWarningThresholdCrossed || got[0].setSizeCriticalThresholdCrossed {
+		t.Errorf("want size thresholds not crossed for 1 GB of remaining snapshots")
+	}
+
+	if got[0].setCountWarningThresholdCrossed || got[0].setCountCriticalThresholdCrossed {
+		t.Errorf("want count thresholds not crossed for 1 remaining snapshot")
+	}
+
+	if !set.setCountCriticalThresholdCrossed {
+		t.Errorf("want original snapshot set state unmodified")
+	}
+}
+
+// countedSnapshotSets returns a collection of SnapshotSummarySets with the
+// given number of snapshots for each VM.
+func countedSnapshotSets(counts ...int) SnapshotSummarySets {
+	sets := make(SnapshotSummarySets, 0, len(counts))
+	for i, count := range counts {
+		vmName := fmt.Sprintf("vm%d", i+1)
+		set := SnapshotSummarySet{VMName: vmName}
+		for j := 0; j < count; j++ {
+			set.Snapshots = append(set.Snapshots, SnapshotSummary{
+				Name:   fmt.Sprintf("snap%d", j+1),
+				VMName: vmName,
+			})
+		}
+		sets = append(sets, set)
+	}
+
+	return sets
+}
+
+func TestSnapshotSummarySetsTotalCountState(t *testing.T) {
+	thresholds := SnapshotThresholds{
+		TotalCountWarning:  5,
+		TotalCountCritical: 10,
+	}
+
+	tests := map[string]struct {
+		sets         SnapshotSummarySets
+		thresholds   SnapshotThresholds
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below warning threshold": {
+			sets:       countedSnapshotSets(2, 2),
+			thresholds: thresholds,
+		},
+		"equal to warning threshold": {
+			sets:       countedSnapshotSets(2, 3),
+			thresholds: thresholds,
+		},
+		"above warning threshold": {
+			sets:        countedSnapshotSets(2, 2, 2),
+			thresholds:  thresholds,
+			wantWarning: true,
+		},
+		"equal to critical threshold": {
+			sets:        countedSnapshotSets(5, 5),
+			thresholds:  thresholds,
+			wantWarning: true,
+		},
+		"above critical threshold": {
+			sets:         countedSnapshotSets(1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1),
+			thresholds:   thresholds,
+			wantCritical: true,
+		},
+		"aggregate thresholds not set": {
+			sets:       countedSnapshotSets(50, 50),
+			thresholds: SnapshotThresholds{CountWarning: 1, CountCritical: 2},
+		},
+		"no snapshot sets": {
+			sets:       SnapshotSummarySets{},
+			thresholds: thresholds,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.sets.IsTotalCountCriticalState(tt.thresholds); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := tt.sets.IsTotalCountWarningState(tt.thresholds); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestSnapshotSummarySetsMaxSnapshotsPerVM(t *testing.T) {
+	tests := map[string]struct {
+		sets SnapshotSummarySets
+		want int
+	}{
+		"no snapshot sets": {sets: SnapshotSummarySets{}, want: 0},
+		"single VM":        {sets: countedSnapshotSets(3), want: 3},
+		"multiple VMs":     {sets: countedSnapshotSets(2, 7, 4), want: 7},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.sets.MaxSnapshotsPerVM(); got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSnapshotsCountOneLineCheckSummaryTotalCount(t *testing.T) {
+	thresholds := SnapshotThresholds{
+		CountWarning:       25,
+		CountCritical:      50,
+		TotalCountWarning:  5,
+		TotalCountCritical: 10,
+	}
+
+	tests := map[string]struct {
+		stateLabel string
+		sets       SnapshotSummarySets
+		want       string
+	}{
+		"total count critical": {
+			stateLabel: "CRITICAL",
+			sets:       countedSnapshotSets(6, 6),
+			want:       "CRITICAL: 12 total snapshots exceeds aggregate threshold of 10 (evaluated 0 VMs, 12 Snapshots, 0 Resource Pools)",
+		},
+		"total count warning": {
+			stateLabel: "WARNING",
+			sets:       countedSnapshotSets(3, 3),
+			want:       "WARNING: 6 total snapshots exceeds aggregate threshold of 5 (evaluated 0 VMs, 6 Snapshots, 0 Resource Pools)",
+		},
+		"below total count thresholds": {
+			stateLabel: "OK",
+			sets:       countedSnapshotSets(2, 2),
+			want:       "OK: No VMs with snapshots count greater than 25 detected (evaluated 0 VMs, 4 Snapshots, 0 Resource Pools)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := SnapshotsCountOneLineCheckSummary(tt.stateLabel, tt.sets, thresholds, VMsFilterResults{})
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// stateFilePerms is the file mode used when writing plugin state files.
+const stateFilePerms os.FileMode = 0600
+
+// readStateFile reads the contents of the specified state file. A nil value
+// is returned if the state file does not exist.
+func readStateFile(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(filename))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		logger.Printf("state file %s not found", filename)
+
+		return nil, nil
+
+	case err != nil:
+		return nil, fmt.Errorf(
+			"failed to read state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return data, nil
+}
+
+// writeStateFile writes the given data to the specified state file. The
+// state file is replaced atomically so that an interrupted write does not
+// leave a truncated state file behind.
+func writeStateFile(filename string, data []byte) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf(
+			"failed to create temporary state file: %w",
+			err,
+		)
+	}
+	tmpName := tmpFile.Name()
+
+	defer func() {
+		// Cleanup temporary file if rename was not successful.
+		if _, statErr := os.Stat(tmpName); statErr == nil {
+			if removeErr := os.Remove(tmpName); removeErr != nil {
+				logger.Printf("failed to remove temporary file %s: %v", tmpName, removeErr)
+			}
+		}
+	}()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	if err := os.Chmod(tmpName, stateFilePerms); err != nil {
+		return fmt.Errorf("failed to set state file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmpName, filename); err != nil {
+		return fmt.Errorf(
+			"failed to replace state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return nil
+}
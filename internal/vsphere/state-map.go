@@ -0,0 +1,63 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+)
+
+// stateLabelExitCodes maps supported Nagios state labels to their exit
+// codes.
+var stateLabelExitCodes = map[string]int{
+	nagios.StateOKLabel:       nagios.StateOKExitCode,
+	nagios.StateWARNINGLabel:  nagios.StateWARNINGExitCode,
+	nagios.StateCRITICALLabel: nagios.StateCRITICALExitCode,
+	nagios.StateUNKNOWNLabel:  nagios.StateUNKNOWNExitCode,
+}
+
+// applyStateMap applies user-specified Nagios state mappings (e.g., WARNING
+// to OK) to the final plugin state. The exit code and the state label at
+// the start of the one-line service check results summary are updated and a
+// note recording the original state is appended to the Long Service Output.
+// This is applied by the SetupPlugin cleanup function once the plugin state
+// has been determined.
+func applyStateMap(plugin *nagios.Plugin, stateMap config.StateMapFlag) {
+	mapping, ok := stateMap.Mapping(plugin.ExitStatusCode)
+	if !ok {
+		return
+	}
+
+	fromLabel, toLabel := mapping.FromLabel, mapping.ToLabel
+
+	logger.Printf("mapping plugin state %s to %s", fromLabel, toLabel)
+
+	plugin.ExitStatusCode = mapping.ToExitCode
+
+	// The state label may follow the emergency prefix.
+	switch {
+	case strings.HasPrefix(plugin.ServiceOutput, fromLabel):
+		plugin.ServiceOutput = toLabel + strings.TrimPrefix(plugin.ServiceOutput, fromLabel)
+
+	case strings.HasPrefix(plugin.ServiceOutput, EmergencyLabelPrefix+fromLabel):
+		plugin.ServiceOutput = EmergencyLabelPrefix + toLabel +
+			strings.TrimPrefix(plugin.ServiceOutput, EmergencyLabelPrefix+fromLabel)
+	}
+
+	plugin.LongServiceOutput += fmt.Sprintf(
+		"%sNOTE: Plugin state %s mapped to %s (state-map flag)%s",
+		nagios.CheckOutputEOL,
+		fromLabel,
+		toLabel,
+		nagios.CheckOutputEOL,
+	)
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+
+	"github.com/atc0005/check-vmware/internal/config"
+)
+
+func TestApplyStateMap(t *testing.T) {
+	stateMap := config.StateMapFlag{
+		nagios.StateWARNINGLabel:  nagios.StateOKLabel,
+		nagios.StateCRITICALLabel: nagios.StateWARNINGLabel,
+	}
+
+	tests := map[string]struct {
+		stateMap      config.StateMapFlag
+		exitCode      int
+		serviceOutput string
+		wantExitCode  int
+		wantOutput    string
+		wantNote      bool
+	}{
+		"WARNING mapped to OK": {
+			stateMap:      stateMap,
+			exitCode:      nagios.StateWARNINGExitCode,
+			serviceOutput: "WARNING: 3 VMs with old snapshots",
+			wantExitCode:  nagios.StateOKExitCode,
+			wantOutput:    "OK: 3 VMs with old snapshots",
+			wantNote:      true,
+		},
+		"CRITICAL mapped to WARNING after emergency prefix": {
+			stateMap:      stateMap,
+			exitCode:      nagios.StateCRITICALExitCode,
+			serviceOutput: EmergencyLabelPrefix + "CRITICAL: datastore full",
+			wantExitCode:  nagios.StateWARNINGExitCode,
+			wantOutput:    EmergencyLabelPrefix + "WARNING: datastore full",
+			wantNote:      true,
+		},
+		"summary without state label": {
+			stateMap:      stateMap,
+			exitCode:      nagios.StateWARNINGExitCode,
+			serviceOutput: "3 VMs with old snapshots",
+			wantExitCode:  nagios.StateOKExitCode,
+			wantOutput:    "3 VMs with old snapshots",
+			wantNote:      true,
+		},
+		"unmapped state": {
+			stateMap:      stateMap,
+			exitCode:      nagios.StateUNKNOWNExitCode,
+			serviceOutput: "UNKNOWN: Error logging into vCenter",
+			wantExitCode:  nagios.StateUNKNOWNExitCode,
+			wantOutput:    "UNKNOWN: Error logging into vCenter",
+		},
+		"no mappings": {
+			exitCode:      nagios.StateWARNINGExitCode,
+			serviceOutput: "WARNING: 3 VMs with old snapshots",
+			wantExitCode:  nagios.StateWARNINGExitCode,
+			wantOutput:    "WARNING: 3 VMs with old snapshots",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			plugin := nagios.NewPlugin()
+			plugin.ExitStatusCode = tt.exitCode
+			plugin.ServiceOutput = tt.serviceOutput
+
+			applyStateMap(plugin, tt.stateMap)
+
+			if plugin.ExitStatusCode != tt.wantExitCode {
+				t.Errorf("want exit code %d; got %d", tt.wantExitCode, plugin.ExitStatusCode)
+			}
+
+			if plugin.ServiceOutput != tt.wantOutput {
+				t.Errorf("want service output %q; got %q", tt.wantOutput, plugin.ServiceOutput)
+			}
+
+			gotNote := strings.Contains(plugin.LongServiceOutput, "(state-map flag)")
+			if gotNote != tt.wantNote {
+				t.Errorf("want state mapping note %t; got %t (%q)", tt.wantNote, gotNote, plugin.LongServiceOutput)
+			}
+		})
+	}
+}
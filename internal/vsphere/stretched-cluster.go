@@ -0,0 +1,769 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrStretchedClusterSiteBalanceCheckFailed indicates that VM placement is
+// imbalanced across the sites of one or more stretched clusters or that
+// VM/Host site affinity rules are violated.
+var ErrStretchedClusterSiteBalanceCheckFailed = errors.New("stretched cluster site balance check failed")
+
+// Sources of site definitions for stretched clusters.
+const (
+	StretchedClusterSiteSourceHostGroup   string = "DRS host group"
+	StretchedClusterSiteSourceFaultDomain string = "vSAN fault domain"
+)
+
+// StretchedClusterSiteBalanceThresholds represents the user-specified site
+// definitions and site imbalance thresholds.
+type StretchedClusterSiteBalanceThresholds struct {
+
+	// SiteHostGroups is the collection of DRS host group names used to
+	// define sites. If not specified, vSAN fault domains are used to define
+	// sites.
+	SiteHostGroups []string
+
+	// ImbalanceWarning is the difference (in percentage points of all
+	// powered on VMs) between the sites with the most and the fewest powered
+	// on VMs when a WARNING threshold is reached.
+	ImbalanceWarning int
+
+	// ImbalanceCritical is the difference (in percentage points of all
+	// powered on VMs) between the sites with the most and the fewest powered
+	// on VMs when a CRITICAL threshold is reached.
+	ImbalanceCritical int
+}
+
+// StretchedClusterSite represents a site (fault domain) of a stretched
+// cluster along with the powered on VMs placed on hosts within the site.
+type StretchedClusterSite struct {
+	Name  string
+	Hosts []string
+
+	// NumVMs is the number of powered on VMs running on hosts within the
+	// site.
+	NumVMs int
+}
+
+// StretchedClusterRuleViolation represents a VM running on a host which is
+// not permitted by a VM/Host affinity or anti-affinity rule.
+type StretchedClusterRuleViolation struct {
+	Rule      string
+	Mandatory bool
+	VM        string
+	Host      string
+}
+
+// StretchedClusterSiteBalance tracks VM placement across the sites of a
+// specific ClusterComputeResource along with any detected issues.
+type StretchedClusterSiteBalance struct {
+	Cluster mo.ClusterComputeResource
+
+	// SiteSource indicates how sites were defined for the cluster; empty if
+	// sites could not be determined.
+	SiteSource string
+
+	Sites []StretchedClusterSite
+
+	// NumVMsOutsideSites is the number of powered on VMs running on hosts
+	// which are not part of any site.
+	NumVMsOutsideSites int
+
+	Violations []StretchedClusterRuleViolation
+
+	// Critical is the collection of issues detected which map to a
+	// CRITICAL state.
+	Critical []string
+
+	// Warning is the collection of issues detected which map to a WARNING
+	// state.
+	Warning []string
+
+	Thresholds StretchedClusterSiteBalanceThresholds
+}
+
+// StretchedClusterSiteBalanceSet is a collection of
+// StretchedClusterSiteBalance values.
+type StretchedClusterSiteBalanceSet []StretchedClusterSiteBalance
+
+// clusterGroups returns the DRS host and VM groups defined for the given
+// cluster indexed by group name.
+func clusterGroups(cluster mo.ClusterComputeResource) (map[string]*types.ClusterHostGroup, map[string]*types.ClusterVmGroup) {
+	hostGroups := make(map[string]*types.ClusterHostGroup)
+	vmGroups := make(map[string]*types.ClusterVmGroup)
+
+	cfgEx, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return hostGroups, vmGroups
+	}
+
+	for _, group := range cfgEx.Group {
+		switch g := group.(type) {
+		case *types.ClusterHostGroup:
+			hostGroups[g.Name] = g
+		case *types.ClusterVmGroup:
+			vmGroups[g.Name] = g
+		}
+	}
+
+	return hostGroups, vmGroups
+}
+
+// clusterVMHostRules returns the enabled VM/Host rules defined for the given
+// cluster.
+func clusterVMHostRules(cluster mo.ClusterComputeResource) []*types.ClusterVmHostRuleInfo {
+	cfgEx, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok {
+		return nil
+	}
+
+	var rules []*types.ClusterVmHostRuleInfo
+	for _, rule := range cfgEx.Rule {
+		vmHostRule, ok := rule.(*types.ClusterVmHostRuleInfo)
+		if !ok {
+			continue
+		}
+
+		if vmHostRule.Enabled == nil || !*vmHostRule.Enabled {
+			continue
+		}
+
+		rules = append(rules, vmHostRule)
+	}
+
+	return rules
+}
+
+// refSet returns the values of the given managed object references for
+// membership tests.
+func refSet(refs []types.ManagedObjectReference) map[string]bool {
+	set := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		set[ref.Value] = true
+	}
+
+	return set
+}
+
+// NewStretchedClusterSiteBalance receives a ClusterComputeResource along
+// with the member hosts and VMs and evaluates VM placement across sites and
+// VM/Host affinity rule compliance. Sites are defined by the specified DRS
+// host groups or, if not specified, by vSAN fault domains.
+func NewStretchedClusterSiteBalance(
+	cluster mo.ClusterComputeResource,
+	hss []mo.HostSystem,
+	vms []mo.VirtualMachine,
+	thresholds StretchedClusterSiteBalanceThresholds,
+) StretchedClusterSiteBalance {
+
+	status := StretchedClusterSiteBalance{
+		Cluster:    cluster,
+		Thresholds: thresholds,
+	}
+
+	hostNames := make(map[string]string, len(hss))
+	for _, hs := range hss {
+		hostNames[hs.Self.Value] = hs.Name
+	}
+
+	hostGroups, vmGroups := clusterGroups(cluster)
+
+	// siteOf maps host MOIDs to the index of the site the host belongs to.
+	siteOf := make(map[string]int)
+
+	switch {
+	case len(thresholds.SiteHostGroups) > 0:
+		status.SiteSource = StretchedClusterSiteSourceHostGroup
+
+		for _, groupName := range thresholds.SiteHostGroups {
+			group, ok := hostGroups[groupName]
+			if !ok {
+				status.Critical = append(status.Critical, fmt.Sprintf(
+					"site host group %s not found",
+					groupName,
+				))
+
+				continue
+			}
+
+			site := StretchedClusterSite{Name: groupName}
+			for _, ref := range group.Host {
+				siteOf[ref.Value] = len(status.Sites)
+				site.Hosts = append(site.Hosts, hostNames[ref.Value])
+			}
+
+			status.Sites = append(status.Sites, site)
+		}
+
+	default:
+		faultDomains := make(map[string]int)
+		for _, hs := range hss {
+			if hs.Config == nil ||
+				hs.Config.VsanHostConfig == nil ||
+				hs.Config.VsanHostConfig.FaultDomainInfo == nil ||
+				hs.Config.VsanHostConfig.FaultDomainInfo.Name == "" {
+				continue
+			}
+
+			name := hs.Config.VsanHostConfig.FaultDomainInfo.Name
+			idx, ok := faultDomains[name]
+			if !ok {
+				idx = len(status.Sites)
+				faultDomains[name] = idx
+				status.Sites = append(status.Sites, StretchedClusterSite{Name: name})
+			}
+
+			siteOf[hs.Self.Value] = idx
+			status.Sites[idx].Hosts = append(status.Sites[idx].Hosts, hs.Name)
+		}
+
+		if len(status.Sites) > 0 {
+			status.SiteSource = StretchedClusterSiteSourceFaultDomain
+		}
+	}
+
+	for i := range status.Sites {
+		sort.Strings(status.Sites[i].Hosts)
+	}
+
+	for _, vm := range vms {
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn ||
+			vm.Runtime.Host == nil {
+			continue
+		}
+
+		idx, ok := siteOf[vm.Runtime.Host.Value]
+		if !ok {
+			status.NumVMsOutsideSites++
+
+			continue
+		}
+
+		status.Sites[idx].NumVMs++
+	}
+
+	vmsByID := make(map[string]mo.VirtualMachine, len(vms))
+	for _, vm := range vms {
+		vmsByID[vm.Self.Value] = vm
+	}
+
+	for _, rule := range clusterVMHostRules(cluster) {
+		vmGroup, ok := vmGroups[rule.VmGroupName]
+		if !ok {
+			continue
+		}
+
+		var affine, antiAffine map[string]bool
+		if group, ok := hostGroups[rule.AffineHostGroupName]; ok {
+			affine = refSet(group.Host)
+		}
+		if group, ok := hostGroups[rule.AntiAffineHostGroupName]; ok {
+			antiAffine = refSet(group.Host)
+		}
+
+		for _, ref := range vmGroup.Vm {
+			vm, ok := vmsByID[ref.Value]
+			if !ok ||
+				vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn ||
+				vm.Runtime.Host == nil {
+				continue
+			}
+
+			host := vm.Runtime.Host.Value
+			if (affine != nil && !affine[host]) ||
+				(antiAffine != nil && antiAffine[host]) {
+				status.Violations = append(status.Violations, StretchedClusterRuleViolation{
+					Rule:      rule.Name,
+					Mandatory: rule.Mandatory != nil && *rule.Mandatory,
+					VM:        vm.Name,
+					Host:      hostNames[host],
+				})
+			}
+		}
+	}
+
+	if len(status.Sites) < 2 {
+		// Sites could not be determined; the cluster is not evaluated for
+		// site balance.
+		if len(thresholds.SiteHostGroups) > 0 {
+			status.Critical = append(status.Critical, fmt.Sprintf(
+				"%d of %d site host groups found; at least 2 sites required",
+				len(status.Sites),
+				len(thresholds.SiteHostGroups),
+			))
+		}
+	} else {
+		imbalance := status.ImbalancePercent()
+		switch {
+		case imbalance >= float64(thresholds.ImbalanceCritical):
+			status.Critical = append(status.Critical, fmt.Sprintf(
+				"site imbalance of %.2f%% exceeds CRITICAL threshold of %d%%",
+				imbalance,
+				thresholds.ImbalanceCritical,
+			))
+
+		case imbalance >= float64(thresholds.ImbalanceWarning):
+			status.Warning = append(status.Warning, fmt.Sprintf(
+				"site imbalance of %.2f%% exceeds WARNING threshold of %d%%",
+				imbalance,
+				thresholds.ImbalanceWarning,
+			))
+		}
+	}
+
+	var numMandatory, numPreferred int
+	for _, violation := range status.Violations {
+		if violation.Mandatory {
+			numMandatory++
+
+			continue
+		}
+		numPreferred++
+	}
+
+	if numMandatory > 0 {
+		status.Critical = append(status.Critical, fmt.Sprintf(
+			"%d VMs violating mandatory (must) VM/Host affinity rules",
+			numMandatory,
+		))
+	}
+
+	if numPreferred > 0 {
+		status.Warning = append(status.Warning, fmt.Sprintf(
+			"%d VMs violating preferential (should) VM/Host affinity rules",
+			numPreferred,
+		))
+	}
+
+	return status
+
+}
+
+// GetStretchedClusterSiteBalanceSet retrieves the member hosts and VMs for
+// each of the given clusters and evaluates VM placement across sites and
+// VM/Host affinity rule compliance.
+func GetStretchedClusterSiteBalanceSet(
+	ctx context.Context,
+	c *vim25.Client,
+	clusters []mo.ClusterComputeResource,
+	thresholds StretchedClusterSiteBalanceThresholds,
+) (StretchedClusterSiteBalanceSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetStretchedClusterSiteBalanceSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	pc := property.DefaultCollector(c)
+
+	set := make(StretchedClusterSiteBalanceSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		var hss []mo.HostSystem
+		if len(cluster.Host) > 0 {
+			// Only the vSAN host configuration is needed from the (large)
+			// host configuration property.
+			props := []string{"name", "vm", "config.vsanHostConfig"}
+			if err := pc.Retrieve(ctx, cluster.Host, props, &hss); err != nil {
+				return nil, fmt.Errorf(
+					"failed to retrieve hosts for cluster %s: %w",
+					cluster.Name,
+					err,
+				)
+			}
+		}
+
+		var vmRefs []types.ManagedObjectReference
+		for _, hs := range hss {
+			vmRefs = append(vmRefs, hs.Vm...)
+		}
+
+		var vms []mo.VirtualMachine
+		if len(vmRefs) > 0 {
+			if err := pc.Retrieve(ctx, vmRefs, []string{"name", "runtime"}, &vms); err != nil {
+				return nil, fmt.Errorf(
+					"failed to retrieve VMs for cluster %s: %w",
+					cluster.Name,
+					err,
+				)
+			}
+		}
+
+		set = append(set, NewStretchedClusterSiteBalance(cluster, hss, vms, thresholds))
+	}
+
+	return set, nil
+
+}
+
+// NumVMs returns the number of powered on VMs running on hosts within the
+// sites of the cluster.
+func (scb StretchedClusterSiteBalance) NumVMs() int {
+	var num int
+	for _, site := range scb.Sites {
+		num += site.NumVMs
+	}
+
+	return num
+}
+
+// HasSites indicates whether at least two sites were determined for the
+// cluster.
+func (scb StretchedClusterSiteBalance) HasSites() bool {
+	return len(scb.Sites) >= 2
+}
+
+// ImbalancePercent returns the difference (in percentage points of all
+// powered on VMs within the sites of the cluster) between the sites with the
+// most and the fewest powered on VMs. Zero is returned if there are fewer
+// than two sites or no powered on VMs.
+func (scb StretchedClusterSiteBalance) ImbalancePercent() float64 {
+	total := scb.NumVMs()
+	if !scb.HasSites() || total == 0 {
+		return 0
+	}
+
+	most, fewest := scb.Sites[0].NumVMs, scb.Sites[0].NumVMs
+	for _, site := range scb.Sites[1:] {
+		if site.NumVMs > most {
+			most = site.NumVMs
+		}
+		if site.NumVMs < fewest {
+			fewest = site.NumVMs
+		}
+	}
+
+	return float64(most-fewest) / float64(total) * 100
+}
+
+// IsCriticalState indicates whether any issues mapping to a CRITICAL state
+// were detected for the cluster.
+func (scb StretchedClusterSiteBalance) IsCriticalState() bool {
+	return len(scb.Critical) > 0
+}
+
+// IsWarningState indicates whether any issues mapping to a WARNING state
+// (and none mapping to a CRITICAL state) were detected for the cluster.
+func (scb StretchedClusterSiteBalance) IsWarningState() bool {
+	return !scb.IsCriticalState() && len(scb.Warning) > 0
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set StretchedClusterSiteBalanceSet) NumCritical() int {
+	var num int
+	for _, scb := range set {
+		if scb.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set StretchedClusterSiteBalanceSet) NumWarning() int {
+	var num int
+	for _, scb := range set {
+		if scb.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWithoutSites returns the number of clusters for which sites could not
+// be determined.
+func (set StretchedClusterSiteBalanceSet) NumWithoutSites() int {
+	var num int
+	for _, scb := range set {
+		if !scb.HasSites() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumViolations returns the number of VM/Host affinity rule violations
+// across all evaluated clusters.
+func (set StretchedClusterSiteBalanceSet) NumViolations() int {
+	var num int
+	for _, scb := range set {
+		num += len(scb.Violations)
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated cluster is in a CRITICAL
+// state.
+func (set StretchedClusterSiteBalanceSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated cluster is in a WARNING
+// state.
+func (set StretchedClusterSiteBalanceSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// StretchedClusterSiteBalancePerfData generates performance data metrics
+// from the given collection of evaluated clusters. The site imbalance is
+// emitted for each cluster with sites.
+func StretchedClusterSiteBalancePerfData(set StretchedClusterSiteBalanceSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_without_sites",
+			Value: fmt.Sprintf("%d", set.NumWithoutSites()),
+			Min:   "0",
+		},
+		{
+			Label: "affinity_rule_violations",
+			Value: fmt.Sprintf("%d", set.NumViolations()),
+			Min:   "0",
+		},
+	}
+
+	for _, scb := range set {
+		if !scb.HasSites() {
+			continue
+		}
+
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(scb.Cluster.Name, "site_imbalance"),
+				Value:             fmt.Sprintf("%.2f", scb.ImbalancePercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", scb.Thresholds.ImbalanceWarning),
+				Crit:              fmt.Sprintf("%d", scb.Thresholds.ImbalanceCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// StretchedClusterSiteBalanceOneLineCheckSummary is used to generate a
+// one-line Nagios service check results summary. This is the line most
+// prominent in notifications.
+func StretchedClusterSiteBalanceOneLineCheckSummary(
+	stateLabel string,
+	set StretchedClusterSiteBalanceSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute StretchedClusterSiteBalanceOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with site balance or affinity issues detected (evaluated %d clusters, %d affinity rule violations)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+			set.NumViolations(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No site balance or affinity issues detected (evaluated %d clusters, %d without sites)",
+			stateLabel,
+			len(set),
+			set.NumWithoutSites(),
+		)
+	}
+}
+
+// StretchedClusterSiteBalanceReport generates a summary of VM placement
+// across sites for the evaluated clusters along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field commonly
+// displayed on the detailed service check results display in the web UI or
+// in the body of many notifications.
+func StretchedClusterSiteBalanceReport(
+	c *vim25.Client,
+	set StretchedClusterSiteBalanceSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute StretchedClusterSiteBalanceReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	for _, scb := range set {
+		var state string
+		switch {
+		case scb.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case scb.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Cluster %s [%s]:%s",
+			scb.Cluster.Name,
+			state,
+			nagios.CheckOutputEOL,
+		)
+
+		if !scb.HasSites() && len(scb.Thresholds.SiteHostGroups) == 0 {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* Sites: none detected (no vSAN fault domains); not evaluated for site balance%s",
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if scb.HasSites() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* Sites (%s): %d%s"+
+					"* Site imbalance: %.2f%%%s"+
+					"* Powered on VMs outside of sites: %d%s",
+				scb.SiteSource,
+				len(scb.Sites),
+				nagios.CheckOutputEOL,
+				scb.ImbalancePercent(),
+				nagios.CheckOutputEOL,
+				scb.NumVMsOutsideSites,
+				nagios.CheckOutputEOL,
+			)
+
+			for _, site := range scb.Sites {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s: %d powered on VMs on %d hosts [%s]%s",
+					site.Name,
+					site.NumVMs,
+					len(site.Hosts),
+					strings.Join(site.Hosts, ", "),
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		for _, violation := range scb.Violations {
+			ruleType := "should"
+			if violation.Mandatory {
+				ruleType = "must"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Rule %s (%s): VM %s running on host %s%s",
+				violation.Rule,
+				ruleType,
+				violation.VM,
+				violation.Host,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range scb.Critical {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateCRITICALLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range scb.Warning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateWARNINGLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,366 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// stretchedRef returns a managed object reference of the given type and ID.
+func stretchedRef(kind string, id string) types.ManagedObjectReference {
+	return types.ManagedObjectReference{Type: kind, Value: id}
+}
+
+// stretchedHosts returns four hosts; host-1 and host-2 in the vSAN fault
+// domain SiteA and host-3 and host-4 in the vSAN fault domain SiteB. Fault
+// domains are only set if requested.
+func stretchedHosts(faultDomains bool) []mo.HostSystem {
+	sites := map[string]string{
+		"host-1": "SiteA",
+		"host-2": "SiteA",
+		"host-3": "SiteB",
+		"host-4": "SiteB",
+	}
+
+	hss := make([]mo.HostSystem, 0, len(sites))
+	for _, id := range []string{"host-1", "host-2", "host-3", "host-4"} {
+		var hs mo.HostSystem
+		hs.Self = stretchedRef(MgObjRefTypeHostSystem, id)
+		hs.Name = "esx-" + id
+		if faultDomains {
+			hs.Config = &types.HostConfigInfo{
+				VsanHostConfig: &types.VsanHostConfigInfo{
+					FaultDomainInfo: &types.VsanHostFaultDomainInfo{
+						Name: sites[id],
+					},
+				},
+			}
+		}
+		hss = append(hss, hs)
+	}
+
+	return hss
+}
+
+// stretchedCluster returns a cluster with SiteA and SiteB DRS host groups,
+// a PinnedVMs DRS VM group containing vm-1 and the given rules.
+func stretchedCluster(rules ...types.BaseClusterRuleInfo) mo.ClusterComputeResource {
+	var cluster mo.ClusterComputeResource
+	cluster.Name = "Stretched"
+	cluster.ConfigurationEx = &types.ClusterConfigInfoEx{
+		Group: []types.BaseClusterGroupInfo{
+			&types.ClusterHostGroup{
+				ClusterGroupInfo: types.ClusterGroupInfo{Name: "SiteA"},
+				Host: []types.ManagedObjectReference{
+					stretchedRef(MgObjRefTypeHostSystem, "host-1"),
+					stretchedRef(MgObjRefTypeHostSystem, "host-2"),
+				},
+			},
+			&types.ClusterHostGroup{
+				ClusterGroupInfo: types.ClusterGroupInfo{Name: "SiteB"},
+				Host: []types.ManagedObjectReference{
+					stretchedRef(MgObjRefTypeHostSystem, "host-3"),
+					stretchedRef(MgObjRefTypeHostSystem, "host-4"),
+				},
+			},
+			&types.ClusterVmGroup{
+				ClusterGroupInfo: types.ClusterGroupInfo{Name: "PinnedVMs"},
+				Vm: []types.ManagedObjectReference{
+					stretchedRef(MgObjRefTypeVirtualMachine, "vm-1"),
+				},
+			},
+		},
+		Rule: rules,
+	}
+
+	return cluster
+}
+
+// stretchedRule returns a VM/Host rule keeping the PinnedVMs group on (or
+// off, if antiAffine is set) hosts in SiteA.
+func stretchedRule(enabled bool, mandatory bool, antiAffine bool) types.BaseClusterRuleInfo {
+	rule := types.ClusterVmHostRuleInfo{
+		ClusterRuleInfo: types.ClusterRuleInfo{
+			Name:      "PinnedVMs-SiteA",
+			Enabled:   &enabled,
+			Mandatory: &mandatory,
+		},
+		VmGroupName: "PinnedVMs",
+	}
+
+	if antiAffine {
+		rule.AntiAffineHostGroupName = "SiteA"
+	} else {
+		rule.AffineHostGroupName = "SiteA"
+	}
+
+	return &rule
+}
+
+// stretchedVMs returns a powered on VM for each of the given host IDs. VM
+// IDs are numbered from vm-1 in the order given.
+func stretchedVMs(hostIDs ...string) []mo.VirtualMachine {
+	vms := make([]mo.VirtualMachine, 0, len(hostIDs))
+	for i, hostID := range hostIDs {
+		var vm mo.VirtualMachine
+		vm.Self = stretchedRef(MgObjRefTypeVirtualMachine, "vm-"+strconv.Itoa(i+1))
+		vm.Name = vm.Self.Value
+		host := stretchedRef(MgObjRefTypeHostSystem, hostID)
+		vm.Runtime.Host = &host
+		vm.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOn
+		vms = append(vms, vm)
+	}
+
+	return vms
+}
+
+func TestNewStretchedClusterSiteBalance(t *testing.T) {
+	poweredOff := stretchedVMs("host-1", "host-3", "host-2", "host-4", "host-1", "host-1")
+	for i := 4; i < len(poweredOff); i++ {
+		poweredOff[i].Runtime.PowerState = types.VirtualMachinePowerStatePoweredOff
+	}
+
+	tests := map[string]struct {
+		cluster        mo.ClusterComputeResource
+		faultDomains   bool
+		vms            []mo.VirtualMachine
+		siteHostGroups []string
+		wantCritical   []string
+		wantWarning    []string
+		wantViolations []StretchedClusterRuleViolation
+	}{
+		"balanced sites": {
+			cluster:        stretchedCluster(),
+			vms:            stretchedVMs("host-1", "host-3", "host-2", "host-4"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+		},
+		"imbalance at WARNING threshold": {
+			cluster:        stretchedCluster(),
+			vms:            stretchedVMs("host-1", "host-3", "host-2", "host-4", "host-1"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantWarning:    []string{"site imbalance of 20.00% exceeds WARNING threshold of 20%"},
+		},
+		"imbalance above CRITICAL threshold": {
+			cluster:        stretchedCluster(),
+			vms:            stretchedVMs("host-1", "host-3", "host-2", "host-1", "host-2"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantCritical:   []string{"site imbalance of 60.00% exceeds CRITICAL threshold of 40%"},
+		},
+		"powered off VMs ignored": {
+			cluster:        stretchedCluster(),
+			vms:            poweredOff,
+			siteHostGroups: []string{"SiteA", "SiteB"},
+		},
+		"missing site host group": {
+			cluster:        stretchedCluster(),
+			vms:            stretchedVMs("host-1", "host-3"),
+			siteHostGroups: []string{"SiteA", "SiteC"},
+			wantCritical: []string{
+				"site host group SiteC not found",
+				"1 of 2 site host groups found; at least 2 sites required",
+			},
+		},
+		"mandatory rule violation": {
+			cluster:        stretchedCluster(stretchedRule(true, true, false)),
+			vms:            stretchedVMs("host-3", "host-1"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantCritical:   []string{"1 VMs violating mandatory (must) VM/Host affinity rules"},
+			wantViolations: []StretchedClusterRuleViolation{
+				{Rule: "PinnedVMs-SiteA", Mandatory: true, VM: "vm-1", Host: "esx-host-3"},
+			},
+		},
+		"preferential rule violation": {
+			cluster:        stretchedCluster(stretchedRule(true, false, false)),
+			vms:            stretchedVMs("host-3", "host-1"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantWarning:    []string{"1 VMs violating preferential (should) VM/Host affinity rules"},
+			wantViolations: []StretchedClusterRuleViolation{
+				{Rule: "PinnedVMs-SiteA", VM: "vm-1", Host: "esx-host-3"},
+			},
+		},
+		"anti-affinity rule violation": {
+			cluster:        stretchedCluster(stretchedRule(true, false, true)),
+			vms:            stretchedVMs("host-2", "host-3"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantWarning:    []string{"1 VMs violating preferential (should) VM/Host affinity rules"},
+			wantViolations: []StretchedClusterRuleViolation{
+				{Rule: "PinnedVMs-SiteA", VM: "vm-1", Host: "esx-host-2"},
+			},
+		},
+		"disabled rule ignored": {
+			cluster:        stretchedCluster(stretchedRule(false, true, false)),
+			vms:            stretchedVMs("host-3", "host-1"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+		},
+		"rule satisfied": {
+			cluster:        stretchedCluster(stretchedRule(true, true, false)),
+			vms:            stretchedVMs("host-1", "host-3"),
+			siteHostGroups: []string{"SiteA", "SiteB"},
+		},
+		"fault domain sites imbalanced": {
+			cluster:      stretchedCluster(),
+			faultDomains: true,
+			vms:          stretchedVMs("host-1", "host-2", "host-1"),
+			wantCritical: []string{"site imbalance of 100.00% exceeds CRITICAL threshold of 40%"},
+		},
+		"no sites determined": {
+			cluster: stretchedCluster(),
+			vms:     stretchedVMs("host-1", "host-2", "host-1"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			thresholds := StretchedClusterSiteBalanceThresholds{
+				SiteHostGroups:    tt.siteHostGroups,
+				ImbalanceWarning:  20,
+				ImbalanceCritical: 40,
+			}
+
+			scb := NewStretchedClusterSiteBalance(
+				tt.cluster,
+				stretchedHosts(tt.faultDomains),
+				tt.vms,
+				thresholds,
+			)
+
+			if d := cmp.Diff(tt.wantCritical, scb.Critical); d != "" {
+				t.Errorf("critical (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantWarning, scb.Warning); d != "" {
+				t.Errorf("warning (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantViolations, scb.Violations); d != "" {
+				t.Errorf("violations (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestStretchedClusterSiteBalanceSites(t *testing.T) {
+	tests := map[string]struct {
+		faultDomains   bool
+		siteHostGroups []string
+		wantSource     string
+	}{
+		"DRS host groups": {
+			siteHostGroups: []string{"SiteA", "SiteB"},
+			wantSource:     StretchedClusterSiteSourceHostGroup,
+		},
+		"vSAN fault domains": {
+			faultDomains: true,
+			wantSource:   StretchedClusterSiteSourceFaultDomain,
+		},
+	}
+
+	wantSites := []StretchedClusterSite{
+		{Name: "SiteA", Hosts: []string{"esx-host-1", "esx-host-2"}, NumVMs: 3},
+		{Name: "SiteB", Hosts: []string{"esx-host-3", "esx-host-4"}, NumVMs: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vms := stretchedVMs("host-1", "host-3", "host-2", "host-1", "host-5")
+
+			scb := NewStretchedClusterSiteBalance(
+				stretchedCluster(),
+				stretchedHosts(tt.faultDomains),
+				vms,
+				StretchedClusterSiteBalanceThresholds{
+					SiteHostGroups:    tt.siteHostGroups,
+					ImbalanceWarning:  60,
+					ImbalanceCritical: 80,
+				},
+			)
+
+			if scb.SiteSource != tt.wantSource {
+				t.Errorf("want site source %q; got %q", tt.wantSource, scb.SiteSource)
+			}
+
+			if d := cmp.Diff(wantSites, scb.Sites); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if scb.NumVMsOutsideSites != 1 {
+				t.Errorf("want 1 VM outside sites; got %d", scb.NumVMsOutsideSites)
+			}
+
+			if got := scb.NumVMs(); got != 4 {
+				t.Errorf("want 4 VMs within sites; got %d", got)
+			}
+
+			if got := scb.ImbalancePercent(); math.Abs(got-50) > 0.01 {
+				t.Errorf("want site imbalance 50%%; got %.2f%%", got)
+			}
+		})
+	}
+}
+
+func TestStretchedClusterSiteBalanceImbalancePercent(t *testing.T) {
+	tests := map[string]struct {
+		vmsPerSite []int
+		want       float64
+	}{
+		"single site":         {vmsPerSite: []int{10}},
+		"no powered on VMs":   {vmsPerSite: []int{0, 0}},
+		"balanced sites":      {vmsPerSite: []int{5, 5}},
+		"imbalanced sites":    {vmsPerSite: []int{3, 1}, want: 50},
+		"three sites":         {vmsPerSite: []int{4, 1, 5}, want: 40},
+		"all VMs in one site": {vmsPerSite: []int{0, 7}, want: 100},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var scb StretchedClusterSiteBalance
+			for _, num := range tt.vmsPerSite {
+				scb.Sites = append(scb.Sites, StretchedClusterSite{NumVMs: num})
+			}
+
+			if got := scb.ImbalancePercent(); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("want %.2f%%; got %.2f%%", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStretchedClusterSiteBalanceSetCounts(t *testing.T) {
+	set := StretchedClusterSiteBalanceSet{
+		{Sites: []StretchedClusterSite{{}, {}}, Critical: []string{"imbalance"}},
+		{Sites: []StretchedClusterSite{{}, {}}, Critical: []string{"violation"}, Warning: []string{"imbalance"}},
+		{
+			Sites:      []StretchedClusterSite{{}, {}},
+			Warning:    []string{"violation"},
+			Violations: []StretchedClusterRuleViolation{{}, {}},
+		},
+		{},
+	}
+
+	if got := set.NumCritical(); got != 2 || !set.HasCriticalState() {
+		t.Errorf("want 2 CRITICAL clusters; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING cluster; got %d", got)
+	}
+
+	if got := set.NumWithoutSites(); got != 1 {
+		t.Errorf("want 1 cluster without sites; got %d", got)
+	}
+
+	if got := set.NumViolations(); got != 2 {
+		t.Errorf("want 2 rule violations; got %d", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// SummaryTemplateData is the data made available to a user-specified
+// one-line summary template.
+type SummaryTemplateData struct {
+	// State is the Nagios state label (e.g., WARNING) for the final plugin
+	// state.
+	State string
+
+	// ExitCode is the Nagios exit code for the final plugin state.
+	ExitCode int
+
+	// Summary is the default one-line summary generated by the plugin.
+	Summary string
+
+	// Data is the collection of values passed to the OneLineCheckSummary
+	// function for the plugin, indexed by parameter name (e.g.,
+	// vmsFilterResults). The stateLabel parameter is provided as State.
+	Data map[string]interface{}
+}
+
+// summaryData records the values most recently passed to a
+// OneLineCheckSummary function for use with a user-specified summary
+// template.
+var summaryData = struct {
+	sync.Mutex
+	values map[string]interface{}
+}{}
+
+// recordSummaryData records the given values passed to a
+// OneLineCheckSummary function for later use with a user-specified summary
+// template.
+func recordSummaryData(values map[string]interface{}) {
+	summaryData.Lock()
+	defer summaryData.Unlock()
+
+	summaryData.values = values
+}
+
+// ApplySummaryTemplate is a helper function used to replace the one-line
+// service check results summary with the output of the given (optional)
+// Go template. The template has access to the final plugin state, the
+// default summary and the values passed to the OneLineCheckSummary function
+// for the plugin. The default summary is retained if the template fails to
+// execute. This is intended to be deferred so that it runs after the plugin
+// state has been determined.
+func ApplySummaryTemplate(plugin *nagios.Plugin, tmpl string) {
+	if tmpl == "" || plugin.ServiceOutput == "" {
+		return
+	}
+
+	t, err := template.New("summary").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		logger.Printf("failed to parse summary template: %v", err)
+
+		return
+	}
+
+	summaryData.Lock()
+	values := summaryData.values
+	summaryData.Unlock()
+
+	data := SummaryTemplateData{
+		ExitCode: plugin.ExitStatusCode,
+		Summary:  plugin.ServiceOutput,
+		Data:     values,
+	}
+
+	for label, exitCode := range stateLabelExitCodes {
+		if exitCode == plugin.ExitStatusCode {
+			data.State = label
+			break
+		}
+	}
+
+	var output strings.Builder
+	if err := t.Execute(&output, data); err != nil {
+		logger.Printf("failed to execute summary template: %v", err)
+
+		return
+	}
+
+	summary := strings.TrimSpace(output.String())
+	if summary == "" {
+		logger.Printf("summary template produced empty output; retaining default summary")
+
+		return
+	}
+
+	plugin.ServiceOutput = summary
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+)
+
+func TestApplySummaryTemplate(t *testing.T) {
+	const defaultSummary = "WARNING: 2 of 10 VMs with snapshots"
+
+	tests := map[string]struct {
+		tmpl          string
+		serviceOutput string
+		exitCode      int
+		values        map[string]interface{}
+		want          string
+	}{
+		"no template": {
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			want:          defaultSummary,
+		},
+		"state and exit code": {
+			tmpl:          "{{ .State }} ({{ .ExitCode }})",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			want:          "WARNING (1)",
+		},
+		"default summary": {
+			tmpl:          "[vmware] {{ .Summary }}",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			want:          "[vmware] " + defaultSummary,
+		},
+		"summary data": {
+			tmpl:          "{{ .State }}: {{ .Data.numVMs }} VMs",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateCRITICALExitCode,
+			values:        map[string]interface{}{"numVMs": 10},
+			want:          "CRITICAL: 10 VMs",
+		},
+		"surrounding whitespace trimmed": {
+			tmpl:          "  {{ .State }}\n",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateOKExitCode,
+			want:          "OK",
+		},
+		"missing data key retains default summary": {
+			tmpl:          "{{ .Data.missing }}",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			values:        map[string]interface{}{"numVMs": 10},
+			want:          defaultSummary,
+		},
+		"invalid template retains default summary": {
+			tmpl:          "{{ .State ",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			want:          defaultSummary,
+		},
+		"empty output retains default summary": {
+			tmpl:          "{{ if false }}unused{{ end }}",
+			serviceOutput: defaultSummary,
+			exitCode:      nagios.StateWARNINGExitCode,
+			want:          defaultSummary,
+		},
+		"empty service output is not replaced": {
+			tmpl:     "{{ .State }}",
+			exitCode: nagios.StateUNKNOWNExitCode,
+			want:     "",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			recordSummaryData(tt.values)
+			t.Cleanup(func() { recordSummaryData(nil) })
+
+			plugin := nagios.NewPlugin()
+			plugin.ServiceOutput = tt.serviceOutput
+			plugin.ExitStatusCode = tt.exitCode
+
+			ApplySummaryTemplate(plugin, tt.tmpl)
+
+			if plugin.ServiceOutput != tt.want {
+				t.Errorf("want %q; got %q", tt.want, plugin.ServiceOutput)
+			}
+		})
+	}
+}
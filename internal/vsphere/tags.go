@@ -0,0 +1,417 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrTaggingClientNotProvided indicates that filtering by vSphere Tags was
+// requested, but a vSphere Automation API client was not provided to query
+// the tagging service.
+var ErrTaggingClientNotProvided = errors.New("vSphere Automation API client not provided for tag filtering")
+
+// tagServicePaths holds the vSphere Automation API paths used to query the
+// tagging service.
+type tagServicePaths struct {
+	// association is the path for the tag association service.
+	association string
+
+	// associationAction is the name of the query parameter used to specify
+	// the tag association service action.
+	associationAction string
+
+	// tag is the path (format string) for a specific tag.
+	tag string
+
+	// category is the path (format string) for a specific tag category.
+	category string
+}
+
+// tagServiceAPIPaths are the paths for the tagging service provided by the
+// /api endpoint. The tag association service is only available via this
+// endpoint as of vCenter 7.0 Update 2.
+var tagServiceAPIPaths = tagServicePaths{
+	association:       "/api/cis/tagging/tag-association",
+	associationAction: "action",
+	tag:               "/api/cis/tagging/tag/%s",
+	category:          "/api/cis/tagging/category/%s",
+}
+
+// tagServiceRESTPaths are the paths for the tagging service provided by the
+// (deprecated) /rest endpoint. These paths are used if the tag association
+// service is not available via the /api endpoint (e.g., vCenter releases
+// prior to 7.0 Update 2). The rest.Client prefixes these paths with /rest.
+var tagServiceRESTPaths = tagServicePaths{
+	association:       "/com/vmware/cis/tagging/tag-association",
+	associationAction: "~action",
+	tag:               "/com/vmware/cis/tagging/tag/id:%s",
+	category:          "/com/vmware/cis/tagging/category/id:%s",
+}
+
+// tagAssociationBatchSize is the maximum number of objects submitted in a
+// single request to retrieve attached tags.
+const tagAssociationBatchSize int = 500
+
+// TagCategorySeparator is the separator used between a tag category name and
+// a tag name when specifying a category-qualified tag (e.g.,
+// Backup:Nightly).
+const TagCategorySeparator string = ":"
+
+// Tag represents a vSphere Tag along with the name of the category it
+// belongs to.
+type Tag struct {
+	ID           string
+	Name         string
+	CategoryID   string
+	CategoryName string
+}
+
+// Tags is a collection of vSphere Tags.
+type Tags []Tag
+
+// tagObjectID is the vSphere Automation API representation of a managed
+// object reference.
+type tagObjectID struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// tagObjectAssociation is the vSphere Automation API representation of the
+// tags attached to a specific object.
+type tagObjectAssociation struct {
+	ObjectID tagObjectID `json:"object_id"`
+	TagIDs   []string    `json:"tag_ids"`
+}
+
+// tagModel is the subset of the vSphere Automation API tag model that we
+// are interested in.
+type tagModel struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	CategoryID string `json:"category_id"`
+}
+
+// tagCategoryModel is the subset of the vSphere Automation API tag category
+// model that we are interested in.
+type tagCategoryModel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// String provides the category-qualified name of the tag (e.g.,
+// Backup:Nightly).
+func (t Tag) String() string {
+	return t.CategoryName + TagCategorySeparator + t.Name
+}
+
+// Matches indicates whether the tag matches the given tag specification.
+// A tag specification is either a tag name (matching a tag of that name in
+// any category) or a category-qualified tag name (e.g., Backup:Nightly).
+// Comparisons are case-insensitive and use the current pattern matching
+// mode.
+func (t Tag) Matches(spec string) bool {
+	category, name, qualified := strings.Cut(spec, TagCategorySeparator)
+	if !qualified {
+		return textutils.MatchPattern(t.Name, spec, patternMatchMode, true)
+	}
+
+	return textutils.MatchPattern(t.CategoryName, category, patternMatchMode, true) &&
+		textutils.MatchPattern(t.Name, name, patternMatchMode, true)
+}
+
+// MatchesAny indicates whether any tag in the collection matches any of the
+// given tag specifications.
+func (ts Tags) MatchesAny(specs []string) bool {
+	for _, tag := range ts {
+		for _, spec := range specs {
+			if tag.Matches(spec) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Names returns the category-qualified names of all tags in the collection.
+func (ts Tags) Names() []string {
+	names := make([]string, 0, len(ts))
+	for _, tag := range ts {
+		names = append(names, tag.String())
+	}
+
+	return names
+}
+
+// GetAttachedTags uses the given vSphere Automation API client to retrieve
+// the vSphere Tags attached to each of the given managed objects. The
+// results are indexed by managed object ID value (e.g., vm-42). The tagging
+// service provided by the /api endpoint (vCenter 7.0 Update 2 or later) is
+// used if available, otherwise the /rest endpoint is used.
+func GetAttachedTags(
+	ctx context.Context,
+	rc *rest.Client,
+	objs []types.ManagedObjectReference,
+) (map[string]Tags, error) {
+
+	funcTimeStart := time.Now()
+
+	attached := make(map[string]Tags, len(objs))
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetAttachedTags func (and retrieve tags for %d objects).\n",
+			time.Since(funcTimeStart),
+			len(attached),
+		)
+	}()
+
+	if rc == nil {
+		return nil, ErrTaggingClientNotProvided
+	}
+
+	tagCache := make(map[string]Tag)
+	categoryCache := make(map[string]string)
+	paths := tagServiceAPIPaths
+
+	for start := 0; start < len(objs); start += tagAssociationBatchSize {
+		end := start + tagAssociationBatchSize
+		if end > len(objs) {
+			end = len(objs)
+		}
+
+		body := struct {
+			ObjectIDs []tagObjectID `json:"object_ids"`
+		}{
+			ObjectIDs: make([]tagObjectID, 0, end-start),
+		}
+
+		for _, obj := range objs[start:end] {
+			body.ObjectIDs = append(body.ObjectIDs, tagObjectID{
+				Type: obj.Type,
+				ID:   obj.Value,
+			})
+		}
+
+		associations, err := listAttachedTags(ctx, rc, paths, body)
+		if rest.IsStatusError(err, http.StatusNotFound) && paths == tagServiceAPIPaths {
+			logger.Println("tag association service not found at /api endpoint; using /rest endpoint")
+
+			paths = tagServiceRESTPaths
+			associations, err = listAttachedTags(ctx, rc, paths, body)
+		}
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve attached tags: %w",
+				err,
+			)
+		}
+
+		for _, association := range associations {
+			tags := make(Tags, 0, len(association.TagIDs))
+			for _, tagID := range association.TagIDs {
+				tag, err := getTag(ctx, rc, paths, tagID, tagCache, categoryCache)
+				if err != nil {
+					return nil, err
+				}
+
+				tags = append(tags, tag)
+			}
+
+			attached[association.ObjectID.ID] = tags
+		}
+	}
+
+	return attached, nil
+}
+
+// listAttachedTags retrieves the tags attached to the objects in the given
+// request body using the given tagging service paths.
+func listAttachedTags(
+	ctx context.Context,
+	rc *rest.Client,
+	paths tagServicePaths,
+	body interface{},
+) ([]tagObjectAssociation, error) {
+	req := rc.Resource(paths.association).
+		WithParam(paths.associationAction, "list-attached-tags-on-objects").
+		Request(http.MethodPost, body)
+
+	var associations []tagObjectAssociation
+	if err := rc.Do(ctx, req, &associations); err != nil {
+		return nil, err
+	}
+
+	return associations, nil
+}
+
+// getTag retrieves the tag (and category name) for the given tag ID using
+// the given tagging service paths and caches to limit repeated lookups.
+func getTag(
+	ctx context.Context,
+	rc *rest.Client,
+	paths tagServicePaths,
+	tagID string,
+	tagCache map[string]Tag,
+	categoryCache map[string]string,
+) (Tag, error) {
+
+	if tag, ok := tagCache[tagID]; ok {
+		return tag, nil
+	}
+
+	var tm tagModel
+	req := rc.Resource(fmt.Sprintf(paths.tag, tagID)).Request(http.MethodGet)
+	if err := rc.Do(ctx, req, &tm); err != nil {
+		return Tag{}, fmt.Errorf(
+			"failed to retrieve tag %s: %w",
+			tagID,
+			err,
+		)
+	}
+
+	categoryName, ok := categoryCache[tm.CategoryID]
+	if !ok {
+		var cm tagCategoryModel
+		req := rc.Resource(fmt.Sprintf(paths.category, tm.CategoryID)).Request(http.MethodGet)
+		if err := rc.Do(ctx, req, &cm); err != nil {
+			return Tag{}, fmt.Errorf(
+				"failed to retrieve tag category %s: %w",
+				tm.CategoryID,
+				err,
+			)
+		}
+
+		categoryName = cm.Name
+		categoryCache[tm.CategoryID] = categoryName
+	}
+
+	tag := Tag{
+		ID:           tagID,
+		Name:         tm.Name,
+		CategoryID:   tm.CategoryID,
+		CategoryName: categoryName,
+	}
+	tagCache[tagID] = tag
+
+	return tag, nil
+}
+
+// FilterVMsByTags filters the given VirtualMachines using the given vSphere
+// Tags (indexed by VirtualMachine managed object ID value) and tag
+// specifications. If included tags are specified, only VirtualMachines with
+// at least one matching tag are kept. If excluded tags are specified,
+// VirtualMachines with any matching tag are excluded. The filtered
+// collection is returned along with the number of VirtualMachines excluded.
+func FilterVMsByTags(
+	vms []mo.VirtualMachine,
+	vmTags map[string]Tags,
+	includeTags []string,
+	excludeTags []string,
+) ([]mo.VirtualMachine, int) {
+
+	funcTimeStart := time.Now()
+
+	vmsToKeep := make([]mo.VirtualMachine, 0, len(vms))
+
+	defer func(vms []mo.VirtualMachine, filteredVMs *[]mo.VirtualMachine) {
+		logger.Printf(
+			"It took %v to execute FilterVMsByTags func (for %d VMs, yielding %d VMs)\n",
+			time.Since(funcTimeStart),
+			len(vms),
+			len(*filteredVMs),
+		)
+	}(vms, &vmsToKeep)
+
+	for _, vm := range vms {
+		tags := vmTags[vm.Self.Value]
+
+		switch {
+		case len(includeTags) > 0:
+			if tags.MatchesAny(includeTags) {
+				vmsToKeep = append(vmsToKeep, vm)
+			}
+
+		case len(excludeTags) > 0:
+			if !tags.MatchesAny(excludeTags) {
+				vmsToKeep = append(vmsToKeep, vm)
+			}
+
+		default:
+			vmsToKeep = append(vmsToKeep, vm)
+		}
+	}
+
+	numExcluded := len(vms) - len(vmsToKeep)
+
+	return vmsToKeep, numExcluded
+}
+
+// filterVMsByTag uses the given filtering options to limit the given
+// VirtualMachines to those with (or without) the specified vSphere Tags.
+func filterVMsByTag(
+	ctx context.Context,
+	vms []mo.VirtualMachine,
+	filterOptions VMsFilterOptions,
+) ([]mo.VirtualMachine, int, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute filterVMsByTag func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if len(filterOptions.TagsIncluded) == 0 && len(filterOptions.TagsExcluded) == 0 {
+		logger.Println("Skipping filter by tag; tag filtering not requested")
+
+		return vms, 0, nil
+	}
+
+	vmRefs := make([]types.ManagedObjectReference, 0, len(vms))
+	for _, vm := range vms {
+		vmRefs = append(vmRefs, vm.Self)
+	}
+
+	vmTags, err := GetAttachedTags(ctx, filterOptions.TaggingClient, vmRefs)
+	if err != nil {
+		return nil, 0, fmt.Errorf(
+			"failed to filter VMs by tag: %w",
+			err,
+		)
+	}
+
+	filteredVMs, numExcluded := FilterVMsByTags(
+		vms,
+		vmTags,
+		filterOptions.TagsIncluded,
+		filterOptions.TagsExcluded,
+	)
+
+	logger.Printf(
+		"VMs after tag filtering: %v (kept: %d, excluded: %d)",
+		strings.Join(VMNames(filteredVMs), ", "),
+		len(filteredVMs),
+		numExcluded,
+	)
+
+	return filteredVMs, numExcluded, nil
+}
@@ -0,0 +1,291 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const (
+	testTagID      string = "urn:vmomi:InventoryServiceTag:1:GLOBAL"
+	testCategoryID string = "urn:vmomi:InventoryServiceCategory:1:GLOBAL"
+)
+
+// newTaggingServer returns a test server providing the tagging service via
+// the /api endpoint (if api is true) and the /rest endpoint.
+func newTaggingServer(t *testing.T, api bool) *httptest.Server {
+	t.Helper()
+
+	respond := func(w http.ResponseWriter, wrap bool, v interface{}) {
+		if wrap {
+			v = map[string]interface{}{"value": v}
+		}
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+
+	associations := []tagObjectAssociation{
+		{
+			ObjectID: tagObjectID{Type: MgObjRefTypeVirtualMachine, ID: "vm-1"},
+			TagIDs:   []string{testTagID},
+		},
+	}
+	tag := tagModel{ID: testTagID, Name: "Nightly", CategoryID: testCategoryID}
+	category := tagCategoryModel{ID: testCategoryID, Name: "Backup"}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case api && r.Method == http.MethodPost && r.URL.Path == "/api/cis/tagging/tag-association" &&
+			r.URL.Query().Get("action") == "list-attached-tags-on-objects":
+			respond(w, false, associations)
+
+		case api && r.URL.Path == "/api/cis/tagging/tag/"+testTagID:
+			respond(w, false, tag)
+
+		case api && r.URL.Path == "/api/cis/tagging/category/"+testCategoryID:
+			respond(w, false, category)
+
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/com/vmware/cis/tagging/tag-association" &&
+			r.URL.Query().Get("~action") == "list-attached-tags-on-objects":
+			respond(w, true, associations)
+
+		case r.URL.Path == "/rest/com/vmware/cis/tagging/tag/id:"+testTagID:
+			respond(w, true, tag)
+
+		case r.URL.Path == "/rest/com/vmware/cis/tagging/category/id:"+testCategoryID:
+			respond(w, true, category)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGetAttachedTags(t *testing.T) {
+	objs := []types.ManagedObjectReference{
+		{Type: MgObjRefTypeVirtualMachine, Value: "vm-1"},
+	}
+
+	want := map[string]Tags{
+		"vm-1": {
+			{ID: testTagID, Name: "Nightly", CategoryID: testCategoryID, CategoryName: "Backup"},
+		},
+	}
+
+	tests := map[string]struct {
+		api bool
+	}{
+		"api endpoint":              {api: true},
+		"fallback to rest endpoint": {api: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := newTaggingServer(t, tt.api)
+
+			u, _ := url.Parse(srv.URL + vim25.Path)
+			rc := rest.NewClient(&vim25.Client{Client: soap.NewClient(u, false)})
+
+			got, err := GetAttachedTags(context.Background(), rc, objs)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestGetAttachedTagsNoClient(t *testing.T) {
+	_, err := GetAttachedTags(context.Background(), nil, nil)
+	if !errors.Is(err, ErrTaggingClientNotProvided) {
+		t.Errorf("want %v error; got %v", ErrTaggingClientNotProvided, err)
+	}
+}
+
+func TestTagMatches(t *testing.T) {
+	tag := Tag{Name: "Nightly", CategoryName: "Backup"}
+
+	tests := map[string]struct {
+		mode string
+		spec string
+		want bool
+	}{
+		"name":                            {mode: textutils.PatternMatchExact, spec: "Nightly", want: true},
+		"name case-insensitive":           {mode: textutils.PatternMatchExact, spec: "NIGHTLY", want: true},
+		"different name":                  {mode: textutils.PatternMatchExact, spec: "Weekly", want: false},
+		"category qualified":              {mode: textutils.PatternMatchExact, spec: "Backup:Nightly", want: true},
+		"category qualified mixed case":   {mode: textutils.PatternMatchExact, spec: "backup:nightly", want: true},
+		"different category":              {mode: textutils.PatternMatchExact, spec: "Owner:Nightly", want: false},
+		"category name only is not a tag": {mode: textutils.PatternMatchExact, spec: "Backup", want: false},
+		"empty category":                  {mode: textutils.PatternMatchExact, spec: ":Nightly", want: false},
+		"glob name":                       {mode: textutils.PatternMatchGlob, spec: "Night*", want: true},
+		"glob category and name":          {mode: textutils.PatternMatchGlob, spec: "Back*:*ly", want: true},
+		"glob category mismatch":          {mode: textutils.PatternMatchGlob, spec: "Own*:Nightly", want: false},
+		"regex name":                      {mode: textutils.PatternMatchRegex, spec: "^night", want: true},
+		"regex category and name":         {mode: textutils.PatternMatchRegex, spec: "^backup$:^(nightly|weekly)$", want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			setTestPatternMatchMode(t, tt.mode)
+
+			if got := tag.Matches(tt.spec); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTagsMatchesAny(t *testing.T) {
+	tags := Tags{
+		{Name: "Nightly", CategoryName: "Backup"},
+		{Name: "Finance", CategoryName: "Owner"},
+	}
+
+	tests := map[string]struct {
+		tags  Tags
+		specs []string
+		want  bool
+	}{
+		"first tag matches": {
+			tags:  tags,
+			specs: []string{"Backup:Nightly"},
+			want:  true,
+		},
+		"second spec matches second tag": {
+			tags:  tags,
+			specs: []string{"Weekly", "Owner:Finance"},
+			want:  true,
+		},
+		"no matches": {
+			tags:  tags,
+			specs: []string{"Weekly", "Owner:HR"},
+			want:  false,
+		},
+		"no specs": {
+			tags:  tags,
+			specs: nil,
+			want:  false,
+		},
+		"no tags": {
+			tags:  nil,
+			specs: []string{"Nightly"},
+			want:  false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.tags.MatchesAny(tt.specs); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTagsNames(t *testing.T) {
+	tags := Tags{
+		{Name: "Nightly", CategoryName: "Backup"},
+		{Name: "Finance", CategoryName: "Owner"},
+	}
+
+	want := []string{"Backup:Nightly", "Owner:Finance"}
+
+	if d := cmp.Diff(want, tags.Names()); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestFilterVMsByTags(t *testing.T) {
+	vm := func(id string) mo.VirtualMachine {
+		var vm mo.VirtualMachine
+		vm.Self = types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: id}
+		vm.Name = id
+
+		return vm
+	}
+
+	vms := []mo.VirtualMachine{vm("vm-1"), vm("vm-2"), vm("vm-3")}
+
+	vmTags := map[string]Tags{
+		"vm-1": {{Name: "Nightly", CategoryName: "Backup"}},
+		"vm-2": {{Name: "Weekly", CategoryName: "Backup"}, {Name: "Finance", CategoryName: "Owner"}},
+	}
+
+	tests := map[string]struct {
+		include      []string
+		exclude      []string
+		wantVMs      []string
+		wantExcluded int
+	}{
+		"no tag filters": {
+			wantVMs:      []string{"vm-1", "vm-2", "vm-3"},
+			wantExcluded: 0,
+		},
+		"include by tag name": {
+			include:      []string{"Nightly"},
+			wantVMs:      []string{"vm-1"},
+			wantExcluded: 2,
+		},
+		"include by category qualified tags": {
+			include:      []string{"Backup:Nightly", "Owner:Finance"},
+			wantVMs:      []string{"vm-1", "vm-2"},
+			wantExcluded: 1,
+		},
+		"exclude by tag name": {
+			exclude:      []string{"Weekly"},
+			wantVMs:      []string{"vm-1", "vm-3"},
+			wantExcluded: 1,
+		},
+		"exclude keeps untagged VMs": {
+			exclude:      []string{"Nightly", "Weekly"},
+			wantVMs:      []string{"vm-3"},
+			wantExcluded: 2,
+		},
+		"include without matches": {
+			include:      []string{"Owner:HR"},
+			wantVMs:      []string{},
+			wantExcluded: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, numExcluded := FilterVMsByTags(vms, vmTags, tt.include, tt.exclude)
+
+			if d := cmp.Diff(tt.wantVMs, VMNames(filtered)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded VMs; got %d", tt.wantExcluded, numExcluded)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrFailedTasksThresholdCrossed indicates that the number of failed vCenter
+// tasks within the lookback window has crossed a specified threshold.
+var ErrFailedTasksThresholdCrossed = errors.New("failed tasks exceed specified threshold")
+
+// taskCollectorPageSize is the number of tasks retrieved from the task
+// history collector per request.
+const taskCollectorPageSize int32 = 100
+
+// Task initiator labels used when a task was not initiated by a user.
+const (
+	TaskInitiatorSystem   string = "system"
+	TaskInitiatorUnknown  string = "unknown"
+	taskInitiatorSchedule string = "scheduled task"
+	taskInitiatorAlarm    string = "alarm"
+)
+
+// TaskFilterOptions is the set of options used to limit evaluation of failed
+// tasks by description ID (e.g., VirtualMachine.clone) or initiating user.
+type TaskFilterOptions struct {
+	IncludedDescriptionIDs []string
+	ExcludedDescriptionIDs []string
+	IncludedUsers          []string
+	ExcludedUsers          []string
+}
+
+// TaskThresholds represents the user-specified thresholds for the number of
+// failed tasks within the lookback window.
+type TaskThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// FailedTasksSummary is the result of evaluating failed vCenter tasks
+// within the lookback window.
+type FailedTasksSummary struct {
+	// Tasks is the collection of failed tasks which were not excluded by
+	// the specified filter options.
+	Tasks []types.TaskInfo
+
+	// NumExcluded is the number of failed tasks excluded by the specified
+	// filter options.
+	NumExcluded int
+
+	// Lookback is the period of time prior to plugin execution evaluated
+	// for failed tasks.
+	Lookback time.Duration
+
+	Thresholds TaskThresholds
+}
+
+// GetFailedTasks uses a task history collector to retrieve all tasks which
+// started within the specified lookback window and completed with an error.
+func GetFailedTasks(ctx context.Context, c *vim25.Client, lookback time.Duration) ([]types.TaskInfo, error) {
+
+	funcTimeStart := time.Now()
+
+	var failedTasks []types.TaskInfo
+
+	defer func(tasks *[]types.TaskInfo) {
+		logger.Printf(
+			"It took %v to execute GetFailedTasks func (and retrieve %d tasks).\n",
+			time.Since(funcTimeStart),
+			len(*tasks),
+		)
+	}(&failedTasks)
+
+	beginTime := time.Now().Add(-lookback)
+
+	filter := types.TaskFilterSpec{
+		Time: &types.TaskFilterSpecByTime{
+			TimeType:  types.TaskFilterSpecTimeOptionStartedTime,
+			BeginTime: &beginTime,
+		},
+		State: []types.TaskInfoState{
+			types.TaskInfoStateError,
+		},
+	}
+
+	failedTasks, err := collectTasks(ctx, c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(failedTasks, func(i, j int) bool {
+		return failedTasks[i].QueueTime.After(failedTasks[j].QueueTime)
+	})
+
+	return failedTasks, nil
+
+}
+
+// collectTasks uses a task history collector to retrieve all tasks matching
+// the given filter.
+func collectTasks(ctx context.Context, c *vim25.Client, filter types.TaskFilterSpec) ([]types.TaskInfo, error) {
+
+	var tasks []types.TaskInfo
+
+	collector, err := task.NewManager(c).CreateCollectorForTasks(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create task history collector: %w",
+			err,
+		)
+	}
+
+	defer func() {
+		// The collector is a server-side object; destroy it once we're done
+		// to free resources on the server.
+		if err := collector.Destroy(ctx); err != nil {
+			logger.Printf("Error occurred while destroying task collector: %s", err)
+		}
+	}()
+
+	// Position the collector at the oldest matching task before reading
+	// forward through the collected tasks.
+	if err := collector.Rewind(ctx); err != nil {
+		return nil, fmt.Errorf(
+			"failed to rewind task history collector: %w",
+			err,
+		)
+	}
+
+	for {
+		page, err := collector.ReadNextTasks(ctx, taskCollectorPageSize)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve tasks: %w",
+				err,
+			)
+		}
+
+		if len(page) == 0 {
+			break
+		}
+
+		tasks = append(tasks, page...)
+	}
+
+	return tasks, nil
+
+}
+
+// TaskInitiator returns the name of the user that initiated the given task
+// or a description of the scheduled task or alarm which triggered it.
+func TaskInitiator(ti types.TaskInfo) string {
+	switch reason := ti.Reason.(type) {
+	case *types.TaskReasonUser:
+		return reason.UserName
+	case *types.TaskReasonSchedule:
+		return fmt.Sprintf("%s: %s", taskInitiatorSchedule, reason.Name)
+	case *types.TaskReasonAlarm:
+		return fmt.Sprintf("%s: %s", taskInitiatorAlarm, reason.AlarmName)
+	case *types.TaskReasonSystem:
+		return TaskInitiatorSystem
+	default:
+		return TaskInitiatorUnknown
+	}
+}
+
+// TaskErrorMessage returns the error message recorded for the given failed
+// task. If a localized message is unavailable the name of the fault type is
+// returned instead.
+func TaskErrorMessage(ti types.TaskInfo) string {
+	switch {
+	case ti.Error == nil:
+		return "unknown error"
+	case ti.Error.LocalizedMessage != "":
+		return ti.Error.LocalizedMessage
+	case ti.Error.Fault != nil:
+		return reflect.TypeOf(ti.Error.Fault).Elem().Name()
+	default:
+		return "unknown error"
+	}
+}
+
+// FilterTasks applies the given filter options to the collection of tasks,
+// returning the tasks which were not excluded along with the number of tasks
+// which were.
+func FilterTasks(tasks []types.TaskInfo, opts TaskFilterOptions) ([]types.TaskInfo, int) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FilterTasks func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	filtered := make([]types.TaskInfo, 0, len(tasks))
+
+	for _, ti := range tasks {
+		initiator := TaskInitiator(ti)
+
+		switch {
+		case len(opts.IncludedDescriptionIDs) > 0 &&
+			!textutils.InList(ti.DescriptionId, opts.IncludedDescriptionIDs, true):
+			continue
+
+		case len(opts.ExcludedDescriptionIDs) > 0 &&
+			textutils.InList(ti.DescriptionId, opts.ExcludedDescriptionIDs, true):
+			continue
+
+		case len(opts.IncludedUsers) > 0 &&
+			!textutils.InList(initiator, opts.IncludedUsers, true):
+			continue
+
+		case len(opts.ExcludedUsers) > 0 &&
+			textutils.InList(initiator, opts.ExcludedUsers, true):
+			continue
+		}
+
+		filtered = append(filtered, ti)
+	}
+
+	return filtered, len(tasks) - len(filtered)
+
+}
+
+// NewFailedTasksSummary applies the given filter options to the collection
+// of failed tasks and evaluates the remaining tasks against the specified
+// thresholds.
+func NewFailedTasksSummary(
+	tasks []types.TaskInfo,
+	opts TaskFilterOptions,
+	lookback time.Duration,
+	thresholds TaskThresholds,
+) FailedTasksSummary {
+
+	filtered, numExcluded := FilterTasks(tasks, opts)
+
+	return FailedTasksSummary{
+		Tasks:       filtered,
+		NumExcluded: numExcluded,
+		Lookback:    lookback,
+		Thresholds:  thresholds,
+	}
+
+}
+
+// IsCriticalState indicates whether the number of failed tasks has crossed
+// the CRITICAL threshold.
+func (fts FailedTasksSummary) IsCriticalState() bool {
+	return len(fts.Tasks) > fts.Thresholds.Critical
+}
+
+// IsWarningState indicates whether the number of failed tasks has crossed
+// the WARNING threshold, but not the CRITICAL threshold.
+func (fts FailedTasksSummary) IsWarningState() bool {
+	return !fts.IsCriticalState() && len(fts.Tasks) > fts.Thresholds.Warning
+}
+
+// FailedTasksPerfData generates performance data metrics from the given
+// failed tasks summary.
+func FailedTasksPerfData(fts FailedTasksSummary) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "tasks_failed",
+			Value: fmt.Sprintf("%d", len(fts.Tasks)),
+			Warn:  fmt.Sprintf("%d", fts.Thresholds.Warning),
+			Crit:  fmt.Sprintf("%d", fts.Thresholds.Critical),
+			Min:   "0",
+		},
+		{
+			Label: "tasks_failed_excluded",
+			Value: fmt.Sprintf("%d", fts.NumExcluded),
+			Min:   "0",
+		},
+	}
+}
+
+// FailedTasksOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func FailedTasksOneLineCheckSummary(
+	stateLabel string,
+	fts FailedTasksSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"fts": fts,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FailedTasksOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case fts.IsCriticalState() || fts.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d failed tasks detected within the last %v (%d excluded)",
+			stateLabel,
+			len(fts.Tasks),
+			fts.Lookback,
+			fts.NumExcluded,
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: %d failed tasks (within thresholds) detected within the last %v (%d excluded)",
+			stateLabel,
+			len(fts.Tasks),
+			fts.Lookback,
+			fts.NumExcluded,
+		)
+	}
+}
+
+// FailedTasksReport generates a summary of failed tasks along with various
+// verbose details intended to aid in troubleshooting check results at a
+// glance. This information is provided for use with the Long Service Output
+// field commonly displayed on the detailed service check results display in
+// the web UI or in the body of many notifications.
+func FailedTasksReport(
+	c *vim25.Client,
+	fts FailedTasksSummary,
+	opts TaskFilterOptions,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute FailedTasksReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Failed tasks:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, ti := range fts.Tasks {
+		entityName := ti.EntityName
+		if entityName == "" {
+			entityName = "N/A"
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s on %s (queued: %s, initiated by: %s)%s"+
+				"** Error: %s%s",
+			ti.DescriptionId,
+			entityName,
+			ti.QueueTime.Local().Format(time.RFC3339),
+			TaskInitiator(ti),
+			nagios.CheckOutputEOL,
+			TaskErrorMessage(ti),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(fts.Tasks) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Lookback window: %v%s",
+		fts.Lookback,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Failed tasks excluded: %d%s",
+		fts.NumExcluded,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified task IDs to explicitly include (%d): [%v]%s",
+		len(opts.IncludedDescriptionIDs),
+		strings.Join(opts.IncludedDescriptionIDs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified task IDs to explicitly exclude (%d): [%v]%s",
+		len(opts.ExcludedDescriptionIDs),
+		strings.Join(opts.ExcludedDescriptionIDs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified users to explicitly include (%d): [%v]%s",
+		len(opts.IncludedUsers),
+		strings.Join(opts.IncludedUsers, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified users to explicitly exclude (%d): [%v]%s",
+		len(opts.ExcludedUsers),
+		strings.Join(opts.ExcludedUsers, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,240 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// failedTask returns a failed task with the given description ID and reason.
+func failedTask(descriptionID string, reason types.BaseTaskReason) types.TaskInfo {
+	return types.TaskInfo{
+		DescriptionId: descriptionID,
+		State:         types.TaskInfoStateError,
+		Reason:        reason,
+	}
+}
+
+// failedTasks returns failed tasks started by two users and by the system.
+func failedTasks() []types.TaskInfo {
+	return []types.TaskInfo{
+		failedTask("VirtualMachine.powerOn", &types.TaskReasonUser{UserName: `VSPHERE.LOCAL\alice`}),
+		failedTask("VirtualMachine.migrate", &types.TaskReasonUser{UserName: `VSPHERE.LOCAL\bob`}),
+		failedTask("HostSystem.enterMaintenanceMode", &types.TaskReasonSystem{}),
+	}
+}
+
+func TestTaskInitiator(t *testing.T) {
+	tests := map[string]struct {
+		reason types.BaseTaskReason
+		want   string
+	}{
+		"user": {
+			reason: &types.TaskReasonUser{UserName: `VSPHERE.LOCAL\alice`},
+			want:   `VSPHERE.LOCAL\alice`,
+		},
+		"scheduled task": {
+			reason: &types.TaskReasonSchedule{Name: "Nightly snapshot"},
+			want:   "scheduled task: Nightly snapshot",
+		},
+		"alarm": {
+			reason: &types.TaskReasonAlarm{AlarmName: "Host connection failure"},
+			want:   "alarm: Host connection failure",
+		},
+		"system": {
+			reason: &types.TaskReasonSystem{},
+			want:   TaskInitiatorSystem,
+		},
+		"not reported": {
+			want: TaskInitiatorUnknown,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := TaskInitiator(failedTask("VirtualMachine.powerOn", tt.reason)); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTaskErrorMessage(t *testing.T) {
+	tests := map[string]struct {
+		fault *types.LocalizedMethodFault
+		want  string
+	}{
+		"localized message": {
+			fault: &types.LocalizedMethodFault{LocalizedMessage: "operation failed"},
+			want:  "operation failed",
+		},
+		"fault type without message": {
+			fault: &types.LocalizedMethodFault{Fault: &types.NotAuthenticated{}},
+			want:  "NotAuthenticated",
+		},
+		"empty fault": {
+			fault: &types.LocalizedMethodFault{},
+			want:  "unknown error",
+		},
+		"no fault": {
+			want: "unknown error",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ti := failedTask("VirtualMachine.powerOn", &types.TaskReasonSystem{})
+			ti.Error = tt.fault
+
+			if got := TaskErrorMessage(ti); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterTasks(t *testing.T) {
+	tests := map[string]struct {
+		opts         TaskFilterOptions
+		want         []string
+		wantExcluded int
+	}{
+		"no filters": {
+			want: []string{
+				"VirtualMachine.powerOn",
+				"VirtualMachine.migrate",
+				"HostSystem.enterMaintenanceMode",
+			},
+		},
+		"excluded description IDs case-insensitive": {
+			opts: TaskFilterOptions{ExcludedDescriptionIDs: []string{"virtualmachine.poweron"}},
+			want: []string{
+				"VirtualMachine.migrate",
+				"HostSystem.enterMaintenanceMode",
+			},
+			wantExcluded: 1,
+		},
+		"included description IDs": {
+			opts:         TaskFilterOptions{IncludedDescriptionIDs: []string{"HostSystem.enterMaintenanceMode"}},
+			want:         []string{"HostSystem.enterMaintenanceMode"},
+			wantExcluded: 2,
+		},
+		"excluded system initiator": {
+			opts: TaskFilterOptions{ExcludedUsers: []string{TaskInitiatorSystem}},
+			want: []string{
+				"VirtualMachine.powerOn",
+				"VirtualMachine.migrate",
+			},
+			wantExcluded: 1,
+		},
+		"included users": {
+			opts:         TaskFilterOptions{IncludedUsers: []string{`vsphere.local\bob`}},
+			want:         []string{"VirtualMachine.migrate"},
+			wantExcluded: 2,
+		},
+		"included users without matching tasks": {
+			opts:         TaskFilterOptions{IncludedUsers: []string{`VSPHERE.LOCAL\carol`}},
+			want:         []string{},
+			wantExcluded: 3,
+		},
+		"included description ID from excluded user": {
+			opts: TaskFilterOptions{
+				IncludedDescriptionIDs: []string{"VirtualMachine.powerOn"},
+				ExcludedUsers:          []string{`VSPHERE.LOCAL\alice`},
+			},
+			want:         []string{},
+			wantExcluded: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, numExcluded := FilterTasks(failedTasks(), tt.opts)
+
+			got := make([]string, 0, len(filtered))
+			for _, ti := range filtered {
+				got = append(got, ti.DescriptionId)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded tasks; got %d", tt.wantExcluded, numExcluded)
+			}
+		})
+	}
+}
+
+func TestFailedTasksSummaryState(t *testing.T) {
+	thresholds := TaskThresholds{Warning: 0, Critical: 2}
+
+	tests := map[string]struct {
+		tasks        []types.TaskInfo
+		opts         TaskFilterOptions
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no failed tasks": {},
+		"failed tasks above WARNING threshold": {
+			tasks:       failedTasks()[:2],
+			wantWarning: true,
+		},
+		"failed tasks above CRITICAL threshold": {
+			tasks:        failedTasks(),
+			wantCritical: true,
+		},
+		"CRITICAL state avoided by exclusions": {
+			tasks:       failedTasks(),
+			opts:        TaskFilterOptions{ExcludedUsers: []string{TaskInitiatorSystem}},
+			wantWarning: true,
+		},
+		"all failed tasks excluded": {
+			tasks: failedTasks(),
+			opts:  TaskFilterOptions{IncludedUsers: []string{`VSPHERE.LOCAL\carol`}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := NewFailedTasksSummary(tt.tasks, tt.opts, 24*time.Hour, thresholds)
+
+			if got := summary.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := summary.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestFailedTasksPerfData(t *testing.T) {
+	summary := NewFailedTasksSummary(
+		failedTasks(),
+		TaskFilterOptions{ExcludedUsers: []string{TaskInitiatorSystem}},
+		24*time.Hour,
+		TaskThresholds{Warning: 0, Critical: 2},
+	)
+
+	want := []nagios.PerformanceData{
+		{Label: "tasks_failed", Value: "2", Warn: "0", Crit: "2", Min: "0"},
+		{Label: "tasks_failed_excluded", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, FailedTasksPerfData(summary)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
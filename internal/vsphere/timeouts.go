@@ -0,0 +1,186 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeoutSuggestionMultiplier is applied to the plugin runtime recorded when
+// the context deadline was reached in order to provide a suggested timeout
+// value.
+const timeoutSuggestionMultiplier float64 = 2
+
+// pluginStart is used to approximate the total plugin runtime at the point
+// where the context deadline is reached. Access is guarded by the phases
+// mutex.
+var pluginStart = time.Now()
+
+// PhaseTiming records how long a specific plugin execution phase (e.g.,
+// login or object retrieval) took and whether the phase was interrupted by
+// the plugin runtime timeout.
+type PhaseTiming struct {
+	Name     string
+	Duration time.Duration
+	TimedOut bool
+}
+
+// phases records the execution phases completed (or interrupted) during
+// plugin execution. The collection is used to provide diagnostics when the
+// plugin runtime timeout is reached.
+var phases struct {
+	sync.Mutex
+	timings []PhaseTiming
+}
+
+// PhaseTimeoutError indicates that the plugin runtime timeout (context
+// deadline) was reached while performing a specific operation.
+type PhaseTimeoutError struct {
+	// Phase is the name of the operation which was interrupted.
+	Phase string
+
+	// Elapsed is the time spent in the interrupted operation.
+	Elapsed time.Duration
+
+	// Err is the original error returned by the interrupted operation.
+	Err error
+}
+
+// Error satisfies the error interface.
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"timeout reached during %s after %v: %v",
+		e.Phase,
+		e.Elapsed.Round(time.Millisecond),
+		e.Err,
+	)
+}
+
+// Unwrap exposes the original error along with context.DeadlineExceeded so
+// that callers (and error annotation logic) can match on either.
+func (e *PhaseTimeoutError) Unwrap() []error {
+	return []error{e.Err, context.DeadlineExceeded}
+}
+
+// recordPhase records the duration of the named phase starting at the given
+// time. If the given error (or the context) indicates that the context
+// deadline was reached the error is wrapped in a PhaseTimeoutError noting
+// which operation timed out. The error is returned unmodified otherwise. The
+// phase is also recorded as a span if OpenTelemetry tracing is enabled.
+func recordPhase(ctx context.Context, name string, start time.Time, err error) error {
+	elapsed := time.Since(start)
+
+	timedOut := err != nil &&
+		(errors.Is(err, context.DeadlineExceeded) ||
+			errors.Is(ctx.Err(), context.DeadlineExceeded))
+
+	phases.Lock()
+	phases.timings = append(phases.timings, PhaseTiming{
+		Name:     name,
+		Duration: elapsed,
+		TimedOut: timedOut,
+	})
+	phases.Unlock()
+
+	recordSpan(name, start, start.Add(elapsed), err, timedOut)
+
+	if !timedOut {
+		return err
+	}
+
+	// Retain the innermost phase if nested phases time out.
+	var phaseErr *PhaseTimeoutError
+	if errors.As(err, &phaseErr) {
+		return err
+	}
+
+	return &PhaseTimeoutError{
+		Phase:   name,
+		Elapsed: elapsed,
+		Err:     err,
+	}
+}
+
+// TrackPhase executes the given function as a named plugin execution phase,
+// recording how long it took. If the plugin runtime timeout is reached while
+// the function executes the returned error notes which operation timed out.
+func TrackPhase(ctx context.Context, name string, fn func() error) error {
+	start := time.Now()
+
+	return recordPhase(ctx, name, start, fn())
+}
+
+// PhaseTimings returns a copy of the plugin execution phases recorded thus
+// far.
+func PhaseTimings() []PhaseTiming {
+	phases.Lock()
+	defer phases.Unlock()
+
+	timings := make([]PhaseTiming, len(phases.timings))
+	copy(timings, phases.timings)
+
+	return timings
+}
+
+// ResetPhaseTimings discards the plugin execution phases recorded thus far
+// and restarts the runtime used when providing timeout diagnostics. This is
+// used by long-running applications (e.g., the exporter) to scope recorded
+// phases to a single collection.
+func ResetPhaseTimings() {
+	phases.Lock()
+	defer phases.Unlock()
+
+	phases.timings = nil
+	pluginStart = time.Now()
+}
+
+// TimeoutDiagnostics returns a human readable summary of the recorded plugin
+// execution phases along with a suggested plugin timeout value based on the
+// runtime observed when the timeout was reached.
+func TimeoutDiagnostics() string {
+	phases.Lock()
+	runtime := time.Since(pluginStart)
+	phases.Unlock()
+
+	suggested := int(math.Ceil(runtime.Seconds() * timeoutSuggestionMultiplier))
+
+	timings := PhaseTimings()
+
+	var summary string
+	switch {
+	case len(timings) == 0:
+		summary = "no phases recorded"
+	default:
+		entries := make([]string, 0, len(timings))
+		for _, timing := range timings {
+			entry := fmt.Sprintf(
+				"%s: %v",
+				timing.Name,
+				timing.Duration.Round(time.Millisecond),
+			)
+			if timing.TimedOut {
+				entry += " (timed out)"
+			}
+			entries = append(entries, entry)
+		}
+		summary = strings.Join(entries, ", ")
+	}
+
+	return fmt.Sprintf(
+		"phase timings: [%s]; plugin runtime at timeout: %v; consider increasing the plugin timeout value to at least %d seconds",
+		summary,
+		runtime.Round(time.Millisecond),
+		suggested,
+	)
+}
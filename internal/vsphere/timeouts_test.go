@@ -0,0 +1,63 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordPhaseTimeout(t *testing.T) {
+	ResetPhaseTimings()
+	defer ResetPhaseTimings()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	err := TrackPhase(ctx, "login", func() error { return ctx.Err() })
+
+	var phaseErr *PhaseTimeoutError
+	if !errors.As(err, &phaseErr) {
+		t.Fatalf("want PhaseTimeoutError; got %v", err)
+	}
+	if phaseErr.Phase != "login" {
+		t.Errorf("want phase %q; got %q", "login", phaseErr.Phase)
+	}
+
+	timings := PhaseTimings()
+	if len(timings) != 1 || !timings[0].TimedOut {
+		t.Errorf("want one timed out phase; got %+v", timings)
+	}
+}
+
+// TestResetTimings asserts that timings recorded by one exporter collection
+// are not carried over to the next.
+func TestResetTimings(t *testing.T) {
+	defer ResetPhaseTimings()
+	defer ResetPropertyRetrievalTimings()
+
+	for collection := 1; collection <= 3; collection++ {
+		ResetPhaseTimings()
+		ResetPropertyRetrievalTimings()
+
+		_ = TrackPhase(context.Background(), "retrieve hosts", func() error { return nil })
+		recordPropertyRetrieval("HostSystem", time.Millisecond)
+		recordPropertyRetrieval("HostSystem", time.Millisecond)
+
+		if got := len(PhaseTimings()); got != 1 {
+			t.Errorf("collection %d: want 1 phase; got %d", collection, got)
+		}
+
+		retrievals := PropertyRetrievalTimings()
+		if len(retrievals) != 1 || retrievals[0].Retrievals != 2 {
+			t.Errorf("collection %d: want 2 HostSystem retrievals; got %+v", collection, retrievals)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// ErrTLSConfigInvalid indicates that the user-specified TLS settings could
+// not be used to build a TLS configuration.
+var ErrTLSConfigInvalid = errors.New("invalid TLS configuration")
+
+// tlsVersions maps supported minimum TLS version values to the equivalent
+// crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// supportedTLSVersions returns the list of supported minimum TLS version
+// values in ascending order.
+func supportedTLSVersions() []string {
+	return []string{"1.0", "1.1", "1.2", "1.3"}
+}
+
+// configureTLS builds the TLS configuration for the given SOAP client from
+// the certificate trust setting and the user-specified TLS settings. If
+// trustCert is true certificate verification is disabled entirely and the CA
+// bundle and hostname verification settings are ignored.
+func configureTLS(sc *soap.Client, trustCert bool, opts ConnectionOptions) error {
+	tlsConfig := sc.DefaultTransport().TLSClientConfig
+	tlsConfig.InsecureSkipVerify = trustCert
+
+	if opts.TLSMinVersion != "" {
+		version, ok := tlsVersions[opts.TLSMinVersion]
+		if !ok {
+			return fmt.Errorf(
+				"unsupported minimum TLS version %q; supported versions: %s: %w",
+				opts.TLSMinVersion,
+				strings.Join(supportedTLSVersions(), ", "),
+				ErrTLSConfigInvalid,
+			)
+		}
+
+		tlsConfig.MinVersion = version
+	}
+
+	if trustCert {
+		return nil
+	}
+
+	if opts.CAFile != "" {
+		if err := sc.SetRootCAs(opts.CAFile); err != nil {
+			return fmt.Errorf(
+				"failed to load CA certificate bundle %q: %v: %w",
+				opts.CAFile,
+				err,
+				ErrTLSConfigInvalid,
+			)
+		}
+
+		logger.Printf("using CA certificate bundle %q", opts.CAFile)
+	}
+
+	if opts.SkipHostnameVerify {
+		logger.Printf("server certificate hostname verification disabled")
+
+		// Disable the standard verification (which includes the hostname
+		// check) and verify the certificate chain ourselves.
+		roots := tlsConfig.RootCAs
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyCertChain(cs.PeerCertificates, roots)
+		}
+	}
+
+	return nil
+}
+
+// verifyCertChain verifies the given peer certificate chain against the
+// specified root certificate pool (or the system pool if nil) without
+// verifying the server hostname.
+func verifyCertChain(certs []*x509.Certificate, roots *x509.CertPool) error {
+	if len(certs) == 0 {
+		return errors.New("server did not provide a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+
+	return err
+}
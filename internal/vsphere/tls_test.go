@@ -0,0 +1,208 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// writeServerCA writes the certificate used by the given test server to a
+// PEM encoded file for use as a CA certificate bundle.
+func writeServerCA(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: srv.Certificate().Raw,
+	})
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	return filename
+}
+
+func TestConfigureTLSMinVersion(t *testing.T) {
+	tests := map[string]struct {
+		minVersion string
+		want       uint16
+		wantErr    bool
+	}{
+		"default":     {minVersion: "", want: 0},
+		"TLS 1.0":     {minVersion: "1.0", want: tls.VersionTLS10},
+		"TLS 1.2":     {minVersion: "1.2", want: tls.VersionTLS12},
+		"TLS 1.3":     {minVersion: "1.3", want: tls.VersionTLS13},
+		"unsupported": {minVersion: "1.4", wantErr: true},
+		"prefixed":    {minVersion: "TLS1.2", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, _ := url.Parse("https://vc1.example.com/sdk")
+			sc := soap.NewClient(u, false)
+
+			err := configureTLS(sc, false, ConnectionOptions{TLSMinVersion: tt.minVersion})
+			if tt.wantErr {
+				if !errors.Is(err, ErrTLSConfigInvalid) {
+					t.Fatalf("want %v error; got %v", ErrTLSConfigInvalid, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if got := sc.DefaultTransport().TLSClientConfig.MinVersion; got != tt.want {
+				t.Errorf("want minimum version %#x; got %#x", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestConfigureTLSVerification asserts how the CA bundle, hostname
+// verification and certificate trust settings affect connections to a server
+// using a self-signed certificate issued for 127.0.0.1 and example.com.
+func TestConfigureTLSVerification(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	caFile := writeServerCA(t, srv)
+	srvURL, _ := url.Parse(srv.URL)
+
+	// "localhost" is not a name included in the test server certificate.
+	localhostURL := *srvURL
+	localhostURL.Host = "localhost:" + srvURL.Port()
+
+	tests := map[string]struct {
+		target     *url.URL
+		trustCert  bool
+		opts       ConnectionOptions
+		wantErr    error
+		wantDialOK bool
+	}{
+		"system roots": {
+			target: srvURL,
+		},
+		"CA bundle": {
+			target:     srvURL,
+			opts:       ConnectionOptions{CAFile: caFile},
+			wantDialOK: true,
+		},
+		"CA bundle with hostname mismatch": {
+			target: &localhostURL,
+			opts:   ConnectionOptions{CAFile: caFile},
+		},
+		"CA bundle skipping hostname verification": {
+			target:     &localhostURL,
+			opts:       ConnectionOptions{CAFile: caFile, SkipHostnameVerify: true},
+			wantDialOK: true,
+		},
+		"skipping hostname verification still verifies chain": {
+			target: &localhostURL,
+			opts:   ConnectionOptions{SkipHostnameVerify: true},
+		},
+		"missing CA bundle": {
+			target:  srvURL,
+			opts:    ConnectionOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantErr: ErrTLSConfigInvalid,
+		},
+		"trusted certificate ignores CA bundle": {
+			target:     &localhostURL,
+			trustCert:  true,
+			opts:       ConnectionOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+			wantDialOK: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc := soap.NewClient(tt.target, tt.trustCert)
+
+			err := configureTLS(sc, tt.trustCert, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v error; got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			transport := sc.DefaultTransport()
+			transport.Proxy = nil
+
+			resp, err := (&http.Client{Transport: transport}).Get(tt.target.String())
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+
+			switch {
+			case tt.wantDialOK && err != nil:
+				t.Errorf("want successful connection; got %v", err)
+			case !tt.wantDialOK && err == nil:
+				t.Error("want certificate verification error; got nil")
+			}
+		})
+	}
+}
+
+func TestConfigureTLSMinVersionHandshake(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := writeServerCA(t, srv)
+	srvURL, _ := url.Parse(srv.URL)
+
+	tests := map[string]struct {
+		minVersion string
+		wantDialOK bool
+	}{
+		"minimum version supported by server":  {minVersion: "1.2", wantDialOK: true},
+		"minimum version above server maximum": {minVersion: "1.3"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sc := soap.NewClient(srvURL, false)
+
+			opts := ConnectionOptions{CAFile: caFile, TLSMinVersion: tt.minVersion}
+			if err := configureTLS(sc, false, opts); err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			transport := sc.DefaultTransport()
+			transport.Proxy = nil
+
+			resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+			if err == nil {
+				_ = resp.Body.Close()
+			}
+
+			switch {
+			case tt.wantDialOK && err != nil:
+				t.Errorf("want successful connection; got %v", err)
+			case !tt.wantDialOK && err == nil:
+				t.Error("want protocol version error; got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineToolsRunningNoIP indicates that one or more powered on
+// VirtualMachines with VMware Tools running do not report an IP Address
+// after the specified grace period.
+var ErrVirtualMachineToolsRunningNoIP = errors.New("vmware tools running but no ip address reported")
+
+// VMToolsNoIPSummary represents the results of evaluating powered on
+// VirtualMachines for a running VMware Tools instance without a reported
+// (usable) IP Address.
+type VMToolsNoIPSummary struct {
+	// VMsNoIP is the collection of VirtualMachines with VMware Tools running
+	// but no IP Address reported after the grace period has elapsed.
+	VMsNoIP []mo.VirtualMachine
+
+	// VMsWithinGracePeriod is the collection of VirtualMachines with VMware
+	// Tools running but no IP Address reported which have not yet been
+	// powered on for longer than the grace period.
+	VMsWithinGracePeriod []mo.VirtualMachine
+
+	// NumToolsNotRunning is the number of VirtualMachines without a running
+	// VMware Tools instance. These VirtualMachines cannot report an IP
+	// Address and are not evaluated.
+	NumToolsNotRunning int
+
+	// NumEvaluated is the number of VirtualMachines with VMware Tools
+	// running evaluated for a reported IP Address.
+	NumEvaluated int
+
+	// GracePeriod is the amount of time after power on before a missing IP
+	// Address is considered a problem.
+	GracePeriod time.Duration
+}
+
+// isUsableIPAddress indicates whether the given IP Address is a valid
+// address other than a loopback or link-local address. Link-local addresses
+// (e.g., 169.254.x.x) are typically self-assigned when address
+// configuration fails.
+func isUsableIPAddress(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	return !ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsUnspecified()
+}
+
+// VMGuestIPAddresses returns the usable IP Addresses reported by VMware
+// Tools for the given VirtualMachine.
+func VMGuestIPAddresses(vm mo.VirtualMachine) []string {
+	if vm.Guest == nil {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var addrs []string
+
+	add := func(addr string) {
+		if !isUsableIPAddress(addr) {
+			return
+		}
+		if _, ok := seen[addr]; ok {
+			return
+		}
+		seen[addr] = struct{}{}
+		addrs = append(addrs, addr)
+	}
+
+	add(vm.Guest.IpAddress)
+
+	for _, nic := range vm.Guest.Net {
+		for _, addr := range nic.IpAddress {
+			add(addr)
+		}
+	}
+
+	return addrs
+}
+
+// IsVMToolsRunning indicates whether VMware Tools is running within the
+// guest of the given VirtualMachine.
+func IsVMToolsRunning(vm mo.VirtualMachine) bool {
+	return vm.Guest != nil &&
+		vm.Guest.ToolsRunningStatus == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+}
+
+// NewVMToolsNoIPSummary evaluates the given VirtualMachines for a running
+// VMware Tools instance without a reported (usable) IP Address.
+// VirtualMachines powered on for less than the specified grace period are
+// tracked separately and do not contribute to a non-OK state.
+func NewVMToolsNoIPSummary(vms []mo.VirtualMachine, gracePeriod time.Duration) VMToolsNoIPSummary {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMToolsNoIPSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	summary := VMToolsNoIPSummary{
+		GracePeriod: gracePeriod,
+	}
+
+	for _, vm := range vms {
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			continue
+		}
+
+		if !IsVMToolsRunning(vm) {
+			summary.NumToolsNotRunning++
+
+			continue
+		}
+
+		summary.NumEvaluated++
+
+		if len(VMGuestIPAddresses(vm)) > 0 {
+			continue
+		}
+
+		uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+		switch {
+		case uptime < gracePeriod:
+			summary.VMsWithinGracePeriod = append(summary.VMsWithinGracePeriod, vm)
+		default:
+			summary.VMsNoIP = append(summary.VMsNoIP, vm)
+		}
+	}
+
+	sort.Slice(summary.VMsNoIP, func(i, j int) bool {
+		return strings.ToLower(summary.VMsNoIP[i].Name) < strings.ToLower(summary.VMsNoIP[j].Name)
+	})
+
+	sort.Slice(summary.VMsWithinGracePeriod, func(i, j int) bool {
+		return strings.ToLower(summary.VMsWithinGracePeriod[i].Name) < strings.ToLower(summary.VMsWithinGracePeriod[j].Name)
+	})
+
+	return summary
+
+}
+
+// IsWarningState indicates whether any evaluated VirtualMachine with VMware
+// Tools running has not reported an IP Address after the grace period.
+func (s VMToolsNoIPSummary) IsWarningState() bool {
+	return len(s.VMsNoIP) > 0
+}
+
+// VMToolsNoIPPerfData generates performance data metrics from the given
+// evaluation results.
+func VMToolsNoIPPerfData(s VMToolsNoIPSummary) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_tools_running",
+			Value: fmt.Sprintf("%d", s.NumEvaluated),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_not_running",
+			Value: fmt.Sprintf("%d", s.NumToolsNotRunning),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_running_no_ip",
+			Value: fmt.Sprintf("%d", len(s.VMsNoIP)),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_running_no_ip_grace_period",
+			Value: fmt.Sprintf("%d", len(s.VMsWithinGracePeriod)),
+			Min:   "0",
+		},
+	}
+}
+
+// VMToolsNoIPOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMToolsNoIPOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	s VMToolsNoIPSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"s":                s,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMToolsNoIPOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case s.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with VMware Tools running but no IP Address after %v grace period (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(s.VMsNoIP),
+			s.GracePeriod,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with VMware Tools running but no IP Address detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMToolsNoIPReport generates a summary of VMs with VMware Tools running but
+// no reported IP Address along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMToolsNoIPReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	s VMToolsNoIPSummary,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMToolsNoIPReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMs := func(header string, vms []mo.VirtualMachine) {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s:%s%s",
+			header,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		if len(vms) == 0 {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+			return
+		}
+
+		for _, vm := range vms {
+			uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+
+			var hostname string
+			if vm.Guest != nil {
+				hostname = vm.Guest.HostName
+			}
+			if hostname == "" {
+				hostname = "unknown"
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (guest hostname: %s, uptime: %v)%s",
+				vm.Name,
+				hostname,
+				uptime,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	writeVMs(
+		fmt.Sprintf(
+			"VMs with VMware Tools running but no IP Address after %v grace period",
+			s.GracePeriod,
+		),
+		s.VMsNoIP,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	writeVMs(
+		"VMs with VMware Tools running but no IP Address within grace period",
+		s.VMsWithinGracePeriod,
+	)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func noIPVM(name string, toolsRunning bool, uptimeSeconds int32, addrs ...string) mo.VirtualMachine {
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Guest: &types.GuestInfo{
+			ToolsRunningStatus: string(types.VirtualMachineToolsRunningStatusGuestToolsNotRunning),
+		},
+	}
+	vm.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOn
+	vm.Summary.QuickStats.UptimeSeconds = uptimeSeconds
+
+	if toolsRunning {
+		vm.Guest.ToolsRunningStatus = string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+	}
+
+	if len(addrs) > 0 {
+		vm.Guest.IpAddress = addrs[0]
+		vm.Guest.Net = []types.GuestNicInfo{
+			{IpAddress: addrs},
+		}
+	}
+
+	return vm
+}
+
+func TestIsUsableIPAddress(t *testing.T) {
+	tests := map[string]struct {
+		addr string
+		want bool
+	}{
+		"IPv4":              {addr: "192.168.1.10", want: true},
+		"IPv6":              {addr: "2001:db8::10", want: true},
+		"IPv4 link-local":   {addr: "169.254.10.20"},
+		"IPv6 link-local":   {addr: "fe80::1"},
+		"IPv4 loopback":     {addr: "127.0.0.1"},
+		"IPv6 loopback":     {addr: "::1"},
+		"IPv4 unspecified":  {addr: "0.0.0.0"},
+		"IPv6 unspecified":  {addr: "::"},
+		"empty":             {addr: ""},
+		"not an IP Address": {addr: "vm1.example.com"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isUsableIPAddress(tt.addr); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMGuestIPAddresses(t *testing.T) {
+	noGuest := noIPVM("vm1", true, 3600)
+	noGuest.Guest = nil
+
+	multipleNICs := noIPVM("vm1", true, 3600, "192.168.1.10", "fe80::1")
+	multipleNICs.Guest.Net = append(multipleNICs.Guest.Net, types.GuestNicInfo{
+		IpAddress: []string{"10.0.0.5", "192.168.1.10"},
+	})
+
+	tests := map[string]struct {
+		vm   mo.VirtualMachine
+		want []string
+	}{
+		"no guest details": {
+			vm: noGuest,
+		},
+		"no addresses": {
+			vm: noIPVM("vm1", true, 3600),
+		},
+		"only unusable addresses": {
+			vm: noIPVM("vm1", true, 3600, "169.254.10.20", "127.0.0.1", "fe80::1"),
+		},
+		"duplicates removed across NICs": {
+			vm:   multipleNICs,
+			want: []string{"192.168.1.10", "10.0.0.5"},
+		},
+		"usable address on NIC only": {
+			vm:   noIPVM("vm1", true, 3600, "fe80::1", "2001:db8::10"),
+			want: []string{"2001:db8::10"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, VMGuestIPAddresses(tt.vm)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMToolsNoIPSummary(t *testing.T) {
+	gracePeriod := 10 * time.Minute
+
+	poweredOff := noIPVM("vm9", true, 0)
+	poweredOff.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOff
+
+	tests := map[string]struct {
+		vms                   []mo.VirtualMachine
+		wantNoIP              int
+		wantWithinGracePeriod int
+		wantToolsNotRunning   int
+		wantEvaluated         int
+	}{
+		"IP Address reported": {
+			vms: []mo.VirtualMachine{
+				noIPVM("vm1", true, 3600, "192.168.1.10"),
+				noIPVM("vm2", true, 3600, "fe80::1", "2001:db8::10"),
+			},
+			wantEvaluated: 2,
+		},
+		"no IP Address after grace period": {
+			vms: []mo.VirtualMachine{
+				noIPVM("vm1", true, 3600, "192.168.1.10"),
+				noIPVM("vm2", true, 3600),
+			},
+			wantNoIP:      1,
+			wantEvaluated: 2,
+		},
+		"no IP Address at end of grace period": {
+			vms:           []mo.VirtualMachine{noIPVM("vm1", true, 600)},
+			wantNoIP:      1,
+			wantEvaluated: 1,
+		},
+		"no IP Address within grace period": {
+			vms:                   []mo.VirtualMachine{noIPVM("vm1", true, 60)},
+			wantWithinGracePeriod: 1,
+			wantEvaluated:         1,
+		},
+		"only link-local and loopback addresses": {
+			vms:           []mo.VirtualMachine{noIPVM("vm1", true, 3600, "169.254.10.20", "127.0.0.1", "fe80::1")},
+			wantNoIP:      1,
+			wantEvaluated: 1,
+		},
+		"tools not running and powered off VMs are not evaluated": {
+			vms: []mo.VirtualMachine{
+				noIPVM("vm1", false, 3600),
+				poweredOff,
+			},
+			wantToolsNotRunning: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := NewVMToolsNoIPSummary(tt.vms, gracePeriod)
+
+			if got := summary.IsWarningState(); got != (tt.wantNoIP > 0) {
+				t.Errorf("want WARNING state %t; got %t", tt.wantNoIP > 0, got)
+			}
+			if got := len(summary.VMsNoIP); got != tt.wantNoIP {
+				t.Errorf("want %d VMs without IP Address; got %d", tt.wantNoIP, got)
+			}
+			if got := len(summary.VMsWithinGracePeriod); got != tt.wantWithinGracePeriod {
+				t.Errorf("want %d VMs within grace period; got %d", tt.wantWithinGracePeriod, got)
+			}
+			if summary.NumToolsNotRunning != tt.wantToolsNotRunning {
+				t.Errorf("want %d VMs without VMware Tools running; got %d", tt.wantToolsNotRunning, summary.NumToolsNotRunning)
+			}
+			if summary.NumEvaluated != tt.wantEvaluated {
+				t.Errorf("want %d VMs evaluated; got %d", tt.wantEvaluated, summary.NumEvaluated)
+			}
+		})
+	}
+}
+
+func TestVMToolsNoIPPerfData(t *testing.T) {
+	summary := NewVMToolsNoIPSummary(
+		[]mo.VirtualMachine{
+			noIPVM("vm3", true, 3600),
+			noIPVM("VM2", true, 3600),
+			noIPVM("vm1", true, 60),
+			noIPVM("vm4", false, 3600),
+			noIPVM("vm5", true, 3600, "192.168.1.10"),
+		},
+		10*time.Minute,
+	)
+
+	var names []string
+	for _, vm := range summary.VMsNoIP {
+		names = append(names, vm.Name)
+	}
+	if d := cmp.Diff([]string{"VM2", "vm3"}, names); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_tools_running", Value: "4", Min: "0"},
+		{Label: "vms_tools_not_running", Value: "1", Min: "0"},
+		{Label: "vms_tools_running_no_ip", Value: "2", Min: "0"},
+		{Label: "vms_tools_running_no_ip_grace_period", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMToolsNoIPPerfData(summary)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,417 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineToolsVersionNonCompliant indicates that one or more
+// VirtualMachines have an outdated or unsupported VMware Tools version.
+var ErrVirtualMachineToolsVersionNonCompliant = errors.New("virtual machines with non-compliant VMware Tools version found")
+
+// VMToolsVersion represents the VMware Tools version details for a
+// VirtualMachine.
+type VMToolsVersion struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// Version is the numeric VMware Tools version reported by the vSphere
+	// API. A value of zero indicates that VMware Tools is not installed or
+	// the version is unknown.
+	Version int
+
+	// VersionStatus is the VMware Tools version status as reported by the
+	// toolsVersionStatus2 property.
+	VersionStatus types.VirtualMachineToolsVersionStatus
+
+	// MinVersion is the minimum acceptable VMware Tools version. A value of
+	// zero disables this check.
+	MinVersion int
+}
+
+// VMToolsVersionSet is a collection of VMToolsVersion values.
+type VMToolsVersionSet []VMToolsVersion
+
+// FormatToolsVersion converts the numeric VMware Tools version reported by
+// the vSphere API to the familiar MAJOR.MINOR.PATCH format (e.g., 12352 is
+// converted to 12.2.0).
+func FormatToolsVersion(version int) string {
+	if version <= 0 {
+		return "unknown"
+	}
+
+	return fmt.Sprintf(
+		"%d.%d.%d",
+		version>>10,
+		(version>>5)&0x1f,
+		version&0x1f,
+	)
+}
+
+// IsInstalled indicates whether VMware Tools is installed.
+func (vtv VMToolsVersion) IsInstalled() bool {
+	return vtv.VersionStatus != types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled &&
+		vtv.Version > 0
+}
+
+// IsBelowMinVersion indicates whether the installed VMware Tools version is
+// older than the specified minimum version.
+func (vtv VMToolsVersion) IsBelowMinVersion() bool {
+	return vtv.MinVersion > 0 && vtv.IsInstalled() && vtv.Version < vtv.MinVersion
+}
+
+// IsUnsupported indicates whether the installed VMware Tools version is
+// unsupported (too old, too new or blacklisted).
+func (vtv VMToolsVersion) IsUnsupported() bool {
+	switch vtv.VersionStatus {
+	case types.VirtualMachineToolsVersionStatusGuestToolsTooOld,
+		types.VirtualMachineToolsVersionStatusGuestToolsTooNew,
+		types.VirtualMachineToolsVersionStatusGuestToolsBlacklisted:
+		return true
+	default:
+		return false
+	}
+}
+
+// NeedsUpgrade indicates whether the installed VMware Tools version is
+// supported, but a newer version is available.
+func (vtv VMToolsVersion) NeedsUpgrade() bool {
+	switch vtv.VersionStatus {
+	case types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade,
+		types.VirtualMachineToolsVersionStatusGuestToolsSupportedOld:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCriticalState indicates whether the installed VMware Tools version is
+// unsupported or older than the specified minimum version.
+func (vtv VMToolsVersion) IsCriticalState() bool {
+	return vtv.IsUnsupported() || vtv.IsBelowMinVersion()
+}
+
+// IsWarningState indicates whether the installed VMware Tools version needs
+// to be upgraded, but is not otherwise in a CRITICAL state.
+func (vtv VMToolsVersion) IsWarningState() bool {
+	return !vtv.IsCriticalState() && vtv.NeedsUpgrade()
+}
+
+// NewVMToolsVersionSet evaluates the VMware Tools version details for the
+// given VirtualMachines. VirtualMachines with a VMware Tools version older
+// than the given minimum version are flagged; a minimum version of zero
+// disables this check.
+func NewVMToolsVersionSet(vms []mo.VirtualMachine, minVersion int) VMToolsVersionSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMToolsVersionSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMToolsVersionSet, 0, len(vms))
+	for _, vm := range vms {
+		// The version is reported as a string, but is always numeric. Treat
+		// an empty or invalid value as unknown.
+		version, _ := strconv.Atoi(vm.Guest.ToolsVersion)
+
+		set = append(set, VMToolsVersion{
+			VMName:        vm.Name,
+			PowerState:    vm.Runtime.PowerState,
+			Version:       version,
+			VersionStatus: types.VirtualMachineToolsVersionStatus(vm.Guest.ToolsVersionStatus2),
+			MinVersion:    minVersion,
+		})
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// count returns the number of items in the set for which the given
+// predicate is true.
+func (set VMToolsVersionSet) count(fn func(VMToolsVersion) bool) int {
+	var num int
+	for _, vtv := range set {
+		if fn(vtv) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCurrent returns the number of VirtualMachines with a current (or
+// supported newer) VMware Tools version.
+func (set VMToolsVersionSet) NumCurrent() int {
+	return set.count(func(vtv VMToolsVersion) bool {
+		return vtv.VersionStatus == types.VirtualMachineToolsVersionStatusGuestToolsCurrent ||
+			vtv.VersionStatus == types.VirtualMachineToolsVersionStatusGuestToolsSupportedNew
+	})
+}
+
+// NumUnmanaged returns the number of VirtualMachines with VMware Tools not
+// managed by VMware (e.g., open-vm-tools).
+func (set VMToolsVersionSet) NumUnmanaged() int {
+	return set.count(func(vtv VMToolsVersion) bool {
+		return vtv.VersionStatus == types.VirtualMachineToolsVersionStatusGuestToolsUnmanaged
+	})
+}
+
+// NumNotInstalled returns the number of VirtualMachines without VMware Tools
+// installed.
+func (set VMToolsVersionSet) NumNotInstalled() int {
+	return set.count(func(vtv VMToolsVersion) bool {
+		return !vtv.IsInstalled()
+	})
+}
+
+// NumNeedUpgrade returns the number of VirtualMachines with a supported
+// VMware Tools version for which a newer version is available.
+func (set VMToolsVersionSet) NumNeedUpgrade() int {
+	return set.count(VMToolsVersion.NeedsUpgrade)
+}
+
+// NumUnsupported returns the number of VirtualMachines with an unsupported
+// VMware Tools version.
+func (set VMToolsVersionSet) NumUnsupported() int {
+	return set.count(VMToolsVersion.IsUnsupported)
+}
+
+// NumBelowMinVersion returns the number of VirtualMachines with a VMware
+// Tools version older than the specified minimum version.
+func (set VMToolsVersionSet) NumBelowMinVersion() int {
+	return set.count(VMToolsVersion.IsBelowMinVersion)
+}
+
+// NumCritical returns the number of VirtualMachines in a CRITICAL state.
+func (set VMToolsVersionSet) NumCritical() int {
+	return set.count(VMToolsVersion.IsCriticalState)
+}
+
+// NumWarning returns the number of VirtualMachines in a WARNING state.
+func (set VMToolsVersionSet) NumWarning() int {
+	return set.count(VMToolsVersion.IsWarningState)
+}
+
+// IsCriticalState indicates whether any VirtualMachine in the set has an
+// unsupported VMware Tools version or one older than the specified minimum
+// version.
+func (set VMToolsVersionSet) IsCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has a
+// VMware Tools version which needs to be upgraded.
+func (set VMToolsVersionSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VMToolsVersionPerfData generates performance data metrics from the given
+// evaluation results.
+func VMToolsVersionPerfData(set VMToolsVersionSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_tools_current",
+			Value: fmt.Sprintf("%d", set.NumCurrent()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_unmanaged",
+			Value: fmt.Sprintf("%d", set.NumUnmanaged()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_need_upgrade",
+			Value: fmt.Sprintf("%d", set.NumNeedUpgrade()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_unsupported",
+			Value: fmt.Sprintf("%d", set.NumUnsupported()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_below_min_version",
+			Value: fmt.Sprintf("%d", set.NumBelowMinVersion()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_tools_not_installed",
+			Value: fmt.Sprintf("%d", set.NumNotInstalled()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMToolsVersionOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMToolsVersionOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMToolsVersionSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMToolsVersionOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState() || set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with non-compliant VMware Tools version (%d CRITICAL, %d WARNING; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumCritical(),
+			set.NumWarning(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with non-compliant VMware Tools version detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMToolsVersionReport generates a summary of VMs with non-compliant VMware
+// Tools versions along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMToolsVersionReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMToolsVersionSet,
+	minVersion int,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMToolsVersionReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMs := func(header string, fn func(VMToolsVersion) bool) {
+		if report.Len() > 0 {
+			_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s:%s%s",
+			header,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		var found bool
+		for _, vtv := range set {
+			if !fn(vtv) {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (%s, version: %s [%d], status: %s)%s",
+				vtv.VMName,
+				vtv.PowerState,
+				FormatToolsVersion(vtv.Version),
+				vtv.Version,
+				vtv.VersionStatus,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	writeVMs(
+		"VMs with unsupported VMware Tools version or older than minimum version",
+		VMToolsVersion.IsCriticalState,
+	)
+
+	writeVMs(
+		"VMs with VMware Tools version needing upgrade",
+		VMToolsVersion.IsWarningState,
+	)
+
+	writeVMs(
+		"VMs without VMware Tools installed (not evaluated)",
+		func(vtv VMToolsVersion) bool { return !vtv.IsInstalled() },
+	)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	minVersionDesc := "not specified"
+	if minVersion > 0 {
+		minVersionDesc = fmt.Sprintf("%s [%d]", FormatToolsVersion(minVersion), minVersion)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified minimum VMware Tools version: %s%s",
+		minVersionDesc,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,197 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func toolsVersionVM(name string, version string, status types.VirtualMachineToolsVersionStatus) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Guest: &types.GuestInfo{
+			ToolsVersion:        version,
+			ToolsVersionStatus2: string(status),
+		},
+	}
+}
+
+func TestFormatToolsVersion(t *testing.T) {
+	tests := map[string]struct {
+		version int
+		want    string
+	}{
+		"12.2.0":   {version: 12352, want: "12.2.0"},
+		"11.3.5":   {version: 11365, want: "11.3.5"},
+		"10.3.10":  {version: 10346, want: "10.3.10"},
+		"zero":     {version: 0, want: "unknown"},
+		"negative": {version: -1, want: "unknown"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := FormatToolsVersion(tt.version); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMToolsVersionState(t *testing.T) {
+	tests := map[string]struct {
+		vtv              VMToolsVersion
+		wantInstalled    bool
+		wantBelowMin     bool
+		wantUnsupported  bool
+		wantNeedsUpgrade bool
+		wantCritical     bool
+		wantWarning      bool
+	}{
+		"current": {
+			vtv:           VMToolsVersion{Version: 12352, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsCurrent},
+			wantInstalled: true,
+		},
+		"need upgrade": {
+			vtv:              VMToolsVersion{Version: 11365, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade},
+			wantInstalled:    true,
+			wantNeedsUpgrade: true,
+			wantWarning:      true,
+		},
+		"supported old": {
+			vtv:              VMToolsVersion{Version: 11365, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsSupportedOld},
+			wantInstalled:    true,
+			wantNeedsUpgrade: true,
+			wantWarning:      true,
+		},
+		"too old": {
+			vtv:             VMToolsVersion{Version: 9536, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsTooOld},
+			wantInstalled:   true,
+			wantUnsupported: true,
+			wantCritical:    true,
+		},
+		"too new": {
+			vtv:             VMToolsVersion{Version: 13000, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsTooNew},
+			wantInstalled:   true,
+			wantUnsupported: true,
+			wantCritical:    true,
+		},
+		"blacklisted": {
+			vtv:             VMToolsVersion{Version: 10346, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsBlacklisted},
+			wantInstalled:   true,
+			wantUnsupported: true,
+			wantCritical:    true,
+		},
+		"below minimum version": {
+			vtv:           VMToolsVersion{Version: 11365, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsUnmanaged, MinVersion: 12352},
+			wantInstalled: true,
+			wantBelowMin:  true,
+			wantCritical:  true,
+		},
+		"below minimum version takes precedence over upgrade": {
+			vtv:              VMToolsVersion{Version: 11365, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade, MinVersion: 12352},
+			wantInstalled:    true,
+			wantBelowMin:     true,
+			wantNeedsUpgrade: true,
+			wantCritical:     true,
+		},
+		"equal to minimum version": {
+			vtv:           VMToolsVersion{Version: 12352, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsUnmanaged, MinVersion: 12352},
+			wantInstalled: true,
+		},
+		"not installed": {
+			vtv: VMToolsVersion{Version: 0, VersionStatus: types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled, MinVersion: 12352},
+		},
+		"unknown version": {
+			vtv: VMToolsVersion{MinVersion: 12352},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.vtv.IsInstalled(); got != tt.wantInstalled {
+				t.Errorf("want installed %t; got %t", tt.wantInstalled, got)
+			}
+			if got := tt.vtv.IsBelowMinVersion(); got != tt.wantBelowMin {
+				t.Errorf("want below minimum version %t; got %t", tt.wantBelowMin, got)
+			}
+			if got := tt.vtv.IsUnsupported(); got != tt.wantUnsupported {
+				t.Errorf("want unsupported %t; got %t", tt.wantUnsupported, got)
+			}
+			if got := tt.vtv.NeedsUpgrade(); got != tt.wantNeedsUpgrade {
+				t.Errorf("want needs upgrade %t; got %t", tt.wantNeedsUpgrade, got)
+			}
+			if got := tt.vtv.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := tt.vtv.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestNewVMToolsVersionSet(t *testing.T) {
+	const (
+		current      = types.VirtualMachineToolsVersionStatusGuestToolsCurrent
+		supportedNew = types.VirtualMachineToolsVersionStatusGuestToolsSupportedNew
+		needUpgrade  = types.VirtualMachineToolsVersionStatusGuestToolsNeedUpgrade
+		tooOld       = types.VirtualMachineToolsVersionStatusGuestToolsTooOld
+		unmanaged    = types.VirtualMachineToolsVersionStatusGuestToolsUnmanaged
+		notInstalled = types.VirtualMachineToolsVersionStatusGuestToolsNotInstalled
+	)
+
+	set := NewVMToolsVersionSet(
+		[]mo.VirtualMachine{
+			toolsVersionVM("vm7", "", ""),
+			toolsVersionVM("vm6", "0", notInstalled),
+			toolsVersionVM("vm5", "11365", unmanaged),
+			toolsVersionVM("vm4", "9536", tooOld),
+			toolsVersionVM("vm3", "11365", needUpgrade),
+			toolsVersionVM("VM2", "12400", supportedNew),
+			toolsVersionVM("vm1", "12352", current),
+		},
+		12352,
+	)
+
+	var names []string
+	for _, vtv := range set {
+		names = append(names, vtv.VMName)
+	}
+	if d := cmp.Diff([]string{"vm1", "VM2", "vm3", "vm4", "vm5", "vm6", "vm7"}, names); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if set[0].Version != 12352 || set[0].MinVersion != 12352 {
+		t.Errorf("want version and minimum version 12352; got %+v", set[0])
+	}
+
+	if got := set.NumCritical(); got != 3 {
+		t.Errorf("want 3 critical; got %d", got)
+	}
+	if got := set.NumWarning(); got != 0 {
+		t.Errorf("want 0 warning; got %d", got)
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_tools_current", Value: "2", Min: "0"},
+		{Label: "vms_tools_unmanaged", Value: "1", Min: "0"},
+		{Label: "vms_tools_need_upgrade", Value: "1", Min: "0"},
+		{Label: "vms_tools_unsupported", Value: "1", Min: "0"},
+		{Label: "vms_tools_below_min_version", Value: "3", Min: "0"},
+		{Label: "vms_tools_not_installed", Value: "2", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMToolsVersionPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -121,6 +121,11 @@ func VMToolsOneLineCheckSummary(
 	vmsWithIssues []mo.VirtualMachine,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"vmsWithIssues":    vmsWithIssues,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
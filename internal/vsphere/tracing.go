@@ -0,0 +1,368 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// otlpTracesPath is the path appended to a base OTLP/HTTP endpoint URL in
+// order to export traces.
+const otlpTracesPath string = "/v1/traces"
+
+// traceScopeName is the instrumentation scope name used for exported spans.
+const traceScopeName string = "github.com/atc0005/check-vmware/internal/vsphere"
+
+// OTLP span kind and status code values.
+//
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/trace/v1/trace.proto
+const (
+	otlpSpanKindInternal int = 1
+	otlpSpanKindClient   int = 3
+	otlpStatusCodeUnset  int = 0
+	otlpStatusCodeError  int = 2
+)
+
+// Length in bytes of OTLP trace and span identifiers.
+const (
+	otlpTraceIDLength int = 16
+	otlpSpanIDLength  int = 8
+)
+
+// traceSpan records a single completed plugin execution phase (e.g., login
+// or object retrieval) for later export as an OpenTelemetry span.
+type traceSpan struct {
+	spanID   string
+	name     string
+	start    time.Time
+	end      time.Time
+	err      error
+	timedOut bool
+}
+
+// tracing holds the OpenTelemetry tracing settings along with the spans
+// recorded during plugin execution.
+var tracing struct {
+	sync.Mutex
+	endpoint string
+	plugin   string
+	server   string
+	traceID  string
+	deadline time.Time
+	spans    []traceSpan
+}
+
+// SetTracing enables OpenTelemetry tracing of vSphere retrieval functions
+// for the current plugin execution. Recorded spans are exported via OTLP/HTTP
+// (JSON encoding) by the ExportTraces function. The endpoint is a base URL
+// (e.g., http://localhost:4318) to which the /v1/traces path is appended.
+// Tracing is disabled if no endpoint is specified. Spans are exported only
+// if the given deadline (usually the plugin runtime timeout) has not been
+// reached so that trace export does not extend plugin execution. A zero
+// deadline does not limit trace export.
+func SetTracing(endpoint string, pluginName string, server string, deadline time.Time) {
+	if endpoint != "" {
+		endpoint = strings.TrimSuffix(endpoint, "/") + otlpTracesPath
+	}
+
+	tracing.Lock()
+	defer tracing.Unlock()
+
+	tracing.endpoint = endpoint
+	tracing.plugin = pluginName
+	tracing.server = server
+	tracing.deadline = deadline
+	tracing.spans = nil
+
+	if endpoint == "" {
+		return
+	}
+
+	tracing.traceID = newTraceID(otlpTraceIDLength)
+
+	logger.Printf("OpenTelemetry tracing enabled using endpoint %q", endpoint)
+}
+
+// tracingEnabled indicates whether OpenTelemetry tracing was requested for
+// the current plugin execution.
+func tracingEnabled() bool {
+	tracing.Lock()
+	defer tracing.Unlock()
+
+	return tracing.endpoint != ""
+}
+
+// newTraceID returns a random, hex-encoded identifier of the given length in
+// bytes for use as a trace or span ID.
+func newTraceID(length int) string {
+	id := make([]byte, length)
+	if _, err := rand.Read(id); err != nil {
+		logger.Printf("failed to generate trace identifier: %v", err)
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// recordSpan records the named plugin execution phase as a span if
+// OpenTelemetry tracing is enabled.
+func recordSpan(name string, start time.Time, end time.Time, err error, timedOut bool) {
+	if !tracingEnabled() {
+		return
+	}
+
+	span := traceSpan{
+		spanID:   newTraceID(otlpSpanIDLength),
+		name:     name,
+		start:    start,
+		end:      end,
+		err:      err,
+		timedOut: timedOut,
+	}
+
+	tracing.Lock()
+	defer tracing.Unlock()
+
+	tracing.spans = append(tracing.spans, span)
+}
+
+// otlpTraceRequest is the OTLP/HTTP JSON encoded trace export request.
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func otlpString(key string, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int) otlpKeyValue {
+	v := strconv.Itoa(value)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &v}}
+}
+
+func otlpBool(key string, value bool) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &value}}
+}
+
+func otlpTimestamp(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// ExportTraces is a helper function used to export the spans recorded
+// during plugin execution to the OTLP/HTTP endpoint specified via
+// SetTracing. All recorded spans are children of a root span covering the
+// plugin runtime which notes the final plugin state. Object names within span
+// error messages are redacted if name redaction is enabled. Export failures
+// are logged but otherwise do not affect plugin results.
+func ExportTraces(plugin *nagios.Plugin) {
+	tracing.Lock()
+	endpoint := tracing.endpoint
+	pluginName := tracing.plugin
+	server := tracing.server
+	traceID := tracing.traceID
+	deadline := tracing.deadline
+	spans := make([]traceSpan, len(tracing.spans))
+	copy(spans, tracing.spans)
+	tracing.Unlock()
+
+	if endpoint == "" {
+		return
+	}
+
+	if !deadline.IsZero() && time.Now().After(deadline) {
+		logger.Printf("skipping trace export; plugin runtime timeout reached")
+
+		return
+	}
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ExportTraces func (and export %d spans).\n",
+			time.Since(funcTimeStart),
+			len(spans)+1,
+		)
+	}()
+
+	rootSpanID := newTraceID(otlpSpanIDLength)
+
+	rootSpan := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootSpanID,
+		Name:              pluginName,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTimestamp(pluginStart),
+		EndTimeUnixNano:   otlpTimestamp(funcTimeStart),
+		Attributes: []otlpKeyValue{
+			otlpString("vsphere.server", server),
+			otlpInt("nagios.exit_code", plugin.ExitStatusCode),
+		},
+	}
+
+	for label, exitCode := range stateLabelExitCodes {
+		if exitCode == plugin.ExitStatusCode {
+			rootSpan.Attributes = append(
+				rootSpan.Attributes,
+				otlpString("nagios.state", label),
+			)
+			break
+		}
+	}
+
+	otlpSpans := make([]otlpSpan, 0, len(spans)+1)
+	otlpSpans = append(otlpSpans, rootSpan)
+
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            span.spanID,
+			ParentSpanID:      rootSpanID,
+			Name:              span.name,
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: otlpTimestamp(span.start),
+			EndTimeUnixNano:   otlpTimestamp(span.end),
+			Attributes: []otlpKeyValue{
+				otlpString("vsphere.server", server),
+				otlpBool("vsphere.phase.timed_out", span.timedOut),
+			},
+			Status: otlpStatus{Code: otlpStatusCodeUnset},
+		}
+
+		if span.err != nil {
+			s.Status = otlpStatus{
+				Code:    otlpStatusCodeError,
+				Message: redactString(span.err.Error()),
+			}
+		}
+
+		otlpSpans = append(otlpSpans, s)
+	}
+
+	request := otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: []otlpKeyValue{
+						otlpString("service.name", pluginName),
+					},
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: traceScopeName},
+						Spans: otlpSpans,
+					},
+				},
+			},
+		},
+	}
+
+	if err := exportTraceRequest(endpoint, deadline, request); err != nil {
+		logger.Printf("failed to export traces: %v", err)
+	}
+}
+
+// exportTraceRequest submits the given trace export request to the specified
+// OTLP/HTTP endpoint, abandoning the request if the given deadline is
+// reached.
+func exportTraceRequest(endpoint string, deadline time.Time, request otlpTraceRequest) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace export request: %w", err)
+	}
+
+	ctx := context.Background()
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to prepare trace export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to submit trace export request: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(
+			"unexpected response from %s: %s",
+			endpoint,
+			resp.Status,
+		)
+	}
+
+	return nil
+}
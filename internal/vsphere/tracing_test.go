@@ -0,0 +1,172 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+)
+
+// traceCollector is a minimal OTLP/HTTP collector recording received trace
+// export requests.
+type traceCollector struct {
+	sync.Mutex
+	paths    []string
+	requests []otlpTraceRequest
+}
+
+func newTraceCollector(t *testing.T, delay time.Duration) (*traceCollector, *httptest.Server) {
+	t.Helper()
+
+	tc := &traceCollector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpTraceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode trace export request: %v", err)
+		}
+
+		tc.Lock()
+		tc.paths = append(tc.paths, r.URL.Path)
+		tc.requests = append(tc.requests, req)
+		tc.Unlock()
+
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return tc, srv
+}
+
+func (tc *traceCollector) received() []otlpTraceRequest {
+	tc.Lock()
+	defer tc.Unlock()
+
+	return append([]otlpTraceRequest(nil), tc.requests...)
+}
+
+func TestExportTraces(t *testing.T) {
+	tc, srv := newTraceCollector(t, 0)
+
+	SetTracing(srv.URL+"/", "check_vmware_vcpus", "vc1.example.com", time.Now().Add(time.Minute))
+	defer SetTracing("", "", "", time.Time{})
+
+	_ = TrackPhase(context.Background(), "login", func() error { return nil })
+	_ = TrackPhase(context.Background(), "retrieve VMs", func() error { return errors.New("permission denied") })
+
+	plugin := nagios.NewPlugin()
+	plugin.ExitStatusCode = nagios.StateWARNINGExitCode
+
+	ExportTraces(plugin)
+
+	requests := tc.received()
+	if len(requests) != 1 {
+		t.Fatalf("want 1 export request; got %d", len(requests))
+	}
+	if tc.paths[0] != otlpTracesPath {
+		t.Errorf("want request path %q; got %q", otlpTracesPath, tc.paths[0])
+	}
+
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 3 {
+		t.Fatalf("want root span and 2 child spans; got %d spans", len(spans))
+	}
+
+	root := spans[0]
+	if root.Name != "check_vmware_vcpus" || root.ParentSpanID != "" {
+		t.Errorf("want root span named after plugin; got %+v", root)
+	}
+
+	var state string
+	for _, attr := range root.Attributes {
+		if attr.Key == "nagios.state" && attr.Value.StringValue != nil {
+			state = *attr.Value.StringValue
+		}
+	}
+	if state != nagios.StateWARNINGLabel {
+		t.Errorf("want root span state %q; got %q", nagios.StateWARNINGLabel, state)
+	}
+
+	for _, span := range spans[1:] {
+		if span.TraceID != root.TraceID || span.ParentSpanID != root.SpanID {
+			t.Errorf("want span %q to be a child of the root span", span.Name)
+		}
+	}
+
+	if spans[1].Status.Code != otlpStatusCodeUnset {
+		t.Errorf("want unset status for %q; got %d", spans[1].Name, spans[1].Status.Code)
+	}
+	if spans[2].Status.Code != otlpStatusCodeError || spans[2].Status.Message != "permission denied" {
+		t.Errorf("want error status for %q; got %+v", spans[2].Name, spans[2].Status)
+	}
+}
+
+func TestExportTracesNotExported(t *testing.T) {
+	tests := map[string]struct {
+		endpoint func(srv *httptest.Server) string
+		deadline time.Time
+	}{
+		"no endpoint with OTLP environment variables": {
+			endpoint: func(*httptest.Server) string { return "" },
+			deadline: time.Now().Add(time.Minute),
+		},
+		"deadline reached": {
+			endpoint: func(srv *httptest.Server) string { return srv.URL },
+			deadline: time.Now().Add(-time.Second),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc, srv := newTraceCollector(t, 0)
+
+			t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+			t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", srv.URL+otlpTracesPath)
+
+			SetTracing(tt.endpoint(srv), "check_vmware_vcpus", "vc1.example.com", tt.deadline)
+			defer SetTracing("", "", "", time.Time{})
+
+			_ = TrackPhase(context.Background(), "login", func() error { return nil })
+			ExportTraces(nagios.NewPlugin())
+
+			if got := len(tc.received()); got != 0 {
+				t.Errorf("want no export requests; got %d", got)
+			}
+		})
+	}
+}
+
+// TestExportTracesDeadline asserts that a slow collector does not extend
+// plugin execution beyond the plugin runtime timeout.
+func TestExportTracesDeadline(t *testing.T) {
+	tc, srv := newTraceCollector(t, 10*time.Second)
+
+	SetTracing(srv.URL, "check_vmware_vcpus", "vc1.example.com", time.Now().Add(200*time.Millisecond))
+	defer SetTracing("", "", "", time.Time{})
+
+	start := time.Now()
+	ExportTraces(nagios.NewPlugin())
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("want export abandoned at deadline; took %v", elapsed)
+	}
+
+	if got := len(tc.received()); got != 1 {
+		t.Errorf("want 1 export request; got %d", got)
+	}
+}
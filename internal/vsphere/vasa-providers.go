@@ -0,0 +1,571 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVASAProviderStatusCheckFailed indicates that one or more VASA storage
+// providers are offline, not registered or have certificates which are
+// expired or expiring soon.
+var ErrVASAProviderStatusCheckFailed = errors.New("VASA provider status check failed")
+
+// Storage Monitoring Service (SMS) API details. VASA storage providers are
+// exposed by vCenter via a dedicated SOAP endpoint separate from the standard
+// vSphere API endpoint.
+const (
+	smsServicePath string = "/sms/sdk"
+	smsNamespace   string = "sms"
+)
+
+// Known VASA provider status values as used by the SMS API.
+const (
+	VASAProviderStatusOnline       string = "online"
+	VASAProviderStatusOffline      string = "offline"
+	VASAProviderStatusSyncError    string = "syncError"
+	VASAProviderStatusUnknown      string = "unknown"
+	VASAProviderStatusConnected    string = "connected"
+	VASAProviderStatusDisconnected string = "disconnected"
+)
+
+// smsServiceInstance is the well-known SMS service instance managed object
+// exposed by vCenter.
+var smsServiceInstance = types.ManagedObjectReference{
+	Type:  "SmsServiceInstance",
+	Value: "ServiceInstance",
+}
+
+// VASAProviderInfo is the subset of the VASA provider details returned by
+// the SMS API used by this project.
+type VASAProviderInfo struct {
+	UID               string `xml:"uid,omitempty"`
+	Name              string `xml:"name,omitempty"`
+	Description       string `xml:"description,omitempty"`
+	Version           string `xml:"version,omitempty"`
+	URL               string `xml:"url,omitempty"`
+	Certificate       string `xml:"certificate,omitempty"`
+	Status            string `xml:"status,omitempty"`
+	VasaVersion       string `xml:"vasaVersion,omitempty"`
+	CertificateStatus string `xml:"certificateStatus,omitempty"`
+}
+
+type smsQueryStorageManagerRequest struct {
+	This types.ManagedObjectReference `xml:"_this"`
+}
+
+type smsQueryStorageManagerResponse struct {
+	Returnval types.ManagedObjectReference `xml:"returnval"`
+}
+
+type smsQueryStorageManagerBody struct {
+	Req    *smsQueryStorageManagerRequest  `xml:"urn:sms QueryStorageManager,omitempty"`
+	Res    *smsQueryStorageManagerResponse `xml:"urn:sms QueryStorageManagerResponse,omitempty"`
+	Fault_ *soap.Fault                     `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body>Fault,omitempty"` //nolint:revive,stylecheck
+}
+
+func (b *smsQueryStorageManagerBody) Fault() *soap.Fault { return b.Fault_ }
+
+type smsQueryProviderRequest struct {
+	This types.ManagedObjectReference `xml:"_this"`
+}
+
+type smsQueryProviderResponse struct {
+	Returnval []types.ManagedObjectReference `xml:"returnval,omitempty"`
+}
+
+type smsQueryProviderBody struct {
+	Req    *smsQueryProviderRequest  `xml:"urn:sms QueryProvider,omitempty"`
+	Res    *smsQueryProviderResponse `xml:"urn:sms QueryProviderResponse,omitempty"`
+	Fault_ *soap.Fault               `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body>Fault,omitempty"` //nolint:revive,stylecheck
+}
+
+func (b *smsQueryProviderBody) Fault() *soap.Fault { return b.Fault_ }
+
+type smsQueryProviderInfoRequest struct {
+	This types.ManagedObjectReference `xml:"_this"`
+}
+
+type smsQueryProviderInfoResponse struct {
+	Returnval VASAProviderInfo `xml:"returnval"`
+}
+
+type smsQueryProviderInfoBody struct {
+	Req    *smsQueryProviderInfoRequest  `xml:"urn:sms QueryProviderInfo,omitempty"`
+	Res    *smsQueryProviderInfoResponse `xml:"urn:sms QueryProviderInfoResponse,omitempty"`
+	Fault_ *soap.Fault                   `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body>Fault,omitempty"` //nolint:revive,stylecheck
+}
+
+func (b *smsQueryProviderInfoBody) Fault() *soap.Fault { return b.Fault_ }
+
+// VASAProviderThresholds represents the user-specified VASA provider
+// certificate expiration thresholds (in days).
+type VASAProviderThresholds struct {
+	CertExpiryWarning  int
+	CertExpiryCritical int
+}
+
+// VASAProviderStatus pairs a registered VASA provider with the expiration
+// date of its certificate (if available).
+type VASAProviderStatus struct {
+	Info VASAProviderInfo
+
+	// CertNotAfter is the expiration date of the provider certificate. This
+	// is the zero value if the certificate is unavailable or could not be
+	// parsed.
+	CertNotAfter time.Time
+
+	Thresholds VASAProviderThresholds
+}
+
+// VASAProviderStatusSet is a collection of registered VASA providers along
+// with any required providers which were not found.
+type VASAProviderStatusSet struct {
+	Providers []VASAProviderStatus
+
+	// Missing is the collection of required provider names which are not
+	// registered.
+	Missing []string
+}
+
+// GetVASAProviders queries the SMS API for the details of all registered
+// VASA storage providers.
+func GetVASAProviders(ctx context.Context, c *vim25.Client) ([]VASAProviderInfo, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVASAProviders func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	sc := c.Client.NewServiceClient(smsServicePath, smsNamespace)
+
+	smReq := smsQueryStorageManagerBody{
+		Req: &smsQueryStorageManagerRequest{This: smsServiceInstance},
+	}
+	var smRes smsQueryStorageManagerBody
+	if err := sc.RoundTrip(ctx, &smReq, &smRes); err != nil {
+		return nil, fmt.Errorf("failed to retrieve SMS storage manager: %w", err)
+	}
+
+	if smRes.Res == nil {
+		return nil, fmt.Errorf("empty SMS storage manager response")
+	}
+
+	providersReq := smsQueryProviderBody{
+		Req: &smsQueryProviderRequest{This: smRes.Res.Returnval},
+	}
+	var providersRes smsQueryProviderBody
+	if err := sc.RoundTrip(ctx, &providersReq, &providersRes); err != nil {
+		return nil, fmt.Errorf("failed to retrieve VASA providers: %w", err)
+	}
+
+	if providersRes.Res == nil {
+		return []VASAProviderInfo{}, nil
+	}
+
+	providers := make([]VASAProviderInfo, 0, len(providersRes.Res.Returnval))
+	for _, providerRef := range providersRes.Res.Returnval {
+		infoReq := smsQueryProviderInfoBody{
+			Req: &smsQueryProviderInfoRequest{This: providerRef},
+		}
+		var infoRes smsQueryProviderInfoBody
+		if err := sc.RoundTrip(ctx, &infoReq, &infoRes); err != nil {
+			return nil, fmt.Errorf(
+				"failed to retrieve details for VASA provider %s: %w",
+				providerRef.Value,
+				err,
+			)
+		}
+
+		if infoRes.Res == nil {
+			return nil, fmt.Errorf(
+				"empty details response for VASA provider %s",
+				providerRef.Value,
+			)
+		}
+
+		providers = append(providers, infoRes.Res.Returnval)
+	}
+
+	sort.Slice(providers, func(i, j int) bool {
+		return strings.ToLower(providers[i].Name) < strings.ToLower(providers[j].Name)
+	})
+
+	return providers, nil
+
+}
+
+// certificateNotAfter parses the given PEM encoded certificate and returns
+// its expiration date.
+func certificateNotAfter(pemCert string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(pemCert))
+	if block == nil {
+		return time.Time{}, fmt.Errorf("failed to decode PEM certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert.NotAfter, nil
+}
+
+// NewVASAProviderStatusSet evaluates the given VASA providers against the
+// specified list of required provider names and certificate expiration
+// thresholds.
+func NewVASAProviderStatusSet(providers []VASAProviderInfo, required []string, thresholds VASAProviderThresholds) VASAProviderStatusSet {
+
+	set := VASAProviderStatusSet{
+		Providers: make([]VASAProviderStatus, 0, len(providers)),
+	}
+
+	names := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		names = append(names, provider.Name)
+
+		status := VASAProviderStatus{
+			Info:       provider,
+			Thresholds: thresholds,
+		}
+
+		if provider.Certificate != "" {
+			notAfter, err := certificateNotAfter(provider.Certificate)
+			if err != nil {
+				logger.Printf(
+					"failed to evaluate certificate for VASA provider %s: %v",
+					provider.Name,
+					err,
+				)
+			}
+			status.CertNotAfter = notAfter
+		}
+
+		set.Providers = append(set.Providers, status)
+	}
+
+	for _, name := range required {
+		if !textutils.InList(name, names, true) {
+			set.Missing = append(set.Missing, name)
+		}
+	}
+
+	return set
+
+}
+
+// IsOnline indicates whether the VASA provider is online.
+func (vps VASAProviderStatus) IsOnline() bool {
+	switch vps.Info.Status {
+	case VASAProviderStatusOnline, VASAProviderStatusConnected:
+		return true
+	default:
+		return false
+	}
+}
+
+// CertDaysRemaining returns the number of days remaining before the VASA
+// provider certificate expires and whether the expiration date is known.
+func (vps VASAProviderStatus) CertDaysRemaining() (int, bool) {
+	if vps.CertNotAfter.IsZero() {
+		return 0, false
+	}
+
+	return int(time.Until(vps.CertNotAfter).Hours() / 24), true
+}
+
+// IsCriticalState indicates whether the VASA provider is offline or its
+// certificate has crossed the CRITICAL expiration threshold.
+func (vps VASAProviderStatus) IsCriticalState() bool {
+	if !vps.IsOnline() && vps.Info.Status != VASAProviderStatusSyncError {
+		return true
+	}
+
+	if days, ok := vps.CertDaysRemaining(); ok {
+		return days <= vps.Thresholds.CertExpiryCritical
+	}
+
+	return false
+}
+
+// IsWarningState indicates whether the VASA provider has a synchronization
+// error or its certificate has crossed the WARNING expiration threshold.
+func (vps VASAProviderStatus) IsWarningState() bool {
+	if vps.IsCriticalState() {
+		return false
+	}
+
+	if vps.Info.Status == VASAProviderStatusSyncError {
+		return true
+	}
+
+	if days, ok := vps.CertDaysRemaining(); ok {
+		return days <= vps.Thresholds.CertExpiryWarning
+	}
+
+	return false
+}
+
+// NumOffline returns the number of VASA providers which are not online.
+func (set VASAProviderStatusSet) NumOffline() int {
+	var num int
+	for _, vps := range set.Providers {
+		if !vps.IsOnline() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCritical returns the number of VASA providers in a CRITICAL state.
+func (set VASAProviderStatusSet) NumCritical() int {
+	var num int
+	for _, vps := range set.Providers {
+		if vps.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VASA providers in a WARNING state.
+func (set VASAProviderStatusSet) NumWarning() int {
+	var num int
+	for _, vps := range set.Providers {
+		if vps.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any required VASA provider is missing
+// or any registered VASA provider is in a CRITICAL state.
+func (set VASAProviderStatusSet) HasCriticalState() bool {
+	return len(set.Missing) > 0 || set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any registered VASA provider is in a
+// WARNING state.
+func (set VASAProviderStatusSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VASAProviderStatusPerfData generates performance data metrics from the
+// given collection of evaluated VASA providers.
+func VASAProviderStatusPerfData(set VASAProviderStatusSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vasa_providers",
+			Value: fmt.Sprintf("%d", len(set.Providers)),
+			Min:   "0",
+		},
+		{
+			Label: "vasa_providers_offline",
+			Value: fmt.Sprintf("%d", set.NumOffline()),
+			Min:   "0",
+		},
+		{
+			Label: "vasa_providers_missing",
+			Value: fmt.Sprintf("%d", len(set.Missing)),
+			Min:   "0",
+		},
+		{
+			Label: "vasa_providers_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "vasa_providers_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+	}
+}
+
+// VASAProviderStatusOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VASAProviderStatusOneLineCheckSummary(
+	stateLabel string,
+	set VASAProviderStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VASAProviderStatusOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d VASA providers with issues detected (%d offline, %d missing, %d evaluated)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning()+len(set.Missing),
+			set.NumOffline(),
+			len(set.Missing),
+			len(set.Providers),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VASA provider issues detected (evaluated %d providers)",
+			stateLabel,
+			len(set.Providers),
+		)
+	}
+}
+
+// VASAProviderStatusReport generates a summary of registered VASA providers
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VASAProviderStatusReport(
+	c *vim25.Client,
+	set VASAProviderStatusSet,
+	required []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VASAProviderStatusReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VASA providers:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, vps := range set.Providers {
+		var state string
+		switch {
+		case vps.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case vps.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		certExpiry := "unknown"
+		if days, ok := vps.CertDaysRemaining(); ok {
+			certExpiry = fmt.Sprintf(
+				"%s (%d days)",
+				vps.CertNotAfter.Format("2006-01-02"),
+				days,
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]%s"+
+				"** Status: %s%s"+
+				"** URL: %s%s"+
+				"** VASA version: %s%s"+
+				"** Certificate expires: %s%s",
+			vps.Info.Name,
+			state,
+			nagios.CheckOutputEOL,
+			vps.Info.Status,
+			nagios.CheckOutputEOL,
+			vps.Info.URL,
+			nagios.CheckOutputEOL,
+			vps.Info.VasaVersion,
+			nagios.CheckOutputEOL,
+			certExpiry,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(set.Providers) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	if len(set.Missing) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sRequired VASA providers not registered:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, name := range set.Missing {
+			_, _ = fmt.Fprintf(&report, "* %s%s", name, nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified required VASA providers (%d): [%v]%s",
+		len(required),
+		strings.Join(required, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,268 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// vasaCertificate returns a PEM encoded self-signed certificate which
+// expires at the given time.
+func vasaCertificate(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "vasa.example.com"},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// vasaExpiresIn returns an expiration date the given number of whole days
+// from now.
+func vasaExpiresIn(days int) time.Time {
+	return time.Now().Add(time.Duration(days)*24*time.Hour + time.Hour)
+}
+
+func TestCertificateNotAfter(t *testing.T) {
+	notAfter := time.Date(2030, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := certificateNotAfter(vasaCertificate(t, notAfter))
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+	if !got.Equal(notAfter) {
+		t.Errorf("want %v; got %v", notAfter, got)
+	}
+
+	if _, err := certificateNotAfter("not a certificate"); err == nil {
+		t.Error("want error for invalid PEM data; got nil")
+	}
+
+	invalid := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}))
+	if _, err := certificateNotAfter(invalid); err == nil {
+		t.Error("want error for invalid certificate; got nil")
+	}
+}
+
+func TestNewVASAProviderStatusSet(t *testing.T) {
+	notAfter := time.Date(2030, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	providers := []VASAProviderInfo{
+		{Name: "array1", Status: VASAProviderStatusOnline, Certificate: vasaCertificate(t, notAfter)},
+		{Name: "array2", Status: VASAProviderStatusOnline, Certificate: "not a certificate"},
+		{Name: "array3", Status: VASAProviderStatusConnected},
+	}
+
+	tests := map[string]struct {
+		required    []string
+		wantMissing []string
+	}{
+		"no required providers":          {},
+		"required provider registered":   {required: []string{"ARRAY1", "array3"}},
+		"required providers not present": {required: []string{"array1", "array4", "array5"}, wantMissing: []string{"array4", "array5"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVASAProviderStatusSet(providers, tt.required, VASAProviderThresholds{})
+
+			if len(set.Providers) != len(providers) {
+				t.Fatalf("want %d providers; got %d", len(providers), len(set.Providers))
+			}
+
+			if !set.Providers[0].CertNotAfter.Equal(notAfter) {
+				t.Errorf("want certificate expiration %v; got %v", notAfter, set.Providers[0].CertNotAfter)
+			}
+
+			for _, vps := range set.Providers[1:] {
+				if !vps.CertNotAfter.IsZero() {
+					t.Errorf("want unknown certificate expiration for %s; got %v", vps.Info.Name, vps.CertNotAfter)
+				}
+			}
+
+			if d := cmp.Diff(tt.wantMissing, set.Missing); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestVASAProviderStatusState(t *testing.T) {
+	thresholds := VASAProviderThresholds{
+		CertExpiryWarning:  30,
+		CertExpiryCritical: 7,
+	}
+
+	tests := map[string]struct {
+		status       string
+		notAfter     time.Time
+		wantOnline   bool
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"online with valid certificate": {
+			status:     VASAProviderStatusOnline,
+			notAfter:   vasaExpiresIn(365),
+			wantOnline: true,
+		},
+		"connected without certificate": {
+			status:     VASAProviderStatusConnected,
+			wantOnline: true,
+		},
+		"offline": {
+			status:       VASAProviderStatusOffline,
+			notAfter:     vasaExpiresIn(365),
+			wantCritical: true,
+		},
+		"disconnected": {
+			status:       VASAProviderStatusDisconnected,
+			wantCritical: true,
+		},
+		"unknown status": {
+			status:       VASAProviderStatusUnknown,
+			wantCritical: true,
+		},
+		"sync error": {
+			status:      VASAProviderStatusSyncError,
+			notAfter:    vasaExpiresIn(365),
+			wantWarning: true,
+		},
+		"sync error with certificate within CRITICAL threshold": {
+			status:       VASAProviderStatusSyncError,
+			notAfter:     vasaExpiresIn(3),
+			wantCritical: true,
+		},
+		"certificate at WARNING threshold": {
+			status:      VASAProviderStatusOnline,
+			notAfter:    vasaExpiresIn(30),
+			wantOnline:  true,
+			wantWarning: true,
+		},
+		"certificate just outside WARNING threshold": {
+			status:     VASAProviderStatusOnline,
+			notAfter:   vasaExpiresIn(31),
+			wantOnline: true,
+		},
+		"certificate at CRITICAL threshold": {
+			status:       VASAProviderStatusOnline,
+			notAfter:     vasaExpiresIn(7),
+			wantOnline:   true,
+			wantCritical: true,
+		},
+		"expired certificate": {
+			status:       VASAProviderStatusOnline,
+			notAfter:     time.Now().Add(-48 * time.Hour),
+			wantOnline:   true,
+			wantCritical: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vps := VASAProviderStatus{
+				Info:         VASAProviderInfo{Name: "array1", Status: tt.status},
+				CertNotAfter: tt.notAfter,
+				Thresholds:   thresholds,
+			}
+
+			if got := vps.IsOnline(); got != tt.wantOnline {
+				t.Errorf("want online %t; got %t", tt.wantOnline, got)
+			}
+
+			if got := vps.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := vps.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVASAProviderStatusCertDaysRemaining(t *testing.T) {
+	tests := map[string]struct {
+		notAfter  time.Time
+		want      int
+		wantKnown bool
+	}{
+		"unknown expiration": {},
+		"90 days":            {notAfter: vasaExpiresIn(90), want: 90, wantKnown: true},
+		"less than one day":  {notAfter: time.Now().Add(time.Hour), want: 0, wantKnown: true},
+		"expired":            {notAfter: time.Now().Add(-49 * time.Hour), want: -2, wantKnown: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, known := VASAProviderStatus{CertNotAfter: tt.notAfter}.CertDaysRemaining()
+
+			if got != tt.want || known != tt.wantKnown {
+				t.Errorf("want %d days (known: %t); got %d (known: %t)", tt.want, tt.wantKnown, got, known)
+			}
+		})
+	}
+}
+
+func TestVASAProviderStatusSetCounts(t *testing.T) {
+	thresholds := VASAProviderThresholds{CertExpiryWarning: 30, CertExpiryCritical: 7}
+
+	set := VASAProviderStatusSet{
+		Providers: []VASAProviderStatus{
+			{Info: VASAProviderInfo{Status: VASAProviderStatusOnline}, CertNotAfter: vasaExpiresIn(365), Thresholds: thresholds},
+			{Info: VASAProviderInfo{Status: VASAProviderStatusOffline}, Thresholds: thresholds},
+			{Info: VASAProviderInfo{Status: VASAProviderStatusSyncError}, Thresholds: thresholds},
+			{Info: VASAProviderInfo{Status: VASAProviderStatusConnected}, CertNotAfter: vasaExpiresIn(20), Thresholds: thresholds},
+		},
+	}
+
+	if got := set.NumOffline(); got != 2 {
+		t.Errorf("want 2 offline providers; got %d", got)
+	}
+
+	if got := set.NumCritical(); got != 1 {
+		t.Errorf("want 1 CRITICAL provider; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 2 || !set.HasWarningState() {
+		t.Errorf("want 2 WARNING providers; got %d", got)
+	}
+
+	healthy := VASAProviderStatusSet{Providers: set.Providers[:1]}
+	if healthy.HasCriticalState() || healthy.HasWarningState() {
+		t.Error("want no CRITICAL or WARNING state for healthy providers")
+	}
+
+	healthy.Missing = []string{"array2"}
+	if !healthy.HasCriticalState() {
+		t.Error("want CRITICAL state for missing required provider")
+	}
+}
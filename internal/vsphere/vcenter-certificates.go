@@ -0,0 +1,616 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrCertificateExpirationThresholdCrossed indicates that one or more
+// certificates presented by vCenter or ESXi hosts have expired or are
+// expiring soon.
+var ErrCertificateExpirationThresholdCrossed = errors.New("certificate expiration threshold crossed")
+
+// Sources of evaluated certificates.
+const (
+	// CertificateSourceConnection indicates that the certificate was
+	// presented by the vSphere endpoint used for the plugin connection.
+	CertificateSourceConnection string = "connection"
+
+	// CertificateSourceHost indicates that the certificate was retrieved
+	// from the HostCertificateManager for an ESXi host.
+	CertificateSourceHost string = "host"
+)
+
+// maxDiscardedBodySize is the maximum number of response body bytes read
+// (and discarded) when retrieving the vSphere endpoint certificate.
+const maxDiscardedBodySize int64 = 64 * 1024
+
+// CertificateThresholds represents the user-specified certificate expiration
+// thresholds (in days).
+type CertificateThresholds struct {
+	ExpiryWarning  int
+	ExpiryCritical int
+}
+
+// CertificateStatus represents the expiration details for a certificate
+// presented by vCenter or an ESXi host.
+type CertificateStatus struct {
+	// Endpoint is the name of the vSphere endpoint or ESXi host presenting
+	// the certificate.
+	Endpoint string
+
+	// Source indicates how the certificate was retrieved.
+	Source string
+
+	// Subject is the subject of the certificate.
+	Subject string
+
+	// Issuer is the issuer of the certificate.
+	Issuer string
+
+	// NotAfter is the expiration date of the certificate. This is the zero
+	// value if the expiration date is unavailable.
+	NotAfter time.Time
+
+	// Status is the certificate status as reported by vCenter for ESXi host
+	// certificates. This is empty for the connection certificate.
+	Status string
+
+	// Unavailable indicates whether certificate details could not be
+	// retrieved for an ESXi host due to its connection state.
+	Unavailable bool
+
+	Thresholds CertificateThresholds
+}
+
+// CertificateStatusSet is a collection of CertificateStatus values.
+type CertificateStatusSet []CertificateStatus
+
+// GetConnectionCertificate retrieves the leaf certificate presented by the
+// vSphere endpoint used by the given client. The certificate is read from
+// the TLS connection state of a request made using the transport of the
+// given client so that the same proxy and TLS settings used for the plugin
+// connection are applied.
+func GetConnectionCertificate(ctx context.Context, c *vim25.Client) (*x509.Certificate, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetConnectionCertificate func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	u := c.URL()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to prepare request for %s: %w",
+			u.Host,
+			err,
+		)
+	}
+
+	var peerCerts []*x509.Certificate
+
+	// The response status is not relevant; only the certificates presented
+	// during the TLS handshake are of interest.
+	err = c.Client.Do(ctx, req, func(resp *http.Response) error {
+		if resp.TLS != nil {
+			peerCerts = resp.TLS.PeerCertificates
+		}
+
+		_, copyErr := io.Copy(io.Discard, io.LimitReader(resp.Body, maxDiscardedBodySize))
+
+		return copyErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to connect to %s: %w",
+			u.Host,
+			err,
+		)
+	}
+
+	if len(peerCerts) == 0 {
+		return nil, fmt.Errorf("no certificates presented by %s", u.Host)
+	}
+
+	return peerCerts[0], nil
+
+}
+
+// NewConnectionCertificateStatus evaluates the given certificate presented
+// by the specified vSphere endpoint.
+func NewConnectionCertificateStatus(endpoint string, cert *x509.Certificate, thresholds CertificateThresholds) CertificateStatus {
+	return CertificateStatus{
+		Endpoint:   endpoint,
+		Source:     CertificateSourceConnection,
+		Subject:    cert.Subject.String(),
+		Issuer:     cert.Issuer.String(),
+		NotAfter:   cert.NotAfter,
+		Thresholds: thresholds,
+	}
+}
+
+// GetHostCertificateInfo uses the HostCertificateManager for the specified
+// HostSystem to retrieve certificate details.
+func GetHostCertificateInfo(ctx context.Context, c *vim25.Client, hs mo.HostSystem) (types.HostCertificateManagerCertificateInfo, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostCertificateInfo func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	host := object.NewHostSystem(c, hs.Reference())
+
+	cm, err := host.ConfigManager().CertificateManager(ctx)
+	if err != nil {
+		return types.HostCertificateManagerCertificateInfo{}, fmt.Errorf(
+			"failed to retrieve certificate manager for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	info, err := cm.CertificateInfo(ctx)
+	if err != nil {
+		return types.HostCertificateManagerCertificateInfo{}, fmt.Errorf(
+			"failed to retrieve certificate details for host %s: %w",
+			hs.Name,
+			err,
+		)
+	}
+
+	return info.HostCertificateManagerCertificateInfo, nil
+
+}
+
+// NewHostCertificateStatus evaluates the given certificate details
+// retrieved from the HostCertificateManager for a HostSystem.
+func NewHostCertificateStatus(hs mo.HostSystem, info types.HostCertificateManagerCertificateInfo, thresholds CertificateThresholds) CertificateStatus {
+	cs := CertificateStatus{
+		Endpoint:   hs.Name,
+		Source:     CertificateSourceHost,
+		Subject:    info.Subject,
+		Issuer:     info.Issuer,
+		Status:     info.Status,
+		Thresholds: thresholds,
+	}
+
+	if info.NotAfter != nil {
+		cs.NotAfter = *info.NotAfter
+	}
+
+	return cs
+}
+
+// GetHostCertificateStatusSet retrieves and evaluates the certificate
+// details for each given HostSystem. HostSystems which are not connected are
+// flagged as unavailable and are not evaluated.
+func GetHostCertificateStatusSet(ctx context.Context, c *vim25.Client, hss []mo.HostSystem, thresholds CertificateThresholds) (CertificateStatusSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetHostCertificateStatusSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(CertificateStatusSet, 0, len(hss))
+
+	for _, hs := range hss {
+		if hs.Runtime.ConnectionState != types.HostSystemConnectionStateConnected {
+			logger.Printf(
+				"host %s connection state is %s; skipping certificate evaluation",
+				hs.Name,
+				hs.Runtime.ConnectionState,
+			)
+
+			set = append(set, CertificateStatus{
+				Endpoint:    hs.Name,
+				Source:      CertificateSourceHost,
+				Unavailable: true,
+				Thresholds:  thresholds,
+			})
+
+			continue
+		}
+
+		info, err := GetHostCertificateInfo(ctx, c, hs)
+		if err != nil {
+			return nil, err
+		}
+
+		set = append(set, NewHostCertificateStatus(hs, info, thresholds))
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].Endpoint) < strings.ToLower(set[j].Endpoint)
+	})
+
+	return set, nil
+
+}
+
+// DaysRemaining returns the number of days remaining before the certificate
+// expires and whether the expiration date is known. The number of days is
+// negative for expired certificates.
+func (cs CertificateStatus) DaysRemaining() (int, bool) {
+	if cs.Unavailable || cs.NotAfter.IsZero() {
+		return 0, false
+	}
+
+	return int(time.Until(cs.NotAfter).Hours() / 24), true
+}
+
+// IsExpired indicates whether the certificate has expired.
+func (cs CertificateStatus) IsExpired() bool {
+	if cs.Unavailable || cs.NotAfter.IsZero() {
+		return false
+	}
+
+	return time.Now().After(cs.NotAfter)
+}
+
+// IsCriticalState indicates whether the certificate has expired or has
+// crossed the CRITICAL expiration threshold.
+func (cs CertificateStatus) IsCriticalState() bool {
+	if cs.IsExpired() {
+		return true
+	}
+
+	if days, ok := cs.DaysRemaining(); ok {
+		return days <= cs.Thresholds.ExpiryCritical
+	}
+
+	return false
+}
+
+// IsWarningState indicates whether the certificate has crossed the WARNING
+// expiration threshold, but not the CRITICAL threshold.
+func (cs CertificateStatus) IsWarningState() bool {
+	if cs.IsCriticalState() {
+		return false
+	}
+
+	if days, ok := cs.DaysRemaining(); ok {
+		return days <= cs.Thresholds.ExpiryWarning
+	}
+
+	return false
+}
+
+// NumEvaluated returns the number of certificates evaluated.
+func (set CertificateStatusSet) NumEvaluated() int {
+	var num int
+	for _, cs := range set {
+		if !cs.Unavailable {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumUnavailable returns the number of ESXi hosts whose certificate details
+// could not be evaluated.
+func (set CertificateStatusSet) NumUnavailable() int {
+	return len(set) - set.NumEvaluated()
+}
+
+// NumExpired returns the number of expired certificates.
+func (set CertificateStatusSet) NumExpired() int {
+	var num int
+	for _, cs := range set {
+		if cs.IsExpired() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCritical returns the number of certificates in a CRITICAL state.
+func (set CertificateStatusSet) NumCritical() int {
+	var num int
+	for _, cs := range set {
+		if cs.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of certificates in a WARNING state.
+func (set CertificateStatusSet) NumWarning() int {
+	var num int
+	for _, cs := range set {
+		if cs.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated certificate is in a
+// CRITICAL state.
+func (set CertificateStatusSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated certificate is in a
+// WARNING state.
+func (set CertificateStatusSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// MinDaysRemaining returns the lowest number of days remaining before
+// expiration across all evaluated certificates and whether any expiration
+// date is known.
+func (set CertificateStatusSet) MinDaysRemaining() (int, bool) {
+	var minDays int
+	var found bool
+	for _, cs := range set {
+		days, ok := cs.DaysRemaining()
+		if !ok {
+			continue
+		}
+
+		if !found || days < minDays {
+			minDays = days
+			found = true
+		}
+	}
+
+	return minDays, found
+}
+
+// CertificateStatusPerfData generates performance data metrics from the
+// given collection of evaluated certificates.
+func CertificateStatusPerfData(set CertificateStatusSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "certificates",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "certificates_evaluated",
+			Value: fmt.Sprintf("%d", set.NumEvaluated()),
+			Min:   "0",
+		},
+		{
+			Label: "certificates_unavailable",
+			Value: fmt.Sprintf("%d", set.NumUnavailable()),
+			Min:   "0",
+		},
+		{
+			Label: "certificates_expired",
+			Value: fmt.Sprintf("%d", set.NumExpired()),
+			Min:   "0",
+		},
+		{
+			Label: "certificates_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "certificates_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+	}
+
+	if days, ok := set.MinDaysRemaining(); ok {
+		// The same thresholds are applied to all evaluated certificates. The
+		// range format is used to indicate a threshold is crossed when fewer
+		// days than specified remain.
+		thresholds := set[0].Thresholds
+
+		pd = append(pd, nagios.PerformanceData{
+			Label: "days_remaining_min",
+			Value: fmt.Sprintf("%d", days),
+			Warn:  fmt.Sprintf("%d:", thresholds.ExpiryWarning),
+			Crit:  fmt.Sprintf("%d:", thresholds.ExpiryCritical),
+		})
+	}
+
+	return pd
+
+}
+
+// CertificateStatusOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func CertificateStatusOneLineCheckSummary(
+	stateLabel string,
+	set CertificateStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute CertificateStatusOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d certificates expired or expiring soon (%d expired, %d evaluated)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumExpired(),
+			set.NumEvaluated(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No expiring certificates detected (evaluated %d certificates)",
+			stateLabel,
+			set.NumEvaluated(),
+		)
+	}
+}
+
+// CertificateStatusReport generates a summary of evaluated vCenter and ESXi
+// host certificates along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func CertificateStatusReport(
+	c *vim25.Client,
+	set CertificateStatusSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute CertificateStatusReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Certificates:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, cs := range set {
+		if cs.Unavailable {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (%s) [unavailable]%s",
+				cs.Endpoint,
+				cs.Source,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		var state string
+		switch {
+		case cs.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case cs.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		expiry := "unknown"
+		if days, ok := cs.DaysRemaining(); ok {
+			expiry = fmt.Sprintf(
+				"%s (%d days remaining)",
+				cs.NotAfter.Format("2006-01-02"),
+				days,
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s) [%s]%s"+
+				"** Issuer: %s%s"+
+				"** Expires: %s%s",
+			cs.Endpoint,
+			cs.Source,
+			state,
+			nagios.CheckOutputEOL,
+			cs.Issuer,
+			nagios.CheckOutputEOL,
+			expiry,
+			nagios.CheckOutputEOL,
+		)
+
+		if cs.Status != "" {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** vCenter status: %s%s",
+				cs.Status,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	if set.NumUnavailable() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Hosts unavailable for evaluation: %d%s",
+			set.NumUnavailable(),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	return report.String()
+}
@@ -0,0 +1,276 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// certExpiresIn returns an expiration date the given number of whole days
+// from now.
+func certExpiresIn(days int) time.Time {
+	return time.Now().Add(time.Duration(days)*24*time.Hour + time.Hour)
+}
+
+func TestGetConnectionCertificate(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	t.Run("TLS endpoint", func(t *testing.T) {
+		srv := httptest.NewTLSServer(handler)
+		defer srv.Close()
+
+		u, _ := url.Parse(srv.URL + vim25.Path)
+		c := &vim25.Client{Client: soap.NewClient(u, true)}
+
+		got, err := GetConnectionCertificate(context.Background(), c)
+		if err != nil {
+			t.Fatalf("want nil error; got %v", err)
+		}
+
+		if !got.Equal(srv.Certificate()) {
+			t.Errorf("want server certificate %s; got %s", srv.Certificate().Subject, got.Subject)
+		}
+	})
+
+	t.Run("plain HTTP endpoint", func(t *testing.T) {
+		srv := httptest.NewServer(handler)
+		defer srv.Close()
+
+		u, _ := url.Parse(srv.URL + vim25.Path)
+		c := &vim25.Client{Client: soap.NewClient(u, true)}
+
+		if _, err := GetConnectionCertificate(context.Background(), c); err == nil {
+			t.Error("want error for endpoint without certificates; got nil")
+		}
+	})
+}
+
+func TestNewConnectionCertificateStatus(t *testing.T) {
+	notAfter := time.Date(2030, time.June, 1, 12, 0, 0, 0, time.UTC)
+	thresholds := CertificateThresholds{ExpiryWarning: 30, ExpiryCritical: 15}
+
+	cert := x509.Certificate{
+		Subject:  pkix.Name{CommonName: "vc1.example.com"},
+		Issuer:   pkix.Name{CommonName: "CA"},
+		NotAfter: notAfter,
+	}
+
+	want := CertificateStatus{
+		Endpoint:   "vc1.example.com",
+		Source:     CertificateSourceConnection,
+		Subject:    "CN=vc1.example.com",
+		Issuer:     "CN=CA",
+		NotAfter:   notAfter,
+		Thresholds: thresholds,
+	}
+
+	got := NewConnectionCertificateStatus("vc1.example.com", &cert, thresholds)
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestNewHostCertificateStatus(t *testing.T) {
+	notAfter := time.Date(2030, time.June, 1, 12, 0, 0, 0, time.UTC)
+	thresholds := CertificateThresholds{ExpiryWarning: 30, ExpiryCritical: 15}
+
+	var hs mo.HostSystem
+	hs.Name = "esx1"
+
+	tests := map[string]struct {
+		notAfter *time.Time
+		want     time.Time
+	}{
+		"expiration date reported":     {notAfter: &notAfter, want: notAfter},
+		"expiration date not reported": {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			info := types.HostCertificateManagerCertificateInfo{
+				Subject:  "CN=esx1",
+				Issuer:   "CN=VMCA",
+				NotAfter: tt.notAfter,
+				Status:   "good",
+			}
+
+			want := CertificateStatus{
+				Endpoint:   "esx1",
+				Source:     CertificateSourceHost,
+				Subject:    "CN=esx1",
+				Issuer:     "CN=VMCA",
+				NotAfter:   tt.want,
+				Status:     "good",
+				Thresholds: thresholds,
+			}
+
+			got := NewHostCertificateStatus(hs, info, thresholds)
+			if d := cmp.Diff(want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestCertificateStatusState(t *testing.T) {
+	thresholds := CertificateThresholds{ExpiryWarning: 30, ExpiryCritical: 15}
+
+	tests := map[string]struct {
+		cs           CertificateStatus
+		wantDays     int
+		wantKnown    bool
+		wantExpired  bool
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"valid certificate": {
+			cs:        CertificateStatus{NotAfter: certExpiresIn(365)},
+			wantDays:  365,
+			wantKnown: true,
+		},
+		"just outside WARNING threshold": {
+			cs:        CertificateStatus{NotAfter: certExpiresIn(31)},
+			wantDays:  31,
+			wantKnown: true,
+		},
+		"at WARNING threshold": {
+			cs:          CertificateStatus{NotAfter: certExpiresIn(30)},
+			wantDays:    30,
+			wantKnown:   true,
+			wantWarning: true,
+		},
+		"at CRITICAL threshold": {
+			cs:           CertificateStatus{NotAfter: certExpiresIn(15)},
+			wantDays:     15,
+			wantKnown:    true,
+			wantCritical: true,
+		},
+		"expired": {
+			cs:           CertificateStatus{NotAfter: time.Now().Add(-5*24*time.Hour - time.Hour)},
+			wantDays:     -5,
+			wantKnown:    true,
+			wantExpired:  true,
+			wantCritical: true,
+		},
+		"expired within the last day": {
+			cs:           CertificateStatus{NotAfter: time.Now().Add(-time.Hour)},
+			wantKnown:    true,
+			wantExpired:  true,
+			wantCritical: true,
+		},
+		"expiration date not reported": {
+			cs: CertificateStatus{},
+		},
+		"unavailable host": {
+			cs: CertificateStatus{NotAfter: time.Now().Add(-time.Hour), Unavailable: true},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.cs.Thresholds = thresholds
+
+			days, known := tt.cs.DaysRemaining()
+			if days != tt.wantDays || known != tt.wantKnown {
+				t.Errorf("want %d days (known: %t); got %d (known: %t)", tt.wantDays, tt.wantKnown, days, known)
+			}
+
+			if got := tt.cs.IsExpired(); got != tt.wantExpired {
+				t.Errorf("want expired %t; got %t", tt.wantExpired, got)
+			}
+
+			if got := tt.cs.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := tt.cs.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestCertificateStatusSetCounts(t *testing.T) {
+	thresholds := CertificateThresholds{ExpiryWarning: 30, ExpiryCritical: 15}
+
+	set := CertificateStatusSet{
+		{Endpoint: "vc1", NotAfter: certExpiresIn(200), Thresholds: thresholds},
+		{Endpoint: "esx1", NotAfter: certExpiresIn(20), Thresholds: thresholds},
+		{Endpoint: "esx2", NotAfter: time.Now().Add(-3*24*time.Hour - time.Hour), Thresholds: thresholds},
+		{Endpoint: "esx3", Unavailable: true, Thresholds: thresholds},
+	}
+
+	if got := set.NumEvaluated(); got != 3 {
+		t.Errorf("want 3 evaluated certificates; got %d", got)
+	}
+
+	if got := set.NumUnavailable(); got != 1 {
+		t.Errorf("want 1 unavailable host; got %d", got)
+	}
+
+	if got := set.NumExpired(); got != 1 {
+		t.Errorf("want 1 expired certificate; got %d", got)
+	}
+
+	if got := set.NumCritical(); got != 1 || !set.HasCriticalState() {
+		t.Errorf("want 1 CRITICAL certificate; got %d", got)
+	}
+
+	if got := set.NumWarning(); got != 1 || !set.HasWarningState() {
+		t.Errorf("want 1 WARNING certificate; got %d", got)
+	}
+
+	days, ok := set.MinDaysRemaining()
+	if !ok || days != -3 {
+		t.Errorf("want -3 minimum days remaining; got %d (known: %t)", days, ok)
+	}
+
+	if _, ok := set[3:].MinDaysRemaining(); ok {
+		t.Error("want unknown minimum days remaining for unavailable hosts")
+	}
+}
+
+func TestCertificateStatusPerfData(t *testing.T) {
+	thresholds := CertificateThresholds{ExpiryWarning: 30, ExpiryCritical: 15}
+
+	set := CertificateStatusSet{
+		{Endpoint: "vc1", NotAfter: certExpiresIn(200), Thresholds: thresholds},
+		{Endpoint: "esx1", NotAfter: certExpiresIn(20), Thresholds: thresholds},
+		{Endpoint: "esx2", Unavailable: true, Thresholds: thresholds},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "certificates", Value: "3", Min: "0"},
+		{Label: "certificates_evaluated", Value: "2", Min: "0"},
+		{Label: "certificates_unavailable", Value: "1", Min: "0"},
+		{Label: "certificates_expired", Value: "0", Min: "0"},
+		{Label: "certificates_critical", Value: "0", Min: "0"},
+		{Label: "certificates_warning", Value: "1", Min: "0"},
+		{Label: "days_remaining_min", Value: "20", Warn: "30:", Crit: "15:"},
+	}
+
+	if d := cmp.Diff(want, CertificateStatusPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,614 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+)
+
+// ErrVCenterDatabaseHealthThresholdCrossed indicates that the vCenter
+// database health or storage usage has crossed a specified threshold.
+var ErrVCenterDatabaseHealthThresholdCrossed = errors.New("vCenter database health threshold crossed")
+
+// applianceDatabaseStorageHealthPath is the vSphere Automation API path
+// (relative to the /api endpoint) for the overall health of the vCenter
+// appliance database storage.
+const applianceDatabaseStorageHealthPath string = "/api/appliance/health/database-storage"
+
+// applianceDatabaseHealthPath is the vSphere Automation API path (relative to
+// the /api endpoint) for the health of the vCenter appliance database. This
+// endpoint is available in vCenter 7.0 U2 and newer.
+const applianceDatabaseHealthPath string = "/api/appliance/health/database"
+
+// applianceMonitoringQueryPath is the vSphere Automation API path (relative
+// to the /api endpoint) used to query monitored items of the vCenter
+// appliance.
+const applianceMonitoringQueryPath string = "/api/appliance/monitoring/query"
+
+// applianceMonitoringQueryWindow is the period of time prior to the current
+// time used when querying monitored items of the vCenter appliance. The most
+// recent non-empty sample in this period is used.
+const applianceMonitoringQueryWindow = time.Hour
+
+// Health status values reported by the vCenter appliance health API.
+const (
+	ApplianceHealthGreen   string = "green"
+	ApplianceHealthYellow  string = "yellow"
+	ApplianceHealthOrange  string = "orange"
+	ApplianceHealthRed     string = "red"
+	ApplianceHealthGray    string = "gray"
+	ApplianceHealthUnknown string = "unknown"
+)
+
+// Health status values reported by the vCenter appliance database health
+// API.
+const (
+	ApplianceDatabaseHealthy   string = "HEALTHY"
+	ApplianceDatabaseDegraded  string = "DEGRADED"
+	ApplianceDatabaseUnhealthy string = "UNHEALTHY"
+)
+
+// VCenterDBPartition represents the usage of a vCenter appliance filesystem
+// partition used by the embedded vPostgres database.
+type VCenterDBPartition struct {
+
+	// Name is the monitoring name of the partition (e.g., seat).
+	Name string
+
+	// MountPoint is the filesystem path of the partition (e.g.,
+	// /storage/seat).
+	MountPoint string
+
+	// UsedKB is the used space of the partition in kilobytes.
+	UsedKB int64
+
+	// TotalKB is the total size of the partition in kilobytes.
+	TotalKB int64
+
+	// Available indicates whether usage details were reported for the
+	// partition.
+	Available bool
+}
+
+// VCenterDBComponent represents the storage used by a category of data in
+// the vCenter database (e.g., Stats, Events, Alarms and Tasks).
+type VCenterDBComponent struct {
+
+	// Name is the monitoring name of the component (e.g., vcdb_seat).
+	Name string
+
+	// Description is the human readable description of the component.
+	Description string
+
+	// UsedKB is the space used by the component in kilobytes.
+	UsedKB int64
+}
+
+// VCenterDatabaseHealth represents the health and storage usage of the
+// vCenter appliance database.
+type VCenterDatabaseHealth struct {
+
+	// StorageHealth is the overall database storage health (e.g., green).
+	StorageHealth string
+
+	// DatabaseStatus is the database health status (e.g., HEALTHY). This is
+	// empty if not supported by the vCenter version.
+	DatabaseStatus string
+
+	// Messages are the health messages reported with the database status.
+	Messages []string
+
+	// Partitions is the collection of database partitions.
+	Partitions []VCenterDBPartition
+
+	// Components is the collection of database components with reported
+	// storage usage.
+	Components []VCenterDBComponent
+
+	// UsageWarning is the WARNING threshold for partition usage (as a
+	// percentage).
+	UsageWarning int
+
+	// UsageCritical is the CRITICAL threshold for partition usage (as a
+	// percentage).
+	UsageCritical int
+}
+
+// vcenterDBPartitions is the collection of vCenter appliance partitions used
+// by the embedded vPostgres database. The /storage/seat partition holds
+// Stats, Events, Alarms and Tasks (SEAT) data.
+var vcenterDBPartitions = []VCenterDBPartition{
+	{Name: "seat", MountPoint: "/storage/seat"},
+	{Name: "db", MountPoint: "/storage/db"},
+	{Name: "dblog", MountPoint: "/storage/dblog"},
+}
+
+// vcenterDBComponents is the collection of vCenter database components
+// reported by the appliance monitoring API.
+var vcenterDBComponents = []VCenterDBComponent{
+	{Name: "vcdb_seat", Description: "Stats, Events, Alarms and Tasks (SEAT)"},
+	{Name: "vcdb_core_inventory", Description: "Core inventory"},
+	{Name: "vcdb_transaction_log", Description: "Transaction log"},
+}
+
+// applianceDatabaseHealth is the database health response returned by the
+// vSphere Automation API.
+type applianceDatabaseHealth struct {
+	Status   string `json:"status"`
+	Messages []struct {
+		Severity string `json:"severity"`
+		Message  struct {
+			DefaultMessage string `json:"default_message"`
+		} `json:"message"`
+	} `json:"messages"`
+}
+
+// applianceMonitoredItemData is a monitored item sample collection returned
+// by the vSphere Automation API.
+type applianceMonitoredItemData struct {
+	Name string   `json:"name"`
+	Data []string `json:"data"`
+}
+
+// UsedPercent returns the percentage of the partition used.
+func (p VCenterDBPartition) UsedPercent() float64 {
+	if p.TotalKB <= 0 {
+		return 0
+	}
+
+	return float64(p.UsedKB) / float64(p.TotalKB) * 100
+}
+
+// getApplianceMonitoredItem uses the given vSphere Automation API client to
+// retrieve the most recent non-empty sample for the specified appliance
+// monitored item. A false value is returned if a sample is not available.
+func getApplianceMonitoredItem(ctx context.Context, rc *rest.Client, name string) (int64, bool, error) {
+	end := time.Now().UTC()
+	start := end.Add(-applianceMonitoringQueryWindow)
+
+	req := rc.Resource(applianceMonitoringQueryPath).
+		WithParam("names", name).
+		WithParam("interval", "MINUTES5").
+		WithParam("function", "MAX").
+		WithParam("start_time", start.Format(time.RFC3339)).
+		WithParam("end_time", end.Format(time.RFC3339)).
+		Request(http.MethodGet)
+
+	var results []applianceMonitoredItemData
+	if err := rc.Do(ctx, req, &results); err != nil {
+		return 0, false, fmt.Errorf(
+			"failed to query appliance monitored item %s: %w",
+			name,
+			err,
+		)
+	}
+
+	for _, item := range results {
+		if item.Name != name {
+			continue
+		}
+
+		for i := len(item.Data) - 1; i >= 0; i-- {
+			sample := strings.TrimSpace(item.Data[i])
+			if sample == "" {
+				continue
+			}
+
+			value, err := strconv.ParseFloat(sample, 64)
+			if err != nil {
+				return 0, false, fmt.Errorf(
+					"failed to parse sample %q for appliance monitored item %s: %w",
+					sample,
+					name,
+					err,
+				)
+			}
+
+			return int64(value), true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// GetVCenterDatabaseHealth uses the given vSphere Automation API client to
+// retrieve the health and storage usage of the vCenter appliance database.
+// Details not supported by the vCenter version are omitted.
+func GetVCenterDatabaseHealth(
+	ctx context.Context,
+	rc *rest.Client,
+	usageWarning int,
+	usageCritical int,
+) (VCenterDatabaseHealth, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVCenterDatabaseHealth func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	dbHealth := VCenterDatabaseHealth{
+		UsageWarning:  usageWarning,
+		UsageCritical: usageCritical,
+	}
+
+	req := rc.Resource(applianceDatabaseStorageHealthPath).Request(http.MethodGet)
+	if err := rc.Do(ctx, req, &dbHealth.StorageHealth); err != nil {
+		return VCenterDatabaseHealth{}, fmt.Errorf(
+			"failed to retrieve database storage health: %w",
+			err,
+		)
+	}
+
+	var status applianceDatabaseHealth
+	req = rc.Resource(applianceDatabaseHealthPath).Request(http.MethodGet)
+	switch err := rc.Do(ctx, req, &status); {
+	case err != nil:
+		// Not supported prior to vCenter 7.0 U2.
+		logger.Printf("database health status unavailable: %v", err)
+
+	default:
+		dbHealth.DatabaseStatus = status.Status
+		for _, msg := range status.Messages {
+			dbHealth.Messages = append(
+				dbHealth.Messages,
+				fmt.Sprintf("%s: %s", msg.Severity, msg.Message.DefaultMessage),
+			)
+		}
+	}
+
+	for _, partition := range vcenterDBPartitions {
+		used, usedOK, err := getApplianceMonitoredItem(
+			ctx, rc, "storage.used.filesystem."+partition.Name,
+		)
+		if err != nil {
+			return VCenterDatabaseHealth{}, err
+		}
+
+		total, totalOK, err := getApplianceMonitoredItem(
+			ctx, rc, "storage.totalsize.filesystem."+partition.Name,
+		)
+		if err != nil {
+			return VCenterDatabaseHealth{}, err
+		}
+
+		if usedOK && totalOK && total > 0 {
+			partition.UsedKB = used
+			partition.TotalKB = total
+			partition.Available = true
+		} else {
+			logger.Printf(
+				"usage details for partition %s unavailable",
+				partition.MountPoint,
+			)
+		}
+
+		dbHealth.Partitions = append(dbHealth.Partitions, partition)
+	}
+
+	for _, component := range vcenterDBComponents {
+		used, ok, err := getApplianceMonitoredItem(ctx, rc, component.Name)
+		switch {
+		case err != nil:
+			// Component monitored items vary by vCenter version.
+			logger.Printf(
+				"usage details for database component %s unavailable: %v",
+				component.Name,
+				err,
+			)
+
+			continue
+
+		case !ok:
+			continue
+		}
+
+		component.UsedKB = used
+		dbHealth.Components = append(dbHealth.Components, component)
+	}
+
+	return dbHealth, nil
+
+}
+
+// NumPartitionsCritical returns the number of partitions with usage at or
+// above the CRITICAL threshold.
+func (dbh VCenterDatabaseHealth) NumPartitionsCritical() int {
+	var num int
+	for _, p := range dbh.Partitions {
+		if p.Available && p.UsedPercent() >= float64(dbh.UsageCritical) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumPartitionsWarning returns the number of partitions with usage at or
+// above the WARNING threshold, but below the CRITICAL threshold.
+func (dbh VCenterDatabaseHealth) NumPartitionsWarning() int {
+	var num int
+	for _, p := range dbh.Partitions {
+		if p.Available &&
+			p.UsedPercent() >= float64(dbh.UsageWarning) &&
+			p.UsedPercent() < float64(dbh.UsageCritical) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether the database storage health, database
+// health or partition usage is in a CRITICAL state.
+func (dbh VCenterDatabaseHealth) IsCriticalState() bool {
+	return strings.EqualFold(dbh.StorageHealth, ApplianceHealthRed) ||
+		strings.EqualFold(dbh.DatabaseStatus, ApplianceDatabaseUnhealthy) ||
+		dbh.NumPartitionsCritical() > 0
+}
+
+// IsWarningState indicates whether the database storage health, database
+// health or partition usage is in a WARNING state.
+func (dbh VCenterDatabaseHealth) IsWarningState() bool {
+	return strings.EqualFold(dbh.StorageHealth, ApplianceHealthOrange) ||
+		strings.EqualFold(dbh.StorageHealth, ApplianceHealthYellow) ||
+		strings.EqualFold(dbh.DatabaseStatus, ApplianceDatabaseDegraded) ||
+		dbh.NumPartitionsWarning() > 0
+}
+
+// VCenterDatabaseHealthPerfData generates performance data metrics from the
+// given vCenter database health details.
+func VCenterDatabaseHealthPerfData(dbh VCenterDatabaseHealth) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "partitions_critical",
+			Value: fmt.Sprintf("%d", dbh.NumPartitionsCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "partitions_warning",
+			Value: fmt.Sprintf("%d", dbh.NumPartitionsWarning()),
+			Min:   "0",
+		},
+	}
+
+	for _, p := range dbh.Partitions {
+		if !p.Available {
+			continue
+		}
+
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label:             p.Name + "_usage",
+				Value:             fmt.Sprintf("%.2f", p.UsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", dbh.UsageWarning),
+				Crit:              fmt.Sprintf("%d", dbh.UsageCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             p.Name + "_used",
+				Value:             fmt.Sprintf("%d", p.UsedKB),
+				UnitOfMeasurement: "KB",
+				Min:               "0",
+			},
+		)
+	}
+
+	for _, component := range dbh.Components {
+		pd = append(pd, nagios.PerformanceData{
+			Label:             component.Name + "_used",
+			Value:             fmt.Sprintf("%d", component.UsedKB),
+			UnitOfMeasurement: "KB",
+			Min:               "0",
+		})
+	}
+
+	return pd
+
+}
+
+// VCenterDatabaseHealthOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VCenterDatabaseHealthOneLineCheckSummary(
+	stateLabel string,
+	dbh VCenterDatabaseHealth,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"dbh": dbh,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterDatabaseHealthOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var seatUsage string
+	for _, p := range dbh.Partitions {
+		if p.Name == "seat" && p.Available {
+			seatUsage = fmt.Sprintf(", %s %.2f%% used", p.MountPoint, p.UsedPercent())
+		}
+	}
+
+	switch {
+	case dbh.IsCriticalState() || dbh.IsWarningState():
+		return fmt.Sprintf(
+			"%s: vCenter database health issues detected (storage health %s, %d partitions above threshold%s)",
+			stateLabel,
+			dbh.StorageHealth,
+			dbh.NumPartitionsCritical()+dbh.NumPartitionsWarning(),
+			seatUsage,
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: vCenter database healthy (storage health %s%s)",
+			stateLabel,
+			dbh.StorageHealth,
+			seatUsage,
+		)
+	}
+}
+
+// VCenterDatabaseHealthReport generates a summary of the vCenter database
+// health and storage usage along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body
+// of many notifications.
+func VCenterDatabaseHealthReport(
+	c *vim25.Client,
+	dbh VCenterDatabaseHealth,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterDatabaseHealthReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	dbStatus := dbh.DatabaseStatus
+	if dbStatus == "" {
+		dbStatus = "unavailable"
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Database health:%s%s"+
+			"* Storage health: %s%s"+
+			"* Database status: %s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		dbh.StorageHealth,
+		nagios.CheckOutputEOL,
+		dbStatus,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, msg := range dbh.Messages {
+		_, _ = fmt.Fprintf(
+			&report,
+			"** %s%s",
+			msg,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sPartitions:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, p := range dbh.Partitions {
+		if !p.Available {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s [unavailable]%s",
+				p.MountPoint,
+				nagios.CheckOutputEOL,
+			)
+
+			continue
+		}
+
+		var state string
+		switch {
+		case p.UsedPercent() >= float64(dbh.UsageCritical):
+			state = nagios.StateCRITICALLabel
+		case p.UsedPercent() >= float64(dbh.UsageWarning):
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %s of %s used (%.2f%%) [%s]%s",
+			p.MountPoint,
+			units.ByteSize(p.UsedKB*units.KB),
+			units.ByteSize(p.TotalKB*units.KB),
+			p.UsedPercent(),
+			state,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(dbh.Components) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sDatabase components:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, component := range dbh.Components {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: %s%s",
+				component.Description,
+				units.ByteSize(component.UsedKB*units.KB),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
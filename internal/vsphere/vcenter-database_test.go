@@ -0,0 +1,334 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// newApplianceClient returns a vSphere Automation API client for a test
+// server providing the appliance health and monitoring services. The
+// monitoring service returns the given samples for each monitored item;
+// items listed as failing return a server error. The database health
+// service is only provided if dbStatus is not empty.
+func newApplianceClient(
+	t *testing.T,
+	dbStatus string,
+	samples map[string][]string,
+	failing ...string,
+) *rest.Client {
+	t.Helper()
+
+	respond := func(w http.ResponseWriter, v interface{}) {
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case applianceDatabaseStorageHealthPath:
+			respond(w, ApplianceHealthGreen)
+
+		case applianceDatabaseHealthPath:
+			if dbStatus == "" {
+				http.NotFound(w, r)
+				return
+			}
+
+			status := map[string]interface{}{
+				"status": dbStatus,
+				"messages": []map[string]interface{}{
+					{
+						"severity": "WARNING",
+						"message":  map[string]string{"default_message": "replication lag"},
+					},
+				},
+			}
+			respond(w, status)
+
+		case applianceMonitoringQueryPath:
+			name := r.URL.Query().Get("names")
+			for _, item := range failing {
+				if item == name {
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+			}
+
+			results := []applianceMonitoredItemData{}
+			if data, ok := samples[name]; ok {
+				results = append(results, applianceMonitoredItemData{Name: name, Data: data})
+			}
+			respond(w, results)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL + vim25.Path)
+
+	return rest.NewClient(&vim25.Client{Client: soap.NewClient(u, false)})
+}
+
+func TestGetApplianceMonitoredItem(t *testing.T) {
+	tests := map[string]struct {
+		data    []string
+		want    int64
+		wantOK  bool
+		wantErr bool
+	}{
+		"latest sample":                  {data: []string{"10", "20", "30"}, want: 30, wantOK: true},
+		"trailing empty samples":         {data: []string{"10", "25.9", "", " "}, want: 25, wantOK: true},
+		"no samples":                     {data: []string{}},
+		"only empty samples":             {data: []string{"", ""}},
+		"invalid sample":                 {data: []string{"10", "n/a"}, wantErr: true},
+		"earlier invalid sample ignored": {data: []string{"n/a", "15"}, want: 15, wantOK: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			rc := newApplianceClient(t, "", map[string][]string{"storage.used.filesystem.seat": tt.data})
+
+			got, ok, err := getApplianceMonitoredItem(context.Background(), rc, "storage.used.filesystem.seat")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t; got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("want %d (available: %t); got %d (available: %t)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestGetVCenterDatabaseHealth(t *testing.T) {
+	samples := map[string][]string{
+		"storage.used.filesystem.seat":       {"10240", "20480", ""},
+		"storage.totalsize.filesystem.seat":  {"102400"},
+		"storage.used.filesystem.db":         {"5120"},
+		"storage.totalsize.filesystem.db":    {},
+		"storage.used.filesystem.dblog":      {"1024"},
+		"storage.totalsize.filesystem.dblog": {"0"},
+		"vcdb_seat":                          {"2048"},
+		"vcdb_core_inventory":                {},
+	}
+
+	t.Run("database health supported", func(t *testing.T) {
+		rc := newApplianceClient(t, ApplianceDatabaseDegraded, samples, "vcdb_transaction_log")
+
+		got, err := GetVCenterDatabaseHealth(context.Background(), rc, 80, 90)
+		if err != nil {
+			t.Fatalf("want nil error; got %v", err)
+		}
+
+		want := VCenterDatabaseHealth{
+			StorageHealth:  ApplianceHealthGreen,
+			DatabaseStatus: ApplianceDatabaseDegraded,
+			Messages:       []string{"WARNING: replication lag"},
+			Partitions: []VCenterDBPartition{
+				{Name: "seat", MountPoint: "/storage/seat", UsedKB: 20480, TotalKB: 102400, Available: true},
+				{Name: "db", MountPoint: "/storage/db"},
+				{Name: "dblog", MountPoint: "/storage/dblog"},
+			},
+			Components: []VCenterDBComponent{
+				{Name: "vcdb_seat", Description: "Stats, Events, Alarms and Tasks (SEAT)", UsedKB: 2048},
+			},
+			UsageWarning:  80,
+			UsageCritical: 90,
+		}
+
+		if d := cmp.Diff(want, got); d != "" {
+			t.Errorf("(-want, +got):\n%s", d)
+		}
+	})
+
+	t.Run("database health not supported", func(t *testing.T) {
+		rc := newApplianceClient(t, "", samples)
+
+		got, err := GetVCenterDatabaseHealth(context.Background(), rc, 80, 90)
+		if err != nil {
+			t.Fatalf("want nil error; got %v", err)
+		}
+
+		if got.DatabaseStatus != "" || got.Messages != nil {
+			t.Errorf("want no database status; got %q (%v)", got.DatabaseStatus, got.Messages)
+		}
+	})
+
+	t.Run("partition usage query failure", func(t *testing.T) {
+		rc := newApplianceClient(t, "", samples, "storage.totalsize.filesystem.db")
+
+		if _, err := GetVCenterDatabaseHealth(context.Background(), rc, 80, 90); err == nil {
+			t.Error("want error for failed partition usage query; got nil")
+		}
+	})
+}
+
+func TestVCenterDBPartitionUsedPercent(t *testing.T) {
+	tests := map[string]struct {
+		partition VCenterDBPartition
+		want      float64
+	}{
+		"quarter used":   {partition: VCenterDBPartition{UsedKB: 25, TotalKB: 100}, want: 25},
+		"fully used":     {partition: VCenterDBPartition{UsedKB: 2048, TotalKB: 2048}, want: 100},
+		"no size":        {partition: VCenterDBPartition{UsedKB: 10}},
+		"invalid size":   {partition: VCenterDBPartition{UsedKB: 10, TotalKB: -1}},
+		"nothing in use": {partition: VCenterDBPartition{TotalKB: 100}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.partition.UsedPercent(); got != tt.want {
+				t.Errorf("want %v%%; got %v%%", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVCenterDatabaseHealthState(t *testing.T) {
+	const totalKB int64 = 100 * 1024 * 1024
+
+	partition := func(usedPercent int64, available bool) VCenterDBPartition {
+		return VCenterDBPartition{
+			Name:      "seat",
+			UsedKB:    totalKB / 100 * usedPercent,
+			TotalKB:   totalKB,
+			Available: available,
+		}
+	}
+
+	tests := map[string]struct {
+		storageHealth    string
+		databaseStatus   string
+		partitions       []VCenterDBPartition
+		wantPartsCrit    int
+		wantPartsWarning int
+		wantCritical     bool
+		wantWarning      bool
+	}{
+		"healthy": {
+			storageHealth:  ApplianceHealthGreen,
+			databaseStatus: ApplianceDatabaseHealthy,
+			partitions:     []VCenterDBPartition{partition(40, true), partition(20, true)},
+		},
+		"database status not supported": {
+			storageHealth: ApplianceHealthGreen,
+		},
+		"storage health yellow": {
+			storageHealth: ApplianceHealthYellow,
+			wantWarning:   true,
+		},
+		"storage health orange uppercase": {
+			storageHealth: "ORANGE",
+			wantWarning:   true,
+		},
+		"storage health red": {
+			storageHealth: ApplianceHealthRed,
+			wantCritical:  true,
+		},
+		"storage health gray": {
+			storageHealth: ApplianceHealthGray,
+		},
+		"database degraded": {
+			storageHealth:  ApplianceHealthGreen,
+			databaseStatus: ApplianceDatabaseDegraded,
+			wantWarning:    true,
+		},
+		"database unhealthy": {
+			storageHealth:  ApplianceHealthGreen,
+			databaseStatus: ApplianceDatabaseUnhealthy,
+			wantCritical:   true,
+		},
+		"partition usage at WARNING threshold": {
+			storageHealth:    ApplianceHealthGreen,
+			partitions:       []VCenterDBPartition{partition(80, true), partition(20, true)},
+			wantPartsWarning: 1,
+			wantWarning:      true,
+		},
+		"partition usage at CRITICAL threshold": {
+			storageHealth:    ApplianceHealthGreen,
+			partitions:       []VCenterDBPartition{partition(90, true), partition(85, true)},
+			wantPartsCrit:    1,
+			wantPartsWarning: 1,
+			wantCritical:     true,
+			wantWarning:      true,
+		},
+		"unavailable partition usage ignored": {
+			storageHealth: ApplianceHealthGreen,
+			partitions:    []VCenterDBPartition{partition(95, false)},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dbHealth := VCenterDatabaseHealth{
+				StorageHealth:  tt.storageHealth,
+				DatabaseStatus: tt.databaseStatus,
+				Partitions:     tt.partitions,
+				UsageWarning:   80,
+				UsageCritical:  90,
+			}
+
+			if got := dbHealth.NumPartitionsCritical(); got != tt.wantPartsCrit {
+				t.Errorf("want %d CRITICAL partitions; got %d", tt.wantPartsCrit, got)
+			}
+
+			if got := dbHealth.NumPartitionsWarning(); got != tt.wantPartsWarning {
+				t.Errorf("want %d WARNING partitions; got %d", tt.wantPartsWarning, got)
+			}
+
+			if got := dbHealth.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := dbHealth.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVCenterDatabaseHealthPerfData(t *testing.T) {
+	dbHealth := VCenterDatabaseHealth{
+		Partitions: []VCenterDBPartition{
+			{Name: "seat", UsedKB: 85, TotalKB: 100, Available: true},
+			{Name: "db"},
+		},
+		Components: []VCenterDBComponent{
+			{Name: "vcdb_seat", UsedKB: 2048},
+		},
+		UsageWarning:  80,
+		UsageCritical: 90,
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "partitions_critical", Value: "0", Min: "0"},
+		{Label: "partitions_warning", Value: "1", Min: "0"},
+		{Label: "seat_usage", Value: "85.00", UnitOfMeasurement: "%", Warn: "80", Crit: "90", Min: "0", Max: "100"},
+		{Label: "seat_used", Value: "85", UnitOfMeasurement: "KB", Min: "0"},
+		{Label: "vcdb_seat_used", Value: "2048", UnitOfMeasurement: "KB", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VCenterDatabaseHealthPerfData(dbHealth)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
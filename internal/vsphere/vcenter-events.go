@@ -0,0 +1,719 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrMatchedEventsThresholdCrossed indicates that the number of events
+// matching the specified event type IDs or message substrings within the
+// lookback window has crossed a specified threshold.
+var ErrMatchedEventsThresholdCrossed = errors.New("matched events exceed specified threshold")
+
+// VCenterEvent represents an event recorded by vCenter along with the
+// results of evaluating the event against specified filters.
+type VCenterEvent struct {
+
+	// Created is when the event was recorded.
+	Created time.Time
+
+	// TypeID is the event type ID (e.g., HostConnectionLostEvent or
+	// esx.problem.vmfs.heartbeat.timedout) for the event.
+	TypeID string
+
+	// Message is the formatted message for the event.
+	Message string
+
+	// UserName is the user responsible for the event. This is empty for
+	// events not associated with a user.
+	UserName string
+
+	// EntityName is the name of the most specific entity (e.g., VM, host or
+	// datastore) associated with the event.
+	EntityName string
+
+	// Datacenter is the name of the datacenter associated with the event.
+	Datacenter string
+
+	// ExcludeReason gives a brief explanation of why a VCenterEvent is
+	// excluded.
+	ExcludeReason string
+
+	// Key is the unique identifier for the event.
+	Key int32
+
+	// Exclude indicates whether the VCenterEvent has been excluded from
+	// final evaluation. During processing multiple filters are applied. We
+	// track exclusion state through the filtering pipeline so that any
+	// explicit inclusions chosen by the sysadmin will have the opportunity to
+	// reset this state and have the VCenterEvent considered for evaluation.
+	Exclude bool
+
+	// ExplicitlyIncluded indicates whether the VCenterEvent has been marked
+	// for explicit inclusion by a step in the filtering pipeline. A
+	// VCenterEvent marked in this way is not "dropped" by later explicit
+	// inclusion filtering steps in the pipeline.
+	ExplicitlyIncluded bool
+
+	// ExplicitlyExcluded indicates whether the VCenterEvent has been marked
+	// for explicit exclusion by a step in the filtering pipeline.
+	ExplicitlyExcluded bool
+}
+
+// VCenterEvents is a collection of events recorded by vCenter.
+type VCenterEvents []VCenterEvent
+
+// VCenterEventFilters is a collection of the options specified by the user
+// for filtering retrieved VCenterEvents. This is most often used for
+// providing summary information in logging or user-facing output.
+type VCenterEventFilters struct {
+	IncludedEventTypeIDs  []string
+	ExcludedEventTypeIDs  []string
+	IncludedEventMessages []string
+	ExcludedEventMessages []string
+	ExcludedEntityNames   []string
+	ExcludedUserNames     []string
+}
+
+// VCenterEventThresholds represents the user-specified thresholds for the
+// number of matched events within the lookback window.
+type VCenterEventThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// eventEntityName returns the name of the most specific entity associated
+// with the given event.
+func eventEntityName(event types.BaseEvent) string {
+	e := event.GetEvent()
+
+	switch {
+	case e.Vm != nil:
+		return e.Vm.Name
+	case e.Host != nil:
+		return e.Host.Name
+	case e.Ds != nil:
+		return e.Ds.Name
+	case e.Net != nil:
+		return e.Net.Name
+	case e.Dvs != nil:
+		return e.Dvs.Name
+	case e.ComputeResource != nil:
+		return e.ComputeResource.Name
+	case e.Datacenter != nil:
+		return e.Datacenter.Name
+	}
+
+	if ex, ok := event.(*types.EventEx); ok {
+		return ex.ObjectName
+	}
+
+	return ""
+}
+
+// eventFormattedMessage returns the formatted message for the given event,
+// falling back to the unformatted message for extended events if a
+// formatted message is not available.
+func eventFormattedMessage(event types.BaseEvent) string {
+	e := event.GetEvent()
+
+	if e.FullFormattedMessage == "" {
+		if ex, ok := event.(*types.EventEx); ok {
+			return ex.Message
+		}
+	}
+
+	return strings.TrimSpace(e.FullFormattedMessage)
+}
+
+// NewVCenterEvent converts the given event into a VCenterEvent.
+func NewVCenterEvent(event types.BaseEvent) VCenterEvent {
+	e := event.GetEvent()
+
+	var datacenter string
+	if e.Datacenter != nil {
+		datacenter = e.Datacenter.Name
+	}
+
+	return VCenterEvent{
+		Key:        e.Key,
+		Created:    e.CreatedTime,
+		TypeID:     EventTypeID(event),
+		Message:    eventFormattedMessage(event),
+		UserName:   e.UserName,
+		EntityName: eventEntityName(event),
+		Datacenter: datacenter,
+	}
+}
+
+// GetVCenterEvents retrieves all events recorded within the specified
+// lookback window. If only event type IDs are specified for inclusion the
+// events are limited to those type IDs by vCenter; otherwise all events
+// within the lookback window are retrieved for filtering.
+func GetVCenterEvents(ctx context.Context, c *vim25.Client, lookback time.Duration, filters VCenterEventFilters) (VCenterEvents, error) {
+
+	funcTimeStart := time.Now()
+
+	var vcEvents VCenterEvents
+
+	defer func(vcEvents *VCenterEvents) {
+		logger.Printf(
+			"It took %v to execute GetVCenterEvents func (and retrieve %d events).\n",
+			time.Since(funcTimeStart),
+			len(*vcEvents),
+		)
+	}(&vcEvents)
+
+	begin := time.Now().Add(-lookback)
+
+	filter := types.EventFilterSpec{
+		Time: &types.EventFilterSpecByTime{
+			BeginTime: &begin,
+		},
+	}
+
+	// Message substring matches require evaluating all events.
+	if len(filters.IncludedEventTypeIDs) > 0 && len(filters.IncludedEventMessages) == 0 {
+		filter.EventTypeId = filters.IncludedEventTypeIDs
+	}
+
+	events, err := GetEvents(ctx, c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	vcEvents = make(VCenterEvents, 0, len(events))
+	for _, event := range events {
+		vcEvents = append(vcEvents, NewVCenterEvent(event))
+	}
+
+	return vcEvents, nil
+
+}
+
+// NumExcluded returns the number of VCenterEvents that have been implicitly
+// or explicitly excluded.
+func (vces VCenterEvents) NumExcluded() int {
+	var num int
+	for _, vce := range vces {
+		if vce.Excluded() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumExcludedFinal returns the number of VCenterEvents that have been
+// explicitly excluded.
+func (vces VCenterEvents) NumExcludedFinal() int {
+	var num int
+	for _, vce := range vces {
+		if vce.ExcludedFinal() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumMatched returns the number of VCenterEvents that have not been
+// excluded.
+func (vces VCenterEvents) NumMatched() int {
+	return len(vces) - vces.NumExcluded()
+}
+
+// Matched returns the collection of VCenterEvents that have not been
+// excluded.
+func (vces VCenterEvents) Matched() VCenterEvents {
+	matched := make(VCenterEvents, 0, vces.NumMatched())
+	for _, vce := range vces {
+		if !vce.Excluded() {
+			matched = append(matched, vce)
+		}
+	}
+
+	return matched
+}
+
+// IsCriticalState indicates whether the number of matched VCenterEvents has
+// crossed the CRITICAL threshold.
+func (vces VCenterEvents) IsCriticalState(thresholds VCenterEventThresholds) bool {
+	return vces.NumMatched() > thresholds.Critical
+}
+
+// IsWarningState indicates whether the number of matched VCenterEvents has
+// crossed the WARNING threshold, but not the CRITICAL threshold.
+func (vces VCenterEvents) IsWarningState(thresholds VCenterEventThresholds) bool {
+	return !vces.IsCriticalState(thresholds) &&
+		vces.NumMatched() > thresholds.Warning
+}
+
+// Excluded indicates whether a VCenterEvent has been excluded implicitly
+// (for now) or explicitly (permanently) from further evaluation.
+func (vce VCenterEvent) Excluded() bool {
+	return vce.ExplicitlyExcluded || vce.Exclude
+}
+
+// ExcludedFinal indicates whether a VCenterEvent has been permanently
+// excluded from further evaluation.
+func (vce VCenterEvent) ExcludedFinal() bool {
+	return vce.ExplicitlyExcluded
+}
+
+// logMarked is a helper method for logging when a VCenterEvent has been
+// marked for inclusion or exclusion, mostly for debugging purposes.
+func (vce VCenterEvent) logMarked(keep bool, explicit bool) {
+
+	markType := "implicitly"
+	if explicit {
+		markType = "explicitly"
+	}
+
+	action := "exclusion"
+	if keep {
+		action = "inclusion"
+	}
+
+	logger.Printf(
+		"Event (key %d) of type %q for entity name %q %s marked for %s",
+		vce.Key,
+		vce.TypeID,
+		vce.EntityName,
+		markType,
+		action,
+	)
+
+}
+
+// include marks the VCenterEvent as explicitly included unless it has
+// already been explicitly excluded.
+func (vce *VCenterEvent) include() {
+	// Don't explicitly *include* the VCenterEvent if the VCenterEvent has
+	// already been explicitly *excluded*.
+	if !vce.ExplicitlyExcluded {
+		vce.Exclude = false
+		vce.ExplicitlyIncluded = true
+		vce.logMarked(true, true)
+	}
+}
+
+// excludeImplicit marks the VCenterEvent as implicitly excluded unless it
+// has already been explicitly included by another filter in the pipeline.
+func (vce *VCenterEvent) excludeImplicit(reason string) {
+	if !vce.ExplicitlyIncluded {
+		vce.Exclude = true
+		vce.ExcludeReason = reason
+		vce.logMarked(false, false)
+	}
+}
+
+// excludeExplicit marks the VCenterEvent as explicitly excluded.
+func (vce *VCenterEvent) excludeExplicit(reason string) {
+	vce.Exclude = true
+	vce.ExcludeReason = reason
+	vce.ExplicitlyExcluded = true
+	vce.logMarked(false, true)
+}
+
+// Filter explicitly includes or excludes VCenterEvents based on specified
+// filter settings. Events matching any of the included event type IDs or
+// message substrings are explicitly included; all other events are
+// implicitly excluded. Explicit exclusions are applied afterward and
+// "drop" matching events from further evaluation.
+func (vces *VCenterEvents) Filter(filters VCenterEventFilters) {
+
+	logger.Println("Filtering events by type ID")
+	vces.filterByTypeID(filters.IncludedEventTypeIDs, filters.ExcludedEventTypeIDs)
+
+	logger.Println("Filtering events by message")
+	vces.filterBySubstring(eventMessage, filters.IncludedEventMessages, filters.ExcludedEventMessages)
+
+	logger.Println("Filtering events by entity name")
+	vces.filterBySubstring(eventEntity, []string{}, filters.ExcludedEntityNames)
+
+	logger.Println("Filtering events by user name")
+	vces.filterBySubstring(eventUserName, []string{}, filters.ExcludedUserNames)
+
+}
+
+// filterByTypeID uses slices of event type IDs to explicitly mark
+// VCenterEvent values for inclusion or exclusion in the final evaluation.
+// Type ID comparisons are case-insensitive.
+func (vces *VCenterEvents) filterByTypeID(include []string, exclude []string) {
+
+	funcTimeStart := time.Now()
+
+	// Collect number of non-excluded VCenterEvents at the start of this
+	// filtering process. We'll collect this number again after filtering has
+	// been applied in order to show the results of this filter.
+	nonExcludedStart := len(*vces) - vces.NumExcluded()
+
+	defer func(start *int) {
+		logger.Printf(
+			"It took %v to execute filterByTypeID func (for %d non-excluded VCenterEvents, yielding %d non-excluded VCenterEvents)\n",
+			time.Since(funcTimeStart),
+			*start,
+			len(*vces)-vces.NumExcluded(),
+		)
+	}(&nonExcludedStart)
+
+	switch {
+	// if the collection of VCenterEvents is empty, skip filtering attempts.
+	case len(*vces) == 0:
+		logger.Println("Events list is empty, aborting")
+		return
+
+	// if we're not limiting VCenterEvents by type ID, skip filtering
+	// attempts.
+	case len(include) == 0 && len(exclude) == 0:
+		logger.Println("Events type ID inclusion and exclusion lists are empty, aborting")
+		return
+	}
+
+	for i := range *vces {
+		typeID := (*vces)[i].TypeID
+
+		if len(include) > 0 {
+			switch {
+			case textutils.InList(typeID, include, true):
+				(*vces)[i].include()
+			default:
+				(*vces)[i].excludeImplicit(eventExcludeReasonTypeID)
+			}
+		}
+
+		// explicitly excluded
+		//
+		// no implicit inclusions are applied for non-matching event types as
+		// that could unintentionally flip the results from earlier filtering
+		// stages.
+		if len(exclude) > 0 && textutils.InList(typeID, exclude, true) {
+			(*vces)[i].excludeExplicit(eventExcludeReasonTypeID)
+		}
+	}
+
+}
+
+// filterBySubstring accepts a field keyword and slices of substrings to use
+// in case-insensitive comparisons against VCenterEvent fields in order to
+// explicitly mark VCenterEvents for inclusion or exclusion in the final
+// evaluation. The provided field keyword indicates which field the
+// comparison should be against. If an invalid field keyword is supplied the
+// field comparison will default to using the event message.
+func (vces *VCenterEvents) filterBySubstring(fieldKeyword string, include []string, exclude []string) {
+
+	funcTimeStart := time.Now()
+
+	// Collect number of non-excluded VCenterEvents at the start of this
+	// filtering process. We'll collect this number again after filtering has
+	// been applied in order to show the results of this filter.
+	nonExcludedStart := len(*vces) - vces.NumExcluded()
+
+	defer func(start *int, keyword string) {
+		logger.Printf(
+			"It took %v to execute filterBySubstring func (for %d non-excluded VCenterEvents, using keyword %s, yielding %d non-excluded VCenterEvents)\n",
+			time.Since(funcTimeStart),
+			*start,
+			keyword,
+			len(*vces)-vces.NumExcluded(),
+		)
+	}(&nonExcludedStart, fieldKeyword)
+
+	switch {
+	// if the collection of VCenterEvents is empty, skip filtering attempts.
+	case len(*vces) == 0:
+		logger.Println("Events list is empty, aborting")
+		return
+
+	// if we're not limiting VCenterEvents by this field, skip filtering
+	// attempts.
+	case len(include) == 0 && len(exclude) == 0:
+		logger.Printf(
+			"Events substring (%s) inclusion and exclusion lists are empty, aborting",
+			fieldKeyword,
+		)
+		return
+	}
+
+	matches := func(field string, substrs []string) bool {
+		for _, substr := range substrs {
+			if strings.Contains(strings.ToLower(field), strings.ToLower(substr)) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i := range *vces {
+
+		var substrField string
+		var excludeReason string
+		switch fieldKeyword {
+		case eventEntity:
+			substrField = (*vces)[i].EntityName
+			excludeReason = eventExcludeReasonEntityName
+		case eventUserName:
+			substrField = (*vces)[i].UserName
+			excludeReason = eventExcludeReasonUserName
+		case eventMessage:
+			substrField = (*vces)[i].Message
+			excludeReason = eventExcludeReasonMessage
+		default:
+			logger.Printf(
+				"substring field %q not recognized, defaulting to event message",
+				fieldKeyword,
+			)
+			substrField = (*vces)[i].Message
+			excludeReason = eventExcludeReasonMessage
+		}
+
+		if len(include) > 0 {
+			switch {
+			case matches(substrField, include):
+				(*vces)[i].include()
+			default:
+				(*vces)[i].excludeImplicit(excludeReason)
+			}
+		}
+
+		if len(exclude) > 0 && matches(substrField, exclude) {
+			(*vces)[i].excludeExplicit(excludeReason)
+		}
+	}
+}
+
+// VCenterEventsPerfData generates performance data metrics from the given
+// collection of filtered VCenterEvents.
+func VCenterEventsPerfData(vces VCenterEvents, thresholds VCenterEventThresholds) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "events",
+			Value: fmt.Sprintf("%d", len(vces)),
+			Min:   "0",
+		},
+		{
+			Label: "events_matched",
+			Value: fmt.Sprintf("%d", vces.NumMatched()),
+			Warn:  fmt.Sprintf("%d", thresholds.Warning),
+			Crit:  fmt.Sprintf("%d", thresholds.Critical),
+			Min:   "0",
+		},
+		{
+			Label: "events_excluded",
+			Value: fmt.Sprintf("%d", vces.NumExcludedFinal()),
+			Min:   "0",
+		},
+	}
+}
+
+// VCenterEventsOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VCenterEventsOneLineCheckSummary(
+	stateLabel string,
+	vces VCenterEvents,
+	lookback time.Duration,
+	thresholds VCenterEventThresholds,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vces":       vces,
+		"lookback":   lookback,
+		"thresholds": thresholds,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterEventsOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case vces.IsCriticalState(thresholds) || vces.IsWarningState(thresholds):
+		return fmt.Sprintf(
+			"%s: %d matching events detected within the last %v (%d evaluated)",
+			stateLabel,
+			vces.NumMatched(),
+			lookback,
+			len(vces),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: %d matching events (within thresholds) detected within the last %v (%d evaluated)",
+			stateLabel,
+			vces.NumMatched(),
+			lookback,
+			len(vces),
+		)
+	}
+}
+
+// VCenterEventsReport generates a summary of matched events along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func VCenterEventsReport(
+	c *vim25.Client,
+	vces VCenterEvents,
+	lookback time.Duration,
+	filters VCenterEventFilters,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterEventsReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Matching events:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	matched := vces.Matched()
+	for _, vce := range matched {
+		entityName := vce.EntityName
+		if entityName == "" {
+			entityName = "N/A"
+		}
+
+		userName := vce.UserName
+		if userName == "" {
+			userName = "N/A"
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s on %s (created: %s, user: %s)%s"+
+				"** %s%s",
+			vce.TypeID,
+			entityName,
+			vce.Created.Local().Format(time.RFC3339),
+			userName,
+			nagios.CheckOutputEOL,
+			vce.Message,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if len(matched) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Lookback window: %v%s",
+		lookback,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Events evaluated: %d (%d explicitly excluded)%s",
+		len(vces),
+		vces.NumExcludedFinal(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified event type IDs to explicitly include (%d): [%v]%s",
+		len(filters.IncludedEventTypeIDs),
+		strings.Join(filters.IncludedEventTypeIDs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified event type IDs to explicitly exclude (%d): [%v]%s",
+		len(filters.ExcludedEventTypeIDs),
+		strings.Join(filters.ExcludedEventTypeIDs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified event message substrings to explicitly include (%d): [%v]%s",
+		len(filters.IncludedEventMessages),
+		strings.Join(filters.IncludedEventMessages, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified event message substrings to explicitly exclude (%d): [%v]%s",
+		len(filters.ExcludedEventMessages),
+		strings.Join(filters.ExcludedEventMessages, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified entity names to explicitly exclude (%d): [%v]%s",
+		len(filters.ExcludedEntityNames),
+		strings.Join(filters.ExcludedEntityNames, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified user names to explicitly exclude (%d): [%v]%s",
+		len(filters.ExcludedUserNames),
+		strings.Join(filters.ExcludedUserNames, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
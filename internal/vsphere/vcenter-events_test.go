@@ -0,0 +1,227 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// vcenterEvents returns a collection of events recorded by vCenter.
+func vcenterEvents() VCenterEvents {
+	hostLost := types.HostConnectionLostEvent{}
+	hostLost.Key = 1
+	hostLost.FullFormattedMessage = "Host esx1 in DC1 is not responding"
+	hostLost.Host = &types.HostEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "esx1"},
+	}
+
+	vmPoweredOff := types.VmPoweredOffEvent{}
+	vmPoweredOff.Key = 2
+	vmPoweredOff.FullFormattedMessage = "build1 on esx1 in DC1 is powered off"
+	vmPoweredOff.UserName = "VSPHERE.LOCAL\\svc-build"
+	vmPoweredOff.Vm = &types.VmEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "build1"},
+	}
+
+	heartbeat := types.EventEx{
+		EventTypeId: "esx.problem.vmfs.heartbeat.timedout",
+		Message:     "Lost access to volume ds1",
+		ObjectName:  "ds1",
+	}
+	heartbeat.Key = 3
+
+	return VCenterEvents{
+		NewVCenterEvent(&hostLost),
+		NewVCenterEvent(&vmPoweredOff),
+		NewVCenterEvent(&heartbeat),
+	}
+}
+
+func TestNewVCenterEvent(t *testing.T) {
+	host := &types.HostEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "esx1"},
+	}
+	datacenter := &types.DatacenterEventArgument{
+		EntityEventArgument: types.EntityEventArgument{Name: "DC1"},
+	}
+
+	tests := map[string]struct {
+		event          types.BaseEvent
+		wantTypeID     string
+		wantMessage    string
+		wantEntityName string
+		wantDatacenter string
+	}{
+		"VM preferred over host": {
+			event: &types.VmPoweredOffEvent{
+				VmEvent: types.VmEvent{
+					Event: types.Event{
+						FullFormattedMessage: " build1 is powered off\n",
+						Host:                 host,
+						Datacenter:           datacenter,
+						Vm: &types.VmEventArgument{
+							EntityEventArgument: types.EntityEventArgument{Name: "build1"},
+						},
+					},
+				},
+			},
+			wantTypeID:     "VmPoweredOffEvent",
+			wantMessage:    "build1 is powered off",
+			wantEntityName: "build1",
+			wantDatacenter: "DC1",
+		},
+		"datacenter as last resort entity": {
+			event: &types.GeneralEvent{
+				Event: types.Event{Datacenter: datacenter},
+			},
+			wantTypeID:     "GeneralEvent",
+			wantEntityName: "DC1",
+			wantDatacenter: "DC1",
+		},
+		"extended event object name and message": {
+			event: &types.EventEx{
+				EventTypeId: "esx.problem.vmfs.heartbeat.timedout",
+				Message:     "Lost access to volume ds1",
+				ObjectName:  "ds1",
+			},
+			wantTypeID:     "esx.problem.vmfs.heartbeat.timedout",
+			wantMessage:    "Lost access to volume ds1",
+			wantEntityName: "ds1",
+		},
+		"extended event formatted message preferred": {
+			event: &types.EventEx{
+				Event: types.Event{
+					FullFormattedMessage: "Lost access to volume ds1 due to connectivity issues",
+					Ds: &types.DatastoreEventArgument{
+						EntityEventArgument: types.EntityEventArgument{Name: "ds1"},
+					},
+				},
+				EventTypeId: "esx.problem.vmfs.heartbeat.timedout",
+				Message:     "Lost access to volume ds1",
+				ObjectName:  "volume1",
+			},
+			wantTypeID:     "esx.problem.vmfs.heartbeat.timedout",
+			wantMessage:    "Lost access to volume ds1 due to connectivity issues",
+			wantEntityName: "ds1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVCenterEvent(tt.event)
+
+			if got.TypeID != tt.wantTypeID {
+				t.Errorf("want type ID %q; got %q", tt.wantTypeID, got.TypeID)
+			}
+
+			if got.Message != tt.wantMessage {
+				t.Errorf("want message %q; got %q", tt.wantMessage, got.Message)
+			}
+
+			if got.EntityName != tt.wantEntityName {
+				t.Errorf("want entity name %q; got %q", tt.wantEntityName, got.EntityName)
+			}
+
+			if got.Datacenter != tt.wantDatacenter {
+				t.Errorf("want datacenter %q; got %q", tt.wantDatacenter, got.Datacenter)
+			}
+		})
+	}
+}
+
+func TestVCenterEventsFilter(t *testing.T) {
+	thresholds := VCenterEventThresholds{Warning: 0, Critical: 1}
+
+	tests := map[string]struct {
+		filters           VCenterEventFilters
+		wantMatched       []int32
+		wantExcludedFinal int
+		wantCritical      bool
+		wantWarning       bool
+	}{
+		"no filters": {
+			wantMatched:  []int32{1, 2, 3},
+			wantCritical: true,
+		},
+		"included event type ID": {
+			filters: VCenterEventFilters{
+				IncludedEventTypeIDs: []string{"hostconnectionlostevent"},
+			},
+			wantMatched: []int32{1},
+			wantWarning: true,
+		},
+		"included event type ID or message": {
+			filters: VCenterEventFilters{
+				IncludedEventTypeIDs:  []string{"HostConnectionLostEvent"},
+				IncludedEventMessages: []string{"lost access"},
+			},
+			wantMatched:  []int32{1, 3},
+			wantCritical: true,
+		},
+		"excluded event type ID overrides inclusion": {
+			filters: VCenterEventFilters{
+				IncludedEventMessages: []string{"DC1"},
+				ExcludedEventTypeIDs:  []string{"VmPoweredOffEvent"},
+			},
+			wantMatched:       []int32{1},
+			wantExcludedFinal: 1,
+			wantWarning:       true,
+		},
+		"excluded entity and user names": {
+			filters: VCenterEventFilters{
+				ExcludedEntityNames: []string{"esx1"},
+				ExcludedUserNames:   []string{"svc-build"},
+			},
+			wantMatched:       []int32{3},
+			wantExcludedFinal: 2,
+			wantWarning:       true,
+		},
+		"all events excluded": {
+			filters: VCenterEventFilters{
+				ExcludedEventMessages: []string{"esx1", "ds1"},
+			},
+			wantMatched:       []int32{},
+			wantExcludedFinal: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			events := vcenterEvents()
+			events.Filter(tt.filters)
+
+			got := make([]int32, 0, len(events))
+			for _, event := range events.Matched() {
+				got = append(got, event.Key)
+			}
+
+			if d := cmp.Diff(tt.wantMatched, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got, want := events.NumExcluded(), len(events)-len(tt.wantMatched); got != want {
+				t.Errorf("want %d excluded events; got %d", want, got)
+			}
+
+			if got := events.NumExcludedFinal(); got != tt.wantExcludedFinal {
+				t.Errorf("want %d explicitly excluded events; got %d", tt.wantExcludedFinal, got)
+			}
+
+			if got := events.IsCriticalState(thresholds); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := events.IsWarningState(thresholds); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,474 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrVCenterServiceRequiredNotRunning indicates that one or more vCenter
+// services required to be running were found to be stopped, unhealthy or
+// missing.
+var ErrVCenterServiceRequiredNotRunning = errors.New("required vCenter service not running")
+
+// ErrVCenterServiceDegraded indicates that one or more vCenter services were
+// found to be degraded or not running as configured.
+var ErrVCenterServiceDegraded = errors.New("vCenter service degraded")
+
+// vcenterServicesPath is the vSphere Automation API path (relative to the
+// /api endpoint) for the services managed by the vCenter appliance service
+// lifecycle manager (vMon).
+const vcenterServicesPath string = "/api/vcenter/services"
+
+// vCenter service state values.
+const (
+	VCenterServiceStateStarting string = "STARTING"
+	VCenterServiceStateStopping string = "STOPPING"
+	VCenterServiceStateStarted  string = "STARTED"
+	VCenterServiceStateStopped  string = "STOPPED"
+)
+
+// vCenter service health values. Health is only reported for started
+// services.
+const (
+	VCenterServiceHealthHealthy             string = "HEALTHY"
+	VCenterServiceHealthHealthyWithWarnings string = "HEALTHY_WITH_WARNINGS"
+	VCenterServiceHealthDegraded            string = "DEGRADED"
+)
+
+// VCenterServiceStartupAutomatic is the startup type for a vCenter service
+// which is started along with the vCenter appliance.
+const VCenterServiceStartupAutomatic string = "AUTOMATIC"
+
+// VCenterService represents a service managed by the vCenter appliance
+// service lifecycle manager.
+type VCenterService struct {
+
+	// ID is the service identifier (e.g., vpxd).
+	ID string
+
+	// StartupType is the startup type of the service (e.g., AUTOMATIC).
+	StartupType string
+
+	// State is the current state of the service (e.g., STARTED).
+	State string
+
+	// Health is the health of the service (e.g., HEALTHY). This is empty if
+	// the service is not started.
+	Health string
+
+	// HealthMessages are the messages reported with the service health.
+	HealthMessages []string
+}
+
+// VCenterServices tracks the services for the vCenter appliance along with
+// the results of evaluating those services against the list of required and
+// ignored services.
+type VCenterServices struct {
+
+	// Services is the collection of all services reported by the vCenter
+	// appliance.
+	Services []VCenterService
+
+	// RequiredNotRunning is the collection of required services that are not
+	// started or are degraded.
+	RequiredNotRunning []VCenterService
+
+	// RequiredMissing is the collection of required service names not found.
+	RequiredMissing []string
+
+	// AutomaticNotRunning is the collection of services with an automatic
+	// startup type that are not started. Required and ignored services are
+	// not included.
+	AutomaticNotRunning []VCenterService
+
+	// Degraded is the collection of started services reporting a health
+	// other than healthy. Required services which are degraded are not
+	// included.
+	Degraded []VCenterService
+}
+
+// vcenterServiceInfo is the service information returned by the vSphere
+// Automation API.
+type vcenterServiceInfo struct {
+	StartupType    string `json:"startup_type"`
+	State          string `json:"state"`
+	Health         string `json:"health"`
+	HealthMessages []struct {
+		DefaultMessage string `json:"default_message"`
+	} `json:"health_messages"`
+}
+
+// IsRunning indicates whether the service is started.
+func (svc VCenterService) IsRunning() bool {
+	return strings.EqualFold(svc.State, VCenterServiceStateStarted)
+}
+
+// IsHealthy indicates whether the service is started and reports a healthy
+// status.
+func (svc VCenterService) IsHealthy() bool {
+	return svc.IsRunning() &&
+		(svc.Health == "" || strings.EqualFold(svc.Health, VCenterServiceHealthHealthy))
+}
+
+// String provides a human readable description of the service status.
+func (svc VCenterService) String() string {
+	switch {
+	case svc.Health != "":
+		return fmt.Sprintf(
+			"%s (state: %s, health: %s, startup: %s)",
+			svc.ID,
+			svc.State,
+			svc.Health,
+			svc.StartupType,
+		)
+	default:
+		return fmt.Sprintf(
+			"%s (state: %s, startup: %s)",
+			svc.ID,
+			svc.State,
+			svc.StartupType,
+		)
+	}
+}
+
+// GetVCenterServices uses the given vSphere Automation API client to
+// retrieve the collection of services managed by the vCenter appliance. The
+// collection is sorted by service ID.
+func GetVCenterServices(ctx context.Context, rc *rest.Client) ([]VCenterService, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVCenterServices func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	req := rc.Resource(vcenterServicesPath).Request(http.MethodGet)
+
+	var results map[string]vcenterServiceInfo
+	if err := rc.Do(ctx, req, &results); err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve vCenter services: %w",
+			err,
+		)
+	}
+
+	services := make([]VCenterService, 0, len(results))
+	for id, info := range results {
+		svc := VCenterService{
+			ID:          id,
+			StartupType: info.StartupType,
+			State:       info.State,
+			Health:      info.Health,
+		}
+
+		for _, msg := range info.HealthMessages {
+			svc.HealthMessages = append(svc.HealthMessages, msg.DefaultMessage)
+		}
+
+		services = append(services, svc)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].ID < services[j].ID
+	})
+
+	return services, nil
+
+}
+
+// NewVCenterServices evaluates the given vCenter services against the
+// specified lists of required and ignored service names. Ignored services
+// are not evaluated unless also required.
+func NewVCenterServices(services []VCenterService, required []string, ignored []string) VCenterServices {
+
+	vcServices := VCenterServices{
+		Services: services,
+	}
+
+	for _, name := range required {
+		var found bool
+		for _, svc := range services {
+			if !strings.EqualFold(svc.ID, name) {
+				continue
+			}
+			found = true
+
+			if !svc.IsRunning() || strings.EqualFold(svc.Health, VCenterServiceHealthDegraded) {
+				vcServices.RequiredNotRunning = append(vcServices.RequiredNotRunning, svc)
+			}
+		}
+
+		if !found {
+			vcServices.RequiredMissing = append(vcServices.RequiredMissing, name)
+		}
+	}
+
+	for _, svc := range services {
+		isRequired := textutils.InList(svc.ID, required, true)
+
+		switch {
+		case textutils.InList(svc.ID, ignored, true) && !isRequired:
+			continue
+
+		case !svc.IsRunning():
+			if !isRequired && strings.EqualFold(svc.StartupType, VCenterServiceStartupAutomatic) {
+				vcServices.AutomaticNotRunning = append(vcServices.AutomaticNotRunning, svc)
+			}
+
+		case !svc.IsHealthy():
+			if !isRequired || !strings.EqualFold(svc.Health, VCenterServiceHealthDegraded) {
+				vcServices.Degraded = append(vcServices.Degraded, svc)
+			}
+		}
+	}
+
+	return vcServices
+
+}
+
+// HasCriticalState indicates whether any required services were found to be
+// stopped, degraded or missing.
+func (vcs VCenterServices) HasCriticalState() bool {
+	return len(vcs.RequiredNotRunning) > 0 || len(vcs.RequiredMissing) > 0
+}
+
+// HasWarningState indicates whether any services with an automatic startup
+// type were found to be stopped or any started services were found to be
+// reporting a health other than healthy.
+func (vcs VCenterServices) HasWarningState() bool {
+	return len(vcs.AutomaticNotRunning) > 0 || len(vcs.Degraded) > 0
+}
+
+// NumRequiredNotRunning returns the number of required services stopped,
+// degraded or missing.
+func (vcs VCenterServices) NumRequiredNotRunning() int {
+	return len(vcs.RequiredNotRunning) + len(vcs.RequiredMissing)
+}
+
+// NumRunning returns the number of started services.
+func (vcs VCenterServices) NumRunning() int {
+	var num int
+	for _, svc := range vcs.Services {
+		if svc.IsRunning() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// VCenterServicesPerfData generates performance data metrics from the given
+// evaluated vCenter services.
+func VCenterServicesPerfData(vcs VCenterServices) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vcenter_services",
+			Value: fmt.Sprintf("%d", len(vcs.Services)),
+			Min:   "0",
+		},
+		{
+			Label: "vcenter_services_running",
+			Value: fmt.Sprintf("%d", vcs.NumRunning()),
+			Min:   "0",
+		},
+		{
+			Label: "vcenter_services_required_not_running",
+			Value: fmt.Sprintf("%d", vcs.NumRequiredNotRunning()),
+			Min:   "0",
+		},
+		{
+			Label: "vcenter_services_automatic_not_running",
+			Value: fmt.Sprintf("%d", len(vcs.AutomaticNotRunning)),
+			Min:   "0",
+		},
+		{
+			Label: "vcenter_services_degraded",
+			Value: fmt.Sprintf("%d", len(vcs.Degraded)),
+			Min:   "0",
+		},
+	}
+}
+
+// VCenterServicesOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VCenterServicesOneLineCheckSummary(
+	stateLabel string,
+	vcs VCenterServices,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vcs": vcs,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterServicesOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case vcs.HasCriticalState() || vcs.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d required services not running and %d services stopped or degraded (evaluated %d services)",
+			stateLabel,
+			vcs.NumRequiredNotRunning(),
+			len(vcs.AutomaticNotRunning)+len(vcs.Degraded),
+			len(vcs.Services),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No vCenter service issues detected (evaluated %d services)",
+			stateLabel,
+			len(vcs.Services),
+		)
+	}
+}
+
+// VCenterServicesReport generates a summary of vCenter service status along
+// with various verbose details intended to aid in troubleshooting check
+// results at a glance. This information is provided for use with the Long
+// Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VCenterServicesReport(
+	c *vim25.Client,
+	vcs VCenterServices,
+	required []string,
+	ignored []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VCenterServicesReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"vCenter services with issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, name := range vcs.RequiredMissing {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: required, not found%s",
+			name,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	printService := func(svc VCenterService, note string) {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %s%s",
+			svc.String(),
+			note,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, msg := range svc.HealthMessages {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s%s",
+				msg,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	for _, svc := range vcs.RequiredNotRunning {
+		printService(svc, "required")
+	}
+
+	for _, svc := range vcs.AutomaticNotRunning {
+		printService(svc, "automatic startup, not running")
+	}
+
+	for _, svc := range vcs.Degraded {
+		printService(svc, "health issues reported")
+	}
+
+	if !vcs.HasCriticalState() && !vcs.HasWarningState() {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Services running: %d of %d%s",
+		vcs.NumRunning(),
+		len(vcs.Services),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Required services: %s%s",
+		strings.Join(required, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	if len(ignored) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* Ignored services: %s%s",
+			strings.Join(ignored, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	return report.String()
+}
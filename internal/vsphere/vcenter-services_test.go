@@ -0,0 +1,296 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// vcenterService returns a vCenter service with an automatic startup type
+// and the given state and health.
+func vcenterService(id string, state string, health string) VCenterService {
+	return VCenterService{
+		ID:          id,
+		StartupType: VCenterServiceStartupAutomatic,
+		State:       state,
+		Health:      health,
+	}
+}
+
+// vcenterServiceIDs returns the IDs of the given services.
+func vcenterServiceIDs(services []VCenterService) []string {
+	var ids []string
+	for _, svc := range services {
+		ids = append(ids, svc.ID)
+	}
+
+	return ids
+}
+
+func TestGetVCenterServices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != vcenterServicesPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+			"vpxd": {
+				"startup_type": "AUTOMATIC",
+				"state": "STARTED",
+				"health": "HEALTHY_WITH_WARNINGS",
+				"health_messages": [{"default_message": "certificate expires soon"}]
+			},
+			"content-library": {"startup_type": "AUTOMATIC", "state": "STARTED", "health": "HEALTHY"},
+			"vsan-health": {"startup_type": "MANUAL", "state": "STOPPED"}
+		}`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + vim25.Path)
+	rc := rest.NewClient(&vim25.Client{Client: soap.NewClient(u, false)})
+
+	got, err := GetVCenterServices(context.Background(), rc)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	want := []VCenterService{
+		{
+			ID:          "content-library",
+			StartupType: VCenterServiceStartupAutomatic,
+			State:       VCenterServiceStateStarted,
+			Health:      VCenterServiceHealthHealthy,
+		},
+		{
+			ID:             "vpxd",
+			StartupType:    VCenterServiceStartupAutomatic,
+			State:          VCenterServiceStateStarted,
+			Health:         VCenterServiceHealthHealthyWithWarnings,
+			HealthMessages: []string{"certificate expires soon"},
+		},
+		{
+			ID:          "vsan-health",
+			StartupType: "MANUAL",
+			State:       VCenterServiceStateStopped,
+		},
+	}
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestVCenterServiceStatus(t *testing.T) {
+	tests := map[string]struct {
+		svc         VCenterService
+		wantRunning bool
+		wantHealthy bool
+		wantString  string
+	}{
+		"started and healthy": {
+			svc:         vcenterService("vpxd", VCenterServiceStateStarted, VCenterServiceHealthHealthy),
+			wantRunning: true,
+			wantHealthy: true,
+			wantString:  "vpxd (state: STARTED, health: HEALTHY, startup: AUTOMATIC)",
+		},
+		"started without reported health": {
+			svc:         vcenterService("vpxd", "started", ""),
+			wantRunning: true,
+			wantHealthy: true,
+			wantString:  "vpxd (state: started, startup: AUTOMATIC)",
+		},
+		"started with warnings": {
+			svc:         vcenterService("vpxd", VCenterServiceStateStarted, VCenterServiceHealthHealthyWithWarnings),
+			wantRunning: true,
+			wantString:  "vpxd (state: STARTED, health: HEALTHY_WITH_WARNINGS, startup: AUTOMATIC)",
+		},
+		"starting": {
+			svc:        vcenterService("vpxd", VCenterServiceStateStarting, ""),
+			wantString: "vpxd (state: STARTING, startup: AUTOMATIC)",
+		},
+		"stopped": {
+			svc:        vcenterService("vpxd", VCenterServiceStateStopped, ""),
+			wantString: "vpxd (state: STOPPED, startup: AUTOMATIC)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.svc.IsRunning(); got != tt.wantRunning {
+				t.Errorf("want running %t; got %t", tt.wantRunning, got)
+			}
+
+			if got := tt.svc.IsHealthy(); got != tt.wantHealthy {
+				t.Errorf("want healthy %t; got %t", tt.wantHealthy, got)
+			}
+
+			if got := tt.svc.String(); got != tt.wantString {
+				t.Errorf("want %q; got %q", tt.wantString, got)
+			}
+		})
+	}
+}
+
+func TestNewVCenterServices(t *testing.T) {
+	started := VCenterServiceStateStarted
+	stopped := VCenterServiceStateStopped
+	healthy := VCenterServiceHealthHealthy
+	degraded := VCenterServiceHealthDegraded
+
+	manual := vcenterService("vsan-health", stopped, "")
+	manual.StartupType = "MANUAL"
+
+	tests := map[string]struct {
+		services                []VCenterService
+		required                []string
+		ignored                 []string
+		wantRequiredNotRunning  []string
+		wantRequiredMissing     []string
+		wantAutomaticNotRunning []string
+		wantDegraded            []string
+		wantCritical            bool
+		wantWarning             bool
+	}{
+		"all services healthy": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, healthy),
+				vcenterService("vapi-endpoint", started, healthy),
+			},
+			required: []string{"vpxd"},
+		},
+		"stopped manual service": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, healthy),
+				manual,
+			},
+		},
+		"stopped automatic service": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, healthy),
+				vcenterService("vapi-endpoint", stopped, ""),
+			},
+			wantAutomaticNotRunning: []string{"vapi-endpoint"},
+			wantWarning:             true,
+		},
+		"stopped automatic service ignored": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, healthy),
+				vcenterService("vapi-endpoint", stopped, ""),
+			},
+			ignored: []string{"VAPI-ENDPOINT"},
+		},
+		"service healthy with warnings": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, VCenterServiceHealthHealthyWithWarnings),
+			},
+			wantDegraded: []string{"vpxd"},
+			wantWarning:  true,
+		},
+		"required service healthy with warnings": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, VCenterServiceHealthHealthyWithWarnings),
+			},
+			required:     []string{"vpxd"},
+			wantDegraded: []string{"vpxd"},
+			wantWarning:  true,
+		},
+		"required service stopped": {
+			services: []VCenterService{
+				vcenterService("vpxd", stopped, ""),
+			},
+			required:               []string{"vpxd"},
+			wantRequiredNotRunning: []string{"vpxd"},
+			wantCritical:           true,
+		},
+		"required service degraded": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, degraded),
+			},
+			required:               []string{"VPXD"},
+			wantRequiredNotRunning: []string{"vpxd"},
+			wantCritical:           true,
+		},
+		"required ignored service stopped": {
+			services: []VCenterService{
+				vcenterService("vpxd", stopped, ""),
+			},
+			required:               []string{"vpxd"},
+			ignored:                []string{"vpxd"},
+			wantRequiredNotRunning: []string{"vpxd"},
+			wantCritical:           true,
+		},
+		"required service missing": {
+			services: []VCenterService{
+				vcenterService("vpxd", started, healthy),
+			},
+			required:            []string{"vpxd", "sps"},
+			wantRequiredMissing: []string{"sps"},
+			wantCritical:        true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vcServices := NewVCenterServices(tt.services, tt.required, tt.ignored)
+
+			if d := cmp.Diff(tt.wantRequiredNotRunning, vcenterServiceIDs(vcServices.RequiredNotRunning)); d != "" {
+				t.Errorf("required not running (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantRequiredMissing, vcServices.RequiredMissing); d != "" {
+				t.Errorf("required missing (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantAutomaticNotRunning, vcenterServiceIDs(vcServices.AutomaticNotRunning)); d != "" {
+				t.Errorf("automatic not running (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantDegraded, vcenterServiceIDs(vcServices.Degraded)); d != "" {
+				t.Errorf("degraded (-want, +got):\n%s", d)
+			}
+
+			if got := vcServices.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := vcServices.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVCenterServicesCounts(t *testing.T) {
+	vcServices := NewVCenterServices(
+		[]VCenterService{
+			vcenterService("vpxd", VCenterServiceStateStopped, ""),
+			vcenterService("sts", VCenterServiceStateStarted, VCenterServiceHealthHealthy),
+			vcenterService("vapi-endpoint", VCenterServiceStateStarted, VCenterServiceHealthDegraded),
+		},
+		[]string{"vpxd", "sps"},
+		nil,
+	)
+
+	if got := vcServices.NumRequiredNotRunning(); got != 2 {
+		t.Errorf("want 2 required services not running; got %d", got)
+	}
+
+	if got := vcServices.NumRunning(); got != 2 {
+		t.Errorf("want 2 running services; got %d", got)
+	}
+}
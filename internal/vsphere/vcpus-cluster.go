@@ -0,0 +1,406 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrVCPUsClusterRatioThresholdCrossed indicates that the ratio of allocated
+// vCPUs to physical CPU cores for one or more clusters has exceeded a given
+// threshold.
+var ErrVCPUsClusterRatioThresholdCrossed = errors.New("cluster vCPU:pCPU ratio exceeds specified threshold")
+
+// VCPUsClusterRatioThresholds represents the vCPU:pCPU overcommit ratio
+// thresholds used to determine whether a cluster is considered to be in a
+// CRITICAL or WARNING state.
+type VCPUsClusterRatioThresholds struct {
+	Critical float64
+	Warning  float64
+}
+
+// ClusterVCPUsAllocation tracks allocated vCPUs and physical CPU cores for a
+// specific cluster.
+type ClusterVCPUsAllocation struct {
+
+	// ClusterName is the name of the cluster.
+	ClusterName string
+
+	// VCPUsAllocated is the number of vCPUs allocated to evaluated VMs
+	// running on hosts in the cluster.
+	VCPUsAllocated int64
+
+	// PhysicalCores is the number of physical CPU cores provided by hosts in
+	// the cluster.
+	PhysicalCores int64
+
+	// NumVMs is the number of evaluated VMs running on hosts in the cluster.
+	NumVMs int
+
+	// Thresholds are the vCPU:pCPU ratio thresholds used to evaluate the
+	// cluster.
+	Thresholds VCPUsClusterRatioThresholds
+}
+
+// ClusterVCPUsAllocationSet is a collection of per-cluster vCPU allocation
+// summaries.
+type ClusterVCPUsAllocationSet []ClusterVCPUsAllocation
+
+// NewClusterVCPUsAllocationSet receives a collection of clusters and the
+// evaluated VMs and tallies allocated vCPUs per cluster based on the current
+// host of each VM. Only clusters with at least one evaluated VM are included.
+// The number of evaluated VMs not running on a cluster member host (e.g.,
+// standalone hosts) is also returned.
+func NewClusterVCPUsAllocationSet(
+	clusters []mo.ClusterComputeResource,
+	vms []mo.VirtualMachine,
+	thresholds VCPUsClusterRatioThresholds,
+) (ClusterVCPUsAllocationSet, int) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewClusterVCPUsAllocationSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	// Index cluster membership by host ID.
+	hostClusterIndex := make(map[string]int, len(clusters))
+	allocations := make([]ClusterVCPUsAllocation, len(clusters))
+	for i, cluster := range clusters {
+		allocations[i] = ClusterVCPUsAllocation{
+			ClusterName: cluster.Name,
+			Thresholds:  thresholds,
+		}
+
+		if cluster.Summary != nil {
+			allocations[i].PhysicalCores = int64(
+				cluster.Summary.GetComputeResourceSummary().NumCpuCores,
+			)
+		}
+
+		for _, host := range cluster.Host {
+			hostClusterIndex[host.Value] = i
+		}
+	}
+
+	var numVMsNotInCluster int
+	for _, vm := range vms {
+		if vm.Summary.Runtime.Host == nil {
+			numVMsNotInCluster++
+
+			continue
+		}
+
+		i, ok := hostClusterIndex[vm.Summary.Runtime.Host.Value]
+		if !ok {
+			numVMsNotInCluster++
+
+			continue
+		}
+
+		allocations[i].VCPUsAllocated += int64(vm.Summary.Config.NumCpu)
+		allocations[i].NumVMs++
+	}
+
+	set := make(ClusterVCPUsAllocationSet, 0, len(allocations))
+	for _, allocation := range allocations {
+		if allocation.NumVMs == 0 {
+			continue
+		}
+
+		set = append(set, allocation)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].ClusterName) < strings.ToLower(set[j].ClusterName)
+	})
+
+	return set, numVMsNotInCluster
+
+}
+
+// Ratio returns the vCPU:pCPU overcommit ratio for the cluster. Zero is
+// returned if the number of physical CPU cores is unknown.
+func (cva ClusterVCPUsAllocation) Ratio() float64 {
+	if cva.PhysicalCores == 0 {
+		return 0
+	}
+
+	return float64(cva.VCPUsAllocated) / float64(cva.PhysicalCores)
+}
+
+// IsCriticalState indicates whether the vCPU:pCPU ratio for the cluster has
+// crossed the CRITICAL threshold.
+func (cva ClusterVCPUsAllocation) IsCriticalState() bool {
+	return cva.Ratio() > cva.Thresholds.Critical
+}
+
+// IsWarningState indicates whether the vCPU:pCPU ratio for the cluster has
+// crossed the WARNING threshold, but NOT the CRITICAL threshold.
+func (cva ClusterVCPUsAllocation) IsWarningState() bool {
+	return !cva.IsCriticalState() && cva.Ratio() > cva.Thresholds.Warning
+}
+
+// NumCritical returns the number of clusters in a CRITICAL state.
+func (set ClusterVCPUsAllocationSet) NumCritical() int {
+	var num int
+	for _, cva := range set {
+		if cva.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters in a WARNING state.
+func (set ClusterVCPUsAllocationSet) NumWarning() int {
+	var num int
+	for _, cva := range set {
+		if cva.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any cluster is in a CRITICAL state.
+func (set ClusterVCPUsAllocationSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any cluster is in a WARNING state.
+func (set ClusterVCPUsAllocationSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// Highest returns the cluster with the highest vCPU:pCPU ratio. The zero
+// value is returned if the collection is empty.
+func (set ClusterVCPUsAllocationSet) Highest() ClusterVCPUsAllocation {
+	var highest ClusterVCPUsAllocation
+	for _, cva := range set {
+		if highest.ClusterName == "" || cva.Ratio() > highest.Ratio() {
+			highest = cva
+		}
+	}
+
+	return highest
+}
+
+// ClusterVCPUsAllocationPerfData generates performance data metrics from the
+// given collection of evaluated clusters.
+func ClusterVCPUsAllocationPerfData(set ClusterVCPUsAllocationSet, thresholds VCPUsClusterRatioThresholds) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "clusters_vcpu_ratio_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_vcpu_ratio_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "vcpu_pcpu_ratio_max",
+			Value: fmt.Sprintf("%.2f", set.Highest().Ratio()),
+			Warn:  fmt.Sprintf("%.2f", thresholds.Warning),
+			Crit:  fmt.Sprintf("%.2f", thresholds.Critical),
+			Min:   "0",
+		},
+	}
+
+	for _, cva := range set {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: PerfDataLabel(cva.ClusterName, "vcpu_pcpu_ratio"),
+				Value: fmt.Sprintf("%.2f", cva.Ratio()),
+				Warn:  fmt.Sprintf("%.2f", cva.Thresholds.Warning),
+				Crit:  fmt.Sprintf("%.2f", cva.Thresholds.Critical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: PerfDataLabel(cva.ClusterName, "vcpus_used"),
+				Value: fmt.Sprintf("%d", cva.VCPUsAllocated),
+				Min:   "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// ClusterVCPUsAllocationOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func ClusterVCPUsAllocationOneLineCheckSummary(
+	stateLabel string,
+	set ClusterVCPUsAllocationSet,
+	thresholds VCPUsClusterRatioThresholds,
+	vmsFilterResults VMsFilterResults,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set":              set,
+		"thresholds":       thresholds,
+		"vmsFilterResults": vmsFilterResults,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterVCPUsAllocationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	highest := set.Highest()
+
+	switch {
+	case set.HasCriticalState():
+		return fmt.Sprintf(
+			"%s: %d clusters with vCPU:pCPU ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters, %d VMs)",
+			stateLabel,
+			set.NumCritical(),
+			thresholds.Critical,
+			highest.Ratio(),
+			highest.ClusterName,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters with vCPU:pCPU ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters, %d VMs)",
+			stateLabel,
+			set.NumWarning(),
+			thresholds.Warning,
+			highest.Ratio(),
+			highest.ClusterName,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	case len(set) == 0:
+		return fmt.Sprintf(
+			"%s: No clusters with evaluated VMs found (evaluated %d VMs)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No clusters with vCPU:pCPU ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters, %d VMs)",
+			stateLabel,
+			thresholds.Warning,
+			highest.Ratio(),
+			highest.ClusterName,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+	}
+}
+
+// ClusterVCPUsAllocationReport generates a summary of vCPU allocation per
+// cluster along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func ClusterVCPUsAllocationReport(
+	c *vim25.Client,
+	set ClusterVCPUsAllocationSet,
+	numVMsNotInCluster int,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ClusterVCPUsAllocationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"vCPU allocation per cluster:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, cva := range set {
+		var state string
+		switch {
+		case cva.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case cva.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s]: %d vCPUs / %d cores (%.2f:1, %d VMs)%s",
+			cva.ClusterName,
+			state,
+			cva.VCPUsAllocated,
+			cva.PhysicalCores,
+			cva.Ratio(),
+			cva.NumVMs,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numVMsNotInCluster > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s%d evaluated VMs not running on a cluster member host were skipped%s",
+			nagios.CheckOutputEOL,
+			numVMsNotInCluster,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func ratioCluster(name string, cores int16, hostIDs ...string) mo.ClusterComputeResource {
+	var cluster mo.ClusterComputeResource
+	cluster.Name = name
+	cluster.Summary = &types.ClusterComputeResourceSummary{
+		ComputeResourceSummary: types.ComputeResourceSummary{
+			NumCpuCores: cores,
+		},
+	}
+
+	for _, hostID := range hostIDs {
+		cluster.Host = append(cluster.Host, types.ManagedObjectReference{
+			Type:  MgObjRefTypeHostSystem,
+			Value: hostID,
+		})
+	}
+
+	return cluster
+}
+
+func ratioVM(name string, hostID string, numCPU int32) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Summary.Config.NumCpu = numCPU
+
+	if hostID != "" {
+		vm.Summary.Runtime.Host = &types.ManagedObjectReference{
+			Type:  MgObjRefTypeHostSystem,
+			Value: hostID,
+		}
+	}
+
+	return vm
+}
+
+func TestNewClusterVCPUsAllocationSet(t *testing.T) {
+	thresholds := VCPUsClusterRatioThresholds{Warning: 3, Critical: 5}
+
+	var noSummary mo.ClusterComputeResource
+	noSummary.Name = "no-summary"
+	noSummary.Host = []types.ManagedObjectReference{{Type: MgObjRefTypeHostSystem, Value: "host-9"}}
+
+	clusters := []mo.ClusterComputeResource{
+		ratioCluster("prod", 32, "host-1", "host-2"),
+		ratioCluster("Dev", 16, "host-3"),
+		ratioCluster("empty", 16, "host-4"),
+		noSummary,
+	}
+
+	vms := []mo.VirtualMachine{
+		ratioVM("vm1", "host-1", 8),
+		ratioVM("vm2", "host-2", 4),
+		ratioVM("vm3", "host-3", 2),
+		ratioVM("vm4", "host-9", 4),
+		ratioVM("standalone", "host-5", 4),
+		ratioVM("no-host", "", 4),
+	}
+
+	want := ClusterVCPUsAllocationSet{
+		{ClusterName: "Dev", VCPUsAllocated: 2, PhysicalCores: 16, NumVMs: 1, Thresholds: thresholds},
+		{ClusterName: "no-summary", VCPUsAllocated: 4, PhysicalCores: 0, NumVMs: 1, Thresholds: thresholds},
+		{ClusterName: "prod", VCPUsAllocated: 12, PhysicalCores: 32, NumVMs: 2, Thresholds: thresholds},
+	}
+
+	got, numNotInCluster := NewClusterVCPUsAllocationSet(clusters, vms, thresholds)
+
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if numNotInCluster != 2 {
+		t.Errorf("want %d VMs not in a cluster; got %d", 2, numNotInCluster)
+	}
+}
+
+func TestClusterVCPUsAllocationState(t *testing.T) {
+	thresholds := VCPUsClusterRatioThresholds{Warning: 3, Critical: 5}
+
+	tests := map[string]struct {
+		vcpus        int64
+		cores        int64
+		wantRatio    float64
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below warning threshold":     {vcpus: 32, cores: 16, wantRatio: 2},
+		"equal to warning threshold":  {vcpus: 48, cores: 16, wantRatio: 3},
+		"above warning threshold":     {vcpus: 50, cores: 16, wantRatio: 3.125, wantWarning: true},
+		"equal to critical threshold": {vcpus: 80, cores: 16, wantRatio: 5, wantWarning: true},
+		"above critical threshold":    {vcpus: 81, cores: 16, wantRatio: 5.0625, wantCritical: true},
+		"physical cores unknown":      {vcpus: 81, cores: 0, wantRatio: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cva := ClusterVCPUsAllocation{
+				ClusterName:    "prod",
+				VCPUsAllocated: tt.vcpus,
+				PhysicalCores:  tt.cores,
+				Thresholds:     thresholds,
+			}
+
+			if got := cva.Ratio(); math.Abs(got-tt.wantRatio) > 0.0001 {
+				t.Errorf("want ratio %.4f; got %.4f", tt.wantRatio, got)
+			}
+
+			if got := cva.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := cva.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestClusterVCPUsAllocationSetState(t *testing.T) {
+	thresholds := VCPUsClusterRatioThresholds{Warning: 3, Critical: 5}
+
+	allocation := func(name string, vcpus int64) ClusterVCPUsAllocation {
+		return ClusterVCPUsAllocation{
+			ClusterName:    name,
+			VCPUsAllocated: vcpus,
+			PhysicalCores:  10,
+			Thresholds:     thresholds,
+		}
+	}
+
+	tests := map[string]struct {
+		set          ClusterVCPUsAllocationSet
+		wantCritical int
+		wantWarning  int
+		wantHighest  string
+	}{
+		"empty set": {
+			set: ClusterVCPUsAllocationSet{},
+		},
+		"all clusters OK": {
+			set:         ClusterVCPUsAllocationSet{allocation("a", 10), allocation("b", 20)},
+			wantHighest: "b",
+		},
+		"mixed states": {
+			set: ClusterVCPUsAllocationSet{
+				allocation("a", 40),
+				allocation("b", 60),
+				allocation("c", 35),
+				allocation("d", 10),
+			},
+			wantCritical: 1,
+			wantWarning:  2,
+			wantHighest:  "b",
+		},
+		"highest ratio is first on tie": {
+			set:         ClusterVCPUsAllocationSet{allocation("a", 20), allocation("b", 20)},
+			wantHighest: "a",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.set.NumCritical(); got != tt.wantCritical {
+				t.Errorf("want %d critical clusters; got %d", tt.wantCritical, got)
+			}
+
+			if got := tt.set.NumWarning(); got != tt.wantWarning {
+				t.Errorf("want %d warning clusters; got %d", tt.wantWarning, got)
+			}
+
+			if got := tt.set.HasCriticalState(); got != (tt.wantCritical > 0) {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical > 0, got)
+			}
+
+			if got := tt.set.HasWarningState(); got != (tt.wantWarning > 0) {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning > 0, got)
+			}
+
+			if got := tt.set.Highest().ClusterName; got != tt.wantHighest {
+				t.Errorf("want highest ratio cluster %q; got %q", tt.wantHighest, got)
+			}
+		})
+	}
+}
+
+func TestClusterVCPUsAllocationPerfData(t *testing.T) {
+	thresholds := VCPUsClusterRatioThresholds{Warning: 3, Critical: 5}
+
+	set := ClusterVCPUsAllocationSet{
+		{ClusterName: "Dev Cluster", VCPUsAllocated: 40, PhysicalCores: 10, Thresholds: thresholds},
+		{ClusterName: "prod", VCPUsAllocated: 15, PhysicalCores: 10, Thresholds: thresholds},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "clusters_vcpu_ratio_critical", Value: "0", Min: "0"},
+		{Label: "clusters_vcpu_ratio_warning", Value: "1", Min: "0"},
+		{Label: "vcpu_pcpu_ratio_max", Value: "4.00", Warn: "3.00", Crit: "5.00", Min: "0"},
+		{Label: "Dev_Cluster_vcpu_pcpu_ratio", Value: "4.00", Warn: "3.00", Crit: "5.00", Min: "0"},
+		{Label: "Dev_Cluster_vcpus_used", Value: "40", Min: "0"},
+		{Label: "prod_vcpu_pcpu_ratio", Value: "1.50", Warn: "3.00", Crit: "5.00", Min: "0"},
+		{Label: "prod_vcpus_used", Value: "15", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, ClusterVCPUsAllocationPerfData(set, thresholds)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestClusterVCPUsAllocationOneLineCheckSummary(t *testing.T) {
+	thresholds := VCPUsClusterRatioThresholds{Warning: 3, Critical: 5}
+
+	allocation := func(name string, vcpus int64) ClusterVCPUsAllocation {
+		return ClusterVCPUsAllocation{
+			ClusterName:    name,
+			VCPUsAllocated: vcpus,
+			PhysicalCores:  10,
+			Thresholds:     thresholds,
+		}
+	}
+
+	tests := map[string]struct {
+		stateLabel string
+		set        ClusterVCPUsAllocationSet
+		want       string
+	}{
+		"critical": {
+			stateLabel: "CRITICAL",
+			set:        ClusterVCPUsAllocationSet{allocation("a", 60), allocation("b", 40)},
+			want:       "CRITICAL: 1 clusters with vCPU:pCPU ratio greater than 5.00:1; highest 6.00:1 on a (evaluated 2 clusters, 0 VMs)",
+		},
+		"warning": {
+			stateLabel: "WARNING",
+			set:        ClusterVCPUsAllocationSet{allocation("a", 10), allocation("b", 40)},
+			want:       "WARNING: 1 clusters with vCPU:pCPU ratio greater than 3.00:1; highest 4.00:1 on b (evaluated 2 clusters, 0 VMs)",
+		},
+		"ok": {
+			stateLabel: "OK",
+			set:        ClusterVCPUsAllocationSet{allocation("a", 10), allocation("b", 25)},
+			want:       "OK: No clusters with vCPU:pCPU ratio greater than 3.00:1; highest 2.50:1 on b (evaluated 2 clusters, 0 VMs)",
+		},
+		"no clusters": {
+			stateLabel: "OK",
+			set:        ClusterVCPUsAllocationSet{},
+			want:       "OK: No clusters with evaluated VMs found (evaluated 0 VMs)",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ClusterVCPUsAllocationOneLineCheckSummary(tt.stateLabel, tt.set, thresholds, VMsFilterResults{})
+			if got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrVLCMNonCompliantHostsThresholdCrossed indicates that the number of
+// hosts not compliant with the vLCM desired image for their cluster exceeds
+// the specified threshold.
+var ErrVLCMNonCompliantHostsThresholdCrossed = errors.New("vLCM non-compliant hosts threshold crossed")
+
+// VLCMComplianceThresholds represents the user-specified thresholds for the
+// number of hosts not compliant with the vLCM desired image for their
+// cluster.
+type VLCMComplianceThresholds struct {
+	Warning  int
+	Critical int
+}
+
+// ClusterVLCMCompliance tracks the vLCM image compliance results for a
+// specific cluster.
+type ClusterVLCMCompliance struct {
+	// Cluster is the evaluated cluster.
+	Cluster mo.ClusterComputeResource
+
+	// Compliance is the most recent vLCM image compliance results for the
+	// cluster. This is nil if the cluster is not managed with a single
+	// image.
+	Compliance *VLCMClusterCompliance
+
+	// HostNames is an index of host names keyed by host Managed Object ID.
+	HostNames map[string]string
+}
+
+// ClusterVLCMComplianceSet is a collection of ClusterVLCMCompliance values.
+type ClusterVLCMComplianceSet []ClusterVLCMCompliance
+
+// GetClusterVLCMComplianceSet retrieves the most recent vLCM image
+// compliance results for each of the given clusters. Clusters which are not
+// managed with a single image are included in the results, but are not
+// evaluated.
+func GetClusterVLCMComplianceSet(
+	ctx context.Context,
+	c *vim25.Client,
+	rc *rest.Client,
+	clusters []mo.ClusterComputeResource,
+) (ClusterVLCMComplianceSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterVLCMComplianceSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(ClusterVLCMComplianceSet, 0, len(clusters))
+
+	for _, cluster := range clusters {
+		compliance, err := GetClusterSoftwareCompliance(ctx, rc, cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		hss, err := GetClusterHostSystems(ctx, c, cluster, true)
+		if err != nil {
+			return nil, err
+		}
+
+		hostNames := make(map[string]string, len(hss))
+		for _, hs := range hss {
+			hostNames[hs.Self.Value] = hs.Name
+		}
+
+		set = append(set, ClusterVLCMCompliance{
+			Cluster:    cluster,
+			Compliance: compliance,
+			HostNames:  hostNames,
+		})
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].Cluster.Name) < strings.ToLower(set[j].Cluster.Name)
+	})
+
+	return set, nil
+
+}
+
+// hostNames resolves the given list of host Managed Object IDs to sorted
+// host names. The Managed Object ID is used if a name is not known.
+func (cvc ClusterVLCMCompliance) hostNames(hostIDs []string) []string {
+	names := make([]string, 0, len(hostIDs))
+	for _, id := range hostIDs {
+		name, ok := cvc.HostNames[id]
+		if !ok {
+			name = id
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsManaged indicates whether the cluster is managed with a single image.
+func (cvc ClusterVLCMCompliance) IsManaged() bool {
+	return cvc.Compliance != nil
+}
+
+// NumHostsNonCompliant returns the number of hosts in the cluster which are
+// not compliant with (or cannot be remediated to) the desired image.
+func (cvc ClusterVLCMCompliance) NumHostsNonCompliant() int {
+	if cvc.Compliance == nil {
+		return 0
+	}
+
+	return len(cvc.Compliance.NonCompliantHosts) + len(cvc.Compliance.IncompatibleHosts)
+}
+
+// NumClustersManaged returns the number of clusters managed with a single
+// image.
+func (set ClusterVLCMComplianceSet) NumClustersManaged() int {
+	var num int
+	for _, cvc := range set {
+		if cvc.IsManaged() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumClustersNotManaged returns the number of clusters not managed with a
+// single image.
+func (set ClusterVLCMComplianceSet) NumClustersNotManaged() int {
+	return len(set) - set.NumClustersManaged()
+}
+
+// NumHostsCompliant returns the number of hosts compliant with the desired
+// image for their cluster.
+func (set ClusterVLCMComplianceSet) NumHostsCompliant() int {
+	var num int
+	for _, cvc := range set {
+		if cvc.IsManaged() {
+			num += len(cvc.Compliance.CompliantHosts)
+		}
+	}
+
+	return num
+}
+
+// NumHostsNonCompliant returns the number of hosts not compliant with (or
+// which cannot be remediated to) the desired image for their cluster.
+func (set ClusterVLCMComplianceSet) NumHostsNonCompliant() int {
+	var num int
+	for _, cvc := range set {
+		num += cvc.NumHostsNonCompliant()
+	}
+
+	return num
+}
+
+// NumHostsIncompatible returns the number of hosts which cannot be
+// remediated to the desired image for their cluster.
+func (set ClusterVLCMComplianceSet) NumHostsIncompatible() int {
+	var num int
+	for _, cvc := range set {
+		if cvc.IsManaged() {
+			num += len(cvc.Compliance.IncompatibleHosts)
+		}
+	}
+
+	return num
+}
+
+// NumHostsUnknown returns the number of hosts for which compliance with the
+// desired image for their cluster could not be determined.
+func (set ClusterVLCMComplianceSet) NumHostsUnknown() int {
+	var num int
+	for _, cvc := range set {
+		if cvc.IsManaged() {
+			num += len(cvc.Compliance.UnavailableHosts)
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether the number of non-compliant hosts
+// exceeds the CRITICAL threshold.
+func (set ClusterVLCMComplianceSet) IsCriticalState(thresholds VLCMComplianceThresholds) bool {
+	return set.NumHostsNonCompliant() > thresholds.Critical
+}
+
+// IsWarningState indicates whether the number of non-compliant hosts
+// exceeds the WARNING threshold.
+func (set ClusterVLCMComplianceSet) IsWarningState(thresholds VLCMComplianceThresholds) bool {
+	return set.NumHostsNonCompliant() > thresholds.Warning
+}
+
+// VLCMCompliancePerfData generates performance data metrics from the given
+// collection of cluster vLCM image compliance results.
+func VLCMCompliancePerfData(
+	set ClusterVLCMComplianceSet,
+	thresholds VLCMComplianceThresholds,
+) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "clusters",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_vlcm_managed",
+			Value: fmt.Sprintf("%d", set.NumClustersManaged()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_not_vlcm_managed",
+			Value: fmt.Sprintf("%d", set.NumClustersNotManaged()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_compliant",
+			Value: fmt.Sprintf("%d", set.NumHostsCompliant()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_non_compliant",
+			Value: fmt.Sprintf("%d", set.NumHostsNonCompliant()),
+			Warn:  fmt.Sprintf("%d", thresholds.Warning),
+			Crit:  fmt.Sprintf("%d", thresholds.Critical),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_incompatible",
+			Value: fmt.Sprintf("%d", set.NumHostsIncompatible()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_unknown",
+			Value: fmt.Sprintf("%d", set.NumHostsUnknown()),
+			Min:   "0",
+		},
+	}
+}
+
+// VLCMComplianceOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VLCMComplianceOneLineCheckSummary(
+	stateLabel string,
+	set ClusterVLCMComplianceSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VLCMComplianceOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.NumHostsNonCompliant() > 0:
+		return fmt.Sprintf(
+			"%s: %d hosts not compliant with vLCM desired image (%d compliant, %d unknown, evaluated %d of %d clusters)",
+			stateLabel,
+			set.NumHostsNonCompliant(),
+			set.NumHostsCompliant(),
+			set.NumHostsUnknown(),
+			set.NumClustersManaged(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No hosts non-compliant with vLCM desired image (%d compliant, %d unknown, evaluated %d of %d clusters)",
+			stateLabel,
+			set.NumHostsCompliant(),
+			set.NumHostsUnknown(),
+			set.NumClustersManaged(),
+			len(set),
+		)
+	}
+}
+
+// VLCMComplianceReport generates a summary of vLCM image compliance results
+// for evaluated clusters along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VLCMComplianceReport(
+	c *vim25.Client,
+	set ClusterVLCMComplianceSet,
+	thresholds VLCMComplianceThresholds,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VLCMComplianceReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeHosts := func(label string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"** %s (%d): [%s]%s",
+			label,
+			len(names),
+			strings.Join(names, ", "),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Clusters managed with a single image:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if set.NumClustersManaged() == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, cvc := range set {
+		if !cvc.IsManaged() {
+			continue
+		}
+
+		lastScan := "unknown"
+		if scanTime := cvc.Compliance.LastScan(); !scanTime.IsZero() {
+			lastScan = FormattedTimeSinceEvent(scanTime)
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (status: %s, last checked: %s)%s",
+			cvc.Cluster.Name,
+			cvc.Compliance.Status,
+			lastScan,
+			nagios.CheckOutputEOL,
+		)
+
+		writeHosts("non-compliant", cvc.hostNames(cvc.Compliance.NonCompliantHosts))
+		writeHosts("incompatible", cvc.hostNames(cvc.Compliance.IncompatibleHosts))
+		writeHosts("unknown", cvc.hostNames(cvc.Compliance.UnavailableHosts))
+		writeHosts("compliant", cvc.hostNames(cvc.Compliance.CompliantHosts))
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sClusters not managed with a single image:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if set.NumClustersNotManaged() == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, cvc := range set {
+		if cvc.IsManaged() {
+			continue
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			cvc.Cluster.Name,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Non-compliant hosts thresholds: WARNING > %d, CRITICAL > %d%s",
+		thresholds.Warning,
+		thresholds.Critical,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,250 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// vlcmCluster returns a cluster with the given name and Managed Object ID.
+func vlcmCluster(name string, id string) mo.ClusterComputeResource {
+	var cluster mo.ClusterComputeResource
+	cluster.Self = types.ManagedObjectReference{Type: MgObjRefTypeClusterComputeResource, Value: id}
+	cluster.Name = name
+
+	return cluster
+}
+
+// vlcmClusterCompliance returns vLCM image compliance results for a cluster
+// with the given name. A nil compliance value indicates a cluster which is
+// not managed with a single image.
+func vlcmClusterCompliance(name string, compliance *VLCMClusterCompliance) ClusterVLCMCompliance {
+	return ClusterVLCMCompliance{
+		Cluster:    vlcmCluster(name, "domain-c1"),
+		Compliance: compliance,
+		HostNames: map[string]string{
+			"host-1": "esx1",
+			"host-2": "esx2",
+			"host-3": "esx3",
+		},
+	}
+}
+
+func TestGetClusterSoftwareCompliance(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(vlcmClusterSoftwareCompliancePath, "domain-c1"):
+			_, _ = w.Write([]byte(`{
+				"status": "NON_COMPLIANT",
+				"scan_time": "2023-05-01T10:30:00Z",
+				"compliant_hosts": ["host-1"],
+				"non_compliant_hosts": ["host-2"],
+				"incompatible_hosts": [],
+				"unavailable_hosts": ["host-3"]
+			}`))
+
+		case fmt.Sprintf(vlcmClusterSoftwareCompliancePath, "domain-c2"):
+			http.Error(w, "cluster is not managed with a single image", http.StatusBadRequest)
+
+		case fmt.Sprintf(vlcmClusterSoftwareCompliancePath, "domain-c3"):
+			http.Error(w, "internal error", http.StatusInternalServerError)
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + vim25.Path)
+	rc := rest.NewClient(&vim25.Client{Client: soap.NewClient(u, false)})
+
+	tests := map[string]struct {
+		clusterID string
+		want      *VLCMClusterCompliance
+		wantErr   bool
+	}{
+		"managed with single image": {
+			clusterID: "domain-c1",
+			want: &VLCMClusterCompliance{
+				Status:            VLCMComplianceStatusNonCompliant,
+				ScanTime:          "2023-05-01T10:30:00Z",
+				CompliantHosts:    []string{"host-1"},
+				NonCompliantHosts: []string{"host-2"},
+				IncompatibleHosts: []string{},
+				UnavailableHosts:  []string{"host-3"},
+			},
+		},
+		"not managed (bad request)": {clusterID: "domain-c2"},
+		"not managed (not found)":   {clusterID: "domain-c4"},
+		"server error":              {clusterID: "domain-c3", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := GetClusterSoftwareCompliance(context.Background(), rc, vlcmCluster("cluster1", tt.clusterID))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t; got %v", tt.wantErr, err)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestVLCMClusterComplianceLastScan(t *testing.T) {
+	tests := map[string]struct {
+		scanTime string
+		want     time.Time
+	}{
+		"RFC 3339":     {scanTime: "2023-05-01T10:30:00Z", want: time.Date(2023, time.May, 1, 10, 30, 0, 0, time.UTC)},
+		"not reported": {},
+		"invalid":      {scanTime: "yesterday"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := VLCMClusterCompliance{ScanTime: tt.scanTime}.LastScan()
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClusterVLCMComplianceHostNames(t *testing.T) {
+	cvc := vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{})
+
+	want := []string{"esx1", "esx3", "host-9"}
+	if d := cmp.Diff(want, cvc.hostNames([]string{"host-3", "host-9", "host-1"})); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestClusterVLCMComplianceSetState(t *testing.T) {
+	thresholds := VLCMComplianceThresholds{Warning: 0, Critical: 1}
+
+	tests := map[string]struct {
+		set              ClusterVLCMComplianceSet
+		wantCritical     bool
+		wantWarning      bool
+		wantNonCompliant int
+	}{
+		"all hosts compliant": {
+			set: ClusterVLCMComplianceSet{
+				vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{
+					CompliantHosts: []string{"host-1", "host-2", "host-3"},
+				}),
+			},
+		},
+		"cluster not managed with single image": {
+			set: ClusterVLCMComplianceSet{vlcmClusterCompliance("cluster1", nil)},
+		},
+		"unavailable hosts ignored": {
+			set: ClusterVLCMComplianceSet{
+				vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{
+					CompliantHosts:   []string{"host-1", "host-2"},
+					UnavailableHosts: []string{"host-3"},
+				}),
+			},
+		},
+		"non-compliant host": {
+			set: ClusterVLCMComplianceSet{
+				vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{
+					CompliantHosts:    []string{"host-1", "host-2"},
+					NonCompliantHosts: []string{"host-3"},
+				}),
+			},
+			wantWarning:      true,
+			wantNonCompliant: 1,
+		},
+		"non-compliant and incompatible hosts": {
+			set: ClusterVLCMComplianceSet{
+				vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{
+					CompliantHosts:    []string{"host-1"},
+					NonCompliantHosts: []string{"host-2"},
+					IncompatibleHosts: []string{"host-3"},
+				}),
+			},
+			wantCritical:     true,
+			wantWarning:      true,
+			wantNonCompliant: 2,
+		},
+		"non-compliant hosts across clusters": {
+			set: ClusterVLCMComplianceSet{
+				vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{NonCompliantHosts: []string{"host-1"}}),
+				vlcmClusterCompliance("cluster2", &VLCMClusterCompliance{NonCompliantHosts: []string{"host-2"}}),
+				vlcmClusterCompliance("cluster3", nil),
+			},
+			wantCritical:     true,
+			wantWarning:      true,
+			wantNonCompliant: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.set.IsCriticalState(thresholds); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := tt.set.IsWarningState(thresholds); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			if got := tt.set.NumHostsNonCompliant(); got != tt.wantNonCompliant {
+				t.Errorf("want %d non-compliant hosts; got %d", tt.wantNonCompliant, got)
+			}
+		})
+	}
+}
+
+func TestVLCMCompliancePerfData(t *testing.T) {
+	set := ClusterVLCMComplianceSet{
+		vlcmClusterCompliance("cluster1", &VLCMClusterCompliance{
+			CompliantHosts:    []string{"host-1"},
+			IncompatibleHosts: []string{"host-2"},
+			UnavailableHosts:  []string{"host-3"},
+		}),
+		vlcmClusterCompliance("cluster2", &VLCMClusterCompliance{
+			CompliantHosts:    []string{"host-4"},
+			NonCompliantHosts: []string{"host-5"},
+		}),
+		vlcmClusterCompliance("cluster3", nil),
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "clusters", Value: "3", Min: "0"},
+		{Label: "clusters_vlcm_managed", Value: "2", Min: "0"},
+		{Label: "clusters_not_vlcm_managed", Value: "1", Min: "0"},
+		{Label: "hosts_compliant", Value: "2", Min: "0"},
+		{Label: "hosts_non_compliant", Value: "2", Warn: "0", Crit: "1", Min: "0"},
+		{Label: "hosts_incompatible", Value: "1", Min: "0"},
+		{Label: "hosts_unknown", Value: "1", Min: "0"},
+	}
+
+	got := VLCMCompliancePerfData(set, VLCMComplianceThresholds{Warning: 0, Critical: 1})
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// vlcmClusterSoftwarePath is the vSphere Automation API path (relative to
+// the /api endpoint) for the vSphere Lifecycle Manager (vLCM) settings of a
+// specific cluster managed with a single image.
+const vlcmClusterSoftwarePath string = "/api/esx/settings/clusters/%s/software"
+
+// vlcmClusterSoftwareCompliancePath is the vSphere Automation API path
+// (relative to the /api endpoint) for the most recent vLCM image compliance
+// results of a specific cluster managed with a single image.
+const vlcmClusterSoftwareCompliancePath string = "/api/esx/settings/clusters/%s/software/compliance"
+
+// vSphere Lifecycle Manager (vLCM) compliance status values.
+const (
+	VLCMComplianceStatusCompliant    string = "COMPLIANT"
+	VLCMComplianceStatusNonCompliant string = "NON_COMPLIANT"
+	VLCMComplianceStatusIncompatible string = "INCOMPATIBLE"
+	VLCMComplianceStatusUnavailable  string = "UNAVAILABLE"
+)
+
+// isVLCMNotManagedErr indicates whether the given error returned from the
+// vSphere Automation API indicates that a cluster is not managed with a
+// single image. Clusters not managed with a single image do not have a
+// software specification. The API indicates this via a "not found" or "bad
+// request" response depending on version.
+func isVLCMNotManagedErr(err error) bool {
+	return rest.IsStatusError(err, http.StatusNotFound) ||
+		strings.HasPrefix(err.Error(), fmt.Sprintf("%d ", http.StatusBadRequest))
+}
+
+// VLCMDesiredImage represents the desired image (base image) specified for
+// a cluster managed by vSphere Lifecycle Manager (vLCM).
+type VLCMDesiredImage struct {
+
+	// Version is the full version of the base image (e.g.,
+	// 7.0.3-0.65.20842708).
+	Version string
+
+	// DisplayName is the human readable name of the base image (e.g.,
+	// ESXi).
+	DisplayName string
+
+	// DisplayVersion is the human readable version of the base image (e.g.,
+	// 7.0 U3i).
+	DisplayVersion string
+}
+
+// vlcmSoftwareSpec is the subset of the cluster software specification
+// returned by the vSphere Automation API that we are interested in.
+type vlcmSoftwareSpec struct {
+	BaseImage struct {
+		Version string `json:"version"`
+		Details struct {
+			DisplayName    string `json:"display_name"`
+			DisplayVersion string `json:"display_version"`
+		} `json:"details"`
+	} `json:"base_image"`
+}
+
+// Build returns the ESXi build number from the desired image version. An
+// empty string is returned if the build number could not be determined.
+func (di VLCMDesiredImage) Build() string {
+	idx := strings.LastIndex(di.Version, ".")
+	if idx < 0 || idx == len(di.Version)-1 {
+		return ""
+	}
+
+	return di.Version[idx+1:]
+}
+
+// String provides a human readable description of the desired image.
+func (di VLCMDesiredImage) String() string {
+	switch {
+	case di.DisplayName != "" && di.DisplayVersion != "":
+		return fmt.Sprintf("%s %s (%s)", di.DisplayName, di.DisplayVersion, di.Version)
+	default:
+		return di.Version
+	}
+}
+
+// GetClusterDesiredImage uses the given vSphere Automation API client to
+// retrieve the vLCM desired image for the specified cluster. A nil value is
+// returned if the cluster is not managed with a single image.
+func GetClusterDesiredImage(
+	ctx context.Context,
+	rc *rest.Client,
+	cluster mo.ClusterComputeResource,
+) (*VLCMDesiredImage, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterDesiredImage func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	path := fmt.Sprintf(vlcmClusterSoftwarePath, cluster.Reference().Value)
+	req := rc.Resource(path).Request(http.MethodGet)
+
+	var spec vlcmSoftwareSpec
+	if err := rc.Do(ctx, req, &spec); err != nil {
+		if isVLCMNotManagedErr(err) {
+			logger.Printf(
+				"cluster %s is not managed with a single image: %v",
+				cluster.Name,
+				err,
+			)
+
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"failed to retrieve vLCM desired image for cluster %s: %w",
+			cluster.Name,
+			err,
+		)
+	}
+
+	if spec.BaseImage.Version == "" {
+		return nil, nil
+	}
+
+	return &VLCMDesiredImage{
+		Version:        spec.BaseImage.Version,
+		DisplayName:    spec.BaseImage.Details.DisplayName,
+		DisplayVersion: spec.BaseImage.Details.DisplayVersion,
+	}, nil
+
+}
+
+// VLCMClusterCompliance represents the most recent vLCM image compliance
+// check results for a cluster managed with a single image. Hosts are
+// recorded by Managed Object ID (e.g., host-42).
+type VLCMClusterCompliance struct {
+
+	// Status is the overall compliance status of the cluster.
+	Status string `json:"status"`
+
+	// ScanTime is the time (in RFC 3339 format) the compliance check was
+	// performed.
+	ScanTime string `json:"scan_time"`
+
+	// CompliantHosts is the list of hosts compliant with the desired image.
+	CompliantHosts []string `json:"compliant_hosts"`
+
+	// NonCompliantHosts is the list of hosts not compliant with the desired
+	// image.
+	NonCompliantHosts []string `json:"non_compliant_hosts"`
+
+	// IncompatibleHosts is the list of hosts which cannot be remediated to
+	// the desired image.
+	IncompatibleHosts []string `json:"incompatible_hosts"`
+
+	// UnavailableHosts is the list of hosts for which compliance could not
+	// be determined.
+	UnavailableHosts []string `json:"unavailable_hosts"`
+}
+
+// GetClusterSoftwareCompliance uses the given vSphere Automation API client
+// to retrieve the most recent vLCM image compliance results for the
+// specified cluster. A nil value is returned if the cluster is not managed
+// with a single image.
+func GetClusterSoftwareCompliance(
+	ctx context.Context,
+	rc *rest.Client,
+	cluster mo.ClusterComputeResource,
+) (*VLCMClusterCompliance, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetClusterSoftwareCompliance func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	path := fmt.Sprintf(vlcmClusterSoftwareCompliancePath, cluster.Reference().Value)
+	req := rc.Resource(path).Request(http.MethodGet)
+
+	var compliance VLCMClusterCompliance
+	if err := rc.Do(ctx, req, &compliance); err != nil {
+		if isVLCMNotManagedErr(err) {
+			logger.Printf(
+				"cluster %s is not managed with a single image: %v",
+				cluster.Name,
+				err,
+			)
+
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"failed to retrieve vLCM compliance for cluster %s: %w",
+			cluster.Name,
+			err,
+		)
+	}
+
+	return &compliance, nil
+
+}
+
+// LastScan returns the time the compliance check was performed. The zero
+// value is returned if the scan time is not available.
+func (vcc VLCMClusterCompliance) LastScan() time.Time {
+	t, err := time.Parse(time.RFC3339, vcc.ScanTime)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// hasBackupViaTag indicates whether a backup date was read from a vSphere
+// Tag in the specified backup date tag category for the Virtual Machine.
+func (vmwb VMWithBackup) hasBackupViaTag() bool {
+	switch {
+	case vmwb.BackupDate == nil:
+		logger.Printf(
+			"No backup date tag in category %q found for %s",
+			vmwb.BackupDateTagCategory,
+			vmwb.Name,
+		)
+		return false
+
+	default:
+		logger.Printf(
+			"Backup date read from tag %q for %s",
+			vmwb.BackupDateTag,
+			vmwb.Name,
+		)
+		return true
+	}
+}
+
+// parseBackupDateTag attempts to parse the given vSphere Tag name as a backup
+// date using the given (optional) prefix, date format and location. The
+// prefix is removed (case-insensitively) before parsing the remaining value.
+func parseBackupDateTag(
+	tagName string,
+	tagPrefix string,
+	backupDateFormat string,
+	location *time.Location,
+) (time.Time, error) {
+
+	val := strings.TrimSpace(tagName)
+
+	if tagPrefix != "" {
+		if len(val) < len(tagPrefix) ||
+			!strings.EqualFold(val[:len(tagPrefix)], tagPrefix) {
+			return time.Time{}, fmt.Errorf(
+				"tag %q does not begin with prefix %q",
+				tagName,
+				tagPrefix,
+			)
+		}
+
+		val = strings.TrimSpace(val[len(tagPrefix):])
+	}
+
+	return time.ParseInLocation(backupDateFormat, val, location)
+}
+
+// GetVMsWithBackupViaTag evaluates the given collection of VirtualMachines
+// and returns a collection of VirtualMachines with backup date details read
+// from vSphere Tags. The backup date for each VirtualMachine is read from the
+// name of the attached tags in the given tag category. An optional prefix is
+// removed from the tag name before the remaining value is parsed using the
+// given date format and time zone (i.e., "location"). If multiple tags in the
+// category can be parsed, the most recent backup date is used. Tags in the
+// category which cannot be parsed are ignored.
+//
+// An error is returned if the given collection of VirtualMachines is empty,
+// the user specified time zone is not recognized or if there are problems
+// retrieving the attached tags.
+func GetVMsWithBackupViaTag(
+	ctx context.Context,
+	rc *rest.Client,
+	vms []mo.VirtualMachine,
+	backupTimezone string,
+	backupTagCategory string,
+	backupTagPrefix string,
+	backupDateFormat string,
+	criticalAgeThreshold int,
+	warningAgeThreshold int,
+) (VMsWithBackup, error) {
+
+	funcTimeStart := time.Now()
+
+	vmsWithBackup := make(VMsWithBackup, 0, len(vms))
+
+	defer func(vms *VMsWithBackup) {
+		logger.Printf(
+			"It took %v to execute GetVMsWithBackupViaTag func (and retrieve %d VMWithBackup).\n",
+			time.Since(funcTimeStart),
+			len(*vms),
+		)
+	}(&vmsWithBackup)
+
+	if len(vms) == 0 {
+		return nil, fmt.Errorf(
+			"received empty collection of virtual machines to evaluate for backup details",
+		)
+	}
+
+	location, err := time.LoadLocation(backupTimezone)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error loading location data using user specified time zone of %q: %w",
+			backupTimezone,
+			err,
+		)
+	}
+
+	vmRefs := make([]types.ManagedObjectReference, 0, len(vms))
+	for _, vm := range vms {
+		vmRefs = append(vmRefs, vm.Self)
+	}
+
+	vmTags, err := GetAttachedTags(ctx, rc, vmRefs)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to retrieve backup date tags: %w",
+			err,
+		)
+	}
+
+	// Custom Attributes are not used to determine the backup date, but are
+	// retrieved to provide access to the complete VMWithCAs value.
+	vmsWithCAs, err := GetVMsWithCAs(vms)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vm := range vmsWithCAs {
+
+		vmWithBackup := VMWithBackup{
+			VMWithCAs:                  vm,
+			BackupDateTagCategory:      backupTagCategory,
+			WarningAgeInDaysThreshold:  warningAgeThreshold,
+			CriticalAgeInDaysThreshold: criticalAgeThreshold,
+		}
+
+		for _, tag := range vmTags[vm.Self.Value] {
+			if !strings.EqualFold(tag.CategoryName, backupTagCategory) {
+				continue
+			}
+
+			backupDate, err := parseBackupDateTag(
+				tag.Name,
+				backupTagPrefix,
+				backupDateFormat,
+				location,
+			)
+			if err != nil {
+				logger.Printf(
+					"ignoring tag %q for %s; unable to parse backup date: %v",
+					tag.String(),
+					vm.Name,
+					err,
+				)
+
+				continue
+			}
+
+			if vmWithBackup.BackupDate == nil || backupDate.After(*vmWithBackup.BackupDate) {
+				backupDate := backupDate
+				vmWithBackup.BackupDate = &backupDate
+				vmWithBackup.BackupDateTag = tag.String()
+			}
+		}
+
+		vmsWithBackup = append(vmsWithBackup, vmWithBackup)
+	}
+
+	return vmsWithBackup, nil
+}
+
+// VMBackupViaTagOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMBackupViaTagOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	vmsWithBackups VMsWithBackup,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"vmsWithBackups":   vmsWithBackups,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMBackupViaTagOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	// The summary is not specific to the source of the backup date.
+	return VMBackupViaCAOneLineCheckSummary(
+		stateLabel,
+		vmsFilterResults,
+		vmsWithBackups,
+	)
+}
+
+// VMBackupViaTagReport generates a summary of VMs & their backup status
+// (read from vSphere Tags) along with various verbose details intended to aid
+// in troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMBackupViaTagReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	vmsWithBackup VMsWithBackup,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMBackupViaTagReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	// The report lists the tag a backup date was read from for each VM.
+	return VMBackupViaCAReport(
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		vmsWithBackup,
+	)
+}
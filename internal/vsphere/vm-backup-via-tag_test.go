@@ -0,0 +1,304 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const backupTagCategory string = "Backup Date"
+
+// newBackupTagClient returns a vSphere Automation API client for a test
+// server providing the tagging service via the /api endpoint. The given
+// tags (keyed by tag ID) are attached to vm-1 and belong to the category
+// named by their CategoryID.
+func newBackupTagClient(t *testing.T, tags map[string]tagModel) *rest.Client {
+	t.Helper()
+
+	respond := func(w http.ResponseWriter, v interface{}) {
+		if err := json.NewEncoder(w).Encode(v); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}
+
+	association := tagObjectAssociation{
+		ObjectID: tagObjectID{Type: MgObjRefTypeVirtualMachine, ID: "vm-1"},
+	}
+	for id := range tags {
+		association.TagIDs = append(association.TagIDs, id)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const (
+			tagPath      string = "/api/cis/tagging/tag/"
+			categoryPath string = "/api/cis/tagging/category/"
+		)
+
+		switch {
+		case r.URL.Path == "/api/cis/tagging/tag-association":
+			respond(w, []tagObjectAssociation{association})
+
+		case strings.HasPrefix(r.URL.Path, tagPath):
+			tag, ok := tags[strings.TrimPrefix(r.URL.Path, tagPath)]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			respond(w, tag)
+
+		case strings.HasPrefix(r.URL.Path, categoryPath):
+			id := strings.TrimPrefix(r.URL.Path, categoryPath)
+			respond(w, tagCategoryModel{ID: id, Name: id})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL + vim25.Path)
+
+	return rest.NewClient(&vim25.Client{Client: soap.NewClient(u, false)})
+}
+
+// backupTagVMs returns VMs vm-1 and vm-2.
+func backupTagVMs() []mo.VirtualMachine {
+	vms := make([]mo.VirtualMachine, 0, 2)
+	for _, id := range []string{"vm-1", "vm-2"} {
+		var vm mo.VirtualMachine
+		vm.Self = types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: id}
+		vm.Name = id
+		vms = append(vms, vm)
+	}
+
+	return vms
+}
+
+// backupTagVM returns a VM with a backup date read from a tag in the backup
+// date tag category the given number of days ago. A negative number of
+// days indicates that no backup date tag was found.
+func backupTagVM(daysAgo int) VMWithBackup {
+	vmwb := VMWithBackup{
+		BackupDateTagCategory:      backupTagCategory,
+		WarningAgeInDaysThreshold:  1,
+		CriticalAgeInDaysThreshold: 2,
+	}
+	vmwb.Name = "vm1"
+
+	if daysAgo >= 0 {
+		backupDate := time.Now().Add(-time.Duration(daysAgo)*24*time.Hour - time.Hour)
+		vmwb.BackupDate = &backupDate
+		vmwb.BackupDateTag = backupTagCategory + TagCategorySeparator + backupDate.Format("2006-01-02")
+	}
+
+	return vmwb
+}
+
+func TestParseBackupDateTag(t *testing.T) {
+	location, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := map[string]struct {
+		tagName string
+		prefix  string
+		format  string
+		want    time.Time
+		wantErr bool
+	}{
+		"date without prefix": {
+			tagName: "2023-05-01",
+			format:  "2006-01-02",
+			want:    time.Date(2023, time.May, 1, 0, 0, 0, 0, location),
+		},
+		"date and time with prefix": {
+			tagName: "Backup 2023-05-01 13:45",
+			prefix:  "Backup",
+			format:  "2006-01-02 15:04",
+			want:    time.Date(2023, time.May, 1, 13, 45, 0, 0, location),
+		},
+		"prefix matched case-insensitively": {
+			tagName: " BACKUP: 2023-05-01 ",
+			prefix:  "backup:",
+			format:  "2006-01-02",
+			want:    time.Date(2023, time.May, 1, 0, 0, 0, 0, location),
+		},
+		"prefix not present": {
+			tagName: "Nightly 2023-05-01",
+			prefix:  "Backup",
+			format:  "2006-01-02",
+			wantErr: true,
+		},
+		"tag shorter than prefix": {
+			tagName: "Back",
+			prefix:  "Backup",
+			format:  "2006-01-02",
+			wantErr: true,
+		},
+		"date format mismatch": {
+			tagName: "05/01/2023",
+			format:  "2006-01-02",
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseBackupDateTag(tt.tagName, tt.prefix, tt.format, location)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %t; got %v", tt.wantErr, err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v; got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetVMsWithBackupViaTag(t *testing.T) {
+	rc := newBackupTagClient(t, map[string]tagModel{
+		"tag-1": {ID: "tag-1", Name: "Backup 2023-05-01", CategoryID: backupTagCategory},
+		"tag-2": {ID: "tag-2", Name: "backup 2023-05-03", CategoryID: "BACKUP DATE"},
+		"tag-3": {ID: "tag-3", Name: "Backup pending", CategoryID: backupTagCategory},
+		"tag-4": {ID: "tag-4", Name: "Backup 2023-06-01", CategoryID: "Archive Date"},
+	})
+
+	got, err := GetVMsWithBackupViaTag(
+		context.Background(), rc, backupTagVMs(),
+		"UTC", backupTagCategory, "Backup", "2006-01-02", 2, 1,
+	)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("want 2 VMs; got %d", len(got))
+	}
+
+	want := time.Date(2023, time.May, 3, 0, 0, 0, 0, time.UTC)
+	switch {
+	case got[0].BackupDate == nil:
+		t.Errorf("want backup date %v for vm-1; got none", want)
+	case !got[0].BackupDate.Equal(want):
+		t.Errorf("want backup date %v for vm-1; got %v", want, *got[0].BackupDate)
+	}
+
+	if wantTag := "BACKUP DATE" + TagCategorySeparator + "backup 2023-05-03"; got[0].BackupDateTag != wantTag {
+		t.Errorf("want backup date tag %q; got %q", wantTag, got[0].BackupDateTag)
+	}
+
+	if got[1].BackupDate != nil || got[1].HasBackup() {
+		t.Errorf("want no backup date for vm-2; got %v", *got[1].BackupDate)
+	}
+
+	for _, vmwb := range got {
+		if vmwb.BackupDateTagCategory != backupTagCategory ||
+			vmwb.CriticalAgeInDaysThreshold != 2 ||
+			vmwb.WarningAgeInDaysThreshold != 1 {
+			t.Errorf("want tag category and thresholds recorded for %s; got %+v", vmwb.Name, vmwb)
+		}
+	}
+}
+
+func TestGetVMsWithBackupViaTagErrors(t *testing.T) {
+	rc := newBackupTagClient(t, nil)
+
+	tests := map[string]struct {
+		vms      []mo.VirtualMachine
+		timezone string
+	}{
+		"no VMs":           {timezone: "UTC"},
+		"unknown timezone": {vms: backupTagVMs(), timezone: "Mars/Olympus_Mons"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := GetVMsWithBackupViaTag(
+				context.Background(), rc, tt.vms,
+				tt.timezone, backupTagCategory, "", "2006-01-02", 2, 1,
+			)
+			if err == nil {
+				t.Error("want error; got nil")
+			}
+		})
+	}
+}
+
+func TestVMsWithBackupViaTagState(t *testing.T) {
+	tests := map[string]struct {
+		vms          VMsWithBackup
+		wantCritical bool
+		wantWarning  bool
+		wantMissing  int
+	}{
+		"recent backups": {
+			vms: VMsWithBackup{backupTagVM(0), backupTagVM(0)},
+		},
+		"backup older than WARNING threshold": {
+			vms:         VMsWithBackup{backupTagVM(0), backupTagVM(1)},
+			wantWarning: true,
+		},
+		"backup older than CRITICAL threshold": {
+			vms:          VMsWithBackup{backupTagVM(0), backupTagVM(5)},
+			wantCritical: true,
+		},
+		"missing backup date tag": {
+			vms:         VMsWithBackup{backupTagVM(0), backupTagVM(-1)},
+			wantWarning: true,
+			wantMissing: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.vms.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := tt.vms.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			if got := tt.vms.NumWithoutBackups(); got != tt.wantMissing {
+				t.Errorf("want %d VMs without backups; got %d", tt.wantMissing, got)
+			}
+		})
+	}
+}
+
+func TestVMWithBackupHasBackupViaTag(t *testing.T) {
+	withTag := backupTagVM(0)
+	withTag.BackupDateCAName = "Last Backup"
+
+	if !withTag.HasBackup() {
+		t.Error("want backup date read from tag to be used without Custom Attribute")
+	}
+
+	withoutTag := backupTagVM(-1)
+	withoutTag.BackupDateCAName = "Last Backup"
+	withoutTag.CustomAttributes = CustomAttributes{"Last Backup": "2023-05-01"}
+
+	if withoutTag.HasBackup() {
+		t.Error("want Custom Attribute ignored when backup dates are read from tags")
+	}
+}
@@ -0,0 +1,447 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrVirtualMachineConnectedMediaFound indicates that one or more
+// VirtualMachines have connected CD-ROM or floppy media which is not
+// explicitly allowed.
+var ErrVirtualMachineConnectedMediaFound = errors.New("virtual machines with connected media found")
+
+// Removable media device kinds evaluated for connected media.
+const (
+	connectedMediaKindCDROM  string = "CD-ROM"
+	connectedMediaKindFloppy string = "floppy"
+)
+
+// VMConnectedMediaDevice represents a CD-ROM or floppy device with connected
+// media.
+type VMConnectedMediaDevice struct {
+
+	// Label is the display label for the device (e.g., "CD/DVD drive 1").
+	Label string
+
+	// Kind is the general category of the device (e.g., CD-ROM, floppy).
+	Kind string
+
+	// Backing is the media used by the device (e.g., the ISO path or host
+	// device name).
+	Backing string
+
+	// Allowed indicates whether the media is permitted to remain connected
+	// based on the VM name or media path allow-lists.
+	Allowed bool
+}
+
+// VMConnectedMedia ties a collection of devices with connected media to a
+// specific VirtualMachine.
+type VMConnectedMedia struct {
+	VMName     string
+	PowerState types.VirtualMachinePowerState
+	Devices    []VMConnectedMediaDevice
+}
+
+// VMConnectedMediaSet is a collection of VMConnectedMedia values.
+type VMConnectedMediaSet []VMConnectedMedia
+
+// HasDisallowedMedia indicates whether the VirtualMachine has any connected
+// media which is not explicitly allowed.
+func (vcm VMConnectedMedia) HasDisallowedMedia() bool {
+	for _, dev := range vcm.Devices {
+		if !dev.Allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// connectedMediaBacking returns a description of the backing for the given
+// removable media device.
+func connectedMediaBacking(vd *types.VirtualDevice) string {
+	switch backing := vd.Backing.(type) {
+	case types.BaseVirtualDeviceFileBackingInfo:
+		return backing.GetVirtualDeviceFileBackingInfo().FileName
+
+	case types.BaseVirtualDeviceDeviceBackingInfo:
+		return fmt.Sprintf(
+			"host device %s",
+			backing.GetVirtualDeviceDeviceBackingInfo().DeviceName,
+		)
+
+	case types.BaseVirtualDeviceRemoteDeviceBackingInfo:
+		return "client device"
+
+	default:
+		if vd.DeviceInfo != nil {
+			return vd.DeviceInfo.GetDescription().Summary
+		}
+
+		return "unknown"
+	}
+}
+
+// NewVMConnectedMedia evaluates the CD-ROM and floppy devices for the given
+// VirtualMachine and returns any with connected media. For powered off
+// VirtualMachines, devices set to connect at power on are considered
+// connected.
+//
+// Connected media is considered allowed if the VirtualMachine name is in the
+// given list of allowed VM names or the media path contains one of the given
+// allowed path substrings (case-insensitive).
+func NewVMConnectedMedia(
+	vm mo.VirtualMachine,
+	allowedVMs []string,
+	allowedPaths []string,
+) VMConnectedMedia {
+
+	vcm := VMConnectedMedia{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+	}
+
+	if vm.Config == nil {
+		return vcm
+	}
+
+	vmAllowed := textutils.InList(vm.Name, allowedVMs, true)
+
+	for _, device := range vm.Config.Hardware.Device {
+		vd := device.GetVirtualDevice()
+
+		var kind string
+		switch device.(type) {
+		case *types.VirtualCdrom:
+			kind = connectedMediaKindCDROM
+		case *types.VirtualFloppy:
+			kind = connectedMediaKindFloppy
+		default:
+			continue
+		}
+
+		if vd.Connectable == nil {
+			continue
+		}
+
+		var connected bool
+		switch vm.Runtime.PowerState {
+		case types.VirtualMachinePowerStatePoweredOff:
+			connected = vd.Connectable.StartConnected
+		default:
+			connected = vd.Connectable.Connected
+		}
+
+		if !connected {
+			continue
+		}
+
+		var label string
+		if vd.DeviceInfo != nil {
+			label = vd.DeviceInfo.GetDescription().Label
+		}
+		if label == "" {
+			label = fmt.Sprintf("device %d", vd.Key)
+		}
+
+		backing := connectedMediaBacking(vd)
+
+		vcm.Devices = append(vcm.Devices, VMConnectedMediaDevice{
+			Label:   label,
+			Kind:    kind,
+			Backing: backing,
+			Allowed: vmAllowed || containsAnySubstring(backing, allowedPaths),
+		})
+	}
+
+	return vcm
+}
+
+// NewVMConnectedMediaSet evaluates the given VirtualMachines and returns
+// those with connected CD-ROM or floppy media.
+func NewVMConnectedMediaSet(
+	vms []mo.VirtualMachine,
+	allowedVMs []string,
+	allowedPaths []string,
+) VMConnectedMediaSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMConnectedMediaSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMConnectedMediaSet, 0, len(vms))
+	for _, vm := range vms {
+		vcm := NewVMConnectedMedia(vm, allowedVMs, allowedPaths)
+		if len(vcm.Devices) == 0 {
+			continue
+		}
+
+		set = append(set, vcm)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumDisallowedVMs returns the number of VirtualMachines with connected
+// media which is not explicitly allowed.
+func (set VMConnectedMediaSet) NumDisallowedVMs() int {
+	var num int
+	for _, vcm := range set {
+		if vcm.HasDisallowedMedia() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumDevices returns the number of devices of the specified kind with
+// connected media across all VirtualMachines in the set. If kind is an empty
+// string all devices are counted. If includeAllowed is false, only devices
+// with media which is not explicitly allowed are counted.
+func (set VMConnectedMediaSet) NumDevices(kind string, includeAllowed bool) int {
+	var num int
+	for _, vcm := range set {
+		for _, dev := range vcm.Devices {
+			if kind != "" && dev.Kind != kind {
+				continue
+			}
+
+			if !includeAllowed && dev.Allowed {
+				continue
+			}
+
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumAllowedDevices returns the number of devices with connected media which
+// is explicitly allowed.
+func (set VMConnectedMediaSet) NumAllowedDevices() int {
+	return set.NumDevices("", true) - set.NumDevices("", false)
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has
+// connected media which is not explicitly allowed.
+func (set VMConnectedMediaSet) IsWarningState() bool {
+	return set.NumDisallowedVMs() > 0
+}
+
+// VMConnectedMediaPerfData generates performance data metrics from the given
+// evaluation results.
+func VMConnectedMediaPerfData(set VMConnectedMediaSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_connected_media",
+			Value: fmt.Sprintf("%d", set.NumDisallowedVMs()),
+			Min:   "0",
+		},
+		{
+			Label: "connected_media",
+			Value: fmt.Sprintf("%d", set.NumDevices("", false)),
+			Min:   "0",
+		},
+		{
+			Label: "connected_cdroms",
+			Value: fmt.Sprintf("%d", set.NumDevices(connectedMediaKindCDROM, false)),
+			Min:   "0",
+		},
+		{
+			Label: "connected_floppies",
+			Value: fmt.Sprintf("%d", set.NumDevices(connectedMediaKindFloppy, false)),
+			Min:   "0",
+		},
+		{
+			Label: "allowed_connected_media",
+			Value: fmt.Sprintf("%d", set.NumAllowedDevices()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMConnectedMediaOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMConnectedMediaOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMConnectedMediaSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMConnectedMediaOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with %d connected CD-ROM or floppy devices detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumDisallowedVMs(),
+			set.NumDevices("", false),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with connected CD-ROM or floppy devices detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMConnectedMediaReport generates a summary of VMs with connected CD-ROM or
+// floppy media along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMConnectedMediaReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMConnectedMediaSet,
+	allowedVMs []string,
+	allowedPaths []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMConnectedMediaReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeDevices := func(allowed bool) {
+		var found bool
+		for _, vcm := range set {
+			var devices []VMConnectedMediaDevice
+			for _, dev := range vcm.Devices {
+				if dev.Allowed == allowed {
+					devices = append(devices, dev)
+				}
+			}
+
+			if len(devices) == 0 {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s)%s",
+				vcm.VMName,
+				vcm.PowerState,
+				nagios.CheckOutputEOL,
+			)
+
+			for _, dev := range devices {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s (%s): %s%s",
+					dev.Label,
+					dev.Kind,
+					dev.Backing,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with connected CD-ROM or floppy media:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeDevices(false)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs with allowed connected media:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeDevices(true)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs permitted to have connected media (%d): [%v]%s",
+		len(allowedVMs),
+		strings.Join(allowedVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified media paths permitted to remain connected (%d): [%v]%s",
+		len(allowedPaths),
+		strings.Join(allowedPaths, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
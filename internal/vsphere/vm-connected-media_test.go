@@ -0,0 +1,281 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// mediaCdrom returns a CD-ROM device backed by the given ISO file.
+func mediaCdrom(isoPath string, connected bool, startConnected bool) types.BaseVirtualDevice {
+	var cdrom types.VirtualCdrom
+	cdrom.Key = 3000
+	cdrom.DeviceInfo = &types.Description{Label: "CD/DVD drive 1"}
+	cdrom.Backing = &types.VirtualCdromIsoBackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+			FileName: isoPath,
+		},
+	}
+	cdrom.Connectable = &types.VirtualDeviceConnectInfo{
+		Connected:      connected,
+		StartConnected: startConnected,
+	}
+
+	return &cdrom
+}
+
+// mediaFloppy returns a connected floppy device backed by a host device.
+func mediaFloppy() types.BaseVirtualDevice {
+	var floppy types.VirtualFloppy
+	floppy.Key = 8000
+	floppy.Backing = &types.VirtualFloppyDeviceBackingInfo{
+		VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+			DeviceName: "/dev/fd0",
+		},
+	}
+	floppy.Connectable = &types.VirtualDeviceConnectInfo{Connected: true}
+
+	return &floppy
+}
+
+// mediaVM returns a VM with the given power state and devices.
+func mediaVM(name string, powerState types.VirtualMachinePowerState, devices ...types.BaseVirtualDevice) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Runtime.PowerState = powerState
+	vm.Config = &types.VirtualMachineConfigInfo{}
+	vm.Config.Hardware.Device = devices
+
+	return vm
+}
+
+func TestConnectedMediaBacking(t *testing.T) {
+	tests := map[string]struct {
+		device types.VirtualDevice
+		want   string
+	}{
+		"ISO file": {
+			device: types.VirtualDevice{
+				Backing: &types.VirtualCdromIsoBackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+						FileName: "[ds1] iso/ubuntu.iso",
+					},
+				},
+			},
+			want: "[ds1] iso/ubuntu.iso",
+		},
+		"host device": {
+			device: types.VirtualDevice{
+				Backing: &types.VirtualCdromAtapiBackingInfo{
+					VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+						DeviceName: "/dev/cdrom",
+					},
+				},
+			},
+			want: "host device /dev/cdrom",
+		},
+		"client device": {
+			device: types.VirtualDevice{
+				Backing: &types.VirtualCdromRemotePassthroughBackingInfo{},
+			},
+			want: "client device",
+		},
+		"device summary": {
+			device: types.VirtualDevice{
+				DeviceInfo: &types.Description{Summary: "Remote device"},
+			},
+			want: "Remote device",
+		},
+		"unknown": {
+			want: "unknown",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := connectedMediaBacking(&tt.device); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMConnectedMedia(t *testing.T) {
+	poweredOn := types.VirtualMachinePowerStatePoweredOn
+	poweredOff := types.VirtualMachinePowerStatePoweredOff
+	iso := "[ds1] iso/ubuntu.iso"
+
+	connectedCdrom := VMConnectedMediaDevice{
+		Label:   "CD/DVD drive 1",
+		Kind:    connectedMediaKindCDROM,
+		Backing: iso,
+	}
+	allowedCdrom := connectedCdrom
+	allowedCdrom.Allowed = true
+
+	connectedFloppy := VMConnectedMediaDevice{
+		Label:   "device 8000",
+		Kind:    connectedMediaKindFloppy,
+		Backing: "host device /dev/fd0",
+	}
+
+	noConnectInfo := mediaCdrom(iso, true, true)
+	noConnectInfo.GetVirtualDevice().Connectable = nil
+
+	tests := map[string]struct {
+		vm           mo.VirtualMachine
+		allowedVMs   []string
+		allowedPaths []string
+		want         []VMConnectedMediaDevice
+	}{
+		"disconnected media": {
+			vm: mediaVM("vm1", poweredOn, mediaCdrom(iso, false, true)),
+		},
+		"connected CD-ROM": {
+			vm:   mediaVM("vm1", poweredOn, mediaCdrom(iso, true, false)),
+			want: []VMConnectedMediaDevice{connectedCdrom},
+		},
+		"connected floppy without label": {
+			vm:   mediaVM("vm1", poweredOn, mediaFloppy()),
+			want: []VMConnectedMediaDevice{connectedFloppy},
+		},
+		"powered off VM connecting at power on": {
+			vm:   mediaVM("vm1", poweredOff, mediaCdrom(iso, false, true)),
+			want: []VMConnectedMediaDevice{connectedCdrom},
+		},
+		"powered off VM not connecting at power on": {
+			vm: mediaVM("vm1", poweredOff, mediaCdrom(iso, true, false)),
+		},
+		"device without connection details": {
+			vm: mediaVM("vm1", poweredOn, noConnectInfo),
+		},
+		"other devices ignored": {
+			vm: mediaVM("vm1", poweredOn, &types.VirtualDisk{
+				VirtualDevice: types.VirtualDevice{
+					Connectable: &types.VirtualDeviceConnectInfo{Connected: true},
+				},
+			}),
+		},
+		"VM without configuration": {
+			vm: mo.VirtualMachine{},
+		},
+		"allowed VM": {
+			vm:         mediaVM("vm1", poweredOn, mediaCdrom(iso, true, false)),
+			allowedVMs: []string{"VM1"},
+			want:       []VMConnectedMediaDevice{allowedCdrom},
+		},
+		"allowed media path": {
+			vm:           mediaVM("vm1", poweredOn, mediaCdrom(iso, true, false)),
+			allowedPaths: []string{"ISO/"},
+			want:         []VMConnectedMediaDevice{allowedCdrom},
+		},
+		"allowed media path with disallowed floppy": {
+			vm:           mediaVM("vm1", poweredOn, mediaCdrom(iso, true, false), mediaFloppy()),
+			allowedPaths: []string{"iso/"},
+			want:         []VMConnectedMediaDevice{allowedCdrom, connectedFloppy},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			vcm := NewVMConnectedMedia(tt.vm, tt.allowedVMs, tt.allowedPaths)
+
+			if d := cmp.Diff(tt.want, vcm.Devices); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMConnectedMediaSet(t *testing.T) {
+	poweredOn := types.VirtualMachinePowerStatePoweredOn
+	iso := "[ds1] iso/ubuntu.iso"
+
+	vms := []mo.VirtualMachine{
+		mediaVM("vm3", poweredOn, mediaCdrom(iso, true, false), mediaFloppy()),
+		mediaVM("vm2", poweredOn, mediaCdrom(iso, false, false)),
+		mediaVM("VM1", poweredOn, mediaCdrom(iso, true, false)),
+	}
+
+	tests := map[string]struct {
+		allowedVMs       []string
+		wantVMs          []string
+		wantDisallowed   int
+		wantDevices      int
+		wantCDROMs       int
+		wantFloppies     int
+		wantAllowed      int
+		wantWarningState bool
+	}{
+		"nothing allowed": {
+			wantVMs:          []string{"VM1", "vm3"},
+			wantDisallowed:   2,
+			wantDevices:      3,
+			wantCDROMs:       2,
+			wantFloppies:     1,
+			wantWarningState: true,
+		},
+		"VM allowed": {
+			allowedVMs:       []string{"vm1"},
+			wantVMs:          []string{"VM1", "vm3"},
+			wantDisallowed:   1,
+			wantDevices:      2,
+			wantCDROMs:       1,
+			wantFloppies:     1,
+			wantAllowed:      1,
+			wantWarningState: true,
+		},
+		"all VMs allowed": {
+			allowedVMs:  []string{"vm1", "vm3"},
+			wantVMs:     []string{"VM1", "vm3"},
+			wantAllowed: 3,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMConnectedMediaSet(vms, tt.allowedVMs, nil)
+
+			var got []string
+			for _, vcm := range set {
+				got = append(got, vcm.VMName)
+			}
+			if d := cmp.Diff(tt.wantVMs, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := set.NumDisallowedVMs(); got != tt.wantDisallowed {
+				t.Errorf("want %d VMs with disallowed media; got %d", tt.wantDisallowed, got)
+			}
+
+			if got := set.NumDevices("", false); got != tt.wantDevices {
+				t.Errorf("want %d devices with disallowed media; got %d", tt.wantDevices, got)
+			}
+
+			if got := set.NumDevices(connectedMediaKindCDROM, false); got != tt.wantCDROMs {
+				t.Errorf("want %d CD-ROMs with disallowed media; got %d", tt.wantCDROMs, got)
+			}
+
+			if got := set.NumDevices(connectedMediaKindFloppy, false); got != tt.wantFloppies {
+				t.Errorf("want %d floppies with disallowed media; got %d", tt.wantFloppies, got)
+			}
+
+			if got := set.NumAllowedDevices(); got != tt.wantAllowed {
+				t.Errorf("want %d devices with allowed media; got %d", tt.wantAllowed, got)
+			}
+
+			if got := set.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrVirtualMachineAffinityFound indicates that one or more VirtualMachines
+// have CPU or NUMA node affinity configured which is not explicitly allowed.
+var ErrVirtualMachineAffinityFound = errors.New("virtual machines with cpu or numa affinity found")
+
+// vmNUMANodeAffinityKey is the advanced configuration setting used to
+// restrict a VirtualMachine to specific NUMA nodes.
+const vmNUMANodeAffinityKey string = "numa.nodeAffinity"
+
+// VMAffinity represents the CPU and NUMA node affinity settings for a
+// VirtualMachine. Manually configured affinity prevents a VirtualMachine from
+// being migrated via vMotion and from being managed by DRS.
+type VMAffinity struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// CPUAffinity is the list of host processors the VirtualMachine is
+	// permitted to run on.
+	CPUAffinity []int32
+
+	// MemoryAffinity is the list of NUMA nodes the VirtualMachine is
+	// permitted to allocate memory from.
+	MemoryAffinity []int32
+
+	// NUMANodeAffinity is the value of the numa.nodeAffinity advanced
+	// setting.
+	NUMANodeAffinity string
+
+	// Allowed indicates whether the VirtualMachine is permitted to have
+	// affinity configured.
+	Allowed bool
+}
+
+// VMAffinitySet is a collection of VMAffinity values.
+type VMAffinitySet []VMAffinity
+
+// HasCPUAffinity indicates whether the VirtualMachine has CPU affinity
+// configured.
+func (va VMAffinity) HasCPUAffinity() bool {
+	return len(va.CPUAffinity) > 0
+}
+
+// HasNUMAAffinity indicates whether the VirtualMachine has NUMA node (or
+// memory) affinity configured.
+func (va VMAffinity) HasNUMAAffinity() bool {
+	return len(va.MemoryAffinity) > 0 || va.NUMANodeAffinity != ""
+}
+
+// HasAffinity indicates whether the VirtualMachine has CPU or NUMA node
+// affinity configured.
+func (va VMAffinity) HasAffinity() bool {
+	return va.HasCPUAffinity() || va.HasNUMAAffinity()
+}
+
+// formatAffinitySet returns a comma-separated list of the given affinity
+// set node numbers.
+func formatAffinitySet(nodes []int32) string {
+	items := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		items = append(items, strconv.Itoa(int(node)))
+	}
+
+	return strings.Join(items, ",")
+}
+
+// NewVMAffinity evaluates the affinity settings for the given
+// VirtualMachine. Affinity is considered allowed if the VirtualMachine name
+// is in the given list of allowed VM names (case-insensitive).
+func NewVMAffinity(vm mo.VirtualMachine, allowedVMs []string) VMAffinity {
+
+	va := VMAffinity{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+		Allowed:    textutils.InList(vm.Name, allowedVMs, true),
+	}
+
+	if vm.Config == nil {
+		return va
+	}
+
+	if vm.Config.CpuAffinity != nil {
+		va.CPUAffinity = vm.Config.CpuAffinity.AffinitySet
+	}
+
+	if vm.Config.MemoryAffinity != nil {
+		va.MemoryAffinity = vm.Config.MemoryAffinity.AffinitySet
+	}
+
+	for _, option := range vm.Config.ExtraConfig {
+		ov := option.GetOptionValue()
+		if ov == nil || !strings.EqualFold(ov.Key, vmNUMANodeAffinityKey) {
+			continue
+		}
+
+		va.NUMANodeAffinity = strings.TrimSpace(fmt.Sprintf("%v", ov.Value))
+	}
+
+	return va
+}
+
+// NewVMAffinitySet evaluates the given VirtualMachines and returns those
+// with CPU or NUMA node affinity configured.
+func NewVMAffinitySet(vms []mo.VirtualMachine, allowedVMs []string) VMAffinitySet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMAffinitySet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMAffinitySet, 0, len(vms))
+	for _, vm := range vms {
+		va := NewVMAffinity(vm, allowedVMs)
+		if !va.HasAffinity() {
+			continue
+		}
+
+		set = append(set, va)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumDisallowed returns the number of VirtualMachines with affinity
+// configured which is not explicitly allowed.
+func (set VMAffinitySet) NumDisallowed() int {
+	var num int
+	for _, va := range set {
+		if !va.Allowed {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumAllowed returns the number of VirtualMachines with affinity configured
+// which is explicitly allowed.
+func (set VMAffinitySet) NumAllowed() int {
+	return len(set) - set.NumDisallowed()
+}
+
+// NumCPUAffinity returns the number of VirtualMachines with CPU affinity
+// configured which is not explicitly allowed.
+func (set VMAffinitySet) NumCPUAffinity() int {
+	var num int
+	for _, va := range set {
+		if !va.Allowed && va.HasCPUAffinity() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumNUMAAffinity returns the number of VirtualMachines with NUMA node
+// affinity configured which is not explicitly allowed.
+func (set VMAffinitySet) NumNUMAAffinity() int {
+	var num int
+	for _, va := range set {
+		if !va.Allowed && va.HasNUMAAffinity() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has
+// affinity configured which is not explicitly allowed.
+func (set VMAffinitySet) IsWarningState() bool {
+	return set.NumDisallowed() > 0
+}
+
+// VMAffinityPerfData generates performance data metrics from the given
+// evaluation results.
+func VMAffinityPerfData(set VMAffinitySet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_affinity",
+			Value: fmt.Sprintf("%d", set.NumDisallowed()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_with_cpu_affinity",
+			Value: fmt.Sprintf("%d", set.NumCPUAffinity()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_with_numa_affinity",
+			Value: fmt.Sprintf("%d", set.NumNUMAAffinity()),
+			Min:   "0",
+		},
+		{
+			Label: "allowed_vms_with_affinity",
+			Value: fmt.Sprintf("%d", set.NumAllowed()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMAffinityOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMAffinityOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMAffinitySet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMAffinityOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with CPU or NUMA affinity detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumDisallowed(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with CPU or NUMA affinity detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMAffinityReport generates a summary of VMs with CPU or NUMA node affinity
+// configured along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMAffinityReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMAffinitySet,
+	allowedVMs []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMAffinityReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMs := func(allowed bool) {
+		var found bool
+		for _, va := range set {
+			if va.Allowed != allowed {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s)%s",
+				va.VMName,
+				va.PowerState,
+				nagios.CheckOutputEOL,
+			)
+
+			if va.HasCPUAffinity() {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** CPU affinity: %s%s",
+					formatAffinitySet(va.CPUAffinity),
+					nagios.CheckOutputEOL,
+				)
+			}
+
+			if len(va.MemoryAffinity) > 0 {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** Memory affinity: %s%s",
+					formatAffinitySet(va.MemoryAffinity),
+					nagios.CheckOutputEOL,
+				)
+			}
+
+			if va.NUMANodeAffinity != "" {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s: %s%s",
+					vmNUMANodeAffinityKey,
+					va.NUMANodeAffinity,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with CPU or NUMA affinity configured:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(false)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs with allowed CPU or NUMA affinity:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(true)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs permitted to have affinity configured (%d): [%v]%s",
+		len(allowedVMs),
+		strings.Join(allowedVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
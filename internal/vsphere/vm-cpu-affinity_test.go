@@ -0,0 +1,204 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// affinityVM returns a powered on VM with the given CPU and memory affinity
+// sets and advanced settings.
+func affinityVM(name string, cpuAffinity []int32, memoryAffinity []int32, extraConfig ...types.BaseOptionValue) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOn
+	vm.Config = &types.VirtualMachineConfigInfo{ExtraConfig: extraConfig}
+
+	if cpuAffinity != nil {
+		vm.Config.CpuAffinity = &types.VirtualMachineAffinityInfo{AffinitySet: cpuAffinity}
+	}
+
+	if memoryAffinity != nil {
+		vm.Config.MemoryAffinity = &types.VirtualMachineAffinityInfo{AffinitySet: memoryAffinity}
+	}
+
+	return vm
+}
+
+// numaNodeAffinity returns a numa.nodeAffinity advanced setting with the
+// given value.
+func numaNodeAffinity(value interface{}) types.BaseOptionValue {
+	return &types.OptionValue{Key: vmNUMANodeAffinityKey, Value: value}
+}
+
+func TestFormatAffinitySet(t *testing.T) {
+	tests := map[string]struct {
+		nodes []int32
+		want  string
+	}{
+		"empty":     {},
+		"single":    {nodes: []int32{3}, want: "3"},
+		"multiple":  {nodes: []int32{0, 1, 12}, want: "0,1,12"},
+		"unordered": {nodes: []int32{4, 2}, want: "4,2"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := formatAffinitySet(tt.nodes); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMAffinity(t *testing.T) {
+	tests := map[string]struct {
+		vm         mo.VirtualMachine
+		allowedVMs []string
+		want       VMAffinity
+		wantCPU    bool
+		wantNUMA   bool
+	}{
+		"no affinity": {
+			vm:   affinityVM("vm1", nil, nil),
+			want: VMAffinity{VMName: "vm1", PowerState: types.VirtualMachinePowerStatePoweredOn},
+		},
+		"empty affinity sets": {
+			vm: affinityVM("vm1", []int32{}, []int32{}),
+			want: VMAffinity{
+				VMName:         "vm1",
+				PowerState:     types.VirtualMachinePowerStatePoweredOn,
+				CPUAffinity:    []int32{},
+				MemoryAffinity: []int32{},
+			},
+		},
+		"CPU affinity": {
+			vm: affinityVM("vm1", []int32{0, 1}, nil),
+			want: VMAffinity{
+				VMName:      "vm1",
+				PowerState:  types.VirtualMachinePowerStatePoweredOn,
+				CPUAffinity: []int32{0, 1},
+			},
+			wantCPU: true,
+		},
+		"memory affinity": {
+			vm: affinityVM("vm1", nil, []int32{0}),
+			want: VMAffinity{
+				VMName:         "vm1",
+				PowerState:     types.VirtualMachinePowerStatePoweredOn,
+				MemoryAffinity: []int32{0},
+			},
+			wantNUMA: true,
+		},
+		"NUMA node affinity setting": {
+			vm: affinityVM("vm1", nil, nil,
+				&types.OptionValue{Key: "numa.vcpu.preferHT", Value: "TRUE"},
+				&types.OptionValue{Key: "NUMA.NodeAffinity", Value: " 0,1 "},
+			),
+			want: VMAffinity{
+				VMName:           "vm1",
+				PowerState:       types.VirtualMachinePowerStatePoweredOn,
+				NUMANodeAffinity: "0,1",
+			},
+			wantNUMA: true,
+		},
+		"non-string NUMA node affinity setting": {
+			vm: affinityVM("vm1", nil, nil, numaNodeAffinity(int32(1))),
+			want: VMAffinity{
+				VMName:           "vm1",
+				PowerState:       types.VirtualMachinePowerStatePoweredOn,
+				NUMANodeAffinity: "1",
+			},
+			wantNUMA: true,
+		},
+		"allowed VM": {
+			vm:         affinityVM("vm1", []int32{0, 1}, []int32{0}),
+			allowedVMs: []string{"VM1"},
+			want: VMAffinity{
+				VMName:         "vm1",
+				PowerState:     types.VirtualMachinePowerStatePoweredOn,
+				CPUAffinity:    []int32{0, 1},
+				MemoryAffinity: []int32{0},
+				Allowed:        true,
+			},
+			wantCPU:  true,
+			wantNUMA: true,
+		},
+		"VM without configuration": {
+			vm:   mo.VirtualMachine{},
+			want: VMAffinity{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMAffinity(tt.vm, tt.allowedVMs)
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got.HasCPUAffinity() != tt.wantCPU {
+				t.Errorf("want CPU affinity %t; got %t", tt.wantCPU, got.HasCPUAffinity())
+			}
+
+			if got.HasNUMAAffinity() != tt.wantNUMA {
+				t.Errorf("want NUMA affinity %t; got %t", tt.wantNUMA, got.HasNUMAAffinity())
+			}
+
+			if want := tt.wantCPU || tt.wantNUMA; got.HasAffinity() != want {
+				t.Errorf("want affinity %t; got %t", want, got.HasAffinity())
+			}
+		})
+	}
+}
+
+func TestNewVMAffinitySet(t *testing.T) {
+	vms := []mo.VirtualMachine{
+		affinityVM("vm4", []int32{2, 3}, nil, numaNodeAffinity("0")),
+		affinityVM("vm3", nil, nil),
+		affinityVM("VM2", nil, []int32{1}),
+		affinityVM("vm1", []int32{0}, nil),
+	}
+
+	set := NewVMAffinitySet(vms, []string{"vm1"})
+
+	var got []string
+	for _, va := range set {
+		got = append(got, va.VMName)
+	}
+
+	if d := cmp.Diff([]string{"vm1", "VM2", "vm4"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if !set.IsWarningState() {
+		t.Error("want WARNING state for VMs with disallowed affinity")
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_affinity", Value: "2", Min: "0"},
+		{Label: "vms_with_cpu_affinity", Value: "1", Min: "0"},
+		{Label: "vms_with_numa_affinity", Value: "2", Min: "0"},
+		{Label: "allowed_vms_with_affinity", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMAffinityPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	allowed := NewVMAffinitySet(vms, []string{"vm1", "vm2", "vm4"})
+	if allowed.IsWarningState() || allowed.NumAllowed() != 3 {
+		t.Errorf("want no WARNING state and 3 allowed VMs; got %d allowed", allowed.NumAllowed())
+	}
+}
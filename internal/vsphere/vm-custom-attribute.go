@@ -0,0 +1,593 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineCustomAttributeViolation indicates that one or more
+// VirtualMachines have a Custom Attribute value which does not satisfy the
+// specified constraints.
+var ErrVirtualMachineCustomAttributeViolation = errors.New("virtual machines with custom attribute constraint violations found")
+
+// ErrVirtualMachineCustomAttributeMissing indicates that one or more
+// VirtualMachines are missing the specified Custom Attribute.
+var ErrVirtualMachineCustomAttributeMissing = errors.New("virtual machines missing custom attribute found")
+
+// VMCustomAttributePolicy is the set of constraints applied to a specific
+// Custom Attribute for evaluated VirtualMachines.
+type VMCustomAttributePolicy struct {
+
+	// Name is the name of the Custom Attribute.
+	Name string
+
+	// IgnoreMissing indicates whether VirtualMachines missing the Custom
+	// Attribute are ignored instead of being treated as a problem.
+	IgnoreMissing bool
+
+	// Pattern is an optional regular expression which the Custom Attribute
+	// value must match.
+	Pattern *regexp.Regexp
+
+	// DateFormat is an optional Go time layout used to parse the Custom
+	// Attribute value (and range bounds) as a date. If not specified, range
+	// bounds are evaluated numerically.
+	DateFormat string
+
+	// Min and Max are the optional numeric range bounds (inclusive) for the
+	// Custom Attribute value.
+	Min *float64
+	Max *float64
+
+	// MinDate and MaxDate are the optional date range bounds (inclusive) for
+	// the Custom Attribute value.
+	MinDate *time.Time
+	MaxDate *time.Time
+}
+
+// VMCustomAttributeStatus is the result of evaluating a VirtualMachine
+// Custom Attribute against a VMCustomAttributePolicy.
+type VMCustomAttributeStatus struct {
+	VMName     string
+	PowerState types.VirtualMachinePowerState
+
+	// Value is the Custom Attribute value for the VirtualMachine.
+	Value string
+
+	// Missing indicates whether the Custom Attribute is not set for the
+	// VirtualMachine.
+	Missing bool
+
+	// Ignored indicates whether the Custom Attribute is missing and missing
+	// Custom Attributes were requested to be ignored.
+	Ignored bool
+
+	// Problems is the list of constraints not satisfied by the Custom
+	// Attribute value.
+	Problems []string
+}
+
+// VMCustomAttributeStatusSet is a collection of VMCustomAttributeStatus
+// values.
+type VMCustomAttributeStatusSet []VMCustomAttributeStatus
+
+// NewVMCustomAttributePolicy parses the given constraints and returns a
+// VMCustomAttributePolicy for the given Custom Attribute name. Empty values
+// indicate that the associated constraint is not used. If a date format is
+// given the range bounds are parsed as dates using that format, otherwise as
+// numbers.
+func NewVMCustomAttributePolicy(
+	name string,
+	ignoreMissing bool,
+	pattern string,
+	minVal string,
+	maxVal string,
+	dateFormat string,
+) (VMCustomAttributePolicy, error) {
+
+	policy := VMCustomAttributePolicy{
+		Name:          name,
+		IgnoreMissing: ignoreMissing,
+		DateFormat:    dateFormat,
+	}
+
+	if strings.TrimSpace(name) == "" {
+		return policy, fmt.Errorf("custom attribute name not provided")
+	}
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return policy, fmt.Errorf(
+				"invalid custom attribute value pattern %q: %w",
+				pattern,
+				err,
+			)
+		}
+		policy.Pattern = re
+	}
+
+	parseBound := func(val string) (*float64, *time.Time, error) {
+		if val == "" {
+			return nil, nil, nil
+		}
+
+		if dateFormat != "" {
+			t, err := time.ParseInLocation(dateFormat, val, time.Local)
+			if err != nil {
+				return nil, nil, fmt.Errorf(
+					"invalid custom attribute date range bound %q: %w",
+					val,
+					err,
+				)
+			}
+
+			return nil, &t, nil
+		}
+
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf(
+				"invalid custom attribute numeric range bound %q: %w",
+				val,
+				err,
+			)
+		}
+
+		return &f, nil, nil
+	}
+
+	var err error
+	if policy.Min, policy.MinDate, err = parseBound(minVal); err != nil {
+		return policy, err
+	}
+	if policy.Max, policy.MaxDate, err = parseBound(maxVal); err != nil {
+		return policy, err
+	}
+
+	switch {
+	case policy.Min != nil && policy.Max != nil && *policy.Min > *policy.Max:
+		return policy, fmt.Errorf(
+			"custom attribute range minimum %v is greater than maximum %v",
+			*policy.Min,
+			*policy.Max,
+		)
+
+	case policy.MinDate != nil && policy.MaxDate != nil && policy.MinDate.After(*policy.MaxDate):
+		return policy, fmt.Errorf(
+			"custom attribute date range minimum %v is after maximum %v",
+			policy.MinDate.Format(dateFormat),
+			policy.MaxDate.Format(dateFormat),
+		)
+	}
+
+	return policy, nil
+}
+
+// String provides a human readable description of the constraints applied
+// by the policy.
+func (p VMCustomAttributePolicy) String() string {
+	constraints := make([]string, 0, 4)
+
+	if !p.IgnoreMissing {
+		constraints = append(constraints, "must exist")
+	}
+
+	if p.Pattern != nil {
+		constraints = append(constraints, fmt.Sprintf("must match %q", p.Pattern.String()))
+	}
+
+	if p.DateFormat != "" {
+		constraints = append(constraints, fmt.Sprintf("must be a date in %q format", p.DateFormat))
+	}
+
+	if p.Min != nil {
+		constraints = append(constraints, fmt.Sprintf("must be >= %v", *p.Min))
+	}
+
+	if p.Max != nil {
+		constraints = append(constraints, fmt.Sprintf("must be <= %v", *p.Max))
+	}
+
+	if p.MinDate != nil {
+		constraints = append(constraints, fmt.Sprintf("must not be before %s", p.MinDate.Format(p.DateFormat)))
+	}
+
+	if p.MaxDate != nil {
+		constraints = append(constraints, fmt.Sprintf("must not be after %s", p.MaxDate.Format(p.DateFormat)))
+	}
+
+	if len(constraints) == 0 {
+		return "none"
+	}
+
+	return strings.Join(constraints, ", ")
+}
+
+// evaluate returns a list of the constraints not satisfied by the given
+// Custom Attribute value.
+func (p VMCustomAttributePolicy) evaluate(value string) []string {
+	var problems []string
+
+	if p.Pattern != nil && !p.Pattern.MatchString(value) {
+		problems = append(problems, fmt.Sprintf("does not match %q", p.Pattern.String()))
+	}
+
+	value = strings.TrimSpace(value)
+
+	switch {
+	case p.DateFormat != "":
+		t, err := time.ParseInLocation(p.DateFormat, value, time.Local)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("not a date in %q format", p.DateFormat))
+
+			break
+		}
+
+		if p.MinDate != nil && t.Before(*p.MinDate) {
+			problems = append(problems, fmt.Sprintf("before %s", p.MinDate.Format(p.DateFormat)))
+		}
+
+		if p.MaxDate != nil && t.After(*p.MaxDate) {
+			problems = append(problems, fmt.Sprintf("after %s", p.MaxDate.Format(p.DateFormat)))
+		}
+
+	case p.Min != nil || p.Max != nil:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			problems = append(problems, "not a number")
+
+			break
+		}
+
+		if p.Min != nil && f < *p.Min {
+			problems = append(problems, fmt.Sprintf("less than %v", *p.Min))
+		}
+
+		if p.Max != nil && f > *p.Max {
+			problems = append(problems, fmt.Sprintf("greater than %v", *p.Max))
+		}
+	}
+
+	return problems
+}
+
+// NewVMCustomAttributeStatusSet evaluates the specified Custom Attribute for
+// each of the given VirtualMachines using the given policy.
+func NewVMCustomAttributeStatusSet(
+	vms []mo.VirtualMachine,
+	policy VMCustomAttributePolicy,
+) (VMCustomAttributeStatusSet, error) {
+
+	funcTimeStart := time.Now()
+
+	set := make(VMCustomAttributeStatusSet, 0, len(vms))
+
+	defer func(set *VMCustomAttributeStatusSet) {
+		logger.Printf(
+			"It took %v to execute NewVMCustomAttributeStatusSet func (and evaluate %d VMs).\n",
+			time.Since(funcTimeStart),
+			len(*set),
+		)
+	}(&set)
+
+	vmsWithCAs, err := GetVMsWithCAs(vms)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vm := range vmsWithCAs {
+		status := VMCustomAttributeStatus{
+			VMName:     vm.Name,
+			PowerState: vm.Runtime.PowerState,
+		}
+
+		value, ok := vm.CustomAttributes[policy.Name]
+		switch {
+		case !ok || strings.TrimSpace(value) == "":
+			logger.Printf(
+				"Custom Attribute %q missing from %s",
+				policy.Name,
+				vm.Name,
+			)
+			status.Missing = true
+			status.Ignored = policy.IgnoreMissing
+
+		default:
+			status.Value = value
+			status.Problems = policy.evaluate(value)
+		}
+
+		set = append(set, status)
+	}
+
+	return set, nil
+}
+
+// HasViolation indicates whether the Custom Attribute value does not satisfy
+// one or more constraints.
+func (s VMCustomAttributeStatus) HasViolation() bool {
+	return len(s.Problems) > 0
+}
+
+// NumMissing returns the number of VirtualMachines missing the Custom
+// Attribute (excluding those ignored by request).
+func (set VMCustomAttributeStatusSet) NumMissing() int {
+	var num int
+	for _, s := range set {
+		if s.Missing && !s.Ignored {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumIgnored returns the number of VirtualMachines missing the Custom
+// Attribute which were ignored by request.
+func (set VMCustomAttributeStatusSet) NumIgnored() int {
+	var num int
+	for _, s := range set {
+		if s.Ignored {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumViolations returns the number of VirtualMachines with a Custom
+// Attribute value which does not satisfy one or more constraints.
+func (set VMCustomAttributeStatusSet) NumViolations() int {
+	var num int
+	for _, s := range set {
+		if s.HasViolation() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCompliant returns the number of VirtualMachines with a Custom Attribute
+// value satisfying all constraints.
+func (set VMCustomAttributeStatusSet) NumCompliant() int {
+	var num int
+	for _, s := range set {
+		if !s.Missing && !s.HasViolation() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether any VirtualMachines have a Custom
+// Attribute value which does not satisfy one or more constraints.
+func (set VMCustomAttributeStatusSet) IsCriticalState() bool {
+	return set.NumViolations() > 0
+}
+
+// IsWarningState indicates whether any VirtualMachines are missing the
+// Custom Attribute (and missing Custom Attributes are not ignored).
+func (set VMCustomAttributeStatusSet) IsWarningState() bool {
+	return set.NumMissing() > 0
+}
+
+// VMCustomAttributePerfData generates performance data metrics from the
+// given evaluation results.
+func VMCustomAttributePerfData(set VMCustomAttributeStatusSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_ca_violations",
+			Value: fmt.Sprintf("%d", set.NumViolations()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_missing_ca",
+			Value: fmt.Sprintf("%d", set.NumMissing()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_missing_ca_ignored",
+			Value: fmt.Sprintf("%d", set.NumIgnored()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_compliant_ca",
+			Value: fmt.Sprintf("%d", set.NumCompliant()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMCustomAttributeOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMCustomAttributeOneLineCheckSummary(
+	stateLabel string,
+	policy VMCustomAttributePolicy,
+	vmsFilterResults VMsFilterResults,
+	set VMCustomAttributeStatusSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"policy":           policy,
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMCustomAttributeOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState() || set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with constraint violations, %d VMs missing Custom Attribute %q detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumViolations(),
+			set.NumMissing(),
+			policy.Name,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with missing or non-compliant Custom Attribute %q detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			policy.Name,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMCustomAttributeReport generates a summary of VMs with missing or
+// non-compliant Custom Attribute values along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field commonly
+// displayed on the detailed service check results display in the web UI or in
+// the body of many notifications.
+func VMCustomAttributeReport(
+	c *vim25.Client,
+	policy VMCustomAttributePolicy,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMCustomAttributeStatusSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMCustomAttributeReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	// Somewhat arbitrary number intended to limit the number of VMs emitted
+	// in order to keep output manageable.
+	vmPrintLimit := 50
+
+	writeVMs := func(num int, include func(VMCustomAttributeStatus) bool) {
+		switch {
+		case num == 0:
+			_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+
+		case num > vmPrintLimit:
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %d VMs; output limit of %d reached, omitting list of VMs%s",
+				num,
+				vmPrintLimit,
+				nagios.CheckOutputEOL,
+			)
+
+		default:
+			for _, s := range set {
+				if !include(s) {
+					continue
+				}
+
+				switch {
+				case s.Missing:
+					_, _ = fmt.Fprintf(
+						&report,
+						"* %s (power state: %s)%s",
+						s.VMName,
+						s.PowerState,
+						nagios.CheckOutputEOL,
+					)
+
+				default:
+					_, _ = fmt.Fprintf(
+						&report,
+						"* %s (power state: %s): %q [%s]%s",
+						s.VMName,
+						s.PowerState,
+						s.Value,
+						strings.Join(s.Problems, ", "),
+						nagios.CheckOutputEOL,
+					)
+				}
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with Custom Attribute constraint violations:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(set.NumViolations(), VMCustomAttributeStatus.HasViolation)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs missing Custom Attribute:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(set.NumMissing(), func(s VMCustomAttributeStatus) bool {
+		return s.Missing && !s.Ignored
+	})
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Custom Attribute: %q%s",
+		policy.Name,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Constraints: %s%s",
+		policy.String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* VMs missing Custom Attribute (ignored): %d%s",
+		set.NumIgnored(),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,292 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const policyCAName string = "Retention"
+
+// policyCAVM returns a VM with the given value set for the Retention Custom
+// Attribute. An empty value indicates that the Custom Attribute is not set.
+func policyCAVM(name string, value string) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Self = types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: name}
+	vm.AvailableField = []types.CustomFieldDef{
+		{Key: 101, Name: policyCAName},
+	}
+
+	if value != "" {
+		vm.CustomValue = []types.BaseCustomFieldValue{
+			&types.CustomFieldStringValue{
+				CustomFieldValue: types.CustomFieldValue{Key: 101},
+				Value:            value,
+			},
+		}
+	}
+
+	return vm
+}
+
+func TestNewVMCustomAttributePolicy(t *testing.T) {
+	tests := map[string]struct {
+		name       string
+		pattern    string
+		minVal     string
+		maxVal     string
+		dateFormat string
+		wantErr    bool
+	}{
+		"name only":                  {name: policyCAName},
+		"pattern":                    {name: policyCAName, pattern: `^\d+d$`},
+		"numeric range":              {name: policyCAName, minVal: "7", maxVal: "90"},
+		"equal numeric bounds":       {name: policyCAName, minVal: "30", maxVal: "30"},
+		"date range":                 {name: policyCAName, minVal: "2022-01-01", maxVal: "2022-12-31", dateFormat: "2006-01-02"},
+		"missing name":               {wantErr: true},
+		"blank name":                 {name: "  ", wantErr: true},
+		"invalid pattern":            {name: policyCAName, pattern: "[", wantErr: true},
+		"invalid numeric bound":      {name: policyCAName, minVal: "seven", wantErr: true},
+		"invalid date bound":         {name: policyCAName, maxVal: "12/31/2022", dateFormat: "2006-01-02", wantErr: true},
+		"minimum above maximum":      {name: policyCAName, minVal: "90", maxVal: "7", wantErr: true},
+		"minimum date after maximum": {name: policyCAName, minVal: "2022-12-31", maxVal: "2022-01-01", dateFormat: "2006-01-02", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewVMCustomAttributePolicy(tt.name, false, tt.pattern, tt.minVal, tt.maxVal, tt.dateFormat)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("want error %t; got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVMCustomAttributePolicyString(t *testing.T) {
+	tests := map[string]struct {
+		ignoreMissing bool
+		pattern       string
+		minVal        string
+		maxVal        string
+		dateFormat    string
+		want          string
+	}{
+		"no constraints": {
+			ignoreMissing: true,
+			want:          "none",
+		},
+		"must exist": {
+			want: "must exist",
+		},
+		"pattern and numeric range": {
+			pattern: `^\d+$`,
+			minVal:  "7",
+			maxVal:  "90.5",
+			want:    `must exist, must match "^\\d+$", must be >= 7, must be <= 90.5`,
+		},
+		"date range": {
+			ignoreMissing: true,
+			minVal:        "2022-01-01",
+			maxVal:        "2022-12-31",
+			dateFormat:    "2006-01-02",
+			want:          `must be a date in "2006-01-02" format, must not be before 2022-01-01, must not be after 2022-12-31`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := NewVMCustomAttributePolicy(policyCAName, tt.ignoreMissing, tt.pattern, tt.minVal, tt.maxVal, tt.dateFormat)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if got := policy.String(); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMCustomAttributePolicyEvaluate(t *testing.T) {
+	tests := map[string]struct {
+		value      string
+		pattern    string
+		minVal     string
+		maxVal     string
+		dateFormat string
+		want       []string
+	}{
+		"no constraints": {
+			value: "anything",
+		},
+		"matches pattern": {
+			value:   "30d",
+			pattern: `^\d+d$`,
+		},
+		"does not match pattern": {
+			value:   "thirty",
+			pattern: `^\d+d$`,
+			want:    []string{`does not match "^\\d+d$"`},
+		},
+		"within numeric range": {
+			value:  " 30 ",
+			minVal: "7",
+			maxVal: "90",
+		},
+		"at numeric bounds": {
+			value:  "7",
+			minVal: "7",
+			maxVal: "7",
+		},
+		"below numeric range": {
+			value:  "3",
+			minVal: "7",
+			maxVal: "90",
+			want:   []string{"less than 7"},
+		},
+		"above numeric range": {
+			value:  "120.5",
+			maxVal: "90",
+			want:   []string{"greater than 90"},
+		},
+		"not a number": {
+			value:  "forever",
+			maxVal: "90",
+			want:   []string{"not a number"},
+		},
+		"pattern and range violations": {
+			value:   "3",
+			pattern: `^\d+d$`,
+			minVal:  "7",
+			want:    []string{`does not match "^\\d+d$"`, "less than 7"},
+		},
+		"date within range": {
+			value:      "2022-06-01",
+			minVal:     "2022-01-01",
+			maxVal:     "2022-12-31",
+			dateFormat: "2006-01-02",
+		},
+		"date before range": {
+			value:      "2021-12-31",
+			minVal:     "2022-01-01",
+			dateFormat: "2006-01-02",
+			want:       []string{"before 2022-01-01"},
+		},
+		"date after range": {
+			value:      "2023-02-01",
+			maxVal:     "2022-12-31",
+			dateFormat: "2006-01-02",
+			want:       []string{"after 2022-12-31"},
+		},
+		"date without range": {
+			value:      "2023-02-01",
+			dateFormat: "2006-01-02",
+		},
+		"not a date": {
+			value:      "06/01/2022",
+			dateFormat: "2006-01-02",
+			want:       []string{`not a date in "2006-01-02" format`},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := NewVMCustomAttributePolicy(policyCAName, false, tt.pattern, tt.minVal, tt.maxVal, tt.dateFormat)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if d := cmp.Diff(tt.want, policy.evaluate(tt.value)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMCustomAttributeStatusSet(t *testing.T) {
+	vms := []mo.VirtualMachine{
+		policyCAVM("vm1", "30"),
+		policyCAVM("vm2", "3"),
+		policyCAVM("vm3", ""),
+		policyCAVM("vm4", "   "),
+	}
+
+	tests := map[string]struct {
+		ignoreMissing    bool
+		wantMissing      int
+		wantIgnored      int
+		wantCritical     bool
+		wantWarningState bool
+	}{
+		"missing values reported": {
+			wantMissing:      2,
+			wantCritical:     true,
+			wantWarningState: true,
+		},
+		"missing values ignored": {
+			ignoreMissing: true,
+			wantIgnored:   2,
+			wantCritical:  true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := NewVMCustomAttributePolicy(policyCAName, tt.ignoreMissing, "", "7", "90", "")
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			set, err := NewVMCustomAttributeStatusSet(vms, policy)
+			if err != nil {
+				t.Fatalf("want nil error; got %v", err)
+			}
+
+			if len(set) != len(vms) {
+				t.Fatalf("want %d evaluated VMs; got %d", len(vms), len(set))
+			}
+
+			if d := cmp.Diff([]string{"less than 7"}, set[1].Problems); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if set[0].Value != "30" || set[0].HasViolation() {
+				t.Errorf("want compliant value 30 for vm1; got %+v", set[0])
+			}
+
+			if got := set.NumMissing(); got != tt.wantMissing {
+				t.Errorf("want %d VMs missing the Custom Attribute; got %d", tt.wantMissing, got)
+			}
+
+			if got := set.NumIgnored(); got != tt.wantIgnored {
+				t.Errorf("want %d ignored VMs; got %d", tt.wantIgnored, got)
+			}
+
+			if got := set.NumViolations(); got != 1 {
+				t.Errorf("want 1 VM with violations; got %d", got)
+			}
+
+			if got := set.NumCompliant(); got != 1 {
+				t.Errorf("want 1 compliant VM; got %d", got)
+			}
+
+			if got := set.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
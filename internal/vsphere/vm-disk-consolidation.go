@@ -0,0 +1,371 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineDiskConsolidationFailed indicates that disk consolidation
+// for a VirtualMachine was attempted but did not complete successfully.
+var ErrVirtualMachineDiskConsolidationFailed = errors.New("virtual machine disk consolidation failed")
+
+// ErrVirtualMachineDiskConsolidationInProgress indicates that disk
+// consolidation for a VirtualMachine was triggered but had not completed when
+// results were collected.
+var ErrVirtualMachineDiskConsolidationInProgress = errors.New("virtual machine disk consolidation in progress")
+
+// VMDiskConsolidationResult is the outcome of a disk consolidation attempt
+// for a VirtualMachine.
+type VMDiskConsolidationResult struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// TaskMOID is the Managed Object ID of the disk consolidation task. This
+	// is empty if the task could not be created.
+	TaskMOID string
+
+	// Skipped indicates that disk consolidation was not attempted for the
+	// VirtualMachine due to the maximum number of remediations being
+	// reached.
+	Skipped bool
+
+	// InProgress indicates that the disk consolidation task had not
+	// completed when results were collected.
+	InProgress bool
+
+	// DeadlineReached indicates that the remediation timeout was reached
+	// while waiting for the disk consolidation task to complete.
+	DeadlineReached bool
+
+	// Err is the error (if any) returned by the disk consolidation task.
+	Err error
+
+	// Duration is the time taken by the disk consolidation task.
+	Duration time.Duration
+}
+
+// VMDiskConsolidationResults is a collection of disk consolidation outcomes.
+type VMDiskConsolidationResults []VMDiskConsolidationResult
+
+// String provides a human readable representation of a disk consolidation
+// outcome.
+func (r VMDiskConsolidationResult) String() string {
+	switch {
+	case r.Skipped:
+		return fmt.Sprintf("%s: skipped (remediation limit reached)", r.VMName)
+	case r.Err != nil && r.TaskMOID != "":
+		return fmt.Sprintf("%s: failed (task %s: %v)", r.VMName, r.TaskMOID, r.Err)
+	case r.Err != nil:
+		return fmt.Sprintf("%s: failed (%v)", r.VMName, r.Err)
+	case r.DeadlineReached:
+		return fmt.Sprintf(
+			"%s: in progress (task %s; remediation timeout reached after %v)",
+			r.VMName,
+			r.TaskMOID,
+			r.Duration.Round(time.Second),
+		)
+	case r.InProgress:
+		return fmt.Sprintf("%s: in progress (task %s)", r.VMName, r.TaskMOID)
+	default:
+		return fmt.Sprintf(
+			"%s: consolidated (task %s; %v)",
+			r.VMName,
+			r.TaskMOID,
+			r.Duration.Round(time.Second),
+		)
+	}
+}
+
+// NumAttempted returns the number of VirtualMachines for which disk
+// consolidation was attempted.
+func (rs VMDiskConsolidationResults) NumAttempted() int {
+	return len(rs) - rs.NumSkipped()
+}
+
+// NumSucceeded returns the number of VirtualMachines for which disk
+// consolidation completed successfully.
+func (rs VMDiskConsolidationResults) NumSucceeded() int {
+	var num int
+	for _, r := range rs {
+		if !r.Skipped && !r.InProgress && r.Err == nil {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInProgress returns the number of VirtualMachines for which disk
+// consolidation was triggered but had not completed when results were
+// collected.
+func (rs VMDiskConsolidationResults) NumInProgress() int {
+	var num int
+	for _, r := range rs {
+		if r.InProgress {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumFailed returns the number of VirtualMachines for which disk
+// consolidation was attempted but failed.
+func (rs VMDiskConsolidationResults) NumFailed() int {
+	var num int
+	for _, r := range rs {
+		if !r.Skipped && r.Err != nil {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumSkipped returns the number of VirtualMachines for which disk
+// consolidation was not attempted due to the maximum number of remediations
+// being reached.
+func (rs VMDiskConsolidationResults) NumSkipped() int {
+	var num int
+	for _, r := range rs {
+		if r.Skipped {
+			num++
+		}
+	}
+
+	return num
+}
+
+// Remediated indicates whether disk consolidation completed successfully for
+// every VirtualMachine in the collection.
+func (rs VMDiskConsolidationResults) Remediated() bool {
+	return len(rs) > 0 && rs.NumSucceeded() == len(rs)
+}
+
+// Pending indicates whether disk consolidation was triggered for every
+// VirtualMachine in the collection without any failures, but one or more
+// tasks had not completed when results were collected.
+func (rs VMDiskConsolidationResults) Pending() bool {
+	return rs.NumInProgress() > 0 &&
+		rs.NumSucceeded()+rs.NumInProgress() == len(rs)
+}
+
+// ConsolidateVMDisks triggers disk consolidation for the given
+// VirtualMachines. VirtualMachines are processed in name order and at most
+// maxRemediations VirtualMachines are consolidated; the remaining
+// VirtualMachines are recorded as skipped.
+//
+// All disk consolidation tasks are started before any are waited on. If
+// waitTimeout is greater than zero the tasks are waited on concurrently until
+// they complete or waitTimeout is reached; tasks still running at that point
+// are recorded as in progress (deadline reached) instead of failed. If
+// waitTimeout is zero the tasks are not waited on and are recorded as in
+// progress. The outcome for each VirtualMachine is returned.
+func ConsolidateVMDisks(
+	ctx context.Context,
+	c *vim25.Client,
+	vms []mo.VirtualMachine,
+	maxRemediations int,
+	waitTimeout time.Duration,
+) VMDiskConsolidationResults {
+
+	funcTimeStart := time.Now()
+
+	results := make(VMDiskConsolidationResults, 0, len(vms))
+
+	defer func(results *VMDiskConsolidationResults) {
+		logger.Printf(
+			"It took %v to execute ConsolidateVMDisks func (for %d VMs, %d attempted).\n",
+			time.Since(funcTimeStart),
+			len(vms),
+			results.NumAttempted(),
+		)
+	}(&results)
+
+	sortedVMs := make([]mo.VirtualMachine, len(vms))
+	copy(sortedVMs, vms)
+
+	sort.Slice(sortedVMs, func(i, j int) bool {
+		return strings.ToLower(sortedVMs[i].Name) < strings.ToLower(sortedVMs[j].Name)
+	})
+
+	tasks := make(map[int]*object.Task)
+
+	for i, vm := range sortedVMs {
+		if i >= maxRemediations {
+			logger.Printf(
+				"skipping disk consolidation for VM %s; remediation limit of %d reached",
+				vm.Name,
+				maxRemediations,
+			)
+
+			results = append(results, VMDiskConsolidationResult{
+				VMName:  vm.Name,
+				Skipped: true,
+			})
+
+			continue
+		}
+
+		task, err := startVMDiskConsolidation(ctx, c, vm)
+		if err != nil {
+			logger.Printf("disk consolidation for VM %s failed: %v", vm.Name, err)
+
+			results = append(results, VMDiskConsolidationResult{
+				VMName: vm.Name,
+				Err:    err,
+			})
+
+			continue
+		}
+
+		logger.Printf(
+			"started disk consolidation task %s for VM %s",
+			task.Reference().Value,
+			vm.Name,
+		)
+
+		tasks[len(results)] = task
+		results = append(results, VMDiskConsolidationResult{
+			VMName:     vm.Name,
+			TaskMOID:   task.Reference().Value,
+			InProgress: true,
+		})
+	}
+
+	if waitTimeout <= 0 || len(tasks) == 0 {
+		return results
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+
+	waitStart := time.Now()
+
+	var wg sync.WaitGroup
+	for idx, task := range tasks {
+		wg.Add(1)
+
+		// Each goroutine updates a distinct element of the results
+		// collection.
+		go func(r *VMDiskConsolidationResult, task *object.Task) {
+			defer wg.Done()
+
+			err := task.Wait(waitCtx)
+			r.Duration = time.Since(waitStart)
+
+			switch {
+			case err == nil:
+				r.InProgress = false
+
+			case waitCtx.Err() != nil:
+				logger.Printf(
+					"disk consolidation task %s for VM %s still in progress; remediation timeout reached",
+					r.TaskMOID,
+					r.VMName,
+				)
+				r.DeadlineReached = true
+
+			default:
+				logger.Printf(
+					"disk consolidation task %s for VM %s failed: %v",
+					r.TaskMOID,
+					r.VMName,
+					err,
+				)
+				r.InProgress = false
+				r.Err = fmt.Errorf(
+					"%w: %v",
+					ErrVirtualMachineDiskConsolidationFailed,
+					err,
+				)
+			}
+		}(&results[idx], task)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// startVMDiskConsolidation triggers disk consolidation for the given
+// VirtualMachine and returns the associated task without waiting for it to
+// complete.
+func startVMDiskConsolidation(ctx context.Context, c *vim25.Client, vm mo.VirtualMachine) (*object.Task, error) {
+	req := types.ConsolidateVMDisks_Task{
+		This: vm.Reference(),
+	}
+
+	res, err := methods.ConsolidateVMDisks_Task(ctx, c, &req)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"%w: failed to create task: %v",
+			ErrVirtualMachineDiskConsolidationFailed,
+			err,
+		)
+	}
+
+	return object.NewTask(c, res.Returnval), nil
+}
+
+// vmDiskConsolidationResultsReport writes the outcome of each disk
+// consolidation attempt. Nothing is written if disk consolidation was not
+// requested.
+func vmDiskConsolidationResultsReport(
+	w io.Writer,
+	consolidationResults VMDiskConsolidationResults,
+) {
+
+	if consolidationResults == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(
+		w,
+		"%sDisk consolidation results (%d attempted, %d succeeded, %d in progress, %d failed, %d skipped):%s%s",
+		nagios.CheckOutputEOL,
+		consolidationResults.NumAttempted(),
+		consolidationResults.NumSucceeded(),
+		consolidationResults.NumInProgress(),
+		consolidationResults.NumFailed(),
+		consolidationResults.NumSkipped(),
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(consolidationResults) == 0 {
+		_, _ = fmt.Fprintf(w, "* None %s", nagios.CheckOutputEOL)
+
+		return
+	}
+
+	for _, r := range consolidationResults {
+		_, _ = fmt.Fprintf(
+			w,
+			"* %s%s",
+			r,
+			nagios.CheckOutputEOL,
+		)
+	}
+}
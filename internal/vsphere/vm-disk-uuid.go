@@ -0,0 +1,300 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineDiskUUIDNotEnabled indicates that one or more
+// VirtualMachines do not have the disk.EnableUUID advanced setting enabled.
+var ErrVirtualMachineDiskUUIDNotEnabled = errors.New("virtual machines without disk.EnableUUID enabled found")
+
+// vmDiskEnableUUIDKey is the advanced configuration setting used to expose
+// consistent virtual disk UUIDs to the guest OS. This setting is required by
+// Kubernetes (vSphere CSI) nodes and many backup agents.
+const vmDiskEnableUUIDKey string = "disk.EnableUUID"
+
+// VMDiskUUID represents the disk.EnableUUID advanced setting for a
+// VirtualMachine.
+type VMDiskUUID struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// Value is the value of the disk.EnableUUID advanced setting. This is
+	// empty if the setting is not present.
+	Value string
+}
+
+// VMDiskUUIDSet is a collection of VMDiskUUID values.
+type VMDiskUUIDSet []VMDiskUUID
+
+// IsSet indicates whether the disk.EnableUUID advanced setting is present
+// for the VirtualMachine.
+func (vdu VMDiskUUID) IsSet() bool {
+	return vdu.Value != ""
+}
+
+// IsEnabled indicates whether the disk.EnableUUID advanced setting is
+// enabled for the VirtualMachine.
+func (vdu VMDiskUUID) IsEnabled() bool {
+	return strings.EqualFold(vdu.Value, "true")
+}
+
+// NewVMDiskUUID evaluates the disk.EnableUUID advanced setting for the given
+// VirtualMachine.
+func NewVMDiskUUID(vm mo.VirtualMachine) VMDiskUUID {
+
+	vdu := VMDiskUUID{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+	}
+
+	if vm.Config == nil {
+		return vdu
+	}
+
+	for _, option := range vm.Config.ExtraConfig {
+		ov := option.GetOptionValue()
+		if ov == nil || !strings.EqualFold(ov.Key, vmDiskEnableUUIDKey) {
+			continue
+		}
+
+		vdu.Value = strings.TrimSpace(fmt.Sprintf("%v", ov.Value))
+	}
+
+	return vdu
+}
+
+// NewVMDiskUUIDSet evaluates the disk.EnableUUID advanced setting for the
+// given VirtualMachines.
+func NewVMDiskUUIDSet(vms []mo.VirtualMachine) VMDiskUUIDSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMDiskUUIDSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMDiskUUIDSet, 0, len(vms))
+	for _, vm := range vms {
+		set = append(set, NewVMDiskUUID(vm))
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumEnabled returns the number of VirtualMachines with the disk.EnableUUID
+// advanced setting enabled.
+func (set VMDiskUUIDSet) NumEnabled() int {
+	var num int
+	for _, vdu := range set {
+		if vdu.IsEnabled() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumMissing returns the number of VirtualMachines without the
+// disk.EnableUUID advanced setting.
+func (set VMDiskUUIDSet) NumMissing() int {
+	var num int
+	for _, vdu := range set {
+		if !vdu.IsSet() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumDisabled returns the number of VirtualMachines with the disk.EnableUUID
+// advanced setting present, but not enabled.
+func (set VMDiskUUIDSet) NumDisabled() int {
+	var num int
+	for _, vdu := range set {
+		if vdu.IsSet() && !vdu.IsEnabled() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumNotEnabled returns the number of VirtualMachines without the
+// disk.EnableUUID advanced setting enabled.
+func (set VMDiskUUIDSet) NumNotEnabled() int {
+	return len(set) - set.NumEnabled()
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set does not
+// have the disk.EnableUUID advanced setting enabled.
+func (set VMDiskUUIDSet) IsWarningState() bool {
+	return set.NumNotEnabled() > 0
+}
+
+// VMDiskUUIDPerfData generates performance data metrics from the given
+// evaluation results.
+func VMDiskUUIDPerfData(set VMDiskUUIDSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_disk_uuid_enabled",
+			Value: fmt.Sprintf("%d", set.NumEnabled()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_disk_uuid_missing",
+			Value: fmt.Sprintf("%d", set.NumMissing()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_disk_uuid_disabled",
+			Value: fmt.Sprintf("%d", set.NumDisabled()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMDiskUUIDOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMDiskUUIDOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMDiskUUIDSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMDiskUUIDOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs without %s enabled (%d missing, %d disabled; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumNotEnabled(),
+			vmDiskEnableUUIDKey,
+			set.NumMissing(),
+			set.NumDisabled(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs without %s enabled detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmDiskEnableUUIDKey,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMDiskUUIDReport generates a summary of VMs without the disk.EnableUUID
+// advanced setting enabled along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMDiskUUIDReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMDiskUUIDSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMDiskUUIDReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs without %s enabled:%s%s",
+		vmDiskEnableUUIDKey,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	switch {
+	case !set.IsWarningState():
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+	default:
+		for _, vdu := range set {
+			if vdu.IsEnabled() {
+				continue
+			}
+
+			setting := "missing"
+			if vdu.IsSet() {
+				setting = fmt.Sprintf("set to %q", vdu.Value)
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s, %s: %s)%s",
+				vdu.VMName,
+				vdu.PowerState,
+				vmDiskEnableUUIDKey,
+				setting,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
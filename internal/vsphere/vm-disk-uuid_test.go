@@ -0,0 +1,175 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func diskUUIDVM(name string, options ...types.BaseOptionValue) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			ExtraConfig: append(
+				[]types.BaseOptionValue{&types.OptionValue{Key: "svga.present", Value: "TRUE"}},
+				options...,
+			),
+		},
+	}
+}
+
+func TestNewVMDiskUUID(t *testing.T) {
+	noConfig := diskUUIDVM("vm1")
+	noConfig.Config = nil
+
+	tests := map[string]struct {
+		vm          mo.VirtualMachine
+		wantValue   string
+		wantSet     bool
+		wantEnabled bool
+	}{
+		"enabled": {
+			vm:          diskUUIDVM("vm1", &types.OptionValue{Key: "disk.EnableUUID", Value: "TRUE"}),
+			wantValue:   "TRUE",
+			wantSet:     true,
+			wantEnabled: true,
+		},
+		"enabled lowercase key and padded value": {
+			vm:          diskUUIDVM("vm1", &types.OptionValue{Key: "disk.enableuuid", Value: " true "}),
+			wantValue:   "true",
+			wantSet:     true,
+			wantEnabled: true,
+		},
+		"disabled": {
+			vm:        diskUUIDVM("vm1", &types.OptionValue{Key: "disk.EnableUUID", Value: "FALSE"}),
+			wantValue: "FALSE",
+			wantSet:   true,
+		},
+		"not a boolean": {
+			vm:        diskUUIDVM("vm1", &types.OptionValue{Key: "disk.EnableUUID", Value: "1"}),
+			wantValue: "1",
+			wantSet:   true,
+		},
+		"missing setting": {
+			vm: diskUUIDVM("vm1"),
+		},
+		"missing configuration": {
+			vm: noConfig,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMDiskUUID(tt.vm)
+
+			if got.Value != tt.wantValue {
+				t.Errorf("want value %q; got %q", tt.wantValue, got.Value)
+			}
+			if got.IsSet() != tt.wantSet {
+				t.Errorf("want set %t; got %t", tt.wantSet, got.IsSet())
+			}
+			if got.IsEnabled() != tt.wantEnabled {
+				t.Errorf("want enabled %t; got %t", tt.wantEnabled, got.IsEnabled())
+			}
+		})
+	}
+}
+
+func TestNewVMDiskUUIDSet(t *testing.T) {
+	enabled := &types.OptionValue{Key: "disk.EnableUUID", Value: "TRUE"}
+	disabled := &types.OptionValue{Key: "disk.EnableUUID", Value: "FALSE"}
+
+	tests := map[string]struct {
+		vms              []mo.VirtualMachine
+		wantEnabled      int
+		wantMissing      int
+		wantDisabled     int
+		wantWarningState bool
+	}{
+		"all enabled": {
+			vms:         []mo.VirtualMachine{diskUUIDVM("vm1", enabled), diskUUIDVM("vm2", enabled)},
+			wantEnabled: 2,
+		},
+		"missing setting": {
+			vms:              []mo.VirtualMachine{diskUUIDVM("vm1", enabled), diskUUIDVM("vm2")},
+			wantEnabled:      1,
+			wantMissing:      1,
+			wantWarningState: true,
+		},
+		"disabled setting": {
+			vms:              []mo.VirtualMachine{diskUUIDVM("vm1", disabled), diskUUIDVM("vm2", enabled)},
+			wantEnabled:      1,
+			wantDisabled:     1,
+			wantWarningState: true,
+		},
+		"no VMs": {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMDiskUUIDSet(tt.vms)
+
+			if got := set.NumEnabled(); got != tt.wantEnabled {
+				t.Errorf("want %d enabled; got %d", tt.wantEnabled, got)
+			}
+			if got := set.NumMissing(); got != tt.wantMissing {
+				t.Errorf("want %d missing; got %d", tt.wantMissing, got)
+			}
+			if got := set.NumDisabled(); got != tt.wantDisabled {
+				t.Errorf("want %d disabled; got %d", tt.wantDisabled, got)
+			}
+			if got := set.NumNotEnabled(); got != tt.wantMissing+tt.wantDisabled {
+				t.Errorf("want %d not enabled; got %d", tt.wantMissing+tt.wantDisabled, got)
+			}
+			if got := set.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
+
+func TestNewVMDiskUUIDSetSorted(t *testing.T) {
+	set := NewVMDiskUUIDSet([]mo.VirtualMachine{
+		diskUUIDVM("web1"),
+		diskUUIDVM("APP1"),
+		diskUUIDVM("db1"),
+	})
+
+	var got []string
+	for _, vdu := range set {
+		got = append(got, vdu.VMName)
+	}
+
+	if d := cmp.Diff([]string{"APP1", "db1", "web1"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestVMDiskUUIDPerfData(t *testing.T) {
+	set := VMDiskUUIDSet{
+		{VMName: "vm1", Value: "TRUE"},
+		{VMName: "vm2", Value: "FALSE"},
+		{VMName: "vm3"},
+		{VMName: "vm4"},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_disk_uuid_enabled", Value: "1", Min: "0"},
+		{Label: "vms_disk_uuid_missing", Value: "2", Min: "0"},
+		{Label: "vms_disk_uuid_disabled", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMDiskUUIDPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,473 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineGuestDiskUsageThresholdCrossed indicates that one or more
+// guest filesystems have crossed a usage threshold.
+var ErrVirtualMachineGuestDiskUsageThresholdCrossed = errors.New("guest filesystem usage exceeds specified threshold")
+
+// GuestDiskUsageThresholds represents the usage thresholds applied to guest
+// filesystems with a mount point matching the specified (case-insensitive)
+// glob pattern. An empty pattern matches all mount points.
+type GuestDiskUsageThresholds struct {
+	Pattern  string
+	Warning  int
+	Critical int
+}
+
+// VMGuestDisk represents a guest filesystem as reported by VMware Tools.
+type VMGuestDisk struct {
+	VMName         string
+	DiskPath       string
+	FilesystemType string
+	Capacity       int64
+	FreeSpace      int64
+	Thresholds     GuestDiskUsageThresholds
+}
+
+// VMGuestDisks is a collection of guest filesystems.
+type VMGuestDisks []VMGuestDisk
+
+// VMGuestDiskUsageSummary is the result of evaluating guest filesystem usage
+// for a collection of VirtualMachines.
+type VMGuestDiskUsageSummary struct {
+	// Disks is the collection of evaluated guest filesystems.
+	Disks VMGuestDisks
+
+	// NumExcluded is the number of guest filesystems excluded from
+	// evaluation due to matching a specified mount point pattern.
+	NumExcluded int
+
+	// VMsWithoutDiskInfo is the collection of VirtualMachine names for
+	// which guest filesystem details were not available (e.g., VMware Tools
+	// not running).
+	VMsWithoutDiskInfo []string
+}
+
+// guestMountMatches indicates whether the given guest filesystem mount point
+// matches the specified (case-insensitive) glob pattern. Backslashes (e.g.,
+// Windows drive letters) are normalized to forward slashes for matching.
+func guestMountMatches(mount string, pattern string) bool {
+	return ObjectNameMatches(
+		strings.ReplaceAll(mount, `\`, "/"),
+		strings.ReplaceAll(pattern, `\`, "/"),
+	)
+}
+
+// UsedPercent returns the percentage of the guest filesystem in use.
+func (gd VMGuestDisk) UsedPercent() float64 {
+	if gd.Capacity <= 0 {
+		return 0
+	}
+
+	return float64(gd.Capacity-gd.FreeSpace) / float64(gd.Capacity) * 100
+}
+
+// CapacityHR returns the capacity of the guest filesystem in human readable
+// format.
+func (gd VMGuestDisk) CapacityHR() string {
+	return units.ByteSize(gd.Capacity).String()
+}
+
+// FreeSpaceHR returns the free space of the guest filesystem in human
+// readable format.
+func (gd VMGuestDisk) FreeSpaceHR() string {
+	return units.ByteSize(gd.FreeSpace).String()
+}
+
+// IsCriticalState indicates whether the guest filesystem usage has crossed
+// the CRITICAL threshold.
+func (gd VMGuestDisk) IsCriticalState() bool {
+	return gd.UsedPercent() > float64(gd.Thresholds.Critical)
+}
+
+// IsWarningState indicates whether the guest filesystem usage has crossed
+// the WARNING threshold, but not the CRITICAL threshold.
+func (gd VMGuestDisk) IsWarningState() bool {
+	return !gd.IsCriticalState() &&
+		gd.UsedPercent() > float64(gd.Thresholds.Warning)
+}
+
+// NewVMGuestDiskUsageSummary evaluates the guest filesystems reported by
+// VMware Tools for the given VirtualMachines. Filesystems with mount points
+// matching any of the given exclusion patterns are skipped. The first
+// matching per-filesystem thresholds entry is applied, otherwise the default
+// thresholds are used.
+func NewVMGuestDiskUsageSummary(
+	vms []mo.VirtualMachine,
+	defaultThresholds GuestDiskUsageThresholds,
+	mountThresholds []GuestDiskUsageThresholds,
+	excludedMounts []string,
+) VMGuestDiskUsageSummary {
+
+	funcTimeStart := time.Now()
+
+	var summary VMGuestDiskUsageSummary
+
+	defer func(summary *VMGuestDiskUsageSummary) {
+		logger.Printf(
+			"It took %v to execute NewVMGuestDiskUsageSummary func (and evaluate %d guest filesystems).\n",
+			time.Since(funcTimeStart),
+			len(summary.Disks),
+		)
+	}(&summary)
+
+	for _, vm := range vms {
+		if vm.Guest == nil || len(vm.Guest.Disk) == 0 {
+			summary.VMsWithoutDiskInfo = append(summary.VMsWithoutDiskInfo, vm.Name)
+
+			continue
+		}
+
+	disks:
+		for _, disk := range vm.Guest.Disk {
+			for _, pattern := range excludedMounts {
+				if guestMountMatches(disk.DiskPath, pattern) {
+					summary.NumExcluded++
+
+					continue disks
+				}
+			}
+
+			thresholds := defaultThresholds
+			for _, t := range mountThresholds {
+				if guestMountMatches(disk.DiskPath, t.Pattern) {
+					thresholds = t
+
+					break
+				}
+			}
+
+			summary.Disks = append(summary.Disks, newVMGuestDisk(vm.Name, disk, thresholds))
+		}
+	}
+
+	sort.Slice(summary.Disks, func(i, j int) bool {
+		return summary.Disks[i].UsedPercent() > summary.Disks[j].UsedPercent()
+	})
+
+	sort.Slice(summary.VMsWithoutDiskInfo, func(i, j int) bool {
+		return strings.ToLower(summary.VMsWithoutDiskInfo[i]) <
+			strings.ToLower(summary.VMsWithoutDiskInfo[j])
+	})
+
+	return summary
+}
+
+// newVMGuestDisk converts the given guest disk details into a VMGuestDisk
+// value.
+func newVMGuestDisk(vmName string, disk types.GuestDiskInfo, thresholds GuestDiskUsageThresholds) VMGuestDisk {
+	return VMGuestDisk{
+		VMName:         vmName,
+		DiskPath:       disk.DiskPath,
+		FilesystemType: disk.FilesystemType,
+		Capacity:       disk.Capacity,
+		FreeSpace:      disk.FreeSpace,
+		Thresholds:     thresholds,
+	}
+}
+
+// NumCritical returns the number of guest filesystems which have crossed
+// the CRITICAL threshold.
+func (vgds VMGuestDiskUsageSummary) NumCritical() int {
+	var num int
+	for _, disk := range vgds.Disks {
+		if disk.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of guest filesystems which have crossed the
+// WARNING threshold, but not the CRITICAL threshold.
+func (vgds VMGuestDiskUsageSummary) NumWarning() int {
+	var num int
+	for _, disk := range vgds.Disks {
+		if disk.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumVMs returns the number of VirtualMachines with evaluated guest
+// filesystems.
+func (vgds VMGuestDiskUsageSummary) NumVMs() int {
+	vms := make(map[string]struct{})
+	for _, disk := range vgds.Disks {
+		vms[disk.VMName] = struct{}{}
+	}
+
+	return len(vms)
+}
+
+// MaxUsedPercent returns the highest usage percentage of all evaluated
+// guest filesystems.
+func (vgds VMGuestDiskUsageSummary) MaxUsedPercent() float64 {
+	var highest float64
+	for _, disk := range vgds.Disks {
+		if used := disk.UsedPercent(); used > highest {
+			highest = used
+		}
+	}
+
+	return highest
+}
+
+// IsCriticalState indicates whether any guest filesystem has crossed the
+// CRITICAL threshold.
+func (vgds VMGuestDiskUsageSummary) IsCriticalState() bool {
+	return vgds.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any guest filesystem has crossed the
+// WARNING threshold.
+func (vgds VMGuestDiskUsageSummary) IsWarningState() bool {
+	return vgds.NumWarning() > 0
+}
+
+// VMGuestDiskUsagePerfData generates performance data metrics from the given
+// evaluation results.
+func VMGuestDiskUsagePerfData(
+	summary VMGuestDiskUsageSummary,
+	defaultThresholds GuestDiskUsageThresholds,
+) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_guest_disk_info",
+			Value: fmt.Sprintf("%d", summary.NumVMs()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_without_guest_disk_info",
+			Value: fmt.Sprintf("%d", len(summary.VMsWithoutDiskInfo)),
+			Min:   "0",
+		},
+		{
+			Label: "filesystems_evaluated",
+			Value: fmt.Sprintf("%d", len(summary.Disks)),
+			Min:   "0",
+		},
+		{
+			Label: "filesystems_excluded",
+			Value: fmt.Sprintf("%d", summary.NumExcluded),
+			Min:   "0",
+		},
+		{
+			Label: "filesystems_critical",
+			Value: fmt.Sprintf("%d", summary.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "filesystems_warning",
+			Value: fmt.Sprintf("%d", summary.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label:             "filesystem_usage_max",
+			Value:             fmt.Sprintf("%.2f", summary.MaxUsedPercent()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", defaultThresholds.Warning),
+			Crit:              fmt.Sprintf("%d", defaultThresholds.Critical),
+			Min:               "0",
+			Max:               "100",
+		},
+	}
+}
+
+// VMGuestDiskUsageOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMGuestDiskUsageOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	summary VMGuestDiskUsageSummary,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"summary":          summary,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMGuestDiskUsageOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case summary.IsCriticalState() || summary.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d guest filesystems exceed usage thresholds (%d CRITICAL, %d WARNING; evaluated %d filesystems, %d VMs, %d Resource Pools)",
+			stateLabel,
+			summary.NumCritical()+summary.NumWarning(),
+			summary.NumCritical(),
+			summary.NumWarning(),
+			len(summary.Disks),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No guest filesystems exceed usage thresholds (evaluated %d filesystems, %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(summary.Disks),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMGuestDiskUsageReport generates a summary of guest filesystems exceeding
+// usage thresholds along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMGuestDiskUsageReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	summary VMGuestDiskUsageSummary,
+	mountThresholds []GuestDiskUsageThresholds,
+	excludedMounts []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMGuestDiskUsageReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"Guest filesystems exceeding usage thresholds:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numListed int
+	for _, disk := range summary.Disks {
+		var state string
+		switch {
+		case disk.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case disk.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			continue
+		}
+
+		numListed++
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s: %s (%s) [%s] %.2f%% used, %s free of %s (thresholds: %d%% WARNING, %d%% CRITICAL)%s",
+			disk.VMName,
+			disk.DiskPath,
+			disk.FilesystemType,
+			state,
+			disk.UsedPercent(),
+			disk.FreeSpaceHR(),
+			disk.CapacityHR(),
+			disk.Thresholds.Warning,
+			disk.Thresholds.Critical,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numListed == 0 {
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+	}
+
+	if len(summary.VMsWithoutDiskInfo) > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sVMs without guest filesystem details (e.g., VMware Tools not running):%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, vmName := range summary.VMsWithoutDiskInfo {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s%s",
+				vmName,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	mountThresholdEntries := make([]string, 0, len(mountThresholds))
+	for _, t := range mountThresholds {
+		mountThresholdEntries = append(
+			mountThresholdEntries,
+			fmt.Sprintf("%s:%d:%d", t.Pattern, t.Warning, t.Critical),
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified per-filesystem thresholds (%d): [%v]%s",
+		len(mountThresholdEntries),
+		strings.Join(mountThresholdEntries, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified mount points to exclude (%d): [%v]%s",
+		len(excludedMounts),
+		strings.Join(excludedMounts, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Guest filesystems excluded: %d%s",
+		summary.NumExcluded,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
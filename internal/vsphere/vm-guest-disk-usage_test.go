@@ -0,0 +1,275 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var guestDiskDefaultThresholds = GuestDiskUsageThresholds{
+	Warning:  80,
+	Critical: 90,
+}
+
+func guestDisk(path string, usedPercent int64) types.GuestDiskInfo {
+	return types.GuestDiskInfo{
+		DiskPath:       path,
+		FilesystemType: "ext4",
+		Capacity:       100,
+		FreeSpace:      100 - usedPercent,
+	}
+}
+
+func guestDiskVM(name string, disks ...types.GuestDiskInfo) mo.VirtualMachine {
+	vm := mo.VirtualMachine{ManagedEntity: mo.ManagedEntity{Name: name}}
+
+	if len(disks) > 0 {
+		vm.Guest = &types.GuestInfo{Disk: disks}
+	}
+
+	return vm
+}
+
+func TestGuestMountMatches(t *testing.T) {
+	tests := map[string]struct {
+		mount   string
+		pattern string
+		want    bool
+	}{
+		"exact":                     {mount: "/var", pattern: "/var", want: true},
+		"glob":                      {mount: "/data01", pattern: "/data*", want: true},
+		"glob does not cross slash": {mount: "/data/logs", pattern: "/data*"},
+		"empty pattern":             {mount: "/var", pattern: "", want: true},
+		"drive letter":              {mount: `C:\`, pattern: `c:\`, want: true},
+		"drive letter glob":         {mount: `D:\Logs`, pattern: `d:\*`, want: true},
+		"different mount":           {mount: "/var", pattern: "/boot"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := guestMountMatches(tt.mount, tt.pattern); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVMGuestDiskState(t *testing.T) {
+	tests := map[string]struct {
+		disk            VMGuestDisk
+		wantUsedPercent float64
+		wantCritical    bool
+		wantWarning     bool
+	}{
+		"below thresholds": {
+			disk:            VMGuestDisk{Capacity: 200, FreeSpace: 100},
+			wantUsedPercent: 50,
+		},
+		"equal to warning threshold": {
+			disk:            VMGuestDisk{Capacity: 200, FreeSpace: 40},
+			wantUsedPercent: 80,
+		},
+		"above warning threshold": {
+			disk:            VMGuestDisk{Capacity: 200, FreeSpace: 30},
+			wantUsedPercent: 85,
+			wantWarning:     true,
+		},
+		"above critical threshold": {
+			disk:            VMGuestDisk{Capacity: 200, FreeSpace: 10},
+			wantUsedPercent: 95,
+			wantCritical:    true,
+		},
+		"zero capacity": {
+			disk: VMGuestDisk{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tt.disk.Thresholds = guestDiskDefaultThresholds
+
+			if got := tt.disk.UsedPercent(); math.Abs(got-tt.wantUsedPercent) > 0.0001 {
+				t.Errorf("want %.2f%% used; got %.2f%%", tt.wantUsedPercent, got)
+			}
+			if got := tt.disk.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := tt.disk.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestNewVMGuestDiskUsageSummary(t *testing.T) {
+	tests := map[string]struct {
+		vms             []mo.VirtualMachine
+		mountThresholds []GuestDiskUsageThresholds
+		excludedMounts  []string
+		wantCritical    int
+		wantWarning     int
+		wantEvaluated   int
+		wantExcluded    int
+		wantWithoutInfo []string
+	}{
+		"below thresholds": {
+			vms:           []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/", 50), guestDisk("/var", 70))},
+			wantEvaluated: 2,
+		},
+		"above warning threshold": {
+			vms:           []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/", 50), guestDisk("/var", 85))},
+			wantWarning:   1,
+			wantEvaluated: 2,
+		},
+		"above critical threshold": {
+			vms:           []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/", 95))},
+			wantCritical:  1,
+			wantEvaluated: 1,
+		},
+		"excluded mount is not evaluated": {
+			vms:            []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/", 50), guestDisk("/boot", 95))},
+			excludedMounts: []string{"/boot"},
+			wantEvaluated:  1,
+			wantExcluded:   1,
+		},
+		"drive letter matches per-mount threshold": {
+			vms: []mo.VirtualMachine{guestDiskVM("vm1", guestDisk(`C:\`, 85))},
+			mountThresholds: []GuestDiskUsageThresholds{
+				{Pattern: `c:\`, Warning: 90, Critical: 95},
+			},
+			wantEvaluated: 1,
+		},
+		"per-mount threshold lower than default": {
+			vms: []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/data", 60))},
+			mountThresholds: []GuestDiskUsageThresholds{
+				{Pattern: "/data*", Warning: 40, Critical: 50},
+			},
+			wantCritical:  1,
+			wantEvaluated: 1,
+		},
+		"first matching per-mount threshold applied": {
+			vms: []mo.VirtualMachine{guestDiskVM("vm1", guestDisk("/data", 60))},
+			mountThresholds: []GuestDiskUsageThresholds{
+				{Pattern: "/data", Warning: 55, Critical: 70},
+				{Pattern: "/data*", Warning: 40, Critical: 50},
+			},
+			wantWarning:   1,
+			wantEvaluated: 1,
+		},
+		"VMs without guest filesystem details": {
+			vms: []mo.VirtualMachine{
+				guestDiskVM("vm1", guestDisk("/", 50)),
+				guestDiskVM("vm3"),
+				guestDiskVM("VM2"),
+			},
+			wantEvaluated:   1,
+			wantWithoutInfo: []string{"VM2", "vm3"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			summary := NewVMGuestDiskUsageSummary(
+				tt.vms,
+				guestDiskDefaultThresholds,
+				tt.mountThresholds,
+				tt.excludedMounts,
+			)
+
+			if got := summary.NumCritical(); got != tt.wantCritical {
+				t.Errorf("want %d critical; got %d", tt.wantCritical, got)
+			}
+			if got := summary.NumWarning(); got != tt.wantWarning {
+				t.Errorf("want %d warning; got %d", tt.wantWarning, got)
+			}
+			if got := summary.IsCriticalState(); got != (tt.wantCritical > 0) {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical > 0, got)
+			}
+			if got := summary.IsWarningState(); got != (tt.wantWarning > 0) {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning > 0, got)
+			}
+			if got := len(summary.Disks); got != tt.wantEvaluated {
+				t.Errorf("want %d evaluated filesystems; got %d", tt.wantEvaluated, got)
+			}
+			if summary.NumExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded filesystems; got %d", tt.wantExcluded, summary.NumExcluded)
+			}
+			if d := cmp.Diff(tt.wantWithoutInfo, summary.VMsWithoutDiskInfo); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMGuestDiskUsageSummarySorted(t *testing.T) {
+	summary := NewVMGuestDiskUsageSummary(
+		[]mo.VirtualMachine{
+			guestDiskVM("vm1", guestDisk("/", 20), guestDisk("/var", 70)),
+			guestDiskVM("vm2", guestDisk("/", 40)),
+		},
+		guestDiskDefaultThresholds,
+		nil,
+		nil,
+	)
+
+	var got []string
+	for _, disk := range summary.Disks {
+		got = append(got, disk.VMName+":"+disk.DiskPath)
+	}
+
+	if d := cmp.Diff([]string{"vm1:/var", "vm2:/", "vm1:/"}, got); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got := summary.NumVMs(); got != 2 {
+		t.Errorf("want 2 VMs; got %d", got)
+	}
+	if got := summary.MaxUsedPercent(); math.Abs(got-70) > 0.0001 {
+		t.Errorf("want max usage 70%%; got %.2f%%", got)
+	}
+}
+
+func TestVMGuestDiskUsagePerfData(t *testing.T) {
+	summary := NewVMGuestDiskUsageSummary(
+		[]mo.VirtualMachine{
+			guestDiskVM("vm1", guestDisk("/", 95), guestDisk("/var", 85), guestDisk("/boot", 99)),
+			guestDiskVM("vm2"),
+		},
+		guestDiskDefaultThresholds,
+		nil,
+		[]string{"/boot"},
+	)
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_guest_disk_info", Value: "1", Min: "0"},
+		{Label: "vms_without_guest_disk_info", Value: "1", Min: "0"},
+		{Label: "filesystems_evaluated", Value: "2", Min: "0"},
+		{Label: "filesystems_excluded", Value: "1", Min: "0"},
+		{Label: "filesystems_critical", Value: "1", Min: "0"},
+		{Label: "filesystems_warning", Value: "1", Min: "0"},
+		{
+			Label:             "filesystem_usage_max",
+			Value:             "95.00",
+			UnitOfMeasurement: "%",
+			Warn:              "80",
+			Crit:              "90",
+			Min:               "0",
+			Max:               "100",
+		},
+	}
+
+	if d := cmp.Diff(want, VMGuestDiskUsagePerfData(summary, guestDiskDefaultThresholds)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
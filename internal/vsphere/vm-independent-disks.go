@@ -0,0 +1,401 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrVirtualMachineIndependentDisksFound indicates that one or more
+// VirtualMachines have independent disks which are not explicitly allowed.
+var ErrVirtualMachineIndependentDisksFound = errors.New("virtual machines with independent disks found")
+
+// VMIndependentDisk represents a virtual disk configured with an independent
+// (persistent or nonpersistent) disk mode.
+type VMIndependentDisk struct {
+
+	// Label is the device label (e.g., Hard disk 2).
+	Label string
+
+	// FileName is the path to the backing VMDK file.
+	FileName string
+
+	// DiskMode is the disk mode (e.g., independent_persistent).
+	DiskMode string
+}
+
+// VMIndependentDisks represents a VirtualMachine with one or more disks
+// configured with an independent disk mode. Independent disks are excluded
+// from snapshots and are therefore skipped by most backup tools.
+type VMIndependentDisks struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// Disks is the collection of independent disks for the VirtualMachine.
+	Disks []VMIndependentDisk
+
+	// Allowed indicates whether the VirtualMachine is permitted to have
+	// independent disks.
+	Allowed bool
+}
+
+// VMIndependentDisksSet is a collection of VMIndependentDisks values.
+type VMIndependentDisksSet []VMIndependentDisks
+
+// virtualDiskMode returns the disk mode and backing file name for the given
+// virtual disk. An empty disk mode is returned for backing types which do
+// not provide a disk mode.
+func virtualDiskMode(disk *types.VirtualDisk) (string, string) {
+	switch backing := disk.Backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskSeSparseBackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskFlatVer1BackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskSparseVer1BackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	case *types.VirtualDiskLocalPMemBackingInfo:
+		return backing.DiskMode, backing.FileName
+
+	default:
+		return "", ""
+	}
+}
+
+// isIndependentDiskMode indicates whether the given disk mode is an
+// independent (persistent or nonpersistent) disk mode.
+func isIndependentDiskMode(diskMode string) bool {
+	switch types.VirtualDiskMode(diskMode) {
+	case types.VirtualDiskModeIndependent_persistent,
+		types.VirtualDiskModeIndependent_nonpersistent:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// NewVMIndependentDisks evaluates the virtual disks for the given
+// VirtualMachine. Independent disks are considered allowed if the
+// VirtualMachine name is in the given list of allowed VM names
+// (case-insensitive).
+func NewVMIndependentDisks(vm mo.VirtualMachine, allowedVMs []string) VMIndependentDisks {
+
+	vid := VMIndependentDisks{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+		Allowed:    textutils.InList(vm.Name, allowedVMs, true),
+	}
+
+	if vm.Config == nil {
+		return vid
+	}
+
+	for _, device := range vm.Config.Hardware.Device {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+
+		diskMode, fileName := virtualDiskMode(disk)
+		if !isIndependentDiskMode(diskMode) {
+			continue
+		}
+
+		var label string
+		if info := disk.GetVirtualDevice().DeviceInfo; info != nil {
+			label = info.GetDescription().Label
+		}
+
+		vid.Disks = append(vid.Disks, VMIndependentDisk{
+			Label:    label,
+			FileName: fileName,
+			DiskMode: diskMode,
+		})
+	}
+
+	return vid
+}
+
+// NewVMIndependentDisksSet evaluates the given VirtualMachines and returns
+// those with independent disks.
+func NewVMIndependentDisksSet(vms []mo.VirtualMachine, allowedVMs []string) VMIndependentDisksSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMIndependentDisksSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMIndependentDisksSet, 0, len(vms))
+	for _, vm := range vms {
+		vid := NewVMIndependentDisks(vm, allowedVMs)
+		if len(vid.Disks) == 0 {
+			continue
+		}
+
+		set = append(set, vid)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumDisallowed returns the number of VirtualMachines with independent disks
+// which are not explicitly allowed.
+func (set VMIndependentDisksSet) NumDisallowed() int {
+	var num int
+	for _, vid := range set {
+		if !vid.Allowed {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumAllowed returns the number of VirtualMachines with independent disks
+// which are explicitly allowed.
+func (set VMIndependentDisksSet) NumAllowed() int {
+	return len(set) - set.NumDisallowed()
+}
+
+// numDisksByMode returns the number of independent disks with the given disk
+// mode for VirtualMachines which are not explicitly allowed.
+func (set VMIndependentDisksSet) numDisksByMode(diskMode types.VirtualDiskMode) int {
+	var num int
+	for _, vid := range set {
+		if vid.Allowed {
+			continue
+		}
+
+		for _, disk := range vid.Disks {
+			if disk.DiskMode == string(diskMode) {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumIndependentPersistent returns the number of independent-persistent
+// disks for VirtualMachines which are not explicitly allowed.
+func (set VMIndependentDisksSet) NumIndependentPersistent() int {
+	return set.numDisksByMode(types.VirtualDiskModeIndependent_persistent)
+}
+
+// NumIndependentNonPersistent returns the number of independent-nonpersistent
+// disks for VirtualMachines which are not explicitly allowed.
+func (set VMIndependentDisksSet) NumIndependentNonPersistent() int {
+	return set.numDisksByMode(types.VirtualDiskModeIndependent_nonpersistent)
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has
+// independent disks which are not explicitly allowed.
+func (set VMIndependentDisksSet) IsWarningState() bool {
+	return set.NumDisallowed() > 0
+}
+
+// VMIndependentDisksPerfData generates performance data metrics from the
+// given evaluation results.
+func VMIndependentDisksPerfData(set VMIndependentDisksSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_independent_disks",
+			Value: fmt.Sprintf("%d", set.NumDisallowed()),
+			Min:   "0",
+		},
+		{
+			Label: "independent_persistent_disks",
+			Value: fmt.Sprintf("%d", set.NumIndependentPersistent()),
+			Min:   "0",
+		},
+		{
+			Label: "independent_nonpersistent_disks",
+			Value: fmt.Sprintf("%d", set.NumIndependentNonPersistent()),
+			Min:   "0",
+		},
+		{
+			Label: "allowed_vms_with_independent_disks",
+			Value: fmt.Sprintf("%d", set.NumAllowed()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMIndependentDisksOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VMIndependentDisksOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMIndependentDisksSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMIndependentDisksOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with independent disks detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumDisallowed(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with independent disks detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMIndependentDisksReport generates a summary of VMs with independent disks
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VMIndependentDisksReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMIndependentDisksSet,
+	allowedVMs []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMIndependentDisksReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMs := func(allowed bool) {
+		var found bool
+		for _, vid := range set {
+			if vid.Allowed != allowed {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s)%s",
+				vid.VMName,
+				vid.PowerState,
+				nagios.CheckOutputEOL,
+			)
+
+			for _, disk := range vid.Disks {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s: %s [%s]%s",
+					disk.Label,
+					disk.DiskMode,
+					disk.FileName,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with independent disks (excluded from snapshots and most backups):%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(false)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs with allowed independent disks:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(true)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs permitted to have independent disks (%d): [%v]%s",
+		len(allowedVMs),
+		strings.Join(allowedVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func independentDisk(label string, backing types.BaseVirtualDeviceBackingInfo) types.BaseVirtualDevice {
+	return &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			DeviceInfo: &types.Description{Label: label},
+			Backing:    backing,
+		},
+	}
+}
+
+func independentDiskVM(name string, devices ...types.BaseVirtualDevice) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{Device: devices},
+		},
+		Runtime: types.VirtualMachineRuntimeInfo{
+			PowerState: types.VirtualMachinePowerStatePoweredOn,
+		},
+	}
+}
+
+func flatDiskBacking(fileName string, mode types.VirtualDiskMode) *types.VirtualDiskFlatVer2BackingInfo {
+	return &types.VirtualDiskFlatVer2BackingInfo{
+		VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: fileName},
+		DiskMode:                     string(mode),
+	}
+}
+
+func TestIsIndependentDiskMode(t *testing.T) {
+	tests := map[string]struct {
+		diskMode string
+		want     bool
+	}{
+		"persistent":                {diskMode: string(types.VirtualDiskModePersistent)},
+		"nonpersistent":             {diskMode: string(types.VirtualDiskModeNonpersistent)},
+		"independent persistent":    {diskMode: string(types.VirtualDiskModeIndependent_persistent), want: true},
+		"independent nonpersistent": {diskMode: string(types.VirtualDiskModeIndependent_nonpersistent), want: true},
+		"empty":                     {},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isIndependentDiskMode(tt.diskMode); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMIndependentDisks(t *testing.T) {
+	tests := map[string]struct {
+		vm          mo.VirtualMachine
+		allowedVMs  []string
+		wantDisks   []VMIndependentDisk
+		wantAllowed bool
+	}{
+		"persistent disks only": {
+			vm: independentDiskVM("app1",
+				independentDisk("Hard disk 1", flatDiskBacking("[ds1] app1/app1.vmdk", types.VirtualDiskModePersistent)),
+			),
+		},
+		"independent flat and rdm disks": {
+			vm: independentDiskVM("app1",
+				independentDisk("Hard disk 1", flatDiskBacking("[ds1] app1/app1.vmdk", types.VirtualDiskModePersistent)),
+				independentDisk("Hard disk 2", flatDiskBacking("[ds1] app1/app1_1.vmdk", types.VirtualDiskModeIndependent_persistent)),
+				independentDisk("Hard disk 3", &types.VirtualDiskRawDiskMappingVer1BackingInfo{
+					VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: "[ds1] app1/app1_2.vmdk"},
+					DiskMode:                     string(types.VirtualDiskModeIndependent_nonpersistent),
+				}),
+			),
+			wantDisks: []VMIndependentDisk{
+				{Label: "Hard disk 2", FileName: "[ds1] app1/app1_1.vmdk", DiskMode: string(types.VirtualDiskModeIndependent_persistent)},
+				{Label: "Hard disk 3", FileName: "[ds1] app1/app1_2.vmdk", DiskMode: string(types.VirtualDiskModeIndependent_nonpersistent)},
+			},
+		},
+		"non-disk devices and unknown backing ignored": {
+			vm: independentDiskVM("app1",
+				&types.VirtualCdrom{},
+				independentDisk("Hard disk 1", &types.VirtualDiskPartitionedRawDiskVer2BackingInfo{}),
+			),
+		},
+		"allowed case-insensitive": {
+			vm: independentDiskVM("app1",
+				independentDisk("Hard disk 1", flatDiskBacking("[ds1] app1/app1.vmdk", types.VirtualDiskModeIndependent_persistent)),
+			),
+			allowedVMs: []string{"APP1"},
+			wantDisks: []VMIndependentDisk{
+				{Label: "Hard disk 1", FileName: "[ds1] app1/app1.vmdk", DiskMode: string(types.VirtualDiskModeIndependent_persistent)},
+			},
+			wantAllowed: true,
+		},
+		"missing config": {
+			vm: mo.VirtualMachine{ManagedEntity: mo.ManagedEntity{Name: "app1"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMIndependentDisks(tt.vm, tt.allowedVMs)
+
+			if d := cmp.Diff(tt.wantDisks, got.Disks); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+			if got.Allowed != tt.wantAllowed {
+				t.Errorf("want allowed %t; got %t", tt.wantAllowed, got.Allowed)
+			}
+			if got.VMName != "app1" {
+				t.Errorf("want VM name app1; got %s", got.VMName)
+			}
+		})
+	}
+}
+
+func TestNewVMIndependentDisksSet(t *testing.T) {
+	vms := []mo.VirtualMachine{
+		independentDiskVM("web1",
+			independentDisk("Hard disk 1", flatDiskBacking("[ds1] web1/web1.vmdk", types.VirtualDiskModePersistent)),
+		),
+		independentDiskVM("DB1",
+			independentDisk("Hard disk 1", flatDiskBacking("[ds1] db1/db1.vmdk", types.VirtualDiskModeIndependent_persistent)),
+			independentDisk("Hard disk 2", flatDiskBacking("[ds1] db1/db1_1.vmdk", types.VirtualDiskModeIndependent_nonpersistent)),
+		),
+		independentDiskVM("app1",
+			independentDisk("Hard disk 1", flatDiskBacking("[ds1] app1/app1.vmdk", types.VirtualDiskModeIndependent_persistent)),
+		),
+	}
+
+	tests := map[string]struct {
+		allowedVMs        []string
+		wantDisallowed    int
+		wantAllowed       int
+		wantPersistent    int
+		wantNonPersistent int
+		wantWarningState  bool
+	}{
+		"nothing allowed": {
+			wantDisallowed:    2,
+			wantPersistent:    2,
+			wantNonPersistent: 1,
+			wantWarningState:  true,
+		},
+		"one VM allowed": {
+			allowedVMs:        []string{"db1"},
+			wantDisallowed:    1,
+			wantAllowed:       1,
+			wantPersistent:    1,
+			wantNonPersistent: 0,
+			wantWarningState:  true,
+		},
+		"all VMs allowed": {
+			allowedVMs:  []string{"app1", "db1"},
+			wantAllowed: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMIndependentDisksSet(vms, tt.allowedVMs)
+
+			if len(set) != 2 || set[0].VMName != "app1" || set[1].VMName != "DB1" {
+				t.Fatalf("want VMs [app1 DB1]; got %+v", set)
+			}
+
+			if got := set.NumDisallowed(); got != tt.wantDisallowed {
+				t.Errorf("want %d disallowed; got %d", tt.wantDisallowed, got)
+			}
+			if got := set.NumAllowed(); got != tt.wantAllowed {
+				t.Errorf("want %d allowed; got %d", tt.wantAllowed, got)
+			}
+			if got := set.NumIndependentPersistent(); got != tt.wantPersistent {
+				t.Errorf("want %d independent persistent; got %d", tt.wantPersistent, got)
+			}
+			if got := set.NumIndependentNonPersistent(); got != tt.wantNonPersistent {
+				t.Errorf("want %d independent nonpersistent; got %d", tt.wantNonPersistent, got)
+			}
+			if got := set.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
+
+func TestVMIndependentDisksPerfData(t *testing.T) {
+	set := VMIndependentDisksSet{
+		{
+			VMName: "db1",
+			Disks: []VMIndependentDisk{
+				{DiskMode: string(types.VirtualDiskModeIndependent_persistent)},
+				{DiskMode: string(types.VirtualDiskModeIndependent_nonpersistent)},
+			},
+		},
+		{
+			VMName:  "app1",
+			Disks:   []VMIndependentDisk{{DiskMode: string(types.VirtualDiskModeIndependent_persistent)}},
+			Allowed: true,
+		},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_independent_disks", Value: "1", Min: "0"},
+		{Label: "independent_persistent_disks", Value: "1", Min: "0"},
+		{Label: "independent_nonpersistent_disks", Value: "1", Min: "0"},
+		{Label: "allowed_vms_with_independent_disks", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMIndependentDisksPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,604 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrVMMemoryAllocationThresholdCrossed indicates that specified VM memory
+// allocation has exceeded a given threshold.
+var ErrVMMemoryAllocationThresholdCrossed = errors.New("VM memory allocation exceeds specified threshold")
+
+// ErrVMMemoryOvercommitRatioThresholdCrossed indicates that the ratio of
+// allocated VM memory to physical memory for one or more clusters or
+// standalone hosts has exceeded a given threshold.
+var ErrVMMemoryOvercommitRatioThresholdCrossed = errors.New("memory overcommit ratio exceeds specified threshold")
+
+// MemoryOvercommitRatioThresholds represents the memory overcommit ratio
+// (allocated to physical) thresholds used to determine whether a cluster or
+// standalone host is considered to be in a CRITICAL or WARNING state.
+type MemoryOvercommitRatioThresholds struct {
+	Critical float64
+	Warning  float64
+}
+
+// ComputeResourceMemoryAllocation tracks allocated VM memory and physical
+// memory for a specific cluster or standalone host.
+type ComputeResourceMemoryAllocation struct {
+
+	// Name is the name of the cluster or standalone host.
+	Name string
+
+	// Standalone indicates whether this is a standalone host (i.e., not a
+	// member of a cluster).
+	Standalone bool
+
+	// MemoryAllocated is the amount of memory in bytes allocated to
+	// evaluated VMs running on the cluster or standalone host.
+	MemoryAllocated int64
+
+	// PhysicalMemory is the amount of physical memory in bytes provided by
+	// the cluster or standalone host.
+	PhysicalMemory int64
+
+	// NumVMs is the number of evaluated VMs running on the cluster or
+	// standalone host.
+	NumVMs int
+
+	// Thresholds are the memory overcommit ratio thresholds used to evaluate
+	// the cluster or standalone host.
+	Thresholds MemoryOvercommitRatioThresholds
+}
+
+// ComputeResourceMemoryAllocationSet is a collection of per-cluster (or
+// standalone host) memory allocation summaries.
+type ComputeResourceMemoryAllocationSet []ComputeResourceMemoryAllocation
+
+// VMMemoryAllocated returns the amount of memory in bytes allocated to the
+// given VirtualMachines.
+func VMMemoryAllocated(vms []mo.VirtualMachine) int64 {
+	var allocated int64
+	for _, vm := range vms {
+		allocated += int64(vm.Summary.Config.MemorySizeMB) * units.MB
+	}
+
+	return allocated
+}
+
+// NewComputeResourceMemoryAllocationSet receives a collection of clusters,
+// hosts and the evaluated VMs and tallies allocated VM memory per cluster
+// (or standalone host) based on the current host of each VM. Only clusters
+// and standalone hosts with at least one evaluated VM are included. The
+// number of evaluated VMs without a known current host is also returned.
+func NewComputeResourceMemoryAllocationSet(
+	clusters []mo.ClusterComputeResource,
+	hosts []mo.HostSystem,
+	vms []mo.VirtualMachine,
+	thresholds MemoryOvercommitRatioThresholds,
+) (ComputeResourceMemoryAllocationSet, int) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewComputeResourceMemoryAllocationSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	// Index cluster (or standalone host) membership by host ID.
+	hostIndex := make(map[string]int, len(hosts))
+	allocations := make([]ComputeResourceMemoryAllocation, 0, len(clusters)+len(hosts))
+	for _, cluster := range clusters {
+		allocation := ComputeResourceMemoryAllocation{
+			Name:       cluster.Name,
+			Thresholds: thresholds,
+		}
+
+		if cluster.Summary != nil {
+			allocation.PhysicalMemory = cluster.Summary.GetComputeResourceSummary().TotalMemory
+		}
+
+		for _, host := range cluster.Host {
+			hostIndex[host.Value] = len(allocations)
+		}
+
+		allocations = append(allocations, allocation)
+	}
+
+	for _, host := range hosts {
+		if _, ok := hostIndex[host.Self.Value]; ok {
+			continue
+		}
+
+		allocation := ComputeResourceMemoryAllocation{
+			Name:       host.Name,
+			Standalone: true,
+			Thresholds: thresholds,
+		}
+
+		if host.Summary.Hardware != nil {
+			allocation.PhysicalMemory = host.Summary.Hardware.MemorySize
+		}
+
+		hostIndex[host.Self.Value] = len(allocations)
+		allocations = append(allocations, allocation)
+	}
+
+	var numVMsUnknownHost int
+	for _, vm := range vms {
+		if vm.Summary.Runtime.Host == nil {
+			numVMsUnknownHost++
+
+			continue
+		}
+
+		i, ok := hostIndex[vm.Summary.Runtime.Host.Value]
+		if !ok {
+			numVMsUnknownHost++
+
+			continue
+		}
+
+		allocations[i].MemoryAllocated += int64(vm.Summary.Config.MemorySizeMB) * units.MB
+		allocations[i].NumVMs++
+	}
+
+	set := make(ComputeResourceMemoryAllocationSet, 0, len(allocations))
+	for _, allocation := range allocations {
+		if allocation.NumVMs == 0 {
+			continue
+		}
+
+		set = append(set, allocation)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].Name) < strings.ToLower(set[j].Name)
+	})
+
+	return set, numVMsUnknownHost
+
+}
+
+// Ratio returns the memory overcommit ratio (allocated to physical) for the
+// cluster or standalone host. Zero is returned if the amount of physical
+// memory is unknown.
+func (crma ComputeResourceMemoryAllocation) Ratio() float64 {
+	if crma.PhysicalMemory == 0 {
+		return 0
+	}
+
+	return float64(crma.MemoryAllocated) / float64(crma.PhysicalMemory)
+}
+
+// IsCriticalState indicates whether the memory overcommit ratio for the
+// cluster or standalone host has crossed the CRITICAL threshold.
+func (crma ComputeResourceMemoryAllocation) IsCriticalState() bool {
+	return crma.Ratio() > crma.Thresholds.Critical
+}
+
+// IsWarningState indicates whether the memory overcommit ratio for the
+// cluster or standalone host has crossed the WARNING threshold, but NOT the
+// CRITICAL threshold.
+func (crma ComputeResourceMemoryAllocation) IsWarningState() bool {
+	return !crma.IsCriticalState() && crma.Ratio() > crma.Thresholds.Warning
+}
+
+// NumCritical returns the number of clusters or standalone hosts in a
+// CRITICAL state.
+func (set ComputeResourceMemoryAllocationSet) NumCritical() int {
+	var num int
+	for _, crma := range set {
+		if crma.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of clusters or standalone hosts in a WARNING
+// state.
+func (set ComputeResourceMemoryAllocationSet) NumWarning() int {
+	var num int
+	for _, crma := range set {
+		if crma.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any cluster or standalone host is in a
+// CRITICAL state.
+func (set ComputeResourceMemoryAllocationSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any cluster or standalone host is in a
+// WARNING state.
+func (set ComputeResourceMemoryAllocationSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// Highest returns the cluster or standalone host with the highest memory
+// overcommit ratio. The zero value is returned if the collection is empty.
+func (set ComputeResourceMemoryAllocationSet) Highest() ComputeResourceMemoryAllocation {
+	var highest ComputeResourceMemoryAllocation
+	for _, crma := range set {
+		if highest.Name == "" || crma.Ratio() > highest.Ratio() {
+			highest = crma
+		}
+	}
+
+	return highest
+}
+
+// ComputeResourceMemoryAllocationPerfData generates performance data metrics
+// from the given collection of evaluated clusters and standalone hosts.
+func ComputeResourceMemoryAllocationPerfData(set ComputeResourceMemoryAllocationSet, thresholds MemoryOvercommitRatioThresholds) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "memory_ratio_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "memory_ratio_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "memory_overcommit_ratio_max",
+			Value: fmt.Sprintf("%.2f", set.Highest().Ratio()),
+			Warn:  fmt.Sprintf("%.2f", thresholds.Warning),
+			Crit:  fmt.Sprintf("%.2f", thresholds.Critical),
+			Min:   "0",
+		},
+	}
+
+	for _, crma := range set {
+		pd = append(pd,
+			nagios.PerformanceData{
+				Label: PerfDataLabel(crma.Name, "memory_overcommit_ratio"),
+				Value: fmt.Sprintf("%.2f", crma.Ratio()),
+				Warn:  fmt.Sprintf("%.2f", crma.Thresholds.Warning),
+				Crit:  fmt.Sprintf("%.2f", crma.Thresholds.Critical),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label:             PerfDataLabel(crma.Name, "memory_allocated"),
+				Value:             fmt.Sprintf("%d", crma.MemoryAllocated),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+			},
+		)
+	}
+
+	return pd
+
+}
+
+// ComputeResourceMemoryAllocationOneLineCheckSummary is used to generate a
+// one-line Nagios service check results summary. This is the line most
+// prominent in notifications.
+func ComputeResourceMemoryAllocationOneLineCheckSummary(
+	stateLabel string,
+	set ComputeResourceMemoryAllocationSet,
+	thresholds MemoryOvercommitRatioThresholds,
+	vmsFilterResults VMsFilterResults,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set":              set,
+		"thresholds":       thresholds,
+		"vmsFilterResults": vmsFilterResults,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ComputeResourceMemoryAllocationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	highest := set.Highest()
+
+	switch {
+	case set.HasCriticalState():
+		return fmt.Sprintf(
+			"%s: %d clusters/hosts with memory overcommit ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters/hosts, %d VMs)",
+			stateLabel,
+			set.NumCritical(),
+			thresholds.Critical,
+			highest.Ratio(),
+			highest.Name,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	case set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d clusters/hosts with memory overcommit ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters/hosts, %d VMs)",
+			stateLabel,
+			set.NumWarning(),
+			thresholds.Warning,
+			highest.Ratio(),
+			highest.Name,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	case len(set) == 0:
+		return fmt.Sprintf(
+			"%s: No clusters or hosts with evaluated VMs found (evaluated %d VMs)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No clusters/hosts with memory overcommit ratio greater than %.2f:1; highest %.2f:1 on %s"+
+				" (evaluated %d clusters/hosts, %d VMs)",
+			stateLabel,
+			thresholds.Warning,
+			highest.Ratio(),
+			highest.Name,
+			len(set),
+			vmsFilterResults.NumVMsAfterFiltering(),
+		)
+	}
+}
+
+// ComputeResourceMemoryAllocationReport generates a summary of VM memory
+// allocation per cluster (or standalone host) along with various verbose
+// details intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func ComputeResourceMemoryAllocationReport(
+	c *vim25.Client,
+	set ComputeResourceMemoryAllocationSet,
+	numVMsUnknownHost int,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute ComputeResourceMemoryAllocationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VM memory allocation per cluster or standalone host:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	for _, crma := range set {
+		var state string
+		switch {
+		case crma.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case crma.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		kind := "cluster"
+		if crma.Standalone {
+			kind = "host"
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (%s) [%s]: %s allocated / %s physical (%.2f:1, %d VMs)%s",
+			crma.Name,
+			kind,
+			state,
+			units.ByteSize(crma.MemoryAllocated),
+			units.ByteSize(crma.PhysicalMemory),
+			crma.Ratio(),
+			crma.NumVMs,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	if numVMsUnknownHost > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%s%d evaluated VMs without a known current host were skipped%s",
+			nagios.CheckOutputEOL,
+			numVMsUnknownHost,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
+
+// VMMemoryAllocationOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VMMemoryAllocationOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	memoryAllocated int64,
+	memoryMax int64,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"memoryAllocated":  memoryAllocated,
+		"memoryMax":        memoryMax,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMMemoryAllocationOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	memoryPercentageUsed := float64(memoryAllocated) / float64(memoryMax) * 100
+
+	switch {
+
+	case memoryAllocated > memoryMax:
+		return fmt.Sprintf(
+			"%s: %s memory allocated (%.1f%%); %s more allocated than %s allowed"+
+				" (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			units.ByteSize(memoryAllocated),
+			memoryPercentageUsed,
+			units.ByteSize(memoryAllocated-memoryMax),
+			units.ByteSize(memoryMax),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: %s memory allocated (%.1f%%); %s more remaining from %s allowed"+
+				" (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			units.ByteSize(memoryAllocated),
+			memoryPercentageUsed,
+			units.ByteSize(memoryMax-memoryAllocated),
+			units.ByteSize(memoryMax),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	}
+}
+
+// VMMemoryAllocationReport generates a summary of VM memory allocation along
+// with various verbose details intended to aid in troubleshooting check
+// results at a glance. This information is provided for use with the Long
+// Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VMMemoryAllocationReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	memoryAllocated int64,
+	memoryMax int64,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMMemoryAllocationReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var vmsReport strings.Builder
+
+	// This is shown regardless of whether the plugin is considered to be in a
+	// non-OK state.
+	_, _ = fmt.Fprintf(
+		&vmsReport,
+		"* Memory%s** Allocated: %s (%.1f%%)%s** Max Allowed: %s%s",
+		nagios.CheckOutputEOL,
+		units.ByteSize(memoryAllocated),
+		float64(memoryAllocated)/float64(memoryMax)*100,
+		nagios.CheckOutputEOL,
+		units.ByteSize(memoryMax),
+		nagios.CheckOutputEOL,
+	)
+
+	if reportSectionEnabled(ReportSectionTopConsumers) {
+		_, _ = fmt.Fprintf(
+			&vmsReport,
+			"%sTop 10 memory consumers:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		evaluatedVMs := vmsFilterResults.VMsAfterFiltering()
+
+		sort.Slice(evaluatedVMs, func(i, j int) bool {
+			return evaluatedVMs[i].Summary.Config.MemorySizeMB > evaluatedVMs[j].Summary.Config.MemorySizeMB
+		})
+
+		// grab up to the first 10 VMs, presorted by large memory allocation
+		sampleSize := len(evaluatedVMs)
+		if sampleSize > 10 {
+			sampleSize = 10
+		}
+		topTen := evaluatedVMs[:sampleSize]
+
+		switch {
+		case len(topTen) == 0:
+			_, _ = fmt.Fprintf(&vmsReport, "* None %s", nagios.CheckOutputEOL)
+		default:
+			for _, vm := range topTen {
+				_, _ = fmt.Fprintf(
+					&vmsReport,
+					"* %s (%s)%s",
+					vm.Name,
+					units.ByteSize(int64(vm.Summary.Config.MemorySizeMB)*units.MB),
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&vmsReport,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return vmsReport.String()
+}
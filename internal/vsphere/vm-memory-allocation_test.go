@@ -0,0 +1,242 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"math"
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var memAllocThresholds = MemoryOvercommitRatioThresholds{
+	Warning:  1.5,
+	Critical: 2.0,
+}
+
+func memAllocCluster(name string, memoryGB int64, hostIDs ...string) mo.ClusterComputeResource {
+	var cluster mo.ClusterComputeResource
+	cluster.Name = name
+	cluster.Summary = &types.ClusterComputeResourceSummary{
+		ComputeResourceSummary: types.ComputeResourceSummary{
+			TotalMemory: memoryGB * units.GB,
+		},
+	}
+
+	for _, id := range hostIDs {
+		cluster.Host = append(cluster.Host, types.ManagedObjectReference{
+			Type:  MgObjRefTypeHostSystem,
+			Value: id,
+		})
+	}
+
+	return cluster
+}
+
+func memAllocHost(id string, name string, memoryGB int64) mo.HostSystem {
+	var host mo.HostSystem
+	host.Self = types.ManagedObjectReference{Type: MgObjRefTypeHostSystem, Value: id}
+	host.Name = name
+	host.Summary.Hardware = &types.HostHardwareSummary{
+		MemorySize: memoryGB * units.GB,
+	}
+
+	return host
+}
+
+func memAllocVM(name string, memoryGB int32, hostID string) mo.VirtualMachine {
+	var vm mo.VirtualMachine
+	vm.Name = name
+	vm.Summary.Config.MemorySizeMB = memoryGB * 1024
+
+	if hostID != "" {
+		vm.Summary.Runtime.Host = &types.ManagedObjectReference{
+			Type:  MgObjRefTypeHostSystem,
+			Value: hostID,
+		}
+	}
+
+	return vm
+}
+
+func TestVMMemoryAllocated(t *testing.T) {
+	tests := map[string]struct {
+		vms  []mo.VirtualMachine
+		want int64
+	}{
+		"no VMs": {},
+		"multiple VMs": {
+			vms:  []mo.VirtualMachine{memAllocVM("vm1", 4, "host-1"), memAllocVM("vm2", 8, "")},
+			want: 12 * units.GB,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := VMMemoryAllocated(tt.vms); got != tt.want {
+				t.Errorf("want %d bytes allocated; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestComputeResourceMemoryAllocationState(t *testing.T) {
+	tests := map[string]struct {
+		allocatedGB  int64
+		physicalGB   int64
+		wantRatio    float64
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"below thresholds":         {allocatedGB: 64, physicalGB: 64, wantRatio: 1},
+		"equal to warning":         {allocatedGB: 96, physicalGB: 64, wantRatio: 1.5},
+		"above warning threshold":  {allocatedGB: 112, physicalGB: 64, wantRatio: 1.75, wantWarning: true},
+		"equal to critical":        {allocatedGB: 128, physicalGB: 64, wantRatio: 2, wantWarning: true},
+		"above critical threshold": {allocatedGB: 56, physicalGB: 16, wantRatio: 3.5, wantCritical: true},
+		"unknown physical memory":  {allocatedGB: 64, physicalGB: 0, wantRatio: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			crma := ComputeResourceMemoryAllocation{
+				MemoryAllocated: tt.allocatedGB * units.GB,
+				PhysicalMemory:  tt.physicalGB * units.GB,
+				Thresholds:      memAllocThresholds,
+			}
+
+			if got := crma.Ratio(); math.Abs(got-tt.wantRatio) > 0.0001 {
+				t.Errorf("want ratio %.2f; got %.2f", tt.wantRatio, got)
+			}
+			if got := crma.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := crma.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestNewComputeResourceMemoryAllocationSet(t *testing.T) {
+	clusters := []mo.ClusterComputeResource{
+		memAllocCluster("cluster1", 64, "host-1", "host-2"),
+	}
+
+	hosts := []mo.HostSystem{
+		memAllocHost("host-1", "esx1", 32),
+		memAllocHost("host-2", "esx2", 32),
+		memAllocHost("host-3", "esx3", 16),
+	}
+
+	tests := map[string]struct {
+		vms             []mo.VirtualMachine
+		want            ComputeResourceMemoryAllocationSet
+		wantUnknownHost int
+		wantCritical    bool
+		wantWarning     bool
+	}{
+		"below thresholds": {
+			vms: []mo.VirtualMachine{
+				memAllocVM("vm1", 32, "host-1"),
+				memAllocVM("vm2", 32, "host-2"),
+			},
+			want: ComputeResourceMemoryAllocationSet{
+				{Name: "cluster1", MemoryAllocated: 64 * units.GB, PhysicalMemory: 64 * units.GB, NumVMs: 2, Thresholds: memAllocThresholds},
+			},
+		},
+		"cluster above warning threshold": {
+			vms: []mo.VirtualMachine{
+				memAllocVM("vm1", 64, "host-1"),
+				memAllocVM("vm2", 48, "host-2"),
+			},
+			want: ComputeResourceMemoryAllocationSet{
+				{Name: "cluster1", MemoryAllocated: 112 * units.GB, PhysicalMemory: 64 * units.GB, NumVMs: 2, Thresholds: memAllocThresholds},
+			},
+			wantWarning: true,
+		},
+		"standalone host above critical threshold": {
+			vms: []mo.VirtualMachine{
+				memAllocVM("vm1", 16, "host-1"),
+				memAllocVM("vm2", 40, "host-3"),
+			},
+			want: ComputeResourceMemoryAllocationSet{
+				{Name: "cluster1", MemoryAllocated: 16 * units.GB, PhysicalMemory: 64 * units.GB, NumVMs: 1, Thresholds: memAllocThresholds},
+				{Name: "esx3", Standalone: true, MemoryAllocated: 40 * units.GB, PhysicalMemory: 16 * units.GB, NumVMs: 1, Thresholds: memAllocThresholds},
+			},
+			wantCritical: true,
+		},
+		"VMs without a known host": {
+			vms: []mo.VirtualMachine{
+				memAllocVM("vm1", 16, "host-1"),
+				memAllocVM("vm2", 16, ""),
+				memAllocVM("vm3", 16, "host-9"),
+			},
+			want: ComputeResourceMemoryAllocationSet{
+				{Name: "cluster1", MemoryAllocated: 16 * units.GB, PhysicalMemory: 64 * units.GB, NumVMs: 1, Thresholds: memAllocThresholds},
+			},
+			wantUnknownHost: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set, numUnknownHost := NewComputeResourceMemoryAllocationSet(clusters, hosts, tt.vms, memAllocThresholds)
+
+			if d := cmp.Diff(tt.want, set); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+			if numUnknownHost != tt.wantUnknownHost {
+				t.Errorf("want %d VMs with unknown host; got %d", tt.wantUnknownHost, numUnknownHost)
+			}
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestComputeResourceMemoryAllocationSetHighest(t *testing.T) {
+	set := ComputeResourceMemoryAllocationSet{
+		{Name: "cluster1", MemoryAllocated: 64 * units.GB, PhysicalMemory: 64 * units.GB},
+		{Name: "esx3", MemoryAllocated: 40 * units.GB, PhysicalMemory: 16 * units.GB},
+		{Name: "cluster2", MemoryAllocated: 0, PhysicalMemory: 64 * units.GB},
+	}
+
+	if got := set.Highest().Name; got != "esx3" {
+		t.Errorf("want esx3; got %q", got)
+	}
+
+	if got := (ComputeResourceMemoryAllocationSet{}).Highest(); got.Name != "" {
+		t.Errorf("want zero value; got %+v", got)
+	}
+}
+
+func TestComputeResourceMemoryAllocationPerfData(t *testing.T) {
+	set := ComputeResourceMemoryAllocationSet{
+		{Name: "cluster1", MemoryAllocated: 112 * units.GB, PhysicalMemory: 64 * units.GB, NumVMs: 2, Thresholds: memAllocThresholds},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "memory_ratio_critical", Value: "0", Min: "0"},
+		{Label: "memory_ratio_warning", Value: "1", Min: "0"},
+		{Label: "memory_overcommit_ratio_max", Value: "1.75", Warn: "1.50", Crit: "2.00", Min: "0"},
+		{Label: "cluster1_memory_overcommit_ratio", Value: "1.75", Warn: "1.50", Crit: "2.00", Min: "0"},
+		{Label: "cluster1_memory_allocated", Value: "120259084288", UnitOfMeasurement: "B", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, ComputeResourceMemoryAllocationPerfData(set, memAllocThresholds)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineOrphanedDevicesFound indicates that one or more
+// VirtualMachines have virtual devices with backings which are missing or
+// otherwise unavailable.
+var ErrVirtualMachineOrphanedDevicesFound = errors.New("virtual machines with orphaned devices found")
+
+// Virtual device kinds evaluated for orphaned or unavailable backings.
+const (
+	orphanDeviceKindDisk    string = "disk"
+	orphanDeviceKindNetwork string = "network adapter"
+	orphanDeviceKindOther   string = "device"
+)
+
+// VMOrphanedDevice represents a virtual device with a backing that is
+// missing or otherwise unavailable.
+type VMOrphanedDevice struct {
+
+	// Label is the display label for the device (e.g., "Hard disk 2").
+	Label string
+
+	// Kind is the general category of the device (e.g., disk, network
+	// adapter).
+	Kind string
+
+	// Backing is the backing used by the device (e.g., the VMDK path or
+	// portgroup name).
+	Backing string
+
+	// Reason is a brief description of why the device is considered
+	// orphaned or unavailable.
+	Reason string
+
+	// Critical indicates whether the device is likely to prevent the
+	// VirtualMachine from powering on.
+	Critical bool
+}
+
+// VMOrphanedDevices ties a collection of orphaned or unavailable devices to
+// a specific VirtualMachine.
+type VMOrphanedDevices struct {
+	VMName     string
+	PowerState types.VirtualMachinePowerState
+	Devices    []VMOrphanedDevice
+}
+
+// VMOrphanedDevicesSet is a collection of VMOrphanedDevices values.
+type VMOrphanedDevicesSet []VMOrphanedDevices
+
+// IsCriticalState indicates whether the VirtualMachine has any orphaned
+// devices likely to prevent it from powering on.
+func (vod VMOrphanedDevices) IsCriticalState() bool {
+	for _, dev := range vod.Devices {
+		if dev.Critical {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsWarningState indicates whether the VirtualMachine has orphaned or
+// unavailable devices which are not likely to prevent it from powering on.
+func (vod VMOrphanedDevices) IsWarningState() bool {
+	return !vod.IsCriticalState() && len(vod.Devices) > 0
+}
+
+// vmLayoutFiles returns an index of the files known to the layout of the
+// given VirtualMachine along with their accessibility. A nil index is
+// returned if file layout details are not available.
+func vmLayoutFiles(vm mo.VirtualMachine) map[string]bool {
+	if vm.LayoutEx == nil || len(vm.LayoutEx.File) == 0 {
+		return nil
+	}
+
+	files := make(map[string]bool, len(vm.LayoutEx.File))
+	for _, file := range vm.LayoutEx.File {
+		accessible := true
+		if file.Accessible != nil {
+			accessible = *file.Accessible
+		}
+		files[file.Name] = accessible
+	}
+
+	return files
+}
+
+// NewVMOrphanedDevices evaluates the virtual devices for the given
+// VirtualMachine and returns any with missing or unavailable backings. The
+// given mapping of network IDs to names is used to determine whether network
+// adapters are connected to networks (or portgroups) that still exist.
+//
+// Virtual disks are considered orphaned if the backing VMDK is not present in
+// the file layout for the VirtualMachine or is marked as inaccessible.
+// Network adapters are considered orphaned if the backing network or
+// distributed portgroup no longer exists. Other devices are considered
+// unavailable if their connection status indicates an error.
+func NewVMOrphanedDevices(vm mo.VirtualMachine, networks map[string]string) VMOrphanedDevices {
+
+	vod := VMOrphanedDevices{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+	}
+
+	if vm.Config == nil {
+		return vod
+	}
+
+	networkNames := make(map[string]struct{}, len(networks))
+	for _, name := range networks {
+		networkNames[name] = struct{}{}
+	}
+
+	layoutFiles := vmLayoutFiles(vm)
+
+	for _, device := range vm.Config.Hardware.Device {
+		vd := device.GetVirtualDevice()
+
+		var label string
+		if vd.DeviceInfo != nil {
+			label = vd.DeviceInfo.GetDescription().Label
+		}
+		if label == "" {
+			label = fmt.Sprintf("device %d", vd.Key)
+		}
+
+		switch dev := device.(type) {
+		case *types.VirtualDisk:
+			backing, ok := dev.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+			if !ok {
+				continue
+			}
+
+			fileName := backing.GetVirtualDeviceFileBackingInfo().FileName
+
+			switch accessible, found := layoutFiles[fileName]; {
+			case fileName == "":
+				vod.Devices = append(vod.Devices, VMOrphanedDevice{
+					Label:    label,
+					Kind:     orphanDeviceKindDisk,
+					Backing:  "unknown",
+					Reason:   "backing file not specified",
+					Critical: true,
+				})
+
+			case layoutFiles == nil:
+				// File layout details unavailable; unable to validate.
+				continue
+
+			case !found:
+				vod.Devices = append(vod.Devices, VMOrphanedDevice{
+					Label:    label,
+					Kind:     orphanDeviceKindDisk,
+					Backing:  fileName,
+					Reason:   "backing file missing",
+					Critical: true,
+				})
+
+			case !accessible:
+				vod.Devices = append(vod.Devices, VMOrphanedDevice{
+					Label:    label,
+					Kind:     orphanDeviceKindDisk,
+					Backing:  fileName,
+					Reason:   "backing file inaccessible",
+					Critical: true,
+				})
+			}
+
+		case types.BaseVirtualEthernetCard:
+			switch backing := vd.Backing.(type) {
+			case *types.VirtualEthernetCardNetworkBackingInfo:
+				var found bool
+				switch {
+				case backing.Network != nil:
+					_, found = networks[backing.Network.Value]
+				default:
+					_, found = networkNames[backing.DeviceName]
+				}
+
+				if !found {
+					vod.Devices = append(vod.Devices, VMOrphanedDevice{
+						Label:   label,
+						Kind:    orphanDeviceKindNetwork,
+						Backing: backing.DeviceName,
+						Reason:  "network not found",
+					})
+				}
+
+			case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+				// The key for a distributed virtual portgroup matches its
+				// Managed Object ID.
+				if _, found := networks[backing.Port.PortgroupKey]; !found {
+					vod.Devices = append(vod.Devices, VMOrphanedDevice{
+						Label:   label,
+						Kind:    orphanDeviceKindNetwork,
+						Backing: backing.Port.PortgroupKey,
+						Reason:  "distributed portgroup not found",
+					})
+				}
+			}
+
+		default:
+			if vd.Connectable == nil {
+				continue
+			}
+
+			if vd.Connectable.Status == string(types.VirtualDeviceConnectInfoStatusUnrecoverableError) {
+				var summary string
+				if vd.DeviceInfo != nil {
+					summary = vd.DeviceInfo.GetDescription().Summary
+				}
+
+				vod.Devices = append(vod.Devices, VMOrphanedDevice{
+					Label:   label,
+					Kind:    orphanDeviceKindOther,
+					Backing: summary,
+					Reason:  "device connection failed",
+				})
+			}
+		}
+	}
+
+	return vod
+}
+
+// NewVMOrphanedDevicesSet evaluates the given VirtualMachines and returns
+// those with orphaned or unavailable devices.
+func NewVMOrphanedDevicesSet(vms []mo.VirtualMachine, nets []mo.Network) VMOrphanedDevicesSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMOrphanedDevicesSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	networks := make(map[string]string, len(nets))
+	for _, net := range nets {
+		networks[net.Self.Value] = net.Name
+	}
+
+	set := make(VMOrphanedDevicesSet, 0, len(vms))
+	for _, vm := range vms {
+		vod := NewVMOrphanedDevices(vm, networks)
+		if len(vod.Devices) == 0 {
+			continue
+		}
+
+		set = append(set, vod)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumCritical returns the number of VirtualMachines with orphaned devices
+// likely to prevent them from powering on.
+func (set VMOrphanedDevicesSet) NumCritical() int {
+	var num int
+	for _, vod := range set {
+		if vod.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of VirtualMachines with orphaned or
+// unavailable devices which are not likely to prevent them from powering
+// on.
+func (set VMOrphanedDevicesSet) NumWarning() int {
+	var num int
+	for _, vod := range set {
+		if vod.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumDevices returns the number of orphaned or unavailable devices of the
+// specified kind across all VirtualMachines in the set. If kind is an empty
+// string all devices are counted.
+func (set VMOrphanedDevicesSet) NumDevices(kind string) int {
+	var num int
+	for _, vod := range set {
+		for _, dev := range vod.Devices {
+			if kind == "" || dev.Kind == kind {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether any VirtualMachine in the set has
+// orphaned devices likely to prevent it from powering on.
+func (set VMOrphanedDevicesSet) IsCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has
+// orphaned or unavailable devices.
+func (set VMOrphanedDevicesSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VMOrphanedDevicesPerfData generates performance data metrics from the
+// given evaluation results.
+func VMOrphanedDevicesPerfData(set VMOrphanedDevicesSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_orphaned_devices",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "vms_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "orphaned_devices",
+			Value: fmt.Sprintf("%d", set.NumDevices("")),
+			Min:   "0",
+		},
+		{
+			Label: "orphaned_disks",
+			Value: fmt.Sprintf("%d", set.NumDevices(orphanDeviceKindDisk)),
+			Min:   "0",
+		},
+		{
+			Label: "orphaned_network_adapters",
+			Value: fmt.Sprintf("%d", set.NumDevices(orphanDeviceKindNetwork)),
+			Min:   "0",
+		},
+		{
+			Label: "unavailable_devices",
+			Value: fmt.Sprintf("%d", set.NumDevices(orphanDeviceKindOther)),
+			Min:   "0",
+		},
+	}
+}
+
+// VMOrphanedDevicesOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMOrphanedDevicesOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMOrphanedDevicesSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMOrphanedDevicesOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(set) > 0:
+		return fmt.Sprintf(
+			"%s: %d VMs with %d orphaned or unavailable devices detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set),
+			set.NumDevices(""),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with orphaned or unavailable devices detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMOrphanedDevicesReport generates a summary of VMs with orphaned or
+// unavailable devices along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided
+// for use with the Long Service Output field commonly displayed on the
+// detailed service check results display in the web UI or in the body of
+// many notifications.
+func VMOrphanedDevicesReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMOrphanedDevicesSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMOrphanedDevicesReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with orphaned or unavailable devices:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+	}
+
+	for _, vod := range set {
+		var state string
+		switch {
+		case vod.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		default:
+			state = nagios.StateWARNINGLabel
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s [%s] (power state: %s)%s",
+			vod.VMName,
+			state,
+			vod.PowerState,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, dev := range vod.Devices {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** %s (%s): %s [%s]%s",
+				dev.Label,
+				dev.Kind,
+				dev.Reason,
+				dev.Backing,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,288 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var orphanNetworks = map[string]string{
+	"network-1":     "VM Network",
+	"dvportgroup-1": "Production",
+}
+
+func orphanDisk(label string, fileName string) types.BaseVirtualDevice {
+	return &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			DeviceInfo: &types.Description{Label: label},
+			Backing: &types.VirtualDiskFlatVer2BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{FileName: fileName},
+			},
+		},
+	}
+}
+
+func orphanNIC(label string, backing types.BaseVirtualDeviceBackingInfo) types.BaseVirtualDevice {
+	return &types.VirtualVmxnet3{
+		VirtualVmxnet: types.VirtualVmxnet{
+			VirtualEthernetCard: types.VirtualEthernetCard{
+				VirtualDevice: types.VirtualDevice{
+					DeviceInfo: &types.Description{Label: label},
+					Backing:    backing,
+				},
+			},
+		},
+	}
+}
+
+func orphanNetworkBacking(name string, networkID string) *types.VirtualEthernetCardNetworkBackingInfo {
+	backing := types.VirtualEthernetCardNetworkBackingInfo{
+		VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{DeviceName: name},
+	}
+	if networkID != "" {
+		backing.Network = &types.ManagedObjectReference{Type: "Network", Value: networkID}
+	}
+
+	return &backing
+}
+
+func orphanCDROM(status types.VirtualDeviceConnectInfoStatus) types.BaseVirtualDevice {
+	return &types.VirtualCdrom{
+		VirtualDevice: types.VirtualDevice{
+			Key:         3000,
+			DeviceInfo:  &types.Description{Summary: "ISO [ds1] iso/os.iso"},
+			Connectable: &types.VirtualDeviceConnectInfo{Status: string(status)},
+		},
+	}
+}
+
+func orphanDevicesVM(name string, files []types.VirtualMachineFileLayoutExFileInfo, devices ...types.BaseVirtualDevice) mo.VirtualMachine {
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{Device: devices},
+		},
+	}
+
+	if files != nil {
+		vm.LayoutEx = &types.VirtualMachineFileLayoutEx{File: files}
+	}
+
+	return vm
+}
+
+func TestVMLayoutFiles(t *testing.T) {
+	inaccessible := false
+
+	tests := map[string]struct {
+		vm   mo.VirtualMachine
+		want map[string]bool
+	}{
+		"no layout": {
+			vm: orphanDevicesVM("vm1", nil),
+		},
+		"empty layout": {
+			vm: orphanDevicesVM("vm1", []types.VirtualMachineFileLayoutExFileInfo{}),
+		},
+		"accessible unless reported otherwise": {
+			vm: orphanDevicesVM("vm1", []types.VirtualMachineFileLayoutExFileInfo{
+				{Name: "[ds1] vm1/vm1.vmdk"},
+				{Name: "[ds2] vm1/vm1_1.vmdk", Accessible: &inaccessible},
+			}),
+			want: map[string]bool{
+				"[ds1] vm1/vm1.vmdk":   true,
+				"[ds2] vm1/vm1_1.vmdk": false,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, vmLayoutFiles(tt.vm)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMOrphanedDevices(t *testing.T) {
+	inaccessible := false
+
+	files := []types.VirtualMachineFileLayoutExFileInfo{
+		{Name: "[ds1] vm1/vm1.vmdk"},
+		{Name: "[ds2] vm1/vm1_1.vmdk", Accessible: &inaccessible},
+	}
+
+	tests := map[string]struct {
+		vm           mo.VirtualMachine
+		want         []VMOrphanedDevice
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"no orphaned devices": {
+			vm: orphanDevicesVM("vm1", files,
+				orphanDisk("Hard disk 1", "[ds1] vm1/vm1.vmdk"),
+				orphanNIC("Network adapter 1", orphanNetworkBacking("VM Network", "network-1")),
+				orphanNIC("Network adapter 2", orphanNetworkBacking("VM Network", "")),
+				orphanNIC("Network adapter 3", &types.VirtualEthernetCardDistributedVirtualPortBackingInfo{
+					Port: types.DistributedVirtualSwitchPortConnection{PortgroupKey: "dvportgroup-1"},
+				}),
+				orphanCDROM(types.VirtualDeviceConnectInfoStatusOk),
+			),
+		},
+		"missing disk backing file": {
+			vm: orphanDevicesVM("vm1", files,
+				orphanDisk("Hard disk 2", "[ds1] vm1/vm1_2.vmdk"),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Hard disk 2", Kind: orphanDeviceKindDisk, Backing: "[ds1] vm1/vm1_2.vmdk", Reason: "backing file missing", Critical: true},
+			},
+			wantCritical: true,
+		},
+		"inaccessible disk backing file": {
+			vm: orphanDevicesVM("vm1", files,
+				orphanDisk("Hard disk 2", "[ds2] vm1/vm1_1.vmdk"),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Hard disk 2", Kind: orphanDeviceKindDisk, Backing: "[ds2] vm1/vm1_1.vmdk", Reason: "backing file inaccessible", Critical: true},
+			},
+			wantCritical: true,
+		},
+		"disk backing file not specified": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanDisk("Hard disk 1", ""),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Hard disk 1", Kind: orphanDeviceKindDisk, Backing: "unknown", Reason: "backing file not specified", Critical: true},
+			},
+			wantCritical: true,
+		},
+		"disk not validated without file layout": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanDisk("Hard disk 1", "[ds1] vm1/vm1.vmdk"),
+			),
+		},
+		"missing network": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanNIC("Network adapter 1", orphanNetworkBacking("Old Network", "network-2")),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Network adapter 1", Kind: orphanDeviceKindNetwork, Backing: "Old Network", Reason: "network not found"},
+			},
+			wantWarning: true,
+		},
+		"missing network by name": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanNIC("Network adapter 1", orphanNetworkBacking("Old Network", "")),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Network adapter 1", Kind: orphanDeviceKindNetwork, Backing: "Old Network", Reason: "network not found"},
+			},
+			wantWarning: true,
+		},
+		"missing distributed portgroup": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanNIC("Network adapter 1", &types.VirtualEthernetCardDistributedVirtualPortBackingInfo{
+					Port: types.DistributedVirtualSwitchPortConnection{PortgroupKey: "dvportgroup-2"},
+				}),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "Network adapter 1", Kind: orphanDeviceKindNetwork, Backing: "dvportgroup-2", Reason: "distributed portgroup not found"},
+			},
+			wantWarning: true,
+		},
+		"failed device connection": {
+			vm: orphanDevicesVM("vm1", nil,
+				orphanCDROM(types.VirtualDeviceConnectInfoStatusUnrecoverableError),
+			),
+			want: []VMOrphanedDevice{
+				{Label: "device 3000", Kind: orphanDeviceKindOther, Backing: "ISO [ds1] iso/os.iso", Reason: "device connection failed"},
+			},
+			wantWarning: true,
+		},
+		"missing configuration": {
+			vm: mo.VirtualMachine{ManagedEntity: mo.ManagedEntity{Name: "vm1"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMOrphanedDevices(tt.vm, orphanNetworks)
+
+			if d := cmp.Diff(tt.want, got.Devices); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+			if got.IsCriticalState() != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got.IsCriticalState())
+			}
+			if got.IsWarningState() != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got.IsWarningState())
+			}
+		})
+	}
+}
+
+func TestNewVMOrphanedDevicesSet(t *testing.T) {
+	var network mo.Network
+	network.Self = types.ManagedObjectReference{Type: "Network", Value: "network-1"}
+	network.Name = "VM Network"
+
+	files := []types.VirtualMachineFileLayoutExFileInfo{{Name: "[ds1] vm1/vm1.vmdk"}}
+
+	set := NewVMOrphanedDevicesSet(
+		[]mo.VirtualMachine{
+			orphanDevicesVM("vm3", files,
+				orphanDisk("Hard disk 1", "[ds1] vm1/vm1.vmdk"),
+				orphanNIC("Network adapter 1", orphanNetworkBacking("VM Network", "network-1")),
+			),
+			orphanDevicesVM("vm2", nil,
+				orphanNIC("Network adapter 1", orphanNetworkBacking("Old Network", "network-2")),
+				orphanCDROM(types.VirtualDeviceConnectInfoStatusUnrecoverableError),
+			),
+			orphanDevicesVM("VM1", files,
+				orphanDisk("Hard disk 2", "[ds1] vm1/vm1_1.vmdk"),
+				orphanNIC("Network adapter 1", orphanNetworkBacking("Old Network", "network-2")),
+			),
+		},
+		[]mo.Network{network},
+	)
+
+	var names []string
+	for _, vod := range set {
+		names = append(names, vod.VMName)
+	}
+	if d := cmp.Diff([]string{"VM1", "vm2"}, names); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if !set.IsCriticalState() || set.NumCritical() != 1 {
+		t.Errorf("want 1 CRITICAL VM; got %d", set.NumCritical())
+	}
+	if !set.IsWarningState() || set.NumWarning() != 1 {
+		t.Errorf("want 1 WARNING VM; got %d", set.NumWarning())
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_orphaned_devices", Value: "2", Min: "0"},
+		{Label: "vms_critical", Value: "1", Min: "0"},
+		{Label: "vms_warning", Value: "1", Min: "0"},
+		{Label: "orphaned_devices", Value: "4", Min: "0"},
+		{Label: "orphaned_disks", Value: "1", Min: "0"},
+		{Label: "orphaned_network_adapters", Value: "2", Min: "0"},
+		{Label: "unavailable_devices", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMOrphanedDevicesPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
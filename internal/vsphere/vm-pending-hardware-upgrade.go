@@ -0,0 +1,514 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachinePendingHardwareUpgradeFound indicates that one or more
+// VirtualMachines have a scheduled virtual hardware upgrade which has
+// remained pending for too long, has failed or conflicts with the minimum
+// virtual hardware version policy.
+var ErrVirtualMachinePendingHardwareUpgradeFound = errors.New("virtual machines with problematic scheduled hardware upgrades found")
+
+// VMPendingHardwareUpgrade represents a VirtualMachine with a scheduled
+// virtual hardware upgrade.
+type VMPendingHardwareUpgrade struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// CurrentVersion is the current virtual hardware version (e.g.,
+	// vmx-15) of the VirtualMachine.
+	CurrentVersion string
+
+	// TargetVersion is the virtual hardware version (e.g., vmx-19) used for
+	// the scheduled upgrade. If empty, the upgrade targets the latest
+	// version supported by the host.
+	TargetVersion string
+
+	// Policy is the scheduled upgrade policy (e.g., onSoftPowerOff).
+	Policy string
+
+	// Status is the status of the last attempt to run the scheduled upgrade
+	// (e.g., pending, failed).
+	Status string
+
+	// Fault is the reason given for the last failed attempt to run the
+	// scheduled upgrade.
+	Fault string
+
+	// PendingSince is the time of the last VirtualMachine configuration
+	// change. vSphere does not record when an upgrade was scheduled, so
+	// this is used as the closest approximation.
+	PendingSince time.Time
+
+	// MinimumVersionConflict indicates that the scheduled upgrade targets a
+	// version older than the specified minimum virtual hardware version.
+	MinimumVersionConflict bool
+}
+
+// VMPendingHardwareUpgradeSet is a collection of VMPendingHardwareUpgrade
+// values along with the thresholds used to evaluate them.
+type VMPendingHardwareUpgradeSet struct {
+
+	// Upgrades is the collection of VirtualMachines with a scheduled
+	// virtual hardware upgrade.
+	Upgrades []VMPendingHardwareUpgrade
+
+	// AgeWarning is the number of days a scheduled upgrade may remain
+	// pending before a WARNING threshold is reached.
+	AgeWarning int
+
+	// AgeCritical is the number of days a scheduled upgrade may remain
+	// pending before a CRITICAL threshold is reached.
+	AgeCritical int
+
+	// MinimumVersion is the minimum virtual hardware version accepted for
+	// each VirtualMachine. A negative value indicates that the minimum
+	// version policy is not evaluated.
+	MinimumVersion int
+}
+
+// NewVMPendingHardwareUpgrade evaluates the scheduled virtual hardware
+// upgrade settings for the given VirtualMachine. The boolean return value
+// indicates whether an upgrade is scheduled.
+func NewVMPendingHardwareUpgrade(vm mo.VirtualMachine, minVersion int) (VMPendingHardwareUpgrade, bool) {
+
+	if vm.Config == nil || vm.Config.ScheduledHardwareUpgradeInfo == nil {
+		return VMPendingHardwareUpgrade{}, false
+	}
+
+	info := vm.Config.ScheduledHardwareUpgradeInfo
+
+	failed := info.ScheduledHardwareUpgradeStatus ==
+		string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed)
+
+	scheduled := info.UpgradePolicy != "" &&
+		info.UpgradePolicy != string(types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyNever)
+
+	if !scheduled && !failed {
+		return VMPendingHardwareUpgrade{}, false
+	}
+
+	upgrade := VMPendingHardwareUpgrade{
+		VMName:         vm.Name,
+		PowerState:     vm.Runtime.PowerState,
+		CurrentVersion: vm.Config.Version,
+		TargetVersion:  info.VersionKey,
+		Policy:         info.UpgradePolicy,
+		Status:         info.ScheduledHardwareUpgradeStatus,
+		PendingSince:   vm.Config.Modified,
+	}
+
+	if info.Fault != nil {
+		upgrade.Fault = info.Fault.LocalizedMessage
+	}
+
+	if upgrade.TargetVersion != "" && minVersion >= 0 {
+		targetNum := newHardwareVersionString(upgrade.TargetVersion).VersionNumber()
+		upgrade.MinimumVersionConflict = targetNum >= 0 && targetNum < minVersion
+	}
+
+	return upgrade, true
+}
+
+// NewVMPendingHardwareUpgradeSet evaluates the given VirtualMachines and
+// returns those with a scheduled virtual hardware upgrade.
+func NewVMPendingHardwareUpgradeSet(
+	vms []mo.VirtualMachine,
+	ageWarning int,
+	ageCritical int,
+	minVersion int,
+) VMPendingHardwareUpgradeSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMPendingHardwareUpgradeSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := VMPendingHardwareUpgradeSet{
+		Upgrades:       make([]VMPendingHardwareUpgrade, 0, len(vms)),
+		AgeWarning:     ageWarning,
+		AgeCritical:    ageCritical,
+		MinimumVersion: minVersion,
+	}
+
+	for _, vm := range vms {
+		upgrade, ok := NewVMPendingHardwareUpgrade(vm, minVersion)
+		if !ok {
+			continue
+		}
+
+		set.Upgrades = append(set.Upgrades, upgrade)
+	}
+
+	sort.Slice(set.Upgrades, func(i, j int) bool {
+		return strings.ToLower(set.Upgrades[i].VMName) < strings.ToLower(set.Upgrades[j].VMName)
+	})
+
+	return set
+}
+
+// IsPending indicates whether the scheduled upgrade has yet to be
+// executed.
+func (upgrade VMPendingHardwareUpgrade) IsPending() bool {
+	return upgrade.Status == string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending)
+}
+
+// IsFailed indicates whether the last attempt to run the scheduled upgrade
+// failed.
+func (upgrade VMPendingHardwareUpgrade) IsFailed() bool {
+	return upgrade.Status == string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed)
+}
+
+// PendingDays returns the number of days that the scheduled upgrade has
+// been pending or zero if the upgrade is not pending.
+func (upgrade VMPendingHardwareUpgrade) PendingDays() int {
+	if !upgrade.IsPending() || upgrade.PendingSince.IsZero() {
+		return 0
+	}
+
+	return int(time.Since(upgrade.PendingSince).Hours() / 24)
+}
+
+// Target returns the target virtual hardware version of the scheduled
+// upgrade or a placeholder if the latest supported version is used.
+func (upgrade VMPendingHardwareUpgrade) Target() string {
+	if upgrade.TargetVersion == "" {
+		return "latest supported"
+	}
+
+	return upgrade.TargetVersion
+}
+
+// isCriticalUpgrade indicates whether the scheduled upgrade has failed or
+// has been pending for at least the CRITICAL threshold.
+func (set VMPendingHardwareUpgradeSet) isCriticalUpgrade(upgrade VMPendingHardwareUpgrade) bool {
+	return upgrade.IsFailed() || upgrade.PendingDays() >= set.AgeCritical
+}
+
+// isWarningUpgrade indicates whether the scheduled upgrade has been pending
+// for at least the WARNING threshold or conflicts with the minimum version
+// policy. CRITICAL upgrades are not considered.
+func (set VMPendingHardwareUpgradeSet) isWarningUpgrade(upgrade VMPendingHardwareUpgrade) bool {
+	if set.isCriticalUpgrade(upgrade) {
+		return false
+	}
+
+	return upgrade.MinimumVersionConflict || upgrade.PendingDays() >= set.AgeWarning
+}
+
+// NumPending returns the number of scheduled upgrades which have yet to be
+// executed.
+func (set VMPendingHardwareUpgradeSet) NumPending() int {
+	var num int
+	for _, upgrade := range set.Upgrades {
+		if upgrade.IsPending() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumFailed returns the number of scheduled upgrades which failed.
+func (set VMPendingHardwareUpgradeSet) NumFailed() int {
+	var num int
+	for _, upgrade := range set.Upgrades {
+		if upgrade.IsFailed() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumConflicts returns the number of scheduled upgrades which conflict with
+// the minimum virtual hardware version policy.
+func (set VMPendingHardwareUpgradeSet) NumConflicts() int {
+	var num int
+	for _, upgrade := range set.Upgrades {
+		if upgrade.MinimumVersionConflict {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCritical returns the number of scheduled upgrades in a CRITICAL state.
+func (set VMPendingHardwareUpgradeSet) NumCritical() int {
+	var num int
+	for _, upgrade := range set.Upgrades {
+		if set.isCriticalUpgrade(upgrade) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of scheduled upgrades in a WARNING state.
+func (set VMPendingHardwareUpgradeSet) NumWarning() int {
+	var num int
+	for _, upgrade := range set.Upgrades {
+		if set.isWarningUpgrade(upgrade) {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsCriticalState indicates whether any scheduled upgrade has failed or has
+// been pending for at least the CRITICAL threshold.
+func (set VMPendingHardwareUpgradeSet) IsCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// IsWarningState indicates whether any scheduled upgrade has been pending
+// for at least the WARNING threshold or conflicts with the minimum virtual
+// hardware version policy.
+func (set VMPendingHardwareUpgradeSet) IsWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VMPendingHardwareUpgradePerfData generates performance data metrics from
+// the given evaluation results.
+func VMPendingHardwareUpgradePerfData(set VMPendingHardwareUpgradeSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_scheduled_upgrades",
+			Value: fmt.Sprintf("%d", len(set.Upgrades)),
+			Min:   "0",
+		},
+		{
+			Label: "pending_upgrades",
+			Value: fmt.Sprintf("%d", set.NumPending()),
+			Min:   "0",
+		},
+		{
+			Label: "failed_upgrades",
+			Value: fmt.Sprintf("%d", set.NumFailed()),
+			Min:   "0",
+		},
+		{
+			Label: "minimum_version_conflicts",
+			Value: fmt.Sprintf("%d", set.NumConflicts()),
+			Min:   "0",
+		},
+		{
+			Label: "upgrades_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "upgrades_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMPendingHardwareUpgradeOneLineCheckSummary is used to generate a
+// one-line Nagios service check results summary. This is the line most
+// prominent in notifications.
+func VMPendingHardwareUpgradeOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMPendingHardwareUpgradeSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMPendingHardwareUpgradeOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsCriticalState() || set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with problematic scheduled hardware upgrades detected (%d failed, %d pending, %d minimum version conflicts; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			set.NumFailed(),
+			set.NumPending(),
+			set.NumConflicts(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with problematic scheduled hardware upgrades detected (%d pending; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumPending(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMPendingHardwareUpgradeReport generates a summary of VMs with scheduled
+// virtual hardware upgrades along with various verbose details intended to
+// aid in troubleshooting check results at a glance. This information is
+// provided for use with the Long Service Output field commonly displayed on
+// the detailed service check results display in the web UI or in the body
+// of many notifications.
+func VMPendingHardwareUpgradeReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMPendingHardwareUpgradeSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMPendingHardwareUpgradeReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeUpgrades := func(include func(VMPendingHardwareUpgrade) bool) {
+		var found bool
+		for _, upgrade := range set.Upgrades {
+			if !include(upgrade) {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s, current: %s, target: %s, policy: %s, status: %s, pending days: %d)%s",
+				upgrade.VMName,
+				upgrade.PowerState,
+				upgrade.CurrentVersion,
+				upgrade.Target(),
+				upgrade.Policy,
+				upgrade.Status,
+				upgrade.PendingDays(),
+				nagios.CheckOutputEOL,
+			)
+
+			if upgrade.Fault != "" {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** fault: %s%s",
+					upgrade.Fault,
+					nagios.CheckOutputEOL,
+				)
+			}
+
+			if upgrade.MinimumVersionConflict {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** target version older than minimum version %s%d%s",
+					virtualHardwareVersionPrefix,
+					set.MinimumVersion,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with failed or long pending scheduled hardware upgrades (CRITICAL):%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeUpgrades(set.isCriticalUpgrade)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs with pending or conflicting scheduled hardware upgrades (WARNING):%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeUpgrades(set.isWarningUpgrade)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sOther VMs with scheduled hardware upgrades:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeUpgrades(func(upgrade VMPendingHardwareUpgrade) bool {
+		return !set.isCriticalUpgrade(upgrade) && !set.isWarningUpgrade(upgrade)
+	})
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Pending age thresholds (days): warning %d, critical %d%s",
+		set.AgeWarning,
+		set.AgeCritical,
+		nagios.CheckOutputEOL,
+	)
+
+	minVersion := "not specified"
+	if set.MinimumVersion >= 0 {
+		minVersion = fmt.Sprintf("%s%d", virtualHardwareVersionPrefix, set.MinimumVersion)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Minimum virtual hardware version: %s%s",
+		minVersion,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,296 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func hwUpgradeVM(
+	name string,
+	policy types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicy,
+	status types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatus,
+	target string,
+	modifiedDaysAgo int,
+) mo.VirtualMachine {
+	vm := mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			Version:  "vmx-15",
+			Modified: time.Now().Add(-time.Duration(modifiedDaysAgo) * 24 * time.Hour),
+		},
+	}
+
+	if policy != "" || status != "" {
+		vm.Config.ScheduledHardwareUpgradeInfo = &types.ScheduledHardwareUpgradeInfo{
+			UpgradePolicy:                  string(policy),
+			VersionKey:                     target,
+			ScheduledHardwareUpgradeStatus: string(status),
+		}
+	}
+
+	return vm
+}
+
+func TestNewVMPendingHardwareUpgrade(t *testing.T) {
+	const (
+		onSoftPowerOff = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyOnSoftPowerOff
+		never          = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyNever
+		pending        = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending
+		failed         = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed
+	)
+
+	withFault := hwUpgradeVM("vm1", never, failed, "vmx-19", 1)
+	withFault.Config.ScheduledHardwareUpgradeInfo.Fault = &types.LocalizedMethodFault{
+		LocalizedMessage: "insufficient permissions",
+	}
+
+	noConfig := hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 1)
+	noConfig.Config = nil
+
+	tests := map[string]struct {
+		vm            mo.VirtualMachine
+		minVersion    int
+		wantScheduled bool
+		wantConflict  bool
+		wantTarget    string
+		wantFault     string
+	}{
+		"no upgrade settings": {
+			vm:         hwUpgradeVM("vm1", "", "", "", 0),
+			minVersion: -1,
+		},
+		"never policy": {
+			vm:         hwUpgradeVM("vm1", never, pending, "vmx-19", 0),
+			minVersion: -1,
+		},
+		"missing configuration": {
+			vm:         noConfig,
+			minVersion: -1,
+		},
+		"scheduled upgrade": {
+			vm:            hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 1),
+			minVersion:    -1,
+			wantScheduled: true,
+			wantTarget:    "vmx-19",
+		},
+		"failed upgrade with never policy": {
+			vm:            withFault,
+			minVersion:    -1,
+			wantScheduled: true,
+			wantTarget:    "vmx-19",
+			wantFault:     "insufficient permissions",
+		},
+		"target below minimum version": {
+			vm:            hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-15", 1),
+			minVersion:    17,
+			wantScheduled: true,
+			wantConflict:  true,
+			wantTarget:    "vmx-15",
+		},
+		"target equal to minimum version": {
+			vm:            hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-17", 1),
+			minVersion:    17,
+			wantScheduled: true,
+			wantTarget:    "vmx-17",
+		},
+		"latest supported target ignores minimum version": {
+			vm:            hwUpgradeVM("vm1", onSoftPowerOff, pending, "", 1),
+			minVersion:    17,
+			wantScheduled: true,
+			wantTarget:    "latest supported",
+		},
+		"minimum version not evaluated": {
+			vm:            hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-15", 1),
+			minVersion:    -1,
+			wantScheduled: true,
+			wantTarget:    "vmx-15",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, scheduled := NewVMPendingHardwareUpgrade(tt.vm, tt.minVersion)
+
+			if scheduled != tt.wantScheduled {
+				t.Fatalf("want scheduled %t; got %t", tt.wantScheduled, scheduled)
+			}
+			if !scheduled {
+				return
+			}
+
+			if got.MinimumVersionConflict != tt.wantConflict {
+				t.Errorf("want minimum version conflict %t; got %t", tt.wantConflict, got.MinimumVersionConflict)
+			}
+			if got.Target() != tt.wantTarget {
+				t.Errorf("want target %q; got %q", tt.wantTarget, got.Target())
+			}
+			if got.Fault != tt.wantFault {
+				t.Errorf("want fault %q; got %q", tt.wantFault, got.Fault)
+			}
+			if got.CurrentVersion != "vmx-15" {
+				t.Errorf("want current version vmx-15; got %q", got.CurrentVersion)
+			}
+		})
+	}
+}
+
+func TestVMPendingHardwareUpgradePendingDays(t *testing.T) {
+	tests := map[string]struct {
+		upgrade VMPendingHardwareUpgrade
+		want    int
+	}{
+		"pending": {
+			upgrade: VMPendingHardwareUpgrade{
+				Status:       string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending),
+				PendingSince: time.Now().Add(-10*24*time.Hour - time.Hour),
+			},
+			want: 10,
+		},
+		"not pending": {
+			upgrade: VMPendingHardwareUpgrade{
+				Status:       string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusSuccess),
+				PendingSince: time.Now().Add(-10 * 24 * time.Hour),
+			},
+		},
+		"unknown pending since": {
+			upgrade: VMPendingHardwareUpgrade{
+				Status: string(types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.upgrade.PendingDays(); got != tt.want {
+				t.Errorf("want %d days; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMPendingHardwareUpgradeSet(t *testing.T) {
+	const (
+		onSoftPowerOff = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyOnSoftPowerOff
+		never          = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyNever
+		pending        = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending
+		failed         = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed
+		success        = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusSuccess
+	)
+
+	tests := map[string]struct {
+		vm           mo.VirtualMachine
+		minVersion   int
+		wantCritical bool
+		wantWarning  bool
+		wantUpgrades int
+	}{
+		"no scheduled upgrade": {
+			vm:         hwUpgradeVM("vm1", never, "", "", 0),
+			minVersion: -1,
+		},
+		"recently scheduled upgrade": {
+			vm:           hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 1),
+			minVersion:   -1,
+			wantUpgrades: 1,
+		},
+		"pending above warning age": {
+			vm:           hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 10),
+			minVersion:   -1,
+			wantWarning:  true,
+			wantUpgrades: 1,
+		},
+		"pending above critical age": {
+			vm:           hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 40),
+			minVersion:   -1,
+			wantCritical: true,
+			wantUpgrades: 1,
+		},
+		"failed upgrade": {
+			vm:           hwUpgradeVM("vm1", never, failed, "vmx-19", 1),
+			minVersion:   -1,
+			wantCritical: true,
+			wantUpgrades: 1,
+		},
+		"completed upgrade": {
+			vm:           hwUpgradeVM("vm1", onSoftPowerOff, success, "vmx-19", 40),
+			minVersion:   -1,
+			wantUpgrades: 1,
+		},
+		"target below minimum version": {
+			vm:           hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-15", 1),
+			minVersion:   17,
+			wantWarning:  true,
+			wantUpgrades: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMPendingHardwareUpgradeSet([]mo.VirtualMachine{tt.vm}, 7, 30, tt.minVersion)
+
+			if got := set.IsCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := set.IsWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+			if got := len(set.Upgrades); got != tt.wantUpgrades {
+				t.Errorf("want %d scheduled upgrades; got %d", tt.wantUpgrades, got)
+			}
+		})
+	}
+}
+
+func TestVMPendingHardwareUpgradePerfData(t *testing.T) {
+	const (
+		onSoftPowerOff = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyOnSoftPowerOff
+		never          = types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyNever
+		pending        = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusPending
+		failed         = types.ScheduledHardwareUpgradeInfoHardwareUpgradeStatusFailed
+	)
+
+	set := NewVMPendingHardwareUpgradeSet(
+		[]mo.VirtualMachine{
+			hwUpgradeVM("vm4", "", "", "", 0),
+			hwUpgradeVM("vm3", onSoftPowerOff, pending, "vmx-15", 1),
+			hwUpgradeVM("VM2", never, failed, "vmx-19", 1),
+			hwUpgradeVM("vm1", onSoftPowerOff, pending, "vmx-19", 10),
+		},
+		7,
+		30,
+		17,
+	)
+
+	var names []string
+	for _, upgrade := range set.Upgrades {
+		names = append(names, upgrade.VMName)
+	}
+	if d := cmp.Diff([]string{"vm1", "VM2", "vm3"}, names); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_scheduled_upgrades", Value: "3", Min: "0"},
+		{Label: "pending_upgrades", Value: "2", Min: "0"},
+		{Label: "failed_upgrades", Value: "1", Min: "0"},
+		{Label: "minimum_version_conflicts", Value: "1", Min: "0"},
+		{Label: "upgrades_critical", Value: "1", Min: "0"},
+		{Label: "upgrades_warning", Value: "2", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMPendingHardwareUpgradePerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineQuestionAutoAnswerFailed indicates that an automatic
+// answer to an interactive question blocking a VirtualMachine was attempted
+// but did not complete successfully.
+var ErrVirtualMachineQuestionAutoAnswerFailed = errors.New("virtual machine interactive question auto-answer failed")
+
+// VMQuestionAnswerResult is the outcome of evaluating (and optionally
+// applying) auto-answer mappings for an interactive question blocking a
+// VirtualMachine.
+type VMQuestionAnswerResult struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// Pattern is the auto-answer pattern which matched the interactive
+	// question. This is empty if no pattern matched.
+	Pattern string
+
+	// Answer is the label of the answer selected for the interactive
+	// question. This is empty if no pattern matched.
+	Answer string
+
+	// DryRun indicates that a matching answer was found but not applied.
+	DryRun bool
+
+	// Err is the error (if any) encountered selecting or applying the
+	// answer.
+	Err error
+}
+
+// VMQuestionAnswerResults is a collection of auto-answer outcomes.
+type VMQuestionAnswerResults []VMQuestionAnswerResult
+
+// String provides a human readable representation of an auto-answer
+// outcome.
+func (r VMQuestionAnswerResult) String() string {
+	switch {
+	case r.Pattern == "":
+		return fmt.Sprintf("%s: no matching auto-answer", r.VMName)
+	case r.Err != nil:
+		return fmt.Sprintf("%s: failed to answer %q (%v)", r.VMName, r.Answer, r.Err)
+	case r.DryRun:
+		return fmt.Sprintf("%s: would answer %q (dry-run)", r.VMName, r.Answer)
+	default:
+		return fmt.Sprintf("%s: answered %q", r.VMName, r.Answer)
+	}
+}
+
+// NumMatched returns the number of VirtualMachines with an interactive
+// question matching an auto-answer pattern.
+func (rs VMQuestionAnswerResults) NumMatched() int {
+	var num int
+	for _, r := range rs {
+		if r.Pattern != "" {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumAnswered returns the number of VirtualMachines with an interactive
+// question successfully answered.
+func (rs VMQuestionAnswerResults) NumAnswered() int {
+	var num int
+	for _, r := range rs {
+		if r.Pattern != "" && !r.DryRun && r.Err == nil {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumFailed returns the number of VirtualMachines with an interactive
+// question matching an auto-answer pattern which could not be answered.
+func (rs VMQuestionAnswerResults) NumFailed() int {
+	var num int
+	for _, r := range rs {
+		if r.Pattern != "" && r.Err != nil {
+			num++
+		}
+	}
+
+	return num
+}
+
+// Remediated indicates whether an interactive question was successfully
+// answered for every VirtualMachine in the collection.
+func (rs VMQuestionAnswerResults) Remediated() bool {
+	return len(rs) > 0 && rs.NumAnswered() == len(rs)
+}
+
+// VMQuestionAutoAnswer is a PATTERN=ANSWER mapping used to automatically
+// answer matching interactive questions.
+type VMQuestionAutoAnswer struct {
+
+	// Pattern is the case-insensitive regular expression as specified by
+	// the user.
+	Pattern string
+
+	// Regexp is the compiled (case-insensitive) form of Pattern. Pattern is
+	// compiled on use if not set.
+	Regexp *regexp.Regexp
+
+	// Answer is the label or key of the possible answer to apply.
+	Answer string
+}
+
+// AnswerVMQuestions evaluates the interactive question blocking each given
+// VirtualMachine against the given PATTERN=ANSWER auto-answer mappings.
+// Patterns are case-insensitive regular expressions matched against the
+// question text and message IDs and are evaluated in the given order; the
+// first match is used. Matching answers are only applied if requested, otherwise
+// they are recorded as a dry-run. The outcome for each VirtualMachine is
+// returned.
+func AnswerVMQuestions(
+	ctx context.Context,
+	c *vim25.Client,
+	vms []mo.VirtualMachine,
+	autoAnswers []VMQuestionAutoAnswer,
+	apply bool,
+) VMQuestionAnswerResults {
+
+	funcTimeStart := time.Now()
+
+	results := make(VMQuestionAnswerResults, 0, len(vms))
+
+	defer func(results *VMQuestionAnswerResults) {
+		logger.Printf(
+			"It took %v to execute AnswerVMQuestions func (for %d VMs, %d matched).\n",
+			time.Since(funcTimeStart),
+			len(vms),
+			results.NumMatched(),
+		)
+	}(&results)
+
+	autoAnswerPatterns := make([]*regexp.Regexp, len(autoAnswers))
+	for i, aa := range autoAnswers {
+		autoAnswerPatterns[i] = aa.Regexp
+		if aa.Regexp != nil {
+			continue
+		}
+
+		re, err := regexp.Compile("(?i)" + aa.Pattern)
+		if err != nil {
+			logger.Printf("skipping invalid auto-answer pattern %q: %v", aa.Pattern, err)
+
+			continue
+		}
+		autoAnswerPatterns[i] = re
+	}
+
+	sortedVMs := make([]mo.VirtualMachine, len(vms))
+	copy(sortedVMs, vms)
+
+	sort.Slice(sortedVMs, func(i, j int) bool {
+		return strings.ToLower(sortedVMs[i].Name) < strings.ToLower(sortedVMs[j].Name)
+	})
+
+	for _, vm := range sortedVMs {
+		result := VMQuestionAnswerResult{VMName: vm.Name}
+
+		question := vm.Summary.Runtime.Question
+		if question == nil {
+			results = append(results, result)
+
+			continue
+		}
+
+		for i, re := range autoAnswerPatterns {
+			if re == nil {
+				continue
+			}
+
+			if questionMatches(re, question) {
+				result.Pattern = autoAnswers[i].Pattern
+				result.Answer = autoAnswers[i].Answer
+
+				break
+			}
+		}
+
+		if result.Pattern == "" {
+			logger.Printf("no auto-answer pattern matched question for VM %s", vm.Name)
+			results = append(results, result)
+
+			continue
+		}
+
+		choice, label, err := questionChoice(question, result.Answer)
+		switch {
+		case err != nil:
+			result.Err = err
+
+		case !apply:
+			result.Answer = label
+			result.DryRun = true
+
+		default:
+			result.Answer = label
+			result.Err = answerVMQuestion(ctx, c, vm, question.Id, choice)
+		}
+
+		if result.Err != nil {
+			logger.Printf("auto-answer for VM %s failed: %v", vm.Name, result.Err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// questionMatches indicates whether the given pattern matches the text or
+// any message ID of the given interactive question.
+func questionMatches(re *regexp.Regexp, question *types.VirtualMachineQuestionInfo) bool {
+	if re.MatchString(question.Text) {
+		return true
+	}
+
+	for _, msg := range question.Message {
+		if re.MatchString(msg.Id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// questionChoice returns the key and label of the possible answer to the
+// given interactive question matching the given answer by label or key
+// (case-insensitive). An error is returned if no possible answer matches.
+func questionChoice(question *types.VirtualMachineQuestionInfo, answer string) (string, string, error) {
+	labels := make([]string, 0, len(question.Choice.ChoiceInfo))
+	for _, e := range question.Choice.ChoiceInfo {
+		ed := e.GetElementDescription()
+		if strings.EqualFold(ed.Label, answer) || strings.EqualFold(ed.Key, answer) {
+			return ed.Key, ed.Label, nil
+		}
+		labels = append(labels, ed.Label)
+	}
+
+	return "", "", fmt.Errorf(
+		"%w: answer %q not in possible answers %v",
+		ErrVirtualMachineQuestionAutoAnswerFailed,
+		answer,
+		labels,
+	)
+}
+
+// answerVMQuestion applies the given answer choice to the interactive
+// question blocking the given VirtualMachine.
+func answerVMQuestion(ctx context.Context, c *vim25.Client, vm mo.VirtualMachine, questionID string, choice string) error {
+	if err := object.NewVirtualMachine(c, vm.Reference()).Answer(ctx, questionID, choice); err != nil {
+		return fmt.Errorf(
+			"%w: %v",
+			ErrVirtualMachineQuestionAutoAnswerFailed,
+			err,
+		)
+	}
+
+	return nil
+}
+
+// vmQuestionAnswerResultsReport writes the outcome of evaluating auto-answer
+// mappings for each VirtualMachine. Nothing is written if no auto-answer
+// mappings were specified.
+func vmQuestionAnswerResultsReport(
+	w io.Writer,
+	answerResults VMQuestionAnswerResults,
+) {
+
+	if answerResults == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(
+		w,
+		"%sAuto-answer results (%d matched, %d answered, %d failed):%s%s",
+		nagios.CheckOutputEOL,
+		answerResults.NumMatched(),
+		answerResults.NumAnswered(),
+		answerResults.NumFailed(),
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	if len(answerResults) == 0 {
+		_, _ = fmt.Fprintf(w, "* None %s", nagios.CheckOutputEOL)
+
+		return
+	}
+
+	for _, r := range answerResults {
+		_, _ = fmt.Fprintf(
+			w,
+			"* %s%s",
+			r,
+			nagios.CheckOutputEOL,
+		)
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+)
+
+// ErrVirtualMachineRDMsFound indicates that one or more VirtualMachines use
+// Raw Device Mappings which are not explicitly allowed.
+var ErrVirtualMachineRDMsFound = errors.New("virtual machines with raw device mappings found")
+
+// VMRDM represents a virtual disk backed by a Raw Device Mapping (RDM).
+type VMRDM struct {
+
+	// Label is the device label (e.g., Hard disk 2).
+	Label string
+
+	// FileName is the path to the RDM mapping file.
+	FileName string
+
+	// DeviceName is the host specific name of the mapped LUN (e.g.,
+	// vml.0200...).
+	DeviceName string
+
+	// CompatibilityMode is the RDM compatibility mode (physicalMode or
+	// virtualMode).
+	CompatibilityMode string
+
+	// DiskMode is the disk mode (e.g., persistent). The disk mode is
+	// ignored by ESXi for physical compatibility mode RDMs.
+	DiskMode string
+}
+
+// VMRDMUsage represents a VirtualMachine with one or more disks backed by a
+// Raw Device Mapping. RDMs constrain VM mobility (e.g., vMotion, Storage
+// vMotion) and physical compatibility mode RDMs are excluded from
+// snapshots and therefore most backups.
+type VMRDMUsage struct {
+
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// PowerState is the power state of the VirtualMachine.
+	PowerState types.VirtualMachinePowerState
+
+	// RDMs is the collection of RDM backed disks for the VirtualMachine.
+	RDMs []VMRDM
+
+	// Allowed indicates whether the VirtualMachine is permitted to use
+	// RDMs.
+	Allowed bool
+}
+
+// VMRDMUsageSet is a collection of VMRDMUsage values.
+type VMRDMUsageSet []VMRDMUsage
+
+// NewVMRDMUsage evaluates the virtual disks for the given VirtualMachine.
+// RDMs are considered allowed if the VirtualMachine name is in the given
+// list of allowed VM names (case-insensitive).
+func NewVMRDMUsage(vm mo.VirtualMachine, allowedVMs []string) VMRDMUsage {
+
+	usage := VMRDMUsage{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+		Allowed:    textutils.InList(vm.Name, allowedVMs, true),
+	}
+
+	if vm.Config == nil {
+		return usage
+	}
+
+	for _, device := range vm.Config.Hardware.Device {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+
+		backing, ok := disk.Backing.(*types.VirtualDiskRawDiskMappingVer1BackingInfo)
+		if !ok {
+			continue
+		}
+
+		var label string
+		if info := disk.GetVirtualDevice().DeviceInfo; info != nil {
+			label = info.GetDescription().Label
+		}
+
+		usage.RDMs = append(usage.RDMs, VMRDM{
+			Label:             label,
+			FileName:          backing.FileName,
+			DeviceName:        backing.DeviceName,
+			CompatibilityMode: backing.CompatibilityMode,
+			DiskMode:          backing.DiskMode,
+		})
+	}
+
+	return usage
+}
+
+// NewVMRDMUsageSet evaluates the given VirtualMachines and returns those
+// with disks backed by Raw Device Mappings.
+func NewVMRDMUsageSet(vms []mo.VirtualMachine, allowedVMs []string) VMRDMUsageSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMRDMUsageSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := make(VMRDMUsageSet, 0, len(vms))
+	for _, vm := range vms {
+		usage := NewVMRDMUsage(vm, allowedVMs)
+		if len(usage.RDMs) == 0 {
+			continue
+		}
+
+		set = append(set, usage)
+	}
+
+	sort.Slice(set, func(i, j int) bool {
+		return strings.ToLower(set[i].VMName) < strings.ToLower(set[j].VMName)
+	})
+
+	return set
+}
+
+// NumDisallowed returns the number of VirtualMachines using RDMs which are
+// not explicitly allowed.
+func (set VMRDMUsageSet) NumDisallowed() int {
+	var num int
+	for _, usage := range set {
+		if !usage.Allowed {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumAllowed returns the number of VirtualMachines using RDMs which are
+// explicitly allowed.
+func (set VMRDMUsageSet) NumAllowed() int {
+	return len(set) - set.NumDisallowed()
+}
+
+// numRDMsByMode returns the number of RDMs with the given compatibility
+// mode for VirtualMachines which are not explicitly allowed.
+func (set VMRDMUsageSet) numRDMsByMode(mode types.VirtualDiskCompatibilityMode) int {
+	var num int
+	for _, usage := range set {
+		if usage.Allowed {
+			continue
+		}
+
+		for _, rdm := range usage.RDMs {
+			if rdm.CompatibilityMode == string(mode) {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumPhysicalModeRDMs returns the number of physical compatibility mode
+// RDMs for VirtualMachines which are not explicitly allowed.
+func (set VMRDMUsageSet) NumPhysicalModeRDMs() int {
+	return set.numRDMsByMode(types.VirtualDiskCompatibilityModePhysicalMode)
+}
+
+// NumVirtualModeRDMs returns the number of virtual compatibility mode RDMs
+// for VirtualMachines which are not explicitly allowed.
+func (set VMRDMUsageSet) NumVirtualModeRDMs() int {
+	return set.numRDMsByMode(types.VirtualDiskCompatibilityModeVirtualMode)
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set uses RDMs
+// which are not explicitly allowed.
+func (set VMRDMUsageSet) IsWarningState() bool {
+	return set.NumDisallowed() > 0
+}
+
+// VMRDMUsagePerfData generates performance data metrics from the given
+// evaluation results.
+func VMRDMUsagePerfData(set VMRDMUsageSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_with_rdms",
+			Value: fmt.Sprintf("%d", set.NumDisallowed()),
+			Min:   "0",
+		},
+		{
+			Label: "physical_mode_rdms",
+			Value: fmt.Sprintf("%d", set.NumPhysicalModeRDMs()),
+			Min:   "0",
+		},
+		{
+			Label: "virtual_mode_rdms",
+			Value: fmt.Sprintf("%d", set.NumVirtualModeRDMs()),
+			Min:   "0",
+		},
+		{
+			Label: "allowed_vms_with_rdms",
+			Value: fmt.Sprintf("%d", set.NumAllowed()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMRDMUsageOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMRDMUsageOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMRDMUsageSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMRDMUsageOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with Raw Device Mappings detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumDisallowed(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with Raw Device Mappings detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMRDMUsageReport generates a summary of VMs using Raw Device Mappings
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VMRDMUsageReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMRDMUsageSet,
+	allowedVMs []string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMRDMUsageReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	writeVMs := func(allowed bool) {
+		var found bool
+		for _, usage := range set {
+			if usage.Allowed != allowed {
+				continue
+			}
+
+			found = true
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s)%s",
+				usage.VMName,
+				usage.PowerState,
+				nagios.CheckOutputEOL,
+			)
+
+			for _, rdm := range usage.RDMs {
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s: %s, %s [%s, %s]%s",
+					rdm.Label,
+					rdm.CompatibilityMode,
+					rdm.DiskMode,
+					rdm.DeviceName,
+					rdm.FileName,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+
+		if !found {
+			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+		}
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with Raw Device Mappings (limits mobility, physical mode excluded from snapshots):%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(false)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sVMs with allowed Raw Device Mappings:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	writeVMs(true)
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified VMs permitted to use Raw Device Mappings (%d): [%v]%s",
+		len(allowedVMs),
+		strings.Join(allowedVMs, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func rdmDisk(label string, mode types.VirtualDiskCompatibilityMode) types.BaseVirtualDevice {
+	return &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			DeviceInfo: &types.Description{Label: label},
+			Backing: &types.VirtualDiskRawDiskMappingVer1BackingInfo{
+				VirtualDeviceFileBackingInfo: types.VirtualDeviceFileBackingInfo{
+					FileName: "[ds1] vm/vm_1.vmdk",
+				},
+				CompatibilityMode: string(mode),
+				DeviceName:        "vml.0200000000",
+				DiskMode:          string(types.VirtualDiskModePersistent),
+			},
+		},
+	}
+}
+
+func rdmVM(name string, devices ...types.BaseVirtualDevice) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			Hardware: types.VirtualHardware{Device: devices},
+		},
+	}
+}
+
+func TestNewVMRDMUsage(t *testing.T) {
+	flatDisk := &types.VirtualDisk{
+		VirtualDevice: types.VirtualDevice{
+			Backing: flatDiskBacking("[ds1] vm/vm.vmdk", types.VirtualDiskModePersistent),
+		},
+	}
+
+	tests := map[string]struct {
+		vm          mo.VirtualMachine
+		allowedVMs  []string
+		want        []VMRDM
+		wantAllowed bool
+	}{
+		"no RDMs": {
+			vm: rdmVM("vm1", flatDisk, &types.VirtualCdrom{}),
+		},
+		"physical and virtual mode RDMs": {
+			vm: rdmVM("vm1",
+				flatDisk,
+				rdmDisk("Hard disk 2", types.VirtualDiskCompatibilityModePhysicalMode),
+				rdmDisk("Hard disk 3", types.VirtualDiskCompatibilityModeVirtualMode),
+			),
+			want: []VMRDM{
+				{
+					Label:             "Hard disk 2",
+					FileName:          "[ds1] vm/vm_1.vmdk",
+					DeviceName:        "vml.0200000000",
+					CompatibilityMode: string(types.VirtualDiskCompatibilityModePhysicalMode),
+					DiskMode:          string(types.VirtualDiskModePersistent),
+				},
+				{
+					Label:             "Hard disk 3",
+					FileName:          "[ds1] vm/vm_1.vmdk",
+					DeviceName:        "vml.0200000000",
+					CompatibilityMode: string(types.VirtualDiskCompatibilityModeVirtualMode),
+					DiskMode:          string(types.VirtualDiskModePersistent),
+				},
+			},
+		},
+		"allowed case-insensitive": {
+			vm:         rdmVM("vm1", rdmDisk("Hard disk 1", types.VirtualDiskCompatibilityModePhysicalMode)),
+			allowedVMs: []string{"VM1"},
+			want: []VMRDM{
+				{
+					Label:             "Hard disk 1",
+					FileName:          "[ds1] vm/vm_1.vmdk",
+					DeviceName:        "vml.0200000000",
+					CompatibilityMode: string(types.VirtualDiskCompatibilityModePhysicalMode),
+					DiskMode:          string(types.VirtualDiskModePersistent),
+				},
+			},
+			wantAllowed: true,
+		},
+		"missing configuration": {
+			vm: mo.VirtualMachine{ManagedEntity: mo.ManagedEntity{Name: "vm1"}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMRDMUsage(tt.vm, tt.allowedVMs)
+
+			if d := cmp.Diff(tt.want, got.RDMs); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+			if got.Allowed != tt.wantAllowed {
+				t.Errorf("want allowed %t; got %t", tt.wantAllowed, got.Allowed)
+			}
+		})
+	}
+}
+
+func TestNewVMRDMUsageSet(t *testing.T) {
+	const (
+		physical = types.VirtualDiskCompatibilityModePhysicalMode
+		virtual  = types.VirtualDiskCompatibilityModeVirtualMode
+	)
+
+	tests := map[string]struct {
+		vms              []mo.VirtualMachine
+		allowedVMs       []string
+		wantVMs          []string
+		wantDisallowed   int
+		wantAllowed      int
+		wantPhysical     int
+		wantVirtual      int
+		wantWarningState bool
+	}{
+		"no RDMs": {
+			vms: []mo.VirtualMachine{rdmVM("vm1")},
+		},
+		"disallowed RDMs": {
+			vms: []mo.VirtualMachine{
+				rdmVM("vm2", rdmDisk("Hard disk 1", virtual)),
+				rdmVM("VM1", rdmDisk("Hard disk 2", physical)),
+			},
+			wantVMs:          []string{"VM1", "vm2"},
+			wantDisallowed:   2,
+			wantPhysical:     1,
+			wantVirtual:      1,
+			wantWarningState: true,
+		},
+		"allowed RDMs": {
+			vms:         []mo.VirtualMachine{rdmVM("SQL01", rdmDisk("Hard disk 1", physical))},
+			allowedVMs:  []string{"sql01"},
+			wantVMs:     []string{"SQL01"},
+			wantAllowed: 1,
+		},
+		"allowed and disallowed RDMs": {
+			vms: []mo.VirtualMachine{
+				rdmVM("sql01", rdmDisk("Hard disk 1", physical)),
+				rdmVM("vm1", rdmDisk("Hard disk 1", virtual), rdmDisk("Hard disk 2", virtual)),
+			},
+			allowedVMs:       []string{"sql01"},
+			wantVMs:          []string{"sql01", "vm1"},
+			wantDisallowed:   1,
+			wantAllowed:      1,
+			wantVirtual:      2,
+			wantWarningState: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMRDMUsageSet(tt.vms, tt.allowedVMs)
+
+			var names []string
+			for _, usage := range set {
+				names = append(names, usage.VMName)
+			}
+			if d := cmp.Diff(tt.wantVMs, names); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := set.NumDisallowed(); got != tt.wantDisallowed {
+				t.Errorf("want %d disallowed; got %d", tt.wantDisallowed, got)
+			}
+			if got := set.NumAllowed(); got != tt.wantAllowed {
+				t.Errorf("want %d allowed; got %d", tt.wantAllowed, got)
+			}
+			if got := set.NumPhysicalModeRDMs(); got != tt.wantPhysical {
+				t.Errorf("want %d physical mode RDMs; got %d", tt.wantPhysical, got)
+			}
+			if got := set.NumVirtualModeRDMs(); got != tt.wantVirtual {
+				t.Errorf("want %d virtual mode RDMs; got %d", tt.wantVirtual, got)
+			}
+			if got := set.IsWarningState(); got != tt.wantWarningState {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarningState, got)
+			}
+		})
+	}
+}
+
+func TestVMRDMUsagePerfData(t *testing.T) {
+	set := VMRDMUsageSet{
+		{
+			VMName: "vm1",
+			RDMs: []VMRDM{
+				{CompatibilityMode: string(types.VirtualDiskCompatibilityModePhysicalMode)},
+				{CompatibilityMode: string(types.VirtualDiskCompatibilityModeVirtualMode)},
+			},
+		},
+		{
+			VMName:  "sql01",
+			RDMs:    []VMRDM{{CompatibilityMode: string(types.VirtualDiskCompatibilityModePhysicalMode)}},
+			Allowed: true,
+		},
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_with_rdms", Value: "1", Min: "0"},
+		{Label: "physical_mode_rdms", Value: "1", Min: "0"},
+		{Label: "virtual_mode_rdms", Value: "1", Min: "0"},
+		{Label: "allowed_vms_with_rdms", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMRDMUsagePerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,502 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// ErrVirtualMachineRestoreDetected indicates that one or more
+// VirtualMachines have an instance UUID or creation date which changed since
+// the last plugin run.
+var ErrVirtualMachineRestoreDetected = errors.New("virtual machine restores or re-registrations detected since last run")
+
+// VMIdentity is the set of VirtualMachine properties expected to remain
+// unchanged for the life of a VirtualMachine. A change in these values
+// indicates that the VirtualMachine was restored or re-registered.
+type VMIdentity struct {
+	// ID is the Managed Object ID of the VirtualMachine.
+	ID string `json:"id"`
+
+	// InstanceUUID is the vCenter-specific instance UUID of the
+	// VirtualMachine.
+	InstanceUUID string `json:"instance_uuid"`
+
+	// CreateDate is the creation date of the VirtualMachine. This is nil if
+	// not reported by the vSphere environment.
+	CreateDate *time.Time `json:"create_date,omitempty"`
+}
+
+// VMIdentityBaseline is the identity of all evaluated VirtualMachines as
+// recorded by a plugin run.
+type VMIdentityBaseline struct {
+	// Recorded is when the baseline was recorded.
+	Recorded time.Time `json:"recorded"`
+
+	// VMs is the identity of each VirtualMachine keyed by VirtualMachine
+	// name.
+	VMs map[string]VMIdentity `json:"vms"`
+}
+
+// VMRestore is a VirtualMachine with an identity which changed since the
+// baseline.
+type VMRestore struct {
+	// VMName is the name of the VirtualMachine.
+	VMName string
+
+	// Previous is the VirtualMachine identity recorded in the baseline.
+	Previous VMIdentity
+
+	// Current is the VirtualMachine identity for the current plugin run.
+	Current VMIdentity
+}
+
+// VMRestoreSet is the result of comparing current VirtualMachine identities
+// against a stored baseline.
+type VMRestoreSet struct {
+	// Current is the VirtualMachine identities for the current plugin run.
+	Current VMIdentityBaseline
+
+	// Baseline is the VirtualMachine identities recorded by a previous
+	// plugin run. This is nil if a baseline was not previously recorded.
+	Baseline *VMIdentityBaseline
+
+	// Restores is the collection of VirtualMachines with an instance UUID or
+	// creation date which changed since the baseline.
+	Restores []VMRestore
+
+	// NewVMs is the number of evaluated VirtualMachines not present in the
+	// baseline.
+	NewVMs int
+}
+
+// NewVMIdentityBaseline records the identity of each of the given
+// VirtualMachines. VirtualMachines sharing a name with another evaluated
+// VirtualMachine cannot be reliably tracked between plugin runs and are
+// omitted.
+func NewVMIdentityBaseline(vms []mo.VirtualMachine) VMIdentityBaseline {
+
+	funcTimeStart := time.Now()
+
+	current := VMIdentityBaseline{
+		Recorded: time.Now(),
+		VMs:      make(map[string]VMIdentity, len(vms)),
+	}
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMIdentityBaseline func (for %d VMs, yielding %d VMs).\n",
+			time.Since(funcTimeStart),
+			len(vms),
+			len(current.VMs),
+		)
+	}()
+
+	duplicates := make(map[string]struct{})
+	for _, vm := range vms {
+		if _, ok := current.VMs[vm.Name]; ok {
+			duplicates[vm.Name] = struct{}{}
+
+			continue
+		}
+
+		identity := VMIdentity{
+			ID: vm.Self.Value,
+		}
+
+		if vm.Config != nil {
+			identity.InstanceUUID = vm.Config.InstanceUuid
+			identity.CreateDate = vm.Config.CreateDate
+		}
+
+		current.VMs[vm.Name] = identity
+	}
+
+	for name := range duplicates {
+		logger.Printf(
+			"VM name %s is not unique; omitting VMs with this name from evaluation",
+			name,
+		)
+
+		delete(current.VMs, name)
+	}
+
+	return current
+}
+
+// LoadVMIdentityBaseline reads the VirtualMachine identity baseline from the
+// specified state file. A nil baseline is returned if the state file does
+// not exist.
+func LoadVMIdentityBaseline(filename string) (*VMIdentityBaseline, error) {
+	data, err := readStateFile(filename)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to load VM identity baseline: %w", err)
+
+	case data == nil:
+		return nil, nil
+	}
+
+	var baseline VMIdentityBaseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf(
+			"failed to parse VM identity state file %s: %w",
+			filename,
+			err,
+		)
+	}
+
+	return &baseline, nil
+}
+
+// SaveVMIdentityBaseline writes the given VirtualMachine identities to the
+// specified state file for use as the baseline by the next plugin run.
+func SaveVMIdentityBaseline(filename string, baseline VMIdentityBaseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode VM identity baseline: %w", err)
+	}
+
+	if err := writeStateFile(filename, data); err != nil {
+		return fmt.Errorf("failed to save VM identity baseline: %w", err)
+	}
+
+	return nil
+}
+
+// NewVMRestoreSet compares the current VirtualMachine identities against the
+// given baseline.
+func NewVMRestoreSet(current VMIdentityBaseline, baseline *VMIdentityBaseline) VMRestoreSet {
+
+	set := VMRestoreSet{
+		Current:  current,
+		Baseline: baseline,
+	}
+
+	if baseline == nil {
+		return set
+	}
+
+	for name, identity := range current.VMs {
+		previous, ok := baseline.VMs[name]
+		if !ok {
+			set.NewVMs++
+
+			continue
+		}
+
+		restore := VMRestore{
+			VMName:   name,
+			Previous: previous,
+			Current:  identity,
+		}
+
+		if restore.InstanceUUIDChanged() || restore.CreateDateChanged() {
+			set.Restores = append(set.Restores, restore)
+		}
+	}
+
+	sort.Slice(set.Restores, func(i, j int) bool {
+		return strings.ToLower(set.Restores[i].VMName) < strings.ToLower(set.Restores[j].VMName)
+	})
+
+	return set
+
+}
+
+// InstanceUUIDChanged indicates whether the instance UUID of the
+// VirtualMachine changed since the baseline.
+func (restore VMRestore) InstanceUUIDChanged() bool {
+	return restore.Previous.InstanceUUID != "" &&
+		restore.Current.InstanceUUID != "" &&
+		restore.Previous.InstanceUUID != restore.Current.InstanceUUID
+}
+
+// CreateDateChanged indicates whether the creation date of the
+// VirtualMachine changed since the baseline.
+func (restore VMRestore) CreateDateChanged() bool {
+	return restore.Previous.CreateDate != nil &&
+		restore.Current.CreateDate != nil &&
+		!restore.Previous.CreateDate.Equal(*restore.Current.CreateDate)
+}
+
+// Reregistered indicates whether the Managed Object ID of the
+// VirtualMachine changed since the baseline.
+func (restore VMRestore) Reregistered() bool {
+	return restore.Previous.ID != restore.Current.ID
+}
+
+// NextBaseline returns the VirtualMachine identities to record as the
+// baseline for the next plugin run. VirtualMachines present in the previous
+// baseline but not evaluated by this plugin run (e.g., temporarily
+// unregistered or filtered) are retained so that a later restore of those
+// VirtualMachines is still detected.
+func (set VMRestoreSet) NextBaseline() VMIdentityBaseline {
+	next := VMIdentityBaseline{
+		Recorded: set.Current.Recorded,
+		VMs:      make(map[string]VMIdentity, len(set.Current.VMs)),
+	}
+
+	if set.Baseline != nil {
+		for name, identity := range set.Baseline.VMs {
+			next.VMs[name] = identity
+		}
+	}
+
+	for name, identity := range set.Current.VMs {
+		next.VMs[name] = identity
+	}
+
+	return next
+}
+
+// HasBaseline indicates whether a baseline was available for comparison.
+func (set VMRestoreSet) HasBaseline() bool {
+	return set.Baseline != nil
+}
+
+// HasRestores indicates whether any VirtualMachines were restored or
+// re-registered since the baseline.
+func (set VMRestoreSet) HasRestores() bool {
+	return len(set.Restores) > 0
+}
+
+// NumInstanceUUIDChanged returns the number of VirtualMachines with an
+// instance UUID which changed since the baseline.
+func (set VMRestoreSet) NumInstanceUUIDChanged() int {
+	var num int
+	for _, restore := range set.Restores {
+		if restore.InstanceUUIDChanged() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumCreateDateChanged returns the number of VirtualMachines with a
+// creation date which changed since the baseline.
+func (set VMRestoreSet) NumCreateDateChanged() int {
+	var num int
+	for _, restore := range set.Restores {
+		if restore.CreateDateChanged() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// VMRestoreDetectionPerfData generates performance data metrics from the
+// given evaluation results.
+func VMRestoreDetectionPerfData(set VMRestoreSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_restored",
+			Value: fmt.Sprintf("%d", len(set.Restores)),
+			Min:   "0",
+		},
+		{
+			Label: "vms_instance_uuid_changed",
+			Value: fmt.Sprintf("%d", set.NumInstanceUUIDChanged()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_create_date_changed",
+			Value: fmt.Sprintf("%d", set.NumCreateDateChanged()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_new",
+			Value: fmt.Sprintf("%d", set.NewVMs),
+			Min:   "0",
+		},
+	}
+}
+
+// VMRestoreDetectionOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VMRestoreDetectionOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMRestoreSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMRestoreDetectionOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case !set.HasBaseline():
+		return fmt.Sprintf(
+			"%s: VM identity baseline recorded (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	case set.HasRestores():
+		return fmt.Sprintf(
+			"%s: %d VMs restored or re-registered since last run (%d instance UUID changes, %d creation date changes; evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(set.Restores),
+			set.NumInstanceUUIDChanged(),
+			set.NumCreateDateChanged(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VM restores or re-registrations detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMRestoreDetectionReport generates a summary of VMs restored or
+// re-registered since the baseline along with various verbose details
+// intended to aid in troubleshooting check results at a glance. This
+// information is provided for use with the Long Service Output field
+// commonly displayed on the detailed service check results display in the
+// web UI or in the body of many notifications.
+func VMRestoreDetectionReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMRestoreSet,
+	stateFile string,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMRestoreDetectionReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	formatCreateDate := func(t *time.Time) string {
+		if t == nil {
+			return "unknown"
+		}
+
+		return t.Format(time.RFC3339)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs restored or re-registered since baseline:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, restore := range set.Restores {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s%s",
+			restore.VMName,
+			nagios.CheckOutputEOL,
+		)
+
+		if restore.InstanceUUIDChanged() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** instance UUID: %s -> %s%s",
+				restore.Previous.InstanceUUID,
+				restore.Current.InstanceUUID,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if restore.CreateDateChanged() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** creation date: %s -> %s%s",
+				formatCreateDate(restore.Previous.CreateDate),
+				formatCreateDate(restore.Current.CreateDate),
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		if restore.Reregistered() {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** Managed Object ID: %s -> %s%s",
+				restore.Previous.ID,
+				restore.Current.ID,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if len(set.Restores) == 0 {
+		_, _ = fmt.Fprintf(&report, "* None%s", nagios.CheckOutputEOL)
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* State file: %s%s",
+		stateFile,
+		nagios.CheckOutputEOL,
+	)
+
+	baselineRecorded := "none (baseline recorded by this run)"
+	if set.HasBaseline() {
+		baselineRecorded = set.Baseline.Recorded.Format(time.RFC3339)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Baseline recorded: %s%s",
+		baselineRecorded,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* VMs not present in baseline: %d%s",
+		set.NewVMs,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
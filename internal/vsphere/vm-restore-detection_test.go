@@ -0,0 +1,301 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+var restoreCreated = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func restoreVM(name string, id string, instanceUUID string, created time.Time) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{
+			ExtensibleManagedObject: mo.ExtensibleManagedObject{
+				Self: types.ManagedObjectReference{Type: MgObjRefTypeVirtualMachine, Value: id},
+			},
+			Name: name,
+		},
+		Config: &types.VirtualMachineConfigInfo{
+			InstanceUuid: instanceUUID,
+			CreateDate:   &created,
+		},
+	}
+}
+
+func TestNewVMIdentityBaseline(t *testing.T) {
+	noConfig := restoreVM("vm3", "vm-3", "", restoreCreated)
+	noConfig.Config = nil
+
+	got := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+		restoreVM("dup", "vm-4", "uuid-4", restoreCreated),
+		restoreVM("dup", "vm-5", "uuid-5", restoreCreated),
+		restoreVM("dup", "vm-6", "uuid-6", restoreCreated),
+		noConfig,
+	})
+
+	created := restoreCreated
+	want := map[string]VMIdentity{
+		"vm1": {ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+		"vm3": {ID: "vm-3"},
+	}
+
+	if d := cmp.Diff(want, got.VMs); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if got.Recorded.IsZero() {
+		t.Error("want recorded time; got zero value")
+	}
+}
+
+func TestVMRestoreChanges(t *testing.T) {
+	created := restoreCreated
+	later := restoreCreated.AddDate(0, 1, 0)
+
+	tests := map[string]struct {
+		previous          VMIdentity
+		current           VMIdentity
+		wantUUIDChanged   bool
+		wantCreateChanged bool
+		wantReregistered  bool
+	}{
+		"unchanged": {
+			previous: VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+			current:  VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+		},
+		"instance UUID changed": {
+			previous:         VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+			current:          VMIdentity{ID: "vm-10", InstanceUUID: "uuid-10", CreateDate: &created},
+			wantUUIDChanged:  true,
+			wantReregistered: true,
+		},
+		"creation date changed": {
+			previous:          VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+			current:           VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &later},
+			wantCreateChanged: true,
+		},
+		"re-registered only": {
+			previous:         VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1"},
+			current:          VMIdentity{ID: "vm-10", InstanceUUID: "uuid-1"},
+			wantReregistered: true,
+		},
+		"values not previously reported": {
+			previous: VMIdentity{ID: "vm-1"},
+			current:  VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+		},
+		"values no longer reported": {
+			previous: VMIdentity{ID: "vm-1", InstanceUUID: "uuid-1", CreateDate: &created},
+			current:  VMIdentity{ID: "vm-1"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			restore := VMRestore{VMName: "vm1", Previous: tt.previous, Current: tt.current}
+
+			if got := restore.InstanceUUIDChanged(); got != tt.wantUUIDChanged {
+				t.Errorf("want instance UUID changed %t; got %t", tt.wantUUIDChanged, got)
+			}
+			if got := restore.CreateDateChanged(); got != tt.wantCreateChanged {
+				t.Errorf("want creation date changed %t; got %t", tt.wantCreateChanged, got)
+			}
+			if got := restore.Reregistered(); got != tt.wantReregistered {
+				t.Errorf("want re-registered %t; got %t", tt.wantReregistered, got)
+			}
+		})
+	}
+}
+
+func TestNewVMRestoreSet(t *testing.T) {
+	baseline := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+		restoreVM("vm2", "vm-2", "uuid-2", restoreCreated),
+	})
+
+	tests := map[string]struct {
+		vms                   []mo.VirtualMachine
+		baseline              *VMIdentityBaseline
+		wantRestores          []string
+		wantUUIDChanged       int
+		wantCreateDateChanged int
+		wantNewVMs            int
+	}{
+		"no baseline": {
+			vms: []mo.VirtualMachine{restoreVM("vm1", "vm-1", "uuid-1", restoreCreated)},
+		},
+		"unchanged identities": {
+			vms: []mo.VirtualMachine{
+				restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+				restoreVM("vm2", "vm-2", "uuid-2", restoreCreated),
+			},
+			baseline: &baseline,
+		},
+		"instance UUID and creation date changed": {
+			vms: []mo.VirtualMachine{
+				restoreVM("vm2", "vm-2", "uuid-2", restoreCreated.AddDate(0, 1, 0)),
+				restoreVM("vm1", "vm-10", "uuid-10", restoreCreated),
+			},
+			baseline:              &baseline,
+			wantRestores:          []string{"vm1", "vm2"},
+			wantUUIDChanged:       1,
+			wantCreateDateChanged: 1,
+		},
+		"re-registration alone is not a restore": {
+			vms:      []mo.VirtualMachine{restoreVM("vm1", "vm-10", "uuid-1", restoreCreated)},
+			baseline: &baseline,
+		},
+		"new VM": {
+			vms: []mo.VirtualMachine{
+				restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+				restoreVM("vm3", "vm-3", "uuid-3", restoreCreated),
+			},
+			baseline:   &baseline,
+			wantNewVMs: 1,
+		},
+		"duplicate VM names are omitted": {
+			vms: []mo.VirtualMachine{
+				restoreVM("vm1", "vm-10", "uuid-10", restoreCreated),
+				restoreVM("vm1", "vm-11", "uuid-11", restoreCreated),
+			},
+			baseline: &baseline,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := NewVMRestoreSet(NewVMIdentityBaseline(tt.vms), tt.baseline)
+
+			var restores []string
+			for _, restore := range set.Restores {
+				restores = append(restores, restore.VMName)
+			}
+			if d := cmp.Diff(tt.wantRestores, restores); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if got := set.HasBaseline(); got != (tt.baseline != nil) {
+				t.Errorf("want baseline %t; got %t", tt.baseline != nil, got)
+			}
+			if got := set.HasRestores(); got != (len(tt.wantRestores) > 0) {
+				t.Errorf("want restores %t; got %t", len(tt.wantRestores) > 0, got)
+			}
+			if got := set.NumInstanceUUIDChanged(); got != tt.wantUUIDChanged {
+				t.Errorf("want %d instance UUID changes; got %d", tt.wantUUIDChanged, got)
+			}
+			if got := set.NumCreateDateChanged(); got != tt.wantCreateDateChanged {
+				t.Errorf("want %d creation date changes; got %d", tt.wantCreateDateChanged, got)
+			}
+			if set.NewVMs != tt.wantNewVMs {
+				t.Errorf("want %d new VMs; got %d", tt.wantNewVMs, set.NewVMs)
+			}
+		})
+	}
+}
+
+func TestVMRestoreSetNextBaseline(t *testing.T) {
+	baseline := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+		restoreVM("vm2", "vm-2", "uuid-2", restoreCreated),
+	})
+
+	current := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-10", "uuid-10", restoreCreated),
+		restoreVM("vm3", "vm-3", "uuid-3", restoreCreated),
+	})
+
+	next := NewVMRestoreSet(current, &baseline).NextBaseline()
+
+	created := restoreCreated
+	want := map[string]VMIdentity{
+		"vm1": {ID: "vm-10", InstanceUUID: "uuid-10", CreateDate: &created},
+		"vm2": {ID: "vm-2", InstanceUUID: "uuid-2", CreateDate: &created},
+		"vm3": {ID: "vm-3", InstanceUUID: "uuid-3", CreateDate: &created},
+	}
+
+	if d := cmp.Diff(want, next.VMs); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if !next.Recorded.Equal(current.Recorded) {
+		t.Errorf("want recorded time %v; got %v", current.Recorded, next.Recorded)
+	}
+}
+
+func TestVMIdentityBaselineStateFile(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "vm-identities.json")
+
+	baseline, err := LoadVMIdentityBaseline(stateFile)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+	if baseline != nil {
+		t.Fatal("want nil baseline for missing state file")
+	}
+
+	want := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+	})
+
+	if err := SaveVMIdentityBaseline(stateFile, want); err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	baseline, err = LoadVMIdentityBaseline(stateFile)
+	if err != nil {
+		t.Fatalf("want nil error; got %v", err)
+	}
+
+	if d := cmp.Diff(want.VMs, baseline.VMs); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+	if !baseline.Recorded.Equal(want.Recorded) {
+		t.Errorf("want recorded time %v; got %v", want.Recorded, baseline.Recorded)
+	}
+
+	if err := os.WriteFile(stateFile, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+	if _, err := LoadVMIdentityBaseline(stateFile); err == nil {
+		t.Error("want error for invalid state file; got nil")
+	}
+}
+
+func TestVMRestoreDetectionPerfData(t *testing.T) {
+	baseline := NewVMIdentityBaseline([]mo.VirtualMachine{
+		restoreVM("vm1", "vm-1", "uuid-1", restoreCreated),
+	})
+
+	set := NewVMRestoreSet(
+		NewVMIdentityBaseline([]mo.VirtualMachine{
+			restoreVM("vm1", "vm-10", "uuid-10", restoreCreated.AddDate(0, 1, 0)),
+			restoreVM("vm2", "vm-2", "uuid-2", restoreCreated),
+		}),
+		&baseline,
+	)
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_restored", Value: "1", Min: "0"},
+		{Label: "vms_instance_uuid_changed", Value: "1", Min: "0"},
+		{Label: "vms_create_date_changed", Value: "1", Min: "0"},
+		{Label: "vms_new", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMRestoreDetectionPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVirtualMachineTimeSyncPolicyViolation indicates that one or more
+// VirtualMachines have VMware Tools time synchronization settings which do
+// not match the specified policy.
+var ErrVirtualMachineTimeSyncPolicyViolation = errors.New("virtual machine time synchronization settings violate policy")
+
+// VMTimeSyncPolicy is the required state of VMware Tools time
+// synchronization settings for evaluated VirtualMachines. A nil value
+// indicates that any setting is accepted.
+type VMTimeSyncPolicy struct {
+
+	// PeriodicSync is the required state of periodic time synchronization
+	// with the host.
+	PeriodicSync *bool
+
+	// StartupSync is the required state of one-off time synchronization with
+	// the host (e.g., at startup, resume from suspend or snapshot revert).
+	StartupSync *bool
+}
+
+// VMTimeSync is the VMware Tools time synchronization settings for a
+// VirtualMachine along with any policy violations.
+type VMTimeSync struct {
+	VMName     string
+	PowerState types.VirtualMachinePowerState
+
+	// PeriodicSync indicates whether VMware Tools periodically synchronizes
+	// guest time with the host.
+	PeriodicSync bool
+
+	// StartupSync indicates whether VMware Tools is allowed to synchronize
+	// guest time with the host for one-off events (e.g., startup).
+	StartupSync bool
+
+	// Unknown indicates that time synchronization settings could not be
+	// determined for the VirtualMachine.
+	Unknown bool
+
+	// Violations is the collection of settings which do not match the
+	// specified policy.
+	Violations []string
+}
+
+// VMTimeSyncSet is a collection of VMTimeSync values along with the policy
+// used to evaluate them.
+type VMTimeSyncSet struct {
+	Policy VMTimeSyncPolicy
+	VMs    []VMTimeSync
+}
+
+// timeSyncPolicyLabel returns a human readable representation of a time
+// synchronization policy setting.
+func timeSyncPolicyLabel(setting *bool) string {
+	switch {
+	case setting == nil:
+		return "any"
+	case *setting:
+		return "enabled"
+	default:
+		return "disabled"
+	}
+}
+
+// timeSyncLabel returns a human readable representation of a time
+// synchronization setting.
+func timeSyncLabel(enabled bool) string {
+	return timeSyncPolicyLabel(&enabled)
+}
+
+// String provides a human readable representation of the time
+// synchronization policy.
+func (p VMTimeSyncPolicy) String() string {
+	return fmt.Sprintf(
+		"periodic sync: %s, startup sync: %s",
+		timeSyncPolicyLabel(p.PeriodicSync),
+		timeSyncPolicyLabel(p.StartupSync),
+	)
+}
+
+// NewVMTimeSync evaluates the VMware Tools time synchronization settings for
+// the given VirtualMachine against the specified policy.
+//
+// Versions of vSphere prior to 7.0 Update 1 do not provide a setting to
+// disallow one-off time synchronization; for those versions one-off time
+// synchronization is considered enabled. Periodic time synchronization is
+// only effective if one-off time synchronization is allowed.
+func NewVMTimeSync(vm mo.VirtualMachine, policy VMTimeSyncPolicy) VMTimeSync {
+	vts := VMTimeSync{
+		VMName:     vm.Name,
+		PowerState: vm.Runtime.PowerState,
+	}
+
+	if vm.Config == nil || vm.Config.Tools == nil {
+		vts.Unknown = true
+
+		return vts
+	}
+
+	tools := vm.Config.Tools
+
+	vts.StartupSync = tools.SyncTimeWithHostAllowed == nil || *tools.SyncTimeWithHostAllowed
+	vts.PeriodicSync = vts.StartupSync &&
+		tools.SyncTimeWithHost != nil && *tools.SyncTimeWithHost
+
+	if policy.PeriodicSync != nil && vts.PeriodicSync != *policy.PeriodicSync {
+		vts.Violations = append(vts.Violations, fmt.Sprintf(
+			"periodic sync %s (policy: %s)",
+			timeSyncLabel(vts.PeriodicSync),
+			timeSyncPolicyLabel(policy.PeriodicSync),
+		))
+	}
+
+	if policy.StartupSync != nil && vts.StartupSync != *policy.StartupSync {
+		vts.Violations = append(vts.Violations, fmt.Sprintf(
+			"startup sync %s (policy: %s)",
+			timeSyncLabel(vts.StartupSync),
+			timeSyncPolicyLabel(policy.StartupSync),
+		))
+	}
+
+	return vts
+}
+
+// NewVMTimeSyncSet evaluates the VMware Tools time synchronization settings
+// for the given VirtualMachines against the specified policy.
+func NewVMTimeSyncSet(vms []mo.VirtualMachine, policy VMTimeSyncPolicy) VMTimeSyncSet {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute NewVMTimeSyncSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	set := VMTimeSyncSet{
+		Policy: policy,
+		VMs:    make([]VMTimeSync, 0, len(vms)),
+	}
+
+	for _, vm := range vms {
+		set.VMs = append(set.VMs, NewVMTimeSync(vm, policy))
+	}
+
+	sort.Slice(set.VMs, func(i, j int) bool {
+		return strings.ToLower(set.VMs[i].VMName) < strings.ToLower(set.VMs[j].VMName)
+	})
+
+	return set
+}
+
+// Violations returns the VirtualMachines with time synchronization settings
+// which do not match the specified policy.
+func (set VMTimeSyncSet) Violations() []VMTimeSync {
+	var violations []VMTimeSync
+	for _, vts := range set.VMs {
+		if len(vts.Violations) > 0 {
+			violations = append(violations, vts)
+		}
+	}
+
+	return violations
+}
+
+// ViolationVMNames returns the names of VirtualMachines with time
+// synchronization settings which do not match the specified policy.
+func (set VMTimeSyncSet) ViolationVMNames() []string {
+	violations := set.Violations()
+
+	names := make([]string, 0, len(violations))
+	for _, vts := range violations {
+		names = append(names, vts.VMName)
+	}
+
+	return names
+}
+
+// NumViolations returns the number of VirtualMachines with time
+// synchronization settings which do not match the specified policy.
+func (set VMTimeSyncSet) NumViolations() int {
+	return len(set.Violations())
+}
+
+// NumUnknown returns the number of VirtualMachines for which time
+// synchronization settings could not be determined.
+func (set VMTimeSyncSet) NumUnknown() int {
+	var num int
+	for _, vts := range set.VMs {
+		if vts.Unknown {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumPeriodicSync returns the number of VirtualMachines with periodic time
+// synchronization enabled.
+func (set VMTimeSyncSet) NumPeriodicSync() int {
+	var num int
+	for _, vts := range set.VMs {
+		if !vts.Unknown && vts.PeriodicSync {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumStartupSync returns the number of VirtualMachines with one-off (e.g.,
+// startup) time synchronization enabled.
+func (set VMTimeSyncSet) NumStartupSync() int {
+	var num int
+	for _, vts := range set.VMs {
+		if !vts.Unknown && vts.StartupSync {
+			num++
+		}
+	}
+
+	return num
+}
+
+// IsWarningState indicates whether any VirtualMachine in the set has time
+// synchronization settings which do not match the specified policy.
+func (set VMTimeSyncSet) IsWarningState() bool {
+	return set.NumViolations() > 0
+}
+
+// VMTimeSyncPolicyPerfData generates performance data metrics from the given
+// evaluation results.
+func VMTimeSyncPolicyPerfData(set VMTimeSyncSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "vms_time_sync_policy_violations",
+			Value: fmt.Sprintf("%d", set.NumViolations()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_periodic_time_sync",
+			Value: fmt.Sprintf("%d", set.NumPeriodicSync()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_startup_time_sync",
+			Value: fmt.Sprintf("%d", set.NumStartupSync()),
+			Min:   "0",
+		},
+		{
+			Label: "vms_time_sync_unknown",
+			Value: fmt.Sprintf("%d", set.NumUnknown()),
+			Min:   "0",
+		},
+	}
+}
+
+// VMTimeSyncPolicyOneLineCheckSummary is used to generate a one-line Nagios
+// service check results summary. This is the line most prominent in
+// notifications.
+func VMTimeSyncPolicyOneLineCheckSummary(
+	stateLabel string,
+	vmsFilterResults VMsFilterResults,
+	set VMTimeSyncSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"set":              set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMTimeSyncPolicyOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.IsWarningState():
+		return fmt.Sprintf(
+			"%s: %d VMs with time synchronization settings violating policy (%s) detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.NumViolations(),
+			set.Policy,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No VMs with time synchronization settings violating policy (%s) detected (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			set.Policy,
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+	}
+}
+
+// VMTimeSyncPolicyReport generates a summary of VMs with time
+// synchronization settings which violate the specified policy along with
+// various verbose details intended to aid in troubleshooting check results
+// at a glance. This information is provided for use with the Long Service
+// Output field commonly displayed on the detailed service check results
+// display in the web UI or in the body of many notifications.
+func VMTimeSyncPolicyReport(
+	c *vim25.Client,
+	vmsFilterOptions VMsFilterOptions,
+	vmsFilterResults VMsFilterResults,
+	set VMTimeSyncSet,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VMTimeSyncPolicyReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"VMs with time synchronization settings violating policy:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	violations := set.Violations()
+	switch {
+	case len(violations) == 0:
+		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+
+	default:
+		for _, vts := range violations {
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s): %s%s",
+				vts.VMName,
+				vts.PowerState,
+				strings.Join(vts.Violations, ", "),
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	if set.NumUnknown() > 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"%sVMs with unknown time synchronization settings:%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+
+		for _, vts := range set.VMs {
+			if !vts.Unknown {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s (power state: %s)%s",
+				vts.VMName,
+				vts.PowerState,
+				nagios.CheckOutputEOL,
+			)
+		}
+	}
+
+	vmFilterResultsReportTrailer(
+		&report,
+		c,
+		vmsFilterOptions,
+		vmsFilterResults,
+		true,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Periodic time sync policy: %s%s",
+		timeSyncPolicyLabel(set.Policy.PeriodicSync),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Startup time sync policy: %s%s",
+		timeSyncPolicyLabel(set.Policy.StartupSync),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* VMs with periodic time sync enabled: %d%s",
+		set.NumPeriodicSync(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* VMs with startup time sync enabled: %d%s",
+		set.NumStartupSync(),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
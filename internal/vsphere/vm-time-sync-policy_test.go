@@ -0,0 +1,187 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func timeSyncVM(name string, periodic *bool, startup *bool) mo.VirtualMachine {
+	return mo.VirtualMachine{
+		ManagedEntity: mo.ManagedEntity{Name: name},
+		Config: &types.VirtualMachineConfigInfo{
+			Tools: &types.ToolsConfigInfo{
+				SyncTimeWithHost:        periodic,
+				SyncTimeWithHostAllowed: startup,
+			},
+		},
+	}
+}
+
+func TestVMTimeSyncPolicyString(t *testing.T) {
+	tests := map[string]struct {
+		policy VMTimeSyncPolicy
+		want   string
+	}{
+		"any": {
+			want: "periodic sync: any, startup sync: any",
+		},
+		"periodic disabled": {
+			policy: VMTimeSyncPolicy{PeriodicSync: types.NewBool(false)},
+			want:   "periodic sync: disabled, startup sync: any",
+		},
+		"both enabled": {
+			policy: VMTimeSyncPolicy{PeriodicSync: types.NewBool(true), StartupSync: types.NewBool(true)},
+			want:   "periodic sync: enabled, startup sync: enabled",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.want {
+				t.Errorf("want %q; got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewVMTimeSync(t *testing.T) {
+	enabled := types.NewBool(true)
+	disabled := types.NewBool(false)
+
+	noTools := timeSyncVM("vm1", nil, nil)
+	noTools.Config.Tools = nil
+
+	tests := map[string]struct {
+		vm             mo.VirtualMachine
+		policy         VMTimeSyncPolicy
+		wantPeriodic   bool
+		wantStartup    bool
+		wantUnknown    bool
+		wantViolations []string
+	}{
+		"periodic sync disabled as required": {
+			vm:          timeSyncVM("vm1", disabled, enabled),
+			policy:      VMTimeSyncPolicy{PeriodicSync: disabled},
+			wantStartup: true,
+		},
+		"periodic sync enabled violates policy": {
+			vm:             timeSyncVM("vm1", enabled, enabled),
+			policy:         VMTimeSyncPolicy{PeriodicSync: disabled},
+			wantPeriodic:   true,
+			wantStartup:    true,
+			wantViolations: []string{"periodic sync enabled (policy: disabled)"},
+		},
+		"periodic sync ineffective without startup sync": {
+			vm:     timeSyncVM("vm1", enabled, disabled),
+			policy: VMTimeSyncPolicy{PeriodicSync: disabled},
+		},
+		"startup sync allowed when not reported": {
+			vm:             timeSyncVM("vm1", disabled, nil),
+			policy:         VMTimeSyncPolicy{StartupSync: disabled},
+			wantStartup:    true,
+			wantViolations: []string{"startup sync enabled (policy: disabled)"},
+		},
+		"periodic sync not reported": {
+			vm:          timeSyncVM("vm1", nil, enabled),
+			wantStartup: true,
+		},
+		"both settings violate policy": {
+			vm:     timeSyncVM("vm1", disabled, disabled),
+			policy: VMTimeSyncPolicy{PeriodicSync: enabled, StartupSync: enabled},
+			wantViolations: []string{
+				"periodic sync disabled (policy: enabled)",
+				"startup sync disabled (policy: enabled)",
+			},
+		},
+		"unknown settings are not violations": {
+			vm:          noTools,
+			policy:      VMTimeSyncPolicy{PeriodicSync: disabled, StartupSync: enabled},
+			wantUnknown: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVMTimeSync(tt.vm, tt.policy)
+
+			if got.PeriodicSync != tt.wantPeriodic {
+				t.Errorf("want periodic sync %t; got %t", tt.wantPeriodic, got.PeriodicSync)
+			}
+			if got.StartupSync != tt.wantStartup {
+				t.Errorf("want startup sync %t; got %t", tt.wantStartup, got.StartupSync)
+			}
+			if got.Unknown != tt.wantUnknown {
+				t.Errorf("want unknown %t; got %t", tt.wantUnknown, got.Unknown)
+			}
+			if d := cmp.Diff(tt.wantViolations, got.Violations); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestNewVMTimeSyncSet(t *testing.T) {
+	enabled := types.NewBool(true)
+	disabled := types.NewBool(false)
+
+	noTools := timeSyncVM("vm4", nil, nil)
+	noTools.Config.Tools = nil
+
+	set := NewVMTimeSyncSet(
+		[]mo.VirtualMachine{
+			timeSyncVM("vm3", enabled, enabled),
+			timeSyncVM("VM2", disabled, enabled),
+			timeSyncVM("vm1", enabled, enabled),
+			noTools,
+		},
+		VMTimeSyncPolicy{PeriodicSync: disabled},
+	)
+
+	if d := cmp.Diff([]string{"vm1", "vm3"}, set.ViolationVMNames()); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+
+	if !set.IsWarningState() {
+		t.Error("want WARNING state true; got false")
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "vms_time_sync_policy_violations", Value: "2", Min: "0"},
+		{Label: "vms_periodic_time_sync", Value: "2", Min: "0"},
+		{Label: "vms_startup_time_sync", Value: "3", Min: "0"},
+		{Label: "vms_time_sync_unknown", Value: "1", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VMTimeSyncPolicyPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
+
+func TestNewVMTimeSyncSetAnyPolicy(t *testing.T) {
+	set := NewVMTimeSyncSet(
+		[]mo.VirtualMachine{
+			timeSyncVM("vm1", types.NewBool(true), types.NewBool(true)),
+			timeSyncVM("vm2", types.NewBool(false), types.NewBool(false)),
+		},
+		VMTimeSyncPolicy{},
+	)
+
+	if set.IsWarningState() {
+		t.Error("want WARNING state false; got true")
+	}
+
+	if got := set.NumViolations(); got != 0 {
+		t.Errorf("want 0 violations; got %d", got)
+	}
+}
@@ -16,9 +16,9 @@ import (
 	"strings"
 	"time"
 
-	"github.com/atc0005/check-vmware/internal/textutils"
 	"github.com/atc0005/go-nagios"
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/view"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -58,6 +58,18 @@ var ErrValidationOfIncludeExcludeRPLists = errors.New("validation failed for inc
 // attempt of the given Folder include or exclude lists failed.
 var ErrValidationOfIncludeExcludeFolderIDLists = errors.New("validation failed for include/exclude folder ID lists")
 
+// ErrValidationOfIncludeExcludeDatacenterLists indicates that a validation
+// attempt of the given Datacenter include or exclude lists failed.
+var ErrValidationOfIncludeExcludeDatacenterLists = errors.New("validation failed for include/exclude datacenter lists")
+
+// ErrValidationOfIncludeExcludeClusterLists indicates that a validation
+// attempt of the given Cluster include or exclude lists failed.
+var ErrValidationOfIncludeExcludeClusterLists = errors.New("validation failed for include/exclude cluster lists")
+
+// ErrValidationOfIncludeExcludeHostLists indicates that a validation attempt
+// of the given Host include or exclude lists failed.
+var ErrValidationOfIncludeExcludeHostLists = errors.New("validation failed for include/exclude host lists")
+
 // ErrVirtualMachineConfigurationIsNil indicates that the configuration for a
 // virtual machine is unset, which may occur if the property is not requested
 // from the vSphere API or if the service account executing the plugin has
@@ -97,6 +109,10 @@ type VirtualMachinePowerCycleUptimeStatus struct {
 	VMsOK             []mo.VirtualMachine
 	WarningThreshold  int
 	CriticalThreshold int
+
+	// UseBootTime indicates whether power cycle uptime is calculated from
+	// the VM boot time instead of QuickStats uptime.
+	UseBootTime bool
 }
 
 // VMWithBackup is a VirtualMachine with backup date details.
@@ -117,6 +133,16 @@ type VMWithBackup struct {
 	// this VirtualMachine.
 	BackupMetadataCAName string
 
+	// BackupDateTagCategory is the name of the vSphere Tag category used to
+	// record when the last backup occurred for this VirtualMachine. This is
+	// only set when backup dates are read from vSphere Tags instead of
+	// Custom Attributes.
+	BackupDateTagCategory string
+
+	// BackupDateTag is the category-qualified name of the vSphere Tag from
+	// which the backup date for this VirtualMachine was read.
+	BackupDateTag string
+
 	// BackupDate is the date/time of the last backup for this VirtualMachine.
 	// If a backup date is recorded for a VM, then the time zone (aka,
 	// "location") for the parsed date/time value is set to the user-specified
@@ -144,10 +170,35 @@ type VMsWithBackup []VMWithBackup
 type VMsFilterOptions struct {
 	ResourcePoolsIncluded       []string
 	ResourcePoolsExcluded       []string
+	DatacentersIncluded         []string
+	DatacentersExcluded         []string
+	ClusterNamesIncluded        []string
+	ClusterNamesExcluded        []string
+	HostNamesIncluded           []string
+	HostNamesExcluded           []string
 	FoldersIncluded             []string
 	FoldersExcluded             []string
 	VirtualMachineNamesExcluded []string
 	IncludePoweredOff           bool
+
+	// TagsIncluded and TagsExcluded are vSphere Tag specifications (tag
+	// name or category-qualified tag name, e.g., Backup:Nightly) used to
+	// explicitly include or exclude VMs from evaluation.
+	TagsIncluded []string
+	TagsExcluded []string
+
+	// TaggingClient is the vSphere Automation API client used to query the
+	// tagging service. This is required if filtering by tags.
+	TaggingClient *rest.Client
+
+	// MaintenanceCAName is the name of the Custom Attribute used to record
+	// the end of a maintenance window for a VM. VMs with an active
+	// maintenance window are suppressed (excluded from evaluation).
+	MaintenanceCAName string
+
+	// MaintenanceCADateFormat is the Go time layout used to parse the value
+	// of the maintenance window Custom Attribute.
+	MaintenanceCADateFormat string
 }
 
 // vmsRPFilterResults is the results of performing resource pool filtering
@@ -157,6 +208,24 @@ type vmsRPFilterResults struct {
 	VMs []mo.VirtualMachine
 }
 
+// vmsDatacenterFilterResults is the results of performing datacenter
+// filtering operations on a given VirtualMachines and ResourcePools
+// collection.
+type vmsDatacenterFilterResults struct {
+	RPs                        []mo.ResourcePool
+	VMs                        []mo.VirtualMachine
+	NumVMsExcludedByDatacenter int
+	NumDatacentersEvaluated    int
+}
+
+// vmsHostPlacementFilterResults is the results of performing cluster or host
+// filtering operations on a given VirtualMachines collection.
+type vmsHostPlacementFilterResults struct {
+	VMs            []mo.VirtualMachine
+	NumVMsExcluded int
+	NumEvaluated   int
+}
+
 // vmsFolderFilterResults is the results of performing folder filtering
 // operations on a given VirtualMachines collection.
 type vmsFolderFilterResults struct {
@@ -180,14 +249,79 @@ type VMsFilterResults struct {
 	// out" via resource pool filtering.
 	numVMsExcludedByResourcePool int
 
+	// numVMsExcludedByDatacenter is the count of vms excluded or "filtered
+	// out" via datacenter filtering.
+	numVMsExcludedByDatacenter int
+
+	// numVMsExcludedByCluster is the count of vms excluded or "filtered out"
+	// via cluster filtering.
+	numVMsExcludedByCluster int
+
+	// numVMsExcludedByHost is the count of vms excluded or "filtered out"
+	// via host filtering.
+	numVMsExcludedByHost int
+
 	// numVMsExcludedByFolder is the count of vms excluded or "filtered out"
 	// via folder filtering.
 	numVMsExcludedByFolder int
 
+	// numVMsExcludedByTag is the count of vms excluded or "filtered out"
+	// via vSphere Tag filtering.
+	numVMsExcludedByTag int
+
 	// numVMsExcludedByPowerState is the count of vms excluded or "filtered
 	// out" via power state filtering.
 	numVMsExcludedByPowerState int
 
+	// vmsSuppressedByMaintenanceWindow is the collection of vms excluded or
+	// "filtered out" due to an active maintenance window.
+	vmsSuppressedByMaintenanceWindow VMMaintenanceWindows
+
+	// numDatacentersAll is the count of all datacenters in the inventory.
+	numDatacentersAll int
+
+	// numDatacentersEvaluated is the number of datacenters remaining after
+	// they have been explicitly included or excluded.
+	numDatacentersEvaluated int
+
+	// numDatacentersIncluded is the count of datacenters explicitly
+	// specified via CLI flag for inclusion.
+	numDatacentersIncluded int
+
+	// numDatacentersExcluded is the count of datacenters explicitly
+	// specified via CLI flag for exclusion.
+	numDatacentersExcluded int
+
+	// numClustersAll is the count of all clusters in the inventory.
+	numClustersAll int
+
+	// numClustersEvaluated is the number of clusters remaining after they
+	// have been explicitly included or excluded.
+	numClustersEvaluated int
+
+	// numClustersIncluded is the count of clusters explicitly specified via
+	// CLI flag for inclusion.
+	numClustersIncluded int
+
+	// numClustersExcluded is the count of clusters explicitly specified via
+	// CLI flag for exclusion.
+	numClustersExcluded int
+
+	// numHostsAll is the count of all hosts in the inventory.
+	numHostsAll int
+
+	// numHostsEvaluated is the number of hosts remaining after they have
+	// been explicitly included or excluded.
+	numHostsEvaluated int
+
+	// numHostsIncluded is the count of hosts explicitly specified via CLI
+	// flag for inclusion.
+	numHostsIncluded int
+
+	// numHostsExcluded is the count of hosts explicitly specified via CLI
+	// flag for exclusion.
+	numHostsExcluded int
+
 	// numFoldersAll is the count of all folders in the inventory.
 	numFoldersAll int
 
@@ -224,9 +358,14 @@ type VMsFilterResults struct {
 	rpsAfterAllFiltering []mo.ResourcePool
 
 	vmsAfterRPFiltering         []mo.VirtualMachine
+	vmsAfterDatacenterFiltering []mo.VirtualMachine
+	vmsAfterClusterFiltering    []mo.VirtualMachine
+	vmsAfterHostFiltering       []mo.VirtualMachine
 	vmsAfterFolderFiltering     []mo.VirtualMachine
+	vmsAfterTagFiltering        []mo.VirtualMachine
 	vmsAfterVMNameFiltering     []mo.VirtualMachine
 	vmsAfterPowerStateFiltering []mo.VirtualMachine
+	vmsAfterMaintenanceWindow   []mo.VirtualMachine
 	vmsAfterAllFiltering        []mo.VirtualMachine
 }
 
@@ -281,6 +420,12 @@ func (vmwb VMWithBackup) IsOKState() bool {
 // only that the requested value exists. This method does not consider whether
 // the optional metadata Custom Attribute is present.
 func (vmwb VMWithBackup) HasBackup() bool {
+	// Backup dates read from vSphere Tags are not recorded via Custom
+	// Attributes.
+	if vmwb.BackupDateTagCategory != "" {
+		return vmwb.hasBackupViaTag()
+	}
+
 	backupDateVal, backupDateValExists := vmwb.CustomAttributes[vmwb.BackupDateCAName]
 
 	// NOTE: This is an optional Custom Attribute, so we don't require it here.
@@ -561,6 +706,39 @@ func (vpcs VirtualMachinePowerCycleUptimeStatus) VMNames() string {
 	return strings.Join(vmNames, ", ")
 }
 
+// Uptime returns the power cycle uptime for the given VirtualMachine using
+// the configured source. When using the VM boot time as the source, the time
+// elapsed since the last boot is returned. A VirtualMachine without a
+// recorded boot time (e.g., powered off) is reported as having zero uptime.
+func (vpcs VirtualMachinePowerCycleUptimeStatus) Uptime(vm mo.VirtualMachine) time.Duration {
+	return vmPowerCycleUptime(vm, vpcs.UseBootTime)
+}
+
+// vmPowerCycleUptime returns the power cycle uptime for the given
+// VirtualMachine using either QuickStats uptime or the time elapsed since the
+// last boot.
+func vmPowerCycleUptime(vm mo.VirtualMachine, useBootTime bool) time.Duration {
+	if !useBootTime {
+		return time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+	}
+
+	if vm.Runtime.BootTime == nil {
+		return 0
+	}
+
+	return time.Since(*vm.Runtime.BootTime)
+}
+
+// vmBootTime returns a human readable representation of the boot time for
+// the given VirtualMachine.
+func vmBootTime(vm mo.VirtualMachine) string {
+	if vm.Runtime.BootTime == nil {
+		return "unknown"
+	}
+
+	return vm.Runtime.BootTime.Format(time.RFC3339)
+}
+
 // TopTenOK is a helper method that returns at most ten VMs with the highest
 // power cycle uptime values that have yet to exceed specified thresholds.
 func (vpcs VirtualMachinePowerCycleUptimeStatus) TopTenOK() []mo.VirtualMachine {
@@ -577,7 +755,7 @@ func (vpcs VirtualMachinePowerCycleUptimeStatus) TopTenOK() []mo.VirtualMachine
 	// sort before we sample the VMs so that we only get the ones with highest
 	// power cycle uptime
 	sort.Slice(vpcs.VMsOK, func(i, j int) bool {
-		return vpcs.VMsOK[i].Summary.QuickStats.UptimeSeconds > vpcs.VMsOK[j].Summary.QuickStats.UptimeSeconds
+		return vpcs.Uptime(vpcs.VMsOK[i]) > vpcs.Uptime(vpcs.VMsOK[j])
 	})
 
 	sampleSize := len(vpcs.VMsOK)
@@ -615,7 +793,7 @@ func (vpcs VirtualMachinePowerCycleUptimeStatus) BottomTenOK() []mo.VirtualMachi
 	// power cycle uptime; require that the VM be powered on in order to sort
 	// in the intended order.
 	sort.Slice(poweredOnVMs, func(i, j int) bool {
-		return poweredOnVMs[i].Summary.QuickStats.UptimeSeconds < poweredOnVMs[j].Summary.QuickStats.UptimeSeconds
+		return vpcs.Uptime(poweredOnVMs[i]) < vpcs.Uptime(poweredOnVMs[j])
 
 	})
 
@@ -725,9 +903,13 @@ func CountVMsPowerStates(vms []mo.VirtualMachine) (int, int, int) {
 //	Filter order:
 //
 //	1. Resource Pools
-//	2. Folder
-//	3. VirtualMachine Name
-//	4. VirtualMachine Power State
+//	2. Datacenter
+//	3. Cluster
+//	4. Host
+//	5. Folder
+//	6. vSphere Tag
+//	7. VirtualMachine Name
+//	8. VirtualMachine Power State
 //
 // Separate filtering functions are provided for a more fine-tuned, manual
 // approach to filtering VirtualMachines.
@@ -745,6 +927,18 @@ func FilterVMs(ctx context.Context, client *vim25.Client, filterOptions VMsFilte
 		return VMsFilterResults{}, err
 	}
 
+	if err := validateDatacenters(ctx, client, filterOptions); err != nil {
+		return VMsFilterResults{}, err
+	}
+
+	if err := validateClusters(ctx, client, filterOptions); err != nil {
+		return VMsFilterResults{}, err
+	}
+
+	if err := validateHostSystems(ctx, client, filterOptions); err != nil {
+		return VMsFilterResults{}, err
+	}
+
 	if err := validateFolders(ctx, client, filterOptions); err != nil {
 		return VMsFilterResults{}, err
 	}
@@ -754,6 +948,21 @@ func FilterVMs(ctx context.Context, client *vim25.Client, filterOptions VMsFilte
 		return VMsFilterResults{}, rpsCountErr
 	}
 
+	numDatacenters, datacentersCountErr := GetNumTotalDatacenters(ctx, client)
+	if datacentersCountErr != nil {
+		return VMsFilterResults{}, datacentersCountErr
+	}
+
+	numClusters, clustersCountErr := GetNumTotalClusters(ctx, client)
+	if clustersCountErr != nil {
+		return VMsFilterResults{}, clustersCountErr
+	}
+
+	numHosts, hostsCountErr := GetNumTotalHostSystems(ctx, client)
+	if hostsCountErr != nil {
+		return VMsFilterResults{}, hostsCountErr
+	}
+
 	numFolders, foldersCountErr := GetNumTotalFolders(ctx, client)
 	if foldersCountErr != nil {
 		return VMsFilterResults{}, foldersCountErr
@@ -770,16 +979,48 @@ func FilterVMs(ctx context.Context, client *vim25.Client, filterOptions VMsFilte
 		return VMsFilterResults{}, rpsFilterErr
 	}
 
+	logger.Println("Filtering VMs by datacenter")
+	vmsDatacenterResults, datacenterFilterErr := filterVMsByDatacenter(
+		ctx, client, vmsRPResults.VMs, vmsRPResults.RPs, filterOptions,
+	)
+	if datacenterFilterErr != nil {
+		return VMsFilterResults{}, datacenterFilterErr
+	}
+
+	logger.Println("Filtering VMs by cluster")
+	vmsClusterResults, clusterFilterErr := filterVMsByCluster(
+		ctx, client, vmsDatacenterResults.VMs, filterOptions,
+	)
+	if clusterFilterErr != nil {
+		return VMsFilterResults{}, clusterFilterErr
+	}
+
+	logger.Println("Filtering VMs by host")
+	vmsHostResults, hostFilterErr := filterVMsByHost(
+		ctx, client, vmsClusterResults.VMs, filterOptions,
+	)
+	if hostFilterErr != nil {
+		return VMsFilterResults{}, hostFilterErr
+	}
+
 	logger.Println("Filtering VMs by folder")
 	vmsFolderResults, folderFilterErr := filterVMsByFolder(
-		ctx, client, vmsRPResults.VMs, filterOptions,
+		ctx, client, vmsHostResults.VMs, filterOptions,
 	)
 	if folderFilterErr != nil {
 		return VMsFilterResults{}, folderFilterErr
 	}
 
+	logger.Println("Filtering VMs by tag")
+	vmsAfterTagFiltering, numVMsExcludedByTag, tagFilterErr := filterVMsByTag(
+		ctx, vmsFolderResults.VMs, filterOptions,
+	)
+	if tagFilterErr != nil {
+		return VMsFilterResults{}, tagFilterErr
+	}
+
 	logger.Println("Filtering VMs by name")
-	vmsAfterNameFiltering, numVMsExcludedByName := ExcludeVMsByName(vmsFolderResults.VMs, filterOptions.VirtualMachineNamesExcluded)
+	vmsAfterNameFiltering, numVMsExcludedByName := ExcludeVMsByName(vmsAfterTagFiltering, filterOptions.VirtualMachineNamesExcluded)
 	logger.Printf(
 		"VMs after name filtering: (filteredByName: %v, excludedByName: %d)",
 		strings.Join(VMNames(vmsAfterNameFiltering), ", "),
@@ -794,29 +1035,81 @@ func FilterVMs(ctx context.Context, client *vim25.Client, filterOptions VMsFilte
 		numVMsExcludedByPowerState,
 	)
 
+	logger.Println("Filtering VMs by maintenance window")
+	vmsAfterMaintenanceWindow, vmsSuppressedByMaintenanceWindow := filterVMsByMaintenanceWindow(
+		vmsAfterPowerStateFiltering, filterOptions,
+	)
+
+	if evaluationManifestEnabled() {
+		logger.Println("Recording VM filtering results for evaluation manifest")
+		recordVMsFilterManifest(
+			ctx,
+			client,
+			filterOptions,
+			numNonTemplateVMs,
+			[]vmsFilterStep{
+				{reason: ManifestSkipReasonResourcePool, after: vmsRPResults.VMs},
+				{reason: ManifestSkipReasonDatacenter, after: vmsDatacenterResults.VMs},
+				{reason: ManifestSkipReasonCluster, after: vmsClusterResults.VMs},
+				{reason: ManifestSkipReasonHost, after: vmsHostResults.VMs},
+				{reason: ManifestSkipReasonFolder, after: vmsFolderResults.VMs},
+				{reason: ManifestSkipReasonTag, after: vmsAfterTagFiltering},
+				{reason: ManifestSkipReasonName, after: vmsAfterNameFiltering},
+				{reason: ManifestSkipReasonPowerState, after: vmsAfterPowerStateFiltering},
+				{reason: ManifestSkipReasonMaintenanceWindow, after: vmsAfterMaintenanceWindow},
+			},
+		)
+	}
+
 	return VMsFilterResults{
 		numVMsAll:                    numNonTemplateVMs,
 		numVMsExcludedByResourcePool: numNonTemplateVMs - len(vmsRPResults.VMs),
+		numVMsExcludedByDatacenter:   vmsDatacenterResults.NumVMsExcludedByDatacenter,
+		numVMsExcludedByCluster:      vmsClusterResults.NumVMsExcluded,
+		numVMsExcludedByHost:         vmsHostResults.NumVMsExcluded,
 		numVMsExcludedByFolder:       vmsFolderResults.NumVMsExcludedByFolder,
+		numVMsExcludedByTag:          numVMsExcludedByTag,
 		numVMsExcludedByName:         numVMsExcludedByName,
 		numVMsExcludedByPowerState:   numVMsExcludedByPowerState,
 
+		vmsSuppressedByMaintenanceWindow: vmsSuppressedByMaintenanceWindow,
+
+		numDatacentersAll:       numDatacenters,
+		numDatacentersEvaluated: vmsDatacenterResults.NumDatacentersEvaluated,
+		numDatacentersIncluded:  len(filterOptions.DatacentersIncluded),
+		numDatacentersExcluded:  len(filterOptions.DatacentersExcluded),
+
+		numClustersAll:       numClusters,
+		numClustersEvaluated: vmsClusterResults.NumEvaluated,
+		numClustersIncluded:  len(filterOptions.ClusterNamesIncluded),
+		numClustersExcluded:  len(filterOptions.ClusterNamesExcluded),
+
+		numHostsAll:       numHosts,
+		numHostsEvaluated: vmsHostResults.NumEvaluated,
+		numHostsIncluded:  len(filterOptions.HostNamesIncluded),
+		numHostsExcluded:  len(filterOptions.HostNamesExcluded),
+
 		numFoldersAll:       numFolders,
 		numFoldersEvaluated: vmsFolderResults.NumFoldersEvaluated,
 		numFoldersIncluded:  len(filterOptions.FoldersIncluded),
 		numFoldersExcluded:  len(filterOptions.FoldersExcluded),
 
 		numResourcePoolsAll:       numAllRPs,
-		numResourcePoolsEvaluated: len(vmsRPResults.RPs),
+		numResourcePoolsEvaluated: len(vmsDatacenterResults.RPs),
 		numResourcePoolsIncluded:  len(filterOptions.FoldersIncluded),
 		numResourcePoolsExcluded:  len(filterOptions.FoldersExcluded),
 
 		vmsAfterRPFiltering:         vmsRPResults.VMs,
+		vmsAfterDatacenterFiltering: vmsDatacenterResults.VMs,
+		vmsAfterClusterFiltering:    vmsClusterResults.VMs,
+		vmsAfterHostFiltering:       vmsHostResults.VMs,
 		vmsAfterFolderFiltering:     vmsFolderResults.VMs,
+		vmsAfterTagFiltering:        vmsAfterTagFiltering,
 		vmsAfterVMNameFiltering:     vmsAfterNameFiltering,
 		vmsAfterPowerStateFiltering: vmsAfterPowerStateFiltering,
-		vmsAfterAllFiltering:        vmsAfterPowerStateFiltering,
-		rpsAfterAllFiltering:        vmsRPResults.RPs,
+		vmsAfterMaintenanceWindow:   vmsAfterMaintenanceWindow,
+		vmsAfterAllFiltering:        vmsAfterMaintenanceWindow,
+		rpsAfterAllFiltering:        vmsDatacenterResults.RPs,
 	}, nil
 
 }
@@ -974,6 +1267,334 @@ func filterVMsByRP(
 	}, nil
 }
 
+// filterVMsByDatacenter uses the given filtering options to limit the given
+// VirtualMachines and ResourcePools to those within (or outside of) the
+// specified datacenters. Because ResourcePool names are not unique across
+// datacenters, this allows limiting evaluation to a specific datacenter.
+func filterVMsByDatacenter(
+	ctx context.Context,
+	client *vim25.Client,
+	vms []mo.VirtualMachine,
+	rps []mo.ResourcePool,
+	filterOptions VMsFilterOptions,
+) (vmsDatacenterFilterResults, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute filterVMsByDatacenter func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.DatacentersIncluded) > 0 || len(filterOptions.DatacentersExcluded) > 0:
+		var (
+			siftList       []string
+			siftListDesc   string
+			keepMatchedVMs bool
+		)
+
+		switch {
+		case len(filterOptions.DatacentersIncluded) > 0:
+			siftList = filterOptions.DatacentersIncluded
+			siftListDesc = "included datacenters list"
+			keepMatchedVMs = true
+		case len(filterOptions.DatacentersExcluded) > 0:
+			siftList = filterOptions.DatacentersExcluded
+			siftListDesc = "excluded datacenters list"
+			keepMatchedVMs = false
+		}
+
+		logger.Println("Resolving datacenter names to datacenter values")
+		datacenters, retrieveErr := GetDatacenters(ctx, client, siftList, true)
+		if retrieveErr != nil {
+			logger.Printf(
+				"Error retrieving %s: %v",
+				siftListDesc,
+				retrieveErr,
+			)
+			return vmsDatacenterFilterResults{}, fmt.Errorf(
+				"failed to retrieve %s: %w",
+				siftListDesc,
+				retrieveErr,
+			)
+		}
+
+		dcEntityVals := make([]mo.ManagedEntity, 0, len(datacenters))
+		dcRPIDs := make(map[string]struct{})
+		for _, dc := range datacenters {
+			dcEntityVals = append(dcEntityVals, dc.ManagedEntity)
+
+			var dcRPs []mo.ResourcePool
+			err := getObjects(ctx, client, &dcRPs, dc.Reference(), true, true)
+			if err != nil {
+				return vmsDatacenterFilterResults{}, fmt.Errorf(
+					"failed to retrieve resource pools from datacenter %s: %w",
+					dc.Name,
+					err,
+				)
+			}
+
+			for _, rp := range dcRPs {
+				dcRPIDs[rp.Self.Value] = struct{}{}
+			}
+		}
+
+		logger.Printf("Retrieving vms from %d datacenters", len(dcEntityVals))
+		vmsFromDatacenters, getVMsErr := GetVMsFromContainer(ctx, client, true, dcEntityVals...)
+		if getVMsErr != nil {
+			logger.Printf(
+				"Error retrieving list of VMs from %s: %v",
+				siftListDesc,
+				getVMsErr,
+			)
+
+			return vmsDatacenterFilterResults{}, fmt.Errorf(
+				"failed to retrieve list of VMs from %s: %w",
+				siftListDesc,
+				getVMsErr,
+			)
+		}
+
+		filteredVMs, numVMsExcludedByDatacenter := SiftVMsByVMs(vms, vmsFromDatacenters, keepMatchedVMs)
+
+		filteredRPs := make([]mo.ResourcePool, 0, len(rps))
+		for _, rp := range rps {
+			if _, inDatacenter := dcRPIDs[rp.Self.Value]; inDatacenter == keepMatchedVMs {
+				filteredRPs = append(filteredRPs, rp)
+			}
+		}
+
+		logger.Printf(
+			"VMs after datacenter filtering: %v (kept: %d, excluded: %d)",
+			strings.Join(VMNames(filteredVMs), ", "),
+			len(filteredVMs),
+			numVMsExcludedByDatacenter,
+		)
+
+		return vmsDatacenterFilterResults{
+			RPs:                        filteredRPs,
+			VMs:                        filteredVMs,
+			NumVMsExcludedByDatacenter: numVMsExcludedByDatacenter,
+			NumDatacentersEvaluated:    len(datacenters),
+		}, nil
+
+	default:
+		logger.Println("Skipping filter by datacenter; datacenter filtering not requested")
+
+		// Return the original collections untouched.
+		return vmsDatacenterFilterResults{
+			RPs:                        rps,
+			VMs:                        vms,
+			NumVMsExcludedByDatacenter: 0,
+			NumDatacentersEvaluated:    0,
+		}, nil
+	}
+}
+
+// filterVMsByCluster uses the given filtering options to limit the given
+// VirtualMachines to those currently running on hosts within (or outside of)
+// the specified clusters. Cluster names are matched across all datacenters.
+func filterVMsByCluster(
+	ctx context.Context,
+	client *vim25.Client,
+	vms []mo.VirtualMachine,
+	filterOptions VMsFilterOptions,
+) (vmsHostPlacementFilterResults, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute filterVMsByCluster func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.ClusterNamesIncluded) > 0 || len(filterOptions.ClusterNamesExcluded) > 0:
+		var (
+			siftList       []string
+			siftListDesc   string
+			keepMatchedVMs bool
+		)
+
+		switch {
+		case len(filterOptions.ClusterNamesIncluded) > 0:
+			siftList = filterOptions.ClusterNamesIncluded
+			siftListDesc = "included clusters list"
+			keepMatchedVMs = true
+		case len(filterOptions.ClusterNamesExcluded) > 0:
+			siftList = filterOptions.ClusterNamesExcluded
+			siftListDesc = "excluded clusters list"
+			keepMatchedVMs = false
+		}
+
+		logger.Println("Resolving cluster names to cluster values")
+		clusters, retrieveErr := GetClusters(ctx, client, true)
+		if retrieveErr != nil {
+			logger.Printf(
+				"Error retrieving %s: %v",
+				siftListDesc,
+				retrieveErr,
+			)
+			return vmsHostPlacementFilterResults{}, fmt.Errorf(
+				"failed to retrieve %s: %w",
+				siftListDesc,
+				retrieveErr,
+			)
+		}
+
+		var numClustersEvaluated int
+		hostIDs := make(map[string]struct{})
+		for _, cluster := range clusters {
+			if !inPatternList(cluster.Name, siftList) {
+				continue
+			}
+
+			numClustersEvaluated++
+			for _, host := range cluster.Host {
+				hostIDs[host.Value] = struct{}{}
+			}
+		}
+
+		filteredVMs, numVMsExcludedByCluster := siftVMsByHostIDs(vms, hostIDs, keepMatchedVMs)
+
+		logger.Printf(
+			"VMs after cluster filtering: %v (kept: %d, excluded: %d)",
+			strings.Join(VMNames(filteredVMs), ", "),
+			len(filteredVMs),
+			numVMsExcludedByCluster,
+		)
+
+		return vmsHostPlacementFilterResults{
+			VMs:            filteredVMs,
+			NumVMsExcluded: numVMsExcludedByCluster,
+			NumEvaluated:   numClustersEvaluated,
+		}, nil
+
+	default:
+		logger.Println("Skipping filter by cluster; cluster filtering not requested")
+
+		// Return the original collection untouched.
+		return vmsHostPlacementFilterResults{
+			VMs:            vms,
+			NumVMsExcluded: 0,
+			NumEvaluated:   0,
+		}, nil
+	}
+}
+
+// filterVMsByHost uses the given filtering options to limit the given
+// VirtualMachines to those currently running on (or not running on) the
+// specified hosts.
+func filterVMsByHost(
+	ctx context.Context,
+	client *vim25.Client,
+	vms []mo.VirtualMachine,
+	filterOptions VMsFilterOptions,
+) (vmsHostPlacementFilterResults, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute filterVMsByHost func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case len(filterOptions.HostNamesIncluded) > 0 || len(filterOptions.HostNamesExcluded) > 0:
+		var (
+			siftList       []string
+			siftListDesc   string
+			keepMatchedVMs bool
+		)
+
+		switch {
+		case len(filterOptions.HostNamesIncluded) > 0:
+			siftList = filterOptions.HostNamesIncluded
+			siftListDesc = "included hosts list"
+			keepMatchedVMs = true
+		case len(filterOptions.HostNamesExcluded) > 0:
+			siftList = filterOptions.HostNamesExcluded
+			siftListDesc = "excluded hosts list"
+			keepMatchedVMs = false
+		}
+
+		logger.Println("Resolving host names to host values")
+		hss, retrieveErr := GetHostSystems(ctx, client, true)
+		if retrieveErr != nil {
+			logger.Printf(
+				"Error retrieving %s: %v",
+				siftListDesc,
+				retrieveErr,
+			)
+			return vmsHostPlacementFilterResults{}, fmt.Errorf(
+				"failed to retrieve %s: %w",
+				siftListDesc,
+				retrieveErr,
+			)
+		}
+
+		hostIDs := make(map[string]struct{})
+		for _, hs := range hss {
+			if inPatternList(hs.Name, siftList) {
+				hostIDs[hs.Self.Value] = struct{}{}
+			}
+		}
+
+		filteredVMs, numVMsExcludedByHost := siftVMsByHostIDs(vms, hostIDs, keepMatchedVMs)
+
+		logger.Printf(
+			"VMs after host filtering: %v (kept: %d, excluded: %d)",
+			strings.Join(VMNames(filteredVMs), ", "),
+			len(filteredVMs),
+			numVMsExcludedByHost,
+		)
+
+		return vmsHostPlacementFilterResults{
+			VMs:            filteredVMs,
+			NumVMsExcluded: numVMsExcludedByHost,
+			NumEvaluated:   len(hostIDs),
+		}, nil
+
+	default:
+		logger.Println("Skipping filter by host; host filtering not requested")
+
+		// Return the original collection untouched.
+		return vmsHostPlacementFilterResults{
+			VMs:            vms,
+			NumVMsExcluded: 0,
+			NumEvaluated:   0,
+		}, nil
+	}
+}
+
+// siftVMsByHostIDs keeps (or discards) the VirtualMachines in the given
+// collection currently running on a host with a Managed Object ID in the
+// given index. VirtualMachines without a current host are treated as not
+// matching. The filtered collection is returned along with the number of
+// VirtualMachines excluded.
+func siftVMsByHostIDs(vms []mo.VirtualMachine, hostIDs map[string]struct{}, keepMatched bool) ([]mo.VirtualMachine, int) {
+	filtered := make([]mo.VirtualMachine, 0, len(vms))
+	for _, vm := range vms {
+		var matched bool
+		if vm.Runtime.Host != nil {
+			_, matched = hostIDs[vm.Runtime.Host.Value]
+		}
+
+		if matched == keepMatched {
+			filtered = append(filtered, vm)
+		}
+	}
+
+	return filtered, len(vms) - len(filtered)
+}
+
 func filterVMsByFolder(
 	ctx context.Context,
 	client *vim25.Client,
@@ -1384,28 +2005,34 @@ func GetVMsFromContainer(ctx context.Context, c *vim25.Client, propsSubset bool,
 // indicates whether a subset of properties per VirtualMachine are retrieved.
 // If requested, a subset of all available properties will be retrieved
 // (faster) instead of recursively fetching all properties (about 2x as slow)
-// A collection of VirtualMachines with requested properties is returned or
-// nil and an error, if one occurs.
-func GetVMsFromDatastore(ctx context.Context, c *vim25.Client, ds mo.Datastore, propsSubset bool) ([]mo.VirtualMachine, error) {
+//
+// VirtualMachine references listed by the Datastore which cannot be resolved
+// (e.g., orphaned entries) are retried before being skipped. The IDs for
+// skipped references are returned alongside the collection of resolved
+// VirtualMachines so that the caller may surface them. Nil values and an
+// error are returned if VirtualMachines could not be retrieved.
+func GetVMsFromDatastore(ctx context.Context, c *vim25.Client, ds mo.Datastore, propsSubset bool) ([]mo.VirtualMachine, []string, error) {
 
 	funcTimeStart := time.Now()
 
 	// declare this early so that we can grab a pointer to it in order to
 	// access the entries later
-	dsVMs := make([]mo.VirtualMachine, len(ds.Vm))
+	dsVMs := make([]mo.VirtualMachine, 0, len(ds.Vm))
+	unresolvedVMIDs := make([]string, 0)
 
-	defer func(vms *[]mo.VirtualMachine) {
+	defer func(vms *[]mo.VirtualMachine, unresolved *[]string) {
 		logger.Printf(
-			"It took %v to execute GetVMsFromDatastore func (and retrieve %d VMs).\n",
+			"It took %v to execute GetVMsFromDatastore func (and retrieve %d VMs, skip %d unresolved).\n",
 			time.Since(funcTimeStart),
 			len(*vms),
+			len(*unresolved),
 		)
-	}(&dsVMs)
+	}(&dsVMs, &unresolvedVMIDs)
 
 	var allVMs []mo.VirtualMachine
 	err := getObjects(ctx, c, &allVMs, c.ServiceContent.RootFolder, propsSubset, true)
 	if err != nil {
-		return nil, fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"failed to retrieve VirtualMachines from Datastore %s: %w",
 			ds.Name,
 			err,
@@ -1415,22 +2042,87 @@ func GetVMsFromDatastore(ctx context.Context, c *vim25.Client, ds mo.Datastore,
 	for i := range ds.Vm {
 		vm, _, err := FilterVMsByID(allVMs, ds.Vm[i].Value)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to retrieve VM for VM ID %s: %w",
+			logger.Printf(
+				"failed to resolve VM ID %s from Datastore %s; retrying: %v",
 				ds.Vm[i].Value,
+				ds.Name,
 				err,
 			)
+
+			vm, err = retrieveDatastoreVM(ctx, c, ds.Vm[i], propsSubset)
+			if err != nil {
+				logger.Printf(
+					"skipping unresolvable VM ID %s from Datastore %s: %v",
+					ds.Vm[i].Value,
+					ds.Name,
+					err,
+				)
+
+				unresolvedVMIDs = append(unresolvedVMIDs, ds.Vm[i].Value)
+
+				continue
+			}
 		}
 
-		dsVMs[i] = vm
+		dsVMs = append(dsVMs, vm)
 	}
 
 	sort.Slice(dsVMs, func(i, j int) bool {
 		return strings.ToLower(dsVMs[i].Name) < strings.ToLower(dsVMs[j].Name)
 	})
 
-	return dsVMs, nil
+	sort.Strings(unresolvedVMIDs)
+
+	return dsVMs, unresolvedVMIDs, nil
+
+}
+
+// retrieveDatastoreVM attempts to resolve a VirtualMachine reference listed
+// by a Datastore, retrying a limited number of times before giving up. The
+// resolved VirtualMachine is returned or an error if all attempts fail.
+func retrieveDatastoreVM(ctx context.Context, c *vim25.Client, ref types.ManagedObjectReference, propsSubset bool) (mo.VirtualMachine, error) {
+
+	// If the properties slice is nil, all properties are loaded.
+	var props []string
+	if propsSubset {
+		props = getVirtualMachinePropsSubset()
+	}
+
+	pc := property.DefaultCollector(c)
+
+	var err error
+	for attempt := 1; attempt <= datastoreVMRetrievalAttempts; attempt++ {
+		var vm mo.VirtualMachine
+		err = pc.RetrieveOne(ctx, ref, props, &vm)
+		if err == nil {
+			return vm, nil
+		}
+
+		logger.Printf(
+			"attempt %d of %d to retrieve VM ID %s failed: %v",
+			attempt,
+			datastoreVMRetrievalAttempts,
+			ref.Value,
+			err,
+		)
+
+		if attempt == datastoreVMRetrievalAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return mo.VirtualMachine{}, ctx.Err()
+		case <-time.After(datastoreVMRetrievalRetryDelay):
+		}
+	}
 
+	return mo.VirtualMachine{}, fmt.Errorf(
+		"failed to retrieve VM for VM ID %s after %d attempts: %w",
+		ref.Value,
+		datastoreVMRetrievalAttempts,
+		err,
+	)
 }
 
 // GetVMByName accepts the name of a VirtualMachine, the name of a datacenter
@@ -1975,7 +2667,7 @@ func ExcludeVMsByName(allVMs []mo.VirtualMachine, ignoreList []string) ([]mo.Vir
 	vmsToKeep := make([]mo.VirtualMachine, 0, len(allVMs))
 
 	for _, vm := range allVMs {
-		if textutils.InList(vm.Name, ignoreList, true) {
+		if inPatternList(vm.Name, ignoreList) {
 			continue
 		}
 		vmsToKeep = append(vmsToKeep, vm)
@@ -2042,8 +2734,9 @@ func FilterVMsByPowerState(vms []mo.VirtualMachine, includePoweredOff bool) ([]m
 // FilterVMsByPowerCycleUptime filters the provided collection of
 // VirtualMachines to just those with WARNING or CRITICAL values. The
 // collection is returned along with the number of VirtualMachines that were
-// excluded.
-func FilterVMsByPowerCycleUptime(vms []mo.VirtualMachine, warningThreshold int) ([]mo.VirtualMachine, int) {
+// excluded. If specified, power cycle uptime is calculated from the VM boot
+// time instead of QuickStats uptime.
+func FilterVMsByPowerCycleUptime(vms []mo.VirtualMachine, warningThreshold int, useBootTime bool) ([]mo.VirtualMachine, int) {
 
 	// setup early so we can reference it from deferred stats output
 	var vmsWithIssues []mo.VirtualMachine
@@ -2060,7 +2753,7 @@ func FilterVMsByPowerCycleUptime(vms []mo.VirtualMachine, warningThreshold int)
 	}(vms, &vmsWithIssues)
 
 	for _, vm := range vms {
-		uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+		uptime := vmPowerCycleUptime(vm, useBootTime)
 		uptimeDays := uptime.Hours() / 24
 
 		// compare against the WARNING threshold as that will net VMs with
@@ -2285,22 +2978,145 @@ func (vfr VMsFilterResults) NumVMsExcludedByName() int {
 	return vfr.numVMsExcludedByName
 }
 
-// NumVMsExcludedByPowerState is the count of all VirtualMachines excluded by
-// power state filtering.
-func (vfr VMsFilterResults) NumVMsExcludedByPowerState() int {
-	return vfr.numVMsExcludedByPowerState
+// NumVMsExcludedByPowerState is the count of all VirtualMachines excluded by
+// power state filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByPowerState() int {
+	return vfr.numVMsExcludedByPowerState
+}
+
+// NumVMsExcludedByResourcePool is the count of all VirtualMachines excluded
+// by resource pool filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByResourcePool() int {
+	return vfr.numVMsExcludedByResourcePool
+}
+
+// NumVMsExcludedByFolder is the count of all VirtualMachines excluded by
+// folder filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByFolder() int {
+	return vfr.numVMsExcludedByFolder
+}
+
+// NumVMsExcludedByDatacenter is the count of all VirtualMachines excluded by
+// datacenter filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByDatacenter() int {
+	return vfr.numVMsExcludedByDatacenter
+}
+
+// NumDatacentersAll is the count of all Datacenters in the inventory.
+func (vfr VMsFilterResults) NumDatacentersAll() int {
+	return vfr.numDatacentersAll
+}
+
+// NumDatacentersIncluded is the count of all Datacenters explicitly
+// specified for inclusion.
+func (vfr VMsFilterResults) NumDatacentersIncluded() int {
+	return vfr.numDatacentersIncluded
+}
+
+// NumDatacentersExcluded is the count of all Datacenters explicitly
+// specified for exclusion.
+func (vfr VMsFilterResults) NumDatacentersExcluded() int {
+	return vfr.numDatacentersExcluded
+}
+
+// NumDatacentersAfterFiltering is the count of all Datacenters explicitly
+// included or excluded by filtering operations. If datacenter filtering was
+// not requested, all Datacenters are considered evaluated.
+func (vfr VMsFilterResults) NumDatacentersAfterFiltering() int {
+	switch {
+	case vfr.numDatacentersIncluded > 0:
+		return vfr.numDatacentersEvaluated
+	case vfr.numDatacentersExcluded > 0:
+		return vfr.numDatacentersAll - vfr.numDatacentersEvaluated
+	default:
+		return vfr.numDatacentersAll
+	}
+}
+
+// NumVMsExcludedByCluster is the count of all VirtualMachines excluded by
+// cluster filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByCluster() int {
+	return vfr.numVMsExcludedByCluster
+}
+
+// NumVMsExcludedByHost is the count of all VirtualMachines excluded by host
+// filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByHost() int {
+	return vfr.numVMsExcludedByHost
+}
+
+// NumVMsExcludedByTag is the count of all VirtualMachines excluded by
+// vSphere Tag filtering.
+func (vfr VMsFilterResults) NumVMsExcludedByTag() int {
+	return vfr.numVMsExcludedByTag
+}
+
+// NumVMsSuppressedByMaintenanceWindow is the count of all VirtualMachines
+// excluded due to an active maintenance window.
+func (vfr VMsFilterResults) NumVMsSuppressedByMaintenanceWindow() int {
+	return len(vfr.vmsSuppressedByMaintenanceWindow)
+}
+
+// NumClustersAll is the count of all Clusters in the inventory.
+func (vfr VMsFilterResults) NumClustersAll() int {
+	return vfr.numClustersAll
+}
+
+// NumClustersIncluded is the count of all Clusters explicitly specified for
+// inclusion.
+func (vfr VMsFilterResults) NumClustersIncluded() int {
+	return vfr.numClustersIncluded
+}
+
+// NumClustersExcluded is the count of all Clusters explicitly specified for
+// exclusion.
+func (vfr VMsFilterResults) NumClustersExcluded() int {
+	return vfr.numClustersExcluded
+}
+
+// NumClustersAfterFiltering is the count of all Clusters explicitly included
+// or excluded by filtering operations. If cluster filtering was not
+// requested, all Clusters are considered evaluated.
+func (vfr VMsFilterResults) NumClustersAfterFiltering() int {
+	switch {
+	case vfr.numClustersIncluded > 0:
+		return vfr.numClustersEvaluated
+	case vfr.numClustersExcluded > 0:
+		return vfr.numClustersAll - vfr.numClustersEvaluated
+	default:
+		return vfr.numClustersAll
+	}
+}
+
+// NumHostsAll is the count of all Hosts in the inventory.
+func (vfr VMsFilterResults) NumHostsAll() int {
+	return vfr.numHostsAll
 }
 
-// NumVMsExcludedByResourcePool is the count of all VirtualMachines excluded
-// by resource pool filtering.
-func (vfr VMsFilterResults) NumVMsExcludedByResourcePool() int {
-	return vfr.numVMsExcludedByResourcePool
+// NumHostsIncluded is the count of all Hosts explicitly specified for
+// inclusion.
+func (vfr VMsFilterResults) NumHostsIncluded() int {
+	return vfr.numHostsIncluded
 }
 
-// NumVMsExcludedByFolder is the count of all VirtualMachines excluded by
-// folder filtering.
-func (vfr VMsFilterResults) NumVMsExcludedByFolder() int {
-	return vfr.numVMsExcludedByFolder
+// NumHostsExcluded is the count of all Hosts explicitly specified for
+// exclusion.
+func (vfr VMsFilterResults) NumHostsExcluded() int {
+	return vfr.numHostsExcluded
+}
+
+// NumHostsAfterFiltering is the count of all Hosts explicitly included or
+// excluded by filtering operations. If host filtering was not requested, all
+// Hosts are considered evaluated.
+func (vfr VMsFilterResults) NumHostsAfterFiltering() int {
+	switch {
+	case vfr.numHostsIncluded > 0:
+		return vfr.numHostsEvaluated
+	case vfr.numHostsExcluded > 0:
+		return vfr.numHostsAll - vfr.numHostsEvaluated
+	default:
+		return vfr.numHostsAll
+	}
 }
 
 // NumFoldersAll is the count of all Folders in the inventory.
@@ -2361,6 +3177,42 @@ func (vfr VMsFilterResults) VMsAfterResourcePoolFiltering() []mo.VirtualMachine
 	return vfr.vmsAfterRPFiltering
 }
 
+// VMsAfterDatacenterFiltering is the collection of VirtualMachines after
+// datacenter filtering was applied.
+func (vfr VMsFilterResults) VMsAfterDatacenterFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterDatacenterFiltering
+}
+
+// VMsBeforeDatacenterFiltering is the collection of VirtualMachines before
+// datacenter filtering was applied.
+func (vfr VMsFilterResults) VMsBeforeDatacenterFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterRPFiltering
+}
+
+// VMsAfterClusterFiltering is the collection of VirtualMachines after
+// cluster filtering was applied.
+func (vfr VMsFilterResults) VMsAfterClusterFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterClusterFiltering
+}
+
+// VMsBeforeClusterFiltering is the collection of VirtualMachines before
+// cluster filtering was applied.
+func (vfr VMsFilterResults) VMsBeforeClusterFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterDatacenterFiltering
+}
+
+// VMsAfterHostFiltering is the collection of VirtualMachines after host
+// filtering was applied.
+func (vfr VMsFilterResults) VMsAfterHostFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterHostFiltering
+}
+
+// VMsBeforeHostFiltering is the collection of VirtualMachines before host
+// filtering was applied.
+func (vfr VMsFilterResults) VMsBeforeHostFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterClusterFiltering
+}
+
 // VMsAfterFolderFiltering is the collection of VirtualMachines after
 // folder filtering was applied.
 func (vfr VMsFilterResults) VMsAfterFolderFiltering() []mo.VirtualMachine {
@@ -2370,7 +3222,7 @@ func (vfr VMsFilterResults) VMsAfterFolderFiltering() []mo.VirtualMachine {
 // VMsBeforeFolderFiltering is the collection of VirtualMachines before
 // folder filtering was applied.
 func (vfr VMsFilterResults) VMsBeforeFolderFiltering() []mo.VirtualMachine {
-	return vfr.vmsAfterRPFiltering
+	return vfr.vmsAfterHostFiltering
 }
 
 // VMsAfterVMNameFiltering is the collection of VirtualMachines after
@@ -2379,10 +3231,22 @@ func (vfr VMsFilterResults) VMsAfterVMNameFiltering() []mo.VirtualMachine {
 	return vfr.vmsAfterVMNameFiltering
 }
 
+// VMsAfterTagFiltering is the collection of VirtualMachines after vSphere
+// Tag filtering was applied.
+func (vfr VMsFilterResults) VMsAfterTagFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterTagFiltering
+}
+
+// VMsBeforeTagFiltering is the collection of VirtualMachines before vSphere
+// Tag filtering was applied.
+func (vfr VMsFilterResults) VMsBeforeTagFiltering() []mo.VirtualMachine {
+	return vfr.vmsAfterFolderFiltering
+}
+
 // VMsBeforeVMNameFiltering is the collection of VirtualMachines before
 // VirtualMachine name filtering was applied.
 func (vfr VMsFilterResults) VMsBeforeVMNameFiltering() []mo.VirtualMachine {
-	return vfr.vmsAfterFolderFiltering
+	return vfr.vmsAfterTagFiltering
 }
 
 // VMsAfterPowerStateFiltering is the collection of VirtualMachines after
@@ -2397,6 +3261,12 @@ func (vfr VMsFilterResults) VMsBeforePowerStateFiltering() []mo.VirtualMachine {
 	return vfr.vmsAfterVMNameFiltering
 }
 
+// VMsSuppressedByMaintenanceWindow is the collection of VirtualMachines
+// excluded due to an active maintenance window.
+func (vfr VMsFilterResults) VMsSuppressedByMaintenanceWindow() VMMaintenanceWindows {
+	return vfr.vmsSuppressedByMaintenanceWindow
+}
+
 // RPsAfterFiltering is the collection of ResourcePools after all filtering
 // steps were applied.
 func (vfr VMsFilterResults) RPsAfterFiltering() []mo.ResourcePool {
@@ -2425,11 +3295,15 @@ func (vfr VMsFilterResults) RPNamesAfterFiltering() []string {
 
 // GetVMPowerCycleUptimeStatusSummary accepts a list of VirtualMachines and
 // threshold values and generates a collection of VirtualMachines that exceeds
-// given thresholds along with those given thresholds.
+// given thresholds along with those given thresholds. If specified, power
+// cycle uptime is calculated from the VM boot time instead of QuickStats
+// uptime; this remains available when QuickStats uptime is not (e.g., host
+// disconnected, delayed stats).
 func GetVMPowerCycleUptimeStatusSummary(
 	vms []mo.VirtualMachine,
 	warningThreshold int,
 	criticalThreshold int,
+	useBootTime bool,
 ) VirtualMachinePowerCycleUptimeStatus {
 
 	funcTimeStart := time.Now()
@@ -2447,7 +3321,7 @@ func GetVMPowerCycleUptimeStatusSummary(
 
 	for _, vm := range vms {
 
-		uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+		uptime := vmPowerCycleUptime(vm, useBootTime)
 		uptimeDays := uptime.Hours() / 24
 
 		switch {
@@ -2470,6 +3344,7 @@ func GetVMPowerCycleUptimeStatusSummary(
 		VMsOK:             vmsOK,
 		WarningThreshold:  warningThreshold,
 		CriticalThreshold: criticalThreshold,
+		UseBootTime:       useBootTime,
 	}
 
 }
@@ -2484,6 +3359,11 @@ func VMPowerCycleUptimeOneLineCheckSummary(
 
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"uptimeSummary":    uptimeSummary,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -2571,19 +3451,20 @@ func VMPowerCycleUptimeReport(
 		vmsWithHighUptime = append(vmsWithHighUptime, uptimeSummary.VMsCritical...)
 
 		sort.Slice(vmsWithHighUptime, func(i, j int) bool {
-			return vmsWithHighUptime[i].Summary.QuickStats.UptimeSeconds > vmsWithHighUptime[j].Summary.QuickStats.UptimeSeconds
+			return uptimeSummary.Uptime(vmsWithHighUptime[i]) > uptimeSummary.Uptime(vmsWithHighUptime[j])
 		})
 
 		for _, vm := range vmsWithHighUptime {
 
-			uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+			uptime := uptimeSummary.Uptime(vm)
 			uptimeDays := uptime.Hours() / 24
 
 			_, _ = fmt.Fprintf(
 				&report,
-				"* %s: %.2f days%s",
+				"* %s: %.2f days (boot time: %s)%s",
 				vm.Name,
 				uptimeDays,
+				vmBootTime(vm),
 				nagios.CheckOutputEOL,
 			)
 		}
@@ -2591,60 +3472,66 @@ func VMPowerCycleUptimeReport(
 
 		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
 
+		if reportSectionEnabled(ReportSectionTopOKVMs) {
+			_, _ = fmt.Fprintf(
+				&report,
+				"%sTop 10 VMs, not yet exceeding power cycle uptime thresholds:%s%s",
+				nagios.CheckOutputEOL,
+				nagios.CheckOutputEOL,
+				nagios.CheckOutputEOL,
+			)
+
+			topTen := uptimeSummary.TopTenOK()
+			switch {
+			case len(topTen) == 0:
+				_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
+			default:
+				for _, vm := range topTen {
+					uptime := uptimeSummary.Uptime(vm)
+					uptimeDays := uptime.Hours() / 24
+
+					_, _ = fmt.Fprintf(
+						&report,
+						"* %s: %.2f days (boot time: %s)%s",
+						vm.Name,
+						uptimeDays,
+						vmBootTime(vm),
+						nagios.CheckOutputEOL,
+					)
+				}
+			}
+		}
+
+	}
+
+	if reportSectionEnabled(ReportSectionRecentlyStartedVMs) {
 		_, _ = fmt.Fprintf(
 			&report,
-			"%sTop 10 VMs, not yet exceeding power cycle uptime thresholds:%s%s",
+			"%sTen most recently started VMs:%s%s",
 			nagios.CheckOutputEOL,
 			nagios.CheckOutputEOL,
 			nagios.CheckOutputEOL,
 		)
 
-		topTen := uptimeSummary.TopTenOK()
+		bottomTen := uptimeSummary.BottomTenOK()
 		switch {
-		case len(topTen) == 0:
+		case len(bottomTen) == 0:
 			_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
 		default:
-			for _, vm := range topTen {
-				uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
+			for _, vm := range bottomTen {
+				uptime := uptimeSummary.Uptime(vm)
 				uptimeDays := uptime.Hours() / 24
 
 				_, _ = fmt.Fprintf(
 					&report,
-					"* %s: %.2f days%s",
+					"* %s: %.2f days (boot time: %s)%s",
 					vm.Name,
 					uptimeDays,
+					vmBootTime(vm),
 					nagios.CheckOutputEOL,
 				)
 			}
 		}
-
-	}
-
-	_, _ = fmt.Fprintf(
-		&report,
-		"%sTen most recently started VMs:%s%s",
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-		nagios.CheckOutputEOL,
-	)
-
-	bottomTen := uptimeSummary.BottomTenOK()
-	switch {
-	case len(bottomTen) == 0:
-		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
-	default:
-		for _, vm := range bottomTen {
-			uptime := time.Duration(vm.Summary.QuickStats.UptimeSeconds) * time.Second
-			uptimeDays := uptime.Hours() / 24
-
-			_, _ = fmt.Fprintf(
-				&report,
-				"* %s: %.2f days%s",
-				vm.Name,
-				uptimeDays,
-				nagios.CheckOutputEOL,
-			)
-		}
 	}
 
 	vmFilterResultsReportTrailer(
@@ -2655,6 +3542,18 @@ func VMPowerCycleUptimeReport(
 		true,
 	)
 
+	uptimeSource := "QuickStats uptime"
+	if uptimeSummary.UseBootTime {
+		uptimeSource = "VM boot time"
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Power cycle uptime source: %s%s",
+		uptimeSource,
+		nagios.CheckOutputEOL,
+	)
+
 	return report.String()
 }
 
@@ -2665,8 +3564,15 @@ func VMDiskConsolidationOneLineCheckSummary(
 	stateLabel string,
 	vmsFilterResults VMsFilterResults,
 	vmsNeedingConsolidation []mo.VirtualMachine,
+	consolidationResults VMDiskConsolidationResults,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults":        vmsFilterResults,
+		"vmsNeedingConsolidation": vmsNeedingConsolidation,
+		"consolidationResults":    consolidationResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -2677,6 +3583,19 @@ func VMDiskConsolidationOneLineCheckSummary(
 	}()
 
 	switch {
+	case len(vmsNeedingConsolidation) > 0 && consolidationResults != nil:
+		return fmt.Sprintf(
+			"%s: %d VMs requiring disk consolidation detected; %d consolidated, %d in progress, %d failed, %d skipped (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(vmsNeedingConsolidation),
+			consolidationResults.NumSucceeded(),
+			consolidationResults.NumInProgress(),
+			consolidationResults.NumFailed(),
+			consolidationResults.NumSkipped(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
 	case len(vmsNeedingConsolidation) > 0:
 		return fmt.Sprintf(
 			"%s: %d VMs requiring disk consolidation detected (evaluated %d VMs, %d Resource Pools)",
@@ -2702,12 +3621,14 @@ func VMDiskConsolidationOneLineCheckSummary(
 // troubleshooting check results at a glance. This information is provided for
 // use with the Long Service Output field commonly displayed on the detailed
 // service check results display in the web UI or in the body of many
-// notifications.
+// notifications. The outcome of each disk consolidation attempt is included
+// if disk consolidation was requested.
 func VMDiskConsolidationReport(
 	c *vim25.Client,
 	vmsFilterOptions VMsFilterOptions,
 	vmsFilterResults VMsFilterResults,
 	vmsNeedingConsolidation []mo.VirtualMachine,
+	consolidationResults VMDiskConsolidationResults,
 ) string {
 
 	funcTimeStart := time.Now()
@@ -2750,6 +3671,9 @@ func VMDiskConsolidationReport(
 		_, _ = fmt.Fprintf(&report, "* None %s", nagios.CheckOutputEOL)
 
 	}
+
+	vmDiskConsolidationResultsReport(&report, consolidationResults)
+
 	vmFilterResultsReportTrailer(
 		&report,
 		c,
@@ -2768,8 +3692,15 @@ func VMInteractiveQuestionOneLineCheckSummary(
 	stateLabel string,
 	vmsFilterResults VMsFilterResults,
 	vmsNeedingResponse []mo.VirtualMachine,
+	answerResults VMQuestionAnswerResults,
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults":   vmsFilterResults,
+		"vmsNeedingResponse": vmsNeedingResponse,
+		"answerResults":      answerResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -2780,6 +3711,18 @@ func VMInteractiveQuestionOneLineCheckSummary(
 	}()
 
 	switch {
+	case len(vmsNeedingResponse) > 0 && answerResults != nil:
+		return fmt.Sprintf(
+			"%s: %d VMs requiring interactive response detected; %d matched auto-answers, %d answered, %d failed (evaluated %d VMs, %d Resource Pools)",
+			stateLabel,
+			len(vmsNeedingResponse),
+			answerResults.NumMatched(),
+			answerResults.NumAnswered(),
+			answerResults.NumFailed(),
+			vmsFilterResults.NumVMsAfterFiltering(),
+			vmsFilterResults.NumRPsAfterFiltering(),
+		)
+
 	case len(vmsNeedingResponse) > 0:
 		return fmt.Sprintf(
 			"%s: %d VMs requiring interactive response detected (evaluated %d VMs, %d Resource Pools)",
@@ -2805,12 +3748,14 @@ func VMInteractiveQuestionOneLineCheckSummary(
 // troubleshooting check results at a glance. This information is provided for
 // use with the Long Service Output field commonly displayed on the detailed
 // service check results display in the web UI or in the body of many
-// notifications.
+// notifications. The outcome of evaluating auto-answer mappings for each VM
+// is included if auto-answer mappings were specified.
 func VMInteractiveQuestionReport(
 	c *vim25.Client,
 	vmsFilterOptions VMsFilterOptions,
 	vmsFilterResults VMsFilterResults,
 	vmsNeedingResponse []mo.VirtualMachine,
+	answerResults VMQuestionAnswerResults,
 ) string {
 
 	funcTimeStart := time.Now()
@@ -2878,6 +3823,8 @@ func VMInteractiveQuestionReport(
 
 	}
 
+	vmQuestionAnswerResultsReport(&report, answerResults)
+
 	vmFilterResultsReportTrailer(
 		&report,
 		c,
@@ -2899,6 +3846,11 @@ func VMBackupViaCAOneLineCheckSummary(
 
 ) string {
 
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+		"vmsWithBackups":   vmsWithBackups,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -3026,6 +3978,16 @@ func VMBackupViaCAReport(
 			)
 		}
 
+		if vm.BackupDateTag != "" {
+			_, _ = fmt.Fprintf(
+				w,
+				"\t** %s: %q%s",
+				"Backup date tag",
+				vm.BackupDateTag,
+				nagios.CheckOutputEOL,
+			)
+		}
+
 		if backupDateMetadataVal != "" {
 			_, _ = fmt.Fprintf(
 				w,
@@ -3154,6 +4116,10 @@ func VMBackupViaCAReport(
 // VMListOneLineCheckSummary is used to generate a one-line Nagios service
 // check results summary. This is the line most prominent in notifications.
 func VMListOneLineCheckSummary(stateLabel string, vmsFilterResults VMsFilterResults) string {
+	recordSummaryData(map[string]interface{}{
+		"vmsFilterResults": vmsFilterResults,
+	})
+
 	funcTimeStart := time.Now()
 
 	defer func() {
@@ -3230,6 +4196,27 @@ func VMListReport(
 		nagios.CheckOutputEOL,
 	)
 
+	_, _ = fmt.Fprintf(
+		&report,
+		"* %d Datacenters%s",
+		vmsFilterResults.NumDatacentersAll(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* %d Clusters%s",
+		vmsFilterResults.NumClustersAll(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* %d Hosts%s",
+		vmsFilterResults.NumHostsAll(),
+		nagios.CheckOutputEOL,
+	)
+
 	_, _ = fmt.Fprintf(
 		&report,
 		"* %d Folders%s",
@@ -3254,6 +4241,42 @@ func VMListReport(
 		vmsFilterResults.NumVMsAll(),
 		"resource pool",
 		vmsFilterResults.VMsAfterResourcePoolFiltering,
+		"datacenter",
+		vmsFilterResults.VMsBeforeDatacenterFiltering,
+		vmsFilterResults.VMsAfterDatacenterFiltering,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	vmListReportFilteringBeforeAfterResults(
+		&report,
+		vmsFilterResults.NumVMsAll(),
+		"datacenter",
+		vmsFilterResults.VMsAfterDatacenterFiltering,
+		"cluster",
+		vmsFilterResults.VMsBeforeClusterFiltering,
+		vmsFilterResults.VMsAfterClusterFiltering,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	vmListReportFilteringBeforeAfterResults(
+		&report,
+		vmsFilterResults.NumVMsAll(),
+		"cluster",
+		vmsFilterResults.VMsAfterClusterFiltering,
+		"host",
+		vmsFilterResults.VMsBeforeHostFiltering,
+		vmsFilterResults.VMsAfterHostFiltering,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	vmListReportFilteringBeforeAfterResults(
+		&report,
+		vmsFilterResults.NumVMsAll(),
+		"host",
+		vmsFilterResults.VMsAfterHostFiltering,
 		"folder",
 		vmsFilterResults.VMsBeforeFolderFiltering,
 		vmsFilterResults.VMsAfterFolderFiltering,
@@ -3266,6 +4289,18 @@ func VMListReport(
 		vmsFilterResults.NumVMsAll(),
 		"folder",
 		vmsFilterResults.VMsAfterFolderFiltering,
+		"tag",
+		vmsFilterResults.VMsBeforeTagFiltering,
+		vmsFilterResults.VMsAfterTagFiltering,
+	)
+
+	_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+
+	vmListReportFilteringBeforeAfterResults(
+		&report,
+		vmsFilterResults.NumVMsAll(),
+		"tag",
+		vmsFilterResults.VMsAfterTagFiltering,
 		"VM name",
 		vmsFilterResults.VMsBeforeVMNameFiltering,
 		vmsFilterResults.VMsAfterVMNameFiltering,
@@ -3423,6 +4458,94 @@ func vmFilterResultsReportTrailer(
 		nagios.CheckOutputEOL,
 	)
 
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Datacenters to explicitly include (%d): [%v]%s",
+		len(vmsFilterOptions.DatacentersIncluded),
+		strings.Join(vmsFilterOptions.DatacentersIncluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Datacenters to explicitly exclude (%d): [%v]%s",
+		len(vmsFilterOptions.DatacentersExcluded),
+		strings.Join(vmsFilterOptions.DatacentersExcluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Datacenters evaluated: %d of %d%s",
+		vmsFilterResults.NumDatacentersAfterFiltering(),
+		vmsFilterResults.NumDatacentersAll(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Clusters to explicitly include (%d): [%v]%s",
+		len(vmsFilterOptions.ClusterNamesIncluded),
+		strings.Join(vmsFilterOptions.ClusterNamesIncluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Clusters to explicitly exclude (%d): [%v]%s",
+		len(vmsFilterOptions.ClusterNamesExcluded),
+		strings.Join(vmsFilterOptions.ClusterNamesExcluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Clusters evaluated: %d of %d%s",
+		vmsFilterResults.NumClustersAfterFiltering(),
+		vmsFilterResults.NumClustersAll(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Hosts to explicitly include (%d): [%v]%s",
+		len(vmsFilterOptions.HostNamesIncluded),
+		strings.Join(vmsFilterOptions.HostNamesIncluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Hosts to explicitly exclude (%d): [%v]%s",
+		len(vmsFilterOptions.HostNamesExcluded),
+		strings.Join(vmsFilterOptions.HostNamesExcluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Hosts evaluated: %d of %d%s",
+		vmsFilterResults.NumHostsAfterFiltering(),
+		vmsFilterResults.NumHostsAll(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Tags to explicitly include (%d): [%v]%s",
+		len(vmsFilterOptions.TagsIncluded),
+		strings.Join(vmsFilterOptions.TagsIncluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		w,
+		"* Specified Tags to explicitly exclude (%d): [%v]%s",
+		len(vmsFilterOptions.TagsExcluded),
+		strings.Join(vmsFilterOptions.TagsExcluded, ", "),
+		nagios.CheckOutputEOL,
+	)
+
 	_, _ = fmt.Fprintf(
 		w,
 		"* Specified Folders to explicitly include (%d): [%v]%s",
@@ -3471,6 +4594,8 @@ func vmFilterResultsReportTrailer(
 		strings.Join(vmsFilterResults.RPNamesAfterFiltering(), ", "),
 		nagios.CheckOutputEOL,
 	)
+
+	vmMaintenanceWindowReport(w, vmsFilterOptions, vmsFilterResults)
 }
 
 // vmListReportFilteringBeforeAfterResults is a helper function used by the
@@ -3599,21 +4724,27 @@ func VMFilterResultsPerfData(vmsFilterResults VMsFilterResults) []nagios.Perform
 			// inventory.
 			Label: "vms",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+			Min:   "0",
 		},
 		{
 			// Alias to vms metric.
 			Label: "vms_all",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+			Min:   "0",
 		},
 		{
 			// Alias to vms_after_filtering performance metric.
 			Label: "vms_evaluated",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			// Alias to vms_evaluated performance metric.
 			Label: "vms_after_filtering",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			// We pull this metric from the collection remaining after
@@ -3624,6 +4755,8 @@ func VMFilterResultsPerfData(vmsFilterResults VMsFilterResults) []nagios.Perform
 				"%d",
 				CountVMsPowerStateOff(vmsFilterResults.VMsAfterResourcePoolFiltering()),
 			),
+			Min: "0",
+			Max: fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			// We pull this metric from the collection remaining after
@@ -3634,54 +4767,177 @@ func VMFilterResultsPerfData(vmsFilterResults VMsFilterResults) []nagios.Perform
 				"%d",
 				CountVMsPowerStateOn(vmsFilterResults.VMsAfterResourcePoolFiltering()),
 			),
+			Min: "0",
+			Max: fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			Label: "vms_excluded_by_name",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByName()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "vms_excluded_by_datacenter",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByDatacenter()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "vms_excluded_by_cluster",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByCluster()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "vms_excluded_by_host",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByHost()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			Label: "vms_excluded_by_folder",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByFolder()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "vms_excluded_by_tag",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByTag()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			Label: "vms_excluded_by_resource_pool",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByResourcePool()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
 		},
 		{
 			Label: "vms_excluded_by_power_state",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsExcludedByPowerState()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "vms_suppressed_by_maintenance_window",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumVMsSuppressedByMaintenanceWindow()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumVMsAll()),
+		},
+		{
+			Label: "datacenters_all",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumDatacentersAll()),
+			Min:   "0",
+		},
+		{
+			Label: "datacenters_excluded",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumDatacentersExcluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumDatacentersAll()),
+		},
+		{
+			Label: "datacenters_included",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumDatacentersIncluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumDatacentersAll()),
+		},
+		{
+			Label: "datacenters_evaluated",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumDatacentersAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumDatacentersAll()),
+		},
+		{
+			Label: "clusters_all",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumClustersAll()),
+			Min:   "0",
+		},
+		{
+			Label: "clusters_excluded",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumClustersExcluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumClustersAll()),
+		},
+		{
+			Label: "clusters_included",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumClustersIncluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumClustersAll()),
+		},
+		{
+			Label: "clusters_evaluated",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumClustersAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumClustersAll()),
+		},
+		{
+			Label: "hosts_all",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumHostsAll()),
+			Min:   "0",
+		},
+		{
+			Label: "hosts_excluded",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumHostsExcluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumHostsAll()),
+		},
+		{
+			Label: "hosts_included",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumHostsIncluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumHostsAll()),
+		},
+		{
+			Label: "hosts_evaluated",
+			Value: fmt.Sprintf("%d", vmsFilterResults.NumHostsAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumHostsAll()),
 		},
 		{
 			Label: "folders_all",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumFoldersAll()),
+			Min:   "0",
 		},
 		{
 			Label: "folders_excluded",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumFoldersExcluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumFoldersAll()),
 		},
 		{
 			Label: "folders_included",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumFoldersIncluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumFoldersAll()),
 		},
 		{
 			Label: "folders_evaluated",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumFoldersAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumFoldersAll()),
 		},
 		{
 			Label: "resource_pools_all",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumRPsAll()),
+			Min:   "0",
 		},
 		{
 			Label: "resource_pools_excluded",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumRPsExcluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumRPsAll()),
 		},
 		{
 			Label: "resource_pools_included",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumRPsIncluded()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumRPsAll()),
 		},
 		{
 			Label: "resource_pools_evaluated",
 			Value: fmt.Sprintf("%d", vmsFilterResults.NumRPsAfterFiltering()),
+			Min:   "0",
+			Max:   fmt.Sprintf("%d", vmsFilterResults.NumRPsAll()),
 		},
 	}
 }
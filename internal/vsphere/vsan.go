@@ -0,0 +1,582 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/check-vmware/internal/textutils"
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVSANHealthCheckFailed indicates that one or more vSAN health test
+// groups for evaluated clusters are in a non-OK state.
+var ErrVSANHealthCheckFailed = errors.New("vSAN health check failed")
+
+// ErrVSANClustersNotFound indicates that no vSAN enabled clusters were found
+// for evaluation.
+var ErrVSANClustersNotFound = errors.New("no vSAN enabled clusters found")
+
+// vSAN management API details. The vSAN health service is exposed by vCenter
+// via a dedicated SOAP endpoint separate from the standard vSphere API
+// endpoint.
+const (
+	vsanServicePath string = "/vsanHealth"
+	vsanNamespace   string = "vsan"
+)
+
+// Known vSAN health status values as used by the vSAN management API.
+const (
+	VSANHealthStatusGreen   string = "green"
+	VSANHealthStatusYellow  string = "yellow"
+	VSANHealthStatusRed     string = "red"
+	VSANHealthStatusInfo    string = "info"
+	VSANHealthStatusSkipped string = "skipped"
+	VSANHealthStatusUnknown string = "unknown"
+)
+
+// vsanVcClusterHealthSystem is the well-known vSAN cluster health system
+// managed object exposed by vCenter.
+var vsanVcClusterHealthSystem = types.ManagedObjectReference{
+	Type:  "VsanVcClusterHealthSystem",
+	Value: "vsan-cluster-health-system",
+}
+
+// VSANClusterHealthTest represents a single vSAN health test result.
+type VSANClusterHealthTest struct {
+	TestID          string `xml:"testId,omitempty"`
+	TestName        string `xml:"testName,omitempty"`
+	TestDescription string `xml:"testDescription,omitempty"`
+	TestHealth      string `xml:"testHealth,omitempty"`
+}
+
+// VSANClusterHealthGroup represents a vSAN health test group (e.g.,
+// "Network", "Data", "Physical disk") and the results of the tests within
+// that group.
+type VSANClusterHealthGroup struct {
+	GroupID     string                  `xml:"groupId,omitempty"`
+	GroupName   string                  `xml:"groupName,omitempty"`
+	GroupHealth string                  `xml:"groupHealth,omitempty"`
+	GroupTests  []VSANClusterHealthTest `xml:"groupTests,omitempty"`
+}
+
+// VSANClusterHealthSummary is the subset of the vSAN cluster health summary
+// returned by the vSAN management API used by this project.
+type VSANClusterHealthSummary struct {
+	OverallHealth            string                   `xml:"overallHealth,omitempty"`
+	OverallHealthDescription string                   `xml:"overallHealthDescription,omitempty"`
+	Groups                   []VSANClusterHealthGroup `xml:"groups,omitempty"`
+}
+
+// VSANClusterHealth pairs a vSAN enabled cluster with its health summary.
+type VSANClusterHealth struct {
+	Cluster mo.ClusterComputeResource
+	Summary VSANClusterHealthSummary
+}
+
+// VSANClusterHealthSet is a collection of vSAN cluster health summaries.
+type VSANClusterHealthSet []VSANClusterHealth
+
+type vsanQueryVcClusterHealthSummaryRequest struct {
+	This            types.ManagedObjectReference  `xml:"_this"`
+	Cluster         *types.ManagedObjectReference `xml:"cluster,omitempty"`
+	IncludeObjUuids *bool                         `xml:"includeObjUuids"`
+	FetchFromCache  *bool                         `xml:"fetchFromCache"`
+}
+
+type vsanQueryVcClusterHealthSummaryResponse struct {
+	Returnval VSANClusterHealthSummary `xml:"returnval"`
+}
+
+type vsanQueryVcClusterHealthSummaryBody struct {
+	Req    *vsanQueryVcClusterHealthSummaryRequest  `xml:"urn:vsan VsanQueryVcClusterHealthSummary,omitempty"`
+	Res    *vsanQueryVcClusterHealthSummaryResponse `xml:"urn:vsan VsanQueryVcClusterHealthSummaryResponse,omitempty"`
+	Fault_ *soap.Fault                              `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body>Fault,omitempty"` //nolint:revive,stylecheck
+}
+
+func (b *vsanQueryVcClusterHealthSummaryBody) Fault() *soap.Fault { return b.Fault_ }
+
+// vsanHealthRank provides a severity ranking for vSAN health status values.
+// Higher values indicate a more severe status.
+func vsanHealthRank(health string) int {
+	switch strings.ToLower(health) {
+	case VSANHealthStatusRed:
+		return 3
+	case VSANHealthStatusYellow:
+		return 2
+	case VSANHealthStatusGreen:
+		return 1
+	default:
+		// info, skipped, unknown
+		return 0
+	}
+}
+
+// IsVSANEnabled indicates whether vSAN is enabled for the given cluster.
+func IsVSANEnabled(cluster mo.ClusterComputeResource) bool {
+	cfgEx, ok := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !ok || cfgEx.VsanConfigInfo == nil || cfgEx.VsanConfigInfo.Enabled == nil {
+		return false
+	}
+
+	return *cfgEx.VsanConfigInfo.Enabled
+}
+
+// GetVSANClusters retrieves vSAN enabled clusters. If a cluster name is
+// specified only that cluster is retrieved (using the specified or default
+// datacenter) and an error is returned if vSAN is not enabled for it.
+// Otherwise all visible vSAN enabled clusters are returned. An error is
+// returned if no vSAN enabled clusters are found.
+func GetVSANClusters(ctx context.Context, c *vim25.Client, clusterName string, datacenter string) ([]mo.ClusterComputeResource, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVSANClusters func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	if clusterName != "" {
+		cluster, err := GetClusterByName(ctx, c, clusterName, datacenter, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if !IsVSANEnabled(cluster) {
+			return nil, fmt.Errorf(
+				"vSAN not enabled for cluster %s: %w",
+				cluster.Name,
+				ErrVSANClustersNotFound,
+			)
+		}
+
+		return []mo.ClusterComputeResource{cluster}, nil
+	}
+
+	clusters, err := GetClusters(ctx, c, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vsanClusters := make([]mo.ClusterComputeResource, 0, len(clusters))
+	for _, cluster := range clusters {
+		if IsVSANEnabled(cluster) {
+			vsanClusters = append(vsanClusters, cluster)
+		}
+	}
+
+	if len(vsanClusters) == 0 {
+		return nil, ErrVSANClustersNotFound
+	}
+
+	return vsanClusters, nil
+
+}
+
+// GetVSANClusterHealthSummary queries the vSAN management API for the health
+// summary of the specified cluster. If requested, cached health test results
+// are returned instead of triggering a (potentially slow) new health check
+// run.
+func GetVSANClusterHealthSummary(ctx context.Context, c *vim25.Client, cluster mo.ClusterComputeResource, fetchFromCache bool) (VSANClusterHealthSummary, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVSANClusterHealthSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	sc := c.Client.NewServiceClient(vsanServicePath, vsanNamespace)
+	sc.Version = c.Version
+
+	clusterRef := cluster.Reference()
+	includeObjUUIDs := false
+
+	req := vsanQueryVcClusterHealthSummaryBody{
+		Req: &vsanQueryVcClusterHealthSummaryRequest{
+			This:            vsanVcClusterHealthSystem,
+			Cluster:         &clusterRef,
+			IncludeObjUuids: &includeObjUUIDs,
+			FetchFromCache:  &fetchFromCache,
+		},
+	}
+
+	var res vsanQueryVcClusterHealthSummaryBody
+
+	if err := sc.RoundTrip(ctx, &req, &res); err != nil {
+		return VSANClusterHealthSummary{}, fmt.Errorf(
+			"failed to retrieve vSAN health summary for cluster %s: %w",
+			cluster.Name,
+			err,
+		)
+	}
+
+	if res.Res == nil {
+		return VSANClusterHealthSummary{}, fmt.Errorf(
+			"empty vSAN health summary response for cluster %s",
+			cluster.Name,
+		)
+	}
+
+	return res.Res.Returnval, nil
+
+}
+
+// GetVSANClusterHealthSet retrieves health summaries for each of the given
+// vSAN enabled clusters.
+func GetVSANClusterHealthSet(ctx context.Context, c *vim25.Client, clusters []mo.ClusterComputeResource, fetchFromCache bool) (VSANClusterHealthSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVSANClusterHealthSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	healthSet := make(VSANClusterHealthSet, 0, len(clusters))
+	for _, cluster := range clusters {
+		summary, err := GetVSANClusterHealthSummary(ctx, c, cluster, fetchFromCache)
+		if err != nil {
+			return nil, err
+		}
+
+		healthSet = append(healthSet, VSANClusterHealth{
+			Cluster: cluster,
+			Summary: summary,
+		})
+	}
+
+	return healthSet, nil
+
+}
+
+// FilterVSANHealthTests removes health tests with an ID or name
+// (case-insensitive) matching any of the given values. The health status for
+// each test group with removed tests is recalculated using the most severe
+// status of the remaining tests.
+func (vhs VSANClusterHealthSet) FilterVSANHealthTests(ignoredTests []string) VSANClusterHealthSet {
+
+	if len(ignoredTests) == 0 {
+		return vhs
+	}
+
+	filtered := make(VSANClusterHealthSet, 0, len(vhs))
+	for _, clusterHealth := range vhs {
+
+		groups := make([]VSANClusterHealthGroup, 0, len(clusterHealth.Summary.Groups))
+		for _, group := range clusterHealth.Summary.Groups {
+
+			tests := make([]VSANClusterHealthTest, 0, len(group.GroupTests))
+			for _, test := range group.GroupTests {
+				if textutils.InList(test.TestID, ignoredTests, true) ||
+					textutils.InList(test.TestName, ignoredTests, true) {
+
+					logger.Printf(
+						"ignoring vSAN health test %q for cluster %s",
+						test.TestName,
+						clusterHealth.Cluster.Name,
+					)
+
+					continue
+				}
+				tests = append(tests, test)
+			}
+
+			if len(tests) != len(group.GroupTests) {
+				group.GroupHealth = VSANHealthStatusGreen
+				for _, test := range tests {
+					if vsanHealthRank(test.TestHealth) > vsanHealthRank(group.GroupHealth) {
+						group.GroupHealth = test.TestHealth
+					}
+				}
+			}
+			group.GroupTests = tests
+
+			groups = append(groups, group)
+		}
+
+		clusterHealth.Summary.Groups = groups
+		filtered = append(filtered, clusterHealth)
+	}
+
+	return filtered
+
+}
+
+// numGroupsWithHealth returns the number of test groups across all clusters
+// in the set with the specified health status.
+func (vhs VSANClusterHealthSet) numGroupsWithHealth(health string) int {
+	var num int
+	for _, clusterHealth := range vhs {
+		for _, group := range clusterHealth.Summary.Groups {
+			if strings.EqualFold(group.GroupHealth, health) {
+				num++
+			}
+		}
+	}
+
+	return num
+}
+
+// NumGroups returns the number of test groups across all clusters in the
+// set.
+func (vhs VSANClusterHealthSet) NumGroups() int {
+	var num int
+	for _, clusterHealth := range vhs {
+		num += len(clusterHealth.Summary.Groups)
+	}
+
+	return num
+}
+
+// NumGroupsRed returns the number of test groups with a red health status.
+func (vhs VSANClusterHealthSet) NumGroupsRed() int {
+	return vhs.numGroupsWithHealth(VSANHealthStatusRed)
+}
+
+// NumGroupsYellow returns the number of test groups with a yellow health
+// status.
+func (vhs VSANClusterHealthSet) NumGroupsYellow() int {
+	return vhs.numGroupsWithHealth(VSANHealthStatusYellow)
+}
+
+// NumGroupsGreen returns the number of test groups with a green health
+// status.
+func (vhs VSANClusterHealthSet) NumGroupsGreen() int {
+	return vhs.numGroupsWithHealth(VSANHealthStatusGreen)
+}
+
+// HasCriticalState indicates whether any test group is in a red health
+// status.
+func (vhs VSANClusterHealthSet) HasCriticalState() bool {
+	return vhs.NumGroupsRed() > 0
+}
+
+// HasWarningState indicates whether any test group is in a yellow health
+// status.
+func (vhs VSANClusterHealthSet) HasWarningState() bool {
+	return vhs.NumGroupsYellow() > 0
+}
+
+// VSANHealthPerfData generates performance data metrics for the given vSAN
+// cluster health set.
+func VSANHealthPerfData(vhs VSANClusterHealthSet) []nagios.PerformanceData {
+	return []nagios.PerformanceData{
+		{
+			Label: "clusters",
+			Value: fmt.Sprintf("%d", len(vhs)),
+			Min:   "0",
+		},
+		{
+			Label: "vsan_health_groups",
+			Value: fmt.Sprintf("%d", vhs.NumGroups()),
+			Min:   "0",
+		},
+		{
+			Label: "vsan_health_groups_red",
+			Value: fmt.Sprintf("%d", vhs.NumGroupsRed()),
+			Min:   "0",
+		},
+		{
+			Label: "vsan_health_groups_yellow",
+			Value: fmt.Sprintf("%d", vhs.NumGroupsYellow()),
+			Min:   "0",
+		},
+		{
+			Label: "vsan_health_groups_green",
+			Value: fmt.Sprintf("%d", vhs.NumGroupsGreen()),
+			Min:   "0",
+		},
+	}
+}
+
+// VSANHealthOneLineCheckSummary is used to generate a one-line Nagios service
+// check results summary. This is the line most prominent in notifications.
+func VSANHealthOneLineCheckSummary(
+	stateLabel string,
+	vhs VSANClusterHealthSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"vhs": vhs,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VSANHealthOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case vhs.HasCriticalState() || vhs.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d red and %d yellow vSAN health test groups detected (evaluated %d clusters, %d groups)",
+			stateLabel,
+			vhs.NumGroupsRed(),
+			vhs.NumGroupsYellow(),
+			len(vhs),
+			vhs.NumGroups(),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No vSAN health issues detected (evaluated %d clusters, %d groups)",
+			stateLabel,
+			len(vhs),
+			vhs.NumGroups(),
+		)
+	}
+}
+
+// VSANHealthReport generates a summary of vSAN health test groups for
+// evaluated clusters along with various verbose details intended to aid in
+// troubleshooting check results at a glance. This information is provided for
+// use with the Long Service Output field commonly displayed on the detailed
+// service check results display in the web UI or in the body of many
+// notifications.
+func VSANHealthReport(
+	c *vim25.Client,
+	vhs VSANClusterHealthSet,
+	ignoredTests []string,
+	fetchFromCache bool,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VSANHealthReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"vSAN health test groups with issues:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	var numProblemGroups int
+	for _, clusterHealth := range vhs {
+		for _, group := range clusterHealth.Summary.Groups {
+			if vsanHealthRank(group.GroupHealth) < vsanHealthRank(VSANHealthStatusYellow) {
+				continue
+			}
+			numProblemGroups++
+
+			_, _ = fmt.Fprintf(
+				&report,
+				"* %s: %s (%s)%s",
+				clusterHealth.Cluster.Name,
+				group.GroupName,
+				group.GroupHealth,
+				nagios.CheckOutputEOL,
+			)
+
+			for _, test := range group.GroupTests {
+				if vsanHealthRank(test.TestHealth) < vsanHealthRank(VSANHealthStatusYellow) {
+					continue
+				}
+
+				_, _ = fmt.Fprintf(
+					&report,
+					"** %s (%s)%s",
+					test.TestName,
+					test.TestHealth,
+					nagios.CheckOutputEOL,
+				)
+			}
+		}
+	}
+
+	if numProblemGroups == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* None%s",
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%sEvaluated clusters:%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	for _, clusterHealth := range vhs {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* %s (overall: %s, groups: %d)%s",
+			clusterHealth.Cluster.Name,
+			clusterHealth.Summary.OverallHealth,
+			len(clusterHealth.Summary.Groups),
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"%s---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Cached health test results used: %t%s",
+		fetchFromCache,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Specified health tests to ignore (%d): [%v]%s",
+		len(ignoredTests),
+		strings.Join(ignoredTests, ", "),
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
@@ -0,0 +1,328 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func vsanTest(id string, name string, health string) VSANClusterHealthTest {
+	return VSANClusterHealthTest{
+		TestID:     id,
+		TestName:   name,
+		TestHealth: health,
+	}
+}
+
+func vsanGroup(name string, health string, tests ...VSANClusterHealthTest) VSANClusterHealthGroup {
+	return VSANClusterHealthGroup{
+		GroupID:     name,
+		GroupName:   name,
+		GroupHealth: health,
+		GroupTests:  tests,
+	}
+}
+
+func vsanClusterHealth(name string, groups ...VSANClusterHealthGroup) VSANClusterHealth {
+	var cluster mo.ClusterComputeResource
+	cluster.Name = name
+
+	return VSANClusterHealth{
+		Cluster: cluster,
+		Summary: VSANClusterHealthSummary{
+			Groups: groups,
+		},
+	}
+}
+
+func TestVSANHealthRank(t *testing.T) {
+	tests := map[string]struct {
+		health string
+		want   int
+	}{
+		"red":            {health: VSANHealthStatusRed, want: 3},
+		"yellow":         {health: VSANHealthStatusYellow, want: 2},
+		"green":          {health: VSANHealthStatusGreen, want: 1},
+		"info":           {health: VSANHealthStatusInfo, want: 0},
+		"skipped":        {health: VSANHealthStatusSkipped, want: 0},
+		"unknown":        {health: VSANHealthStatusUnknown, want: 0},
+		"mixed case red": {health: "Red", want: 3},
+		"empty":          {health: "", want: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := vsanHealthRank(tt.health); got != tt.want {
+				t.Errorf("want %d; got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsVSANEnabled(t *testing.T) {
+	tests := map[string]struct {
+		configEx types.BaseComputeResourceConfigInfo
+		want     bool
+	}{
+		"enabled": {
+			configEx: &types.ClusterConfigInfoEx{
+				VsanConfigInfo: &types.VsanClusterConfigInfo{
+					Enabled: types.NewBool(true),
+				},
+			},
+			want: true,
+		},
+		"explicitly disabled": {
+			configEx: &types.ClusterConfigInfoEx{
+				VsanConfigInfo: &types.VsanClusterConfigInfo{
+					Enabled: types.NewBool(false),
+				},
+			},
+			want: false,
+		},
+		"enabled flag not set": {
+			configEx: &types.ClusterConfigInfoEx{
+				VsanConfigInfo: &types.VsanClusterConfigInfo{},
+			},
+			want: false,
+		},
+		"no vSAN configuration": {
+			configEx: &types.ClusterConfigInfoEx{},
+			want:     false,
+		},
+		"no extended configuration": {
+			configEx: nil,
+			want:     false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var cluster mo.ClusterComputeResource
+			cluster.ConfigurationEx = tt.configEx
+
+			if got := IsVSANEnabled(cluster); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterVSANHealthTests(t *testing.T) {
+	tests := map[string]struct {
+		set     VSANClusterHealthSet
+		ignored []string
+		want    VSANClusterHealthSet
+	}{
+		"no ignored tests": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusRed,
+						vsanTest("netconn", "Connectivity", VSANHealthStatusRed),
+					),
+				),
+			},
+			want: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusRed,
+						vsanTest("netconn", "Connectivity", VSANHealthStatusRed),
+					),
+				),
+			},
+		},
+		"ignored by name recalculates group health": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("hcl", VSANHealthStatusRed,
+						vsanTest("hcldbuptodate", "vSAN HCL DB up-to-date", VSANHealthStatusRed),
+						vsanTest("controllerdriver", "Controller driver", VSANHealthStatusYellow),
+						vsanTest("controllerfirmware", "Controller firmware", VSANHealthStatusGreen),
+					),
+				),
+			},
+			ignored: []string{"VSAN HCL DB UP-TO-DATE"},
+			want: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("hcl", VSANHealthStatusYellow,
+						vsanTest("controllerdriver", "Controller driver", VSANHealthStatusYellow),
+						vsanTest("controllerfirmware", "Controller firmware", VSANHealthStatusGreen),
+					),
+				),
+			},
+		},
+		"all failing tests ignored by ID": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("hcl", VSANHealthStatusRed,
+						vsanTest("hcldbuptodate", "vSAN HCL DB up-to-date", VSANHealthStatusRed),
+						vsanTest("controllerdriver", "Controller driver", VSANHealthStatusYellow),
+						vsanTest("controllerfirmware", "Controller firmware", VSANHealthStatusGreen),
+					),
+				),
+			},
+			ignored: []string{"hcldbuptodate", "controllerdriver"},
+			want: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("hcl", VSANHealthStatusGreen,
+						vsanTest("controllerfirmware", "Controller firmware", VSANHealthStatusGreen),
+					),
+				),
+			},
+		},
+		"groups without ignored tests keep reported health": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusYellow,
+						vsanTest("netconn", "Connectivity", VSANHealthStatusGreen),
+					),
+					vsanGroup("data", VSANHealthStatusRed,
+						vsanTest("objecthealth", "vSAN object health", VSANHealthStatusRed),
+					),
+				),
+			},
+			ignored: []string{"objecthealth"},
+			want: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusYellow,
+						vsanTest("netconn", "Connectivity", VSANHealthStatusGreen),
+					),
+					VSANClusterHealthGroup{
+						GroupID:     "data",
+						GroupName:   "data",
+						GroupHealth: VSANHealthStatusGreen,
+						GroupTests:  []VSANClusterHealthTest{},
+					},
+				),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := tt.set.FilterVSANHealthTests(tt.ignored)
+
+			opt := cmp.Comparer(func(a, b mo.ClusterComputeResource) bool {
+				return a.Name == b.Name
+			})
+			if d := cmp.Diff(tt.want, got, opt); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestFilterVSANHealthTestsDoesNotModifyOriginal(t *testing.T) {
+	set := VSANClusterHealthSet{
+		vsanClusterHealth("cluster1",
+			vsanGroup("data", VSANHealthStatusRed,
+				vsanTest("objecthealth", "vSAN object health", VSANHealthStatusRed),
+			),
+		),
+	}
+
+	_ = set.FilterVSANHealthTests([]string{"objecthealth"})
+
+	group := set[0].Summary.Groups[0]
+	if group.GroupHealth != VSANHealthStatusRed {
+		t.Errorf("want original group health %q; got %q", VSANHealthStatusRed, group.GroupHealth)
+	}
+	if len(group.GroupTests) != 1 {
+		t.Errorf("want 1 original group test; got %d", len(group.GroupTests))
+	}
+}
+
+func TestVSANClusterHealthSetState(t *testing.T) {
+	tests := map[string]struct {
+		set          VSANClusterHealthSet
+		wantCritical bool
+		wantWarning  bool
+	}{
+		"all groups healthy": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusGreen),
+					vsanGroup("data", VSANHealthStatusGreen),
+				),
+			},
+		},
+		"info and skipped groups": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusInfo),
+					vsanGroup("data", VSANHealthStatusSkipped),
+				),
+			},
+		},
+		"yellow group": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusGreen),
+					vsanGroup("data", "Yellow"),
+				),
+			},
+			wantWarning: true,
+		},
+		"red group in second cluster": {
+			set: VSANClusterHealthSet{
+				vsanClusterHealth("cluster1",
+					vsanGroup("network", VSANHealthStatusYellow),
+				),
+				vsanClusterHealth("cluster2",
+					vsanGroup("data", VSANHealthStatusRed),
+				),
+			},
+			wantCritical: true,
+			wantWarning:  true,
+		},
+		"empty set": {
+			set: VSANClusterHealthSet{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+			if got := tt.set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+		})
+	}
+}
+
+func TestVSANHealthPerfData(t *testing.T) {
+	set := VSANClusterHealthSet{
+		vsanClusterHealth("cluster1",
+			vsanGroup("network", VSANHealthStatusGreen),
+			vsanGroup("data", VSANHealthStatusRed),
+			vsanGroup("hcl", VSANHealthStatusInfo),
+		),
+		vsanClusterHealth("cluster2",
+			vsanGroup("network", VSANHealthStatusYellow),
+			vsanGroup("data", VSANHealthStatusGreen),
+		),
+	}
+
+	want := []nagios.PerformanceData{
+		{Label: "clusters", Value: "2", Min: "0"},
+		{Label: "vsan_health_groups", Value: "5", Min: "0"},
+		{Label: "vsan_health_groups_red", Value: "1", Min: "0"},
+		{Label: "vsan_health_groups_yellow", Value: "1", Min: "0"},
+		{Label: "vsan_health_groups_green", Value: "2", Min: "0"},
+	}
+
+	if d := cmp.Diff(want, VSANHealthPerfData(set)); d != "" {
+		t.Errorf("(-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,549 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ErrVVolDatastoreHealthCheckFailed indicates that one or more vVol
+// datastores are inaccessible, are missing protocol endpoints or have
+// crossed specified capacity thresholds.
+var ErrVVolDatastoreHealthCheckFailed = errors.New("vVol datastore health check failed")
+
+// ErrDatastoreNotVVol indicates that a specified datastore is not a vVol
+// datastore.
+var ErrDatastoreNotVVol = errors.New("datastore is not a vVol datastore")
+
+// datastoreTypeVVol is the datastore summary type value used for vVol
+// datastores.
+const datastoreTypeVVol string = "VVOL"
+
+// VVolDatastoreHealthThresholds represents the user-specified vVol datastore
+// capacity usage percentage thresholds.
+type VVolDatastoreHealthThresholds struct {
+	UsageWarning  int
+	UsageCritical int
+}
+
+// VVolDatastoreHealth tracks accessibility, protocol endpoint and capacity
+// details for a specific vVol datastore.
+type VVolDatastoreHealth struct {
+	Datastore mo.Datastore
+
+	// StorageArrays is the collection of storage array names backing the
+	// vVol datastore (storage container).
+	StorageArrays []string
+
+	// HostsMounted is the number of hosts which have the datastore mounted.
+	HostsMounted int
+
+	// ProtocolEndpoints is the number of protocol endpoints bound to hosts
+	// with the datastore mounted.
+	ProtocolEndpoints int
+
+	// Critical is the collection of issues detected which map to a
+	// CRITICAL state.
+	Critical []string
+
+	// Warning is the collection of issues detected which map to a WARNING
+	// state.
+	Warning []string
+
+	Thresholds VVolDatastoreHealthThresholds
+}
+
+// VVolDatastoreHealthSet is a collection of VVolDatastoreHealth values.
+type VVolDatastoreHealthSet []VVolDatastoreHealth
+
+// IsVVolDatastore indicates whether the given datastore is a vVol datastore.
+func IsVVolDatastore(ds mo.Datastore) bool {
+	return strings.EqualFold(ds.Summary.Type, datastoreTypeVVol)
+}
+
+// FilterVVolDatastores receives a collection of Datastores and returns only
+// the vVol datastores not found in the given list of ignored datastore
+// names. The number of datastores excluded is also returned.
+func FilterVVolDatastores(dss []mo.Datastore, ignoredDatastores []string) ([]mo.Datastore, int) {
+	vvolDatastores := make([]mo.Datastore, 0, len(dss))
+	for _, ds := range dss {
+		if !IsVVolDatastore(ds) {
+			continue
+		}
+
+		if inPatternList(ds.Name, ignoredDatastores) {
+			continue
+		}
+
+		vvolDatastores = append(vvolDatastores, ds)
+	}
+
+	return vvolDatastores, len(dss) - len(vvolDatastores)
+}
+
+// NewVVolDatastoreHealth receives a vVol Datastore and a mapping of
+// HostSystem IDs to names and evaluates datastore accessibility, protocol
+// endpoint bindings and capacity usage against the specified thresholds.
+func NewVVolDatastoreHealth(ds mo.Datastore, hostNames map[string]string, thresholds VVolDatastoreHealthThresholds) VVolDatastoreHealth {
+
+	health := VVolDatastoreHealth{
+		Datastore:  ds,
+		Thresholds: thresholds,
+	}
+
+	hostName := func(ref types.ManagedObjectReference) string {
+		if name, ok := hostNames[ref.Value]; ok {
+			return name
+		}
+
+		return ref.Value
+	}
+
+	if !ds.Summary.Accessible {
+		reasons, _ := ValidateDatastoreAccessibility(ds)
+		health.Critical = append(health.Critical, fmt.Sprintf(
+			"datastore is inaccessible (reasons: %s)",
+			strings.Join(reasons, ", "),
+		))
+	}
+
+	// Index the number of protocol endpoints bound to each host.
+	hostPEs := make(map[string]int)
+	if info, ok := ds.Info.(*types.VvolDatastoreInfo); ok && info.VvolDS != nil {
+		for _, hostPE := range info.VvolDS.HostPE {
+			hostPEs[hostPE.Key.Value] += len(hostPE.ProtocolEndpoint)
+		}
+
+		for _, array := range info.VvolDS.StorageArray {
+			health.StorageArrays = append(health.StorageArrays, array.Name)
+		}
+	}
+
+	for _, hostMount := range ds.Host {
+		if hostMount.MountInfo.Mounted != nil && !*hostMount.MountInfo.Mounted {
+			continue
+		}
+
+		health.HostsMounted++
+
+		if hostMount.MountInfo.Accessible != nil && !*hostMount.MountInfo.Accessible {
+			health.Critical = append(health.Critical, fmt.Sprintf(
+				"datastore is inaccessible from host %s (reason: %s)",
+				hostName(hostMount.Key),
+				hostMount.MountInfo.InaccessibleReason,
+			))
+
+			continue
+		}
+
+		numPEs := hostPEs[hostMount.Key.Value]
+		health.ProtocolEndpoints += numPEs
+
+		if numPEs == 0 {
+			health.Critical = append(health.Critical, fmt.Sprintf(
+				"no protocol endpoints bound to host %s",
+				hostName(hostMount.Key),
+			))
+		}
+	}
+
+	if health.HostsMounted == 0 {
+		health.Warning = append(health.Warning, "datastore is not mounted on any host")
+	}
+
+	switch {
+	case health.StorageUsedPercent() > float64(thresholds.UsageCritical):
+		health.Critical = append(health.Critical, fmt.Sprintf(
+			"storage container usage %.2f%% exceeds CRITICAL threshold of %d%%",
+			health.StorageUsedPercent(),
+			thresholds.UsageCritical,
+		))
+
+	case health.StorageUsedPercent() > float64(thresholds.UsageWarning):
+		health.Warning = append(health.Warning, fmt.Sprintf(
+			"storage container usage %.2f%% exceeds WARNING threshold of %d%%",
+			health.StorageUsedPercent(),
+			thresholds.UsageWarning,
+		))
+	}
+
+	return health
+
+}
+
+// GetVVolDatastoreHealthSet retrieves all HostSystems in order to resolve
+// host names and evaluates the health of each of the given vVol datastores.
+func GetVVolDatastoreHealthSet(ctx context.Context, c *vim25.Client, dss []mo.Datastore, thresholds VVolDatastoreHealthThresholds) (VVolDatastoreHealthSet, error) {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute GetVVolDatastoreHealthSet func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	hss, err := GetHostSystems(ctx, c, true)
+	if err != nil {
+		return nil, err
+	}
+
+	hostNames := make(map[string]string, len(hss))
+	for _, hs := range hss {
+		hostNames[hs.Self.Value] = hs.Name
+	}
+
+	set := make(VVolDatastoreHealthSet, 0, len(dss))
+	for _, ds := range dss {
+		if !IsVVolDatastore(ds) {
+			return nil, fmt.Errorf(
+				"failed to evaluate datastore %s of type %s: %w",
+				ds.Name,
+				ds.Summary.Type,
+				ErrDatastoreNotVVol,
+			)
+		}
+
+		set = append(set, NewVVolDatastoreHealth(ds, hostNames, thresholds))
+	}
+
+	return set, nil
+
+}
+
+// StorageUsedPercent returns the percentage of storage container capacity
+// used.
+func (vdh VVolDatastoreHealth) StorageUsedPercent() float64 {
+	if vdh.Datastore.Summary.Capacity == 0 {
+		return 0
+	}
+
+	used := vdh.Datastore.Summary.Capacity - vdh.Datastore.Summary.FreeSpace
+
+	return float64(used) / float64(vdh.Datastore.Summary.Capacity) * 100
+}
+
+// IsCriticalState indicates whether any issues mapping to a CRITICAL state
+// were detected for the datastore.
+func (vdh VVolDatastoreHealth) IsCriticalState() bool {
+	return len(vdh.Critical) > 0
+}
+
+// IsWarningState indicates whether any issues mapping to a WARNING state
+// (and none mapping to a CRITICAL state) were detected for the datastore.
+func (vdh VVolDatastoreHealth) IsWarningState() bool {
+	return !vdh.IsCriticalState() && len(vdh.Warning) > 0
+}
+
+// NumCritical returns the number of datastores in a CRITICAL state.
+func (set VVolDatastoreHealthSet) NumCritical() int {
+	var num int
+	for _, vdh := range set {
+		if vdh.IsCriticalState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumWarning returns the number of datastores in a WARNING state.
+func (set VVolDatastoreHealthSet) NumWarning() int {
+	var num int
+	for _, vdh := range set {
+		if vdh.IsWarningState() {
+			num++
+		}
+	}
+
+	return num
+}
+
+// NumInaccessible returns the number of datastores which are inaccessible.
+func (set VVolDatastoreHealthSet) NumInaccessible() int {
+	var num int
+	for _, vdh := range set {
+		if !vdh.Datastore.Summary.Accessible {
+			num++
+		}
+	}
+
+	return num
+}
+
+// HasCriticalState indicates whether any evaluated datastore is in a
+// CRITICAL state.
+func (set VVolDatastoreHealthSet) HasCriticalState() bool {
+	return set.NumCritical() > 0
+}
+
+// HasWarningState indicates whether any evaluated datastore is in a WARNING
+// state.
+func (set VVolDatastoreHealthSet) HasWarningState() bool {
+	return set.NumWarning() > 0
+}
+
+// VVolDatastoreHealthPerfData generates performance data metrics from the
+// given collection of evaluated vVol datastores. If a single datastore is
+// evaluated detailed capacity metrics are emitted, otherwise usage
+// percentage metrics are emitted per datastore.
+func VVolDatastoreHealthPerfData(set VVolDatastoreHealthSet) []nagios.PerformanceData {
+
+	pd := []nagios.PerformanceData{
+		{
+			Label: "datastores_evaluated",
+			Value: fmt.Sprintf("%d", len(set)),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_critical",
+			Value: fmt.Sprintf("%d", set.NumCritical()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_warning",
+			Value: fmt.Sprintf("%d", set.NumWarning()),
+			Min:   "0",
+		},
+		{
+			Label: "datastores_inaccessible",
+			Value: fmt.Sprintf("%d", set.NumInaccessible()),
+			Min:   "0",
+		},
+	}
+
+	if len(set) == 1 {
+		vdh := set[0]
+
+		return append(pd,
+			nagios.PerformanceData{
+				Label:             "datastore_usage",
+				Value:             fmt.Sprintf("%.2f", vdh.StorageUsedPercent()),
+				UnitOfMeasurement: "%",
+				Warn:              fmt.Sprintf("%d", vdh.Thresholds.UsageWarning),
+				Crit:              fmt.Sprintf("%d", vdh.Thresholds.UsageCritical),
+				Min:               "0",
+				Max:               "100",
+			},
+			nagios.PerformanceData{
+				Label:             "datastore_storage_used",
+				Value:             fmt.Sprintf("%d", vdh.Datastore.Summary.Capacity-vdh.Datastore.Summary.FreeSpace),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", vdh.Datastore.Summary.Capacity),
+			},
+			nagios.PerformanceData{
+				Label:             "datastore_storage_remaining",
+				Value:             fmt.Sprintf("%d", vdh.Datastore.Summary.FreeSpace),
+				UnitOfMeasurement: "B",
+				Min:               "0",
+				Max:               fmt.Sprintf("%d", vdh.Datastore.Summary.Capacity),
+			},
+			nagios.PerformanceData{
+				Label: "hosts_mounted",
+				Value: fmt.Sprintf("%d", vdh.HostsMounted),
+				Min:   "0",
+			},
+			nagios.PerformanceData{
+				Label: "protocol_endpoints",
+				Value: fmt.Sprintf("%d", vdh.ProtocolEndpoints),
+				Min:   "0",
+			},
+		)
+	}
+
+	for _, vdh := range set {
+		pd = append(pd, nagios.PerformanceData{
+			Label:             PerfDataLabel(vdh.Datastore.Name, "datastore_usage"),
+			Value:             fmt.Sprintf("%.2f", vdh.StorageUsedPercent()),
+			UnitOfMeasurement: "%",
+			Warn:              fmt.Sprintf("%d", vdh.Thresholds.UsageWarning),
+			Crit:              fmt.Sprintf("%d", vdh.Thresholds.UsageCritical),
+			Min:               "0",
+			Max:               "100",
+		})
+	}
+
+	return pd
+
+}
+
+// VVolDatastoreHealthOneLineCheckSummary is used to generate a one-line
+// Nagios service check results summary. This is the line most prominent in
+// notifications.
+func VVolDatastoreHealthOneLineCheckSummary(
+	stateLabel string,
+	set VVolDatastoreHealthSet,
+) string {
+
+	recordSummaryData(map[string]interface{}{
+		"set": set,
+	})
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VVolDatastoreHealthOneLineCheckSummary func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	switch {
+	case set.HasCriticalState() || set.HasWarningState():
+		return fmt.Sprintf(
+			"%s: %d vVol datastores with health issues detected (evaluated %d vVol datastores)",
+			stateLabel,
+			set.NumCritical()+set.NumWarning(),
+			len(set),
+		)
+
+	default:
+		return fmt.Sprintf(
+			"%s: No vVol datastore health issues detected (evaluated %d vVol datastores)",
+			stateLabel,
+			len(set),
+		)
+	}
+}
+
+// VVolDatastoreHealthReport generates a summary of vVol datastore health
+// along with various verbose details intended to aid in troubleshooting
+// check results at a glance. This information is provided for use with the
+// Long Service Output field commonly displayed on the detailed service check
+// results display in the web UI or in the body of many notifications.
+func VVolDatastoreHealthReport(
+	c *vim25.Client,
+	set VVolDatastoreHealthSet,
+	numDatastoresExcluded int,
+) string {
+
+	funcTimeStart := time.Now()
+
+	defer func() {
+		logger.Printf(
+			"It took %v to execute VVolDatastoreHealthReport func.\n",
+			time.Since(funcTimeStart),
+		)
+	}()
+
+	var report strings.Builder
+
+	if len(set) == 0 {
+		_, _ = fmt.Fprintf(
+			&report,
+			"* No vVol datastores found%s%s",
+			nagios.CheckOutputEOL,
+			nagios.CheckOutputEOL,
+		)
+	}
+
+	for _, vdh := range set {
+		var state string
+		switch {
+		case vdh.IsCriticalState():
+			state = nagios.StateCRITICALLabel
+		case vdh.IsWarningState():
+			state = nagios.StateWARNINGLabel
+		default:
+			state = nagios.StateOKLabel
+		}
+
+		storageArrays := "unknown"
+		if len(vdh.StorageArrays) > 0 {
+			storageArrays = strings.Join(vdh.StorageArrays, ", ")
+		}
+
+		_, _ = fmt.Fprintf(
+			&report,
+			"Datastore %s [%s]:%s"+
+				"* Accessible: %t%s"+
+				"* Storage arrays: %s%s"+
+				"* Hosts mounted: %d (protocol endpoints: %d)%s"+
+				"* Capacity: %s (used: %s [%.2f%%], remaining: %s)%s",
+			vdh.Datastore.Name,
+			state,
+			nagios.CheckOutputEOL,
+			vdh.Datastore.Summary.Accessible,
+			nagios.CheckOutputEOL,
+			storageArrays,
+			nagios.CheckOutputEOL,
+			vdh.HostsMounted,
+			vdh.ProtocolEndpoints,
+			nagios.CheckOutputEOL,
+			units.ByteSize(vdh.Datastore.Summary.Capacity),
+			units.ByteSize(vdh.Datastore.Summary.Capacity-vdh.Datastore.Summary.FreeSpace),
+			vdh.StorageUsedPercent(),
+			units.ByteSize(vdh.Datastore.Summary.FreeSpace),
+			nagios.CheckOutputEOL,
+		)
+
+		for _, issue := range vdh.Critical {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateCRITICALLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		for _, issue := range vdh.Warning {
+			_, _ = fmt.Fprintf(
+				&report,
+				"** [%s] %s%s",
+				nagios.StateWARNINGLabel,
+				issue,
+				nagios.CheckOutputEOL,
+			)
+		}
+
+		_, _ = fmt.Fprint(&report, nagios.CheckOutputEOL)
+	}
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"---%s%s",
+		nagios.CheckOutputEOL,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* vSphere environment: %s%s",
+		c.URL().String(),
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Plugin User Agent: %s%s",
+		c.Client.UserAgent,
+		nagios.CheckOutputEOL,
+	)
+
+	_, _ = fmt.Fprintf(
+		&report,
+		"* Datastores excluded (non-vVol or ignored): %d%s",
+		numDatastoresExcluded,
+		nagios.CheckOutputEOL,
+	)
+
+	return report.String()
+}
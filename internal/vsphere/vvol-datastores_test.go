@@ -0,0 +1,443 @@
+// Copyright 2021 Adam Chalkley
+//
+// https://github.com/atc0005/check-vmware
+//
+// Licensed under the MIT License. See LICENSE file in the project root for
+// full license information.
+
+package vsphere
+
+import (
+	"testing"
+
+	"github.com/atc0005/go-nagios"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmware/govmomi/units"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type vvolMount struct {
+	hostID       string
+	numPEs       int
+	inaccessible bool
+	unmounted    bool
+}
+
+func vvolDatastore(name string, dsType string, usedPercent int64, mounts ...vvolMount) mo.Datastore {
+	var ds mo.Datastore
+	ds.Name = name
+	ds.Summary = types.DatastoreSummary{
+		Name:       name,
+		Type:       dsType,
+		Accessible: true,
+		Capacity:   100 * units.GB,
+		FreeSpace:  (100 - usedPercent) * units.GB,
+	}
+
+	vvolDS := &types.HostVvolVolume{
+		StorageArray: []types.VASAStorageArray{{Name: "array1"}},
+	}
+
+	for _, mount := range mounts {
+		hostRef := types.ManagedObjectReference{Type: MgObjRefTypeHostSystem, Value: mount.hostID}
+
+		mountInfo := types.HostMountInfo{
+			Mounted:    types.NewBool(!mount.unmounted),
+			Accessible: types.NewBool(!mount.inaccessible),
+		}
+		if mount.inaccessible {
+			mountInfo.InaccessibleReason = "AllPathsDown_Start"
+		}
+
+		ds.Host = append(ds.Host, types.DatastoreHostMount{
+			Key:       hostRef,
+			MountInfo: mountInfo,
+		})
+
+		vvolDS.HostPE = append(vvolDS.HostPE, types.VVolHostPE{
+			Key:              hostRef,
+			ProtocolEndpoint: make([]types.HostProtocolEndpoint, mount.numPEs),
+		})
+	}
+
+	ds.Info = &types.VvolDatastoreInfo{VvolDS: vvolDS}
+
+	return ds
+}
+
+func TestIsVVolDatastore(t *testing.T) {
+	tests := map[string]struct {
+		dsType string
+		want   bool
+	}{
+		"VVOL":       {dsType: "VVOL", want: true},
+		"lower case": {dsType: "vvol", want: true},
+		"VMFS":       {dsType: "VMFS", want: false},
+		"NFS":        {dsType: "NFS", want: false},
+		"empty":      {dsType: "", want: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ds := vvolDatastore("ds1", tt.dsType, 50)
+			if got := IsVVolDatastore(ds); got != tt.want {
+				t.Errorf("want %t; got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterVVolDatastores(t *testing.T) {
+	dss := []mo.Datastore{
+		vvolDatastore("vvol1", "VVOL", 50),
+		vvolDatastore("vvol2", "vvol", 50),
+		vvolDatastore("vvol-test", "VVOL", 50),
+		vvolDatastore("vmfs1", "VMFS", 50),
+	}
+
+	tests := map[string]struct {
+		ignored      []string
+		wantNames    []string
+		wantExcluded int
+	}{
+		"no ignored datastores": {
+			wantNames:    []string{"vvol1", "vvol2", "vvol-test"},
+			wantExcluded: 1,
+		},
+		"ignored datastore": {
+			ignored:      []string{"vvol-test"},
+			wantNames:    []string{"vvol1", "vvol2"},
+			wantExcluded: 2,
+		},
+		"ignored datastore case-insensitive": {
+			ignored:      []string{"VVOL-TEST"},
+			wantNames:    []string{"vvol1", "vvol2"},
+			wantExcluded: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			filtered, numExcluded := FilterVVolDatastores(dss, tt.ignored)
+
+			gotNames := make([]string, 0, len(filtered))
+			for _, ds := range filtered {
+				gotNames = append(gotNames, ds.Name)
+			}
+
+			if d := cmp.Diff(tt.wantNames, gotNames); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+
+			if numExcluded != tt.wantExcluded {
+				t.Errorf("want %d excluded datastores; got %d", tt.wantExcluded, numExcluded)
+			}
+		})
+	}
+}
+
+func TestNewVVolDatastoreHealth(t *testing.T) {
+	thresholds := VVolDatastoreHealthThresholds{
+		UsageWarning:  80,
+		UsageCritical: 90,
+	}
+
+	hostNames := map[string]string{
+		"host-1": "esx1",
+		"host-2": "esx2",
+	}
+
+	inaccessible := vvolDatastore("vvol1", "VVOL", 50, vvolMount{hostID: "host-1", numPEs: 1})
+	inaccessible.Summary.Accessible = false
+
+	tests := map[string]struct {
+		ds                    mo.Datastore
+		wantCritical          []string
+		wantWarning           []string
+		wantHostsMounted      int
+		wantProtocolEndpoints int
+	}{
+		"healthy datastore": {
+			ds: vvolDatastore(
+				"vvol1", "VVOL", 50,
+				vvolMount{hostID: "host-1", numPEs: 2},
+				vvolMount{hostID: "host-2", numPEs: 2},
+			),
+			wantHostsMounted:      2,
+			wantProtocolEndpoints: 4,
+		},
+		"usage above warning threshold": {
+			ds: vvolDatastore("vvol1", "VVOL", 85, vvolMount{hostID: "host-1", numPEs: 1}),
+			wantWarning: []string{
+				"storage container usage 85.00% exceeds WARNING threshold of 80%",
+			},
+			wantHostsMounted:      1,
+			wantProtocolEndpoints: 1,
+		},
+		"usage above critical threshold": {
+			ds: vvolDatastore("vvol1", "VVOL", 95, vvolMount{hostID: "host-1", numPEs: 1}),
+			wantCritical: []string{
+				"storage container usage 95.00% exceeds CRITICAL threshold of 90%",
+			},
+			wantHostsMounted:      1,
+			wantProtocolEndpoints: 1,
+		},
+		"host without protocol endpoints": {
+			ds: vvolDatastore(
+				"vvol1", "VVOL", 50,
+				vvolMount{hostID: "host-1", numPEs: 1},
+				vvolMount{hostID: "host-2"},
+			),
+			wantCritical: []string{
+				"no protocol endpoints bound to host esx2",
+			},
+			wantHostsMounted:      2,
+			wantProtocolEndpoints: 1,
+		},
+		"unknown host uses host ID": {
+			ds: vvolDatastore("vvol1", "VVOL", 50, vvolMount{hostID: "host-3"}),
+			wantCritical: []string{
+				"no protocol endpoints bound to host host-3",
+			},
+			wantHostsMounted: 1,
+		},
+		"inaccessible from host": {
+			ds: vvolDatastore(
+				"vvol1", "VVOL", 50,
+				vvolMount{hostID: "host-1", numPEs: 1, inaccessible: true},
+			),
+			wantCritical: []string{
+				"datastore is inaccessible from host esx1 (reason: AllPathsDown_Start)",
+			},
+			wantHostsMounted: 1,
+		},
+		"inaccessible datastore": {
+			ds: inaccessible,
+			wantCritical: []string{
+				"datastore is inaccessible (reasons: unknown)",
+			},
+			wantHostsMounted:      1,
+			wantProtocolEndpoints: 1,
+		},
+		"unmounted host is skipped": {
+			ds: vvolDatastore(
+				"vvol1", "VVOL", 50,
+				vvolMount{hostID: "host-1", numPEs: 1},
+				vvolMount{hostID: "host-2", unmounted: true},
+			),
+			wantHostsMounted:      1,
+			wantProtocolEndpoints: 1,
+		},
+		"not mounted on any host": {
+			ds: vvolDatastore("vvol1", "VVOL", 50),
+			wantWarning: []string{
+				"datastore is not mounted on any host",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := NewVVolDatastoreHealth(tt.ds, hostNames, thresholds)
+
+			if d := cmp.Diff(tt.wantCritical, got.Critical); d != "" {
+				t.Errorf("critical (-want, +got):\n%s", d)
+			}
+
+			if d := cmp.Diff(tt.wantWarning, got.Warning); d != "" {
+				t.Errorf("warning (-want, +got):\n%s", d)
+			}
+
+			if got.HostsMounted != tt.wantHostsMounted {
+				t.Errorf("want %d hosts mounted; got %d", tt.wantHostsMounted, got.HostsMounted)
+			}
+
+			if got.ProtocolEndpoints != tt.wantProtocolEndpoints {
+				t.Errorf("want %d protocol endpoints; got %d", tt.wantProtocolEndpoints, got.ProtocolEndpoints)
+			}
+
+			if d := cmp.Diff([]string{"array1"}, got.StorageArrays); d != "" {
+				t.Errorf("storage arrays (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestVVolDatastoreHealthStorageUsedPercent(t *testing.T) {
+	tests := map[string]struct {
+		capacity  int64
+		freeSpace int64
+		want      float64
+	}{
+		"half used":     {capacity: 100 * units.GB, freeSpace: 50 * units.GB, want: 50},
+		"fully used":    {capacity: 100 * units.GB, freeSpace: 0, want: 100},
+		"empty":         {capacity: 100 * units.GB, freeSpace: 100 * units.GB, want: 0},
+		"zero capacity": {capacity: 0, freeSpace: 0, want: 0},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var vdh VVolDatastoreHealth
+			vdh.Datastore.Summary.Capacity = tt.capacity
+			vdh.Datastore.Summary.FreeSpace = tt.freeSpace
+
+			if got := vdh.StorageUsedPercent(); got != tt.want {
+				t.Errorf("want %.2f; got %.2f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVVolDatastoreHealthSetState(t *testing.T) {
+	thresholds := VVolDatastoreHealthThresholds{
+		UsageWarning:  80,
+		UsageCritical: 90,
+	}
+
+	inaccessible := vvolDatastore("vvol3", "VVOL", 50, vvolMount{hostID: "host-1", numPEs: 1})
+	inaccessible.Summary.Accessible = false
+
+	tests := map[string]struct {
+		datastores       []mo.Datastore
+		wantCritical     bool
+		wantWarning      bool
+		wantNumCritical  int
+		wantNumWarning   int
+		wantInaccessible int
+	}{
+		"healthy datastores": {
+			datastores: []mo.Datastore{
+				vvolDatastore("vvol1", "VVOL", 50, vvolMount{hostID: "host-1", numPEs: 1}),
+				vvolDatastore("vvol2", "VVOL", 50, vvolMount{hostID: "host-1", numPEs: 1}),
+			},
+		},
+		"warning only": {
+			datastores: []mo.Datastore{
+				vvolDatastore("vvol1", "VVOL", 50, vvolMount{hostID: "host-1", numPEs: 1}),
+				vvolDatastore("vvol2", "VVOL", 85, vvolMount{hostID: "host-1", numPEs: 1}),
+			},
+			wantWarning:    true,
+			wantNumWarning: 1,
+		},
+		"critical and warning datastores": {
+			datastores: []mo.Datastore{
+				vvolDatastore("vvol1", "VVOL", 95, vvolMount{hostID: "host-1", numPEs: 1}),
+				vvolDatastore("vvol2", "VVOL", 85, vvolMount{hostID: "host-1", numPEs: 1}),
+				inaccessible,
+			},
+			wantCritical:     true,
+			wantWarning:      true,
+			wantNumCritical:  2,
+			wantNumWarning:   1,
+			wantInaccessible: 1,
+		},
+		"critical datastore with warning issues": {
+			datastores: []mo.Datastore{
+				vvolDatastore("vvol1", "VVOL", 95),
+			},
+			wantCritical:    true,
+			wantNumCritical: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			set := make(VVolDatastoreHealthSet, 0, len(tt.datastores))
+			for _, ds := range tt.datastores {
+				set = append(set, NewVVolDatastoreHealth(ds, nil, thresholds))
+			}
+
+			if got := set.HasCriticalState(); got != tt.wantCritical {
+				t.Errorf("want CRITICAL state %t; got %t", tt.wantCritical, got)
+			}
+
+			if got := set.HasWarningState(); got != tt.wantWarning {
+				t.Errorf("want WARNING state %t; got %t", tt.wantWarning, got)
+			}
+
+			if got := set.NumCritical(); got != tt.wantNumCritical {
+				t.Errorf("want %d critical datastores; got %d", tt.wantNumCritical, got)
+			}
+
+			if got := set.NumWarning(); got != tt.wantNumWarning {
+				t.Errorf("want %d warning datastores; got %d", tt.wantNumWarning, got)
+			}
+
+			if got := set.NumInaccessible(); got != tt.wantInaccessible {
+				t.Errorf("want %d inaccessible datastores; got %d", tt.wantInaccessible, got)
+			}
+		})
+	}
+}
+
+func TestVVolDatastoreHealthPerfData(t *testing.T) {
+	thresholds := VVolDatastoreHealthThresholds{
+		UsageWarning:  80,
+		UsageCritical: 90,
+	}
+
+	single := VVolDatastoreHealthSet{
+		NewVVolDatastoreHealth(
+			vvolDatastore(
+				"vvol1", "VVOL", 25,
+				vvolMount{hostID: "host-1", numPEs: 2},
+				vvolMount{hostID: "host-2", numPEs: 1},
+			),
+			nil,
+			thresholds,
+		),
+	}
+
+	multiple := VVolDatastoreHealthSet{
+		NewVVolDatastoreHealth(
+			vvolDatastore("vvol1", "VVOL", 25, vvolMount{hostID: "host-1", numPEs: 1}),
+			nil,
+			thresholds,
+		),
+		NewVVolDatastoreHealth(
+			vvolDatastore("vvol2", "VVOL", 95, vvolMount{hostID: "host-1", numPEs: 1}),
+			nil,
+			thresholds,
+		),
+	}
+
+	tests := map[string]struct {
+		set  VVolDatastoreHealthSet
+		want []nagios.PerformanceData
+	}{
+		"single datastore": {
+			set: single,
+			want: []nagios.PerformanceData{
+				{Label: "datastores_evaluated", Value: "1", Min: "0"},
+				{Label: "datastores_critical", Value: "0", Min: "0"},
+				{Label: "datastores_warning", Value: "0", Min: "0"},
+				{Label: "datastores_inaccessible", Value: "0", Min: "0"},
+				{Label: "datastore_usage", Value: "25.00", UnitOfMeasurement: "%", Warn: "80", Crit: "90", Min: "0", Max: "100"},
+				{Label: "datastore_storage_used", Value: "26843545600", UnitOfMeasurement: "B", Min: "0", Max: "107374182400"},
+				{Label: "datastore_storage_remaining", Value: "80530636800", UnitOfMeasurement: "B", Min: "0", Max: "107374182400"},
+				{Label: "hosts_mounted", Value: "2", Min: "0"},
+				{Label: "protocol_endpoints", Value: "3", Min: "0"},
+			},
+		},
+		"multiple datastores": {
+			set: multiple,
+			want: []nagios.PerformanceData{
+				{Label: "datastores_evaluated", Value: "2", Min: "0"},
+				{Label: "datastores_critical", Value: "1", Min: "0"},
+				{Label: "datastores_warning", Value: "0", Min: "0"},
+				{Label: "datastores_inaccessible", Value: "0", Min: "0"},
+				{Label: "vvol1_datastore_usage", Value: "25.00", UnitOfMeasurement: "%", Warn: "80", Crit: "90", Min: "0", Max: "100"},
+				{Label: "vvol2_datastore_usage", Value: "95.00", UnitOfMeasurement: "%", Warn: "80", Crit: "90", Min: "0", Max: "100"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := cmp.Diff(tt.want, VVolDatastoreHealthPerfData(tt.set)); d != "" {
+				t.Errorf("(-want, +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -51,7 +51,65 @@ if [ -x "$(command -v selinuxenabled)" ]; then
             check_vmware_disk_consolidation \
             check_vmware_question \
             check_vmware_alarms \
-            check_vmware_vm_backup_via_ca
+            check_vmware_vm_backup_via_ca \
+            check_vmware_vsan_health \
+            check_vmware_host_services \
+            check_vmware_licensing_feature_usage \
+            check_vmware_vm_cpu_ready \
+            check_vmware_folder_vm_counts \
+            check_vmware_vm_memory_pressure \
+            check_vmware_cluster_resource_usage \
+            check_vmware_vasa_provider_status \
+            check_vmware_cluster_ha_status \
+            check_vmware_vvol_datastore_health \
+            check_vmware_cluster_drs_status \
+            check_vmware_host_hardware_sensors \
+            check_vmware_vm_ft_latency \
+            check_vmware_host_storage_paths \
+            check_vmware_vm_tools_running_but_no_ip \
+            check_vmware_vcenter_certificates \
+            check_vmware_license \
+            check_vmware_datacenter_inventory_drift \
+            check_vmware_tasks \
+            check_vmware_host_esxi_shell_ssh_enabled \
+            check_vmware_host_dns_routing \
+            check_vmware_events \
+            check_vmware_snapshots_quota_per_datastore \
+            check_vmware_vm_hotplug_orphan_devices \
+            check_vmware_orphaned_vmdks \
+            check_vmware_vm_connected_media \
+            check_vmware_vm_guest_disk_usage \
+            check_vmware_resource_pool_runaway_vm \
+            check_vmware_vm_tools_version \
+            check_vmware_vm_cpu_affinity_set \
+            check_vmware_host_time_drift \
+            check_vmware_esxi_image_profile_drift \
+            check_vmware_vlcm_compliance \
+            check_vmware_alarm_definitions_hash \
+            check_vmware_cbrc_and_memory_tiering_status \
+            check_vmware_vm_backup_via_tag \
+            check_vmware_vm_custom_attribute \
+            check_vmware_vcenter_database_health \
+            check_vmware_vcenter_service_status \
+            check_vmware_alarm_action_disabled \
+            check_vmware_vm_disk_mode_independent \
+            check_vmware_vm_rdm_usage \
+            check_vmware_sriov_and_passthrough_capacity \
+            check_vmware_gpu_allocation \
+            check_vmware_cluster_das_isolation_addresses \
+            check_vmware_datastore_overcommit \
+            check_vmware_stretched_cluster_site_balance \
+            check_vmware_datastore_accessibility \
+            check_vmware_vm_pending_hardware_upgrade \
+            check_vmware_vm_restore_detection \
+            check_vmware_host_scheduled_reboot_pending \
+            check_vmware_vm_time_sync_policy \
+            check_vmware_datastore_vm_density \
+            check_vmware_snapshot_removal_stalls \
+            check_vmware_vm_memory_allocation \
+            check_vmware_vm_disk_uuid_enabled \
+            check_vmware_host_connection_state \
+            check_vmware_cluster_ha_host_failures_in_history
         do
 
             echo -e "\nApplying SELinux contexts on ${plugin_path}/${plugin_name}${plugin_name_suffix}"